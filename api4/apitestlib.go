@@ -607,6 +607,11 @@ func CheckCreatedStatus(t *testing.T, resp *model.Response) {
 	checkHTTPStatus(t, resp, http.StatusCreated, false)
 }
 
+func CheckAcceptedStatus(t *testing.T, resp *model.Response) {
+	t.Helper()
+	checkHTTPStatus(t, resp, http.StatusAccepted, false)
+}
+
 func CheckForbiddenStatus(t *testing.T, resp *model.Response) {
 	t.Helper()
 	checkHTTPStatus(t, resp, http.StatusForbidden, true)
@@ -0,0 +1,118 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// Headers of a sub-response that are returned to the client, since the others are the same for every response.
+var batchResponseHeaders = []string{model.HEADER_ETAG_SERVER, "Last-Modified", "Content-Type"}
+
+func (api *API) InitBatch() {
+	api.BaseRoutes.ApiRoot.Handle("/batch", api.ApiSessionRequired(api.executeBatch)).Methods("POST")
+}
+
+// batchResponseWriter collects the response to a sub-request of a batch.
+type batchResponseWriter struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *batchResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+func (w *batchResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+func (api *API) executeBatch(c *Context, w http.ResponseWriter, r *http.Request) {
+	batch := model.BatchRequestFromJson(r.Body)
+	if batch == nil {
+		c.SetInvalidParam("batch")
+		return
+	}
+
+	if err := batch.IsValid(*c.App.Config().ServiceSettings.MaxBatchRequests); err != nil {
+		c.Err = err
+		return
+	}
+
+	responses := make([]*model.BatchSubResponse, 0, len(batch.Requests))
+	for _, subRequest := range batch.Requests {
+		response := api.executeBatchSubRequest(c, r, subRequest)
+		responses = append(responses, response)
+
+		if batch.StopOnError && response.Status >= http.StatusBadRequest {
+			break
+		}
+	}
+
+	w.Write([]byte(model.BatchSubResponsesToJson(responses)))
+}
+
+// executeBatchSubRequest routes a sub-request through the API as if it had been made on its own with the headers of
+// the batch request, so that it's authenticated, authorized and rate limited the same way.
+func (api *API) executeBatchSubRequest(c *Context, r *http.Request, subRequest *model.BatchSubRequest) *model.BatchSubResponse {
+	request, err := http.NewRequest(subRequest.Method, model.API_URL_SUFFIX+subRequest.Path, bytes.NewReader(subRequest.Body))
+	if err != nil {
+		return &model.BatchSubResponse{Status: http.StatusBadRequest}
+	}
+	request = request.WithContext(r.Context())
+	request.Host = r.Host
+	request.RemoteAddr = r.RemoteAddr
+	for key, values := range r.Header {
+		if key != "Content-Length" && key != "Content-Type" {
+			request.Header[key] = values
+		}
+	}
+	if len(subRequest.Body) > 0 {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	// The rate limiter wraps the whole server, so each sub-request has to go through it again to count against it
+	handler := http.Handler(api.BaseRoutes.Root)
+	if rateLimiter := c.App.Srv.RateLimiter; rateLimiter != nil {
+		handler = rateLimiter.RateLimitHandler(handler)
+	}
+
+	writer := &batchResponseWriter{header: http.Header{}}
+	handler.ServeHTTP(writer, request)
+
+	response := &model.BatchSubResponse{
+		Status:  writer.statusCode,
+		Headers: map[string]string{},
+	}
+	if response.Status == 0 {
+		response.Status = http.StatusOK
+	}
+	for _, key := range batchResponseHeaders {
+		if value := writer.header.Get(key); len(value) > 0 {
+			response.Headers[key] = value
+		}
+	}
+
+	if body := writer.body.Bytes(); len(body) > 0 {
+		if json.Valid(body) {
+			response.Body = body
+		} else {
+			response.Body, _ = json.Marshal(string(body))
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestExecuteBatch(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	t.Run("sub-requests are made with the session of the batch", func(t *testing.T) {
+		responses, resp := Client.ExecuteBatch([]*model.BatchSubRequest{
+			{Method: "GET", Path: "/users/me"},
+			{Method: "GET", Path: "/channels/" + th.BasicChannel.Id},
+			{Method: "PUT", Path: "/users/me/patch", Body: []byte(`{"nickname":"batched"}`)},
+		}, false)
+		CheckNoError(t, resp)
+		require.Len(t, responses, 3)
+
+		for _, response := range responses {
+			assert.Equal(t, http.StatusOK, response.Status)
+		}
+
+		user := model.UserFromJson(bytes.NewReader(responses[0].Body))
+		require.NotNil(t, user)
+		assert.Equal(t, th.BasicUser.Id, user.Id)
+
+		channel := model.ChannelFromJson(bytes.NewReader(responses[1].Body))
+		require.NotNil(t, channel)
+		assert.Equal(t, th.BasicChannel.Id, channel.Id)
+		assert.NotEmpty(t, responses[1].Headers[model.HEADER_ETAG_SERVER])
+
+		user, err := th.App.GetUser(th.BasicUser.Id)
+		require.Nil(t, err)
+		assert.Equal(t, "batched", user.Nickname)
+	})
+
+	t.Run("sub-requests are authorized individually", func(t *testing.T) {
+		responses, resp := Client.ExecuteBatch([]*model.BatchSubRequest{
+			{Method: "GET", Path: "/config"},
+			{Method: "GET", Path: "/users/me"},
+		}, false)
+		CheckNoError(t, resp)
+		require.Len(t, responses, 2)
+		assert.Equal(t, http.StatusForbidden, responses[0].Status)
+		assert.Equal(t, http.StatusOK, responses[1].Status)
+	})
+
+	t.Run("stop on error", func(t *testing.T) {
+		responses, resp := Client.ExecuteBatch([]*model.BatchSubRequest{
+			{Method: "GET", Path: "/channels/" + model.NewId()},
+			{Method: "GET", Path: "/users/me"},
+		}, true)
+		CheckNoError(t, resp)
+		require.Len(t, responses, 1)
+		assert.True(t, responses[0].Status >= http.StatusBadRequest)
+	})
+
+	t.Run("invalid batches", func(t *testing.T) {
+		_, resp := Client.ExecuteBatch([]*model.BatchSubRequest{}, false)
+		CheckBadRequestStatus(t, resp)
+
+		_, resp = Client.ExecuteBatch([]*model.BatchSubRequest{{Method: "POST", Path: "/batch"}}, false)
+		CheckBadRequestStatus(t, resp)
+
+		_, resp = Client.ExecuteBatch([]*model.BatchSubRequest{{Method: "POST", Path: "/%62atch"}}, false)
+		CheckBadRequestStatus(t, resp)
+
+		_, resp = Client.ExecuteBatch([]*model.BatchSubRequest{{Method: "GET", Path: "/%77ebsocket"}}, false)
+		CheckBadRequestStatus(t, resp)
+
+		th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.MaxBatchRequests = 1 })
+		defer th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.MaxBatchRequests = model.SERVICE_SETTINGS_DEFAULT_MAX_BATCH_REQUESTS
+		})
+
+		_, resp = Client.ExecuteBatch([]*model.BatchSubRequest{
+			{Method: "GET", Path: "/users/me"},
+			{Method: "GET", Path: "/users/me"},
+		}, false)
+		CheckBadRequestStatus(t, resp)
+	})
+
+	t.Run("requires a session", func(t *testing.T) {
+		client := th.CreateClient()
+		_, resp := client.ExecuteBatch([]*model.BatchSubRequest{{Method: "GET", Path: "/users/me"}}, false)
+		CheckUnauthorizedStatus(t, resp)
+	})
+}
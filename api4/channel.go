@@ -5,36 +5,47 @@ package api4
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+	"github.com/mattermost/mattermost-server/web"
 )
 
 func (api *API) InitChannel() {
 	api.BaseRoutes.Channels.Handle("", api.ApiSessionRequired(getAllChannels)).Methods("GET")
 	api.BaseRoutes.Channels.Handle("", api.ApiSessionRequired(createChannel)).Methods("POST")
 	api.BaseRoutes.Channels.Handle("/direct", api.ApiSessionRequired(createDirectChannel)).Methods("POST")
-	api.BaseRoutes.Channels.Handle("/search", api.ApiSessionRequired(searchAllChannels)).Methods("POST")
+	api.BaseRoutes.Channels.Handle("/search", api.ApiSessionRequiredSearch(searchAllChannels)).Methods("POST")
 	api.BaseRoutes.Channels.Handle("/group", api.ApiSessionRequired(createGroupChannel)).Methods("POST")
 	api.BaseRoutes.Channels.Handle("/members/{user_id:[A-Za-z0-9]+}/view", api.ApiSessionRequired(viewChannel)).Methods("POST")
 	api.BaseRoutes.Channels.Handle("/{channel_id:[A-Za-z0-9]+}/scheme", api.ApiSessionRequired(updateChannelScheme)).Methods("PUT")
 
 	api.BaseRoutes.ChannelsForTeam.Handle("", api.ApiSessionRequired(getPublicChannelsForTeam)).Methods("GET")
+	api.BaseRoutes.ChannelsForTeam.Handle("/duplicates", api.ApiSessionRequired(getDuplicateChannelsForTeam)).Methods("GET")
 	api.BaseRoutes.ChannelsForTeam.Handle("/deleted", api.ApiSessionRequired(getDeletedChannelsForTeam)).Methods("GET")
 	api.BaseRoutes.ChannelsForTeam.Handle("/ids", api.ApiSessionRequired(getPublicChannelsByIdsForTeam)).Methods("POST")
-	api.BaseRoutes.ChannelsForTeam.Handle("/search", api.ApiSessionRequired(searchChannelsForTeam)).Methods("POST")
+	api.BaseRoutes.ChannelsForTeam.Handle("/search", api.ApiSessionRequiredSearch(searchChannelsForTeam)).Methods("POST")
 	api.BaseRoutes.ChannelsForTeam.Handle("/autocomplete", api.ApiSessionRequired(autocompleteChannelsForTeam)).Methods("GET")
 	api.BaseRoutes.ChannelsForTeam.Handle("/search_autocomplete", api.ApiSessionRequired(autocompleteChannelsForTeamForSearch)).Methods("GET")
 	api.BaseRoutes.User.Handle("/teams/{team_id:[A-Za-z0-9]+}/channels", api.ApiSessionRequired(getChannelsForTeamForUser)).Methods("GET")
+	api.BaseRoutes.User.Handle("/channels/common", api.ApiSessionRequired(getCommonChannels)).Methods("GET")
 
 	api.BaseRoutes.Channel.Handle("", api.ApiSessionRequired(getChannel)).Methods("GET")
 	api.BaseRoutes.Channel.Handle("", api.ApiSessionRequired(updateChannel)).Methods("PUT")
 	api.BaseRoutes.Channel.Handle("/patch", api.ApiSessionRequired(patchChannel)).Methods("PUT")
 	api.BaseRoutes.Channel.Handle("/convert", api.ApiSessionRequired(convertChannelToPrivate)).Methods("POST")
 	api.BaseRoutes.Channel.Handle("/restore", api.ApiSessionRequired(restoreChannel)).Methods("POST")
+	api.BaseRoutes.Channel.Handle("/move", api.ApiSystemAdminRequired(moveChannel)).Methods("POST")
 	api.BaseRoutes.Channel.Handle("", api.ApiSessionRequired(deleteChannel)).Methods("DELETE")
 	api.BaseRoutes.Channel.Handle("/stats", api.ApiSessionRequired(getChannelStats)).Methods("GET")
 	api.BaseRoutes.Channel.Handle("/pinned", api.ApiSessionRequired(getPinnedPosts)).Methods("GET")
+	api.BaseRoutes.Channel.Handle("/banner", api.ApiSessionRequired(updateChannelBanner)).Methods("PUT")
+	api.BaseRoutes.Channel.Handle("/activity", api.ApiSessionRequired(getChannelActivity)).Methods("GET")
+	api.BaseRoutes.Channel.Handle("/mentions/counts", api.ApiSessionRequired(getChannelMentionCounts)).Methods("POST")
+	api.BaseRoutes.Channel.Handle("/mentions/resolve", api.ApiSessionRequired(resolveChannelMentions)).Methods("POST")
+	api.BaseRoutes.Channel.Handle("/notifications/preview", api.ApiSessionRequired(previewNotifications)).Methods("POST")
 	api.BaseRoutes.Channel.Handle("/timezones", api.ApiSessionRequired(getChannelMembersTimezones)).Methods("GET")
 	api.BaseRoutes.ChannelForUser.Handle("/unread", api.ApiSessionRequired(getChannelUnread)).Methods("GET")
 
@@ -43,7 +54,11 @@ func (api *API) InitChannel() {
 
 	api.BaseRoutes.ChannelMembers.Handle("", api.ApiSessionRequired(getChannelMembers)).Methods("GET")
 	api.BaseRoutes.ChannelMembers.Handle("/ids", api.ApiSessionRequired(getChannelMembersByIds)).Methods("POST")
+	api.BaseRoutes.ChannelMembersSearch.Handle("", api.ApiSessionRequired(searchChannelMembers)).Methods("GET")
 	api.BaseRoutes.ChannelMembers.Handle("", api.ApiSessionRequired(addChannelMember)).Methods("POST")
+	api.BaseRoutes.ChannelMembers.Handle("/bulk", api.ApiSessionRequired(addChannelMembersBulk)).Methods("POST")
+	api.BaseRoutes.ChannelMembers.Handle("/copy", api.ApiSessionRequired(copyChannelMembers)).Methods("POST")
+	api.BaseRoutes.ChannelMembers.Handle("/roles/bulk", api.ApiSessionRequired(updateChannelMembersRolesBulk)).Methods("PUT")
 	api.BaseRoutes.ChannelMembersForUser.Handle("", api.ApiSessionRequired(getChannelMembersForUser)).Methods("GET")
 	api.BaseRoutes.ChannelMember.Handle("", api.ApiSessionRequired(getChannelMember)).Methods("GET")
 	api.BaseRoutes.ChannelMember.Handle("", api.ApiSessionRequired(removeChannelMember)).Methods("DELETE")
@@ -309,6 +324,30 @@ func restoreChannel(c *Context, w http.ResponseWriter, r *http.Request) {
 
 }
 
+func moveChannel(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireChannelId()
+	if c.Err != nil {
+		return
+	}
+
+	request := model.ChannelMoveRequestFromJson(r.Body)
+	if request == nil || !model.IsValidId(request.TeamId) {
+		c.SetInvalidParam("team_id")
+		return
+	}
+
+	job, err := c.App.CreateMoveChannelJob(c.Params.ChannelId, request, c.App.Session.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("channel_id=" + c.Params.ChannelId + " team_id=" + request.TeamId + " remove_members_not_in_team=" + strconv.FormatBool(request.RemoveMembersNotInTeam) + " job_id=" + job.Id)
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(job.ToJson()))
+}
+
 func createDirectChannel(c *Context, w http.ResponseWriter, r *http.Request) {
 	userIds := model.ArrayFromJson(r.Body)
 	allowed := false
@@ -416,7 +455,13 @@ func getChannel(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Write([]byte(channel.ToJson()))
+	// Props are computed from other channels, so only the ETag can tell whether they've changed
+	var lastModified int64
+	if len(channel.Props) == 0 {
+		lastModified = channel.LastModifiedAt()
+	}
+
+	c.WriteConditionally([]byte(channel.ToJson()), lastModified, "Get Channel", w, r)
 }
 
 func getChannelUnread(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -465,6 +510,166 @@ func getChannelStats(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(stats.ToJson()))
 }
 
+func getChannelActivity(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireChannelId()
+	if c.Err != nil {
+		return
+	}
+
+	query := r.URL.Query()
+
+	granularity := query.Get("granularity")
+	if granularity == "" {
+		granularity = model.CHANNEL_ACTIVITY_GRANULARITY_DAY
+	}
+
+	timeZone := query.Get("time_zone")
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+
+	since, parseErr := strconv.ParseInt(query.Get("since"), 10, 64)
+	if parseErr != nil {
+		c.SetInvalidUrlParam("since")
+		return
+	}
+
+	until := model.GetMillis()
+	if untilStr := query.Get("until"); untilStr != "" {
+		if until, parseErr = strconv.ParseInt(untilStr, 10, 64); parseErr != nil {
+			c.SetInvalidUrlParam("until")
+			return
+		}
+	}
+
+	if !c.App.SessionHasPermissionToChannel(c.App.Session, c.Params.ChannelId, model.PERMISSION_READ_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+		return
+	}
+
+	activity, err := c.App.GetChannelActivity(c.Params.ChannelId, granularity, timeZone, since, until)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(activity.ToJson()))
+}
+
+func getChannelMentionCounts(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireChannelId()
+	if c.Err != nil {
+		return
+	}
+
+	userIds := model.ArrayFromJson(r.Body)
+	if len(userIds) == 0 {
+		c.SetInvalidParam("user_ids")
+		return
+	}
+
+	query := r.URL.Query()
+
+	since, parseErr := strconv.ParseInt(query.Get("since"), 10, 64)
+	if parseErr != nil {
+		c.SetInvalidUrlParam("since")
+		return
+	}
+
+	until := model.GetMillis()
+	if untilStr := query.Get("until"); untilStr != "" {
+		if until, parseErr = strconv.ParseInt(untilStr, 10, 64); parseErr != nil {
+			c.SetInvalidUrlParam("until")
+			return
+		}
+	}
+
+	if !c.App.SessionHasPermissionToChannel(c.App.Session, c.Params.ChannelId, model.PERMISSION_MANAGE_CHANNEL_ROLES) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_CHANNEL_ROLES)
+		return
+	}
+
+	counts, err := c.App.GetChannelMentionCounts(c.Params.ChannelId, userIds, since, until)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(counts.ToJson()))
+}
+
+func resolveChannelMentions(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireChannelId()
+	if c.Err != nil {
+		return
+	}
+
+	tokens := model.ArrayFromJson(r.Body)
+	if len(tokens) == 0 || len(tokens) > model.MENTION_RESOLUTION_MAX_TOKENS {
+		c.SetInvalidParam("mentions")
+		return
+	}
+
+	if !c.App.SessionHasPermissionToChannel(c.App.Session, c.Params.ChannelId, model.PERMISSION_READ_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+		return
+	}
+
+	channel, err := c.App.GetChannel(c.Params.ChannelId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	resolution, err := c.App.ResolveChannelMentions(channel, tokens)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(resolution.ToJson()))
+}
+
+func previewNotifications(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireChannelId()
+	if c.Err != nil {
+		return
+	}
+
+	draft := model.PostFromJson(r.Body)
+	if draft == nil {
+		c.SetInvalidParam("post")
+		return
+	}
+
+	if !c.App.SessionHasPermissionToChannel(c.App.Session, c.Params.ChannelId, model.PERMISSION_CREATE_POST) {
+		c.SetPermissionError(model.PERMISSION_CREATE_POST)
+		return
+	}
+
+	channel, err := c.App.GetChannel(c.Params.ChannelId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	// Only the parts of the draft that affect notifications are used, and the sender is always the current user.
+	post := &model.Post{
+		UserId:    c.App.Session.UserId,
+		ChannelId: channel.Id,
+		RootId:    draft.RootId,
+		Message:   draft.Message,
+	}
+
+	preview, err := c.App.PreviewNotifications(post, channel)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(preview.ToJson()))
+}
+
 func getPinnedPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.RequireChannelId()
 	if c.Err != nil {
@@ -487,27 +692,108 @@ func getPinnedPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 	clientPostList := c.App.PreparePostListForClient(posts)
+	c.App.FillInPermalinkPreviewsForList(clientPostList, c.App.Session)
 
 	w.Header().Set(model.HEADER_ETAG_SERVER, clientPostList.Etag())
 	w.Write([]byte(clientPostList.ToJson()))
 }
 
+func updateChannelBanner(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireChannelId()
+	if c.Err != nil {
+		return
+	}
+
+	props := model.MapFromJson(r.Body)
+	postId, ok := props["post_id"]
+	if !ok || (len(postId) != 0 && !model.IsValidId(postId)) {
+		c.SetInvalidParam("post_id")
+		return
+	}
+
+	channel, err := c.App.GetChannel(c.Params.ChannelId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	// The banner is channel metadata like the header, so it is protected by the same permissions.
+	switch channel.Type {
+	case model.CHANNEL_OPEN:
+		if !c.App.SessionHasPermissionToChannel(c.App.Session, c.Params.ChannelId, model.PERMISSION_MANAGE_PUBLIC_CHANNEL_PROPERTIES) {
+			c.SetPermissionError(model.PERMISSION_MANAGE_PUBLIC_CHANNEL_PROPERTIES)
+			return
+		}
+
+	case model.CHANNEL_PRIVATE:
+		if !c.App.SessionHasPermissionToChannel(c.App.Session, c.Params.ChannelId, model.PERMISSION_MANAGE_PRIVATE_CHANNEL_PROPERTIES) {
+			c.SetPermissionError(model.PERMISSION_MANAGE_PRIVATE_CHANNEL_PROPERTIES)
+			return
+		}
+
+	default:
+		if _, err = c.App.GetChannelMember(c.Params.ChannelId, c.App.Session.UserId); err != nil {
+			c.Err = model.NewAppError("updateChannelBanner", "api.channel.patch_update_channel.forbidden.app_error", nil, "", http.StatusForbidden)
+			return
+		}
+	}
+
+	if channel.DeleteAt != 0 {
+		c.Err = model.NewAppError("updateChannelBanner", "api.channel.update_channel_banner.deleted.app_error", nil, "", http.StatusBadRequest)
+		return
+	}
+
+	rchannel, err := c.App.SetChannelBanner(channel, postId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if err = c.App.FillInChannelProps(rchannel); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("post_id=" + postId)
+	w.Write([]byte(rchannel.ToJson()))
+}
+
 func getAllChannels(c *Context, w http.ResponseWriter, r *http.Request) {
+	contentType := c.NegotiateContentType(w, r, web.CONTENT_TYPE_JSON, web.CONTENT_TYPE_CSV)
+	if c.Err != nil {
+		return
+	}
+
 	if !c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_SYSTEM) {
 		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
 		return
 	}
 
-	channels, err := c.App.GetAllChannels(c.Params.Page, c.Params.PerPage, false)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	channels, err := c.App.GetAllChannels(pagination.Page, pagination.PerPage, false)
 	if err != nil {
 		c.Err = err
 		return
 	}
 
+	if contentType == web.CONTENT_TYPE_CSV {
+		web.WriteCsv(w, channels.ToCsv())
+		return
+	}
+
 	w.Write([]byte(channels.ToJson()))
 }
 
 func getPublicChannelsForTeam(c *Context, w http.ResponseWriter, r *http.Request) {
+	contentType := c.NegotiateContentType(w, r, web.CONTENT_TYPE_JSON, web.CONTENT_TYPE_CSV)
+	if c.Err != nil {
+		return
+	}
+
 	c.RequireTeamId()
 	if c.Err != nil {
 		return
@@ -518,7 +804,12 @@ func getPublicChannelsForTeam(c *Context, w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	channels, err := c.App.GetPublicChannelsForTeam(c.Params.TeamId, c.Params.Page*c.Params.PerPage, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	channels, err := c.App.GetPublicChannelsForTeam(c.Params.TeamId, pagination.Offset, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
@@ -530,9 +821,46 @@ func getPublicChannelsForTeam(c *Context, w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if contentType == web.CONTENT_TYPE_CSV {
+		web.WriteCsv(w, channels.ToCsv())
+		return
+	}
+
 	w.Write([]byte(channels.ToJson()))
 }
 
+func getDuplicateChannelsForTeam(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	threshold := model.CHANNEL_DUPLICATES_DEFAULT_THRESHOLD
+	if thresholdStr := r.URL.Query().Get("threshold"); thresholdStr != "" {
+		var err error
+		if threshold, err = strconv.ParseFloat(thresholdStr, 64); err != nil || threshold <= 0 || threshold > 1 {
+			c.SetInvalidUrlParam("threshold")
+			return
+		}
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	// Team admins only see the private channels they're a member of
+	includeAllPrivate := c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_SYSTEM)
+
+	clusters, err := c.App.GetDuplicateChannels(c.Params.TeamId, c.App.Session.UserId, includeAllPrivate, threshold)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(model.ChannelDuplicateClustersToJson(clusters)))
+}
+
 func getDeletedChannelsForTeam(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.RequireTeamId()
 	if c.Err != nil {
@@ -544,7 +872,12 @@ func getDeletedChannelsForTeam(c *Context, w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	channels, err := c.App.GetDeletedChannels(c.Params.TeamId, c.Params.Page*c.Params.PerPage, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	channels, err := c.App.GetDeletedChannels(c.Params.TeamId, pagination.Offset, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
@@ -598,7 +931,55 @@ func getPublicChannelsByIdsForTeam(c *Context, w http.ResponseWriter, r *http.Re
 	w.Write([]byte(channels.ToJson()))
 }
 
+func getCommonChannels(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireUserId()
+	if c.Err != nil {
+		return
+	}
+
+	// Otherwise anyone could find out which channels any user is in by listing those they share with them
+	if !c.App.SessionHasPermissionToUser(c.App.Session, c.Params.UserId) {
+		teamMembers, err := c.App.GetTeamMembersForUser(c.Params.UserId)
+		if err != nil {
+			c.Err = err
+			return
+		}
+
+		canSeeUser := false
+		for _, teamMember := range teamMembers {
+			if teamMember.DeleteAt == 0 && c.App.SessionHasPermissionToTeam(c.App.Session, teamMember.TeamId, model.PERMISSION_VIEW_TEAM) {
+				canSeeUser = true
+				break
+			}
+		}
+
+		if !canSeeUser {
+			c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
+			return
+		}
+	}
+
+	// Only channels that the requester is a member of are returned, so there is nothing they couldn't already see.
+	channels, err := c.App.GetCommonChannels(c.App.Session.UserId, c.Params.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if err = c.App.FillInChannelsProps(channels); err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(channels.ToJson()))
+}
+
 func getChannelsForTeamForUser(c *Context, w http.ResponseWriter, r *http.Request) {
+	contentType := c.NegotiateContentType(w, r, web.CONTENT_TYPE_JSON, web.CONTENT_TYPE_CSV)
+	if c.Err != nil {
+		return
+	}
+
 	c.RequireUserId().RequireTeamId()
 	if c.Err != nil {
 		return
@@ -620,7 +1001,8 @@ func getChannelsForTeamForUser(c *Context, w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if c.HandleEtag(channels.Etag(), "Get Channels", w, r) {
+	etag := web.EtagForContentType(channels.Etag(), contentType)
+	if c.HandleEtag(etag, "Get Channels", w, r) {
 		return
 	}
 
@@ -630,7 +1012,13 @@ func getChannelsForTeamForUser(c *Context, w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	w.Header().Set(model.HEADER_ETAG_SERVER, channels.Etag())
+	w.Header().Set(model.HEADER_ETAG_SERVER, etag)
+
+	if contentType == web.CONTENT_TYPE_CSV {
+		web.WriteCsv(w, channels.ToCsv())
+		return
+	}
+
 	w.Write([]byte(channels.ToJson()))
 }
 
@@ -847,7 +1235,59 @@ func getChannelMembers(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	members, err := c.App.GetChannelMembersPage(c.Params.ChannelId, c.Params.Page, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	var members *model.ChannelMembers
+	var err *model.AppError
+	if sort := r.URL.Query().Get("sort"); sort == "" {
+		members, err = c.App.GetChannelMembersPage(c.Params.ChannelId, pagination.Page, pagination.PerPage)
+	} else if !model.IsValidChannelMemberSort(sort) {
+		c.SetInvalidUrlParam("sort")
+		return
+	} else {
+		members, err = c.App.GetChannelMembersPageSorted(c.Params.ChannelId, sort, pagination.Page, pagination.PerPage)
+	}
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(members.ToJson()))
+}
+
+func searchChannelMembers(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireChannelId()
+	if c.Err != nil {
+		return
+	}
+
+	if !c.App.SessionHasPermissionToChannel(c.App.Session, c.Params.ChannelId, model.PERMISSION_READ_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+		return
+	}
+
+	query := r.URL.Query()
+
+	role := query.Get("role")
+	if role != "" && !model.IsValidRoleName(role) {
+		c.SetInvalidParam("role")
+		return
+	}
+
+	allowFullNames := c.App.Config().PrivacySettings.ShowFullName
+	if c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		allowFullNames = true
+	}
+
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	members, err := c.App.SearchChannelMembers(c.Params.ChannelId, query.Get("term"), role, allowFullNames, pagination.Page, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
@@ -1019,6 +1459,46 @@ func updateChannelMemberRoles(c *Context, w http.ResponseWriter, r *http.Request
 	ReturnStatusOK(w)
 }
 
+func updateChannelMembersRolesBulk(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireChannelId()
+	if c.Err != nil {
+		return
+	}
+
+	request := model.ChannelMemberRolesBulkRequestFromJson(r.Body)
+	if request == nil {
+		c.SetInvalidParam("roles")
+		return
+	}
+
+	if err := request.IsValid(); err != nil {
+		c.Err = err
+		return
+	}
+
+	if !c.App.SessionHasPermissionToChannel(c.App.Session, c.Params.ChannelId, model.PERMISSION_MANAGE_CHANNEL_ROLES) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_CHANNEL_ROLES)
+		return
+	}
+
+	channel, err := c.App.GetChannel(c.Params.ChannelId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	results, err := c.App.UpdateChannelMembersRolesBulk(channel, request)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	updated := sanitizeChannelMemberBulkResults(c, results)
+
+	c.LogAudit("name=" + channel.Name + " users=" + strconv.Itoa(updated))
+	w.Write([]byte(model.ChannelMemberBulkResultsToJson(results)))
+}
+
 func updateChannelMemberSchemeRoles(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.RequireChannelId().RequireUserId()
 	if c.Err != nil {
@@ -1148,6 +1628,145 @@ func addChannelMember(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(cm.ToJson()))
 }
 
+func addChannelMembersBulk(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireChannelId()
+	if c.Err != nil {
+		return
+	}
+
+	request := model.ChannelMembersBulkRequestFromJson(r.Body)
+	if request == nil {
+		c.SetInvalidParam("request")
+		return
+	}
+
+	if err := request.IsValid(); err != nil {
+		c.Err = err
+		return
+	}
+
+	channel, err := c.App.GetChannel(c.Params.ChannelId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if channel.Type == model.CHANNEL_OPEN && !c.App.SessionHasPermissionToChannel(c.App.Session, channel.Id, model.PERMISSION_MANAGE_PUBLIC_CHANNEL_MEMBERS) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_PUBLIC_CHANNEL_MEMBERS)
+		return
+	}
+
+	if channel.Type == model.CHANNEL_PRIVATE && !c.App.SessionHasPermissionToChannel(c.App.Session, channel.Id, model.PERMISSION_MANAGE_PRIVATE_CHANNEL_MEMBERS) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_PRIVATE_CHANNEL_MEMBERS)
+		return
+	}
+
+	if channel.Type == model.CHANNEL_DIRECT || channel.Type == model.CHANNEL_GROUP {
+		c.Err = model.NewAppError("addChannelMembersBulk", "api.channel.add_user_to_channel.type.app_error", nil, "", http.StatusBadRequest)
+		return
+	}
+
+	// Listing the members of a group or team needs the same permissions as the endpoints that list them.
+	if len(request.GroupId) > 0 {
+		if c.App.License() == nil || !*c.App.License().Features.LDAPGroups {
+			c.Err = model.NewAppError("addChannelMembersBulk", "api.ldap_groups.license_error", nil, "", http.StatusNotImplemented)
+			return
+		}
+
+		if !c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_SYSTEM) {
+			c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+			return
+		}
+	}
+
+	if len(request.TeamId) > 0 && !c.App.SessionHasPermissionToTeam(c.App.Session, request.TeamId, model.PERMISSION_VIEW_TEAM) {
+		c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
+		return
+	}
+
+	results, err := c.App.AddChannelMembersBulk(channel, request, c.App.Session.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	added := sanitizeChannelMemberBulkResults(c, results)
+
+	c.LogAudit("name=" + channel.Name + " users=" + strconv.Itoa(added))
+	w.Write([]byte(model.ChannelMemberBulkResultsToJson(results)))
+}
+
+func copyChannelMembers(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireChannelId()
+	if c.Err != nil {
+		return
+	}
+
+	request := model.ChannelMembersCopyRequestFromJson(r.Body)
+	if request == nil || !model.IsValidId(request.SourceChannelId) {
+		c.SetInvalidParam("source_channel_id")
+		return
+	}
+
+	if !c.App.SessionHasPermissionToChannel(c.App.Session, request.SourceChannelId, model.PERMISSION_READ_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+		return
+	}
+
+	source, err := c.App.GetChannel(request.SourceChannelId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	target, err := c.App.GetChannel(c.Params.ChannelId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if target.Type == model.CHANNEL_OPEN && !c.App.SessionHasPermissionToChannel(c.App.Session, target.Id, model.PERMISSION_MANAGE_PUBLIC_CHANNEL_MEMBERS) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_PUBLIC_CHANNEL_MEMBERS)
+		return
+	}
+
+	if target.Type == model.CHANNEL_PRIVATE && !c.App.SessionHasPermissionToChannel(c.App.Session, target.Id, model.PERMISSION_MANAGE_PRIVATE_CHANNEL_MEMBERS) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_PRIVATE_CHANNEL_MEMBERS)
+		return
+	}
+
+	if request.PreserveRoles && !c.App.SessionHasPermissionToChannel(c.App.Session, target.Id, model.PERMISSION_MANAGE_CHANNEL_ROLES) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_CHANNEL_ROLES)
+		return
+	}
+
+	results, err := c.App.CopyChannelMembers(source, target, request.PreserveRoles, c.App.Session.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	added := sanitizeChannelMemberBulkResults(c, results)
+
+	c.LogAudit("source=" + source.Name + " target=" + target.Name + " users=" + strconv.Itoa(added))
+	w.Write([]byte(model.ChannelMemberBulkResultsToJson(results)))
+}
+
+// sanitizeChannelMemberBulkResults prepares per-user errors the same way as request errors and returns the number of
+// users without one.
+func sanitizeChannelMemberBulkResults(c *Context, results []*model.ChannelMemberBulkResult) int {
+	succeeded := 0
+	for _, result := range results {
+		if err := result.Error; err != nil {
+			err.Translate(c.App.T)
+			utils.SanitizeAppError(c.App.Config(), err)
+		} else {
+			succeeded++
+		}
+	}
+	return succeeded
+}
+
 func removeChannelMember(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.RequireChannelId().RequireUserId()
 	if c.Err != nil {
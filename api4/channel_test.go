@@ -4,6 +4,7 @@
 package api4
 
 import (
+	"encoding/csv"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -141,6 +142,61 @@ func TestCreateChannel(t *testing.T) {
 	}
 }
 
+func TestChannelNamingConvention(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+	team := th.BasicTeam
+
+	namePattern := "proj-[a-z0-9]+"
+	displayNamePattern := "Project .+"
+	_, resp := th.SystemAdminClient.PatchTeam(team.Id, &model.TeamPatch{ChannelNamePattern: &namePattern, ChannelDisplayNamePattern: &displayNamePattern})
+	CheckNoError(t, resp)
+
+	invalidPattern := "proj-("
+	_, resp = th.SystemAdminClient.PatchTeam(team.Id, &model.TeamPatch{ChannelNamePattern: &invalidPattern})
+	CheckBadRequestStatus(t, resp)
+
+	t.Run("create", func(t *testing.T) {
+		_, resp := Client.CreateChannel(&model.Channel{DisplayName: "Project Apollo", Name: "apollo" + model.NewId(), Type: model.CHANNEL_OPEN, TeamId: team.Id})
+		CheckBadRequestStatus(t, resp)
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, "api.channel.naming_convention.name.app_error", resp.Error.Id)
+
+		_, resp = Client.CreateChannel(&model.Channel{DisplayName: "Apollo", Name: "proj-" + model.NewId(), Type: model.CHANNEL_PRIVATE, TeamId: team.Id})
+		CheckBadRequestStatus(t, resp)
+		require.NotNil(t, resp.Error)
+		assert.Equal(t, "api.channel.naming_convention.display_name.app_error", resp.Error.Id)
+
+		_, resp = Client.CreateChannel(&model.Channel{DisplayName: "Project Apollo", Name: "proj-" + model.NewId(), Type: model.CHANNEL_OPEN, TeamId: team.Id})
+		CheckNoError(t, resp)
+	})
+
+	t.Run("team admins are exempt", func(t *testing.T) {
+		_, resp := th.SystemAdminClient.CreateChannel(&model.Channel{DisplayName: "Apollo", Name: "apollo" + model.NewId(), Type: model.CHANNEL_OPEN, TeamId: team.Id})
+		CheckNoError(t, resp)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		channel, resp := Client.CreateChannel(&model.Channel{DisplayName: "Project Gemini", Name: "proj-" + model.NewId(), Type: model.CHANNEL_OPEN, TeamId: team.Id})
+		CheckNoError(t, resp)
+
+		name := "gemini" + model.NewId()
+		_, resp = Client.PatchChannel(channel.Id, &model.ChannelPatch{Name: &name})
+		CheckBadRequestStatus(t, resp)
+
+		channel.Name = name
+		_, resp = Client.UpdateChannel(channel)
+		CheckBadRequestStatus(t, resp)
+	})
+
+	t.Run("existing channels are grandfathered", func(t *testing.T) {
+		header := "new header"
+		_, resp := Client.PatchChannel(th.BasicChannel.Id, &model.ChannelPatch{Header: &header})
+		CheckNoError(t, resp)
+	})
+}
+
 func TestUpdateChannel(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -535,6 +591,111 @@ func TestGetChannel(t *testing.T) {
 	CheckNotFoundStatus(t, resp)
 }
 
+func TestGetChannelConditional(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	_, resp := Client.GetChannel(th.BasicChannel.Id, "")
+	CheckNoError(t, resp)
+	require.NotEmpty(t, resp.Etag)
+	lastModified := resp.Header.Get("Last-Modified")
+	require.NotEmpty(t, lastModified)
+
+	channel, etagResp := Client.GetChannel(th.BasicChannel.Id, resp.Etag)
+	CheckEtag(t, channel, etagResp)
+
+	Client.HttpHeader["If-Modified-Since"] = lastModified
+	defer delete(Client.HttpHeader, "If-Modified-Since")
+
+	channel, modifiedResp := Client.GetChannel(th.BasicChannel.Id, "")
+	CheckEtag(t, channel, modifiedResp)
+
+	// Posting changes the channel's last post time and message count without updating the channel itself
+	time.Sleep(time.Second)
+	th.CreatePost()
+
+	channel, modifiedResp = Client.GetChannel(th.BasicChannel.Id, resp.Etag)
+	CheckNoError(t, modifiedResp)
+	require.NotNil(t, channel)
+	assert.NotEqual(t, resp.Etag, modifiedResp.Etag)
+
+	channel, modifiedResp = Client.GetChannel(th.BasicChannel.Id, "")
+	CheckNoError(t, modifiedResp)
+	require.NotNil(t, channel)
+}
+
+func TestGetDuplicateChannelsForTeam(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+	team := th.BasicTeam
+
+	suffix := model.NewId()[:8]
+	channel1, err := th.App.CreateChannel(&model.Channel{DisplayName: "Release " + suffix, Name: "release-" + suffix, Type: model.CHANNEL_OPEN, TeamId: team.Id}, false)
+	require.Nil(t, err)
+	channel2, err := th.App.CreateChannel(&model.Channel{DisplayName: "Releases " + suffix, Name: "releases-" + suffix, Type: model.CHANNEL_PRIVATE, TeamId: team.Id}, false)
+	require.Nil(t, err)
+	_, err = th.App.AddUserToChannel(th.BasicUser, channel2)
+	require.Nil(t, err)
+
+	clusters, resp := th.SystemAdminClient.GetDuplicateChannelsForTeam(team.Id, model.CHANNEL_DUPLICATES_DEFAULT_THRESHOLD)
+	CheckNoError(t, resp)
+
+	var found *model.ChannelDuplicateCluster
+	for _, cluster := range clusters {
+		for _, candidate := range cluster.Channels {
+			if candidate.ChannelId == channel1.Id {
+				found = cluster
+			}
+		}
+	}
+	require.NotNil(t, found)
+	require.Len(t, found.Channels, 2)
+	assert.Equal(t, channel2.Id, found.Channels[0].ChannelId, "the channel with the most members comes first")
+	assert.EqualValues(t, 1, found.Channels[0].MemberCount)
+	assert.Equal(t, channel1.Id, found.Channels[1].ChannelId)
+
+	t.Run("team admins only see private channels they're a member of", func(t *testing.T) {
+		th.LoginBasic2()
+		defer th.LoginBasic()
+		th.UpdateUserToTeamAdmin(th.BasicUser2, team)
+		defer th.UpdateUserToNonTeamAdmin(th.BasicUser2, team)
+
+		clusters, resp := Client.GetDuplicateChannelsForTeam(team.Id, model.CHANNEL_DUPLICATES_DEFAULT_THRESHOLD)
+		CheckNoError(t, resp)
+		for _, cluster := range clusters {
+			for _, candidate := range cluster.Channels {
+				assert.NotEqual(t, channel2.Id, candidate.ChannelId, "a private channel the team admin isn't a member of was returned")
+			}
+		}
+
+		th.AddUserToChannel(th.BasicUser2, channel2)
+
+		clusters, resp = Client.GetDuplicateChannelsForTeam(team.Id, model.CHANNEL_DUPLICATES_DEFAULT_THRESHOLD)
+		CheckNoError(t, resp)
+		found := false
+		for _, cluster := range clusters {
+			for _, candidate := range cluster.Channels {
+				if candidate.ChannelId == channel2.Id {
+					found = true
+				}
+			}
+		}
+		assert.True(t, found)
+	})
+
+	_, resp = th.SystemAdminClient.GetDuplicateChannelsForTeam(team.Id, 2)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.GetDuplicateChannelsForTeam(team.Id, model.CHANNEL_DUPLICATES_DEFAULT_THRESHOLD)
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.GetDuplicateChannelsForTeam(team.Id, model.CHANNEL_DUPLICATES_DEFAULT_THRESHOLD)
+	CheckUnauthorizedStatus(t, resp)
+}
+
 func TestGetDeletedChannelsForTeam(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -772,6 +933,42 @@ func TestGetChannelsForTeamForUser(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+func TestGetChannelsAsCsv(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	th.SystemAdminClient.HttpHeader = map[string]string{"Accept": "text/csv"}
+
+	t.Run("all channels", func(t *testing.T) {
+		r, resp := th.SystemAdminClient.DoApiGet(th.SystemAdminClient.GetChannelsRoute()+"?per_page=200", "")
+		require.Nil(t, resp)
+		defer closeBody(r)
+
+		assert.Equal(t, "text/csv; charset=utf-8", r.Header.Get("Content-Type"))
+
+		records, err := csv.NewReader(r.Body).ReadAll()
+		require.Nil(t, err)
+		require.True(t, len(records) > 3)
+		assert.Equal(t, "team_display_name", records[0][len(records[0])-1])
+	})
+
+	t.Run("public channels for team", func(t *testing.T) {
+		r, resp := th.SystemAdminClient.DoApiGet(th.SystemAdminClient.GetTeamRoute(th.BasicTeam.Id)+"/channels", "")
+		require.Nil(t, resp)
+		defer closeBody(r)
+
+		records, err := csv.NewReader(r.Body).ReadAll()
+		require.Nil(t, err)
+
+		names := []string{}
+		for _, record := range records[1:] {
+			names = append(names, record[7])
+		}
+		assert.Contains(t, names, th.BasicChannel.Name)
+		assert.NotContains(t, names, th.BasicPrivateChannel.Name)
+	})
+}
+
 func TestGetAllChannels(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -1153,6 +1350,36 @@ func TestRestoreChannel(t *testing.T) {
 	CheckOKStatus(t, resp)
 }
 
+func TestMoveChannel(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	team := th.CreateTeam()
+	channel := th.CreatePublicChannel()
+	request := &model.ChannelMoveRequest{TeamId: team.Id, RemoveMembersNotInTeam: true}
+
+	_, resp := Client.MoveChannelToTeam(channel.Id, request)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.MoveChannelToTeam(channel.Id, &model.ChannelMoveRequest{TeamId: "junk"})
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.MoveChannelToTeam(channel.Id, &model.ChannelMoveRequest{TeamId: team.Id})
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.MoveChannelToTeam(channel.Id, &model.ChannelMoveRequest{TeamId: th.BasicTeam.Id})
+	CheckBadRequestStatus(t, resp)
+
+	job, resp := th.SystemAdminClient.MoveChannelToTeam(channel.Id, request)
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+	require.Equal(t, model.JOB_TYPE_MOVE_CHANNEL, job.Type)
+	require.Equal(t, channel.Id, job.Data["channel_id"])
+	require.Equal(t, team.Id, job.Data["team_id"])
+	require.Equal(t, "true", job.Data["remove_members_not_in_team"])
+}
+
 func TestGetChannelByName(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -1308,6 +1535,151 @@ func TestGetChannelMembers(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+func TestGetCommonChannels(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	privateChannel := th.CreatePrivateChannel()
+	th.App.AddUserToChannel(th.BasicUser2, privateChannel)
+
+	channels, resp := Client.GetCommonChannels(th.BasicUser2.Id)
+	CheckNoError(t, resp)
+
+	channelIds := []string{}
+	for _, channel := range channels {
+		channelIds = append(channelIds, channel.Id)
+	}
+	assert.Contains(t, channelIds, th.BasicChannel.Id)
+	assert.Contains(t, channelIds, privateChannel.Id)
+	assert.NotContains(t, channelIds, th.BasicDeletedChannel.Id)
+
+	// A private channel the requester isn't in must not be revealed.
+	otherChannel, err := th.App.CreateChannel(&model.Channel{
+		TeamId:      th.BasicTeam.Id,
+		DisplayName: "Other",
+		Name:        GenerateTestChannelName(),
+		Type:        model.CHANNEL_PRIVATE,
+	}, false)
+	require.Nil(t, err)
+	th.LinkUserToTeam(th.SystemAdminUser, th.BasicTeam)
+	th.App.AddUserToChannel(th.BasicUser2, otherChannel)
+	th.App.AddUserToChannel(th.SystemAdminUser, otherChannel)
+
+	channels, resp = th.SystemAdminClient.GetCommonChannels(th.BasicUser2.Id)
+	CheckNoError(t, resp)
+	channelIds = []string{}
+	for _, channel := range channels {
+		channelIds = append(channelIds, channel.Id)
+	}
+	assert.Contains(t, channelIds, otherChannel.Id)
+	assert.NotContains(t, channelIds, th.BasicChannel.Id)
+
+	channels, resp = Client.GetCommonChannels(th.BasicUser2.Id)
+	CheckNoError(t, resp)
+	for _, channel := range channels {
+		assert.NotEqual(t, otherChannel.Id, channel.Id)
+	}
+
+	_, resp = Client.GetCommonChannels("junk")
+	CheckBadRequestStatus(t, resp)
+
+	// Users who don't share a team with the requester can't be looked up.
+	otherUser := th.CreateUser()
+	_, resp = Client.GetCommonChannels(otherUser.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetCommonChannels(otherUser.Id)
+	CheckNoError(t, resp)
+
+	th.LinkUserToTeam(otherUser, th.BasicTeam)
+	_, resp = Client.GetCommonChannels(otherUser.Id)
+	CheckNoError(t, resp)
+
+	Client.Logout()
+	_, resp = Client.GetCommonChannels(th.BasicUser2.Id)
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestGetChannelMembersSorted(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	members, resp := Client.GetChannelMembersSorted(th.BasicChannel.Id, model.CHANNEL_MEMBER_SORT_USERNAME, 0, 60)
+	CheckNoError(t, resp)
+	require.Len(t, *members, 3)
+
+	usernames := []string{}
+	for _, member := range *members {
+		user, err := th.App.GetUser(member.UserId)
+		require.Nil(t, err)
+		usernames = append(usernames, user.Username)
+	}
+	assert.True(t, sort.StringsAreSorted(usernames))
+
+	for _, sortBy := range []string{model.CHANNEL_MEMBER_SORT_NICKNAME, model.CHANNEL_MEMBER_SORT_JOINED_AT, model.CHANNEL_MEMBER_SORT_ROLE} {
+		members, resp = Client.GetChannelMembersSorted(th.BasicChannel.Id, sortBy, 0, 60)
+		CheckNoError(t, resp)
+		assert.Len(t, *members, 3)
+	}
+
+	_, resp = Client.GetChannelMembersSorted(th.BasicChannel.Id, "email", 0, 60)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.GetChannelMembersSorted(model.NewId(), model.CHANNEL_MEMBER_SORT_USERNAME, 0, 60)
+	CheckForbiddenStatus(t, resp)
+}
+
+func TestSearchChannelMembers(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	_, err := th.App.UpdateChannelMemberSchemeRoles(th.BasicChannel.Id, th.BasicUser2.Id, true, true)
+	require.Nil(t, err)
+
+	members, resp := Client.SearchChannelMembers(th.BasicChannel.Id, th.BasicUser2.Username, "", 0, 60)
+	CheckNoError(t, resp)
+	require.Len(t, *members, 1)
+	assert.Equal(t, th.BasicUser2.Id, (*members)[0].UserId)
+
+	members, resp = Client.SearchChannelMembers(th.BasicChannel.Id, "", model.CHANNEL_ADMIN_ROLE_ID, 0, 60)
+	CheckNoError(t, resp)
+	userIds := []string{}
+	for _, member := range *members {
+		assert.True(t, member.SchemeAdmin)
+		userIds = append(userIds, member.UserId)
+	}
+	assert.Contains(t, userIds, th.BasicUser2.Id)
+
+	_, resp = Client.SearchChannelMembers(th.BasicChannel.Id, "", "%", 0, 60)
+	CheckBadRequestStatus(t, resp)
+
+	members, resp = Client.SearchChannelMembers(th.BasicChannel.Id, "", "", 0, 2)
+	CheckNoError(t, resp)
+	assert.Len(t, *members, 2)
+
+	members, resp = Client.SearchChannelMembers(th.BasicChannel.Id, model.NewId(), "", 0, 60)
+	CheckNoError(t, resp)
+	assert.Empty(t, *members)
+
+	_, resp = Client.SearchChannelMembers(model.NewId(), "", "", 0, 60)
+	CheckForbiddenStatus(t, resp)
+
+	user := th.CreateUser()
+	Client.Login(user.Email, user.Password)
+	_, resp = Client.SearchChannelMembers(th.BasicChannel.Id, "", "", 0, 60)
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.SearchChannelMembers(th.BasicChannel.Id, "", "", 0, 60)
+	CheckUnauthorizedStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.SearchChannelMembers(th.BasicChannel.Id, "", "", 0, 60)
+	CheckNoError(t, resp)
+}
+
 func TestGetChannelMembersByIds(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -1610,6 +1982,198 @@ func TestGetChannelStats(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+func TestGetChannelActivity(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+	channel := th.CreatePrivateChannel()
+
+	th.CreatePostWithClient(Client, channel)
+	th.CreatePostWithClient(Client, channel)
+
+	since := model.GetMillis() - 24*60*60*1000
+	activity, resp := Client.GetChannelActivity(channel.Id, model.CHANNEL_ACTIVITY_GRANULARITY_HOUR, "America/Toronto", since, model.GetMillis()+1000)
+	CheckNoError(t, resp)
+	assert.Equal(t, channel.Id, activity.ChannelId)
+	assert.Equal(t, "America/Toronto", activity.TimeZone)
+	assert.True(t, len(activity.Buckets) >= 24)
+
+	var total int64
+	for _, bucket := range activity.Buckets {
+		total += bucket.Count
+	}
+	assert.Equal(t, int64(2), total)
+
+	_, resp = Client.GetChannelActivity(channel.Id, "week", "UTC", since, model.GetMillis())
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.GetChannelActivity(channel.Id, model.CHANNEL_ACTIVITY_GRANULARITY_DAY, "Not/AZone", since, model.GetMillis())
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.GetChannelActivity(channel.Id, model.CHANNEL_ACTIVITY_GRANULARITY_HOUR, "UTC", 0, model.GetMillis())
+	CheckBadRequestStatus(t, resp)
+
+	th.LoginBasic2()
+
+	_, resp = Client.GetChannelActivity(channel.Id, model.CHANNEL_ACTIVITY_GRANULARITY_DAY, "UTC", since, model.GetMillis())
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetChannelActivity(channel.Id, model.CHANNEL_ACTIVITY_GRANULARITY_DAY, "UTC", since, model.GetMillis())
+	CheckNoError(t, resp)
+
+	Client.Logout()
+	_, resp = Client.GetChannelActivity(channel.Id, model.CHANNEL_ACTIVITY_GRANULARITY_DAY, "UTC", since, model.GetMillis())
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestGetChannelMentionCounts(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+	channel := th.BasicChannel
+
+	for _, message := range []string{
+		"hi @" + th.BasicUser2.Username,
+		"@" + th.BasicUser2.Username + " and @" + th.BasicUser.Username,
+		"talking to myself @" + th.BasicUser.Username,
+		"no mentions",
+	} {
+		_, resp := Client.CreatePost(&model.Post{ChannelId: channel.Id, Message: message})
+		CheckNoError(t, resp)
+	}
+
+	since := model.GetMillis() - 60*60*1000
+	until := model.GetMillis() + 1000
+	userIds := []string{th.BasicUser.Id, th.BasicUser2.Id, th.SystemAdminUser.Id}
+
+	counts, resp := th.SystemAdminClient.GetChannelMentionCounts(channel.Id, userIds, since, until)
+	CheckNoError(t, resp)
+	assert.Equal(t, channel.Id, counts.ChannelId)
+	assert.Equal(t, map[string]int64{
+		th.BasicUser.Id:       0,
+		th.BasicUser2.Id:      2,
+		th.SystemAdminUser.Id: 0,
+	}, counts.Counts)
+
+	_, resp = th.SystemAdminClient.GetChannelMentionCounts(channel.Id, []string{}, since, until)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetChannelMentionCounts(channel.Id, userIds, until, since)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.GetChannelMentionCounts(channel.Id, userIds, since, until)
+	CheckForbiddenStatus(t, resp)
+
+	th.MakeUserChannelAdmin(th.BasicUser, channel)
+	th.App.Srv.Store.Channel().ClearCaches()
+
+	_, resp = Client.GetChannelMentionCounts(channel.Id, userIds, since, until)
+	CheckNoError(t, resp)
+
+	Client.Logout()
+	_, resp = Client.GetChannelMentionCounts(channel.Id, userIds, since, until)
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestResolveChannelMentions(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+	channel := th.BasicChannel
+
+	nonMember := th.CreateUser()
+	th.LinkUserToTeam(nonMember, th.BasicTeam)
+
+	otherTeamUser := th.CreateUser()
+
+	mentions := []string{
+		"@" + th.BasicUser2.Username,
+		"@" + strings.ToUpper(nonMember.Username),
+		"@" + otherTeamUser.Username,
+		"@channel",
+		"@all",
+		"@nobody" + model.NewId(),
+	}
+
+	resolution, resp := Client.ResolveChannelMentions(channel.Id, mentions)
+	CheckNoError(t, resp)
+	assert.Equal(t, channel.Id, resolution.ChannelId)
+
+	require.Len(t, resolution.Users, 2)
+	assert.Equal(t, mentions[0], resolution.Users[0].Mention)
+	assert.Equal(t, th.BasicUser2.Id, resolution.Users[0].UserId)
+	assert.True(t, resolution.Users[0].IsChannelMember)
+	assert.Equal(t, mentions[1], resolution.Users[1].Mention)
+	assert.Equal(t, nonMember.Id, resolution.Users[1].UserId)
+	assert.False(t, resolution.Users[1].IsChannelMember)
+
+	assert.Equal(t, []string{"@channel", "@all"}, resolution.Special)
+	assert.Equal(t, []string{mentions[2], mentions[5]}, resolution.Unknown)
+
+	_, resp = Client.ResolveChannelMentions(channel.Id, []string{})
+	CheckBadRequestStatus(t, resp)
+
+	privateChannel, err := th.App.CreateChannel(&model.Channel{
+		TeamId:      th.BasicTeam.Id,
+		DisplayName: "Private",
+		Name:        GenerateTestChannelName(),
+		Type:        model.CHANNEL_PRIVATE,
+	}, false)
+	require.Nil(t, err)
+
+	_, resp = Client.ResolveChannelMentions(privateChannel.Id, mentions)
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.ResolveChannelMentions(channel.Id, mentions)
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestPreviewNotifications(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+	channel := th.BasicChannel
+
+	draft := &model.Post{Message: "hi @" + th.BasicUser2.Username}
+
+	preview, resp := Client.PreviewNotifications(channel.Id, draft)
+	CheckNoError(t, resp)
+	assert.Equal(t, channel.Id, preview.ChannelId)
+	require.Len(t, preview.Recipients, 1)
+	assert.Equal(t, th.BasicUser2.Id, preview.Recipients[0].UserId)
+	assert.True(t, preview.Recipients[0].Mentioned)
+
+	// Nothing is posted.
+	posts, resp := Client.GetPostsForChannel(channel.Id, 0, 60, "")
+	CheckNoError(t, resp)
+	for _, post := range posts.Posts {
+		assert.NotEqual(t, draft.Message, post.Message)
+	}
+
+	// The sender is always the current user.
+	draft.UserId = th.BasicUser2.Id
+	preview, resp = Client.PreviewNotifications(channel.Id, draft)
+	CheckNoError(t, resp)
+	require.Len(t, preview.Recipients, 1)
+	assert.Equal(t, th.BasicUser2.Id, preview.Recipients[0].UserId)
+
+	privateChannel, err := th.App.CreateChannel(&model.Channel{
+		TeamId:      th.BasicTeam.Id,
+		DisplayName: "Private",
+		Name:        GenerateTestChannelName(),
+		Type:        model.CHANNEL_PRIVATE,
+	}, false)
+	require.Nil(t, err)
+
+	_, resp = Client.PreviewNotifications(privateChannel.Id, draft)
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.PreviewNotifications(channel.Id, draft)
+	CheckUnauthorizedStatus(t, resp)
+}
+
 func TestGetPinnedPosts(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -1649,6 +2213,77 @@ func TestGetPinnedPosts(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+func TestUpdateChannelBanner(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+	channel := th.BasicChannel
+
+	post1 := th.CreatePost()
+	post2 := th.CreatePost()
+
+	rchannel, resp := Client.UpdateChannelBanner(channel.Id, post1.Id)
+	CheckNoError(t, resp)
+	assert.Equal(t, post1.Id, rchannel.BannerPostId)
+
+	rchannel, resp = Client.GetChannel(channel.Id, "")
+	CheckNoError(t, resp)
+	assert.Equal(t, post1.Id, rchannel.BannerPostId)
+
+	t.Run("setting a new banner replaces the old one", func(t *testing.T) {
+		rchannel, resp := Client.UpdateChannelBanner(channel.Id, post2.Id)
+		CheckNoError(t, resp)
+		assert.Equal(t, post2.Id, rchannel.BannerPostId)
+	})
+
+	t.Run("post from another channel", func(t *testing.T) {
+		otherPost := th.CreatePostWithClient(Client, th.BasicChannel2)
+		_, resp := Client.UpdateChannelBanner(channel.Id, otherPost.Id)
+		CheckBadRequestStatus(t, resp)
+	})
+
+	t.Run("invalid post id", func(t *testing.T) {
+		_, resp := Client.UpdateChannelBanner(channel.Id, "junk")
+		CheckBadRequestStatus(t, resp)
+	})
+
+	t.Run("deleting the banner post clears the banner", func(t *testing.T) {
+		_, resp := Client.DeletePost(post2.Id)
+		CheckNoError(t, resp)
+
+		rchannel, resp := Client.GetChannel(channel.Id, "")
+		CheckNoError(t, resp)
+		assert.Empty(t, rchannel.BannerPostId)
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		_, resp := Client.UpdateChannelBanner(channel.Id, post1.Id)
+		CheckNoError(t, resp)
+
+		rchannel, resp := Client.UpdateChannelBanner(channel.Id, "")
+		CheckNoError(t, resp)
+		assert.Empty(t, rchannel.BannerPostId)
+	})
+
+	t.Run("without permission", func(t *testing.T) {
+		defaultRolePermissions := th.SaveDefaultRolePermissions()
+		defer th.RestoreDefaultRolePermissions(defaultRolePermissions)
+		th.RemovePermissionFromRole(model.PERMISSION_MANAGE_PUBLIC_CHANNEL_PROPERTIES.Id, model.CHANNEL_USER_ROLE_ID)
+
+		_, resp := Client.UpdateChannelBanner(channel.Id, post1.Id)
+		CheckForbiddenStatus(t, resp)
+
+		_, resp = th.SystemAdminClient.UpdateChannelBanner(channel.Id, post1.Id)
+		CheckNoError(t, resp)
+	})
+
+	t.Run("logged out", func(t *testing.T) {
+		Client.Logout()
+		_, resp := Client.UpdateChannelBanner(channel.Id, post1.Id)
+		CheckUnauthorizedStatus(t, resp)
+	})
+}
+
 func TestUpdateChannelRoles(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -1728,6 +2363,80 @@ func TestUpdateChannelRoles(t *testing.T) {
 	CheckForbiddenStatus(t, resp)
 }
 
+func TestUpdateChannelMembersRolesBulk(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	const CHANNEL_ADMIN = "channel_user channel_admin"
+	const CHANNEL_MEMBER = "channel_user"
+
+	// User 1 creates a channel, making them channel admin by default.
+	channel := th.CreatePublicChannel()
+	th.App.AddUserToChannel(th.BasicUser2, channel)
+
+	nonMember := th.CreateUser()
+	th.LinkUserToTeam(nonMember, th.BasicTeam)
+
+	request := model.ChannelMemberRolesBulkRequest{
+		th.BasicUser2.Id: CHANNEL_ADMIN,
+		nonMember.Id:     CHANNEL_ADMIN,
+	}
+
+	results, resp := Client.UpdateChannelMembersRolesBulk(channel.Id, request)
+	CheckNoError(t, resp)
+	require.Len(t, results, 2)
+
+	for _, result := range results {
+		switch result.UserId {
+		case th.BasicUser2.Id:
+			assert.Nil(t, result.Error)
+			require.NotNil(t, result.Member)
+			assert.Equal(t, CHANNEL_ADMIN, result.Member.Roles)
+		case nonMember.Id:
+			assert.Nil(t, result.Member)
+			require.NotNil(t, result.Error)
+			assert.Equal(t, "api.channel.update_channel_member_roles_bulk.not_member.app_error", result.Error.Id)
+		default:
+			t.Fatal("unexpected user " + result.UserId)
+		}
+	}
+
+	member, resp := Client.GetChannelMember(channel.Id, th.BasicUser2.Id, "")
+	CheckNoError(t, resp)
+	assert.Equal(t, CHANNEL_ADMIN, member.Roles)
+
+	// Invalid roles are reported without affecting the other members
+	results, resp = Client.UpdateChannelMembersRolesBulk(channel.Id, model.ChannelMemberRolesBulkRequest{
+		th.BasicUser.Id:  "junk",
+		th.BasicUser2.Id: CHANNEL_MEMBER,
+	})
+	CheckNoError(t, resp)
+	require.Len(t, results, 2)
+	for _, result := range results {
+		if result.UserId == th.BasicUser.Id {
+			require.NotNil(t, result.Error)
+		} else {
+			assert.Nil(t, result.Error)
+		}
+	}
+
+	member, resp = Client.GetChannelMember(channel.Id, th.BasicUser.Id, "")
+	CheckNoError(t, resp)
+	assert.Equal(t, CHANNEL_ADMIN, member.Roles)
+
+	_, resp = Client.UpdateChannelMembersRolesBulk(channel.Id, model.ChannelMemberRolesBulkRequest{})
+	CheckBadRequestStatus(t, resp)
+
+	// User 2 is no longer a channel admin, so can't update roles
+	th.LoginBasic2()
+	_, resp = Client.UpdateChannelMembersRolesBulk(channel.Id, model.ChannelMemberRolesBulkRequest{th.BasicUser.Id: CHANNEL_MEMBER})
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.UpdateChannelMembersRolesBulk(channel.Id, model.ChannelMemberRolesBulkRequest{th.BasicUser.Id: CHANNEL_MEMBER})
+	CheckNoError(t, resp)
+}
+
 func TestUpdateChannelMemberSchemeRoles(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -2012,6 +2721,185 @@ func TestAddChannelMember(t *testing.T) {
 	Client.Logout()
 }
 
+func TestAddChannelMembersBulk(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	channel := th.CreatePublicChannel()
+
+	teamUser := th.CreateUser()
+	th.LinkUserToTeam(teamUser, th.BasicTeam)
+
+	otherUser := th.CreateUser()
+
+	deactivatedUser := th.CreateUser()
+	th.LinkUserToTeam(deactivatedUser, th.BasicTeam)
+	_, err := th.App.UpdateActive(deactivatedUser, false)
+	require.Nil(t, err)
+
+	_, resp := Client.AddChannelMember(channel.Id, th.BasicUser2.Id)
+	CheckNoError(t, resp)
+
+	t.Run("by user ids", func(t *testing.T) {
+		unknownId := model.NewId()
+		request := &model.ChannelMembersBulkRequest{UserIds: []string{teamUser.Id, otherUser.Id, deactivatedUser.Id, th.BasicUser2.Id, unknownId, teamUser.Id}}
+
+		results, resp := Client.AddChannelMembersBulk(channel.Id, request)
+		CheckNoError(t, resp)
+		require.Len(t, results, 5, "duplicates should be ignored")
+
+		assert.Equal(t, teamUser.Id, results[0].UserId)
+		require.NotNil(t, results[0].Member)
+		assert.Equal(t, channel.Id, results[0].Member.ChannelId)
+		assert.Nil(t, results[0].Error)
+
+		require.NotNil(t, results[1].Error)
+		assert.Equal(t, "api.channel.add_members_bulk.not_team_member.app_error", results[1].Error.Id)
+		require.NotNil(t, results[2].Error)
+		assert.Equal(t, "api.channel.add_members_bulk.user_deactivated.app_error", results[2].Error.Id)
+		require.NotNil(t, results[3].Member, "existing members should be reported")
+		assert.Nil(t, results[3].Error)
+		require.NotNil(t, results[4].Error)
+		assert.Equal(t, "api.channel.add_members_bulk.user_not_found.app_error", results[4].Error.Id)
+
+		_, resp = Client.GetChannelMember(channel.Id, teamUser.Id, "")
+		CheckNoError(t, resp)
+		_, resp = Client.GetChannelMember(channel.Id, otherUser.Id, "")
+		CheckNotFoundStatus(t, resp)
+	})
+
+	t.Run("from a team", func(t *testing.T) {
+		teamChannel := th.CreatePublicChannel()
+
+		results, resp := Client.AddChannelMembersBulk(teamChannel.Id, &model.ChannelMembersBulkRequest{TeamId: th.BasicTeam.Id})
+		CheckNoError(t, resp)
+
+		addedIds := []string{}
+		for _, result := range results {
+			assert.Nil(t, result.Error)
+			addedIds = append(addedIds, result.UserId)
+		}
+		assert.Contains(t, addedIds, teamUser.Id)
+		assert.Contains(t, addedIds, th.BasicUser2.Id)
+		assert.NotContains(t, addedIds, otherUser.Id)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, resp := Client.AddChannelMembersBulk(channel.Id, &model.ChannelMembersBulkRequest{})
+		CheckBadRequestStatus(t, resp)
+
+		_, resp = Client.AddChannelMembersBulk(channel.Id, &model.ChannelMembersBulkRequest{GroupId: model.NewId()})
+		CheckNotImplementedStatus(t, resp)
+
+		dm, resp := Client.CreateDirectChannel(th.BasicUser.Id, th.BasicUser2.Id)
+		CheckNoError(t, resp)
+		_, resp = Client.AddChannelMembersBulk(dm.Id, &model.ChannelMembersBulkRequest{UserIds: []string{teamUser.Id}})
+		CheckBadRequestStatus(t, resp)
+	})
+
+	t.Run("permissions", func(t *testing.T) {
+		privateChannel, err := th.App.CreateChannel(&model.Channel{
+			TeamId:      th.BasicTeam.Id,
+			DisplayName: "Private",
+			Name:        GenerateTestChannelName(),
+			Type:        model.CHANNEL_PRIVATE,
+		}, false)
+		require.Nil(t, err)
+
+		_, resp := Client.AddChannelMembersBulk(privateChannel.Id, &model.ChannelMembersBulkRequest{UserIds: []string{teamUser.Id}})
+		CheckForbiddenStatus(t, resp)
+
+		otherTeam := th.CreateTeamWithClient(th.SystemAdminClient)
+		_, resp = Client.AddChannelMembersBulk(channel.Id, &model.ChannelMembersBulkRequest{TeamId: otherTeam.Id})
+		CheckForbiddenStatus(t, resp)
+
+		Client.Logout()
+		_, resp = Client.AddChannelMembersBulk(channel.Id, &model.ChannelMembersBulkRequest{UserIds: []string{teamUser.Id}})
+		CheckUnauthorizedStatus(t, resp)
+	})
+}
+
+func TestCopyChannelMembers(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	teamUser := th.CreateUser()
+	th.LinkUserToTeam(teamUser, th.BasicTeam)
+
+	source := th.CreatePublicChannel()
+	_, resp := Client.AddChannelMember(source.Id, th.BasicUser2.Id)
+	CheckNoError(t, resp)
+	_, resp = Client.AddChannelMember(source.Id, teamUser.Id)
+	CheckNoError(t, resp)
+	_, err := th.App.UpdateChannelMemberSchemeRoles(source.Id, th.BasicUser2.Id, true, true)
+	require.Nil(t, err)
+
+	t.Run("without roles", func(t *testing.T) {
+		target := th.CreatePublicChannel()
+
+		results, resp := Client.CopyChannelMembers(target.Id, &model.ChannelMembersCopyRequest{SourceChannelId: source.Id})
+		CheckNoError(t, resp)
+		require.Len(t, results, 3)
+		for _, result := range results {
+			assert.Nil(t, result.Error)
+		}
+
+		member, resp := Client.GetChannelMember(target.Id, th.BasicUser2.Id, "")
+		CheckNoError(t, resp)
+		assert.False(t, member.SchemeAdmin)
+		_, resp = Client.GetChannelMember(target.Id, teamUser.Id, "")
+		CheckNoError(t, resp)
+	})
+
+	t.Run("with roles", func(t *testing.T) {
+		target := th.CreatePublicChannel()
+
+		_, resp := Client.CopyChannelMembers(target.Id, &model.ChannelMembersCopyRequest{SourceChannelId: source.Id, PreserveRoles: true})
+		CheckNoError(t, resp)
+
+		member, resp := Client.GetChannelMember(target.Id, th.BasicUser2.Id, "")
+		CheckNoError(t, resp)
+		assert.True(t, member.SchemeAdmin)
+		member, resp = Client.GetChannelMember(target.Id, teamUser.Id, "")
+		CheckNoError(t, resp)
+		assert.False(t, member.SchemeAdmin)
+
+		// Only channel admins of the target can hand out the role.
+		_, resp = Client.CopyChannelMembers(th.BasicChannel.Id, &model.ChannelMembersCopyRequest{SourceChannelId: source.Id, PreserveRoles: true})
+		CheckForbiddenStatus(t, resp)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, resp := Client.CopyChannelMembers(source.Id, &model.ChannelMembersCopyRequest{SourceChannelId: source.Id})
+		CheckBadRequestStatus(t, resp)
+
+		_, resp = Client.CopyChannelMembers(source.Id, &model.ChannelMembersCopyRequest{SourceChannelId: "junk"})
+		CheckBadRequestStatus(t, resp)
+	})
+
+	t.Run("permissions", func(t *testing.T) {
+		privateChannel, err := th.App.CreateChannel(&model.Channel{
+			TeamId:      th.BasicTeam.Id,
+			DisplayName: "Private",
+			Name:        GenerateTestChannelName(),
+			Type:        model.CHANNEL_PRIVATE,
+		}, false)
+		require.Nil(t, err)
+
+		_, resp := Client.CopyChannelMembers(source.Id, &model.ChannelMembersCopyRequest{SourceChannelId: privateChannel.Id})
+		CheckForbiddenStatus(t, resp)
+
+		_, resp = Client.CopyChannelMembers(privateChannel.Id, &model.ChannelMembersCopyRequest{SourceChannelId: source.Id})
+		CheckForbiddenStatus(t, resp)
+
+		Client.Logout()
+		_, resp = Client.CopyChannelMembers(source.Id, &model.ChannelMembersCopyRequest{SourceChannelId: th.BasicChannel.Id})
+		CheckUnauthorizedStatus(t, resp)
+	})
+}
+
 func TestRemoveChannelMember(t *testing.T) {
 	th := Setup().InitBasic()
 	user1 := th.BasicUser
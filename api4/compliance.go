@@ -49,7 +49,12 @@ func getComplianceReports(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	crs, err := c.App.GetComplianceReports(c.Params.Page, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	crs, err := c.App.GetComplianceReports(pagination.Page, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
@@ -3,10 +3,12 @@ package api4
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/mattermost/mattermost-server/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -91,6 +93,28 @@ func TestCORSRequestHandling(t *testing.T) {
 			"",
 			"",
 		},
+		"CORSEnabledWildcard": {
+			"https://*.mattermost.com",
+			"",
+			false,
+			func(req *http.Request) {
+				req.Header.Set("Origin", "https://pre-release.mattermost.com")
+			},
+			"https://pre-release.mattermost.com",
+			"",
+			"",
+		},
+		"CORSEnabledWildcardNotMatching": {
+			"https://*.mattermost.com",
+			"",
+			false,
+			func(req *http.Request) {
+				req.Header.Set("Origin", "https://mattermost.com.evil.com")
+			},
+			"",
+			"",
+			"",
+		},
 		"CORSEnabledWithCredentials": {
 			"http://mattermost.com",
 			"",
@@ -102,6 +126,39 @@ func TestCORSRequestHandling(t *testing.T) {
 			"",
 			"true",
 		},
+		"CORSEnabledWildcardWithCredentials": {
+			"https://*.mattermost.com",
+			"",
+			true,
+			func(req *http.Request) {
+				req.Header.Set("Origin", "https://pre-release.mattermost.com")
+			},
+			"https://pre-release.mattermost.com",
+			"",
+			"",
+		},
+		"CORSEnabledStarOriginWithCredentials": {
+			"*",
+			"",
+			true,
+			func(req *http.Request) {
+				req.Header.Set("Origin", "http://pre-release.mattermost.com")
+			},
+			"*",
+			"",
+			"",
+		},
+		"CORSEnabledStarOriginAndExactWithCredentials": {
+			"* http://mattermost.com",
+			"",
+			true,
+			func(req *http.Request) {
+				req.Header.Set("Origin", "http://mattermost.com")
+			},
+			"http://mattermost.com",
+			"",
+			"true",
+		},
 		"CORSEnabledWithHeaders": {
 			"http://mattermost.com",
 			"x-my-special-header x-blueberry",
@@ -116,9 +173,9 @@ func TestCORSRequestHandling(t *testing.T) {
 	} {
 		t.Run(name, func(t *testing.T) {
 			th := SetupConfig(func(cfg *model.Config) {
-				*cfg.ServiceSettings.AllowCorsFrom = testcase.AllowCorsFrom
-				*cfg.ServiceSettings.CorsExposedHeaders = testcase.CorsExposedHeaders
-				*cfg.ServiceSettings.CorsAllowCredentials = testcase.CorsAllowCredentials
+				cfg.CorsSettings.AllowedOrigins = strings.Fields(testcase.AllowCorsFrom)
+				cfg.CorsSettings.ExposedHeaders = strings.Fields(testcase.CorsExposedHeaders)
+				*cfg.CorsSettings.AllowCredentials = testcase.CorsAllowCredentials
 			})
 			defer th.TearDown()
 
@@ -146,5 +203,90 @@ func TestCORSRequestHandling(t *testing.T) {
 			assert.Equal(t, "", resp.Header.Get(acAllowHeaders))
 		})
 	}
+}
+
+func TestCORSPreflightRequestHandling(t *testing.T) {
+	th := SetupConfig(func(cfg *model.Config) {
+		cfg.CorsSettings.AllowedOrigins = []string{"http://mattermost.com"}
+		cfg.CorsSettings.AllowedMethods = []string{"GET", "POST"}
+		*cfg.CorsSettings.MaxAgeSeconds = 600
+	})
+	defer th.TearDown()
+
+	host := fmt.Sprintf("http://localhost:%v", th.App.Srv.ListenAddr.Port)
+
+	preflight := func(t *testing.T, path, origin, method string) *http.Response {
+		req, err := http.NewRequest("OPTIONS", host+path, nil)
+		require.Nil(t, err)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", method)
+		req.Header.Set("Access-Control-Request-Headers", "X-Requested-With")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	t.Run("allowed origin and method", func(t *testing.T) {
+		resp := preflight(t, "/api/v4/users/login", "http://mattermost.com", "POST")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "http://mattermost.com", resp.Header.Get(acAllowOrigin))
+		assert.Equal(t, "GET, POST", resp.Header.Get(acAllowMethods))
+		assert.Equal(t, "X-Requested-With", resp.Header.Get(acAllowHeaders))
+		assert.Equal(t, "600", resp.Header.Get(acMaxAge))
+	})
+
+	t.Run("disallowed origin", func(t *testing.T) {
+		resp := preflight(t, "/api/v4/users/login", "http://evil.com", "POST")
+		assert.Equal(t, "", resp.Header.Get(acAllowOrigin))
+		assert.Equal(t, "", resp.Header.Get(acAllowMethods))
+	})
+
+	t.Run("disallowed header", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			cfg.CorsSettings.AllowedHeaders = []string{"X-Requested-With", "Content-Type"}
+		})
+		defer th.App.UpdateConfig(func(cfg *model.Config) {
+			cfg.CorsSettings.AllowedHeaders = []string{"*"}
+		})
+
+		resp := preflight(t, "/api/v4/users/login", "http://mattermost.com", "POST")
+		assert.Equal(t, "http://mattermost.com", resp.Header.Get(acAllowOrigin))
+
+		req, err := http.NewRequest("OPTIONS", host+"/api/v4/users/login", nil)
+		require.Nil(t, err)
+		req.Header.Set("Origin", "http://mattermost.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		req.Header.Set("Access-Control-Request-Headers", "X-Requested-With, X-Other")
+
+		resp, err = http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		resp.Body.Close()
+		assert.Equal(t, "", resp.Header.Get(acAllowOrigin))
+		assert.Equal(t, "", resp.Header.Get(acAllowHeaders))
+	})
+
+	t.Run("disallowed method", func(t *testing.T) {
+		resp := preflight(t, "/api/v4/users/me", "http://mattermost.com", "DELETE")
+		assert.Equal(t, "", resp.Header.Get(acAllowOrigin))
+		assert.Equal(t, "", resp.Header.Get(acAllowMethods))
+	})
+
+	t.Run("not part of the api", func(t *testing.T) {
+		resp := preflight(t, "/static/manifest.json", "http://mattermost.com", "GET")
+		assert.Equal(t, "", resp.Header.Get(acAllowOrigin))
+	})
+
+	t.Run("policy follows config changes", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			cfg.CorsSettings.AllowedOrigins = []string{"http://evil.com"}
+		})
+
+		resp := preflight(t, "/api/v4/users/login", "http://evil.com", "POST")
+		assert.Equal(t, "http://evil.com", resp.Header.Get(acAllowOrigin))
 
+		resp = preflight(t, "/api/v4/users/login", "http://mattermost.com", "POST")
+		assert.Equal(t, "", resp.Header.Get(acAllowOrigin))
+	})
 }
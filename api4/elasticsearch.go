@@ -12,6 +12,7 @@ import (
 func (api *API) InitElasticsearch() {
 	api.BaseRoutes.Elasticsearch.Handle("/test", api.ApiSessionRequired(testElasticsearch)).Methods("POST")
 	api.BaseRoutes.Elasticsearch.Handle("/purge_indexes", api.ApiSessionRequired(purgeElasticsearchIndexes)).Methods("POST")
+	api.BaseRoutes.Elasticsearch.Handle("/reindex", api.ApiSystemAdminRequired(reindexElasticsearch)).Methods("POST")
 }
 
 func testElasticsearch(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -46,3 +47,30 @@ func purgeElasticsearchIndexes(c *Context, w http.ResponseWriter, r *http.Reques
 
 	ReturnStatusOK(w)
 }
+
+func reindexElasticsearch(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.MapFromJson(r.Body)
+	teamId := props["team_id"]
+	channelId := props["channel_id"]
+
+	if teamId != "" && !model.IsValidId(teamId) {
+		c.SetInvalidParam("team_id")
+		return
+	}
+
+	if channelId != "" && !model.IsValidId(channelId) {
+		c.SetInvalidParam("channel_id")
+		return
+	}
+
+	job, err := c.App.CreateElasticsearchReindexJob(teamId, channelId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("team_id=" + teamId + " channel_id=" + channelId + " job_id=" + job.Id)
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(job.ToJson()))
+}
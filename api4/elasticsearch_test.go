@@ -28,3 +28,20 @@ func TestElasticsearchPurgeIndexes(t *testing.T) {
 	_, resp = th.SystemAdminClient.PurgeElasticsearchIndexes()
 	CheckNotImplementedStatus(t, resp)
 }
+
+func TestElasticsearchReindex(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	_, resp := th.Client.ReindexElasticsearch(th.BasicTeam.Id, "")
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.ReindexElasticsearch("junk", "")
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.ReindexElasticsearch("", "junk")
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.ReindexElasticsearch(th.BasicTeam.Id, "")
+	CheckNotImplementedStatus(t, resp)
+}
@@ -19,10 +19,11 @@ const (
 )
 
 func (api *API) InitEmoji() {
-	api.BaseRoutes.Emojis.Handle("", api.ApiSessionRequired(createEmoji)).Methods("POST")
-	api.BaseRoutes.Emojis.Handle("", api.ApiSessionRequired(getEmojiList)).Methods("GET")
+	api.BaseRoutes.Emojis.Handle("", api.ApiSessionRequiredUpload(0, createEmoji)).Methods("POST")
+	api.BaseRoutes.Emojis.Handle("", api.ApiSessionRequiredWithResponseCache(model.RESPONSE_CACHE_ENDPOINT_EMOJI_LIST, getEmojiList)).Methods("GET")
 	api.BaseRoutes.Emojis.Handle("/search", api.ApiSessionRequired(searchEmojis)).Methods("POST")
 	api.BaseRoutes.Emojis.Handle("/autocomplete", api.ApiSessionRequired(autocompleteEmojis)).Methods("GET")
+	api.BaseRoutes.Emojis.Handle("/autocomplete/ranked", api.ApiSessionRequired(autocompleteEmojisRanked)).Methods("GET")
 	api.BaseRoutes.Emoji.Handle("", api.ApiSessionRequired(deleteEmoji)).Methods("DELETE")
 	api.BaseRoutes.Emoji.Handle("", api.ApiSessionRequired(getEmoji)).Methods("GET")
 	api.BaseRoutes.EmojiByName.Handle("", api.ApiSessionRequired(getEmojiByName)).Methods("GET")
@@ -109,7 +110,12 @@ func getEmojiList(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	listEmoji, err := c.App.GetEmojiList(c.Params.Page, c.Params.PerPage, sort)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	listEmoji, err := c.App.GetEmojiList(pagination.Page, pagination.PerPage, sort)
 	if err != nil {
 		c.Err = err
 		return
@@ -277,3 +283,20 @@ func autocompleteEmojis(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	w.Write([]byte(model.EmojiListToJson(emojis)))
 }
+
+func autocompleteEmojisRanked(c *Context, w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+
+	if name == "" {
+		c.SetInvalidUrlParam("name")
+		return
+	}
+
+	suggestions, err := c.App.AutocompleteEmoji(c.App.Session.UserId, name, EMOJI_MAX_AUTOCOMPLETE_ITEMS)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(model.EmojiSuggestionListToJson(suggestions)))
+}
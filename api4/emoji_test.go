@@ -14,6 +14,7 @@ import (
 	"github.com/mattermost/mattermost-server/utils"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCreateEmoji(t *testing.T) {
@@ -731,3 +732,58 @@ func TestAutocompleteEmoji(t *testing.T) {
 	_, resp = Client.AutocompleteEmoji(searchTerm1, "")
 	CheckUnauthorizedStatus(t, resp)
 }
+
+func TestAutocompleteEmojiRanked(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableCustomEmoji = true })
+
+	emoji, resp := Client.CreateEmoji(&model.Emoji{
+		CreatorId: th.BasicUser.Id,
+		Name:      "tada" + model.NewId(),
+	}, utils.CreateTestGif(t, 10, 10), "image.gif")
+	CheckNoError(t, resp)
+
+	suggestions, resp := Client.AutocompleteEmojiRanked("tada")
+	CheckNoError(t, resp)
+	require.Len(t, suggestions, 2)
+	assert.Equal(t, "tada", suggestions[0].Name)
+	assert.Equal(t, model.SystemEmojis["tada"], suggestions[0].Unicode)
+	assert.Equal(t, emoji.Name, suggestions[1].Name)
+	assert.Equal(t, emoji.Id, suggestions[1].EmojiId)
+
+	require.Nil(t, th.App.RecordEmojiUsage(th.BasicUser.Id, []string{emoji.Name, "notanemoji"}))
+
+	suggestions, resp = Client.AutocompleteEmojiRanked("tada")
+	CheckNoError(t, resp)
+	require.Len(t, suggestions, 2)
+	assert.Equal(t, emoji.Name, suggestions[0].Name)
+	assert.Equal(t, "tada", suggestions[1].Name)
+
+	usage, err := th.App.GetEmojiUsage(th.BasicUser.Id)
+	require.Nil(t, err)
+	require.Len(t, usage, 1)
+	assert.Equal(t, emoji.Name, usage[0].Name)
+
+	// Other users have their own history.
+	suggestions, resp = th.SystemAdminClient.AutocompleteEmojiRanked("tada")
+	CheckNoError(t, resp)
+	require.Len(t, suggestions, 2)
+	assert.Equal(t, "tada", suggestions[0].Name)
+
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableCustomEmoji = false })
+
+	suggestions, resp = Client.AutocompleteEmojiRanked("tada")
+	CheckNoError(t, resp)
+	require.Len(t, suggestions, 1)
+	assert.Equal(t, "tada", suggestions[0].Name)
+
+	_, resp = Client.AutocompleteEmojiRanked("")
+	CheckBadRequestStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.AutocompleteEmojiRanked("tada")
+	CheckUnauthorizedStatus(t, resp)
+}
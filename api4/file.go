@@ -19,6 +19,7 @@ import (
 	"github.com/mattermost/mattermost-server/app"
 	"github.com/mattermost/mattermost-server/model"
 	"github.com/mattermost/mattermost-server/utils"
+	"github.com/mattermost/mattermost-server/web"
 )
 
 const (
@@ -37,30 +38,22 @@ var UNSAFE_CONTENT_TYPES = [...]string{
 	"text/html",
 }
 
-var MEDIA_CONTENT_TYPES = [...]string{
-	"image/jpeg",
-	"image/png",
-	"image/bmp",
-	"image/gif",
-	"video/avi",
-	"video/mpeg",
-	"video/mp4",
-	"audio/mpeg",
-	"audio/wav",
-}
-
 const maxUploadDrainBytes = (10 * 1024 * 1024) // 10Mb
 const maxMultipartFormDataBytes = 10 * 1024    // 10Kb
 
 func (api *API) InitFile() {
-	api.BaseRoutes.Files.Handle("", api.ApiSessionRequired(uploadFileStream)).Methods("POST")
+	api.BaseRoutes.Files.Handle("", api.ApiSessionRequiredUpload(0, uploadFileStream)).Methods("POST")
 	api.BaseRoutes.File.Handle("", api.ApiSessionRequiredTrustRequester(getFile)).Methods("GET")
 	api.BaseRoutes.File.Handle("/thumbnail", api.ApiSessionRequiredTrustRequester(getFileThumbnail)).Methods("GET")
 	api.BaseRoutes.File.Handle("/link", api.ApiSessionRequired(getFileLink)).Methods("GET")
 	api.BaseRoutes.File.Handle("/preview", api.ApiSessionRequiredTrustRequester(getFilePreview)).Methods("GET")
 	api.BaseRoutes.File.Handle("/info", api.ApiSessionRequired(getFileInfo)).Methods("GET")
+	api.BaseRoutes.File.Handle("/integrity", api.ApiSystemAdminRequired(verifyFileIntegrity)).Methods("GET")
+
+	api.BaseRoutes.Team.Handle("/files/search", api.ApiSessionRequiredSearch(searchFiles)).Methods("POST")
 
 	api.BaseRoutes.PublicFile.Handle("", api.ApiHandler(getPublicFile)).Methods("GET")
+	api.BaseRoutes.DownloadFile.Handle("", api.ApiHandlerTrustRequester(getFileWithDownloadToken)).Methods("GET")
 
 }
 
@@ -537,7 +530,53 @@ func getFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fileReader, err := c.App.FileReader(info.Path)
+	writeFileInfoResponse(c, info, forceDownload, w, r)
+}
+
+// getFileWithDownloadToken serves a file without a session to whoever has a download token for it, such as from a link
+// in an email. The user's permissions were checked when the token was created.
+func getFileWithDownloadToken(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireFileId()
+	if c.Err != nil {
+		return
+	}
+
+	forceDownload, convErr := strconv.ParseBool(r.URL.Query().Get("download"))
+	if convErr != nil {
+		forceDownload = false
+	}
+
+	userId, err := c.App.VerifyFileDownloadToken(c.Params.FileId, r.URL.Query().Get("t"))
+	if err != nil {
+		c.Err = err
+		utils.RenderWebAppError(c.App.Config(), w, r, c.Err, c.App.AsymmetricSigningKey())
+		return
+	}
+
+	if user, err := c.App.GetUser(userId); err != nil || user.DeleteAt != 0 {
+		c.Err = model.NewAppError("getFileWithDownloadToken", "app.file.download_token.invalid.app_error", nil, "user_id="+userId, http.StatusForbidden)
+		utils.RenderWebAppError(c.App.Config(), w, r, c.Err, c.App.AsymmetricSigningKey())
+		return
+	}
+
+	info, err := c.App.GetFileInfo(c.Params.FileId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	writeFileInfoResponse(c, info, forceDownload, w, r)
+}
+
+// writeFileInfoResponse serves the contents of a file once the request has been allowed to read it.
+func writeFileInfoResponse(c *Context, info *model.FileInfo, forceDownload bool, w http.ResponseWriter, r *http.Request) {
+	if c.App.ShouldVerifyChecksumOnDownload(info) {
+		if c.Err = checkFileIntegrity(c, info); c.Err != nil {
+			return
+		}
+	}
+
+	fileReader, err := c.App.FileReaderForInfo(info, info.Path)
 	if err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
@@ -545,7 +584,7 @@ func getFile(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 	defer fileReader.Close()
 
-	err = writeFileResponse(info.Name, info.MimeType, info.Size, fileReader, forceDownload, w, r)
+	err = writeFileResponse(info.Name, info.MimeType, info.Size, fileReader, forceDownload, &c.App.Config().FileSettings, w, r)
 	if err != nil {
 		c.Err = err
 		return
@@ -579,7 +618,7 @@ func getFileThumbnail(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fileReader, err := c.App.FileReader(info.ThumbnailPath)
+	fileReader, err := c.App.FileReaderForInfo(info, info.ThumbnailPath)
 	if err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
@@ -587,7 +626,7 @@ func getFileThumbnail(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 	defer fileReader.Close()
 
-	err = writeFileResponse(info.Name, THUMBNAIL_IMAGE_TYPE, 0, fileReader, forceDownload, w, r)
+	err = writeFileResponse(info.Name, THUMBNAIL_IMAGE_TYPE, 0, fileReader, forceDownload, &c.App.Config().FileSettings, w, r)
 	if err != nil {
 		c.Err = err
 		return
@@ -654,7 +693,7 @@ func getFilePreview(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fileReader, err := c.App.FileReader(info.PreviewPath)
+	fileReader, err := c.App.FileReaderForInfo(info, info.PreviewPath)
 	if err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
@@ -662,7 +701,7 @@ func getFilePreview(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 	defer fileReader.Close()
 
-	err = writeFileResponse(info.Name, PREVIEW_IMAGE_TYPE, 0, fileReader, forceDownload, w, r)
+	err = writeFileResponse(info.Name, PREVIEW_IMAGE_TYPE, 0, fileReader, forceDownload, &c.App.Config().FileSettings, w, r)
 	if err != nil {
 		c.Err = err
 		return
@@ -690,6 +729,42 @@ func getFileInfo(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(info.ToJson()))
 }
 
+func verifyFileIntegrity(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireFileId()
+	if c.Err != nil {
+		return
+	}
+
+	info, err := c.App.GetFileInfo(c.Params.FileId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	result, err := c.App.VerifyFileIntegrity(info)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(result.ToJson()))
+}
+
+// checkFileIntegrity returns an error if the file doesn't match its checksum, so that a corrupted file isn't sent. A
+// file that's missing is left for reading it to fail.
+func checkFileIntegrity(c *Context, info *model.FileInfo) *model.AppError {
+	result, err := c.App.VerifyFileIntegrity(info)
+	if err != nil {
+		return err
+	}
+
+	if result.Status == model.FILE_INTEGRITY_STATUS_MISMATCH {
+		return model.NewAppError("checkFileIntegrity", "api.file.get_file.checksum_mismatch.app_error", nil, "file_id="+info.Id, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
 func getPublicFile(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.RequireFileId()
 	if c.Err != nil {
@@ -721,21 +796,29 @@ func getPublicFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fileReader, err := c.App.FileReader(info.Path)
+	if c.App.ShouldVerifyChecksumOnDownload(info) {
+		if c.Err = checkFileIntegrity(c, info); c.Err != nil {
+			return
+		}
+	}
+
+	fileReader, err := c.App.FileReaderForInfo(info, info.Path)
 	if err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
 	}
 	defer fileReader.Close()
 
-	err = writeFileResponse(info.Name, info.MimeType, info.Size, fileReader, false, w, r)
+	err = writeFileResponse(info.Name, info.MimeType, info.Size, fileReader, false, &c.App.Config().FileSettings, w, r)
 	if err != nil {
 		c.Err = err
 		return
 	}
 }
 
-func writeFileResponse(filename string, contentType string, contentSize int64, fileReader io.Reader, forceDownload bool, w http.ResponseWriter, r *http.Request) *model.AppError {
+// writeFileResponse serves a file, which is only displayed inline by browsers if its type is listed in
+// FileSettings.InlineContentTypes. Anything else is served as an attachment so that it can't run scripts on the site.
+func writeFileResponse(filename string, contentType string, contentSize int64, fileReader io.Reader, forceDownload bool, fileSettings *model.FileSettings, w http.ResponseWriter, r *http.Request) *model.AppError {
 	w.Header().Set("Cache-Control", "max-age=2592000, private")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 
@@ -747,7 +830,7 @@ func writeFileResponse(filename string, contentType string, contentSize int64, f
 		contentType = "application/octet-stream"
 	} else {
 		for _, unsafeContentType := range UNSAFE_CONTENT_TYPES {
-			if strings.HasPrefix(contentType, unsafeContentType) {
+			if strings.HasPrefix(strings.ToLower(contentType), unsafeContentType) {
 				contentType = "text/plain"
 				break
 			}
@@ -756,21 +839,7 @@ func writeFileResponse(filename string, contentType string, contentSize int64, f
 
 	w.Header().Set("Content-Type", contentType)
 
-	var toDownload bool
-	if forceDownload {
-		toDownload = true
-	} else {
-		isMediaType := false
-
-		for _, mediaContentType := range MEDIA_CONTENT_TYPES {
-			if strings.HasPrefix(contentType, mediaContentType) {
-				isMediaType = true
-				break
-			}
-		}
-
-		toDownload = !isMediaType
-	}
+	toDownload := forceDownload || !fileSettings.IsInlineContentType(contentType)
 
 	filename = url.PathEscape(filename)
 
@@ -788,3 +857,65 @@ func writeFileResponse(filename string, contentType string, contentSize int64, f
 
 	return nil
 }
+
+func searchFiles(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_VIEW_TEAM) {
+		c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
+		return
+	}
+
+	params := model.SearchParameterFromJson(r.Body)
+	if params == nil || params.Terms == nil || len(*params.Terms) == 0 {
+		c.SetInvalidParam("terms")
+		return
+	}
+
+	timeZoneOffset := 0
+	if params.TimeZoneOffset != nil {
+		timeZoneOffset = *params.TimeZoneOffset
+	}
+
+	isOrSearch := false
+	if params.IsOrSearch != nil {
+		isOrSearch = *params.IsOrSearch
+	}
+
+	page := web.PAGE_DEFAULT
+	if params.Page != nil {
+		page = *params.Page
+	}
+
+	perPage := web.PER_PAGE_DEFAULT
+	if params.PerPage != nil {
+		perPage = *params.PerPage
+	}
+
+	if perPage == 0 {
+		c.SetInvalidParam("per_page")
+		return
+	}
+
+	pagination := c.NewPagination(page, perPage)
+	if c.Err != nil {
+		return
+	}
+
+	includeDeletedChannels := false
+	if params.IncludeDeletedChannels != nil {
+		includeDeletedChannels = *params.IncludeDeletedChannels
+	}
+
+	infos, err := c.App.SearchFilesInTeam(*params.Terms, c.App.Session.UserId, c.Params.TeamId, isOrSearch, includeDeletedChannels, timeZoneOffset, pagination.Page, pagination.PerPage)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Write([]byte(model.FileInfosToJson(infos)))
+}
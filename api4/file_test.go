@@ -23,6 +23,9 @@ import (
 	"github.com/mattermost/mattermost-server/store"
 	"github.com/mattermost/mattermost-server/utils/fileutils"
 	"github.com/mattermost/mattermost-server/utils/testutils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var testDir = ""
@@ -854,7 +857,8 @@ func TestGetFileHeaders(t *testing.T) {
 	t.Run("gif", testHeaders(data, "test.gif", "image/gif", true))
 	t.Run("mp4", testHeaders(data, "test.mp4", "video/mp4", true))
 	t.Run("mp3", testHeaders(data, "test.mp3", "audio/mpeg", true))
-	t.Run("pdf", testHeaders(data, "test.pdf", "application/pdf", false))
+	t.Run("pdf", testHeaders(data, "test.pdf", "application/pdf", true))
+	t.Run("svg", testHeaders(data, "test.svg", "image/svg+xml", false))
 	t.Run("txt", testHeaders(data, "test.txt", "text/plain", false))
 	t.Run("html", testHeaders(data, "test.html", "text/plain", false))
 	t.Run("js", testHeaders(data, "test.js", "text/plain", false))
@@ -864,6 +868,14 @@ func TestGetFileHeaders(t *testing.T) {
 	//t.Run("exe", testHeaders(data, "test.exe", "application/x-ms", false))
 	t.Run("no extension", testHeaders(data, "test", "application/octet-stream", false))
 	t.Run("no extension 2", testHeaders([]byte("<html></html>"), "test", "application/octet-stream", false))
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		cfg.FileSettings.InlineContentTypes = []string{"text/plain"}
+	})
+
+	t.Run("txt allowed inline", testHeaders(data, "test.txt", "text/plain", true))
+	t.Run("png not allowed inline", testHeaders(data, "test.png", "image/png", false))
+	t.Run("html served inline as plain text", testHeaders(data, "test.html", "text/plain", true))
 }
 
 func TestGetFileThumbnail(t *testing.T) {
@@ -1184,3 +1196,166 @@ func TestGetPublicFile(t *testing.T) {
 
 	th.cleanupTestFile(info)
 }
+
+func TestGetFileWithDownloadToken(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	if *th.App.Config().FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	sent, err := testutils.ReadTestFile("test.png")
+	require.Nil(t, err)
+
+	fileResp, resp := Client.UploadFile(sent, th.BasicChannel.Id, "test.png")
+	CheckNoError(t, resp)
+	info := fileResp.FileInfos[0]
+	defer th.cleanupTestFile(info)
+
+	store.Must(th.App.Srv.Store.FileInfo().AttachToPost(info.Id, th.BasicPost.Id, th.BasicUser.Id))
+
+	link, appErr := th.App.GenerateFileDownloadLink(Client.Url, info, th.BasicUser2.Id)
+	require.Nil(t, appErr)
+
+	// No session is needed to download the file
+	httpResp, err := http.Get(link)
+	require.Nil(t, err)
+	data, err := ioutil.ReadAll(httpResp.Body)
+	httpResp.Body.Close()
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+	assert.Equal(t, sent, data)
+
+	for _, badLink := range []string{
+		link[:strings.LastIndex(link, "?")],
+		link + "x",
+		strings.Replace(link, info.Id, th.BasicPost.Id, 1),
+	} {
+		httpResp, err := http.Get(badLink)
+		require.Nil(t, err)
+		httpResp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, httpResp.StatusCode, badLink)
+	}
+
+	_, appErr = th.App.UpdateActive(th.BasicUser2, false)
+	require.Nil(t, appErr)
+
+	httpResp, err = http.Get(link)
+	require.Nil(t, err)
+	httpResp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, httpResp.StatusCode)
+}
+
+func TestVerifyFileIntegrity(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	if *th.App.Config().FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	fileResp, resp := Client.UploadFile([]byte("contents"), th.BasicChannel.Id, "test.txt")
+	CheckNoError(t, resp)
+	fileId := fileResp.FileInfos[0].Id
+
+	info, err := th.App.GetFileInfo(fileId)
+	require.Nil(t, err)
+	defer th.cleanupTestFile(info)
+
+	_, resp = Client.VerifyFileIntegrity(fileId)
+	CheckForbiddenStatus(t, resp)
+
+	result, resp := th.SystemAdminClient.VerifyFileIntegrity(fileId)
+	CheckNoError(t, resp)
+	assert.Equal(t, model.FILE_INTEGRITY_STATUS_OK, result.Status)
+
+	_, resp = th.SystemAdminClient.VerifyFileIntegrity(model.NewId())
+	CheckNotFoundStatus(t, resp)
+
+	_, err = th.App.WriteFile(bytes.NewReader([]byte("corrupted")), info.Path)
+	require.Nil(t, err)
+
+	result, resp = th.SystemAdminClient.VerifyFileIntegrity(fileId)
+	CheckNoError(t, resp)
+	assert.Equal(t, model.FILE_INTEGRITY_STATUS_MISMATCH, result.Status)
+	assert.Equal(t, info.Checksum, result.ExpectedChecksum)
+	assert.NotEqual(t, info.Checksum, result.ActualChecksum)
+
+	t.Run("corrupted files are only refused when their type is verified", func(t *testing.T) {
+		_, resp := Client.GetFile(fileId)
+		CheckNoError(t, resp)
+
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.FileSettings.VerifyChecksumFileTypes = []string{".txt"} })
+		defer th.App.UpdateConfig(func(cfg *model.Config) { cfg.FileSettings.VerifyChecksumFileTypes = []string{} })
+
+		_, resp = Client.GetFile(fileId)
+		CheckInternalErrorStatus(t, resp)
+	})
+}
+
+func TestSearchFiles(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	if *th.App.Config().FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	data, err := testutils.ReadTestFile("test.png")
+	require.Nil(t, err)
+
+	upload := func(channel *model.Channel, name string) *model.FileInfo {
+		fileResp, resp := Client.UploadFile(data, channel.Id, name)
+		CheckNoError(t, resp)
+
+		info := fileResp.FileInfos[0]
+		_, resp = Client.CreatePost(&model.Post{ChannelId: channel.Id, Message: "file", FileIds: []string{info.Id}})
+		CheckNoError(t, resp)
+
+		return info
+	}
+
+	search := func(terms string, page, perPage int) []string {
+		infos, resp := Client.SearchFiles(th.BasicTeam.Id, &model.SearchParameter{
+			Terms:   model.NewString(terms),
+			Page:    model.NewInt(page),
+			PerPage: model.NewInt(perPage),
+		})
+		CheckNoError(t, resp)
+
+		ids := []string{}
+		for _, info := range infos {
+			ids = append(ids, info.Id)
+		}
+		return ids
+	}
+
+	info1 := upload(th.BasicChannel, "budget-2019.png")
+	time.Sleep(10 * time.Millisecond)
+	info2 := upload(th.BasicChannel2, "budget-2020.png")
+
+	assert.Equal(t, []string{info2.Id, info1.Id}, search("budget", 0, 60))
+	assert.Equal(t, []string{info1.Id}, search("budget in:"+th.BasicChannel.Name, 0, 60))
+	assert.Equal(t, []string{info2.Id, info1.Id}, search("budget from:"+th.BasicUser.Username, 0, 60))
+	assert.Empty(t, search("budget from:"+th.BasicUser2.Username, 0, 60))
+	assert.Empty(t, search("budget before:2000-01-01", 0, 60))
+	assert.Equal(t, []string{info2.Id, info1.Id}, search("budget after:2000-01-01", 0, 60))
+
+	assert.Equal(t, []string{info2.Id}, search("budget", 0, 1))
+	assert.Equal(t, []string{info1.Id}, search("budget", 1, 1))
+
+	_, resp := Client.SearchFiles(th.BasicTeam.Id, &model.SearchParameter{Terms: model.NewString("")})
+	CheckBadRequestStatus(t, resp)
+
+	otherTeam := th.CreateTeamWithClient(th.SystemAdminClient)
+	_, resp = Client.SearchFiles(otherTeam.Id, &model.SearchParameter{Terms: model.NewString("budget")})
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.SearchFiles(th.BasicTeam.Id, &model.SearchParameter{Terms: model.NewString("budget")})
+	CheckUnauthorizedStatus(t, resp)
+}
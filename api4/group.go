@@ -411,7 +411,12 @@ func getGroupMembers(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	members, count, err := c.App.GetGroupMemberUsersPage(c.Params.GroupId, c.Params.Page, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	members, count, err := c.App.GetGroupMemberUsersPage(c.Params.GroupId, pagination.Page, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
@@ -21,6 +21,7 @@ func (api *API) ApiHandler(h func(*Context, http.ResponseWriter, *http.Request))
 		TrustRequester:      false,
 		RequireMfa:          false,
 		IsStatic:            false,
+		RequireJSONBody:     true,
 	}
 }
 
@@ -34,6 +35,22 @@ func (api *API) ApiSessionRequired(h func(*Context, http.ResponseWriter, *http.R
 		TrustRequester:      false,
 		RequireMfa:          true,
 		IsStatic:            false,
+		RequireJSONBody:     true,
+	}
+}
+
+// ApiSystemAdminRequired provides a handler for API endpoints which may only be used by system admins. Every request to
+// them is audited, whether or not it's allowed.
+func (api *API) ApiSystemAdminRequired(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
+	return &web.Handler{
+		GetGlobalAppOptions: api.GetGlobalAppOptions,
+		HandleFunc:          h,
+		RequireSession:      true,
+		TrustRequester:      false,
+		RequireMfa:          true,
+		IsStatic:            false,
+		RequireSystemAdmin:  true,
+		RequireJSONBody:     true,
 	}
 }
 
@@ -48,6 +65,7 @@ func (api *API) ApiSessionRequiredMfa(h func(*Context, http.ResponseWriter, *htt
 		TrustRequester:      false,
 		RequireMfa:          false,
 		IsStatic:            false,
+		RequireJSONBody:     true,
 	}
 }
 
@@ -55,6 +73,20 @@ func (api *API) ApiSessionRequiredMfa(h func(*Context, http.ResponseWriter, *htt
 // allowed to be requested directly rather than via javascript/XMLHttpRequest, such as site branding images or the
 // websocket.
 func (api *API) ApiHandlerTrustRequester(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
+	return &web.Handler{
+		GetGlobalAppOptions: api.GetGlobalAppOptions,
+		HandleFunc:          h,
+		RequireSession:      false,
+		TrustRequester:      true,
+		RequireMfa:          false,
+		IsStatic:            false,
+		RequireJSONBody:     true,
+	}
+}
+
+// ApiFormHandlerTrustRequester provides a handler like ApiHandlerTrustRequester for endpoints whose request bodies are
+// form-encoded rather than JSON, such as the OAuth token endpoint.
+func (api *API) ApiFormHandlerTrustRequester(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
 	return &web.Handler{
 		GetGlobalAppOptions: api.GetGlobalAppOptions,
 		HandleFunc:          h,
@@ -75,5 +107,126 @@ func (api *API) ApiSessionRequiredTrustRequester(h func(*Context, http.ResponseW
 		TrustRequester:      true,
 		RequireMfa:          true,
 		IsStatic:            false,
+		RequireJSONBody:     true,
+	}
+}
+
+// ApiHandlerWithResponseCache provides a handler like ApiHandler whose responses can be cached under the given
+// endpoint name, see model.RESPONSE_CACHE_ENDPOINT_*.
+func (api *API) ApiHandlerWithResponseCache(endpoint string, h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
+	return &web.Handler{
+		GetGlobalAppOptions: api.GetGlobalAppOptions,
+		HandleFunc:          h,
+		RequireSession:      false,
+		TrustRequester:      false,
+		RequireMfa:          false,
+		IsStatic:            false,
+		ResponseCache:       endpoint,
+		RequireJSONBody:     true,
+	}
+}
+
+// ApiSessionRequiredWithResponseCache provides a handler like ApiSessionRequired whose responses can be cached under
+// the given endpoint name, see model.RESPONSE_CACHE_ENDPOINT_*.
+func (api *API) ApiSessionRequiredWithResponseCache(endpoint string, h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
+	return &web.Handler{
+		GetGlobalAppOptions: api.GetGlobalAppOptions,
+		HandleFunc:          h,
+		RequireSession:      true,
+		TrustRequester:      false,
+		RequireMfa:          true,
+		IsStatic:            false,
+		ResponseCache:       endpoint,
+		RequireJSONBody:     true,
+	}
+}
+
+// ApiSessionRequiredIdempotent provides a handler like ApiSessionRequired that only handles a request once for each
+// Idempotency-Key header that it's made with, sending the same response again when it's retried.
+func (api *API) ApiSessionRequiredIdempotent(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
+	return &web.Handler{
+		GetGlobalAppOptions: api.GetGlobalAppOptions,
+		HandleFunc:          h,
+		RequireSession:      true,
+		TrustRequester:      false,
+		RequireMfa:          true,
+		IsStatic:            false,
+		Idempotent:          true,
+		RequireJSONBody:     true,
+	}
+}
+
+// ApiSessionRequiredSearch provides a handler like ApiSessionRequired for endpoints that run searches, which are
+// limited by SearchRateLimitSettings.
+func (api *API) ApiSessionRequiredSearch(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
+	return &web.Handler{
+		GetGlobalAppOptions: api.GetGlobalAppOptions,
+		HandleFunc:          h,
+		RequireSession:      true,
+		TrustRequester:      false,
+		RequireMfa:          true,
+		IsStatic:            false,
+		IsSearch:            true,
+		RequireJSONBody:     true,
+	}
+}
+
+// ApiCriticalHandler provides a handler like ApiHandler for endpoints that must keep working while the server is
+// shedding load, such as health checks.
+func (api *API) ApiCriticalHandler(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
+	return &web.Handler{
+		GetGlobalAppOptions: api.GetGlobalAppOptions,
+		HandleFunc:          h,
+		RequireSession:      false,
+		TrustRequester:      false,
+		RequireMfa:          false,
+		IsStatic:            false,
+		Critical:            true,
+		RequireJSONBody:     true,
+	}
+}
+
+// ApiCriticalHandlerTrustRequester provides a handler like ApiHandlerTrustRequester for endpoints that must keep
+// working while the server is shedding load, such as the websocket.
+func (api *API) ApiCriticalHandlerTrustRequester(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
+	return &web.Handler{
+		GetGlobalAppOptions: api.GetGlobalAppOptions,
+		HandleFunc:          h,
+		RequireSession:      false,
+		TrustRequester:      true,
+		RequireMfa:          false,
+		IsStatic:            false,
+		Critical:            true,
+		RequireJSONBody:     true,
+	}
+}
+
+// ApiSecureHandler provides a handler like ApiHandler for sensitive endpoints, such as logins, that must not be served
+// over plain HTTP when the site is meant to be served over HTTPS.
+func (api *API) ApiSecureHandler(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
+	return &web.Handler{
+		GetGlobalAppOptions:     api.GetGlobalAppOptions,
+		HandleFunc:              h,
+		RequireSession:          false,
+		TrustRequester:          false,
+		RequireMfa:              false,
+		IsStatic:                false,
+		RequireSecureConnection: true,
+		RequireJSONBody:         true,
+	}
+}
+
+// ApiSessionRequiredUpload provides a handler like ApiSessionRequired for endpoints that accept files, whose request
+// bodies may be as large as the larger of maxBodyBytes and FileSettings.MaxFileSize.
+func (api *API) ApiSessionRequiredUpload(maxBodyBytes int64, h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
+	return &web.Handler{
+		GetGlobalAppOptions: api.GetGlobalAppOptions,
+		HandleFunc:          h,
+		RequireSession:      true,
+		TrustRequester:      false,
+		RequireMfa:          true,
+		IsStatic:            false,
+		IsUpload:            true,
+		MaxBodyBytes:        maxBodyBytes,
 	}
 }
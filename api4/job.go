@@ -69,7 +69,12 @@ func getJobs(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobs, err := c.App.GetJobsPage(c.Params.Page, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	jobs, err := c.App.GetJobsPage(pagination.Page, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
@@ -89,7 +94,12 @@ func getJobsByType(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobs, err := c.App.GetJobsByTypePage(c.Params.JobType, c.Params.Page, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	jobs, err := c.App.GetJobsByTypePage(c.Params.JobType, pagination.Page, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
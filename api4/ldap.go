@@ -68,7 +68,12 @@ func getLdapGroups(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	groups, total, err := c.App.GetAllLdapGroupsPage(c.Params.Page, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	groups, total, err := c.App.GetAllLdapGroupsPage(pagination.Page, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
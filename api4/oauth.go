@@ -31,18 +31,18 @@ func (api *API) InitOAuth() {
 	api.BaseRoutes.Root.Handle("/oauth/authorize", api.ApiHandlerTrustRequester(authorizeOAuthPage)).Methods("GET")
 	api.BaseRoutes.Root.Handle("/oauth/authorize", api.ApiSessionRequired(authorizeOAuthApp)).Methods("POST")
 	api.BaseRoutes.Root.Handle("/oauth/deauthorize", api.ApiSessionRequired(deauthorizeOAuthApp)).Methods("POST")
-	api.BaseRoutes.Root.Handle("/oauth/access_token", api.ApiHandlerTrustRequester(getAccessToken)).Methods("POST")
+	api.BaseRoutes.Root.Handle("/oauth/access_token", api.ApiFormHandlerTrustRequester(getAccessToken)).Methods("POST")
 
 	// API version independent OAuth as a client endpoints
-	api.BaseRoutes.Root.Handle("/oauth/{service:[A-Za-z0-9]+}/complete", api.ApiHandler(completeOAuth)).Methods("GET")
-	api.BaseRoutes.Root.Handle("/oauth/{service:[A-Za-z0-9]+}/login", api.ApiHandler(loginWithOAuth)).Methods("GET")
-	api.BaseRoutes.Root.Handle("/oauth/{service:[A-Za-z0-9]+}/mobile_login", api.ApiHandler(mobileLoginWithOAuth)).Methods("GET")
-	api.BaseRoutes.Root.Handle("/oauth/{service:[A-Za-z0-9]+}/signup", api.ApiHandler(signupWithOAuth)).Methods("GET")
+	api.BaseRoutes.Root.Handle("/oauth/{service:[A-Za-z0-9]+}/complete", api.ApiSecureHandler(completeOAuth)).Methods("GET")
+	api.BaseRoutes.Root.Handle("/oauth/{service:[A-Za-z0-9]+}/login", api.ApiSecureHandler(loginWithOAuth)).Methods("GET")
+	api.BaseRoutes.Root.Handle("/oauth/{service:[A-Za-z0-9]+}/mobile_login", api.ApiSecureHandler(mobileLoginWithOAuth)).Methods("GET")
+	api.BaseRoutes.Root.Handle("/oauth/{service:[A-Za-z0-9]+}/signup", api.ApiSecureHandler(signupWithOAuth)).Methods("GET")
 
 	// Old endpoints for backwards compatibility, needed to not break SSO for any old setups
-	api.BaseRoutes.Root.Handle("/api/v3/oauth/{service:[A-Za-z0-9]+}/complete", api.ApiHandler(completeOAuth)).Methods("GET")
-	api.BaseRoutes.Root.Handle("/signup/{service:[A-Za-z0-9]+}/complete", api.ApiHandler(completeOAuth)).Methods("GET")
-	api.BaseRoutes.Root.Handle("/login/{service:[A-Za-z0-9]+}/complete", api.ApiHandler(completeOAuth)).Methods("GET")
+	api.BaseRoutes.Root.Handle("/api/v3/oauth/{service:[A-Za-z0-9]+}/complete", api.ApiSecureHandler(completeOAuth)).Methods("GET")
+	api.BaseRoutes.Root.Handle("/signup/{service:[A-Za-z0-9]+}/complete", api.ApiSecureHandler(completeOAuth)).Methods("GET")
+	api.BaseRoutes.Root.Handle("/login/{service:[A-Za-z0-9]+}/complete", api.ApiSecureHandler(completeOAuth)).Methods("GET")
 }
 
 func createOAuthApp(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -128,12 +128,17 @@ func getOAuthApps(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
 	var apps []*model.OAuthApp
 	var err *model.AppError
 	if c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_SYSTEM_WIDE_OAUTH) {
-		apps, err = c.App.GetOAuthApps(c.Params.Page, c.Params.PerPage)
+		apps, err = c.App.GetOAuthApps(pagination.Page, pagination.PerPage)
 	} else if c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_OAUTH) {
-		apps, err = c.App.GetOAuthAppsByCreator(c.App.Session.UserId, c.Params.Page, c.Params.PerPage)
+		apps, err = c.App.GetOAuthAppsByCreator(c.App.Session.UserId, pagination.Page, pagination.PerPage)
 	} else {
 		c.SetPermissionError(model.PERMISSION_MANAGE_OAUTH)
 		return
@@ -265,7 +270,12 @@ func getAuthorizedOAuthApps(c *Context, w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	apps, err := c.App.GetAuthorizedAppsForUser(c.Params.UserId, c.Params.Page, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	apps, err := c.App.GetAuthorizedAppsForUser(c.Params.UserId, pagination.Page, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
@@ -492,7 +502,7 @@ func completeOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 		err.Translate(c.App.T)
 		mlog.Error(err.Error())
 		if action == model.OAUTH_ACTION_MOBILE {
-			w.Write([]byte(err.ToJson()))
+			utils.RenderMobileAppError(c.App.Config(), w, err)
 		} else {
 			utils.RenderWebAppError(c.App.Config(), w, r, err, c.App.AsymmetricSigningKey())
 		}
@@ -504,7 +514,7 @@ func completeOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 		err.Translate(c.App.T)
 		mlog.Error(err.Error())
 		if action == model.OAUTH_ACTION_MOBILE {
-			w.Write([]byte(err.ToJson()))
+			utils.RenderMobileAppError(c.App.Config(), w, err)
 		} else {
 			utils.RenderWebAppError(c.App.Config(), w, r, err, c.App.AsymmetricSigningKey())
 		}
@@ -523,7 +533,7 @@ func completeOAuth(c *Context, w http.ResponseWriter, r *http.Request) {
 			err.Translate(c.App.T)
 			c.Err = err
 			if action == model.OAUTH_ACTION_MOBILE {
-				w.Write([]byte(err.ToJson()))
+				utils.RenderMobileAppError(c.App.Config(), w, err)
 			}
 			return
 		}
@@ -5,24 +5,38 @@ package api4
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/web"
 )
 
 func (api *API) InitPost() {
-	api.BaseRoutes.Posts.Handle("", api.ApiSessionRequired(createPost)).Methods("POST")
+	api.BaseRoutes.Posts.Handle("", api.ApiSessionRequiredIdempotent(createPost)).Methods("POST")
 	api.BaseRoutes.Post.Handle("", api.ApiSessionRequired(getPost)).Methods("GET")
 	api.BaseRoutes.Post.Handle("", api.ApiSessionRequired(deletePost)).Methods("DELETE")
 	api.BaseRoutes.Posts.Handle("/ephemeral", api.ApiSessionRequired(createEphemeralPost)).Methods("POST")
+	api.BaseRoutes.Posts.Handle("/checklist", api.ApiSessionRequired(createChecklistPost)).Methods("POST")
+	api.BaseRoutes.Posts.Handle("/scan", api.ApiSessionRequired(scanPostMessage)).Methods("POST")
+	api.BaseRoutes.Post.Handle("/checklist", api.ApiSessionRequired(checkChecklistItem)).Methods("PUT")
 	api.BaseRoutes.Post.Handle("/thread", api.ApiSessionRequired(getPostThread)).Methods("GET")
+	api.BaseRoutes.Post.Handle("/thread/split", api.ApiSessionRequired(splitPostThread)).Methods("POST")
 	api.BaseRoutes.Post.Handle("/files/info", api.ApiSessionRequired(getFileInfosForPost)).Methods("GET")
+	api.BaseRoutes.Post.Handle("/here_mentions", api.ApiSessionRequired(getPostHereMentions)).Methods("GET")
 	api.BaseRoutes.PostsForChannel.Handle("", api.ApiSessionRequired(getPostsForChannel)).Methods("GET")
+	api.BaseRoutes.PostsForChannel.Handle("/deleted", api.ApiSessionRequired(getDeletedPostsForChannel)).Methods("GET")
+	api.BaseRoutes.PostsForChannel.Handle("/move", api.ApiSessionRequired(movePosts)).Methods("POST")
 	api.BaseRoutes.PostsForUser.Handle("/flagged", api.ApiSessionRequired(getFlaggedPostsForUser)).Methods("GET")
+	api.BaseRoutes.PostsForUser.Handle("/catch_up", api.ApiSessionRequired(getCatchUpSummary)).Methods("GET")
+	api.BaseRoutes.PostForUser.Handle("/notification_explanation", api.ApiSessionRequired(explainPostNotification)).Methods("GET")
 
-	api.BaseRoutes.Team.Handle("/posts/search", api.ApiSessionRequired(searchPosts)).Methods("POST")
+	api.BaseRoutes.Team.Handle("/posts/search", api.ApiSessionRequiredSearch(searchPosts)).Methods("POST")
+	api.BaseRoutes.Team.Handle("/posts/search/export", api.ApiSessionRequiredSearch(exportSearchResults)).Methods("POST")
+	api.BaseRoutes.Posts.Handle("/search", api.ApiSessionRequiredSearch(searchPostsInTeams)).Methods("POST")
 	api.BaseRoutes.Post.Handle("", api.ApiSessionRequired(updatePost)).Methods("PUT")
 	api.BaseRoutes.Post.Handle("/patch", api.ApiSessionRequired(patchPost)).Methods("PUT")
 	api.BaseRoutes.Post.Handle("/pin", api.ApiSessionRequired(pinPost)).Methods("POST")
@@ -72,6 +86,58 @@ func createPost(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(rp.ToJson()))
 }
 
+func createChecklistPost(c *Context, w http.ResponseWriter, r *http.Request) {
+	request := model.ChecklistRequestFromJson(r.Body)
+	if request == nil {
+		c.SetInvalidParam("checklist")
+		return
+	}
+
+	if !c.App.SessionHasPermissionToChannel(c.App.Session, request.ChannelId, model.PERMISSION_CREATE_POST) {
+		c.SetPermissionError(model.PERMISSION_CREATE_POST)
+		return
+	}
+
+	rp, err := c.App.CreateChecklistPost(request, c.App.Session.UserId, !c.App.Session.IsMobileApp())
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.App.SetStatusOnline(c.App.Session.UserId, false)
+	c.App.UpdateLastActivityAtIfNeeded(c.App.Session)
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(rp.ToJson()))
+}
+
+func checkChecklistItem(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequirePostId()
+	if c.Err != nil {
+		return
+	}
+
+	check := model.ChecklistItemCheckFromJson(r.Body)
+	if check == nil {
+		c.SetInvalidParam("check")
+		return
+	}
+
+	// Anyone who can post in the channel can check items off, not just the author of the checklist
+	if !c.App.SessionHasPermissionToChannelByPost(c.App.Session, c.Params.PostId, model.PERMISSION_CREATE_POST) {
+		c.SetPermissionError(model.PERMISSION_CREATE_POST)
+		return
+	}
+
+	rpost, err := c.App.CheckChecklistItem(c.Params.PostId, check, c.App.Session.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(rpost.ToJson()))
+}
+
 func createEphemeralPost(c *Context, w http.ResponseWriter, r *http.Request) {
 	ephRequest := model.PostEphemeral{}
 
@@ -100,6 +166,17 @@ func createEphemeralPost(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(c.App.PreparePostForClient(rp, true).ToJson()))
 }
 
+func scanPostMessage(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.MapFromJson(r.Body)
+	message, ok := props["message"]
+	if !ok {
+		c.SetInvalidParam("message")
+		return
+	}
+
+	w.Write([]byte(c.App.ScanPostMessage(message).ToJson()))
+}
+
 func getPostsForChannel(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.RequireChannelId()
 	if c.Err != nil {
@@ -130,32 +207,40 @@ func getPostsForChannel(c *Context, w http.ResponseWriter, r *http.Request) {
 	var err *model.AppError
 	etag := ""
 
+	// The etag of the posts doesn't cover the related objects that can be embedded in them
+	useEtag := len(c.Params.Expand) == 0
+
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
 	if since > 0 {
 		list, err = c.App.GetPostsSince(c.Params.ChannelId, since)
 	} else if len(afterPost) > 0 {
 		etag = c.App.GetPostsEtag(c.Params.ChannelId)
 
-		if c.HandleEtag(etag, "Get Posts After", w, r) {
+		if useEtag && c.HandleEtag(etag, "Get Posts After", w, r) {
 			return
 		}
 
-		list, err = c.App.GetPostsAfterPost(c.Params.ChannelId, afterPost, c.Params.Page, c.Params.PerPage)
+		list, err = c.App.GetPostsAfterPost(c.Params.ChannelId, afterPost, pagination.Page, pagination.PerPage)
 	} else if len(beforePost) > 0 {
 		etag = c.App.GetPostsEtag(c.Params.ChannelId)
 
-		if c.HandleEtag(etag, "Get Posts Before", w, r) {
+		if useEtag && c.HandleEtag(etag, "Get Posts Before", w, r) {
 			return
 		}
 
-		list, err = c.App.GetPostsBeforePost(c.Params.ChannelId, beforePost, c.Params.Page, c.Params.PerPage)
+		list, err = c.App.GetPostsBeforePost(c.Params.ChannelId, beforePost, pagination.Page, pagination.PerPage)
 	} else {
 		etag = c.App.GetPostsEtag(c.Params.ChannelId)
 
-		if c.HandleEtag(etag, "Get Posts", w, r) {
+		if useEtag && c.HandleEtag(etag, "Get Posts", w, r) {
 			return
 		}
 
-		list, err = c.App.GetPostsPage(c.Params.ChannelId, c.Params.Page, c.Params.PerPage)
+		list, err = c.App.GetPostsPage(c.Params.ChannelId, pagination.Page, pagination.PerPage)
 	}
 
 	if err != nil {
@@ -163,10 +248,105 @@ func getPostsForChannel(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(etag) > 0 {
+	clientPostList := c.App.PreparePostListForClient(list)
+	c.App.FillInPermalinkPreviewsForList(clientPostList, c.App.Session)
+	if err := c.App.ExpandPostList(clientPostList, c.Params.Expand, c.IsSystemAdmin()); err != nil {
+		c.Err = err
+		return
+	}
+
+	if useEtag && len(etag) > 0 {
 		w.Header().Set(model.HEADER_ETAG_SERVER, etag)
 	}
 
+	w.Write([]byte(clientPostList.ToJson()))
+}
+
+func getDeletedPostsForChannel(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireChannelId()
+	if c.Err != nil {
+		return
+	}
+
+	// Only moderators, i.e. those able to delete the posts of others, may review deleted content
+	if !c.App.SessionHasPermissionToChannel(c.App.Session, c.Params.ChannelId, model.PERMISSION_DELETE_OTHERS_POSTS) {
+		c.SetPermissionError(model.PERMISSION_DELETE_OTHERS_POSTS)
+		return
+	}
+
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	list, err := c.App.GetDeletedPostsForChannel(c.Params.ChannelId, pagination.Page, pagination.PerPage)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(c.App.PreparePostListForClient(list).ToJson()))
+}
+
+func movePosts(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireChannelId()
+	if c.Err != nil {
+		return
+	}
+
+	moveRequest := model.PostsMoveRequestFromJson(r.Body)
+	if moveRequest == nil {
+		c.SetInvalidParam("move_request")
+		return
+	}
+
+	if err := moveRequest.IsValid(); err != nil {
+		c.Err = err
+		return
+	}
+
+	if !c.App.SessionHasPermissionToChannel(c.App.Session, c.Params.ChannelId, model.PERMISSION_DELETE_OTHERS_POSTS) {
+		c.SetPermissionError(model.PERMISSION_DELETE_OTHERS_POSTS)
+		return
+	}
+
+	if !c.App.SessionHasPermissionToChannel(c.App.Session, moveRequest.ChannelId, model.PERMISSION_CREATE_POST) {
+		c.SetPermissionError(model.PERMISSION_CREATE_POST)
+		return
+	}
+
+	fromChannel, err := c.App.GetChannel(c.Params.ChannelId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	toChannel, err := c.App.GetChannel(moveRequest.ChannelId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	// Moving posts between teams or into and out of direct messages would expose them to a different audience
+	if fromChannel.TeamId != toChannel.TeamId || len(fromChannel.TeamId) == 0 {
+		c.SetInvalidParam("channel_id")
+		return
+	}
+
+	user, err := c.App.GetUser(c.App.Session.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	list, err := c.App.MovePosts(moveRequest.PostIds, fromChannel, toChannel, moveRequest.IncludeThread, user)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit(fmt.Sprintf("from_channel_id=%v to_channel_id=%v count=%v", fromChannel.Id, toChannel.Id, len(list.Order)))
+
 	w.Write([]byte(c.App.PreparePostListForClient(list).ToJson()))
 }
 
@@ -184,15 +364,19 @@ func getFlaggedPostsForUser(c *Context, w http.ResponseWriter, r *http.Request)
 	channelId := r.URL.Query().Get("channel_id")
 	teamId := r.URL.Query().Get("team_id")
 
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
 	var posts *model.PostList
 	var err *model.AppError
-
 	if len(channelId) > 0 {
-		posts, err = c.App.GetFlaggedPostsForChannel(c.Params.UserId, channelId, c.Params.Page, c.Params.PerPage)
+		posts, err = c.App.GetFlaggedPostsForChannel(c.Params.UserId, channelId, pagination.Page, pagination.PerPage)
 	} else if len(teamId) > 0 {
-		posts, err = c.App.GetFlaggedPostsForTeam(c.Params.UserId, teamId, c.Params.Page, c.Params.PerPage)
+		posts, err = c.App.GetFlaggedPostsForTeam(c.Params.UserId, teamId, pagination.Page, pagination.PerPage)
 	} else {
-		posts, err = c.App.GetFlaggedPosts(c.Params.UserId, c.Params.Page, c.Params.PerPage)
+		posts, err = c.App.GetFlaggedPosts(c.Params.UserId, pagination.Page, pagination.PerPage)
 	}
 
 	pl := model.NewPostList()
@@ -226,7 +410,74 @@ func getFlaggedPostsForUser(c *Context, w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	w.Write([]byte(c.App.PreparePostListForClient(pl).ToJson()))
+	clientPostList := c.App.PreparePostListForClient(pl)
+	c.App.FillInPermalinkPreviewsForList(clientPostList, c.App.Session)
+
+	w.Write([]byte(clientPostList.ToJson()))
+}
+
+func getCatchUpSummary(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireUserId()
+	if c.Err != nil {
+		return
+	}
+
+	if !c.App.SessionHasPermissionToUser(c.App.Session, c.Params.UserId) {
+		c.SetPermissionError(model.PERMISSION_EDIT_OTHER_USERS)
+		return
+	}
+
+	query := r.URL.Query()
+
+	var since int64
+	if sinceStr := query.Get("since"); sinceStr != "" {
+		var parseErr error
+		if since, parseErr = strconv.ParseInt(sinceStr, 10, 64); parseErr != nil || since <= 0 {
+			c.SetInvalidUrlParam("since")
+			return
+		}
+	}
+
+	collapsedThreads := query.Get("collapsed_threads") == "true"
+
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	summary, err := c.App.GetCatchUpSummary(c.Params.UserId, since, collapsedThreads, pagination.Page, pagination.PerPage)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(summary.ToJson()))
+}
+
+func explainPostNotification(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireUserId().RequirePostId()
+	if c.Err != nil {
+		return
+	}
+
+	// Explaining someone else's notifications reveals their settings and status, so it's left to system admins
+	if c.Params.UserId != c.App.Session.UserId && !c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	if !c.App.SessionHasPermissionToChannelByPost(c.App.Session, c.Params.PostId, model.PERMISSION_READ_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+		return
+	}
+
+	explanation, err := c.App.ExplainNotification(c.Params.PostId, c.Params.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(explanation.ToJson()))
 }
 
 func getPost(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -260,6 +511,18 @@ func getPost(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 	post = c.App.PreparePostForClient(post, false)
+	c.App.FillInPermalinkPreviews([]*model.Post{post}, c.App.Session)
+
+	// The etag of the post doesn't cover the related objects that can be embedded in it
+	if len(c.Params.Expand) > 0 {
+		if err := c.App.ExpandPost(post, c.Params.Expand, c.IsSystemAdmin()); err != nil {
+			c.Err = err
+			return
+		}
+
+		w.Write([]byte(post.ToJson()))
+		return
+	}
 
 	if c.HandleEtag(post.Etag(), "Get Post", w, r) {
 		return
@@ -337,17 +600,83 @@ func getPostThread(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if c.HandleEtag(list.Etag(), "Get Post Thread", w, r) {
+	// The etag of the thread doesn't cover the related objects that can be embedded in its posts
+	useEtag := len(c.Params.Expand) == 0
+
+	if useEtag && c.HandleEtag(list.Etag(), "Get Post Thread", w, r) {
 		return
 	}
 
 	clientPostList := c.App.PreparePostListForClient(list)
+	c.App.FillInPermalinkPreviewsForList(clientPostList, c.App.Session)
+	if err := c.App.ExpandPostList(clientPostList, c.Params.Expand, c.IsSystemAdmin()); err != nil {
+		c.Err = err
+		return
+	}
 
-	w.Header().Set(model.HEADER_ETAG_SERVER, clientPostList.Etag())
+	if useEtag {
+		w.Header().Set(model.HEADER_ETAG_SERVER, clientPostList.Etag())
+	}
 
 	w.Write([]byte(clientPostList.ToJson()))
 }
 
+func splitPostThread(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequirePostId()
+	if c.Err != nil {
+		return
+	}
+
+	channel := model.ChannelFromJson(r.Body)
+	if channel == nil {
+		c.SetInvalidParam("channel")
+		return
+	}
+
+	post, err := c.App.GetSinglePost(c.Params.PostId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if !c.App.SessionHasPermissionToChannel(c.App.Session, post.ChannelId, model.PERMISSION_DELETE_OTHERS_POSTS) {
+		c.SetPermissionError(model.PERMISSION_DELETE_OTHERS_POSTS)
+		return
+	}
+
+	fromChannel, err := c.App.GetChannel(post.ChannelId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if channel.Type == model.CHANNEL_OPEN && !c.App.SessionHasPermissionToTeam(c.App.Session, fromChannel.TeamId, model.PERMISSION_CREATE_PUBLIC_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_CREATE_PUBLIC_CHANNEL)
+		return
+	}
+
+	if channel.Type == model.CHANNEL_PRIVATE && !c.App.SessionHasPermissionToTeam(c.App.Session, fromChannel.TeamId, model.PERMISSION_CREATE_PRIVATE_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_CREATE_PRIVATE_CHANNEL)
+		return
+	}
+
+	user, err := c.App.GetUser(c.App.Session.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	sc, err := c.App.SplitThreadToChannel(post.Id, channel, user)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("post_id=" + post.Id + " name=" + sc.Name)
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(sc.ToJson()))
+}
+
 func searchPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.RequireTeamId()
 	if c.Err != nil {
@@ -360,8 +689,141 @@ func searchPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 	params := model.SearchParameterFromJson(r.Body)
+	allTime := r.URL.Query().Get("all_time") == "true"
+
+	doSearchPosts(c, w, params, func(terms string, isOrSearch bool, includeDeletedChannels bool, timeZoneOffset int, page, perPage int) (*model.PostSearchResults, *model.AppError) {
+		return c.App.SearchPostsInTeam(terms, c.App.Session.UserId, c.Params.TeamId, isOrSearch, includeDeletedChannels, allTime, timeZoneOffset, page, perPage)
+	})
+}
+
+func exportSearchResults(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = model.SEARCH_EXPORT_FORMAT_JSONL
+	}
+	if !model.IsValidSearchExportFormat(format) {
+		c.SetInvalidParam("format")
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_VIEW_TEAM) {
+		c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
+		return
+	}
+
+	params := model.SearchParameterFromJson(r.Body)
+	if params == nil || params.Terms == nil || len(*params.Terms) == 0 {
+		c.SetInvalidParam("terms")
+		return
+	}
+
+	timeZoneOffset := 0
+	if params.TimeZoneOffset != nil {
+		timeZoneOffset = *params.TimeZoneOffset
+	}
+
+	isOrSearch := false
+	if params.IsOrSearch != nil {
+		isOrSearch = *params.IsOrSearch
+	}
+
+	includeDeletedChannels := false
+	if params.IncludeDeletedChannels != nil {
+		includeDeletedChannels = *params.IncludeDeletedChannels
+	}
+
+	contentType := "application/x-ndjson"
+	if format == model.SEARCH_EXPORT_FORMAT_CSV {
+		contentType = "text/csv"
+	}
+	sw := &exportResponseWriter{
+		ResponseWriter: w,
+		contentType:    contentType,
+		filename:       "search_results." + format,
+	}
+
+	exported, err := c.App.ExportSearchResults(sw, format, *params.Terms, c.App.Session.UserId, c.Params.TeamId, isOrSearch, includeDeletedChannels, timeZoneOffset)
+	if err != nil {
+		if !sw.started {
+			c.Err = err
+			return
+		}
+
+		// The response has already started, so the error can't be returned to the client.
+		mlog.Error("Failed to export search results", mlog.String("team_id", c.Params.TeamId), mlog.Int("exported", exported), mlog.Err(err))
+		return
+	}
+
+	c.LogAudit(fmt.Sprintf("exported=%v format=%v", exported, format))
+
+	if !sw.started {
+		sw.writeHeaders()
+	}
+}
+
+// exportResponseWriter only sends the headers of a streamed export, such as of search results, once the first of it is
+// written, so that errors that happen before then can still be returned as usual.
+type exportResponseWriter struct {
+	http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (w *exportResponseWriter) writeHeaders() {
+	w.started = true
+	w.Header().Set("Content-Type", w.contentType)
+	w.Header().Set("Content-Disposition", "attachment;filename=\""+w.filename+"\"")
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (w *exportResponseWriter) Write(b []byte) (int, error) {
+	if !w.started {
+		w.writeHeaders()
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *exportResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func searchPostsInTeams(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := model.SearchParameterFromJson(r.Body)
+	if params == nil {
+		c.SetInvalidParam("terms")
+		return
+	}
+
+	for _, teamId := range params.TeamIds {
+		if !model.IsValidId(teamId) {
+			c.SetInvalidParam("team_ids")
+			return
+		}
+
+		if !c.App.SessionHasPermissionToTeam(c.App.Session, teamId, model.PERMISSION_VIEW_TEAM) {
+			c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
+			return
+		}
+	}
+
+	allTime := r.URL.Query().Get("all_time") == "true"
 
-	if params.Terms == nil || len(*params.Terms) == 0 {
+	doSearchPosts(c, w, params, func(terms string, isOrSearch bool, includeDeletedChannels bool, timeZoneOffset int, page, perPage int) (*model.PostSearchResults, *model.AppError) {
+		return c.App.SearchPostsInTeams(terms, c.App.Session.UserId, params.TeamIds, isOrSearch, includeDeletedChannels, allTime, timeZoneOffset, page, perPage)
+	})
+}
+
+func doSearchPosts(c *Context, w http.ResponseWriter, params *model.SearchParameter, search func(terms string, isOrSearch bool, includeDeletedChannels bool, timeZoneOffset int, page, perPage int) (*model.PostSearchResults, *model.AppError)) {
+	if params == nil || params.Terms == nil || len(*params.Terms) == 0 {
 		c.SetInvalidParam("terms")
 		return
 	}
@@ -377,16 +839,21 @@ func searchPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 		isOrSearch = *params.IsOrSearch
 	}
 
-	page := 0
+	page := web.PAGE_DEFAULT
 	if params.Page != nil {
 		page = *params.Page
 	}
 
-	perPage := 60
+	perPage := web.PER_PAGE_DEFAULT
 	if params.PerPage != nil {
 		perPage = *params.PerPage
 	}
 
+	pagination := c.NewPagination(page, perPage)
+	if c.Err != nil {
+		return
+	}
+
 	includeDeletedChannels := false
 	if params.IncludeDeletedChannels != nil {
 		includeDeletedChannels = *params.IncludeDeletedChannels
@@ -394,7 +861,7 @@ func searchPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	startTime := time.Now()
 
-	results, err := c.App.SearchPostsInTeam(terms, c.App.Session.UserId, c.Params.TeamId, isOrSearch, includeDeletedChannels, int(timeZoneOffset), page, perPage)
+	results, err := search(terms, isOrSearch, includeDeletedChannels, timeZoneOffset, pagination.Page, pagination.PerPage)
 
 	elapsedTime := float64(time.Since(startTime)) / float64(time.Second)
 	metrics := c.App.Metrics
@@ -408,9 +875,8 @@ func searchPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	clientPostList := c.App.PreparePostListForClient(results.PostList)
-
-	results = model.MakePostSearchResults(clientPostList, results.Matches)
+	results.PostList = c.App.PreparePostListForClient(results.PostList)
+	c.App.FillInPermalinkPreviewsForList(results.PostList, c.App.Session)
 
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
 	w.Write([]byte(results.ToJson()))
@@ -545,6 +1011,20 @@ func saveIsPinnedPost(c *Context, w http.ResponseWriter, r *http.Request, isPinn
 	patch := &model.PostPatch{}
 	patch.IsPinned = model.NewBool(isPinned)
 
+	if isPinned {
+		props := model.StringInterfaceFromJson(r.Body)
+		if value, ok := props["pinned_until"]; ok && value != nil {
+			pinnedUntil, ok := value.(float64)
+			if !ok || int64(pinnedUntil) <= model.GetMillis() {
+				c.SetInvalidParam("pinned_until")
+				return
+			}
+			patch.PinnedUntil = model.NewInt64(int64(pinnedUntil))
+		} else {
+			patch.PinnedUntil = model.NewInt64(0)
+		}
+	}
+
 	_, err = c.App.PatchPost(c.Params.PostId, patch)
 	if err != nil {
 		c.Err = err
@@ -562,6 +1042,26 @@ func unpinPost(c *Context, w http.ResponseWriter, r *http.Request) {
 	saveIsPinnedPost(c, w, r, false)
 }
 
+func getPostHereMentions(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequirePostId()
+	if c.Err != nil {
+		return
+	}
+
+	if !c.App.SessionHasPermissionToChannelByPost(c.App.Session, c.Params.PostId, model.PERMISSION_READ_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+		return
+	}
+
+	mentions, err := c.App.GetPostHereMentions(c.Params.PostId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(mentions.ToJson()))
+}
+
 func getFileInfosForPost(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.RequirePostId()
 	if c.Err != nil {
@@ -4,6 +4,7 @@
 package api4
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -16,6 +17,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/mattermost/mattermost-server/app"
 	"github.com/mattermost/mattermost-server/model"
@@ -120,6 +122,37 @@ func TestCreatePost(t *testing.T) {
 	}
 }
 
+func TestCreatePostIdempotent(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	Client.HttpHeader = map[string]string{model.HEADER_IDEMPOTENCY_KEY: model.NewId()}
+	defer func() {
+		Client.HttpHeader = nil
+	}()
+
+	post := &model.Post{ChannelId: th.BasicChannel.Id, Message: "retried"}
+	rpost, resp := Client.CreatePost(post)
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+
+	retried, resp := Client.CreatePost(post)
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+	assert.Equal(t, rpost.Id, retried.Id)
+
+	posts, resp := Client.GetPostsSince(th.BasicChannel.Id, rpost.CreateAt-1)
+	CheckNoError(t, resp)
+	count := 0
+	for _, p := range posts.Posts {
+		if p.Message == post.Message {
+			count++
+		}
+	}
+	assert.Equal(t, 1, count, "the retry shouldn't have created another post")
+}
+
 func TestCreatePostEphemeral(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -713,6 +746,53 @@ func TestPatchPost(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+func TestChecklistPost(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	request := &model.ChecklistRequest{ChannelId: th.BasicChannel.Id, RootId: th.BasicPost.Id, Title: "Deploy", Items: []string{"Build", "Ship"}}
+
+	post, resp := Client.CreateChecklistPost(request)
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+	assert.Equal(t, th.BasicPost.Id, post.RootId)
+	assert.Equal(t, "#### Deploy\n\n- [ ] Build\n- [ ] Ship", post.Message)
+
+	_, resp = Client.CreateChecklistPost(&model.ChecklistRequest{ChannelId: th.BasicChannel.Id})
+	CheckBadRequestStatus(t, resp)
+
+	privateChannel := th.CreateChannelWithClientAndTeam(th.SystemAdminClient, model.CHANNEL_PRIVATE, th.BasicTeam.Id)
+	_, resp = Client.CreateChecklistPost(&model.ChecklistRequest{ChannelId: privateChannel.Id, Items: []string{"Build"}})
+	CheckForbiddenStatus(t, resp)
+
+	th.LoginBasic2()
+
+	rpost, resp := Client.CheckChecklistItem(post.Id, &model.ChecklistItemCheck{Index: 0, Checked: true})
+	CheckNoError(t, resp)
+	assert.Equal(t, "#### Deploy\n\n- [x] Build\n- [ ] Ship", rpost.Message)
+
+	rpost, resp = Client.GetPost(post.Id, "")
+	CheckNoError(t, resp)
+	checklist := model.ChecklistFromPost(rpost)
+	require.NotNil(t, checklist)
+	assert.Equal(t, th.BasicUser2.Id, checklist.Items[0].CheckedBy)
+
+	_, resp = Client.CheckChecklistItem(th.BasicPost.Id, &model.ChecklistItemCheck{Index: 0, Checked: true})
+	CheckBadRequestStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.CheckChecklistItem(post.Id, &model.ChecklistItemCheck{Index: 0, Checked: false})
+	CheckUnauthorizedStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.RemoveUserFromChannel(th.BasicChannel.Id, th.BasicUser2.Id)
+	CheckNoError(t, resp)
+
+	th.LoginBasic2()
+	_, resp = Client.CheckChecklistItem(post.Id, &model.ChecklistItemCheck{Index: 0, Checked: false})
+	CheckForbiddenStatus(t, resp)
+}
+
 func TestPinPost(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -764,6 +844,67 @@ func TestPinPost(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+func TestPinPostUntil(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	post := th.CreatePost()
+
+	_, resp := Client.PinPostUntil(post.Id, model.GetMillis()-1000)
+	CheckBadRequestStatus(t, resp)
+
+	pinnedUntil := model.GetMillis() + 60*60*1000
+	pass, resp := Client.PinPostUntil(post.Id, pinnedUntil)
+	CheckNoError(t, resp)
+	require.True(t, pass)
+
+	rpost, err := th.App.GetSinglePost(post.Id)
+	require.Nil(t, err)
+	assert.True(t, rpost.IsPinned)
+	assert.Equal(t, pinnedUntil, rpost.PinnedUntil)
+
+	t.Run("unpin cancels the expiry", func(t *testing.T) {
+		_, resp := Client.UnpinPost(post.Id)
+		CheckNoError(t, resp)
+
+		rpost, err := th.App.GetSinglePost(post.Id)
+		require.Nil(t, err)
+		assert.False(t, rpost.IsPinned)
+		assert.Zero(t, rpost.PinnedUntil)
+	})
+
+	t.Run("pinning without an expiry clears a previous one", func(t *testing.T) {
+		_, resp := Client.PinPostUntil(post.Id, pinnedUntil)
+		CheckNoError(t, resp)
+		_, resp = Client.PinPost(post.Id)
+		CheckNoError(t, resp)
+
+		rpost, err := th.App.GetSinglePost(post.Id)
+		require.Nil(t, err)
+		assert.True(t, rpost.IsPinned)
+		assert.Zero(t, rpost.PinnedUntil)
+	})
+
+	t.Run("expired pins are removed", func(t *testing.T) {
+		expiring := th.CreatePost()
+		_, resp := Client.PinPostUntil(expiring.Id, model.GetMillis()+100)
+		CheckNoError(t, resp)
+
+		time.Sleep(200 * time.Millisecond)
+		require.Nil(t, th.App.UnpinExpiredPosts())
+
+		rpost, err := th.App.GetSinglePost(expiring.Id)
+		require.Nil(t, err)
+		assert.False(t, rpost.IsPinned)
+		assert.Zero(t, rpost.PinnedUntil)
+
+		rpost, err = th.App.GetSinglePost(post.Id)
+		require.Nil(t, err)
+		assert.True(t, rpost.IsPinned, "pins without an expiry should be kept")
+	})
+}
+
 func TestUnpinPost(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -911,6 +1052,317 @@ func TestGetPostsForChannel(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+func TestGetDeletedPostsForChannel(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	post := th.CreatePost()
+	_, resp := th.SystemAdminClient.DeletePost(post.Id)
+	CheckNoError(t, resp)
+
+	_, resp = Client.GetDeletedPostsForChannel(th.BasicChannel.Id, 0, 60)
+	CheckForbiddenStatus(t, resp)
+
+	list, resp := th.SystemAdminClient.GetDeletedPostsForChannel(th.BasicChannel.Id, 0, 60)
+	CheckNoError(t, resp)
+	require.Len(t, list.Order, 1)
+	assert.Equal(t, post.Id, list.Order[0])
+	assert.NotZero(t, list.Posts[post.Id].DeleteAt)
+	assert.Equal(t, th.SystemAdminUser.Id, list.Posts[post.Id].Props[model.POST_PROPS_DELETE_BY])
+
+	_, resp = th.SystemAdminClient.GetDeletedPostsForChannel("junk", 0, 60)
+	CheckBadRequestStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.GetDeletedPostsForChannel(th.BasicChannel.Id, 0, 60)
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestMovePosts(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	destination := th.CreatePublicChannel()
+
+	root := th.CreatePost()
+	reply, resp := Client.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "zz" + model.NewId() + "a", RootId: root.Id})
+	CheckNoError(t, resp)
+
+	_, resp = Client.SaveReaction(&model.Reaction{UserId: th.BasicUser.Id, PostId: root.Id, EmojiName: "smile"})
+	CheckNoError(t, resp)
+
+	moveRequest := &model.PostsMoveRequest{ChannelId: destination.Id, PostIds: []string{root.Id}}
+
+	_, resp = Client.MovePosts(th.BasicChannel.Id, moveRequest)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.MovePosts(th.BasicChannel.Id, &model.PostsMoveRequest{ChannelId: th.BasicChannel.Id, PostIds: []string{root.Id}})
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.MovePosts(th.BasicChannel.Id, &model.PostsMoveRequest{ChannelId: destination.Id})
+	CheckBadRequestStatus(t, resp)
+
+	t.Run("authors must be members of the destination", func(t *testing.T) {
+		other, resp := th.SystemAdminClient.CreateChannel(&model.Channel{DisplayName: "Other", Name: GenerateTestChannelName(), Type: model.CHANNEL_OPEN, TeamId: th.BasicTeam.Id})
+		CheckNoError(t, resp)
+
+		_, resp = th.SystemAdminClient.MovePosts(th.BasicChannel.Id, &model.PostsMoveRequest{ChannelId: other.Id, PostIds: []string{root.Id}})
+		CheckBadRequestStatus(t, resp)
+		assert.Equal(t, "api.post.move_posts.author_not_member.app_error", resp.Error.Id)
+	})
+
+	sourceBefore, err := th.App.GetChannel(th.BasicChannel.Id)
+	require.Nil(t, err)
+	destinationBefore, err := th.App.GetChannel(destination.Id)
+	require.Nil(t, err)
+
+	list, resp := th.SystemAdminClient.MovePosts(th.BasicChannel.Id, moveRequest)
+	CheckNoError(t, resp)
+	require.Len(t, list.Order, 2, "replies should come along with their root post")
+	assert.Equal(t, destination.Id, list.Posts[root.Id].ChannelId)
+	assert.Equal(t, destination.Id, list.Posts[reply.Id].ChannelId)
+	assert.Equal(t, root.CreateAt, list.Posts[root.Id].CreateAt)
+	assert.Equal(t, th.BasicUser.Id, list.Posts[root.Id].UserId)
+
+	destinationAfter, err := th.App.GetChannel(destination.Id)
+	require.Nil(t, err)
+	assert.Equal(t, destinationBefore.TotalMsgCount+2, destinationAfter.TotalMsgCount)
+	assert.Equal(t, reply.CreateAt, destinationAfter.LastPostAt)
+
+	// The source channel loses the moved posts, but gains the message about them being moved
+	sourceAfter, err := th.App.GetChannel(th.BasicChannel.Id)
+	require.Nil(t, err)
+	assert.Equal(t, sourceBefore.TotalMsgCount-1, sourceAfter.TotalMsgCount)
+
+	reactions, resp := Client.GetReactions(root.Id)
+	CheckNoError(t, resp)
+	assert.Len(t, reactions, 1)
+
+	posts, resp := Client.GetPostsForChannel(th.BasicChannel.Id, 0, 60, "")
+	CheckNoError(t, resp)
+	_, ok := posts.Posts[root.Id]
+	assert.False(t, ok, "moved post should no longer be in the source channel")
+	assert.Equal(t, model.POST_MOVE_POSTS, posts.Posts[posts.Order[0]].Type)
+
+	_, resp = th.SystemAdminClient.MovePosts(th.BasicChannel.Id, moveRequest)
+	CheckBadRequestStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.MovePosts(th.BasicChannel.Id, moveRequest)
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestSplitPostThread(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	root := th.CreatePost()
+	th.LoginBasic2()
+	reply, resp := Client.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "zz" + model.NewId() + "a", RootId: root.Id})
+	CheckNoError(t, resp)
+	th.LoginBasic()
+
+	channel := &model.Channel{DisplayName: "Split Thread", Name: GenerateTestChannelName(), Type: model.CHANNEL_OPEN}
+
+	_, resp = Client.SplitPostThread(reply.Id, channel)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.SplitPostThread("junk", channel)
+	CheckBadRequestStatus(t, resp)
+
+	t.Run("participants must be members of the team", func(t *testing.T) {
+		user := th.CreateUser()
+		th.LinkUserToTeam(user, th.BasicTeam)
+		th.AddUserToChannel(user, th.BasicChannel)
+
+		otherRoot := th.CreatePost()
+		otherReply, err := th.App.CreatePostAsUser(&model.Post{UserId: user.Id, ChannelId: th.BasicChannel.Id, Message: "a reply", RootId: otherRoot.Id}, false)
+		require.Nil(t, err)
+
+		require.Nil(t, th.App.RemoveUserFromTeam(th.BasicTeam.Id, user.Id, th.SystemAdminUser.Id))
+
+		otherChannel := &model.Channel{DisplayName: "Split Thread", Name: GenerateTestChannelName(), Type: model.CHANNEL_OPEN}
+		_, resp := th.SystemAdminClient.SplitPostThread(otherReply.Id, otherChannel)
+		CheckBadRequestStatus(t, resp)
+		assert.Equal(t, "api.post.split_thread.participant_not_member.app_error", resp.Error.Id)
+
+		_, err = th.App.GetChannelByName(otherChannel.Name, th.BasicTeam.Id, true)
+		assert.NotNil(t, err, "the channel shouldn't have been created")
+	})
+
+	sc, resp := th.SystemAdminClient.SplitPostThread(reply.Id, channel)
+	CheckCreatedStatus(t, resp)
+	assert.Equal(t, th.BasicTeam.Id, sc.TeamId)
+
+	thread, resp := th.SystemAdminClient.GetPostThread(root.Id, "")
+	CheckNoError(t, resp)
+	require.Len(t, thread.Order, 2)
+	for _, post := range thread.Posts {
+		assert.Equal(t, sc.Id, post.ChannelId)
+	}
+
+	_, resp = th.SystemAdminClient.GetChannelMember(sc.Id, th.BasicUser.Id, "")
+	CheckNoError(t, resp)
+
+	_, resp = th.SystemAdminClient.GetChannelMember(sc.Id, th.BasicUser2.Id, "")
+	CheckNoError(t, resp)
+}
+
+func TestGetCatchUpSummary(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+	user := th.BasicUser
+
+	since := model.GetMillis() - 1000
+	root := th.CreatePost()
+
+	client2 := th.CreateClient()
+	th.LoginBasic2WithClient(client2)
+	th.CreateMessagePostWithClient(client2, th.BasicChannel, "hello @"+user.Username)
+	_, resp := client2.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, RootId: root.Id, ParentId: root.Id, Message: "a reply"})
+	CheckNoError(t, resp)
+	th.CreatePostWithClient(client2, th.BasicChannel2)
+
+	summary, resp := Client.GetCatchUpSummary(user.Id, since, false, 0, 60)
+	CheckNoError(t, resp)
+	assert.Equal(t, since, summary.Since)
+	assert.False(t, summary.HasMore)
+	require.Len(t, summary.Teams, 1)
+	assert.Equal(t, th.BasicTeam.Id, summary.Teams[0].TeamId)
+	assert.Len(t, summary.Teams[0].Channels, 2)
+	assert.Equal(t, int64(3), summary.NewPosts)
+	assert.Equal(t, int64(1), summary.Mentions)
+	assert.Equal(t, int64(1), summary.ThreadReplies)
+
+	summary, resp = Client.GetCatchUpSummary(user.Id, since, true, 0, 60)
+	CheckNoError(t, resp)
+	assert.Equal(t, int64(2), summary.NewPosts)
+	assert.Equal(t, int64(1), summary.ThreadReplies)
+
+	summary, resp = Client.GetCatchUpSummary(user.Id, since, false, 0, 1)
+	CheckNoError(t, resp)
+	assert.True(t, summary.HasMore)
+	require.Len(t, summary.Teams, 1)
+	assert.Len(t, summary.Teams[0].Channels, 1)
+
+	_, resp = Client.UpdateChannelNotifyProps(th.BasicChannel2.Id, user.Id, map[string]string{model.MARK_UNREAD_NOTIFY_PROP: model.CHANNEL_MARK_UNREAD_MENTION})
+	CheckNoError(t, resp)
+
+	summary, resp = Client.GetCatchUpSummary(user.Id, since, false, 0, 60)
+	CheckNoError(t, resp)
+	require.Len(t, summary.Teams, 1)
+	require.Len(t, summary.Teams[0].Channels, 1)
+	assert.Equal(t, th.BasicChannel.Id, summary.Teams[0].Channels[0].ChannelId)
+
+	// Without a time, each channel is caught up on since it was last viewed
+	summary, resp = Client.GetCatchUpSummary(user.Id, 0, false, 0, 60)
+	CheckNoError(t, resp)
+	require.Len(t, summary.Teams, 1)
+	require.Len(t, summary.Teams[0].Channels, 1)
+	assert.Equal(t, int64(1), summary.Mentions)
+
+	_, resp = Client.ViewChannel(user.Id, &model.ChannelView{ChannelId: th.BasicChannel.Id})
+	CheckNoError(t, resp)
+
+	summary, resp = Client.GetCatchUpSummary(user.Id, 0, false, 0, 60)
+	CheckNoError(t, resp)
+	assert.Empty(t, summary.Teams)
+
+	_, resp = Client.GetCatchUpSummary(th.BasicUser2.Id, since, false, 0, 60)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetCatchUpSummary(user.Id, since, false, 0, 60)
+	CheckNoError(t, resp)
+
+	Client.Logout()
+	_, resp = Client.GetCatchUpSummary(user.Id, since, false, 0, 60)
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestExplainPostNotification(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	client2 := th.CreateClient()
+	th.LoginBasic2WithClient(client2)
+	post := th.CreateMessagePostWithClient(client2, th.BasicChannel, "hello @"+th.BasicUser.Username)
+
+	explanation, resp := Client.ExplainPostNotification(th.BasicUser.Id, post.Id)
+	CheckNoError(t, resp)
+	assert.Equal(t, post.Id, explanation.PostId)
+	assert.Equal(t, th.BasicUser.Id, explanation.UserId)
+	assert.Equal(t, model.MENTION_REASON_USERNAME, explanation.MentionReason)
+	require.NotNil(t, explanation.Push)
+	require.NotNil(t, explanation.Email)
+	require.NotNil(t, explanation.Desktop)
+
+	_, resp = Client.ExplainPostNotification(th.BasicUser2.Id, post.Id)
+	CheckForbiddenStatus(t, resp)
+
+	explanation, resp = th.SystemAdminClient.ExplainPostNotification(th.BasicUser2.Id, post.Id)
+	CheckNoError(t, resp)
+	assert.Equal(t, model.NOTIFICATION_REASON_OWN_POST, explanation.Push.Reason)
+
+	privatePost := th.CreatePostWithClient(Client, th.CreatePrivateChannel())
+	_, resp = client2.ExplainPostNotification(th.BasicUser2.Id, privatePost.Id)
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.ExplainPostNotification(th.BasicUser.Id, post.Id)
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestScanPostMessage(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	message := "my card is 4111-1111-1111-1111"
+
+	scan, resp := Client.ScanPostMessage(message)
+	CheckNoError(t, resp)
+	assert.Empty(t, scan.Warnings)
+	assert.False(t, scan.Blocked)
+
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.DataLossPreventionSettings.Enable = true })
+
+	scan, resp = Client.ScanPostMessage(message)
+	CheckNoError(t, resp)
+	require.Len(t, scan.Warnings, 1)
+	assert.Equal(t, "Credit card number", scan.Warnings[0].Name)
+	assert.False(t, scan.Blocked)
+
+	post := &model.Post{ChannelId: th.BasicChannel.Id, Message: message}
+	_, resp = Client.CreatePost(post)
+	CheckNoError(t, resp)
+
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.DataLossPreventionSettings.BlockMatchingPosts = true })
+
+	scan, resp = Client.ScanPostMessage(message)
+	CheckNoError(t, resp)
+	assert.True(t, scan.Blocked)
+
+	_, resp = Client.CreatePost(post)
+	CheckBadRequestStatus(t, resp)
+	CheckErrorMessage(t, resp, "api.post.check_post_data_loss_prevention.app_error")
+
+	_, resp = Client.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "nothing sensitive"})
+	CheckNoError(t, resp)
+
+	_, err := Client.DoApiPost("/posts/scan", "{}")
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.StatusCode)
+
+	Client.Logout()
+	_, resp = Client.ScanPostMessage(message)
+	CheckUnauthorizedStatus(t, resp)
+}
+
 func TestGetFlaggedPostsForUser(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -1287,6 +1739,59 @@ func TestGetPost(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+func TestGetPostExpanded(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	t.Run("user and channel", func(t *testing.T) {
+		post, resp := Client.GetPostExpanded(th.BasicPost.Id, []string{model.POST_EXPAND_USER, model.POST_EXPAND_CHANNEL})
+		CheckNoError(t, resp)
+		assert.Empty(t, resp.Etag)
+
+		require.NotNil(t, post.Metadata)
+		require.NotNil(t, post.Metadata.User)
+		assert.Equal(t, th.BasicPost.UserId, post.Metadata.User.Id)
+		assert.Empty(t, post.Metadata.User.Password)
+		require.NotNil(t, post.Metadata.Channel)
+		assert.Equal(t, th.BasicChannel.Id, post.Metadata.Channel.Id)
+	})
+
+	t.Run("unknown values are ignored", func(t *testing.T) {
+		post, resp := Client.GetPostExpanded(th.BasicPost.Id, []string{"author", model.POST_EXPAND_CHANNEL})
+		CheckNoError(t, resp)
+		require.NotNil(t, post.Metadata)
+		assert.Nil(t, post.Metadata.User)
+		assert.NotNil(t, post.Metadata.Channel)
+	})
+
+	t.Run("private fields of the author are hidden", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.PrivacySettings.ShowEmailAddress = false })
+		defer th.App.UpdateConfig(func(cfg *model.Config) { cfg.PrivacySettings.ShowEmailAddress = true })
+
+		post := th.CreatePostWithClient(th.SystemAdminClient, th.BasicChannel)
+		expanded, resp := Client.GetPostExpanded(post.Id, []string{model.POST_EXPAND_USER})
+		CheckNoError(t, resp)
+		require.NotNil(t, expanded.Metadata.User)
+		assert.Empty(t, expanded.Metadata.User.Email)
+	})
+
+	t.Run("post lists", func(t *testing.T) {
+		r, err := Client.DoApiGet(Client.GetChannelRoute(th.BasicChannel.Id)+"/posts?expand=user", "")
+		require.Nil(t, err)
+		defer r.Body.Close()
+		assert.Empty(t, r.Header.Get(model.HEADER_ETAG_SERVER))
+
+		list := model.PostListFromJson(r.Body)
+		require.NotEmpty(t, list.Order)
+		for _, post := range list.Posts {
+			require.NotNil(t, post.Metadata)
+			require.NotNil(t, post.Metadata.User)
+			assert.Equal(t, post.UserId, post.Metadata.User.Id)
+		}
+	})
+}
+
 func TestDeletePost(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -1528,6 +2033,172 @@ func TestSearchPosts(t *testing.T) {
 	CheckUnauthorizedStatus(t, resp)
 }
 
+func TestSearchPostsInTeams(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	team2 := th.CreateTeam()
+	channel2 := th.CreateChannelWithClientAndTeam(Client, model.CHANNEL_OPEN, team2.Id)
+
+	team3 := th.CreateTeam()
+	channel3 := th.CreateChannelWithClientAndTeam(Client, model.CHANNEL_OPEN, team3.Id)
+
+	post1 := th.CreateMessagePostWithClient(Client, th.BasicChannel, "crossteam search one")
+	post2 := th.CreateMessagePostWithClient(Client, channel2, "crossteam search two")
+	post3 := th.CreateMessagePostWithClient(Client, channel3, "crossteam search three")
+
+	otherTeam := th.CreateTeamWithClient(th.SystemAdminClient)
+	otherChannel := th.CreateChannelWithClientAndTeam(th.SystemAdminClient, model.CHANNEL_OPEN, otherTeam.Id)
+	otherPost := th.CreateMessagePostWithClient(th.SystemAdminClient, otherChannel, "crossteam search hidden")
+
+	terms := "crossteam"
+
+	t.Run("all teams", func(t *testing.T) {
+		results, resp := Client.SearchPostsInTeams(&model.SearchParameter{Terms: &terms})
+		CheckNoError(t, resp)
+
+		require.Len(t, results.Order, 3)
+		assert.Equal(t, []string{post3.Id, post2.Id, post1.Id}, results.Order)
+		assert.NotContains(t, results.Posts, otherPost.Id)
+
+		assert.Equal(t, th.BasicTeam.Id, results.TeamIds[post1.Id])
+		assert.Equal(t, team2.Id, results.TeamIds[post2.Id])
+		assert.Equal(t, team3.Id, results.TeamIds[post3.Id])
+	})
+
+	t.Run("selected teams", func(t *testing.T) {
+		results, resp := Client.SearchPostsInTeams(&model.SearchParameter{Terms: &terms, TeamIds: []string{th.BasicTeam.Id, team3.Id}})
+		CheckNoError(t, resp)
+
+		assert.Equal(t, []string{post3.Id, post1.Id}, results.Order)
+	})
+
+	t.Run("team the user can't view", func(t *testing.T) {
+		_, resp := Client.SearchPostsInTeams(&model.SearchParameter{Terms: &terms, TeamIds: []string{otherTeam.Id}})
+		CheckForbiddenStatus(t, resp)
+	})
+
+	t.Run("invalid team id", func(t *testing.T) {
+		_, resp := Client.SearchPostsInTeams(&model.SearchParameter{Terms: &terms, TeamIds: []string{"junk"}})
+		CheckBadRequestStatus(t, resp)
+	})
+
+	t.Run("missing terms", func(t *testing.T) {
+		_, resp := Client.SearchPostsInTeams(&model.SearchParameter{})
+		CheckBadRequestStatus(t, resp)
+	})
+
+	Client.Logout()
+	_, resp := Client.SearchPostsInTeams(&model.SearchParameter{Terms: &terms})
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestExportSearchResults(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	var posts []*model.Post
+	for i := 0; i < 5; i++ {
+		posts = append(posts, th.CreateMessagePostWithClient(Client, th.BasicChannel, fmt.Sprintf("exportable result %v", i)))
+	}
+
+	otherChannel, appErr := th.App.CreateChannel(&model.Channel{
+		TeamId:      th.BasicTeam.Id,
+		Name:        "exporthidden" + model.NewId(),
+		DisplayName: "Hidden",
+		Type:        model.CHANNEL_PRIVATE,
+		CreatorId:   th.BasicUser2.Id,
+	}, true)
+	require.Nil(t, appErr)
+	hiddenPost, appErr := th.App.CreatePost(&model.Post{ChannelId: otherChannel.Id, UserId: th.BasicUser2.Id, Message: "exportable hidden"}, otherChannel, false)
+	require.Nil(t, appErr)
+
+	terms := "exportable"
+
+	t.Run("jsonl", func(t *testing.T) {
+		data, resp := Client.ExportSearchResults(th.BasicTeam.Id, model.SEARCH_EXPORT_FORMAT_JSONL, &model.SearchParameter{Terms: &terms})
+		CheckNoError(t, resp)
+
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		require.Len(t, lines, 5)
+
+		var row model.PostSearchExportRow
+		require.Nil(t, json.Unmarshal([]byte(lines[0]), &row))
+		assert.Equal(t, posts[4].Id, row.PostId)
+		assert.Equal(t, th.BasicChannel.Name, row.ChannelName)
+		assert.Equal(t, th.BasicUser.Username, row.Username)
+		assert.Equal(t, th.BasicTeam.Id, row.TeamId)
+		assert.NotContains(t, string(data), hiddenPost.Id)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		data, resp := Client.ExportSearchResults(th.BasicTeam.Id, model.SEARCH_EXPORT_FORMAT_CSV, &model.SearchParameter{Terms: &terms})
+		CheckNoError(t, resp)
+
+		records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+		require.Nil(t, err)
+		require.Len(t, records, 6)
+		assert.Equal(t, model.PostSearchExportCSVHeader(), records[0])
+		assert.Equal(t, posts[4].Id, records[1][0])
+	})
+
+	t.Run("no results", func(t *testing.T) {
+		noMatch := "nothingmatchesthis"
+		data, resp := Client.ExportSearchResults(th.BasicTeam.Id, model.SEARCH_EXPORT_FORMAT_CSV, &model.SearchParameter{Terms: &noMatch})
+		CheckNoError(t, resp)
+
+		records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+		require.Nil(t, err)
+		assert.Len(t, records, 1)
+	})
+
+	t.Run("csv formulas are escaped", func(t *testing.T) {
+		th.CreateMessagePostWithClient(Client, th.BasicChannel, "=HYPERLINK(\"http://example.com\") formulaexport")
+
+		formulaTerms := "formulaexport"
+		data, resp := Client.ExportSearchResults(th.BasicTeam.Id, model.SEARCH_EXPORT_FORMAT_CSV, &model.SearchParameter{Terms: &formulaTerms})
+		CheckNoError(t, resp)
+
+		records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+		require.Nil(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, "'=HYPERLINK(\"http://example.com\") formulaexport", records[1][8])
+	})
+
+	t.Run("results are capped", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.SearchExportMaxResults = 2 })
+		defer th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.SearchExportMaxResults = model.SERVICE_SETTINGS_DEFAULT_SEARCH_EXPORT_MAX_RESULTS
+		})
+
+		data, resp := Client.ExportSearchResults(th.BasicTeam.Id, model.SEARCH_EXPORT_FORMAT_JSONL, &model.SearchParameter{Terms: &terms})
+		CheckNoError(t, resp)
+		assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 2)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		_, resp := Client.ExportSearchResults(th.BasicTeam.Id, "xml", &model.SearchParameter{Terms: &terms})
+		CheckBadRequestStatus(t, resp)
+	})
+
+	t.Run("missing terms", func(t *testing.T) {
+		_, resp := Client.ExportSearchResults(th.BasicTeam.Id, model.SEARCH_EXPORT_FORMAT_JSONL, &model.SearchParameter{})
+		CheckBadRequestStatus(t, resp)
+	})
+
+	t.Run("team the user can't view", func(t *testing.T) {
+		otherTeam := th.CreateTeamWithClient(th.SystemAdminClient)
+		_, resp := Client.ExportSearchResults(otherTeam.Id, model.SEARCH_EXPORT_FORMAT_JSONL, &model.SearchParameter{Terms: &terms})
+		CheckForbiddenStatus(t, resp)
+	})
+
+	Client.Logout()
+	_, resp := Client.ExportSearchResults(th.BasicTeam.Id, model.SEARCH_EXPORT_FORMAT_JSONL, &model.SearchParameter{Terms: &terms})
+	CheckUnauthorizedStatus(t, resp)
+}
+
 func TestSearchHashtagPosts(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -1754,6 +2425,77 @@ func TestSearchPostsWithDateFlags(t *testing.T) {
 	}
 }
 
+func TestSearchPostsWithDefaultLookback(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	oldPost := th.CreateMessagePostNoClient(th.BasicChannel, "lookback old", utils.MillisFromTime(time.Now().AddDate(0, 0, -100)))
+	newPost := th.CreateMessagePostWithClient(Client, th.BasicChannel, "lookback new")
+
+	t.Run("unlimited by default", func(t *testing.T) {
+		results, resp := Client.SearchPostsWithMatches(th.BasicTeam.Id, "lookback", false)
+		CheckNoError(t, resp)
+		assert.Len(t, results.Order, 2)
+		assert.Zero(t, results.SearchedAfter)
+	})
+
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.SearchDefaultLookbackDays = 90 })
+	defer th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.SearchDefaultLookbackDays = 0 })
+
+	t.Run("limited to recent posts", func(t *testing.T) {
+		results, resp := Client.SearchPostsWithMatches(th.BasicTeam.Id, "lookback", false)
+		CheckNoError(t, resp)
+		assert.Equal(t, []string{newPost.Id}, results.Order)
+		assert.True(t, results.SearchedAfter > oldPost.CreateAt)
+		assert.True(t, results.SearchedAfter < newPost.CreateAt)
+	})
+
+	t.Run("all time", func(t *testing.T) {
+		terms := "lookback"
+		results, resp := Client.SearchPostsAllTime(th.BasicTeam.Id, &model.SearchParameter{Terms: &terms})
+		CheckNoError(t, resp)
+		assert.Equal(t, []string{newPost.Id, oldPost.Id}, results.Order)
+		assert.Zero(t, results.SearchedAfter)
+	})
+
+	t.Run("explicit date filters aren't limited", func(t *testing.T) {
+		after := time.Now().AddDate(0, 0, -101).Format("2006-01-02")
+		results, resp := Client.SearchPostsWithMatches(th.BasicTeam.Id, "lookback after:"+after, false)
+		CheckNoError(t, resp)
+		assert.Len(t, results.Order, 2)
+		assert.Zero(t, results.SearchedAfter)
+	})
+}
+
+func TestGetPostHereMentions(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	th.App.AddStatusCacheSkipClusterSend(&model.Status{UserId: th.BasicUser2.Id, Status: model.STATUS_ONLINE})
+
+	post, resp := Client.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "@here standup"})
+	CheckNoError(t, resp)
+
+	mentions, resp := Client.GetPostHereMentions(post.Id)
+	CheckNoError(t, resp)
+	assert.Equal(t, post.Id, mentions.PostId)
+	assert.Contains(t, mentions.UserIds, th.BasicUser2.Id)
+	assert.NotContains(t, mentions.UserIds, th.BasicUser.Id)
+
+	_, resp = Client.GetPostHereMentions(th.BasicPost.Id)
+	CheckNotFoundStatus(t, resp)
+
+	_, resp = Client.GetPostHereMentions("junk")
+	CheckBadRequestStatus(t, resp)
+
+	privateChannel := th.CreateChannelWithClient(th.SystemAdminClient, model.CHANNEL_PRIVATE)
+	privatePost := th.CreatePostWithClient(th.SystemAdminClient, privateChannel)
+	_, resp = Client.GetPostHereMentions(privatePost.Id)
+	CheckForbiddenStatus(t, resp)
+}
+
 func TestGetFileInfosForPost(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -13,6 +13,9 @@ func (api *API) InitPreference() {
 	api.BaseRoutes.Preferences.Handle("", api.ApiSessionRequired(getPreferences)).Methods("GET")
 	api.BaseRoutes.Preferences.Handle("", api.ApiSessionRequired(updatePreferences)).Methods("PUT")
 	api.BaseRoutes.Preferences.Handle("/delete", api.ApiSessionRequired(deletePreferences)).Methods("POST")
+	api.BaseRoutes.Preferences.Handle("/sync", api.ApiSessionRequired(syncPreferences)).Methods("POST")
+	api.BaseRoutes.Preferences.Handle("/export", api.ApiSessionRequired(exportPreferences)).Methods("GET")
+	api.BaseRoutes.Preferences.Handle("/import", api.ApiSessionRequired(importPreferences)).Methods("POST")
 	api.BaseRoutes.Preferences.Handle("/{category:[A-Za-z0-9_]+}", api.ApiSessionRequired(getPreferencesByCategory)).Methods("GET")
 	api.BaseRoutes.Preferences.Handle("/{category:[A-Za-z0-9_]+}/name/{preference_name:[A-Za-z0-9_]+}", api.ApiSessionRequired(getPreferenceByCategoryAndName)).Methods("GET")
 }
@@ -94,6 +97,102 @@ func updatePreferences(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sanitizedPreferences := sanitizePreferences(c, preferences)
+	if c.Err != nil {
+		return
+	}
+
+	if err := c.App.UpdatePreferences(c.Params.UserId, sanitizedPreferences); err != nil {
+		c.Err = err
+		return
+	}
+
+	ReturnStatusOK(w)
+}
+
+func syncPreferences(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireUserId()
+	if c.Err != nil {
+		return
+	}
+
+	if !c.App.SessionHasPermissionToUser(c.App.Session, c.Params.UserId) {
+		c.SetPermissionError(model.PERMISSION_EDIT_OTHER_USERS)
+		return
+	}
+
+	preferences, err := model.PreferencesFromJson(r.Body)
+	if err != nil {
+		c.SetInvalidParam("preferences")
+		return
+	}
+
+	sanitizedPreferences := sanitizePreferences(c, preferences)
+	if c.Err != nil {
+		return
+	}
+
+	synced, appErr := c.App.SyncPreferences(c.Params.UserId, sanitizedPreferences)
+	if appErr != nil {
+		c.Err = appErr
+		return
+	}
+
+	w.Write([]byte(synced.ToJson()))
+}
+
+func exportPreferences(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireUserId()
+	if c.Err != nil {
+		return
+	}
+
+	if !c.App.SessionHasPermissionToUser(c.App.Session, c.Params.UserId) {
+		c.SetPermissionError(model.PERMISSION_EDIT_OTHER_USERS)
+		return
+	}
+
+	export, err := c.App.ExportUserPreferences(c.Params.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(export.ToJson()))
+}
+
+func importPreferences(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireUserId()
+	if c.Err != nil {
+		return
+	}
+
+	if !c.App.SessionHasPermissionToUser(c.App.Session, c.Params.UserId) {
+		c.SetPermissionError(model.PERMISSION_EDIT_OTHER_USERS)
+		return
+	}
+
+	export := model.UserPreferencesExportFromJson(r.Body)
+	if export == nil {
+		c.SetInvalidParam("export")
+		return
+	}
+
+	imported, err := c.App.ImportUserPreferences(c.Params.UserId, export)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if c.Params.UserId != c.App.Session.UserId {
+		c.LogAudit("imported preferences for user_id=" + c.Params.UserId)
+	}
+
+	w.Write([]byte(imported.ToJson()))
+}
+
+// sanitizePreferences checks that the session can read the posts that are flagged by any of the preferences.
+func sanitizePreferences(c *Context, preferences model.Preferences) model.Preferences {
 	var sanitizedPreferences model.Preferences
 
 	for _, pref := range preferences {
@@ -101,24 +200,19 @@ func updatePreferences(c *Context, w http.ResponseWriter, r *http.Request) {
 			post, err := c.App.GetSinglePost(pref.Name)
 			if err != nil {
 				c.SetInvalidParam("preference.name")
-				return
+				return nil
 			}
 
 			if !c.App.SessionHasPermissionToChannel(c.App.Session, post.ChannelId, model.PERMISSION_READ_CHANNEL) {
 				c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
-				return
+				return nil
 			}
 		}
 
 		sanitizedPreferences = append(sanitizedPreferences, pref)
 	}
 
-	if err := c.App.UpdatePreferences(c.Params.UserId, sanitizedPreferences); err != nil {
-		c.Err = err
-		return
-	}
-
-	ReturnStatusOK(w)
+	return sanitizedPreferences
 }
 
 func deletePreferences(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -8,6 +8,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/mattermost/mattermost-server/model"
 )
 
@@ -302,6 +305,93 @@ func TestUpdatePreferencesWebsocket(t *testing.T) {
 	}
 }
 
+func TestSyncPreferences(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	userId := th.BasicUser.Id
+	category := model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS
+
+	_, resp := Client.UpdatePreferences(userId, &model.Preferences{{UserId: userId, Category: category, Name: "a", Value: "server"}})
+	CheckNoError(t, resp)
+
+	stored, resp := Client.GetPreferenceByCategoryAndName(userId, category, "a")
+	CheckNoError(t, resp)
+	require.NotZero(t, stored.UpdateAt)
+
+	t.Run("stale changes don't overwrite newer ones", func(t *testing.T) {
+		synced, resp := Client.SyncPreferences(userId, &model.Preferences{
+			{UserId: userId, Category: category, Name: "a", Value: "stale", UpdateAt: stored.UpdateAt - 1},
+			{UserId: userId, Category: category, Name: "b", Value: "device", UpdateAt: stored.UpdateAt - 1},
+		})
+		CheckNoError(t, resp)
+		require.Len(t, synced, 2)
+
+		values := map[string]string{}
+		for _, preference := range synced {
+			values[preference.Name] = preference.Value
+		}
+		assert.Equal(t, map[string]string{"a": "server", "b": "device"}, values)
+	})
+
+	t.Run("newer changes are saved", func(t *testing.T) {
+		synced, resp := Client.SyncPreferences(userId, &model.Preferences{
+			{UserId: userId, Category: category, Name: "a", Value: "device", UpdateAt: stored.UpdateAt + 1},
+		})
+		CheckNoError(t, resp)
+		require.Len(t, synced, 1)
+		assert.Equal(t, "device", synced[0].Value)
+		assert.Equal(t, stored.UpdateAt+1, synced[0].UpdateAt)
+	})
+
+	t.Run("other users' preferences can't be synced", func(t *testing.T) {
+		_, resp := Client.SyncPreferences(th.BasicUser2.Id, &model.Preferences{{UserId: th.BasicUser2.Id, Category: category, Name: "a"}})
+		CheckForbiddenStatus(t, resp)
+	})
+}
+
+func TestExportImportPreferences(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	userId := th.BasicUser.Id
+	_, resp := Client.UpdatePreferences(userId, &model.Preferences{
+		{UserId: userId, Category: model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS, Name: model.PREFERENCE_NAME_USE_MILITARY_TIME, Value: "true"},
+	})
+	CheckNoError(t, resp)
+
+	export, resp := Client.ExportPreferences(userId)
+	CheckNoError(t, resp)
+	require.NotNil(t, export)
+	require.Len(t, export.Preferences, 1)
+
+	t.Run("other users' preferences can't be exported or imported", func(t *testing.T) {
+		_, resp := Client.ExportPreferences(th.BasicUser2.Id)
+		CheckForbiddenStatus(t, resp)
+
+		_, resp = Client.ImportPreferences(th.BasicUser2.Id, export)
+		CheckForbiddenStatus(t, resp)
+	})
+
+	t.Run("admins can copy preferences between users", func(t *testing.T) {
+		imported, resp := th.SystemAdminClient.ImportPreferences(th.BasicUser2.Id, export)
+		CheckNoError(t, resp)
+		require.Len(t, imported, 1)
+		assert.Equal(t, th.BasicUser2.Id, imported[0].UserId)
+
+		preference, resp := th.SystemAdminClient.GetPreferenceByCategoryAndName(th.BasicUser2.Id, model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS, model.PREFERENCE_NAME_USE_MILITARY_TIME)
+		CheckNoError(t, resp)
+		assert.Equal(t, "true", preference.Value)
+	})
+
+	t.Run("unsupported versions", func(t *testing.T) {
+		_, resp := Client.ImportPreferences(userId, &model.UserPreferencesExport{Version: 0})
+		CheckBadRequestStatus(t, resp)
+	})
+}
+
 func TestDeletePreferences(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
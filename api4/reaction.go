@@ -5,15 +5,19 @@ package api4
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
 )
 
 func (api *API) InitReaction() {
 	api.BaseRoutes.Reactions.Handle("", api.ApiSessionRequired(saveReaction)).Methods("POST")
+	api.BaseRoutes.Reactions.Handle("/bulk", api.ApiSessionRequired(saveBulkReaction)).Methods("POST")
 	api.BaseRoutes.Post.Handle("/reactions", api.ApiSessionRequired(getReactions)).Methods("GET")
 	api.BaseRoutes.ReactionByNameForPostForUser.Handle("", api.ApiSessionRequired(deleteReaction)).Methods("DELETE")
 	api.BaseRoutes.Posts.Handle("/ids/reactions", api.ApiSessionRequired(getBulkReactions)).Methods("POST")
+	api.BaseRoutes.Channel.Handle("/reactions/{emoji_name:[A-Za-z0-9\\_\\-\\+]+}/users", api.ApiSessionRequired(getUsersWhoReactedInChannel)).Methods("GET")
 }
 
 func saveReaction(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -47,6 +51,59 @@ func saveReaction(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(reaction.ToJson()))
 }
 
+func saveBulkReaction(c *Context, w http.ResponseWriter, r *http.Request) {
+	bulkReaction := model.BulkReactionFromJson(r.Body)
+	if bulkReaction == nil {
+		c.SetInvalidParam("bulk_reaction")
+		return
+	}
+
+	if err := bulkReaction.IsValid(); err != nil {
+		c.Err = err
+		return
+	}
+
+	if bulkReaction.UserId != c.App.Session.UserId {
+		c.Err = model.NewAppError("saveBulkReaction", "api.reaction.save_reaction.user_id.app_error", nil, "", http.StatusForbidden)
+		return
+	}
+
+	postIds := model.RemoveDuplicateStrings(bulkReaction.PostIds)
+
+	// Posts that the user can't react to are reported individually rather than failing the whole request.
+	results := make([]*model.BulkReactionResult, len(postIds))
+	allowedPostIds := []string{}
+	for i, postId := range postIds {
+		if !c.App.SessionHasPermissionToChannelByPost(c.App.Session, postId, model.PERMISSION_ADD_REACTION) {
+			err := model.NewAppError("saveBulkReaction", "api.context.permissions.app_error", nil, "permission="+model.PERMISSION_ADD_REACTION.Id, http.StatusForbidden)
+			results[i] = &model.BulkReactionResult{PostId: postId, Error: err}
+			continue
+		}
+		allowedPostIds = append(allowedPostIds, postId)
+	}
+
+	savedResults, err := c.App.SaveReactionForPosts(bulkReaction.UserId, bulkReaction.EmojiName, allowedPostIds)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	for i := range results {
+		if results[i] == nil {
+			results[i] = savedResults[0]
+			savedResults = savedResults[1:]
+		}
+
+		// Per-post errors are sanitized the same way as request errors.
+		if err := results[i].Error; err != nil {
+			err.Translate(c.App.T)
+			utils.SanitizeAppError(c.App.Config(), err)
+		}
+	}
+
+	w.Write([]byte(model.BulkReactionResultsToJson(results)))
+}
+
 func getReactions(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.RequirePostId()
 	if c.Err != nil {
@@ -124,3 +181,37 @@ func getBulkReactions(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	w.Write([]byte(model.MapPostIdToReactionsToJson(reactions)))
 }
+
+func getUsersWhoReactedInChannel(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireChannelId().RequireEmojiName()
+	if c.Err != nil {
+		return
+	}
+
+	var since int64
+	if sinceString := r.URL.Query().Get("since"); len(sinceString) > 0 {
+		var err error
+		if since, err = strconv.ParseInt(sinceString, 10, 64); err != nil || since < 0 {
+			c.SetInvalidUrlParam("since")
+			return
+		}
+	}
+
+	if !c.App.SessionHasPermissionToChannel(c.App.Session, c.Params.ChannelId, model.PERMISSION_READ_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
+		return
+	}
+
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	users, err := c.App.GetUsersWhoReactedInChannel(c.Params.ChannelId, c.Params.EmojiName, since, pagination.Page, pagination.PerPage, c.IsSystemAdmin())
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(model.UserListToJson(users)))
+}
@@ -4,10 +4,12 @@
 package api4
 
 import (
+	"net/http"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/mattermost/mattermost-server/model"
 )
@@ -224,6 +226,81 @@ func TestSaveReaction(t *testing.T) {
 	})
 }
 
+func TestSaveBulkReaction(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	post1 := th.CreatePost()
+	post2 := th.CreatePost()
+
+	otherChannel, err := th.App.CreateChannel(&model.Channel{
+		TeamId:      th.BasicTeam.Id,
+		DisplayName: "Other",
+		Name:        GenerateTestChannelName(),
+		Type:        model.CHANNEL_PRIVATE,
+	}, false)
+	require.Nil(t, err)
+	th.LinkUserToTeam(th.SystemAdminUser, th.BasicTeam)
+	th.AddUserToChannel(th.SystemAdminUser, otherChannel)
+	otherPost, err := th.App.CreatePost(&model.Post{UserId: th.SystemAdminUser.Id, ChannelId: otherChannel.Id, Message: "hidden"}, otherChannel, false)
+	require.Nil(t, err)
+
+	bulkReaction := &model.BulkReaction{
+		UserId:    th.BasicUser.Id,
+		EmojiName: "smile",
+		PostIds:   []string{post1.Id, otherPost.Id, post2.Id},
+	}
+
+	results, resp := Client.SaveBulkReaction(bulkReaction)
+	CheckNoError(t, resp)
+	require.Len(t, results, 3)
+
+	for i, postId := range bulkReaction.PostIds {
+		assert.Equal(t, postId, results[i].PostId)
+	}
+
+	require.NotNil(t, results[0].Reaction)
+	assert.Nil(t, results[0].Error)
+	assert.Equal(t, "smile", results[0].Reaction.EmojiName)
+
+	assert.Nil(t, results[1].Reaction)
+	require.NotNil(t, results[1].Error)
+	assert.Equal(t, http.StatusForbidden, results[1].Error.StatusCode)
+
+	require.NotNil(t, results[2].Reaction)
+	assert.Equal(t, post2.Id, results[2].Reaction.PostId)
+
+	reactions, err := th.App.GetReactionsForPost(post2.Id)
+	require.Nil(t, err)
+	assert.Len(t, reactions, 1)
+
+	reactions, err = th.App.GetReactionsForPost(otherPost.Id)
+	require.Nil(t, err)
+	assert.Empty(t, reactions)
+
+	t.Run("unknown emoji", func(t *testing.T) {
+		_, resp := Client.SaveBulkReaction(&model.BulkReaction{UserId: th.BasicUser.Id, EmojiName: "notanemoji" + model.NewId(), PostIds: []string{post1.Id}})
+		CheckBadRequestStatus(t, resp)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		_, resp := Client.SaveBulkReaction(&model.BulkReaction{UserId: th.BasicUser.Id, EmojiName: "smile"})
+		CheckBadRequestStatus(t, resp)
+	})
+
+	t.Run("another user", func(t *testing.T) {
+		_, resp := Client.SaveBulkReaction(&model.BulkReaction{UserId: th.BasicUser2.Id, EmojiName: "smile", PostIds: []string{post1.Id}})
+		CheckForbiddenStatus(t, resp)
+	})
+
+	t.Run("logged out", func(t *testing.T) {
+		Client.Logout()
+		_, resp := Client.SaveBulkReaction(bulkReaction)
+		CheckUnauthorizedStatus(t, resp)
+	})
+}
+
 func TestGetReactions(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -649,3 +726,78 @@ func TestGetBulkReactions(t *testing.T) {
 		CheckUnauthorizedStatus(t, resp)
 	})
 }
+
+func TestGetUsersWhoReactedInChannel(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	post1 := th.CreatePost()
+	post2 := th.CreatePost()
+
+	reactions := []*model.Reaction{
+		{UserId: th.BasicUser2.Id, PostId: post1.Id, EmojiName: "thumbsup"},
+		{UserId: th.BasicUser.Id, PostId: post2.Id, EmojiName: "thumbsup"},
+		{UserId: th.BasicUser2.Id, PostId: post2.Id, EmojiName: "thumbsup"},
+		{UserId: th.BasicUser.Id, PostId: post1.Id, EmojiName: "smile"},
+	}
+	for i, reaction := range reactions {
+		reaction.CreateAt = int64(1000 * (i + 1))
+		_, err := th.App.SaveReactionForPost(reaction)
+		require.Nil(t, err)
+	}
+
+	users, resp := Client.GetUsersWhoReactedInChannel(th.BasicChannel.Id, "thumbsup", 0, 0, 60)
+	CheckNoError(t, resp)
+	require.Len(t, users, 2)
+	assert.Equal(t, th.BasicUser2.Id, users[0].Id)
+	assert.Equal(t, th.BasicUser.Id, users[1].Id)
+
+	t.Run("since", func(t *testing.T) {
+		users, resp := Client.GetUsersWhoReactedInChannel(th.BasicChannel.Id, "thumbsup", 2500, 0, 60)
+		CheckNoError(t, resp)
+		require.Len(t, users, 1)
+		assert.Equal(t, th.BasicUser2.Id, users[0].Id)
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		users, resp := Client.GetUsersWhoReactedInChannel(th.BasicChannel.Id, "thumbsup", 0, 1, 1)
+		CheckNoError(t, resp)
+		require.Len(t, users, 1)
+		assert.Equal(t, th.BasicUser.Id, users[0].Id)
+	})
+
+	t.Run("profiles are sanitized", func(t *testing.T) {
+		users, resp := Client.GetUsersWhoReactedInChannel(th.BasicChannel.Id, "thumbsup", 0, 0, 60)
+		CheckNoError(t, resp)
+		for _, user := range users {
+			assert.Empty(t, user.Password)
+			assert.Empty(t, user.AuthData)
+		}
+	})
+
+	t.Run("no access to channel", func(t *testing.T) {
+		privateChannel, err := th.App.CreateChannel(&model.Channel{
+			TeamId:      th.BasicTeam.Id,
+			DisplayName: "Private",
+			Name:        GenerateTestChannelName(),
+			Type:        model.CHANNEL_PRIVATE,
+		}, false)
+		require.Nil(t, err)
+
+		_, resp := Client.GetUsersWhoReactedInChannel(privateChannel.Id, "thumbsup", 0, 0, 60)
+		CheckForbiddenStatus(t, resp)
+	})
+
+	t.Run("invalid since", func(t *testing.T) {
+		resp, err := Client.DoApiGet(Client.GetChannelRoute(th.BasicChannel.Id)+"/reactions/thumbsup/users?since=yesterday", "")
+		require.NotNil(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("logged out", func(t *testing.T) {
+		Client.Logout()
+		_, resp := Client.GetUsersWhoReactedInChannel(th.BasicChannel.Id, "thumbsup", 0, 0, 60)
+		CheckUnauthorizedStatus(t, resp)
+	})
+}
@@ -78,7 +78,12 @@ func getSchemes(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	schemes, err := c.App.GetSchemesPage(c.Params.Scope, c.Params.Page, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	schemes, err := c.App.GetSchemesPage(c.Params.Scope, pagination.Page, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
@@ -109,7 +114,12 @@ func getTeamsForScheme(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	teams, err := c.App.GetTeamsForSchemePage(scheme, c.Params.Page, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	teams, err := c.App.GetTeamsForSchemePage(scheme, pagination.Page, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
@@ -140,7 +150,12 @@ func getChannelsForScheme(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	channels, err := c.App.GetChannelsForSchemePage(scheme, c.Params.Page, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	channels, err := c.App.GetChannelsForSchemePage(scheme, pagination.Page, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
@@ -10,11 +10,14 @@ import (
 	"io"
 	"net/http"
 	"runtime"
+	"strconv"
+	"time"
 
 	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/model"
 	"github.com/mattermost/mattermost-server/services/filesstore"
 	"github.com/mattermost/mattermost-server/utils"
+	"github.com/mattermost/mattermost-server/web"
 )
 
 const REDIRECT_LOCATION_CACHE_SIZE = 10000
@@ -22,25 +25,33 @@ const REDIRECT_LOCATION_CACHE_SIZE = 10000
 var redirectLocationDataCache = utils.NewLru(REDIRECT_LOCATION_CACHE_SIZE)
 
 func (api *API) InitSystem() {
-	api.BaseRoutes.System.Handle("/ping", api.ApiHandler(getSystemPing)).Methods("GET")
+	api.BaseRoutes.System.Handle("/ping", api.ApiCriticalHandler(getSystemPing)).Methods("GET")
+	api.BaseRoutes.System.Handle("/ready", api.ApiCriticalHandler(web.ServeReadiness)).Methods("GET")
+	api.BaseRoutes.System.Handle("/status", api.ApiSystemAdminRequired(getSystemStatus)).Methods("GET")
 
 	api.BaseRoutes.System.Handle("/timezones", api.ApiSessionRequired(getSupportedTimezones)).Methods("GET")
+	api.BaseRoutes.System.Handle("/maintenance_mode", api.ApiSystemAdminRequired(getMaintenanceMode)).Methods("GET")
+	api.BaseRoutes.System.Handle("/maintenance_mode", api.ApiSystemAdminRequired(patchMaintenanceMode)).Methods("PUT")
+	api.BaseRoutes.System.Handle("/repair_counts", api.ApiSystemAdminRequired(repairCounts)).Methods("POST")
 
 	api.BaseRoutes.ApiRoot.Handle("/config", api.ApiSessionRequired(getConfig)).Methods("GET")
 	api.BaseRoutes.ApiRoot.Handle("/config", api.ApiSessionRequired(updateConfig)).Methods("PUT")
 	api.BaseRoutes.ApiRoot.Handle("/config/reload", api.ApiSessionRequired(configReload)).Methods("POST")
-	api.BaseRoutes.ApiRoot.Handle("/config/client", api.ApiHandler(getClientConfig)).Methods("GET")
+	api.BaseRoutes.ApiRoot.Handle("/config/client", api.ApiHandlerWithResponseCache(model.RESPONSE_CACHE_ENDPOINT_CLIENT_CONFIG, getClientConfig)).Methods("GET")
 	api.BaseRoutes.ApiRoot.Handle("/config/environment", api.ApiSessionRequired(getEnvironmentConfig)).Methods("GET")
 
-	api.BaseRoutes.ApiRoot.Handle("/license", api.ApiSessionRequired(addLicense)).Methods("POST")
+	api.BaseRoutes.ApiRoot.Handle("/license", api.ApiSessionRequiredUpload(0, addLicense)).Methods("POST")
 	api.BaseRoutes.ApiRoot.Handle("/license", api.ApiSessionRequired(removeLicense)).Methods("DELETE")
 	api.BaseRoutes.ApiRoot.Handle("/license/client", api.ApiHandler(getClientLicense)).Methods("GET")
 
 	api.BaseRoutes.ApiRoot.Handle("/audits", api.ApiSessionRequired(getAudits)).Methods("GET")
 	api.BaseRoutes.ApiRoot.Handle("/email/test", api.ApiSessionRequired(testEmail)).Methods("POST")
 	api.BaseRoutes.ApiRoot.Handle("/file/s3_test", api.ApiSessionRequired(testS3)).Methods("POST")
+	api.BaseRoutes.ApiRoot.Handle("/file/orphaned/cleanup", api.ApiSystemAdminRequired(cleanupOrphanedFiles)).Methods("POST")
+	api.BaseRoutes.ApiRoot.Handle("/memberships/orphaned/cleanup", api.ApiSystemAdminRequired(cleanupOrphanedMemberships)).Methods("POST")
 	api.BaseRoutes.ApiRoot.Handle("/database/recycle", api.ApiSessionRequired(databaseRecycle)).Methods("POST")
 	api.BaseRoutes.ApiRoot.Handle("/caches/invalidate", api.ApiSessionRequired(invalidateCaches)).Methods("POST")
+	api.BaseRoutes.ApiRoot.Handle("/integrations/circuit_breakers", api.ApiSystemAdminRequired(getIntegrationCircuitBreakers)).Methods("GET")
 
 	api.BaseRoutes.ApiRoot.Handle("/logs", api.ApiSessionRequired(getLogs)).Methods("GET")
 	api.BaseRoutes.ApiRoot.Handle("/logs", api.ApiHandler(postLog)).Methods("POST")
@@ -77,6 +88,10 @@ func getSystemPing(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func getSystemStatus(c *Context, w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(c.App.GetSystemStatus().ToJson()))
+}
+
 func testEmail(c *Context, w http.ResponseWriter, r *http.Request) {
 	cfg := model.ConfigFromJson(r.Body)
 	if cfg == nil {
@@ -170,7 +185,12 @@ func getAudits(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	audits, err := c.App.GetAuditsPage("", c.Params.Page, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	audits, err := c.App.GetAuditsPage("", pagination.Page, pagination.PerPage)
 
 	if err != nil {
 		c.Err = err
@@ -180,6 +200,64 @@ func getAudits(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(audits.ToJson()))
 }
 
+func getIntegrationCircuitBreakers(c *Context, w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(model.CircuitBreakerStatusListToJson(c.App.HTTPService.GetCircuitBreakerStatuses())))
+}
+
+func getMaintenanceMode(c *Context, w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Write([]byte(c.App.GetMaintenanceMode().ToJson()))
+}
+
+func patchMaintenanceMode(c *Context, w http.ResponseWriter, r *http.Request) {
+	// Strict so that a misspelled field isn't taken for a patch that changes nothing
+	var patch *model.MaintenanceModePatch
+	if !c.DecodeJSONBody(r, &patch, true, nil) {
+		return
+	}
+	if patch == nil {
+		c.SetInvalidParam("maintenance_mode")
+		return
+	}
+
+	maintenanceMode, err := c.App.PatchMaintenanceMode(patch)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("enable=" + strconv.FormatBool(maintenanceMode.Enable))
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Write([]byte(maintenanceMode.ToJson()))
+}
+
+func repairCounts(c *Context, w http.ResponseWriter, r *http.Request) {
+	var request *model.CountRepairRequest
+	if !c.DecodeJSONBody(r, &request, true, nil) {
+		return
+	}
+	if request == nil {
+		c.SetInvalidParam("count_repair_request")
+		return
+	}
+
+	if err := request.IsValid(); err != nil {
+		c.Err = err
+		return
+	}
+
+	job, err := c.App.CreateCountRepairJob(request, c.App.Session.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("team_id=" + request.TeamId + " channel_id=" + request.ChannelId + " dry_run=" + strconv.FormatBool(request.DryRun) + " job_id=" + job.Id)
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(job.ToJson()))
+}
+
 func databaseRecycle(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	if !c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_SYSTEM) {
@@ -192,6 +270,71 @@ func databaseRecycle(c *Context, w http.ResponseWriter, r *http.Request) {
 	ReturnStatusOK(w)
 }
 
+func cleanupOrphanedFiles(c *Context, w http.ResponseWriter, r *http.Request) {
+	// Nothing is deleted unless it's explicitly asked for
+	dryRun := true
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			c.SetInvalidUrlParam("dry_run")
+			return
+		}
+	}
+
+	gracePeriodHours := model.ORPHANED_FILES_DEFAULT_GRACE_PERIOD_HOURS
+	if value := r.URL.Query().Get("grace_period_hours"); value != "" {
+		var err error
+		if gracePeriodHours, err = strconv.Atoi(value); err != nil || gracePeriodHours < model.ORPHANED_FILES_MIN_GRACE_PERIOD_HOURS {
+			c.SetInvalidUrlParam("grace_period_hours")
+			return
+		}
+	}
+
+	if !dryRun {
+		c.LogAudit("attempt")
+	}
+
+	report, err := c.App.CleanupOrphanedFiles(dryRun, time.Duration(gracePeriodHours)*time.Hour)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if !dryRun {
+		c.LogAudit(fmt.Sprintf("file_infos=%v paths=%v", len(report.FileInfos), len(report.Paths)))
+	}
+
+	w.Write([]byte(report.ToJson()))
+}
+
+func cleanupOrphanedMemberships(c *Context, w http.ResponseWriter, r *http.Request) {
+	// Nothing is deleted unless it's explicitly asked for
+	dryRun := true
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			c.SetInvalidUrlParam("dry_run")
+			return
+		}
+	}
+
+	if !dryRun {
+		c.LogAudit("attempt")
+	}
+
+	report, err := c.App.CleanupOrphanedMemberships(dryRun, c.App.Session.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if !dryRun {
+		c.LogAudit(fmt.Sprintf("channel_members=%v team_members=%v", len(report.ChannelMembers), len(report.TeamMembers)))
+	}
+
+	w.Write([]byte(report.ToJson()))
+}
+
 func invalidateCaches(c *Context, w http.ResponseWriter, r *http.Request) {
 	if !c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_SYSTEM) {
 		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
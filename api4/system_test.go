@@ -7,9 +7,12 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/mattermost/mattermost-server/app"
 	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/store"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -38,6 +41,50 @@ func TestGetPing(t *testing.T) {
 	}
 }
 
+func TestMethodNotAllowed(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	t.Run("a route registered for other methods", func(t *testing.T) {
+		resp, err := Client.DoApiPost("/system/ping", "")
+		require.NotNil(t, err)
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+		assert.Equal(t, "api.context.method_not_allowed.app_error", err.Id)
+		assert.Equal(t, "GET", resp.Header.Get("Allow"))
+	})
+
+	t.Run("a route registered for several methods", func(t *testing.T) {
+		resp, err := Client.DoApiRequest(http.MethodPatch, Client.ApiUrl+"/users/"+th.BasicUser.Id, "", "")
+		require.NotNil(t, err)
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+		assert.Equal(t, "DELETE, GET, PUT", resp.Header.Get("Allow"))
+	})
+
+	t.Run("a route that doesn't exist", func(t *testing.T) {
+		resp, err := Client.DoApiPost("/system/missing", "")
+		require.NotNil(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Allow"))
+	})
+}
+
+func TestGetReady(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	status, resp := th.Client.GetReady()
+	CheckNoError(t, resp)
+	assert.Equal(t, model.STATUS_OK, status)
+
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.PluginSettings.Enable = true })
+	th.App.ShutDownPlugins()
+
+	status, resp = th.Client.GetReady()
+	checkHTTPStatus(t, resp, http.StatusServiceUnavailable, true)
+	assert.Equal(t, model.STATUS_UNREADY, status)
+}
+
 func TestGetConfig(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -341,6 +388,39 @@ func TestGetOldClientConfig(t *testing.T) {
 	})
 }
 
+func TestGetClientConfigWithResponseCache(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		cfg.ServiceSettings.ResponseCacheEndpoints = []string{model.RESPONSE_CACHE_ENDPOINT_CLIENT_CONFIG}
+	})
+
+	_, resp := th.Client.GetOldClientConfig("")
+	CheckNoError(t, resp)
+	require.NotEmpty(t, resp.Etag)
+
+	_, notModifiedResp := th.Client.GetOldClientConfig(resp.Etag)
+	CheckNoError(t, notModifiedResp)
+	assert.Equal(t, http.StatusNotModified, notModifiedResp.StatusCode)
+
+	t.Run("anonymous and logged in users get different responses", func(t *testing.T) {
+		client := th.CreateClient()
+		_, anonymousResp := client.GetOldClientConfig("")
+		CheckNoError(t, anonymousResp)
+		assert.NotEqual(t, resp.Etag, anonymousResp.Etag)
+	})
+
+	t.Run("config changes invalidate the cache", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.TeamSettings.SiteName = "cached" })
+
+		config, updatedResp := th.Client.GetOldClientConfig(resp.Etag)
+		CheckNoError(t, updatedResp)
+		assert.Equal(t, "cached", config["SiteName"])
+		assert.NotEqual(t, resp.Etag, updatedResp.Etag)
+	})
+}
+
 func TestGetOldClientLicense(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -401,7 +481,7 @@ func TestGetAudits(t *testing.T) {
 	}
 
 	_, resp = th.SystemAdminClient.GetAudits(-1, -1, "")
-	CheckNoError(t, resp)
+	CheckBadRequestStatus(t, resp)
 
 	_, resp = Client.GetAudits(0, 100, "")
 	CheckForbiddenStatus(t, resp)
@@ -411,6 +491,92 @@ func TestGetAudits(t *testing.T) {
 	CheckUnauthorizedStatus(t, resp)
 }
 
+func TestGetSystemStatus(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	status, resp := th.SystemAdminClient.GetSystemStatus()
+	CheckNoError(t, resp)
+	assert.NotZero(t, status.Timestamp)
+	assert.NotZero(t, status.Goroutines)
+	require.NotNil(t, status.Memory)
+	assert.NotZero(t, status.Memory.Sys)
+
+	require.NotEmpty(t, status.DbPools)
+	assert.Equal(t, "master", status.DbPools[0].Name)
+	assert.NotZero(t, status.DbPools[0].OpenConnections)
+
+	var sessionCache *model.CacheStats
+	for _, cache := range status.Caches {
+		if cache.Name == "Session" {
+			sessionCache = cache
+		}
+	}
+	require.NotNil(t, sessionCache, "should include the session cache")
+	assert.NotZero(t, sessionCache.Hits)
+	assert.True(t, sessionCache.HitRate > 0 && sessionCache.HitRate <= 1)
+
+	_, resp = Client.GetSystemStatus()
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.GetSystemStatus()
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestGetIntegrationCircuitBreakers(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.EnableCommands = true
+		*cfg.ServiceSettings.AllowedUntrustedInternalConnections = "127.0.0.0/8"
+		*cfg.IntegrationHTTPSettings.SlashCommands.CircuitBreakerFailureThreshold = 2
+	})
+
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	_, err := th.App.CreateCommand(&model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       ts.URL + "/command",
+		Method:    model.COMMAND_METHOD_POST,
+		Trigger:   "failingcommand",
+	})
+	require.Nil(t, err)
+
+	breakers, resp := th.SystemAdminClient.GetIntegrationCircuitBreakers()
+	CheckNoError(t, resp)
+	assert.Empty(t, breakers)
+
+	for i := 0; i < 3; i++ {
+		_, resp = Client.ExecuteCommand(th.BasicChannel.Id, "/failingcommand")
+		require.NotNil(t, resp.Error)
+	}
+	assert.Equal(t, 2, requests)
+
+	breakers, resp = th.SystemAdminClient.GetIntegrationCircuitBreakers()
+	CheckNoError(t, resp)
+	require.Len(t, breakers, 1)
+	assert.Equal(t, ts.URL+"/command", breakers[0].Endpoint)
+	assert.Equal(t, model.CIRCUIT_BREAKER_STATE_OPEN, breakers[0].State)
+	assert.Equal(t, 2, breakers[0].ConsecutiveFailures)
+
+	_, resp = Client.GetIntegrationCircuitBreakers()
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.GetIntegrationCircuitBreakers()
+	CheckUnauthorizedStatus(t, resp)
+}
+
 func TestEmailTest(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -458,6 +624,248 @@ func TestDatabaseRecycle(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+func TestMaintenanceMode(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	_, resp := Client.GetMaintenanceMode()
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.PatchMaintenanceMode(&model.MaintenanceModePatch{Enable: model.NewBool(false)})
+	CheckForbiddenStatus(t, resp)
+
+	maintenanceMode, resp := th.SystemAdminClient.GetMaintenanceMode()
+	CheckNoError(t, resp)
+	assert.False(t, maintenanceMode.Enable)
+
+	maintenanceMode, resp = th.SystemAdminClient.PatchMaintenanceMode(&model.MaintenanceModePatch{
+		Enable:  model.NewBool(true),
+		Message: model.NewString("Migrating posts"),
+	})
+	CheckNoError(t, resp)
+	assert.True(t, maintenanceMode.Enable)
+	assert.Equal(t, "Migrating posts", maintenanceMode.Message)
+	assert.True(t, *th.App.Config().ServiceSettings.MaintenanceMode)
+
+	_, resp = Client.GetMe("")
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, "Migrating posts", resp.Error.Message)
+
+	_, resp = Client.GetPing()
+	CheckNoError(t, resp)
+
+	_, resp = th.SystemAdminClient.GetMe("")
+	CheckNoError(t, resp)
+
+	// Leaving out the message keeps it
+	maintenanceMode, resp = th.SystemAdminClient.PatchMaintenanceMode(&model.MaintenanceModePatch{Enable: model.NewBool(false)})
+	CheckNoError(t, resp)
+	assert.False(t, maintenanceMode.Enable)
+	assert.Equal(t, "Migrating posts", maintenanceMode.Message)
+
+	_, resp = Client.GetMe("")
+	CheckNoError(t, resp)
+
+	// Misspelled fields are rejected rather than ignored
+	_, err := th.SystemAdminClient.DoApiPut(th.SystemAdminClient.GetSystemRoute()+"/maintenance_mode", `{"enabled": true}`)
+	require.NotNil(t, err)
+	assert.Equal(t, "api.context.invalid_body_fields.app_error", err.Id)
+	assert.Equal(t, http.StatusBadRequest, err.StatusCode)
+}
+
+func TestRepairCounts(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	request := &model.CountRepairRequest{TeamId: th.BasicTeam.Id, DryRun: true}
+
+	_, resp := Client.RepairCounts(request)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.RepairCounts(&model.CountRepairRequest{TeamId: th.BasicTeam.Id, ChannelId: th.BasicChannel.Id})
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.RepairCounts(&model.CountRepairRequest{ChannelId: model.NewId()})
+	CheckNotFoundStatus(t, resp)
+
+	job, resp := th.SystemAdminClient.RepairCounts(request)
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+	assert.Equal(t, model.JOB_TYPE_COUNT_REPAIR, job.Type)
+	assert.Equal(t, th.BasicTeam.Id, job.Data["team_id"])
+	assert.Equal(t, "", job.Data["channel_id"])
+	assert.Equal(t, "true", job.Data["dry_run"])
+
+	job, resp = th.SystemAdminClient.RepairCounts(&model.CountRepairRequest{ChannelId: th.BasicChannel.Id})
+	CheckNoError(t, resp)
+	assert.Equal(t, th.BasicChannel.Id, job.Data["channel_id"])
+	assert.Equal(t, "false", job.Data["dry_run"])
+}
+
+func TestCleanupOrphanedFiles(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	_, resp := Client.CleanupOrphanedFiles(true, 0)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.CleanupOrphanedFiles(true, 0)
+	CheckNoError(t, resp)
+
+	_, err := th.SystemAdminClient.DoApiPost("/file/orphaned/cleanup?grace_period_hours=0", "")
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.StatusCode)
+
+	// A file uploaded a few days ago that was never posted, and one whose FileInfo is missing
+	uploadedAt := time.Now().AddDate(0, 0, -5)
+	pathPrefix := uploadedAt.Format("20060102") + "/teams/noteam/channels/" + th.BasicChannel.Id + "/users/" + th.BasicUser.Id + "/"
+
+	unattached := &model.FileInfo{
+		Id:        model.NewId(),
+		CreatorId: th.BasicUser.Id,
+		CreateAt:  model.GetMillisForTime(uploadedAt),
+		Name:      "unattached.txt",
+		Size:      4,
+	}
+	unattached.Path = pathPrefix + unattached.Id + "/unattached.txt"
+	_, appErr := th.App.WriteFile(strings.NewReader("test"), unattached.Path)
+	require.Nil(t, appErr)
+	store.Must(th.App.Srv.Store.FileInfo().Save(unattached))
+
+	missingDir := pathPrefix + model.NewId()
+	_, appErr = th.App.WriteFile(strings.NewReader("test"), missingDir+"/missing.txt")
+	require.Nil(t, appErr)
+
+	// A file that was just uploaded is still within the grace period
+	fileResp, resp := Client.UploadFile([]byte("test"), th.BasicChannel.Id, "recent.txt")
+	CheckNoError(t, resp)
+	recent := fileResp.FileInfos[0]
+
+	report, resp := th.SystemAdminClient.CleanupOrphanedFiles(true, 0)
+	CheckNoError(t, resp)
+	assert.True(t, report.DryRun)
+
+	reportedIds := map[string]bool{}
+	for _, info := range report.FileInfos {
+		reportedIds[info.Id] = true
+	}
+	assert.True(t, reportedIds[unattached.Id])
+	assert.False(t, reportedIds[recent.Id])
+	assert.Contains(t, report.Paths, missingDir)
+
+	exists, appErr := th.App.FileExists(unattached.Path)
+	require.Nil(t, appErr)
+	assert.True(t, exists)
+
+	report, resp = th.SystemAdminClient.CleanupOrphanedFiles(false, 0)
+	CheckNoError(t, resp)
+	assert.False(t, report.DryRun)
+
+	exists, appErr = th.App.FileExists(unattached.Path)
+	require.Nil(t, appErr)
+	assert.False(t, exists)
+
+	exists, appErr = th.App.FileExists(missingDir + "/missing.txt")
+	require.Nil(t, appErr)
+	assert.False(t, exists)
+
+	result := <-th.App.Srv.Store.FileInfo().Get(unattached.Id)
+	assert.NotNil(t, result.Err)
+
+	_, resp = Client.GetFileInfo(recent.Id)
+	CheckNoError(t, resp)
+}
+
+func TestCleanupOrphanedMemberships(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	_, resp := Client.CleanupOrphanedMemberships(true)
+	CheckForbiddenStatus(t, resp)
+
+	_, err := th.SystemAdminClient.DoApiPost("/memberships/orphaned/cleanup?dry_run=maybe", "")
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.StatusCode)
+
+	// Memberships left behind by a user and a channel that were deleted straight from the database
+	missingUserId := model.NewId()
+	store.Must(th.App.Srv.Store.Channel().SaveMember(&model.ChannelMember{
+		ChannelId:   th.BasicChannel.Id,
+		UserId:      missingUserId,
+		NotifyProps: model.GetDefaultChannelNotifyProps(),
+	}))
+	store.Must(th.App.Srv.Store.Team().SaveMember(&model.TeamMember{TeamId: th.BasicTeam.Id, UserId: missingUserId}, -1))
+
+	deleted := th.CreatePublicChannel()
+	store.Must(th.App.Srv.Store.Channel().PermanentDelete(deleted.Id))
+
+	hasMembers := func(report *model.OrphanedMembershipsReport) (bool, bool, bool) {
+		missingUserChannel, missingChannel, missingUserTeam := false, false, false
+		for _, member := range report.ChannelMembers {
+			if member.ChannelId == th.BasicChannel.Id && member.UserId == missingUserId {
+				missingUserChannel = !member.UserExists
+			}
+			if member.ChannelId == deleted.Id && member.UserId == th.BasicUser.Id {
+				missingChannel = !member.ChannelExists
+			}
+			assert.NotEqual(t, th.BasicUser2.Id, member.UserId)
+		}
+		for _, member := range report.TeamMembers {
+			if member.TeamId == th.BasicTeam.Id && member.UserId == missingUserId {
+				missingUserTeam = !member.UserExists
+			}
+		}
+		return missingUserChannel, missingChannel, missingUserTeam
+	}
+
+	report, resp := th.SystemAdminClient.CleanupOrphanedMemberships(true)
+	CheckNoError(t, resp)
+	assert.True(t, report.DryRun)
+
+	missingUserChannel, missingChannel, missingUserTeam := hasMembers(report)
+	assert.True(t, missingUserChannel)
+	assert.True(t, missingChannel)
+	assert.True(t, missingUserTeam)
+
+	store.Must(th.App.Srv.Store.Channel().GetMember(deleted.Id, th.BasicUser.Id))
+
+	report, resp = th.SystemAdminClient.CleanupOrphanedMemberships(false)
+	CheckNoError(t, resp)
+	assert.False(t, report.DryRun)
+
+	missingUserChannel, missingChannel, missingUserTeam = hasMembers(report)
+	assert.True(t, missingUserChannel)
+	assert.True(t, missingChannel)
+	assert.True(t, missingUserTeam)
+
+	result := <-th.App.Srv.Store.Channel().GetMember(deleted.Id, th.BasicUser.Id)
+	assert.NotNil(t, result.Err)
+	result = <-th.App.Srv.Store.Team().GetMember(th.BasicTeam.Id, missingUserId)
+	assert.NotNil(t, result.Err)
+
+	audits, appErr := th.App.GetAudits(th.SystemAdminUser.Id, 100)
+	require.Nil(t, appErr)
+	actions := make(map[string]int)
+	for _, audit := range audits {
+		actions[audit.Action]++
+	}
+	assert.True(t, actions[app.AUDIT_ACTION_ORPHANED_CHANNEL_MEMBER_DELETED] >= 2)
+	assert.True(t, actions[app.AUDIT_ACTION_ORPHANED_TEAM_MEMBER_DELETED] >= 1)
+
+	// Nothing is left to clean up
+	report, resp = th.SystemAdminClient.CleanupOrphanedMemberships(true)
+	CheckNoError(t, resp)
+	missingUserChannel, missingChannel, missingUserTeam = hasMembers(report)
+	assert.False(t, missingUserChannel)
+	assert.False(t, missingChannel)
+	assert.False(t, missingUserTeam)
+}
+
 func TestInvalidateCaches(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
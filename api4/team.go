@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/model"
 )
 
@@ -24,7 +25,7 @@ func (api *API) InitTeam() {
 	api.BaseRoutes.Teams.Handle("", api.ApiSessionRequired(createTeam)).Methods("POST")
 	api.BaseRoutes.Teams.Handle("", api.ApiSessionRequired(getAllTeams)).Methods("GET")
 	api.BaseRoutes.Teams.Handle("/{team_id:[A-Za-z0-9]+}/scheme", api.ApiSessionRequired(updateTeamScheme)).Methods("PUT")
-	api.BaseRoutes.Teams.Handle("/search", api.ApiSessionRequired(searchTeams)).Methods("POST")
+	api.BaseRoutes.Teams.Handle("/search", api.ApiSessionRequiredSearch(searchTeams)).Methods("POST")
 	api.BaseRoutes.TeamsForUser.Handle("", api.ApiSessionRequired(getTeamsForUser)).Methods("GET")
 	api.BaseRoutes.TeamsForUser.Handle("/unread", api.ApiSessionRequired(getTeamsUnreadForUser)).Methods("GET")
 
@@ -33,9 +34,27 @@ func (api *API) InitTeam() {
 	api.BaseRoutes.Team.Handle("", api.ApiSessionRequired(deleteTeam)).Methods("DELETE")
 	api.BaseRoutes.Team.Handle("/patch", api.ApiSessionRequired(patchTeam)).Methods("PUT")
 	api.BaseRoutes.Team.Handle("/stats", api.ApiSessionRequired(getTeamStats)).Methods("GET")
+	api.BaseRoutes.Team.Handle("/activity", api.ApiSessionRequired(getTeamActivitySummary)).Methods("GET")
+	api.BaseRoutes.Team.Handle("/storage_usage", api.ApiSessionRequired(getTeamStorageUsage)).Methods("GET")
+	api.BaseRoutes.Team.Handle("/storage_quota", api.ApiSystemAdminRequired(updateTeamStorageQuota)).Methods("PUT")
+	api.BaseRoutes.Team.Handle("/auto_join", api.ApiSystemAdminRequired(updateTeamAutoJoin)).Methods("PUT")
+	api.BaseRoutes.Team.Handle("/merge", api.ApiSystemAdminRequired(mergeTeam)).Methods("POST")
+	api.BaseRoutes.Team.Handle("/membership_limit", api.ApiSystemAdminRequired(updateTeamMembershipLimit)).Methods("PUT")
+	api.BaseRoutes.Team.Handle("/waitlist", api.ApiSessionRequired(getTeamWaitlist)).Methods("GET")
+	api.BaseRoutes.Team.Handle("/waitlist/{user_id:[A-Za-z0-9]+}/approve", api.ApiSessionRequired(approveTeamWaitlistEntry)).Methods("POST")
+	api.BaseRoutes.Team.Handle("/waitlist/{user_id:[A-Za-z0-9]+}", api.ApiSessionRequired(removeTeamWaitlistEntry)).Methods("DELETE")
+	api.BaseRoutes.Team.Handle("/invites", api.ApiSessionRequired(createTeamInvite)).Methods("POST")
+	api.BaseRoutes.Team.Handle("/invites", api.ApiSessionRequired(getTeamInvites)).Methods("GET")
+	api.BaseRoutes.Team.Handle("/invites/{invite_id:[A-Za-z0-9]+}", api.ApiSessionRequired(revokeTeamInvite)).Methods("DELETE")
+	api.BaseRoutes.Team.Handle("/channel_templates", api.ApiSessionRequired(createChannelTemplate)).Methods("POST")
+	api.BaseRoutes.Team.Handle("/channel_templates", api.ApiSessionRequired(getChannelTemplates)).Methods("GET")
+	api.BaseRoutes.Team.Handle("/channel_templates/{template_id:[A-Za-z0-9]+}", api.ApiSessionRequired(updateChannelTemplate)).Methods("PUT")
+	api.BaseRoutes.Team.Handle("/channel_templates/{template_id:[A-Za-z0-9]+}", api.ApiSessionRequired(deleteChannelTemplate)).Methods("DELETE")
+	api.BaseRoutes.Team.Handle("/channel_templates/{template_id:[A-Za-z0-9]+}/channels", api.ApiSessionRequired(createChannelFromTemplate)).Methods("POST")
+	api.BaseRoutes.Team.Handle("/access_graph", api.ApiSessionRequired(getTeamAccessGraph)).Methods("GET")
 
 	api.BaseRoutes.Team.Handle("/image", api.ApiSessionRequiredTrustRequester(getTeamIcon)).Methods("GET")
-	api.BaseRoutes.Team.Handle("/image", api.ApiSessionRequired(setTeamIcon)).Methods("POST")
+	api.BaseRoutes.Team.Handle("/image", api.ApiSessionRequiredUpload(0, setTeamIcon)).Methods("POST")
 	api.BaseRoutes.Team.Handle("/image", api.ApiSessionRequired(removeTeamIcon)).Methods("DELETE")
 
 	api.BaseRoutes.TeamMembers.Handle("", api.ApiSessionRequired(getTeamMembers)).Methods("GET")
@@ -53,9 +72,10 @@ func (api *API) InitTeam() {
 	api.BaseRoutes.TeamByName.Handle("/exists", api.ApiSessionRequired(teamExists)).Methods("GET")
 	api.BaseRoutes.TeamMember.Handle("/roles", api.ApiSessionRequired(updateTeamMemberRoles)).Methods("PUT")
 	api.BaseRoutes.TeamMember.Handle("/schemeRoles", api.ApiSessionRequired(updateTeamMemberSchemeRoles)).Methods("PUT")
-	api.BaseRoutes.Team.Handle("/import", api.ApiSessionRequired(importTeam)).Methods("POST")
+	api.BaseRoutes.Team.Handle("/import", api.ApiSessionRequiredUpload(MAXIMUM_BULK_IMPORT_SIZE, importTeam)).Methods("POST")
 	api.BaseRoutes.Team.Handle("/invite/email", api.ApiSessionRequired(inviteUsersToTeam)).Methods("POST")
 	api.BaseRoutes.Teams.Handle("/invite/{invite_id:[A-Za-z0-9]+}", api.ApiHandler(getInviteInfo)).Methods("GET")
+	api.BaseRoutes.Teams.Handle("/invites/{invite_id:[A-Za-z0-9]+}/preview", api.ApiHandler(getTeamInvitePreview)).Methods("GET")
 }
 
 func createTeam(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -70,6 +90,15 @@ func createTeam(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Only system admins can set a storage quota, membership limit or auto join, see updateTeamStorageQuota,
+	// updateTeamMembershipLimit and updateTeamAutoJoin
+	if !c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		team.StorageQuota = 0
+		team.MaxMembers = 0
+		team.AllowWaitlist = false
+		team.AutoJoin = false
+	}
+
 	rteam, err := c.App.CreateTeamWithUser(team, c.App.Session.UserId)
 	if err != nil {
 		c.Err = err
@@ -100,7 +129,7 @@ func getTeam(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 	c.App.SanitizeTeam(c.App.Session, team)
-	w.Write([]byte(team.ToJson()))
+	c.WriteConditionally([]byte(team.ToJson()), team.LastModifiedAt(), "Get Team", w, r)
 }
 
 func getTeamByName(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -290,7 +319,12 @@ func getTeamMembers(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	members, err := c.App.GetTeamMembers(c.Params.TeamId, c.Params.Page*c.Params.PerPage, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	members, err := c.App.GetTeamMembers(c.Params.TeamId, pagination.Offset, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
@@ -383,14 +417,18 @@ func addTeamMember(c *Context, w http.ResponseWriter, r *http.Request) {
 func addUserToTeamFromInvite(c *Context, w http.ResponseWriter, r *http.Request) {
 	tokenId := r.URL.Query().Get("token")
 	inviteId := r.URL.Query().Get("invite_id")
+	teamInviteId := r.URL.Query().Get("team_invite_id")
 
 	var member *model.TeamMember
+	var entry *model.TeamWaitlistEntry
 	var err *model.AppError
 
 	if len(tokenId) > 0 {
 		member, err = c.App.AddTeamMemberByToken(c.App.Session.UserId, tokenId)
 	} else if len(inviteId) > 0 {
-		member, err = c.App.AddTeamMemberByInviteId(inviteId, c.App.Session.UserId)
+		member, entry, err = c.App.AddTeamMemberByInviteIdOrWaitlist(inviteId, c.App.Session.UserId)
+	} else if len(teamInviteId) > 0 {
+		member, err = c.App.AddTeamMemberByTeamInvite(teamInviteId, c.App.Session.UserId)
 	} else {
 		err = model.NewAppError("addTeamMember", "api.team.add_user_to_team.missing_parameter.app_error", nil, "", http.StatusBadRequest)
 	}
@@ -400,6 +438,13 @@ func addUserToTeamFromInvite(c *Context, w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// The team was full, so the user is waiting for a team admin to approve them instead
+	if entry != nil {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(entry.ToJson()))
+		return
+	}
+
 	w.WriteHeader(http.StatusCreated)
 	w.Write([]byte(member.ToJson()))
 }
@@ -515,6 +560,508 @@ func getTeamStats(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(stats.ToJson()))
 }
 
+func getTeamActivitySummary(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	query := r.URL.Query()
+
+	since, parseErr := strconv.ParseInt(query.Get("since"), 10, 64)
+	if parseErr != nil {
+		c.SetInvalidUrlParam("since")
+		return
+	}
+
+	until := model.GetMillis()
+	if untilStr := query.Get("until"); untilStr != "" {
+		if until, parseErr = strconv.ParseInt(untilStr, 10, 64); parseErr != nil {
+			c.SetInvalidUrlParam("until")
+			return
+		}
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	summary, err := c.App.GetTeamActivitySummary(c.Params.TeamId, since, until)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(summary.ToJson()))
+}
+
+func getTeamStorageUsage(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	usage, err := c.App.GetTeamStorageUsage(c.Params.TeamId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(usage.ToJson()))
+}
+
+func updateTeamStorageQuota(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	patch := model.TeamStorageQuotaPatch{}
+	if p := model.TeamStorageQuotaPatchFromJson(r.Body); p != nil {
+		patch = *p
+	}
+	if patch.StorageQuota == nil || *patch.StorageQuota < 0 {
+		c.SetInvalidParam("storage_quota")
+		return
+	}
+
+	team, err := c.App.SetTeamStorageQuota(c.Params.TeamId, *patch.StorageQuota)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("storage_quota=" + strconv.FormatInt(team.StorageQuota, 10))
+	w.Write([]byte(team.ToJson()))
+}
+
+func updateTeamAutoJoin(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	patch := model.TeamAutoJoinPatch{}
+	if p := model.TeamAutoJoinPatchFromJson(r.Body); p != nil {
+		patch = *p
+	}
+	if patch.AutoJoin == nil {
+		c.SetInvalidParam("auto_join")
+		return
+	}
+
+	team, err := c.App.SetTeamAutoJoin(c.Params.TeamId, *patch.AutoJoin)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("auto_join=" + strconv.FormatBool(team.AutoJoin))
+	w.Write([]byte(team.ToJson()))
+}
+
+func updateTeamMembershipLimit(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	patch := model.TeamMembershipLimitPatch{}
+	if p := model.TeamMembershipLimitPatchFromJson(r.Body); p != nil {
+		patch = *p
+	}
+	if patch.MaxMembers != nil && *patch.MaxMembers < 0 {
+		c.SetInvalidParam("max_members")
+		return
+	}
+
+	team, err := c.App.SetTeamMembershipLimit(c.Params.TeamId, &patch)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("max_members=" + strconv.Itoa(team.MaxMembers) + " allow_waitlist=" + strconv.FormatBool(team.AllowWaitlist))
+	w.Write([]byte(team.ToJson()))
+}
+
+func getTeamWaitlist(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	entries, err := c.App.GetTeamWaitlist(c.Params.TeamId, pagination.Offset, pagination.PerPage)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(model.TeamWaitlistEntriesToJson(entries)))
+}
+
+func approveTeamWaitlistEntry(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId().RequireUserId()
+	if c.Err != nil {
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	member, err := c.App.ApproveTeamWaitlistEntry(c.Params.TeamId, c.Params.UserId, c.App.Session.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("user_id=" + c.Params.UserId)
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(member.ToJson()))
+}
+
+func removeTeamWaitlistEntry(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId().RequireUserId()
+	if c.Err != nil {
+		return
+	}
+
+	// Users can take themselves off a waitlist
+	if c.App.Session.UserId != c.Params.UserId && !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	if err := c.App.RemoveTeamWaitlistEntry(c.Params.TeamId, c.Params.UserId); err != nil {
+		c.Err = err
+		return
+	}
+
+	ReturnStatusOK(w)
+}
+
+func createTeamInvite(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	invite := model.TeamInviteFromJson(r.Body)
+	if invite == nil {
+		c.SetInvalidParam("invite")
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	if invite.SchemeAdmin && !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_MANAGE_TEAM_ROLES) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM_ROLES)
+		return
+	}
+
+	invite.TeamId = c.Params.TeamId
+	invite.CreatorId = c.App.Session.UserId
+
+	invite, err := c.App.CreateTeamInvite(invite)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("invite_id=" + invite.Id)
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(invite.ToJson()))
+}
+
+func getTeamInvites(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	invites, err := c.App.GetTeamInvites(c.Params.TeamId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(model.TeamInvitesToJson(invites)))
+}
+
+func getTeamAccessGraph(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	if _, err := c.App.GetTeam(c.Params.TeamId); err != nil {
+		c.Err = err
+		return
+	}
+
+	ew := &exportResponseWriter{
+		ResponseWriter: w,
+		contentType:    "application/x-ndjson",
+		filename:       "team_access_graph.jsonl",
+	}
+
+	exported, err := c.App.ExportTeamAccessGraph(ew, c.Params.TeamId)
+	if err != nil {
+		if !ew.started {
+			c.Err = err
+			return
+		}
+
+		// The response has already started, so the error can't be returned to the client.
+		mlog.Error("Failed to export team access graph", mlog.String("team_id", c.Params.TeamId), mlog.Int("exported", exported), mlog.Err(err))
+		return
+	}
+
+	c.LogAudit(fmt.Sprintf("exported=%v", exported))
+
+	if !ew.started {
+		ew.writeHeaders()
+	}
+}
+
+func revokeTeamInvite(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId().RequireInviteId()
+	if c.Err != nil {
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	if err := c.App.RevokeTeamInvite(c.Params.TeamId, c.Params.InviteId); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("invite_id=" + c.Params.InviteId)
+	ReturnStatusOK(w)
+}
+
+func createChannelTemplate(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	template := model.ChannelTemplateFromJson(r.Body)
+	if template == nil {
+		c.SetInvalidParam("channel_template")
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	template.TeamId = c.Params.TeamId
+	template.CreatorId = c.App.Session.UserId
+
+	template, err := c.App.CreateChannelTemplate(template)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("template_id=" + template.Id)
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(template.ToJson()))
+}
+
+func getChannelTemplates(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_VIEW_TEAM) {
+		c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
+		return
+	}
+
+	templates, err := c.App.GetChannelTemplates(c.Params.TeamId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(model.ChannelTemplatesToJson(templates)))
+}
+
+func updateChannelTemplate(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId().RequireTemplateId()
+	if c.Err != nil {
+		return
+	}
+
+	template := model.ChannelTemplateFromJson(r.Body)
+	if template == nil {
+		c.SetInvalidParam("channel_template")
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	template.Id = c.Params.TemplateId
+	template.TeamId = c.Params.TeamId
+
+	template, err := c.App.UpdateChannelTemplate(template)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("template_id=" + template.Id)
+	w.Write([]byte(template.ToJson()))
+}
+
+func deleteChannelTemplate(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId().RequireTemplateId()
+	if c.Err != nil {
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return
+	}
+
+	if err := c.App.DeleteChannelTemplate(c.Params.TeamId, c.Params.TemplateId); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("template_id=" + c.Params.TemplateId)
+	ReturnStatusOK(w)
+}
+
+func createChannelFromTemplate(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId().RequireTemplateId()
+	if c.Err != nil {
+		return
+	}
+
+	request := model.ChannelFromTemplateFromJson(r.Body)
+	if request == nil {
+		c.SetInvalidParam("channel")
+		return
+	}
+
+	if !c.App.SessionHasPermissionToTeam(c.App.Session, c.Params.TeamId, model.PERMISSION_VIEW_TEAM) {
+		c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
+		return
+	}
+
+	template, err := c.App.GetChannelTemplate(c.Params.TeamId, c.Params.TemplateId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if template.Type == model.CHANNEL_OPEN && !c.App.SessionHasPermissionToTeam(c.App.Session, template.TeamId, model.PERMISSION_CREATE_PUBLIC_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_CREATE_PUBLIC_CHANNEL)
+		return
+	}
+
+	if template.Type == model.CHANNEL_PRIVATE && !c.App.SessionHasPermissionToTeam(c.App.Session, template.TeamId, model.PERMISSION_CREATE_PRIVATE_CHANNEL) {
+		c.SetPermissionError(model.PERMISSION_CREATE_PRIVATE_CHANNEL)
+		return
+	}
+
+	channel, created, err := c.App.CreateChannelFromTemplate(template, request.Name, request.DisplayName, c.App.Session.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if created {
+		c.LogAudit("name=" + channel.Name + " template_id=" + template.Id)
+		w.WriteHeader(http.StatusCreated)
+	}
+	w.Write([]byte(channel.ToJson()))
+}
+
+func mergeTeam(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	request := model.TeamMergeRequestFromJson(r.Body)
+	if request == nil {
+		c.SetInvalidParam("merge_request")
+		return
+	}
+
+	if err := request.IsValid(); err != nil {
+		c.Err = err
+		return
+	}
+
+	if request.DryRun {
+		report, err := c.App.PlanTeamMerge(c.Params.TeamId, request)
+		if err != nil {
+			c.Err = err
+			return
+		}
+
+		w.Write([]byte(report.ToJson()))
+		return
+	}
+
+	job, err := c.App.CreateMergeTeamsJob(c.Params.TeamId, request, c.App.Session.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("target_team_id=" + request.TargetTeamId + " channel_collision_policy=" + request.ChannelCollisionPolicy + " job_id=" + job.Id)
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(job.ToJson()))
+}
+
 func updateTeamMemberRoles(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.RequireTeamId().RequireUserId()
 	if c.Err != nil {
@@ -568,13 +1115,17 @@ func updateTeamMemberSchemeRoles(c *Context, w http.ResponseWriter, r *http.Requ
 }
 
 func getAllTeams(c *Context, w http.ResponseWriter, r *http.Request) {
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
 	var teams []*model.Team
 	var err *model.AppError
-
 	if c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_SYSTEM) {
-		teams, err = c.App.GetAllTeamsPage(c.Params.Page*c.Params.PerPage, c.Params.PerPage)
+		teams, err = c.App.GetAllTeamsPage(pagination.Offset, pagination.PerPage)
 	} else {
-		teams, err = c.App.GetAllOpenTeamsPage(c.Params.Page*c.Params.PerPage, c.Params.PerPage)
+		teams, err = c.App.GetAllOpenTeamsPage(pagination.Offset, pagination.PerPage)
 	}
 
 	if err != nil {
@@ -765,6 +1316,21 @@ func getInviteInfo(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(model.MapToJson(result)))
 }
 
+func getTeamInvitePreview(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireInviteId()
+	if c.Err != nil {
+		return
+	}
+
+	preview, err := c.App.GetTeamInvitePreview(c.Params.InviteId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(preview.ToJson()))
+}
+
 func getTeamIcon(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.RequireTeamId()
 	if c.Err != nil {
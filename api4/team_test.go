@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"encoding/base64"
 
@@ -172,6 +173,54 @@ func TestGetTeam(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+func TestGetTeamConditional(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	_, resp := Client.GetTeam(th.BasicTeam.Id, "")
+	CheckNoError(t, resp)
+	require.NotEmpty(t, resp.Etag)
+	lastModified := resp.Header.Get("Last-Modified")
+	require.NotEmpty(t, lastModified)
+
+	team, etagResp := Client.GetTeam(th.BasicTeam.Id, resp.Etag)
+	CheckEtag(t, team, etagResp)
+
+	t.Run("if modified since", func(t *testing.T) {
+		Client.HttpHeader["If-Modified-Since"] = lastModified
+		defer delete(Client.HttpHeader, "If-Modified-Since")
+
+		team, resp := Client.GetTeam(th.BasicTeam.Id, "")
+		CheckEtag(t, team, resp)
+	})
+
+	t.Run("sanitized and unsanitized teams have different etags", func(t *testing.T) {
+		team, adminResp := th.SystemAdminClient.GetTeam(th.BasicTeam.Id, resp.Etag)
+		CheckNoError(t, adminResp)
+		require.NotNil(t, team)
+		assert.NotEqual(t, resp.Etag, adminResp.Etag)
+	})
+
+	t.Run("updates change the validators", func(t *testing.T) {
+		time.Sleep(time.Second)
+		_, patchResp := th.SystemAdminClient.PatchTeam(th.BasicTeam.Id, &model.TeamPatch{DisplayName: model.NewString("Conditional")})
+		CheckNoError(t, patchResp)
+
+		Client.HttpHeader["If-Modified-Since"] = lastModified
+		defer delete(Client.HttpHeader, "If-Modified-Since")
+
+		team, updatedResp := Client.GetTeam(th.BasicTeam.Id, resp.Etag)
+		CheckNoError(t, updatedResp)
+		require.NotNil(t, team)
+		assert.Equal(t, "Conditional", team.DisplayName)
+
+		team, updatedResp = Client.GetTeam(th.BasicTeam.Id, "")
+		CheckNoError(t, updatedResp)
+		require.NotNil(t, team)
+	})
+}
+
 func TestGetTeamSanitization(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -1543,6 +1592,119 @@ func TestRemoveTeamMember(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+func TestGetTeamActivitySummary(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+	team := th.BasicTeam
+
+	since := model.GetMillis()
+	th.CreatePost()
+	th.CreatePost()
+	th.CreatePostWithClient(th.SystemAdminClient, th.BasicChannel)
+	th.CreatePublicChannel()
+	until := model.GetMillis() + 1000
+
+	_, resp := Client.GetTeamActivitySummary(team.Id, since, until)
+	CheckForbiddenStatus(t, resp)
+
+	summary, resp := th.SystemAdminClient.GetTeamActivitySummary(team.Id, since, until)
+	CheckNoError(t, resp)
+	assert.Equal(t, team.Id, summary.TeamId)
+	assert.Equal(t, int64(3), summary.TotalPosts)
+	assert.Equal(t, int64(2), summary.ActiveUsers)
+	assert.Equal(t, int64(1), summary.NewChannels)
+	assert.NotEmpty(t, summary.Days)
+
+	th.UpdateUserToTeamAdmin(th.BasicUser, team)
+	th.App.InvalidateAllCaches()
+	th.LoginBasic()
+
+	_, resp = Client.GetTeamActivitySummary(team.Id, since, until)
+	CheckNoError(t, resp)
+
+	_, resp = th.SystemAdminClient.GetTeamActivitySummary(team.Id, until, since)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetTeamActivitySummary(team.Id, 0, until)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetTeamActivitySummary("junk", since, until)
+	CheckBadRequestStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.GetTeamActivitySummary(team.Id, since, until)
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestTeamStorageQuota(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+	team := th.BasicTeam
+
+	_, resp := Client.UpdateTeamStorageQuota(team.Id, 1024)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.GetTeamStorageUsage(team.Id)
+	CheckForbiddenStatus(t, resp)
+
+	usage, resp := th.SystemAdminClient.GetTeamStorageUsage(team.Id)
+	CheckNoError(t, resp)
+	assert.Equal(t, team.Id, usage.TeamId)
+	assert.Equal(t, int64(0), usage.Quota)
+
+	rteam, resp := th.SystemAdminClient.UpdateTeamStorageQuota(team.Id, usage.Used+10)
+	CheckNoError(t, resp)
+	assert.Equal(t, usage.Used+10, rteam.StorageQuota)
+	defer th.App.SetTeamStorageQuota(team.Id, 0)
+
+	_, resp = th.SystemAdminClient.UpdateTeamStorageQuota(team.Id, -1)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.UpdateTeamStorageQuota("junk", 1024)
+	CheckBadRequestStatus(t, resp)
+
+	t.Run("team admins can see the usage", func(t *testing.T) {
+		th.UpdateUserToTeamAdmin(th.BasicUser, team)
+		th.App.InvalidateAllCaches()
+		th.LoginBasic()
+
+		usage, resp := Client.GetTeamStorageUsage(team.Id)
+		CheckNoError(t, resp)
+		assert.Equal(t, rteam.StorageQuota, usage.Quota)
+
+		// But they can't change the quota, even by updating the team
+		_, resp = Client.UpdateTeamStorageQuota(team.Id, 0)
+		CheckForbiddenStatus(t, resp)
+
+		team.StorageQuota = 0
+		updated, resp := Client.UpdateTeam(team)
+		CheckNoError(t, resp)
+		assert.Equal(t, rteam.StorageQuota, updated.StorageQuota)
+	})
+
+	t.Run("uploads over the quota are rejected", func(t *testing.T) {
+		_, resp := Client.UploadFile(make([]byte, 11), th.BasicChannel.Id, "test.txt")
+		CheckRequestEntityTooLargeStatus(t, resp)
+
+		fileResp, resp := Client.UploadFile(make([]byte, 10), th.BasicChannel.Id, "test.txt")
+		CheckNoError(t, resp)
+		require.Len(t, fileResp.FileInfos, 1)
+
+		info, err := th.App.GetFileInfo(fileResp.FileInfos[0].Id)
+		require.Nil(t, err)
+		defer th.App.RemoveFile(info.Path)
+
+		_, resp = Client.UploadFile(make([]byte, 1), th.BasicChannel.Id, "test.txt")
+		CheckRequestEntityTooLargeStatus(t, resp)
+	})
+
+	Client.Logout()
+	_, resp = Client.GetTeamStorageUsage(team.Id)
+	CheckUnauthorizedStatus(t, resp)
+}
+
 func TestGetTeamStats(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -2210,3 +2372,305 @@ func TestUpdateTeamScheme(t *testing.T) {
 	_, resp = th.SystemAdminClient.UpdateTeamScheme(team.Id, teamScheme.Id)
 	CheckUnauthorizedStatus(t, resp)
 }
+
+func TestMergeTeam(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	team := th.CreateTeam()
+	request := &model.TeamMergeRequest{TargetTeamId: team.Id, ChannelCollisionPolicy: model.TEAM_MERGE_CHANNEL_COLLISION_MERGE}
+
+	_, resp := Client.PlanTeamMerge(th.BasicTeam.Id, request)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.MergeTeam(th.BasicTeam.Id, request)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.PlanTeamMerge(th.BasicTeam.Id, &model.TeamMergeRequest{TargetTeamId: "junk", ChannelCollisionPolicy: model.TEAM_MERGE_CHANNEL_COLLISION_MERGE})
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.PlanTeamMerge(th.BasicTeam.Id, &model.TeamMergeRequest{TargetTeamId: team.Id, ChannelCollisionPolicy: "junk"})
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.MergeTeam(th.BasicTeam.Id, &model.TeamMergeRequest{TargetTeamId: th.BasicTeam.Id, ChannelCollisionPolicy: model.TEAM_MERGE_CHANNEL_COLLISION_MERGE})
+	CheckBadRequestStatus(t, resp)
+
+	report, resp := th.SystemAdminClient.PlanTeamMerge(th.BasicTeam.Id, request)
+	CheckNoError(t, resp)
+	CheckOKStatus(t, resp)
+	require.True(t, report.DryRun)
+	require.Equal(t, th.BasicTeam.Id, report.SourceTeamId)
+	require.Equal(t, team.Id, report.TargetTeamId)
+	require.NotEmpty(t, report.NewMembers)
+
+	job, resp := th.SystemAdminClient.MergeTeam(th.BasicTeam.Id, request)
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+	require.Equal(t, model.JOB_TYPE_MERGE_TEAMS, job.Type)
+	require.Equal(t, th.BasicTeam.Id, job.Data["source_team_id"])
+	require.Equal(t, team.Id, job.Data["target_team_id"])
+	require.Equal(t, model.TEAM_MERGE_CHANNEL_COLLISION_MERGE, job.Data["channel_collision_policy"])
+}
+
+func TestTeamMembershipLimit(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	team := th.CreateTeamWithClient(th.SystemAdminClient)
+	patch := &model.TeamMembershipLimitPatch{MaxMembers: model.NewInt(1), AllowWaitlist: model.NewBool(true)}
+
+	_, resp := Client.UpdateTeamMembershipLimit(team.Id, patch)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.UpdateTeamMembershipLimit(team.Id, &model.TeamMembershipLimitPatch{MaxMembers: model.NewInt(-1)})
+	CheckBadRequestStatus(t, resp)
+
+	// The admin who created the team fills it
+	rteam, resp := th.SystemAdminClient.UpdateTeamMembershipLimit(team.Id, patch)
+	CheckNoError(t, resp)
+	assert.Equal(t, 1, rteam.MaxMembers)
+	assert.True(t, rteam.AllowWaitlist)
+
+	_, resp = th.SystemAdminClient.AddTeamMember(team.Id, th.BasicUser2.Id)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.AddTeamMemberFromInvite("", rteam.InviteId)
+	CheckNoError(t, resp)
+	CheckAcceptedStatus(t, resp)
+
+	_, resp = Client.GetTeamWaitlist(team.Id, 0, 10)
+	CheckForbiddenStatus(t, resp)
+
+	entries, resp := th.SystemAdminClient.GetTeamWaitlist(team.Id, 0, 10)
+	CheckNoError(t, resp)
+	require.Len(t, entries, 1)
+	assert.Equal(t, th.BasicUser.Id, entries[0].UserId)
+
+	_, resp = Client.ApproveTeamWaitlistEntry(team.Id, th.BasicUser.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.ApproveTeamWaitlistEntry(team.Id, th.BasicUser.Id)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.UpdateTeamMembershipLimit(team.Id, &model.TeamMembershipLimitPatch{MaxMembers: model.NewInt(2)})
+	CheckNoError(t, resp)
+
+	member, resp := th.SystemAdminClient.ApproveTeamWaitlistEntry(team.Id, th.BasicUser.Id)
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+	assert.Equal(t, th.BasicUser.Id, member.UserId)
+
+	_, resp = th.SystemAdminClient.ApproveTeamWaitlistEntry(team.Id, th.BasicUser.Id)
+	CheckNotFoundStatus(t, resp)
+
+	t.Run("users can leave the waitlist", func(t *testing.T) {
+		_, err := th.App.AddUserToTeamWaitlist(team.Id, th.BasicUser2.Id)
+		require.Nil(t, err)
+
+		_, resp := Client.RemoveTeamWaitlistEntry(team.Id, th.BasicUser2.Id)
+		CheckForbiddenStatus(t, resp)
+
+		th.LoginBasic2()
+		ok, resp := Client.RemoveTeamWaitlistEntry(team.Id, th.BasicUser2.Id)
+		CheckNoError(t, resp)
+		assert.True(t, ok)
+	})
+}
+
+func TestTeamInvites(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	team := th.CreateTeamWithClient(th.SystemAdminClient)
+	channel := th.CreateChannelWithClientAndTeam(th.SystemAdminClient, model.CHANNEL_OPEN, team.Id)
+
+	invite := &model.TeamInvite{TeamId: team.Id, MaxUses: 1, ChannelIds: model.StringArray{channel.Id}}
+
+	_, resp := Client.CreateTeamInvite(invite)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.CreateTeamInvite(&model.TeamInvite{TeamId: team.Id, MaxUses: -1})
+	CheckBadRequestStatus(t, resp)
+
+	rinvite, resp := th.SystemAdminClient.CreateTeamInvite(invite)
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+	assert.Equal(t, th.SystemAdminUser.Id, rinvite.CreatorId)
+
+	member, resp := Client.AddTeamMemberFromTeamInvite(rinvite.Id)
+	CheckNoError(t, resp)
+	assert.Equal(t, th.BasicUser.Id, member.UserId)
+
+	_, err := th.App.GetChannelMember(channel.Id, th.BasicUser.Id)
+	assert.Nil(t, err)
+
+	th.LoginBasic2()
+	_, resp = Client.AddTeamMemberFromTeamInvite(rinvite.Id)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.GetTeamInvites(team.Id)
+	CheckForbiddenStatus(t, resp)
+
+	invites, resp := th.SystemAdminClient.GetTeamInvites(team.Id)
+	CheckNoError(t, resp)
+	require.Len(t, invites, 1)
+	assert.Equal(t, 1, invites[0].Uses)
+
+	_, resp = Client.RevokeTeamInvite(team.Id, rinvite.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.RevokeTeamInvite(th.BasicTeam.Id, rinvite.Id)
+	CheckNotFoundStatus(t, resp)
+
+	ok, resp := th.SystemAdminClient.RevokeTeamInvite(team.Id, rinvite.Id)
+	CheckNoError(t, resp)
+	assert.True(t, ok)
+
+	invites, resp = th.SystemAdminClient.GetTeamInvites(team.Id)
+	CheckNoError(t, resp)
+	assert.Empty(t, invites)
+}
+
+func TestChannelTemplates(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	template := &model.ChannelTemplate{TeamId: th.BasicTeam.Id, Name: "Incident", Type: model.CHANNEL_OPEN, Purpose: "Incident response", IntroMessage: "Follow the runbook"}
+
+	_, resp := Client.CreateChannelTemplate(template)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.CreateChannelTemplate(&model.ChannelTemplate{TeamId: th.BasicTeam.Id, Type: model.CHANNEL_OPEN})
+	CheckBadRequestStatus(t, resp)
+
+	rtemplate, resp := th.SystemAdminClient.CreateChannelTemplate(template)
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+	assert.Equal(t, th.SystemAdminUser.Id, rtemplate.CreatorId)
+
+	templates, resp := Client.GetChannelTemplates(th.BasicTeam.Id)
+	CheckNoError(t, resp)
+	require.Len(t, templates, 1)
+	assert.Equal(t, rtemplate.Id, templates[0].Id)
+
+	request := &model.ChannelFromTemplate{Name: "incident-" + model.NewId()[:10], DisplayName: "Incident"}
+
+	channel, resp := Client.CreateChannelFromTemplate(th.BasicTeam.Id, rtemplate.Id, request)
+	CheckNoError(t, resp)
+	CheckCreatedStatus(t, resp)
+	assert.Equal(t, template.Purpose, channel.Purpose)
+
+	again, resp := Client.CreateChannelFromTemplate(th.BasicTeam.Id, rtemplate.Id, request)
+	CheckNoError(t, resp)
+	CheckOKStatus(t, resp)
+	assert.Equal(t, channel.Id, again.Id)
+
+	_, resp = Client.CreateChannelFromTemplate(th.BasicTeam.Id, model.NewId(), request)
+	CheckNotFoundStatus(t, resp)
+
+	rtemplate.Type = model.CHANNEL_PRIVATE
+	_, resp = Client.UpdateChannelTemplate(rtemplate)
+	CheckForbiddenStatus(t, resp)
+
+	utemplate, resp := th.SystemAdminClient.UpdateChannelTemplate(rtemplate)
+	CheckNoError(t, resp)
+	assert.Equal(t, model.CHANNEL_PRIVATE, utemplate.Type)
+
+	_, resp = Client.DeleteChannelTemplate(th.BasicTeam.Id, rtemplate.Id)
+	CheckForbiddenStatus(t, resp)
+
+	ok, resp := th.SystemAdminClient.DeleteChannelTemplate(th.BasicTeam.Id, rtemplate.Id)
+	CheckNoError(t, resp)
+	assert.True(t, ok)
+
+	templates, resp = Client.GetChannelTemplates(th.BasicTeam.Id)
+	CheckNoError(t, resp)
+	assert.Empty(t, templates)
+}
+
+func TestGetTeamAccessGraph(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	_, resp := Client.GetTeamAccessGraph(th.BasicTeam.Id)
+	CheckForbiddenStatus(t, resp)
+
+	entries, resp := th.SystemAdminClient.GetTeamAccessGraph(th.BasicTeam.Id)
+	CheckNoError(t, resp)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+	foundMember := false
+	foundChannel := false
+	for _, entry := range entries {
+		if entry.Type == model.TEAM_ACCESS_GRAPH_ENTRY_TEAM_MEMBER && entry.TeamMember.UserId == th.BasicUser.Id {
+			foundMember = true
+		}
+		if entry.Type == model.TEAM_ACCESS_GRAPH_ENTRY_CHANNEL && entry.Channel.Id == th.BasicPrivateChannel.Id {
+			foundChannel = true
+		}
+	}
+	assert.True(t, foundMember)
+	assert.True(t, foundChannel, "private channels should be included")
+
+	_, resp = th.SystemAdminClient.GetTeamAccessGraph(model.NewId())
+	CheckNotFoundStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.UpdateTeamMemberRoles(th.BasicTeam.Id, th.BasicUser.Id, model.TEAM_USER_ROLE_ID+" "+model.TEAM_ADMIN_ROLE_ID)
+	CheckNoError(t, resp)
+
+	_, resp = Client.GetTeamAccessGraph(th.BasicTeam.Id)
+	CheckNoError(t, resp)
+}
+
+func TestGetTeamInvitePreview(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	team := th.CreateTeamWithClient(th.SystemAdminClient)
+	channel := th.CreateChannelWithClientAndTeam(th.SystemAdminClient, model.CHANNEL_OPEN, team.Id)
+
+	invite, resp := th.SystemAdminClient.CreateTeamInvite(&model.TeamInvite{TeamId: team.Id, ChannelIds: model.StringArray{channel.Id}})
+	CheckNoError(t, resp)
+
+	Client.Logout()
+
+	preview, resp := Client.GetTeamInvitePreview(invite.Id)
+	CheckNoError(t, resp)
+	assert.Equal(t, team.Name, preview.TeamName)
+	require.Len(t, preview.Channels, 1)
+	assert.Equal(t, channel.DisplayName, preview.Channels[0].DisplayName)
+
+	_, resp = Client.GetTeamInvitePreview(model.NewId())
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.RevokeTeamInvite(team.Id, invite.Id)
+	CheckNoError(t, resp)
+
+	_, resp = Client.GetTeamInvitePreview(invite.Id)
+	CheckBadRequestStatus(t, resp)
+}
+
+func TestUpdateTeamAutoJoin(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+	Client := th.Client
+
+	_, resp := Client.UpdateTeamAutoJoin(th.BasicTeam.Id, true)
+	CheckForbiddenStatus(t, resp)
+
+	rteam, resp := th.SystemAdminClient.UpdateTeamAutoJoin(th.BasicTeam.Id, true)
+	CheckNoError(t, resp)
+	assert.True(t, rteam.AutoJoin)
+
+	// Team admins can't turn it on when creating a team
+	team := &model.Team{DisplayName: "DisplayName", Name: GenerateTestTeamName(), Email: th.GenerateTestEmail(), Type: model.TEAM_OPEN, AutoJoin: true}
+	rteam, resp = Client.CreateTeam(team)
+	CheckNoError(t, resp)
+	assert.False(t, rteam.AutoJoin)
+}
@@ -11,24 +11,26 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/mattermost/mattermost-server/app"
 	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/web"
 )
 
 func (api *API) InitUser() {
 	api.BaseRoutes.Users.Handle("", api.ApiHandler(createUser)).Methods("POST")
 	api.BaseRoutes.Users.Handle("", api.ApiSessionRequired(getUsers)).Methods("GET")
 	api.BaseRoutes.Users.Handle("/ids", api.ApiSessionRequired(getUsersByIds)).Methods("POST")
+	api.BaseRoutes.Users.Handle("/display_names", api.ApiSessionRequired(getUserDisplayNames)).Methods("POST")
 	api.BaseRoutes.Users.Handle("/usernames", api.ApiSessionRequired(getUsersByNames)).Methods("POST")
-	api.BaseRoutes.Users.Handle("/search", api.ApiSessionRequired(searchUsers)).Methods("POST")
+	api.BaseRoutes.Users.Handle("/search", api.ApiSessionRequiredSearch(searchUsers)).Methods("POST")
 	api.BaseRoutes.Users.Handle("/autocomplete", api.ApiSessionRequired(autocompleteUsers)).Methods("GET")
 	api.BaseRoutes.Users.Handle("/stats", api.ApiSessionRequired(getTotalUsersStats)).Methods("GET")
+	api.BaseRoutes.Users.Handle("/last_activity/export", api.ApiSystemAdminRequired(exportUsersLastActivity)).Methods("GET")
 
 	api.BaseRoutes.User.Handle("", api.ApiSessionRequired(getUser)).Methods("GET")
 	api.BaseRoutes.User.Handle("/image/default", api.ApiSessionRequiredTrustRequester(getDefaultProfileImage)).Methods("GET")
 	api.BaseRoutes.User.Handle("/image", api.ApiSessionRequiredTrustRequester(getProfileImage)).Methods("GET")
-	api.BaseRoutes.User.Handle("/image", api.ApiSessionRequired(setProfileImage)).Methods("POST")
+	api.BaseRoutes.User.Handle("/image", api.ApiSessionRequiredUpload(0, setProfileImage)).Methods("POST")
 	api.BaseRoutes.User.Handle("/image", api.ApiSessionRequired(setDefaultProfileImage)).Methods("DELETE")
 	api.BaseRoutes.User.Handle("", api.ApiSessionRequired(updateUser)).Methods("PUT")
 	api.BaseRoutes.User.Handle("/patch", api.ApiSessionRequired(patchUser)).Methods("PUT")
@@ -49,7 +51,7 @@ func (api *API) InitUser() {
 	api.BaseRoutes.User.Handle("/mfa", api.ApiSessionRequiredMfa(updateUserMfa)).Methods("PUT")
 	api.BaseRoutes.User.Handle("/mfa/generate", api.ApiSessionRequiredMfa(generateMfaSecret)).Methods("POST")
 
-	api.BaseRoutes.Users.Handle("/login", api.ApiHandler(login)).Methods("POST")
+	api.BaseRoutes.Users.Handle("/login", api.ApiSecureHandler(login)).Methods("POST")
 	api.BaseRoutes.Users.Handle("/login/switch", api.ApiHandler(switchAccountType)).Methods("POST")
 	api.BaseRoutes.Users.Handle("/logout", api.ApiHandler(logout)).Methods("POST")
 
@@ -70,6 +72,7 @@ func (api *API) InitUser() {
 	api.BaseRoutes.Users.Handle("/tokens/revoke", api.ApiSessionRequired(revokeUserAccessToken)).Methods("POST")
 	api.BaseRoutes.Users.Handle("/tokens/disable", api.ApiSessionRequired(disableUserAccessToken)).Methods("POST")
 	api.BaseRoutes.Users.Handle("/tokens/enable", api.ApiSessionRequired(enableUserAccessToken)).Methods("POST")
+	api.BaseRoutes.Users.Handle("/me/tokens/validate", api.ApiSessionRequired(validateUserAccessToken)).Methods("GET")
 }
 
 func createUser(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -359,7 +362,33 @@ func getTotalUsersStats(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(stats.ToJson()))
 }
 
+func exportUsersLastActivity(c *Context, w http.ResponseWriter, r *http.Request) {
+	var inactiveSince int64
+	if inactiveSinceStr := r.URL.Query().Get("inactive_since"); inactiveSinceStr != "" {
+		var parseErr error
+		if inactiveSince, parseErr = strconv.ParseInt(inactiveSinceStr, 10, 64); parseErr != nil || inactiveSince < 0 {
+			c.SetInvalidUrlParam("inactive_since")
+			return
+		}
+	}
+
+	c.LogAudit(fmt.Sprintf("inactive_since=%v", inactiveSince))
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment;filename=\"users_last_activity.csv\"")
+
+	// The response has already started by the time an error can occur, so it can only be logged.
+	if err := c.App.ExportUsersLastActivity(w, inactiveSince); err != nil {
+		mlog.Error("Failed to export users last activity", mlog.Err(err))
+	}
+}
+
 func getUsers(c *Context, w http.ResponseWriter, r *http.Request) {
+	contentType := c.NegotiateContentType(w, r, web.CONTENT_TYPE_JSON, web.CONTENT_TYPE_CSV)
+	if c.Err != nil {
+		return
+	}
+
 	inTeamId := r.URL.Query().Get("in_team")
 	notInTeamId := r.URL.Query().Get("not_in_team")
 	inChannelId := r.URL.Query().Get("in_channel")
@@ -393,6 +422,11 @@ func getUsers(c *Context, w http.ResponseWriter, r *http.Request) {
 	withoutTeamBool, _ := strconv.ParseBool(withoutTeam)
 	inactiveBool, _ := strconv.ParseBool(inactive)
 
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
 	userGetOptions := &model.UserGetOptions{
 		InTeamId:       inTeamId,
 		InChannelId:    inChannelId,
@@ -402,8 +436,8 @@ func getUsers(c *Context, w http.ResponseWriter, r *http.Request) {
 		Inactive:       inactiveBool,
 		Role:           role,
 		Sort:           sort,
-		Page:           c.Params.Page,
-		PerPage:        c.Params.PerPage,
+		Page:           pagination.Page,
+		PerPage:        pagination.PerPage,
 	}
 
 	var profiles []*model.User
@@ -417,26 +451,26 @@ func getUsers(c *Context, w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		profiles, err = c.App.GetUsersWithoutTeamPage(c.Params.Page, c.Params.PerPage, c.IsSystemAdmin())
+		profiles, err = c.App.GetUsersWithoutTeamPage(pagination.Page, pagination.PerPage, c.IsSystemAdmin())
 	} else if len(notInChannelId) > 0 {
 		if !c.App.SessionHasPermissionToChannel(c.App.Session, notInChannelId, model.PERMISSION_READ_CHANNEL) {
 			c.SetPermissionError(model.PERMISSION_READ_CHANNEL)
 			return
 		}
 
-		profiles, err = c.App.GetUsersNotInChannelPage(inTeamId, notInChannelId, c.Params.Page, c.Params.PerPage, c.IsSystemAdmin())
+		profiles, err = c.App.GetUsersNotInChannelPage(inTeamId, notInChannelId, pagination.Page, pagination.PerPage, c.IsSystemAdmin())
 	} else if len(notInTeamId) > 0 {
 		if !c.App.SessionHasPermissionToTeam(c.App.Session, notInTeamId, model.PERMISSION_VIEW_TEAM) {
 			c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
 			return
 		}
 
-		etag = c.App.GetUsersNotInTeamEtag(inTeamId)
+		etag = web.EtagForContentType(c.App.GetUsersNotInTeamEtag(inTeamId), contentType)
 		if c.HandleEtag(etag, "Get Users Not in Team", w, r) {
 			return
 		}
 
-		profiles, err = c.App.GetUsersNotInTeamPage(notInTeamId, c.Params.Page, c.Params.PerPage, c.IsSystemAdmin())
+		profiles, err = c.App.GetUsersNotInTeamPage(notInTeamId, pagination.Page, pagination.PerPage, c.IsSystemAdmin())
 	} else if len(inTeamId) > 0 {
 		if !c.App.SessionHasPermissionToTeam(c.App.Session, inTeamId, model.PERMISSION_VIEW_TEAM) {
 			c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
@@ -444,11 +478,11 @@ func getUsers(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 
 		if sort == "last_activity_at" {
-			profiles, err = c.App.GetRecentlyActiveUsersForTeamPage(inTeamId, c.Params.Page, c.Params.PerPage, c.IsSystemAdmin())
+			profiles, err = c.App.GetRecentlyActiveUsersForTeamPage(inTeamId, pagination.Page, pagination.PerPage, c.IsSystemAdmin())
 		} else if sort == "create_at" {
-			profiles, err = c.App.GetNewUsersForTeamPage(inTeamId, c.Params.Page, c.Params.PerPage, c.IsSystemAdmin())
+			profiles, err = c.App.GetNewUsersForTeamPage(inTeamId, pagination.Page, pagination.PerPage, c.IsSystemAdmin())
 		} else {
-			etag = c.App.GetUsersInTeamEtag(inTeamId)
+			etag = web.EtagForContentType(c.App.GetUsersInTeamEtag(inTeamId), contentType)
 			if c.HandleEtag(etag, "Get Users in Team", w, r) {
 				return
 			}
@@ -460,14 +494,14 @@ func getUsers(c *Context, w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if sort == "status" {
-			profiles, err = c.App.GetUsersInChannelPageByStatus(inChannelId, c.Params.Page, c.Params.PerPage, c.IsSystemAdmin())
+			profiles, err = c.App.GetUsersInChannelPageByStatus(inChannelId, pagination.Page, pagination.PerPage, c.IsSystemAdmin())
 		} else {
-			profiles, err = c.App.GetUsersInChannelPage(inChannelId, c.Params.Page, c.Params.PerPage, c.IsSystemAdmin())
+			profiles, err = c.App.GetUsersInChannelPage(inChannelId, pagination.Page, pagination.PerPage, c.IsSystemAdmin())
 		}
 	} else {
 		// No permission check required
 
-		etag = c.App.GetUsersEtag()
+		etag = web.EtagForContentType(c.App.GetUsersEtag(), contentType)
 		if c.HandleEtag(etag, "Get Users", w, r) {
 			return
 		}
@@ -483,6 +517,12 @@ func getUsers(c *Context, w http.ResponseWriter, r *http.Request) {
 		w.Header().Set(model.HEADER_ETAG_SERVER, etag)
 	}
 	c.App.UpdateLastActivityAtIfNeeded(c.App.Session)
+
+	if contentType == web.CONTENT_TYPE_CSV {
+		web.WriteCsv(w, model.UserListToCsv(profiles))
+		return
+	}
+
 	w.Write([]byte(model.UserListToJson(profiles)))
 }
 
@@ -505,6 +545,25 @@ func getUsersByIds(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(model.UserListToJson(users)))
 }
 
+func getUserDisplayNames(c *Context, w http.ResponseWriter, r *http.Request) {
+	userIds := model.ArrayFromJson(r.Body)
+
+	if len(userIds) == 0 {
+		c.SetInvalidParam("user_ids")
+		return
+	}
+
+	// No permission check required
+
+	displayNames, err := c.App.GetUserDisplayNames(userIds, c.App.Session.UserId, c.IsSystemAdmin())
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(model.MapToJson(displayNames)))
+}
+
 func getUsersByNames(c *Context, w http.ResponseWriter, r *http.Request) {
 	usernames := model.ArrayFromJson(r.Body)
 
@@ -1288,11 +1347,6 @@ func attachDeviceId(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	maxAge := *c.App.Config().ServiceSettings.SessionLengthMobileInDays * 60 * 60 * 24
 
-	secure := false
-	if app.GetProtocol(r) == "https" {
-		secure = true
-	}
-
 	expiresAt := time.Unix(model.GetMillis()/1000+int64(maxAge), 0)
 	sessionCookie := &http.Cookie{
 		Name:     model.SESSION_COOKIE_TOKEN,
@@ -1301,11 +1355,8 @@ func attachDeviceId(c *Context, w http.ResponseWriter, r *http.Request) {
 		MaxAge:   maxAge,
 		Expires:  expiresAt,
 		HttpOnly: true,
-		Domain:   c.App.GetCookieDomain(),
-		Secure:   secure,
 	}
-
-	http.SetCookie(w, sessionCookie)
+	c.App.SetSessionCookie(w, r, sessionCookie)
 
 	if err := c.App.AttachDeviceId(c.App.Session.Id, deviceId, c.App.Session.ExpiresAt); err != nil {
 		c.Err = err
@@ -1327,7 +1378,12 @@ func getUserAudits(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	audits, err := c.App.GetAuditsPage(c.Params.UserId, c.Params.Page, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	audits, err := c.App.GetAuditsPage(c.Params.UserId, pagination.Page, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
@@ -1496,7 +1552,12 @@ func getUserAccessTokens(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessTokens, err := c.App.GetUserAccessTokens(c.Params.Page, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	accessTokens, err := c.App.GetUserAccessTokens(pagination.Page, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
@@ -1521,7 +1582,12 @@ func getUserAccessTokensForUser(c *Context, w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	accessTokens, err := c.App.GetUserAccessTokensForUser(c.Params.UserId, c.Params.Page, c.Params.PerPage)
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
+	accessTokens, err := c.App.GetUserAccessTokensForUser(c.Params.UserId, pagination.Page, pagination.PerPage)
 	if err != nil {
 		c.Err = err
 		return
@@ -1662,6 +1728,18 @@ func enableUserAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
 	ReturnStatusOK(w)
 }
 
+// validateUserAccessToken describes the user access token that the request was made with. A token that isn't valid
+// never gets this far since the request fails to authenticate with a 401.
+func validateUserAccessToken(c *Context, w http.ResponseWriter, r *http.Request) {
+	validation, err := c.App.ValidateUserAccessTokenSession(c.App.Session)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(validation.ToJson()))
+}
+
 func saveUserTermsOfService(c *Context, w http.ResponseWriter, r *http.Request) {
 	props := model.StringInterfaceFromJson(r.Body)
 
@@ -4,6 +4,8 @@
 package api4
 
 import (
+	"bytes"
+	"encoding/csv"
 	"net/http"
 	"strconv"
 	"testing"
@@ -952,6 +954,88 @@ func TestGetUsersByIds(t *testing.T) {
 	CheckUnauthorizedStatus(t, resp)
 }
 
+func TestGetUserDisplayNames(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	user := th.CreateUser()
+	user.FirstName = "Jane"
+	user.LastName = "Doe"
+	user.Nickname = "jd"
+	_, err := th.App.UpdateUser(user, false)
+	require.Nil(t, err)
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		cfg.PrivacySettings.ShowFullName = true
+		*cfg.TeamSettings.TeammateNameDisplay = model.SHOW_USERNAME
+	})
+
+	displayNames, resp := th.Client.GetUserDisplayNames([]string{user.Id, th.BasicUser.Id, "junk"})
+	CheckNoError(t, resp)
+	assert.Equal(t, map[string]string{user.Id: user.Username, th.BasicUser.Id: th.BasicUser.Username}, displayNames)
+
+	t.Run("the requester's preference is used", func(t *testing.T) {
+		_, resp := th.Client.UpdatePreferences(th.BasicUser.Id, &model.Preferences{{
+			UserId:   th.BasicUser.Id,
+			Category: model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS,
+			Name:     model.PREFERENCE_NAME_NAME_FORMAT,
+			Value:    model.SHOW_FULLNAME,
+		}})
+		CheckNoError(t, resp)
+
+		displayNames, resp := th.Client.GetUserDisplayNames([]string{user.Id})
+		CheckNoError(t, resp)
+		assert.Equal(t, "Jane Doe", displayNames[user.Id])
+
+		_, resp = th.Client.UpdatePreferences(th.BasicUser.Id, &model.Preferences{{
+			UserId:   th.BasicUser.Id,
+			Category: model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS,
+			Name:     model.PREFERENCE_NAME_NAME_FORMAT,
+			Value:    model.SHOW_NICKNAME_FULLNAME,
+		}})
+		CheckNoError(t, resp)
+
+		displayNames, resp = th.Client.GetUserDisplayNames([]string{user.Id})
+		CheckNoError(t, resp)
+		assert.Equal(t, "jd", displayNames[user.Id])
+	})
+
+	t.Run("hidden full names aren't used", func(t *testing.T) {
+		_, resp := th.Client.UpdatePreferences(th.BasicUser.Id, &model.Preferences{{
+			UserId:   th.BasicUser.Id,
+			Category: model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS,
+			Name:     model.PREFERENCE_NAME_NAME_FORMAT,
+			Value:    model.SHOW_FULLNAME,
+		}})
+		CheckNoError(t, resp)
+
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.PrivacySettings.ShowFullName = false })
+
+		displayNames, resp := th.Client.GetUserDisplayNames([]string{user.Id})
+		CheckNoError(t, resp)
+		assert.Equal(t, user.Username, displayNames[user.Id])
+
+		_, resp = th.SystemAdminClient.UpdatePreferences(th.SystemAdminUser.Id, &model.Preferences{{
+			UserId:   th.SystemAdminUser.Id,
+			Category: model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS,
+			Name:     model.PREFERENCE_NAME_NAME_FORMAT,
+			Value:    model.SHOW_FULLNAME,
+		}})
+		CheckNoError(t, resp)
+
+		displayNames, resp = th.SystemAdminClient.GetUserDisplayNames([]string{user.Id})
+		CheckNoError(t, resp)
+		assert.Equal(t, "Jane Doe", displayNames[user.Id])
+	})
+
+	_, resp = th.Client.GetUserDisplayNames([]string{})
+	CheckBadRequestStatus(t, resp)
+
+	th.Client.Logout()
+	_, resp = th.Client.GetUserDisplayNames([]string{th.BasicUser.Id})
+	CheckUnauthorizedStatus(t, resp)
+}
+
 func TestGetUsersByUsernames(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -998,6 +1082,49 @@ func TestGetTotalUsersStat(t *testing.T) {
 	}
 }
 
+func TestExportUsersLastActivity(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	dormant := th.CreateUser()
+
+	_, resp := th.Client.ExportUsersLastActivity(0)
+	CheckForbiddenStatus(t, resp)
+
+	readRecords := func(data []byte) map[string][]string {
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		require.Nil(t, err)
+		require.NotEmpty(t, records)
+		assert.Equal(t, model.UserLastActivityCsvHeader, records[0])
+
+		byUserId := map[string][]string{}
+		for _, record := range records[1:] {
+			byUserId[record[0]] = record
+		}
+		return byUserId
+	}
+
+	data, resp := th.SystemAdminClient.ExportUsersLastActivity(0)
+	CheckNoError(t, resp)
+	records := readRecords(data)
+	require.Contains(t, records, th.BasicUser.Id)
+	require.Contains(t, records, dormant.Id)
+	assert.Equal(t, th.BasicUser.Username, records[th.BasicUser.Id][1])
+	assert.NotEqual(t, "0", records[th.BasicUser.Id][4])
+	assert.Equal(t, "0", records[dormant.Id][4])
+	assert.Equal(t, model.USER_LAST_ACTIVITY_STATUS_ACTIVE, records[dormant.Id][5])
+
+	data, resp = th.SystemAdminClient.ExportUsersLastActivity(model.GetMillis() - 60*60*1000)
+	CheckNoError(t, resp)
+	records = readRecords(data)
+	assert.Contains(t, records, dormant.Id)
+	assert.NotContains(t, records, th.BasicUser.Id)
+
+	th.Client.Logout()
+	_, resp = th.Client.ExportUsersLastActivity(0)
+	CheckUnauthorizedStatus(t, resp)
+}
+
 func TestUpdateUser(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -1197,6 +1324,33 @@ func TestPatchUser(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+func TestPatchUserEnforcedNotifyProps(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		cfg.NotificationDefaultSettings.NotifyProps = map[string]string{model.EMAIL_NOTIFY_PROP: "false"}
+		cfg.NotificationDefaultSettings.EnforcedNotifyProps = []string{model.EMAIL_NOTIFY_PROP}
+	})
+
+	user := th.CreateUser()
+	require.Equal(t, "false", user.NotifyProps[model.EMAIL_NOTIFY_PROP])
+	th.Client.Login(user.Email, user.Password)
+
+	patch := &model.UserPatch{NotifyProps: model.StringMap{model.EMAIL_NOTIFY_PROP: "true"}}
+	_, resp := th.Client.PatchUser(user.Id, patch)
+	CheckForbiddenStatus(t, resp)
+
+	patch.NotifyProps = model.StringMap{model.EMAIL_NOTIFY_PROP: "false", model.PUSH_NOTIFY_PROP: model.USER_NOTIFY_ALL}
+	ruser, resp := th.Client.PatchUser(user.Id, patch)
+	CheckNoError(t, resp)
+	assert.Equal(t, model.USER_NOTIFY_ALL, ruser.NotifyProps[model.PUSH_NOTIFY_PROP])
+
+	ruser.NotifyProps[model.EMAIL_NOTIFY_PROP] = "true"
+	_, resp = th.Client.UpdateUser(ruser)
+	CheckForbiddenStatus(t, resp)
+}
+
 func TestUpdateUserAuth(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -1560,6 +1714,74 @@ func TestGetRecentlyActiveUsersInTeam(t *testing.T) {
 	CheckUnauthorizedStatus(t, resp)
 }
 
+func TestGetUsersAsCsv(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	th.Client.HttpHeader = map[string]string{"Accept": "text/csv"}
+
+	r, resp := th.Client.DoApiGet(th.Client.GetUsersRoute()+"?in_team="+th.BasicTeam.Id, "")
+	require.Nil(t, resp)
+	defer closeBody(r)
+
+	assert.Equal(t, "text/csv; charset=utf-8", r.Header.Get("Content-Type"))
+	assert.Contains(t, r.Header["Vary"], "Accept")
+
+	records, err := csv.NewReader(r.Body).ReadAll()
+	require.Nil(t, err)
+	require.True(t, len(records) > 1)
+	assert.Equal(t, "id", records[0][0])
+
+	ids := []string{}
+	for _, record := range records[1:] {
+		ids = append(ids, record[0])
+	}
+	assert.Contains(t, ids, th.BasicUser.Id)
+	assert.Contains(t, ids, th.BasicUser2.Id)
+
+	t.Run("etag depends on the format", func(t *testing.T) {
+		th.Client.HttpHeader = map[string]string{"Accept": "text/csv"}
+		csvResponse, resp := th.Client.DoApiGet(th.Client.GetUsersRoute()+"?in_team="+th.BasicTeam.Id, "")
+		require.Nil(t, resp)
+		defer closeBody(csvResponse)
+
+		th.Client.HttpHeader = map[string]string{"Accept": "application/json"}
+		jsonResponse, resp := th.Client.DoApiGet(th.Client.GetUsersRoute()+"?in_team="+th.BasicTeam.Id, "")
+		require.Nil(t, resp)
+		defer closeBody(jsonResponse)
+
+		csvEtag := csvResponse.Header.Get(model.HEADER_ETAG_SERVER)
+		require.NotEmpty(t, csvEtag)
+		assert.NotEqual(t, jsonResponse.Header.Get(model.HEADER_ETAG_SERVER), csvEtag)
+
+		r, resp := th.Client.DoApiGet(th.Client.GetUsersRoute()+"?in_team="+th.BasicTeam.Id, csvEtag)
+		require.Nil(t, resp)
+		defer closeBody(r)
+		assert.Equal(t, http.StatusOK, r.StatusCode, "a CSV etag shouldn't match a JSON response")
+	})
+
+	t.Run("unsupported types fall back to json", func(t *testing.T) {
+		th.Client.HttpHeader = map[string]string{"Accept": "application/xml"}
+
+		r, resp := th.Client.DoApiGet(th.Client.GetUsersRoute(), "")
+		require.Nil(t, resp)
+		defer closeBody(r)
+
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NotEmpty(t, model.UserListFromJson(r.Body))
+	})
+
+	t.Run("unsupported types can be rejected", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.RejectUnacceptableResponseTypes = true })
+		defer th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.RejectUnacceptableResponseTypes = false })
+
+		_, resp := th.Client.DoApiGet(th.Client.GetUsersRoute(), "")
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusNotAcceptable, resp.StatusCode)
+		assert.Equal(t, "api.context.not_acceptable.app_error", resp.Id)
+	})
+}
+
 func TestGetUsersWithoutTeam(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -2933,6 +3155,55 @@ func TestEnableUserAccessToken(t *testing.T) {
 	th.Client.AuthToken = oldSessionToken
 }
 
+func TestValidateUserAccessToken(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	testDescription := "test token"
+
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableUserAccessTokens = true })
+
+	th.App.UpdateUserRoles(th.BasicUser.Id, model.SYSTEM_USER_ROLE_ID+" "+model.SYSTEM_USER_ACCESS_TOKEN_ROLE_ID, false)
+	token, resp := th.Client.CreateUserAccessToken(th.BasicUser.Id, testDescription)
+	CheckNoError(t, resp)
+
+	t.Run("regular session", func(t *testing.T) {
+		_, resp := th.Client.ValidateUserAccessToken()
+		CheckBadRequestStatus(t, resp)
+	})
+
+	client := th.CreateClient()
+	client.AuthToken = token.Token
+
+	t.Run("valid token", func(t *testing.T) {
+		validation, resp := client.ValidateUserAccessToken()
+		CheckNoError(t, resp)
+
+		assert.Equal(t, token.Id, validation.TokenId)
+		assert.True(t, validation.IsActive)
+		assert.Equal(t, testDescription, validation.Description)
+		assert.Equal(t, th.BasicUser.Id, validation.UserId)
+		assert.Equal(t, th.BasicUser.Username, validation.Username)
+		assert.Equal(t, int64(0), validation.ExpiresAt)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		invalidClient := th.CreateClient()
+		invalidClient.AuthToken = model.NewId()
+
+		_, resp := invalidClient.ValidateUserAccessToken()
+		CheckUnauthorizedStatus(t, resp)
+	})
+
+	t.Run("disabled token", func(t *testing.T) {
+		_, resp := th.Client.DisableUserAccessToken(token.Id)
+		CheckNoError(t, resp)
+
+		_, resp = client.ValidateUserAccessToken()
+		CheckUnauthorizedStatus(t, resp)
+	})
+}
+
 func TestUserAccessTokenInactiveUser(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
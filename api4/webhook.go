@@ -133,23 +133,27 @@ func updateIncomingHook(c *Context, w http.ResponseWriter, r *http.Request) {
 func getIncomingHooks(c *Context, w http.ResponseWriter, r *http.Request) {
 	teamId := r.URL.Query().Get("team_id")
 
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
 	var hooks []*model.IncomingWebhook
 	var err *model.AppError
-
 	if len(teamId) > 0 {
 		if !c.App.SessionHasPermissionToTeam(c.App.Session, teamId, model.PERMISSION_MANAGE_WEBHOOKS) {
 			c.SetPermissionError(model.PERMISSION_MANAGE_WEBHOOKS)
 			return
 		}
 
-		hooks, err = c.App.GetIncomingWebhooksForTeamPage(teamId, c.Params.Page, c.Params.PerPage)
+		hooks, err = c.App.GetIncomingWebhooksForTeamPage(teamId, pagination.Page, pagination.PerPage)
 	} else {
 		if !c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_WEBHOOKS) {
 			c.SetPermissionError(model.PERMISSION_MANAGE_WEBHOOKS)
 			return
 		}
 
-		hooks, err = c.App.GetIncomingWebhooksPage(c.Params.Page, c.Params.PerPage)
+		hooks, err = c.App.GetIncomingWebhooksPage(pagination.Page, pagination.PerPage)
 	}
 
 	if err != nil {
@@ -335,30 +339,34 @@ func getOutgoingHooks(c *Context, w http.ResponseWriter, r *http.Request) {
 	channelId := r.URL.Query().Get("channel_id")
 	teamId := r.URL.Query().Get("team_id")
 
+	pagination := c.Pagination()
+	if c.Err != nil {
+		return
+	}
+
 	var hooks []*model.OutgoingWebhook
 	var err *model.AppError
-
 	if len(channelId) > 0 {
 		if !c.App.SessionHasPermissionToChannel(c.App.Session, channelId, model.PERMISSION_MANAGE_WEBHOOKS) {
 			c.SetPermissionError(model.PERMISSION_MANAGE_WEBHOOKS)
 			return
 		}
 
-		hooks, err = c.App.GetOutgoingWebhooksForChannelPage(channelId, c.Params.Page, c.Params.PerPage)
+		hooks, err = c.App.GetOutgoingWebhooksForChannelPage(channelId, pagination.Page, pagination.PerPage)
 	} else if len(teamId) > 0 {
 		if !c.App.SessionHasPermissionToTeam(c.App.Session, teamId, model.PERMISSION_MANAGE_WEBHOOKS) {
 			c.SetPermissionError(model.PERMISSION_MANAGE_WEBHOOKS)
 			return
 		}
 
-		hooks, err = c.App.GetOutgoingWebhooksForTeamPage(teamId, c.Params.Page, c.Params.PerPage)
+		hooks, err = c.App.GetOutgoingWebhooksForTeamPage(teamId, pagination.Page, pagination.PerPage)
 	} else {
 		if !c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_WEBHOOKS) {
 			c.SetPermissionError(model.PERMISSION_MANAGE_WEBHOOKS)
 			return
 		}
 
-		hooks, err = c.App.GetOutgoingWebhooksPage(c.Params.Page, c.Params.PerPage)
+		hooks, err = c.App.GetOutgoingWebhooksPage(pagination.Page, pagination.PerPage)
 	}
 
 	if err != nil {
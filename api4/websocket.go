@@ -13,17 +13,29 @@ import (
 )
 
 func (api *API) InitWebSocket() {
-	api.BaseRoutes.ApiRoot.Handle("/websocket", api.ApiHandlerTrustRequester(connectWebSocket)).Methods("GET")
+	api.BaseRoutes.ApiRoot.Handle("/websocket", api.ApiCriticalHandlerTrustRequester(connectWebSocket)).Methods("GET")
 }
 
 func connectWebSocket(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !c.App.CheckWebSocketOrigin(r) {
+		c.Err = model.NewAppError("connect", "api.web_socket.connect.origin.app_error", nil, "origin="+r.Header.Get("Origin"), http.StatusForbidden)
+		return
+	}
+
 	upgrader := websocket.Upgrader{
 		ReadBufferSize:  model.SOCKET_MAX_MESSAGE_SIZE_KB,
 		WriteBufferSize: model.SOCKET_MAX_MESSAGE_SIZE_KB,
-		CheckOrigin:     c.App.OriginChecker(),
+		// The origin has already been checked above
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+
+	// The upgrade is written straight to the connection, leaving out the headers already set by ServeHTTP
+	var responseHeader http.Header
+	if nodeId := c.App.ExposedNodeId(); nodeId != "" {
+		responseHeader = http.Header{model.HEADER_NODE_ID: []string{nodeId}}
 	}
 
-	ws, err := upgrader.Upgrade(w, r, nil)
+	ws, err := upgrader.Upgrade(w, r, responseHeader)
 	if err != nil {
 		mlog.Error(fmt.Sprintf("websocket connect err: %v", err))
 		c.Err = model.NewAppError("connect", "api.web_socket.connect.upgrade.app_error", nil, "", http.StatusInternalServerError)
@@ -227,12 +227,15 @@ func TestWebsocketOriginSecurity(t *testing.T) {
 	url := fmt.Sprintf("ws://localhost:%v", th.App.Srv.ListenAddr.Port)
 
 	// Should fail because origin doesn't match
-	_, _, err := websocket.DefaultDialer.Dial(url+model.API_URL_SUFFIX+"/websocket", http.Header{
+	_, resp, err := websocket.DefaultDialer.Dial(url+model.API_URL_SUFFIX+"/websocket", http.Header{
 		"Origin": []string{"http://www.evil.com"},
 	})
 	if err == nil {
 		t.Fatal("Should have errored because Origin does not match host! SECURITY ISSUE!")
 	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatal("Should have rejected the connection with a 403")
+	}
 
 	// We are not a browser so we can spoof this just fine
 	_, _, err = websocket.DefaultDialer.Dial(url+model.API_URL_SUFFIX+"/websocket", http.Header{
@@ -242,17 +245,26 @@ func TestWebsocketOriginSecurity(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	// Should succeed now because open CORS
-	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.AllowCorsFrom = "*" })
+	// Should succeed because native clients don't send an origin
+	_, _, err = websocket.DefaultDialer.Dial(url+model.API_URL_SUFFIX+"/websocket", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Should still fail because CORS doesn't apply to websocket connections
+	th.App.UpdateConfig(func(cfg *model.Config) { cfg.CorsSettings.AllowedOrigins = []string{"*"} })
 	_, _, err = websocket.DefaultDialer.Dial(url+model.API_URL_SUFFIX+"/websocket", http.Header{
 		"Origin": []string{"http://www.evil.com"},
 	})
-	if err != nil {
-		t.Fatal(err)
+	if err == nil {
+		t.Fatal("Should have errored because CORS settings allowed the origin! SECURITY ISSUE!")
 	}
+	th.App.UpdateConfig(func(cfg *model.Config) { cfg.CorsSettings.AllowedOrigins = []string{} })
 
-	// Should succeed now because matching CORS
-	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.AllowCorsFrom = "http://www.evil.com" })
+	// Should succeed now because matching websocket origins
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		cfg.ServiceSettings.WebsocketAllowedOrigins = []string{"http://www.evil.com"}
+	})
 	_, _, err = websocket.DefaultDialer.Dial(url+model.API_URL_SUFFIX+"/websocket", http.Header{
 		"Origin": []string{"http://www.evil.com"},
 	})
@@ -260,17 +272,17 @@ func TestWebsocketOriginSecurity(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	// Should fail because non-matching CORS
-	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.AllowCorsFrom = "http://www.good.com" })
+	// Should fail because non-matching websocket origins
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		cfg.ServiceSettings.WebsocketAllowedOrigins = []string{"http://www.good.com"}
+	})
 	_, _, err = websocket.DefaultDialer.Dial(url+model.API_URL_SUFFIX+"/websocket", http.Header{
 		"Origin": []string{"http://www.evil.com"},
 	})
 	if err == nil {
-		t.Fatal("Should have errored because Origin contain AllowCorsFrom")
+		t.Fatal("Should have errored because Origin isn't in WebsocketAllowedOrigins")
 	}
 
-	// Should fail because non-matching CORS
-	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.AllowCorsFrom = "http://www.good.com" })
 	_, _, err = websocket.DefaultDialer.Dial(url+model.API_URL_SUFFIX+"/websocket", http.Header{
 		"Origin": []string{"http://www.good.co"},
 	})
@@ -278,7 +290,7 @@ func TestWebsocketOriginSecurity(t *testing.T) {
 		t.Fatal("Should have errored because Origin does not match host! SECURITY ISSUE!")
 	}
 
-	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.AllowCorsFrom = "" })
+	th.App.UpdateConfig(func(cfg *model.Config) { cfg.ServiceSettings.WebsocketAllowedOrigins = []string{} })
 }
 
 func TestWebSocketStatuses(t *testing.T) {
@@ -140,6 +140,7 @@ func (a *App) InvalidateAllCaches() *model.AppError {
 func (a *App) InvalidateAllCachesSkipSend() {
 	mlog.Info("Purging all caches")
 	a.Srv.sessionCache.Purge()
+	a.Srv.responseCache.Purge()
 	ClearStatusCache()
 	a.Srv.Store.Channel().ClearCaches()
 	a.Srv.Store.User().ClearCaches()
@@ -205,6 +206,32 @@ func (a *App) SaveConfig(cfg *model.Config, sendConfigChangeClusterMessage bool)
 	return nil
 }
 
+// GetMaintenanceMode returns whether the server is in maintenance mode and the message shown while it is.
+func (a *App) GetMaintenanceMode() *model.MaintenanceMode {
+	return &model.MaintenanceMode{
+		Enable:  *a.Config().ServiceSettings.MaintenanceMode,
+		Message: *a.Config().ServiceSettings.MaintenanceModeMessage,
+	}
+}
+
+// PatchMaintenanceMode turns maintenance mode on or off and saves it to the config, so it applies to requests straight
+// away, is sent to the other nodes of a cluster and outlasts a restart.
+func (a *App) PatchMaintenanceMode(patch *model.MaintenanceModePatch) (*model.MaintenanceMode, *model.AppError) {
+	cfg := a.Config().Clone()
+	if patch.Enable != nil {
+		cfg.ServiceSettings.MaintenanceMode = model.NewBool(*patch.Enable)
+	}
+	if patch.Message != nil {
+		cfg.ServiceSettings.MaintenanceModeMessage = model.NewString(*patch.Message)
+	}
+
+	if err := a.SaveConfig(cfg, true); err != nil {
+		return nil, err
+	}
+
+	return a.GetMaintenanceMode(), nil
+}
+
 func (a *App) RecycleDatabaseConnection() {
 	oldStore := a.Srv.Store
 
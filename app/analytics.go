@@ -5,6 +5,7 @@ package app
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/model"
@@ -282,3 +283,46 @@ func (a *App) GetNewUsersForTeamPage(teamId string, page, perPage int, asAdmin b
 
 	return a.sanitizeProfiles(users, asAdmin), nil
 }
+
+// GetTeamActivitySummary returns aggregate activity counts for a team between since and until.
+func (a *App) GetTeamActivitySummary(teamId string, since int64, until int64) (*model.TeamActivitySummary, *model.AppError) {
+	if since < 0 || until <= since || until-since > model.TEAM_ACTIVITY_MAX_DAYS*DAY_MILLISECONDS {
+		return nil, model.NewAppError("GetTeamActivitySummary", "app.analytics.team_activity.range.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	daysChan := a.Srv.Store.Post().AnalyticsTeamActivityByDay(teamId, since, until)
+	postersChan := a.Srv.Store.Post().AnalyticsPostersCount(teamId, since, until)
+	channelsChan := a.Srv.Store.Channel().AnalyticsCreatedCount(teamId, since, until)
+
+	summary := &model.TeamActivitySummary{
+		TeamId: teamId,
+		Since:  since,
+		Until:  until,
+		Days:   []*model.TeamActivityDay{},
+	}
+
+	r := <-daysChan
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	if days := r.Data.([]*model.TeamActivityDay); days != nil {
+		summary.Days = days
+	}
+	for _, day := range summary.Days {
+		summary.TotalPosts += day.PostCount
+	}
+
+	r = <-postersChan
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	summary.ActiveUsers = r.Data.(int64)
+
+	r = <-channelsChan
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	summary.NewChannels = r.Data.(int64)
+
+	return summary, nil
+}
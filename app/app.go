@@ -4,6 +4,7 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -16,6 +17,7 @@ import (
 	"github.com/mattermost/mattermost-server/services/httpservice"
 	"github.com/mattermost/mattermost-server/services/imageproxy"
 	"github.com/mattermost/mattermost-server/services/timezones"
+	"github.com/mattermost/mattermost-server/services/tracing"
 	"github.com/mattermost/mattermost-server/utils"
 	goi18n "github.com/nicksnyder/go-i18n/i18n"
 )
@@ -33,6 +35,14 @@ type App struct {
 	UserAgent      string
 	AcceptLanguage string
 
+	// Span is the trace span of the request being handled, if it's being traced. Operations that are worth tracing
+	// separately can be recorded as its children using Span.StartChild.
+	Span *tracing.Span
+
+	// Context is the context of the request being handled, if any, which is cancelled once the request times out.
+	// Long running operations should give up when it's done.
+	Context context.Context
+
 	AccountMigration einterfaces.AccountMigrationInterface
 	Cluster          einterfaces.ClusterInterface
 	Compliance       einterfaces.ComplianceInterface
@@ -41,6 +51,7 @@ type App struct {
 	Ldap             einterfaces.LdapInterface
 	MessageExport    einterfaces.MessageExportInterface
 	Metrics          einterfaces.MetricsInterface
+	MfaVerifier      einterfaces.MfaVerifierInterface
 	Saml             einterfaces.SamlInterface
 
 	HTTPService httpservice.HTTPService
@@ -61,6 +72,17 @@ func New(options ...AppOption) *App {
 // DO NOT CALL THIS.
 // This is to avoid having to change all the code in cmd/mattermost/commands/* for now
 // shutdown should be called directly on the server
+// RequestContext returns the context of the request being handled, or a background context if there isn't one, so that
+// outgoing requests that the request waits on are abandoned once it times out. Work that's been handed off to
+// Srv.Go can outlive the request, so it mustn't use it.
+func (a *App) RequestContext() context.Context {
+	if a.Context == nil {
+		return context.Background()
+	}
+
+	return a.Context
+}
+
 func (a *App) Shutdown() {
 	a.Srv.Shutdown()
 	a.Srv = nil
@@ -72,6 +94,7 @@ func (a *App) configOrLicenseListener() {
 
 func (s *Server) initJobs() {
 	s.Jobs = jobs.NewJobServer(s, s.Store)
+	s.Jobs.Metrics = s.Metrics
 	if jobsDataRetentionJobInterface != nil {
 		s.Jobs.DataRetentionJob = jobsDataRetentionJobInterface(s.FakeApp())
 	}
@@ -93,6 +116,36 @@ func (s *Server) initJobs() {
 	if jobsPluginsInterface != nil {
 		s.Jobs.Plugins = jobsPluginsInterface(s.FakeApp())
 	}
+	if jobsExpirePinsInterface != nil {
+		s.Jobs.ExpirePins = jobsExpirePinsInterface(s.FakeApp())
+	}
+	if jobsInactiveUsersInterface != nil {
+		s.Jobs.InactiveUsers = jobsInactiveUsersInterface(s.FakeApp())
+	}
+	if jobsInactiveChannelsInterface != nil {
+		s.Jobs.InactiveChannels = jobsInactiveChannelsInterface(s.FakeApp())
+	}
+	if jobsColdStorageInterface != nil {
+		s.Jobs.ColdStorage = jobsColdStorageInterface(s.FakeApp())
+	}
+	if jobsFileIntegrityScanInterface != nil {
+		s.Jobs.FileIntegrityScan = jobsFileIntegrityScanInterface(s.FakeApp())
+	}
+	if jobsMoveChannelInterface != nil {
+		s.Jobs.MoveChannel = jobsMoveChannelInterface(s.FakeApp())
+	}
+	if jobsMergeTeamsInterface != nil {
+		s.Jobs.MergeTeams = jobsMergeTeamsInterface(s.FakeApp())
+	}
+	if jobsCountRepairInterface != nil {
+		s.Jobs.CountRepair = jobsCountRepairInterface(s.FakeApp())
+	}
+	if jobsElasticsearchReindexInterface != nil {
+		s.Jobs.ElasticsearchReindex = jobsElasticsearchReindexInterface(s.FakeApp())
+	}
+	if jobsPluginJobsInterface != nil {
+		s.Jobs.PluginJobs = jobsPluginJobsInterface(s.FakeApp())
+	}
 	s.Jobs.Workers = s.Jobs.InitWorkers()
 	s.Jobs.Schedulers = s.Jobs.InitSchedulers()
 }
@@ -228,25 +228,21 @@ func (a *App) authenticateUser(user *model.User, password, mfaToken string) (*mo
 	return user, nil
 }
 
+// ParseAuthTokenFromRequest returns the token that a request is authenticated with and where it was found. A token in
+// the Authorization header is preferred over the session cookie, since a client that sends one, such as a script using
+// a personal access token, means to act with it rather than with whatever session the cookie belongs to. Authorization
+// headers that aren't in a form that's understood are ignored, leaving the request to be authenticated by its cookie.
 func ParseAuthTokenFromRequest(r *http.Request) (string, TokenLocation) {
-	authHeader := r.Header.Get(model.HEADER_AUTH)
+	// Parse the token from the header
+	if token, ok := parseAuthHeader(r.Header.Get(model.HEADER_AUTH)); ok {
+		return token, TokenLocationHeader
+	}
 
 	// Attempt to parse the token from the cookie
 	if cookie, err := r.Cookie(model.SESSION_COOKIE_TOKEN); err == nil {
 		return cookie.Value, TokenLocationCookie
 	}
 
-	// Parse the token from the header
-	if len(authHeader) > 6 && strings.ToUpper(authHeader[0:6]) == model.HEADER_BEARER {
-		// Default session token
-		return authHeader[7:], TokenLocationHeader
-	}
-
-	if len(authHeader) > 5 && strings.ToLower(authHeader[0:5]) == model.HEADER_TOKEN {
-		// OAuth token
-		return authHeader[6:], TokenLocationHeader
-	}
-
 	// Attempt to parse token out of the query string
 	if token := r.URL.Query().Get("access_token"); token != "" {
 		return token, TokenLocationQueryString
@@ -254,3 +250,24 @@ func ParseAuthTokenFromRequest(r *http.Request) (string, TokenLocation) {
 
 	return "", TokenLocationNotFound
 }
+
+// parseAuthHeader returns the token from an Authorization header of the form "Bearer <token>", which is used for
+// session and personal access tokens, or "Token <token>", which is used for OAuth access tokens. The scheme is case
+// insensitive. It returns false for anything else, including a header without a token or with more than one.
+func parseAuthHeader(authHeader string) (string, bool) {
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 {
+		return "", false
+	}
+
+	scheme, token := parts[0], strings.TrimSpace(parts[1])
+	if !strings.EqualFold(scheme, model.HEADER_BEARER) && !strings.EqualFold(scheme, model.HEADER_TOKEN) {
+		return "", false
+	}
+
+	if token == "" || strings.ContainsAny(token, " \t") {
+		return "", false
+	}
+
+	return token, true
+}
@@ -26,6 +26,16 @@ func TestParseAuthTokenFromRequest(t *testing.T) {
 		{"BEARER mytoken", "", "", "mytoken", TokenLocationHeader},
 		{"", "mytoken", "", "mytoken", TokenLocationCookie},
 		{"", "", "mytoken", "mytoken", TokenLocationQueryString},
+		{"Bearer mytoken", "", "", "mytoken", TokenLocationHeader},
+		{"bearer mytoken", "", "", "mytoken", TokenLocationHeader},
+		{"Bearer headertoken", "cookietoken", "", "headertoken", TokenLocationHeader},
+		{"Token headertoken", "cookietoken", "", "headertoken", TokenLocationHeader},
+		{"Bearer", "cookietoken", "", "cookietoken", TokenLocationCookie},
+		{"Bearer ", "cookietoken", "", "cookietoken", TokenLocationCookie},
+		{"Bearer my token", "cookietoken", "", "cookietoken", TokenLocationCookie},
+		{"Basic dXNlcjpwYXNz", "cookietoken", "", "cookietoken", TokenLocationCookie},
+		{"Bearermytoken", "", "", "", TokenLocationNotFound},
+		{"Basic dXNlcjpwYXNz", "", "mytoken", "mytoken", TokenLocationQueryString},
 	}
 
 	for testnum, tc := range cases {
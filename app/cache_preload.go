@@ -0,0 +1,83 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// PreloadCaches warms the store caches for the most recently active users, their channel memberships and the
+// channels they belong to, so that a freshly started node doesn't send its first requests straight to the database.
+// It gives up once ServiceSettings.CachePreloadingTimeoutSeconds have passed, keeping whatever was loaded until then,
+// and returns the number of users and channels that were loaded.
+func (a *App) PreloadCaches() (int, int) {
+	settings := a.Config().ServiceSettings
+	deadline := time.Now().Add(time.Duration(*settings.CachePreloadingTimeoutSeconds) * time.Second)
+	start := time.Now()
+
+	result := <-a.Srv.Store.Status().GetRecentlyActive(*settings.CachePreloadingMaxUsers)
+	if result.Err != nil {
+		mlog.Warn("Failed to get recently active users to preload caches", mlog.Err(result.Err))
+		return 0, 0
+	}
+	statuses := result.Data.([]*model.Status)
+
+	userIds := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		userIds = append(userIds, status.UserId)
+	}
+
+	if len(userIds) > 0 {
+		if result := <-a.Srv.Store.User().GetProfileByIds(userIds, true); result.Err != nil {
+			mlog.Warn("Failed to preload user profiles", mlog.Err(result.Err))
+		}
+	}
+
+	users := 0
+	channelIds := []string{}
+	seen := map[string]bool{}
+	for _, userId := range userIds {
+		if time.Now().After(deadline) {
+			break
+		}
+
+		result := <-a.Srv.Store.Channel().GetAllChannelMembersForUser(userId, true, false)
+		if result.Err != nil {
+			mlog.Warn("Failed to preload channel memberships", mlog.String("user_id", userId), mlog.Err(result.Err))
+			continue
+		}
+		users++
+
+		for channelId := range result.Data.(map[string]string) {
+			if !seen[channelId] {
+				seen[channelId] = true
+				channelIds = append(channelIds, channelId)
+			}
+		}
+	}
+
+	channels := 0
+	for _, channelId := range channelIds {
+		if time.Now().After(deadline) {
+			break
+		}
+
+		if result := <-a.Srv.Store.Channel().Get(channelId, true); result.Err != nil {
+			mlog.Warn("Failed to preload channel", mlog.String("channel_id", channelId), mlog.Err(result.Err))
+			continue
+		}
+		channels++
+	}
+
+	if time.Now().After(deadline) {
+		mlog.Warn("Cache preloading timed out", mlog.Int("users", users), mlog.Int("channels", channels))
+	} else {
+		mlog.Info("Finished preloading caches", mlog.Int("users", users), mlog.Int("channels", channels), mlog.String("duration", time.Since(start).String()))
+	}
+
+	return users, channels
+}
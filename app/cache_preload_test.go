@@ -0,0 +1,36 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestPreloadCaches(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	th.App.SetStatusOnline(th.BasicUser.Id, true)
+	th.App.SetStatusOnline(th.BasicUser2.Id, true)
+
+	t.Run("loads recently active users and their channels", func(t *testing.T) {
+		users, channels := th.App.PreloadCaches()
+		assert.True(t, users >= 2)
+		assert.True(t, channels > 0)
+	})
+
+	t.Run("limited to the most recently active users", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.CachePreloadingMaxUsers = 1 })
+		defer th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.CachePreloadingMaxUsers = model.SERVICE_SETTINGS_DEFAULT_CACHE_PRELOADING_MAX_USERS
+		})
+
+		users, _ := th.App.PreloadCaches()
+		assert.Equal(t, 1, users)
+	})
+}
@@ -6,6 +6,7 @@ package app
 import (
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -19,13 +20,13 @@ import (
 func (a *App) CreateDefaultChannels(teamId string) ([]*model.Channel, *model.AppError) {
 	townSquare := &model.Channel{DisplayName: utils.T("api.channel.create_default_channels.town_square"), Name: "town-square", Type: model.CHANNEL_OPEN, TeamId: teamId}
 
-	if _, err := a.CreateChannel(townSquare, false); err != nil {
+	if _, err := a.createChannel(townSquare, false); err != nil {
 		return nil, err
 	}
 
 	offTopic := &model.Channel{DisplayName: utils.T("api.channel.create_default_channels.off_topic"), Name: "off-topic", Type: model.CHANNEL_OPEN, TeamId: teamId}
 
-	if _, err := a.CreateChannel(offTopic, false); err != nil {
+	if _, err := a.createChannel(offTopic, false); err != nil {
 		return nil, err
 	}
 
@@ -33,16 +34,8 @@ func (a *App) CreateDefaultChannels(teamId string) ([]*model.Channel, *model.App
 	return channels, nil
 }
 
-func (a *App) JoinDefaultChannels(teamId string, user *model.User, shouldBeAdmin bool, userRequestorId string) *model.AppError {
-	var requestor *model.User
-	if userRequestorId != "" {
-		u := <-a.Srv.Store.User().Get(userRequestorId)
-		if u.Err != nil {
-			return u.Err
-		}
-		requestor = u.Data.(*model.User)
-	}
-
+// DefaultChannelNames returns the names of the channels that users join when they join a team.
+func (a *App) DefaultChannelNames() []string {
 	defaultChannelList := []string{"town-square"}
 
 	if len(a.Config().TeamSettings.ExperimentalDefaultChannels) == 0 {
@@ -57,8 +50,21 @@ func (a *App) JoinDefaultChannels(teamId string, user *model.User, shouldBeAdmin
 		}
 	}
 
+	return defaultChannelList
+}
+
+func (a *App) JoinDefaultChannels(teamId string, user *model.User, shouldBeAdmin bool, userRequestorId string) *model.AppError {
+	var requestor *model.User
+	if userRequestorId != "" {
+		u := <-a.Srv.Store.User().Get(userRequestorId)
+		if u.Err != nil {
+			return u.Err
+		}
+		requestor = u.Data.(*model.User)
+	}
+
 	var err *model.AppError
-	for _, channelName := range defaultChannelList {
+	for _, channelName := range a.DefaultChannelNames() {
 		if result := <-a.Srv.Store.Channel().GetByName(teamId, channelName, true); result.Err != nil {
 			err = result.Err
 		} else {
@@ -78,6 +84,8 @@ func (a *App) JoinDefaultChannels(teamId string, user *model.User, shouldBeAdmin
 
 			if cmResult := <-a.Srv.Store.Channel().SaveMember(cm); cmResult.Err != nil {
 				err = cmResult.Err
+			} else {
+				a.publishFirehoseEvent(model.FIREHOSE_EVENT_CHANNEL_MEMBER_ADDED, channel.TeamId, channel.Id, user.Id, cm)
 			}
 			if result = <-a.Srv.Store.ChannelMemberHistory().LogJoinEvent(user.Id, channel.Id, model.GetMillis()); result.Err != nil {
 				mlog.Warn(fmt.Sprintf("Failed to update ChannelMemberHistory table %v", result.Err))
@@ -186,7 +194,63 @@ func (a *App) RenameChannel(channel *model.Channel, newChannelName string, newDi
 	return newChannel, nil
 }
 
+// checkChannelNamingConvention returns an error if the channel's URL name or display name doesn't match the naming
+// patterns of its team, unless the session is allowed to manage the team. Only names that differ from those of the
+// saved channel are checked, so that channels created before the patterns were set keep their names until they are
+// renamed. isNew is true for channels that haven't been saved yet.
+func (a *App) checkChannelNamingConvention(channel *model.Channel, isNew bool) *model.AppError {
+	if channel.TeamId == "" || (channel.Type != model.CHANNEL_OPEN && channel.Type != model.CHANNEL_PRIVATE) {
+		return nil
+	}
+
+	team, err := a.GetTeam(channel.TeamId)
+	if err != nil {
+		return err
+	}
+
+	if team.ChannelNamePattern == "" && team.ChannelDisplayNamePattern == "" {
+		return nil
+	}
+
+	if a.SessionHasPermissionToTeam(a.Session, channel.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		return nil
+	}
+
+	var oldChannel *model.Channel
+	if !isNew {
+		result := <-a.Srv.Store.Channel().Get(channel.Id, false)
+		if result.Err != nil {
+			return result.Err
+		}
+		oldChannel = result.Data.(*model.Channel)
+	}
+
+	if (oldChannel == nil || channel.Name != oldChannel.Name) && !team.IsChannelNameAllowed(channel.Name) {
+		return model.NewAppError("checkChannelNamingConvention", "api.channel.naming_convention.name.app_error", map[string]interface{}{"Pattern": team.ChannelNamePattern}, "name="+channel.Name, http.StatusBadRequest)
+	}
+
+	if (oldChannel == nil || channel.DisplayName != oldChannel.DisplayName) && !team.IsChannelDisplayNameAllowed(channel.DisplayName) {
+		return model.NewAppError("checkChannelNamingConvention", "api.channel.naming_convention.display_name.app_error", map[string]interface{}{"Pattern": team.ChannelDisplayNamePattern}, "display_name="+channel.DisplayName, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
 func (a *App) CreateChannel(channel *model.Channel, addMember bool) (*model.Channel, *model.AppError) {
+	if err := a.checkChannelNamingConvention(channel, true); err != nil {
+		return nil, err
+	}
+
+	return a.createChannel(channel, addMember)
+}
+
+// createChannel creates a channel without checking its names against the naming patterns of its team, which is only
+// done for the channels that every team is created with.
+func (a *App) createChannel(channel *model.Channel, addMember bool) (*model.Channel, *model.AppError) {
+	if err := a.checkMaxPostSizeOverride(channel.MaxPostSize); err != nil {
+		return nil, err
+	}
+
 	result := <-a.Srv.Store.Channel().Save(channel, *a.Config().TeamSettings.MaxChannelsPerTeam)
 	if result.Err != nil {
 		return nil, result.Err
@@ -194,6 +258,8 @@ func (a *App) CreateChannel(channel *model.Channel, addMember bool) (*model.Chan
 
 	sc := result.Data.(*model.Channel)
 
+	a.publishFirehoseEvent(model.FIREHOSE_EVENT_CHANNEL_CREATED, sc.TeamId, sc.Id, "", sc)
+
 	if addMember {
 		cm := &model.ChannelMember{
 			ChannelId:   sc.Id,
@@ -210,6 +276,8 @@ func (a *App) CreateChannel(channel *model.Channel, addMember bool) (*model.Chan
 			mlog.Warn(fmt.Sprintf("Failed to update ChannelMemberHistory table %v", result.Err))
 		}
 
+		a.publishFirehoseEvent(model.FIREHOSE_EVENT_CHANNEL_MEMBER_ADDED, sc.TeamId, sc.Id, cm.UserId, cm)
+
 		a.InvalidateCacheForUser(channel.CreatorId)
 	}
 
@@ -243,6 +311,8 @@ func (a *App) GetOrCreateDirectChannel(userId, otherUserId string) (*model.Chann
 			a.InvalidateCacheForUser(userId)
 			a.InvalidateCacheForUser(otherUserId)
 
+			a.publishFirehoseEvent(model.FIREHOSE_EVENT_CHANNEL_CREATED, "", channel.Id, "", channel)
+
 			if pluginsEnvironment := a.GetPluginsEnvironment(); pluginsEnvironment != nil {
 				a.Srv.Go(func() {
 					pluginContext := a.PluginContext()
@@ -377,6 +447,8 @@ func (a *App) createGroupChannel(userIds []string, creatorId string) (*model.Cha
 	}
 	channel := result.Data.(*model.Channel)
 
+	a.publishFirehoseEvent(model.FIREHOSE_EVENT_CHANNEL_CREATED, "", channel.Id, "", channel)
+
 	for _, user := range users {
 		cm := &model.ChannelMember{
 			UserId:      user.Id,
@@ -388,6 +460,7 @@ func (a *App) createGroupChannel(userIds []string, creatorId string) (*model.Cha
 		if result := <-a.Srv.Store.Channel().SaveMember(cm); result.Err != nil {
 			return nil, result.Err
 		}
+		a.publishFirehoseEvent(model.FIREHOSE_EVENT_CHANNEL_MEMBER_ADDED, "", channel.Id, user.Id, cm)
 		if result := <-a.Srv.Store.ChannelMemberHistory().LogJoinEvent(user.Id, channel.Id, model.GetMillis()); result.Err != nil {
 			mlog.Warn(fmt.Sprintf("Failed to update ChannelMemberHistory table %v", result.Err))
 		}
@@ -420,6 +493,10 @@ func (a *App) GetGroupChannel(userIds []string) (*model.Channel, *model.AppError
 }
 
 func (a *App) UpdateChannel(channel *model.Channel) (*model.Channel, *model.AppError) {
+	if err := a.checkChannelNamingConvention(channel, false); err != nil {
+		return nil, err
+	}
+
 	result := <-a.Srv.Store.Channel().Update(channel)
 	if result.Err != nil {
 		return nil, result.Err
@@ -427,6 +504,8 @@ func (a *App) UpdateChannel(channel *model.Channel) (*model.Channel, *model.AppE
 
 	a.InvalidateCacheForChannel(channel)
 
+	a.publishFirehoseEvent(model.FIREHOSE_EVENT_CHANNEL_UPDATED, channel.TeamId, channel.Id, "", channel)
+
 	messageWs := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_CHANNEL_UPDATED, "", channel.Id, "", nil)
 	messageWs.Add("channel", channel.ToJson())
 	a.Publish(messageWs)
@@ -434,6 +513,26 @@ func (a *App) UpdateChannel(channel *model.Channel) (*model.Channel, *model.AppE
 	return channel, nil
 }
 
+// SetChannelBanner designates a post in the channel as its banner, replacing any previous one. An empty postId
+// clears the banner.
+func (a *App) SetChannelBanner(channel *model.Channel, postId string) (*model.Channel, *model.AppError) {
+	if len(postId) != 0 {
+		post, err := a.GetSinglePost(postId)
+		if err != nil {
+			return nil, err
+		}
+
+		if post.ChannelId != channel.Id {
+			return nil, model.NewAppError("SetChannelBanner", "api.channel.set_channel_banner.post_channel.app_error", nil, "post_id="+postId+", channel_id="+channel.Id, http.StatusBadRequest)
+		}
+	}
+
+	channel = channel.DeepCopy()
+	channel.BannerPostId = postId
+
+	return a.UpdateChannel(channel)
+}
+
 func (a *App) UpdateChannelScheme(channel *model.Channel) (*model.Channel, *model.AppError) {
 	var oldChannel *model.Channel
 	var err *model.AppError
@@ -512,6 +611,12 @@ func (a *App) PatchChannel(channel *model.Channel, patch *model.ChannelPatch, us
 	oldChannelHeader := channel.Header
 	oldChannelPurpose := channel.Purpose
 
+	if patch.MaxPostSize != nil {
+		if err := a.checkMaxPostSizeOverride(*patch.MaxPostSize); err != nil {
+			return nil, err
+		}
+	}
+
 	channel.Patch(patch)
 	channel, err := a.UpdateChannel(channel)
 	if err != nil {
@@ -582,6 +687,23 @@ func (a *App) UpdateChannelMemberRoles(channelId string, userId string, newRoles
 		return nil, err
 	}
 
+	if err = a.setChannelMemberRoles(member, newRoles, schemeUserRole, schemeAdminRole); err != nil {
+		return nil, err
+	}
+
+	result := <-a.Srv.Store.Channel().UpdateMember(member)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	member = result.Data.(*model.ChannelMember)
+
+	a.InvalidateCacheForUser(userId)
+	return member, nil
+}
+
+// setChannelMemberRoles replaces the roles of a channel member with the given space-separated roles, without saving
+// it. Roles managed by the channel's scheme are set through the member's scheme flags.
+func (a *App) setChannelMemberRoles(member *model.ChannelMember, newRoles string, schemeUserRole string, schemeAdminRole string) *model.AppError {
 	var newExplicitRoles []string
 	member.SchemeUser = false
 	member.SchemeAdmin = false
@@ -590,7 +712,7 @@ func (a *App) UpdateChannelMemberRoles(channelId string, userId string, newRoles
 		role, err := a.GetRoleByName(roleName)
 		if err != nil {
 			err.StatusCode = http.StatusBadRequest
-			return nil, err
+			return err
 		}
 
 		if !role.SchemeManaged {
@@ -605,21 +727,97 @@ func (a *App) UpdateChannelMemberRoles(channelId string, userId string, newRoles
 				member.SchemeUser = true
 			default:
 				// If not part of the scheme for this channel, then it is not allowed to apply it as an explicit role.
-				return nil, model.NewAppError("UpdateChannelMemberRoles", "api.channel.update_channel_member_roles.scheme_role.app_error", nil, "role_name="+roleName, http.StatusBadRequest)
+				return model.NewAppError("UpdateChannelMemberRoles", "api.channel.update_channel_member_roles.scheme_role.app_error", nil, "role_name="+roleName, http.StatusBadRequest)
 			}
 		}
 	}
 
 	member.ExplicitRoles = strings.Join(newExplicitRoles, " ")
+	return nil
+}
 
-	result := <-a.Srv.Store.Channel().UpdateMember(member)
+// UpdateChannelMembersRolesBulk replaces the roles of several members of a channel at once, given as a map of user id
+// to space-separated roles. Users that aren't members of the channel or are given invalid roles are reported with an
+// error and left unchanged, while the other members are all updated in a single transaction. The channel is notified
+// of the updated members with a single event.
+func (a *App) UpdateChannelMembersRolesBulk(channel *model.Channel, request model.ChannelMemberRolesBulkRequest) ([]*model.ChannelMemberBulkResult, *model.AppError) {
+	if channel.DeleteAt > 0 {
+		return nil, model.NewAppError("UpdateChannelMembersRolesBulk", "api.channel.update_channel_member_roles_bulk.deleted.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	schemeUserRole, schemeAdminRole, err := a.GetSchemeRolesForChannel(channel.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	userIds := make([]string, 0, len(request))
+	for userId := range request {
+		userIds = append(userIds, userId)
+	}
+	sort.Strings(userIds)
+
+	result := <-a.Srv.Store.Channel().GetMembersByIds(channel.Id, userIds)
 	if result.Err != nil {
 		return nil, result.Err
 	}
-	member = result.Data.(*model.ChannelMember)
+	members := make(map[string]*model.ChannelMember)
+	for _, member := range *result.Data.(*model.ChannelMembers) {
+		member := member
+		members[member.UserId] = &member
+	}
 
-	a.InvalidateCacheForUser(userId)
-	return member, nil
+	results := make([]*model.ChannelMemberBulkResult, 0, len(userIds))
+	pending := make(map[string]*model.ChannelMemberBulkResult)
+	var toUpdate []*model.ChannelMember
+	for _, userId := range userIds {
+		member, ok := members[userId]
+		if !ok {
+			results = append(results, &model.ChannelMemberBulkResult{
+				UserId: userId,
+				Error:  model.NewAppError("UpdateChannelMembersRolesBulk", "api.channel.update_channel_member_roles_bulk.not_member.app_error", nil, "user_id="+userId, http.StatusBadRequest),
+			})
+			continue
+		}
+
+		if !model.IsValidUserRoles(request[userId]) {
+			results = append(results, &model.ChannelMemberBulkResult{
+				UserId: userId,
+				Error:  model.NewAppError("UpdateChannelMembersRolesBulk", "api.context.invalid_body_param.app_error", map[string]interface{}{"Name": "roles"}, "user_id="+userId, http.StatusBadRequest),
+			})
+			continue
+		}
+
+		if err := a.setChannelMemberRoles(member, request[userId], schemeUserRole, schemeAdminRole); err != nil {
+			results = append(results, &model.ChannelMemberBulkResult{UserId: userId, Error: err})
+			continue
+		}
+
+		pending[userId] = &model.ChannelMemberBulkResult{UserId: userId}
+		results = append(results, pending[userId])
+		toUpdate = append(toUpdate, member)
+	}
+
+	if len(toUpdate) == 0 {
+		return results, nil
+	}
+
+	result = <-a.Srv.Store.Channel().UpdateMultipleMembers(toUpdate)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	updated := make(model.ChannelMembers, 0, len(toUpdate))
+	for _, member := range result.Data.([]*model.ChannelMember) {
+		pending[member.UserId].Member = member
+		updated = append(updated, *member)
+		a.InvalidateCacheForUser(member.UserId)
+	}
+
+	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_CHANNEL_MEMBERS_UPDATED, "", channel.Id, "", nil)
+	message.Add("channel_members", updated.ToJson())
+	a.Publish(message)
+
+	return results, nil
 }
 
 func (a *App) UpdateChannelMemberSchemeRoles(channelId string, userId string, isSchemeUser bool, isSchemeAdmin bool) (*model.ChannelMember, *model.AppError) {
@@ -653,6 +851,10 @@ func (a *App) UpdateChannelMemberNotifyProps(data map[string]string, channelId s
 		return nil, err
 	}
 
+	if err = a.checkEnforcedChannelNotifyProps(member.NotifyProps, data); err != nil {
+		return nil, err
+	}
+
 	// update whichever notify properties have been provided, but don't change the others
 	if markUnread, exists := data[model.MARK_UNREAD_NOTIFY_PROP]; exists {
 		member.NotifyProps[model.MARK_UNREAD_NOTIFY_PROP] = markUnread
@@ -674,6 +876,10 @@ func (a *App) UpdateChannelMemberNotifyProps(data map[string]string, channelId s
 		member.NotifyProps[model.IGNORE_CHANNEL_MENTIONS_NOTIFY_PROP] = ignoreChannelMentions
 	}
 
+	if muteSchedule, exists := data[model.MUTE_SCHEDULE_NOTIFY_PROP]; exists {
+		member.NotifyProps[model.MUTE_SCHEDULE_NOTIFY_PROP] = muteSchedule
+	}
+
 	result := <-a.Srv.Store.Channel().UpdateMember(member)
 	if result.Err != nil {
 		return nil, result.Err
@@ -764,6 +970,10 @@ func (a *App) DeleteChannel(channel *model.Channel, userId string) *model.AppErr
 	}
 	a.InvalidateCacheForChannel(channel)
 
+	deletedChannel := channel.DeepCopy()
+	deletedChannel.DeleteAt = deleteAt
+	a.publishFirehoseEvent(model.FIREHOSE_EVENT_CHANNEL_DELETED, channel.TeamId, channel.Id, "", deletedChannel)
+
 	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_CHANNEL_DELETED, channel.TeamId, "", "", nil)
 	message.Add("channel_id", channel.Id)
 	message.Add("delete_at", deleteAt)
@@ -807,6 +1017,8 @@ func (a *App) addUserToChannel(user *model.User, channel *model.Channel, teamMem
 	a.InvalidateCacheForUser(user.Id)
 	a.InvalidateCacheForChannelMembers(channel.Id)
 
+	a.publishFirehoseEvent(model.FIREHOSE_EVENT_CHANNEL_MEMBER_ADDED, channel.TeamId, channel.Id, user.Id, newMember)
+
 	return newMember, nil
 }
 
@@ -889,6 +1101,271 @@ func (a *App) AddChannelMember(userId string, channel *model.Channel, userReques
 	return cm, nil
 }
 
+// AddChannelMembersBulk adds the users selected by the request to a channel in batches, reporting the outcome for
+// each user. Users must be active members of the channel's team. A user that can't be added doesn't prevent the
+// others from being added.
+func (a *App) AddChannelMembersBulk(channel *model.Channel, request *model.ChannelMembersBulkRequest, userRequestorId string) ([]*model.ChannelMemberBulkResult, *model.AppError) {
+	if channel.Type != model.CHANNEL_OPEN && channel.Type != model.CHANNEL_PRIVATE {
+		return nil, model.NewAppError("AddChannelMembersBulk", "api.channel.add_user_to_channel.type.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if channel.DeleteAt > 0 {
+		return nil, model.NewAppError("AddChannelMembersBulk", "api.channel.add_members_bulk.deleted.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	userIds, err := a.getChannelMembersBulkUserIds(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.addChannelMembers(channel, userIds, nil, userRequestorId)
+}
+
+// CopyChannelMembers adds the members of the source channel to the target channel in the same way as
+// AddChannelMembersBulk. If preserveRoles is set, channel admins of the source channel that are newly added become
+// channel admins of the target channel as well. Existing members of the target channel are left unchanged.
+func (a *App) CopyChannelMembers(source *model.Channel, target *model.Channel, preserveRoles bool, userRequestorId string) ([]*model.ChannelMemberBulkResult, *model.AppError) {
+	if source.Id == target.Id {
+		return nil, model.NewAppError("CopyChannelMembers", "api.channel.copy_members.same_channel.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if target.Type != model.CHANNEL_OPEN && target.Type != model.CHANNEL_PRIVATE {
+		return nil, model.NewAppError("CopyChannelMembers", "api.channel.add_user_to_channel.type.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if target.DeleteAt > 0 {
+		return nil, model.NewAppError("CopyChannelMembers", "api.channel.add_members_bulk.deleted.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	result := <-a.Srv.Store.Channel().GetMembers(source.Id, 0, model.CHANNEL_MEMBERS_BULK_MAX_USERS+1)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	sourceMembers := *result.Data.(*model.ChannelMembers)
+
+	if len(sourceMembers) > model.CHANNEL_MEMBERS_BULK_MAX_USERS {
+		return nil, model.NewAppError("CopyChannelMembers", "model.channel_members_bulk.is_valid.too_many_users.app_error", map[string]interface{}{"Max": model.CHANNEL_MEMBERS_BULK_MAX_USERS}, "", http.StatusBadRequest)
+	}
+
+	userIds := make([]string, 0, len(sourceMembers))
+	schemeAdmins := make(map[string]bool)
+	for _, member := range sourceMembers {
+		userIds = append(userIds, member.UserId)
+		if preserveRoles && member.SchemeAdmin {
+			schemeAdmins[member.UserId] = true
+		}
+	}
+
+	return a.addChannelMembers(target, userIds, schemeAdmins, userRequestorId)
+}
+
+// addChannelMembers adds users to a channel in batches and posts a system message for each user that was added.
+// Users in schemeAdmins are added as channel admins.
+func (a *App) addChannelMembers(channel *model.Channel, userIds []string, schemeAdmins map[string]bool, userRequestorId string) ([]*model.ChannelMemberBulkResult, *model.AppError) {
+	var userRequestor *model.User
+	if userRequestorId != "" {
+		var err *model.AppError
+		if userRequestor, err = a.GetUser(userRequestorId); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]*model.ChannelMemberBulkResult, 0, len(userIds))
+	var added []*model.User
+	for start := 0; start < len(userIds); start += model.CHANNEL_MEMBERS_BULK_BATCH_SIZE {
+		end := start + model.CHANNEL_MEMBERS_BULK_BATCH_SIZE
+		if end > len(userIds) {
+			end = len(userIds)
+		}
+
+		batchResults, batchAdded, err := a.addChannelMembersBatch(channel, userIds[start:end], schemeAdmins, userRequestor)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, batchResults...)
+		added = append(added, batchAdded...)
+	}
+
+	if len(added) == 0 {
+		return results, nil
+	}
+
+	a.Srv.Go(func() {
+		for _, user := range added {
+			if userRequestor == nil || user.Id == userRequestor.Id {
+				a.postJoinChannelMessage(user, channel)
+			} else {
+				a.PostAddToChannelMessage(userRequestor, user, channel, "")
+			}
+		}
+	})
+
+	if userRequestor != nil {
+		a.MarkChannelsAsViewed([]string{channel.Id}, userRequestor.Id, false)
+	}
+
+	return results, nil
+}
+
+// getChannelMembersBulkUserIds returns the ids of the users selected by the request without duplicates.
+func (a *App) getChannelMembersBulkUserIds(request *model.ChannelMembersBulkRequest) ([]string, *model.AppError) {
+	var userIds []string
+
+	switch {
+	case len(request.GroupId) > 0:
+		users, err := a.GetGroupMemberUsers(request.GroupId)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			userIds = append(userIds, user.Id)
+		}
+	case len(request.TeamId) > 0:
+		result := <-a.Srv.Store.Team().GetMembers(request.TeamId, 0, model.CHANNEL_MEMBERS_BULK_MAX_USERS+1)
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		for _, member := range result.Data.([]*model.TeamMember) {
+			userIds = append(userIds, member.UserId)
+		}
+	default:
+		userIds = request.UserIds
+	}
+
+	if len(userIds) > model.CHANNEL_MEMBERS_BULK_MAX_USERS {
+		return nil, model.NewAppError("AddChannelMembersBulk", "model.channel_members_bulk.is_valid.too_many_users.app_error", map[string]interface{}{"Max": model.CHANNEL_MEMBERS_BULK_MAX_USERS}, "", http.StatusBadRequest)
+	}
+
+	return model.RemoveDuplicateStrings(userIds), nil
+}
+
+// addChannelMembersBatch adds a batch of users to a channel in a single transaction and publishes one event for
+// all of them. It returns the outcome for each user along with the users that were newly added.
+func (a *App) addChannelMembersBatch(channel *model.Channel, userIds []string, schemeAdmins map[string]bool, userRequestor *model.User) ([]*model.ChannelMemberBulkResult, []*model.User, *model.AppError) {
+	uchan := a.Srv.Store.User().GetProfileByIds(userIds, true)
+	tmchan := a.Srv.Store.Team().GetMembersByIds(channel.TeamId, userIds)
+	cmchan := a.Srv.Store.Channel().GetMembersByIds(channel.Id, userIds)
+
+	result := <-uchan
+	if result.Err != nil {
+		return nil, nil, result.Err
+	}
+	users := make(map[string]*model.User)
+	for _, user := range result.Data.([]*model.User) {
+		users[user.Id] = user
+	}
+
+	result = <-tmchan
+	if result.Err != nil {
+		return nil, nil, result.Err
+	}
+	teamMembers := make(map[string]bool)
+	for _, member := range result.Data.([]*model.TeamMember) {
+		teamMembers[member.UserId] = true
+	}
+
+	result = <-cmchan
+	if result.Err != nil {
+		return nil, nil, result.Err
+	}
+	channelMembers := make(map[string]*model.ChannelMember)
+	existingMembers := *result.Data.(*model.ChannelMembers)
+	for i := range existingMembers {
+		channelMembers[existingMembers[i].UserId] = &existingMembers[i]
+	}
+
+	results := make([]*model.ChannelMemberBulkResult, len(userIds))
+	var newMembers []*model.ChannelMember
+	for i, userId := range userIds {
+		results[i] = &model.ChannelMemberBulkResult{UserId: userId}
+
+		user, ok := users[userId]
+		switch {
+		case !ok:
+			results[i].Error = model.NewAppError("AddChannelMembersBulk", "api.channel.add_members_bulk.user_not_found.app_error", nil, "user_id="+userId, http.StatusNotFound)
+		case channelMembers[userId] != nil:
+			results[i].Member = channelMembers[userId]
+		case user.DeleteAt > 0:
+			results[i].Error = model.NewAppError("AddChannelMembersBulk", "api.channel.add_members_bulk.user_deactivated.app_error", nil, "user_id="+userId, http.StatusBadRequest)
+		case !teamMembers[userId]:
+			results[i].Error = model.NewAppError("AddChannelMembersBulk", "api.channel.add_members_bulk.not_team_member.app_error", nil, "user_id="+userId, http.StatusBadRequest)
+		default:
+			newMembers = append(newMembers, &model.ChannelMember{
+				ChannelId:   channel.Id,
+				UserId:      userId,
+				NotifyProps: model.GetDefaultChannelNotifyProps(),
+				SchemeUser:  true,
+				SchemeAdmin: schemeAdmins[userId],
+			})
+		}
+	}
+
+	if len(newMembers) == 0 {
+		return results, nil, nil
+	}
+
+	result = <-a.Srv.Store.Channel().SaveMultipleMembers(newMembers)
+	if result.Err != nil {
+		mlog.Error(fmt.Sprintf("Failed to add members channel_id=%v err=%v", channel.Id, result.Err))
+		appErr := model.NewAppError("AddChannelMembersBulk", "api.channel.add_user.to.channel.failed.app_error", nil, "", http.StatusInternalServerError)
+		for _, memberResult := range results {
+			if memberResult.Error == nil && memberResult.Member == nil {
+				memberResult.Error = appErr
+			}
+		}
+		return results, nil, nil
+	}
+
+	savedMembers := make(map[string]*model.ChannelMember)
+	for _, member := range result.Data.([]*model.ChannelMember) {
+		savedMembers[member.UserId] = member
+		a.publishFirehoseEvent(model.FIREHOSE_EVENT_CHANNEL_MEMBER_ADDED, channel.TeamId, channel.Id, member.UserId, member)
+	}
+
+	var added []*model.User
+	var addedIds []string
+	for _, memberResult := range results {
+		member, ok := savedMembers[memberResult.UserId]
+		if !ok {
+			continue
+		}
+		memberResult.Member = member
+		added = append(added, users[member.UserId])
+		addedIds = append(addedIds, member.UserId)
+	}
+
+	a.WaitForChannelMembership(channel.Id, addedIds[len(addedIds)-1])
+
+	now := model.GetMillis()
+	for _, userId := range addedIds {
+		if result := <-a.Srv.Store.ChannelMemberHistory().LogJoinEvent(userId, channel.Id, now); result.Err != nil {
+			mlog.Warn(fmt.Sprintf("Failed to update ChannelMemberHistory table %v", result.Err))
+		}
+		a.InvalidateCacheForUser(userId)
+	}
+	a.InvalidateCacheForChannelMembers(channel.Id)
+
+	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_USERS_ADDED, "", channel.Id, "", nil)
+	message.Add("user_ids", model.ArrayToJson(addedIds))
+	message.Add("team_id", channel.TeamId)
+	a.Publish(message)
+
+	if pluginsEnvironment := a.GetPluginsEnvironment(); pluginsEnvironment != nil {
+		a.Srv.Go(func() {
+			pluginContext := a.PluginContext()
+			for _, userId := range addedIds {
+				member := savedMembers[userId]
+				pluginsEnvironment.RunMultiPluginHook(func(hooks plugin.Hooks) bool {
+					hooks.UserHasJoinedChannel(pluginContext, member, userRequestor)
+					return true
+				}, plugin.UserHasJoinedChannelId)
+			}
+		})
+	}
+
+	return results, added, nil
+}
+
 func (a *App) AddDirectChannels(teamId string, user *model.User) *model.AppError {
 	var profiles []*model.User
 	options := &model.UserGetOptions{InTeamId: teamId, Page: 0, PerPage: 100}
@@ -1117,6 +1594,14 @@ func (a *App) GetChannelsForUser(teamId string, userId string, includeDeleted bo
 	return result.Data.(*model.ChannelList), nil
 }
 
+func (a *App) GetCommonChannels(userId string, otherUserId string) (*model.ChannelList, *model.AppError) {
+	result := <-a.Srv.Store.Channel().GetCommonChannels(userId, otherUserId)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return result.Data.(*model.ChannelList), nil
+}
+
 func (a *App) GetAllChannels(page, perPage int, includeDeleted bool) (*model.ChannelListWithTeamData, *model.AppError) {
 	result := <-a.Srv.Store.Channel().GetAllChannels(page*perPage, perPage, includeDeleted)
 	if result.Err != nil {
@@ -1173,6 +1658,22 @@ func (a *App) GetChannelMembersPage(channelId string, page, perPage int) (*model
 	return result.Data.(*model.ChannelMembers), nil
 }
 
+func (a *App) GetChannelMembersPageSorted(channelId string, sort string, page, perPage int) (*model.ChannelMembers, *model.AppError) {
+	result := <-a.Srv.Store.Channel().GetMembersSorted(channelId, sort, page*perPage, perPage)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return result.Data.(*model.ChannelMembers), nil
+}
+
+func (a *App) SearchChannelMembers(channelId string, term string, role string, allowFullNames bool, page, perPage int) (*model.ChannelMembers, *model.AppError) {
+	result := <-a.Srv.Store.Channel().SearchMembers(channelId, term, role, allowFullNames, page*perPage, perPage)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return result.Data.(*model.ChannelMembers), nil
+}
+
 func (a *App) GetChannelMembersTimezones(channelId string) ([]string, *model.AppError) {
 	result := <-a.Srv.Store.Channel().GetChannelMembersTimezones(channelId)
 	if result.Err != nil {
@@ -1215,6 +1716,146 @@ func (a *App) GetChannelMemberCount(channelId string) (int64, *model.AppError) {
 	return result.Data.(int64), nil
 }
 
+// GetChannelActivity returns the number of posts made in a channel, bucketed by hour or day in the given time zone.
+func (a *App) GetChannelActivity(channelId string, granularity string, timeZone string, since int64, until int64) (*model.ChannelActivity, *model.AppError) {
+	activity, err := model.NewChannelActivity(channelId, granularity, timeZone, since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	result := <-a.Srv.Store.Post().AnalyticsPostCountsByInterval(channelId, since, until, model.CHANNEL_ACTIVITY_BASE_INTERVAL)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	for _, bucket := range result.Data.([]*model.ChannelActivityBucket) {
+		activity.AddCount(bucket.StartAt, bucket.Count)
+	}
+
+	return activity, nil
+}
+
+// CHANNEL_MENTION_COUNTS_PAGE_SIZE is how many posts GetChannelMentionCounts loads at a time.
+const CHANNEL_MENTION_COUNTS_PAGE_SIZE = 1000
+
+// GetChannelMentionCounts counts, for each of the given users, the posts in a channel during [since, until) that
+// explicitly @mention them. Users mentioning themselves aren't counted.
+func (a *App) GetChannelMentionCounts(channelId string, userIds []string, since int64, until int64) (*model.ChannelMentionCounts, *model.AppError) {
+	mentionCounts, err := model.NewChannelMentionCounts(channelId, model.RemoveDuplicateStrings(userIds), since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	result := <-a.Srv.Store.User().GetProfileByIds(userIds, true)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	users := result.Data.([]*model.User)
+	if len(users) == 0 {
+		return mentionCounts, nil
+	}
+
+	keywords := make(map[string][]string, len(users))
+	terms := make([]string, 0, len(users))
+	for _, user := range users {
+		mention := "@" + strings.ToLower(user.Username)
+		keywords[mention] = append(keywords[mention], user.Id)
+		terms = append(terms, mention)
+	}
+
+	for offset := 0; ; offset += CHANNEL_MENTION_COUNTS_PAGE_SIZE {
+		result = <-a.Srv.Store.Post().GetPostsWithTermsInChannel(channelId, terms, since, until, offset, CHANNEL_MENTION_COUNTS_PAGE_SIZE)
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		posts := result.Data.([]*model.Post)
+
+		for _, post := range posts {
+			for userId := range GetExplicitMentions(post, keywords).MentionedUserIds {
+				if userId != post.UserId {
+					mentionCounts.Counts[userId]++
+				}
+			}
+		}
+
+		if len(posts) < CHANNEL_MENTION_COUNTS_PAGE_SIZE {
+			return mentionCounts, nil
+		}
+	}
+}
+
+// ResolveChannelMentions reports, for each mention token, whether posting it in the channel would notify a user, a
+// group of users or nobody. Only active users on the channel's team are considered, so that users who can't be
+// notified in the channel are reported as unknown.
+func (a *App) ResolveChannelMentions(channel *model.Channel, tokens []string) (*model.MentionResolution, *model.AppError) {
+	resolution := &model.MentionResolution{
+		ChannelId: channel.Id,
+		Users:     []*model.ResolvedMention{},
+		Special:   []string{},
+		Unknown:   []string{},
+	}
+
+	tokens = model.RemoveDuplicateStrings(tokens)
+
+	var usernames []string
+	for _, token := range tokens {
+		name := model.NormalizeMentionToken(token)
+		if model.IsSpecialMention(name) {
+			resolution.Special = append(resolution.Special, token)
+		} else if model.IsValidUsername(name) {
+			usernames = append(usernames, name)
+		}
+	}
+
+	usersByUsername := map[string]*model.User{}
+	if len(usernames) > 0 {
+		result := <-a.Srv.Store.User().GetProfilesByUsernames(usernames, channel.TeamId)
+		if result.Err != nil {
+			return nil, result.Err
+		}
+
+		var userIds []string
+		for _, user := range result.Data.([]*model.User) {
+			if user.DeleteAt == 0 {
+				usersByUsername[user.Username] = user
+				userIds = append(userIds, user.Id)
+			}
+		}
+
+		if len(userIds) > 0 {
+			result = <-a.Srv.Store.Channel().GetMembersByIds(channel.Id, userIds)
+			if result.Err != nil {
+				return nil, result.Err
+			}
+
+			members := map[string]bool{}
+			for _, member := range *result.Data.(*model.ChannelMembers) {
+				members[member.UserId] = true
+			}
+
+			for _, token := range tokens {
+				if user, ok := usersByUsername[model.NormalizeMentionToken(token)]; ok {
+					resolution.Users = append(resolution.Users, &model.ResolvedMention{
+						Mention:         token,
+						UserId:          user.Id,
+						Username:        user.Username,
+						IsChannelMember: members[user.Id],
+					})
+				}
+			}
+		}
+	}
+
+	for _, token := range tokens {
+		name := model.NormalizeMentionToken(token)
+		if _, ok := usersByUsername[name]; !ok && !model.IsSpecialMention(name) {
+			resolution.Unknown = append(resolution.Unknown, token)
+		}
+	}
+
+	return resolution, nil
+}
+
 func (a *App) GetChannelCounts(teamId string, userId string) (*model.ChannelCounts, *model.AppError) {
 	result := <-a.Srv.Store.Channel().GetChannelCounts(teamId, userId)
 	if result.Err != nil {
@@ -1281,6 +1922,10 @@ func (a *App) JoinChannel(channel *model.Channel, userId string) *model.AppError
 }
 
 func (a *App) postJoinChannelMessage(user *model.User, channel *model.Channel) *model.AppError {
+	if channel.SuppressJoinLeaveMessages {
+		return nil
+	}
+
 	post := &model.Post{
 		ChannelId: channel.Id,
 		Message:   fmt.Sprintf(utils.T("api.channel.join_channel.post_and_forget"), user.Username),
@@ -1299,6 +1944,10 @@ func (a *App) postJoinChannelMessage(user *model.User, channel *model.Channel) *
 }
 
 func (a *App) postJoinTeamMessage(user *model.User, channel *model.Channel) *model.AppError {
+	if channel.SuppressJoinLeaveMessages {
+		return nil
+	}
+
 	post := &model.Post{
 		ChannelId: channel.Id,
 		Message:   fmt.Sprintf(utils.T("api.team.join_team.post_and_forget"), user.Username),
@@ -1364,6 +2013,10 @@ func (a *App) LeaveChannel(channelId string, userId string) *model.AppError {
 }
 
 func (a *App) postLeaveChannelMessage(user *model.User, channel *model.Channel) *model.AppError {
+	if channel.SuppressJoinLeaveMessages {
+		return nil
+	}
+
 	post := &model.Post{
 		ChannelId: channel.Id,
 		Message:   fmt.Sprintf(utils.T("api.channel.leave.left"), user.Username),
@@ -1382,6 +2035,10 @@ func (a *App) postLeaveChannelMessage(user *model.User, channel *model.Channel)
 }
 
 func (a *App) PostAddToChannelMessage(user *model.User, addedUser *model.User, channel *model.Channel, postRootId string) *model.AppError {
+	if channel.SuppressJoinLeaveMessages {
+		return nil
+	}
+
 	post := &model.Post{
 		ChannelId: channel.Id,
 		Message:   fmt.Sprintf(utils.T("api.channel.add_member.added"), addedUser.Username, user.Username),
@@ -1404,6 +2061,10 @@ func (a *App) PostAddToChannelMessage(user *model.User, addedUser *model.User, c
 }
 
 func (a *App) postAddToTeamMessage(user *model.User, addedUser *model.User, channel *model.Channel, postRootId string) *model.AppError {
+	if channel.SuppressJoinLeaveMessages {
+		return nil
+	}
+
 	post := &model.Post{
 		ChannelId: channel.Id,
 		Message:   fmt.Sprintf(utils.T("api.team.add_user_to_team.added"), addedUser.Username, user.Username),
@@ -1426,6 +2087,10 @@ func (a *App) postAddToTeamMessage(user *model.User, addedUser *model.User, chan
 }
 
 func (a *App) postRemoveFromChannelMessage(removerUserId string, removedUser *model.User, channel *model.Channel) *model.AppError {
+	if channel.SuppressJoinLeaveMessages {
+		return nil
+	}
+
 	post := &model.Post{
 		ChannelId: channel.Id,
 		Message:   fmt.Sprintf(utils.T("api.channel.remove_member.removed"), removedUser.Username),
@@ -1461,9 +2126,21 @@ func (a *App) removeUserFromChannel(userIdToRemove string, removerUserId string,
 		return cmhResult.Err
 	}
 
+	a.notifyUserRemovedFromChannel(cm, removerUserId, channel)
+
+	return nil
+}
+
+// notifyUserRemovedFromChannel lets the rest of the server, plugins and clients know that a member has been removed
+// from a channel, once they've been removed in the store.
+func (a *App) notifyUserRemovedFromChannel(cm *model.ChannelMember, removerUserId string, channel *model.Channel) {
+	userIdToRemove := cm.UserId
+
 	a.InvalidateCacheForUser(userIdToRemove)
 	a.InvalidateCacheForChannelMembers(channel.Id)
 
+	a.publishFirehoseEvent(model.FIREHOSE_EVENT_CHANNEL_MEMBER_REMOVED, channel.TeamId, channel.Id, userIdToRemove, cm)
+
 	if pluginsEnvironment := a.GetPluginsEnvironment(); pluginsEnvironment != nil {
 		var actorUser *model.User
 		if removerUserId != "" {
@@ -1489,8 +2166,6 @@ func (a *App) removeUserFromChannel(userIdToRemove string, removerUserId string,
 	userMsg.Add("channel_id", channel.Id)
 	userMsg.Add("remover_id", removerUserId)
 	a.Publish(userMsg)
-
-	return nil
 }
 
 func (a *App) RemoveUserFromChannel(userIdToRemove string, removerUserId string, channel *model.Channel) *model.AppError {
@@ -1874,3 +2549,73 @@ func (a *App) FillInChannelsProps(channelList *model.ChannelList) *model.AppErro
 
 	return nil
 }
+
+// GetDuplicateChannels reports groups of open and private channels in a team that are likely to duplicate each
+// other, along with their member counts and last activity, largest groups first. Private channels that userId isn't
+// a member of are left out unless includeAllPrivate is set.
+func (a *App) GetDuplicateChannels(teamId string, userId string, includeAllPrivate bool, threshold float64) ([]*model.ChannelDuplicateCluster, *model.AppError) {
+	result := <-a.Srv.Store.Channel().GetTeamChannels(teamId)
+	if result.Err != nil {
+		if result.Err.Id == "store.sql_channel.get_channels.not_found.app_error" {
+			return []*model.ChannelDuplicateCluster{}, nil
+		}
+		return nil, result.Err
+	}
+
+	var memberOf map[string]string
+	if !includeAllPrivate {
+		mresult := <-a.Srv.Store.Channel().GetAllChannelMembersForUser(userId, false, false)
+		if mresult.Err != nil {
+			return nil, mresult.Err
+		}
+		memberOf = mresult.Data.(map[string]string)
+	}
+
+	var channels []*model.Channel
+	for _, channel := range *result.Data.(*model.ChannelList) {
+		if channel.DeleteAt != 0 {
+			continue
+		}
+
+		if channel.Type == model.CHANNEL_OPEN {
+			channels = append(channels, channel)
+		} else if channel.Type == model.CHANNEL_PRIVATE {
+			if _, ok := memberOf[channel.Id]; includeAllPrivate || ok {
+				channels = append(channels, channel)
+			}
+		}
+	}
+
+	clusters := []*model.ChannelDuplicateCluster{}
+	for _, duplicates := range model.FindDuplicateChannels(channels, threshold) {
+		cluster := &model.ChannelDuplicateCluster{}
+		for _, channel := range duplicates {
+			memberCount, err := a.GetChannelMemberCount(channel.Id)
+			if err != nil {
+				return nil, err
+			}
+
+			cluster.Channels = append(cluster.Channels, &model.ChannelDuplicateCandidate{
+				ChannelId:   channel.Id,
+				Name:        channel.Name,
+				DisplayName: channel.DisplayName,
+				Type:        channel.Type,
+				Purpose:     channel.Purpose,
+				MemberCount: memberCount,
+				LastPostAt:  channel.LastPostAt,
+			})
+		}
+
+		// The busiest channel comes first since it's usually the one to keep.
+		sort.Slice(cluster.Channels, func(i, j int) bool {
+			return cluster.Channels[i].MemberCount > cluster.Channels[j].MemberCount
+		})
+		clusters = append(clusters, cluster)
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return len(clusters[i].Channels) > len(clusters[j].Channels)
+	})
+
+	return clusters, nil
+}
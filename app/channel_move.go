@@ -0,0 +1,247 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// CreateMoveChannelJob checks that a channel can be moved to another team and enqueues a job that moves it. See
+// MoveChannelToTeam.
+func (a *App) CreateMoveChannelJob(channelId string, request *model.ChannelMoveRequest, userId string) (*model.Job, *model.AppError) {
+	channel, team, err := a.getChannelAndTeamForMove(channelId, request.TeamId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !request.RemoveMembersNotInTeam {
+		userIds, err := a.getChannelMembersNotInTeam(channel.Id, team.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(userIds) > 0 {
+			return nil, model.NewAppError("CreateMoveChannelJob", "app.channel.move_channel.members_do_not_match.error", nil, "channel_id="+channel.Id+", team_id="+team.Id, http.StatusBadRequest)
+		}
+	}
+
+	data := map[string]string{
+		"channel_id":                 channel.Id,
+		"team_id":                    team.Id,
+		"user_id":                    userId,
+		"remove_members_not_in_team": strconv.FormatBool(request.RemoveMembersNotInTeam),
+	}
+
+	return a.Srv.Jobs.CreateJob(model.JOB_TYPE_MOVE_CHANNEL, data)
+}
+
+// MoveChannelToTeam moves a public or private channel, along with its posts, files and webhooks, to another team.
+// Members of the channel who aren't in the team are removed from it if removeMembersNotInTeam is set, and otherwise
+// the channel isn't moved. They're removed in the same transaction as the move, so that a move that fails leaves
+// everyone in the channel and can simply be retried. If the team already has a channel with the same name, the moved
+// channel is renamed. The progress is reported as a percentage through onProgress. It returns the moved channel and
+// the number of members who were removed from it.
+func (a *App) MoveChannelToTeam(channelId string, teamId string, userId string, removeMembersNotInTeam bool, onProgress func(progress int64)) (*model.Channel, int, *model.AppError) {
+	channel, team, err := a.getChannelAndTeamForMove(channelId, teamId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	user, err := a.GetUser(userId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	previousTeam, err := a.GetTeam(channel.TeamId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if !removeMembersNotInTeam {
+		userIds, err := a.getChannelMembersNotInTeam(channel.Id, team.Id)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if len(userIds) > 0 {
+			return nil, 0, model.NewAppError("MoveChannelToTeam", "app.channel.move_channel.members_do_not_match.error", nil, "channel_id="+channel.Id+", team_id="+team.Id, http.StatusBadRequest)
+		}
+	}
+
+	onProgress(10)
+
+	name, err := a.channelNameForTeam(channel, team.Id)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// A channel that has to be renamed to fit in the team is held to the team's naming patterns like any other rename
+	if name != channel.Name {
+		renamedChannel := channel.DeepCopy()
+		renamedChannel.TeamId = team.Id
+		renamedChannel.Name = name
+		if err = a.checkChannelNamingConvention(renamedChannel, false); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	previousChannel := channel.DeepCopy()
+
+	// Members may have joined the channel since they were checked, so the store finds the members who aren't in the
+	// team again in the same transaction as the move.
+	result := <-a.Srv.Store.Channel().MoveToTeam(channel, team.Id, name, removeMembersNotInTeam)
+	if result.Err != nil {
+		return nil, 0, result.Err
+	}
+	removedMembers := *result.Data.(*model.ChannelMembers)
+
+	onProgress(50)
+
+	for i := range removedMembers {
+		member := &removedMembers[i]
+		a.notifyUserRemovedFromChannel(member, user.Id, previousChannel)
+
+		if removedUser, err := a.GetUser(member.UserId); err != nil {
+			mlog.Warn("Failed to get a user removed from a moved channel", mlog.String("channel_id", channel.Id), mlog.String("user_id", member.UserId), mlog.Err(err))
+		} else {
+			a.Srv.Go(func() {
+				a.postRemoveFromChannelMessage(user.Id, removedUser, channel)
+			})
+		}
+
+		onProgress(50 + int64(30*(i+1)/len(removedMembers)))
+	}
+
+	onProgress(80)
+
+	// The files stay where they were stored, but they count towards the quota of the team that the channel is in.
+	if result := <-a.Srv.Store.FileInfo().GetStorageSizeForChannel(channel.Id); result.Err != nil {
+		mlog.Warn("Failed to get the storage used by a moved channel", mlog.String("channel_id", channel.Id), mlog.Err(result.Err))
+	} else {
+		size := result.Data.(int64)
+		a.addTeamStorageUsage(previousTeam.Id, -size)
+		a.addTeamStorageUsage(team.Id, size)
+	}
+
+	a.InvalidateCacheForChannel(previousChannel)
+	a.InvalidateCacheForChannel(channel)
+	a.Srv.Store.Webhook().ClearCaches()
+
+	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_CHANNEL_UPDATED, "", channel.Id, "", nil)
+	message.Add("channel", channel.ToJson())
+	a.Publish(message)
+
+	if err := a.postChannelMoveMessage(user, channel, previousTeam); err != nil {
+		mlog.Warn("Failed to post the message for a moved channel", mlog.String("channel_id", channel.Id), mlog.Err(err))
+	}
+
+	if a.Elasticsearch != nil && *a.Config().ElasticsearchSettings.EnableIndexing {
+		if _, err := a.CreateElasticsearchReindexJob("", channel.Id); err != nil {
+			mlog.Error("Failed to reindex a moved channel", mlog.String("channel_id", channel.Id), mlog.Err(err))
+		}
+	}
+
+	onProgress(100)
+
+	return channel, len(removedMembers), nil
+}
+
+func (a *App) getChannelAndTeamForMove(channelId string, teamId string) (*model.Channel, *model.Team, *model.AppError) {
+	channel, err := a.GetChannel(channelId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if channel.Type != model.CHANNEL_OPEN && channel.Type != model.CHANNEL_PRIVATE {
+		return nil, nil, model.NewAppError("getChannelAndTeamForMove", "app.channel.move_channel.type.app_error", nil, "channel_id="+channel.Id, http.StatusBadRequest)
+	}
+
+	if channel.Name == model.DEFAULT_CHANNEL {
+		return nil, nil, model.NewAppError("getChannelAndTeamForMove", "app.channel.move_channel.default_channel.app_error", map[string]interface{}{"Channel": model.DEFAULT_CHANNEL}, "channel_id="+channel.Id, http.StatusBadRequest)
+	}
+
+	if channel.DeleteAt != 0 {
+		return nil, nil, model.NewAppError("getChannelAndTeamForMove", "app.channel.move_channel.deleted.app_error", nil, "channel_id="+channel.Id, http.StatusBadRequest)
+	}
+
+	team, err := a.GetTeam(teamId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if team.DeleteAt != 0 {
+		return nil, nil, model.NewAppError("getChannelAndTeamForMove", "app.channel.move_channel.team_deleted.app_error", nil, "team_id="+team.Id, http.StatusBadRequest)
+	}
+
+	if team.Id == channel.TeamId {
+		return nil, nil, model.NewAppError("getChannelAndTeamForMove", "app.channel.move_channel.same_team.app_error", nil, "channel_id="+channel.Id+", team_id="+team.Id, http.StatusBadRequest)
+	}
+
+	return channel, team, nil
+}
+
+// getChannelMembersNotInTeam returns the ids of the members of a channel who aren't active members of a team.
+func (a *App) getChannelMembersNotInTeam(channelId string, teamId string) ([]string, *model.AppError) {
+	channelMembers, err := a.GetChannelMembersPage(channelId, 0, 10000000)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(*channelMembers) == 0 {
+		return nil, nil
+	}
+
+	userIds := make([]string, 0, len(*channelMembers))
+	for _, member := range *channelMembers {
+		userIds = append(userIds, member.UserId)
+	}
+
+	teamMembers, err := a.GetTeamMembersByIds(teamId, userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	inTeam := make(map[string]bool, len(teamMembers))
+	for _, member := range teamMembers {
+		inTeam[member.UserId] = true
+	}
+
+	notInTeam := []string{}
+	for _, userId := range userIds {
+		if !inTeam[userId] {
+			notInTeam = append(notInTeam, userId)
+		}
+	}
+
+	return notInTeam, nil
+}
+
+// channelNameForTeam returns the name that a channel will have once it's moved to a team. That's its current name
+// unless the team already has a channel with that name, in which case the id of the moved channel is appended to it.
+func (a *App) channelNameForTeam(channel *model.Channel, teamId string) (string, *model.AppError) {
+	if _, err := a.GetChannelByName(channel.Name, teamId, true); err != nil {
+		if err.StatusCode == http.StatusNotFound {
+			return channel.Name, nil
+		}
+		return "", err
+	}
+
+	return channelNameWithId(channel), nil
+}
+
+// channelNameWithId returns the name of a channel with its id appended, truncating the name if needed, which is unique
+// in any team.
+func channelNameWithId(channel *model.Channel) string {
+	suffix := "-" + channel.Id
+	name := channel.Name
+	if len(name)+len(suffix) > model.CHANNEL_NAME_MAX_LENGTH {
+		name = name[:model.CHANNEL_NAME_MAX_LENGTH-len(suffix)]
+	}
+
+	return name + suffix
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestMoveChannelToTeam(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	noProgress := func(int64) {}
+
+	t.Run("members must be in the team", func(t *testing.T) {
+		team := th.CreateTeam()
+		channel := th.CreateChannel(th.BasicTeam)
+
+		_, err := th.App.CreateMoveChannelJob(channel.Id, &model.ChannelMoveRequest{TeamId: team.Id}, th.SystemAdminUser.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, "app.channel.move_channel.members_do_not_match.error", err.Id)
+
+		_, _, err = th.App.MoveChannelToTeam(channel.Id, team.Id, th.SystemAdminUser.Id, false, noProgress)
+		require.NotNil(t, err)
+		assert.Equal(t, "app.channel.move_channel.members_do_not_match.error", err.Id)
+	})
+
+	t.Run("members not in the team are removed", func(t *testing.T) {
+		team := th.CreateTeam()
+		th.LinkUserToTeam(th.BasicUser, team)
+
+		channel := th.CreateChannel(th.BasicTeam)
+		th.AddUserToChannel(th.BasicUser2, channel)
+
+		var progress []int64
+		moved, removed, err := th.App.MoveChannelToTeam(channel.Id, team.Id, th.SystemAdminUser.Id, true, func(p int64) {
+			progress = append(progress, p)
+		})
+		require.Nil(t, err)
+		assert.Equal(t, 1, removed)
+		assert.Equal(t, team.Id, moved.TeamId)
+		assert.Equal(t, channel.Name, moved.Name)
+		assert.Equal(t, int64(100), progress[len(progress)-1])
+
+		_, err = th.App.GetChannelMember(channel.Id, th.BasicUser2.Id)
+		assert.NotNil(t, err)
+
+		_, err = th.App.GetChannelMember(channel.Id, th.BasicUser.Id)
+		assert.Nil(t, err)
+	})
+
+	t.Run("channels are renamed if the name is taken", func(t *testing.T) {
+		team := th.CreateTeam()
+		th.LinkUserToTeam(th.BasicUser, team)
+
+		channel := th.CreateChannel(th.BasicTeam)
+		existing := th.CreateChannel(team)
+		existing.Name = channel.Name
+		_, err := th.App.UpdateChannel(existing)
+		require.Nil(t, err)
+
+		moved, _, err := th.App.MoveChannelToTeam(channel.Id, team.Id, th.SystemAdminUser.Id, false, noProgress)
+		require.Nil(t, err)
+		assert.Equal(t, channel.Name+"-"+channel.Id, moved.Name)
+
+		byName, err := th.App.GetChannelByName(moved.Name, team.Id, false)
+		require.Nil(t, err)
+		assert.Equal(t, channel.Id, byName.Id)
+	})
+
+	t.Run("storage usage moves with the channel", func(t *testing.T) {
+		team := th.CreateTeam()
+		th.LinkUserToTeam(th.BasicUser, team)
+
+		channel := th.CreateChannel(th.BasicTeam)
+		info, err := th.App.DoUploadFile(time.Now(), th.BasicTeam.Id, channel.Id, th.BasicUser.Id, "file.txt", []byte("0123456789"))
+		require.Nil(t, err)
+		defer th.App.RemoveFile(info.Path)
+
+		before, err := th.App.GetTeamStorageUsage(th.BasicTeam.Id)
+		require.Nil(t, err)
+
+		_, _, err = th.App.MoveChannelToTeam(channel.Id, team.Id, th.SystemAdminUser.Id, false, noProgress)
+		require.Nil(t, err)
+
+		after, err := th.App.GetTeamStorageUsage(th.BasicTeam.Id)
+		require.Nil(t, err)
+		assert.Equal(t, before.Used-10, after.Used)
+
+		usage, err := th.App.GetTeamStorageUsage(team.Id)
+		require.Nil(t, err)
+		assert.Equal(t, int64(10), usage.Used)
+	})
+
+	t.Run("invalid moves", func(t *testing.T) {
+		team := th.CreateTeam()
+
+		_, err := th.App.CreateMoveChannelJob(th.BasicChannel.Id, &model.ChannelMoveRequest{TeamId: th.BasicTeam.Id}, th.SystemAdminUser.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, "app.channel.move_channel.same_team.app_error", err.Id)
+
+		dm := th.CreateDmChannel(th.BasicUser2)
+		_, err = th.App.CreateMoveChannelJob(dm.Id, &model.ChannelMoveRequest{TeamId: team.Id}, th.SystemAdminUser.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, "app.channel.move_channel.type.app_error", err.Id)
+
+		townSquare, err := th.App.GetChannelByName(model.DEFAULT_CHANNEL, th.BasicTeam.Id, false)
+		require.Nil(t, err)
+		_, err = th.App.CreateMoveChannelJob(townSquare.Id, &model.ChannelMoveRequest{TeamId: team.Id}, th.SystemAdminUser.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, "app.channel.move_channel.default_channel.app_error", err.Id)
+	})
+}
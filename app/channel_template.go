@@ -0,0 +1,157 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func (a *App) CreateChannelTemplate(template *model.ChannelTemplate) (*model.ChannelTemplate, *model.AppError) {
+	team, err := a.GetTeam(template.TeamId)
+	if err != nil {
+		return nil, err
+	}
+
+	if team.DeleteAt != 0 {
+		return nil, model.NewAppError("CreateChannelTemplate", "app.channel_template.team_deleted.app_error", nil, "team_id="+team.Id, http.StatusBadRequest)
+	}
+
+	template.Id = ""
+
+	result := <-a.Srv.Store.Team().SaveChannelTemplate(template)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	return result.Data.(*model.ChannelTemplate), nil
+}
+
+func (a *App) GetChannelTemplates(teamId string) ([]*model.ChannelTemplate, *model.AppError) {
+	result := <-a.Srv.Store.Team().GetChannelTemplatesForTeam(teamId)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	return result.Data.([]*model.ChannelTemplate), nil
+}
+
+// GetChannelTemplate returns a channel template of a team, which is reported as missing if it belongs to another team.
+func (a *App) GetChannelTemplate(teamId string, templateId string) (*model.ChannelTemplate, *model.AppError) {
+	result := <-a.Srv.Store.Team().GetChannelTemplate(templateId)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	template := result.Data.(*model.ChannelTemplate)
+	if template.TeamId != teamId {
+		return nil, model.NewAppError("GetChannelTemplate", "store.sql_team.get_channel_template.missing.app_error", nil, "id="+templateId, http.StatusNotFound)
+	}
+
+	return template, nil
+}
+
+// UpdateChannelTemplate changes how channels created from a template are set up. Channels that were already created
+// from it are left as they are.
+func (a *App) UpdateChannelTemplate(template *model.ChannelTemplate) (*model.ChannelTemplate, *model.AppError) {
+	oldTemplate, err := a.GetChannelTemplate(template.TeamId, template.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	oldTemplate.Name = template.Name
+	oldTemplate.Type = template.Type
+	oldTemplate.Header = template.Header
+	oldTemplate.Purpose = template.Purpose
+	oldTemplate.IntroMessage = template.IntroMessage
+
+	result := <-a.Srv.Store.Team().UpdateChannelTemplate(oldTemplate)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	return result.Data.(*model.ChannelTemplate), nil
+}
+
+func (a *App) DeleteChannelTemplate(teamId string, templateId string) *model.AppError {
+	if _, err := a.GetChannelTemplate(teamId, templateId); err != nil {
+		return err
+	}
+
+	if result := <-a.Srv.Store.Team().DeleteChannelTemplate(templateId); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+// CreateChannelFromTemplate creates a channel set up as described by a template, and returns it along with whether it
+// was created. Applying the template again for a channel that was already created from it, which the user is a member
+// of, returns that channel instead, and posts the template's intro message if that failed the first time. Whether the
+// user may create channels of the template's type is left to the caller.
+func (a *App) CreateChannelFromTemplate(template *model.ChannelTemplate, name string, displayName string, userId string) (*model.Channel, bool, *model.AppError) {
+	channel, err := a.GetChannelByName(name, template.TeamId, false)
+	created := false
+
+	if err == nil {
+		if channel.Type != template.Type || channel.Header != template.Header || channel.Purpose != template.Purpose {
+			return nil, false, model.NewAppError("CreateChannelFromTemplate", "app.channel_template.create_channel.exists.app_error", nil, "name="+name, http.StatusBadRequest)
+		}
+
+		if _, err := a.GetChannelMember(channel.Id, userId); err != nil {
+			return nil, false, model.NewAppError("CreateChannelFromTemplate", "app.channel_template.create_channel.exists.app_error", nil, "name="+name, http.StatusBadRequest)
+		}
+	} else if err.StatusCode != http.StatusNotFound {
+		return nil, false, err
+	} else {
+		channel, err = a.CreateChannelWithUser(&model.Channel{
+			TeamId:      template.TeamId,
+			Name:        name,
+			DisplayName: displayName,
+			Type:        template.Type,
+			Header:      template.Header,
+			Purpose:     template.Purpose,
+			CreatorId:   userId,
+		}, userId)
+		if err != nil {
+			return nil, false, err
+		}
+		created = true
+	}
+
+	if template.IntroMessage != "" {
+		if err := a.postChannelTemplateIntro(template, channel, userId); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return channel, created, nil
+}
+
+// postChannelTemplateIntro posts and pins the intro message of a template in a channel created from it, unless it
+// already has been.
+func (a *App) postChannelTemplateIntro(template *model.ChannelTemplate, channel *model.Channel, userId string) *model.AppError {
+	pinned, err := a.GetPinnedPosts(channel.Id)
+	if err != nil {
+		return err
+	}
+
+	for _, post := range pinned.Posts {
+		if templateId, _ := post.Props[model.CHANNEL_TEMPLATE_PROP].(string); templateId == template.Id {
+			return nil
+		}
+	}
+
+	post := &model.Post{
+		ChannelId: channel.Id,
+		UserId:    userId,
+		Message:   template.IntroMessage,
+		IsPinned:  true,
+	}
+	post.AddProp(model.CHANNEL_TEMPLATE_PROP, template.Id)
+
+	_, err = a.CreatePost(post, channel, false)
+	return err
+}
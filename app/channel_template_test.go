@@ -0,0 +1,73 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestCreateChannelFromTemplate(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	template, err := th.App.CreateChannelTemplate(&model.ChannelTemplate{
+		TeamId:       th.BasicTeam.Id,
+		CreatorId:    th.BasicUser.Id,
+		Name:         "Incident",
+		Type:         model.CHANNEL_PRIVATE,
+		Header:       "Severity: unknown",
+		Purpose:      "Incident response",
+		IntroMessage: "Follow the runbook",
+	})
+	require.Nil(t, err)
+
+	name := "incident-" + model.NewId()
+	channel, created, err := th.App.CreateChannelFromTemplate(template, name, "Incident", th.BasicUser.Id)
+	require.Nil(t, err)
+	assert.True(t, created)
+	assert.Equal(t, model.CHANNEL_PRIVATE, channel.Type)
+	assert.Equal(t, template.Header, channel.Header)
+	assert.Equal(t, template.Purpose, channel.Purpose)
+
+	pinned, err := th.App.GetPinnedPosts(channel.Id)
+	require.Nil(t, err)
+	require.Len(t, pinned.Posts, 1)
+	for _, post := range pinned.Posts {
+		assert.Equal(t, template.IntroMessage, post.Message)
+	}
+
+	t.Run("applying the template again returns the channel", func(t *testing.T) {
+		again, created, err := th.App.CreateChannelFromTemplate(template, name, "Incident", th.BasicUser.Id)
+		require.Nil(t, err)
+		assert.False(t, created)
+		assert.Equal(t, channel.Id, again.Id)
+
+		pinned, err := th.App.GetPinnedPosts(channel.Id)
+		require.Nil(t, err)
+		assert.Len(t, pinned.Posts, 1, "the intro message shouldn't be posted again")
+	})
+
+	t.Run("other users can't take over the channel", func(t *testing.T) {
+		_, _, err := th.App.CreateChannelFromTemplate(template, name, "Incident", th.BasicUser2.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, "app.channel_template.create_channel.exists.app_error", err.Id)
+	})
+
+	t.Run("channels that weren't created from the template aren't reused", func(t *testing.T) {
+		_, _, err := th.App.CreateChannelFromTemplate(template, th.BasicChannel.Name, "Incident", th.BasicUser.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, "app.channel_template.create_channel.exists.app_error", err.Id)
+	})
+
+	t.Run("templates of other teams are missing", func(t *testing.T) {
+		_, err := th.App.GetChannelTemplate(th.CreateTeam().Id, template.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, 404, err.StatusCode)
+	})
+}
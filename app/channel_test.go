@@ -458,6 +458,40 @@ func TestAddChannelMemberNoUserRequestor(t *testing.T) {
 	}
 }
 
+func TestSuppressJoinLeaveMessages(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	user := th.CreateUser()
+	th.LinkUserToTeam(user, th.BasicTeam)
+
+	channel := th.createChannel(th.BasicTeam, model.CHANNEL_OPEN)
+	suppress := true
+	channel, err := th.App.PatchChannel(channel, &model.ChannelPatch{SuppressJoinLeaveMessages: &suppress}, th.BasicUser.Id)
+	require.Nil(t, err)
+	require.True(t, channel.SuppressJoinLeaveMessages)
+
+	postCount := func() int {
+		postList := store.Must(th.App.Srv.Store.Post().GetPosts(channel.Id, 0, 60, false)).(*model.PostList)
+		return len(postList.Order)
+	}
+	before := postCount()
+
+	_, err = th.App.AddChannelMember(user.Id, channel, "", "", false)
+	require.Nil(t, err)
+
+	_, err = th.App.GetChannelMember(channel.Id, user.Id)
+	require.Nil(t, err, "membership should still be updated")
+
+	err = th.App.RemoveUserFromChannel(user.Id, user.Id, channel)
+	require.Nil(t, err)
+
+	_, err = th.App.GetChannelMember(channel.Id, user.Id)
+	require.NotNil(t, err)
+
+	assert.Equal(t, before, postCount())
+}
+
 func TestAppUpdateChannelScheme(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -728,6 +762,48 @@ func TestRenameChannel(t *testing.T) {
 	}
 }
 
+func TestChannelNamingConvention(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	team := th.BasicTeam
+	team.ChannelNamePattern = "proj-[a-z0-9]+"
+	_, err := th.App.UpdateTeam(team)
+	require.Nil(t, err)
+
+	existing := th.BasicChannel
+
+	t.Run("channels created without a session are checked", func(t *testing.T) {
+		_, err := th.App.CreateChannel(&model.Channel{DisplayName: "Apollo", Name: "apollo" + model.NewId(), Type: model.CHANNEL_OPEN, TeamId: team.Id}, false)
+		require.NotNil(t, err)
+		assert.Equal(t, "api.channel.naming_convention.name.app_error", err.Id)
+
+		_, err = th.App.CreateChannel(&model.Channel{DisplayName: "Apollo", Name: "proj-" + model.NewId(), Type: model.CHANNEL_OPEN, TeamId: team.Id}, false)
+		require.Nil(t, err)
+	})
+
+	t.Run("renames are checked", func(t *testing.T) {
+		_, err := th.App.RenameChannel(existing.DeepCopy(), "gemini"+model.NewId(), "")
+		require.NotNil(t, err)
+		assert.Equal(t, "api.channel.naming_convention.name.app_error", err.Id)
+	})
+
+	t.Run("existing channels are grandfathered", func(t *testing.T) {
+		channel := existing.DeepCopy()
+		channel.Header = "new header"
+		_, err := th.App.UpdateChannel(channel)
+		require.Nil(t, err)
+	})
+
+	t.Run("team admins are exempt", func(t *testing.T) {
+		th.App.Session = model.Session{UserId: th.SystemAdminUser.Id, Roles: model.SYSTEM_ADMIN_ROLE_ID}
+		defer func() { th.App.Session = model.Session{} }()
+
+		_, err := th.App.CreateChannel(&model.Channel{DisplayName: "Apollo", Name: "apollo" + model.NewId(), Type: model.CHANNEL_OPEN, TeamId: team.Id}, false)
+		require.Nil(t, err)
+	})
+}
+
 func TestGetChannelMembersTimezones(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -0,0 +1,118 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// CreateChecklistPost posts a checklist to a channel on behalf of a user, as a single post whose message lists the
+// items and whose props keep track of which have been checked.
+func (a *App) CreateChecklistPost(request *model.ChecklistRequest, userId string, clearPushNotifications bool) (*model.Post, *model.AppError) {
+	if err := request.IsValid(); err != nil {
+		return nil, err
+	}
+
+	checklist := request.ToChecklist()
+
+	post := &model.Post{
+		ChannelId: request.ChannelId,
+		RootId:    request.RootId,
+		UserId:    userId,
+		Message:   checklist.ToMessage(),
+	}
+	post.AddProp(model.POST_PROPS_CHECKLIST, checklist)
+
+	return a.CreatePostAsUser(post, clearPushNotifications)
+}
+
+// CHECKLIST_UPDATE_ATTEMPTS is how many times checking an item is tried before giving up, when the post keeps being
+// changed by others at the same time.
+const CHECKLIST_UPDATE_ATTEMPTS = 5
+
+// CheckChecklistItem checks or unchecks an item of a checklist post on behalf of a user, and updates the post's
+// message to match. Unlike other edits, this isn't subject to the post edit time limit and doesn't mark the post as
+// edited. Since many users may check items of the same checklist at once, the post is only updated if it hasn't
+// changed since it was read, and otherwise the item is checked again on top of the latest version.
+func (a *App) CheckChecklistItem(postId string, check *model.ChecklistItemCheck, userId string) (*model.Post, *model.AppError) {
+	for attempt := 0; attempt < CHECKLIST_UPDATE_ATTEMPTS; attempt++ {
+		rpost, err := a.checkChecklistItem(postId, check, userId)
+		if err != nil || rpost != nil {
+			return rpost, err
+		}
+	}
+
+	return nil, model.NewAppError("CheckChecklistItem", "app.checklist.conflict.app_error", nil, "post_id="+postId, http.StatusConflict)
+}
+
+// checkChecklistItem makes a single attempt at checking an item, returning a nil post if the post was changed by
+// someone else before it could be updated.
+func (a *App) checkChecklistItem(postId string, check *model.ChecklistItemCheck, userId string) (*model.Post, *model.AppError) {
+	oldPost, err := a.GetSinglePost(postId)
+	if err != nil {
+		return nil, err
+	}
+
+	checklist := model.ChecklistFromPost(oldPost)
+	if checklist == nil {
+		return nil, model.NewAppError("CheckChecklistItem", "app.checklist.not_checklist.app_error", nil, "post_id="+postId, http.StatusBadRequest)
+	}
+
+	if check.Index < 0 || check.Index >= len(checklist.Items) {
+		return nil, model.NewAppError("CheckChecklistItem", "app.checklist.item_index.app_error", nil, "post_id="+postId, http.StatusBadRequest)
+	}
+
+	item := checklist.Items[check.Index]
+	if check.Checked == (item.CheckedBy != "") {
+		return a.PreparePostForClient(oldPost, false), nil
+	}
+
+	if check.Checked {
+		item.CheckedBy = userId
+		item.CheckedAt = model.GetMillis()
+	} else {
+		item.CheckedBy = ""
+		item.CheckedAt = 0
+	}
+
+	channel, err := a.GetChannel(oldPost.ChannelId)
+	if err != nil {
+		return nil, err
+	}
+
+	if channel.DeleteAt != 0 {
+		return nil, model.NewAppError("CheckChecklistItem", "api.post.update_post.can_not_update_post_in_deleted.error", nil, "", http.StatusBadRequest)
+	}
+
+	newPost := oldPost.Clone()
+	newPost.Message = checklist.ToMessage()
+	newPost.Props = model.StringInterface{}
+	for key, value := range oldPost.Props {
+		newPost.Props[key] = value
+	}
+	newPost.Props[model.POST_PROPS_CHECKLIST] = checklist
+
+	result := <-a.Srv.Store.Post().OverwriteIfUnchanged(newPost, oldPost.UpdateAt)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	if result.Data == nil {
+		return nil, nil
+	}
+	rpost := result.Data.(*model.Post)
+
+	a.publishPostFirehoseEvent(model.FIREHOSE_EVENT_POST_EDITED, rpost, channel)
+
+	a.InvalidatePermalinkPreview(rpost.Id)
+
+	rpost = a.PreparePostForClient(rpost, false)
+
+	a.sendUpdatedPostEvent(rpost)
+
+	a.InvalidateCacheForChannelPosts(rpost.ChannelId)
+
+	return rpost, nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestCheckChecklistItem(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	post, err := th.App.CreateChecklistPost(&model.ChecklistRequest{
+		ChannelId: th.BasicChannel.Id,
+		Title:     "Deploy",
+		Items:     []string{"Build", "Ship"},
+	}, th.BasicUser.Id, false)
+	require.Nil(t, err)
+	assert.Equal(t, "#### Deploy\n\n- [ ] Build\n- [ ] Ship", post.Message)
+
+	rpost, err := th.App.CheckChecklistItem(post.Id, &model.ChecklistItemCheck{Index: 1, Checked: true}, th.BasicUser2.Id)
+	require.Nil(t, err)
+	assert.Equal(t, "#### Deploy\n\n- [ ] Build\n- [x] Ship", rpost.Message)
+	assert.Zero(t, rpost.EditAt)
+
+	// The state is kept in the props of the post that's saved
+	saved, err := th.App.GetSinglePost(post.Id)
+	require.Nil(t, err)
+	checklist := model.ChecklistFromPost(saved)
+	require.NotNil(t, checklist)
+	assert.Empty(t, checklist.Items[0].CheckedBy)
+	assert.Equal(t, th.BasicUser2.Id, checklist.Items[1].CheckedBy)
+	assert.NotZero(t, checklist.Items[1].CheckedAt)
+
+	rpost, err = th.App.CheckChecklistItem(post.Id, &model.ChecklistItemCheck{Index: 1, Checked: false}, th.BasicUser.Id)
+	require.Nil(t, err)
+	assert.Equal(t, post.Message, rpost.Message)
+
+	t.Run("items checked at the same time are all kept", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := range checklist.Items {
+			wg.Add(1)
+			go func(index int) {
+				defer wg.Done()
+				_, err := th.App.CheckChecklistItem(post.Id, &model.ChecklistItemCheck{Index: index, Checked: true}, th.BasicUser.Id)
+				assert.Nil(t, err)
+			}(i)
+		}
+		wg.Wait()
+
+		saved, err := th.App.GetSinglePost(post.Id)
+		require.Nil(t, err)
+		assert.Equal(t, "#### Deploy\n\n- [x] Build\n- [x] Ship", saved.Message)
+	})
+
+	_, err = th.App.CheckChecklistItem(post.Id, &model.ChecklistItemCheck{Index: 2, Checked: true}, th.BasicUser.Id)
+	require.NotNil(t, err)
+	assert.Equal(t, "app.checklist.item_index.app_error", err.Id)
+
+	_, err = th.App.CheckChecklistItem(th.BasicPost.Id, &model.ChecklistItemCheck{Index: 0, Checked: true}, th.BasicUser.Id)
+	require.NotNil(t, err)
+	assert.Equal(t, "app.checklist.not_checklist.app_error", err.Id)
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// oidEmailAddress is the legacy emailAddress attribute of a certificate subject, which smartcard certificates often
+// use in place of a subject alternative name.
+var oidEmailAddress = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 1}
+
+// loadClientCertCAs reads the PEM encoded certificates that client certificates are verified against.
+func loadClientCertCAs(path string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read client certificate CA file")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.Errorf("no certificates found in client certificate CA file %v", path)
+	}
+
+	return pool, nil
+}
+
+// ClientCertFromRequest returns the client certificate of a request's TLS connection once it's been verified, or nil
+// if there isn't one.
+func ClientCertFromRequest(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+
+	return r.TLS.VerifiedChains[0][0]
+}
+
+// GetUserForClientCert returns the active user that a client certificate identifies, using the attribute configured
+// by ServiceSettings.ClientCertAuthAttribute.
+func (a *App) GetUserForClientCert(cert *x509.Certificate) (*model.User, *model.AppError) {
+	var user *model.User
+	err := model.NewAppError("GetUserForClientCert", "app.client_cert.no_user.app_error", nil, "", http.StatusUnauthorized)
+
+	switch *a.Config().ServiceSettings.ClientCertAuthAttribute {
+	case model.CLIENT_CERT_AUTH_ATTRIBUTE_CN:
+		if cert.Subject.CommonName != "" {
+			user, err = a.GetUserByUsername(cert.Subject.CommonName)
+		}
+	case model.CLIENT_CERT_AUTH_ATTRIBUTE_SAN:
+		for _, email := range cert.EmailAddresses {
+			if user, err = a.GetUserByEmail(email); err == nil {
+				break
+			}
+		}
+	default:
+		for _, name := range cert.Subject.Names {
+			if email, ok := name.Value.(string); ok && name.Type.Equal(oidEmailAddress) {
+				user, err = a.GetUserByEmail(email)
+				break
+			}
+		}
+	}
+
+	if err != nil {
+		return nil, model.NewAppError("GetUserForClientCert", "app.client_cert.no_user.app_error", nil, err.Error(), http.StatusUnauthorized)
+	}
+
+	if user.DeleteAt != 0 {
+		return nil, model.NewAppError("GetUserForClientCert", "api.user.login.inactive.app_error", nil, "user_id="+user.Id, http.StatusUnauthorized)
+	}
+
+	return user, nil
+}
+
+// LoginByClientCert signs in the user that a client certificate identifies, subject to the same checks as logging in
+// with a password, such as the user's email being verified and their account not being locked. Users with MFA must log
+// in with their MFA code instead. Since clients that rely on certificates may not keep the session's cookie, the session
+// created for a certificate is reused by later requests with the same certificate for as long as it's valid.
+func (a *App) LoginByClientCert(w http.ResponseWriter, r *http.Request, cert *x509.Certificate) (*model.Session, *model.AppError) {
+	user, err := a.GetUserForClientCert(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.CheckUserAllAuthenticationCriteria(user, ""); err != nil {
+		return nil, err
+	}
+
+	if user.MfaActive && *a.Config().ServiceSettings.EnableMultifactorAuthentication {
+		return nil, model.NewAppError("LoginByClientCert", "app.client_cert.mfa.app_error", nil, "user_id="+user.Id, http.StatusUnauthorized)
+	}
+
+	fingerprint := sha256.Sum256(cert.Raw)
+	key := user.Id + ":" + hex.EncodeToString(fingerprint[:])
+
+	if token, ok := a.Srv.clientCertSessionCache.Get(key); ok {
+		if session, err := a.GetSession(token.(string)); err == nil && session.UserId == user.Id {
+			return session, nil
+		}
+		a.Srv.clientCertSessionCache.Remove(key)
+	}
+
+	session, err := a.DoLogin(w, r, user, "")
+	if err != nil {
+		return nil, err
+	}
+
+	a.Srv.clientCertSessionCache.Add(key, session.Token)
+
+	audit := &model.Audit{UserId: user.Id, IpAddress: a.IpAddress, Action: a.Path, ExtraInfo: "authenticated with client certificate", SessionId: session.Id}
+	if result := <-a.Srv.Store.Audit().Save(audit); result.Err != nil {
+		mlog.Error("Failed to save client certificate login audit", mlog.String("user_id", user.Id), mlog.Err(result.Err))
+	}
+
+	return session, nil
+}
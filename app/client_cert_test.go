@@ -0,0 +1,74 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestGetUserForClientCert(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	cert := &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName: th.BasicUser.Username,
+			Names:      []pkix.AttributeTypeAndValue{{Type: oidEmailAddress, Value: th.BasicUser.Email}},
+		},
+		EmailAddresses: []string{"unknown@example.com", th.BasicUser2.Email},
+	}
+
+	for attribute, expectedUserId := range map[string]string{
+		model.CLIENT_CERT_AUTH_ATTRIBUTE_EMAIL: th.BasicUser.Id,
+		model.CLIENT_CERT_AUTH_ATTRIBUTE_CN:    th.BasicUser.Id,
+		model.CLIENT_CERT_AUTH_ATTRIBUTE_SAN:   th.BasicUser2.Id,
+	} {
+		t.Run(attribute, func(t *testing.T) {
+			th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.ClientCertAuthAttribute = attribute })
+
+			user, err := th.App.GetUserForClientCert(cert)
+			require.Nil(t, err)
+			assert.Equal(t, expectedUserId, user.Id)
+
+			_, err = th.App.GetUserForClientCert(&x509.Certificate{})
+			require.NotNil(t, err)
+			assert.Equal(t, "app.client_cert.no_user.app_error", err.Id)
+		})
+	}
+
+	t.Run("deactivated user", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.ClientCertAuthAttribute = model.CLIENT_CERT_AUTH_ATTRIBUTE_CN
+		})
+
+		user := th.CreateUser()
+		_, err := th.App.UpdateActive(user, false)
+		require.Nil(t, err)
+
+		_, err = th.App.GetUserForClientCert(&x509.Certificate{Subject: pkix.Name{CommonName: user.Username}})
+		require.NotNil(t, err)
+		assert.Equal(t, http.StatusUnauthorized, err.StatusCode)
+	})
+}
+
+func TestClientCertFromRequest(t *testing.T) {
+	r := &http.Request{}
+	assert.Nil(t, ClientCertFromRequest(r))
+
+	r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{}}}
+	assert.Nil(t, ClientCertFromRequest(r), "unverified certificates should be ignored")
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "test"}}
+	r.TLS.VerifiedChains = [][]*x509.Certificate{{cert, {}}}
+	assert.Equal(t, cert, ClientCertFromRequest(r))
+}
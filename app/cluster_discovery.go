@@ -23,9 +23,11 @@ type ClusterDiscoveryService struct {
 
 func (a *App) NewClusterDiscoveryService() *ClusterDiscoveryService {
 	ds := &ClusterDiscoveryService{
-		ClusterDiscovery: model.ClusterDiscovery{},
-		app:              a,
-		stop:             make(chan bool),
+		ClusterDiscovery: model.ClusterDiscovery{
+			Tags: a.Config().ClusterSettings.NodeTags,
+		},
+		app:  a,
+		stop: make(chan bool),
 	}
 
 	return ds
@@ -92,3 +94,13 @@ func (a *App) GetClusterId() string {
 
 	return a.Cluster.GetClusterId()
 }
+
+// ExposedNodeId returns the id of this cluster node if ServiceSettings.ExposeNodeHeader is set, so that it can be sent
+// to clients to tell which node served them. It's empty otherwise, or when the server isn't part of a cluster.
+func (a *App) ExposedNodeId() string {
+	if !*a.Config().ServiceSettings.ExposeNodeHeader {
+		return ""
+	}
+
+	return a.GetClusterId()
+}
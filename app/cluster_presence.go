@@ -0,0 +1,265 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const (
+	CLUSTER_PRESENCE_SYNC_INTERVAL = 30 * time.Second
+
+	// A node that hasn't shared its presence for this long is assumed to have gone down without disconnecting its
+	// users.
+	CLUSTER_PRESENCE_NODE_TIMEOUT = 3 * CLUSTER_PRESENCE_SYNC_INTERVAL
+)
+
+// clusterPresence tracks which users have websocket connections to this node and, when clustering is enabled, to the
+// other nodes in the cluster, so that users are only set offline once they've disconnected from all of them.
+type clusterPresence struct {
+	mutex sync.Mutex
+	local map[string]bool
+	nodes map[string]*clusterPresenceNode
+
+	// deferred holds the users who disconnected from this node while still connected to another one. This node sets
+	// them offline if they disconnect from the other nodes without the other nodes doing it, which happens when they
+	// disconnect from several nodes at once or when a node goes down.
+	deferred map[string]bool
+}
+
+type clusterPresenceNode struct {
+	userIds    map[string]bool
+	lastSeenAt int64
+}
+
+func newClusterPresence() *clusterPresence {
+	return &clusterPresence{
+		local:    make(map[string]bool),
+		nodes:    make(map[string]*clusterPresenceNode),
+		deferred: make(map[string]bool),
+	}
+}
+
+func (p *clusterPresence) connectedLocally(userId string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.local[userId] = true
+	delete(p.deferred, userId)
+}
+
+// disconnectedLocally records that the user has no connections left to this node and returns whether they're still
+// connected to another node.
+func (p *clusterPresence) disconnectedLocally(userId string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.local, userId)
+
+	if p.isConnectedLocked(userId) {
+		p.deferred[userId] = true
+		return true
+	}
+
+	return false
+}
+
+func (p *clusterPresence) localUserIds() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	userIds := make([]string, 0, len(p.local))
+	for userId := range p.local {
+		userIds = append(userIds, userId)
+	}
+
+	return userIds
+}
+
+func (p *clusterPresence) isConnected(userId string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.isConnectedLocked(userId)
+}
+
+func (p *clusterPresence) isConnectedLocked(userId string) bool {
+	if p.local[userId] {
+		return true
+	}
+
+	for _, node := range p.nodes {
+		if node.userIds[userId] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// update applies the presence shared by another node and returns the users that this node should now set offline.
+// A full update replaces everything previously known about the node. Users who disconnected from a node are normally
+// set offline by that node, so unless handleOrphans is set, only deferred users are returned.
+func (p *clusterPresence) update(presence *model.ClusterPresence, full bool, handleOrphans bool, now int64) []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	node, ok := p.nodes[presence.NodeId]
+	if !ok {
+		node = &clusterPresenceNode{userIds: make(map[string]bool)}
+		p.nodes[presence.NodeId] = node
+	}
+	node.lastSeenAt = now
+
+	var removed []string
+	if full {
+		userIds := make(map[string]bool, len(presence.UserIds))
+		for _, userId := range presence.UserIds {
+			userIds[userId] = true
+		}
+
+		for userId := range node.userIds {
+			if !userIds[userId] {
+				removed = append(removed, userId)
+			}
+		}
+
+		node.userIds = userIds
+	} else if presence.Connected {
+		for _, userId := range presence.UserIds {
+			node.userIds[userId] = true
+		}
+	} else {
+		for _, userId := range presence.UserIds {
+			if node.userIds[userId] {
+				delete(node.userIds, userId)
+				removed = append(removed, userId)
+			}
+		}
+	}
+
+	return p.disconnectedLocked(removed, handleOrphans)
+}
+
+// expire forgets about the nodes that haven't shared their presence since cutoff and returns the users that this node
+// should set offline as a result.
+func (p *clusterPresence) expire(cutoff int64, handleOrphans bool) []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var removed []string
+	for nodeId, node := range p.nodes {
+		if node.lastSeenAt >= cutoff {
+			continue
+		}
+
+		for userId := range node.userIds {
+			removed = append(removed, userId)
+		}
+		delete(p.nodes, nodeId)
+	}
+
+	return p.disconnectedLocked(removed, handleOrphans)
+}
+
+func (p *clusterPresence) disconnectedLocked(userIds []string, handleOrphans bool) []string {
+	var disconnected []string
+	for _, userId := range userIds {
+		if p.isConnectedLocked(userId) {
+			continue
+		}
+
+		if p.deferred[userId] {
+			delete(p.deferred, userId)
+			disconnected = append(disconnected, userId)
+		} else if handleOrphans {
+			disconnected = append(disconnected, userId)
+		}
+	}
+
+	return disconnected
+}
+
+// UserConnectedToNode is called by the hubs when the user makes their first websocket connection to this node.
+func (a *App) UserConnectedToNode(userId string) {
+	a.Srv.clusterPresence.connectedLocally(userId)
+
+	if a.Cluster != nil {
+		a.Srv.Go(func() {
+			a.sendClusterPresence(model.CLUSTER_EVENT_PRESENCE_UPDATE, []string{userId}, true)
+		})
+	}
+}
+
+// UserDisconnectedFromNode is called by the hubs when the user closes their last websocket connection to this node.
+// The user is set offline unless they're still connected to another node in the cluster.
+func (a *App) UserDisconnectedFromNode(userId string) {
+	connectedElsewhere := a.Srv.clusterPresence.disconnectedLocally(userId)
+
+	a.Srv.Go(func() {
+		if a.Cluster != nil {
+			a.sendClusterPresence(model.CLUSTER_EVENT_PRESENCE_UPDATE, []string{userId}, false)
+		}
+
+		if !connectedElsewhere {
+			a.SetStatusOffline(userId, false)
+		}
+	})
+}
+
+// SyncClusterPresence shares the users connected to this node with the rest of the cluster and sets offline the
+// users of any node that stopped doing the same.
+func (a *App) SyncClusterPresence() {
+	if a.Cluster == nil {
+		return
+	}
+
+	a.sendClusterPresence(model.CLUSTER_EVENT_PRESENCE_SYNC, a.Srv.clusterPresence.localUserIds(), true)
+
+	// Only the leader sets offline the users of a node that went down, so that it's only done once.
+	cutoff := model.GetMillis() - int64(CLUSTER_PRESENCE_NODE_TIMEOUT/time.Millisecond)
+	for _, userId := range a.Srv.clusterPresence.expire(cutoff, a.Cluster.IsLeader()) {
+		a.SetStatusOffline(userId, false)
+	}
+}
+
+func (a *App) sendClusterPresence(event string, userIds []string, connected bool) {
+	presence := &model.ClusterPresence{
+		NodeId:    a.Cluster.GetClusterId(),
+		UserIds:   userIds,
+		Connected: connected,
+	}
+
+	a.Cluster.SendClusterMessage(&model.ClusterMessage{
+		Event:    event,
+		SendType: model.CLUSTER_SEND_BEST_EFFORT,
+		Data:     presence.ToJson(),
+	})
+}
+
+func (a *App) ClusterPresenceSyncHandler(msg *model.ClusterMessage) {
+	a.handleClusterPresence(msg, true)
+}
+
+func (a *App) ClusterPresenceUpdateHandler(msg *model.ClusterMessage) {
+	a.handleClusterPresence(msg, false)
+}
+
+func (a *App) handleClusterPresence(msg *model.ClusterMessage, full bool) {
+	presence := model.ClusterPresenceFromJson(strings.NewReader(msg.Data))
+	if presence == nil || presence.NodeId == "" || presence.NodeId == a.Cluster.GetClusterId() {
+		return
+	}
+
+	// A full update only drops users whose disconnection wasn't shared, such as when a node restarts, so the leader
+	// takes care of them.
+	handleOrphans := full && a.Cluster.IsLeader()
+	for _, userId := range a.Srv.clusterPresence.update(presence, full, handleOrphans, model.GetMillis()) {
+		a.SetStatusOffline(userId, false)
+	}
+}
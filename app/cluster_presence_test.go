@@ -0,0 +1,83 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestClusterPresence(t *testing.T) {
+	t.Run("users connected to another node aren't set offline", func(t *testing.T) {
+		p := newClusterPresence()
+		userId := model.NewId()
+
+		p.connectedLocally(userId)
+		assert.Empty(t, p.update(&model.ClusterPresence{NodeId: "b", UserIds: []string{userId}, Connected: true}, false, false, 1))
+
+		assert.True(t, p.disconnectedLocally(userId))
+		assert.True(t, p.isConnected(userId))
+
+		// This node deferred setting the user offline, so it does it once they've disconnected from the other node.
+		assert.Equal(t, []string{userId}, p.update(&model.ClusterPresence{NodeId: "b", UserIds: []string{userId}}, false, false, 2))
+		assert.False(t, p.isConnected(userId))
+	})
+
+	t.Run("users who disconnected from another node are set offline by that node", func(t *testing.T) {
+		p := newClusterPresence()
+		userId := model.NewId()
+
+		p.update(&model.ClusterPresence{NodeId: "b", UserIds: []string{userId}, Connected: true}, false, false, 1)
+		assert.True(t, p.isConnected(userId))
+
+		assert.Empty(t, p.update(&model.ClusterPresence{NodeId: "b", UserIds: []string{userId}}, false, false, 2))
+		assert.False(t, p.isConnected(userId))
+	})
+
+	t.Run("users still connected locally aren't set offline", func(t *testing.T) {
+		p := newClusterPresence()
+		userId := model.NewId()
+
+		p.connectedLocally(userId)
+		p.update(&model.ClusterPresence{NodeId: "b", UserIds: []string{userId}}, true, true, 1)
+
+		assert.Empty(t, p.update(&model.ClusterPresence{NodeId: "b", UserIds: []string{}}, true, true, 2))
+		assert.Empty(t, p.expire(3, true))
+		assert.Equal(t, []string{userId}, p.localUserIds())
+	})
+
+	t.Run("full updates replace what's known about a node", func(t *testing.T) {
+		p := newClusterPresence()
+		userId1 := model.NewId()
+		userId2 := model.NewId()
+
+		p.update(&model.ClusterPresence{NodeId: "b", UserIds: []string{userId1}}, true, true, 1)
+
+		assert.Empty(t, p.update(&model.ClusterPresence{NodeId: "b", UserIds: []string{userId2}}, true, false, 2))
+		assert.False(t, p.isConnected(userId1))
+		assert.True(t, p.isConnected(userId2))
+
+		assert.Equal(t, []string{userId2}, p.update(&model.ClusterPresence{NodeId: "b", UserIds: []string{}}, true, true, 3))
+	})
+
+	t.Run("users of nodes that went down are set offline", func(t *testing.T) {
+		p := newClusterPresence()
+		userId1 := model.NewId()
+		userId2 := model.NewId()
+
+		p.update(&model.ClusterPresence{NodeId: "b", UserIds: []string{userId1}}, true, false, 1)
+		p.update(&model.ClusterPresence{NodeId: "c", UserIds: []string{userId1, userId2}}, true, false, 5)
+
+		// The user is still connected to node c.
+		assert.Empty(t, p.expire(2, true))
+		assert.True(t, p.isConnected(userId1))
+
+		assert.Empty(t, p.expire(10, false))
+		assert.False(t, p.isConnected(userId1))
+		assert.False(t, p.isConnected(userId2))
+	})
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mattermost/mattermost-server/einterfaces"
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/services/clustertransport"
+)
+
+// transportCluster sends and receives the cluster messages of a cluster implementation through the transport selected
+// by ClusterSettings.Transport. Everything else, such as discovery, leader election and cluster stats, is still left
+// to the implementation, which is how the nodes that messages are sent to are found.
+type transportCluster struct {
+	einterfaces.ClusterInterface
+
+	server    *Server
+	transport clustertransport.Transport
+	port      int
+
+	handlersMutex sync.RWMutex
+	handlers      map[string]einterfaces.ClusterMessageHandler
+}
+
+// newTransportCluster wraps a cluster implementation so that its messages go through the transport selected by the
+// config, or returns it as it is if it should use its own.
+func newTransportCluster(s *Server, cluster einterfaces.ClusterInterface) (einterfaces.ClusterInterface, error) {
+	settings := &s.Config().ClusterSettings
+
+	transport, err := clustertransport.New(settings, s.Metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	if transport == nil {
+		return cluster, nil
+	}
+
+	return &transportCluster{
+		ClusterInterface: cluster,
+		server:           s,
+		transport:        transport,
+		port:             *settings.GrpcPort,
+		handlers:         make(map[string]einterfaces.ClusterMessageHandler),
+	}, nil
+}
+
+func (c *transportCluster) StartInterNodeCommunication() {
+	if err := c.transport.Start(fmt.Sprintf(":%v", c.port), c.handleMessage); err != nil {
+		mlog.Critical("Failed to start the cluster transport", mlog.Err(err))
+	}
+
+	c.ClusterInterface.StartInterNodeCommunication()
+}
+
+func (c *transportCluster) StopInterNodeCommunication() {
+	c.ClusterInterface.StopInterNodeCommunication()
+	c.transport.Stop()
+}
+
+func (c *transportCluster) RegisterClusterMessageHandler(event string, handler einterfaces.ClusterMessageHandler) {
+	c.handlersMutex.Lock()
+	defer c.handlersMutex.Unlock()
+
+	c.handlers[event] = handler
+}
+
+func (c *transportCluster) handleMessage(msg *model.ClusterMessage) {
+	c.handlersMutex.RLock()
+	handler, ok := c.handlers[msg.Event]
+	c.handlersMutex.RUnlock()
+
+	if !ok {
+		mlog.Warn("Received a cluster message with no handler", mlog.String("event", msg.Event))
+		return
+	}
+
+	handler(msg)
+}
+
+// SendClusterMessage sends a message to every other node that has registered itself through cluster discovery. It
+// only waits for the message to be sent when WaitForAllToSend is set.
+func (c *transportCluster) SendClusterMessage(msg *model.ClusterMessage) {
+	result := <-c.server.Store.ClusterDiscovery().GetAll(model.CDS_TYPE_APP, *c.server.Config().ClusterSettings.ClusterName)
+	if result.Err != nil {
+		mlog.Error("Failed to find the nodes to send a cluster message to", mlog.String("event", msg.Event), mlog.Err(result.Err))
+		return
+	}
+
+	me := c.GetMyClusterInfo()
+
+	var wg sync.WaitGroup
+	for _, node := range result.Data.([]*model.ClusterDiscovery) {
+		if me != nil && (node.Hostname == me.Hostname || node.Hostname == me.IpAddress) {
+			continue
+		}
+
+		address := clustertransport.NodeAddress(node, c.port)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := c.transport.Send(address, msg); err != nil {
+				mlog.Error("Failed to send a cluster message", mlog.String("event", msg.Event), mlog.String("address", address), mlog.Err(err))
+			}
+		}()
+	}
+
+	if msg.WaitForAllToSend {
+		wg.Wait()
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/einterfaces"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/services/clustertransport"
+)
+
+type fakeClusterTransport struct {
+	listenAddress string
+	handler       clustertransport.MessageHandler
+	stopped       bool
+}
+
+func (t *fakeClusterTransport) Start(listenAddress string, handler clustertransport.MessageHandler) error {
+	t.listenAddress = listenAddress
+	t.handler = handler
+	return nil
+}
+
+func (t *fakeClusterTransport) Stop() {
+	t.stopped = true
+}
+
+func (t *fakeClusterTransport) Send(address string, msg *model.ClusterMessage) error {
+	return nil
+}
+
+type fakeCluster struct {
+	einterfaces.ClusterInterface
+
+	started  bool
+	stopped  bool
+	handlers map[string]einterfaces.ClusterMessageHandler
+}
+
+func (c *fakeCluster) StartInterNodeCommunication() {
+	c.started = true
+}
+
+func (c *fakeCluster) StopInterNodeCommunication() {
+	c.stopped = true
+}
+
+func (c *fakeCluster) RegisterClusterMessageHandler(event string, handler einterfaces.ClusterMessageHandler) {
+	c.handlers[event] = handler
+}
+
+func TestTransportCluster(t *testing.T) {
+	transport := &fakeClusterTransport{}
+	inner := &fakeCluster{handlers: map[string]einterfaces.ClusterMessageHandler{}}
+
+	cluster := &transportCluster{
+		ClusterInterface: inner,
+		transport:        transport,
+		port:             8076,
+		handlers:         map[string]einterfaces.ClusterMessageHandler{},
+	}
+
+	var received []*model.ClusterMessage
+	cluster.RegisterClusterMessageHandler(model.CLUSTER_EVENT_PUBLISH, func(msg *model.ClusterMessage) {
+		received = append(received, msg)
+	})
+	assert.Empty(t, inner.handlers, "messages should only be handled when they arrive through the transport")
+
+	cluster.StartInterNodeCommunication()
+	assert.True(t, inner.started)
+	assert.Equal(t, ":8076", transport.listenAddress)
+	require.NotNil(t, transport.handler)
+
+	transport.handler(&model.ClusterMessage{Event: model.CLUSTER_EVENT_PUBLISH, Data: "data"})
+	transport.handler(&model.ClusterMessage{Event: "unknown"})
+	require.Len(t, received, 1)
+	assert.Equal(t, "data", received[0].Data)
+
+	cluster.StopInterNodeCommunication()
+	assert.True(t, inner.stopped)
+	assert.True(t, transport.stopped)
+}
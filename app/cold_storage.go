@@ -0,0 +1,170 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/services/filesstore"
+)
+
+// ColdFileBackend returns the file store that old files are moved to, as configured by the ColdStorageSettings.
+func (a *App) ColdFileBackend() (filesstore.FileBackend, *model.AppError) {
+	license := a.License()
+	cfg := a.Config()
+	return filesstore.NewFileBackend(cfg.ColdStorageSettings.ToFileSettings(cfg.FileSettings), license != nil && *license.Features.Compliance)
+}
+
+// FileBackendForInfo returns the file store that holds the file described by the given FileInfo.
+func (a *App) FileBackendForInfo(info *model.FileInfo) (filesstore.FileBackend, *model.AppError) {
+	if info.StorageTier == model.FILE_INFO_STORAGE_TIER_COLD {
+		return a.ColdFileBackend()
+	}
+	return a.FileBackend()
+}
+
+// FileReaderForInfo opens one of the paths of the given FileInfo from whichever file store holds the file. Caller
+// must close the first return value.
+func (a *App) FileReaderForInfo(info *model.FileInfo, path string) (io.ReadCloser, *model.AppError) {
+	backend, err := a.FileBackendForInfo(info)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := backend.Reader(path)
+	if err == nil || info.StorageTier != model.FILE_INFO_STORAGE_TIER_HOT {
+		return reader, err
+	}
+
+	// The file may have been moved to cold storage since its FileInfo was read
+	coldBackend, coldErr := a.ColdFileBackend()
+	if coldErr != nil {
+		return nil, err
+	}
+	if exists, _ := coldBackend.FileExists(path); !exists {
+		return nil, err
+	}
+	return coldBackend.Reader(path)
+}
+
+// ReadFileForInfo reads one of the paths of the given FileInfo from whichever file store holds the file.
+func (a *App) ReadFileForInfo(info *model.FileInfo, path string) ([]byte, *model.AppError) {
+	reader, err := a.FileReaderForInfo(info, path)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, readErr := ioutil.ReadAll(reader)
+	if readErr != nil {
+		return nil, model.NewAppError("ReadFileForInfo", "api.file.read_file.reading_file.app_error", nil, readErr.Error(), http.StatusInternalServerError)
+	}
+	return data, nil
+}
+
+// MoveFilesToColdStorage moves the files attached to posts that are older than ColdStorageSettings.MinFileAgeDays,
+// along with their thumbnails and previews, from the main file store to cold storage, BatchSize files at a time.
+// Files that can't be moved are left where they are, to be tried again the next time that this runs. After each
+// batch, onProgress is called with a checkpoint of the run so far, and the run is aborted if it returns an error.
+// Passing the last checkpoint back in resumes an interrupted run.
+func (a *App) MoveFilesToColdStorage(checkpoint *model.ColdStorageCheckpoint, onProgress func(checkpoint *model.ColdStorageCheckpoint) *model.AppError) (*model.ColdStorageCheckpoint, *model.AppError) {
+	settings := a.Config().ColdStorageSettings
+
+	if checkpoint == nil {
+		checkpoint = &model.ColdStorageCheckpoint{
+			Before: model.GetMillis() - int64(*settings.MinFileAgeDays)*DAY_MILLISECONDS,
+		}
+	}
+
+	hotBackend, err := a.FileBackend()
+	if err != nil {
+		return checkpoint, err
+	}
+
+	coldBackend, err := a.ColdFileBackend()
+	if err != nil {
+		return checkpoint, err
+	}
+	if err = coldBackend.TestConnection(); err != nil {
+		return checkpoint, err
+	}
+
+	for {
+		result := <-a.Srv.Store.FileInfo().GetForColdStorage(checkpoint.Before, checkpoint.AfterId, *settings.BatchSize)
+		if result.Err != nil {
+			return checkpoint, result.Err
+		}
+		infos := result.Data.([]*model.FileInfo)
+		if len(infos) == 0 {
+			break
+		}
+
+		for _, info := range infos {
+			if err := a.moveFileToColdStorage(info, hotBackend, coldBackend); err != nil {
+				mlog.Warn("Failed to move file to cold storage", mlog.String("file_id", info.Id), mlog.Err(err))
+				checkpoint.Failed++
+			} else {
+				checkpoint.Moved++
+			}
+		}
+
+		checkpoint.AfterId = infos[len(infos)-1].Id
+		if err := onProgress(checkpoint); err != nil {
+			return checkpoint, err
+		}
+	}
+
+	mlog.Info("Moved files to cold storage", mlog.Int64("moved", checkpoint.Moved), mlog.Int64("failed", checkpoint.Failed))
+
+	return checkpoint, nil
+}
+
+func (a *App) moveFileToColdStorage(info *model.FileInfo, hotBackend, coldBackend filesstore.FileBackend) *model.AppError {
+	// The files are copied before the FileInfo is updated so that they can always be read from one tier or the other
+	var paths []string
+	for _, filePath := range info.GetPaths() {
+		// A thumbnail or preview that failed to be generated isn't needed to move the file
+		if filePath != info.Path {
+			if exists, err := hotBackend.FileExists(filePath); err != nil {
+				return err
+			} else if !exists {
+				continue
+			}
+		}
+
+		if err := copyFileToBackend(hotBackend, coldBackend, filePath); err != nil {
+			return err
+		}
+		paths = append(paths, filePath)
+	}
+
+	if result := <-a.Srv.Store.FileInfo().UpdateStorageTier(info.Id, model.FILE_INFO_STORAGE_TIER_COLD); result.Err != nil {
+		return result.Err
+	}
+	a.Srv.Store.FileInfo().InvalidateFileInfosForPostCache(info.PostId)
+
+	// The file is read from cold storage from now on, so failing to remove it here only wastes space
+	for _, filePath := range paths {
+		if err := hotBackend.RemoveFile(filePath); err != nil {
+			mlog.Warn("Failed to remove file that was moved to cold storage", mlog.String("file_id", info.Id), mlog.String("path", filePath), mlog.Err(err))
+		}
+	}
+
+	return nil
+}
+
+func copyFileToBackend(from, to filesstore.FileBackend, path string) *model.AppError {
+	reader, err := from.Reader(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	_, err = to.WriteFile(reader, path)
+	return err
+}
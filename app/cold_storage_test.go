@@ -0,0 +1,120 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/store/sqlstore"
+)
+
+func TestMoveFilesToColdStorage(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	coldDirectory, err := ioutil.TempDir("", "coldstorage")
+	require.Nil(t, err)
+	defer os.RemoveAll(coldDirectory)
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ColdStorageSettings.Enable = true
+		*cfg.ColdStorageSettings.MinFileAgeDays = 30
+		*cfg.ColdStorageSettings.BatchSize = 1
+		*cfg.ColdStorageSettings.Directory = coldDirectory
+	})
+
+	sqlStore := th.App.Srv.Store.FileInfo().(*sqlstore.SqlFileInfoStore)
+	now := model.GetMillis()
+
+	uploadFile := func(data []byte, createAt int64) *model.FileInfo {
+		info, appErr := th.App.DoUploadFile(time.Now(), th.BasicTeam.Id, th.BasicChannel.Id, th.BasicUser.Id, "file.txt", data)
+		require.Nil(t, appErr)
+
+		_, err := sqlStore.GetMaster().Exec("UPDATE FileInfo SET CreateAt = :CreateAt, PostId = :PostId WHERE Id = :Id", map[string]interface{}{"CreateAt": createAt, "PostId": th.BasicPost.Id, "Id": info.Id})
+		require.Nil(t, err)
+
+		info, appErr = th.App.GetFileInfo(info.Id)
+		require.Nil(t, appErr)
+		return info
+	}
+
+	old := uploadFile([]byte("old"), now-60*DAY_MILLISECONDS)
+	old2 := uploadFile([]byte("old2"), now-45*DAY_MILLISECONDS)
+	recent := uploadFile([]byte("recent"), now-10*DAY_MILLISECONDS)
+	defer th.App.RemoveFile(recent.Path)
+
+	// Files left behind by other tests may be moved too
+	checkpoints := 0
+	checkpoint, appErr := th.App.MoveFilesToColdStorage(nil, func(checkpoint *model.ColdStorageCheckpoint) *model.AppError {
+		checkpoints++
+		return nil
+	})
+	require.Nil(t, appErr)
+	assert.True(t, checkpoint.Moved >= 2)
+	assert.True(t, checkpoints >= 2, "a checkpoint should be reported after each batch")
+
+	for _, info := range []*model.FileInfo{old, old2} {
+		updated, appErr := th.App.GetFileInfo(info.Id)
+		require.Nil(t, appErr)
+		assert.Equal(t, model.FILE_INFO_STORAGE_TIER_COLD, updated.StorageTier)
+
+		exists, appErr := th.App.FileExists(info.Path)
+		require.Nil(t, appErr)
+		assert.False(t, exists, "file should have been removed from the main file store")
+
+		_, err := os.Stat(coldDirectory + "/" + info.Path)
+		assert.Nil(t, err, "file should be in cold storage")
+	}
+
+	t.Run("files are read from the tier that they're in", func(t *testing.T) {
+		data, appErr := th.App.GetFile(old.Id)
+		require.Nil(t, appErr)
+		assert.Equal(t, []byte("old"), data)
+
+		data, appErr = th.App.GetFile(recent.Id)
+		require.Nil(t, appErr)
+		assert.Equal(t, []byte("recent"), data)
+	})
+
+	t.Run("a stale FileInfo is still read from cold storage", func(t *testing.T) {
+		data, appErr := th.App.ReadFileForInfo(old2, old2.Path)
+		require.Nil(t, appErr)
+		assert.Equal(t, []byte("old2"), data)
+	})
+
+	t.Run("recent files are left in the main file store", func(t *testing.T) {
+		updated, appErr := th.App.GetFileInfo(recent.Id)
+		require.Nil(t, appErr)
+		assert.Equal(t, model.FILE_INFO_STORAGE_TIER_HOT, updated.StorageTier)
+
+		exists, appErr := th.App.FileExists(recent.Path)
+		require.Nil(t, appErr)
+		assert.True(t, exists)
+	})
+
+	t.Run("interrupted runs can be resumed", func(t *testing.T) {
+		uploadFile([]byte("old3"), now-60*DAY_MILLISECONDS)
+		uploadFile([]byte("old4"), now-60*DAY_MILLISECONDS)
+
+		checkpoint, appErr := th.App.MoveFilesToColdStorage(nil, func(checkpoint *model.ColdStorageCheckpoint) *model.AppError {
+			return model.NewAppError("test", "interrupted", nil, "", http.StatusOK)
+		})
+		require.NotNil(t, appErr)
+		assert.Equal(t, int64(1), checkpoint.Moved)
+
+		checkpoint, appErr = th.App.MoveFilesToColdStorage(checkpoint, func(checkpoint *model.ColdStorageCheckpoint) *model.AppError {
+			return nil
+		})
+		require.Nil(t, appErr)
+		assert.Equal(t, int64(2), checkpoint.Moved)
+	})
+}
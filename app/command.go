@@ -313,7 +313,7 @@ func (a *App) doCommandRequest(cmd *model.Command, p url.Values) (*model.Command
 	}
 
 	// Send the request
-	resp, err := a.HTTPService.MakeClient(false).Do(req)
+	resp, err := a.HTTPService.MakeIntegrationClient(a.Config().IntegrationHTTPSettings.SlashCommands).Do(req.WithContext(a.RequestContext()))
 	if err != nil {
 		return cmd, nil, model.NewAppError("command", "api.command.execute_command.failed.app_error", map[string]interface{}{"Trigger": cmd.Trigger}, err.Error(), http.StatusInternalServerError)
 	}
@@ -16,7 +16,6 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/mattermost/mattermost-server/model"
-	"github.com/mattermost/mattermost-server/services/httpservice"
 )
 
 func TestMoveCommand(t *testing.T) {
@@ -343,18 +342,18 @@ func TestDoCommandRequest(t *testing.T) {
 	})
 
 	t.Run("with a slow response", func(t *testing.T) {
-		timeout := 100 * time.Millisecond
-
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			time.Sleep(timeout + time.Millisecond)
+			time.Sleep(time.Second + 100*time.Millisecond)
 			io.Copy(w, strings.NewReader(`{"text": "Hello, World!"}`))
 		}))
 		defer server.Close()
 
-		th.App.HTTPService.(*httpservice.HTTPServiceImpl).RequestTimeout = timeout
-		defer func() {
-			th.App.HTTPService.(*httpservice.HTTPServiceImpl).RequestTimeout = httpservice.RequestTimeout
-		}()
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.IntegrationHTTPSettings.SlashCommands.RequestTimeoutSeconds = 1
+		})
+		defer th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.IntegrationHTTPSettings.SlashCommands.RequestTimeoutSeconds = model.INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_REQUEST_TIMEOUT_SECONDS
+		})
 
 		_, _, err := th.App.doCommandRequest(&model.Command{URL: server.URL}, url.Values{})
 		require.NotNil(t, err)
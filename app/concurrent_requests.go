@@ -0,0 +1,52 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"sync"
+)
+
+// concurrentRequests counts the requests in progress on this node for each user, or for each IP address for requests
+// made without a session.
+type concurrentRequests struct {
+	mutex  sync.Mutex
+	counts map[string]int
+}
+
+func newConcurrentRequests() *concurrentRequests {
+	return &concurrentRequests{
+		counts: make(map[string]int),
+	}
+}
+
+// AcquireConcurrentRequest counts a request as in progress for a key, such as a user id, unless max requests are
+// already in progress for it, in which case false is returned and the request should be rejected. Every successful
+// call must be followed by a call to ReleaseConcurrentRequest once the request has been handled.
+func (s *Server) AcquireConcurrentRequest(key string, max int) bool {
+	requests := s.concurrentRequests
+
+	requests.mutex.Lock()
+	defer requests.mutex.Unlock()
+
+	if requests.counts[key] >= max {
+		return false
+	}
+
+	requests.counts[key]++
+	return true
+}
+
+// ReleaseConcurrentRequest stops counting a request acquired with AcquireConcurrentRequest as in progress.
+func (s *Server) ReleaseConcurrentRequest(key string) {
+	requests := s.concurrentRequests
+
+	requests.mutex.Lock()
+	defer requests.mutex.Unlock()
+
+	if requests.counts[key] <= 1 {
+		delete(requests.counts, key)
+	} else {
+		requests.counts[key]--
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 	"runtime/debug"
 	"strconv"
@@ -301,6 +302,10 @@ func (a *App) regenerateClientConfig() {
 
 	clientConfigJSON, _ := json.Marshal(a.Srv.clientConfig)
 	a.Srv.clientConfigHash = fmt.Sprintf("%x", md5.Sum(clientConfigJSON))
+
+	// Every node regenerates its client config when the config or license changes, and cached responses may depend
+	// on either, so they're all dropped without notifying the rest of the cluster.
+	a.Srv.responseCache.Purge()
 }
 
 func (a *App) Desanitize(cfg *model.Config) {
@@ -316,6 +321,9 @@ func (a *App) Desanitize(cfg *model.Config) {
 	if cfg.FileSettings.AmazonS3SecretAccessKey == model.FAKE_SETTING {
 		cfg.FileSettings.AmazonS3SecretAccessKey = actual.FileSettings.AmazonS3SecretAccessKey
 	}
+	if cfg.ColdStorageSettings.AmazonS3SecretAccessKey != nil && *cfg.ColdStorageSettings.AmazonS3SecretAccessKey == model.FAKE_SETTING {
+		*cfg.ColdStorageSettings.AmazonS3SecretAccessKey = *actual.ColdStorageSettings.AmazonS3SecretAccessKey
+	}
 
 	if cfg.EmailSettings.InviteSalt == model.FAKE_SETTING {
 		cfg.EmailSettings.InviteSalt = actual.EmailSettings.InviteSalt
@@ -349,6 +357,10 @@ func (a *App) Desanitize(cfg *model.Config) {
 }
 
 func (a *App) GetCookieDomain() string {
+	if domain := *a.Config().ServiceSettings.SessionCookieDomain; domain != "" {
+		return domain
+	}
+
 	if *a.Config().ServiceSettings.AllowCookiesForSubdomains {
 		if siteURL, err := url.Parse(*a.Config().ServiceSettings.SiteURL); err == nil {
 			return siteURL.Hostname()
@@ -357,6 +369,29 @@ func (a *App) GetCookieDomain() string {
 	return ""
 }
 
+// SetSessionCookie applies the configured domain, Secure flag and SameSite attribute to a session cookie and adds
+// it to the response. SameSite=None is only accepted by browsers on secure cookies, so it always sets the Secure flag.
+func (a *App) SetSessionCookie(w http.ResponseWriter, r *http.Request, cookie *http.Cookie) {
+	settings := a.Config().ServiceSettings
+	cookie.Domain = a.GetCookieDomain()
+	cookie.Secure = *settings.SessionCookieSecure == model.SESSION_COOKIE_SECURE_ALWAYS || GetProtocol(r) == "https"
+	switch *settings.SessionCookieSameSite {
+	case model.SESSION_COOKIE_SAME_SITE_STRICT:
+		cookie.SameSite = http.SameSiteStrictMode
+	case model.SESSION_COOKIE_SAME_SITE_LAX:
+		cookie.SameSite = http.SameSiteLaxMode
+	case model.SESSION_COOKIE_SAME_SITE_NONE:
+		// net/http can't write SameSite=None until Go 1.13, so it's appended by hand
+		cookie.Secure = true
+		if v := cookie.String(); v != "" {
+			w.Header().Add("Set-Cookie", v+"; SameSite=None")
+		}
+		return
+	}
+
+	http.SetCookie(w, cookie)
+}
+
 func (a *App) GetSiteURL() string {
 	return *a.Config().ServiceSettings.SiteURL
 }
@@ -5,6 +5,8 @@ package app
 
 import (
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"strings"
 	"testing"
@@ -111,6 +113,84 @@ func TestClientConfigWithComputed(t *testing.T) {
 	}
 }
 
+func TestSetSessionCookie(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	setCookie := func(protocol string) string {
+		r := httptest.NewRequest("GET", "/login", nil)
+		r.Header.Set(model.HEADER_FORWARDED_PROTO, protocol)
+		w := httptest.NewRecorder()
+
+		th.App.SetSessionCookie(w, r, &http.Cookie{Name: model.SESSION_COOKIE_TOKEN, Value: "token"})
+		return w.Header().Get("Set-Cookie")
+	}
+	newCookie := func(protocol string) *http.Cookie {
+		cookies := (&http.Response{Header: http.Header{"Set-Cookie": {setCookie(protocol)}}}).Cookies()
+		require.Len(t, cookies, 1)
+		return cookies[0]
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		cookie := newCookie("http")
+		assert.Equal(t, "", cookie.Domain)
+		assert.False(t, cookie.Secure)
+		assert.NotContains(t, setCookie("http"), "SameSite", "no SameSite attribute should be set by default")
+
+		assert.True(t, newCookie("https").Secure)
+	})
+
+	t.Run("same site lax", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.SessionCookieSameSite = model.SESSION_COOKIE_SAME_SITE_LAX
+		})
+		defer th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.SessionCookieSameSite = model.SESSION_COOKIE_SAME_SITE_UNSET
+		})
+
+		assert.Contains(t, setCookie("http"), "; SameSite=Lax")
+	})
+
+	t.Run("always secure", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.SessionCookieSecure = model.SESSION_COOKIE_SECURE_ALWAYS
+		})
+		defer th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.SessionCookieSecure = model.SESSION_COOKIE_SECURE_AUTO
+		})
+
+		assert.True(t, newCookie("http").Secure)
+	})
+
+	t.Run("same site none forces secure", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.SessionCookieSameSite = model.SESSION_COOKIE_SAME_SITE_NONE
+		})
+		defer th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.SessionCookieSameSite = model.SESSION_COOKIE_SAME_SITE_UNSET
+		})
+
+		header := setCookie("http")
+		assert.Contains(t, header, "; Secure")
+		assert.True(t, strings.HasSuffix(header, "; SameSite=None"), header)
+	})
+
+	t.Run("domain", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.SiteURL = "https://chat.example.com"
+			*cfg.ServiceSettings.AllowCookiesForSubdomains = true
+		})
+
+		assert.Equal(t, "chat.example.com", newCookie("https").Domain)
+
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.SessionCookieDomain = "example.com"
+		})
+
+		assert.Equal(t, "example.com", newCookie("https").Domain)
+	})
+}
+
 func TestEnsureInstallationDate(t *testing.T) {
 	th := Setup()
 	defer th.TearDown()
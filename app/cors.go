@@ -0,0 +1,286 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+// corsHandler applies the policy in CorsSettings to requests made to the API. The policy is rebuilt whenever the
+// config changes, so that changing it doesn't require a restart.
+type corsHandler struct {
+	// policy holds a *corsPolicy, which is nil while no origins are allowed.
+	policy atomic.Value
+
+	// apiPrefix holds the path under which the API is served, including the subpath of the SiteURL.
+	apiPrefix atomic.Value
+
+	handler http.Handler
+}
+
+func (s *Server) newCorsHandler(handler http.Handler) *corsHandler {
+	h := &corsHandler{
+		handler: handler,
+	}
+	h.update(s.Config(), s.Log)
+
+	s.corsListenerId = s.AddConfigListener(func(oldConfig *model.Config, newConfig *model.Config) {
+		if !reflect.DeepEqual(oldConfig.CorsSettings, newConfig.CorsSettings) || *oldConfig.ServiceSettings.SiteURL != *newConfig.ServiceSettings.SiteURL {
+			h.update(newConfig, s.Log)
+		}
+	})
+
+	return h
+}
+
+func (h *corsHandler) update(config *model.Config, logger *mlog.Logger) {
+	subpath, _ := utils.GetSubpathFromConfig(config)
+	h.apiPrefix.Store(path.Join(subpath, "api") + "/")
+	h.policy.Store(newCorsPolicy(&config.CorsSettings, logger))
+}
+
+func (h *corsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	policy := h.policy.Load().(*corsPolicy)
+	if policy == nil || !strings.HasPrefix(r.URL.Path, h.apiPrefix.Load().(string)) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	// Preflight requests are answered here without being passed on, since they don't carry any credentials and the
+	// routes of the API don't accept OPTIONS requests
+	if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+		policy.handlePreflight(w, r)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	policy.handleRequest(w, r)
+	h.handler.ServeHTTP(w, r)
+}
+
+// corsPolicy decides which cross-origin requests are allowed. Origins that aren't allowed get no CORS headers at all,
+// and credentials are only ever allowed for origins that are listed exactly, rather than matched by a wildcard.
+type corsPolicy struct {
+	allowAllOrigins  bool
+	exactOrigins     map[string]bool
+	wildcardOrigins  []string
+	allowedMethods   []string
+	allowAllHeaders  bool
+	allowedHeaders   map[string]bool
+	exposedHeaders   []string
+	allowCredentials bool
+	maxAgeSeconds    int
+
+	// logger is only set when CorsSettings.Debug is, to log why requests weren't allowed.
+	logger *mlog.Logger
+}
+
+type corsOriginMatch int
+
+const (
+	corsOriginNotAllowed corsOriginMatch = iota
+	corsOriginAllowedByAll
+	corsOriginAllowedByWildcard
+	corsOriginAllowedExactly
+)
+
+func newCorsPolicy(settings *model.CorsSettings, logger *mlog.Logger) *corsPolicy {
+	if len(settings.AllowedOrigins) == 0 {
+		return nil
+	}
+
+	policy := &corsPolicy{
+		exactOrigins:     map[string]bool{},
+		allowedHeaders:   map[string]bool{},
+		allowCredentials: *settings.AllowCredentials,
+		maxAgeSeconds:    *settings.MaxAgeSeconds,
+	}
+
+	for _, origin := range settings.AllowedOrigins {
+		origin = strings.ToLower(origin)
+		if origin == "*" {
+			policy.allowAllOrigins = true
+		} else if strings.Contains(origin, "*") {
+			policy.wildcardOrigins = append(policy.wildcardOrigins, origin)
+		} else {
+			policy.exactOrigins[origin] = true
+		}
+	}
+
+	for _, method := range settings.AllowedMethods {
+		policy.allowedMethods = append(policy.allowedMethods, strings.ToUpper(method))
+	}
+
+	for _, header := range settings.AllowedHeaders {
+		if header == "*" {
+			policy.allowAllHeaders = true
+		} else {
+			policy.allowedHeaders[http.CanonicalHeaderKey(header)] = true
+		}
+	}
+
+	for _, header := range settings.ExposedHeaders {
+		policy.exposedHeaders = append(policy.exposedHeaders, http.CanonicalHeaderKey(header))
+	}
+
+	if *settings.Debug && logger != nil {
+		policy.logger = logger.With(mlog.String("source", "cors"))
+	}
+
+	return policy
+}
+
+// matchOrigin returns how requests from an origin are allowed, if at all.
+func (p *corsPolicy) matchOrigin(origin string) corsOriginMatch {
+	if origin == "" {
+		return corsOriginNotAllowed
+	}
+
+	origin = strings.ToLower(origin)
+	if p.exactOrigins[origin] {
+		return corsOriginAllowedExactly
+	}
+
+	for _, wildcardOrigin := range p.wildcardOrigins {
+		if utils.MatchWildcardOrigin(wildcardOrigin, origin) {
+			return corsOriginAllowedByWildcard
+		}
+	}
+
+	if p.allowAllOrigins {
+		return corsOriginAllowedByAll
+	}
+	return corsOriginNotAllowed
+}
+
+func (p *corsPolicy) isMethodAllowed(method string) bool {
+	// Preflight requests are always allowed, since they're how clients learn about the policy
+	if method == http.MethodOptions {
+		return true
+	}
+
+	for _, allowedMethod := range p.allowedMethods {
+		if method == allowedMethod {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowedRequestHeaders returns the canonical names of the headers that a preflight request asked to send, or false if
+// any of them aren't allowed.
+func (p *corsPolicy) allowedRequestHeaders(r *http.Request) ([]string, bool) {
+	var headers []string
+	for _, value := range r.Header["Access-Control-Request-Headers"] {
+		for _, header := range strings.Split(value, ",") {
+			if header = strings.TrimSpace(header); header == "" {
+				continue
+			}
+
+			header = http.CanonicalHeaderKey(header)
+			if !p.allowAllHeaders && !p.allowedHeaders[header] {
+				return nil, false
+			}
+			headers = append(headers, header)
+		}
+	}
+
+	return headers, true
+}
+
+// setAllowOrigin allows a request from an origin, which is echoed back unless it's only allowed through "*".
+func (p *corsPolicy) setAllowOrigin(w http.ResponseWriter, origin string, match corsOriginMatch) {
+	if match == corsOriginAllowedByAll {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if match == corsOriginAllowedExactly && p.allowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (p *corsPolicy) handlePreflight(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Origin")
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	origin := r.Header.Get("Origin")
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+
+	match := p.matchOrigin(origin)
+	if match == corsOriginNotAllowed {
+		p.logDenied("Preflight request from an origin that isn't allowed", r)
+		return
+	}
+
+	if !p.isMethodAllowed(method) {
+		p.logDenied("Preflight request for a method that isn't allowed", r)
+		return
+	}
+
+	headers, ok := p.allowedRequestHeaders(r)
+	if !ok {
+		p.logDenied("Preflight request with headers that aren't allowed", r)
+		return
+	}
+
+	p.setAllowOrigin(w, origin, match)
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(p.allowedMethods, ", "))
+	if len(headers) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	}
+	if p.maxAgeSeconds > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(p.maxAgeSeconds))
+	}
+}
+
+func (p *corsPolicy) handleRequest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Origin")
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+
+	match := p.matchOrigin(origin)
+	if match == corsOriginNotAllowed {
+		p.logDenied("Request from an origin that isn't allowed", r)
+		return
+	}
+
+	if !p.isMethodAllowed(r.Method) {
+		p.logDenied("Request with a method that isn't allowed", r)
+		return
+	}
+
+	p.setAllowOrigin(w, origin, match)
+	if len(p.exposedHeaders) > 0 {
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(p.exposedHeaders, ", "))
+	}
+}
+
+func (p *corsPolicy) logDenied(message string, r *http.Request) {
+	if p.logger == nil {
+		return
+	}
+
+	p.logger.Info(message,
+		mlog.String("origin", r.Header.Get("Origin")),
+		mlog.String("method", r.Method),
+		mlog.String("request_method", r.Header.Get("Access-Control-Request-Method")),
+		mlog.String("request_headers", r.Header.Get("Access-Control-Request-Headers")),
+	)
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestCorsPolicy(t *testing.T) {
+	newPolicy := func(allowedOrigins ...string) *corsPolicy {
+		settings := model.CorsSettings{AllowedOrigins: allowedOrigins}
+		settings.SetDefaults(&model.ServiceSettings{})
+		*settings.AllowCredentials = true
+		return newCorsPolicy(&settings, nil)
+	}
+
+	request := func(policy *corsPolicy, origin string) http.Header {
+		r := httptest.NewRequest("GET", "/api/v4/users/me", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		policy.handleRequest(w, r)
+		return w.Header()
+	}
+
+	assert.Nil(t, newPolicy())
+
+	t.Run("exact origins", func(t *testing.T) {
+		policy := newPolicy("https://partner.example.com")
+
+		header := request(policy, "https://partner.example.com")
+		assert.Equal(t, "https://partner.example.com", header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", header.Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, []string{"Origin"}, header["Vary"])
+
+		header = request(policy, "HTTPS://Partner.Example.com")
+		assert.Equal(t, "HTTPS://Partner.Example.com", header.Get("Access-Control-Allow-Origin"))
+
+		header = request(policy, "https://other.example.com")
+		assert.Empty(t, header.Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, header.Get("Access-Control-Allow-Credentials"))
+
+		header = request(policy, "")
+		assert.Empty(t, header.Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("wildcard subdomains", func(t *testing.T) {
+		policy := newPolicy("https://*.example.com")
+
+		header := request(policy, "https://a.b.example.com")
+		assert.Equal(t, "https://a.b.example.com", header.Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, header.Get("Access-Control-Allow-Credentials"))
+
+		for _, origin := range []string{"https://example.com", "https://.example.com", "http://a.example.com", "https://example.com.evil.com", "https://evil-example.com", "https://a.b/c.example.com"} {
+			header = request(policy, origin)
+			assert.Empty(t, header.Get("Access-Control-Allow-Origin"), origin)
+		}
+	})
+
+	t.Run("all origins", func(t *testing.T) {
+		policy := newPolicy("*", "https://partner.example.com")
+
+		header := request(policy, "https://anywhere.example.org")
+		assert.Equal(t, "*", header.Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, header.Get("Access-Control-Allow-Credentials"))
+
+		header = request(policy, "https://partner.example.com")
+		assert.Equal(t, "https://partner.example.com", header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", header.Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		settings := model.CorsSettings{
+			AllowedOrigins: []string{"https://partner.example.com"},
+			AllowedMethods: []string{"GET", "POST"},
+			AllowedHeaders: []string{"x-requested-with", "Content-Type"},
+		}
+		settings.SetDefaults(&model.ServiceSettings{})
+		*settings.MaxAgeSeconds = 600
+		policy := newCorsPolicy(&settings, nil)
+
+		preflight := func(origin, method, headers string) http.Header {
+			r := httptest.NewRequest("OPTIONS", "/api/v4/users/login", nil)
+			r.Header.Set("Origin", origin)
+			r.Header.Set("Access-Control-Request-Method", method)
+			if headers != "" {
+				r.Header.Set("Access-Control-Request-Headers", headers)
+			}
+			w := httptest.NewRecorder()
+			policy.handlePreflight(w, r)
+			return w.Header()
+		}
+
+		header := preflight("https://partner.example.com", "post", "X-Requested-With, content-type")
+		assert.Equal(t, "https://partner.example.com", header.Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST", header.Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "X-Requested-With, Content-Type", header.Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", header.Get("Access-Control-Max-Age"))
+		assert.Empty(t, header.Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, header["Vary"])
+
+		for _, tc := range [][]string{
+			{"https://evil.example.com", "POST", ""},
+			{"https://partner.example.com", "DELETE", ""},
+			{"https://partner.example.com", "POST", "X-Requested-With, X-Other"},
+		} {
+			header = preflight(tc[0], tc[1], tc[2])
+			assert.Empty(t, header.Get("Access-Control-Allow-Origin"), tc)
+			assert.Empty(t, header.Get("Access-Control-Allow-Methods"), tc)
+			assert.Empty(t, header.Get("Access-Control-Max-Age"), tc)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"strconv"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// COUNT_REPAIR_BATCH_SIZE is the number of channels whose counts are checked at once. Each channel is repaired on its
+// own, and is only locked while its own count is corrected, so that posting in it is held up as little as possible.
+const COUNT_REPAIR_BATCH_SIZE = 100
+
+// CreateCountRepairJob creates a job that checks and, unless the request is a dry run, repairs the message counts of
+// the channels in the scope of the request. See RepairCounts.
+func (a *App) CreateCountRepairJob(request *model.CountRepairRequest, userId string) (*model.Job, *model.AppError) {
+	if request.ChannelId != "" {
+		if _, err := a.GetChannel(request.ChannelId); err != nil {
+			return nil, err
+		}
+	} else if request.TeamId != "" {
+		if _, err := a.GetTeam(request.TeamId); err != nil {
+			return nil, err
+		}
+	}
+
+	data := map[string]string{
+		"team_id":    request.TeamId,
+		"channel_id": request.ChannelId,
+		"dry_run":    strconv.FormatBool(request.DryRun),
+		"user_id":    userId,
+	}
+
+	return a.Srv.Jobs.CreateJob(model.JOB_TYPE_COUNT_REPAIR, data)
+}
+
+// RepairCounts checks the message counts of the channels in the scope of the request, and those of their members,
+// against the posts that they count, COUNT_REPAIR_BATCH_SIZE channels at a time. Every channel with counts that are
+// wrong is logged, and unless the request is a dry run, its counts are corrected. After each batch, onProgress is
+// called with a checkpoint of the repair so far, and the repair is aborted if it returns an error. Passing the last
+// checkpoint back in resumes an interrupted repair.
+func (a *App) RepairCounts(request *model.CountRepairRequest, checkpoint *model.CountRepairCheckpoint, onProgress func(checkpoint *model.CountRepairCheckpoint) *model.AppError) (*model.CountRepairCheckpoint, *model.AppError) {
+	if checkpoint == nil {
+		checkpoint = &model.CountRepairCheckpoint{}
+	}
+
+	for {
+		result := <-a.Srv.Store.Channel().GetMsgCountsForRepair(request.TeamId, request.ChannelId, checkpoint.AfterId, COUNT_REPAIR_BATCH_SIZE)
+		if result.Err != nil {
+			return checkpoint, result.Err
+		}
+		batch := result.Data.([]*model.ChannelMsgCounts)
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, counts := range batch {
+			checkpoint.Checked++
+			if counts.IsConsistent() {
+				continue
+			}
+
+			if counts.TotalMsgCount != counts.ActualMsgCount {
+				checkpoint.MsgCountDiscrepancies++
+			}
+			if counts.InvalidMemberCounts > 0 {
+				checkpoint.MemberDiscrepancies++
+			}
+
+			mlog.Info("Found a channel with the wrong message counts",
+				mlog.String("channel_id", counts.ChannelId),
+				mlog.Int64("total_msg_count", counts.TotalMsgCount),
+				mlog.Int64("actual_msg_count", counts.ActualMsgCount),
+				mlog.Int64("invalid_member_counts", counts.InvalidMemberCounts),
+				mlog.Bool("dry_run", request.DryRun),
+			)
+
+			if request.DryRun {
+				continue
+			}
+
+			repaired, err := a.repairChannelMsgCounts(counts)
+			if err != nil {
+				return checkpoint, err
+			}
+
+			if repaired {
+				checkpoint.Repaired++
+			} else {
+				checkpoint.Skipped++
+			}
+		}
+
+		checkpoint.AfterId = batch[len(batch)-1].ChannelId
+		if err := onProgress(checkpoint); err != nil {
+			return checkpoint, err
+		}
+	}
+
+	return checkpoint, nil
+}
+
+// repairChannelMsgCounts corrects the message counts of a channel and its members, returning false if the channel was
+// skipped since its counts were corrected after they were checked. The unread counts of teams are worked out from those
+// of their channels, so they're corrected along with them, and the channel's members are told to fetch it again so
+// that their clients show the corrected unread counts.
+func (a *App) repairChannelMsgCounts(counts *model.ChannelMsgCounts) (bool, *model.AppError) {
+	result := <-a.Srv.Store.Channel().RepairMsgCounts(counts)
+	if result.Err != nil {
+		return false, result.Err
+	}
+
+	if !result.Data.(bool) {
+		mlog.Info("Skipped repairing the message counts of a channel that were corrected while it was being repaired", mlog.String("channel_id", counts.ChannelId))
+		return false, nil
+	}
+
+	// The store only invalidates the channel on this server
+	if channel, err := a.GetChannel(counts.ChannelId); err == nil {
+		a.InvalidateCacheForChannel(channel)
+
+		message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_CHANNEL_UPDATED, "", channel.Id, "", nil)
+		message.Add("channel", channel.ToJson())
+		a.Publish(message)
+	}
+
+	return true, nil
+}
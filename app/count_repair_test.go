@@ -0,0 +1,88 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/store"
+)
+
+func TestRepairCounts(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	channel := th.CreateChannel(th.BasicTeam)
+	th.CreatePost(channel)
+	th.CreatePost(channel)
+
+	drifted := store.Must(th.App.Srv.Store.Channel().Get(channel.Id, false)).(*model.Channel)
+	actualMsgCount := drifted.TotalMsgCount
+	drifted.TotalMsgCount += 3
+	store.Must(th.App.Srv.Store.Channel().Update(drifted))
+
+	noProgress := func(*model.CountRepairCheckpoint) *model.AppError { return nil }
+
+	t.Run("dry run", func(t *testing.T) {
+		checkpoint, err := th.App.RepairCounts(&model.CountRepairRequest{TeamId: th.BasicTeam.Id, DryRun: true}, nil, noProgress)
+		require.Nil(t, err)
+		assert.True(t, checkpoint.Checked >= 2)
+		assert.True(t, checkpoint.MsgCountDiscrepancies >= 1)
+		assert.Equal(t, int64(0), checkpoint.Repaired)
+
+		unchanged := store.Must(th.App.Srv.Store.Channel().Get(channel.Id, false)).(*model.Channel)
+		assert.Equal(t, actualMsgCount+3, unchanged.TotalMsgCount)
+	})
+
+	t.Run("repair a channel", func(t *testing.T) {
+		var checkpoints []*model.CountRepairCheckpoint
+		checkpoint, err := th.App.RepairCounts(&model.CountRepairRequest{ChannelId: channel.Id}, nil, func(checkpoint *model.CountRepairCheckpoint) *model.AppError {
+			copied := *checkpoint
+			checkpoints = append(checkpoints, &copied)
+			return nil
+		})
+		require.Nil(t, err)
+		require.Len(t, checkpoints, 1)
+		assert.Equal(t, channel.Id, checkpoint.AfterId)
+		assert.Equal(t, int64(1), checkpoint.Checked)
+		assert.Equal(t, int64(1), checkpoint.Repaired)
+
+		repaired, err := th.App.GetChannel(channel.Id)
+		require.Nil(t, err)
+		assert.Equal(t, actualMsgCount, repaired.TotalMsgCount)
+
+		checkpoint, err = th.App.RepairCounts(&model.CountRepairRequest{ChannelId: channel.Id}, nil, noProgress)
+		require.Nil(t, err)
+		assert.Equal(t, int64(0), checkpoint.MsgCountDiscrepancies)
+		assert.Equal(t, int64(0), checkpoint.Repaired)
+	})
+
+	t.Run("stops when onProgress fails", func(t *testing.T) {
+		_, err := th.App.RepairCounts(&model.CountRepairRequest{TeamId: th.BasicTeam.Id}, nil, func(*model.CountRepairCheckpoint) *model.AppError {
+			return model.NewAppError("test", "test", nil, "", http.StatusOK)
+		})
+		require.NotNil(t, err)
+		assert.Equal(t, "test", err.Id)
+	})
+}
+
+func TestCreateCountRepairJob(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	_, err := th.App.CreateCountRepairJob(&model.CountRepairRequest{ChannelId: model.NewId()}, th.SystemAdminUser.Id)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusNotFound, err.StatusCode)
+
+	job, err := th.App.CreateCountRepairJob(&model.CountRepairRequest{TeamId: th.BasicTeam.Id, DryRun: true}, th.SystemAdminUser.Id)
+	require.Nil(t, err)
+	assert.Equal(t, model.JOB_TYPE_COUNT_REPAIR, job.Type)
+	assert.Equal(t, th.BasicTeam.Id, job.Data["team_id"])
+	assert.Equal(t, "true", job.Data["dry_run"])
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// SetCsrfCookie sends the CSRF token of the session to the browser. Unlike the session cookie, it must be readable
+// by the webapp so that it can be echoed back in the X-CSRF-Token header.
+func (a *App) SetCsrfCookie(w http.ResponseWriter, r *http.Request, session *model.Session) {
+	cookie := &http.Cookie{
+		Name:    model.SESSION_COOKIE_CSRF,
+		Value:   session.GetCSRF(),
+		Path:    "/",
+		Expires: time.Unix(session.ExpiresAt/1000, 0),
+	}
+	if session.ExpiresAt > 0 {
+		cookie.MaxAge = int((session.ExpiresAt - model.GetMillis()) / 1000)
+	}
+	a.SetSessionCookie(w, r, cookie)
+}
+
+// IsCsrfTokenRotationDue returns whether the CSRF token of the session is older than the configured rotation interval.
+func (a *App) IsCsrfTokenRotationDue(session *model.Session) bool {
+	if session.GetCSRF() == "" {
+		return true
+	}
+
+	rotationMinutes := *a.Config().ServiceSettings.CsrfTokenRotationMinutes
+	if rotationMinutes <= 0 {
+		return false
+	}
+
+	return model.GetMillis()-session.GetCSRFIssuedAt() >= int64(rotationMinutes)*60*1000
+}
+
+// RefreshCsrfToken rotates the CSRF token of a cookie-authenticated session when it is due, and makes sure that the
+// browser has a cookie holding the current token.
+func (a *App) RefreshCsrfToken(w http.ResponseWriter, r *http.Request, session *model.Session) {
+	if a.IsCsrfTokenRotationDue(session) {
+		// The cached session is shared between requests, so the new token is generated on a copy
+		rotated := *session
+		rotated.Props = model.CopyStringMap(session.Props)
+		rotated.GenerateCSRF()
+
+		if result := <-a.Srv.Store.Session().UpdateProps(&rotated); result.Err != nil {
+			mlog.Error("Failed to rotate the CSRF token of a session", mlog.String("session_id", session.Id), mlog.String("error", result.Err.Error()))
+			return
+		}
+
+		a.ClearSessionCacheForUser(session.UserId)
+		a.AddSessionToCache(&rotated)
+		*session = rotated
+	}
+
+	if cookie, err := r.Cookie(model.SESSION_COOKIE_CSRF); err != nil || cookie.Value != session.GetCSRF() {
+		a.SetCsrfCookie(w, r, session)
+	}
+}
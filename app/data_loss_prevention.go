@@ -0,0 +1,35 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"reflect"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// initDataLossPreventionScanner compiles the data loss prevention patterns from the config, and compiles them again
+// whenever DataLossPreventionSettings change, so that messages aren't checked against patterns compiled for each one.
+func (s *Server) initDataLossPreventionScanner() {
+	s.updateDataLossPreventionScanner(&s.Config().DataLossPreventionSettings)
+
+	s.dataLossPreventionListenerId = s.AddConfigListener(func(oldConfig *model.Config, newConfig *model.Config) {
+		if !reflect.DeepEqual(oldConfig.DataLossPreventionSettings, newConfig.DataLossPreventionSettings) {
+			s.updateDataLossPreventionScanner(&newConfig.DataLossPreventionSettings)
+		}
+	})
+}
+
+func (s *Server) updateDataLossPreventionScanner(settings *model.DataLossPreventionSettings) {
+	var scanner *model.DataLossPreventionScanner
+	if *settings.Enable {
+		var err error
+		if scanner, err = model.NewDataLossPreventionScanner(settings); err != nil {
+			mlog.Error("Unable to compile the data loss prevention patterns.", mlog.Err(err))
+		}
+	}
+
+	s.dataLossPreventionScanner.Store(scanner)
+}
@@ -22,6 +22,7 @@ const (
 	TRACK_CONFIG_SQL                = "config_sql"
 	TRACK_CONFIG_LOG                = "config_log"
 	TRACK_CONFIG_FILE               = "config_file"
+	TRACK_CONFIG_COLD_STORAGE       = "config_cold_storage"
 	TRACK_CONFIG_RATE               = "config_rate"
 	TRACK_CONFIG_EMAIL              = "config_email"
 	TRACK_CONFIG_PRIVACY            = "config_privacy"
@@ -46,6 +47,7 @@ const (
 	TRACK_CONFIG_DISPLAY            = "config_display"
 	TRACK_CONFIG_TIMEZONE           = "config_timezone"
 	TRACK_CONFIG_IMAGE_PROXY        = "config_image_proxy"
+	TRACK_CONFIG_CORS               = "config_cors"
 	TRACK_PERMISSIONS_GENERAL       = "permissions_general"
 	TRACK_PERMISSIONS_SYSTEM_SCHEME = "permissions_system_scheme"
 	TRACK_PERMISSIONS_TEAM_SCHEMES  = "permissions_team_schemes"
@@ -255,10 +257,6 @@ func (a *App) trackConfig() {
 		"isdefault_read_timeout":                                  isDefault(*cfg.ServiceSettings.ReadTimeout, model.SERVICE_SETTINGS_DEFAULT_READ_TIMEOUT),
 		"isdefault_write_timeout":                                 isDefault(*cfg.ServiceSettings.WriteTimeout, model.SERVICE_SETTINGS_DEFAULT_WRITE_TIMEOUT),
 		"isdefault_google_developer_key":                          isDefault(cfg.ServiceSettings.GoogleDeveloperKey, ""),
-		"isdefault_allow_cors_from":                               isDefault(*cfg.ServiceSettings.AllowCorsFrom, model.SERVICE_SETTINGS_DEFAULT_ALLOW_CORS_FROM),
-		"isdefault_cors_exposed_headers":                          isDefault(cfg.ServiceSettings.CorsExposedHeaders, ""),
-		"cors_allow_credentials":                                  *cfg.ServiceSettings.CorsAllowCredentials,
-		"cors_debug":                                              *cfg.ServiceSettings.CorsDebug,
 		"isdefault_allowed_untrusted_internal_connections":        isDefault(*cfg.ServiceSettings.AllowedUntrustedInternalConnections, ""),
 		"restrict_post_delete":                                    *cfg.ServiceSettings.DEPRECATED_DO_NOT_USE_RestrictPostDelete,
 		"allow_edit_post":                                         *cfg.ServiceSettings.DEPRECATED_DO_NOT_USE_AllowEditPost,
@@ -268,6 +266,8 @@ func (a *App) trackConfig() {
 		"time_between_user_typing_updates_milliseconds":           *cfg.ServiceSettings.TimeBetweenUserTypingUpdatesMilliseconds,
 		"cluster_log_timeout_milliseconds":                        *cfg.ServiceSettings.ClusterLogTimeoutMilliseconds,
 		"enable_post_search":                                      *cfg.ServiceSettings.EnablePostSearch,
+		"search_export_max_results":                               *cfg.ServiceSettings.SearchExportMaxResults,
+		"search_default_lookback_days":                            *cfg.ServiceSettings.SearchDefaultLookbackDays,
 		"enable_user_statuses":                                    *cfg.ServiceSettings.EnableUserStatuses,
 		"close_unused_direct_messages":                            *cfg.ServiceSettings.CloseUnusedDirectMessages,
 		"enable_preview_features":                                 *cfg.ServiceSettings.EnablePreviewFeatures,
@@ -276,6 +276,13 @@ func (a *App) trackConfig() {
 		"experimental_group_unread_channels":                      *cfg.ServiceSettings.ExperimentalGroupUnreadChannels,
 		"websocket_url":                                           isDefault(*cfg.ServiceSettings.WebsocketURL, ""),
 		"allow_cookies_for_subdomains":                            *cfg.ServiceSettings.AllowCookiesForSubdomains,
+		"session_cookie_same_site":                                *cfg.ServiceSettings.SessionCookieSameSite,
+		"isdefault_session_cookie_domain":                         isDefault(*cfg.ServiceSettings.SessionCookieDomain, ""),
+		"session_cookie_secure":                                   *cfg.ServiceSettings.SessionCookieSecure,
+		"csrf_protection_mode":                                    *cfg.ServiceSettings.CsrfProtectionMode,
+		"csrf_token_rotation_minutes":                             *cfg.ServiceSettings.CsrfTokenRotationMinutes,
+		"enable_response_compression":                             *cfg.ServiceSettings.EnableResponseCompression,
+		"response_compression_min_size":                           *cfg.ServiceSettings.ResponseCompressionMinSize,
 		"enable_api_team_deletion":                                *cfg.ServiceSettings.EnableAPITeamDeletion,
 		"experimental_enable_hardened_mode":                       *cfg.ServiceSettings.ExperimentalEnableHardenedMode,
 		"enable_email_invitations":                                *cfg.ServiceSettings.EnableEmailInvitations,
@@ -356,18 +363,31 @@ func (a *App) trackConfig() {
 	})
 
 	a.SendDiagnostic(TRACK_CONFIG_FILE, map[string]interface{}{
-		"enable_public_links":     cfg.FileSettings.EnablePublicLink,
-		"driver_name":             *cfg.FileSettings.DriverName,
-		"isdefault_directory":     isDefault(cfg.FileSettings.Directory, model.FILE_SETTINGS_DEFAULT_DIRECTORY),
-		"isabsolute_directory":    filepath.IsAbs(cfg.FileSettings.Directory),
-		"amazon_s3_ssl":           *cfg.FileSettings.AmazonS3SSL,
-		"amazon_s3_sse":           *cfg.FileSettings.AmazonS3SSE,
-		"amazon_s3_signv2":        *cfg.FileSettings.AmazonS3SignV2,
-		"amazon_s3_trace":         *cfg.FileSettings.AmazonS3Trace,
-		"max_file_size":           *cfg.FileSettings.MaxFileSize,
-		"enable_file_attachments": *cfg.FileSettings.EnableFileAttachments,
-		"enable_mobile_upload":    *cfg.FileSettings.EnableMobileUpload,
-		"enable_mobile_download":  *cfg.FileSettings.EnableMobileDownload,
+		"enable_public_links":        cfg.FileSettings.EnablePublicLink,
+		"driver_name":                *cfg.FileSettings.DriverName,
+		"isdefault_directory":        isDefault(cfg.FileSettings.Directory, model.FILE_SETTINGS_DEFAULT_DIRECTORY),
+		"isabsolute_directory":       filepath.IsAbs(cfg.FileSettings.Directory),
+		"amazon_s3_ssl":              *cfg.FileSettings.AmazonS3SSL,
+		"amazon_s3_sse":              *cfg.FileSettings.AmazonS3SSE,
+		"amazon_s3_signv2":           *cfg.FileSettings.AmazonS3SignV2,
+		"amazon_s3_trace":            *cfg.FileSettings.AmazonS3Trace,
+		"max_file_size":              *cfg.FileSettings.MaxFileSize,
+		"enable_file_attachments":    *cfg.FileSettings.EnableFileAttachments,
+		"enable_mobile_upload":       *cfg.FileSettings.EnableMobileUpload,
+		"enable_mobile_download":     *cfg.FileSettings.EnableMobileDownload,
+		"enable_svg_uploads":         *cfg.FileSettings.EnableSvgUploads,
+		"allowed_upload_file_types":  len(cfg.FileSettings.AllowedUploadFileTypes),
+		"denied_upload_file_types":   len(cfg.FileSettings.DeniedUploadFileTypes),
+		"max_file_size_by_type":      len(cfg.FileSettings.MaxFileSizeByType),
+		"verify_checksum_file_types": len(cfg.FileSettings.VerifyChecksumFileTypes),
+	})
+
+	a.SendDiagnostic(TRACK_CONFIG_COLD_STORAGE, map[string]interface{}{
+		"enable":            *cfg.ColdStorageSettings.Enable,
+		"min_file_age_days": *cfg.ColdStorageSettings.MinFileAgeDays,
+		"batch_size":        *cfg.ColdStorageSettings.BatchSize,
+		"driver_name":       *cfg.ColdStorageSettings.DriverName,
+		"amazon_s3_ssl":     *cfg.ColdStorageSettings.AmazonS3SSL,
 	})
 
 	a.SendDiagnostic(TRACK_CONFIG_EMAIL, map[string]interface{}{
@@ -399,6 +419,7 @@ func (a *App) trackConfig() {
 		"enable_rate_limiter":      *cfg.RateLimitSettings.Enable,
 		"vary_by_remote_address":   *cfg.RateLimitSettings.VaryByRemoteAddr,
 		"vary_by_user":             *cfg.RateLimitSettings.VaryByUser,
+		"per_user":                 *cfg.RateLimitSettings.PerUser,
 		"per_sec":                  *cfg.RateLimitSettings.PerSec,
 		"max_burst":                *cfg.RateLimitSettings.MaxBurst,
 		"memory_store_size":        *cfg.RateLimitSettings.MemoryStoreSize,
@@ -586,6 +607,16 @@ func (a *App) trackConfig() {
 		"isdefault_remote_image_proxy_url":     isDefault(*cfg.ImageProxySettings.RemoteImageProxyURL, ""),
 		"isdefault_remote_image_proxy_options": isDefault(*cfg.ImageProxySettings.RemoteImageProxyOptions, ""),
 	})
+
+	a.SendDiagnostic(TRACK_CONFIG_CORS, map[string]interface{}{
+		"allowed_origins":           len(cfg.CorsSettings.AllowedOrigins),
+		"allowed_methods":           len(cfg.CorsSettings.AllowedMethods),
+		"isdefault_allowed_headers": len(cfg.CorsSettings.AllowedHeaders) == 1 && cfg.CorsSettings.AllowedHeaders[0] == "*",
+		"exposed_headers":           len(cfg.CorsSettings.ExposedHeaders),
+		"allow_credentials":         *cfg.CorsSettings.AllowCredentials,
+		"max_age_seconds":           *cfg.CorsSettings.MaxAgeSeconds,
+		"debug":                     *cfg.CorsSettings.Debug,
+	})
 }
 
 func (a *App) trackLicense() {
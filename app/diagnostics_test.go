@@ -121,6 +121,7 @@ func TestDiagnostics(t *testing.T) {
 			TRACK_CONFIG_SQL,
 			TRACK_CONFIG_LOG,
 			TRACK_CONFIG_FILE,
+			TRACK_CONFIG_COLD_STORAGE,
 			TRACK_CONFIG_RATE,
 			TRACK_CONFIG_EMAIL,
 			TRACK_CONFIG_PRIVACY,
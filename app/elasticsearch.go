@@ -5,10 +5,14 @@ package app
 
 import (
 	"net/http"
+	"sort"
 
+	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/model"
 )
 
+const ELASTICSEARCH_REINDEX_BATCH_SIZE = 500
+
 func (a *App) TestElasticsearch(cfg *model.Config) *model.AppError {
 	if *cfg.ElasticsearchSettings.Password == model.FAKE_SETTING {
 		if *cfg.ElasticsearchSettings.ConnectionUrl == *a.Config().ElasticsearchSettings.ConnectionUrl && *cfg.ElasticsearchSettings.Username == *a.Config().ElasticsearchSettings.Username {
@@ -43,3 +47,128 @@ func (a *App) PurgeElasticsearchIndexes() *model.AppError {
 
 	return nil
 }
+
+// CreateElasticsearchReindexJob enqueues a job that reindexes the posts of a single channel, or of every channel of
+// a team, without rebuilding the rest of the index. Exactly one of teamId and channelId must be given.
+func (a *App) CreateElasticsearchReindexJob(teamId string, channelId string) (*model.Job, *model.AppError) {
+	if a.Elasticsearch == nil {
+		return nil, model.NewAppError("CreateElasticsearchReindexJob", "ent.elasticsearch.test_config.license.error", nil, "", http.StatusNotImplemented)
+	}
+
+	if !*a.Config().ElasticsearchSettings.EnableIndexing {
+		return nil, model.NewAppError("CreateElasticsearchReindexJob", "app.elasticsearch.reindex.indexing_disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	data := map[string]string{}
+	if teamId != "" && channelId == "" {
+		if _, err := a.GetTeam(teamId); err != nil {
+			return nil, err
+		}
+		data["team_id"] = teamId
+	} else if channelId != "" && teamId == "" {
+		if _, err := a.GetChannel(channelId); err != nil {
+			return nil, err
+		}
+		data["channel_id"] = channelId
+	} else {
+		return nil, model.NewAppError("CreateElasticsearchReindexJob", "app.elasticsearch.reindex.scope.app_error", nil, "team_id="+teamId+", channel_id="+channelId, http.StatusBadRequest)
+	}
+
+	return a.Srv.Jobs.CreateJob(model.JOB_TYPE_ELASTICSEARCH_SCOPED_REINDEX, data)
+}
+
+// ReindexElasticsearchPosts indexes every post of the channel, or of every channel of the team, again and removes the
+// deleted ones from the index. After each batch, onProgress is called with a checkpoint of the reindex so far and an
+// estimate of the total number of posts, and the reindex is aborted if it returns an error. Passing the last
+// checkpoint back in resumes an interrupted reindex. Posts are read in small batches from the search replica so that
+// it's safe to run while the server is in use.
+func (a *App) ReindexElasticsearchPosts(teamId string, channelId string, checkpoint *model.ElasticsearchReindexCheckpoint, onProgress func(checkpoint *model.ElasticsearchReindexCheckpoint, total int64) *model.AppError) (int64, *model.AppError) {
+	esI := a.Elasticsearch
+	if esI == nil {
+		return 0, model.NewAppError("ReindexElasticsearchPosts", "ent.elasticsearch.test_config.license.error", nil, "", http.StatusNotImplemented)
+	}
+
+	var channels []*model.Channel
+	if channelId != "" {
+		channel, err := a.GetChannel(channelId)
+		if err != nil {
+			return 0, err
+		}
+		channels = append(channels, channel)
+	} else {
+		result := <-a.Srv.Store.Channel().GetTeamChannels(teamId)
+		if result.Err != nil && result.Err.StatusCode != http.StatusNotFound {
+			return 0, result.Err
+		} else if result.Err == nil {
+			channels = *result.Data.(*model.ChannelList)
+		}
+	}
+
+	// Channels are reindexed in a stable order so that a checkpoint can tell which ones are done.
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].Id < channels[j].Id
+	})
+
+	if checkpoint == nil {
+		checkpoint = &model.ElasticsearchReindexCheckpoint{}
+	}
+
+	var total int64
+	for _, channel := range channels {
+		total += channel.TotalMsgCount
+	}
+
+	reindexed := checkpoint.Reindexed
+	for _, channel := range channels {
+		if channel.Id < checkpoint.ChannelId {
+			continue
+		}
+
+		afterCreateAt, afterId := int64(0), ""
+		if channel.Id == checkpoint.ChannelId {
+			afterCreateAt, afterId = checkpoint.AfterCreateAt, checkpoint.AfterId
+		}
+
+		for {
+			result := <-a.Srv.Store.Post().GetPostsBatchForChannelIndexing(channel.Id, afterCreateAt, afterId, ELASTICSEARCH_REINDEX_BATCH_SIZE)
+			if result.Err != nil {
+				return reindexed, result.Err
+			}
+			posts := result.Data.([]*model.Post)
+			if len(posts) == 0 {
+				break
+			}
+
+			for _, post := range posts {
+				if post.DeleteAt != 0 {
+					if err := esI.DeletePost(post); err != nil {
+						mlog.Warn("Failed to remove deleted post from the search index", mlog.String("post_id", post.Id), mlog.Err(err))
+					}
+					continue
+				}
+
+				if err := esI.IndexPost(post, channel.TeamId); err != nil {
+					return reindexed, err
+				}
+				reindexed++
+			}
+
+			last := posts[len(posts)-1]
+			afterCreateAt, afterId = last.CreateAt, last.Id
+
+			if reindexed > total {
+				total = reindexed
+			}
+			if err := onProgress(&model.ElasticsearchReindexCheckpoint{
+				ChannelId:     channel.Id,
+				AfterCreateAt: afterCreateAt,
+				AfterId:       afterId,
+				Reindexed:     reindexed,
+			}, total); err != nil {
+				return reindexed, err
+			}
+		}
+	}
+
+	return reindexed, nil
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+type fakeElasticsearch struct {
+	mutex   sync.Mutex
+	indexed map[string]string
+	deleted map[string]bool
+}
+
+func newFakeElasticsearch() *fakeElasticsearch {
+	return &fakeElasticsearch{
+		indexed: map[string]string{},
+		deleted: map[string]bool{},
+	}
+}
+
+func (es *fakeElasticsearch) Start() *model.AppError { return nil }
+func (es *fakeElasticsearch) Stop() *model.AppError  { return nil }
+
+func (es *fakeElasticsearch) IndexPost(post *model.Post, teamId string) *model.AppError {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+	es.indexed[post.Id] = teamId
+	return nil
+}
+
+func (es *fakeElasticsearch) SearchPosts(channels *model.ChannelList, searchParams []*model.SearchParams, page, perPage int) ([]string, model.PostSearchMatches, *model.AppError) {
+	return nil, nil, nil
+}
+
+func (es *fakeElasticsearch) DeletePost(post *model.Post) *model.AppError {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+	es.deleted[post.Id] = true
+	return nil
+}
+
+func (es *fakeElasticsearch) TestConfig(cfg *model.Config) *model.AppError                { return nil }
+func (es *fakeElasticsearch) PurgeIndexes() *model.AppError                               { return nil }
+func (es *fakeElasticsearch) DataRetentionDeleteIndexes(cutoff time.Time) *model.AppError { return nil }
+
+func TestCreateElasticsearchReindexJob(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	_, err := th.App.CreateElasticsearchReindexJob(th.BasicTeam.Id, "")
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusNotImplemented, err.StatusCode)
+
+	th.App.Elasticsearch = newFakeElasticsearch()
+	defer func() { th.App.Elasticsearch = nil }()
+
+	_, err = th.App.CreateElasticsearchReindexJob(th.BasicTeam.Id, "")
+	require.NotNil(t, err)
+	assert.Equal(t, "app.elasticsearch.reindex.indexing_disabled.app_error", err.Id)
+
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ElasticsearchSettings.EnableIndexing = true })
+	defer th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ElasticsearchSettings.EnableIndexing = false })
+
+	_, err = th.App.CreateElasticsearchReindexJob(th.BasicTeam.Id, th.BasicChannel.Id)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.StatusCode)
+
+	_, err = th.App.CreateElasticsearchReindexJob("", "")
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.StatusCode)
+
+	_, err = th.App.CreateElasticsearchReindexJob("", model.NewId())
+	require.NotNil(t, err)
+
+	job, err := th.App.CreateElasticsearchReindexJob("", th.BasicChannel.Id)
+	require.Nil(t, err)
+	assert.Equal(t, model.JOB_TYPE_ELASTICSEARCH_SCOPED_REINDEX, job.Type)
+	assert.Equal(t, th.BasicChannel.Id, job.Data["channel_id"])
+}
+
+func TestReindexElasticsearchPosts(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	post1 := th.CreatePost(th.BasicChannel)
+	post2 := th.CreatePost(th.BasicChannel)
+	_, err := th.App.DeletePost(post2.Id, th.BasicUser.Id)
+	require.Nil(t, err)
+	otherPost := th.CreatePost(th.CreateChannel(th.BasicTeam))
+
+	es := newFakeElasticsearch()
+	th.App.Elasticsearch = es
+	defer func() { th.App.Elasticsearch = nil }()
+
+	t.Run("channel", func(t *testing.T) {
+		var progress [][2]int64
+		reindexed, err := th.App.ReindexElasticsearchPosts("", th.BasicChannel.Id, nil, func(checkpoint *model.ElasticsearchReindexCheckpoint, total int64) *model.AppError {
+			progress = append(progress, [2]int64{checkpoint.Reindexed, total})
+			return nil
+		})
+		require.Nil(t, err)
+
+		assert.Equal(t, th.BasicTeam.Id, es.indexed[post1.Id])
+		assert.NotContains(t, es.indexed, post2.Id)
+		assert.True(t, es.deleted[post2.Id])
+		assert.NotContains(t, es.indexed, otherPost.Id)
+
+		require.NotEmpty(t, progress)
+		last := progress[len(progress)-1]
+		assert.Equal(t, reindexed, last[0])
+		assert.True(t, last[1] >= last[0])
+	})
+
+	t.Run("team", func(t *testing.T) {
+		_, err := th.App.ReindexElasticsearchPosts(th.BasicTeam.Id, "", nil, func(checkpoint *model.ElasticsearchReindexCheckpoint, total int64) *model.AppError {
+			return nil
+		})
+		require.Nil(t, err)
+
+		assert.Equal(t, th.BasicTeam.Id, es.indexed[otherPost.Id])
+	})
+
+	t.Run("aborted by progress callback", func(t *testing.T) {
+		abort := model.NewAppError("test", "test", nil, "", http.StatusOK)
+		_, err := th.App.ReindexElasticsearchPosts(th.BasicTeam.Id, "", nil, func(checkpoint *model.ElasticsearchReindexCheckpoint, total int64) *model.AppError {
+			return abort
+		})
+		assert.Equal(t, abort, err)
+	})
+
+	t.Run("resumed from a checkpoint", func(t *testing.T) {
+		resumeEs := newFakeElasticsearch()
+		th.App.Elasticsearch = resumeEs
+		defer func() { th.App.Elasticsearch = es }()
+
+		checkpoint := &model.ElasticsearchReindexCheckpoint{
+			ChannelId:     th.BasicChannel.Id,
+			AfterCreateAt: post1.CreateAt,
+			AfterId:       post1.Id,
+			Reindexed:     10,
+		}
+		reindexed, err := th.App.ReindexElasticsearchPosts("", th.BasicChannel.Id, checkpoint, func(checkpoint *model.ElasticsearchReindexCheckpoint, total int64) *model.AppError {
+			return nil
+		})
+		require.Nil(t, err)
+
+		assert.NotContains(t, resumeEs.indexed, post1.Id, "posts before the checkpoint shouldn't be indexed again")
+		assert.True(t, resumeEs.deleted[post2.Id])
+		assert.True(t, reindexed >= 10)
+	})
+}
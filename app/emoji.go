@@ -63,6 +63,7 @@ func (a *App) CreateEmoji(sessionUserId string, emoji *model.Emoji, multiPartIma
 	if result.Err != nil {
 		return nil, result.Err
 	}
+	a.InvalidateResponseCache(model.RESPONSE_CACHE_ENDPOINT_EMOJI_LIST)
 
 	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_EMOJI_ADDED, "", "", "", nil)
 	message.Add("emoji", emoji.ToJson())
@@ -147,6 +148,7 @@ func (a *App) DeleteEmoji(emoji *model.Emoji) *model.AppError {
 	if err := (<-a.Srv.Store.Emoji().Delete(emoji.Id, model.GetMillis())).Err; err != nil {
 		return err
 	}
+	a.InvalidateResponseCache(model.RESPONSE_CACHE_ENDPOINT_EMOJI_LIST)
 
 	a.deleteEmojiImage(emoji.Id)
 	a.deleteReactionsForEmoji(emoji.Name)
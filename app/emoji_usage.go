@@ -0,0 +1,134 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func (a *App) GetEmojiUsage(userId string) (model.EmojiUsageList, *model.AppError) {
+	result := <-a.Srv.Store.Preference().GetCategory(userId, model.PREFERENCE_CATEGORY_EMOJI_USAGE)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	for _, preference := range result.Data.(model.Preferences) {
+		if preference.Name == model.PREFERENCE_NAME_EMOJI_USAGE {
+			return model.EmojiUsageListFromJson(strings.NewReader(preference.Value)), nil
+		}
+	}
+
+	return model.EmojiUsageList{}, nil
+}
+
+// RecordEmojiUsage counts a use of each of the given emoji by the user so that they can be suggested first by emoji
+// autocomplete. Names that aren't system or custom emoji are ignored.
+func (a *App) RecordEmojiUsage(userId string, names []string) *model.AppError {
+	names = model.RemoveDuplicateStrings(names)
+
+	emojiNames := []string{}
+	customNames := []string{}
+	for _, name := range names {
+		if _, ok := model.SystemEmojis[name]; ok {
+			emojiNames = append(emojiNames, name)
+		} else {
+			customNames = append(customNames, name)
+		}
+	}
+
+	if len(customNames) > 0 && *a.Config().ServiceSettings.EnableCustomEmoji {
+		result := <-a.Srv.Store.Emoji().GetMultipleByName(customNames)
+		if result.Err != nil {
+			return result.Err
+		}
+
+		for _, emoji := range result.Data.([]*model.Emoji) {
+			emojiNames = append(emojiNames, emoji.Name)
+		}
+	}
+
+	if len(emojiNames) == 0 {
+		return nil
+	}
+
+	usage, err := a.GetEmojiUsage(userId)
+	if err != nil {
+		return err
+	}
+
+	preferences := model.Preferences{{
+		UserId:   userId,
+		Category: model.PREFERENCE_CATEGORY_EMOJI_USAGE,
+		Name:     model.PREFERENCE_NAME_EMOJI_USAGE,
+		Value:    usage.Record(emojiNames, model.GetMillis()).ToJson(),
+	}}
+	if result := <-a.Srv.Store.Preference().Save(&preferences); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+// AutocompleteEmoji returns up to limit system and custom emoji whose names start with the given prefix. The emoji
+// that the user uses most often and most recently come first, followed by the rest in alphabetical order.
+func (a *App) AutocompleteEmoji(userId string, prefix string, limit int) ([]*model.EmojiSuggestion, *model.AppError) {
+	usage, err := a.GetEmojiUsage(userId)
+	if err != nil {
+		return nil, err
+	}
+	scores := usage.Scores(model.GetMillis())
+
+	suggestions := map[string]*model.EmojiSuggestion{}
+	for _, name := range model.SystemEmojiNamesWithPrefix(prefix) {
+		suggestions[name] = &model.EmojiSuggestion{Name: name, Unicode: model.SystemEmojis[name]}
+	}
+
+	if *a.Config().ServiceSettings.EnableCustomEmoji {
+		result := <-a.Srv.Store.Emoji().Search(prefix, true, limit)
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		customEmoji := result.Data.([]*model.Emoji)
+
+		// Custom emoji that the user has used might not be among the first matches alphabetically.
+		usedNames := []string{}
+		for name := range scores {
+			if _, ok := suggestions[name]; !ok && strings.HasPrefix(name, prefix) {
+				usedNames = append(usedNames, name)
+			}
+		}
+		if len(usedNames) > 0 {
+			result = <-a.Srv.Store.Emoji().GetMultipleByName(usedNames)
+			if result.Err != nil {
+				return nil, result.Err
+			}
+			customEmoji = append(customEmoji, result.Data.([]*model.Emoji)...)
+		}
+
+		for _, emoji := range customEmoji {
+			suggestions[emoji.Name] = &model.EmojiSuggestion{Name: emoji.Name, EmojiId: emoji.Id}
+		}
+	}
+
+	list := make([]*model.EmojiSuggestion, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		list = append(list, suggestion)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if scores[list[i].Name] != scores[list[j].Name] {
+			return scores[list[i].Name] > scores[list[j].Name]
+		}
+		return list[i].Name < list[j].Name
+	})
+
+	if len(list) > limit {
+		list = list[:limit]
+	}
+
+	return list, nil
+}
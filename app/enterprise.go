@@ -7,6 +7,7 @@ import (
 	"github.com/mattermost/mattermost-server/einterfaces"
 	ejobs "github.com/mattermost/mattermost-server/einterfaces/jobs"
 	tjobs "github.com/mattermost/mattermost-server/jobs/interfaces"
+	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/model"
 	"github.com/mattermost/mattermost-server/utils"
 )
@@ -41,6 +42,14 @@ func RegisterElasticsearchInterface(f func(*App) einterfaces.ElasticsearchInterf
 	elasticsearchInterface = f
 }
 
+var firehoseWriterInterface func(*Server) einterfaces.FirehoseWriterInterface
+
+// RegisterFirehoseWriterInterface registers the writer used by the event firehose when its destination is custom,
+// to deliver events to somewhere like Kafka.
+func RegisterFirehoseWriterInterface(f func(*Server) einterfaces.FirehoseWriterInterface) {
+	firehoseWriterInterface = f
+}
+
 var jobsDataRetentionJobInterface func(*App) ejobs.DataRetentionJobInterface
 
 func RegisterJobsDataRetentionJobInterface(f func(*App) ejobs.DataRetentionJobInterface) {
@@ -83,6 +92,66 @@ func RegisterJobsPluginsJobInterface(f func(*App) tjobs.PluginsJobInterface) {
 	jobsPluginsInterface = f
 }
 
+var jobsExpirePinsInterface func(*App) tjobs.ExpirePinsJobInterface
+
+func RegisterJobsExpirePinsJobInterface(f func(*App) tjobs.ExpirePinsJobInterface) {
+	jobsExpirePinsInterface = f
+}
+
+var jobsInactiveUsersInterface func(*App) tjobs.InactiveUsersJobInterface
+
+func RegisterJobsInactiveUsersJobInterface(f func(*App) tjobs.InactiveUsersJobInterface) {
+	jobsInactiveUsersInterface = f
+}
+
+var jobsInactiveChannelsInterface func(*App) tjobs.InactiveChannelsJobInterface
+
+func RegisterJobsInactiveChannelsJobInterface(f func(*App) tjobs.InactiveChannelsJobInterface) {
+	jobsInactiveChannelsInterface = f
+}
+
+var jobsColdStorageInterface func(*App) tjobs.ColdStorageJobInterface
+
+func RegisterJobsColdStorageJobInterface(f func(*App) tjobs.ColdStorageJobInterface) {
+	jobsColdStorageInterface = f
+}
+
+var jobsFileIntegrityScanInterface func(*App) tjobs.FileIntegrityScanJobInterface
+
+func RegisterJobsFileIntegrityScanJobInterface(f func(*App) tjobs.FileIntegrityScanJobInterface) {
+	jobsFileIntegrityScanInterface = f
+}
+
+var jobsMoveChannelInterface func(*App) tjobs.MoveChannelJobInterface
+
+func RegisterJobsMoveChannelJobInterface(f func(*App) tjobs.MoveChannelJobInterface) {
+	jobsMoveChannelInterface = f
+}
+
+var jobsMergeTeamsInterface func(*App) tjobs.MergeTeamsJobInterface
+
+func RegisterJobsMergeTeamsJobInterface(f func(*App) tjobs.MergeTeamsJobInterface) {
+	jobsMergeTeamsInterface = f
+}
+
+var jobsCountRepairInterface func(*App) tjobs.CountRepairJobInterface
+
+func RegisterJobsCountRepairJobInterface(f func(*App) tjobs.CountRepairJobInterface) {
+	jobsCountRepairInterface = f
+}
+
+var jobsElasticsearchReindexInterface func(*App) tjobs.ElasticsearchReindexJobInterface
+
+func RegisterJobsElasticsearchReindexJobInterface(f func(*App) tjobs.ElasticsearchReindexJobInterface) {
+	jobsElasticsearchReindexInterface = f
+}
+
+var jobsPluginJobsInterface func(*App) tjobs.PluginJobsInterface
+
+func RegisterJobsPluginJobsInterface(f func(*App) tjobs.PluginJobsInterface) {
+	jobsPluginJobsInterface = f
+}
+
 var ldapInterface func(*App) einterfaces.LdapInterface
 
 func RegisterLdapInterface(f func(*App) einterfaces.LdapInterface) {
@@ -117,6 +186,9 @@ func (s *Server) initEnterprise() {
 	if elasticsearchInterface != nil {
 		s.Elasticsearch = elasticsearchInterface(s.FakeApp())
 	}
+	if firehoseWriterInterface != nil {
+		s.FirehoseWriter = firehoseWriterInterface(s)
+	}
 	if ldapInterface != nil {
 		s.Ldap = ldapInterface(s.FakeApp())
 		s.AddConfigListener(func(_, cfg *model.Config) {
@@ -141,6 +213,12 @@ func (s *Server) initEnterprise() {
 		s.DataRetention = dataRetentionInterface(s.FakeApp())
 	}
 	if clusterInterface != nil {
-		s.Cluster = clusterInterface(s)
+		cluster := clusterInterface(s)
+		if transportCluster, err := newTransportCluster(s, cluster); err != nil {
+			mlog.Critical("Failed to create the cluster transport, so cluster messages won't reach nodes using it", mlog.Err(err))
+			s.Cluster = cluster
+		} else {
+			s.Cluster = transportCluster
+		}
 	}
 }
@@ -123,6 +123,26 @@ func (a *App) RemoveFile(path string) *model.AppError {
 	return backend.RemoveFile(path)
 }
 
+func (a *App) ListDirectory(path string) ([]string, *model.AppError) {
+	backend, err := a.FileBackend()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := backend.ListDirectory(path)
+	if err != nil {
+		return nil, err
+	}
+	return *paths, nil
+}
+
+func (a *App) RemoveDirectory(path string) *model.AppError {
+	backend, err := a.FileBackend()
+	if err != nil {
+		return err
+	}
+	return backend.RemoveDirectory(path)
+}
+
 func (a *App) GetInfoForFilename(post *model.Post, teamId string, filename string) *model.FileInfo {
 	// Find the path from the Filename of the form /{channelId}/{userId}/{uid}/{nameWithExtension}
 	split := strings.SplitN(filename, "/", 5)
@@ -511,6 +531,7 @@ type uploadFileTask struct {
 	pluginsEnvironment *plugin.Environment
 	writeFile          func(io.Reader, string) (int64, *model.AppError)
 	saveToDatabase     func(*model.FileInfo) store.StoreChannel
+	recordStorage      func(string, int64)
 }
 
 func (t *uploadFileTask) init(a *App) {
@@ -546,6 +567,7 @@ func (t *uploadFileTask) init(a *App) {
 	t.pluginsEnvironment = a.GetPluginsEnvironment()
 	t.writeFile = a.WriteFile
 	t.saveToDatabase = a.Srv.Store.FileInfo().Save
+	t.recordStorage = a.recordTeamStorageForPath
 }
 
 // UploadFileX uploads a single file as specified in t. It applies the upload
@@ -574,6 +596,10 @@ func (a *App) UploadFileX(channelId, name string, input io.Reader,
 		return nil, t.newAppError("api.file.upload_file.too_large_detailed.app_error",
 			"", http.StatusRequestEntityTooLarge, "Length", t.ContentLength, "Limit", t.maxFileSize)
 	}
+	if t.fileinfo.IsSvg() && !*a.Config().FileSettings.EnableSvgUploads {
+		return nil, t.newAppError("api.file.upload_file.svg_disabled.app_error",
+			"", http.StatusBadRequest)
+	}
 
 	var aerr *model.AppError
 	if !t.Raw && t.fileinfo.IsImage() {
@@ -588,11 +614,36 @@ func (a *App) UploadFileX(channelId, name string, input io.Reader,
 		return t.fileinfo, aerr
 	}
 
+	aerr = a.checkUploadFileRules(t.Name, t.fileinfo.MimeType, t.buf.Bytes())
+	if aerr != nil {
+		return t.fileinfo, aerr
+	}
+
 	aerr = t.runPlugins()
 	if aerr != nil {
 		return t.fileinfo, aerr
 	}
 
+	// Plugins may have replaced the file, so it's only sanitized once they're done with it
+	if t.fileinfo.IsSvg() {
+		aerr = t.sanitizeSvg()
+		if aerr != nil {
+			return t.fileinfo, aerr
+		}
+	}
+
+	storageTeamId, aerr := a.storageTeamIdForChannel(t.ChannelId)
+	if aerr != nil {
+		return t.fileinfo, aerr
+	}
+	aerr = a.checkTeamStorageQuota(storageTeamId, int64(t.buf.Len()))
+	if aerr != nil {
+		return t.fileinfo, aerr
+	}
+
+	// The data can't fail to be read from the buffer
+	t.fileinfo.Checksum, _ = fileChecksum(bytes.NewReader(t.buf.Bytes()))
+
 	// Concurrently upload and update DB, and post-process the image.
 	wg := sync.WaitGroup{}
 
@@ -613,6 +664,8 @@ func (a *App) UploadFileX(channelId, name string, input io.Reader,
 		return nil, result.Err
 	}
 
+	a.addTeamStorageUsage(storageTeamId, t.fileinfo.Size)
+
 	wg.Wait()
 
 	return t.fileinfo, nil
@@ -672,6 +725,18 @@ func (t *uploadFileTask) runPlugins() *model.AppError {
 	return nil
 }
 
+func (t *uploadFileTask) sanitizeSvg() *model.AppError {
+	sanitized, err := utils.SanitizeSvg(t.buf.Bytes())
+	if err != nil {
+		return t.newAppError("api.file.upload_file.invalid_svg.app_error",
+			err.Error(), http.StatusBadRequest)
+	}
+
+	t.buf = bytes.NewBuffer(sanitized)
+	t.fileinfo.Size = int64(t.buf.Len())
+	return nil
+}
+
 func (t *uploadFileTask) preprocessImage() *model.AppError {
 	// If we fail to decode, return "as is".
 	config, _, err := image.DecodeConfig(t.newReader())
@@ -749,12 +814,21 @@ func (t *uploadFileTask) postprocessImage() {
 
 	writeJPEG := func(img image.Image, path string) {
 		r, w := io.Pipe()
+		done := make(chan struct{})
 		go func() {
-			_, aerr := t.writeFile(r, path)
+			defer close(done)
+
+			written, aerr := t.writeFile(r, path)
 			if aerr != nil {
 				mlog.Error(fmt.Sprintf("Unable to upload path=%v err=%v", path, aerr))
+				// Unblock the encoder, since nothing reads what it writes anymore
+				r.CloseWithError(aerr)
 				return
 			}
+
+			if t.recordStorage != nil {
+				t.recordStorage(path, written)
+			}
 		}()
 
 		err := jpeg.Encode(w, img, &jpeg.Options{Quality: 90})
@@ -764,6 +838,9 @@ func (t *uploadFileTask) postprocessImage() {
 		} else {
 			w.Close()
 		}
+
+		// Waited for so that the upload is only done once the image has been stored and counted
+		<-done
 	}
 
 	w := decoded.Bounds().Dx()
@@ -844,6 +921,14 @@ func (a *App) DoUploadFileExpectModification(now time.Time, rawTeamId string, ra
 		return nil, data, err
 	}
 
+	if info.IsSvg() && !*a.Config().FileSettings.EnableSvgUploads {
+		return nil, data, model.NewAppError("uploadFile", "api.file.upload_file.svg_disabled.app_error", map[string]interface{}{"Filename": filename}, "", http.StatusBadRequest)
+	}
+
+	if err := a.checkUploadFileRules(filename, info.MimeType, data); err != nil {
+		return nil, data, err
+	}
+
 	if orientation, err := getImageOrientation(bytes.NewReader(data)); err == nil &&
 		(orientation == RotatedCWMirrored ||
 			orientation == RotatedCCW ||
@@ -896,6 +981,26 @@ func (a *App) DoUploadFileExpectModification(now time.Time, rawTeamId string, ra
 		}
 	}
 
+	if info.IsSvg() {
+		sanitized, err := utils.SanitizeSvg(data)
+		if err != nil {
+			return nil, data, model.NewAppError("uploadFile", "api.file.upload_file.invalid_svg.app_error", map[string]interface{}{"Filename": filename}, err.Error(), http.StatusBadRequest)
+		}
+
+		data = sanitized
+		info.Size = int64(len(data))
+	}
+
+	storageTeamId, err := a.storageTeamIdForChannel(channelId)
+	if err != nil {
+		return nil, data, err
+	}
+	if err = a.checkTeamStorageQuota(storageTeamId, int64(len(data))); err != nil {
+		return nil, data, err
+	}
+
+	info.Checksum, _ = fileChecksum(bytes.NewReader(data))
+
 	if _, err := a.WriteFile(bytes.NewReader(data), info.Path); err != nil {
 		return nil, data, err
 	}
@@ -904,6 +1009,8 @@ func (a *App) DoUploadFileExpectModification(now time.Time, rawTeamId string, ra
 		return nil, data, result.Err
 	}
 
+	a.addTeamStorageUsage(storageTeamId, info.Size)
+
 	return info, data, nil
 }
 
@@ -1015,10 +1122,13 @@ func (a *App) generateThumbnailImage(img image.Image, thumbnailPath string, widt
 		return
 	}
 
-	if _, err := a.WriteFile(buf, thumbnailPath); err != nil {
+	written, err := a.WriteFile(buf, thumbnailPath)
+	if err != nil {
 		mlog.Error(fmt.Sprintf("Unable to upload thumbnail path=%v err=%v", thumbnailPath, err))
 		return
 	}
+
+	a.recordTeamStorageForPath(thumbnailPath, written)
 }
 
 func (a *App) generatePreviewImage(img image.Image, previewPath string, width int) {
@@ -1037,10 +1147,13 @@ func (a *App) generatePreviewImage(img image.Image, previewPath string, width in
 		return
 	}
 
-	if _, err := a.WriteFile(buf, previewPath); err != nil {
+	written, err := a.WriteFile(buf, previewPath)
+	if err != nil {
 		mlog.Error(fmt.Sprintf("Unable to upload preview err=%v", err), mlog.String("path", previewPath))
 		return
 	}
+
+	a.recordTeamStorageForPath(previewPath, written)
 }
 
 func (a *App) GetFileInfo(fileId string) (*model.FileInfo, *model.AppError) {
@@ -1057,7 +1170,7 @@ func (a *App) GetFile(fileId string) ([]byte, *model.AppError) {
 		return nil, err
 	}
 
-	data, err := a.ReadFile(info.Path)
+	data, err := a.ReadFileForInfo(info, info.Path)
 	if err != nil {
 		return nil, err
 	}
@@ -1077,6 +1190,7 @@ func (a *App) CopyFileInfos(userId string, fileIds []string) ([]string, *model.A
 			return nil, result.Err
 		}
 
+		// The copy shares the stored file of the original, so it doesn't use any more of the team's storage quota
 		fileInfo := result.Data.(*model.FileInfo)
 		fileInfo.Id = model.NewId()
 		fileInfo.CreatorId = userId
@@ -1093,3 +1207,55 @@ func (a *App) CopyFileInfos(userId string, fileIds []string) ([]string, *model.A
 
 	return newFileIds, nil
 }
+
+// SearchFilesInTeam returns the files with names matching the search terms that the user can see in the team. The
+// terms support the same in:, from:, on:, before: and after: filters as post search.
+func (a *App) SearchFilesInTeam(terms string, userId string, teamId string, isOrSearch bool, includeDeletedChannels bool, timeZoneOffset int, page, perPage int) ([]*model.FileInfo, *model.AppError) {
+	paramsList := model.ParseSearchParams(terms, timeZoneOffset)
+	if len(paramsList) == 0 {
+		return []*model.FileInfo{}, nil
+	}
+
+	// File names aren't split into hashtags, so the terms of every parsed set are searched for together.
+	params := paramsList[0]
+	termList := []string{}
+	for _, p := range paramsList {
+		if p.Terms != "" {
+			termList = append(termList, p.Terms)
+		}
+	}
+	params.Terms = strings.Join(termList, " ")
+
+	// Don't allow users to search for everything
+	if params.Terms == "*" {
+		return []*model.FileInfo{}, nil
+	}
+
+	params.OrTerms = isOrSearch
+	params.IncludeDeletedChannels = includeDeletedChannels && *a.Config().TeamSettings.ExperimentalViewArchivedChannels
+
+	// Unknown channels and users are left as they are so that they don't match anything.
+	for idx, channelName := range params.InChannels {
+		channel, err := a.parseAndFetchChannelIdByNameFromInFilter(channelName, userId, teamId, params.IncludeDeletedChannels)
+		if err != nil {
+			mlog.Error(fmt.Sprint(err))
+			continue
+		}
+		params.InChannels[idx] = channel.Id
+	}
+
+	for idx, username := range params.FromUsers {
+		user, err := a.GetUserByUsername(username)
+		if err != nil {
+			mlog.Error(fmt.Sprint(err))
+			continue
+		}
+		params.FromUsers[idx] = user.Id
+	}
+
+	result := <-a.Srv.Store.FileInfo().Search(teamId, userId, params, page*perPage, perPage)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return result.Data.([]*model.FileInfo), nil
+}
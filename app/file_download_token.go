@@ -0,0 +1,83 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const (
+	// FILE_DOWNLOAD_TOKEN_EXPIRY is how long, in milliseconds, a file download token can be used for after it's created.
+	FILE_DOWNLOAD_TOKEN_EXPIRY = 1000 * 60 * 60 * 24
+)
+
+// CreateFileDownloadToken returns a signed token that lets a file be downloaded on behalf of a user without a session,
+// such as from a link in an email, until the token expires. The user must be able to read the file when it's created.
+func (a *App) CreateFileDownloadToken(fileId string, userId string) (string, *model.AppError) {
+	info, err := a.GetFileInfo(fileId)
+	if err != nil {
+		return "", err
+	}
+
+	if info.CreatorId != userId && (info.PostId == "" || !a.HasPermissionToChannelByPost(userId, info.PostId, model.PERMISSION_READ_CHANNEL)) {
+		return "", model.NewAppError("CreateFileDownloadToken", "api.context.permissions.app_error", nil, "file_id="+fileId+", user_id="+userId, http.StatusForbidden)
+	}
+
+	expireAt := strconv.FormatInt(model.GetMillis()+FILE_DOWNLOAD_TOKEN_EXPIRY, 10)
+
+	return userId + "." + expireAt + "." + a.signFileDownloadToken(fileId, userId, expireAt), nil
+}
+
+// GenerateFileDownloadLink returns a link that downloads a file on behalf of a user without a session, such as from
+// a notification email.
+func (a *App) GenerateFileDownloadLink(siteURL string, info *model.FileInfo, userId string) (string, *model.AppError) {
+	token, err := a.CreateFileDownloadToken(info.Id, userId)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%s/files/%v/download?t=%s", siteURL, model.API_URL_SUFFIX, info.Id, url.QueryEscape(token)), nil
+}
+
+// VerifyFileDownloadToken checks that a token was created for a file and hasn't expired, and returns the id of the user
+// that it was created for.
+func (a *App) VerifyFileDownloadToken(fileId string, token string) (string, *model.AppError) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", model.NewAppError("VerifyFileDownloadToken", "app.file.download_token.invalid.app_error", nil, "file_id="+fileId, http.StatusForbidden)
+	}
+	userId, expireAt, signature := parts[0], parts[1], parts[2]
+
+	// The signature is checked first so that nothing else in a tampered token is trusted
+	if !hmac.Equal([]byte(signature), []byte(a.signFileDownloadToken(fileId, userId, expireAt))) {
+		return "", model.NewAppError("VerifyFileDownloadToken", "app.file.download_token.invalid.app_error", nil, "file_id="+fileId, http.StatusForbidden)
+	}
+
+	if expiry, err := strconv.ParseInt(expireAt, 10, 64); err != nil || model.GetMillis() >= expiry {
+		return "", model.NewAppError("VerifyFileDownloadToken", "app.file.download_token.expired.app_error", nil, "file_id="+fileId, http.StatusForbidden)
+	}
+
+	return userId, nil
+}
+
+// signFileDownloadToken signs the contents of a download token with a key derived from the public link salt, so that
+// changing the salt also revokes every download token.
+func (a *App) signFileDownloadToken(fileId string, userId string, expireAt string) string {
+	keyHash := hmac.New(sha256.New, []byte(*a.Config().FileSettings.PublicLinkSalt))
+	keyHash.Write([]byte("file_download_token"))
+
+	hash := hmac.New(sha256.New, keyHash.Sum(nil))
+	hash.Write([]byte(fileId + ":" + userId + ":" + expireAt))
+
+	return base64.RawURLEncoding.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestFileDownloadToken(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	result := <-th.App.Srv.Store.FileInfo().Save(&model.FileInfo{CreatorId: th.BasicUser.Id, PostId: th.BasicPost.Id, Path: "path", Name: "file.txt"})
+	require.Nil(t, result.Err)
+	info := result.Data.(*model.FileInfo)
+
+	token, err := th.App.CreateFileDownloadToken(info.Id, th.BasicUser2.Id)
+	require.Nil(t, err)
+
+	userId, err := th.App.VerifyFileDownloadToken(info.Id, token)
+	require.Nil(t, err)
+	assert.Equal(t, th.BasicUser2.Id, userId)
+
+	t.Run("users who can't read the file can't create tokens", func(t *testing.T) {
+		_, err := th.App.CreateFileDownloadToken(info.Id, th.CreateUser().Id)
+		require.NotNil(t, err)
+		assert.Equal(t, 403, err.StatusCode)
+	})
+
+	t.Run("tokens only work for their file", func(t *testing.T) {
+		_, err := th.App.VerifyFileDownloadToken(model.NewId(), token)
+		require.NotNil(t, err)
+		assert.Equal(t, "app.file.download_token.invalid.app_error", err.Id)
+	})
+
+	t.Run("tampered tokens don't work", func(t *testing.T) {
+		for _, tampered := range []string{
+			"",
+			token + "x",
+			th.BasicUser.Id + token[len(th.BasicUser2.Id):],
+			th.BasicUser2.Id + "." + strconv.FormatInt(model.GetMillis()+FILE_DOWNLOAD_TOKEN_EXPIRY*2, 10) + token[len(th.BasicUser2.Id)+14:],
+		} {
+			_, err := th.App.VerifyFileDownloadToken(info.Id, tampered)
+			require.NotNil(t, err, tampered)
+			assert.Equal(t, "app.file.download_token.invalid.app_error", err.Id)
+			assert.Equal(t, 403, err.StatusCode)
+		}
+	})
+
+	t.Run("expired tokens don't work", func(t *testing.T) {
+		expireAt := strconv.FormatInt(model.GetMillis()-1, 10)
+		expired := th.BasicUser2.Id + "." + expireAt + "." + th.App.signFileDownloadToken(info.Id, th.BasicUser2.Id, expireAt)
+
+		_, err := th.App.VerifyFileDownloadToken(info.Id, expired)
+		require.NotNil(t, err)
+		assert.Equal(t, "app.file.download_token.expired.app_error", err.Id)
+		assert.Equal(t, 403, err.StatusCode)
+	})
+
+	t.Run("changing the public link salt revokes tokens", func(t *testing.T) {
+		salt := *th.App.Config().FileSettings.PublicLinkSalt
+		defer th.App.UpdateConfig(func(cfg *model.Config) { *cfg.FileSettings.PublicLinkSalt = salt })
+		th.App.UpdateConfig(func(cfg *model.Config) { *cfg.FileSettings.PublicLinkSalt = model.NewRandomString(32) })
+
+		_, err := th.App.VerifyFileDownloadToken(info.Id, token)
+		require.NotNil(t, err)
+	})
+}
+
+func TestGetNotificationEmailFileLinks(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	result := <-th.App.Srv.Store.FileInfo().Save(&model.FileInfo{CreatorId: th.BasicUser.Id, PostId: th.BasicPost.Id, Path: "path", Name: "file.txt"})
+	require.Nil(t, result.Err)
+	info := result.Data.(*model.FileInfo)
+
+	post := th.BasicPost.Clone()
+	post.FileIds = model.StringArray{info.Id}
+
+	links := th.App.getNotificationEmailFileLinks(th.BasicUser2, post)
+	require.Len(t, links, 1)
+	assert.Equal(t, "file.txt", links[0].Name)
+
+	token, err := url.Parse(links[0].Link)
+	require.Nil(t, err)
+	userId, appErr := th.App.VerifyFileDownloadToken(info.Id, token.Query().Get("t"))
+	require.Nil(t, appErr)
+	assert.Equal(t, th.BasicUser2.Id, userId)
+
+	t.Run("users who can't read the file get no links", func(t *testing.T) {
+		assert.Empty(t, th.App.getNotificationEmailFileLinks(th.CreateUser(), post))
+	})
+}
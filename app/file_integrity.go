@@ -0,0 +1,137 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const FILE_INTEGRITY_SCAN_BATCH_SIZE = 100
+
+// fileChecksum returns the checksum that's recorded in a FileInfo for a file with the given contents.
+func fileChecksum(r io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ShouldVerifyChecksumOnDownload returns whether the file's checksum needs to be verified before it's downloaded,
+// according to FileSettings.VerifyChecksumFileTypes.
+func (a *App) ShouldVerifyChecksumOnDownload(info *model.FileInfo) bool {
+	return info.Checksum != "" && a.Config().FileSettings.ShouldVerifyChecksum(info.Name, info.MimeType)
+}
+
+// VerifyFileIntegrity compares the contents of a file against the checksum that was recorded in its FileInfo when it
+// was uploaded. A file that doesn't match or that's missing from the file store is logged and counted in the metrics,
+// since it means that the file store has lost or corrupted it.
+func (a *App) VerifyFileIntegrity(info *model.FileInfo) (*model.FileIntegrityResult, *model.AppError) {
+	result := &model.FileIntegrityResult{
+		FileId:           info.Id,
+		ExpectedChecksum: info.Checksum,
+	}
+
+	if info.Checksum == "" {
+		result.Status = model.FILE_INTEGRITY_STATUS_NO_CHECKSUM
+		return result, nil
+	}
+
+	reader, err := a.FileReaderForInfo(info, info.Path)
+	if err != nil {
+		backend, backendErr := a.FileBackendForInfo(info)
+		if backendErr != nil {
+			return nil, err
+		}
+		if exists, existsErr := backend.FileExists(info.Path); existsErr != nil || exists {
+			return nil, err
+		}
+
+		result.Status = model.FILE_INTEGRITY_STATUS_MISSING
+		a.reportFileIntegrityFailure(info, result)
+		return result, nil
+	}
+	defer reader.Close()
+
+	checksum, readErr := fileChecksum(reader)
+	if readErr != nil {
+		return nil, model.NewAppError("VerifyFileIntegrity", "app.file.verify_integrity.read.app_error", nil, "file_id="+info.Id+", err="+readErr.Error(), http.StatusInternalServerError)
+	}
+	result.ActualChecksum = checksum
+
+	if checksum != info.Checksum {
+		result.Status = model.FILE_INTEGRITY_STATUS_MISMATCH
+		a.reportFileIntegrityFailure(info, result)
+		return result, nil
+	}
+
+	result.Status = model.FILE_INTEGRITY_STATUS_OK
+	return result, nil
+}
+
+func (a *App) reportFileIntegrityFailure(info *model.FileInfo, result *model.FileIntegrityResult) {
+	mlog.Error("File failed integrity check",
+		mlog.String("file_id", info.Id),
+		mlog.String("path", info.Path),
+		mlog.String("status", result.Status),
+		mlog.String("expected_checksum", result.ExpectedChecksum),
+		mlog.String("actual_checksum", result.ActualChecksum),
+	)
+
+	if a.Metrics != nil {
+		a.Metrics.IncrementFileIntegrityFailure(result.Status)
+	}
+}
+
+// ScanFileIntegrity verifies every file that hasn't been deleted against its checksum, FILE_INTEGRITY_SCAN_BATCH_SIZE
+// files at a time. After each batch, onProgress is called with a checkpoint of the scan so far, and the scan is
+// aborted if it returns an error. Passing the last checkpoint back in resumes an interrupted scan.
+func (a *App) ScanFileIntegrity(checkpoint *model.FileIntegrityScanCheckpoint, onProgress func(checkpoint *model.FileIntegrityScanCheckpoint) *model.AppError) (*model.FileIntegrityScanCheckpoint, *model.AppError) {
+	if checkpoint == nil {
+		checkpoint = &model.FileIntegrityScanCheckpoint{}
+	}
+
+	for {
+		result := <-a.Srv.Store.FileInfo().GetBatchForIntegrityScan(checkpoint.AfterId, FILE_INTEGRITY_SCAN_BATCH_SIZE)
+		if result.Err != nil {
+			return checkpoint, result.Err
+		}
+		infos := result.Data.([]*model.FileInfo)
+		if len(infos) == 0 {
+			break
+		}
+
+		for _, info := range infos {
+			integrity, err := a.VerifyFileIntegrity(info)
+			if err != nil {
+				// The file store may be briefly unavailable, which shouldn't stop the rest of the files from being checked
+				mlog.Warn("Failed to verify file integrity", mlog.String("file_id", info.Id), mlog.Err(err))
+				continue
+			}
+
+			switch integrity.Status {
+			case model.FILE_INTEGRITY_STATUS_MISMATCH:
+				checkpoint.Mismatched++
+			case model.FILE_INTEGRITY_STATUS_MISSING:
+				checkpoint.Missing++
+			case model.FILE_INTEGRITY_STATUS_NO_CHECKSUM:
+				checkpoint.NoChecksum++
+			}
+			checkpoint.Checked++
+		}
+
+		checkpoint.AfterId = infos[len(infos)-1].Id
+		if err := onProgress(checkpoint); err != nil {
+			return checkpoint, err
+		}
+	}
+
+	return checkpoint, nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestFileChecksum(t *testing.T) {
+	checksum, err := fileChecksum(strings.NewReader("hello"))
+	require.Nil(t, err)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", checksum)
+}
+
+func TestVerifyFileIntegrity(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	info, err := th.App.UploadFile([]byte("hello"), th.BasicChannel.Id, "file.txt")
+	require.Nil(t, err)
+	defer th.App.RemoveFile(info.Path)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", info.Checksum)
+
+	result, err := th.App.VerifyFileIntegrity(info)
+	require.Nil(t, err)
+	assert.Equal(t, model.FILE_INTEGRITY_STATUS_OK, result.Status)
+
+	noChecksum := *info
+	noChecksum.Checksum = ""
+	result, err = th.App.VerifyFileIntegrity(&noChecksum)
+	require.Nil(t, err)
+	assert.Equal(t, model.FILE_INTEGRITY_STATUS_NO_CHECKSUM, result.Status)
+
+	_, err = th.App.WriteFile(bytes.NewReader([]byte("olleh")), info.Path)
+	require.Nil(t, err)
+
+	result, err = th.App.VerifyFileIntegrity(info)
+	require.Nil(t, err)
+	assert.Equal(t, model.FILE_INTEGRITY_STATUS_MISMATCH, result.Status)
+	assert.Equal(t, info.Checksum, result.ExpectedChecksum)
+	assert.NotEqual(t, info.Checksum, result.ActualChecksum)
+
+	require.Nil(t, th.App.RemoveFile(info.Path))
+
+	result, err = th.App.VerifyFileIntegrity(info)
+	require.Nil(t, err)
+	assert.Equal(t, model.FILE_INTEGRITY_STATUS_MISSING, result.Status)
+}
+
+func TestScanFileIntegrity(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	info, err := th.App.UploadFile([]byte("hello"), th.BasicChannel.Id, "file.txt")
+	require.Nil(t, err)
+	defer th.App.RemoveFile(info.Path)
+
+	_, err = th.App.WriteFile(bytes.NewReader([]byte("olleh")), info.Path)
+	require.Nil(t, err)
+
+	// Files left behind by other tests are scanned too
+	var checkpoints []*model.FileIntegrityScanCheckpoint
+	checkpoint, err := th.App.ScanFileIntegrity(nil, func(checkpoint *model.FileIntegrityScanCheckpoint) *model.AppError {
+		copied := *checkpoint
+		checkpoints = append(checkpoints, &copied)
+		return nil
+	})
+	require.Nil(t, err)
+	require.NotEmpty(t, checkpoints)
+	assert.True(t, checkpoint.Checked >= 1)
+	assert.True(t, checkpoint.Mismatched >= 1)
+
+	t.Run("resumes from a checkpoint", func(t *testing.T) {
+		resumed, err := th.App.ScanFileIntegrity(checkpoints[len(checkpoints)-1], func(*model.FileIntegrityScanCheckpoint) *model.AppError {
+			return nil
+		})
+		require.Nil(t, err)
+		assert.Equal(t, checkpoint.Checked, resumed.Checked)
+	})
+
+	t.Run("stops when onProgress fails", func(t *testing.T) {
+		_, err := th.App.ScanFileIntegrity(nil, func(*model.FileIntegrityScanCheckpoint) *model.AppError {
+			return model.NewAppError("test", "test", nil, "", http.StatusOK)
+		})
+		require.NotNil(t, err)
+		assert.Equal(t, "test", err.Id)
+	})
+}
@@ -4,7 +4,9 @@
 package app
 
 import (
+	"bytes"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
@@ -131,6 +133,58 @@ func TestUploadFile(t *testing.T) {
 	}
 }
 
+func TestUploadFileSvg(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	channelId := model.NewId()
+	svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg" onload="alert(1)"><script>alert(2)</script><rect width="1" height="1"/></svg>`)
+	sanitized := `<svg xmlns="http://www.w3.org/2000/svg"><rect width="1" height="1"></rect></svg>`
+
+	uploads := map[string]func(data []byte) (*model.FileInfo, *model.AppError){
+		"UploadFile": func(data []byte) (*model.FileInfo, *model.AppError) {
+			return th.App.UploadFile(data, channelId, "test.svg")
+		},
+		"UploadFileX": func(data []byte) (*model.FileInfo, *model.AppError) {
+			return th.App.UploadFileX(channelId, "test.svg", bytes.NewReader(data), UploadFileSetContentLength(int64(len(data))))
+		},
+	}
+
+	for name, upload := range uploads {
+		t.Run(name, func(t *testing.T) {
+			t.Run("sanitized", func(t *testing.T) {
+				info, err := upload(svg)
+				require.Nil(t, err)
+				defer func() {
+					<-th.App.Srv.Store.FileInfo().PermanentDelete(info.Id)
+					th.App.RemoveFile(info.Path)
+				}()
+
+				data, err := th.App.ReadFile(info.Path)
+				require.Nil(t, err)
+				assert.Equal(t, sanitized, string(data))
+				assert.Equal(t, int64(len(sanitized)), info.Size)
+			})
+
+			t.Run("malformed", func(t *testing.T) {
+				_, err := upload([]byte(`<svg xmlns="http://www.w3.org/2000/svg"><rect>`))
+				require.NotNil(t, err)
+				assert.Equal(t, "api.file.upload_file.invalid_svg.app_error", err.Id)
+				assert.Equal(t, http.StatusBadRequest, err.StatusCode)
+			})
+
+			t.Run("disabled", func(t *testing.T) {
+				th.App.UpdateConfig(func(cfg *model.Config) { *cfg.FileSettings.EnableSvgUploads = false })
+				defer th.App.UpdateConfig(func(cfg *model.Config) { *cfg.FileSettings.EnableSvgUploads = true })
+
+				_, err := upload(svg)
+				require.NotNil(t, err)
+				assert.Equal(t, "api.file.upload_file.svg_disabled.app_error", err.Id)
+			})
+		})
+	}
+}
+
 func TestGetInfoForFilename(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
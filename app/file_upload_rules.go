@@ -0,0 +1,79 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// machOSignatures are the magic numbers of 32 and 64 bit Mach-O executables in either byte order.
+var machOSignatures = [][]byte{
+	[]byte("\xfe\xed\xfa\xce"),
+	[]byte("\xfe\xed\xfa\xcf"),
+	[]byte("\xce\xfa\xed\xfe"),
+	[]byte("\xcf\xfa\xed\xfe"),
+}
+
+// detectUploadContentType returns the content type of an uploaded file as detected from its data. Since the data of
+// many formats, such as JSON or CSV, is only recognizable as arbitrary text or binary data, the content type given by
+// the file's extension is used instead in those cases.
+func detectUploadContentType(extensionContentType string, data []byte) string {
+	if contentType := detectExecutableContentType(data); contentType != "" {
+		return contentType
+	}
+
+	contentType := http.DetectContentType(data)
+	if extensionContentType != "" && (contentType == "application/octet-stream" || strings.HasPrefix(contentType, "text/plain")) {
+		return extensionContentType
+	}
+
+	return contentType
+}
+
+// detectExecutableContentType recognizes the executable formats that http.DetectContentType doesn't.
+func detectExecutableContentType(data []byte) string {
+	// Windows executables start with a DOS header that points to the PE header
+	if len(data) >= 0x40 && bytes.HasPrefix(data, []byte("MZ")) {
+		offset := int64(binary.LittleEndian.Uint32(data[0x3c:0x40]))
+		if offset+4 <= int64(len(data)) && bytes.Equal(data[offset:offset+4], []byte("PE\x00\x00")) {
+			return "application/x-msdownload"
+		}
+	}
+
+	if bytes.HasPrefix(data, []byte("\x7fELF")) {
+		return "application/x-executable"
+	}
+
+	for _, signature := range machOSignatures {
+		if bytes.HasPrefix(data, signature) {
+			return "application/x-mach-binary"
+		}
+	}
+
+	return ""
+}
+
+// checkUploadFileRules enforces FileSettings.AllowedUploadFileTypes, DeniedUploadFileTypes and MaxFileSizeByType
+// against an uploaded file, using the content type detected from its data rather than trusting its extension alone.
+func (a *App) checkUploadFileRules(filename, extensionContentType string, data []byte) *model.AppError {
+	fileSettings := &a.Config().FileSettings
+	contentType := detectUploadContentType(extensionContentType, data)
+
+	if fileType := fileSettings.GetUploadFileTypeViolation(filename, contentType); fileType == "*" {
+		return model.NewAppError("checkUploadFileRules", "api.file.upload_file.type_not_allowed.app_error", map[string]interface{}{"Filename": filename, "ContentType": contentType}, "", http.StatusBadRequest)
+	} else if fileType != "" {
+		return model.NewAppError("checkUploadFileRules", "api.file.upload_file.type_denied.app_error", map[string]interface{}{"Filename": filename, "FileType": fileType}, "content_type="+contentType, http.StatusBadRequest)
+	}
+
+	if limit, fileType := fileSettings.GetMaxUploadFileSize(filename, contentType); fileType != "" && int64(len(data)) > limit {
+		return model.NewAppError("checkUploadFileRules", "api.file.upload_file.too_large_for_type.app_error", map[string]interface{}{"Filename": filename, "FileType": fileType, "Length": len(data), "Limit": limit}, "content_type="+contentType, http.StatusRequestEntityTooLarge)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func testWindowsExecutable() []byte {
+	data := make([]byte, 0x80)
+	copy(data, "MZ")
+	binary.LittleEndian.PutUint32(data[0x3c:], 0x40)
+	copy(data[0x40:], "PE\x00\x00")
+	return data
+}
+
+func TestDetectUploadContentType(t *testing.T) {
+	png := []byte("\x89PNG\x0D\x0A\x1A\x0A")
+
+	assert.Equal(t, "image/png", detectUploadContentType("image/png", png))
+	assert.Equal(t, "image/png", detectUploadContentType("application/pdf", png))
+	assert.Equal(t, "application/x-msdownload", detectUploadContentType("image/png", testWindowsExecutable()))
+	assert.Equal(t, "application/x-executable", detectUploadContentType("", []byte("\x7fELF\x02\x01\x01")))
+	assert.Equal(t, "application/x-mach-binary", detectUploadContentType("", []byte("\xcf\xfa\xed\xfe\x07\x00")))
+
+	// Text and binary data that isn't recognized falls back to the extension's content type
+	assert.Equal(t, "application/json", detectUploadContentType("application/json", []byte(`{"a": 1}`)))
+	assert.Equal(t, "text/plain; charset=utf-8", detectUploadContentType("", []byte(`{"a": 1}`)))
+	assert.Equal(t, "application/zip", detectUploadContentType("application/zip", []byte{0, 1, 2, 3}))
+
+	// Text starting with MZ isn't a Windows executable
+	assert.Equal(t, "text/plain; charset=utf-8", detectUploadContentType("", []byte("MZ is not an executable")))
+}
+
+func TestUploadFileRules(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	channelId := model.NewId()
+	png := append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), bytes.Repeat([]byte{0}, 1024)...)
+
+	uploads := map[string]func(filename string, data []byte) (*model.FileInfo, *model.AppError){
+		"UploadFile": func(filename string, data []byte) (*model.FileInfo, *model.AppError) {
+			return th.App.UploadFile(data, channelId, filename)
+		},
+		"UploadFileX": func(filename string, data []byte) (*model.FileInfo, *model.AppError) {
+			return th.App.UploadFileX(channelId, filename, bytes.NewReader(data), UploadFileSetContentLength(int64(len(data))), UploadFileSetRaw())
+		},
+	}
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		cfg.FileSettings.AllowedUploadFileTypes = []string{"image/*", ".txt"}
+		cfg.FileSettings.DeniedUploadFileTypes = []string{".exe", "application/x-msdownload"}
+		cfg.FileSettings.MaxFileSizeByType = map[string]int64{"image/png": 512}
+	})
+
+	for name, upload := range uploads {
+		t.Run(name, func(t *testing.T) {
+			t.Run("allowed", func(t *testing.T) {
+				info, err := upload("test.txt", []byte("some text"))
+				require.Nil(t, err)
+				<-th.App.Srv.Store.FileInfo().PermanentDelete(info.Id)
+				th.App.RemoveFile(info.Path)
+			})
+
+			t.Run("denied by extension", func(t *testing.T) {
+				_, err := upload("test.exe", []byte("some text"))
+				require.NotNil(t, err)
+				assert.Equal(t, "api.file.upload_file.type_denied.app_error", err.Id)
+				assert.Equal(t, http.StatusBadRequest, err.StatusCode)
+			})
+
+			t.Run("denied by detected content type", func(t *testing.T) {
+				_, err := upload("test.txt", testWindowsExecutable())
+				require.NotNil(t, err)
+				assert.Equal(t, "api.file.upload_file.type_denied.app_error", err.Id)
+				assert.Equal(t, "content_type=application/x-msdownload", err.DetailedError)
+			})
+
+			t.Run("not allowed", func(t *testing.T) {
+				_, err := upload("test.pdf", []byte("%PDF-1.4"))
+				require.NotNil(t, err)
+				assert.Equal(t, "api.file.upload_file.type_not_allowed.app_error", err.Id)
+				assert.Equal(t, http.StatusBadRequest, err.StatusCode)
+			})
+
+			t.Run("too large for type", func(t *testing.T) {
+				_, err := upload("test.png", png)
+				require.NotNil(t, err)
+				assert.Equal(t, "api.file.upload_file.too_large_for_type.app_error", err.Id)
+				assert.Equal(t, http.StatusRequestEntityTooLarge, err.StatusCode)
+			})
+		})
+	}
+}
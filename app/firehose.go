@@ -0,0 +1,449 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/einterfaces"
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const (
+	FIREHOSE_MIN_RETRY_INTERVAL = time.Second
+	FIREHOSE_MAX_RETRY_INTERVAL = time.Minute
+)
+
+// InitEventFirehose starts or stops the event firehose to match the config. Any events that are still buffered when
+// it's stopped get one attempt to be delivered, and are spooled to be delivered later if that fails. Events left in
+// the spool by a previous run are delivered once the firehose starts.
+func (s *Server) InitEventFirehose() {
+	settings := s.Config().FirehoseSettings
+
+	var firehose *eventFirehose
+	if *settings.Enable {
+		writer, err := s.newFirehoseWriter()
+		if err != nil {
+			mlog.Error("Failed to start the event firehose", mlog.Err(err))
+		} else {
+			firehose = newEventFirehose(writer, *settings.BufferSize, *settings.BatchSize, time.Duration(*settings.FlushIntervalMilliseconds)*time.Millisecond)
+		}
+	}
+
+	s.eventFirehoseLock.Lock()
+	oldFirehose := s.eventFirehose
+	s.eventFirehose = firehose
+	s.eventFirehoseLock.Unlock()
+
+	// The old firehose has to let go of the spool before the new one opens it.
+	if oldFirehose != nil {
+		oldFirehose.stop()
+	}
+
+	if firehose != nil {
+		if *settings.SpoolDirectory != "" {
+			if err := firehose.openSpool(*settings.SpoolDirectory); err != nil {
+				mlog.Error("Failed to open the event firehose's spool, so events that don't fit in its buffer will be dropped", mlog.String("directory", *settings.SpoolDirectory), mlog.Err(err))
+			}
+		}
+		firehose.start()
+	}
+}
+
+func (s *Server) newFirehoseWriter() (einterfaces.FirehoseWriterInterface, error) {
+	settings := s.Config().FirehoseSettings
+
+	switch *settings.Destination {
+	case model.FIREHOSE_DESTINATION_HTTP:
+		return &httpFirehoseWriter{
+			client:   s.HTTPService.MakeClient(true),
+			endpoint: *settings.HTTPEndpoint,
+		}, nil
+	case model.FIREHOSE_DESTINATION_CUSTOM:
+		if s.FirehoseWriter == nil {
+			return nil, fmt.Errorf("no custom firehose writer has been registered")
+		}
+		return s.FirehoseWriter, nil
+	}
+
+	return nil, fmt.Errorf("unknown firehose destination %v", *settings.Destination)
+}
+
+// firehoseEventData is implemented by the objects that firehose events are about.
+type firehoseEventData interface {
+	ToJson() string
+}
+
+// publishFirehoseEvent queues an event to be sent to the event firehose, if it's enabled. The data is serialized
+// straight away since the event is sent later on. Request handling is never blocked by the firehose, so events that
+// don't fit in the buffer are spooled, or dropped if there's no spool, when the destination can't keep up.
+func (a *App) publishFirehoseEvent(eventType string, teamId string, channelId string, userId string, data firehoseEventData) {
+	if !*a.Config().FirehoseSettings.Enable {
+		return
+	}
+
+	event := &model.FirehoseEvent{
+		Id:        model.NewId(),
+		Type:      eventType,
+		CreateAt:  model.GetMillis(),
+		SiteURL:   a.GetSiteURL(),
+		ActorId:   a.Session.UserId,
+		TeamId:    teamId,
+		ChannelId: channelId,
+		UserId:    userId,
+	}
+	if data != nil {
+		event.Data = json.RawMessage(data.ToJson())
+	}
+
+	a.Srv.eventFirehoseLock.RLock()
+	defer a.Srv.eventFirehoseLock.RUnlock()
+
+	if a.Srv.eventFirehose == nil {
+		return
+	}
+
+	if a.Srv.eventFirehose.add(event) {
+		mlog.Warn("The event firehose's buffer is full so events are being spooled, or dropped if there's no spool, until they can be written. Please check the destination or increase the BufferSize.", mlog.Int("buffer_size", a.Srv.eventFirehose.bufferSize))
+	}
+}
+
+// publishPostFirehoseEvent publishes an event about a post, looking up its channel to find the team if it's not
+// provided.
+func (a *App) publishPostFirehoseEvent(eventType string, post *model.Post, channel *model.Channel) {
+	if !*a.Config().FirehoseSettings.Enable {
+		return
+	}
+
+	teamId := ""
+	if channel != nil {
+		teamId = channel.TeamId
+	} else if channel, err := a.GetChannel(post.ChannelId); err == nil {
+		teamId = channel.TeamId
+	}
+
+	a.publishFirehoseEvent(eventType, teamId, post.ChannelId, post.UserId, post)
+}
+
+func (a *App) publishUserFirehoseEvent(eventType string, user *model.User) {
+	if !*a.Config().FirehoseSettings.Enable {
+		return
+	}
+
+	user = user.DeepCopy()
+	user.Sanitize(map[string]bool{})
+
+	a.publishFirehoseEvent(eventType, "", "", user.Id, user)
+}
+
+type eventFirehose struct {
+	writer        einterfaces.FirehoseWriterInterface
+	bufferSize    int
+	batchSize     int
+	flushInterval time.Duration
+
+	eventsMutex sync.Mutex
+	events      []*model.FirehoseEvent
+	spool       *firehoseSpool
+	overflowing bool
+	dropped     int
+	batchReady  chan struct{}
+
+	stopping chan struct{}
+	stopped  chan struct{}
+}
+
+func newEventFirehose(writer einterfaces.FirehoseWriterInterface, bufferSize, batchSize int, flushInterval time.Duration) *eventFirehose {
+	return &eventFirehose{
+		writer:        writer,
+		bufferSize:    bufferSize,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		events:        make([]*model.FirehoseEvent, 0, bufferSize),
+		batchReady:    make(chan struct{}, 1),
+		stopping:      make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+}
+
+// openSpool sets up the spool in a directory that events are put in when they don't fit in the buffer, or when they
+// haven't been written by the time the firehose stops. It must be called before the firehose starts.
+func (f *eventFirehose) openSpool(directory string) error {
+	spool, err := newFirehoseSpool(directory, f.batchSize)
+	if err != nil {
+		return err
+	}
+
+	f.eventsMutex.Lock()
+	f.spool = spool
+	f.eventsMutex.Unlock()
+
+	return nil
+}
+
+// add queues an event, returning true if the event is the one that filled the buffer so that the caller can warn
+// about it once rather than for every event until the buffer drains. Events that don't fit in the buffer are
+// spooled, as are all events while the spool has some waiting, so that they're written in order. If they can't be
+// spooled they're dropped and counted.
+func (f *eventFirehose) add(event *model.FirehoseEvent) bool {
+	f.eventsMutex.Lock()
+	defer f.eventsMutex.Unlock()
+
+	full := len(f.events) >= f.bufferSize
+	filled := full && !f.overflowing
+	if full {
+		f.overflowing = true
+	}
+
+	if f.spool != nil && (full || !f.spool.empty()) {
+		if err := f.spool.append([]*model.FirehoseEvent{event}); err != nil {
+			mlog.Error("Failed to spool an event firehose event, so it was dropped", mlog.String("event_id", event.Id), mlog.Err(err))
+			f.dropped++
+		}
+		return filled
+	}
+
+	if full {
+		f.dropped++
+		return filled
+	}
+
+	f.events = append(f.events, event)
+
+	if len(f.events) >= f.batchSize {
+		select {
+		case f.batchReady <- struct{}{}:
+		default:
+		}
+	}
+
+	return false
+}
+
+// take removes the next batch of events from the buffer, returning nil if there isn't a full batch to write unless
+// partial batches are allowed.
+func (f *eventFirehose) take(allowPartial bool) []*model.FirehoseEvent {
+	f.eventsMutex.Lock()
+	defer f.eventsMutex.Unlock()
+
+	size := f.batchSize
+	if size > len(f.events) {
+		if !allowPartial || len(f.events) == 0 {
+			return nil
+		}
+		size = len(f.events)
+	}
+
+	batch := make([]*model.FirehoseEvent, size)
+	copy(batch, f.events)
+	f.events = append(f.events[:0], f.events[size:]...)
+
+	if len(f.events) < f.bufferSize {
+		f.overflowing = false
+	}
+
+	return batch
+}
+
+// takeAll removes every event from the buffer.
+func (f *eventFirehose) takeAll() []*model.FirehoseEvent {
+	f.eventsMutex.Lock()
+	defer f.eventsMutex.Unlock()
+
+	events := f.events
+	f.events = make([]*model.FirehoseEvent, 0, f.bufferSize)
+	f.overflowing = false
+
+	return events
+}
+
+// takeDropped returns how many events have been dropped since it was last called.
+func (f *eventFirehose) takeDropped() int {
+	f.eventsMutex.Lock()
+	defer f.eventsMutex.Unlock()
+
+	dropped := f.dropped
+	f.dropped = 0
+
+	return dropped
+}
+
+// getSpool returns the firehose's spool, or nil if it doesn't have one.
+func (f *eventFirehose) getSpool() *firehoseSpool {
+	f.eventsMutex.Lock()
+	defer f.eventsMutex.Unlock()
+
+	return f.spool
+}
+
+func (f *eventFirehose) start() {
+	go f.run()
+}
+
+func (f *eventFirehose) stop() {
+	close(f.stopping)
+	<-f.stopped
+}
+
+// run writes batches of events once they're full or the flush interval has passed, followed by any spooled events.
+// Batches are retried until they're written, while new events keep being buffered or spooled.
+func (f *eventFirehose) run() {
+	defer close(f.stopped)
+
+	ticker := time.NewTicker(f.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		allowPartial := false
+
+		select {
+		case <-f.batchReady:
+		case <-ticker.C:
+			allowPartial = true
+		case <-f.stopping:
+			f.flush(nil)
+			return
+		}
+
+		for batch := f.take(allowPartial); batch != nil; batch = f.take(allowPartial) {
+			if !f.write(batch) {
+				f.flush(batch)
+				return
+			}
+		}
+
+		if !f.writeSpooled() {
+			f.flush(nil)
+			return
+		}
+
+		if dropped := f.takeDropped(); dropped > 0 {
+			mlog.Error("Events were dropped because they didn't fit in the event firehose's buffer and couldn't be spooled", mlog.Int("dropped", dropped))
+		}
+	}
+}
+
+// writeSpooled writes the spooled events a segment at a time, removing each segment from the spool once it's been
+// written. It returns false if the firehose was stopped first, in which case the unwritten segments stay spooled.
+func (f *eventFirehose) writeSpooled() bool {
+	spool := f.getSpool()
+	if spool == nil {
+		return true
+	}
+
+	for {
+		name, events, err := spool.next()
+		if err != nil {
+			mlog.Error("Failed to read events from the event firehose's spool", mlog.Err(err))
+			return true
+		} else if name == "" {
+			return true
+		}
+
+		if len(events) > 0 && !f.write(events) {
+			return false
+		}
+
+		if err := spool.remove(name); err != nil {
+			mlog.Error("Failed to remove written events from the event firehose's spool", mlog.String("segment", name), mlog.Err(err))
+			return true
+		}
+	}
+}
+
+// write tries to write a batch until it succeeds, returning false if the firehose was stopped first.
+func (f *eventFirehose) write(batch []*model.FirehoseEvent) bool {
+	retryInterval := FIREHOSE_MIN_RETRY_INTERVAL
+	for {
+		err := f.writer.Write(batch)
+		if err == nil {
+			return true
+		}
+
+		mlog.Warn("Failed to write events to the firehose, retrying", mlog.Int("events", len(batch)), mlog.Err(err))
+
+		select {
+		case <-f.stopping:
+			return false
+		case <-time.After(retryInterval):
+		}
+
+		retryInterval *= 2
+		if retryInterval > FIREHOSE_MAX_RETRY_INTERVAL {
+			retryInterval = FIREHOSE_MAX_RETRY_INTERVAL
+		}
+	}
+}
+
+// flush makes a single attempt to write the given batch along with any events that are still buffered, spooling
+// whatever can't be written so that it's written once the firehose is started again.
+func (f *eventFirehose) flush(batch []*model.FirehoseEvent) {
+	batch = append(batch, f.takeAll()...)
+	spool := f.getSpool()
+
+	for len(batch) > 0 {
+		size := f.batchSize
+		if size > len(batch) {
+			size = len(batch)
+		}
+
+		if err := f.writer.Write(batch[:size]); err != nil {
+			if spool == nil {
+				mlog.Error("Failed to write events to the firehose while stopping, so they were dropped", mlog.Int("events", len(batch)), mlog.Err(err))
+			} else if err := spool.append(batch); err != nil {
+				mlog.Error("Failed to write events to the firehose while stopping, and failed to spool them, so they were dropped", mlog.Int("events", len(batch)), mlog.Err(err))
+			}
+			break
+		}
+		batch = batch[size:]
+	}
+
+	if dropped := f.takeDropped(); dropped > 0 {
+		mlog.Error("Events were dropped because they didn't fit in the event firehose's buffer and couldn't be spooled", mlog.Int("dropped", dropped))
+	}
+
+	if spool != nil {
+		if err := spool.close(); err != nil {
+			mlog.Error("Failed to close the event firehose's spool", mlog.Err(err))
+		}
+	}
+}
+
+// httpFirehoseWriter posts each batch of events to an HTTP endpoint as a JSON array, treating any response other
+// than a 2xx as a failure.
+type httpFirehoseWriter struct {
+	client   *http.Client
+	endpoint string
+}
+
+func (w *httpFirehoseWriter) Write(events []*model.FirehoseEvent) error {
+	req, err := http.NewRequest("POST", w.endpoint, bytes.NewBufferString(model.FirehoseEventListToJson(events)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("firehose endpoint returned status %v", resp.StatusCode)
+	}
+
+	return nil
+}
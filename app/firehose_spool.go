@@ -0,0 +1,191 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const FIREHOSE_SPOOL_SEGMENT_EXTENSION = ".jsonl"
+
+// firehoseSpool keeps firehose events on disk until they've been written, as segments of up to segmentSize events
+// stored one per line. Segments are only removed once their events have been written, so events that are spooled
+// survive the firehose being stopped or the server restarting.
+type firehoseSpool struct {
+	directory   string
+	segmentSize int
+
+	mutex        sync.Mutex
+	segments     []string
+	current      *os.File
+	currentName  string
+	currentCount int
+}
+
+// newFirehoseSpool opens the spool in a directory, creating the directory if needed, and picks up any segments that
+// were left there by a previous run.
+func newFirehoseSpool(directory string, segmentSize int) (*firehoseSpool, error) {
+	if err := os.MkdirAll(directory, 0750); err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	spool := &firehoseSpool{
+		directory:   directory,
+		segmentSize: segmentSize,
+	}
+
+	// Segments are named after when they were created, and ReadDir sorts by name, so they're oldest first.
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), FIREHOSE_SPOOL_SEGMENT_EXTENSION) {
+			spool.segments = append(spool.segments, file.Name())
+		}
+	}
+
+	return spool, nil
+}
+
+// empty returns whether there are no spooled events waiting to be written.
+func (s *firehoseSpool) empty() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.segments) == 0 && s.currentCount == 0
+}
+
+// append adds events to the end of the spool.
+func (s *firehoseSpool) append(events []*model.FirehoseEvent) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, event := range events {
+		if s.current == nil {
+			name := fmt.Sprintf("%020d-%s%s", model.GetMillis(), model.NewId(), FIREHOSE_SPOOL_SEGMENT_EXTENSION)
+			file, err := os.OpenFile(filepath.Join(s.directory, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+			if err != nil {
+				return err
+			}
+
+			s.current = file
+			s.currentName = name
+			s.currentCount = 0
+		}
+
+		if _, err := s.current.WriteString(event.ToJson() + "\n"); err != nil {
+			return err
+		}
+		s.currentCount++
+
+		if s.currentCount >= s.segmentSize {
+			if err := s.closeCurrent(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// closeCurrent finishes the segment being appended to, if there is one, so that it can be read. The caller must hold
+// the mutex.
+func (s *firehoseSpool) closeCurrent() error {
+	if s.current == nil {
+		return nil
+	}
+
+	err := s.current.Close()
+	s.segments = append(s.segments, s.currentName)
+	s.current = nil
+	s.currentName = ""
+	s.currentCount = 0
+
+	return err
+}
+
+// next returns the name and events of the oldest segment, or an empty name if nothing is spooled. The segment stays
+// in the spool until it's removed.
+func (s *firehoseSpool) next() (string, []*model.FirehoseEvent, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.segments) == 0 {
+		if err := s.closeCurrent(); err != nil {
+			return "", nil, err
+		}
+
+		if len(s.segments) == 0 {
+			return "", nil, nil
+		}
+	}
+
+	name := s.segments[0]
+	file, err := os.Open(filepath.Join(s.directory, name))
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	var events []*model.FirehoseEvent
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var event *model.FirehoseEvent
+			if jsonErr := json.Unmarshal(line, &event); jsonErr != nil || event == nil {
+				// The last line of a segment may have been cut short if the server stopped while writing it.
+				mlog.Warn("Skipping an unreadable event in the firehose spool", mlog.String("segment", name))
+			} else {
+				events = append(events, event)
+			}
+		}
+
+		if err == io.EOF {
+			return name, events, nil
+		} else if err != nil {
+			return "", nil, err
+		}
+	}
+}
+
+// remove deletes a segment returned by next once its events have been written.
+func (s *firehoseSpool) remove(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.Remove(filepath.Join(s.directory, name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for i, segment := range s.segments {
+		if segment == name {
+			s.segments = append(s.segments[:i], s.segments[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// close finishes the segment being appended to. The spool can be opened again later to write the events it holds.
+func (s *firehoseSpool) close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.closeCurrent()
+}
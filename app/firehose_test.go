@@ -0,0 +1,264 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+type fakeFirehoseWriter struct {
+	mutex    sync.Mutex
+	events   []*model.FirehoseEvent
+	batches  int
+	failures int
+}
+
+func (w *fakeFirehoseWriter) Write(events []*model.FirehoseEvent) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.failures > 0 {
+		w.failures--
+		return errors.New("unavailable")
+	}
+
+	w.events = append(w.events, events...)
+	w.batches++
+	return nil
+}
+
+func (w *fakeFirehoseWriter) written() []*model.FirehoseEvent {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return append([]*model.FirehoseEvent{}, w.events...)
+}
+
+func (w *fakeFirehoseWriter) eventsOfType(eventType string) []*model.FirehoseEvent {
+	var events []*model.FirehoseEvent
+	for _, event := range w.written() {
+		if event.Type == eventType {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func waitForFirehose(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		require.True(t, time.Now().Before(deadline), "timed out waiting for firehose events")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestEventFirehose(t *testing.T) {
+	t.Run("batches are written once full", func(t *testing.T) {
+		writer := &fakeFirehoseWriter{}
+		firehose := newEventFirehose(writer, 10, 2, time.Hour)
+		firehose.start()
+		defer firehose.stop()
+
+		for i := 0; i < 4; i++ {
+			require.False(t, firehose.add(&model.FirehoseEvent{Id: model.NewId()}))
+		}
+
+		waitForFirehose(t, func() bool { return len(writer.written()) == 4 })
+		assert.Equal(t, 2, writer.batches)
+	})
+
+	t.Run("partial batches are written after the flush interval", func(t *testing.T) {
+		writer := &fakeFirehoseWriter{}
+		firehose := newEventFirehose(writer, 10, 5, 10*time.Millisecond)
+		firehose.start()
+		defer firehose.stop()
+
+		require.False(t, firehose.add(&model.FirehoseEvent{Id: model.NewId()}))
+
+		waitForFirehose(t, func() bool { return len(writer.written()) == 1 })
+	})
+
+	t.Run("failed batches are retried", func(t *testing.T) {
+		writer := &fakeFirehoseWriter{failures: 1}
+		firehose := newEventFirehose(writer, 10, 1, time.Hour)
+		firehose.start()
+		defer firehose.stop()
+
+		event := &model.FirehoseEvent{Id: model.NewId()}
+		require.False(t, firehose.add(event))
+
+		waitForFirehose(t, func() bool { return len(writer.written()) == 1 })
+		assert.Equal(t, event.Id, writer.written()[0].Id)
+	})
+
+	t.Run("buffered events are written when stopping", func(t *testing.T) {
+		writer := &fakeFirehoseWriter{}
+		firehose := newEventFirehose(writer, 10, 5, time.Hour)
+		firehose.start()
+
+		require.False(t, firehose.add(&model.FirehoseEvent{Id: model.NewId()}))
+		require.False(t, firehose.add(&model.FirehoseEvent{Id: model.NewId()}))
+		firehose.stop()
+
+		assert.Len(t, writer.written(), 2)
+	})
+
+	t.Run("events that don't fit in the buffer are dropped without a spool", func(t *testing.T) {
+		writer := &fakeFirehoseWriter{}
+		firehose := newEventFirehose(writer, 2, 1, time.Hour)
+
+		var ids []string
+		for i := 0; i < 4; i++ {
+			ids = append(ids, model.NewId())
+		}
+
+		assert.False(t, firehose.add(&model.FirehoseEvent{Id: ids[0]}))
+		assert.False(t, firehose.add(&model.FirehoseEvent{Id: ids[1]}))
+		assert.True(t, firehose.add(&model.FirehoseEvent{Id: ids[2]}), "should report that the buffer is full")
+		assert.False(t, firehose.add(&model.FirehoseEvent{Id: ids[3]}), "should only report that the buffer is full once")
+		assert.Equal(t, 2, firehose.takeDropped())
+
+		firehose.start()
+		waitForFirehose(t, func() bool { return len(writer.written()) == 2 })
+		firehose.stop()
+
+		for i, event := range writer.written() {
+			assert.Equal(t, ids[i], event.Id)
+		}
+	})
+
+	t.Run("events that don't fit in the buffer are spooled", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "firehose")
+		require.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		writer := &fakeFirehoseWriter{}
+		firehose := newEventFirehose(writer, 2, 1, 10*time.Millisecond)
+		require.Nil(t, firehose.openSpool(dir))
+
+		var ids []string
+		for i := 0; i < 4; i++ {
+			ids = append(ids, model.NewId())
+		}
+
+		assert.False(t, firehose.add(&model.FirehoseEvent{Id: ids[0]}))
+		assert.False(t, firehose.add(&model.FirehoseEvent{Id: ids[1]}))
+		assert.True(t, firehose.add(&model.FirehoseEvent{Id: ids[2]}), "should report that the buffer is full")
+		assert.False(t, firehose.add(&model.FirehoseEvent{Id: ids[3]}), "should only report that the buffer is full once")
+		assert.Zero(t, firehose.takeDropped())
+
+		firehose.start()
+		waitForFirehose(t, func() bool { return len(writer.written()) == 4 })
+		firehose.stop()
+
+		for i, event := range writer.written() {
+			assert.Equal(t, ids[i], event.Id)
+		}
+
+		files, err := ioutil.ReadDir(dir)
+		require.Nil(t, err)
+		assert.Empty(t, files, "written events should be removed from the spool")
+	})
+
+	t.Run("events that can't be written when stopping are written after a restart", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "firehose")
+		require.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		writer := &fakeFirehoseWriter{failures: 1}
+		firehose := newEventFirehose(writer, 10, 5, time.Hour)
+		require.Nil(t, firehose.openSpool(dir))
+		firehose.start()
+
+		event := &model.FirehoseEvent{Id: model.NewId()}
+		require.False(t, firehose.add(event))
+		firehose.stop()
+		assert.Empty(t, writer.written())
+
+		firehose = newEventFirehose(writer, 10, 5, 10*time.Millisecond)
+		require.Nil(t, firehose.openSpool(dir))
+		firehose.start()
+		defer firehose.stop()
+
+		waitForFirehose(t, func() bool { return len(writer.written()) == 1 })
+		assert.Equal(t, event.Id, writer.written()[0].Id)
+	})
+}
+
+func TestHTTPFirehoseWriter(t *testing.T) {
+	var received []*model.FirehoseEvent
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		received = model.FirehoseEventListFromJson(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	writer := &httpFirehoseWriter{client: http.DefaultClient, endpoint: server.URL}
+	events := []*model.FirehoseEvent{{Id: model.NewId(), Type: model.FIREHOSE_EVENT_POST_CREATED}}
+
+	require.Nil(t, writer.Write(events))
+	require.Len(t, received, 1)
+	assert.Equal(t, events[0].Id, received[0].Id)
+
+	status = http.StatusServiceUnavailable
+	assert.NotNil(t, writer.Write(events))
+}
+
+func TestPublishFirehoseEvents(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	writer := &fakeFirehoseWriter{}
+	th.App.Srv.FirehoseWriter = writer
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.FirehoseSettings.Enable = true
+		*cfg.FirehoseSettings.Destination = model.FIREHOSE_DESTINATION_CUSTOM
+		*cfg.FirehoseSettings.FlushIntervalMilliseconds = 10
+	})
+	defer th.App.UpdateConfig(func(cfg *model.Config) { *cfg.FirehoseSettings.Enable = false })
+
+	post := th.CreatePost(th.BasicChannel)
+	_, err := th.App.DeletePost(post.Id, th.BasicUser.Id)
+	require.Nil(t, err)
+
+	user := th.CreateUser()
+	th.LinkUserToTeam(user, th.BasicTeam)
+	th.AddUserToChannel(user, th.BasicChannel)
+	require.Nil(t, th.App.RemoveUserFromChannel(user.Id, th.BasicUser.Id, th.BasicChannel))
+
+	waitForFirehose(t, func() bool {
+		return len(writer.eventsOfType(model.FIREHOSE_EVENT_CHANNEL_MEMBER_REMOVED)) == 1
+	})
+
+	created := writer.eventsOfType(model.FIREHOSE_EVENT_POST_CREATED)
+	require.Len(t, created, 1)
+	assert.Equal(t, th.BasicTeam.Id, created[0].TeamId)
+	assert.Equal(t, th.BasicChannel.Id, created[0].ChannelId)
+	assert.Equal(t, th.BasicUser.Id, created[0].UserId)
+	assert.Equal(t, post.Id, model.PostFromJson(strings.NewReader(string(created[0].Data))).Id)
+
+	require.Len(t, writer.eventsOfType(model.FIREHOSE_EVENT_POST_DELETED), 1)
+
+	userCreated := writer.eventsOfType(model.FIREHOSE_EVENT_USER_CREATED)
+	require.Len(t, userCreated, 1)
+	assert.Equal(t, user.Id, userCreated[0].UserId)
+	assert.NotContains(t, string(userCreated[0].Data), "\"password\"")
+
+	require.Len(t, writer.eventsOfType(model.FIREHOSE_EVENT_TEAM_MEMBER_ADDED), 1)
+	assert.NotEmpty(t, writer.eventsOfType(model.FIREHOSE_EVENT_CHANNEL_MEMBER_ADDED))
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// getOnlineUserIds returns which of the given users are online, as @here decides who it notifies. Statuses that aren't
+// in the status cache, which is kept in sync across the cluster, are read from the database that every node saves
+// them to, rather than the user being taken to be offline because this node hasn't seen them lately.
+func (a *App) getOnlineUserIds(userIds []string) map[string]bool {
+	online := make(map[string]bool)
+
+	statuses, err := a.GetStatusesByIds(userIds)
+	if err != nil {
+		mlog.Warn("Failed to get statuses for @here, only using cached ones", mlog.Err(err))
+
+		for _, userId := range userIds {
+			if status := GetStatusFromCache(userId); status != nil && status.Status == model.STATUS_ONLINE {
+				online[userId] = true
+			}
+		}
+		return online
+	}
+
+	for userId, status := range statuses {
+		if status == model.STATUS_ONLINE {
+			online[userId] = true
+		}
+	}
+	return online
+}
+
+// setHereMentionKeywords makes @here mention whichever of the users that @channel would mention are online, taking their
+// presence from across the cluster rather than just this node's status cache. That means reading their statuses, so
+// it's only done for posts that use @here.
+func (a *App) setHereMentionKeywords(keywords map[string][]string) {
+	delete(keywords, "@here")
+
+	userIds := keywords["@channel"]
+	if len(userIds) == 0 {
+		return
+	}
+
+	onlineUserIds := a.getOnlineUserIds(userIds)
+	for _, userId := range userIds {
+		if onlineUserIds[userId] {
+			keywords["@here"] = append(keywords["@here"], userId)
+		}
+	}
+}
+
+// saveHereMentions records who an @here in a post notified, so that clients show the same users as mentioned no matter
+// who they see as online now. Someone who goes offline between the post being written and it being made isn't
+// notified or recorded, since presence is only ever taken from when the post is made.
+func (a *App) saveHereMentions(post *model.Post, userIds []string) {
+	mentions := &model.PostHereMentions{PostId: post.Id, UserIds: userIds}
+	if result := <-a.Srv.Store.Post().SaveHereMentions(mentions); result.Err != nil {
+		mlog.Warn("Failed to record who @here mentioned", mlog.String("post_id", post.Id), mlog.Err(result.Err))
+	}
+}
+
+// GetPostHereMentions returns who an @here in a post notified. Posts without an @here, or made before they started
+// being recorded, have none.
+func (a *App) GetPostHereMentions(postId string) (*model.PostHereMentions, *model.AppError) {
+	result := <-a.Srv.Store.Post().GetHereMentions(postId)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return result.Data.(*model.PostHereMentions), nil
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestHereMentions(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	user3 := th.CreateUser()
+	th.LinkUserToTeam(user3, th.BasicTeam)
+	th.AddUserToChannel(th.BasicUser2, th.BasicChannel)
+	th.AddUserToChannel(user3, th.BasicChannel)
+
+	// BasicUser2 is online according to another node of the cluster, but isn't in this node's cache
+	require.Nil(t, (<-th.App.Srv.Store.Status().SaveOrUpdate(&model.Status{UserId: th.BasicUser2.Id, Status: model.STATUS_ONLINE})).Err)
+	statusCache.Remove(th.BasicUser2.Id)
+	th.App.AddStatusCacheSkipClusterSend(&model.Status{UserId: user3.Id, Status: model.STATUS_OFFLINE})
+	th.App.AddStatusCacheSkipClusterSend(&model.Status{UserId: th.BasicUser.Id, Status: model.STATUS_ONLINE})
+
+	t.Run("who was online when the post was made is recorded", func(t *testing.T) {
+		post, err := th.App.CreatePostMissingChannel(&model.Post{
+			UserId:    th.BasicUser.Id,
+			ChannelId: th.BasicChannel.Id,
+			Message:   "@here the build is broken",
+		}, false)
+		require.Nil(t, err)
+
+		mentions, err := th.App.GetPostHereMentions(post.Id)
+		require.Nil(t, err)
+		assert.Equal(t, model.StringArray{th.BasicUser2.Id}, mentions.UserIds)
+
+		// Going offline afterwards doesn't change who was mentioned
+		th.App.AddStatusCacheSkipClusterSend(&model.Status{UserId: th.BasicUser2.Id, Status: model.STATUS_OFFLINE})
+		mentions, err = th.App.GetPostHereMentions(post.Id)
+		require.Nil(t, err)
+		assert.Equal(t, model.StringArray{th.BasicUser2.Id}, mentions.UserIds)
+	})
+
+	t.Run("nobody being online is recorded", func(t *testing.T) {
+		th.App.AddStatusCacheSkipClusterSend(&model.Status{UserId: th.BasicUser2.Id, Status: model.STATUS_OFFLINE})
+
+		post, err := th.App.CreatePostMissingChannel(&model.Post{
+			UserId:    th.BasicUser.Id,
+			ChannelId: th.BasicChannel.Id,
+			Message:   "@here anyone?",
+		}, false)
+		require.Nil(t, err)
+
+		mentions, err := th.App.GetPostHereMentions(post.Id)
+		require.Nil(t, err)
+		assert.Empty(t, mentions.UserIds)
+	})
+
+	t.Run("posts without @here have none", func(t *testing.T) {
+		post, err := th.App.CreatePostMissingChannel(&model.Post{
+			UserId:    th.BasicUser.Id,
+			ChannelId: th.BasicChannel.Id,
+			Message:   "hello",
+		}, false)
+		require.Nil(t, err)
+
+		_, err = th.App.GetPostHereMentions(post.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, http.StatusNotFound, err.StatusCode)
+	})
+}
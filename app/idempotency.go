@@ -0,0 +1,121 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const (
+	IDEMPOTENCY_KEY_MAX_LENGTH = 255
+)
+
+// IdempotentResponse is the response to a request that was made with an idempotency key, which is sent again instead
+// of repeating the request when it's retried with the same key.
+type IdempotentResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// StartIdempotentRequest is called before handling a request made by a user with an idempotency key. It returns the
+// response to an earlier request with the same key if there was one, in which case the request mustn't be handled
+// again, or an error if that request is still in progress or was made to a different endpoint or with a different
+// body. Otherwise, the request is marked as in progress until FinishIdempotentRequest is called.
+//
+// Requests are tracked in the database so that a retry is recognised by whichever node of the cluster receives it.
+func (a *App) StartIdempotentRequest(userId, key string, r *http.Request, bodyHash string) (*IdempotentResponse, *model.AppError) {
+	if len(key) > IDEMPOTENCY_KEY_MAX_LENGTH {
+		return nil, model.NewAppError("StartIdempotentRequest", "api.context.idempotency_key.invalid.app_error", map[string]interface{}{"Max": IDEMPOTENCY_KEY_MAX_LENGTH}, "", http.StatusBadRequest)
+	}
+
+	request := &model.IdempotentRequest{
+		Id:       model.IdempotentRequestId(userId, key),
+		UserId:   userId,
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		BodyHash: bodyHash,
+		CreateAt: model.GetMillis(),
+	}
+
+	// An expired request is deleted and the save retried once, after which the key is treated as a new one.
+	for attempt := 0; attempt < 2; attempt++ {
+		result := <-a.Srv.Store.IdempotentRequest().Save(request)
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		if result.Data.(bool) {
+			return nil, nil
+		}
+
+		result = <-a.Srv.Store.IdempotentRequest().Get(request.Id)
+		if result.Err != nil {
+			if result.Err.StatusCode == http.StatusNotFound {
+				// The earlier request was deleted in the meantime, so try to save this one again.
+				continue
+			}
+			return nil, result.Err
+		}
+		existing := result.Data.(*model.IdempotentRequest)
+
+		if existing.IsExpired(request.CreateAt) {
+			if result := <-a.Srv.Store.IdempotentRequest().Delete(existing.Id); result.Err != nil {
+				return nil, result.Err
+			}
+			continue
+		}
+
+		if existing.IsInProgress() {
+			return nil, model.NewAppError("StartIdempotentRequest", "api.context.idempotency_key.in_progress.app_error", nil, "user_id="+userId, http.StatusConflict)
+		}
+
+		if !existing.Matches(request.Method, request.Path, request.BodyHash) {
+			return nil, model.NewAppError("StartIdempotentRequest", "api.context.idempotency_key.reused.app_error", nil, "user_id="+userId, http.StatusUnprocessableEntity)
+		}
+
+		response := &IdempotentResponse{
+			StatusCode: existing.StatusCode,
+			Body:       existing.Body,
+		}
+		if err := json.Unmarshal([]byte(existing.Header), &response.Header); err != nil {
+			mlog.Warn("Unable to decode the headers of an idempotent response", mlog.String("user_id", userId), mlog.Err(err))
+		}
+		return response, nil
+	}
+
+	return nil, model.NewAppError("StartIdempotentRequest", "api.context.idempotency_key.in_progress.app_error", nil, "user_id="+userId, http.StatusConflict)
+}
+
+// FinishIdempotentRequest is called once a request started with StartIdempotentRequest has been handled, with its
+// response if it should be sent again when the request is retried, or nil if the request may be repeated instead.
+func (a *App) FinishIdempotentRequest(userId, key string, response *IdempotentResponse) {
+	id := model.IdempotentRequestId(userId, key)
+
+	if response == nil {
+		if result := <-a.Srv.Store.IdempotentRequest().Delete(id); result.Err != nil {
+			mlog.Error("Unable to delete an idempotent request", mlog.String("user_id", userId), mlog.Err(result.Err))
+		}
+		return
+	}
+
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		mlog.Warn("Unable to encode the headers of an idempotent response", mlog.String("user_id", userId), mlog.Err(err))
+		header = []byte("{}")
+	}
+
+	request := &model.IdempotentRequest{
+		Id:         id,
+		StatusCode: response.StatusCode,
+		Header:     string(header),
+		Body:       response.Body,
+	}
+	if result := <-a.Srv.Store.IdempotentRequest().Complete(request); result.Err != nil {
+		mlog.Error("Unable to save an idempotent response", mlog.String("user_id", userId), mlog.Err(result.Err))
+	}
+}
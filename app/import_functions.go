@@ -462,6 +462,8 @@ func (a *App) ImportUser(data *UserImportData, dryRun bool) *model.AppError {
 			}
 		}
 		if hasNotifyPropsChanged {
+			// Imported settings can't override those enforced by admins
+			a.Config().NotificationDefaultSettings.EnforceNotifyProps(user.NotifyProps)
 			if savedUser, err = a.UpdateUserNotifyProps(user.Id, user.NotifyProps); err != nil {
 				return err
 			}
@@ -767,6 +769,9 @@ func (a *App) ImportUserChannels(user *model.User, team *model.Team, teamMember
 				notifyProps[model.MARK_UNREAD_NOTIFY_PROP] = *cdata.NotifyProps.MarkUnread
 			}
 
+			// Imported settings can't override those enforced by admins
+			a.Config().NotificationDefaultSettings.EnforceChannelNotifyProps(notifyProps)
+
 			if _, err := a.UpdateChannelMemberNotifyProps(notifyProps, channel.Id, user.Id); err != nil {
 				return err
 			}
@@ -1283,6 +1288,7 @@ func (a *App) ImportEmoji(data *EmojiImportData, dryRun bool) *model.AppError {
 		if result := <-a.Srv.Store.Emoji().Save(emoji); result.Err != nil {
 			return result.Err
 		}
+		a.InvalidateResponseCache(model.RESPONSE_CACHE_ENDPOINT_EMOJI_LIST)
 	}
 
 	return nil
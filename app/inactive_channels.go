@@ -0,0 +1,226 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+const (
+	AUDIT_ACTION_INACTIVE_CHANNEL_WARNED   = "inactive_channel_warned"
+	AUDIT_ACTION_INACTIVE_CHANNEL_ARCHIVED = "inactive_channel_archived"
+
+	// INACTIVE_CHANNELS_USERNAME is the user that inactive channels are warned and archived by.
+	INACTIVE_CHANNELS_USERNAME = "channel-archiver"
+
+	// INACTIVE_CHANNELS_USER_ID_KEY is the Systems table key holding the id of the user created to warn and archive
+	// inactive channels.
+	INACTIVE_CHANNELS_USER_ID_KEY = "InactiveChannelsUserId"
+)
+
+// ArchiveInactiveChannels warns the channels that are about to become inactive according to the
+// InactiveChannelSettings, or to the team's own InactiveChannelDays, and archives those whose warning period has
+// passed without anyone posting. Default channels and channels that are exempt from archiving are left alone. In dry
+// run mode, it only reports what it would do.
+func (a *App) ArchiveInactiveChannels() (warned int, archived int, err *model.AppError) {
+	teams, err := a.GetAllTeams()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	now := model.GetMillis()
+	for _, team := range teams {
+		if team.DeleteAt != 0 {
+			continue
+		}
+
+		teamWarned, teamArchived, err := a.archiveInactiveTeamChannels(team, now)
+		warned += teamWarned
+		archived += teamArchived
+		if err != nil {
+			return warned, archived, err
+		}
+	}
+
+	return warned, archived, nil
+}
+
+func (a *App) archiveInactiveTeamChannels(team *model.Team, now int64) (warned int, archived int, err *model.AppError) {
+	settings := a.Config().InactiveChannelSettings
+	dryRun := *settings.DryRun
+
+	inactiveDays := *settings.InactiveDays
+	if team.InactiveChannelDays > 0 {
+		inactiveDays = team.InactiveChannelDays
+	}
+	warningDays := *settings.WarningDays
+	if warningDays > inactiveDays {
+		warningDays = inactiveDays
+	}
+
+	inactiveBefore := now - int64(inactiveDays)*DAY_MILLISECONDS
+	warningPeriod := int64(warningDays) * DAY_MILLISECONDS
+
+	// Posting the warning makes the channel look active, so channels that were warned long enough ago need to be
+	// looked at as well as the ones that are about to become inactive.
+	before := inactiveBefore + warningPeriod
+	if now-warningPeriod > before {
+		before = now - warningPeriod
+	}
+
+	result := <-a.Srv.Store.Channel().GetInactive(team.Id, before)
+	if result.Err != nil {
+		return 0, 0, result.Err
+	}
+
+	exempt := map[string]bool{}
+	for _, name := range a.DefaultChannelNames() {
+		exempt[name] = true
+	}
+
+	var archiver *model.User
+	if !dryRun {
+		if archiver, err = a.getInactiveChannelsUser(); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	for _, channel := range *result.Data.(*model.ChannelList) {
+		if channel.ExemptFromArchiving || exempt[channel.Name] {
+			continue
+		}
+
+		warnedAt, appErr := a.getInactiveChannelWarnedAt(channel)
+		if appErr != nil {
+			return warned, archived, appErr
+		}
+
+		lastActive := channel.LastPostAt
+		if channel.CreateAt > lastActive {
+			lastActive = channel.CreateAt
+		}
+
+		if (warnedAt != 0 && warnedAt <= now-warningPeriod) || (warningPeriod == 0 && lastActive < inactiveBefore) {
+			if dryRun {
+				mlog.Info("Dry run: would archive inactive channel", mlog.String("channel_id", channel.Id), mlog.Int64("last_post_at", lastActive))
+			} else if appErr := a.archiveInactiveChannel(channel, archiver, lastActive); appErr != nil {
+				return warned, archived, appErr
+			}
+			archived++
+		} else if warnedAt == 0 && warningPeriod != 0 && lastActive < inactiveBefore+warningPeriod {
+			if dryRun {
+				mlog.Info("Dry run: would warn inactive channel", mlog.String("channel_id", channel.Id), mlog.Int64("last_post_at", lastActive))
+			} else if appErr := a.warnInactiveChannel(channel, archiver, lastActive, now+warningPeriod); appErr != nil {
+				return warned, archived, appErr
+			}
+			warned++
+		}
+	}
+
+	return warned, archived, nil
+}
+
+// getInactiveChannelsUser returns the user that inactive channels are warned and archived by, creating it if it
+// doesn't exist yet. It's created deactivated so that nobody can log in as it and it isn't counted as a user. The id
+// of the user it creates is recorded in the Systems table, and a user with the same username that wasn't created
+// this way is never used, since anyone could have registered it.
+func (a *App) getInactiveChannelsUser() (*model.User, *model.AppError) {
+	if result := <-a.Srv.Store.System().GetByName(INACTIVE_CHANNELS_USER_ID_KEY); result.Err == nil {
+		userResult := <-a.Srv.Store.User().Get(result.Data.(*model.System).Value)
+		if userResult.Err != nil {
+			return nil, userResult.Err
+		}
+		return userResult.Data.(*model.User), nil
+	}
+
+	if result := <-a.Srv.Store.User().GetByUsername(INACTIVE_CHANNELS_USERNAME); result.Err == nil {
+		return nil, model.NewAppError("getInactiveChannelsUser", "app.inactive_channels.username_taken.app_error", nil, "user_id="+result.Data.(*model.User).Id, http.StatusInternalServerError)
+	}
+
+	user := &model.User{
+		Username: INACTIVE_CHANNELS_USERNAME,
+		Nickname: "Channel Archiver",
+		Email:    INACTIVE_CHANNELS_USERNAME + "@localhost",
+		Password: model.NewId(),
+		Roles:    model.SYSTEM_USER_ROLE_ID,
+		DeleteAt: model.GetMillis(),
+	}
+
+	result := <-a.Srv.Store.User().Save(user)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	user = result.Data.(*model.User)
+
+	if result := <-a.Srv.Store.System().Save(&model.System{Name: INACTIVE_CHANNELS_USER_ID_KEY, Value: user.Id}); result.Err != nil {
+		return nil, result.Err
+	}
+
+	return user, nil
+}
+
+// getInactiveChannelWarnedAt returns when the channel was warned that it's about to be archived, or 0 if anyone has
+// posted in it since.
+func (a *App) getInactiveChannelWarnedAt(channel *model.Channel) (int64, *model.AppError) {
+	result := <-a.Srv.Store.Post().GetPosts(channel.Id, 0, 1, false)
+	if result.Err != nil {
+		return 0, result.Err
+	}
+	list := result.Data.(*model.PostList)
+
+	if len(list.Order) == 0 {
+		return 0, nil
+	}
+
+	if post := list.Posts[list.Order[0]]; post.Type == model.POST_CHANNEL_INACTIVE {
+		return post.CreateAt, nil
+	}
+
+	return 0, nil
+}
+
+func (a *App) warnInactiveChannel(channel *model.Channel, archiver *model.User, lastActive int64, archiveAt int64) *model.AppError {
+	days := (model.GetMillis() - lastActive) / DAY_MILLISECONDS
+
+	post := &model.Post{
+		ChannelId: channel.Id,
+		UserId:    archiver.Id,
+		Type:      model.POST_CHANNEL_INACTIVE,
+		Message: utils.T("app.channel.inactive_channel_warning.message", map[string]interface{}{
+			"Days": days,
+			"Date": utils.TimeFromMillis(archiveAt).Format("January 2, 2006"),
+		}),
+	}
+
+	if _, err := a.CreatePost(post, channel, false); err != nil {
+		return err
+	}
+
+	a.saveInactiveChannelAudit(channel, AUDIT_ACTION_INACTIVE_CHANNEL_WARNED, lastActive)
+	return nil
+}
+
+func (a *App) archiveInactiveChannel(channel *model.Channel, archiver *model.User, lastActive int64) *model.AppError {
+	if err := a.DeleteChannel(channel, archiver.Id); err != nil {
+		return err
+	}
+
+	a.saveInactiveChannelAudit(channel, AUDIT_ACTION_INACTIVE_CHANNEL_ARCHIVED, lastActive)
+	return nil
+}
+
+func (a *App) saveInactiveChannelAudit(channel *model.Channel, action string, lastActive int64) {
+	audit := &model.Audit{
+		Action:    action,
+		ExtraInfo: fmt.Sprintf("channel_id=%v team_id=%v last_post_at=%v", channel.Id, channel.TeamId, lastActive),
+	}
+	if result := <-a.Srv.Store.Audit().Save(audit); result.Err != nil {
+		mlog.Error("Failed to save inactive channel audit", mlog.String("channel_id", channel.Id), mlog.Err(result.Err))
+	}
+}
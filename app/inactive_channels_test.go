@@ -0,0 +1,193 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/store/sqlstore"
+)
+
+func TestArchiveInactiveChannels(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	sqlStore := th.App.Srv.Store.Channel().(*sqlstore.SqlChannelStore)
+	now := model.GetMillis()
+
+	makeDormant := func(channel *model.Channel, lastPostAt int64) {
+		_, err := sqlStore.GetMaster().Exec("UPDATE Channels SET CreateAt = :Time, LastPostAt = :Time WHERE Id = :ChannelId", map[string]interface{}{"Time": lastPostAt, "ChannelId": channel.Id})
+		require.Nil(t, err)
+	}
+
+	dormant := th.CreateChannel(th.BasicTeam)
+	makeDormant(dormant, now-60*DAY_MILLISECONDS)
+
+	exempt := th.CreateChannel(th.BasicTeam)
+	exempt.ExemptFromArchiving = true
+	_, appErr := th.App.UpdateChannel(exempt)
+	require.Nil(t, appErr)
+	makeDormant(exempt, now-60*DAY_MILLISECONDS)
+
+	townSquare, appErr := th.App.GetChannelByName(model.DEFAULT_CHANNEL, th.BasicTeam.Id, false)
+	require.Nil(t, appErr)
+	makeDormant(townSquare, now-60*DAY_MILLISECONDS)
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.InactiveChannelSettings.InactiveDays = 30
+		*cfg.InactiveChannelSettings.WarningDays = 7
+		*cfg.InactiveChannelSettings.DryRun = true
+	})
+
+	t.Run("dry run makes no changes", func(t *testing.T) {
+		warned, archived, err := th.App.archiveInactiveTeamChannels(th.BasicTeam, now)
+		require.Nil(t, err)
+		assert.Equal(t, 1, warned)
+		assert.Equal(t, 0, archived)
+
+		warnedAt, err := th.App.getInactiveChannelWarnedAt(dormant)
+		require.Nil(t, err)
+		assert.Zero(t, warnedAt)
+	})
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.InactiveChannelSettings.DryRun = false
+	})
+
+	t.Run("inactive channels are warned first", func(t *testing.T) {
+		warned, archived, err := th.App.archiveInactiveTeamChannels(th.BasicTeam, now)
+		require.Nil(t, err)
+		assert.Equal(t, 1, warned)
+		assert.Equal(t, 0, archived)
+
+		warnedAt, err := th.App.getInactiveChannelWarnedAt(dormant)
+		require.Nil(t, err)
+		assert.NotZero(t, warnedAt)
+
+		archiver, err := th.App.GetUserByUsername(INACTIVE_CHANNELS_USERNAME)
+		require.Nil(t, err)
+		assert.NotZero(t, archiver.DeleteAt)
+
+		posts, err := th.App.GetPosts(dormant.Id, 0, 1)
+		require.Nil(t, err)
+		require.Len(t, posts.Order, 1)
+		assert.Equal(t, archiver.Id, posts.Posts[posts.Order[0]].UserId)
+
+		warnedAt, err = th.App.getInactiveChannelWarnedAt(exempt)
+		require.Nil(t, err)
+		assert.Zero(t, warnedAt)
+
+		// Running again during the warning period does nothing.
+		warned, archived, err = th.App.archiveInactiveTeamChannels(th.BasicTeam, model.GetMillis())
+		require.Nil(t, err)
+		assert.Equal(t, 0, warned)
+		assert.Equal(t, 0, archived)
+	})
+
+	t.Run("channels are archived after the warning period", func(t *testing.T) {
+		warned, archived, err := th.App.archiveInactiveTeamChannels(th.BasicTeam, model.GetMillis()+8*DAY_MILLISECONDS)
+		require.Nil(t, err)
+		assert.Equal(t, 0, warned)
+		assert.Equal(t, 1, archived)
+
+		channel, err := th.App.GetChannel(dormant.Id)
+		require.Nil(t, err)
+		assert.NotZero(t, channel.DeleteAt)
+
+		channel, err = th.App.GetChannel(exempt.Id)
+		require.Nil(t, err)
+		assert.Zero(t, channel.DeleteAt)
+
+		channel, err = th.App.GetChannel(townSquare.Id)
+		require.Nil(t, err)
+		assert.Zero(t, channel.DeleteAt)
+
+		audits, err := th.App.GetAudits("", 100)
+		require.Nil(t, err)
+		actions := []string{}
+		for _, audit := range audits {
+			actions = append(actions, audit.Action)
+		}
+		assert.Contains(t, actions, AUDIT_ACTION_INACTIVE_CHANNEL_WARNED)
+		assert.Contains(t, actions, AUDIT_ACTION_INACTIVE_CHANNEL_ARCHIVED)
+	})
+
+	t.Run("posting cancels the warning", func(t *testing.T) {
+		channel := th.CreateChannel(th.BasicTeam)
+		makeDormant(channel, now-60*DAY_MILLISECONDS)
+
+		warned, _, err := th.App.archiveInactiveTeamChannels(th.BasicTeam, now)
+		require.Nil(t, err)
+		assert.Equal(t, 1, warned)
+
+		th.CreatePost(channel)
+
+		warned, archived, err := th.App.archiveInactiveTeamChannels(th.BasicTeam, model.GetMillis()+8*DAY_MILLISECONDS)
+		require.Nil(t, err)
+		assert.Equal(t, 0, warned)
+		assert.Equal(t, 0, archived)
+	})
+
+	t.Run("channels without a creator are archived", func(t *testing.T) {
+		channel := th.CreateChannel(th.BasicTeam)
+		_, err := sqlStore.GetMaster().Exec("UPDATE Channels SET CreatorId = '' WHERE Id = :ChannelId", map[string]interface{}{"ChannelId": channel.Id})
+		require.Nil(t, err)
+		th.App.InvalidateCacheForChannel(channel)
+		makeDormant(channel, now-60*DAY_MILLISECONDS)
+
+		warned, _, appErr := th.App.archiveInactiveTeamChannels(th.BasicTeam, now)
+		require.Nil(t, appErr)
+		assert.Equal(t, 1, warned)
+
+		_, archived, appErr := th.App.archiveInactiveTeamChannels(th.BasicTeam, model.GetMillis()+8*DAY_MILLISECONDS)
+		require.Nil(t, appErr)
+		assert.Equal(t, 1, archived)
+
+		channel, appErr = th.App.GetChannel(channel.Id)
+		require.Nil(t, appErr)
+		assert.NotZero(t, channel.DeleteAt)
+	})
+
+	t.Run("a user with the archiver's username that the server didn't create isn't used", func(t *testing.T) {
+		archiver, err := th.App.getInactiveChannelsUser()
+		require.Nil(t, err)
+
+		result := <-th.App.Srv.Store.System().PermanentDeleteByName(INACTIVE_CHANNELS_USER_ID_KEY)
+		require.Nil(t, result.Err)
+		defer func() {
+			result := <-th.App.Srv.Store.System().SaveOrUpdate(&model.System{Name: INACTIVE_CHANNELS_USER_ID_KEY, Value: archiver.Id})
+			require.Nil(t, result.Err)
+		}()
+
+		_, err = th.App.getInactiveChannelsUser()
+		require.NotNil(t, err)
+		assert.Equal(t, "app.inactive_channels.username_taken.app_error", err.Id)
+	})
+
+	t.Run("teams can override the inactivity threshold", func(t *testing.T) {
+		team := th.CreateTeam()
+		team.InactiveChannelDays = 90
+		team, err := th.App.UpdateTeam(team)
+		require.Nil(t, err)
+
+		channel := th.CreateChannel(team)
+		makeDormant(channel, now-60*DAY_MILLISECONDS)
+
+		warned, archived, err := th.App.archiveInactiveTeamChannels(team, now)
+		require.Nil(t, err)
+		assert.Equal(t, 0, warned)
+		assert.Equal(t, 0, archived)
+
+		makeDormant(channel, now-100*DAY_MILLISECONDS)
+
+		warned, archived, err = th.App.archiveInactiveTeamChannels(team, now)
+		require.Nil(t, err)
+		assert.Equal(t, 1, warned)
+		assert.Equal(t, 0, archived)
+	})
+}
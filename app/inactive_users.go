@@ -0,0 +1,198 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+const (
+	INACTIVE_USERS_BATCH_SIZE = 1000
+
+	AUDIT_ACTION_INACTIVE_USER_WARNED      = "inactive_user_warned"
+	AUDIT_ACTION_INACTIVE_USER_DEACTIVATED = "inactive_user_deactivated"
+)
+
+// lastActiveAt returns the time a user was last known to be active. Any login or activity counts, and so does
+// account creation so that new accounts aren't deactivated before they are used. For users that sign in with a
+// password, changing it also counts. Users that sign in with SSO are judged by login alone, since their password
+// age is meaningless.
+func lastActiveAt(activity *model.UserLastActivity) int64 {
+	lastActive := activity.LastActivityAt
+	if activity.CreateAt > lastActive {
+		lastActive = activity.CreateAt
+	}
+	if activity.AuthService == "" && activity.LastPasswordUpdate > lastActive {
+		lastActive = activity.LastPasswordUpdate
+	}
+	return lastActive
+}
+
+// DeactivateInactiveUsers warns users who are about to become inactive according to the InactiveUserSettings
+// and deactivates those whose warning period has passed. In dry run mode, it only reports what it would do.
+func (a *App) DeactivateInactiveUsers() (warned int, deactivated int, err *model.AppError) {
+	settings := a.Config().InactiveUserSettings
+	dryRun := *settings.DryRun
+
+	now := model.GetMillis()
+	inactiveBefore := now - int64(*settings.InactiveDays)*DAY_MILLISECONDS
+	warningPeriod := int64(*settings.WarningDays) * DAY_MILLISECONDS
+
+	exempt := map[string]bool{}
+	for _, username := range settings.GetExemptUsernames() {
+		exempt[username] = true
+	}
+
+	afterId := ""
+	for {
+		result := <-a.Srv.Store.User().GetLastActivityAfter(afterId, inactiveBefore+warningPeriod, INACTIVE_USERS_BATCH_SIZE)
+		if result.Err != nil {
+			return warned, deactivated, result.Err
+		}
+		activities := result.Data.([]*model.UserLastActivity)
+
+		for _, activity := range activities {
+			if activity.DeleteAt != 0 || exempt[activity.Username] {
+				continue
+			}
+
+			// Never lock out the administrators that would be needed to undo a mistake.
+			if model.IsInRole(activity.Roles, model.SYSTEM_ADMIN_ROLE_ID) {
+				continue
+			}
+
+			lastActive := lastActiveAt(activity)
+			if lastActive >= inactiveBefore+warningPeriod {
+				continue
+			}
+
+			warnedAt, appErr := a.getInactivityWarnedAt(activity.UserId)
+			if appErr != nil {
+				return warned, deactivated, appErr
+			}
+
+			// A warning only counts if the user hasn't been active since it was sent.
+			if warnedAt <= lastActive {
+				warnedAt = 0
+			}
+
+			if lastActive < inactiveBefore && warnedAt != 0 && warnedAt <= now-warningPeriod {
+				if dryRun {
+					mlog.Info("Dry run: would deactivate inactive user", mlog.String("user_id", activity.UserId), mlog.Int64("last_active_at", lastActive))
+				} else if appErr := a.deactivateInactiveUser(activity, lastActive); appErr != nil {
+					return warned, deactivated, appErr
+				}
+				deactivated++
+			} else if warnedAt == 0 {
+				if dryRun {
+					mlog.Info("Dry run: would warn inactive user", mlog.String("user_id", activity.UserId), mlog.Int64("last_active_at", lastActive))
+				} else if appErr := a.warnInactiveUser(activity, lastActive, now); appErr != nil {
+					return warned, deactivated, appErr
+				}
+				warned++
+			}
+		}
+
+		if len(activities) < INACTIVE_USERS_BATCH_SIZE {
+			return warned, deactivated, nil
+		}
+		afterId = activities[len(activities)-1].UserId
+	}
+}
+
+func (a *App) getInactivityWarnedAt(userId string) (int64, *model.AppError) {
+	result := <-a.Srv.Store.Preference().GetCategory(userId, model.PREFERENCE_CATEGORY_INACTIVITY)
+	if result.Err != nil {
+		return 0, result.Err
+	}
+
+	for _, preference := range result.Data.(model.Preferences) {
+		if preference.Name == model.PREFERENCE_NAME_INACTIVITY_WARNED_AT {
+			warnedAt, _ := strconv.ParseInt(preference.Value, 10, 64)
+			return warnedAt, nil
+		}
+	}
+
+	return 0, nil
+}
+
+func (a *App) warnInactiveUser(activity *model.UserLastActivity, lastActive int64, now int64) *model.AppError {
+	deactivateAt := now + int64(*a.Config().InactiveUserSettings.WarningDays)*DAY_MILLISECONDS
+	if err := a.SendInactivityWarningEmail(activity.Email, deactivateAt, activity.Locale, a.GetSiteURL()); err != nil {
+		mlog.Error("Failed to send inactivity warning email", mlog.String("user_id", activity.UserId), mlog.Err(err))
+	}
+
+	preferences := model.Preferences{{
+		UserId:   activity.UserId,
+		Category: model.PREFERENCE_CATEGORY_INACTIVITY,
+		Name:     model.PREFERENCE_NAME_INACTIVITY_WARNED_AT,
+		Value:    strconv.FormatInt(now, 10),
+	}}
+	if result := <-a.Srv.Store.Preference().Save(&preferences); result.Err != nil {
+		return result.Err
+	}
+
+	a.saveInactiveUserAudit(activity.UserId, AUDIT_ACTION_INACTIVE_USER_WARNED, lastActive)
+	return nil
+}
+
+func (a *App) deactivateInactiveUser(activity *model.UserLastActivity, lastActive int64) *model.AppError {
+	user, err := a.GetUser(activity.UserId)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.UpdateActive(user, false); err != nil {
+		return err
+	}
+
+	if result := <-a.Srv.Store.Preference().Delete(activity.UserId, model.PREFERENCE_CATEGORY_INACTIVITY, model.PREFERENCE_NAME_INACTIVITY_WARNED_AT); result.Err != nil {
+		mlog.Warn("Failed to clear inactivity warning", mlog.String("user_id", activity.UserId), mlog.Err(result.Err))
+	}
+
+	a.saveInactiveUserAudit(activity.UserId, AUDIT_ACTION_INACTIVE_USER_DEACTIVATED, lastActive)
+	return nil
+}
+
+func (a *App) saveInactiveUserAudit(userId string, action string, lastActive int64) {
+	audit := &model.Audit{
+		UserId:    userId,
+		Action:    action,
+		ExtraInfo: fmt.Sprintf("last_active_at=%v", lastActive),
+	}
+	if result := <-a.Srv.Store.Audit().Save(audit); result.Err != nil {
+		mlog.Error("Failed to save inactive user audit", mlog.String("user_id", userId), mlog.Err(result.Err))
+	}
+}
+
+func (a *App) SendInactivityWarningEmail(email string, deactivateAt int64, locale, siteURL string) *model.AppError {
+	T := utils.GetUserTranslations(locale)
+
+	rawUrl, _ := url.Parse(siteURL)
+	date := utils.TimeFromMillis(deactivateAt).Format("January 2, 2006")
+
+	subject := T("api.templates.inactivity_warning_subject",
+		map[string]interface{}{"SiteName": a.ClientConfig()["SiteName"],
+			"ServerURL": rawUrl.Host})
+
+	bodyPage := a.NewEmailTemplate("inactivity_warning_body", locale)
+	bodyPage.Props["SiteURL"] = siteURL
+	bodyPage.Props["Title"] = T("api.templates.inactivity_warning_body.title", map[string]interface{}{"ServerURL": rawUrl.Host})
+	bodyPage.Props["Info"] = T("api.templates.inactivity_warning_body.info",
+		map[string]interface{}{"SiteURL": siteURL, "Date": date})
+	bodyPage.Props["Warning"] = T("api.templates.inactivity_warning_body.warning")
+
+	if err := a.SendMail(email, subject, bodyPage.Render()); err != nil {
+		return model.NewAppError("SendInactivityWarningEmail", "api.user.send_inactivity_warning_email.failed.error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
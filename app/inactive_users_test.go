@@ -0,0 +1,127 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/store/sqlstore"
+)
+
+func TestLastActiveAt(t *testing.T) {
+	activity := &model.UserLastActivity{CreateAt: 100, LastPasswordUpdate: 300, LastActivityAt: 200}
+	assert.Equal(t, int64(300), lastActiveAt(activity))
+
+	activity.AuthService = model.USER_AUTH_SERVICE_GITLAB
+	assert.Equal(t, int64(200), lastActiveAt(activity), "password age should be ignored for SSO users")
+
+	activity.LastActivityAt = 0
+	assert.Equal(t, int64(100), lastActiveAt(activity))
+}
+
+func TestDeactivateInactiveUsers(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	sqlStore := th.App.Srv.Store.User().(*sqlstore.SqlUserStore)
+	longAgo := model.GetMillis() - 60*DAY_MILLISECONDS
+
+	makeDormant := func(user *model.User) {
+		_, err := sqlStore.GetMaster().Exec("UPDATE Users SET CreateAt = :Time, LastPasswordUpdate = :Time WHERE Id = :UserId", map[string]interface{}{"Time": longAgo, "UserId": user.Id})
+		require.Nil(t, err)
+	}
+
+	dormant := th.CreateUser()
+	makeDormant(dormant)
+
+	exempt := th.CreateUser()
+	makeDormant(exempt)
+
+	ssoUser := th.CreateUser()
+	makeDormant(ssoUser)
+	_, err := sqlStore.GetMaster().Exec("UPDATE Users SET AuthService = :AuthService, LastPasswordUpdate = :Now WHERE Id = :UserId", map[string]interface{}{"AuthService": model.USER_AUTH_SERVICE_GITLAB, "Now": model.GetMillis(), "UserId": ssoUser.Id})
+	require.Nil(t, err)
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.InactiveUserSettings.InactiveDays = 30
+		*cfg.InactiveUserSettings.WarningDays = 7
+		*cfg.InactiveUserSettings.ExemptUsernames = exempt.Username
+		*cfg.InactiveUserSettings.DryRun = true
+	})
+
+	t.Run("dry run makes no changes", func(t *testing.T) {
+		warned, deactivated, err := th.App.DeactivateInactiveUsers()
+		require.Nil(t, err)
+		assert.Equal(t, 2, warned)
+		assert.Equal(t, 0, deactivated)
+
+		warnedAt, err := th.App.getInactivityWarnedAt(dormant.Id)
+		require.Nil(t, err)
+		assert.Zero(t, warnedAt)
+	})
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.InactiveUserSettings.DryRun = false
+	})
+
+	t.Run("inactive users are warned first", func(t *testing.T) {
+		warned, deactivated, err := th.App.DeactivateInactiveUsers()
+		require.Nil(t, err)
+		assert.Equal(t, 2, warned)
+		assert.Equal(t, 0, deactivated)
+
+		for _, user := range []*model.User{dormant, ssoUser} {
+			warnedAt, err := th.App.getInactivityWarnedAt(user.Id)
+			require.Nil(t, err)
+			assert.NotZero(t, warnedAt)
+		}
+
+		warnedAt, err := th.App.getInactivityWarnedAt(exempt.Id)
+		require.Nil(t, err)
+		assert.Zero(t, warnedAt)
+
+		// Running again during the warning period does nothing.
+		warned, deactivated, err = th.App.DeactivateInactiveUsers()
+		require.Nil(t, err)
+		assert.Equal(t, 0, warned)
+		assert.Equal(t, 0, deactivated)
+	})
+
+	t.Run("users are deactivated after the warning period", func(t *testing.T) {
+		preferences := model.Preferences{{
+			UserId:   dormant.Id,
+			Category: model.PREFERENCE_CATEGORY_INACTIVITY,
+			Name:     model.PREFERENCE_NAME_INACTIVITY_WARNED_AT,
+			Value:    strconv.FormatInt(model.GetMillis()-8*DAY_MILLISECONDS, 10),
+		}}
+		require.Nil(t, (<-th.App.Srv.Store.Preference().Save(&preferences)).Err)
+
+		warned, deactivated, err := th.App.DeactivateInactiveUsers()
+		require.Nil(t, err)
+		assert.Equal(t, 0, warned)
+		assert.Equal(t, 1, deactivated)
+
+		user, err := th.App.GetUser(dormant.Id)
+		require.Nil(t, err)
+		assert.NotZero(t, user.DeleteAt)
+
+		user, err = th.App.GetUser(ssoUser.Id)
+		require.Nil(t, err)
+		assert.Zero(t, user.DeleteAt)
+
+		audits, err := th.App.GetAudits(dormant.Id, 10)
+		require.Nil(t, err)
+		actions := []string{}
+		for _, audit := range audits {
+			actions = append(actions, audit.Action)
+		}
+		assert.Contains(t, actions, AUDIT_ACTION_INACTIVE_USER_WARNED)
+		assert.Contains(t, actions, AUDIT_ACTION_INACTIVE_USER_DEACTIVATED)
+	})
+}
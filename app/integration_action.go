@@ -140,10 +140,10 @@ func (a *App) DoActionRequest(rawURL string, body []byte) (*http.Response, *mode
 	if (url.Hostname() == "localhost" || url.Hostname() == "127.0.0.1" || url.Hostname() == siteURL.Hostname()) && strings.HasPrefix(url.Path, path.Join(subpath, "plugins")) {
 		httpClient = a.HTTPService.MakeClient(true)
 	} else {
-		httpClient = a.HTTPService.MakeClient(false)
+		httpClient = a.HTTPService.MakeIntegrationClient(a.Config().IntegrationHTTPSettings.Webhooks)
 	}
 
-	resp, httpErr := httpClient.Do(req)
+	resp, httpErr := httpClient.Do(req.WithContext(a.RequestContext()))
 	if httpErr != nil {
 		return nil, model.NewAppError("DoActionRequest", "api.post.do_action.action_integration.app_error", nil, "err="+httpErr.Error(), http.StatusBadRequest)
 	}
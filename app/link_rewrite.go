@@ -0,0 +1,185 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils/markdown"
+)
+
+// LINK_REWRITE_MAX_REDIRECTS is how many shorteners in a row a link is expanded through.
+const LINK_REWRITE_MAX_REDIRECTS = 3
+
+// rewritePostLinks applies the LinkRewriteSettings to the links in a post's message, stripping tracking parameters
+// and expanding shortened links, so that the cleaned up links are what's saved.
+func (a *App) rewritePostLinks(post *model.Post) {
+	settings := a.Config().LinkRewriteSettings
+	if !*settings.Enable {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(*settings.ExpandTimeoutMilliseconds)*time.Millisecond)
+	defer cancel()
+
+	post.Message = rewriteLinks(post.Message, func(link string) string {
+		return a.rewriteLink(ctx, &settings, link)
+	})
+}
+
+// rewriteLinks replaces the destinations of the inline links and autolinks in a Markdown message with what rewrite
+// returns for them. Links in code are left alone.
+func rewriteLinks(message string, rewrite func(link string) string) string {
+	var ranges []markdown.Range
+
+	markdown.Inspect(message, func(blockOrInline interface{}) bool {
+		switch v := blockOrInline.(type) {
+		case *markdown.Autolink:
+			ranges = append(ranges, v.RawDestination)
+		case *markdown.InlineLink:
+			ranges = append(ranges, v.RawDestination)
+		}
+
+		return true
+	})
+
+	// Replace the links from last to first so that the positions of the earlier ones don't move
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Position > ranges[j].Position
+	})
+
+	for _, r := range ranges {
+		link := message[r.Position:r.End]
+		if rewritten := rewrite(link); rewritten != link {
+			message = message[:r.Position] + rewritten + message[r.End:]
+		}
+	}
+
+	return message
+}
+
+func (a *App) rewriteLink(ctx context.Context, settings *model.LinkRewriteSettings, link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return link
+	}
+
+	changed := false
+
+	for i := 0; i < LINK_REWRITE_MAX_REDIRECTS && isLinkShortenerHost(parsed.Hostname(), settings.ExpandShortenerHosts); i++ {
+		expanded, err := a.expandShortenedLink(ctx, parsed)
+		if err != nil {
+			mlog.Debug("Failed to expand a shortened link", mlog.String("link", parsed.String()), mlog.Err(err))
+			break
+		}
+		if expanded == nil {
+			break
+		}
+
+		parsed = expanded
+		changed = true
+	}
+
+	if stripped := stripQueryParams(parsed.RawQuery, settings.StripQueryParams); stripped != parsed.RawQuery {
+		parsed.RawQuery = stripped
+		changed = true
+	}
+
+	// Links that don't need to change are kept exactly as they were written
+	if !changed {
+		return link
+	}
+
+	// Parentheses would end an inline link early, so they're escaped like the rest of the link
+	return linkParenthesesEscaper.Replace(parsed.String())
+}
+
+var linkParenthesesEscaper = strings.NewReplacer("(", "%28", ")", "%29")
+
+// expandShortenedLink returns where a shortened link redirects to, or nil if it doesn't redirect anywhere. The request
+// goes through the same client as link previews, so it's subject to the same restrictions on outgoing connections.
+func (a *App) expandShortenedLink(ctx context.Context, link *url.URL) (*url.URL, error) {
+	request, err := http.NewRequest(http.MethodHead, link.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := a.HTTPService.MakeIntegrationClient(a.Config().IntegrationHTTPSettings.LinkPreviews)
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	response, err := client.Do(request.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+
+	if response.StatusCode < 300 || response.StatusCode >= 400 {
+		return nil, nil
+	}
+
+	location, err := link.Parse(response.Header.Get("Location"))
+	if err != nil || (location.Scheme != "http" && location.Scheme != "https") || location.Host == "" {
+		return nil, err
+	}
+
+	return location, nil
+}
+
+func isLinkShortenerHost(host string, shortenerHosts []string) bool {
+	for _, shortenerHost := range shortenerHosts {
+		if strings.EqualFold(host, shortenerHost) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stripQueryParams removes the given parameters from a query string, where a parameter ending in * matches any with
+// that prefix. The remaining parameters are kept in their original order and encoding.
+func stripQueryParams(rawQuery string, params []string) string {
+	if rawQuery == "" || len(params) == 0 {
+		return rawQuery
+	}
+
+	var kept []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		name := pair
+		if i := strings.IndexByte(pair, '='); i != -1 {
+			name = pair[:i]
+		}
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+
+		if !isStrippedQueryParam(name, params) {
+			kept = append(kept, pair)
+		}
+	}
+
+	return strings.Join(kept, "&")
+}
+
+func isStrippedQueryParam(name string, params []string) bool {
+	for _, param := range params {
+		if strings.HasSuffix(param, "*") {
+			if strings.HasPrefix(name, strings.TrimSuffix(param, "*")) {
+				return true
+			}
+		} else if name == param {
+			return true
+		}
+	}
+
+	return false
+}
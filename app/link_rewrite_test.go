@@ -0,0 +1,133 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestRewriteLinks(t *testing.T) {
+	upper := func(link string) string {
+		return strings.ToUpper(link)
+	}
+
+	for name, tc := range map[string]struct {
+		Message  string
+		Expected string
+	}{
+		"no links":      {Message: "hello world", Expected: "hello world"},
+		"autolink":      {Message: "see https://example.com/a now", Expected: "see HTTPS://EXAMPLE.COM/A now"},
+		"inline link":   {Message: "see [this](https://example.com/a) now", Expected: "see [this](HTTPS://EXAMPLE.COM/A) now"},
+		"several":       {Message: "https://example.com/a and https://example.com/b", Expected: "HTTPS://EXAMPLE.COM/A and HTTPS://EXAMPLE.COM/B"},
+		"code span":     {Message: "`https://example.com/a`", Expected: "`https://example.com/a`"},
+		"code block":    {Message: "```\nhttps://example.com/a\n```", Expected: "```\nhttps://example.com/a\n```"},
+		"link text":     {Message: "[https://example.com/a](https://example.com/b)", Expected: "[https://example.com/a](HTTPS://EXAMPLE.COM/B)"},
+		"multiple line": {Message: "first\n\nhttps://example.com/a", Expected: "first\n\nHTTPS://EXAMPLE.COM/A"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, rewriteLinks(tc.Message, upper))
+		})
+	}
+}
+
+func TestStripQueryParams(t *testing.T) {
+	params := []string{"utm_*", "fbclid"}
+
+	for name, tc := range map[string]struct {
+		RawQuery string
+		Expected string
+	}{
+		"empty":                       {RawQuery: "", Expected: ""},
+		"nothing to strip":            {RawQuery: "id=1&page=2", Expected: "id=1&page=2"},
+		"exact match":                 {RawQuery: "id=1&fbclid=abc", Expected: "id=1"},
+		"prefix match":                {RawQuery: "utm_source=x&id=1&utm_medium=y", Expected: "id=1"},
+		"everything":                  {RawQuery: "utm_source=x&fbclid=abc", Expected: ""},
+		"no value":                    {RawQuery: "fbclid&id=1", Expected: "id=1"},
+		"escaped name":                {RawQuery: "utm%5Fsource=x&id=1", Expected: "id=1"},
+		"order and encoding are kept": {RawQuery: "b=2&a=%20&utm_term=z", Expected: "b=2&a=%20"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, stripQueryParams(tc.RawQuery, params))
+		})
+	}
+}
+
+func TestRewritePostLinks(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	shortener := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/abc":
+			http.Redirect(w, r, "https://example.com/article?id=1&utm_source=shortener", http.StatusMovedPermanently)
+		case "/chain":
+			http.Redirect(w, r, "/abc", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer shortener.Close()
+
+	shortenerURL, err := url.Parse(shortener.URL)
+	require.Nil(t, err)
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.LinkRewriteSettings.Enable = true
+		cfg.LinkRewriteSettings.ExpandShortenerHosts = []string{shortenerURL.Hostname()}
+		*cfg.ServiceSettings.AllowedUntrustedInternalConnections = shortenerURL.Hostname()
+	})
+
+	rewrite := func(message string) string {
+		post := &model.Post{Message: message}
+		th.App.rewritePostLinks(post)
+		return post.Message
+	}
+
+	t.Run("tracking parameters are stripped", func(t *testing.T) {
+		assert.Equal(t, "see https://example.com/page?id=1", rewrite("see https://example.com/page?id=1&utm_campaign=spring&fbclid=abc"))
+	})
+
+	t.Run("links without tracking parameters are unchanged", func(t *testing.T) {
+		assert.Equal(t, "see https://example.com/a%20b?x=1", rewrite("see https://example.com/a%20b?x=1"))
+	})
+
+	t.Run("shortened links are expanded", func(t *testing.T) {
+		assert.Equal(t, "see https://example.com/article?id=1", rewrite("see "+shortener.URL+"/abc"))
+		assert.Equal(t, "see [it](https://example.com/article?id=1)", rewrite("see [it]("+shortener.URL+"/chain)"))
+	})
+
+	t.Run("links that don't redirect are unchanged", func(t *testing.T) {
+		assert.Equal(t, "see "+shortener.URL+"/missing", rewrite("see "+shortener.URL+"/missing"))
+	})
+
+	t.Run("unreachable links are unchanged", func(t *testing.T) {
+		shortenerURL.Host = shortenerURL.Hostname() + ":1"
+		assert.Equal(t, "see "+shortenerURL.String()+"/abc", rewrite("see "+shortenerURL.String()+"/abc"))
+	})
+
+	t.Run("the outgoing connection allowlist is respected", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.AllowedUntrustedInternalConnections = ""
+		})
+
+		assert.Equal(t, "see "+shortener.URL+"/abc", rewrite("see "+shortener.URL+"/abc"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.LinkRewriteSettings.Enable = false
+		})
+
+		assert.Equal(t, "see https://example.com/page?utm_source=x", rewrite("see https://example.com/page?utm_source=x"))
+	})
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/mattermost-server/mlog"
+)
+
+const LOAD_SHEDDING_CHECK_INTERVAL = time.Second
+
+// IsSheddingLoad returns whether the server is over one of its configured goroutine or memory thresholds, in which
+// case new requests that aren't critical should be rejected until it recovers.
+func (s *Server) IsSheddingLoad() bool {
+	return atomic.LoadInt32(&s.sheddingLoad) == 1
+}
+
+func (s *Server) checkLoadShedding() {
+	goroutineThreshold := *s.Config().ServiceSettings.LoadSheddingGoroutineThreshold
+	memoryThresholdMB := *s.Config().ServiceSettings.LoadSheddingMemoryThresholdMB
+
+	goroutines := runtime.NumGoroutine()
+	shedding := goroutineThreshold > 0 && goroutines > goroutineThreshold
+
+	var heapAllocMB int64
+	if memoryThresholdMB > 0 {
+		var memStats runtime.MemStats
+		runtime.ReadMemStats(&memStats)
+
+		heapAllocMB = int64(memStats.HeapAlloc / 1024 / 1024)
+		shedding = shedding || heapAllocMB > int64(memoryThresholdMB)
+	}
+
+	s.setSheddingLoad(shedding, goroutines, heapAllocMB)
+}
+
+func (s *Server) setSheddingLoad(shedding bool, goroutines int, heapAllocMB int64) {
+	var value int32
+	if shedding {
+		value = 1
+	}
+
+	if atomic.SwapInt32(&s.sheddingLoad, value) == value {
+		return
+	}
+
+	if shedding {
+		mlog.Warn("The server is over its load shedding thresholds, so new requests will be rejected until it recovers", mlog.Int("goroutines", goroutines), mlog.Int64("heap_alloc_mb", heapAllocMB))
+	} else {
+		mlog.Info("The server is back under its load shedding thresholds, so new requests will be accepted again", mlog.Int("goroutines", goroutines), mlog.Int64("heap_alloc_mb", heapAllocMB))
+	}
+
+	if s.Metrics != nil {
+		s.Metrics.SetLoadShedding(shedding)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestCheckLoadShedding(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	th.App.Srv.checkLoadShedding()
+	assert.False(t, th.App.Srv.IsSheddingLoad(), "load shedding is disabled by default")
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.LoadSheddingGoroutineThreshold = 1
+	})
+	th.App.Srv.checkLoadShedding()
+	assert.True(t, th.App.Srv.IsSheddingLoad())
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.LoadSheddingGoroutineThreshold = 1000000
+	})
+	th.App.Srv.checkLoadShedding()
+	assert.False(t, th.App.Srv.IsSheddingLoad())
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.LoadSheddingMemoryThresholdMB = 1
+	})
+	th.App.Srv.checkLoadShedding()
+	assert.True(t, th.App.Srv.IsSheddingLoad(), "the heap is always over 1MB with the server running")
+}
@@ -153,12 +153,6 @@ func (a *App) DoLogin(w http.ResponseWriter, r *http.Request, user *model.User,
 
 	w.Header().Set(model.HEADER_TOKEN, session.Token)
 
-	secure := false
-	if GetProtocol(r) == "https" {
-		secure = true
-	}
-
-	domain := a.GetCookieDomain()
 	expiresAt := time.Unix(model.GetMillis()/1000+int64(maxAge), 0)
 	sessionCookie := &http.Cookie{
 		Name:     model.SESSION_COOKIE_TOKEN,
@@ -167,8 +161,6 @@ func (a *App) DoLogin(w http.ResponseWriter, r *http.Request, user *model.User,
 		MaxAge:   maxAge,
 		Expires:  expiresAt,
 		HttpOnly: true,
-		Domain:   domain,
-		Secure:   secure,
 	}
 
 	userCookie := &http.Cookie{
@@ -177,12 +169,11 @@ func (a *App) DoLogin(w http.ResponseWriter, r *http.Request, user *model.User,
 		Path:    "/",
 		MaxAge:  maxAge,
 		Expires: expiresAt,
-		Domain:  domain,
-		Secure:  secure,
 	}
 
-	http.SetCookie(w, sessionCookie)
-	http.SetCookie(w, userCookie)
+	a.SetSessionCookie(w, r, sessionCookie)
+	a.SetSessionCookie(w, r, userCookie)
+	a.SetCsrfCookie(w, r, session)
 
 	if pluginsEnvironment := a.GetPluginsEnvironment(); pluginsEnvironment != nil {
 		a.Srv.Go(func() {
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 	"unicode"
 
 	"github.com/mattermost/mattermost-server/mlog"
@@ -47,10 +48,13 @@ func (a *App) SendNotifications(post *model.Post, team *model.Team, channel *mod
 	}
 	channelMemberNotifyPropsMap := result.Data.(map[string]model.StringMap)
 
+	profileMap, channelMemberNotifyPropsMap = a.enforceNotifyProps(profileMap, channelMemberNotifyPropsMap)
+
 	mentionedUserIds := make(map[string]bool)
 	threadMentionedUserIds := make(map[string]string)
 	allActivityPushUserIds := []string{}
 	hereNotification := false
+	hereMentionedUserIds := []string{}
 	channelNotification := false
 	allNotification := false
 	updateMentionChans := []store.StoreChannel{}
@@ -86,6 +90,10 @@ func (a *App) SendNotifications(post *model.Post, team *model.Team, channel *mod
 	} else {
 		keywords := a.GetMentionKeywordsInChannel(profileMap, post.Type != model.POST_HEADER_CHANGE && post.Type != model.POST_PURPOSE_CHANGE, channelMemberNotifyPropsMap)
 
+		if GetExplicitMentions(post, nil).HereMentioned {
+			a.setHereMentionKeywords(keywords)
+		}
+
 		m := GetExplicitMentions(post, keywords)
 
 		// Add an implicit mention when a user is added to a channel
@@ -100,20 +108,20 @@ func (a *App) SendNotifications(post *model.Post, team *model.Team, channel *mod
 
 		mentionedUserIds, hereNotification, channelNotification, allNotification = m.MentionedUserIds, m.HereMentioned, m.ChannelMentioned, m.AllMentioned
 
+		if hereNotification {
+			for _, id := range keywords["@here"] {
+				if id != post.UserId || post.Props["from_webhook"] == "true" {
+					hereMentionedUserIds = append(hereMentionedUserIds, id)
+				}
+			}
+		}
+
 		// get users that have comment thread mentions enabled
 		if len(post.RootId) > 0 && parentPostList != nil {
-			for _, threadPost := range parentPostList.Posts {
-				profile := profileMap[threadPost.UserId]
-				if profile != nil && (profile.NotifyProps[model.COMMENTS_NOTIFY_PROP] == THREAD_ANY || (profile.NotifyProps[model.COMMENTS_NOTIFY_PROP] == THREAD_ROOT && threadPost.Id == parentPostList.Order[0])) {
-					if threadPost.Id == parentPostList.Order[0] {
-						threadMentionedUserIds[threadPost.UserId] = THREAD_ROOT
-					} else {
-						threadMentionedUserIds[threadPost.UserId] = THREAD_ANY
-					}
-
-					if _, ok := mentionedUserIds[threadPost.UserId]; !ok {
-						mentionedUserIds[threadPost.UserId] = false
-					}
+			threadMentionedUserIds = getThreadMentionedUserIds(parentPostList, profileMap)
+			for userId := range threadMentionedUserIds {
+				if _, ok := mentionedUserIds[userId]; !ok {
+					mentionedUserIds[userId] = false
 				}
 			}
 		}
@@ -164,21 +172,6 @@ func (a *App) SendNotifications(post *model.Post, team *model.Team, channel *mod
 				continue
 			}
 
-			userAllowsEmails := profileMap[id].NotifyProps[model.EMAIL_NOTIFY_PROP] != "false"
-			if channelEmail, ok := channelMemberNotifyPropsMap[id][model.EMAIL_NOTIFY_PROP]; ok {
-				if channelEmail != model.CHANNEL_NOTIFY_DEFAULT {
-					userAllowsEmails = channelEmail != "false"
-				}
-			}
-
-			// Remove the user as recipient when the user has muted the channel.
-			if channelMuted, ok := channelMemberNotifyPropsMap[id][model.MARK_UNREAD_NOTIFY_PROP]; ok {
-				if channelMuted == model.CHANNEL_MARK_UNREAD_MENTION {
-					mlog.Debug(fmt.Sprintf("Channel muted for user_id %v, channel_mute %v", id, channelMuted))
-					userAllowsEmails = false
-				}
-			}
-
 			//If email verification is required and user email is not verified don't send email.
 			if a.Config().EmailSettings.RequireEmailVerification && !profileMap[id].EmailVerified {
 				mlog.Error(fmt.Sprintf("Skipped sending notification email to %v, address not verified. [details: user_id=%v]", profileMap[id].Email, id))
@@ -197,9 +190,7 @@ func (a *App) SendNotifications(post *model.Post, team *model.Team, channel *mod
 				}
 			}
 
-			autoResponderRelated := status.Status == model.STATUS_OUT_OF_OFFICE || post.Type == model.POST_AUTO_RESPONDER
-
-			if userAllowsEmails && status.Status != model.STATUS_ONLINE && profileMap[id].DeleteAt == 0 && !autoResponderRelated {
+			if ShouldSendEmailNotification(profileMap[id], channelMemberNotifyPropsMap[id], status, post) {
 				a.sendNotificationEmail(notification, profileMap[id], team)
 			}
 		}
@@ -220,6 +211,10 @@ func (a *App) SendNotifications(post *model.Post, team *model.Team, channel *mod
 		)
 	}
 
+	if hereNotification {
+		a.saveHereMentions(post, hereMentionedUserIds)
+	}
+
 	// If the channel has more than 1K users then @channel is disabled
 	if channelNotification && int64(len(profileMap)) > *a.Config().TeamSettings.MaxNotificationsPerChannel {
 		a.SendEphemeralPost(
@@ -253,18 +248,7 @@ func (a *App) SendNotifications(post *model.Post, team *model.Team, channel *mod
 		}
 	}
 
-	sendPushNotifications := false
-	if *a.Config().EmailSettings.SendPushNotifications {
-		pushServer := *a.Config().EmailSettings.PushNotificationServer
-		if license := a.License(); pushServer == model.MHPNS && (license == nil || !*license.Features.MHPNS) {
-			mlog.Warn("Push notifications are disabled. Go to System Console > Notifications > Mobile Push to enable them.")
-			sendPushNotifications = false
-		} else {
-			sendPushNotifications = true
-		}
-	}
-
-	if sendPushNotifications {
+	if a.pushNotificationsEnabled() {
 		for _, id := range mentionedUsersList {
 			if profileMap[id] == nil {
 				continue
@@ -350,10 +334,72 @@ func (a *App) SendNotifications(post *model.Post, team *model.Team, channel *mod
 		message.Add("mentions", model.ArrayToJson(mentionedUsersList))
 	}
 
+	if hereNotification {
+		message.Add("here_mentions", model.ArrayToJson(hereMentionedUserIds))
+	}
+
+	// Desktop notifications are shown by the clients, which only know about channels that were muted manually, so
+	// members whose mute schedule mutes the channel right now get their own copy of the event marked as muted.
+	scheduleMutedUserIds := getScheduleMutedUserIds(profileMap, channelMemberNotifyPropsMap, time.Now())
+	if len(scheduleMutedUserIds) > 0 {
+		message.Broadcast.OmitUsers = make(map[string]bool, len(scheduleMutedUserIds))
+		for _, userId := range scheduleMutedUserIds {
+			message.Broadcast.OmitUsers[userId] = true
+		}
+	}
+
 	a.Publish(message)
+
+	for _, userId := range scheduleMutedUserIds {
+		mutedMessage := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_POSTED, "", post.ChannelId, userId, nil)
+		for key, value := range message.Data {
+			mutedMessage.Add(key, value)
+		}
+		mutedMessage.Add("muted", "true")
+		a.Publish(mutedMessage)
+	}
+
 	return mentionedUsersList, nil
 }
 
+// getScheduleMutedUserIds returns the channel members that have the channel muted by their mute schedule at the
+// given time but not manually, since the clients already know about the channels that were muted manually.
+func getScheduleMutedUserIds(profileMap map[string]*model.User, channelMemberNotifyPropsMap map[string]model.StringMap, now time.Time) []string {
+	var userIds []string
+	for userId, notifyProps := range channelMemberNotifyPropsMap {
+		profile, ok := profileMap[userId]
+		if !ok || notifyProps[model.MUTE_SCHEDULE_NOTIFY_PROP] == "" || notifyProps[model.MARK_UNREAD_NOTIFY_PROP] == model.CHANNEL_MARK_UNREAD_MENTION {
+			continue
+		}
+
+		if model.IsChannelMuted(notifyProps, profile.GetPreferredTimezone(), now) {
+			userIds = append(userIds, userId)
+		}
+	}
+
+	sort.Strings(userIds)
+	return userIds
+}
+
+// getThreadMentionedUserIds returns the users who asked to be notified of replies to a thread they started
+// (THREAD_ROOT) or took part in (THREAD_ANY).
+func getThreadMentionedUserIds(parentPostList *model.PostList, profileMap map[string]*model.User) map[string]string {
+	threadMentionedUserIds := make(map[string]string)
+
+	for _, threadPost := range parentPostList.Posts {
+		profile := profileMap[threadPost.UserId]
+		if profile != nil && (profile.NotifyProps[model.COMMENTS_NOTIFY_PROP] == THREAD_ANY || (profile.NotifyProps[model.COMMENTS_NOTIFY_PROP] == THREAD_ROOT && threadPost.Id == parentPostList.Order[0])) {
+			if threadPost.Id == parentPostList.Order[0] {
+				threadMentionedUserIds[threadPost.UserId] = THREAD_ROOT
+			} else {
+				threadMentionedUserIds[threadPost.UserId] = THREAD_ANY
+			}
+		}
+	}
+
+	return threadMentionedUserIds
+}
+
 func (a *App) sendOutOfChannelMentions(sender *model.User, post *model.Post, users []*model.User) *model.AppError {
 	if len(users) == 0 {
 		return nil
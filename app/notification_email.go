@@ -74,12 +74,7 @@ func (a *App) sendNotificationEmail(notification *postNotification, user *model.
 		useMilitaryTime = result.Data.(model.Preference).Value == "true"
 	}
 
-	var nameFormat string
-	if result := <-a.Srv.Store.Preference().Get(user.Id, model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS, model.PREFERENCE_NAME_NAME_FORMAT); result.Err != nil {
-		nameFormat = *a.Config().TeamSettings.TeammateNameDisplay
-	} else {
-		nameFormat = result.Data.(model.Preference).Value
-	}
+	nameFormat := a.getNameFormatForUser(user.Id)
 
 	channelName := notification.GetChannelName(nameFormat, "")
 	senderName := notification.GetSenderName(nameFormat, a.Config().ServiceSettings.EnablePostUsernameOverride)
@@ -173,6 +168,7 @@ func (a *App) getNotificationEmailBody(recipient *model.User, post *model.Post,
 	if emailNotificationContentsType == model.EMAIL_NOTIFICATION_CONTENTS_FULL {
 		bodyPage = a.NewEmailTemplate("post_body_full", recipient.Locale)
 		bodyPage.Props["PostMessage"] = a.GetMessageForNotification(post, translateFunc)
+		bodyPage.Props["Files"] = a.getNotificationEmailFileLinks(recipient, post)
 	} else {
 		bodyPage = a.NewEmailTemplate("post_body_generic", recipient.Locale)
 	}
@@ -242,6 +238,38 @@ func (a *App) getNotificationEmailBody(recipient *model.User, post *model.Post,
 	return bodyPage.Render()
 }
 
+type notificationEmailFileLink struct {
+	Name string
+	Link string
+}
+
+// getNotificationEmailFileLinks returns links to download the files attached to a post on behalf of the recipient of
+// a notification email, since email clients don't have the session that downloading files otherwise requires.
+func (a *App) getNotificationEmailFileLinks(recipient *model.User, post *model.Post) []notificationEmailFileLink {
+	if len(post.FileIds) == 0 {
+		return nil
+	}
+
+	result := <-a.Srv.Store.FileInfo().GetForPost(post.Id, true, true)
+	if result.Err != nil {
+		mlog.Warn("Unable to get the files for a notification email", mlog.String("post_id", post.Id), mlog.Err(result.Err))
+		return nil
+	}
+
+	var links []notificationEmailFileLink
+	for _, info := range result.Data.([]*model.FileInfo) {
+		link, err := a.GenerateFileDownloadLink(a.GetSiteURL(), info, recipient.Id)
+		if err != nil {
+			mlog.Warn("Unable to create a download link for a notification email", mlog.String("file_id", info.Id), mlog.Err(err))
+			continue
+		}
+
+		links = append(links, notificationEmailFileLink{Name: info.Name, Link: link})
+	}
+
+	return links
+}
+
 type formattedPostTime struct {
 	Time     time.Time
 	Year     string
@@ -316,3 +344,51 @@ func (a *App) GetMessageForNotification(post *model.Post, translateFunc i18n.Tra
 	}
 	return translateFunc("api.post.get_message_for_notification.files_sent", len(filenames), props)
 }
+
+// ShouldSendEmailNotification returns true if a user mentioned by a post should be emailed about it, given their
+// notification preferences for the channel and their current status.
+func ShouldSendEmailNotification(user *model.User, channelNotifyProps model.StringMap, status *model.Status, post *model.Post) bool {
+	send, _ := emailNotificationDecision(user, channelNotifyProps, status, post)
+	return send
+}
+
+// emailNotificationDecision decides ShouldSendEmailNotification, also returning the NOTIFICATION_REASON_* that
+// decided it.
+func emailNotificationDecision(user *model.User, channelNotifyProps model.StringMap, status *model.Status, post *model.Post) (bool, string) {
+	userAllowsEmails := user.NotifyProps[model.EMAIL_NOTIFY_PROP] != "false"
+	reason := model.NOTIFICATION_REASON_USER_SETTING
+	if channelEmail, ok := channelNotifyProps[model.EMAIL_NOTIFY_PROP]; ok {
+		if channelEmail != model.CHANNEL_NOTIFY_DEFAULT {
+			userAllowsEmails = channelEmail != "false"
+			reason = model.NOTIFICATION_REASON_CHANNEL_SETTING
+		}
+	}
+
+	if !userAllowsEmails {
+		return false, reason
+	}
+
+	// Remove the user as recipient when the user has muted the channel, or it's muted by their schedule.
+	if model.IsChannelMuted(channelNotifyProps, user.GetPreferredTimezone(), time.Now()) {
+		mlog.Debug(fmt.Sprintf("Channel muted for user_id %v", user.Id))
+		return false, model.NOTIFICATION_REASON_MUTED
+	}
+
+	if status.Status == model.STATUS_ONLINE {
+		return false, model.NOTIFICATION_REASON_STATUS
+	}
+
+	if user.DeleteAt != 0 {
+		return false, model.NOTIFICATION_REASON_DEACTIVATED
+	}
+
+	if status.Status == model.STATUS_OUT_OF_OFFICE {
+		return false, model.NOTIFICATION_REASON_OUT_OF_OFFICE
+	}
+
+	if post.Type == model.POST_AUTO_RESPONDER {
+		return false, model.NOTIFICATION_REASON_AUTO_RESPONDER
+	}
+
+	return true, model.NOTIFICATION_REASON_MENTION
+}
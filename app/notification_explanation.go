@@ -0,0 +1,220 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// ExplainNotification works out whether the user would be sent push, email and desktop notifications for the post, and
+// which rule decided each of them, using the same checks as SendNotifications. The user's current status and settings
+// are used, so the explanation may differ from what happened when the post was made. Desktop notifications are
+// shown by the clients themselves, so that decision is the one that they're expected to make.
+func (a *App) ExplainNotification(postId string, userId string) (*model.NotificationExplanation, *model.AppError) {
+	post, err := a.GetSinglePost(postId)
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := a.GetChannel(post.ChannelId)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.GetUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation := &model.NotificationExplanation{
+		PostId: post.Id,
+		UserId: user.Id,
+	}
+
+	decideAll := func(reason string) *model.NotificationExplanation {
+		explanation.Push = &model.NotificationDecision{Reason: reason}
+		explanation.Email = &model.NotificationDecision{Reason: reason}
+		explanation.Desktop = &model.NotificationDecision{Reason: reason}
+		return explanation
+	}
+
+	if channel.DeleteAt > 0 {
+		return decideAll(model.NOTIFICATION_REASON_ARCHIVED_CHANNEL), nil
+	}
+
+	pchan := a.Srv.Store.User().GetAllProfilesInChannel(channel.Id, true)
+	cmnchan := a.Srv.Store.Channel().GetAllChannelMembersNotifyPropsForChannel(channel.Id, true)
+
+	result := <-pchan
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	profileMap := result.Data.(map[string]*model.User)
+
+	result = <-cmnchan
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	channelMemberNotifyPropsMap := result.Data.(map[string]model.StringMap)
+
+	if _, ok := profileMap[user.Id]; !ok {
+		return decideAll(model.NOTIFICATION_REASON_NOT_CHANNEL_MEMBER), nil
+	}
+
+	if post.UserId == user.Id && post.Props["from_webhook"] != "true" {
+		return decideAll(model.NOTIFICATION_REASON_OWN_POST), nil
+	}
+
+	explanation.MentionReason, err = a.getMentionReason(post, channel, user, profileMap, channelMemberNotifyPropsMap)
+	if err != nil {
+		return nil, err
+	}
+	wasMentioned := explanation.MentionReason != ""
+
+	status, err := a.GetStatus(user.Id)
+	if err != nil {
+		status = &model.Status{UserId: user.Id, Status: model.STATUS_OFFLINE, Manual: false, LastActivityAt: 0, ActiveChannel: ""}
+	}
+	explanation.Status = status.Status
+
+	channelNotifyProps := channelMemberNotifyPropsMap[user.Id]
+
+	explanation.Email = &model.NotificationDecision{}
+	if !a.Config().EmailSettings.SendEmailNotifications {
+		explanation.Email.Reason = model.NOTIFICATION_REASON_DISABLED
+	} else if !wasMentioned {
+		explanation.Email.Reason = model.NOTIFICATION_REASON_NOT_MENTIONED
+	} else if a.Config().EmailSettings.RequireEmailVerification && !user.EmailVerified {
+		explanation.Email.Reason = model.NOTIFICATION_REASON_EMAIL_NOT_VERIFIED
+	} else {
+		explanation.Email.Send, explanation.Email.Reason = emailNotificationDecision(user, channelNotifyProps, status, post)
+	}
+
+	// Users who aren't mentioned are still sent push notifications of all activity if they've asked for them
+	allActivityPush := channel.Type != model.CHANNEL_DIRECT && !post.IsSystemMessage() &&
+		(user.NotifyProps[model.PUSH_NOTIFY_PROP] == model.USER_NOTIFY_ALL || channelNotifyProps[model.PUSH_NOTIFY_PROP] == model.CHANNEL_NOTIFY_ALL)
+
+	explanation.Push = &model.NotificationDecision{}
+	if !a.pushNotificationsEnabled() {
+		explanation.Push.Reason = model.NOTIFICATION_REASON_DISABLED
+	} else if !wasMentioned && !allActivityPush {
+		explanation.Push.Reason = model.NOTIFICATION_REASON_NOT_MENTIONED
+	} else if send, reason := pushNotifyPropsDecision(user, channelNotifyProps, post, wasMentioned); !send {
+		explanation.Push.Reason = reason
+	} else if send, statusReason := pushStatusDecision(user.NotifyProps, status, post.ChannelId); !send {
+		explanation.Push.Reason = statusReason
+	} else {
+		explanation.Push.Send, explanation.Push.Reason = true, reason
+	}
+
+	explanation.Desktop = &model.NotificationDecision{}
+	explanation.Desktop.Send, explanation.Desktop.Reason = desktopNotificationDecision(user, channelNotifyProps, status, post, wasMentioned)
+
+	return explanation, nil
+}
+
+// getMentionReason returns the MENTION_REASON_* that a post mentions a user by, or an empty string if it doesn't,
+// in the same way as SendNotifications finds who to notify.
+func (a *App) getMentionReason(post *model.Post, channel *model.Channel, user *model.User, profileMap map[string]*model.User, channelMemberNotifyPropsMap map[string]model.StringMap) (string, *model.AppError) {
+	if channel.Type == model.CHANNEL_DIRECT {
+		if post.UserId != user.Id || post.Props["from_webhook"] == "true" {
+			return model.MENTION_REASON_DIRECT_MESSAGE, nil
+		}
+		return "", nil
+	}
+
+	if post.Type == model.POST_ADD_TO_CHANNEL {
+		if addedUserId, _ := post.Props[model.POST_PROPS_ADDED_USER_ID].(string); addedUserId == user.Id {
+			return model.MENTION_REASON_ADDED_TO_CHANNEL, nil
+		}
+	}
+
+	keywords := a.GetMentionKeywordsInChannel(profileMap, post.Type != model.POST_HEADER_CHANGE && post.Type != model.POST_PURPOSE_CHANGE, channelMemberNotifyPropsMap)
+
+	// @here mentioned whoever was online when the post was made, which is recorded for posts made since it has been
+	if hereMentions, err := a.GetPostHereMentions(post.Id); err == nil {
+		keywords["@here"] = hereMentions.UserIds
+	}
+
+	if GetExplicitMentions(post, keywords).MentionedUserIds[user.Id] {
+		// Work out which of the user's keywords mentioned them
+		userMention := "@" + strings.ToLower(user.Username)
+		if GetExplicitMentions(post, map[string][]string{userMention: {user.Id}}).MentionedUserIds[user.Id] {
+			return model.MENTION_REASON_USERNAME, nil
+		}
+
+		channelWideKeywords := make(map[string][]string)
+		for _, keyword := range []string{"@channel", "@all", "@here"} {
+			for _, id := range keywords[keyword] {
+				if id == user.Id {
+					channelWideKeywords[keyword] = []string{user.Id}
+				}
+			}
+		}
+		if GetExplicitMentions(post, channelWideKeywords).MentionedUserIds[user.Id] {
+			return model.MENTION_REASON_CHANNEL_WIDE, nil
+		}
+
+		return model.MENTION_REASON_KEYWORD, nil
+	}
+
+	if len(post.RootId) > 0 {
+		result := <-a.Srv.Store.Post().Get(post.RootId)
+		if result.Err != nil {
+			return "", result.Err
+		}
+		parentPostList := result.Data.(*model.PostList)
+
+		// Only the posts that were in the thread when the post was made count
+		for id, threadPost := range parentPostList.Posts {
+			if threadPost.CreateAt >= post.CreateAt {
+				delete(parentPostList.Posts, id)
+			}
+		}
+
+		if _, ok := getThreadMentionedUserIds(parentPostList, profileMap)[user.Id]; ok {
+			return model.MENTION_REASON_THREAD_REPLY, nil
+		}
+	}
+
+	return "", nil
+}
+
+// desktopNotificationDecision returns whether the clients are expected to show a desktop notification of a post to a
+// user, and the NOTIFICATION_REASON_* that decided it.
+func desktopNotificationDecision(user *model.User, channelNotifyProps model.StringMap, status *model.Status, post *model.Post, wasMentioned bool) (bool, string) {
+	if model.IsChannelMuted(channelNotifyProps, user.GetPreferredTimezone(), time.Now()) {
+		return false, model.NOTIFICATION_REASON_MUTED
+	}
+
+	if post.IsSystemMessage() && !wasMentioned {
+		return false, model.NOTIFICATION_REASON_SYSTEM_MESSAGE
+	}
+
+	if status.Status == model.STATUS_DND {
+		return false, model.NOTIFICATION_REASON_DND
+	}
+	if status.Status == model.STATUS_OUT_OF_OFFICE {
+		return false, model.NOTIFICATION_REASON_OUT_OF_OFFICE
+	}
+
+	level := user.NotifyProps[model.DESKTOP_NOTIFY_PROP]
+	reason := model.NOTIFICATION_REASON_USER_SETTING
+	if channelLevel := channelNotifyProps[model.DESKTOP_NOTIFY_PROP]; channelLevel != "" && channelLevel != model.CHANNEL_NOTIFY_DEFAULT {
+		level = channelLevel
+		reason = model.NOTIFICATION_REASON_CHANNEL_SETTING
+	}
+
+	if level == model.USER_NOTIFY_NONE || (level != model.USER_NOTIFY_ALL && !wasMentioned) {
+		return false, reason
+	}
+
+	if wasMentioned {
+		return true, model.NOTIFICATION_REASON_MENTION
+	}
+	return true, model.NOTIFICATION_REASON_ALL_ACTIVITY
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestExplainNotification(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		cfg.EmailSettings.SendEmailNotifications = true
+		cfg.EmailSettings.RequireEmailVerification = false
+		*cfg.EmailSettings.SendPushNotifications = true
+		*cfg.EmailSettings.PushNotificationServer = "http://localhost:8065"
+	})
+	th.App.SetStatusOffline(th.BasicUser.Id, true)
+
+	createPost := func(message string) *model.Post {
+		post, err := th.App.CreatePost(&model.Post{
+			UserId:    th.BasicUser2.Id,
+			ChannelId: th.BasicChannel.Id,
+			Message:   message,
+		}, th.BasicChannel, false)
+		require.Nil(t, err)
+		return post
+	}
+
+	t.Run("mentioned by username", func(t *testing.T) {
+		explanation, err := th.App.ExplainNotification(createPost("hello @"+th.BasicUser.Username).Id, th.BasicUser.Id)
+		require.Nil(t, err)
+		assert.Equal(t, model.MENTION_REASON_USERNAME, explanation.MentionReason)
+		assert.Equal(t, model.STATUS_OFFLINE, explanation.Status)
+		assert.Equal(t, &model.NotificationDecision{Send: true, Reason: model.NOTIFICATION_REASON_MENTION}, explanation.Email)
+		assert.Equal(t, &model.NotificationDecision{Send: true, Reason: model.NOTIFICATION_REASON_MENTION}, explanation.Push)
+		assert.Equal(t, &model.NotificationDecision{Send: true, Reason: model.NOTIFICATION_REASON_MENTION}, explanation.Desktop)
+	})
+
+	t.Run("not mentioned", func(t *testing.T) {
+		explanation, err := th.App.ExplainNotification(createPost("hello").Id, th.BasicUser.Id)
+		require.Nil(t, err)
+		assert.Empty(t, explanation.MentionReason)
+		assert.Equal(t, &model.NotificationDecision{Reason: model.NOTIFICATION_REASON_NOT_MENTIONED}, explanation.Email)
+		assert.Equal(t, &model.NotificationDecision{Reason: model.NOTIFICATION_REASON_NOT_MENTIONED}, explanation.Push)
+		assert.Equal(t, &model.NotificationDecision{Reason: model.NOTIFICATION_REASON_USER_SETTING}, explanation.Desktop)
+	})
+
+	t.Run("own post", func(t *testing.T) {
+		explanation, err := th.App.ExplainNotification(th.CreatePost(th.BasicChannel).Id, th.BasicUser.Id)
+		require.Nil(t, err)
+		assert.Equal(t, &model.NotificationDecision{Reason: model.NOTIFICATION_REASON_OWN_POST}, explanation.Push)
+	})
+
+	t.Run("do not disturb", func(t *testing.T) {
+		th.App.SetStatusDoNotDisturb(th.BasicUser.Id)
+		defer th.App.SetStatusOffline(th.BasicUser.Id, true)
+
+		explanation, err := th.App.ExplainNotification(createPost("hello @"+th.BasicUser.Username).Id, th.BasicUser.Id)
+		require.Nil(t, err)
+		assert.Equal(t, model.STATUS_DND, explanation.Status)
+		assert.Equal(t, &model.NotificationDecision{Reason: model.NOTIFICATION_REASON_DND}, explanation.Push)
+		assert.Equal(t, &model.NotificationDecision{Reason: model.NOTIFICATION_REASON_DND}, explanation.Desktop)
+	})
+
+	t.Run("muted channel", func(t *testing.T) {
+		_, err := th.App.UpdateChannelMemberNotifyProps(map[string]string{
+			model.MARK_UNREAD_NOTIFY_PROP: model.CHANNEL_MARK_UNREAD_MENTION,
+		}, th.BasicChannel.Id, th.BasicUser.Id)
+		require.Nil(t, err)
+
+		explanation, err := th.App.ExplainNotification(createPost("hello @"+th.BasicUser.Username).Id, th.BasicUser.Id)
+		require.Nil(t, err)
+		assert.Equal(t, &model.NotificationDecision{Reason: model.NOTIFICATION_REASON_MUTED}, explanation.Email)
+		assert.Equal(t, &model.NotificationDecision{Reason: model.NOTIFICATION_REASON_MUTED}, explanation.Push)
+		assert.Equal(t, &model.NotificationDecision{Reason: model.NOTIFICATION_REASON_MUTED}, explanation.Desktop)
+	})
+
+	t.Run("not a member of the channel", func(t *testing.T) {
+		user := th.CreateUser()
+
+		explanation, err := th.App.ExplainNotification(createPost("hello").Id, user.Id)
+		require.Nil(t, err)
+		assert.Equal(t, &model.NotificationDecision{Reason: model.NOTIFICATION_REASON_NOT_CHANNEL_MEMBER}, explanation.Email)
+	})
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/store"
+)
+
+// PreviewNotifications works out who would be notified, and how, if the draft were posted to the channel. It follows
+// the same rules as SendNotifications, using each user's current preferences and status, but nothing is posted or
+// sent.
+func (a *App) PreviewNotifications(draft *model.Post, channel *model.Channel) (*model.NotificationPreview, *model.AppError) {
+	preview := &model.NotificationPreview{
+		ChannelId:            channel.Id,
+		Recipients:           []*model.NotificationPreviewRecipient{},
+		OutOfChannelMentions: []string{},
+	}
+
+	// Notifications aren't sent in archived channels
+	if channel.DeleteAt > 0 {
+		return preview, nil
+	}
+
+	pchan := a.Srv.Store.User().GetAllProfilesInChannel(channel.Id, true)
+	cmnchan := a.Srv.Store.Channel().GetAllChannelMembersNotifyPropsForChannel(channel.Id, true)
+	var ppchan store.StoreChannel
+	if len(draft.RootId) > 0 {
+		ppchan = a.Srv.Store.Post().Get(draft.RootId)
+	}
+
+	result := <-pchan
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	profileMap := result.Data.(map[string]*model.User)
+
+	result = <-cmnchan
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	channelMemberNotifyPropsMap := result.Data.(map[string]model.StringMap)
+
+	profileMap, channelMemberNotifyPropsMap = a.enforceNotifyProps(profileMap, channelMemberNotifyPropsMap)
+
+	var parentPostList *model.PostList
+	if ppchan != nil {
+		result = <-ppchan
+		if result.Err != nil {
+			return nil, model.NewAppError("PreviewNotifications", "api.post.create_post.root_id.app_error", nil, "", http.StatusBadRequest)
+		}
+		parentPostList = result.Data.(*model.PostList)
+		if len(parentPostList.Posts) == 0 || !parentPostList.IsChannelId(channel.Id) {
+			return nil, model.NewAppError("PreviewNotifications", "api.post.create_post.channel_root_id.app_error", nil, "", http.StatusBadRequest)
+		}
+	}
+
+	mentionedUserIds := make(map[string]bool)
+	if channel.Type == model.CHANNEL_DIRECT {
+		for _, userId := range strings.Split(channel.Name, "__") {
+			if _, ok := profileMap[userId]; ok && userId != draft.UserId {
+				mentionedUserIds[userId] = true
+			}
+		}
+	} else {
+		keywords := a.GetMentionKeywordsInChannel(profileMap, true, channelMemberNotifyPropsMap)
+
+		if GetExplicitMentions(draft, nil).HereMentioned {
+			a.setHereMentionKeywords(keywords)
+		}
+
+		m := GetExplicitMentions(draft, keywords)
+		mentionedUserIds = m.MentionedUserIds
+		preview.HereMentioned, preview.ChannelMentioned, preview.AllMentioned = m.HereMentioned, m.ChannelMentioned, m.AllMentioned
+
+		if parentPostList != nil {
+			for userId := range getThreadMentionedUserIds(parentPostList, profileMap) {
+				if _, ok := mentionedUserIds[userId]; !ok {
+					mentionedUserIds[userId] = false
+				}
+			}
+		}
+
+		delete(mentionedUserIds, draft.UserId)
+
+		if len(m.OtherPotentialMentions) > 0 {
+			result = <-a.Srv.Store.User().GetProfilesByUsernames(m.OtherPotentialMentions, channel.TeamId)
+			if result.Err != nil {
+				return nil, result.Err
+			}
+			for _, user := range result.Data.([]*model.User) {
+				preview.OutOfChannelMentions = append(preview.OutOfChannelMentions, user.Username)
+			}
+			sort.Strings(preview.OutOfChannelMentions)
+		}
+	}
+
+	sendPushNotifications := a.pushNotificationsEnabled()
+	sendEmailNotifications := a.Config().EmailSettings.SendEmailNotifications
+
+	for id, profile := range profileMap {
+		if id == draft.UserId {
+			continue
+		}
+
+		channelNotifyProps := channelMemberNotifyPropsMap[id]
+
+		// Like SendNotifications, anyone in the mention list, including thread followers, counts as mentioned.
+		_, mentioned := mentionedUserIds[id]
+		if !mentioned && getNotifyLevel(profile, channelNotifyProps, model.PUSH_NOTIFY_PROP) != model.USER_NOTIFY_ALL &&
+			getNotifyLevel(profile, channelNotifyProps, model.DESKTOP_NOTIFY_PROP) != model.USER_NOTIFY_ALL {
+			continue
+		}
+
+		status, err := a.GetStatus(id)
+		if err != nil {
+			status = &model.Status{UserId: id, Status: model.STATUS_OFFLINE, Manual: false, LastActivityAt: 0, ActiveChannel: ""}
+		}
+
+		recipient := &model.NotificationPreviewRecipient{
+			UserId:    id,
+			Username:  profile.Username,
+			Mentioned: mentioned,
+			Desktop:   status.Status != model.STATUS_DND && doesNotifyPropsAllowDesktopNotification(profile, channelNotifyProps, mentioned),
+		}
+
+		if sendPushNotifications {
+			recipient.Push = ShouldSendPushNotification(profile, channelNotifyProps, mentioned, status, draft)
+		}
+
+		if sendEmailNotifications && mentioned && (!a.Config().EmailSettings.RequireEmailVerification || profile.EmailVerified) {
+			recipient.Email = ShouldSendEmailNotification(profile, channelNotifyProps, status, draft)
+		}
+
+		if recipient.Desktop || recipient.Push || recipient.Email {
+			preview.Recipients = append(preview.Recipients, recipient)
+		}
+	}
+
+	sort.Slice(preview.Recipients, func(i, j int) bool {
+		return preview.Recipients[i].Username < preview.Recipients[j].Username
+	})
+
+	return preview, nil
+}
+
+// getNotifyLevel returns a user's notification level for the given prop in a channel, falling back to their
+// account-wide setting when the channel uses the default.
+func getNotifyLevel(user *model.User, channelNotifyProps model.StringMap, prop string) string {
+	if level := channelNotifyProps[prop]; level != "" && level != model.CHANNEL_NOTIFY_DEFAULT {
+		return level
+	}
+	return user.NotifyProps[prop]
+}
+
+// doesNotifyPropsAllowDesktopNotification mirrors how clients decide whether to show a desktop notification for a
+// post in a channel that isn't muted.
+func doesNotifyPropsAllowDesktopNotification(user *model.User, channelNotifyProps model.StringMap, wasMentioned bool) bool {
+	if model.IsChannelMuted(channelNotifyProps, user.GetPreferredTimezone(), time.Now()) {
+		return false
+	}
+
+	switch getNotifyLevel(user, channelNotifyProps, model.DESKTOP_NOTIFY_PROP) {
+	case model.USER_NOTIFY_ALL:
+		return true
+	case model.USER_NOTIFY_MENTION:
+		return wasMentioned
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestPreviewNotifications(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		cfg.EmailSettings.SendEmailNotifications = true
+		cfg.EmailSettings.RequireEmailVerification = false
+		*cfg.EmailSettings.SendPushNotifications = false
+	})
+
+	channel := th.BasicChannel
+	th.AddUserToChannel(th.BasicUser2, channel)
+
+	user3 := th.CreateUser()
+	th.LinkUserToTeam(user3, th.BasicTeam)
+	th.AddUserToChannel(user3, channel)
+
+	outsider := th.CreateUser()
+	th.LinkUserToTeam(outsider, th.BasicTeam)
+
+	recipientIds := func(preview *model.NotificationPreview) []string {
+		ids := []string{}
+		for _, recipient := range preview.Recipients {
+			ids = append(ids, recipient.UserId)
+		}
+		return ids
+	}
+
+	t.Run("mention", func(t *testing.T) {
+		draft := &model.Post{UserId: th.BasicUser.Id, ChannelId: channel.Id, Message: "hey @" + th.BasicUser2.Username + " and @" + outsider.Username}
+
+		preview, err := th.App.PreviewNotifications(draft, channel)
+		require.Nil(t, err)
+		require.Len(t, preview.Recipients, 1)
+
+		recipient := preview.Recipients[0]
+		assert.Equal(t, th.BasicUser2.Id, recipient.UserId)
+		assert.True(t, recipient.Mentioned)
+		assert.True(t, recipient.Desktop)
+		assert.True(t, recipient.Email)
+		assert.False(t, recipient.Push)
+
+		assert.Equal(t, []string{outsider.Username}, preview.OutOfChannelMentions)
+		assert.False(t, preview.ChannelMentioned)
+	})
+
+	t.Run("enforced notification settings", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			cfg.NotificationDefaultSettings.NotifyProps = map[string]string{model.EMAIL_NOTIFY_PROP: "false"}
+			cfg.NotificationDefaultSettings.EnforcedNotifyProps = []string{model.EMAIL_NOTIFY_PROP}
+		})
+		defer th.App.UpdateConfig(func(cfg *model.Config) {
+			cfg.NotificationDefaultSettings.NotifyProps = map[string]string{}
+			cfg.NotificationDefaultSettings.EnforcedNotifyProps = []string{}
+		})
+
+		draft := &model.Post{UserId: th.BasicUser.Id, ChannelId: channel.Id, Message: "hey @" + th.BasicUser2.Username}
+
+		preview, err := th.App.PreviewNotifications(draft, channel)
+		require.Nil(t, err)
+		require.Len(t, preview.Recipients, 1)
+		assert.True(t, preview.Recipients[0].Desktop)
+		assert.False(t, preview.Recipients[0].Email, "the enforced email setting should override the user's own")
+	})
+
+	t.Run("the sender is never notified", func(t *testing.T) {
+		draft := &model.Post{UserId: th.BasicUser.Id, ChannelId: channel.Id, Message: "note to self @" + th.BasicUser.Username}
+
+		preview, err := th.App.PreviewNotifications(draft, channel)
+		require.Nil(t, err)
+		assert.Empty(t, preview.Recipients)
+	})
+
+	t.Run("channel mention", func(t *testing.T) {
+		draft := &model.Post{UserId: th.BasicUser.Id, ChannelId: channel.Id, Message: "hello @channel"}
+
+		preview, err := th.App.PreviewNotifications(draft, channel)
+		require.Nil(t, err)
+		assert.True(t, preview.ChannelMentioned)
+		assert.ElementsMatch(t, []string{th.BasicUser2.Id, user3.Id}, recipientIds(preview))
+	})
+
+	t.Run("muted channel", func(t *testing.T) {
+		_, err := th.App.UpdateChannelMemberNotifyProps(map[string]string{model.MARK_UNREAD_NOTIFY_PROP: model.CHANNEL_MARK_UNREAD_MENTION}, channel.Id, user3.Id)
+		require.Nil(t, err)
+		defer th.App.UpdateChannelMemberNotifyProps(map[string]string{model.MARK_UNREAD_NOTIFY_PROP: model.CHANNEL_MARK_UNREAD_ALL}, channel.Id, user3.Id)
+
+		draft := &model.Post{UserId: th.BasicUser.Id, ChannelId: channel.Id, Message: "hello @" + user3.Username}
+
+		preview, err := th.App.PreviewNotifications(draft, channel)
+		require.Nil(t, err)
+		assert.Empty(t, preview.Recipients)
+	})
+
+	t.Run("do not disturb", func(t *testing.T) {
+		th.App.SetStatusDoNotDisturb(user3.Id)
+		defer th.App.SetStatusOffline(user3.Id, true)
+
+		draft := &model.Post{UserId: th.BasicUser.Id, ChannelId: channel.Id, Message: "hello @" + user3.Username}
+
+		preview, err := th.App.PreviewNotifications(draft, channel)
+		require.Nil(t, err)
+		require.Len(t, preview.Recipients, 1)
+		assert.False(t, preview.Recipients[0].Desktop)
+		assert.True(t, preview.Recipients[0].Email)
+	})
+
+	t.Run("here mention", func(t *testing.T) {
+		// user3 is online according to another node of the cluster, but isn't in this node's cache
+		require.Nil(t, (<-th.App.Srv.Store.Status().SaveOrUpdate(&model.Status{UserId: user3.Id, Status: model.STATUS_ONLINE})).Err)
+		statusCache.Remove(user3.Id)
+		defer th.App.SetStatusOffline(user3.Id, true)
+		th.App.AddStatusCacheSkipClusterSend(&model.Status{UserId: th.BasicUser2.Id, Status: model.STATUS_OFFLINE})
+
+		draft := &model.Post{UserId: th.BasicUser.Id, ChannelId: channel.Id, Message: "@here the build is broken"}
+
+		preview, err := th.App.PreviewNotifications(draft, channel)
+		require.Nil(t, err)
+		assert.True(t, preview.HereMentioned)
+		require.Len(t, preview.Recipients, 1)
+		assert.Equal(t, user3.Id, preview.Recipients[0].UserId)
+		assert.True(t, preview.Recipients[0].Mentioned)
+	})
+
+	t.Run("reply in a thread from another channel", func(t *testing.T) {
+		otherChannel := th.CreateChannel(th.BasicTeam)
+		rootPost := th.CreatePost(otherChannel)
+
+		draft := &model.Post{UserId: th.BasicUser.Id, ChannelId: channel.Id, RootId: rootPost.Id, Message: "reply"}
+
+		_, err := th.App.PreviewNotifications(draft, channel)
+		require.NotNil(t, err)
+		assert.Equal(t, "api.post.create_post.channel_root_id.app_error", err.Id)
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"hash/fnv"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/model"
@@ -122,12 +123,7 @@ func (a *App) sendPushNotification(notification *postNotification, user *model.U
 	channel := notification.channel
 	post := notification.post
 
-	var nameFormat string
-	if result := <-a.Srv.Store.Preference().Get(user.Id, model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS, model.PREFERENCE_NAME_NAME_FORMAT); result.Err != nil {
-		nameFormat = *a.Config().TeamSettings.TeammateNameDisplay
-	} else {
-		nameFormat = result.Data.(model.Preference).Value
-	}
+	nameFormat := a.getNameFormatForUser(user.Id)
 
 	channelName := notification.GetChannelName(nameFormat, user.Id)
 	senderName := notification.GetSenderName(nameFormat, cfg.ServiceSettings.EnablePostUsernameOverride)
@@ -315,6 +311,13 @@ func ShouldSendPushNotification(user *model.User, channelNotifyProps model.Strin
 }
 
 func DoesNotifyPropsAllowPushNotification(user *model.User, channelNotifyProps model.StringMap, post *model.Post, wasMentioned bool) bool {
+	allowed, _ := pushNotifyPropsDecision(user, channelNotifyProps, post, wasMentioned)
+	return allowed
+}
+
+// pushNotifyPropsDecision decides DoesNotifyPropsAllowPushNotification, also returning the NOTIFICATION_REASON_* that
+// decided it.
+func pushNotifyPropsDecision(user *model.User, channelNotifyProps model.StringMap, post *model.Post, wasMentioned bool) (bool, string) {
 	userNotifyProps := user.NotifyProps
 	userNotify := userNotifyProps[model.PUSH_NOTIFY_PROP]
 	channelNotify, _ := channelNotifyProps[model.PUSH_NOTIFY_PROP]
@@ -322,58 +325,84 @@ func DoesNotifyPropsAllowPushNotification(user *model.User, channelNotifyProps m
 		channelNotify = model.CHANNEL_NOTIFY_DEFAULT
 	}
 
-	// If the channel is muted do not send push notifications
-	if channelNotifyProps[model.MARK_UNREAD_NOTIFY_PROP] == model.CHANNEL_MARK_UNREAD_MENTION {
-		return false
+	// If the channel is muted, including by the user's mute schedule, do not send push notifications
+	if model.IsChannelMuted(channelNotifyProps, user.GetPreferredTimezone(), time.Now()) {
+		return false, model.NOTIFICATION_REASON_MUTED
 	}
 
 	if post.IsSystemMessage() {
-		return false
+		return false, model.NOTIFICATION_REASON_SYSTEM_MESSAGE
 	}
 
 	if channelNotify == model.USER_NOTIFY_NONE {
-		return false
+		return false, model.NOTIFICATION_REASON_CHANNEL_SETTING
 	}
 
 	if channelNotify == model.CHANNEL_NOTIFY_MENTION && !wasMentioned {
-		return false
+		return false, model.NOTIFICATION_REASON_CHANNEL_SETTING
 	}
 
 	if userNotify == model.USER_NOTIFY_MENTION && channelNotify == model.CHANNEL_NOTIFY_DEFAULT && !wasMentioned {
-		return false
+		return false, model.NOTIFICATION_REASON_USER_SETTING
 	}
 
 	if (userNotify == model.USER_NOTIFY_ALL || channelNotify == model.CHANNEL_NOTIFY_ALL) &&
 		(post.UserId != user.Id || post.Props["from_webhook"] == "true") {
-		return true
+		if wasMentioned {
+			return true, model.NOTIFICATION_REASON_MENTION
+		}
+		return true, model.NOTIFICATION_REASON_ALL_ACTIVITY
 	}
 
 	if userNotify == model.USER_NOTIFY_NONE &&
 		channelNotify == model.CHANNEL_NOTIFY_DEFAULT {
-		return false
+		return false, model.NOTIFICATION_REASON_USER_SETTING
 	}
 
-	return true
+	return true, model.NOTIFICATION_REASON_MENTION
 }
 
 func DoesStatusAllowPushNotification(userNotifyProps model.StringMap, status *model.Status, channelId string) bool {
+	allowed, _ := pushStatusDecision(userNotifyProps, status, channelId)
+	return allowed
+}
+
+// pushStatusDecision decides DoesStatusAllowPushNotification, also returning the NOTIFICATION_REASON_* that decided it.
+func pushStatusDecision(userNotifyProps model.StringMap, status *model.Status, channelId string) (bool, string) {
 	// If User status is DND or OOO return false right away
-	if status.Status == model.STATUS_DND || status.Status == model.STATUS_OUT_OF_OFFICE {
-		return false
+	if status.Status == model.STATUS_DND {
+		return false, model.NOTIFICATION_REASON_DND
+	}
+	if status.Status == model.STATUS_OUT_OF_OFFICE {
+		return false, model.NOTIFICATION_REASON_OUT_OF_OFFICE
 	}
 
 	pushStatus, ok := userNotifyProps[model.PUSH_STATUS_NOTIFY_PROP]
 	if (pushStatus == model.STATUS_ONLINE || !ok) && (status.ActiveChannel != channelId || model.GetMillis()-status.LastActivityAt > model.STATUS_CHANNEL_TIMEOUT) {
-		return true
+		return true, model.NOTIFICATION_REASON_STATUS
 	}
 
 	if pushStatus == model.STATUS_AWAY && (status.Status == model.STATUS_AWAY || status.Status == model.STATUS_OFFLINE) {
-		return true
+		return true, model.NOTIFICATION_REASON_STATUS
 	}
 
 	if pushStatus == model.STATUS_OFFLINE && status.Status == model.STATUS_OFFLINE {
-		return true
+		return true, model.NOTIFICATION_REASON_STATUS
+	}
+
+	return false, model.NOTIFICATION_REASON_STATUS
+}
+
+func (a *App) pushNotificationsEnabled() bool {
+	if !*a.Config().EmailSettings.SendPushNotifications {
+		return false
+	}
+
+	pushServer := *a.Config().EmailSettings.PushNotificationServer
+	if license := a.License(); pushServer == model.MHPNS && (license == nil || !*license.Features.MHPNS) {
+		mlog.Warn("Push notifications are disabled. Go to System Console > Notifications > Mobile Push to enable them.")
+		return false
 	}
 
-	return false
+	return true
 }
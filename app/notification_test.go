@@ -5,6 +5,7 @@ package app
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -85,6 +86,27 @@ func TestSendNotifications(t *testing.T) {
 	assert.Len(t, mentions, 0)
 }
 
+func TestGetScheduleMutedUserIds(t *testing.T) {
+	schedule := model.ChannelMuteSchedule{{Start: "09:00", End: "17:00"}}.ToJson()
+	now := time.Date(2019, time.March, 4, 12, 0, 0, 0, time.UTC)
+
+	profileMap := map[string]*model.User{}
+	for _, id := range []string{"scheduled", "outside", "manual", "none"} {
+		profileMap[id] = &model.User{Id: id, Timezone: model.StringMap{"useAutomaticTimezone": "false", "manualTimezone": "UTC"}}
+	}
+	profileMap["outside"].Timezone["manualTimezone"] = "Asia/Tokyo"
+
+	notifyPropsMap := map[string]model.StringMap{
+		"scheduled": {model.MUTE_SCHEDULE_NOTIFY_PROP: schedule},
+		"outside":   {model.MUTE_SCHEDULE_NOTIFY_PROP: schedule},
+		"manual":    {model.MUTE_SCHEDULE_NOTIFY_PROP: schedule, model.MARK_UNREAD_NOTIFY_PROP: model.CHANNEL_MARK_UNREAD_MENTION},
+		"none":      {},
+		"missing":   {model.MUTE_SCHEDULE_NOTIFY_PROP: schedule},
+	}
+
+	assert.Equal(t, []string{"scheduled"}, getScheduleMutedUserIds(profileMap, notifyPropsMap, now))
+}
+
 func TestGetExplicitMentions(t *testing.T) {
 	id1 := model.NewId()
 	id2 := model.NewId()
@@ -17,7 +17,7 @@ import (
 const MaxOpenGraphResponseSize = 1024 * 1024 * 50
 
 func (a *App) GetOpenGraphMetadata(requestURL string) *opengraph.OpenGraph {
-	res, err := a.HTTPService.MakeClient(false).Get(requestURL)
+	res, err := a.HTTPService.MakeIntegrationClient(a.Config().IntegrationHTTPSettings.LinkPreviews).Get(requestURL)
 	if err != nil {
 		mlog.Error("GetOpenGraphMetadata request failed", mlog.String("requestURL", requestURL), mlog.Any("err", err))
 		return nil
@@ -4,6 +4,7 @@
 package app
 
 import (
+	"github.com/mattermost/mattermost-server/einterfaces"
 	"github.com/mattermost/mattermost-server/store"
 )
 
@@ -56,6 +57,13 @@ func DisableConfigWatch(s *Server) {
 	s.disableConfigWatch = true
 }
 
+// MfaVerifier replaces the built-in MFA check of endpoints that require MFA with a custom second factor provider.
+func MfaVerifier(verifier einterfaces.MfaVerifierInterface) Option {
+	return func(s *Server) {
+		s.MfaVerifier = verifier
+	}
+}
+
 type AppOption func(a *App)
 type AppOptionCreator func() []AppOption
 
@@ -73,6 +81,7 @@ func ServerConnector(s *Server) AppOption {
 		a.Ldap = s.Ldap
 		a.MessageExport = s.MessageExport
 		a.Metrics = s.Metrics
+		a.MfaVerifier = s.MfaVerifier
 		a.Saml = s.Saml
 
 		a.HTTPService = s.HTTPService
@@ -0,0 +1,224 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"path"
+	"time"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const orphanedFilesBatchSize = 1000
+
+// uploadDirectoryLayout is the layout of the directories that uploaded files are stored in below the directory for the
+// day that they were uploaded, see uploadFileTask.pathPrefix. The directories below it are named after the FileInfos
+// of the files in them.
+var uploadDirectoryLayout = []string{"teams", "*", "channels", "*", "users", "*"}
+
+// CleanupOrphanedFiles finds the files that aren't referenced by any post and, unless dryRun is set, deletes them.
+// Those are the FileInfos that were never attached to a post or whose post has been deleted, along with their files,
+// and the uploaded files left in the file store without a FileInfo. Anything created or deleted within the grace
+// period is left alone, since it may belong to a file that's still being uploaded or that hasn't been posted yet.
+func (a *App) CleanupOrphanedFiles(dryRun bool, gracePeriod time.Duration) (*model.OrphanedFilesReport, *model.AppError) {
+	before := time.Now().Add(-gracePeriod)
+
+	report := &model.OrphanedFilesReport{
+		DryRun:    dryRun,
+		FileInfos: []*model.FileInfo{},
+		Paths:     []string{},
+	}
+
+	afterId := ""
+	for {
+		result := <-a.Srv.Store.FileInfo().GetOrphaned(model.GetMillisForTime(before), afterId, orphanedFilesBatchSize)
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		infos := result.Data.([]*model.FileInfo)
+
+		for _, info := range infos {
+			if !dryRun {
+				if err := a.deleteOrphanedFileInfo(info); err != nil {
+					return nil, err
+				}
+			}
+
+			report.FileInfos = append(report.FileInfos, info)
+			report.Size += info.Size
+			afterId = info.Id
+		}
+
+		if len(infos) < orphanedFilesBatchSize {
+			break
+		}
+	}
+
+	paths, err := a.getOrphanedUploadDirectories(before)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range paths {
+		if !dryRun {
+			if err := a.RemoveDirectory(dir); err != nil {
+				return nil, err
+			}
+		}
+
+		report.Paths = append(report.Paths, dir)
+	}
+
+	if !dryRun {
+		mlog.Info("Deleted orphaned files", mlog.Int("file_infos", len(report.FileInfos)), mlog.Int("paths", len(report.Paths)), mlog.Int64("size", report.Size))
+	}
+
+	return report, nil
+}
+
+func (a *App) deleteOrphanedFileInfo(info *model.FileInfo) *model.AppError {
+	// A copy of the FileInfo, or the original that it was copied from, may still be using the files
+	result := <-a.Srv.Store.FileInfo().CountByPath(info.Path)
+	if result.Err != nil {
+		return result.Err
+	}
+	if result.Data.(int64) > 1 {
+		return a.permanentDeleteOrphanedFileInfo(info)
+	}
+
+	backend, err := a.FileBackendForInfo(info)
+	if err != nil {
+		return err
+	}
+
+	// Measured before the files are gone, since the sizes of thumbnails and previews aren't recorded
+	size := a.storedFileSize(backend, info)
+
+	// The files are removed first so that the FileInfo is still around to try again if that fails
+	if dir := path.Dir(info.Path); path.Base(dir) == info.Id {
+		if err := backend.RemoveDirectory(dir); err != nil {
+			return err
+		}
+	} else {
+		for _, filePath := range info.GetPaths() {
+			if exists, err := backend.FileExists(filePath); err != nil {
+				return err
+			} else if exists {
+				if err := backend.RemoveFile(filePath); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err := a.permanentDeleteOrphanedFileInfo(info); err != nil {
+		return err
+	}
+
+	a.releaseTeamStorageForFile(info, size)
+
+	return nil
+}
+
+func (a *App) permanentDeleteOrphanedFileInfo(info *model.FileInfo) *model.AppError {
+	if result := <-a.Srv.Store.FileInfo().PermanentDelete(info.Id); result.Err != nil {
+		return result.Err
+	}
+
+	if info.PostId != "" {
+		a.Srv.Store.FileInfo().InvalidateFileInfosForPostCache(info.PostId)
+	}
+
+	return nil
+}
+
+// getOrphanedUploadDirectories returns the directories of uploaded files that don't have a FileInfo, including deleted
+// ones. Since uploaded files are written before their FileInfo is saved, the days that a file could still be being
+// uploaded on before the given time are skipped, allowing an extra day for the time zone that they were named in.
+func (a *App) getOrphanedUploadDirectories(before time.Time) ([]string, *model.AppError) {
+	days, err := a.ListDirectory("")
+	if err != nil {
+		return nil, err
+	}
+
+	orphaned := []string{}
+	for _, day := range days {
+		date, parseErr := time.Parse("20060102", path.Base(day))
+		if parseErr != nil {
+			// Everything else in the file store, such as plugins and emoji, isn't an uploaded file
+			continue
+		}
+
+		if !date.AddDate(0, 0, 2).Before(before) {
+			continue
+		}
+
+		userDirs, err := a.listUploadDirectories(day, uploadDirectoryLayout)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, userDir := range userDirs {
+			fileDirs, err := a.ListDirectory(userDir + "/")
+			if err != nil {
+				return nil, err
+			}
+
+			fileIds := []string{}
+			fileDirsById := map[string]string{}
+			for _, fileDir := range fileDirs {
+				if fileId := path.Base(fileDir); model.IsValidId(fileId) {
+					fileIds = append(fileIds, fileId)
+					fileDirsById[fileId] = fileDir
+				}
+			}
+
+			result := <-a.Srv.Store.FileInfo().GetExistingIds(fileIds)
+			if result.Err != nil {
+				return nil, result.Err
+			}
+
+			for _, fileId := range result.Data.([]string) {
+				delete(fileDirsById, fileId)
+			}
+
+			for _, fileId := range fileIds {
+				if fileDir, ok := fileDirsById[fileId]; ok {
+					orphaned = append(orphaned, fileDir)
+				}
+			}
+		}
+	}
+
+	return orphaned, nil
+}
+
+// listUploadDirectories returns the directories below dir that match the given layout, where each element is either
+// the name of a directory or * to match any.
+func (a *App) listUploadDirectories(dir string, layout []string) ([]string, *model.AppError) {
+	if len(layout) == 0 {
+		return []string{dir}, nil
+	}
+
+	children, err := a.ListDirectory(dir + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := []string{}
+	for _, child := range children {
+		if layout[0] != "*" && path.Base(child) != layout[0] {
+			continue
+		}
+
+		childDirs, err := a.listUploadDirectories(child, layout[1:])
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, childDirs...)
+	}
+
+	return dirs, nil
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const (
+	orphanedMembershipsBatchSize = 1000
+
+	AUDIT_ACTION_ORPHANED_CHANNEL_MEMBER_DELETED = "orphaned_channel_member_deleted"
+	AUDIT_ACTION_ORPHANED_TEAM_MEMBER_DELETED    = "orphaned_team_member_deleted"
+)
+
+// CleanupOrphanedMemberships finds the channel and team memberships whose channel, team or user doesn't exist and,
+// unless dryRun is set, deletes them, saving an audit record for each on behalf of the given user. They're found and
+// deleted orphanedMembershipsBatchSize at a time, and each is checked again as it's deleted, so that memberships that
+// are being created while this runs are left alone.
+func (a *App) CleanupOrphanedMemberships(dryRun bool, userId string) (*model.OrphanedMembershipsReport, *model.AppError) {
+	report := &model.OrphanedMembershipsReport{
+		DryRun:         dryRun,
+		ChannelMembers: []*model.OrphanedChannelMember{},
+		TeamMembers:    []*model.OrphanedTeamMember{},
+	}
+
+	afterChannelId, afterUserId := "", ""
+	for {
+		result := <-a.Srv.Store.Channel().GetOrphanedMembers(afterChannelId, afterUserId, orphanedMembershipsBatchSize)
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		members := result.Data.([]*model.OrphanedChannelMember)
+
+		for _, member := range members {
+			afterChannelId, afterUserId = member.ChannelId, member.UserId
+
+			if !dryRun {
+				deleted, err := a.deleteOrphanedChannelMember(member, userId)
+				if err != nil {
+					return nil, err
+				}
+				if !deleted {
+					continue
+				}
+			}
+
+			report.ChannelMembers = append(report.ChannelMembers, member)
+		}
+
+		if len(members) < orphanedMembershipsBatchSize {
+			break
+		}
+	}
+
+	afterTeamId, afterUserId := "", ""
+	for {
+		result := <-a.Srv.Store.Team().GetOrphanedMembers(afterTeamId, afterUserId, orphanedMembershipsBatchSize)
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		members := result.Data.([]*model.OrphanedTeamMember)
+
+		for _, member := range members {
+			afterTeamId, afterUserId = member.TeamId, member.UserId
+
+			if !dryRun {
+				deleted, err := a.deleteOrphanedTeamMember(member, userId)
+				if err != nil {
+					return nil, err
+				}
+				if !deleted {
+					continue
+				}
+			}
+
+			report.TeamMembers = append(report.TeamMembers, member)
+		}
+
+		if len(members) < orphanedMembershipsBatchSize {
+			break
+		}
+	}
+
+	if !dryRun {
+		mlog.Info("Deleted orphaned memberships", mlog.Int("channel_members", len(report.ChannelMembers)), mlog.Int("team_members", len(report.TeamMembers)))
+	}
+
+	return report, nil
+}
+
+func (a *App) deleteOrphanedChannelMember(member *model.OrphanedChannelMember, userId string) (bool, *model.AppError) {
+	result := <-a.Srv.Store.Channel().RemoveOrphanedMember(member.ChannelId, member.UserId)
+	if result.Err != nil {
+		return false, result.Err
+	}
+	if !result.Data.(bool) {
+		return false, nil
+	}
+
+	a.InvalidateCacheForUser(member.UserId)
+	a.InvalidateCacheForChannelMembers(member.ChannelId)
+
+	a.saveOrphanedMembershipAudit(userId, AUDIT_ACTION_ORPHANED_CHANNEL_MEMBER_DELETED, fmt.Sprintf("channel_id=%v user_id=%v channel_exists=%v user_exists=%v", member.ChannelId, member.UserId, member.ChannelExists, member.UserExists))
+	return true, nil
+}
+
+func (a *App) deleteOrphanedTeamMember(member *model.OrphanedTeamMember, userId string) (bool, *model.AppError) {
+	result := <-a.Srv.Store.Team().RemoveOrphanedMember(member.TeamId, member.UserId)
+	if result.Err != nil {
+		return false, result.Err
+	}
+	if !result.Data.(bool) {
+		return false, nil
+	}
+
+	a.InvalidateCacheForUser(member.UserId)
+	a.ClearSessionCacheForUser(member.UserId)
+
+	a.saveOrphanedMembershipAudit(userId, AUDIT_ACTION_ORPHANED_TEAM_MEMBER_DELETED, fmt.Sprintf("team_id=%v user_id=%v team_exists=%v user_exists=%v", member.TeamId, member.UserId, member.TeamExists, member.UserExists))
+	return true, nil
+}
+
+func (a *App) saveOrphanedMembershipAudit(userId string, action string, extraInfo string) {
+	audit := &model.Audit{
+		UserId:    userId,
+		Action:    action,
+		ExtraInfo: extraInfo,
+	}
+	if result := <-a.Srv.Store.Audit().Save(audit); result.Err != nil {
+		mlog.Error("Failed to save orphaned membership audit", mlog.String("action", action), mlog.Err(result.Err))
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+const PERMALINK_PREVIEW_CACHE_SIZE = 10000
+const PERMALINK_PREVIEW_CACHE_DURATION = 600
+
+// permalinkPreviewCache holds the previews of posts that other posts link to, keyed by the id of the linked post. Who
+// may see a preview is always checked against the current state of its channel, so the cache only needs to be
+// invalidated when the linked post changes. They also expire in case an invalidation from another node is missed.
+var permalinkPreviewCache = utils.NewLru(PERMALINK_PREVIEW_CACHE_SIZE)
+
+// getFirstPermalinkPostId returns the id of the post that the first permalink to this site in a message links to.
+func getFirstPermalinkPostId(message string, siteURL string) string {
+	siteURL = strings.TrimRight(siteURL, "/")
+	if siteURL == "" || !strings.Contains(message, "/pl/") {
+		return ""
+	}
+
+	permalinkPattern := regexp.MustCompile(regexp.QuoteMeta(siteURL) + `/[a-z0-9\-_]+/pl/([a-z0-9]{26})\b`)
+	if match := permalinkPattern.FindStringSubmatch(message); match != nil {
+		return match[1]
+	}
+
+	return ""
+}
+
+// fillInPermalinkPreviewProp records which post a post links to, if any, and generates the preview of the linked post
+// so that it's ready when the post is viewed.
+func (a *App) fillInPermalinkPreviewProp(post *model.Post) {
+	previewedPostId := getFirstPermalinkPostId(post.Message, a.GetSiteURL())
+	if previewedPostId != "" && previewedPostId != post.Id {
+		if _, err := a.getPermalinkPreview(previewedPostId); err == nil {
+			post.AddProp(model.POST_PROPS_PREVIEWED_POST, previewedPostId)
+			return
+		}
+	}
+
+	if post.Props != nil {
+		delete(post.Props, model.POST_PROPS_PREVIEWED_POST)
+	}
+}
+
+// getPermalinkPreview returns the preview of a post, generating it if it isn't cached. It doesn't check whether anyone
+// has access to the post.
+func (a *App) getPermalinkPreview(postId string) (*model.PermalinkPreview, *model.AppError) {
+	if cached, ok := permalinkPreviewCache.Get(postId); ok {
+		return cached.(*model.PermalinkPreview), nil
+	}
+
+	preview, err := a.generatePermalinkPreview(postId)
+	if err != nil {
+		return nil, err
+	}
+
+	permalinkPreviewCache.AddWithExpiresInSecs(postId, preview, PERMALINK_PREVIEW_CACHE_DURATION)
+
+	return preview, nil
+}
+
+// generatePermalinkPreview generates the preview of a post from its current state, bypassing the cache.
+func (a *App) generatePermalinkPreview(postId string) (*model.PermalinkPreview, *model.AppError) {
+	post, err := a.GetSinglePost(postId)
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := a.GetChannel(post.ChannelId)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &model.PermalinkPreview{
+		PostId:             post.Id,
+		Message:            truncateRunes(post.Message, model.PERMALINK_PREVIEW_MAX_MESSAGE_RUNES),
+		UserId:             post.UserId,
+		CreateAt:           post.CreateAt,
+		ChannelId:          channel.Id,
+		ChannelDisplayName: channel.DisplayName,
+		ChannelType:        channel.Type,
+		TeamId:             channel.TeamId,
+	}
+
+	if user, err := a.GetUser(post.UserId); err == nil {
+		preview.Username = user.Username
+	}
+
+	if channel.TeamId != "" {
+		if team, err := a.GetTeam(channel.TeamId); err == nil {
+			preview.TeamName = team.Name
+		}
+	}
+
+	return preview, nil
+}
+
+// InvalidatePermalinkPreview removes the preview of a post from the cache on every node, once it's been edited or
+// deleted.
+func (a *App) InvalidatePermalinkPreview(postId string) {
+	a.InvalidatePermalinkPreviewSkipClusterSend(postId)
+
+	if a.Cluster != nil {
+		msg := &model.ClusterMessage{
+			Event:    model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_PERMALINK_PREVIEW,
+			SendType: model.CLUSTER_SEND_RELIABLE,
+			Data:     postId,
+		}
+		a.Cluster.SendClusterMessage(msg)
+	}
+}
+
+func (a *App) InvalidatePermalinkPreviewSkipClusterSend(postId string) {
+	permalinkPreviewCache.Remove(postId)
+}
+
+// getPermalinkEmbedForPost returns the embed for the post that a post links to, if any. Since what a preview shows
+// depends on who's viewing it, the embed only identifies the linked post until FillInPermalinkPreviews is called.
+func getPermalinkEmbedForPost(post *model.Post) *model.PostEmbed {
+	previewedPostId, ok := post.Props[model.POST_PROPS_PREVIEWED_POST].(string)
+	if !ok || previewedPostId == "" {
+		return nil
+	}
+
+	return &model.PostEmbed{
+		Type: model.POST_EMBED_PERMALINK,
+		Data: &model.PermalinkPreview{PostId: previewedPostId},
+	}
+}
+
+// FillInPermalinkPreviews fills in the permalink embeds and quotes of posts that have been prepared for a client with
+// the previews of the posts that they link to or quote, or with a placeholder when the session can't read those posts.
+func (a *App) FillInPermalinkPreviews(posts []*model.Post, session model.Session) {
+	quotes := a.getSavedQuotes(posts)
+
+	for _, post := range posts {
+		if post.Metadata == nil {
+			continue
+		}
+
+		if post.Metadata.Quote != nil {
+			post.Metadata.Quote = a.resolveQuoteForSession(post, quotes[post.Id], session)
+		}
+
+		for _, embed := range post.Metadata.Embeds {
+			if embed.Type != model.POST_EMBED_PERMALINK {
+				continue
+			}
+
+			placeholder, ok := embed.Data.(*model.PermalinkPreview)
+			if !ok {
+				continue
+			}
+
+			preview, err := a.getPermalinkPreview(placeholder.PostId)
+			if err != nil {
+				mlog.Debug("Failed to get the preview of a linked post", mlog.String("post_id", post.Id), mlog.String("previewed_post_id", placeholder.PostId), mlog.Err(err))
+				embed.Data = &model.PermalinkPreview{PostId: placeholder.PostId, NoAccess: true}
+			} else if !a.sessionCanReadPermalinkPreview(session, preview) {
+				embed.Data = &model.PermalinkPreview{PostId: placeholder.PostId, NoAccess: true}
+			} else {
+				embed.Data = preview
+			}
+		}
+	}
+}
+
+// FillInPermalinkPreviewsForList is like FillInPermalinkPreviews for a list of posts.
+func (a *App) FillInPermalinkPreviewsForList(list *model.PostList, session model.Session) {
+	posts := make([]*model.Post, 0, len(list.Posts))
+	for _, post := range list.Posts {
+		posts = append(posts, post)
+	}
+	a.FillInPermalinkPreviews(posts, session)
+}
+
+// sessionCanReadPermalinkPreview returns whether a session may see a preview. It's checked against the channel as it
+// is now rather than as it was when the preview was generated, since the channel may have been made private or moved
+// to another team since.
+func (a *App) sessionCanReadPermalinkPreview(session model.Session, preview *model.PermalinkPreview) bool {
+	if a.SessionHasPermissionToChannel(session, preview.ChannelId, model.PERMISSION_READ_CHANNEL) {
+		return true
+	}
+
+	channel, err := a.GetChannel(preview.ChannelId)
+	if err != nil {
+		return false
+	}
+
+	return channel.Type == model.CHANNEL_OPEN && a.SessionHasPermissionToTeam(session, channel.TeamId, model.PERMISSION_READ_PUBLIC_CHANNEL)
+}
+
+// userCanReadPermalinkPreview is like sessionCanReadPermalinkPreview for a user.
+func (a *App) userCanReadPermalinkPreview(userId string, preview *model.PermalinkPreview) bool {
+	if a.HasPermissionToChannel(userId, preview.ChannelId, model.PERMISSION_READ_CHANNEL) {
+		return true
+	}
+
+	channel, err := a.GetChannel(preview.ChannelId)
+	if err != nil {
+		return false
+	}
+
+	return channel.Type == model.CHANNEL_OPEN && a.HasPermissionToTeam(userId, channel.TeamId, model.PERMISSION_READ_PUBLIC_CHANNEL)
+}
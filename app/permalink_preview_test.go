@@ -0,0 +1,119 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestGetFirstPermalinkPostId(t *testing.T) {
+	postId := model.NewId()
+	otherPostId := model.NewId()
+
+	for name, tc := range map[string]struct {
+		Message  string
+		SiteURL  string
+		Expected string
+	}{
+		"no permalink":              {Message: "hello world", SiteURL: "https://mattermost.example.com", Expected: ""},
+		"permalink":                 {Message: "see https://mattermost.example.com/team/pl/" + postId, SiteURL: "https://mattermost.example.com", Expected: postId},
+		"site url with a slash":     {Message: "https://mattermost.example.com/team/pl/" + postId, SiteURL: "https://mattermost.example.com/", Expected: postId},
+		"site url with a subpath":   {Message: "https://example.com/chat/team-name/pl/" + postId, SiteURL: "https://example.com/chat", Expected: postId},
+		"first of several":          {Message: "https://example.com/team/pl/" + postId + " https://example.com/team/pl/" + otherPostId, SiteURL: "https://example.com", Expected: postId},
+		"permalink to another site": {Message: "https://other.example.com/team/pl/" + postId, SiteURL: "https://example.com", Expected: ""},
+		"no site url":               {Message: "https://example.com/team/pl/" + postId, SiteURL: "", Expected: ""},
+		"invalid post id":           {Message: "https://example.com/team/pl/" + postId + "abc", SiteURL: "https://example.com", Expected: ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, getFirstPermalinkPostId(tc.Message, tc.SiteURL))
+		})
+	}
+}
+
+func TestPermalinkPreviews(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.SiteURL = "http://mattermost.example.com"
+	})
+
+	privateChannel := th.CreatePrivateChannel(th.BasicTeam)
+	linkedPost := th.CreatePost(privateChannel)
+
+	post, err := th.App.CreatePost(&model.Post{
+		UserId:    th.BasicUser.Id,
+		ChannelId: th.BasicChannel.Id,
+		Message:   "look at http://mattermost.example.com/" + th.BasicTeam.Name + "/pl/" + linkedPost.Id,
+	}, th.BasicChannel, false)
+	require.Nil(t, err)
+	assert.Equal(t, linkedPost.Id, post.Props[model.POST_PROPS_PREVIEWED_POST])
+
+	getPreview := func(t *testing.T, user *model.User) *model.PermalinkPreview {
+		clientPost := th.App.PreparePostForClient(post, false)
+		th.App.FillInPermalinkPreviews([]*model.Post{clientPost}, model.Session{UserId: user.Id, Roles: user.GetRawRoles()})
+
+		for _, embed := range clientPost.Metadata.Embeds {
+			if embed.Type == model.POST_EMBED_PERMALINK {
+				return embed.Data.(*model.PermalinkPreview)
+			}
+		}
+
+		require.Fail(t, "the post should have a permalink embed")
+		return nil
+	}
+
+	t.Run("users who can read the linked post see its preview", func(t *testing.T) {
+		preview := getPreview(t, th.BasicUser)
+		assert.False(t, preview.NoAccess)
+		assert.Equal(t, linkedPost.Id, preview.PostId)
+		assert.Equal(t, linkedPost.Message, preview.Message)
+		assert.Equal(t, th.BasicUser.Username, preview.Username)
+		assert.Equal(t, privateChannel.DisplayName, preview.ChannelDisplayName)
+		assert.Equal(t, th.BasicTeam.Name, preview.TeamName)
+	})
+
+	t.Run("users who can't read the linked post see a placeholder", func(t *testing.T) {
+		preview := getPreview(t, th.BasicUser2)
+		assert.True(t, preview.NoAccess)
+		assert.Equal(t, linkedPost.Id, preview.PostId)
+		assert.Empty(t, preview.Message)
+	})
+
+	t.Run("editing the linked post updates the preview", func(t *testing.T) {
+		linkedPost.Message = "edited"
+		_, err := th.App.UpdatePost(linkedPost, false)
+		require.Nil(t, err)
+
+		assert.Equal(t, "edited", getPreview(t, th.BasicUser).Message)
+	})
+
+	t.Run("making the linked post's channel private hides the preview", func(t *testing.T) {
+		publicChannel := th.CreateChannel(th.BasicTeam)
+		linkedPost = th.CreatePost(publicChannel)
+
+		post, err = th.App.CreatePost(&model.Post{
+			UserId:    th.BasicUser.Id,
+			ChannelId: th.BasicChannel.Id,
+			Message:   "look at http://mattermost.example.com/" + th.BasicTeam.Name + "/pl/" + linkedPost.Id,
+		}, th.BasicChannel, false)
+		require.Nil(t, err)
+
+		// Anyone on the team can read public channels without joining them
+		outsider := th.CreateUser()
+		th.LinkUserToTeam(outsider, th.BasicTeam)
+		assert.False(t, getPreview(t, outsider).NoAccess)
+
+		publicChannel.Type = model.CHANNEL_PRIVATE
+		_, err = th.App.UpdateChannelPrivacy(publicChannel, th.BasicUser)
+		require.Nil(t, err)
+
+		assert.True(t, getPreview(t, outsider).NoAccess)
+	})
+}
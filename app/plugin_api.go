@@ -658,3 +658,15 @@ func (api *PluginAPI) LogError(msg string, keyValuePairs ...interface{}) {
 func (api *PluginAPI) LogWarn(msg string, keyValuePairs ...interface{}) {
 	api.logger.Warn(msg, keyValuePairs...)
 }
+
+func (api *PluginAPI) EnqueueJob(jobType string, data map[string]string, maxAttempts int) (*model.Job, *model.AppError) {
+	return api.app.EnqueuePluginJob(api.id, jobType, data, maxAttempts)
+}
+
+func (api *PluginAPI) GetJob(jobId string) (*model.Job, *model.AppError) {
+	return api.app.GetPluginJob(api.id, jobId)
+}
+
+func (api *PluginAPI) CancelJob(jobId string) *model.AppError {
+	return api.app.CancelPluginJob(api.id, jobId)
+}
@@ -947,3 +947,63 @@ func TestHookContext(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestHookSearchResultsWillBeReturned(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	first, err := th.App.CreatePost(&model.Post{UserId: th.BasicUser.Id, ChannelId: th.BasicChannel.Id, Message: "rerankable first", CreateAt: model.GetMillis() - 20000}, th.BasicChannel, false)
+	require.Nil(t, err)
+	second, err := th.App.CreatePost(&model.Post{UserId: th.BasicUser.Id, ChannelId: th.BasicChannel.Id, Message: "rerankable second", CreateAt: model.GetMillis() - 10000}, th.BasicChannel, false)
+	require.Nil(t, err)
+
+	hiddenChannel, err := th.App.CreateChannel(&model.Channel{DisplayName: "Hidden", Name: "hidden-" + model.NewId(), Type: model.CHANNEL_PRIVATE, TeamId: th.BasicTeam.Id}, false)
+	require.Nil(t, err)
+	hidden, err := th.App.CreatePost(&model.Post{UserId: th.BasicUser2.Id, ChannelId: hiddenChannel.Id, Message: "hidden"}, hiddenChannel, false)
+	require.Nil(t, err)
+
+	tearDown, _, _ := SetAppEnvironmentWithPlugins(t,
+		[]string{strings.Replace(
+			`
+		package main
+
+		import (
+			"github.com/mattermost/mattermost-server/plugin"
+			"github.com/mattermost/mattermost-server/model"
+		)
+
+		type MyPlugin struct {
+			plugin.MattermostPlugin
+		}
+
+		func (p *MyPlugin) SearchResultsWillBeReturned(c *plugin.Context, query *model.PostSearchQuery, results *model.PostSearchResults) *model.PostSearchResults {
+			for i, j := 0, len(results.Order)-1; i < j; i, j = i+1, j-1 {
+				results.Order[i], results.Order[j] = results.Order[j], results.Order[i]
+			}
+			for _, post := range results.Posts {
+				post.AddProp("boosted", true)
+				results.Scores[post.Id] = results.Scores[post.Id] + 1
+			}
+
+			hidden, _ := p.API.GetPost("HIDDEN_POST_ID")
+			results.AddPost(hidden)
+			results.AddOrder(hidden.Id)
+
+			return results
+		}
+
+		func main() {
+			plugin.ClientMain(&MyPlugin{})
+		}
+	`, "HIDDEN_POST_ID", hidden.Id, 1)}, th.App, th.App.NewPluginAPI)
+	defer tearDown()
+
+	results, err := th.App.SearchPostsInTeam("rerankable", th.BasicUser.Id, th.BasicTeam.Id, false, false, false, 0, 0, 20)
+	require.Nil(t, err)
+
+	assert.Equal(t, []string{first.Id, second.Id}, results.Order)
+	assert.NotContains(t, results.Posts, hidden.Id, "posts the user can't read should be removed")
+	assert.Equal(t, true, results.Posts[first.Id].Props["boosted"])
+	assert.Equal(t, 1.5, results.Scores[first.Id])
+	assert.Equal(t, 2.0, results.Scores[second.Id])
+}
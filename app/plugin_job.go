@@ -0,0 +1,84 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/plugin"
+)
+
+// EnqueuePluginJob creates a job that's run by the RunJob hook of the given plugin.
+func (a *App) EnqueuePluginJob(pluginId string, jobType string, data map[string]string, maxAttempts int) (*model.Job, *model.AppError) {
+	if len(jobType) == 0 || len(jobType) > model.PLUGIN_JOB_TYPE_MAX_LENGTH {
+		return nil, model.NewAppError("EnqueuePluginJob", "app.plugin_job.type.app_error", nil, "plugin_id="+pluginId, http.StatusBadRequest)
+	}
+
+	if maxAttempts == 0 {
+		maxAttempts = model.PLUGIN_JOB_DEFAULT_MAX_ATTEMPTS
+	}
+	if maxAttempts < 0 || maxAttempts > model.PLUGIN_JOB_MAXIMUM_MAX_ATTEMPTS {
+		return nil, model.NewAppError("EnqueuePluginJob", "app.plugin_job.max_attempts.app_error", map[string]interface{}{"Max": model.PLUGIN_JOB_MAXIMUM_MAX_ATTEMPTS}, "plugin_id="+pluginId, http.StatusBadRequest)
+	}
+
+	jobData := make(map[string]string, len(data)+4)
+	for key, value := range data {
+		if model.IsReservedPluginJobDataKey(key) {
+			return nil, model.NewAppError("EnqueuePluginJob", "app.plugin_job.reserved_data.app_error", map[string]interface{}{"Key": key}, "plugin_id="+pluginId, http.StatusBadRequest)
+		}
+		jobData[key] = value
+	}
+	jobData[model.PLUGIN_JOB_DATA_PLUGIN_ID] = pluginId
+	jobData[model.PLUGIN_JOB_DATA_TYPE] = jobType
+	jobData[model.PLUGIN_JOB_DATA_ATTEMPTS] = "0"
+	jobData[model.PLUGIN_JOB_DATA_MAX_ATTEMPTS] = strconv.Itoa(maxAttempts)
+
+	return a.Srv.Jobs.CreateJob(model.JOB_TYPE_PLUGIN_JOB, jobData)
+}
+
+// GetPluginJob gets a job that was enqueued by the given plugin. Jobs of other plugins, or that weren't enqueued by
+// a plugin, aren't found.
+func (a *App) GetPluginJob(pluginId string, jobId string) (*model.Job, *model.AppError) {
+	job, err := a.GetJob(jobId)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Type != model.JOB_TYPE_PLUGIN_JOB || job.Data[model.PLUGIN_JOB_DATA_PLUGIN_ID] != pluginId {
+		return nil, model.NewAppError("GetPluginJob", "app.plugin_job.not_found.app_error", nil, "plugin_id="+pluginId+", job_id="+jobId, http.StatusNotFound)
+	}
+
+	return job, nil
+}
+
+func (a *App) CancelPluginJob(pluginId string, jobId string) *model.AppError {
+	if _, err := a.GetPluginJob(pluginId, jobId); err != nil {
+		return err
+	}
+
+	return a.CancelJob(jobId)
+}
+
+// RunPluginJob runs a job enqueued by a plugin with the RunJob hook of that plugin.
+func (a *App) RunPluginJob(job *model.Job) *model.AppError {
+	pluginId := job.Data[model.PLUGIN_JOB_DATA_PLUGIN_ID]
+
+	pluginsEnvironment := a.GetPluginsEnvironment()
+	if pluginsEnvironment == nil || !pluginsEnvironment.Implements(pluginId, plugin.RunJobId) {
+		return model.NewAppError("RunPluginJob", "app.plugin_job.not_active.app_error", nil, "plugin_id="+pluginId+", job_id="+job.Id, http.StatusNotImplemented)
+	}
+
+	hooks, err := pluginsEnvironment.HooksForPlugin(pluginId)
+	if err != nil {
+		return model.NewAppError("RunPluginJob", "app.plugin_job.not_active.app_error", nil, err.Error(), http.StatusNotImplemented)
+	}
+
+	if err := hooks.RunJob(a.PluginContext(), job); err != nil {
+		return model.NewAppError("RunPluginJob", "app.plugin_job.run.app_error", nil, "plugin_id="+pluginId+", job_id="+job.Id+", "+err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestEnqueuePluginJob(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	pluginId := "com.mattermost.jobs"
+
+	t.Run("defaults", func(t *testing.T) {
+		job, err := th.App.EnqueuePluginJob(pluginId, "sync", map[string]string{"key": "value"}, 0)
+		require.Nil(t, err)
+
+		assert.Equal(t, model.JOB_TYPE_PLUGIN_JOB, job.Type)
+		assert.Equal(t, model.JOB_STATUS_PENDING, job.Status)
+		assert.Equal(t, "value", job.Data["key"])
+		assert.Equal(t, pluginId, job.Data[model.PLUGIN_JOB_DATA_PLUGIN_ID])
+		assert.Equal(t, "sync", job.Data[model.PLUGIN_JOB_DATA_TYPE])
+
+		attempts, maxAttempts := model.PluginJobAttempts(job)
+		assert.Equal(t, 0, attempts)
+		assert.Equal(t, model.PLUGIN_JOB_DEFAULT_MAX_ATTEMPTS, maxAttempts)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := th.App.EnqueuePluginJob(pluginId, "", nil, 1)
+		assert.NotNil(t, err)
+
+		_, err = th.App.EnqueuePluginJob(pluginId, strings.Repeat("a", model.PLUGIN_JOB_TYPE_MAX_LENGTH+1), nil, 1)
+		assert.NotNil(t, err)
+
+		_, err = th.App.EnqueuePluginJob(pluginId, "sync", nil, -1)
+		assert.NotNil(t, err)
+
+		_, err = th.App.EnqueuePluginJob(pluginId, "sync", nil, model.PLUGIN_JOB_MAXIMUM_MAX_ATTEMPTS+1)
+		assert.NotNil(t, err)
+
+		_, err = th.App.EnqueuePluginJob(pluginId, "sync", map[string]string{model.PLUGIN_JOB_DATA_PLUGIN_ID: "other"}, 1)
+		require.NotNil(t, err)
+		assert.Equal(t, "app.plugin_job.reserved_data.app_error", err.Id)
+	})
+}
+
+func TestGetAndCancelPluginJob(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	job, err := th.App.EnqueuePluginJob("com.mattermost.jobs", "sync", nil, 1)
+	require.Nil(t, err)
+
+	fetched, err := th.App.GetPluginJob("com.mattermost.jobs", job.Id)
+	require.Nil(t, err)
+	assert.Equal(t, job.Id, fetched.Id)
+
+	_, err = th.App.GetPluginJob("com.mattermost.other", job.Id)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusNotFound, err.StatusCode)
+
+	otherJob, err := th.App.Srv.Jobs.CreateJob(model.JOB_TYPE_MIGRATIONS, nil)
+	require.Nil(t, err)
+	_, err = th.App.GetPluginJob("com.mattermost.jobs", otherJob.Id)
+	assert.NotNil(t, err)
+
+	assert.NotNil(t, th.App.CancelPluginJob("com.mattermost.other", job.Id))
+	require.Nil(t, th.App.CancelPluginJob("com.mattermost.jobs", job.Id))
+
+	fetched, err = th.App.GetPluginJob("com.mattermost.jobs", job.Id)
+	require.Nil(t, err)
+	assert.Equal(t, model.JOB_STATUS_CANCELED, fetched.Status)
+}
+
+func TestRunPluginJob(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	tearDown, pluginIds, activationErrors := SetAppEnvironmentWithPlugins(t, []string{
+		`
+		package main
+
+		import (
+			"fmt"
+
+			"github.com/mattermost/mattermost-server/plugin"
+			"github.com/mattermost/mattermost-server/model"
+		)
+
+		type MyPlugin struct {
+			plugin.MattermostPlugin
+		}
+
+		func (p *MyPlugin) RunJob(c *plugin.Context, job *model.Job) error {
+			if job.Data["fail"] == "true" {
+				return fmt.Errorf("failed to run %v", job.Data["plugin_job_type"])
+			}
+			return nil
+		}
+
+		func main() {
+			plugin.ClientMain(&MyPlugin{})
+		}
+	`}, th.App, th.App.NewPluginAPI)
+	defer tearDown()
+	require.Nil(t, activationErrors[0])
+
+	job, err := th.App.EnqueuePluginJob(pluginIds[0], "sync", nil, 1)
+	require.Nil(t, err)
+	assert.Nil(t, th.App.RunPluginJob(job))
+
+	job, err = th.App.EnqueuePluginJob(pluginIds[0], "sync", map[string]string{"fail": "true"}, 1)
+	require.Nil(t, err)
+	err = th.App.RunPluginJob(job)
+	require.NotNil(t, err)
+	assert.Equal(t, "app.plugin_job.run.app_error", err.Id)
+	assert.Contains(t, err.DetailedError, "failed to run sync")
+
+	job, err = th.App.EnqueuePluginJob("com.mattermost.missing", "sync", nil, 1)
+	require.Nil(t, err)
+	err = th.App.RunPluginJob(job)
+	require.NotNil(t, err)
+	assert.Equal(t, "app.plugin_job.not_active.app_error", err.Id)
+}
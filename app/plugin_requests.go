@@ -23,9 +23,7 @@ func (a *App) ServePluginRequest(w http.ResponseWriter, r *http.Request) {
 	if pluginsEnvironment == nil {
 		err := model.NewAppError("ServePluginRequest", "app.plugin.disabled.app_error", nil, "Enable plugins to serve plugin requests", http.StatusNotImplemented)
 		a.Log.Error(err.Error())
-		w.WriteHeader(err.StatusCode)
-		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte(err.ToJson()))
+		utils.RenderApiError(a.Config(), w, r, err)
 		return
 	}
 
@@ -67,14 +65,12 @@ func (a *App) servePluginRequest(w http.ResponseWriter, r *http.Request, handler
 		session, err := a.GetSession(token)
 		csrfCheckPassed := true
 
-		if err == nil && cookieAuth && r.Method != "GET" && r.Header.Get(model.HEADER_REQUESTED_WITH) != model.HEADER_REQUESTED_WITH_XML {
+		if err == nil && cookieAuth && r.Method != "GET" && r.Header.Get(model.HEADER_REQUESTED_WITH) != model.HEADER_REQUESTED_WITH_XML && !session.IsValidCSRF(r.Header.Get(model.HEADER_CSRF_TOKEN)) {
 			bodyBytes, _ := ioutil.ReadAll(r.Body)
 			r.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
 			r.ParseForm()
-			sentToken := r.FormValue("csrf")
-			expectedToken := session.GetCSRF()
 
-			if sentToken != expectedToken {
+			if !session.IsValidCSRF(r.FormValue("csrf")) {
 				csrfCheckPassed = false
 			}
 
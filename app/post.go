@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/model"
@@ -20,6 +21,8 @@ import (
 const (
 	PENDING_POST_IDS_CACHE_SIZE = 25000
 	PENDING_POST_IDS_CACHE_TTL  = 30 * time.Second
+
+	EXPIRED_PINS_BATCH_SIZE = 100
 )
 
 func (a *App) CreatePostAsUser(post *model.Post, clearPushNotifications bool) (*model.Post, *model.AppError) {
@@ -134,6 +137,12 @@ func (a *App) deduplicateCreatePost(post *model.Post) (foundPost *model.Post, er
 }
 
 func (a *App) CreatePost(post *model.Post, channel *model.Channel, triggerWebhooks bool) (savedPost *model.Post, err *model.AppError) {
+	return a.createPost(post, channel, nil, triggerWebhooks)
+}
+
+// createPost creates a post in a channel. The channel's team can be passed by callers that create several posts in the
+// channel, so that it's only looked up once, or left nil to be looked up when it's needed.
+func (a *App) createPost(post *model.Post, channel *model.Channel, team *model.Team, triggerWebhooks bool) (savedPost *model.Post, err *model.AppError) {
 	if foundPost, err := a.deduplicateCreatePost(post); err != nil {
 		return nil, err
 	} else if foundPost != nil {
@@ -175,6 +184,10 @@ func (a *App) CreatePost(post *model.Post, channel *model.Channel, triggerWebhoo
 		return nil, model.NewAppError("createPost", "api.post.create_post.town_square_read_only", nil, "", http.StatusForbidden)
 	}
 
+	if err := a.fillInQuoteProp(post); err != nil {
+		return nil, err
+	}
+
 	// Verify the parent/child relationships are correct
 	var parentPostList *model.PostList
 	if pchan != nil {
@@ -238,12 +251,26 @@ func (a *App) CreatePost(post *model.Post, channel *model.Channel, triggerWebhoo
 		}
 	}
 
+	if !post.IsSystemMessage() {
+		a.rewritePostLinks(post)
+
+		if err := a.checkPostSizeForChannel(post, channel, team); err != nil {
+			return nil, err
+		}
+
+		if err := a.checkPostDataLossPrevention(post); err != nil {
+			return nil, err
+		}
+	}
+
 	result = <-a.Srv.Store.Post().Save(post)
 	if result.Err != nil {
 		return nil, result.Err
 	}
 	rpost := result.Data.(*model.Post)
 
+	a.publishPostFirehoseEvent(model.FIREHOSE_EVENT_POST_CREATED, rpost, channel)
+
 	// Update the mapping from pending post id to the actual post id, for any clients that
 	// might be duplicating requests.
 	a.Srv.seenPendingPostIdsCache.AddWithExpiresInSecs(post.PendingPostId, rpost.Id, int64(PENDING_POST_IDS_CACHE_TTL.Seconds()))
@@ -269,6 +296,14 @@ func (a *App) CreatePost(post *model.Post, channel *model.Channel, triggerWebhoo
 		a.Metrics.IncrementPostCreate()
 	}
 
+	if names := getEmojiNamesForString(post.Message); len(names) > 0 && !post.IsSystemMessage() && post.Props["from_webhook"] != "true" {
+		a.Srv.Go(func() {
+			if err := a.RecordEmojiUsage(post.UserId, names); err != nil {
+				mlog.Warn("Failed to record emoji usage", mlog.String("user_id", post.UserId), mlog.Err(err))
+			}
+		})
+	}
+
 	if len(post.FileIds) > 0 {
 		if err := a.attachFilesToPost(post); err != nil {
 			mlog.Error("Encountered error attaching files to post", mlog.String("post_id", post.Id), mlog.Any("file_ids", post.FileIds), mlog.Err(result.Err))
@@ -352,6 +387,8 @@ func (a *App) FillInPostProps(post *model.Post, channel *model.Channel) *model.A
 		delete(post.Props, "channel_mentions")
 	}
 
+	a.fillInPermalinkPreviewProp(post)
+
 	return nil
 }
 
@@ -431,13 +468,6 @@ func (a *App) UpdatePost(post *model.Post, safeUpdate bool) (*model.Post, *model
 		return nil, err
 	}
 
-	if a.License() != nil {
-		if *a.Config().ServiceSettings.PostEditTimeLimit != -1 && model.GetMillis() > oldPost.CreateAt+int64(*a.Config().ServiceSettings.PostEditTimeLimit*1000) && post.Message != oldPost.Message {
-			err := model.NewAppError("UpdatePost", "api.post.update_post.permissions_time_limit.app_error", map[string]interface{}{"timeLimit": *a.Config().ServiceSettings.PostEditTimeLimit}, "", http.StatusBadRequest)
-			return nil, err
-		}
-	}
-
 	channel, err := a.GetChannel(oldPost.ChannelId)
 	if err != nil {
 		return nil, err
@@ -448,10 +478,32 @@ func (a *App) UpdatePost(post *model.Post, safeUpdate bool) (*model.Post, *model
 		return nil, err
 	}
 
+	if a.License() != nil && post.Message != oldPost.Message {
+		timeLimit := *a.Config().ServiceSettings.PostEditTimeLimit
+		if roleTimeLimit, ok := a.postTimeLimitForUser(a.Session.UserId, channel); ok {
+			timeLimit = roleTimeLimit
+		}
+
+		if timeLimit != -1 && model.GetMillis() > oldPost.CreateAt+int64(timeLimit)*1000 {
+			err := model.NewAppError("UpdatePost", "api.post.update_post.permissions_time_limit.app_error", map[string]interface{}{"timeLimit": timeLimit}, "", http.StatusBadRequest)
+			return nil, err
+		}
+	}
+
 	newPost := &model.Post{}
 	*newPost = *oldPost
 
 	if newPost.Message != post.Message {
+		a.rewritePostLinks(post)
+
+		if err := a.checkPostSizeForChannel(post, channel, nil); err != nil {
+			return nil, err
+		}
+
+		if err := a.checkPostDataLossPrevention(post); err != nil {
+			return nil, err
+		}
+
 		newPost.Message = post.Message
 		newPost.EditAt = model.GetMillis()
 		newPost.Hashtags, _ = model.ParseHashtags(post.Message)
@@ -459,9 +511,11 @@ func (a *App) UpdatePost(post *model.Post, safeUpdate bool) (*model.Post, *model
 
 	if !safeUpdate {
 		newPost.IsPinned = post.IsPinned
+		newPost.PinnedUntil = post.PinnedUntil
 		newPost.HasReactions = post.HasReactions
 		newPost.FileIds = post.FileIds
 		newPost.Props = post.Props
+		keepQuoteProps(newPost, oldPost)
 	}
 
 	if err := a.FillInPostProps(post, nil); err != nil {
@@ -486,6 +540,8 @@ func (a *App) UpdatePost(post *model.Post, safeUpdate bool) (*model.Post, *model
 	}
 	rpost := result.Data.(*model.Post)
 
+	a.publishPostFirehoseEvent(model.FIREHOSE_EVENT_POST_EDITED, rpost, nil)
+
 	if pluginsEnvironment := a.GetPluginsEnvironment(); pluginsEnvironment != nil {
 		a.Srv.Go(func() {
 			pluginContext := a.PluginContext()
@@ -508,6 +564,8 @@ func (a *App) UpdatePost(post *model.Post, safeUpdate bool) (*model.Post, *model
 		})
 	}
 
+	a.InvalidatePermalinkPreview(rpost.Id)
+
 	rpost = a.PreparePostForClient(rpost, false)
 
 	a.sendUpdatedPostEvent(rpost)
@@ -549,20 +607,47 @@ func (a *App) sendUpdatedPostEvent(post *model.Post) {
 	a.Publish(message)
 }
 
-func (a *App) GetPostsPage(channelId string, page int, perPage int) (*model.PostList, *model.AppError) {
-	result := <-a.Srv.Store.Post().GetPosts(channelId, page*perPage, perPage, true)
-	if result.Err != nil {
-		return nil, result.Err
+// UnpinExpiredPosts unpins every post whose pin has expired and notifies the affected channels.
+func (a *App) UnpinExpiredPosts() *model.AppError {
+	for {
+		result := <-a.Srv.Store.Post().GetExpiredPinnedPosts(model.GetMillis(), EXPIRED_PINS_BATCH_SIZE)
+		if result.Err != nil {
+			return result.Err
+		}
+		posts := result.Data.([]*model.Post)
+
+		unpinned := 0
+		channelIds := map[string]bool{}
+		for _, post := range posts {
+			patch := &model.PostPatch{IsPinned: model.NewBool(false)}
+			if _, err := a.PatchPost(post.Id, patch); err != nil {
+				mlog.Warn("Failed to unpin expired post", mlog.String("post_id", post.Id), mlog.Err(err))
+				continue
+			}
+			unpinned++
+			channelIds[post.ChannelId] = true
+		}
+
+		for channelId := range channelIds {
+			message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_PINNED_POSTS_UPDATED, "", channelId, "", nil)
+			a.Publish(message)
+		}
+
+		// Stop once a batch makes no progress so posts that can't be unpinned aren't retried forever.
+		if len(posts) < EXPIRED_PINS_BATCH_SIZE || unpinned == 0 {
+			return nil
+		}
 	}
-	return result.Data.(*model.PostList), nil
+}
+
+func (a *App) GetPostsPage(channelId string, page int, perPage int) (*model.PostList, *model.AppError) {
+	return a.GetPosts(channelId, page*perPage, perPage)
 }
 
 func (a *App) GetPosts(channelId string, offset int, limit int) (*model.PostList, *model.AppError) {
-	result := <-a.Srv.Store.Post().GetPosts(channelId, offset, limit, true)
-	if result.Err != nil {
-		return nil, result.Err
-	}
-	return result.Data.(*model.PostList), nil
+	return a.coalescePostList(fmt.Sprintf("GetPosts:%s:%d:%d", channelId, offset, limit), func() store.StoreChannel {
+		return a.Srv.Store.Post().GetPosts(channelId, offset, limit, true)
+	})
 }
 
 func (a *App) GetPostsEtag(channelId string) string {
@@ -570,11 +655,67 @@ func (a *App) GetPostsEtag(channelId string) string {
 }
 
 func (a *App) GetPostsSince(channelId string, time int64) (*model.PostList, *model.AppError) {
-	result := <-a.Srv.Store.Post().GetPostsSince(channelId, time, true)
+	return a.coalescePostList(fmt.Sprintf("GetPostsSince:%s:%d", channelId, time), func() store.StoreChannel {
+		return a.Srv.Store.Post().GetPostsSince(channelId, time, true)
+	})
+}
+
+// GetCatchUpSummary summarizes what has happened in the user's channels since the given time, or since the user last
+// viewed each of them when since is 0. Muted channels are left out unless they mention the user, in which case only
+// their mentions are counted. Mentions are always those that the user hasn't read yet.
+func (a *App) GetCatchUpSummary(userId string, since int64, collapsedThreads bool, page, perPage int) (*model.CatchUpSummary, *model.AppError) {
+	user, err := a.GetUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if since < 0 {
+		return nil, model.NewAppError("GetCatchUpSummary", "app.post.catch_up.since.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	sinceLastViewed := since == 0
+	if earliest := model.GetMillis() - model.CATCH_UP_MAX_DAYS*DAY_MILLISECONDS; since < earliest {
+		since = earliest
+	}
+
+	result := <-a.Srv.Store.Post().GetCatchUpForUser(userId, since, sinceLastViewed, collapsedThreads, page*perPage, perPage+1)
 	if result.Err != nil {
 		return nil, result.Err
 	}
-	return result.Data.(*model.PostList), nil
+	channels := result.Data.([]*model.CatchUpChannel)
+
+	summary := &model.CatchUpSummary{
+		Since: since,
+		Teams: []*model.CatchUpTeam{},
+	}
+
+	if len(channels) > perPage {
+		summary.HasMore = true
+		channels = channels[:perPage]
+	}
+
+	timezone := user.GetPreferredTimezone()
+	now := time.Now()
+
+	for _, channel := range channels {
+		if model.IsChannelMuted(channel.NotifyProps, timezone, now) {
+			if channel.Mentions == 0 {
+				continue
+			}
+			channel.NewPosts = 0
+			channel.ThreadReplies = 0
+		}
+
+		// With collapsed threads, a channel whose only activity is in threads that the user isn't following has
+		// nothing to catch up on.
+		if channel.NewPosts == 0 && channel.Mentions == 0 && channel.ThreadReplies == 0 {
+			continue
+		}
+
+		summary.AddChannel(channel)
+	}
+
+	return summary, nil
 }
 
 func (a *App) GetSinglePost(postId string) (*model.Post, *model.AppError) {
@@ -586,11 +727,9 @@ func (a *App) GetSinglePost(postId string) (*model.Post, *model.AppError) {
 }
 
 func (a *App) GetPostThread(postId string) (*model.PostList, *model.AppError) {
-	result := <-a.Srv.Store.Post().Get(postId)
-	if result.Err != nil {
-		return nil, result.Err
-	}
-	return result.Data.(*model.PostList), nil
+	return a.coalescePostList("GetPostThread:"+postId, func() store.StoreChannel {
+		return a.Srv.Store.Post().Get(postId)
+	})
 }
 
 func (a *App) GetFlaggedPosts(userId string, offset int, limit int) (*model.PostList, *model.AppError) {
@@ -617,6 +756,14 @@ func (a *App) GetFlaggedPostsForChannel(userId, channelId string, offset int, li
 	return result.Data.(*model.PostList), nil
 }
 
+func (a *App) GetDeletedPostsForChannel(channelId string, page, perPage int) (*model.PostList, *model.AppError) {
+	result := <-a.Srv.Store.Post().GetDeletedPostsForChannel(channelId, page*perPage, perPage)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return result.Data.(*model.PostList), nil
+}
+
 func (a *App) GetPermalinkPost(postId string, userId string) (*model.PostList, *model.AppError) {
 	result := <-a.Srv.Store.Post().Get(postId)
 	if result.Err != nil {
@@ -642,34 +789,21 @@ func (a *App) GetPermalinkPost(postId string, userId string) (*model.PostList, *
 }
 
 func (a *App) GetPostsBeforePost(channelId, postId string, page, perPage int) (*model.PostList, *model.AppError) {
-	result := <-a.Srv.Store.Post().GetPostsBefore(channelId, postId, perPage, page*perPage)
-	if result.Err != nil {
-		return nil, result.Err
-	}
-	return result.Data.(*model.PostList), nil
+	return a.GetPostsAroundPost(postId, channelId, page*perPage, perPage, true)
 }
 
 func (a *App) GetPostsAfterPost(channelId, postId string, page, perPage int) (*model.PostList, *model.AppError) {
-	result := <-a.Srv.Store.Post().GetPostsAfter(channelId, postId, perPage, page*perPage)
-	if result.Err != nil {
-		return nil, result.Err
-	}
-	return result.Data.(*model.PostList), nil
+	return a.GetPostsAroundPost(postId, channelId, page*perPage, perPage, false)
 }
 
 func (a *App) GetPostsAroundPost(postId, channelId string, offset, limit int, before bool) (*model.PostList, *model.AppError) {
-	var pchan store.StoreChannel
-	if before {
-		pchan = a.Srv.Store.Post().GetPostsBefore(channelId, postId, limit, offset)
-	} else {
-		pchan = a.Srv.Store.Post().GetPostsAfter(channelId, postId, limit, offset)
-	}
-
-	result := <-pchan
-	if result.Err != nil {
-		return nil, result.Err
-	}
-	return result.Data.(*model.PostList), nil
+	key := fmt.Sprintf("GetPostsAroundPost:%s:%s:%d:%d:%t", channelId, postId, offset, limit, before)
+	return a.coalescePostList(key, func() store.StoreChannel {
+		if before {
+			return a.Srv.Store.Post().GetPostsBefore(channelId, postId, limit, offset)
+		}
+		return a.Srv.Store.Post().GetPostsAfter(channelId, postId, limit, offset)
+	})
 }
 
 func (a *App) DeletePost(postId, deleteByID string) (*model.Post, *model.AppError) {
@@ -690,10 +824,21 @@ func (a *App) DeletePost(postId, deleteByID string) (*model.Post, *model.AppErro
 		return nil, err
 	}
 
+	if a.License() != nil {
+		if timeLimit, ok := a.postTimeLimitForUser(deleteByID, channel); ok && timeLimit != -1 && model.GetMillis() > post.CreateAt+int64(timeLimit)*1000 {
+			err := model.NewAppError("DeletePost", "api.post.delete_post.permissions_time_limit.app_error", map[string]interface{}{"timeLimit": timeLimit}, "", http.StatusBadRequest)
+			return nil, err
+		}
+	}
+
 	if result := <-a.Srv.Store.Post().Delete(postId, model.GetMillis(), deleteByID); result.Err != nil {
 		return nil, result.Err
 	}
 
+	a.publishPostFirehoseEvent(model.FIREHOSE_EVENT_POST_DELETED, post, channel)
+
+	a.InvalidatePermalinkPreview(post.Id)
+
 	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_POST_DELETED, "", post.ChannelId, "", nil)
 	message.Add("post", a.PreparePostForClient(post, false).ToJson())
 	a.Publish(message)
@@ -705,6 +850,12 @@ func (a *App) DeletePost(postId, deleteByID string) (*model.Post, *model.AppErro
 		a.DeleteFlaggedPosts(post.Id)
 	})
 
+	if channel.BannerPostId == post.Id {
+		if _, err := a.SetChannelBanner(channel, ""); err != nil {
+			mlog.Warn("Failed to clear the banner of a deleted post", mlog.String("post_id", post.Id), mlog.Err(err))
+		}
+	}
+
 	esInterface := a.Elasticsearch
 	if esInterface != nil && *a.Config().ElasticsearchSettings.EnableIndexing {
 		a.Srv.Go(func() {
@@ -724,6 +875,49 @@ func (a *App) DeleteFlaggedPosts(postId string) {
 	}
 }
 
+// postTimeLimitForUser returns how many seconds the user has to edit or delete a post in the channel after it's made,
+// or -1 if there's no limit, according to ServiceSettings.PostEditTimeLimitsByRole. The most permissive limit of the
+// user's system, team and channel roles applies. ok is false when none of their roles has a limit, including when
+// the post isn't being changed by a user.
+func (a *App) postTimeLimitForUser(userId string, channel *model.Channel) (timeLimit int, ok bool) {
+	timeLimits := a.Config().ServiceSettings.PostEditTimeLimitsByRole
+	if len(timeLimits) == 0 || userId == "" {
+		return 0, false
+	}
+
+	user, err := a.GetUser(userId)
+	if err != nil {
+		return 0, false
+	}
+
+	roles := user.GetRoles()
+	if channel.TeamId != "" {
+		if teamMember, err := a.GetTeamMember(channel.TeamId, userId); err == nil {
+			roles = append(roles, teamMember.GetRoles()...)
+		}
+	}
+	if channelMember, err := a.GetChannelMember(channel.Id, userId); err == nil {
+		roles = append(roles, channelMember.GetRoles()...)
+	}
+
+	for _, roleName := range roles {
+		roleTimeLimit, hasLimit := timeLimits[roleName]
+		if !hasLimit {
+			continue
+		}
+
+		if roleTimeLimit == 0 {
+			return -1, true
+		}
+		if !ok || roleTimeLimit > timeLimit {
+			timeLimit = roleTimeLimit
+			ok = true
+		}
+	}
+
+	return timeLimit, ok
+}
+
 func (a *App) DeletePostFiles(post *model.Post) {
 	if len(post.FileIds) == 0 {
 		return
@@ -771,8 +965,182 @@ func (a *App) parseAndFetchChannelIdByNameFromInFilter(channelName, userId, team
 	return channel, nil
 }
 
-func (a *App) SearchPostsInTeam(terms string, userId string, teamId string, isOrSearch bool, includeDeletedChannels bool, timeZoneOffset int, page, perPage int) (*model.PostSearchResults, *model.AppError) {
+func (a *App) SearchPostsInTeam(terms string, userId string, teamId string, isOrSearch bool, includeDeletedChannels bool, allTime bool, timeZoneOffset int, page, perPage int) (*model.PostSearchResults, *model.AppError) {
+	results, err := a.searchPosts(terms, userId, []string{teamId}, isOrSearch, includeDeletedChannels, allTime, timeZoneOffset, page, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	query := &model.PostSearchQuery{
+		UserId:     userId,
+		TeamId:     teamId,
+		Terms:      terms,
+		IsOrSearch: isOrSearch,
+	}
+
+	return a.runSearchResultsHooks(query, results), nil
+}
+
+// SearchPostsInTeams searches the given teams at once, or all of the user's teams if none are given, and merges the
+// results. Each result is tagged with the team that it was posted in.
+func (a *App) SearchPostsInTeams(terms string, userId string, teamIds []string, isOrSearch bool, includeDeletedChannels bool, allTime bool, timeZoneOffset int, page, perPage int) (*model.PostSearchResults, *model.AppError) {
+	if len(teamIds) == 0 {
+		teams, err := a.GetTeamsForUser(userId)
+		if err != nil {
+			return nil, err
+		}
+		for _, team := range teams {
+			teamIds = append(teamIds, team.Id)
+		}
+	}
+
+	results, err := a.searchPosts(terms, userId, teamIds, isOrSearch, includeDeletedChannels, allTime, timeZoneOffset, page, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	query := &model.PostSearchQuery{
+		UserId:     userId,
+		TeamIds:    teamIds,
+		Terms:      terms,
+		IsOrSearch: isOrSearch,
+	}
+
+	results = a.runSearchResultsHooks(query, results)
+
+	results.TeamIds = make(map[string]string, len(results.Order))
+	channelTeamIds := map[string]string{}
+	for _, postId := range results.Order {
+		channelId := results.Posts[postId].ChannelId
+
+		teamId, ok := channelTeamIds[channelId]
+		if !ok {
+			channel, err := a.GetChannel(channelId)
+			if err != nil {
+				return nil, err
+			}
+			teamId = channel.TeamId
+			channelTeamIds[channelId] = teamId
+		}
+
+		results.TeamIds[postId] = teamId
+	}
+
+	return results, nil
+}
+
+// runSearchResultsHooks lets plugins re-rank and annotate search results. Since plugins could return any post, the
+// results are checked again afterwards so that they only contain posts that the searching user can read.
+func (a *App) runSearchResultsHooks(query *model.PostSearchQuery, results *model.PostSearchResults) *model.PostSearchResults {
+	pluginsEnvironment := a.GetPluginsEnvironment()
+	if pluginsEnvironment == nil || len(results.Order) == 0 {
+		return results
+	}
+
+	results.SetRankScores()
+	searchedAfter := results.SearchedAfter
+
+	hooksRun := false
+	pluginContext := a.PluginContext()
+	pluginsEnvironment.RunMultiPluginHook(func(hooks plugin.Hooks) bool {
+		hooksRun = true
+		if replacementResults := hooks.SearchResultsWillBeReturned(pluginContext, query, results); replacementResults != nil && replacementResults.PostList != nil {
+			results = replacementResults
+		}
+
+		return true
+	}, plugin.SearchResultsWillBeReturnedId)
+
+	if !hooksRun {
+		results.Scores = nil
+		return results
+	}
+
+	filtered := a.filterSearchResultsForUser(query.UserId, results)
+	filtered.SearchedAfter = searchedAfter
+	return filtered
+}
+
+func (a *App) filterSearchResultsForUser(userId string, results *model.PostSearchResults) *model.PostSearchResults {
+	filtered := model.MakePostSearchResults(model.NewPostList(), nil)
+	canRead := map[string]bool{}
+
+	for _, postId := range results.Order {
+		post, ok := results.Posts[postId]
+		if !ok || post == nil || filtered.Posts[postId] != nil {
+			continue
+		}
+
+		allowed, checked := canRead[post.ChannelId]
+		if !checked {
+			allowed = a.HasPermissionToChannel(userId, post.ChannelId, model.PERMISSION_READ_CHANNEL)
+			canRead[post.ChannelId] = allowed
+		}
+		if !allowed {
+			continue
+		}
+
+		filtered.AddPost(post)
+		filtered.AddOrder(postId)
+
+		if matches, ok := results.Matches[postId]; ok {
+			if filtered.Matches == nil {
+				filtered.Matches = model.PostSearchMatches{}
+			}
+			filtered.Matches[postId] = matches
+		}
+
+		if score, ok := results.Scores[postId]; ok {
+			if filtered.Scores == nil {
+				filtered.Scores = map[string]float64{}
+			}
+			filtered.Scores[postId] = score
+		}
+	}
+
+	return filtered
+}
+
+func (a *App) searchPosts(terms string, userId string, teamIds []string, isOrSearch bool, includeDeletedChannels bool, allTime bool, timeZoneOffset int, page, perPage int) (*model.PostSearchResults, *model.AppError) {
 	paramsList := model.ParseSearchParams(terms, timeZoneOffset)
+	searchedAfter := a.limitSearchLookback(paramsList, allTime, timeZoneOffset)
+
+	results, err := a.searchPostsWithParams(paramsList, userId, teamIds, isOrSearch, includeDeletedChannels, page, perPage)
+	if err != nil {
+		return nil, err
+	}
+
+	results.SearchedAfter = searchedAfter
+	return results, nil
+}
+
+// limitSearchLookback restricts the search params without any date filters to the posts created in the last
+// ServiceSettings.SearchDefaultLookbackDays days, unless allTime is set. It returns the time from which posts are
+// searched, or 0 if the search isn't restricted.
+func (a *App) limitSearchLookback(paramsList []*model.SearchParams, allTime bool, timeZoneOffset int) int64 {
+	days := *a.Config().ServiceSettings.SearchDefaultLookbackDays
+	if allTime || days <= 0 {
+		return 0
+	}
+
+	// The after: filter excludes the given day itself.
+	now := time.Now().In(time.FixedZone("Local Search Time Zone", timeZoneOffset))
+	afterDate := now.AddDate(0, 0, -days-1).Format("2006-01-02")
+
+	var searchedAfter int64
+	for _, params := range paramsList {
+		if params.AfterDate != "" || params.BeforeDate != "" || params.OnDate != "" {
+			continue
+		}
+
+		params.AfterDate = afterDate
+		searchedAfter = params.GetAfterDateMillis()
+	}
+
+	return searchedAfter
+}
+
+func (a *App) searchPostsWithParams(paramsList []*model.SearchParams, userId string, teamIds []string, isOrSearch bool, includeDeletedChannels bool, page, perPage int) (*model.PostSearchResults, *model.AppError) {
 	includeDeleted := includeDeletedChannels && *a.Config().TeamSettings.ExperimentalViewArchivedChannels
 
 	esInterface := a.Elasticsearch
@@ -784,15 +1152,29 @@ func (a *App) SearchPostsInTeam(terms string, userId string, teamId string, isOr
 			params.OrTerms = isOrSearch
 			// Don't allow users to search for "*"
 			if params.Terms != "*" {
-				// Convert channel names to channel IDs
-				for idx, channelName := range params.InChannels {
-					channel, err := a.parseAndFetchChannelIdByNameFromInFilter(channelName, userId, teamId, includeDeletedChannels)
-					if err != nil {
-						mlog.Error(fmt.Sprint(err))
-						continue
+				// Convert channel names to channel IDs. The same name may be used by a channel in each team.
+				inChannels := []string{}
+				for _, channelName := range params.InChannels {
+					found := false
+					for _, teamId := range teamIds {
+						channel, err := a.parseAndFetchChannelIdByNameFromInFilter(channelName, userId, teamId, includeDeletedChannels)
+						if err != nil {
+							mlog.Error(fmt.Sprint(err))
+							continue
+						}
+						inChannels = append(inChannels, channel.Id)
+						found = true
+
+						// Direct and group messages don't belong to a team.
+						if strings.HasPrefix(channelName, "@") {
+							break
+						}
+					}
+					if !found {
+						inChannels = append(inChannels, channelName)
 					}
-					params.InChannels[idx] = channel.Id
 				}
+				params.InChannels = inChannels
 
 				// Convert usernames to user IDs
 				for idx, username := range params.FromUsers {
@@ -812,11 +1194,22 @@ func (a *App) SearchPostsInTeam(terms string, userId string, teamId string, isOr
 			return model.MakePostSearchResults(model.NewPostList(), nil), nil
 		}
 
-		// We only allow the user to search in channels they are a member of.
-		userChannels, err := a.GetChannelsForUser(teamId, userId, includeDeleted)
-		if err != nil {
-			mlog.Error(fmt.Sprint(err))
-			return nil, err
+		// We only allow the user to search in channels they are a member of. Direct and group messages are
+		// returned for every team, so they need to be de-duplicated.
+		userChannels := &model.ChannelList{}
+		seenChannels := map[string]bool{}
+		for _, teamId := range teamIds {
+			teamChannels, err := a.GetChannelsForUser(teamId, userId, includeDeleted)
+			if err != nil {
+				mlog.Error(fmt.Sprint(err))
+				return nil, err
+			}
+			for _, channel := range *teamChannels {
+				if !seenChannels[channel.Id] {
+					*userChannels = append(*userChannels, channel)
+					seenChannels[channel.Id] = true
+				}
+			}
 		}
 
 		postIds, matches, err := a.Elasticsearch.SearchPosts(userChannels, finalParamsList, page, perPage)
@@ -843,7 +1236,7 @@ func (a *App) SearchPostsInTeam(terms string, userId string, teamId string, isOr
 	}
 
 	if !*a.Config().ServiceSettings.EnablePostSearch {
-		return nil, model.NewAppError("SearchPostsInTeam", "store.sql_post.search.disabled", nil, fmt.Sprintf("teamId=%v userId=%v", teamId, userId), http.StatusNotImplemented)
+		return nil, model.NewAppError("SearchPostsInTeam", "store.sql_post.search.disabled", nil, fmt.Sprintf("teamIds=%v userId=%v", teamIds, userId), http.StatusNotImplemented)
 	}
 
 	// Since we don't support paging we just return nothing for later pages
@@ -860,7 +1253,7 @@ func (a *App) SearchPostsInTeam(terms string, userId string, teamId string, isOr
 		if params.Terms != "*" {
 			for idx, channelName := range params.InChannels {
 				if strings.HasPrefix(channelName, "@") {
-					channel, err := a.parseAndFetchChannelIdByNameFromInFilter(channelName, userId, teamId, includeDeletedChannels)
+					channel, err := a.parseAndFetchChannelIdByNameFromInFilter(channelName, userId, "", includeDeletedChannels)
 					if err != nil {
 						mlog.Error(fmt.Sprint(err))
 						continue
@@ -868,7 +1261,7 @@ func (a *App) SearchPostsInTeam(terms string, userId string, teamId string, isOr
 					params.InChannels[idx] = channel.Name
 				}
 			}
-			channels = append(channels, a.Srv.Store.Post().Search(teamId, userId, params))
+			channels = append(channels, a.Srv.Store.Post().SearchInTeams(teamIds, userId, params))
 		}
 	}
 
@@ -971,3 +1364,76 @@ func (a *App) MaxPostSize() int {
 	}
 	return result.Data.(int)
 }
+
+// MaxPostSizeForChannel returns the maximum number of runes in a message posted to the channel. A channel's own
+// override takes precedence over its team's, and neither can raise the limit above MaxPostSize.
+func (a *App) MaxPostSizeForChannel(channel *model.Channel) (int, *model.AppError) {
+	return a.maxPostSizeForChannel(channel, nil)
+}
+
+// maxPostSizeForChannel works like MaxPostSizeForChannel, but uses the channel's team if it's passed instead of
+// looking it up.
+func (a *App) maxPostSizeForChannel(channel *model.Channel, team *model.Team) (int, *model.AppError) {
+	maxPostSize := a.MaxPostSize()
+
+	override := channel.MaxPostSize
+	if override == 0 && channel.TeamId != "" {
+		if team == nil {
+			var err *model.AppError
+			if team, err = a.GetTeam(channel.TeamId); err != nil {
+				return 0, err
+			}
+		}
+		override = team.MaxPostSize
+	}
+
+	if override > 0 && override < maxPostSize {
+		return override, nil
+	}
+	return maxPostSize, nil
+}
+
+func (a *App) checkPostSizeForChannel(post *model.Post, channel *model.Channel, team *model.Team) *model.AppError {
+	maxPostSize, err := a.maxPostSizeForChannel(channel, team)
+	if err != nil {
+		return err
+	}
+
+	if utf8.RuneCountInString(post.Message) > maxPostSize {
+		return model.NewAppError("checkPostSizeForChannel", "api.post.check_post_size.too_long.app_error", map[string]interface{}{"Max": maxPostSize}, "channel_id="+channel.Id, http.StatusBadRequest)
+	}
+	return nil
+}
+
+// ScanPostMessage checks a message that's being composed for sensitive content, so that the user can be warned
+// about it before posting.
+func (a *App) ScanPostMessage(message string) *model.DataLossPreventionScan {
+	scanner, _ := a.Srv.dataLossPreventionScanner.Load().(*model.DataLossPreventionScanner)
+	return scanner.Scan(message)
+}
+
+// checkPostDataLossPrevention rejects a post with sensitive content if the data loss prevention patterns are
+// enforced.
+func (a *App) checkPostDataLossPrevention(post *model.Post) *model.AppError {
+	scan := a.ScanPostMessage(post.Message)
+	if !scan.Blocked {
+		return nil
+	}
+
+	names := []string{}
+	for _, warning := range scan.Warnings {
+		if !utils.StringInSlice(warning.Name, names) {
+			names = append(names, warning.Name)
+		}
+	}
+
+	return model.NewAppError("checkPostDataLossPrevention", "api.post.check_post_data_loss_prevention.app_error", map[string]interface{}{"Names": strings.Join(names, ", ")}, "", http.StatusBadRequest)
+}
+
+// checkMaxPostSizeOverride verifies that a channel or team override doesn't exceed what the database can store.
+func (a *App) checkMaxPostSizeOverride(override int) *model.AppError {
+	if maxPostSize := a.MaxPostSize(); override > maxPostSize {
+		return model.NewAppError("checkMaxPostSizeOverride", "api.post.check_max_post_size_override.app_error", map[string]interface{}{"Max": maxPostSize}, "", http.StatusBadRequest)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// ExpandPostList embeds the objects named in expand, any of model.POST_EXPAND_*, in the metadata of each post in the
+// list. Unknown names are ignored. The related objects of every post are looked up together to avoid a query per
+// post. Authors are sanitized as they would be for asAdmin, while channels are returned as is, so the caller must have
+// checked that the session can read the posts of every channel in the list. The posts are modified in place, so the
+// list must already have been prepared for the client.
+func (a *App) ExpandPostList(list *model.PostList, expand []string, asAdmin bool) *model.AppError {
+	expandUser, expandChannel := false, false
+	for _, name := range expand {
+		switch name {
+		case model.POST_EXPAND_USER:
+			expandUser = true
+		case model.POST_EXPAND_CHANNEL:
+			expandChannel = true
+		}
+	}
+
+	if len(list.Posts) == 0 || (!expandUser && !expandChannel) {
+		return nil
+	}
+
+	userIds := []string{}
+	channelIds := []string{}
+	seen := map[string]bool{}
+	for _, post := range list.Posts {
+		if expandUser && !seen[post.UserId] {
+			seen[post.UserId] = true
+			userIds = append(userIds, post.UserId)
+		}
+		if expandChannel && !seen[post.ChannelId] {
+			seen[post.ChannelId] = true
+			channelIds = append(channelIds, post.ChannelId)
+		}
+	}
+
+	users := map[string]*model.User{}
+	if len(userIds) > 0 {
+		profiles, err := a.GetUsersByIds(userIds, asAdmin)
+		if err != nil {
+			return err
+		}
+		for _, user := range profiles {
+			users[user.Id] = user
+		}
+	}
+
+	channels := map[string]*model.Channel{}
+	if len(channelIds) > 0 {
+		result := <-a.Srv.Store.Channel().GetByIds(channelIds, true)
+		if result.Err != nil {
+			return result.Err
+		}
+		for _, channel := range result.Data.([]*model.Channel) {
+			channels[channel.Id] = channel
+		}
+	}
+
+	for _, post := range list.Posts {
+		if post.Metadata == nil {
+			post.Metadata = &model.PostMetadata{}
+		}
+		if expandUser {
+			post.Metadata.User = users[post.UserId]
+		}
+		if expandChannel {
+			post.Metadata.Channel = channels[post.ChannelId]
+		}
+	}
+
+	return nil
+}
+
+func (a *App) ExpandPost(post *model.Post, expand []string, asAdmin bool) *model.AppError {
+	list := model.NewPostList()
+	list.AddPost(post)
+	return a.ExpandPostList(list, expand, asAdmin)
+}
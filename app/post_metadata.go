@@ -57,6 +57,8 @@ func (a *App) PreparePostForClient(originalPost *model.Post, isNewPost bool) *mo
 	// Proxy image links before constructing metadata so that requests go through the proxy
 	post = a.PostWithProxyAddedToImageURLs(post)
 
+	quote := removeQuoteFromProps(post)
+
 	if *a.Config().ExperimentalSettings.DisablePostMetadata {
 		return post
 	}
@@ -89,6 +91,12 @@ func (a *App) PreparePostForClient(originalPost *model.Post, isNewPost bool) *mo
 		post.Metadata.Embeds = []*model.PostEmbed{embed}
 	}
 
+	if permalinkEmbed := getPermalinkEmbedForPost(post); permalinkEmbed != nil {
+		post.Metadata.Embeds = append(post.Metadata.Embeds, permalinkEmbed)
+	}
+
+	post.Metadata.Quote = quote
+
 	post.Metadata.Images = a.getImagesForPost(post, images, isNewPost)
 
 	return post
@@ -366,7 +374,7 @@ func (a *App) getLinkMetadata(requestURL string, timestamp int64, isNewPost bool
 	} else {
 		request.Header.Add("Accept", "image/*, text/html")
 
-		client := a.HTTPService.MakeClient(false)
+		client := a.HTTPService.MakeIntegrationClient(a.Config().IntegrationHTTPSettings.LinkPreviews)
 		client.Timeout = time.Duration(*a.Config().ExperimentalSettings.LinkMetadataTimeoutMilliseconds) * time.Millisecond
 
 		var res *http.Response
@@ -0,0 +1,295 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+// MovePosts relocates the given posts, and optionally the whole of their threads, from one channel to another.
+// Authorship and timestamps are preserved, and since reactions and file attachments are keyed by post they come
+// along with the posts. The authors of the posts must be members of the destination channel. The posts are moved,
+// and the message counts of both channels updated, in a single transaction. A system message referencing the
+// destination is left behind in the source channel.
+func (a *App) MovePosts(postIds []string, fromChannel *model.Channel, toChannel *model.Channel, includeThread bool, user *model.User) (*model.PostList, *model.AppError) {
+	if fromChannel.Id == toChannel.Id {
+		return nil, model.NewAppError("MovePosts", "api.post.move_posts.same_channel.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if fromChannel.DeleteAt != 0 || toChannel.DeleteAt != 0 {
+		return nil, model.NewAppError("MovePosts", "api.post.move_posts.deleted_channel.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	result := <-a.Srv.Store.Post().GetPostsByIds(postIds)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	posts := result.Data.([]*model.Post)
+
+	moving := make(map[string]*model.Post)
+	for _, post := range posts {
+		if post.ChannelId != fromChannel.Id || post.DeleteAt != 0 {
+			return nil, model.NewAppError("MovePosts", "api.post.move_posts.invalid_post.app_error", nil, "post_id="+post.Id, http.StatusBadRequest)
+		}
+		moving[post.Id] = post
+	}
+
+	for _, postId := range postIds {
+		if _, ok := moving[postId]; !ok {
+			return nil, model.NewAppError("MovePosts", "api.post.move_posts.invalid_post.app_error", nil, "post_id="+postId, http.StatusNotFound)
+		}
+	}
+
+	// A thread can't span channels, so root posts always bring their replies with them. Replies only bring the
+	// rest of their thread when explicitly requested.
+	for _, post := range posts {
+		rootId := post.Id
+		if len(post.RootId) > 0 {
+			if !includeThread {
+				continue
+			}
+			rootId = post.RootId
+		}
+
+		tresult := <-a.Srv.Store.Post().Get(rootId)
+		if tresult.Err != nil {
+			return nil, tresult.Err
+		}
+
+		for _, threadPost := range tresult.Data.(*model.PostList).Posts {
+			moving[threadPost.Id] = threadPost
+		}
+	}
+
+	if err := a.checkPostAuthorsAreMembers(moving, toChannel); err != nil {
+		return nil, err
+	}
+
+	movingPosts := make([]*model.Post, 0, len(moving))
+	for _, post := range moving {
+		// Replies whose thread is staying behind become root posts in the destination channel
+		if len(post.RootId) > 0 {
+			if _, ok := moving[post.RootId]; !ok {
+				post.RootId = ""
+				post.ParentId = ""
+			}
+		}
+
+		movingPosts = append(movingPosts, post)
+	}
+
+	if mresult := <-a.Srv.Store.Post().MoveToChannel(movingPosts, fromChannel.Id, toChannel.Id); mresult.Err != nil {
+		return nil, mresult.Err
+	}
+
+	list := model.NewPostList()
+	for _, post := range movingPosts {
+		list.AddPost(post)
+		list.AddOrder(post.Id)
+
+		// Who may see the preview of a post depends on its channel
+		a.InvalidatePermalinkPreview(post.Id)
+	}
+	list.SortByCreateAt()
+
+	a.InvalidateCacheForChannelPosts(fromChannel.Id)
+	a.InvalidateCacheForChannelPosts(toChannel.Id)
+	a.Srv.Store.Channel().InvalidateChannel(fromChannel.Id)
+	a.Srv.Store.Channel().InvalidateChannel(toChannel.Id)
+
+	for _, post := range list.Posts {
+		clientPost := a.PreparePostForClient(post, false)
+
+		removed := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_POST_DELETED, "", fromChannel.Id, "", nil)
+		removed.Add("post", clientPost.ToJson())
+		a.Publish(removed)
+
+		added := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_POSTED, "", toChannel.Id, "", nil)
+		added.Add("post", clientPost.ToJson())
+		added.Add("channel_type", toChannel.Type)
+		added.Add("channel_name", toChannel.Name)
+		added.Add("channel_display_name", toChannel.DisplayName)
+		added.Add("team_id", toChannel.TeamId)
+		a.Publish(added)
+	}
+
+	esInterface := a.Elasticsearch
+	if esInterface != nil && *a.Config().ElasticsearchSettings.EnableIndexing {
+		a.Srv.Go(func() {
+			for _, post := range list.Posts {
+				if err := esInterface.IndexPost(post, toChannel.TeamId); err != nil {
+					mlog.Error("Encountered error indexing moved post", mlog.String("post_id", post.Id), mlog.Err(err))
+				}
+			}
+		})
+	}
+
+	if err := a.postMovedPostsMessage(user, fromChannel, toChannel, len(list.Order)); err != nil {
+		mlog.Error("Failed to post moved posts message", mlog.Err(err))
+	}
+
+	return list, nil
+}
+
+// checkPostAuthorsAreMembers returns an error unless the authors of the given posts are all members of a channel, so
+// that posts aren't moved somewhere their authors couldn't have posted them. System messages are left out, since
+// they're often about users who have left.
+func (a *App) checkPostAuthorsAreMembers(posts map[string]*model.Post, channel *model.Channel) *model.AppError {
+	authors := map[string]bool{}
+	for _, post := range posts {
+		if !post.IsSystemMessage() {
+			authors[post.UserId] = true
+		}
+	}
+
+	if len(authors) == 0 {
+		return nil
+	}
+
+	userIds := make([]string, 0, len(authors))
+	for userId := range authors {
+		userIds = append(userIds, userId)
+	}
+
+	result := <-a.Srv.Store.Channel().GetMembersByIds(channel.Id, userIds)
+	if result.Err != nil {
+		return result.Err
+	}
+
+	for _, member := range *result.Data.(*model.ChannelMembers) {
+		delete(authors, member.UserId)
+	}
+
+	for userId := range authors {
+		return model.NewAppError("MovePosts", "api.post.move_posts.author_not_member.app_error", nil, "user_id="+userId+", channel_id="+channel.Id, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (a *App) postMovedPostsMessage(user *model.User, fromChannel *model.Channel, toChannel *model.Channel, count int) *model.AppError {
+	post := &model.Post{
+		ChannelId: fromChannel.Id,
+		Message:   fmt.Sprintf(utils.T("api.post.move_posts.system_message"), user.Username, count, toChannel.Name),
+		Type:      model.POST_MOVE_POSTS,
+		UserId:    user.Id,
+		Props: model.StringInterface{
+			"username":        user.Username,
+			"moved_count":     count,
+			"to_channel_id":   toChannel.Id,
+			"to_channel_name": toChannel.Name,
+		},
+	}
+
+	if _, err := a.CreatePost(post, fromChannel, false); err != nil {
+		return model.NewAppError("postMovedPostsMessage", "api.post.move_posts.post.error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// SplitThreadToChannel creates a new channel in the thread's team, adds everyone who participated in the thread
+// containing postId as a member, and moves the whole thread into it. The participants must all be active members of
+// the team. If they can't all be added, or the thread can't be moved, the new channel is deleted again.
+func (a *App) SplitThreadToChannel(postId string, channel *model.Channel, user *model.User) (*model.Channel, *model.AppError) {
+	post, err := a.GetSinglePost(postId)
+	if err != nil {
+		return nil, err
+	}
+
+	rootId := post.Id
+	if len(post.RootId) > 0 {
+		rootId = post.RootId
+	}
+
+	fromChannel, err := a.GetChannel(post.ChannelId)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromChannel.IsGroupOrDirect() {
+		return nil, model.NewAppError("SplitThreadToChannel", "api.post.split_thread.direct_channel.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	thread, err := a.GetPostThread(rootId)
+	if err != nil {
+		return nil, err
+	}
+
+	participantIds, err := a.getThreadParticipantsForSplit(thread, fromChannel.TeamId, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	channel.TeamId = fromChannel.TeamId
+	toChannel, err := a.CreateChannelWithUser(channel, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, participantId := range participantIds {
+		if _, err = a.AddChannelMember(participantId, toChannel, user.Id, "", false); err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		_, err = a.MovePosts([]string{rootId}, fromChannel, toChannel, true, user)
+	}
+
+	if err != nil {
+		// Permanently delete the channel so that its name is free for the split to be retried
+		if deleteErr := a.PermanentDeleteChannel(toChannel); deleteErr != nil {
+			mlog.Error("Failed to delete channel after splitting a thread failed", mlog.String("channel_id", toChannel.Id), mlog.Err(deleteErr))
+		}
+		a.InvalidateCacheForChannel(toChannel)
+		return nil, err
+	}
+
+	return toChannel, nil
+}
+
+// getThreadParticipantsForSplit returns the ids of the users, other than userId, who authored posts in a thread,
+// returning an error unless they're all active members of the team the thread is being split within. Like
+// checkPostAuthorsAreMembers, the authors of system messages are left out.
+func (a *App) getThreadParticipantsForSplit(thread *model.PostList, teamId string, userId string) ([]string, *model.AppError) {
+	participants := make(map[string]bool)
+	participantIds := []string{}
+	for _, threadPost := range thread.Posts {
+		if threadPost.IsSystemMessage() || threadPost.UserId == userId || participants[threadPost.UserId] {
+			continue
+		}
+		participants[threadPost.UserId] = true
+		participantIds = append(participantIds, threadPost.UserId)
+	}
+
+	if len(participantIds) == 0 {
+		return participantIds, nil
+	}
+
+	members, err := a.GetTeamMembersByIds(teamId, participantIds)
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string]bool)
+	for _, member := range members {
+		if member.DeleteAt == 0 {
+			active[member.UserId] = true
+		}
+	}
+
+	for _, participantId := range participantIds {
+		if !active[participantId] {
+			return nil, model.NewAppError("SplitThreadToChannel", "api.post.split_thread.participant_not_member.app_error", nil, "user_id="+participantId+", team_id="+teamId, http.StatusBadRequest)
+		}
+	}
+
+	return participantIds, nil
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// fillInQuoteProp takes a snapshot of the post that a new post quotes, if any, so that the quote still shows what
+// was quoted after the quoted post is edited or deleted. The author of the new post must be able to read the quoted
+// post.
+func (a *App) fillInQuoteProp(post *model.Post) *model.AppError {
+	quotedPostId, _ := post.Props[model.POST_PROPS_QUOTED_POST_ID].(string)
+	if quotedPostId == "" {
+		if post.Props != nil {
+			delete(post.Props, model.POST_PROPS_QUOTED_POST_ID)
+		}
+		return nil
+	}
+
+	if !model.IsValidId(quotedPostId) {
+		return model.NewAppError("fillInQuoteProp", "api.post.fill_in_quote_prop.invalid.app_error", nil, "quoted_post_id="+quotedPostId, http.StatusBadRequest)
+	}
+
+	quote, err := a.generatePermalinkPreview(quotedPostId)
+	if err != nil || !a.userCanReadPermalinkPreview(post.UserId, quote) {
+		return model.NewAppError("fillInQuoteProp", "api.post.fill_in_quote_prop.invalid.app_error", nil, "quoted_post_id="+quotedPostId, http.StatusBadRequest)
+	}
+
+	post.AddProp(model.POST_PROPS_QUOTE, quote)
+
+	return nil
+}
+
+// keepQuoteProps carries the quote of a post over to an edited version of it, since a quote can't be changed once the
+// post has been created.
+func keepQuoteProps(newPost *model.Post, oldPost *model.Post) {
+	for _, prop := range []string{model.POST_PROPS_QUOTED_POST_ID, model.POST_PROPS_QUOTE} {
+		if value, ok := oldPost.Props[prop]; ok {
+			newPost.AddProp(prop, value)
+		} else if newPost.Props != nil {
+			delete(newPost.Props, prop)
+		}
+	}
+}
+
+// getQuoteFromProps returns the snapshot of the post that a post quotes, if any.
+func getQuoteFromProps(post *model.Post) *model.PermalinkPreview {
+	switch quote := post.Props[model.POST_PROPS_QUOTE].(type) {
+	case *model.PermalinkPreview:
+		return quote
+	case map[string]interface{}:
+		// Props that have been read back from the database are no longer typed
+		b, _ := json.Marshal(quote)
+
+		var preview *model.PermalinkPreview
+		if err := json.Unmarshal(b, &preview); err != nil {
+			return nil
+		}
+		return preview
+	}
+
+	return nil
+}
+
+// removeQuoteFromProps removes the snapshot of the quoted post from a post that's being prepared for a client, since
+// not everyone who can see the post can necessarily read the quoted post. It returns a placeholder that only
+// identifies the quoted post until resolveQuoteForSession is called.
+func removeQuoteFromProps(post *model.Post) *model.PermalinkPreview {
+	if _, ok := post.Props[model.POST_PROPS_QUOTE]; !ok {
+		return nil
+	}
+	quote := getQuoteFromProps(post)
+
+	// The props are shared with the original post, so they need to be copied before being changed
+	props := make(model.StringInterface, len(post.Props))
+	for key, value := range post.Props {
+		if key != model.POST_PROPS_QUOTE {
+			props[key] = value
+		}
+	}
+	post.Props = props
+
+	if quote == nil {
+		return nil
+	}
+
+	return &model.PermalinkPreview{PostId: quote.PostId}
+}
+
+// getSavedQuotes returns the snapshots of the posts quoted by the given posts that have been prepared for a client,
+// keyed by the id of the quoting post. Since the snapshots were removed from the posts that are being sent, they're
+// read from the saved posts instead, all at once rather than a post at a time.
+func (a *App) getSavedQuotes(posts []*model.Post) map[string]*model.PermalinkPreview {
+	postIds := []string{}
+	for _, post := range posts {
+		if post.Metadata != nil && post.Metadata.Quote != nil {
+			postIds = append(postIds, post.Id)
+		}
+	}
+
+	quotes := make(map[string]*model.PermalinkPreview, len(postIds))
+	if len(postIds) == 0 {
+		return quotes
+	}
+
+	result := <-a.Srv.Store.Post().GetPostsByIds(postIds)
+	if result.Err != nil {
+		mlog.Debug("Failed to get the quotes of posts", mlog.Err(result.Err))
+		return quotes
+	}
+
+	for _, savedPost := range result.Data.([]*model.Post) {
+		if quote := getQuoteFromProps(savedPost); quote != nil {
+			quotes[savedPost.Id] = quote
+		}
+	}
+
+	return quotes
+}
+
+// resolveQuoteForSession returns the snapshot of the post that a post quotes, or a placeholder if there's no snapshot
+// or the session can't read the quoted post.
+func (a *App) resolveQuoteForSession(post *model.Post, quote *model.PermalinkPreview, session model.Session) *model.PermalinkPreview {
+	if quote == nil || !a.sessionCanReadPermalinkPreview(session, quote) {
+		return &model.PermalinkPreview{PostId: post.Metadata.Quote.PostId, NoAccess: true}
+	}
+
+	return quote
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestPostQuotes(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	privateChannel := th.CreatePrivateChannel(th.BasicTeam)
+	quotedPost := th.CreatePost(privateChannel)
+	quotedMessage := quotedPost.Message
+
+	post, err := th.App.CreatePost(&model.Post{
+		UserId:    th.BasicUser.Id,
+		ChannelId: th.BasicChannel.Id,
+		Message:   "agreed",
+		Props:     model.StringInterface{model.POST_PROPS_QUOTED_POST_ID: quotedPost.Id},
+	}, th.BasicChannel, false)
+	require.Nil(t, err)
+	assert.Equal(t, quotedPost.Id, post.Props[model.POST_PROPS_QUOTED_POST_ID])
+
+	getQuote := func(t *testing.T, user *model.User) *model.PermalinkPreview {
+		savedPost, err := th.App.GetSinglePost(post.Id)
+		require.Nil(t, err)
+
+		clientPost := th.App.PreparePostForClient(savedPost, false)
+		assert.NotContains(t, clientPost.Props, model.POST_PROPS_QUOTE)
+		assert.Contains(t, savedPost.Props, model.POST_PROPS_QUOTE)
+
+		th.App.FillInPermalinkPreviews([]*model.Post{clientPost}, model.Session{UserId: user.Id, Roles: user.GetRawRoles()})
+
+		require.NotNil(t, clientPost.Metadata.Quote)
+		return clientPost.Metadata.Quote
+	}
+
+	t.Run("users who can read the quoted post see the quote", func(t *testing.T) {
+		quote := getQuote(t, th.BasicUser)
+		assert.False(t, quote.NoAccess)
+		assert.Equal(t, quotedPost.Id, quote.PostId)
+		assert.Equal(t, quotedMessage, quote.Message)
+		assert.Equal(t, th.BasicUser.Username, quote.Username)
+		assert.Equal(t, privateChannel.Id, quote.ChannelId)
+	})
+
+	t.Run("users who can't read the quoted post see a placeholder", func(t *testing.T) {
+		quote := getQuote(t, th.BasicUser2)
+		assert.True(t, quote.NoAccess)
+		assert.Equal(t, quotedPost.Id, quote.PostId)
+		assert.Empty(t, quote.Message)
+	})
+
+	t.Run("the quotes of several posts are filled in together", func(t *testing.T) {
+		otherQuotedPost := th.CreatePost(th.BasicChannel)
+		otherPost, err := th.App.CreatePost(&model.Post{
+			UserId:    th.BasicUser.Id,
+			ChannelId: th.BasicChannel.Id,
+			Message:   "also agreed",
+			Props:     model.StringInterface{model.POST_PROPS_QUOTED_POST_ID: otherQuotedPost.Id},
+		}, th.BasicChannel, false)
+		require.Nil(t, err)
+
+		clientPosts := []*model.Post{}
+		for _, postId := range []string{post.Id, otherPost.Id, th.BasicPost.Id} {
+			savedPost, err := th.App.GetSinglePost(postId)
+			require.Nil(t, err)
+			clientPosts = append(clientPosts, th.App.PreparePostForClient(savedPost, false))
+		}
+
+		th.App.FillInPermalinkPreviews(clientPosts, model.Session{UserId: th.BasicUser2.Id, Roles: th.BasicUser2.GetRawRoles()})
+
+		require.NotNil(t, clientPosts[0].Metadata.Quote)
+		assert.True(t, clientPosts[0].Metadata.Quote.NoAccess)
+		require.NotNil(t, clientPosts[1].Metadata.Quote)
+		assert.False(t, clientPosts[1].Metadata.Quote.NoAccess)
+		assert.Equal(t, otherQuotedPost.Message, clientPosts[1].Metadata.Quote.Message)
+		assert.Nil(t, clientPosts[2].Metadata.Quote)
+	})
+
+	t.Run("the quote doesn't change when the quoted post is edited or deleted", func(t *testing.T) {
+		quotedPost.Message = "edited"
+		_, err := th.App.UpdatePost(quotedPost, false)
+		require.Nil(t, err)
+		assert.Equal(t, quotedMessage, getQuote(t, th.BasicUser).Message)
+
+		_, err = th.App.DeletePost(quotedPost.Id, th.BasicUser.Id)
+		require.Nil(t, err)
+		assert.Equal(t, quotedMessage, getQuote(t, th.BasicUser).Message)
+	})
+
+	t.Run("editing the quoting post doesn't change the quote", func(t *testing.T) {
+		edit := post.Clone()
+		edit.Message = "edited"
+		edit.Props = model.StringInterface{model.POST_PROPS_QUOTE: &model.PermalinkPreview{PostId: quotedPost.Id, Message: "forged"}}
+
+		_, err := th.App.UpdatePost(edit, false)
+		require.Nil(t, err)
+		assert.Equal(t, quotedMessage, getQuote(t, th.BasicUser).Message)
+	})
+
+	t.Run("quotes can't be forged", func(t *testing.T) {
+		forged, err := th.App.CreatePost(&model.Post{
+			UserId:    th.BasicUser.Id,
+			ChannelId: th.BasicChannel.Id,
+			Message:   "forged",
+			Props:     model.StringInterface{model.POST_PROPS_QUOTE: &model.PermalinkPreview{PostId: quotedPost.Id, Message: "forged"}},
+		}, th.BasicChannel, false)
+		require.Nil(t, err)
+		assert.NotContains(t, forged.Props, model.POST_PROPS_QUOTE)
+		assert.Nil(t, forged.Metadata.Quote)
+	})
+
+	t.Run("users can't quote posts that they can't read", func(t *testing.T) {
+		_, err := th.App.CreatePost(&model.Post{
+			UserId:    th.BasicUser2.Id,
+			ChannelId: th.BasicChannel.Id,
+			Message:   "quoting",
+			Props:     model.StringInterface{model.POST_PROPS_QUOTED_POST_ID: th.CreatePost(privateChannel).Id},
+		}, th.BasicChannel, false)
+		require.NotNil(t, err)
+		assert.Equal(t, "api.post.fill_in_quote_prop.invalid.app_error", err.Id)
+	})
+}
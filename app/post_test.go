@@ -6,6 +6,7 @@ package app
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -309,6 +310,73 @@ func TestUpdatePostTimeLimit(t *testing.T) {
 	})
 }
 
+func TestPostTimeLimitsByRole(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	th.App.SetLicense(model.NewTestLicense())
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.PostEditTimeLimit = -1
+		cfg.ServiceSettings.PostEditTimeLimitsByRole = map[string]int{
+			model.CHANNEL_USER_ROLE_ID:  30,
+			model.CHANNEL_ADMIN_ROLE_ID: 0,
+		}
+	})
+	th.App.Session = model.Session{UserId: th.BasicUser.Id}
+
+	oldPost := func() *model.Post {
+		return store.Must(th.App.Srv.Store.Post().Save(&model.Post{
+			UserId:    th.BasicUser.Id,
+			ChannelId: th.BasicChannel.Id,
+			Message:   "message_" + model.NewId(),
+			CreateAt:  model.GetMillis() - 60*1000,
+		})).(*model.Post)
+	}
+
+	t.Run("outside the window of a role", func(t *testing.T) {
+		post := oldPost()
+		post.Message = model.NewId()
+		_, err := th.App.UpdatePost(post, true)
+		require.NotNil(t, err)
+		assert.Equal(t, "api.post.update_post.permissions_time_limit.app_error", err.Id)
+
+		_, err = th.App.DeletePost(post.Id, th.BasicUser.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, "api.post.delete_post.permissions_time_limit.app_error", err.Id)
+	})
+
+	t.Run("within the window of a role", func(t *testing.T) {
+		post := th.CreatePost(th.BasicChannel)
+		post.Message = model.NewId()
+		_, err := th.App.UpdatePost(post, true)
+		require.Nil(t, err)
+	})
+
+	t.Run("a role without a limit takes precedence", func(t *testing.T) {
+		_, err := th.App.UpdateChannelMemberSchemeRoles(th.BasicChannel.Id, th.BasicUser.Id, true, true)
+		require.Nil(t, err)
+		defer th.App.UpdateChannelMemberSchemeRoles(th.BasicChannel.Id, th.BasicUser.Id, true, false)
+
+		post := oldPost()
+		post.Message = model.NewId()
+		_, err = th.App.UpdatePost(post, true)
+		require.Nil(t, err)
+
+		_, err = th.App.DeletePost(post.Id, th.BasicUser.Id)
+		require.Nil(t, err)
+	})
+
+	t.Run("changes that aren't made by a user", func(t *testing.T) {
+		th.App.Session = model.Session{}
+		defer func() { th.App.Session = model.Session{UserId: th.BasicUser.Id} }()
+
+		post := oldPost()
+		post.Message = model.NewId()
+		_, err := th.App.UpdatePost(post, true)
+		require.Nil(t, err)
+	})
+}
+
 func TestUpdatePostInArchivedChannel(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
@@ -556,6 +624,79 @@ func TestMaxPostSize(t *testing.T) {
 	}
 }
 
+func TestMaxPostSizeForChannel(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	channel := th.BasicChannel
+	team := th.BasicTeam
+	maxPostSize := th.App.MaxPostSize()
+
+	setOverrides := func(channelSize, teamSize int) {
+		channel.MaxPostSize = channelSize
+		_, err := th.App.UpdateChannel(channel)
+		require.Nil(t, err)
+
+		team.MaxPostSize = teamSize
+		_, err = th.App.UpdateTeam(team)
+		require.Nil(t, err)
+	}
+
+	for _, testCase := range []struct {
+		Description string
+		ChannelSize int
+		TeamSize    int
+		Expected    int
+	}{
+		{"no overrides", 0, 0, maxPostSize},
+		{"team override", 0, 100, 100},
+		{"channel override takes precedence", 50, 100, 50},
+		{"channel override above the team's", 200, 100, 200},
+	} {
+		t.Run(testCase.Description, func(t *testing.T) {
+			setOverrides(testCase.ChannelSize, testCase.TeamSize)
+
+			size, err := th.App.MaxPostSizeForChannel(channel)
+			require.Nil(t, err)
+			assert.Equal(t, testCase.Expected, size)
+		})
+	}
+
+	t.Run("overrides are enforced when posting and editing", func(t *testing.T) {
+		setOverrides(10, 0)
+
+		_, err := th.App.CreatePost(&model.Post{ChannelId: channel.Id, UserId: th.BasicUser.Id, Message: strings.Repeat("a", 11)}, channel, false)
+		require.NotNil(t, err)
+		assert.Equal(t, "api.post.check_post_size.too_long.app_error", err.Id)
+
+		post, err := th.App.CreatePost(&model.Post{ChannelId: channel.Id, UserId: th.BasicUser.Id, Message: strings.Repeat("a", 10)}, channel, false)
+		require.Nil(t, err)
+
+		post.Message = strings.Repeat("b", 11)
+		_, err = th.App.UpdatePost(post, true)
+		require.NotNil(t, err)
+		assert.Equal(t, "api.post.check_post_size.too_long.app_error", err.Id)
+	})
+
+	t.Run("webhook posts are split to fit the team's override", func(t *testing.T) {
+		setOverrides(0, 10)
+
+		post, err := th.App.CreateWebhookPost(th.BasicUser.Id, channel, strings.Repeat("a", 25), "", "", nil, "", "")
+		require.Nil(t, err)
+		assert.Equal(t, strings.Repeat("a", 10), post.Message)
+	})
+
+	t.Run("overrides can't exceed the global maximum", func(t *testing.T) {
+		_, err := th.App.PatchChannel(channel, &model.ChannelPatch{MaxPostSize: model.NewInt(maxPostSize + 1)}, th.BasicUser.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, "api.post.check_max_post_size_override.app_error", err.Id)
+
+		_, err = th.App.PatchTeam(team.Id, &model.TeamPatch{MaxPostSize: model.NewInt(maxPostSize + 1)})
+		require.NotNil(t, err)
+		assert.Equal(t, "api.post.check_max_post_size_override.app_error", err.Id)
+	})
+}
+
 func TestDeletePostWithFileAttachments(t *testing.T) {
 	th := Setup().InitBasic()
 	defer th.TearDown()
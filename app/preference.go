@@ -42,11 +42,14 @@ func (a *App) GetPreferenceByCategoryAndNameForUser(userId string, category stri
 }
 
 func (a *App) UpdatePreferences(userId string, preferences model.Preferences) *model.AppError {
-	for _, preference := range preferences {
+	now := model.GetMillis()
+	for i, preference := range preferences {
 		if userId != preference.UserId {
 			return model.NewAppError("savePreferences", "api.preference.update_preferences.set.app_error", nil,
 				"userId="+userId+", preference.UserId="+preference.UserId, http.StatusForbidden)
 		}
+
+		preferences[i].UpdateAt = now
 	}
 
 	if result := <-a.Srv.Store.Preference().Save(&preferences); result.Err != nil {
@@ -61,6 +64,55 @@ func (a *App) UpdatePreferences(userId string, preferences model.Preferences) *m
 	return nil
 }
 
+// SyncPreferences saves each of a user's preferences that was changed on their device after the stored one, according
+// to its UpdateAt, and returns the stored preferences with the same categories and names, which the device should use.
+// Changes from the future, such as from a device with a fast clock, are treated as made now so that they can't prevent
+// later changes from being saved. The preferences_changed event only carries the changes that were saved.
+func (a *App) SyncPreferences(userId string, preferences model.Preferences) (model.Preferences, *model.AppError) {
+	now := model.GetMillis()
+	for i, preference := range preferences {
+		if userId != preference.UserId {
+			return nil, model.NewAppError("SyncPreferences", "api.preference.update_preferences.set.app_error", nil,
+				"userId="+userId+", preference.UserId="+preference.UserId, http.StatusForbidden)
+		}
+
+		if preference.UpdateAt <= 0 || preference.UpdateAt > now {
+			preferences[i].UpdateAt = now
+		}
+	}
+
+	result := <-a.Srv.Store.Preference().SaveNewer(&preferences)
+	if result.Err != nil {
+		result.Err.StatusCode = http.StatusBadRequest
+		return nil, result.Err
+	}
+
+	if saved := result.Data.(model.Preferences); len(saved) > 0 {
+		message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_PREFERENCES_CHANGED, "", "", userId, nil)
+		message.Add("preferences", saved.ToJson())
+		a.Publish(message)
+	}
+
+	stored, err := a.GetPreferencesForUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	synced := make(map[string]bool, len(preferences))
+	for _, preference := range preferences {
+		synced[preference.Category+":"+preference.Name] = true
+	}
+
+	authoritative := model.Preferences{}
+	for _, preference := range stored {
+		if synced[preference.Category+":"+preference.Name] {
+			authoritative = append(authoritative, preference)
+		}
+	}
+
+	return authoritative, nil
+}
+
 func (a *App) DeletePreferences(userId string, preferences model.Preferences) *model.AppError {
 	for _, preference := range preferences {
 		if userId != preference.UserId {
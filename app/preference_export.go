@@ -0,0 +1,117 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// ExportUserPreferences returns a user's preferences in a form that can be imported into another account. Preferences
+// that only describe the state of the account, such as the last channel viewed or authorized OAuth apps, aren't
+// exported.
+func (a *App) ExportUserPreferences(userId string) (*model.UserPreferencesExport, *model.AppError) {
+	preferences, err := a.GetPreferencesForUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &model.UserPreferencesExport{
+		Version:     model.USER_PREFERENCES_EXPORT_VERSION,
+		ExportAt:    model.GetMillis(),
+		Preferences: model.Preferences{},
+	}
+
+	for _, preference := range preferences {
+		if !isPortablePreferenceCategory(preference.Category) {
+			continue
+		}
+
+		preference.UserId = ""
+		preference.UpdateAt = 0
+		export.Preferences = append(export.Preferences, preference)
+	}
+
+	return export, nil
+}
+
+// ImportUserPreferences saves the preferences from an export for a user, replacing any that they already have, and
+// returns those that were saved. Preferences that refer to channels, posts, teams or users that the user can't access
+// are skipped, while any other invalid preference fails the import.
+func (a *App) ImportUserPreferences(userId string, export *model.UserPreferencesExport) (model.Preferences, *model.AppError) {
+	if export == nil || export.Version != model.USER_PREFERENCES_EXPORT_VERSION {
+		return nil, model.NewAppError("ImportUserPreferences", "app.preference.import.version.app_error", map[string]interface{}{"Version": model.USER_PREFERENCES_EXPORT_VERSION}, "user_id="+userId, http.StatusBadRequest)
+	}
+
+	preferences := model.Preferences{}
+	for _, preference := range export.Preferences {
+		if !isPortablePreferenceCategory(preference.Category) {
+			continue
+		}
+
+		preference.UserId = userId
+
+		// Tutorial progress is stored under the id of the user that it belongs to
+		if preference.Category == model.PREFERENCE_CATEGORY_TUTORIAL_STEPS {
+			preference.Name = userId
+		}
+
+		if err := preference.IsValid(); err != nil {
+			return nil, err
+		}
+
+		if !a.canUsePreference(userId, &preference) {
+			continue
+		}
+
+		preferences = append(preferences, preference)
+	}
+
+	if len(preferences) == 0 {
+		return preferences, nil
+	}
+
+	if err := a.UpdatePreferences(userId, preferences); err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+// isPortablePreferenceCategory returns whether preferences of a category can be copied between accounts.
+func isPortablePreferenceCategory(category string) bool {
+	switch category {
+	case model.PREFERENCE_CATEGORY_LAST, model.PREFERENCE_CATEGORY_INACTIVITY, model.PREFERENCE_CATEGORY_AUTHORIZED_OAUTH_APP:
+		return false
+	}
+	return true
+}
+
+// canUsePreference returns whether the channel, post, team or user that an imported preference refers to, if any, can
+// be accessed by the user that it's imported for.
+func (a *App) canUsePreference(userId string, preference *model.Preference) bool {
+	switch preference.Category {
+	case model.PREFERENCE_CATEGORY_FAVORITE_CHANNEL, model.PREFERENCE_CATEGORY_GROUP_CHANNEL_SHOW:
+		_, err := a.GetChannelMember(preference.Name, userId)
+		return err == nil
+
+	case model.PREFERENCE_CATEGORY_DIRECT_CHANNEL_SHOW:
+		user, err := a.GetUser(preference.Name)
+		return err == nil && user.Id != userId
+
+	case model.PREFERENCE_CATEGORY_FLAGGED_POST:
+		return a.HasPermissionToChannelByPost(userId, preference.Name, model.PERMISSION_READ_CHANNEL)
+
+	case model.PREFERENCE_CATEGORY_THEME:
+		// Themes without a team apply to every team
+		if preference.Name == "" {
+			return true
+		}
+		member, err := a.GetTeamMember(preference.Name, userId)
+		return err == nil && member.DeleteAt == 0
+	}
+
+	return true
+}
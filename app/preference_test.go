@@ -0,0 +1,103 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestSyncPreferences(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	userId := th.BasicUser.Id
+	category := model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS
+
+	// A change from the future is treated as made now, so it can't prevent later changes
+	synced, err := th.App.SyncPreferences(userId, model.Preferences{
+		{UserId: userId, Category: category, Name: "a", Value: "future", UpdateAt: model.GetMillis() + 60*60*1000},
+	})
+	require.Nil(t, err)
+	require.Len(t, synced, 1)
+	assert.True(t, synced[0].UpdateAt <= model.GetMillis())
+
+	time.Sleep(5 * time.Millisecond)
+
+	require.Nil(t, th.App.UpdatePreferences(userId, model.Preferences{{UserId: userId, Category: category, Name: "a", Value: "later"}}))
+
+	preference, err := th.App.GetPreferenceByCategoryAndNameForUser(userId, category, "a")
+	require.Nil(t, err)
+	assert.Equal(t, "later", preference.Value)
+
+	_, err = th.App.SyncPreferences(userId, model.Preferences{{UserId: th.BasicUser2.Id, Category: category, Name: "a"}})
+	assert.NotNil(t, err)
+}
+
+func TestExportImportUserPreferences(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	privateChannel := th.CreatePrivateChannel(th.BasicTeam)
+	otherTeam := th.CreateTeam()
+	th.AddUserToChannel(th.BasicUser2, th.BasicChannel)
+
+	template := th.BasicUser.Id
+	preferences := model.Preferences{
+		{UserId: template, Category: model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS, Name: model.PREFERENCE_NAME_USE_MILITARY_TIME, Value: "true"},
+		{UserId: template, Category: model.PREFERENCE_CATEGORY_TUTORIAL_STEPS, Name: template, Value: "999"},
+		{UserId: template, Category: model.PREFERENCE_CATEGORY_FAVORITE_CHANNEL, Name: th.BasicChannel.Id, Value: "true"},
+		{UserId: template, Category: model.PREFERENCE_CATEGORY_FAVORITE_CHANNEL, Name: privateChannel.Id, Value: "true"},
+		{UserId: template, Category: model.PREFERENCE_CATEGORY_THEME, Name: otherTeam.Id, Value: "{}"},
+		{UserId: template, Category: model.PREFERENCE_CATEGORY_LAST, Name: model.PREFERENCE_NAME_LAST_CHANNEL, Value: privateChannel.Id},
+	}
+	require.Nil(t, th.App.UpdatePreferences(template, preferences))
+
+	export, err := th.App.ExportUserPreferences(template)
+	require.Nil(t, err)
+	assert.Equal(t, model.USER_PREFERENCES_EXPORT_VERSION, export.Version)
+	require.Len(t, export.Preferences, 5, "the last channel shouldn't be exported")
+	for _, preference := range export.Preferences {
+		assert.Empty(t, preference.UserId)
+	}
+
+	target := th.BasicUser2.Id
+	imported, err := th.App.ImportUserPreferences(target, export)
+	require.Nil(t, err)
+
+	saved, err := th.App.GetPreferencesForUser(target)
+	require.Nil(t, err)
+
+	for _, preferences := range []model.Preferences{imported, saved} {
+		values := map[string]string{}
+		for _, preference := range preferences {
+			assert.Equal(t, target, preference.UserId)
+			values[preference.Category+":"+preference.Name] = preference.Value
+		}
+
+		assert.Equal(t, map[string]string{
+			model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS + ":" + model.PREFERENCE_NAME_USE_MILITARY_TIME: "true",
+			model.PREFERENCE_CATEGORY_TUTORIAL_STEPS + ":" + target:                                    "999",
+			model.PREFERENCE_CATEGORY_FAVORITE_CHANNEL + ":" + th.BasicChannel.Id:                      "true",
+		}, values, "preferences for channels and teams that the user isn't in should be dropped")
+	}
+
+	t.Run("invalid exports", func(t *testing.T) {
+		_, err := th.App.ImportUserPreferences(target, &model.UserPreferencesExport{Version: 2})
+		require.NotNil(t, err)
+		assert.Equal(t, "app.preference.import.version.app_error", err.Id)
+
+		_, err = th.App.ImportUserPreferences(target, &model.UserPreferencesExport{
+			Version:     model.USER_PREFERENCES_EXPORT_VERSION,
+			Preferences: model.Preferences{{Category: model.PREFERENCE_CATEGORY_THEME, Value: "junk"}},
+		})
+		require.NotNil(t, err)
+		assert.Equal(t, "model.preference.is_valid.theme.app_error", err.Id)
+	})
+}
@@ -23,6 +23,23 @@ type RateLimiter struct {
 	useAuth              bool
 	useIP                bool
 	header               string
+
+	// clientIpAddress returns the IP address that requests are limited by. The server resolves it through its trusted
+	// proxies, while it's otherwise the address that a request came from.
+	clientIpAddress func(r *http.Request) string
+
+	// userRateLimiter limits requests by the user of their session when PerUser is enabled, so that users sharing an
+	// IP address don't share a quota.
+	userRateLimiter *throttled.GCRARateLimiter
+
+	// sessionUserId returns the user of the valid session that a token belongs to, or nothing if there's no such
+	// session. The server looks sessions up like it does when authenticating requests, while otherwise no session is
+	// taken to be valid.
+	sessionUserId func(token string) string
+
+	// config returns the config that rejected requests are written with. The server uses its own, while it's otherwise
+	// the default config.
+	config func() *model.Config
 }
 
 func NewRateLimiter(settings *model.RateLimitSettings) (*RateLimiter, error) {
@@ -41,12 +58,38 @@ func NewRateLimiter(settings *model.RateLimitSettings) (*RateLimiter, error) {
 		return nil, errors.Wrap(err, utils.T("api.server.start_server.rate_limiting_rate_limiter"))
 	}
 
-	return &RateLimiter{
+	rateLimiter := &RateLimiter{
 		throttledRateLimiter: throttledRateLimiter,
 		useAuth:              *settings.VaryByUser,
 		useIP:                *settings.VaryByRemoteAddr,
 		header:               settings.VaryByHeader,
-	}, nil
+		clientIpAddress: func(r *http.Request) string {
+			return utils.GetRealClientIpAddress(r, "", nil)
+		},
+		sessionUserId: func(token string) string {
+			return ""
+		},
+	}
+
+	defaultConfig := &model.Config{}
+	defaultConfig.SetDefaults()
+	rateLimiter.config = func() *model.Config {
+		return defaultConfig
+	}
+
+	if settings.PerUser != nil && *settings.PerUser {
+		userStore, err := memstore.New(*settings.MemoryStoreSize)
+		if err != nil {
+			return nil, errors.Wrap(err, utils.T("api.server.start_server.rate_limiting_memory_store"))
+		}
+
+		rateLimiter.userRateLimiter, err = throttled.NewGCRARateLimiter(userStore, quota)
+		if err != nil {
+			return nil, errors.Wrap(err, utils.T("api.server.start_server.rate_limiting_rate_limiter"))
+		}
+	}
+
+	return rateLimiter, nil
 }
 
 func (rl *RateLimiter) GenerateKey(r *http.Request) string {
@@ -57,14 +100,12 @@ func (rl *RateLimiter) GenerateKey(r *http.Request) string {
 		if tokenLocation != TokenLocationNotFound {
 			key += token
 		} else if rl.useIP { // If we don't find an authentication token and IP based is enabled, fall back to IP
-			key += utils.GetIpAddress(r)
+			key += rl.clientIpAddress(r)
 		}
 	} else if rl.useIP { // Only if Auth based is not enabed do we use a plain IP based
-		key += utils.GetIpAddress(r)
+		key += rl.clientIpAddress(r)
 	}
 
-	// Note that most of the time the user won't have to set this because the utils.GetIpAddress above tries the
-	// most common headers anyway.
 	if rl.header != "" {
 		key += strings.ToLower(r.Header.Get(rl.header))
 	}
@@ -72,8 +113,12 @@ func (rl *RateLimiter) GenerateKey(r *http.Request) string {
 	return key
 }
 
-func (rl *RateLimiter) RateLimitWriter(key string, w http.ResponseWriter) bool {
-	limited, context, err := rl.throttledRateLimiter.RateLimit(key, 1)
+func (rl *RateLimiter) RateLimitWriter(key string, w http.ResponseWriter, r *http.Request) bool {
+	return rl.rateLimitWriter(rl.throttledRateLimiter, key, w, r)
+}
+
+func (rl *RateLimiter) rateLimitWriter(rateLimiter *throttled.GCRARateLimiter, key string, w http.ResponseWriter, r *http.Request) bool {
+	limited, context, err := rateLimiter.RateLimit(key, 1)
 	if err != nil {
 		mlog.Critical("Internal server error when rate limiting. Rate Limiting broken. Error:" + err.Error())
 		return false
@@ -83,25 +128,41 @@ func (rl *RateLimiter) RateLimitWriter(key string, w http.ResponseWriter) bool {
 
 	if limited {
 		mlog.Error(fmt.Sprintf("Denied due to throttling settings code=429 key=%v", key))
-		http.Error(w, "limit exceeded", 429)
+
+		appErr := model.NewAppError("RateLimitWriter", "api.context.rate_limited.app_error", nil, "", http.StatusTooManyRequests)
+		appErr.Translate(utils.T)
+		utils.RenderApiError(rl.config(), w, r, appErr)
 	}
 
 	return limited
 }
 
-func (rl *RateLimiter) UserIdRateLimit(userId string, w http.ResponseWriter) bool {
-	if rl.useAuth {
-		if rl.RateLimitWriter(userId, w) {
+// UserIdRateLimit limits a request by the user of its session when VaryByUser is enabled, once a handler has looked the
+// session up. Requests are already limited by their user when PerUser is enabled, so they aren't counted again.
+func (rl *RateLimiter) UserIdRateLimit(r *http.Request, userId string, w http.ResponseWriter) bool {
+	if rl.useAuth && rl.userRateLimiter == nil {
+		if rl.RateLimitWriter(userId, w, r) {
 			return true
 		}
 	}
 	return false
 }
 
+// RateLimitHandler limits requests by the key from GenerateKey. When PerUser is enabled, requests with the token of a
+// valid session are limited by its user instead, so that they aren't limited by their IP address, and requests with any
+// other token are limited by their IP address, so that making up tokens doesn't get around the limit.
 func (rl *RateLimiter) RateLimitHandler(wrappedHandler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		key := rl.GenerateKey(r)
-		limited := rl.RateLimitWriter(key, w)
+		var limited bool
+		if token, tokenLocation := ParseAuthTokenFromRequest(r); rl.userRateLimiter != nil && tokenLocation != TokenLocationNotFound {
+			if userId := rl.sessionUserId(token); userId != "" {
+				limited = rl.rateLimitWriter(rl.userRateLimiter, userId, w, r)
+			} else {
+				limited = rl.RateLimitWriter(rl.clientIpAddress(r), w, r)
+			}
+		} else {
+			limited = rl.RateLimitWriter(rl.GenerateKey(r), w, r)
+		}
 
 		if !limited {
 			wrappedHandler.ServeHTTP(w, r)
@@ -80,3 +80,85 @@ func TestGenerateKey(t *testing.T) {
 		require.Equal(t, tc.expectedKey, key, "Wrong key on test "+strconv.Itoa(testnum))
 	}
 }
+
+func TestRateLimitHandlerPerUser(t *testing.T) {
+	settings := genRateLimitSettings(false, true, "")
+	settings.MaxBurst = model.NewInt(0)
+	settings.PerSec = model.NewInt(1)
+	settings.PerUser = model.NewBool(true)
+
+	newRateLimiter := func(t *testing.T) (*RateLimiter, http.Handler, *int) {
+		rateLimiter, err := NewRateLimiter(settings)
+		require.NoError(t, err)
+
+		sessions := map[string]string{"token1": "user1", "token2": "user2"}
+		rateLimiter.sessionUserId = func(token string) string {
+			return sessions[token]
+		}
+
+		served := 0
+		handler := rateLimiter.RateLimitHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served++
+		}))
+
+		return rateLimiter, handler, &served
+	}
+
+	newRequest := func(token string) *http.Request {
+		req := httptest.NewRequest("GET", "/plugins/com.example.plugin/hello", nil)
+		req.RemoteAddr = "10.0.0.1:80"
+		if token != "" {
+			req.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+token)
+		}
+		return req
+	}
+
+	t.Run("limited by user", func(t *testing.T) {
+		_, handler, served := newRateLimiter(t)
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest("token1"))
+		require.Equal(t, 1, *served)
+		require.NotEmpty(t, w.Header().Get("X-RateLimit-Limit"))
+		require.NotEmpty(t, w.Header().Get("X-RateLimit-Remaining"))
+
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest("token1"))
+		require.Equal(t, 1, *served)
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+		require.NotEmpty(t, w.Header().Get("Retry-After"))
+
+		// Another user behind the same IP address has their own quota, as does the IP address itself
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("token2"))
+		require.Equal(t, 2, *served)
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest(""))
+		require.Equal(t, 3, *served)
+	})
+
+	t.Run("limited by IP address without a valid session", func(t *testing.T) {
+		_, handler, served := newRateLimiter(t)
+
+		// Making up a new token for each request doesn't get around the limit
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest(model.NewId()))
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest(model.NewId()))
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest(""))
+		require.Equal(t, 1, *served)
+
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest("token1"))
+		require.Equal(t, 2, *served)
+	})
+
+	t.Run("not limited by user again once the session is looked up", func(t *testing.T) {
+		settings.VaryByUser = model.NewBool(true)
+		defer func() {
+			settings.VaryByUser = model.NewBool(false)
+		}()
+
+		rateLimiter, handler, served := newRateLimiter(t)
+
+		req := newRequest("token1")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		require.Equal(t, 1, *served)
+		require.False(t, rateLimiter.UserIdRateLimit(req, "user1", httptest.NewRecorder()))
+	})
+}
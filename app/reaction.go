@@ -6,38 +6,108 @@ package app
 import (
 	"net/http"
 
+	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/model"
 )
 
 func (a *App) SaveReactionForPost(reaction *model.Reaction) (*model.Reaction, *model.AppError) {
-	post, err := a.GetSinglePost(reaction.PostId)
+	reaction, post, err := a.saveReaction(reaction)
 	if err != nil {
 		return nil, err
 	}
 
+	a.Srv.Go(func() {
+		a.sendReactionEvent(model.WEBSOCKET_EVENT_REACTION_ADDED, reaction, post, true)
+	})
+
+	a.Srv.Go(func() {
+		if err := a.RecordEmojiUsage(reaction.UserId, []string{reaction.EmojiName}); err != nil {
+			mlog.Warn("Failed to record emoji usage", mlog.String("user_id", reaction.UserId), mlog.Err(err))
+		}
+	})
+
+	return reaction, nil
+}
+
+// SaveReactionForPosts adds the same reaction to each of the given posts and reports the outcome for each post in the
+// same order. Clients are told about the reactions with a single event per channel rather than one per post, while
+// each post that was reacted to is still sent to them as edited, like it is by SaveReactionForPost.
+func (a *App) SaveReactionForPosts(userId string, emojiName string, postIds []string) ([]*model.BulkReactionResult, *model.AppError) {
+	if _, ok := model.SystemEmojis[emojiName]; !ok {
+		if _, err := a.GetEmojiByName(emojiName); err != nil {
+			return nil, model.NewAppError("SaveReactionForPosts", "api.reaction.save_reaction_for_posts.emoji.app_error", nil, "emoji_name="+emojiName+", "+err.Error(), http.StatusBadRequest)
+		}
+	}
+
+	results := make([]*model.BulkReactionResult, len(postIds))
+	reactionsByChannel := map[string][]*model.Reaction{}
+	var posts []*model.Post
+	for i, postId := range postIds {
+		results[i] = &model.BulkReactionResult{PostId: postId}
+
+		reaction, post, err := a.saveReaction(&model.Reaction{UserId: userId, PostId: postId, EmojiName: emojiName})
+		if err != nil {
+			results[i].Error = err
+			continue
+		}
+
+		results[i].Reaction = reaction
+		reactionsByChannel[post.ChannelId] = append(reactionsByChannel[post.ChannelId], reaction)
+		posts = append(posts, post)
+	}
+
+	if len(reactionsByChannel) > 0 {
+		a.Srv.Go(func() {
+			for channelId, reactions := range reactionsByChannel {
+				message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_REACTIONS_ADDED, "", channelId, "", nil)
+				message.Add("reactions", model.ReactionsToJson(reactions))
+				a.Publish(message)
+			}
+
+			for _, post := range posts {
+				a.sendPostReactionsUpdated(post, true)
+			}
+		})
+
+		a.Srv.Go(func() {
+			if err := a.RecordEmojiUsage(userId, []string{emojiName}); err != nil {
+				mlog.Warn("Failed to record emoji usage", mlog.String("user_id", userId), mlog.Err(err))
+			}
+		})
+	}
+
+	return results, nil
+}
+
+func (a *App) saveReaction(reaction *model.Reaction) (*model.Reaction, *model.Post, *model.AppError) {
+	post, err := a.GetSinglePost(reaction.PostId)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	channel, err := a.GetChannel(post.ChannelId)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if channel.DeleteAt > 0 {
-		return nil, model.NewAppError("deleteReactionForPost", "api.reaction.save.archived_channel.app_error", nil, "", http.StatusForbidden)
+		return nil, nil, model.NewAppError("deleteReactionForPost", "api.reaction.save.archived_channel.app_error", nil, "", http.StatusForbidden)
 	}
 
 	if a.License() != nil && *a.Config().TeamSettings.ExperimentalTownSquareIsReadOnly && channel.Name == model.DEFAULT_CHANNEL {
 		user, err := a.GetUser(reaction.UserId)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		if !a.RolesGrantPermission(user.GetRoles(), model.PERMISSION_MANAGE_SYSTEM.Id) {
-			return nil, model.NewAppError("saveReactionForPost", "api.reaction.town_square_read_only", nil, "", http.StatusForbidden)
+			return nil, nil, model.NewAppError("saveReactionForPost", "api.reaction.town_square_read_only", nil, "", http.StatusForbidden)
 		}
 	}
 
 	result := <-a.Srv.Store.Reaction().Save(reaction)
 	if result.Err != nil {
-		return nil, result.Err
+		return nil, nil, result.Err
 	}
 
 	reaction = result.Data.(*model.Reaction)
@@ -45,11 +115,7 @@ func (a *App) SaveReactionForPost(reaction *model.Reaction) (*model.Reaction, *m
 	// The post is always modified since the UpdateAt always changes
 	a.InvalidateCacheForChannelPosts(post.ChannelId)
 
-	a.Srv.Go(func() {
-		a.sendReactionEvent(model.WEBSOCKET_EVENT_REACTION_ADDED, reaction, post, true)
-	})
-
-	return reaction, nil
+	return reaction, post, nil
 }
 
 func (a *App) GetReactionsForPost(postId string) ([]*model.Reaction, *model.AppError) {
@@ -60,6 +126,38 @@ func (a *App) GetReactionsForPost(postId string) ([]*model.Reaction, *model.AppE
 	return result.Data.([]*model.Reaction), nil
 }
 
+// GetUsersWhoReactedInChannel returns a page of the users who reacted with the given emoji to posts in a channel
+// since the given time, ordered by when they first did so.
+func (a *App) GetUsersWhoReactedInChannel(channelId string, emojiName string, since int64, page int, perPage int, asAdmin bool) ([]*model.User, *model.AppError) {
+	result := <-a.Srv.Store.Reaction().GetUserIdsForEmojiInChannel(channelId, emojiName, since, page*perPage, perPage)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	userIds := result.Data.([]string)
+	if len(userIds) == 0 {
+		return []*model.User{}, nil
+	}
+
+	users, err := a.GetUsersByIds(userIds, asAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	usersById := make(map[string]*model.User, len(users))
+	for _, user := range users {
+		usersById[user.Id] = user
+	}
+
+	ordered := make([]*model.User, 0, len(users))
+	for _, userId := range userIds {
+		if user, ok := usersById[userId]; ok {
+			ordered = append(ordered, user)
+		}
+	}
+
+	return ordered, nil
+}
+
 func (a *App) GetBulkReactionsForPosts(postIds []string) (map[string][]*model.Reaction, *model.AppError) {
 	reactions := make(map[string][]*model.Reaction)
 
@@ -140,6 +238,12 @@ func (a *App) sendReactionEvent(event string, reaction *model.Reaction, post *mo
 	message.Add("reaction", reaction.ToJson())
 	a.Publish(message)
 
+	a.sendPostReactionsUpdated(post, hasReactions)
+}
+
+// sendPostReactionsUpdated tells clients that a post has been updated by its reactions changing, so that they see
+// whether it has any.
+func (a *App) sendPostReactionsUpdated(post *model.Post, hasReactions bool) {
 	post.HasReactions = hasReactions
 	post.UpdateAt = model.GetMillis()
 
@@ -0,0 +1,119 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// CheckReadiness returns nil once the server has finished starting up and the dependencies that CheckDependencies
+// checks respond, so that it can serve traffic, or an error explaining why it can't yet. Starting up includes migrating
+// the database, loading plugins, joining the cluster and, if enabled, preloading caches. When dependencies don't
+// respond, the errors of each of them are returned too, keyed by their name. Unlike the ping endpoint, which only
+// reports whether the server is alive, this is meant for orchestrators deciding whether to route requests to the
+// server.
+func (a *App) CheckReadiness() (map[string]*model.AppError, *model.AppError) {
+	if atomic.LoadInt32(&a.Srv.startupComplete) == 0 {
+		return nil, model.NewAppError("CheckReadiness", "app.system.ready.starting.app_error", nil, "", http.StatusServiceUnavailable)
+	}
+
+	if atomic.LoadInt32(&a.Srv.preloadingCaches) == 1 {
+		return nil, model.NewAppError("CheckReadiness", "app.system.ready.preloading_caches.app_error", nil, "", http.StatusServiceUnavailable)
+	}
+
+	if failed := a.CheckDependencies(); len(failed) > 0 {
+		names := make([]string, 0, len(failed))
+		for name := range failed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return failed, model.NewAppError("CheckReadiness", "app.system.ready.dependencies.app_error", map[string]interface{}{"Dependencies": strings.Join(names, ", ")}, "", http.StatusServiceUnavailable)
+	}
+
+	result := <-a.Srv.Store.System().GetByName("Version")
+	if result.Err != nil {
+		return nil, model.NewAppError("CheckReadiness", "app.system.ready.database.app_error", nil, result.Err.Error(), http.StatusServiceUnavailable)
+	}
+
+	if version := result.Data.(*model.System).Value; version != model.CurrentVersion {
+		return nil, model.NewAppError("CheckReadiness", "app.system.ready.migrations.app_error", nil, "version="+version, http.StatusServiceUnavailable)
+	}
+
+	if *a.Config().PluginSettings.Enable && a.GetPluginsEnvironment() == nil {
+		return nil, model.NewAppError("CheckReadiness", "app.system.ready.plugins.app_error", nil, "", http.StatusServiceUnavailable)
+	}
+
+	return nil, nil
+}
+
+// CheckDependencies checks that each of the dependencies listed in ServiceSettings.ReadinessChecks responds within
+// ServiceSettings.ReadinessCheckTimeoutMilliseconds, returning the errors of those that didn't keyed by their name. The
+// dependencies are checked concurrently, so this takes no longer than the timeout.
+func (a *App) CheckDependencies() map[string]*model.AppError {
+	cfg := a.Config()
+
+	checks := map[string]func() *model.AppError{}
+	for _, name := range cfg.ServiceSettings.ReadinessChecks {
+		switch name {
+		case model.READINESS_CHECK_DATABASE:
+			checks[name] = a.checkDatabaseDependency
+		case model.READINESS_CHECK_SEARCH:
+			if a.Elasticsearch != nil && *cfg.ElasticsearchSettings.EnableIndexing {
+				checks[name] = func() *model.AppError {
+					if err := a.Elasticsearch.TestConfig(cfg); err != nil {
+						return model.NewAppError("CheckDependencies", "app.system.ready.search.app_error", nil, err.Error(), http.StatusServiceUnavailable)
+					}
+					return nil
+				}
+			}
+		}
+	}
+
+	type checkResult struct {
+		name string
+		err  *model.AppError
+	}
+
+	// Checks that time out are left running, so the channel is buffered for them to finish without blocking
+	results := make(chan checkResult, len(checks))
+	for name, check := range checks {
+		go func(name string, check func() *model.AppError) {
+			results <- checkResult{name, check()}
+		}(name, check)
+	}
+
+	failed := map[string]*model.AppError{}
+	timeout := time.After(time.Duration(*cfg.ServiceSettings.ReadinessCheckTimeoutMilliseconds) * time.Millisecond)
+	for pending := len(checks); pending > 0; pending-- {
+		select {
+		case result := <-results:
+			if result.err != nil {
+				failed[result.name] = result.err
+			}
+			delete(checks, result.name)
+		case <-timeout:
+			for name := range checks {
+				failed[name] = model.NewAppError("CheckDependencies", "app.system.ready.timeout.app_error", nil, "dependency="+name, http.StatusServiceUnavailable)
+			}
+			return failed
+		}
+	}
+
+	return failed
+}
+
+// checkDatabaseDependency makes a cheap round trip to the database.
+func (a *App) checkDatabaseDependency() *model.AppError {
+	if result := <-a.Srv.Store.System().GetByName("Version"); result.Err != nil {
+		return model.NewAppError("CheckDependencies", "app.system.ready.database.app_error", nil, result.Err.Error(), http.StatusServiceUnavailable)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+type unreachableElasticsearch struct {
+	*fakeElasticsearch
+	delay time.Duration
+}
+
+func (es *unreachableElasticsearch) TestConfig(cfg *model.Config) *model.AppError {
+	time.Sleep(es.delay)
+	return model.NewAppError("TestConfig", "ent.elasticsearch.test_config.connect_failed", nil, "", http.StatusInternalServerError)
+}
+
+func TestCheckDependencies(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		cfg.ServiceSettings.ReadinessChecks = []string{model.READINESS_CHECK_DATABASE, model.READINESS_CHECK_SEARCH}
+		*cfg.ServiceSettings.ReadinessCheckTimeoutMilliseconds = 200
+	})
+
+	t.Run("search isn't checked unless it's enabled", func(t *testing.T) {
+		th.App.Elasticsearch = &unreachableElasticsearch{fakeElasticsearch: newFakeElasticsearch()}
+		defer func() { th.App.Elasticsearch = nil }()
+
+		assert.Empty(t, th.App.CheckDependencies())
+	})
+
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ElasticsearchSettings.EnableIndexing = true })
+	defer th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ElasticsearchSettings.EnableIndexing = false })
+
+	t.Run("unreachable search fails", func(t *testing.T) {
+		th.App.Elasticsearch = &unreachableElasticsearch{fakeElasticsearch: newFakeElasticsearch()}
+		defer func() { th.App.Elasticsearch = nil }()
+
+		failed := th.App.CheckDependencies()
+		require.Len(t, failed, 1)
+		require.NotNil(t, failed[model.READINESS_CHECK_SEARCH])
+		assert.Equal(t, "app.system.ready.search.app_error", failed[model.READINESS_CHECK_SEARCH].Id)
+	})
+
+	t.Run("unreachable search makes the server unready", func(t *testing.T) {
+		th.App.Elasticsearch = &unreachableElasticsearch{fakeElasticsearch: newFakeElasticsearch()}
+		defer func() { th.App.Elasticsearch = nil }()
+
+		failed, err := th.App.CheckReadiness()
+		require.NotNil(t, err)
+		assert.Equal(t, "app.system.ready.dependencies.app_error", err.Id)
+		require.Len(t, failed, 1)
+		assert.NotNil(t, failed[model.READINESS_CHECK_SEARCH])
+	})
+
+	t.Run("slow search times out", func(t *testing.T) {
+		th.App.Elasticsearch = &unreachableElasticsearch{fakeElasticsearch: newFakeElasticsearch(), delay: time.Second}
+		defer func() { th.App.Elasticsearch = nil }()
+
+		start := time.Now()
+		failed := th.App.CheckDependencies()
+		assert.True(t, time.Since(start) < time.Second)
+		require.Len(t, failed, 1)
+		require.NotNil(t, failed[model.READINESS_CHECK_SEARCH])
+		assert.Equal(t, "app.system.ready.timeout.app_error", failed[model.READINESS_CHECK_SEARCH].Id)
+	})
+
+	t.Run("only the configured checks run", func(t *testing.T) {
+		th.App.Elasticsearch = &unreachableElasticsearch{fakeElasticsearch: newFakeElasticsearch()}
+		defer func() { th.App.Elasticsearch = nil }()
+
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			cfg.ServiceSettings.ReadinessChecks = []string{model.READINESS_CHECK_DATABASE}
+		})
+
+		assert.Empty(t, th.App.CheckDependencies())
+
+		failed, err := th.App.CheckReadiness()
+		assert.Nil(t, err)
+		assert.Empty(t, failed)
+	})
+}
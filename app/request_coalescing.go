@@ -0,0 +1,83 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"sync"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/store"
+)
+
+// coalescedCall is a call in progress, or just completed, whose result is shared by every caller with the same key.
+type coalescedCall struct {
+	wg     sync.WaitGroup
+	result interface{}
+	err    *model.AppError
+}
+
+// requestCoalescer makes concurrent calls with the same key share a single computation instead of repeating it, so
+// that a burst of identical requests, such as every client reloading a busy channel after a broadcast, only hits the
+// database once. Only fetches whose results are the same for every user should be coalesced, with any permission
+// checks done separately by each caller.
+type requestCoalescer struct {
+	mutex sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+func newRequestCoalescer() *requestCoalescer {
+	return &requestCoalescer{
+		calls: make(map[string]*coalescedCall),
+	}
+}
+
+// do calls fn and returns its result, unless a call with the same key is already in progress, in which case it waits
+// for that call to finish and returns its result instead. The result is shared, so it must not be modified, but each
+// caller gets its own copy of the error since the handlers fill in its details for the request they're serving.
+func (c *requestCoalescer) do(key string, fn func() (interface{}, *model.AppError)) (interface{}, *model.AppError) {
+	c.mutex.Lock()
+	if call, ok := c.calls[key]; ok {
+		c.mutex.Unlock()
+		call.wg.Wait()
+		return call.result, copyCoalescedError(call.err)
+	}
+
+	call := &coalescedCall{}
+	call.wg.Add(1)
+	c.calls[key] = call
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.calls, key)
+		c.mutex.Unlock()
+
+		call.wg.Done()
+	}()
+
+	call.result, call.err = fn()
+	return call.result, copyCoalescedError(call.err)
+}
+
+func copyCoalescedError(err *model.AppError) *model.AppError {
+	if err == nil {
+		return nil
+	}
+
+	copy := *err
+	return &copy
+}
+
+// coalescePostList fetches a list of posts from the store, sharing the fetch with any identical one that's in progress.
+// Every caller gets its own copy of the list, since callers sort the lists and change the posts in them.
+func (a *App) coalescePostList(key string, fetch func() store.StoreChannel) (*model.PostList, *model.AppError) {
+	result, err := a.Srv.requestCoalescer.do(key, func() (interface{}, *model.AppError) {
+		result := <-fetch()
+		return result.Data, result.Err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*model.PostList).Clone(), nil
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/store"
+)
+
+func TestRequestCoalescer(t *testing.T) {
+	t.Run("concurrent calls with the same key share a result", func(t *testing.T) {
+		coalescer := newRequestCoalescer()
+
+		var calls int32
+		release := make(chan struct{})
+		fn := func() (interface{}, *model.AppError) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return "result", nil
+		}
+
+		var wg sync.WaitGroup
+		results := make([]interface{}, 10)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], _ = coalescer.do("key", fn)
+			}(i)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		for _, result := range results {
+			assert.Equal(t, "result", result)
+		}
+		assert.Empty(t, coalescer.calls)
+	})
+
+	t.Run("calls with different keys aren't shared", func(t *testing.T) {
+		coalescer := newRequestCoalescer()
+
+		result, err := coalescer.do("a", func() (interface{}, *model.AppError) { return "a", nil })
+		require.Nil(t, err)
+		assert.Equal(t, "a", result)
+
+		result, err = coalescer.do("b", func() (interface{}, *model.AppError) { return "b", nil })
+		require.Nil(t, err)
+		assert.Equal(t, "b", result)
+	})
+
+	t.Run("errors are shared too", func(t *testing.T) {
+		coalescer := newRequestCoalescer()
+
+		_, err := coalescer.do("key", func() (interface{}, *model.AppError) {
+			return nil, model.NewAppError("test", "test.app_error", nil, "", http.StatusInternalServerError)
+		})
+		require.NotNil(t, err)
+		assert.Equal(t, "test.app_error", err.Id)
+
+		// Completed calls aren't remembered, so the next call does the work again
+		result, err := coalescer.do("key", func() (interface{}, *model.AppError) { return "result", nil })
+		require.Nil(t, err)
+		assert.Equal(t, "result", result)
+	})
+	t.Run("concurrent callers get their own copy of an error", func(t *testing.T) {
+		coalescer := newRequestCoalescer()
+
+		release := make(chan struct{})
+		fn := func() (interface{}, *model.AppError) {
+			<-release
+			return nil, model.NewAppError("test", "test.app_error", nil, "", 0)
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]*model.AppError, 10)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := coalescer.do("key", fn)
+
+				// The handlers fill in the error for the request that they're serving
+				err.Where = "caller"
+				err.RequestId = model.NewId()
+				err.StatusCode = http.StatusInternalServerError
+				errs[i] = err
+			}(i)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		requestIds := map[string]bool{}
+		for _, err := range errs {
+			require.NotNil(t, err)
+			assert.Equal(t, "test.app_error", err.Id)
+			requestIds[err.RequestId] = true
+		}
+		assert.Len(t, requestIds, len(errs))
+	})
+
+	t.Run("concurrent callers get their own copy of a post list", func(t *testing.T) {
+		a := &App{Srv: &Server{requestCoalescer: newRequestCoalescer()}}
+
+		post := &model.Post{Id: model.NewId(), Message: "message"}
+		release := make(chan struct{})
+		fetch := func() store.StoreChannel {
+			return store.Do(func(result *store.StoreResult) {
+				<-release
+				list := model.NewPostList()
+				list.AddPost(post)
+				list.AddOrder(post.Id)
+				result.Data = list
+			})
+		}
+
+		var wg sync.WaitGroup
+		lists := make([]*model.PostList, 10)
+		for i := range lists {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				list, err := a.coalescePostList("key", fetch)
+				if !assert.Nil(t, err) {
+					return
+				}
+
+				list.AddOrder(model.NewId())
+				list.Posts[post.Id].AddProp("caller", i)
+				lists[i] = list
+			}(i)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		for i, list := range lists {
+			assert.Len(t, list.Order, 2)
+			assert.Equal(t, i, list.Posts[post.Id].Props["caller"])
+		}
+		assert.Nil(t, post.Props)
+	})
+}
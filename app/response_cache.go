@@ -0,0 +1,75 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"strings"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const (
+	RESPONSE_CACHE_SIZE = 1000
+
+	// Cached responses are invalidated whenever the data behind them changes, so this only bounds how long a
+	// response can be served after an invalidation was missed, such as by a node that was briefly out of the cluster.
+	RESPONSE_CACHE_EXPIRY_SECONDS = 5 * 60
+)
+
+// CachedResponse is the body of a successful response to a cacheable API endpoint, along with the ETag it was sent
+// with.
+type CachedResponse struct {
+	ETag string
+	Body []byte
+}
+
+// IsResponseCacheEnabled returns whether responses to the given endpoint, one of model.RESPONSE_CACHE_ENDPOINT_*,
+// should be cached.
+func (a *App) IsResponseCacheEnabled(endpoint string) bool {
+	for _, enabled := range a.Config().ServiceSettings.ResponseCacheEndpoints {
+		if enabled == endpoint {
+			return true
+		}
+	}
+	return false
+}
+
+func responseCacheKey(endpoint, key string) string {
+	return endpoint + ":" + key
+}
+
+func (a *App) GetCachedResponse(endpoint, key string) *CachedResponse {
+	if cached, ok := a.Srv.responseCache.Get(responseCacheKey(endpoint, key)); ok {
+		return cached.(*CachedResponse)
+	}
+	return nil
+}
+
+func (a *App) AddCachedResponse(endpoint, key string, response *CachedResponse) {
+	a.Srv.responseCache.AddWithExpiresInSecs(responseCacheKey(endpoint, key), response, RESPONSE_CACHE_EXPIRY_SECONDS)
+}
+
+// InvalidateResponseCache drops every cached response to the given endpoint on all nodes. It must be called whenever
+// the data returned by the endpoint changes.
+func (a *App) InvalidateResponseCache(endpoint string) {
+	a.InvalidateResponseCacheSkipClusterSend(endpoint)
+
+	if a.Cluster != nil {
+		msg := &model.ClusterMessage{
+			Event:    model.CLUSTER_EVENT_INVALIDATE_RESPONSE_CACHE,
+			SendType: model.CLUSTER_SEND_RELIABLE,
+			Data:     endpoint,
+		}
+		a.Cluster.SendClusterMessage(msg)
+	}
+}
+
+func (a *App) InvalidateResponseCacheSkipClusterSend(endpoint string) {
+	prefix := responseCacheKey(endpoint, "")
+	for _, key := range a.Srv.responseCache.Keys() {
+		if strings.HasPrefix(key.(string), prefix) {
+			a.Srv.responseCache.Remove(key)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const TOKEN_TYPE_SAML_ASSERTION = "saml_assertion"
+
+// errSamlMultipleAssertions is returned for a SAML response with more than one assertion, since there'd be no telling
+// which of them was verified.
+var errSamlMultipleAssertions = errors.New("the SAML response has more than one assertion")
+
+// samlResponse holds the parts of a SAML response that identify its assertion and say until when it's valid. Encrypted
+// assertions can't be read, so they're identified by their encrypted data instead, which can't be changed without
+// breaking them.
+type samlResponse struct {
+	Assertion *struct {
+		Id         string `xml:"ID,attr"`
+		Conditions *struct {
+			NotOnOrAfter string `xml:"NotOnOrAfter,attr"`
+		} `xml:"Conditions"`
+		SubjectConfirmations []struct {
+			NotOnOrAfter string `xml:"NotOnOrAfter,attr"`
+		} `xml:"Subject>SubjectConfirmation>SubjectConfirmationData"`
+	} `xml:"Assertion"`
+	EncryptedAssertion *struct {
+		CipherValues []string `xml:"EncryptedData>CipherData>CipherValue"`
+	} `xml:"EncryptedAssertion"`
+}
+
+// parseSamlAssertion returns a key that identifies the assertion of a base64 encoded SAML response, along with the
+// latest NotOnOrAfter time in the assertion, or 0 if there's none or the assertion is encrypted. A response with more
+// than one assertion, signed or otherwise, is rejected, so that the assertion that's identified is always the one that
+// the SAML provider verified rather than one added alongside it to make a replay look new.
+func parseSamlAssertion(encodedXML string) (string, int64, error) {
+	decoded, err := base64.StdEncoding.DecodeString(encodedXML)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if count, err := countSamlAssertions(decoded); err != nil {
+		return "", 0, err
+	} else if count > 1 {
+		return "", 0, errSamlMultipleAssertions
+	}
+
+	var response samlResponse
+	if err := xml.Unmarshal(decoded, &response); err != nil {
+		return "", 0, err
+	}
+
+	if assertion := response.Assertion; assertion != nil && assertion.Id != "" {
+		notOnOrAfter := []string{}
+		if assertion.Conditions != nil {
+			notOnOrAfter = append(notOnOrAfter, assertion.Conditions.NotOnOrAfter)
+		}
+		for _, confirmation := range assertion.SubjectConfirmations {
+			notOnOrAfter = append(notOnOrAfter, confirmation.NotOnOrAfter)
+		}
+
+		return "assertion:" + assertion.Id, latestSamlTime(notOnOrAfter), nil
+	}
+
+	if response.EncryptedAssertion != nil && len(response.EncryptedAssertion.CipherValues) > 0 {
+		hash := sha256.New()
+		for _, value := range response.EncryptedAssertion.CipherValues {
+			// Whitespace is ignored when decoding the encrypted data, so it mustn't change the key
+			hash.Write([]byte(strings.Join(strings.Fields(value), "")))
+		}
+		return "encrypted:" + hex.EncodeToString(hash.Sum(nil)), 0, nil
+	}
+
+	return "", 0, errors.New("the SAML response has no assertion")
+}
+
+// latestSamlTime returns the latest of the given SAML times in milliseconds, ignoring any that are empty or invalid, or
+// 0 if there are none.
+func latestSamlTime(values []string) int64 {
+	var latest int64
+	for _, value := range values {
+		if t, err := time.Parse(time.RFC3339, value); err == nil && model.GetMillisForTime(t) > latest {
+			latest = model.GetMillisForTime(t)
+		}
+	}
+	return latest
+}
+
+// countSamlAssertions returns how many assertions, whether encrypted or not, are anywhere in a SAML response.
+func countSamlAssertions(data []byte) (int, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	count := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return count, nil
+		} else if err != nil {
+			return 0, err
+		}
+
+		if element, ok := token.(xml.StartElement); ok && (element.Name.Local == "Assertion" || element.Name.Local == "EncryptedAssertion") {
+			count++
+		}
+	}
+}
+
+// RecordSamlAssertion remembers the assertion of a SAML response that's been used to log in, and returns an error if
+// it's already been used so that a captured assertion can't be replayed. Only assertions that have been verified should
+// be recorded, so that they can't be used up by someone else. The assertions are saved as tokens, so that they're
+// shared across a cluster, and are kept until they expire. Encrypted assertions can't be read to find out when that is,
+// so they're kept for as long as other tokens are.
+func (a *App) RecordSamlAssertion(encodedXML string) *model.AppError {
+	key, expireAt, err := parseSamlAssertion(encodedXML)
+	if err == errSamlMultipleAssertions {
+		return model.NewAppError("RecordSamlAssertion", "api.user.saml.multiple_assertions.app_error", nil, "", http.StatusBadRequest)
+	} else if err != nil {
+		mlog.Warn("Unable to read the assertion of a SAML response to protect it from being replayed", mlog.Err(err))
+		return nil
+	}
+
+	// Assertion IDs can be longer than tokens, so they're hashed
+	hash := sha256.Sum256([]byte(key))
+	token := &model.Token{
+		Token:    hex.EncodeToString(hash[:]),
+		CreateAt: model.GetMillis(),
+		Type:     TOKEN_TYPE_SAML_ASSERTION,
+	}
+
+	// Assertions that expire before tokens are cleaned up anyway are kept for as long as other tokens, which leaves room
+	// for any clock skew that the SAML provider allows
+	if expireAt > token.CreateAt+model.MAX_TOKEN_EXIPRY_TIME {
+		token.ExpireAt = expireAt
+	}
+
+	if result := <-a.Srv.Store.Token().Save(token); result.Err != nil {
+		if result.Err.Id == "store.sql_recover.save.exists.app_error" {
+			return model.NewAppError("RecordSamlAssertion", "api.user.saml.replayed_assertion.app_error", nil, key, http.StatusBadRequest)
+		}
+		return result.Err
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func encodeSamlResponse(xml string) string {
+	return base64.StdEncoding.EncodeToString([]byte(xml))
+}
+
+func TestParseSamlAssertion(t *testing.T) {
+	t.Run("assertion", func(t *testing.T) {
+		id, expireAt, err := parseSamlAssertion(encodeSamlResponse(
+			`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_response">` +
+				`<saml:Assertion ID="_assertion"><saml:Conditions NotOnOrAfter="2019-06-01T12:10:00Z"/></saml:Assertion>` +
+				`</samlp:Response>`,
+		))
+		require.Nil(t, err)
+		assert.Equal(t, "assertion:_assertion", id)
+		assert.Equal(t, int64(1559391000000), expireAt)
+	})
+
+	t.Run("latest expiry", func(t *testing.T) {
+		_, expireAt, err := parseSamlAssertion(encodeSamlResponse(
+			`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_response">` +
+				`<saml:Assertion ID="_assertion">` +
+				`<saml:Subject><saml:SubjectConfirmation><saml:SubjectConfirmationData NotOnOrAfter="2019-06-02T12:10:00Z"/></saml:SubjectConfirmation></saml:Subject>` +
+				`<saml:Conditions NotOnOrAfter="2019-06-01T12:10:00Z"/>` +
+				`</saml:Assertion>` +
+				`</samlp:Response>`,
+		))
+		require.Nil(t, err)
+		assert.Equal(t, int64(1559477400000), expireAt)
+
+		_, expireAt, err = parseSamlAssertion(encodeSamlResponse(
+			`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_response">` +
+				`<saml:Assertion ID="_assertion"><saml:Conditions NotOnOrAfter="not a time"/></saml:Assertion>` +
+				`</samlp:Response>`,
+		))
+		require.Nil(t, err)
+		assert.Zero(t, expireAt)
+	})
+
+	t.Run("encrypted assertion", func(t *testing.T) {
+		encrypted := func(cipherValue string) string {
+			return encodeSamlResponse(
+				`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_` + model.NewId() + `">` +
+					`<saml:EncryptedAssertion><xenc:EncryptedData xmlns:xenc="http://www.w3.org/2001/04/xmlenc#"><xenc:CipherData><xenc:CipherValue>` + cipherValue + `</xenc:CipherValue></xenc:CipherData></xenc:EncryptedData></saml:EncryptedAssertion>` +
+					`</samlp:Response>`,
+			)
+		}
+
+		id, expireAt, err := parseSamlAssertion(encrypted("YWJj ZGVm"))
+		require.Nil(t, err)
+		assert.True(t, strings.HasPrefix(id, "encrypted:"))
+		assert.Zero(t, expireAt)
+
+		sameId, _, err := parseSamlAssertion(encrypted("\nYWJjZGVm\n"))
+		require.Nil(t, err)
+		assert.Equal(t, id, sameId, "the response ID and whitespace shouldn't change the key")
+
+		otherId, _, err := parseSamlAssertion(encrypted("Z2hp"))
+		require.Nil(t, err)
+		assert.NotEqual(t, id, otherId)
+	})
+
+	t.Run("more than one assertion", func(t *testing.T) {
+		_, _, err := parseSamlAssertion(encodeSamlResponse(
+			`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_response">` +
+				`<saml:Assertion ID="_signed"/><saml:Assertion ID="_unsigned"/>` +
+				`</samlp:Response>`,
+		))
+		assert.Equal(t, errSamlMultipleAssertions, err)
+
+		_, _, err = parseSamlAssertion(encodeSamlResponse(
+			`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_response">` +
+				`<samlp:Extensions><saml:Assertion ID="_nested"/></samlp:Extensions><saml:EncryptedAssertion/>` +
+				`</samlp:Response>`,
+		))
+		assert.Equal(t, errSamlMultipleAssertions, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := parseSamlAssertion("not base64!")
+		assert.NotNil(t, err)
+
+		_, _, err = parseSamlAssertion(encodeSamlResponse(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="_response"/>`))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestRecordSamlAssertion(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	response := func(assertions ...string) string {
+		xml := `<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_` + model.NewId() + `">`
+		for _, assertionId := range assertions {
+			xml += `<saml:Assertion ID="` + assertionId + `"/>`
+		}
+		return encodeSamlResponse(xml + `</samlp:Response>`)
+	}
+
+	assertionId := "_" + model.NewId()
+
+	require.Nil(t, th.App.RecordSamlAssertion(response(assertionId)))
+
+	err := th.App.RecordSamlAssertion(response(assertionId))
+	require.NotNil(t, err)
+	assert.Equal(t, "api.user.saml.replayed_assertion.app_error", err.Id)
+
+	t.Run("other assertions", func(t *testing.T) {
+		assert.Nil(t, th.App.RecordSamlAssertion(response("_"+model.NewId())))
+	})
+
+	t.Run("assertions added to a replayed response", func(t *testing.T) {
+		err := th.App.RecordSamlAssertion(response(assertionId, "_"+model.NewId()))
+		require.NotNil(t, err)
+		assert.Equal(t, "api.user.saml.multiple_assertions.app_error", err.Id)
+	})
+
+	t.Run("unreadable responses aren't protected", func(t *testing.T) {
+		assert.Nil(t, th.App.RecordSamlAssertion("not base64!"))
+	})
+	t.Run("assertions are kept until they expire", func(t *testing.T) {
+		longLivedId := "_" + model.NewId()
+		notOnOrAfter := time.Now().Add(7 * 24 * time.Hour).UTC().Truncate(time.Second)
+		longLived := encodeSamlResponse(
+			`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_` + model.NewId() + `">` +
+				`<saml:Assertion ID="` + longLivedId + `"><saml:Conditions NotOnOrAfter="` + notOnOrAfter.Format(time.RFC3339) + `"/></saml:Assertion>` +
+				`</samlp:Response>`,
+		)
+		require.Nil(t, th.App.RecordSamlAssertion(longLived))
+
+		hash := sha256.Sum256([]byte("assertion:" + longLivedId))
+		result := <-th.App.Srv.Store.Token().GetByToken(hex.EncodeToString(hash[:]))
+		require.Nil(t, result.Err)
+		assert.Equal(t, model.GetMillisForTime(notOnOrAfter), result.Data.(*model.Token).ExpireAt)
+
+		// Tokens are otherwise cleaned up once they're old enough, whether or not they've been used
+		old := model.GetMillis() - model.MAX_TOKEN_EXIPRY_TIME - 1000
+		unexpired := &model.Token{Token: model.NewRandomString(model.TOKEN_SIZE), CreateAt: old, Type: TOKEN_TYPE_SAML_ASSERTION, ExpireAt: model.GetMillisForTime(notOnOrAfter)}
+		expired := &model.Token{Token: model.NewRandomString(model.TOKEN_SIZE), CreateAt: old, Type: TOKEN_TYPE_SAML_ASSERTION}
+		require.Nil(t, (<-th.App.Srv.Store.Token().Save(unexpired)).Err)
+		require.Nil(t, (<-th.App.Srv.Store.Token().Save(expired)).Err)
+
+		th.App.Srv.Store.Token().Cleanup()
+
+		assert.Nil(t, (<-th.App.Srv.Store.Token().GetByToken(unexpired.Token)).Err)
+		assert.NotNil(t, (<-th.App.Srv.Store.Token().GetByToken(expired.Token)).Err)
+	})
+}
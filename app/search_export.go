@@ -0,0 +1,231 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const SEARCH_EXPORT_PAGE_SIZE = 200
+
+type searchExportWriter interface {
+	WriteRow(row *model.PostSearchExportRow) error
+	Flush() error
+}
+
+type jsonlSearchExportWriter struct {
+	w io.Writer
+}
+
+func (e *jsonlSearchExportWriter) WriteRow(row *model.PostSearchExportRow) error {
+	_, err := io.WriteString(e.w, row.ToJson()+"\n")
+	return err
+}
+
+func (e *jsonlSearchExportWriter) Flush() error {
+	return nil
+}
+
+type csvSearchExportWriter struct {
+	w *model.CsvWriter
+}
+
+func (e *csvSearchExportWriter) WriteRow(row *model.PostSearchExportRow) error {
+	return e.w.Write(row.CSVRecord())
+}
+
+func (e *csvSearchExportWriter) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// ExportSearchResults writes every post in the team matching the search terms that the user can read to w, in the
+// given format, and returns how many were written. Unlike SearchPostsInTeam, the results aren't limited to a single
+// page, but only up to ServiceSettings.SearchExportMaxResults posts are exported. Each page of results is flushed
+// as soon as it's written so that large exports don't need to be buffered.
+func (a *App) ExportSearchResults(w io.Writer, format string, terms string, userId string, teamId string, isOrSearch bool, includeDeletedChannels bool, timeZoneOffset int) (int, *model.AppError) {
+	esInterface := a.Elasticsearch
+	license := a.License()
+	useElasticsearch := esInterface != nil && *a.Config().ElasticsearchSettings.EnableSearching && license != nil && *license.Features.Elasticsearch
+
+	if !useElasticsearch && !*a.Config().ServiceSettings.EnablePostSearch {
+		return 0, model.NewAppError("ExportSearchResults", "store.sql_post.search.disabled", nil, fmt.Sprintf("teamId=%v userId=%v", teamId, userId), http.StatusNotImplemented)
+	}
+
+	// The CSV header is buffered along with the first page of results, so nothing is written to w before the first
+	// search succeeds.
+	var writer searchExportWriter
+	switch format {
+	case model.SEARCH_EXPORT_FORMAT_JSONL:
+		writer = &jsonlSearchExportWriter{w: w}
+	case model.SEARCH_EXPORT_FORMAT_CSV:
+		csvWriter := model.NewCsvWriter(w)
+		if err := csvWriter.Write(model.PostSearchExportCSVHeader()); err != nil {
+			return 0, model.NewAppError("ExportSearchResults", "app.search_export.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+		writer = &csvSearchExportWriter{w: csvWriter}
+	default:
+		return 0, model.NewAppError("ExportSearchResults", "app.search_export.format.app_error", nil, "format="+format, http.StatusBadRequest)
+	}
+
+	exporter := &searchResultsExporter{
+		app:        a,
+		w:          w,
+		writer:     writer,
+		maxResults: *a.Config().ServiceSettings.SearchExportMaxResults,
+		seen:       map[string]bool{},
+		channels:   map[string]*model.Channel{},
+		usernames:  map[string]string{},
+	}
+
+	if useElasticsearch {
+		for page := 0; !exporter.done(); page++ {
+			results, err := a.searchPosts(terms, userId, []string{teamId}, isOrSearch, includeDeletedChannels, true, timeZoneOffset, page, SEARCH_EXPORT_PAGE_SIZE)
+			if err != nil {
+				return exporter.exported, err
+			}
+			if len(results.Order) == 0 {
+				break
+			}
+			if err := exporter.export(results.PostList); err != nil {
+				return exporter.exported, err
+			}
+		}
+
+		return exporter.exported, exporter.flush()
+	}
+
+	includeDeleted := includeDeletedChannels && *a.Config().TeamSettings.ExperimentalViewArchivedChannels
+
+	for _, params := range model.ParseSearchParams(terms, timeZoneOffset) {
+		// don't allow users to export everything
+		if params.Terms == "*" {
+			continue
+		}
+
+		params.IncludeDeletedChannels = includeDeleted
+		params.OrTerms = isOrSearch
+		for idx, channelName := range params.InChannels {
+			if strings.HasPrefix(channelName, "@") {
+				channel, err := a.parseAndFetchChannelIdByNameFromInFilter(channelName, userId, teamId, includeDeleted)
+				if err != nil {
+					mlog.Error(fmt.Sprint(err))
+					continue
+				}
+				params.InChannels[idx] = channel.Name
+			}
+		}
+
+		for offset := 0; !exporter.done(); offset += SEARCH_EXPORT_PAGE_SIZE {
+			result := <-a.Srv.Store.Post().SearchInTeamsPage([]string{teamId}, userId, params, offset, SEARCH_EXPORT_PAGE_SIZE)
+			if result.Err != nil {
+				return exporter.exported, result.Err
+			}
+			list := result.Data.(*model.PostList)
+			if len(list.Order) == 0 {
+				break
+			}
+			if err := exporter.export(list); err != nil {
+				return exporter.exported, err
+			}
+		}
+	}
+
+	return exporter.exported, exporter.flush()
+}
+
+type searchResultsExporter struct {
+	app        *App
+	w          io.Writer
+	writer     searchExportWriter
+	maxResults int
+	exported   int
+
+	// Posts can match more than one set of search params, so the ones that were already exported are skipped.
+	seen      map[string]bool
+	channels  map[string]*model.Channel
+	usernames map[string]string
+}
+
+func (e *searchResultsExporter) done() bool {
+	return e.exported >= e.maxResults
+}
+
+func (e *searchResultsExporter) export(list *model.PostList) *model.AppError {
+	for _, postId := range list.Order {
+		if e.done() {
+			break
+		}
+
+		post := list.Posts[postId]
+		if post == nil || e.seen[post.Id] {
+			continue
+		}
+		e.seen[post.Id] = true
+
+		channel := e.getChannel(post.ChannelId)
+		if err := e.writer.WriteRow(&model.PostSearchExportRow{
+			PostId:      post.Id,
+			CreateAt:    post.CreateAt,
+			TeamId:      channel.TeamId,
+			ChannelId:   post.ChannelId,
+			ChannelName: channel.Name,
+			UserId:      post.UserId,
+			Username:    e.getUsername(post.UserId),
+			RootId:      post.RootId,
+			Message:     post.Message,
+		}); err != nil {
+			return model.NewAppError("ExportSearchResults", "app.search_export.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+		e.exported++
+	}
+
+	return e.flush()
+}
+
+func (e *searchResultsExporter) flush() *model.AppError {
+	if err := e.writer.Flush(); err != nil {
+		return model.NewAppError("ExportSearchResults", "app.search_export.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	if flusher, ok := e.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+func (e *searchResultsExporter) getChannel(channelId string) *model.Channel {
+	if channel, ok := e.channels[channelId]; ok {
+		return channel
+	}
+
+	channel, err := e.app.GetChannel(channelId)
+	if err != nil {
+		mlog.Warn("Failed to get channel for search export", mlog.String("channel_id", channelId), mlog.Err(err))
+		channel = &model.Channel{Id: channelId}
+	}
+	e.channels[channelId] = channel
+	return channel
+}
+
+func (e *searchResultsExporter) getUsername(userId string) string {
+	if username, ok := e.usernames[userId]; ok {
+		return username
+	}
+
+	username := ""
+	if user, err := e.app.GetUser(userId); err != nil {
+		mlog.Warn("Failed to get user for search export", mlog.String("user_id", userId), mlog.Err(err))
+	} else {
+		username = user.Username
+	}
+	e.usernames[userId] = username
+	return username
+}
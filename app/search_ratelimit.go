@@ -0,0 +1,126 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+	"github.com/pkg/errors"
+	"github.com/throttled/throttled"
+	"github.com/throttled/throttled/store/memstore"
+)
+
+const (
+	SEARCH_RATE_LIMIT_MEMORY_STORE_SIZE = 10000
+
+	// searchRateLimitGlobalKey is the key under which every search counts against the global limit.
+	searchRateLimitGlobalKey = "global"
+)
+
+// searchRateLimiter limits searches with the quotas in SearchRateLimitSettings, separately from the RateLimiter that
+// limits requests in general. Its state is only kept in memory, so each node of a cluster limits searches on its own.
+type searchRateLimiter struct {
+	userRateLimiter   *throttled.GCRARateLimiter
+	globalRateLimiter *throttled.GCRARateLimiter
+}
+
+func newSearchRateLimiter(settings *model.SearchRateLimitSettings) (*searchRateLimiter, error) {
+	userRateLimiter, err := newSearchGCRARateLimiter(*settings.PerUserPerMinute, *settings.PerUserMaxBurst)
+	if err != nil {
+		return nil, err
+	}
+
+	globalRateLimiter, err := newSearchGCRARateLimiter(*settings.GlobalPerMinute, *settings.GlobalMaxBurst)
+	if err != nil {
+		return nil, err
+	}
+
+	return &searchRateLimiter{
+		userRateLimiter:   userRateLimiter,
+		globalRateLimiter: globalRateLimiter,
+	}, nil
+}
+
+func newSearchGCRARateLimiter(perMinute int, maxBurst int) (*throttled.GCRARateLimiter, error) {
+	store, err := memstore.New(SEARCH_RATE_LIMIT_MEMORY_STORE_SIZE)
+	if err != nil {
+		return nil, errors.Wrap(err, utils.T("api.server.start_server.rate_limiting_memory_store"))
+	}
+
+	quota := throttled.RateQuota{
+		MaxRate:  throttled.PerMin(perMinute),
+		MaxBurst: maxBurst,
+	}
+
+	rateLimiter, err := throttled.NewGCRARateLimiter(store, quota)
+	if err != nil {
+		return nil, errors.Wrap(err, utils.T("api.server.start_server.rate_limiting_rate_limiter"))
+	}
+
+	return rateLimiter, nil
+}
+
+// rateLimit counts a search against the limits of its user and of the server as a whole, in that order, so that a
+// user who's over their own limit doesn't use up the quota of everyone else.
+func (rl *searchRateLimiter) rateLimit(userId string) (bool, time.Duration) {
+	for _, limit := range []struct {
+		rateLimiter *throttled.GCRARateLimiter
+		key         string
+	}{
+		{rl.userRateLimiter, userId},
+		{rl.globalRateLimiter, searchRateLimitGlobalKey},
+	} {
+		limited, result, err := limit.rateLimiter.RateLimit(limit.key, 1)
+		if err != nil {
+			// Like the general rate limiter, searches are allowed rather than rejected when limiting them fails
+			mlog.Critical("Internal server error when rate limiting searches.", mlog.Err(err))
+			return false, 0
+		}
+
+		if limited {
+			return true, result.RetryAfter
+		}
+	}
+
+	return false, 0
+}
+
+// initSearchRateLimiter builds the search rate limiter from the config, and rebuilds it whenever
+// SearchRateLimitSettings change, so that changing them doesn't require a restart.
+func (s *Server) initSearchRateLimiter() {
+	s.updateSearchRateLimiter(&s.Config().SearchRateLimitSettings)
+
+	s.searchRateLimitListenerId = s.AddConfigListener(func(oldConfig *model.Config, newConfig *model.Config) {
+		if !reflect.DeepEqual(oldConfig.SearchRateLimitSettings, newConfig.SearchRateLimitSettings) {
+			s.updateSearchRateLimiter(&newConfig.SearchRateLimitSettings)
+		}
+	})
+}
+
+func (s *Server) updateSearchRateLimiter(settings *model.SearchRateLimitSettings) {
+	var rateLimiter *searchRateLimiter
+	if *settings.Enable {
+		var err error
+		if rateLimiter, err = newSearchRateLimiter(settings); err != nil {
+			mlog.Error("Unable to limit the rate of searches.", mlog.Err(err))
+		}
+	}
+
+	s.searchRateLimiter.Store(rateLimiter)
+}
+
+// RateLimitSearch counts a search made by a user against SearchRateLimitSettings. It returns true if the search should
+// be rejected, along with how long the user should wait before searching again.
+func (s *Server) RateLimitSearch(userId string) (bool, time.Duration) {
+	rateLimiter, _ := s.searchRateLimiter.Load().(*searchRateLimiter)
+	if rateLimiter == nil {
+		return false, 0
+	}
+
+	return rateLimiter.rateLimit(userId)
+}
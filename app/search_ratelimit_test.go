@@ -0,0 +1,105 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func genSearchRateLimitSettings(perUserMaxBurst, globalMaxBurst int) *model.SearchRateLimitSettings {
+	return &model.SearchRateLimitSettings{
+		Enable:           model.NewBool(true),
+		PerUserPerMinute: model.NewInt(1),
+		PerUserMaxBurst:  model.NewInt(perUserMaxBurst),
+		GlobalPerMinute:  model.NewInt(1),
+		GlobalMaxBurst:   model.NewInt(globalMaxBurst),
+	}
+}
+
+func TestSearchRateLimiter(t *testing.T) {
+	t.Run("per user", func(t *testing.T) {
+		rateLimiter, err := newSearchRateLimiter(genSearchRateLimitSettings(1, 100))
+		require.Nil(t, err)
+
+		userId := model.NewId()
+		otherUserId := model.NewId()
+
+		// A burst of 1 allows a second search right away
+		for i := 0; i < 2; i++ {
+			limited, _ := rateLimiter.rateLimit(userId)
+			require.False(t, limited)
+		}
+
+		limited, retryAfter := rateLimiter.rateLimit(userId)
+		assert.True(t, limited)
+		assert.True(t, retryAfter > 0)
+
+		limited, _ = rateLimiter.rateLimit(otherUserId)
+		assert.False(t, limited, "other users should have a quota of their own")
+	})
+
+	t.Run("global", func(t *testing.T) {
+		rateLimiter, err := newSearchRateLimiter(genSearchRateLimitSettings(100, 1))
+		require.Nil(t, err)
+
+		for i := 0; i < 2; i++ {
+			limited, _ := rateLimiter.rateLimit(model.NewId())
+			require.False(t, limited)
+		}
+
+		limited, retryAfter := rateLimiter.rateLimit(model.NewId())
+		assert.True(t, limited)
+		assert.True(t, retryAfter > 0)
+	})
+
+	t.Run("limited user doesn't use the global quota", func(t *testing.T) {
+		rateLimiter, err := newSearchRateLimiter(genSearchRateLimitSettings(0, 1))
+		require.Nil(t, err)
+
+		userId := model.NewId()
+		limited, _ := rateLimiter.rateLimit(userId)
+		require.False(t, limited)
+
+		for i := 0; i < 5; i++ {
+			limited, _ = rateLimiter.rateLimit(userId)
+			require.True(t, limited)
+		}
+
+		limited, _ = rateLimiter.rateLimit(model.NewId())
+		assert.False(t, limited)
+	})
+}
+
+func TestRateLimitSearch(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	userId := model.NewId()
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.SearchRateLimitSettings.Enable = false
+	})
+	for i := 0; i < 5; i++ {
+		limited, _ := th.App.Srv.RateLimitSearch(userId)
+		require.False(t, limited, "searches shouldn't be limited while disabled")
+	}
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.SearchRateLimitSettings.Enable = true
+		*cfg.SearchRateLimitSettings.PerUserPerMinute = 1
+		*cfg.SearchRateLimitSettings.PerUserMaxBurst = 1
+	})
+	for i := 0; i < 2; i++ {
+		limited, _ := th.App.Srv.RateLimitSearch(userId)
+		require.False(t, limited)
+	}
+
+	limited, retryAfter := th.App.Srv.RateLimitSearch(userId)
+	assert.True(t, limited)
+	assert.True(t, retryAfter > 0)
+}
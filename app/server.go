@@ -7,11 +7,13 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"reflect"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -20,7 +22,6 @@ import (
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
-	"github.com/rs/cors"
 	"github.com/throttled/throttled"
 	"golang.org/x/crypto/acme/autocert"
 
@@ -32,6 +33,7 @@ import (
 	"github.com/mattermost/mattermost-server/services/httpservice"
 	"github.com/mattermost/mattermost-server/services/imageproxy"
 	"github.com/mattermost/mattermost-server/services/timezones"
+	"github.com/mattermost/mattermost-server/services/tracing"
 	"github.com/mattermost/mattermost-server/store"
 	"github.com/mattermost/mattermost-server/utils"
 	"github.com/mattermost/mattermost-server/utils/fileutils"
@@ -59,6 +61,11 @@ type Server struct {
 	goroutineCount      int32
 	goroutineExitSignal chan struct{}
 
+	// startupComplete is set once NewServer has finished, after which the server is ready to serve traffic.
+	startupComplete int32
+	// preloadingCaches is set while the caches are being warmed after startup, see PreloadCaches.
+	preloadingCaches int32
+
 	PluginsEnvironment     *plugin.Environment
 	PluginConfigListenerId string
 	PluginsLock            sync.RWMutex
@@ -66,6 +73,12 @@ type Server struct {
 	EmailBatching    *EmailBatchingJob
 	EmailRateLimiter *throttled.GCRARateLimiter
 
+	eventFirehose     *eventFirehose
+	eventFirehoseLock sync.RWMutex
+
+	tracer     *tracing.Tracer
+	tracerLock sync.RWMutex
+
 	Hubs                        []*Hub
 	HubsStopCheckingForDeadlock chan bool
 
@@ -88,16 +101,30 @@ type Server struct {
 
 	newStore func() store.Store
 
-	htmlTemplateWatcher     *utils.HTMLTemplateWatcher
-	sessionCache            *utils.Cache
-	seenPendingPostIdsCache *utils.Cache
-	configListenerId        string
-	licenseListenerId       string
-	logListenerId           string
-	clusterLeaderListenerId string
-	disableConfigWatch      bool
-	configWatcher           *utils.ConfigWatcher
-	asymmetricSigningKey    *ecdsa.PrivateKey
+	htmlTemplateWatcher          *utils.HTMLTemplateWatcher
+	sessionCache                 *utils.Cache
+	clientCertSessionCache       *utils.Cache
+	clusterPresence              *clusterPresence
+	clusterPresenceTask          *model.ScheduledTask
+	loadSheddingTask             *model.ScheduledTask
+	sheddingLoad                 int32
+	draining                     int32
+	requestCoalescer             *requestCoalescer
+	seenPendingPostIdsCache      *utils.Cache
+	responseCache                *utils.Cache
+	concurrentRequests           *concurrentRequests
+	searchRateLimiter            atomic.Value
+	dataLossPreventionScanner    atomic.Value
+	configListenerId             string
+	licenseListenerId            string
+	logListenerId                string
+	corsListenerId               string
+	searchRateLimitListenerId    string
+	dataLossPreventionListenerId string
+	clusterLeaderListenerId      string
+	disableConfigWatch           bool
+	configWatcher                *utils.ConfigWatcher
+	asymmetricSigningKey         *ecdsa.PrivateKey
 
 	pluginCommands     []*PluginCommand
 	pluginCommandsLock sync.RWMutex
@@ -124,6 +151,8 @@ type Server struct {
 	Compliance       einterfaces.ComplianceInterface
 	DataRetention    einterfaces.DataRetentionInterface
 	Elasticsearch    einterfaces.ElasticsearchInterface
+	FirehoseWriter   einterfaces.FirehoseWriterInterface
+	MfaVerifier      einterfaces.MfaVerifierInterface
 	Ldap             einterfaces.LdapInterface
 	MessageExport    einterfaces.MessageExportInterface
 	Metrics          einterfaces.MetricsInterface
@@ -140,8 +169,13 @@ func NewServer(options ...Option) (*Server, error) {
 		configListeners:         make(map[string]func(*model.Config, *model.Config)),
 		licenseListeners:        map[string]func(){},
 		sessionCache:            utils.NewLru(model.SESSION_CACHE_SIZE),
+		clientCertSessionCache:  utils.NewLru(model.SESSION_CACHE_SIZE),
 		seenPendingPostIdsCache: utils.NewLru(PENDING_POST_IDS_CACHE_SIZE),
+		responseCache:           utils.NewLru(RESPONSE_CACHE_SIZE),
+		concurrentRequests:      newConcurrentRequests(),
 		clientConfig:            make(map[string]string),
+		clusterPresence:         newClusterPresence(),
+		requestCoalescer:        newRequestCoalescer(),
 	}
 	for _, option := range options {
 		option(s)
@@ -189,6 +223,23 @@ func NewServer(options ...Option) (*Server, error) {
 		s.InitEmailBatching()
 	})
 
+	s.InitEventFirehose()
+	s.AddConfigListener(func(oldConfig *model.Config, newConfig *model.Config) {
+		if !reflect.DeepEqual(oldConfig.FirehoseSettings, newConfig.FirehoseSettings) {
+			s.InitEventFirehose()
+		}
+	})
+
+	s.InitTracing()
+	s.AddConfigListener(func(oldConfig *model.Config, newConfig *model.Config) {
+		if !reflect.DeepEqual(oldConfig.TraceSettings, newConfig.TraceSettings) {
+			s.InitTracing()
+		}
+	})
+
+	s.initSearchRateLimiter()
+	s.initDataLossPreventionScanner()
+
 	mlog.Info(fmt.Sprintf("Current version is %v (%v/%v/%v/%v)", model.CurrentVersion, model.BuildNumber, model.BuildDate, model.BuildHash, model.BuildHashEnterprise))
 	mlog.Info(fmt.Sprintf("Enterprise Enabled: %v", model.BuildEnterpriseReady))
 	pwd, _ := os.Getwd()
@@ -224,12 +275,17 @@ func NewServer(options ...Option) (*Server, error) {
 	if s.joinCluster && s.Cluster != nil {
 		s.FakeApp().RegisterAllClusterMessageHandlers()
 		s.Cluster.StartInterNodeCommunication()
+
+		app := s.FakeApp()
+		s.clusterPresenceTask = model.CreateRecurringTask("Cluster Presence Sync", app.SyncClusterPresence, CLUSTER_PRESENCE_SYNC_INTERVAL)
 	}
 
 	if s.startMetrics && s.Metrics != nil {
 		s.Metrics.StartServer()
 	}
 
+	s.loadSheddingTask = model.CreateRecurringTask("Load Shedding", s.checkLoadShedding, LOAD_SHEDDING_CHECK_INTERVAL)
+
 	if s.startElasticsearch && s.Elasticsearch != nil {
 		s.StartElasticsearch()
 	}
@@ -252,6 +308,9 @@ func NewServer(options ...Option) (*Server, error) {
 		s.Go(func() {
 			runCommandWebhookCleanupJob(s)
 		})
+		s.Go(func() {
+			runIdempotentRequestCleanupJob(s)
+		})
 
 		if complianceI := s.Compliance; complianceI != nil {
 			complianceI.StartComplianceDailyJob()
@@ -265,9 +324,25 @@ func NewServer(options ...Option) (*Server, error) {
 		}
 	}
 
+	if *s.Config().ServiceSettings.EnableCachePreloading {
+		atomic.StoreInt32(&s.preloadingCaches, 1)
+		s.Go(func() {
+			s.FakeApp().PreloadCaches()
+			atomic.StoreInt32(&s.preloadingCaches, 0)
+		})
+	}
+
+	atomic.StoreInt32(&s.startupComplete, 1)
+
 	return s, nil
 }
 
+// IsDraining returns whether the server has started shutting down, after which new requests should be rejected so
+// that clients retry them elsewhere.
+func (s *Server) IsDraining() bool {
+	return atomic.LoadInt32(&s.draining) == 1
+}
+
 // Global app opptions that should be applied to apps created by this server
 func (s *Server) AppOptions() []AppOption {
 	return []AppOption{
@@ -302,11 +377,27 @@ func (s *Server) StopHTTPServer() {
 func (s *Server) Shutdown() error {
 	mlog.Info("Stopping Server...")
 
+	atomic.StoreInt32(&s.draining, 1)
+
 	s.RunOldAppShutdown()
 
 	s.StopHTTPServer()
 	s.WaitForGoroutines()
 
+	s.eventFirehoseLock.Lock()
+	if s.eventFirehose != nil {
+		s.eventFirehose.stop()
+		s.eventFirehose = nil
+	}
+	s.eventFirehoseLock.Unlock()
+
+	s.tracerLock.Lock()
+	if s.tracer != nil {
+		s.tracer.Stop()
+		s.tracer = nil
+	}
+	s.tracerLock.Unlock()
+
 	if s.Store != nil {
 		s.Store.Close()
 	}
@@ -317,9 +408,20 @@ func (s *Server) Shutdown() error {
 
 	s.RemoveConfigListener(s.configListenerId)
 	s.RemoveConfigListener(s.logListenerId)
+	s.RemoveConfigListener(s.corsListenerId)
+	s.RemoveConfigListener(s.searchRateLimitListenerId)
+	s.RemoveConfigListener(s.dataLossPreventionListenerId)
 
 	s.DisableConfigWatch()
 
+	if s.clusterPresenceTask != nil {
+		s.clusterPresenceTask.Cancel()
+	}
+
+	if s.loadSheddingTask != nil {
+		s.loadSheddingTask.Cancel()
+	}
+
 	if s.Cluster != nil {
 		s.Cluster.StopInterNodeCommunication()
 	}
@@ -360,15 +462,6 @@ func (s *Server) WaitForGoroutines() {
 	}
 }
 
-var corsAllowedMethods = []string{
-	"POST",
-	"GET",
-	"OPTIONS",
-	"PUT",
-	"PATCH",
-	"DELETE",
-}
-
 type RecoveryLogger struct {
 }
 
@@ -396,31 +489,17 @@ func stripPort(hostport string) string {
 	return net.JoinHostPort(host, "443")
 }
 
+// RealClientIpAddress returns the IP address of the client that made a request, looking past the trusted proxies in
+// ServiceSettings.TrustedProxies.
+func (s *Server) RealClientIpAddress(r *http.Request) string {
+	settings := s.Config().ServiceSettings
+	return utils.GetRealClientIpAddress(r, *settings.TrustedProxyIPHeader, settings.TrustedProxies)
+}
+
 func (s *Server) Start() error {
 	mlog.Info("Starting Server...")
 
-	var handler http.Handler = s.RootRouter
-	if allowedOrigins := *s.Config().ServiceSettings.AllowCorsFrom; allowedOrigins != "" {
-		exposedCorsHeaders := *s.Config().ServiceSettings.CorsExposedHeaders
-		allowCredentials := *s.Config().ServiceSettings.CorsAllowCredentials
-		debug := *s.Config().ServiceSettings.CorsDebug
-		corsWrapper := cors.New(cors.Options{
-			AllowedOrigins:   strings.Fields(allowedOrigins),
-			AllowedMethods:   corsAllowedMethods,
-			AllowedHeaders:   []string{"*"},
-			ExposedHeaders:   strings.Fields(exposedCorsHeaders),
-			MaxAge:           86400,
-			AllowCredentials: allowCredentials,
-			Debug:            debug,
-		})
-
-		// If we have debugging of CORS turned on then forward messages to logs
-		if debug {
-			corsWrapper.Log = s.Log.StdLog(mlog.String("source", "cors"))
-		}
-
-		handler = corsWrapper.Handler(handler)
-	}
+	var handler http.Handler = s.newCorsHandler(s.RootRouter)
 
 	if *s.Config().RateLimitSettings.Enable {
 		mlog.Info("RateLimiter is enabled")
@@ -430,6 +509,14 @@ func (s *Server) Start() error {
 			return err
 		}
 
+		rateLimiter.clientIpAddress = s.RealClientIpAddress
+		rateLimiter.config = s.Config
+		rateLimiter.sessionUserId = func(token string) string {
+			if session, err := s.FakeApp().GetSession(token); err == nil {
+				return session.UserId
+			}
+			return ""
+		}
 		s.RateLimiter = rateLimiter
 		handler = rateLimiter.RateLimitHandler(handler)
 	}
@@ -450,6 +537,14 @@ func (s *Server) Start() error {
 		}
 	}
 
+	var clientCAs *x509.CertPool
+	if *s.Config().ServiceSettings.ConnectionSecurity == model.CONN_SECURITY_TLS && *s.Config().ServiceSettings.ClientCertAuth {
+		var err error
+		if clientCAs, err = loadClientCertCAs(*s.Config().ServiceSettings.ClientCertAuthCAFile); err != nil {
+			return err
+		}
+	}
+
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
 		errors.Wrapf(err, utils.T("api.server.start_server.starting.critical"), err)
@@ -569,6 +664,12 @@ func (s *Server) Start() error {
 				keyFile = *s.Config().ServiceSettings.TLSKeyFile
 			}
 
+			// Client certificates are verified if given, leaving it to each request whether one is needed
+			if clientCAs != nil {
+				tlsConfig.ClientCAs = clientCAs
+				tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+			}
+
 			s.Server.TLSConfig = tlsConfig
 			err = s.Server.ServeTLS(listener, certFile, keyFile)
 		} else {
@@ -586,19 +687,30 @@ func (s *Server) Start() error {
 	return nil
 }
 
-func (a *App) OriginChecker() func(*http.Request) bool {
-	if allowed := *a.Config().ServiceSettings.AllowCorsFrom; allowed != "" {
-		if allowed != "*" {
-			siteURL, err := url.Parse(*a.Config().ServiceSettings.SiteURL)
-			if err == nil {
-				siteURL.Path = ""
-				allowed += " " + siteURL.String()
-			}
+// CheckWebSocketOrigin returns whether a websocket connection may be made from the origin of a request. Unlike other
+// requests, these are only allowed from the site itself and ServiceSettings.WebsocketAllowedOrigins, since they're
+// long-lived and bypass some of the checks that other requests go through.
+func (a *App) CheckWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// Native clients, such as the mobile apps, don't send an origin
+		return true
+	}
+
+	if originURL, err := url.Parse(origin); err == nil {
+		if strings.EqualFold(originURL.Host, r.Host) {
+			return true
 		}
 
-		return utils.OriginChecker(allowed)
+		if siteURL, err := url.Parse(*a.Config().ServiceSettings.SiteURL); err == nil && siteURL.Host != "" && strings.EqualFold(originURL.Scheme, siteURL.Scheme) && strings.EqualFold(originURL.Host, siteURL.Host) {
+			return true
+		}
 	}
-	return nil
+
+	if allowedOrigins := a.Config().ServiceSettings.WebsocketAllowedOrigins; len(allowedOrigins) != 0 {
+		return utils.CheckOrigin(r, strings.Join(allowedOrigins, " "))
+	}
+	return false
 }
 
 func runSecurityJob(s *Server) {
@@ -629,6 +741,13 @@ func runCommandWebhookCleanupJob(s *Server) {
 	}, time.Hour*1)
 }
 
+func runIdempotentRequestCleanupJob(s *Server) {
+	doIdempotentRequestCleanup(s)
+	model.CreateRecurringTask("Idempotent Request Cleanup", func() {
+		doIdempotentRequestCleanup(s)
+	}, time.Hour*1)
+}
+
 func runSessionCleanupJob(s *Server) {
 	doSessionCleanup(s)
 	model.CreateRecurringTask("Session Cleanup", func() {
@@ -654,6 +773,10 @@ func doCommandWebhookCleanup(s *Server) {
 	s.Store.CommandWebhook().Cleanup()
 }
 
+func doIdempotentRequestCleanup(s *Server) {
+	s.Store.IdempotentRequest().Cleanup()
+}
+
 const (
 	SESSIONS_CLEANUP_BATCH_SIZE = 1000
 )
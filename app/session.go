@@ -29,8 +29,10 @@ func (a *App) GetSession(token string) (*model.Session, *model.AppError) {
 	metrics := a.Metrics
 
 	var session *model.Session
+	fromCache := false
 	if ts, ok := a.Srv.sessionCache.Get(token); ok {
 		session = ts.(*model.Session)
+		fromCache = true
 		if metrics != nil {
 			metrics.IncrementMemCacheHitCounterSession()
 		}
@@ -76,13 +78,15 @@ func (a *App) GetSession(token string) (*model.Session, *model.AppError) {
 		return nil, model.NewAppError("GetSession", "api.context.invalid_token.error", map[string]interface{}{"Token": token}, "", http.StatusUnauthorized)
 	}
 
-	license := a.License()
-	if *a.Config().ServiceSettings.SessionIdleTimeoutInMinutes > 0 &&
-		license != nil && *license.Features.Compliance &&
-		session != nil && !session.IsOAuth && !session.IsMobileApp() &&
-		session.Props[model.SESSION_PROP_TYPE] != model.SESSION_TYPE_USER_ACCESS_TOKEN {
+	if timeout := a.sessionIdleTimeout(session); timeout > 0 && model.GetMillis()-session.LastActivityAt > timeout {
+		// Activity on other servers in the cluster is only recorded in the database, so a cached session may be stale
+		if fromCache {
+			if result := <-a.Srv.Store.Session().Get(token); result.Err == nil {
+				session = result.Data.(*model.Session)
+				a.AddSessionToCache(session)
+			}
+		}
 
-		timeout := int64(*a.Config().ServiceSettings.SessionIdleTimeoutInMinutes) * 1000 * 60
 		if model.GetMillis()-session.LastActivityAt > timeout {
 			a.RevokeSessionById(session.Id)
 			return nil, model.NewAppError("GetSession", "api.context.invalid_token.error", map[string]interface{}{"Token": token}, "idle timeout", http.StatusUnauthorized)
@@ -92,6 +96,30 @@ func (a *App) GetSession(token string) (*model.Session, *model.AppError) {
 	return session, nil
 }
 
+// sessionIdleTimeout returns how long, in milliseconds, the given session may go without any activity before it
+// expires, or 0 if it doesn't expire from being idle. Mobile, OAuth and user access token sessions never do.
+func (a *App) sessionIdleTimeout(session *model.Session) int64 {
+	license := a.License()
+	if *a.Config().ServiceSettings.SessionIdleTimeoutInMinutes <= 0 ||
+		license == nil || !*license.Features.Compliance ||
+		session.IsOAuth || session.IsMobileApp() ||
+		session.Props[model.SESSION_PROP_TYPE] == model.SESSION_TYPE_USER_ACCESS_TOKEN {
+		return 0
+	}
+
+	return int64(*a.Config().ServiceSettings.SessionIdleTimeoutInMinutes) * 1000 * 60
+}
+
+// sessionActivityUpdateInterval returns how out of date the LastActivityAt of a session may get before it's updated.
+// Idle timeouts shorter than twice the usual interval shorten it so that sessions in use don't expire.
+func (a *App) sessionActivityUpdateInterval(session *model.Session) int64 {
+	interval := int64(model.SESSION_ACTIVITY_TIMEOUT)
+	if timeout := a.sessionIdleTimeout(session); timeout > 0 && timeout/2 < interval {
+		interval = timeout / 2
+	}
+	return interval
+}
+
 func (a *App) GetSessions(userId string) ([]*model.Session, *model.AppError) {
 	result := <-a.Srv.Store.Session().GetSessions(userId)
 	if result.Err != nil {
@@ -229,7 +257,21 @@ func (a *App) UpdateLastActivityAtIfNeeded(session model.Session) {
 
 	a.UpdateWebConnUserActivity(session, now)
 
-	if now-session.LastActivityAt < model.SESSION_ACTIVITY_TIMEOUT {
+	a.updateSessionLastActivityAtIfNeeded(session, now)
+}
+
+// RecordSessionActivity keeps a session that has an idle timeout from expiring while it's being used to make requests.
+// LastActivityAt is only written when it's out of date, so that every request doesn't need to update the database.
+func (a *App) RecordSessionActivity(session model.Session) {
+	if a.sessionIdleTimeout(&session) == 0 {
+		return
+	}
+
+	a.updateSessionLastActivityAtIfNeeded(session, model.GetMillis())
+}
+
+func (a *App) updateSessionLastActivityAtIfNeeded(session model.Session, now int64) {
+	if now-session.LastActivityAt < a.sessionActivityUpdateInterval(&session) {
 		return
 	}
 
@@ -319,6 +361,32 @@ func (a *App) createSessionForUserAccessToken(tokenString string) (*model.Sessio
 
 }
 
+// ValidateUserAccessTokenSession describes the user access token that the given session was created for. It doesn't
+// count as activity on the session.
+func (a *App) ValidateUserAccessTokenSession(session model.Session) (*model.UserAccessTokenValidation, *model.AppError) {
+	if session.Props[model.SESSION_PROP_TYPE] != model.SESSION_TYPE_USER_ACCESS_TOKEN {
+		return nil, model.NewAppError("ValidateUserAccessTokenSession", "app.user_access_token.validate.not_user_access_token.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	token, err := a.GetUserAccessToken(session.Props[model.SESSION_PROP_USER_ACCESS_TOKEN_ID], true)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.GetUser(token.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UserAccessTokenValidation{
+		TokenId:     token.Id,
+		IsActive:    token.IsActive && user.DeleteAt == 0,
+		Description: token.Description,
+		UserId:      user.Id,
+		Username:    user.Username,
+	}, nil
+}
+
 func (a *App) RevokeUserAccessToken(token *model.UserAccessToken) *model.AppError {
 	var session *model.Session
 	if result := <-a.Srv.Store.Session().Get(token.Token); result.Err == nil {
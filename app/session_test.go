@@ -139,3 +139,86 @@ func TestGetSessionIdleTimeoutInMinutes(t *testing.T) {
 	_, err = th.App.GetSession(session.Token)
 	assert.Nil(t, err)
 }
+
+func TestGetSessionIdleTimeoutWithStaleCache(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	th.App.SetLicense(model.NewTestLicense("compliance"))
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.SessionIdleTimeoutInMinutes = 5 })
+
+	session, err := th.App.CreateSession(&model.Session{UserId: model.NewId()})
+	require.Nil(t, err)
+
+	// The session was used on another server, so only the database has its latest activity
+	cached := session.DeepCopy()
+	cached.LastActivityAt = session.LastActivityAt - (1000 * 60 * 6)
+	th.App.AddSessionToCache(cached)
+
+	rsession, err := th.App.GetSession(session.Token)
+	require.Nil(t, err)
+	assert.Equal(t, session.LastActivityAt, rsession.LastActivityAt)
+}
+
+func TestRecordSessionActivity(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	th.App.SetLicense(model.NewTestLicense("compliance"))
+
+	createSession := func(lastActivityAt int64) *model.Session {
+		session, err := th.App.CreateSession(&model.Session{UserId: model.NewId()})
+		require.Nil(t, err)
+
+		<-th.App.Srv.Store.Session().UpdateLastActivityAt(session.Id, lastActivityAt)
+		session.LastActivityAt = lastActivityAt
+		return session
+	}
+
+	getLastActivityAt := func(session *model.Session) int64 {
+		result := <-th.App.Srv.Store.Session().Get(session.Token)
+		require.Nil(t, result.Err)
+		return result.Data.(*model.Session).LastActivityAt
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.SessionIdleTimeoutInMinutes = 0 })
+
+		lastActivityAt := model.GetMillis() - (1000 * 60 * 10)
+		session := createSession(lastActivityAt)
+
+		th.App.RecordSessionActivity(*session)
+		assert.Equal(t, lastActivityAt, getLastActivityAt(session))
+	})
+
+	t.Run("recently active", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.SessionIdleTimeoutInMinutes = 60 })
+
+		lastActivityAt := model.GetMillis() - (1000 * 60 * 1)
+		session := createSession(lastActivityAt)
+
+		th.App.RecordSessionActivity(*session)
+		assert.Equal(t, lastActivityAt, getLastActivityAt(session))
+	})
+
+	t.Run("out of date", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.SessionIdleTimeoutInMinutes = 60 })
+
+		lastActivityAt := model.GetMillis() - (1000 * 60 * 10)
+		session := createSession(lastActivityAt)
+
+		th.App.RecordSessionActivity(*session)
+		assert.True(t, getLastActivityAt(session) > lastActivityAt)
+	})
+
+	t.Run("short idle timeout", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.SessionIdleTimeoutInMinutes = 2 })
+
+		// Less than the usual update interval, but enough that the session would soon expire
+		lastActivityAt := model.GetMillis() - (1000 * 60 * 1.5)
+		session := createSession(lastActivityAt)
+
+		th.App.RecordSessionActivity(*session)
+		assert.True(t, getLastActivityAt(session) > lastActivityAt)
+	})
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"runtime"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+// GetSystemStatus returns a snapshot of the goroutines, memory, database connection pools and caches of this server.
+func (a *App) GetSystemStatus() *model.SystemStatus {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	caches := []*model.CacheStats{
+		namedCacheStats("Session", a.Srv.sessionCache),
+		namedCacheStats("Status", statusCache),
+		namedCacheStats("Link Metadata", linkCache),
+	}
+	caches = append(caches, a.Srv.Store.CacheStats()...)
+
+	return &model.SystemStatus{
+		Timestamp:  model.GetMillis(),
+		Goroutines: runtime.NumGoroutine(),
+		Memory: &model.MemoryStats{
+			Alloc:        memStats.Alloc,
+			TotalAlloc:   memStats.TotalAlloc,
+			Sys:          memStats.Sys,
+			HeapAlloc:    memStats.HeapAlloc,
+			HeapInuse:    memStats.HeapInuse,
+			HeapObjects:  memStats.HeapObjects,
+			NumGC:        memStats.NumGC,
+			PauseTotalNs: memStats.PauseTotalNs,
+		},
+		DbPools: a.Srv.Store.DbPoolStats(),
+		Caches:  caches,
+	}
+}
+
+func namedCacheStats(name string, cache *utils.Cache) *model.CacheStats {
+	stats := cache.Stats()
+	stats.Name = name
+	return stats
+}
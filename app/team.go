@@ -22,6 +22,10 @@ import (
 )
 
 func (a *App) CreateTeam(team *model.Team) (*model.Team, *model.AppError) {
+	if err := a.checkMaxPostSizeOverride(team.MaxPostSize); err != nil {
+		return nil, err
+	}
+
 	result := <-a.Srv.Store.Team().Save(team)
 	if result.Err != nil {
 		return nil, result.Err
@@ -123,6 +127,17 @@ func (a *App) UpdateTeam(team *model.Team) (*model.Team, *model.AppError) {
 	oldTeam.AllowedDomains = team.AllowedDomains
 	oldTeam.LastTeamIconUpdate = team.LastTeamIconUpdate
 
+	if team.MaxPostSize != oldTeam.MaxPostSize {
+		if err = a.checkMaxPostSizeOverride(team.MaxPostSize); err != nil {
+			return nil, err
+		}
+		oldTeam.MaxPostSize = team.MaxPostSize
+	}
+
+	oldTeam.ChannelNamePattern = team.ChannelNamePattern
+	oldTeam.ChannelDisplayNamePattern = team.ChannelDisplayNamePattern
+	oldTeam.InactiveChannelDays = team.InactiveChannelDays
+
 	oldTeam, err = a.updateTeamUnsanitized(oldTeam)
 	if err != nil {
 		return team, err
@@ -412,6 +427,10 @@ func (a *App) joinUserToTeam(team *model.Team, user *model.User) (*model.TeamMem
 	etmr := <-a.Srv.Store.Team().GetMember(team.Id, user.Id)
 	if etmr.Err != nil {
 		// Membership appears to be missing. Lets try to add.
+		if err := a.checkTeamMemberLimit(team); err != nil {
+			return nil, false, err
+		}
+
 		tmr := <-a.Srv.Store.Team().SaveMember(tm, *a.Config().TeamSettings.MaxUsersPerTeam)
 		if tmr.Err != nil {
 			return nil, false, tmr.Err
@@ -436,6 +455,10 @@ func (a *App) joinUserToTeam(team *model.Team, user *model.User) (*model.TeamMem
 		return nil, false, model.NewAppError("joinUserToTeam", "app.team.join_user_to_team.max_accounts.app_error", nil, "teamId="+tm.TeamId, http.StatusBadRequest)
 	}
 
+	if err := a.checkTeamMemberLimit(team); err != nil {
+		return nil, false, err
+	}
+
 	tmr := <-a.Srv.Store.Team().UpdateMember(tm)
 	if tmr.Err != nil {
 		return nil, false, tmr.Err
@@ -456,6 +479,8 @@ func (a *App) JoinUserToTeam(team *model.Team, user *model.User, userRequestorId
 		return nil
 	}
 
+	a.publishFirehoseEvent(model.FIREHOSE_EVENT_TEAM_MEMBER_ADDED, team.Id, "", user.Id, tm)
+
 	if pluginsEnvironment := a.GetPluginsEnvironment(); pluginsEnvironment != nil {
 		var actor *model.User
 		if userRequestorId != "" {
@@ -779,6 +804,8 @@ func (a *App) LeaveTeam(team *model.Team, user *model.User, requestorId string)
 		return result.Err
 	}
 
+	a.publishFirehoseEvent(model.FIREHOSE_EVENT_TEAM_MEMBER_REMOVED, team.Id, "", user.Id, teamMember)
+
 	if pluginsEnvironment := a.GetPluginsEnvironment(); pluginsEnvironment != nil {
 		var actor *model.User
 		if requestorId != "" {
@@ -810,6 +837,10 @@ func (a *App) LeaveTeam(team *model.Team, user *model.User, requestorId string)
 }
 
 func (a *App) postLeaveTeamMessage(user *model.User, channel *model.Channel) *model.AppError {
+	if channel.SuppressJoinLeaveMessages {
+		return nil
+	}
+
 	post := &model.Post{
 		ChannelId: channel.Id,
 		Message:   fmt.Sprintf(utils.T("api.team.leave.left"), user.Username),
@@ -828,6 +859,10 @@ func (a *App) postLeaveTeamMessage(user *model.User, channel *model.Channel) *mo
 }
 
 func (a *App) postRemoveFromTeamMessage(user *model.User, channel *model.Channel) *model.AppError {
+	if channel.SuppressJoinLeaveMessages {
+		return nil
+	}
+
 	post := &model.Post{
 		ChannelId: channel.Id,
 		Message:   fmt.Sprintf(utils.T("api.team.remove_user_from_team.removed"), user.Username),
@@ -0,0 +1,125 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const TEAM_ACCESS_GRAPH_PAGE_SIZE = 1000
+
+// ExportTeamAccessGraph writes the access graph of a team to w, one model.TeamAccessGraphEntry per line, and returns
+// how many entries were written. The members of the team come first, followed by every channel of the team, including
+// private and archived ones, each followed by its members. Members are read a page at a time and each page is flushed
+// as soon as it's written, so that large teams don't need to be buffered.
+func (a *App) ExportTeamAccessGraph(w io.Writer, teamId string) (int, *model.AppError) {
+	exporter := &teamAccessGraphExporter{w: w}
+
+	afterUserId := ""
+	for {
+		result := <-a.Srv.Store.Team().GetMembersAfter(teamId, afterUserId, TEAM_ACCESS_GRAPH_PAGE_SIZE)
+		if result.Err != nil {
+			return exporter.written, result.Err
+		}
+		members := result.Data.([]*model.TeamMember)
+		if len(members) == 0 {
+			break
+		}
+
+		for _, member := range members {
+			if err := exporter.write(&model.TeamAccessGraphEntry{Type: model.TEAM_ACCESS_GRAPH_ENTRY_TEAM_MEMBER, TeamMember: member}); err != nil {
+				return exporter.written, err
+			}
+		}
+		exporter.flush()
+
+		afterUserId = members[len(members)-1].UserId
+	}
+
+	result := <-a.Srv.Store.Channel().GetTeamChannels(teamId)
+	if result.Err != nil && result.Err.StatusCode != http.StatusNotFound {
+		return exporter.written, result.Err
+	}
+
+	var channels model.ChannelList
+	if result.Err == nil {
+		channels = *result.Data.(*model.ChannelList)
+	}
+
+	// Members are read in order of channel id, so the channels are listed in the same order to write each channel
+	// right before its members
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Id < channels[j].Id })
+
+	next := 0
+	writeChannelsUntil := func(channelId string) *model.AppError {
+		for next < len(channels) && channels[next].Id <= channelId {
+			if err := exporter.write(&model.TeamAccessGraphEntry{Type: model.TEAM_ACCESS_GRAPH_ENTRY_CHANNEL, Channel: channels[next]}); err != nil {
+				return err
+			}
+			next++
+		}
+		return nil
+	}
+
+	afterChannelId := ""
+	afterUserId = ""
+	for {
+		result := <-a.Srv.Store.Channel().GetMembersForTeamAfter(teamId, afterChannelId, afterUserId, TEAM_ACCESS_GRAPH_PAGE_SIZE)
+		if result.Err != nil {
+			return exporter.written, result.Err
+		}
+		members := *result.Data.(*model.ChannelMembers)
+		if len(members) == 0 {
+			break
+		}
+
+		for i := range members {
+			member := &members[i]
+			if member.ChannelId != afterChannelId {
+				if err := writeChannelsUntil(member.ChannelId); err != nil {
+					return exporter.written, err
+				}
+			}
+
+			if err := exporter.write(&model.TeamAccessGraphEntry{Type: model.TEAM_ACCESS_GRAPH_ENTRY_CHANNEL_MEMBER, ChannelMember: member}); err != nil {
+				return exporter.written, err
+			}
+			afterChannelId, afterUserId = member.ChannelId, member.UserId
+		}
+		exporter.flush()
+	}
+
+	// Channels that sort after the last one with members haven't been written yet
+	if next < len(channels) {
+		if err := writeChannelsUntil(channels[len(channels)-1].Id); err != nil {
+			return exporter.written, err
+		}
+		exporter.flush()
+	}
+
+	return exporter.written, nil
+}
+
+type teamAccessGraphExporter struct {
+	w       io.Writer
+	written int
+}
+
+func (e *teamAccessGraphExporter) write(entry *model.TeamAccessGraphEntry) *model.AppError {
+	if _, err := io.WriteString(e.w, entry.ToJson()+"\n"); err != nil {
+		return model.NewAppError("ExportTeamAccessGraph", "app.team.access_graph.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+	e.written++
+	return nil
+}
+
+func (e *teamAccessGraphExporter) flush() {
+	if flusher, ok := e.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
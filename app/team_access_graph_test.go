@@ -0,0 +1,58 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestExportTeamAccessGraph(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	emptyChannel := th.CreatePrivateChannel(th.BasicTeam)
+	require.Nil(t, th.App.RemoveUserFromChannel(th.BasicUser.Id, th.BasicUser.Id, emptyChannel))
+
+	var buf bytes.Buffer
+	exported, err := th.App.ExportTeamAccessGraph(&buf, th.BasicTeam.Id)
+	require.Nil(t, err)
+
+	entries := model.TeamAccessGraphFromJsonl(&buf)
+	require.Len(t, entries, exported)
+
+	teamMembers := map[string]bool{}
+	channels := map[string]bool{}
+	channelMembers := map[string][]string{}
+	currentChannelId := ""
+	for _, entry := range entries {
+		switch entry.Type {
+		case model.TEAM_ACCESS_GRAPH_ENTRY_TEAM_MEMBER:
+			assert.Empty(t, channels, "team members should come before channels")
+			teamMembers[entry.TeamMember.UserId] = true
+		case model.TEAM_ACCESS_GRAPH_ENTRY_CHANNEL:
+			assert.False(t, channels[entry.Channel.Id], "channels should only be listed once")
+			channels[entry.Channel.Id] = true
+			currentChannelId = entry.Channel.Id
+		case model.TEAM_ACCESS_GRAPH_ENTRY_CHANNEL_MEMBER:
+			assert.Equal(t, currentChannelId, entry.ChannelMember.ChannelId, "channel members should follow their channel")
+			assert.NotEmpty(t, entry.ChannelMember.Roles)
+			channelMembers[entry.ChannelMember.ChannelId] = append(channelMembers[entry.ChannelMember.ChannelId], entry.ChannelMember.UserId)
+		default:
+			assert.Fail(t, "unexpected entry type "+entry.Type)
+		}
+	}
+
+	assert.True(t, teamMembers[th.BasicUser.Id])
+	assert.True(t, teamMembers[th.BasicUser2.Id])
+	assert.True(t, channels[th.BasicChannel.Id])
+	assert.Contains(t, channelMembers[th.BasicChannel.Id], th.BasicUser.Id)
+	assert.True(t, channels[emptyChannel.Id], "channels without members should be listed")
+	assert.Empty(t, channelMembers[emptyChannel.Id])
+}
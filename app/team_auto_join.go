@@ -0,0 +1,55 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// SetTeamAutoJoin sets whether new users whose email matches a team's AllowedDomains join it automatically.
+func (a *App) SetTeamAutoJoin(teamId string, autoJoin bool) (*model.Team, *model.AppError) {
+	team, err := a.GetTeam(teamId)
+	if err != nil {
+		return nil, err
+	}
+
+	team.AutoJoin = autoJoin
+
+	team, err = a.updateTeamUnsanitized(team)
+	if err != nil {
+		return nil, err
+	}
+
+	a.sendTeamEvent(team, model.WEBSOCKET_EVENT_UPDATE_TEAM)
+
+	return team, nil
+}
+
+// AutoJoinTeamsByEmailDomain adds a new user to each team that has AutoJoin set and whose AllowedDomains, compared
+// case-insensitively, match the user's email. Joining a team also joins its default channels. Teams that the user
+// can't join, such as full ones, are skipped. The user's email must have been verified, or anyone could join a team by
+// signing up with an address at its domain.
+func (a *App) AutoJoinTeamsByEmailDomain(user *model.User) {
+	result := <-a.Srv.Store.Team().GetAutoJoinTeams()
+	if result.Err != nil {
+		mlog.Error("Failed to get the teams that users join by email domain", mlog.Err(result.Err))
+		return
+	}
+
+	for _, team := range result.Data.([]*model.Team) {
+		if !a.isTeamEmailAllowed(user, team) {
+			continue
+		}
+
+		if err := a.JoinUserToTeam(team, user, ""); err != nil {
+			mlog.Warn("Failed to add a user to a team by their email domain", mlog.String("team_id", team.Id), mlog.String("user_id", user.Id), mlog.Err(err))
+			continue
+		}
+
+		if err := a.AddDirectChannels(team.Id, user); err != nil {
+			mlog.Error(err.Error())
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestAutoJoinTeamsByEmailDomain(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	team := th.CreateTeam()
+	team.AllowedDomains = "Example.COM, @example.org"
+	team, err := th.App.UpdateTeam(team)
+	require.Nil(t, err)
+
+	team, err = th.App.SetTeamAutoJoin(team.Id, true)
+	require.Nil(t, err)
+	assert.True(t, team.AutoJoin)
+
+	createUser := func(domain string, emailVerified bool) *model.User {
+		id := model.NewId()
+		user, err := th.App.CreateUser(&model.User{
+			Email:         "success+" + id + "@" + domain,
+			Username:      "un_" + id,
+			Password:      "Password1",
+			EmailVerified: emailVerified,
+		})
+		require.Nil(t, err)
+		return user
+	}
+
+	isMember := func(user *model.User) bool {
+		member, err := th.App.GetTeamMember(team.Id, user.Id)
+		return err == nil && member.DeleteAt == 0
+	}
+
+	t.Run("matching users join the team and its default channels", func(t *testing.T) {
+		user := createUser("EXAMPLE.com", true)
+		assert.True(t, isMember(user))
+
+		channel, err := th.App.GetChannelByName(model.DEFAULT_CHANNEL, team.Id, false)
+		require.Nil(t, err)
+		_, err = th.App.GetChannelMember(channel.Id, user.Id)
+		assert.Nil(t, err)
+
+		assert.True(t, isMember(createUser("example.org", true)))
+	})
+
+	t.Run("other users don't join", func(t *testing.T) {
+		assert.False(t, isMember(createUser("example.net", true)))
+		assert.False(t, isMember(createUser("mail.example.com.evil", true)))
+	})
+
+	t.Run("users join once their email is verified", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.EmailSettings.RequireEmailVerification = true })
+		defer th.App.UpdateConfig(func(cfg *model.Config) { cfg.EmailSettings.RequireEmailVerification = false })
+
+		user := createUser("example.com", false)
+		assert.False(t, isMember(user))
+
+		token, err := th.App.CreateVerifyEmailToken(user.Id, user.Email)
+		require.Nil(t, err)
+		require.Nil(t, th.App.VerifyEmailFromToken(token.Token))
+		assert.True(t, isMember(user))
+	})
+
+	t.Run("unverified users don't join when verification isn't required", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) { cfg.EmailSettings.RequireEmailVerification = false })
+
+		user := createUser("example.com", false)
+		assert.False(t, isMember(user))
+
+		token, err := th.App.CreateVerifyEmailToken(user.Id, user.Email)
+		require.Nil(t, err)
+		require.Nil(t, th.App.VerifyEmailFromToken(token.Token))
+		assert.True(t, isMember(user))
+	})
+
+	t.Run("users don't join once auto join is turned off", func(t *testing.T) {
+		_, err := th.App.SetTeamAutoJoin(team.Id, false)
+		require.Nil(t, err)
+
+		assert.False(t, isMember(createUser("example.com", true)))
+	})
+}
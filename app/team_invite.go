@@ -0,0 +1,230 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// CreateTeamInvite creates an invite link to a team. The channels that it adds users to must be public or private
+// channels of the team whose members the creator of the invite is allowed to manage.
+func (a *App) CreateTeamInvite(invite *model.TeamInvite) (*model.TeamInvite, *model.AppError) {
+	team, err := a.GetTeam(invite.TeamId)
+	if err != nil {
+		return nil, err
+	}
+
+	if team.DeleteAt != 0 {
+		return nil, model.NewAppError("CreateTeamInvite", "app.team.create_team_invite.team_deleted.app_error", nil, "team_id="+team.Id, http.StatusBadRequest)
+	}
+
+	channelIds := model.StringArray{}
+	seen := make(map[string]bool, len(invite.ChannelIds))
+	for _, channelId := range invite.ChannelIds {
+		if seen[channelId] {
+			continue
+		}
+		seen[channelId] = true
+
+		channel, err := a.GetChannel(channelId)
+		if err != nil {
+			return nil, err
+		}
+
+		if channel.TeamId != team.Id || channel.DeleteAt != 0 || (channel.Type != model.CHANNEL_OPEN && channel.Type != model.CHANNEL_PRIVATE) {
+			return nil, model.NewAppError("CreateTeamInvite", "app.team.create_team_invite.channel.app_error", nil, "team_id="+team.Id+", channel_id="+channelId, http.StatusBadRequest)
+		}
+
+		if !a.HasPermissionToChannel(invite.CreatorId, channel.Id, teamInviteChannelPermission(channel)) {
+			return nil, model.NewAppError("CreateTeamInvite", "api.context.permissions.app_error", nil, "user_id="+invite.CreatorId+", channel_id="+channelId, http.StatusForbidden)
+		}
+
+		channelIds = append(channelIds, channel.Id)
+	}
+
+	invite.Id = ""
+	invite.CreateAt = 0
+	invite.DeleteAt = 0
+	invite.Uses = 0
+	invite.ChannelIds = channelIds
+
+	result := <-a.Srv.Store.Team().SaveInvite(invite)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	return result.Data.(*model.TeamInvite), nil
+}
+
+// GetTeamInvites returns the invite links to a team that haven't been revoked, along with how often they've been used.
+func (a *App) GetTeamInvites(teamId string) ([]*model.TeamInvite, *model.AppError) {
+	result := <-a.Srv.Store.Team().GetInvitesForTeam(teamId)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	return result.Data.([]*model.TeamInvite), nil
+}
+
+// RevokeTeamInvite stops an invite link to a team from working.
+func (a *App) RevokeTeamInvite(teamId string, inviteId string) *model.AppError {
+	result := <-a.Srv.Store.Team().GetInvite(inviteId)
+	if result.Err != nil {
+		return result.Err
+	}
+
+	if invite := result.Data.(*model.TeamInvite); invite.TeamId != teamId {
+		return model.NewAppError("RevokeTeamInvite", "store.sql_team.get_invite.missing.app_error", nil, "id="+inviteId, http.StatusNotFound)
+	}
+
+	if result := <-a.Srv.Store.Team().RevokeInvite(inviteId, model.GetMillis()); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+// AddTeamMemberByTeamInvite adds a user to a team with an invite link, counting it as a use of the invite, and then
+// adds them to the invite's channels and gives them its role. Users who are already in the team don't use the invite.
+func (a *App) AddTeamMemberByTeamInvite(inviteId string, userId string) (*model.TeamMember, *model.AppError) {
+	result := <-a.Srv.Store.Team().GetInvite(inviteId)
+	if result.Err != nil {
+		if result.Err.StatusCode == http.StatusNotFound {
+			return nil, model.NewAppError("AddTeamMemberByTeamInvite", "app.team.team_invite.invalid.app_error", nil, "id="+inviteId, http.StatusBadRequest)
+		}
+		return nil, result.Err
+	}
+	invite := result.Data.(*model.TeamInvite)
+
+	if member, err := a.GetTeamMember(invite.TeamId, userId); err == nil && member.DeleteAt == 0 {
+		return member, nil
+	}
+
+	result = <-a.Srv.Store.Team().UseInvite(invite.Id, model.GetMillis())
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	if !result.Data.(bool) {
+		// The invite may have been used up or revoked since it was read
+		if result := <-a.Srv.Store.Team().GetInvite(invite.Id); result.Err == nil {
+			invite = result.Data.(*model.TeamInvite)
+		}
+		return nil, teamInviteError(invite)
+	}
+
+	if _, err := a.AddUserToTeam(invite.TeamId, userId, ""); err != nil {
+		if result := <-a.Srv.Store.Team().ReleaseInvite(invite.Id); result.Err != nil {
+			mlog.Error("Failed to release an unused team invite", mlog.String("invite_id", invite.Id), mlog.Err(result.Err))
+		}
+		return nil, err
+	}
+
+	if invite.SchemeAdmin {
+		if _, err := a.UpdateTeamMemberSchemeRoles(invite.TeamId, userId, true, true); err != nil {
+			return nil, err
+		}
+	}
+
+	// Soft error if there is an issue joining the invite's channels, as with the team's default channels. Channels
+	// whose members the creator of the invite is no longer allowed to manage are skipped.
+	for _, channelId := range invite.ChannelIds {
+		channel, err := a.GetChannel(channelId)
+		if err != nil || channel.DeleteAt != 0 || channel.TeamId != invite.TeamId {
+			continue
+		}
+
+		if !a.HasPermissionToChannel(invite.CreatorId, channel.Id, teamInviteChannelPermission(channel)) {
+			mlog.Warn("Skipped a channel of a team invite that its creator can't add members to", mlog.String("invite_id", invite.Id), mlog.String("channel_id", channel.Id))
+			continue
+		}
+
+		if _, err := a.AddChannelMember(userId, channel, "", "", false); err != nil {
+			mlog.Warn("Failed to add a user to a channel of a team invite", mlog.String("invite_id", invite.Id), mlog.String("channel_id", channel.Id), mlog.String("user_id", userId), mlog.Err(err))
+		}
+	}
+
+	return a.GetTeamMember(invite.TeamId, userId)
+}
+
+// GetTeamInvitePreview returns what joining with an invite link would grant without using the invite. Nothing about the
+// team is returned unless the invite can be used.
+func (a *App) GetTeamInvitePreview(inviteId string) (*model.TeamInvitePreview, *model.AppError) {
+	result := <-a.Srv.Store.Team().GetInvite(inviteId)
+	if result.Err != nil {
+		if result.Err.StatusCode == http.StatusNotFound {
+			return nil, model.NewAppError("GetTeamInvitePreview", "app.team.team_invite.invalid.app_error", nil, "id="+inviteId, http.StatusBadRequest)
+		}
+		return nil, result.Err
+	}
+	invite := result.Data.(*model.TeamInvite)
+
+	if invite.DeleteAt != 0 || invite.IsExpired(model.GetMillis()) || invite.IsExhausted() {
+		return nil, teamInviteError(invite)
+	}
+
+	team, err := a.GetTeam(invite.TeamId)
+	if err != nil {
+		return nil, err
+	}
+
+	if team.DeleteAt != 0 {
+		return nil, model.NewAppError("GetTeamInvitePreview", "app.team.team_invite.invalid.app_error", nil, "id="+inviteId, http.StatusBadRequest)
+	}
+
+	preview := &model.TeamInvitePreview{
+		TeamId:          team.Id,
+		TeamName:        team.Name,
+		TeamDisplayName: team.DisplayName,
+		TeamDescription: team.Description,
+		ExpireAt:        invite.ExpireAt,
+		SchemeAdmin:     invite.SchemeAdmin,
+		Channels:        []*model.Channel{},
+	}
+
+	// Only the channels that joining would actually add the user to are listed
+	for _, channelId := range invite.ChannelIds {
+		channel, err := a.GetChannel(channelId)
+		if err != nil || channel.DeleteAt != 0 || channel.TeamId != team.Id {
+			continue
+		}
+
+		preview.Channels = append(preview.Channels, &model.Channel{
+			Id:          channel.Id,
+			TeamId:      channel.TeamId,
+			Type:        channel.Type,
+			Name:        channel.Name,
+			DisplayName: channel.DisplayName,
+			Purpose:     channel.Purpose,
+		})
+	}
+
+	return preview, nil
+}
+
+// teamInviteChannelPermission returns the permission needed to add members to a channel of a team invite.
+func teamInviteChannelPermission(channel *model.Channel) *model.Permission {
+	if channel.Type == model.CHANNEL_PRIVATE {
+		return model.PERMISSION_MANAGE_PRIVATE_CHANNEL_MEMBERS
+	}
+
+	return model.PERMISSION_MANAGE_PUBLIC_CHANNEL_MEMBERS
+}
+
+// teamInviteError returns the reason that an invite can't be used.
+func teamInviteError(invite *model.TeamInvite) *model.AppError {
+	id := "app.team.team_invite.invalid.app_error"
+	if invite.DeleteAt == 0 {
+		if invite.IsExpired(model.GetMillis()) {
+			id = "app.team.team_invite.expired.app_error"
+		} else if invite.IsExhausted() {
+			id = "app.team.team_invite.exhausted.app_error"
+		}
+	}
+
+	return model.NewAppError("AddTeamMemberByTeamInvite", id, nil, "id="+invite.Id, http.StatusBadRequest)
+}
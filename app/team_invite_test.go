@@ -0,0 +1,149 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestTeamInvite(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	team := th.CreateTeam()
+	channel := th.CreateChannel(team)
+	privateChannel := th.CreatePrivateChannel(team)
+
+	t.Run("channels must belong to the team", func(t *testing.T) {
+		_, err := th.App.CreateTeamInvite(&model.TeamInvite{TeamId: team.Id, CreatorId: th.BasicUser.Id, ChannelIds: model.StringArray{th.BasicChannel.Id}})
+		require.NotNil(t, err)
+		assert.Equal(t, "app.team.create_team_invite.channel.app_error", err.Id)
+	})
+
+	t.Run("the creator must be allowed to add members to the channels", func(t *testing.T) {
+		otherPrivateChannel := th.createChannelWithAnotherUser(team, model.CHANNEL_PRIVATE, th.BasicUser2.Id)
+
+		_, err := th.App.CreateTeamInvite(&model.TeamInvite{TeamId: team.Id, CreatorId: th.BasicUser.Id, ChannelIds: model.StringArray{otherPrivateChannel.Id}})
+		require.NotNil(t, err)
+		assert.Equal(t, http.StatusForbidden, err.StatusCode)
+	})
+
+	invite, err := th.App.CreateTeamInvite(&model.TeamInvite{
+		TeamId:      team.Id,
+		CreatorId:   th.BasicUser.Id,
+		MaxUses:     1,
+		Uses:        5,
+		ChannelIds:  model.StringArray{channel.Id, privateChannel.Id, channel.Id},
+		SchemeAdmin: true,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, 0, invite.Uses)
+	assert.Equal(t, model.StringArray{channel.Id, privateChannel.Id}, invite.ChannelIds)
+
+	t.Run("joining adds the user to the channels and role", func(t *testing.T) {
+		member, err := th.App.AddTeamMemberByTeamInvite(invite.Id, th.BasicUser2.Id)
+		require.Nil(t, err)
+		assert.True(t, member.SchemeAdmin)
+
+		_, err = th.App.GetChannelMember(channel.Id, th.BasicUser2.Id)
+		assert.Nil(t, err)
+		_, err = th.App.GetChannelMember(privateChannel.Id, th.BasicUser2.Id)
+		assert.Nil(t, err)
+	})
+
+	t.Run("members joining again don't use the invite", func(t *testing.T) {
+		_, err := th.App.AddTeamMemberByTeamInvite(invite.Id, th.BasicUser2.Id)
+		require.Nil(t, err)
+
+		invites, err := th.App.GetTeamInvites(team.Id)
+		require.Nil(t, err)
+		require.Len(t, invites, 1)
+		assert.Equal(t, 1, invites[0].Uses)
+	})
+
+	t.Run("exhausted invites can't be used", func(t *testing.T) {
+		user := th.CreateUser()
+		_, err := th.App.AddTeamMemberByTeamInvite(invite.Id, user.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, "app.team.team_invite.exhausted.app_error", err.Id)
+	})
+
+	t.Run("expired invites can't be used", func(t *testing.T) {
+		expiring, err := th.App.CreateTeamInvite(&model.TeamInvite{TeamId: team.Id, CreatorId: th.BasicUser.Id, ExpireAt: model.GetMillis() + 100})
+		require.Nil(t, err)
+
+		result := <-th.App.Srv.Store.Team().UseInvite(expiring.Id, expiring.ExpireAt)
+		require.Nil(t, result.Err)
+		assert.False(t, result.Data.(bool))
+
+		expiring.ExpireAt = expiring.CreateAt - 1
+		assert.Equal(t, "app.team.team_invite.expired.app_error", teamInviteError(expiring).Id)
+	})
+
+	t.Run("revoked invites can't be used", func(t *testing.T) {
+		revoked, err := th.App.CreateTeamInvite(&model.TeamInvite{TeamId: team.Id, CreatorId: th.BasicUser.Id})
+		require.Nil(t, err)
+
+		err = th.App.RevokeTeamInvite(th.BasicTeam.Id, revoked.Id)
+		require.NotNil(t, err)
+
+		require.Nil(t, th.App.RevokeTeamInvite(team.Id, revoked.Id))
+
+		user := th.CreateUser()
+		_, err = th.App.AddTeamMemberByTeamInvite(revoked.Id, user.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, "app.team.team_invite.invalid.app_error", err.Id)
+
+		_, err = th.App.AddTeamMemberByTeamInvite(model.NewId(), user.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, "app.team.team_invite.invalid.app_error", err.Id)
+	})
+}
+
+func TestGetTeamInvitePreview(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	team := th.CreateTeam()
+	channel := th.CreatePrivateChannel(team)
+	deletedChannel := th.CreateChannel(team)
+
+	invite, err := th.App.CreateTeamInvite(&model.TeamInvite{TeamId: team.Id, CreatorId: th.BasicUser.Id, MaxUses: 1, ChannelIds: model.StringArray{channel.Id, deletedChannel.Id}})
+	require.Nil(t, err)
+	require.Nil(t, th.App.DeleteChannel(deletedChannel, th.BasicUser.Id))
+
+	preview, err := th.App.GetTeamInvitePreview(invite.Id)
+	require.Nil(t, err)
+	assert.Equal(t, team.Id, preview.TeamId)
+	assert.Equal(t, team.DisplayName, preview.TeamDisplayName)
+	require.Len(t, preview.Channels, 1)
+	assert.Equal(t, channel.Id, preview.Channels[0].Id)
+
+	t.Run("previewing doesn't use the invite", func(t *testing.T) {
+		invites, err := th.App.GetTeamInvites(team.Id)
+		require.Nil(t, err)
+		require.Len(t, invites, 1)
+		assert.Equal(t, 0, invites[0].Uses)
+	})
+
+	t.Run("unusable invites reveal nothing", func(t *testing.T) {
+		_, err := th.App.AddTeamMemberByTeamInvite(invite.Id, th.BasicUser2.Id)
+		require.Nil(t, err)
+
+		preview, err := th.App.GetTeamInvitePreview(invite.Id)
+		require.NotNil(t, err)
+		assert.Nil(t, preview)
+		assert.Equal(t, "app.team.team_invite.exhausted.app_error", err.Id)
+
+		_, err = th.App.GetTeamInvitePreview(model.NewId())
+		require.NotNil(t, err)
+		assert.Equal(t, "app.team.team_invite.invalid.app_error", err.Id)
+	})
+}
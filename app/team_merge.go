@@ -0,0 +1,403 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const (
+	AUDIT_ACTION_TEAM_MERGE_MEMBER_ADDED   = "team_merge_member_added"
+	AUDIT_ACTION_TEAM_MERGE_CHANNEL_MOVED  = "team_merge_channel_moved"
+	AUDIT_ACTION_TEAM_MERGE_CHANNEL_MERGED = "team_merge_channel_merged"
+	AUDIT_ACTION_TEAM_MERGE_COMMAND_MOVED  = "team_merge_command_moved"
+	AUDIT_ACTION_TEAM_MERGE_WEBHOOK_MOVED  = "team_merge_webhook_moved"
+)
+
+// PlanTeamMerge works out what merging a team into the target team of the request would do, without changing
+// anything, so that its conflicts can be reviewed before the merge is started with CreateMergeTeamsJob.
+func (a *App) PlanTeamMerge(sourceTeamId string, request *model.TeamMergeRequest) (*model.TeamMergeReport, *model.AppError) {
+	if sourceTeamId == request.TargetTeamId {
+		return nil, model.NewAppError("PlanTeamMerge", "app.team.merge_teams.same_team.app_error", nil, "team_id="+sourceTeamId, http.StatusBadRequest)
+	}
+
+	for _, teamId := range []string{sourceTeamId, request.TargetTeamId} {
+		team, err := a.GetTeam(teamId)
+		if err != nil {
+			return nil, err
+		}
+
+		if team.DeleteAt != 0 {
+			return nil, model.NewAppError("PlanTeamMerge", "app.team.merge_teams.team_deleted.app_error", nil, "team_id="+teamId, http.StatusBadRequest)
+		}
+	}
+
+	report := &model.TeamMergeReport{
+		SourceTeamId:     sourceTeamId,
+		TargetTeamId:     request.TargetTeamId,
+		DryRun:           true,
+		NewMembers:       []string{},
+		Channels:         []*model.TeamMergeChannel{},
+		MovedCommands:    []string{},
+		CommandConflicts: []string{},
+	}
+
+	if err := a.planTeamMergeMembers(report); err != nil {
+		return nil, err
+	}
+
+	if err := a.planTeamMergeChannels(report, request.ChannelCollisionPolicy); err != nil {
+		return nil, err
+	}
+
+	if err := a.planTeamMergeCommands(report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (a *App) planTeamMergeMembers(report *model.TeamMergeReport) *model.AppError {
+	sourceMembers, err := a.GetTeamMembers(report.SourceTeamId, 0, 10000000)
+	if err != nil {
+		return err
+	}
+
+	if len(sourceMembers) == 0 {
+		return nil
+	}
+
+	userIds := make([]string, 0, len(sourceMembers))
+	for _, member := range sourceMembers {
+		userIds = append(userIds, member.UserId)
+	}
+
+	targetMembers, err := a.GetTeamMembersByIds(report.TargetTeamId, userIds)
+	if err != nil {
+		return err
+	}
+
+	inTarget := make(map[string]bool, len(targetMembers))
+	for _, member := range targetMembers {
+		inTarget[member.UserId] = true
+	}
+
+	for _, userId := range userIds {
+		if inTarget[userId] {
+			report.ExistingMembers++
+		} else {
+			report.NewMembers = append(report.NewMembers, userId)
+		}
+	}
+
+	if len(report.NewMembers) == 0 {
+		return nil
+	}
+
+	result := <-a.Srv.Store.Team().GetActiveMemberCount(report.TargetTeamId)
+	if result.Err != nil {
+		return result.Err
+	}
+
+	if maxUsers := int64(*a.Config().TeamSettings.MaxUsersPerTeam); result.Data.(int64)+int64(len(report.NewMembers)) > maxUsers {
+		return model.NewAppError("PlanTeamMerge", "app.team.merge_teams.max_users.app_error", map[string]interface{}{"MaxUsersPerTeam": maxUsers}, "team_id="+report.TargetTeamId, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// planTeamMergeChannels decides what to do with each of the channels of the source team. Channels are moved to the
+// target team unless it already has a channel with the same name, in which case they're renamed or merged into that
+// channel according to the policy. The default channels of the teams are always merged, since neither can be renamed,
+// but channels are never merged into one of a different type, which could expose the posts of a private channel, or
+// into an archived channel. Archived channels are left in the source team.
+func (a *App) planTeamMergeChannels(report *model.TeamMergeReport, policy string) *model.AppError {
+	result := <-a.Srv.Store.Channel().GetTeamChannels(report.SourceTeamId)
+	if result.Err != nil {
+		if result.Err.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return result.Err
+	}
+
+	for _, channel := range *result.Data.(*model.ChannelList) {
+		if channel.Type != model.CHANNEL_OPEN && channel.Type != model.CHANNEL_PRIVATE {
+			continue
+		}
+
+		planned := &model.TeamMergeChannel{
+			ChannelId:  channel.Id,
+			Name:       channel.Name,
+			Resolution: model.TEAM_MERGE_RESOLUTION_MOVE,
+		}
+		report.Channels = append(report.Channels, planned)
+
+		if channel.DeleteAt != 0 {
+			planned.Resolution = model.TEAM_MERGE_RESOLUTION_SKIP
+			planned.Reason = model.TEAM_MERGE_REASON_ARCHIVED
+			continue
+		}
+
+		existing, err := a.GetChannelByName(channel.Name, report.TargetTeamId, true)
+		if err != nil {
+			if err.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return err
+		}
+		planned.TargetChannelId = existing.Id
+
+		switch {
+		case channel.Name == model.DEFAULT_CHANNEL:
+			planned.Resolution = model.TEAM_MERGE_RESOLUTION_MERGE
+			planned.Reason = model.TEAM_MERGE_REASON_DEFAULT_CHANNEL
+		case existing.DeleteAt != 0:
+			planned.Resolution = model.TEAM_MERGE_RESOLUTION_RENAME
+			planned.Reason = model.TEAM_MERGE_REASON_TARGET_ARCHIVED
+		case policy == model.TEAM_MERGE_CHANNEL_COLLISION_MERGE && existing.Type != channel.Type:
+			planned.Resolution = model.TEAM_MERGE_RESOLUTION_RENAME
+			planned.Reason = model.TEAM_MERGE_REASON_TYPE_MISMATCH
+		case policy == model.TEAM_MERGE_CHANNEL_COLLISION_MERGE:
+			planned.Resolution = model.TEAM_MERGE_RESOLUTION_MERGE
+			planned.Reason = model.TEAM_MERGE_REASON_NAME_TAKEN
+		default:
+			planned.Resolution = model.TEAM_MERGE_RESOLUTION_RENAME
+			planned.Reason = model.TEAM_MERGE_REASON_NAME_TAKEN
+		}
+
+		if planned.Resolution == model.TEAM_MERGE_RESOLUTION_RENAME {
+			planned.NewName = channelNameWithId(channel)
+		}
+	}
+
+	return nil
+}
+
+// planTeamMergeCommands works out which slash commands can be moved to the target team, which are those whose
+// triggers it doesn't already use.
+func (a *App) planTeamMergeCommands(report *model.TeamMergeReport) *model.AppError {
+	result := <-a.Srv.Store.Command().GetByTeam(report.TargetTeamId)
+	if result.Err != nil {
+		return result.Err
+	}
+
+	triggers := make(map[string]bool)
+	for _, command := range result.Data.([]*model.Command) {
+		triggers[command.Trigger] = true
+	}
+
+	result = <-a.Srv.Store.Command().GetByTeam(report.SourceTeamId)
+	if result.Err != nil {
+		return result.Err
+	}
+
+	for _, command := range result.Data.([]*model.Command) {
+		if triggers[command.Trigger] {
+			report.CommandConflicts = append(report.CommandConflicts, command.Trigger)
+		} else {
+			report.MovedCommands = append(report.MovedCommands, command.Trigger)
+		}
+	}
+
+	return nil
+}
+
+// CreateMergeTeamsJob checks that a team can be merged into the target team of the request and enqueues a job that
+// merges them. See MergeTeams.
+func (a *App) CreateMergeTeamsJob(sourceTeamId string, request *model.TeamMergeRequest, userId string) (*model.Job, *model.AppError) {
+	if _, err := a.PlanTeamMerge(sourceTeamId, request); err != nil {
+		return nil, err
+	}
+
+	data := map[string]string{
+		"source_team_id":           sourceTeamId,
+		"target_team_id":           request.TargetTeamId,
+		"user_id":                  userId,
+		"channel_collision_policy": request.ChannelCollisionPolicy,
+	}
+
+	return a.Srv.Jobs.CreateJob(model.JOB_TYPE_MERGE_TEAMS, data)
+}
+
+// MergeTeams merges a team into the target team of the request as planned by PlanTeamMerge. The members of the source
+// team are added to the target team, its channels are moved, renamed or merged, and its slash commands and team-wide
+// outgoing webhooks are moved, with each change being audited. The source team and its memberships are left as they
+// are so that it can be archived once the merge has been checked. Since the merge is planned again each time, a merge
+// that failed part way through can be finished by running it again. The progress is reported as a percentage through
+// onProgress.
+func (a *App) MergeTeams(sourceTeamId string, request *model.TeamMergeRequest, userId string, onProgress func(progress int64)) (*model.TeamMergeReport, *model.AppError) {
+	report, err := a.PlanTeamMerge(sourceTeamId, request)
+	if err != nil {
+		return nil, err
+	}
+	report.DryRun = false
+
+	user, err := a.GetUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	onProgress(5)
+
+	for i, memberId := range report.NewMembers {
+		if _, err := a.AddTeamMember(report.TargetTeamId, memberId); err != nil {
+			return nil, err
+		}
+		a.saveTeamMergeAudit(user.Id, AUDIT_ACTION_TEAM_MERGE_MEMBER_ADDED, fmt.Sprintf("source_team_id=%v target_team_id=%v member_id=%v", report.SourceTeamId, report.TargetTeamId, memberId))
+
+		onProgress(5 + int64(25*(i+1)/len(report.NewMembers)))
+	}
+
+	for i, planned := range report.Channels {
+		switch planned.Resolution {
+		case model.TEAM_MERGE_RESOLUTION_MOVE, model.TEAM_MERGE_RESOLUTION_RENAME:
+			// Members of the channel who have since left the source team aren't in the target team either
+			channel, _, err := a.MoveChannelToTeam(planned.ChannelId, report.TargetTeamId, user.Id, true, func(int64) {})
+			if err != nil {
+				return nil, err
+			}
+			a.saveTeamMergeAudit(user.Id, AUDIT_ACTION_TEAM_MERGE_CHANNEL_MOVED, fmt.Sprintf("source_team_id=%v target_team_id=%v channel_id=%v name=%v new_name=%v", report.SourceTeamId, report.TargetTeamId, channel.Id, planned.Name, channel.Name))
+		case model.TEAM_MERGE_RESOLUTION_MERGE:
+			count, err := a.mergeChannelInto(planned.ChannelId, planned.TargetChannelId, user)
+			if err != nil {
+				return nil, err
+			}
+			a.saveTeamMergeAudit(user.Id, AUDIT_ACTION_TEAM_MERGE_CHANNEL_MERGED, fmt.Sprintf("source_team_id=%v target_team_id=%v channel_id=%v target_channel_id=%v posts=%v", report.SourceTeamId, report.TargetTeamId, planned.ChannelId, planned.TargetChannelId, count))
+		}
+
+		onProgress(30 + int64(60*(i+1)/len(report.Channels)))
+	}
+
+	if err := a.moveTeamIntegrations(report, user); err != nil {
+		return nil, err
+	}
+
+	onProgress(100)
+
+	return report, nil
+}
+
+// mergeChannelInto adds the members of a channel to another, which may be in another team, and moves its posts and
+// webhooks into it before archiving it. The number of posts moved is returned.
+func (a *App) mergeChannelInto(channelId string, targetChannelId string, user *model.User) (int64, *model.AppError) {
+	channel, err := a.GetChannel(channelId)
+	if err != nil {
+		return 0, err
+	}
+
+	target, err := a.GetChannel(targetChannelId)
+	if err != nil {
+		return 0, err
+	}
+
+	members, err := a.GetChannelMembersPage(channel.Id, 0, 10000000)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, member := range *members {
+		// Members who have been deactivated, or who have left the team, can't be added and are left behind
+		if _, err := a.AddChannelMember(member.UserId, target, user.Id, "", false); err != nil {
+			mlog.Warn("Failed to add a member of a merged channel", mlog.String("user_id", member.UserId), mlog.String("channel_id", target.Id), mlog.Err(err))
+		}
+	}
+
+	result := <-a.Srv.Store.Channel().MergeInto(channel, target)
+	if result.Err != nil {
+		return 0, result.Err
+	}
+	count := result.Data.(int64)
+
+	// As with moved channels, the files stay where they were stored but count towards the quota of the target team
+	if channel.TeamId != target.TeamId {
+		if result := <-a.Srv.Store.FileInfo().GetStorageSizeForChannel(channel.Id); result.Err != nil {
+			mlog.Warn("Failed to get the storage used by a merged channel", mlog.String("channel_id", channel.Id), mlog.Err(result.Err))
+		} else {
+			size := result.Data.(int64)
+			a.addTeamStorageUsage(channel.TeamId, -size)
+			a.addTeamStorageUsage(target.TeamId, size)
+		}
+	}
+
+	a.InvalidateCacheForChannelPosts(channel.Id)
+	a.InvalidateCacheForChannelPosts(target.Id)
+	a.InvalidateCacheForChannel(target)
+	a.Srv.Store.Webhook().ClearCaches()
+
+	// The default channel can't be archived, so it's left empty instead
+	if channel.Name != model.DEFAULT_CHANNEL {
+		if err := a.DeleteChannel(channel, user.Id); err != nil {
+			return count, err
+		}
+	}
+
+	if a.Elasticsearch != nil && *a.Config().ElasticsearchSettings.EnableIndexing {
+		if _, err := a.CreateElasticsearchReindexJob("", target.Id); err != nil {
+			mlog.Error("Failed to reindex a merged channel", mlog.String("channel_id", target.Id), mlog.Err(err))
+		}
+	}
+
+	return count, nil
+}
+
+// moveTeamIntegrations moves the slash commands and the outgoing webhooks that aren't limited to a channel from the
+// source team of a merge to the target team. Commands whose triggers are already used by the target team are left
+// behind.
+func (a *App) moveTeamIntegrations(report *model.TeamMergeReport, user *model.User) *model.AppError {
+	moved := make(map[string]bool, len(report.MovedCommands))
+	for _, trigger := range report.MovedCommands {
+		moved[trigger] = true
+	}
+
+	result := <-a.Srv.Store.Command().GetByTeam(report.SourceTeamId)
+	if result.Err != nil {
+		return result.Err
+	}
+
+	for _, command := range result.Data.([]*model.Command) {
+		if !moved[command.Trigger] {
+			continue
+		}
+
+		command.TeamId = report.TargetTeamId
+		if result := <-a.Srv.Store.Command().Update(command); result.Err != nil {
+			return result.Err
+		}
+		a.saveTeamMergeAudit(user.Id, AUDIT_ACTION_TEAM_MERGE_COMMAND_MOVED, fmt.Sprintf("source_team_id=%v target_team_id=%v command_id=%v trigger=%v", report.SourceTeamId, report.TargetTeamId, command.Id, command.Trigger))
+	}
+
+	result = <-a.Srv.Store.Webhook().GetOutgoingByTeam(report.SourceTeamId, -1, -1)
+	if result.Err != nil {
+		return result.Err
+	}
+
+	for _, hook := range result.Data.([]*model.OutgoingWebhook) {
+		if hook.ChannelId != "" {
+			continue
+		}
+
+		hook.TeamId = report.TargetTeamId
+		if result := <-a.Srv.Store.Webhook().UpdateOutgoing(hook); result.Err != nil {
+			return result.Err
+		}
+		a.saveTeamMergeAudit(user.Id, AUDIT_ACTION_TEAM_MERGE_WEBHOOK_MOVED, fmt.Sprintf("source_team_id=%v target_team_id=%v webhook_id=%v", report.SourceTeamId, report.TargetTeamId, hook.Id))
+	}
+
+	return nil
+}
+
+func (a *App) saveTeamMergeAudit(userId string, action string, extraInfo string) {
+	audit := &model.Audit{
+		UserId:    userId,
+		Action:    action,
+		ExtraInfo: extraInfo,
+	}
+	if result := <-a.Srv.Store.Audit().Save(audit); result.Err != nil {
+		mlog.Error("Failed to save team merge audit", mlog.String("action", action), mlog.Err(result.Err))
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/store"
+)
+
+func TestMergeTeams(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	source := th.BasicTeam
+	target := th.CreateTeam()
+
+	// BasicChannel's name is taken by a channel of the same type, and private's by one of a different type
+	taken := th.CreateChannel(target)
+	taken.Name = th.BasicChannel.Name
+	_, err := th.App.UpdateChannel(taken)
+	require.Nil(t, err)
+
+	private := th.CreatePrivateChannel(source)
+	mismatched := th.CreateChannel(target)
+	mismatched.Name = private.Name
+	_, err = th.App.UpdateChannel(mismatched)
+	require.Nil(t, err)
+
+	moved := th.CreateChannel(source)
+
+	archived := th.CreateChannel(source)
+	require.Nil(t, th.App.DeleteChannel(archived, th.BasicUser.Id))
+
+	command := store.Must(th.App.Srv.Store.Command().Save(&model.Command{CreatorId: th.BasicUser.Id, TeamId: source.Id, Trigger: "merged", Method: model.COMMAND_METHOD_POST, URL: "http://nowhere.com"})).(*model.Command)
+	store.Must(th.App.Srv.Store.Command().Save(&model.Command{CreatorId: th.BasicUser.Id, TeamId: source.Id, Trigger: "conflict", Method: model.COMMAND_METHOD_POST, URL: "http://nowhere.com"}))
+	store.Must(th.App.Srv.Store.Command().Save(&model.Command{CreatorId: th.BasicUser.Id, TeamId: target.Id, Trigger: "conflict", Method: model.COMMAND_METHOD_POST, URL: "http://nowhere.com"}))
+
+	resolutions := func(report *model.TeamMergeReport) map[string]string {
+		resolutions := make(map[string]string)
+		for _, channel := range report.Channels {
+			resolutions[channel.Name] = channel.Resolution + "/" + channel.Reason
+		}
+		return resolutions
+	}
+
+	t.Run("plan with the rename policy", func(t *testing.T) {
+		report, err := th.App.PlanTeamMerge(source.Id, &model.TeamMergeRequest{TargetTeamId: target.Id, ChannelCollisionPolicy: model.TEAM_MERGE_CHANNEL_COLLISION_RENAME})
+		require.Nil(t, err)
+
+		assert.True(t, report.DryRun)
+		assert.ElementsMatch(t, []string{th.BasicUser.Id, th.BasicUser2.Id}, report.NewMembers)
+		assert.Equal(t, 0, report.ExistingMembers)
+		assert.Equal(t, map[string]string{
+			model.DEFAULT_CHANNEL: "merge/default_channel",
+			"off-topic":           "rename/name_taken",
+			th.BasicChannel.Name:  "rename/name_taken",
+			private.Name:          "rename/name_taken",
+			moved.Name:            "move/",
+			archived.Name:         "skip/archived",
+		}, resolutions(report))
+		assert.Equal(t, []string{"merged"}, report.MovedCommands)
+		assert.Equal(t, []string{"conflict"}, report.CommandConflicts)
+	})
+
+	t.Run("plan with the merge policy", func(t *testing.T) {
+		report, err := th.App.PlanTeamMerge(source.Id, &model.TeamMergeRequest{TargetTeamId: target.Id, ChannelCollisionPolicy: model.TEAM_MERGE_CHANNEL_COLLISION_MERGE})
+		require.Nil(t, err)
+
+		assert.Equal(t, map[string]string{
+			model.DEFAULT_CHANNEL: "merge/default_channel",
+			"off-topic":           "merge/name_taken",
+			th.BasicChannel.Name:  "merge/name_taken",
+			private.Name:          "rename/type_mismatch",
+			moved.Name:            "move/",
+			archived.Name:         "skip/archived",
+		}, resolutions(report))
+	})
+
+	t.Run("invalid merges", func(t *testing.T) {
+		_, err := th.App.PlanTeamMerge(source.Id, &model.TeamMergeRequest{TargetTeamId: source.Id, ChannelCollisionPolicy: model.TEAM_MERGE_CHANNEL_COLLISION_MERGE})
+		require.NotNil(t, err)
+		assert.Equal(t, "app.team.merge_teams.same_team.app_error", err.Id)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		request := &model.TeamMergeRequest{TargetTeamId: target.Id, ChannelCollisionPolicy: model.TEAM_MERGE_CHANNEL_COLLISION_MERGE}
+
+		var progress []int64
+		report, err := th.App.MergeTeams(source.Id, request, th.SystemAdminUser.Id, func(p int64) {
+			progress = append(progress, p)
+		})
+		require.Nil(t, err)
+		assert.False(t, report.DryRun)
+		assert.Equal(t, int64(100), progress[len(progress)-1])
+
+		_, err = th.App.GetTeamMember(target.Id, th.BasicUser2.Id)
+		assert.Nil(t, err)
+
+		// The posts of merged channels are moved into the channel that has their name
+		post, err := th.App.GetSinglePost(th.BasicPost.Id)
+		require.Nil(t, err)
+		assert.Equal(t, taken.Id, post.ChannelId)
+
+		_, err = th.App.GetChannelMember(taken.Id, th.BasicUser.Id)
+		assert.Nil(t, err)
+
+		basicChannel, err := th.App.GetChannel(th.BasicChannel.Id)
+		require.Nil(t, err)
+		assert.NotZero(t, basicChannel.DeleteAt)
+
+		movedChannel, err := th.App.GetChannel(moved.Id)
+		require.Nil(t, err)
+		assert.Equal(t, target.Id, movedChannel.TeamId)
+		assert.Equal(t, moved.Name, movedChannel.Name)
+
+		renamedChannel, err := th.App.GetChannel(private.Id)
+		require.Nil(t, err)
+		assert.Equal(t, target.Id, renamedChannel.TeamId)
+		assert.Equal(t, private.Name+"-"+private.Id, renamedChannel.Name)
+
+		archivedChannel, err := th.App.GetChannel(archived.Id)
+		require.Nil(t, err)
+		assert.Equal(t, source.Id, archivedChannel.TeamId)
+
+		movedCommand := store.Must(th.App.Srv.Store.Command().Get(command.Id)).(*model.Command)
+		assert.Equal(t, target.Id, movedCommand.TeamId)
+
+		audits, err := th.App.GetAudits(th.SystemAdminUser.Id, 100)
+		require.Nil(t, err)
+		actions := make(map[string]int)
+		for _, audit := range audits {
+			actions[audit.Action]++
+		}
+		assert.Equal(t, 2, actions[AUDIT_ACTION_TEAM_MERGE_MEMBER_ADDED])
+		assert.Equal(t, 2, actions[AUDIT_ACTION_TEAM_MERGE_CHANNEL_MOVED])
+		assert.Equal(t, 3, actions[AUDIT_ACTION_TEAM_MERGE_CHANNEL_MERGED])
+		assert.Equal(t, 1, actions[AUDIT_ACTION_TEAM_MERGE_COMMAND_MOVED])
+
+		// Planning the merge again shows what's left, which is nothing but the emptied default channel
+		report, err = th.App.PlanTeamMerge(source.Id, request)
+		require.Nil(t, err)
+		assert.Empty(t, report.NewMembers)
+		assert.Equal(t, 2, report.ExistingMembers)
+		assert.Equal(t, map[string]string{
+			model.DEFAULT_CHANNEL: "merge/default_channel",
+			"off-topic":           "skip/archived",
+			th.BasicChannel.Name:  "skip/archived",
+			archived.Name:         "skip/archived",
+		}, resolutions(report))
+	})
+}
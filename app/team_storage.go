@@ -0,0 +1,222 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/services/filesstore"
+)
+
+// TEAM_STORAGE_USAGE_BATCH_SIZE is how many files CountTeamStorageUsage counts at a time.
+const TEAM_STORAGE_USAGE_BATCH_SIZE = 1000
+
+// GetTeamStorageUsage returns how much storage the files uploaded to a team take up, along with its quota.
+func (a *App) GetTeamStorageUsage(teamId string) (*model.TeamStorageUsage, *model.AppError) {
+	team, err := a.GetTeam(teamId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := <-a.Srv.Store.Team().GetStorageUsage(teamId)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	return &model.TeamStorageUsage{
+		TeamId: teamId,
+		Used:   result.Data.(int64),
+		Quota:  team.StorageQuota,
+	}, nil
+}
+
+// SetTeamStorageQuota sets the number of bytes of files that may be uploaded to a team, or 0 for no limit. Files that
+// were uploaded before the quota was lowered below the team's usage are kept, but no more can be uploaded.
+func (a *App) SetTeamStorageQuota(teamId string, quota int64) (*model.Team, *model.AppError) {
+	team, err := a.GetTeam(teamId)
+	if err != nil {
+		return nil, err
+	}
+
+	team.StorageQuota = quota
+
+	team, err = a.updateTeamUnsanitized(team)
+	if err != nil {
+		return nil, err
+	}
+
+	a.sendTeamEvent(team, model.WEBSOCKET_EVENT_UPDATE_TEAM)
+
+	return team, nil
+}
+
+// storageTeamIdForChannel returns the id of the team whose storage quota the files uploaded to a channel count
+// towards, or "" for direct and group channels, which are outside of any team, and channels that don't exist.
+func (a *App) storageTeamIdForChannel(channelId string) (string, *model.AppError) {
+	channel, err := a.GetChannel(channelId)
+	if err != nil {
+		if err.StatusCode == http.StatusNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return channel.TeamId, nil
+}
+
+// checkTeamStorageQuota returns an error if uploading a file of the given size would take a team over its storage
+// quota.
+func (a *App) checkTeamStorageQuota(teamId string, size int64) *model.AppError {
+	if teamId == "" {
+		return nil
+	}
+
+	team, err := a.GetTeam(teamId)
+	if err != nil {
+		return err
+	}
+
+	if team.StorageQuota == 0 {
+		return nil
+	}
+
+	result := <-a.Srv.Store.Team().GetStorageUsage(teamId)
+	if result.Err != nil {
+		return result.Err
+	}
+	used := result.Data.(int64)
+
+	if used+size > team.StorageQuota {
+		return model.NewAppError("checkTeamStorageQuota", "api.file.upload_file.storage_quota_exceeded.app_error", map[string]interface{}{"Used": used, "Quota": team.StorageQuota}, "team_id="+teamId, http.StatusRequestEntityTooLarge)
+	}
+
+	return nil
+}
+
+// addTeamStorageUsage records that files have been added to or removed from a team. Failing to do so doesn't fail the
+// upload or removal, since the usage only needs to be close enough to enforce the quota.
+func (a *App) addTeamStorageUsage(teamId string, delta int64) {
+	if teamId == "" || delta == 0 {
+		return
+	}
+
+	if result := <-a.Srv.Store.Team().UpdateStorageUsage(teamId, delta); result.Err != nil {
+		mlog.Warn("Failed to update team storage usage", mlog.String("team_id", teamId), mlog.Int64("delta", delta), mlog.Err(result.Err))
+	}
+}
+
+// recordTeamStorageForPath records that a file has been written to or, when size is negative, removed from the
+// directory of the channel that it was uploaded to, such as the thumbnail or preview generated for an image, so that it
+// counts towards the storage quota of the channel's team.
+func (a *App) recordTeamStorageForPath(filePath string, size int64) {
+	channelId := channelIdForFilePath(filePath)
+	if channelId == "" {
+		return
+	}
+
+	teamId, err := a.storageTeamIdForChannel(channelId)
+	if err != nil {
+		mlog.Warn("Failed to get the team of a stored file", mlog.String("path", filePath), mlog.Err(err))
+		return
+	}
+
+	a.addTeamStorageUsage(teamId, size)
+}
+
+// releaseTeamStorageForFile records that the stored files of a FileInfo, which take up size bytes, have been removed,
+// freeing up the storage that they used in the team of the channel that they were uploaded to.
+func (a *App) releaseTeamStorageForFile(info *model.FileInfo, size int64) {
+	a.recordTeamStorageForPath(info.Path, -size)
+}
+
+// storedFileSize returns how much storage the files of a FileInfo take up, including the thumbnail and preview
+// generated for images. Their sizes aren't recorded, so they're looked up in the file store, and left out if they're
+// missing.
+func (a *App) storedFileSize(backend filesstore.FileBackend, info *model.FileInfo) int64 {
+	size := info.Size
+	for _, filePath := range []string{info.ThumbnailPath, info.PreviewPath} {
+		if filePath == "" {
+			continue
+		}
+
+		if fileSize, err := backend.FileSize(filePath); err == nil {
+			size += fileSize
+		}
+	}
+	return size
+}
+
+// ResetTeamStorageUsage forgets the storage used by every team, before it's counted again by CountTeamStorageUsage.
+func (a *App) ResetTeamStorageUsage() *model.AppError {
+	if result := <-a.Srv.Store.Team().ResetStorageUsage(); result.Err != nil {
+		return result.Err
+	}
+	return nil
+}
+
+// CountTeamStorageUsage adds the storage used by a batch of the files created before a time to the usage of their
+// teams, so that files uploaded before usage was recorded count towards the quotas too. Files created since are
+// already recorded as they're uploaded. It returns the id of the last file of the batch to continue after, or "" once
+// every file has been counted.
+func (a *App) CountTeamStorageUsage(afterId string, before int64) (string, *model.AppError) {
+	result := <-a.Srv.Store.FileInfo().GetBatchForStorageUsage(afterId, before, TEAM_STORAGE_USAGE_BATCH_SIZE)
+	if result.Err != nil {
+		return "", result.Err
+	}
+	infos := result.Data.([]*model.FileInfo)
+	if len(infos) == 0 {
+		return "", nil
+	}
+
+	usage := make(map[string]int64)
+	teamIds := make(map[string]string)
+	for _, info := range infos {
+		channelId := channelIdForFilePath(info.Path)
+		if channelId == "" {
+			continue
+		}
+
+		teamId, ok := teamIds[channelId]
+		if !ok {
+			var err *model.AppError
+			if teamId, err = a.storageTeamIdForChannel(channelId); err != nil {
+				return "", err
+			}
+			teamIds[channelId] = teamId
+		}
+		if teamId == "" {
+			continue
+		}
+
+		backend, err := a.FileBackendForInfo(info)
+		if err != nil {
+			return "", err
+		}
+
+		usage[teamId] += a.storedFileSize(backend, info)
+	}
+
+	for teamId, size := range usage {
+		if result := <-a.Srv.Store.Team().UpdateStorageUsage(teamId, size); result.Err != nil {
+			return "", result.Err
+		}
+	}
+
+	return infos[len(infos)-1].Id, nil
+}
+
+// channelIdForFilePath returns the id of the channel that an uploaded file is stored under, or "" if it isn't stored
+// under one. See uploadDirectoryLayout.
+func channelIdForFilePath(filePath string) string {
+	parts := strings.Split(filePath, "/")
+	for i := 2; i+1 < len(parts); i++ {
+		if parts[i] == "channels" && parts[i-2] == "teams" {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils/testutils"
+)
+
+func TestChannelIdForFilePath(t *testing.T) {
+	channelId := model.NewId()
+
+	assert.Equal(t, channelId, channelIdForFilePath("20190101/teams/noteam/channels/"+channelId+"/users/"+model.NewId()+"/"+model.NewId()+"/file.txt"))
+	assert.Equal(t, channelId, channelIdForFilePath("teams/"+model.NewId()+"/channels/"+channelId+"/users/"+model.NewId()+"/"+model.NewId()+"/file.txt"))
+	assert.Equal(t, "", channelIdForFilePath("brand/image.png"))
+	assert.Equal(t, "", channelIdForFilePath("users/"+model.NewId()+"/profile.png"))
+	assert.Equal(t, "", channelIdForFilePath("20190101/teams/noteam/channels"))
+}
+
+func TestTeamStorageQuota(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	getUsed := func() int64 {
+		usage, err := th.App.GetTeamStorageUsage(th.BasicTeam.Id)
+		require.Nil(t, err)
+		return usage.Used
+	}
+
+	used := getUsed()
+
+	info, err := th.App.DoUploadFile(time.Now(), th.BasicTeam.Id, th.BasicChannel.Id, th.BasicUser.Id, "file.txt", []byte("0123456789"))
+	require.Nil(t, err)
+	defer th.App.RemoveFile(info.Path)
+
+	assert.Equal(t, used+10, getUsed())
+
+	t.Run("copies don't use any more storage", func(t *testing.T) {
+		_, err := th.App.CopyFileInfos(th.BasicUser.Id, []string{info.Id})
+		require.Nil(t, err)
+
+		assert.Equal(t, used+10, getUsed())
+	})
+
+	t.Run("files uploaded outside of a team aren't counted", func(t *testing.T) {
+		channel := th.CreateDmChannel(th.BasicUser2)
+
+		dmInfo, err := th.App.DoUploadFile(time.Now(), "noteam", channel.Id, th.BasicUser.Id, "file.txt", []byte("0123456789"))
+		require.Nil(t, err)
+		defer th.App.RemoveFile(dmInfo.Path)
+
+		assert.Equal(t, used+10, getUsed())
+	})
+
+	t.Run("uploads are limited by the quota", func(t *testing.T) {
+		_, err := th.App.SetTeamStorageQuota(th.BasicTeam.Id, getUsed()+15)
+		require.Nil(t, err)
+		defer th.App.SetTeamStorageQuota(th.BasicTeam.Id, 0)
+
+		_, err = th.App.DoUploadFile(time.Now(), th.BasicTeam.Id, th.BasicChannel.Id, th.BasicUser.Id, "file.txt", []byte("0123456789abcdef"))
+		require.NotNil(t, err)
+		assert.Equal(t, "api.file.upload_file.storage_quota_exceeded.app_error", err.Id)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, err.StatusCode)
+
+		_, err = th.App.UploadFileX(th.BasicChannel.Id, "file.txt", bytes.NewReader([]byte("0123456789abcdef")), UploadFileSetTeamId("noteam"), UploadFileSetUserId(th.BasicUser.Id))
+		require.NotNil(t, err)
+		assert.Equal(t, "api.file.upload_file.storage_quota_exceeded.app_error", err.Id)
+
+		smallInfo, err := th.App.UploadFileX(th.BasicChannel.Id, "file.txt", bytes.NewReader([]byte("0123456789")), UploadFileSetTeamId("noteam"), UploadFileSetUserId(th.BasicUser.Id))
+		require.Nil(t, err)
+		defer th.App.RemoveFile(smallInfo.Path)
+
+		usage, err := th.App.GetTeamStorageUsage(th.BasicTeam.Id)
+		require.Nil(t, err)
+		assert.Equal(t, used+20, usage.Used)
+		assert.Equal(t, used+25, usage.Quota)
+	})
+
+	t.Run("removing orphaned files frees up storage", func(t *testing.T) {
+		before := getUsed()
+
+		orphaned, err := th.App.DoUploadFile(time.Now(), th.BasicTeam.Id, th.BasicChannel.Id, th.BasicUser.Id, "file.txt", []byte("01234"))
+		require.Nil(t, err)
+		assert.Equal(t, before+5, getUsed())
+
+		copyIds, err := th.App.CopyFileInfos(th.BasicUser.Id, []string{orphaned.Id})
+		require.Nil(t, err)
+		copied, err := th.App.GetFileInfo(copyIds[0])
+		require.Nil(t, err)
+
+		// The file is still used by the copy
+		require.Nil(t, th.App.deleteOrphanedFileInfo(orphaned))
+		assert.Equal(t, before+5, getUsed())
+
+		exists, err := th.App.FileExists(orphaned.Path)
+		require.Nil(t, err)
+		assert.True(t, exists)
+
+		require.Nil(t, th.App.deleteOrphanedFileInfo(copied))
+		assert.Equal(t, before, getUsed())
+
+		exists, err = th.App.FileExists(orphaned.Path)
+		require.Nil(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("thumbnails and previews of images count too", func(t *testing.T) {
+		before := getUsed()
+
+		data, err := testutils.ReadTestFile("test.png")
+		require.Nil(t, err)
+
+		image, appErr := th.App.UploadFileX(th.BasicChannel.Id, "test.png", bytes.NewReader(data), UploadFileSetTeamId("noteam"), UploadFileSetUserId(th.BasicUser.Id))
+		require.Nil(t, appErr)
+		require.NotEmpty(t, image.ThumbnailPath)
+		require.NotEmpty(t, image.PreviewPath)
+
+		assert.True(t, getUsed() > before+image.Size)
+
+		require.Nil(t, th.App.deleteOrphanedFileInfo(image))
+		assert.Equal(t, before, getUsed())
+	})
+}
+
+func TestCountTeamStorageUsage(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	info, err := th.App.DoUploadFile(time.Now(), th.BasicTeam.Id, th.BasicChannel.Id, th.BasicUser.Id, "file.txt", []byte("0123456789"))
+	require.Nil(t, err)
+	defer th.App.RemoveFile(info.Path)
+
+	_, err = th.App.CopyFileInfos(th.BasicUser.Id, []string{info.Id})
+	require.Nil(t, err)
+
+	require.Nil(t, th.App.ResetTeamStorageUsage())
+
+	usage, err := th.App.GetTeamStorageUsage(th.BasicTeam.Id)
+	require.Nil(t, err)
+	assert.Equal(t, int64(0), usage.Used)
+
+	before := model.GetMillis() + 1
+	lastId := ""
+	for {
+		lastId, err = th.App.CountTeamStorageUsage(lastId, before)
+		require.Nil(t, err)
+		if lastId == "" {
+			break
+		}
+	}
+
+	// The copy shares the stored file, so it's only counted once
+	usage, err = th.App.GetTeamStorageUsage(th.BasicTeam.Id)
+	require.Nil(t, err)
+	assert.Equal(t, int64(10), usage.Used)
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const (
+	TEAM_MAX_MEMBERS_ERROR = "app.team.join_user_to_team.max_members.app_error"
+)
+
+// SetTeamMembershipLimit sets the number of active members that a team may have and whether users who join it once
+// that's reached are waitlisted. Lowering the limit below the team's member count doesn't remove anyone from it, but no
+// more users can join.
+func (a *App) SetTeamMembershipLimit(teamId string, patch *model.TeamMembershipLimitPatch) (*model.Team, *model.AppError) {
+	team, err := a.GetTeam(teamId)
+	if err != nil {
+		return nil, err
+	}
+
+	if patch.MaxMembers != nil {
+		team.MaxMembers = *patch.MaxMembers
+	}
+	if patch.AllowWaitlist != nil {
+		team.AllowWaitlist = *patch.AllowWaitlist
+	}
+
+	team, err = a.updateTeamUnsanitized(team)
+	if err != nil {
+		return nil, err
+	}
+
+	a.sendTeamEvent(team, model.WEBSOCKET_EVENT_UPDATE_TEAM)
+
+	return team, nil
+}
+
+// checkTeamMemberLimit returns an error if a team has as many active members as its MaxMembers allows.
+func (a *App) checkTeamMemberLimit(team *model.Team) *model.AppError {
+	if team.MaxMembers == 0 {
+		return nil
+	}
+
+	result := <-a.Srv.Store.Team().GetActiveMemberCount(team.Id)
+	if result.Err != nil {
+		return result.Err
+	}
+
+	if result.Data.(int64) >= int64(team.MaxMembers) {
+		return model.NewAppError("checkTeamMemberLimit", TEAM_MAX_MEMBERS_ERROR, map[string]interface{}{"MaxMembers": team.MaxMembers}, "team_id="+team.Id, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// AddTeamMemberByInviteIdOrWaitlist adds a user to the team with the given invite id like AddTeamMemberByInviteId, but
+// adds them to the team's waitlist instead if it's full and allows a waitlist. Exactly one of the returned member and
+// waitlist entry is set when there's no error.
+func (a *App) AddTeamMemberByInviteIdOrWaitlist(inviteId string, userId string) (*model.TeamMember, *model.TeamWaitlistEntry, *model.AppError) {
+	member, err := a.AddTeamMemberByInviteId(inviteId, userId)
+	if err == nil || err.Id != TEAM_MAX_MEMBERS_ERROR {
+		return member, nil, err
+	}
+
+	team, teamErr := a.GetTeamByInviteId(inviteId)
+	if teamErr != nil || !team.AllowWaitlist {
+		return nil, nil, err
+	}
+
+	entry, err := a.AddUserToTeamWaitlist(team.Id, userId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return nil, entry, nil
+}
+
+// AddUserToTeamWaitlist adds a user to the waitlist of a team, or returns their existing entry if they're already on
+// it.
+func (a *App) AddUserToTeamWaitlist(teamId string, userId string) (*model.TeamWaitlistEntry, *model.AppError) {
+	result := <-a.Srv.Store.Team().SaveWaitlistEntry(&model.TeamWaitlistEntry{TeamId: teamId, UserId: userId})
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	return result.Data.(*model.TeamWaitlistEntry), nil
+}
+
+// GetTeamWaitlist returns the users waiting to join a team, in the order that they asked to join it.
+func (a *App) GetTeamWaitlist(teamId string, offset int, limit int) ([]*model.TeamWaitlistEntry, *model.AppError) {
+	result := <-a.Srv.Store.Team().GetWaitlist(teamId, offset, limit)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	return result.Data.([]*model.TeamWaitlistEntry), nil
+}
+
+// ApproveTeamWaitlistEntry adds a waitlisted user to a team and removes them from its waitlist. The team's member limit
+// still applies, so it fails until there's room in the team for them.
+func (a *App) ApproveTeamWaitlistEntry(teamId string, userId string, userRequestorId string) (*model.TeamMember, *model.AppError) {
+	if result := <-a.Srv.Store.Team().GetWaitlistEntry(teamId, userId); result.Err != nil {
+		return nil, result.Err
+	}
+
+	if _, err := a.AddUserToTeam(teamId, userId, userRequestorId); err != nil {
+		return nil, err
+	}
+
+	if err := a.RemoveTeamWaitlistEntry(teamId, userId); err != nil {
+		return nil, err
+	}
+
+	return a.GetTeamMember(teamId, userId)
+}
+
+// RemoveTeamWaitlistEntry removes a user from the waitlist of a team without adding them to it.
+func (a *App) RemoveTeamWaitlistEntry(teamId string, userId string) *model.AppError {
+	if result := <-a.Srv.Store.Team().RemoveWaitlistEntry(teamId, userId); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestTeamMembershipLimit(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	team := th.CreateTeam()
+
+	team, err := th.App.SetTeamMembershipLimit(team.Id, &model.TeamMembershipLimitPatch{MaxMembers: model.NewInt(1)})
+	require.Nil(t, err)
+	assert.Equal(t, 1, team.MaxMembers)
+	assert.False(t, team.AllowWaitlist)
+
+	_, err = th.App.AddTeamMember(team.Id, th.BasicUser.Id)
+	require.Nil(t, err)
+
+	_, err = th.App.AddTeamMember(team.Id, th.BasicUser2.Id)
+	require.NotNil(t, err)
+	assert.Equal(t, TEAM_MAX_MEMBERS_ERROR, err.Id)
+
+	t.Run("joining a full team without a waitlist fails", func(t *testing.T) {
+		member, entry, err := th.App.AddTeamMemberByInviteIdOrWaitlist(team.InviteId, th.BasicUser2.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, TEAM_MAX_MEMBERS_ERROR, err.Id)
+		assert.Nil(t, member)
+		assert.Nil(t, entry)
+	})
+
+	team, err = th.App.SetTeamMembershipLimit(team.Id, &model.TeamMembershipLimitPatch{AllowWaitlist: model.NewBool(true)})
+	require.Nil(t, err)
+	assert.Equal(t, 1, team.MaxMembers)
+	assert.True(t, team.AllowWaitlist)
+
+	t.Run("joining a full team with a waitlist waitlists the user", func(t *testing.T) {
+		member, entry, err := th.App.AddTeamMemberByInviteIdOrWaitlist(team.InviteId, th.BasicUser2.Id)
+		require.Nil(t, err)
+		assert.Nil(t, member)
+		require.NotNil(t, entry)
+		assert.Equal(t, th.BasicUser2.Id, entry.UserId)
+
+		entries, err := th.App.GetTeamWaitlist(team.Id, 0, 10)
+		require.Nil(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, th.BasicUser2.Id, entries[0].UserId)
+	})
+
+	t.Run("approving only adds the user once there's room", func(t *testing.T) {
+		_, err := th.App.ApproveTeamWaitlistEntry(team.Id, th.BasicUser2.Id, th.SystemAdminUser.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, TEAM_MAX_MEMBERS_ERROR, err.Id)
+
+		_, err = th.App.SetTeamMembershipLimit(team.Id, &model.TeamMembershipLimitPatch{MaxMembers: model.NewInt(2)})
+		require.Nil(t, err)
+
+		member, err := th.App.ApproveTeamWaitlistEntry(team.Id, th.BasicUser2.Id, th.SystemAdminUser.Id)
+		require.Nil(t, err)
+		assert.Equal(t, th.BasicUser2.Id, member.UserId)
+
+		entries, err := th.App.GetTeamWaitlist(team.Id, 0, 10)
+		require.Nil(t, err)
+		assert.Empty(t, entries)
+
+		_, err = th.App.ApproveTeamWaitlistEntry(team.Id, th.BasicUser2.Id, th.SystemAdminUser.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, http.StatusNotFound, err.StatusCode)
+	})
+
+	t.Run("users who left can't rejoin a full team", func(t *testing.T) {
+		_, err := th.App.SetTeamMembershipLimit(team.Id, &model.TeamMembershipLimitPatch{MaxMembers: model.NewInt(1)})
+		require.Nil(t, err)
+
+		require.Nil(t, th.App.RemoveUserFromTeam(team.Id, th.BasicUser2.Id, th.SystemAdminUser.Id))
+
+		_, err = th.App.AddTeamMember(team.Id, th.BasicUser2.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, TEAM_MAX_MEMBERS_ERROR, err.Id)
+	})
+
+	t.Run("waitlisted users can be removed", func(t *testing.T) {
+		_, err := th.App.AddUserToTeamWaitlist(team.Id, th.BasicUser2.Id)
+		require.Nil(t, err)
+
+		require.Nil(t, th.App.RemoveTeamWaitlistEntry(team.Id, th.BasicUser2.Id))
+
+		entries, err := th.App.GetTeamWaitlist(team.Id, 0, 10)
+		require.Nil(t, err)
+		assert.Empty(t, entries)
+	})
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"github.com/mattermost/mattermost-server/services/tracing"
+)
+
+// InitTracing starts or stops tracing to match the config. Spans that have already ended when it's stopped are still
+// exported.
+func (s *Server) InitTracing() {
+	var tracer *tracing.Tracer
+	if settings := s.Config().TraceSettings; *settings.Enable {
+		exporter := tracing.NewOTLPExporter(s.HTTPService.MakeClient(true), *settings.OTLPEndpoint)
+		tracer = tracing.NewTracer(exporter, *settings.SamplingRatio)
+		tracer.Start()
+	}
+
+	s.tracerLock.Lock()
+	oldTracer := s.tracer
+	s.tracer = tracer
+	s.tracerLock.Unlock()
+
+	if oldTracer != nil {
+		oldTracer.Stop()
+	}
+}
+
+// Tracer returns the tracer used to trace requests, which is nil while tracing is disabled.
+func (s *Server) Tracer() *tracing.Tracer {
+	s.tracerLock.RLock()
+	defer s.tracerLock.RUnlock()
+
+	return s.tracer
+}
@@ -41,6 +41,8 @@ const (
 	PASSWORD_RECOVER_EXPIRY_TIME  = 1000 * 60 * 60      // 1 hour
 	TEAM_INVITATION_EXPIRY_TIME   = 1000 * 60 * 60 * 48 // 48 hours
 	IMAGE_PROFILE_PIXEL_DIMENSION = 128
+
+	USER_LAST_ACTIVITY_EXPORT_BATCH_SIZE = 1000
 )
 
 func (a *App) CreateUserWithToken(user *model.User, tokenId string) (*model.User, *model.AppError) {
@@ -198,7 +200,8 @@ func (a *App) CreateUser(user *model.User) (*model.User, *model.AppError) {
 	if result.Err != nil {
 		return nil, result.Err
 	}
-	if result.Data.(int64) <= 0 {
+	firstUser := result.Data.(int64) <= 0
+	if firstUser {
 		user.Roles = model.SYSTEM_ADMIN_ROLE_ID + " " + model.SYSTEM_USER_ROLE_ID
 	}
 
@@ -210,6 +213,18 @@ func (a *App) CreateUser(user *model.User) (*model.User, *model.AppError) {
 	if err != nil {
 		return nil, err
 	}
+
+	// The client config tells clients whether any accounts exist yet
+	if firstUser {
+		a.InvalidateResponseCache(model.RESPONSE_CACHE_ENDPOINT_CLIENT_CONFIG)
+	}
+
+	// Users whose email isn't verified yet, even when verification isn't required to log in, only join once it is so
+	// that they can't join teams for a domain they don't control, see VerifyEmailFromToken
+	if ruser.EmailVerified {
+		a.AutoJoinTeamsByEmailDomain(ruser)
+	}
+
 	// This message goes to everyone, so the teamId, channelId and userId are irrelevant
 	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_NEW_USER, "", "", "", nil)
 	message.Add("user_id", ruser.Id)
@@ -220,6 +235,7 @@ func (a *App) CreateUser(user *model.User) (*model.User, *model.AppError) {
 
 func (a *App) createUser(user *model.User) (*model.User, *model.AppError) {
 	user.MakeNonNil()
+	a.applyDefaultNotifyProps(user)
 
 	if err := a.IsPasswordValid(user.Password); user.AuthService == "" && err != nil {
 		return nil, err
@@ -232,6 +248,8 @@ func (a *App) createUser(user *model.User) (*model.User, *model.AppError) {
 	}
 	ruser := result.Data.(*model.User)
 
+	a.publishUserFirehoseEvent(model.FIREHOSE_EVENT_USER_CREATED, ruser)
+
 	if user.EmailVerified {
 		if err := a.VerifyUserEmail(ruser.Id, user.Email); err != nil {
 			mlog.Error(fmt.Sprintf("Failed to set email verified err=%v", err))
@@ -553,6 +571,36 @@ func (a *App) GetUsersByUsernames(usernames []string, asAdmin bool) ([]*model.Us
 	return a.sanitizeProfiles(result.Data.([]*model.User), asAdmin), nil
 }
 
+// GetUserDisplayNames returns the display names of the given users, keyed by their ids, as formatted by the name
+// format preference of the user making the request. Names that the requester can't see, such as full names when
+// they're hidden by the privacy settings, aren't used. Users that don't exist are left out.
+func (a *App) GetUserDisplayNames(userIds []string, requesterId string, asAdmin bool) (map[string]string, *model.AppError) {
+	users, err := a.GetUsersByIds(userIds, asAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	nameFormat := a.getNameFormatForUser(requesterId)
+
+	displayNames := make(map[string]string, len(users))
+	for _, user := range users {
+		displayNames[user.Id] = user.GetDisplayName(nameFormat)
+	}
+
+	return displayNames, nil
+}
+
+// getNameFormatForUser returns how a user prefers other users' names to be displayed, which defaults to the site's
+// TeammateNameDisplay.
+func (a *App) getNameFormatForUser(userId string) string {
+	result := <-a.Srv.Store.Preference().Get(userId, model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS, model.PREFERENCE_NAME_NAME_FORMAT)
+	if result.Err != nil {
+		return *a.Config().TeamSettings.TeammateNameDisplay
+	}
+
+	return result.Data.(model.Preference).Value
+}
+
 func (a *App) sanitizeProfiles(users []*model.User, asAdmin bool) []*model.User {
 	for _, u := range users {
 		a.SanitizeProfile(u, asAdmin)
@@ -918,6 +966,15 @@ func (a *App) SanitizeProfile(user *model.User, asAdmin bool) {
 }
 
 func (a *App) UpdateUserAsUser(user *model.User, asAdmin bool) (*model.User, *model.AppError) {
+	prev, err := a.GetUser(user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.checkEnforcedNotifyProps(prev.NotifyProps, user.NotifyProps); err != nil {
+		return nil, err
+	}
+
 	updatedUser, err := a.UpdateUser(user, true)
 	if err != nil {
 		return nil, err
@@ -934,6 +991,12 @@ func (a *App) PatchUser(userId string, patch *model.UserPatch, asAdmin bool) (*m
 		return nil, err
 	}
 
+	if patch.NotifyProps != nil {
+		if err := a.checkEnforcedNotifyProps(user.NotifyProps, patch.NotifyProps); err != nil {
+			return nil, err
+		}
+	}
+
 	user.Patch(patch)
 
 	updatedUser, err := a.UpdateUser(user, true)
@@ -1019,6 +1082,8 @@ func (a *App) UpdateUser(user *model.User, sendNotifications bool) (*model.User,
 	}
 	rusers := result.Data.([2]*model.User)
 
+	a.publishUserFirehoseEvent(model.FIREHOSE_EVENT_USER_UPDATED, rusers[0])
+
 	if sendNotifications {
 
 		if rusers[0].Email != rusers[1].Email || newEmail != "" {
@@ -1064,12 +1129,97 @@ func (a *App) UpdateUserActive(userId string, active bool) *model.AppError {
 	return nil
 }
 
+// applyDefaultNotifyProps sets the notification settings of a new user to the defaults configured by admins. Settings
+// the user chose themselves are kept, unless they're enforced.
+func (a *App) applyDefaultNotifyProps(user *model.User) {
+	settings := a.Config().NotificationDefaultSettings
+	if len(settings.NotifyProps) == 0 {
+		return
+	}
+
+	chosen := len(user.NotifyProps) > 0
+	if !chosen {
+		user.SetDefaultNotifications()
+	}
+
+	for prop, value := range settings.NotifyProps {
+		if !chosen || settings.IsEnforced(prop) {
+			user.NotifyProps[prop] = value
+		}
+	}
+}
+
+// checkEnforcedNotifyProps returns an error if an update to a user's notification settings changes one that's
+// enforced by admins to something other than its default.
+func (a *App) checkEnforcedNotifyProps(prev, props map[string]string) *model.AppError {
+	settings := a.Config().NotificationDefaultSettings
+
+	for _, prop := range settings.EnforcedNotifyProps {
+		value, ok := props[prop]
+		if !ok || value == prev[prop] || value == settings.NotifyProps[prop] {
+			continue
+		}
+
+		return model.NewAppError("checkEnforcedNotifyProps", "api.user.update_notify_props.enforced.app_error", map[string]interface{}{"Prop": prop}, "", http.StatusForbidden)
+	}
+
+	return nil
+}
+
+// checkEnforcedChannelNotifyProps returns an error if an update to a channel member's notification settings overrides
+// one of the user's settings that's enforced by admins with something other than its default.
+func (a *App) checkEnforcedChannelNotifyProps(prev, props map[string]string) *model.AppError {
+	settings := a.Config().NotificationDefaultSettings
+
+	for _, prop := range settings.EnforcedNotifyProps {
+		value, ok := props[prop]
+		if !ok || !model.IsChannelNotifyPropOverride(prop) || value == prev[prop] || value == model.CHANNEL_NOTIFY_DEFAULT || value == settings.NotifyProps[prop] {
+			continue
+		}
+
+		return model.NewAppError("checkEnforcedChannelNotifyProps", "api.user.update_notify_props.enforced.app_error", map[string]interface{}{"Prop": prop}, "", http.StatusForbidden)
+	}
+
+	return nil
+}
+
+// enforceNotifyProps returns copies of the users in a channel and of their channel notify props with the settings
+// enforced by admins applied, so that settings saved before they were enforced don't count when notifications are
+// sent. The originals may be cached, so they're left alone.
+func (a *App) enforceNotifyProps(profileMap map[string]*model.User, channelMemberNotifyPropsMap map[string]model.StringMap) (map[string]*model.User, map[string]model.StringMap) {
+	settings := a.Config().NotificationDefaultSettings
+	if len(settings.EnforcedNotifyProps) == 0 {
+		return profileMap, channelMemberNotifyPropsMap
+	}
+
+	profiles := make(map[string]*model.User, len(profileMap))
+	for userId, profile := range profileMap {
+		user := *profile
+		user.NotifyProps = model.CopyStringMap(profile.NotifyProps)
+		settings.EnforceNotifyProps(user.NotifyProps)
+		profiles[userId] = &user
+	}
+
+	channelNotifyProps := make(map[string]model.StringMap, len(channelMemberNotifyPropsMap))
+	for userId, props := range channelMemberNotifyPropsMap {
+		props = model.CopyStringMap(props)
+		settings.EnforceChannelNotifyProps(props)
+		channelNotifyProps[userId] = props
+	}
+
+	return profiles, channelNotifyProps
+}
+
 func (a *App) UpdateUserNotifyProps(userId string, props map[string]string) (*model.User, *model.AppError) {
 	user, err := a.GetUser(userId)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := a.checkEnforcedNotifyProps(user.NotifyProps, props); err != nil {
+		return nil, err
+	}
+
 	user.NotifyProps = props
 
 	ruser, err := a.UpdateUser(user, true)
@@ -1341,7 +1491,13 @@ func (a *App) PermanentDeleteUser(user *model.User) *model.AppError {
 
 	infos := result.Data.([]*model.FileInfo)
 	for _, info := range infos {
-		res, err := a.FileExists(info.Path)
+		backend, err := a.FileBackendForInfo(info)
+		if err != nil {
+			mlog.Warn("Unable to get file store for file", mlog.String("path", info.Path), mlog.Err(err))
+			continue
+		}
+
+		res, err := backend.FileExists(info.Path)
 		if err != nil {
 			mlog.Warn(
 				"Error checking existence of file",
@@ -1356,7 +1512,7 @@ func (a *App) PermanentDeleteUser(user *model.User) *model.AppError {
 			continue
 		}
 
-		err = a.RemoveFile(info.Path)
+		err = backend.RemoveFile(info.Path)
 
 		if err != nil {
 			mlog.Warn(
@@ -1364,7 +1520,11 @@ func (a *App) PermanentDeleteUser(user *model.User) *model.AppError {
 				mlog.String("path", info.Path),
 				mlog.Err(err),
 			)
+			continue
 		}
+
+		// Copies of the file share the path, so they're skipped above once it's been removed
+		a.releaseTeamStorageForFile(info, info.Size)
 	}
 
 	if result := <-a.Srv.Store.FileInfo().PermanentDeleteByUser(user.Id); result.Err != nil {
@@ -1441,6 +1601,10 @@ func (a *App) VerifyEmailFromToken(userSuppliedTokenString string) *model.AppErr
 		return err
 	}
 
+	if !user.EmailVerified && user.Email == tokenData.Email {
+		a.AutoJoinTeamsByEmailDomain(user)
+	}
+
 	if user.Email != tokenData.Email {
 		a.Srv.Go(func() {
 			if err := a.SendEmailChangeEmail(user.Email, tokenData.Email, user.Locale, a.GetSiteURL()); err != nil {
@@ -1502,6 +1666,43 @@ func (a *App) GetTotalUsersStats() (*model.UsersStats, *model.AppError) {
 	return stats, nil
 }
 
+// ExportUsersLastActivity writes a CSV record of every user's last activity to w, one batch at a time.
+// If inactiveSince is non-zero, only users with no activity since then are included.
+func (a *App) ExportUsersLastActivity(w io.Writer, inactiveSince int64) *model.AppError {
+	writer := model.NewCsvWriter(w)
+	if err := writer.Write(model.UserLastActivityCsvHeader); err != nil {
+		return model.NewAppError("ExportUsersLastActivity", "app.user.export_last_activity.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	afterId := strings.Repeat("0", 26)
+	for {
+		result := <-a.Srv.Store.User().GetLastActivityAfter(afterId, inactiveSince, USER_LAST_ACTIVITY_EXPORT_BATCH_SIZE)
+		if result.Err != nil {
+			return result.Err
+		}
+		activities := result.Data.([]*model.UserLastActivity)
+
+		for _, activity := range activities {
+			if err := writer.Write(activity.ToCsvRecord()); err != nil {
+				return model.NewAppError("ExportUsersLastActivity", "app.user.export_last_activity.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+			}
+		}
+
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return model.NewAppError("ExportUsersLastActivity", "app.user.export_last_activity.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		if len(activities) < USER_LAST_ACTIVITY_EXPORT_BATCH_SIZE {
+			return nil
+		}
+		afterId = activities[len(activities)-1].UserId
+	}
+}
+
 func (a *App) VerifyUserEmail(userId, email string) *model.AppError {
 	err := (<-a.Srv.Store.User().VerifyEmail(userId, email)).Err
 
@@ -1607,8 +1808,9 @@ func (a *App) SearchUsersWithoutTeam(term string, options *model.UserSearchOptio
 }
 
 func (a *App) AutocompleteUsersInChannel(teamId string, channelId string, term string, options *model.UserSearchOptions) (*model.UserAutocompleteInChannel, *model.AppError) {
-	uchan := a.Srv.Store.User().SearchInChannel(channelId, term, options)
-	nuchan := a.Srv.Store.User().SearchNotInChannel(teamId, channelId, term, options)
+	searchOptions, limit := a.autocompleteSearchOptions(options)
+	uchan := a.Srv.Store.User().SearchInChannel(channelId, term, searchOptions)
+	nuchan := a.Srv.Store.User().SearchNotInChannel(teamId, channelId, term, searchOptions)
 
 	autocomplete := &model.UserAutocompleteInChannel{}
 
@@ -1622,6 +1824,11 @@ func (a *App) AutocompleteUsersInChannel(teamId string, channelId string, term s
 		a.SanitizeProfile(user, options.IsAdmin)
 	}
 
+	users, err := a.rankAutocompleteUsers(users, limit)
+	if err != nil {
+		return nil, err
+	}
+
 	autocomplete.InChannel = users
 
 	result = <-nuchan
@@ -1634,6 +1841,11 @@ func (a *App) AutocompleteUsersInChannel(teamId string, channelId string, term s
 		a.SanitizeProfile(user, options.IsAdmin)
 	}
 
+	users, err = a.rankAutocompleteUsers(users, limit)
+	if err != nil {
+		return nil, err
+	}
+
 	autocomplete.OutOfChannel = users
 
 	return autocomplete, nil
@@ -1642,7 +1854,8 @@ func (a *App) AutocompleteUsersInChannel(teamId string, channelId string, term s
 func (a *App) AutocompleteUsersInTeam(teamId string, term string, options *model.UserSearchOptions) (*model.UserAutocompleteInTeam, *model.AppError) {
 	autocomplete := &model.UserAutocompleteInTeam{}
 
-	result := <-a.Srv.Store.User().Search(teamId, term, options)
+	searchOptions, limit := a.autocompleteSearchOptions(options)
+	result := <-a.Srv.Store.User().Search(teamId, term, searchOptions)
 	if result.Err != nil {
 		return nil, result.Err
 	}
@@ -1652,6 +1865,11 @@ func (a *App) AutocompleteUsersInTeam(teamId string, term string, options *model
 		a.SanitizeProfile(user, options.IsAdmin)
 	}
 
+	users, err := a.rankAutocompleteUsers(users, limit)
+	if err != nil {
+		return nil, err
+	}
+
 	autocomplete.InTeam = users
 
 	return autocomplete, nil
@@ -0,0 +1,108 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"sort"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const (
+	// Users that haven't been active for this long get no credit for recency when ranking autocomplete results.
+	AUTOCOMPLETE_RECENCY_WINDOW = 30 * DAY_MILLISECONDS
+
+	// How many more users than will be returned are searched for when ranking autocomplete results by anything other
+	// than alphabetical order.
+	AUTOCOMPLETE_CANDIDATE_MULTIPLIER = 5
+)
+
+// autocompleteSearchOptions returns the options to search for autocomplete candidates with, along with how many of
+// them should be returned once they're ranked. The store returns users in alphabetical order, so when recency or
+// membership count towards the ranking, more candidates are searched for so that users who would rank highly aren't
+// left out just for being late in the alphabet.
+func (a *App) autocompleteSearchOptions(options *model.UserSearchOptions) (*model.UserSearchOptions, int) {
+	settings := a.Config().AutocompleteSettings
+
+	limit := *settings.ResultLimit
+	if options.Limit > 0 && options.Limit < limit {
+		limit = options.Limit
+	}
+
+	searchOptions := *options
+	searchOptions.Limit = limit
+	if *settings.RecencyWeight > 0 || *settings.MembershipWeight > 0 {
+		searchOptions.Limit = limit * AUTOCOMPLETE_CANDIDATE_MULTIPLIER
+		if searchOptions.Limit > model.USER_SEARCH_MAX_LIMIT {
+			searchOptions.Limit = model.USER_SEARCH_MAX_LIMIT
+		}
+	}
+
+	return &searchOptions, limit
+}
+
+// rankAutocompleteUsers orders autocomplete results according to the AutocompleteSettings weights and trims them to
+// the given limit. Each user scores between 0 and 1 for recency, based on when they were last active, for
+// membership, based on how many channels they share with the current user relative to the other results, and for
+// being early in alphabetical order. The input is expected to be sorted by username, as returned by the store.
+func (a *App) rankAutocompleteUsers(users []*model.User, limit int) ([]*model.User, *model.AppError) {
+	settings := a.Config().AutocompleteSettings
+
+	recencyWeight := float64(*settings.RecencyWeight)
+	membershipWeight := float64(*settings.MembershipWeight)
+	alphabeticalWeight := float64(*settings.AlphabeticalWeight)
+
+	if len(users) > 1 && (recencyWeight > 0 || membershipWeight > 0) {
+		userIds := make([]string, len(users))
+		scores := make(map[string]float64, len(users))
+		for i, user := range users {
+			userIds[i] = user.Id
+			scores[user.Id] = alphabeticalWeight * (1 - float64(i)/float64(len(users)))
+		}
+
+		if recencyWeight > 0 {
+			statuses, err := a.GetUserStatusesByIds(userIds)
+			if err != nil {
+				return nil, err
+			}
+
+			now := model.GetMillis()
+			for _, status := range statuses {
+				if age := now - status.LastActivityAt; age < AUTOCOMPLETE_RECENCY_WINDOW {
+					scores[status.UserId] += recencyWeight * (1 - float64(age)/AUTOCOMPLETE_RECENCY_WINDOW)
+				}
+			}
+		}
+
+		if membershipWeight > 0 {
+			result := <-a.Srv.Store.Channel().GetCommonChannelCounts(a.Session.UserId, userIds)
+			if result.Err != nil {
+				return nil, result.Err
+			}
+			counts := result.Data.(map[string]int64)
+
+			var maxCount int64
+			for _, count := range counts {
+				if count > maxCount {
+					maxCount = count
+				}
+			}
+
+			for userId, count := range counts {
+				scores[userId] += membershipWeight * float64(count) / float64(maxCount)
+			}
+		}
+
+		// Sorting stably keeps users with equal scores in alphabetical order.
+		sort.SliceStable(users, func(i, j int) bool {
+			return scores[users[i].Id] > scores[users[j].Id]
+		})
+	}
+
+	if len(users) > limit {
+		users = users[:limit]
+	}
+
+	return users, nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestRankAutocompleteUsers(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	th.App.Session = model.Session{UserId: th.BasicUser.Id}
+
+	// None of these users share a channel with the current user until user2 joins the basic team below.
+	user1 := th.CreateUser()
+	user2 := th.CreateUser()
+	user3 := th.CreateUser()
+	users := func() []*model.User {
+		return []*model.User{user1, user2, user3}
+	}
+
+	require.Nil(t, (<-th.App.Srv.Store.Status().SaveOrUpdate(&model.Status{UserId: user3.Id, Status: model.STATUS_ONLINE, LastActivityAt: model.GetMillis()})).Err)
+
+	th.LinkUserToTeam(user2, th.BasicTeam)
+	th.AddUserToChannel(user2, th.BasicChannel)
+
+	rankedIds := func(ranked []*model.User) []string {
+		ids := []string{}
+		for _, user := range ranked {
+			ids = append(ids, user.Id)
+		}
+		return ids
+	}
+
+	t.Run("alphabetical by default", func(t *testing.T) {
+		ranked, err := th.App.rankAutocompleteUsers(users(), *th.App.Config().AutocompleteSettings.ResultLimit)
+		require.Nil(t, err)
+		assert.Equal(t, []string{user1.Id, user2.Id, user3.Id}, rankedIds(ranked))
+	})
+
+	t.Run("recency", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.AutocompleteSettings.RecencyWeight = 100
+			*cfg.AutocompleteSettings.MembershipWeight = 0
+		})
+
+		ranked, err := th.App.rankAutocompleteUsers(users(), *th.App.Config().AutocompleteSettings.ResultLimit)
+		require.Nil(t, err)
+		assert.Equal(t, []string{user3.Id, user1.Id, user2.Id}, rankedIds(ranked))
+	})
+
+	t.Run("membership", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.AutocompleteSettings.RecencyWeight = 0
+			*cfg.AutocompleteSettings.MembershipWeight = 100
+		})
+
+		ranked, err := th.App.rankAutocompleteUsers(users(), *th.App.Config().AutocompleteSettings.ResultLimit)
+		require.Nil(t, err)
+		assert.Equal(t, []string{user2.Id, user1.Id, user3.Id}, rankedIds(ranked))
+	})
+
+	t.Run("result limit", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.AutocompleteSettings.MembershipWeight = 0
+			*cfg.AutocompleteSettings.ResultLimit = 2
+		})
+
+		ranked, err := th.App.rankAutocompleteUsers(users(), *th.App.Config().AutocompleteSettings.ResultLimit)
+		require.Nil(t, err)
+		assert.Equal(t, []string{user1.Id, user2.Id}, rankedIds(ranked))
+	})
+}
+
+func TestAutocompleteSearchOptions(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.AutocompleteSettings.ResultLimit = 20
+		*cfg.AutocompleteSettings.RecencyWeight = 0
+		*cfg.AutocompleteSettings.MembershipWeight = 0
+	})
+
+	t.Run("alphabetical results aren't widened", func(t *testing.T) {
+		searchOptions, limit := th.App.autocompleteSearchOptions(&model.UserSearchOptions{Limit: 100})
+		assert.Equal(t, 20, limit)
+		assert.Equal(t, 20, searchOptions.Limit)
+	})
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.AutocompleteSettings.RecencyWeight = 50
+	})
+
+	t.Run("ranked results search for more candidates", func(t *testing.T) {
+		options := &model.UserSearchOptions{Limit: 100, AllowFullNames: true}
+		searchOptions, limit := th.App.autocompleteSearchOptions(options)
+		assert.Equal(t, 20, limit)
+		assert.Equal(t, 20*AUTOCOMPLETE_CANDIDATE_MULTIPLIER, searchOptions.Limit)
+		assert.True(t, searchOptions.AllowFullNames)
+		assert.Equal(t, 100, options.Limit, "should not modify the given options")
+	})
+
+	t.Run("the requested limit is respected", func(t *testing.T) {
+		searchOptions, limit := th.App.autocompleteSearchOptions(&model.UserSearchOptions{Limit: 5})
+		assert.Equal(t, 5, limit)
+		assert.Equal(t, 5*AUTOCOMPLETE_CANDIDATE_MULTIPLIER, searchOptions.Limit)
+	})
+
+	t.Run("candidates are capped", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.AutocompleteSettings.ResultLimit = model.USER_SEARCH_MAX_LIMIT
+		})
+
+		searchOptions, limit := th.App.autocompleteSearchOptions(&model.UserSearchOptions{Limit: model.USER_SEARCH_MAX_LIMIT})
+		assert.Equal(t, model.USER_SEARCH_MAX_LIMIT, limit)
+		assert.Equal(t, model.USER_SEARCH_MAX_LIMIT, searchOptions.Limit)
+	})
+}
@@ -172,6 +172,89 @@ func TestUpdateUserActive(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestDefaultNotifyProps(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		cfg.NotificationDefaultSettings.NotifyProps = map[string]string{
+			model.EMAIL_NOTIFY_PROP: "false",
+			model.PUSH_NOTIFY_PROP:  model.USER_NOTIFY_NONE,
+		}
+		cfg.NotificationDefaultSettings.EnforcedNotifyProps = []string{model.EMAIL_NOTIFY_PROP}
+	})
+
+	t.Run("new user gets the defaults", func(t *testing.T) {
+		user := th.CreateUser()
+		defer th.App.PermanentDeleteUser(user)
+
+		assert.Equal(t, "false", user.NotifyProps[model.EMAIL_NOTIFY_PROP])
+		assert.Equal(t, model.USER_NOTIFY_NONE, user.NotifyProps[model.PUSH_NOTIFY_PROP])
+		assert.Equal(t, model.USER_NOTIFY_MENTION, user.NotifyProps[model.DESKTOP_NOTIFY_PROP])
+	})
+
+	t.Run("new user keeps chosen settings that aren't enforced", func(t *testing.T) {
+		id := model.NewId()
+		user, err := th.App.CreateUser(&model.User{
+			Email:    "success+" + id + "@simulator.amazonses.com",
+			Username: "un_" + id,
+			Password: "Password1",
+			NotifyProps: model.StringMap{
+				model.EMAIL_NOTIFY_PROP: "true",
+				model.PUSH_NOTIFY_PROP:  model.USER_NOTIFY_ALL,
+			},
+		})
+		require.Nil(t, err)
+		defer th.App.PermanentDeleteUser(user)
+
+		assert.Equal(t, "false", user.NotifyProps[model.EMAIL_NOTIFY_PROP])
+		assert.Equal(t, model.USER_NOTIFY_ALL, user.NotifyProps[model.PUSH_NOTIFY_PROP])
+	})
+
+	t.Run("enforced settings can't be changed", func(t *testing.T) {
+		user := th.CreateUser()
+		defer th.App.PermanentDeleteUser(user)
+
+		_, err := th.App.PatchUser(user.Id, &model.UserPatch{NotifyProps: model.StringMap{model.EMAIL_NOTIFY_PROP: "true"}}, false)
+		require.NotNil(t, err)
+		assert.Equal(t, "api.user.update_notify_props.enforced.app_error", err.Id)
+
+		ruser, err := th.App.PatchUser(user.Id, &model.UserPatch{NotifyProps: model.StringMap{model.EMAIL_NOTIFY_PROP: "false", model.PUSH_NOTIFY_PROP: model.USER_NOTIFY_ALL}}, false)
+		require.Nil(t, err)
+		assert.Equal(t, model.USER_NOTIFY_ALL, ruser.NotifyProps[model.PUSH_NOTIFY_PROP])
+
+		props := model.CopyStringMap(ruser.NotifyProps)
+		props[model.EMAIL_NOTIFY_PROP] = "true"
+		_, err = th.App.UpdateUserNotifyProps(user.Id, props)
+		require.NotNil(t, err)
+		assert.Equal(t, "api.user.update_notify_props.enforced.app_error", err.Id)
+	})
+
+	t.Run("enforced settings can't be overridden in channels", func(t *testing.T) {
+		th.InitBasic()
+
+		_, err := th.App.UpdateChannelMemberNotifyProps(map[string]string{model.EMAIL_NOTIFY_PROP: "true"}, th.BasicChannel.Id, th.BasicUser.Id)
+		require.NotNil(t, err)
+		assert.Equal(t, "api.user.update_notify_props.enforced.app_error", err.Id)
+
+		_, err = th.App.UpdateChannelMemberNotifyProps(map[string]string{model.EMAIL_NOTIFY_PROP: model.CHANNEL_NOTIFY_DEFAULT, model.PUSH_NOTIFY_PROP: model.USER_NOTIFY_ALL}, th.BasicChannel.Id, th.BasicUser.Id)
+		require.Nil(t, err)
+	})
+
+	t.Run("enforced settings apply to notifications", func(t *testing.T) {
+		user := &model.User{Id: model.NewId(), NotifyProps: model.StringMap{model.EMAIL_NOTIFY_PROP: "true"}}
+		profileMap := map[string]*model.User{user.Id: user}
+		channelNotifyPropsMap := map[string]model.StringMap{user.Id: {model.EMAIL_NOTIFY_PROP: "true"}}
+
+		profiles, channelNotifyProps := th.App.enforceNotifyProps(profileMap, channelNotifyPropsMap)
+		assert.Equal(t, "false", profiles[user.Id].NotifyProps[model.EMAIL_NOTIFY_PROP])
+		assert.Equal(t, model.CHANNEL_NOTIFY_DEFAULT, channelNotifyProps[user.Id][model.EMAIL_NOTIFY_PROP])
+
+		assert.Equal(t, "true", user.NotifyProps[model.EMAIL_NOTIFY_PROP], "the originals may be cached and shouldn't be changed")
+		assert.Equal(t, "true", channelNotifyPropsMap[user.Id][model.EMAIL_NOTIFY_PROP])
+	})
+}
+
 func TestUpdateOAuthUserAttrs(t *testing.T) {
 	th := Setup()
 	defer th.TearDown()
@@ -16,14 +16,21 @@ import (
 )
 
 const (
-	SEND_QUEUE_SIZE           = 256
-	SEND_SLOW_WARN            = (SEND_QUEUE_SIZE * 50) / 100
-	SEND_DEADLOCK_WARN        = (SEND_QUEUE_SIZE * 95) / 100
-	WRITE_WAIT                = 30 * time.Second
-	PONG_WAIT                 = 100 * time.Second
-	PING_PERIOD               = (PONG_WAIT * 6) / 10
-	AUTH_TIMEOUT              = 5 * time.Second
-	WEBCONN_MEMBER_CACHE_TIME = 1000 * 60 * 30 // 30 minutes
+	SEND_SLOW_WARN_PERCENT     = 50
+	SEND_DEADLOCK_WARN_PERCENT = 95
+	WRITE_WAIT                 = 30 * time.Second
+	PONG_WAIT                  = 100 * time.Second
+	PING_PERIOD                = (PONG_WAIT * 6) / 10
+	AUTH_TIMEOUT               = 5 * time.Second
+	WEBCONN_MEMBER_CACHE_TIME  = 1000 * 60 * 30 // 30 minutes
+
+	// WEBCONN_EVENT_DEDUP_WINDOW is the number of recently sent events that each connection remembers to avoid
+	// delivering the same event twice.
+	WEBCONN_EVENT_DEDUP_WINDOW = 128
+
+	// WEBCONN_EVICTED_CLOSE_REASON is sent along with a close message to clients that have fallen too far behind, so
+	// that they reconnect and reload their state instead of carrying on with missing events.
+	WEBCONN_EVICTED_CLOSE_REASON = "slow consumer"
 )
 
 type WebConn struct {
@@ -40,6 +47,9 @@ type WebConn struct {
 	AllChannelMembers         map[string]string
 	LastAllChannelMembersTime int64
 	Sequence                  int64
+	recentEventIds            *recentEventIds
+	droppedEvents             int
+	evicted                   int32
 	closeOnce                 sync.Once
 	endWritePump              chan struct{}
 	pumpFinished              chan struct{}
@@ -55,7 +65,7 @@ func (a *App) NewWebConn(ws *websocket.Conn, session model.Session, t goi18n.Tra
 
 	wc := &WebConn{
 		App:                a,
-		Send:               make(chan model.WebSocketMessage, SEND_QUEUE_SIZE),
+		Send:               make(chan model.WebSocketMessage, *a.Config().ServiceSettings.WebsocketSendQueueSize),
 		WebSocket:          ws,
 		LastUserActivityAt: model.GetMillis(),
 		UserId:             session.UserId,
@@ -168,15 +178,20 @@ func (c *WebConn) writePump() {
 		select {
 		case msg, ok := <-c.Send:
 			if !ok {
+				closeMessage := []byte{}
+				if c.isEvicted() {
+					closeMessage = websocket.FormatCloseMessage(websocket.CloseTryAgainLater, WEBCONN_EVICTED_CLOSE_REASON)
+				}
+
 				c.WebSocket.SetWriteDeadline(time.Now().Add(WRITE_WAIT))
-				c.WebSocket.WriteMessage(websocket.CloseMessage, []byte{})
+				c.WebSocket.WriteMessage(websocket.CloseMessage, closeMessage)
 				return
 			}
 
 			evt, evtOk := msg.(*model.WebSocketEvent)
 
 			skipSend := false
-			if len(c.Send) >= SEND_SLOW_WARN {
+			if len(c.Send) >= cap(c.Send)*SEND_SLOW_WARN_PERCENT/100 {
 				// When the pump starts to get slow we'll drop non-critical messages
 				if msg.EventType() == model.WEBSOCKET_EVENT_TYPING ||
 					msg.EventType() == model.WEBSOCKET_EVENT_STATUS_CHANGE ||
@@ -198,7 +213,7 @@ func (c *WebConn) writePump() {
 					msgBytes = []byte(msg.ToJson())
 				}
 
-				if len(c.Send) >= SEND_DEADLOCK_WARN {
+				if len(c.Send) >= cap(c.Send)*SEND_DEADLOCK_WARN_PERCENT/100 {
 					if evtOk {
 						mlog.Error(fmt.Sprintf("websocket.full: message userId=%v type=%v channelId=%v size=%v", c.UserId, msg.EventType(), evt.Broadcast.ChannelId, len(msg.ToJson())))
 					} else {
@@ -249,6 +264,65 @@ func (c *WebConn) writePump() {
 	}
 }
 
+// enqueue queues a message to be sent to the client without blocking the hub. If the client has fallen behind and its
+// queue is full, the oldest queued message is dropped to make room when ServiceSettings.WebsocketSlowConsumerPolicy
+// allows it. It returns false if the client should be evicted instead, either because messages can't be dropped or
+// because too many have been dropped in a row. It must only be called from the goroutine of the hub that the
+// connection is registered with.
+func (webCon *WebConn) enqueue(msg model.WebSocketMessage) bool {
+	select {
+	case webCon.Send <- msg:
+		webCon.droppedEvents = 0
+		return true
+	default:
+	}
+
+	settings := webCon.App.Config().ServiceSettings
+	if *settings.WebsocketSlowConsumerPolicy != model.WEBSOCKET_SLOW_CONSUMER_POLICY_DROP_OLDEST {
+		return false
+	}
+
+	webCon.droppedEvents++
+	if webCon.droppedEvents > *settings.WebsocketMaxDroppedEvents {
+		return false
+	}
+
+	select {
+	case dropped := <-webCon.Send:
+		mlog.Debug("websocket.slow: dropped the oldest queued message", mlog.String("user_id", webCon.UserId), mlog.String("type", dropped.EventType()))
+		if webCon.App.Metrics != nil {
+			webCon.App.Metrics.IncrementWebSocketEventDropped(dropped.EventType())
+		}
+	default:
+	}
+
+	select {
+	case webCon.Send <- msg:
+	default:
+		// The queue was refilled by a response to a request from the client in the meantime
+		if webCon.App.Metrics != nil {
+			webCon.App.Metrics.IncrementWebSocketEventDropped(msg.EventType())
+		}
+	}
+
+	return true
+}
+
+// evict disconnects a client that has fallen too far behind, asking it to reconnect once the queued messages are sent.
+// It must only be called from the goroutine of the hub that the connection is registered with.
+func (webCon *WebConn) evict() {
+	atomic.StoreInt32(&webCon.evicted, 1)
+	close(webCon.Send)
+
+	if webCon.App.Metrics != nil {
+		webCon.App.Metrics.IncrementWebSocketSlowConsumerEviction()
+	}
+}
+
+func (webCon *WebConn) isEvicted() bool {
+	return atomic.LoadInt32(&webCon.evicted) == 1
+}
+
 func (webCon *WebConn) InvalidateCache() {
 	webCon.AllChannelMembers = nil
 	webCon.LastAllChannelMembersTime = 0
@@ -282,6 +356,9 @@ func (webCon *WebConn) IsAuthenticated() bool {
 func (webCon *WebConn) SendHello() {
 	msg := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_HELLO, "", "", webCon.UserId, nil)
 	msg.Add("server_version", fmt.Sprintf("%v.%v.%v.%v", model.CurrentVersion, model.BuildNumber, webCon.App.ClientConfigHash(), webCon.App.License() != nil))
+	if nodeId := webCon.App.ExposedNodeId(); nodeId != "" {
+		msg.Add("node_id", nodeId)
+	}
 	webCon.Send <- msg
 }
 
@@ -379,3 +456,48 @@ func (webCon *WebConn) IsMemberOfTeam(teamId string) bool {
 	}
 	return false
 }
+
+// isDuplicateEvent records that the event is being sent over the connection and returns whether it already was. It
+// must only be called by the connection's hub.
+func (webCon *WebConn) isDuplicateEvent(msg *model.WebSocketEvent) bool {
+	if msg.Id == "" {
+		return false
+	}
+
+	if webCon.recentEventIds == nil {
+		webCon.recentEventIds = newRecentEventIds(WEBCONN_EVENT_DEDUP_WINDOW)
+	}
+
+	return !webCon.recentEventIds.add(msg.Id)
+}
+
+// recentEventIds remembers the ids of the last few events sent over a connection.
+type recentEventIds struct {
+	ids  []string
+	seen map[string]bool
+	next int
+}
+
+func newRecentEventIds(size int) *recentEventIds {
+	return &recentEventIds{
+		ids:  make([]string, size),
+		seen: make(map[string]bool, size),
+	}
+}
+
+// add records the id, forgetting the oldest one if the window is full, and returns false if it was already recorded.
+func (r *recentEventIds) add(id string) bool {
+	if r.seen[id] {
+		return false
+	}
+
+	if oldest := r.ids[r.next]; oldest != "" {
+		delete(r.seen, oldest)
+	}
+
+	r.ids[r.next] = id
+	r.seen[id] = true
+	r.next = (r.next + 1) % len(r.ids)
+
+	return true
+}
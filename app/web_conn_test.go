@@ -80,3 +80,66 @@ func TestWebConnShouldSendEvent(t *testing.T) {
 		assert.Equal(t, c.AdminExpected, adminUserWc.ShouldSendEvent(event), c.Description)
 	}
 }
+
+func TestWebConnIsDuplicateEvent(t *testing.T) {
+	wc := &WebConn{}
+
+	event := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_POSTED, "", model.NewId(), "", nil)
+	assert.False(t, wc.isDuplicateEvent(event), "events without an id are never duplicates")
+	assert.False(t, wc.isDuplicateEvent(event))
+
+	event.Id = model.NewId()
+	assert.False(t, wc.isDuplicateEvent(event))
+
+	// The same event received again from another node.
+	assert.True(t, wc.isDuplicateEvent(&model.WebSocketEvent{Id: event.Id, Event: event.Event, Broadcast: event.Broadcast}))
+
+	for i := 0; i < WEBCONN_EVENT_DEDUP_WINDOW; i++ {
+		assert.False(t, wc.isDuplicateEvent(&model.WebSocketEvent{Id: model.NewId()}))
+	}
+
+	assert.False(t, wc.isDuplicateEvent(event), "events are forgotten once they fall out of the window")
+}
+
+func TestWebConnEnqueue(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	newEvent := func(event string) *model.WebSocketEvent {
+		return model.NewWebSocketEvent(event, "", model.NewId(), "", nil)
+	}
+
+	t.Run("disconnect", func(t *testing.T) {
+		wc := &WebConn{App: th.App, Send: make(chan model.WebSocketMessage, 2)}
+
+		assert.True(t, wc.enqueue(newEvent(model.WEBSOCKET_EVENT_POSTED)))
+		assert.True(t, wc.enqueue(newEvent(model.WEBSOCKET_EVENT_POSTED)))
+		assert.False(t, wc.enqueue(newEvent(model.WEBSOCKET_EVENT_POSTED)), "a full queue evicts the client")
+
+		wc.evict()
+		assert.True(t, wc.isEvicted())
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.WebsocketSlowConsumerPolicy = model.WEBSOCKET_SLOW_CONSUMER_POLICY_DROP_OLDEST
+			*cfg.ServiceSettings.WebsocketMaxDroppedEvents = 2
+		})
+
+		wc := &WebConn{App: th.App, Send: make(chan model.WebSocketMessage, 2)}
+
+		assert.True(t, wc.enqueue(newEvent(model.WEBSOCKET_EVENT_TYPING)))
+		assert.True(t, wc.enqueue(newEvent(model.WEBSOCKET_EVENT_POSTED)))
+		assert.True(t, wc.enqueue(newEvent(model.WEBSOCKET_EVENT_POST_EDITED)))
+
+		require.Len(t, wc.Send, 2)
+		assert.Equal(t, model.WEBSOCKET_EVENT_POSTED, (<-wc.Send).EventType(), "the oldest message was dropped")
+
+		// Catching up resets the count of dropped messages
+		assert.True(t, wc.enqueue(newEvent(model.WEBSOCKET_EVENT_POSTED)))
+
+		assert.True(t, wc.enqueue(newEvent(model.WEBSOCKET_EVENT_POSTED)))
+		assert.True(t, wc.enqueue(newEvent(model.WEBSOCKET_EVENT_POSTED)))
+		assert.False(t, wc.enqueue(newEvent(model.WEBSOCKET_EVENT_POSTED)), "too many dropped messages evict the client")
+	})
+}
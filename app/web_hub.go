@@ -162,6 +162,10 @@ func (a *App) Publish(message *model.WebSocketEvent) {
 		metrics.IncrementWebsocketEvent(message.Event)
 	}
 
+	if message.Id == "" {
+		message.Id = model.NewId()
+	}
+
 	a.PublishSkipClusterSend(message)
 
 	if a.Cluster != nil {
@@ -418,6 +422,10 @@ func (h *Hub) Start() {
 			case webCon := <-h.register:
 				connections.Add(webCon)
 				atomic.StoreInt64(&h.connectionCount, int64(len(connections.All())))
+
+				if len(webCon.UserId) > 0 && len(connections.ForUser(webCon.UserId)) == 1 {
+					h.app.UserConnectedToNode(webCon.UserId)
+				}
 			case webCon := <-h.unregister:
 				connections.Remove(webCon)
 				atomic.StoreInt64(&h.connectionCount, int64(len(connections.All())))
@@ -428,9 +436,7 @@ func (h *Hub) Start() {
 
 				conns := connections.ForUser(webCon.UserId)
 				if len(conns) == 0 {
-					h.app.Srv.Go(func() {
-						h.app.SetStatusOffline(webCon.UserId, false)
-					})
+					h.app.UserDisconnectedFromNode(webCon.UserId)
 				} else {
 					var latestActivity int64 = 0
 					for _, conn := range conns {
@@ -461,12 +467,10 @@ func (h *Hub) Start() {
 				}
 				msg.PrecomputeJSON()
 				for _, webCon := range candidates {
-					if webCon.ShouldSendEvent(msg) {
-						select {
-						case webCon.Send <- msg:
-						default:
+					if webCon.ShouldSendEvent(msg) && !webCon.isDuplicateEvent(msg) {
+						if !webCon.enqueue(msg) {
 							mlog.Error(fmt.Sprintf("webhub.broadcast: cannot send, closing websocket for userId=%v", webCon.UserId))
-							close(webCon.Send)
+							webCon.evict()
 							connections.Remove(webCon)
 						}
 					}
@@ -480,7 +484,7 @@ func (h *Hub) Start() {
 				}
 
 				for userId := range userIds {
-					h.app.SetStatusOffline(userId, false)
+					h.app.UserDisconnectedFromNode(userId)
 				}
 
 				h.ExplicitStop = true
@@ -157,7 +157,7 @@ func (a *App) doOutgoingWebhookRequest(url string, body io.Reader, contentType s
 	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := a.HTTPService.MakeClient(false).Do(req)
+	resp, err := a.HTTPService.MakeIntegrationClient(a.Config().IntegrationHTTPSettings.Webhooks).Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -290,13 +290,27 @@ func (a *App) CreateWebhookPost(userId string, channel *model.Channel, text, ove
 		}
 	}
 
-	splits, err := SplitWebhookPost(post, a.MaxPostSize())
+	// Every split is posted to the same channel, so its team is only looked up once to check their sizes.
+	var team *model.Team
+	if channel.MaxPostSize == 0 && channel.TeamId != "" {
+		var err *model.AppError
+		if team, err = a.GetTeam(channel.TeamId); err != nil {
+			return nil, err
+		}
+	}
+
+	maxPostSize, err := a.maxPostSizeForChannel(channel, team)
+	if err != nil {
+		return nil, err
+	}
+
+	splits, err := SplitWebhookPost(post, maxPostSize)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, split := range splits {
-		if _, err := a.CreatePostMissingChannel(split, false); err != nil {
+		if _, err := a.createPost(split, channel, team, false); err != nil {
 			return nil, model.NewAppError("CreateWebhookPost", "api.post.create_webhook_post.creating.app_error", nil, "err="+err.Message, http.StatusInternalServerError)
 		}
 	}
@@ -14,7 +14,6 @@ import (
 	"time"
 
 	"github.com/mattermost/mattermost-server/model"
-	"github.com/mattermost/mattermost-server/services/httpservice"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -724,18 +723,18 @@ func TestDoOutgoingWebhookRequest(t *testing.T) {
 	})
 
 	t.Run("with a slow response", func(t *testing.T) {
-		timeout := 100 * time.Millisecond
-
 		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			time.Sleep(timeout + time.Millisecond)
+			time.Sleep(time.Second + 100*time.Millisecond)
 			io.Copy(w, strings.NewReader(`{"text": "Hello, World!"}`))
 		}))
 		defer server.Close()
 
-		th.App.HTTPService.(*httpservice.HTTPServiceImpl).RequestTimeout = timeout
-		defer func() {
-			th.App.HTTPService.(*httpservice.HTTPServiceImpl).RequestTimeout = httpservice.RequestTimeout
-		}()
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.IntegrationHTTPSettings.Webhooks.RequestTimeoutSeconds = 1
+		})
+		defer th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.IntegrationHTTPSettings.Webhooks.RequestTimeoutSeconds = model.INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_REQUEST_TIMEOUT_SECONDS
+		})
 
 		_, err := th.App.doOutgoingWebhookRequest(server.URL, strings.NewReader(""), "application/json")
 		require.NotNil(t, err)
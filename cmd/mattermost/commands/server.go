@@ -11,6 +11,7 @@ import (
 
 	"github.com/mattermost/mattermost-server/api4"
 	"github.com/mattermost/mattermost-server/app"
+	"github.com/mattermost/mattermost-server/grpcapi"
 	"github.com/mattermost/mattermost-server/manualtesting"
 	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/utils"
@@ -82,6 +83,13 @@ func runServer(configFileLocation string, disableConfigWatch bool, usedPlatform
 	wsapi.Init(server.FakeApp(), server.WebSocketRouter)
 	web.New(server, server.AppOptions, server.Router)
 
+	grpcApi := grpcapi.New(server, server.AppOptions)
+	if err := grpcApi.Start(); err != nil {
+		mlog.Critical(err.Error())
+		return err
+	}
+	defer grpcApi.Stop()
+
 	// If we allow testing then listen for manual testing URL hits
 	if server.Config().ServiceSettings.EnableTesting {
 		manualtesting.Init(api)
@@ -0,0 +1,15 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package einterfaces
+
+import (
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// FirehoseWriterInterface delivers batches of events from the event firehose to a destination such as Kafka. A
+// batch that fails to be written is retried until it succeeds, so Write may be called more than once with the
+// same events.
+type FirehoseWriterInterface interface {
+	Write(events []*model.FirehoseEvent) error
+}
@@ -17,6 +17,9 @@ type MetricsInterface interface {
 	IncrementHttpRequest()
 	IncrementHttpError()
 	ObserveHttpRequestDuration(elapsed float64)
+	ObserveHttpRequestAuthDuration(route string, elapsed float64)
+	ObserveHttpRequestHandlerDuration(route string, elapsed float64)
+	ObserveHttpRequestTotalDuration(route string, elapsed float64)
 
 	IncrementClusterRequest()
 	ObserveClusterRequestDuration(elapsed float64)
@@ -37,10 +40,19 @@ type MetricsInterface interface {
 
 	IncrementWebsocketEvent(eventType string)
 	IncrementWebSocketBroadcast(eventType string)
+	IncrementWebSocketEventDropped(eventType string)
+	IncrementWebSocketSlowConsumerEviction()
 
 	AddMemCacheHitCounter(cacheName string, amount float64)
 	AddMemCacheMissCounter(cacheName string, amount float64)
 
 	IncrementPostsSearchCounter()
 	ObservePostsSearchDuration(elapsed float64)
+
+	SetJobQueueDepth(jobType string, depth float64)
+
+	SetLoadShedding(shedding bool)
+	IncrementHttpRequestShed()
+
+	IncrementFileIntegrityFailure(status string)
 }
@@ -4,6 +4,8 @@
 package einterfaces
 
 import (
+	"net/http"
+
 	"github.com/mattermost/mattermost-server/model"
 )
 
@@ -13,3 +15,9 @@ type MfaInterface interface {
 	Deactivate(userId string) *model.AppError
 	ValidateToken(secret, token string) (bool, *model.AppError)
 }
+
+// MfaVerifierInterface lets a custom second factor provider, such as a WebAuthn gateway, decide whether a request to
+// an endpoint that requires MFA may proceed, in place of the built-in check that the user has activated TOTP.
+type MfaVerifierInterface interface {
+	Verify(session *model.Session, r *http.Request) *model.AppError
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/mattermost/mattermost-server/app"
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/services/configservice"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+// API serves a subset of the REST API over gRPC for server-to-server integrations. Every call must be authenticated
+// with a personal access token, sent in the authorization metadata in the same way as the Authorization header of
+// the REST API.
+type API struct {
+	ConfigService       configservice.ConfigService
+	GetGlobalAppOptions app.AppOptionCreator
+
+	server   *grpc.Server
+	listener net.Listener
+}
+
+func New(configservice configservice.ConfigService, globalOptionsFunc app.AppOptionCreator) *API {
+	return &API{
+		ConfigService:       configservice,
+		GetGlobalAppOptions: globalOptionsFunc,
+	}
+}
+
+// Start listens on ServiceSettings.GrpcListenAddress, doing nothing if it isn't set. The TLS settings of the REST API
+// are used for it as well.
+func (api *API) Start() error {
+	config := api.ConfigService.Config()
+	if *config.ServiceSettings.GrpcListenAddress == "" {
+		return nil
+	}
+
+	options := []grpc.ServerOption{
+		grpc.UnaryInterceptor(api.authenticate),
+	}
+
+	if *config.ServiceSettings.ConnectionSecurity == model.CONN_SECURITY_TLS {
+		cert, err := tls.LoadX509KeyPair(*config.ServiceSettings.TLSCertFile, *config.ServiceSettings.TLSKeyFile)
+		if err != nil {
+			return errors.Wrap(err, "failed to load certificate for the gRPC API")
+		}
+
+		options = append(options, grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		})))
+	}
+
+	listener, err := net.Listen("tcp", *config.ServiceSettings.GrpcListenAddress)
+	if err != nil {
+		return errors.Wrap(err, "failed to listen for the gRPC API")
+	}
+
+	api.listener = listener
+	api.server = grpc.NewServer(options...)
+	api.server.RegisterService(&serviceDesc, api)
+
+	mlog.Info("Starting gRPC API", mlog.String("address", listener.Addr().String()))
+
+	go func() {
+		if err := api.server.Serve(listener); err != nil {
+			mlog.Error("gRPC API stopped unexpectedly", mlog.Err(err))
+		}
+	}()
+
+	return nil
+}
+
+// Addr returns the address that the API is listening on, or nil if it isn't running.
+func (api *API) Addr() net.Addr {
+	if api.listener == nil {
+		return nil
+	}
+	return api.listener.Addr()
+}
+
+func (api *API) Stop() {
+	if api.server != nil {
+		api.server.GracefulStop()
+		api.server = nil
+		api.listener = nil
+	}
+}
+
+type appContextKey struct{}
+
+// appFromContext returns the App created for a call by authenticate, with the session of the caller.
+func appFromContext(ctx context.Context) *app.App {
+	return ctx.Value(appContextKey{}).(*app.App)
+}
+
+// authenticate is a unary interceptor that looks up the session of the personal access token sent with a call,
+// rejecting the call if there isn't one, and creates the App that handles the call.
+func (api *API) authenticate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	a := app.New(api.GetGlobalAppOptions()...)
+	a.RequestId = model.NewId()
+	a.Path = info.FullMethod
+	a.T = utils.GetUserTranslations("")
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("accept-language"); len(values) > 0 {
+		a.AcceptLanguage = values[0]
+		a.T = utils.GetUserTranslations(strings.Split(values[0], ",")[0])
+	}
+	if values := md.Get("user-agent"); len(values) > 0 {
+		a.UserAgent = values[0]
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if addr, ok := p.Addr.(*net.TCPAddr); ok {
+			a.IpAddress = addr.IP.String()
+		}
+	}
+
+	token := parseAuthToken(md)
+	if token == "" {
+		return nil, api.toStatus(a, model.NewAppError("GrpcApi", "api.context.session_expired.app_error", nil, "token not provided", http.StatusUnauthorized))
+	}
+
+	session, err := a.GetSession(token)
+	if err != nil {
+		if err.StatusCode != http.StatusInternalServerError {
+			err = model.NewAppError("GrpcApi", "api.context.session_expired.app_error", nil, "token="+token, http.StatusUnauthorized)
+		}
+		return nil, api.toStatus(a, err)
+	}
+
+	if session.Props[model.SESSION_PROP_TYPE] != model.SESSION_TYPE_USER_ACCESS_TOKEN {
+		return nil, api.toStatus(a, model.NewAppError("GrpcApi", "api.grpc.user_access_token_required.app_error", nil, "", http.StatusUnauthorized))
+	}
+
+	// Sessions are cached, so a token that was used before tokens were disabled would otherwise keep working
+	if !*a.Config().ServiceSettings.EnableUserAccessTokens {
+		return nil, api.toStatus(a, model.NewAppError("GrpcApi", "api.context.session_expired.app_error", nil, "UserAccessToken", http.StatusUnauthorized))
+	}
+
+	a.Session = *session
+
+	resp, handlerErr := handler(context.WithValue(ctx, appContextKey{}, a), req)
+	if appErr, ok := handlerErr.(*model.AppError); ok {
+		return nil, api.toStatus(a, appErr)
+	}
+
+	return resp, handlerErr
+}
+
+func parseAuthToken(md metadata.MD) string {
+	values := md.Get(strings.ToLower(model.HEADER_AUTH))
+	if len(values) == 0 {
+		return ""
+	}
+
+	authHeader := values[0]
+	if len(authHeader) > 6 && strings.ToUpper(authHeader[0:6]) == model.HEADER_BEARER {
+		return strings.TrimSpace(authHeader[6:])
+	} else if len(authHeader) > 5 && strings.ToLower(authHeader[0:5]) == model.HEADER_TOKEN {
+		return strings.TrimSpace(authHeader[5:])
+	}
+
+	return ""
+}
+
+// toStatus converts an AppError into a gRPC status with the closest matching code, logging it the same way that
+// the REST API logs errors.
+func (api *API) toStatus(a *app.App, err *model.AppError) error {
+	err.Translate(a.T)
+	err.RequestId = a.RequestId
+
+	if err.StatusCode == http.StatusInternalServerError {
+		mlog.Error(err.SystemMessage(utils.TDefault), mlog.String("path", a.Path), mlog.String("request_id", a.RequestId), mlog.String("user_id", a.Session.UserId))
+	} else {
+		mlog.Debug(err.SystemMessage(utils.TDefault), mlog.String("path", a.Path), mlog.String("request_id", a.RequestId), mlog.String("user_id", a.Session.UserId))
+	}
+
+	return status.Error(codeForStatus(err.StatusCode), err.Message)
+}
+
+func codeForStatus(statusCode int) codes.Code {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	default:
+		return codes.Internal
+	}
+}
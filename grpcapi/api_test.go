@@ -0,0 +1,233 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package grpcapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/mattermost/mattermost-server/app"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+type TestHelper struct {
+	App    *app.App
+	Server *app.Server
+	API    *API
+	conn   *grpc.ClientConn
+
+	BasicUser    *model.User
+	BasicTeam    *model.Team
+	BasicChannel *model.Channel
+}
+
+func Setup(t *testing.T) *TestHelper {
+	mainHelper.Store.DropAllTables()
+
+	s, err := app.NewServer(app.StoreOverride(mainHelper.Store), app.DisableConfigWatch)
+	require.Nil(t, err)
+	a := s.FakeApp()
+	prevListenAddress := *a.Config().ServiceSettings.ListenAddress
+	a.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.ListenAddress = ":0" })
+	require.Nil(t, s.Start())
+	a.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.ListenAddress = prevListenAddress })
+
+	a.DoAdvancedPermissionsMigration()
+	a.DoEmojisPermissionsMigration()
+	a.DoPermissionsMigrations()
+
+	a.Srv.Store.MarkSystemRanUnitTests()
+
+	a.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.EnableUserAccessTokens = true
+		*cfg.ServiceSettings.GrpcListenAddress = "localhost:0"
+		*cfg.ServiceSettings.ConnectionSecurity = ""
+	})
+
+	api := New(s, s.AppOptions)
+	require.Nil(t, api.Start())
+
+	conn, err := grpc.Dial(api.Addr().String(), grpc.WithInsecure())
+	require.Nil(t, err)
+
+	th := &TestHelper{
+		App:    a,
+		Server: s,
+		API:    api,
+		conn:   conn,
+	}
+
+	user, appErr := a.CreateUser(&model.User{Email: model.NewId() + "success+test@simulator.amazonses.com", Nickname: "Corey Hulen", Password: "passwd1", EmailVerified: true, Roles: model.SYSTEM_USER_ROLE_ID})
+	require.Nil(t, appErr)
+
+	team, appErr := a.CreateTeam(&model.Team{DisplayName: "Name", Name: "z-z-" + model.NewId() + "a", Email: user.Email, Type: model.TEAM_OPEN})
+	require.Nil(t, appErr)
+	require.Nil(t, a.JoinUserToTeam(team, user, ""))
+
+	channel, appErr := a.CreateChannel(&model.Channel{DisplayName: "Test API Name", Name: "zz" + model.NewId() + "a", Type: model.CHANNEL_OPEN, TeamId: team.Id, CreatorId: user.Id}, true)
+	require.Nil(t, appErr)
+
+	th.BasicUser = user
+	th.BasicTeam = team
+	th.BasicChannel = channel
+
+	return th
+}
+
+func (th *TestHelper) TearDown() {
+	th.conn.Close()
+	th.API.Stop()
+	th.Server.Shutdown()
+}
+
+func (th *TestHelper) CreateClient(t *testing.T, user *model.User) *Client {
+	token, err := th.App.CreateUserAccessToken(&model.UserAccessToken{UserId: user.Id, Description: "grpc"})
+	require.Nil(t, err)
+
+	return NewClient(th.conn, token.Token)
+}
+
+func requireCode(t *testing.T, code codes.Code, err error) {
+	t.Helper()
+	require.NotNil(t, err)
+	assert.Equal(t, code, status.Code(err))
+}
+
+func TestAuthentication(t *testing.T) {
+	th := Setup(t)
+	defer th.TearDown()
+
+	req := &GetChannelRequest{ChannelId: th.BasicChannel.Id}
+
+	t.Run("no token", func(t *testing.T) {
+		_, err := NewClient(th.conn, "").GetChannel(context.Background(), req)
+		requireCode(t, codes.Unauthenticated, err)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		_, err := NewClient(th.conn, model.NewId()).GetChannel(context.Background(), req)
+		requireCode(t, codes.Unauthenticated, err)
+	})
+
+	t.Run("session token", func(t *testing.T) {
+		session, appErr := th.App.CreateSession(&model.Session{UserId: th.BasicUser.Id})
+		require.Nil(t, appErr)
+
+		_, err := NewClient(th.conn, session.Token).GetChannel(context.Background(), req)
+		requireCode(t, codes.Unauthenticated, err)
+	})
+
+	t.Run("personal access token", func(t *testing.T) {
+		channel, err := th.CreateClient(t, th.BasicUser).GetChannel(context.Background(), req)
+		require.Nil(t, err)
+		assert.Equal(t, th.BasicChannel.Id, channel.Id)
+		assert.Equal(t, th.BasicChannel.Name, channel.Name)
+		assert.Equal(t, th.BasicTeam.Id, channel.TeamId)
+	})
+	t.Run("personal access tokens disabled after the session is cached", func(t *testing.T) {
+		client := th.CreateClient(t, th.BasicUser)
+		_, err := client.GetChannel(context.Background(), req)
+		require.Nil(t, err)
+
+		th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableUserAccessTokens = false })
+		defer th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableUserAccessTokens = true })
+
+		_, err = client.GetChannel(context.Background(), req)
+		requireCode(t, codes.Unauthenticated, err)
+	})
+}
+
+func TestCreatePost(t *testing.T) {
+	th := Setup(t)
+	defer th.TearDown()
+
+	client := th.CreateClient(t, th.BasicUser)
+
+	post, err := client.CreatePost(context.Background(), &CreatePostRequest{ChannelId: th.BasicChannel.Id, Message: "hello from grpc"})
+	require.Nil(t, err)
+	assert.NotEmpty(t, post.Id)
+	assert.Equal(t, th.BasicUser.Id, post.UserId)
+	assert.Equal(t, "hello from grpc", post.Message)
+
+	reply, err := client.CreatePost(context.Background(), &CreatePostRequest{ChannelId: th.BasicChannel.Id, RootId: post.Id, Message: "reply"})
+	require.Nil(t, err)
+	assert.Equal(t, post.Id, reply.RootId)
+
+	_, err = client.CreatePost(context.Background(), &CreatePostRequest{ChannelId: "junk", Message: "hello"})
+	requireCode(t, codes.InvalidArgument, err)
+
+	privateChannel, appErr := th.App.CreateChannel(&model.Channel{DisplayName: "Private", Name: "zz" + model.NewId() + "a", Type: model.CHANNEL_PRIVATE, TeamId: th.BasicTeam.Id}, false)
+	require.Nil(t, appErr)
+
+	_, err = client.CreatePost(context.Background(), &CreatePostRequest{ChannelId: privateChannel.Id, Message: "hello"})
+	requireCode(t, codes.PermissionDenied, err)
+}
+
+func TestGetChannel(t *testing.T) {
+	th := Setup(t)
+	defer th.TearDown()
+
+	client := th.CreateClient(t, th.BasicUser)
+
+	_, err := client.GetChannel(context.Background(), &GetChannelRequest{ChannelId: model.NewId()})
+	requireCode(t, codes.NotFound, err)
+
+	privateChannel, appErr := th.App.CreateChannel(&model.Channel{DisplayName: "Private", Name: "zz" + model.NewId() + "a", Type: model.CHANNEL_PRIVATE, TeamId: th.BasicTeam.Id}, false)
+	require.Nil(t, appErr)
+
+	_, err = client.GetChannel(context.Background(), &GetChannelRequest{ChannelId: privateChannel.Id})
+	requireCode(t, codes.PermissionDenied, err)
+}
+
+func TestSearchPosts(t *testing.T) {
+	th := Setup(t)
+	defer th.TearDown()
+
+	client := th.CreateClient(t, th.BasicUser)
+
+	post, err := client.CreatePost(context.Background(), &CreatePostRequest{ChannelId: th.BasicChannel.Id, Message: "searchable grpc message"})
+	require.Nil(t, err)
+
+	results, err := client.SearchPosts(context.Background(), &SearchPostsRequest{TeamId: th.BasicTeam.Id, Terms: "searchable"})
+	require.Nil(t, err)
+	require.Len(t, results.Posts, 1)
+	assert.Equal(t, post.Id, results.Posts[0].Id)
+
+	_, err = client.SearchPosts(context.Background(), &SearchPostsRequest{TeamId: th.BasicTeam.Id})
+	requireCode(t, codes.InvalidArgument, err)
+
+	otherTeam, appErr := th.App.CreateTeam(&model.Team{DisplayName: "Other", Name: "z-z-" + model.NewId() + "a", Email: th.BasicUser.Email, Type: model.TEAM_OPEN})
+	require.Nil(t, appErr)
+
+	_, err = client.SearchPosts(context.Background(), &SearchPostsRequest{TeamId: otherTeam.Id, Terms: "searchable"})
+	requireCode(t, codes.PermissionDenied, err)
+}
+
+func TestSearchPostsRateLimit(t *testing.T) {
+	th := Setup(t)
+	defer th.TearDown()
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.SearchRateLimitSettings.Enable = true
+		*cfg.SearchRateLimitSettings.PerUserPerMinute = 1
+		*cfg.SearchRateLimitSettings.PerUserMaxBurst = 0
+	})
+
+	client := th.CreateClient(t, th.BasicUser)
+
+	_, err := client.SearchPosts(context.Background(), &SearchPostsRequest{TeamId: th.BasicTeam.Id, Terms: "searchable"})
+	require.Nil(t, err)
+
+	var header metadata.MD
+	_, err = client.SearchPosts(context.Background(), &SearchPostsRequest{TeamId: th.BasicTeam.Id, Terms: "searchable"}, grpc.Header(&header))
+	requireCode(t, codes.ResourceExhausted, err)
+	assert.NotEmpty(t, header.Get("retry-after"))
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package grpcapi
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// Client calls the gRPC API over an existing connection, authenticating with a personal access token.
+type Client struct {
+	conn  *grpc.ClientConn
+	token string
+}
+
+func NewClient(conn *grpc.ClientConn, token string) *Client {
+	return &Client{
+		conn:  conn,
+		token: token,
+	}
+}
+
+func (c *Client) invoke(ctx context.Context, methodName string, req interface{}, resp interface{}, opts ...grpc.CallOption) error {
+	ctx = metadata.AppendToOutgoingContext(ctx, strings.ToLower(model.HEADER_AUTH), model.HEADER_BEARER+" "+c.token)
+	return c.conn.Invoke(ctx, "/"+serviceName+"/"+methodName, req, resp, opts...)
+}
+
+func (c *Client) CreatePost(ctx context.Context, req *CreatePostRequest, opts ...grpc.CallOption) (*Post, error) {
+	resp := &Post{}
+	if err := c.invoke(ctx, "CreatePost", req, resp, opts...); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) GetChannel(ctx context.Context, req *GetChannelRequest, opts ...grpc.CallOption) (*Channel, error) {
+	resp := &Channel{}
+	if err := c.invoke(ctx, "GetChannel", req, resp, opts...); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) SearchPosts(ctx context.Context, req *SearchPostsRequest, opts ...grpc.CallOption) (*SearchPostsResponse, error) {
+	resp := &SearchPostsResponse{}
+	if err := c.invoke(ctx, "SearchPosts", req, resp, opts...); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package grpcapi
+
+import (
+	"github.com/golang/protobuf/proto"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// The messages of the gRPC API, as defined in mattermost.proto. They're written by hand rather than generated so
+// that building the server doesn't require protoc, and the protobuf tags on them must match the field numbers in
+// the definitions.
+
+type Post struct {
+	Id        string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	CreateAt  int64    `protobuf:"varint,2,opt,name=create_at,json=createAt,proto3" json:"create_at,omitempty"`
+	UpdateAt  int64    `protobuf:"varint,3,opt,name=update_at,json=updateAt,proto3" json:"update_at,omitempty"`
+	EditAt    int64    `protobuf:"varint,4,opt,name=edit_at,json=editAt,proto3" json:"edit_at,omitempty"`
+	DeleteAt  int64    `protobuf:"varint,5,opt,name=delete_at,json=deleteAt,proto3" json:"delete_at,omitempty"`
+	IsPinned  bool     `protobuf:"varint,6,opt,name=is_pinned,json=isPinned,proto3" json:"is_pinned,omitempty"`
+	UserId    string   `protobuf:"bytes,7,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ChannelId string   `protobuf:"bytes,8,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+	RootId    string   `protobuf:"bytes,9,opt,name=root_id,json=rootId,proto3" json:"root_id,omitempty"`
+	ParentId  string   `protobuf:"bytes,10,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	Message   string   `protobuf:"bytes,11,opt,name=message,proto3" json:"message,omitempty"`
+	Type      string   `protobuf:"bytes,12,opt,name=type,proto3" json:"type,omitempty"`
+	Hashtags  string   `protobuf:"bytes,13,opt,name=hashtags,proto3" json:"hashtags,omitempty"`
+	FileIds   []string `protobuf:"bytes,14,rep,name=file_ids,json=fileIds,proto3" json:"file_ids,omitempty"`
+}
+
+func (m *Post) Reset()         { *m = Post{} }
+func (m *Post) String() string { return proto.CompactTextString(m) }
+func (*Post) ProtoMessage()    {}
+
+type Channel struct {
+	Id            string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	CreateAt      int64  `protobuf:"varint,2,opt,name=create_at,json=createAt,proto3" json:"create_at,omitempty"`
+	UpdateAt      int64  `protobuf:"varint,3,opt,name=update_at,json=updateAt,proto3" json:"update_at,omitempty"`
+	DeleteAt      int64  `protobuf:"varint,4,opt,name=delete_at,json=deleteAt,proto3" json:"delete_at,omitempty"`
+	TeamId        string `protobuf:"bytes,5,opt,name=team_id,json=teamId,proto3" json:"team_id,omitempty"`
+	Type          string `protobuf:"bytes,6,opt,name=type,proto3" json:"type,omitempty"`
+	DisplayName   string `protobuf:"bytes,7,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Name          string `protobuf:"bytes,8,opt,name=name,proto3" json:"name,omitempty"`
+	Header        string `protobuf:"bytes,9,opt,name=header,proto3" json:"header,omitempty"`
+	Purpose       string `protobuf:"bytes,10,opt,name=purpose,proto3" json:"purpose,omitempty"`
+	LastPostAt    int64  `protobuf:"varint,11,opt,name=last_post_at,json=lastPostAt,proto3" json:"last_post_at,omitempty"`
+	TotalMsgCount int64  `protobuf:"varint,12,opt,name=total_msg_count,json=totalMsgCount,proto3" json:"total_msg_count,omitempty"`
+	CreatorId     string `protobuf:"bytes,13,opt,name=creator_id,json=creatorId,proto3" json:"creator_id,omitempty"`
+}
+
+func (m *Channel) Reset()         { *m = Channel{} }
+func (m *Channel) String() string { return proto.CompactTextString(m) }
+func (*Channel) ProtoMessage()    {}
+
+type CreatePostRequest struct {
+	ChannelId string   `protobuf:"bytes,1,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+	RootId    string   `protobuf:"bytes,2,opt,name=root_id,json=rootId,proto3" json:"root_id,omitempty"`
+	Message   string   `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	FileIds   []string `protobuf:"bytes,4,rep,name=file_ids,json=fileIds,proto3" json:"file_ids,omitempty"`
+}
+
+func (m *CreatePostRequest) Reset()         { *m = CreatePostRequest{} }
+func (m *CreatePostRequest) String() string { return proto.CompactTextString(m) }
+func (*CreatePostRequest) ProtoMessage()    {}
+
+type GetChannelRequest struct {
+	ChannelId string `protobuf:"bytes,1,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+}
+
+func (m *GetChannelRequest) Reset()         { *m = GetChannelRequest{} }
+func (m *GetChannelRequest) String() string { return proto.CompactTextString(m) }
+func (*GetChannelRequest) ProtoMessage()    {}
+
+type SearchPostsRequest struct {
+	TeamId                 string `protobuf:"bytes,1,opt,name=team_id,json=teamId,proto3" json:"team_id,omitempty"`
+	Terms                  string `protobuf:"bytes,2,opt,name=terms,proto3" json:"terms,omitempty"`
+	IsOrSearch             bool   `protobuf:"varint,3,opt,name=is_or_search,json=isOrSearch,proto3" json:"is_or_search,omitempty"`
+	IncludeDeletedChannels bool   `protobuf:"varint,4,opt,name=include_deleted_channels,json=includeDeletedChannels,proto3" json:"include_deleted_channels,omitempty"`
+	TimeZoneOffset         int32  `protobuf:"varint,5,opt,name=time_zone_offset,json=timeZoneOffset,proto3" json:"time_zone_offset,omitempty"`
+	Page                   int32  `protobuf:"varint,6,opt,name=page,proto3" json:"page,omitempty"`
+	PerPage                int32  `protobuf:"varint,7,opt,name=per_page,json=perPage,proto3" json:"per_page,omitempty"`
+}
+
+func (m *SearchPostsRequest) Reset()         { *m = SearchPostsRequest{} }
+func (m *SearchPostsRequest) String() string { return proto.CompactTextString(m) }
+func (*SearchPostsRequest) ProtoMessage()    {}
+
+type SearchPostsResponse struct {
+	Posts []*Post `protobuf:"bytes,1,rep,name=posts,proto3" json:"posts,omitempty"`
+}
+
+func (m *SearchPostsResponse) Reset()         { *m = SearchPostsResponse{} }
+func (m *SearchPostsResponse) String() string { return proto.CompactTextString(m) }
+func (*SearchPostsResponse) ProtoMessage()    {}
+
+func postFromModel(post *model.Post) *Post {
+	return &Post{
+		Id:        post.Id,
+		CreateAt:  post.CreateAt,
+		UpdateAt:  post.UpdateAt,
+		EditAt:    post.EditAt,
+		DeleteAt:  post.DeleteAt,
+		IsPinned:  post.IsPinned,
+		UserId:    post.UserId,
+		ChannelId: post.ChannelId,
+		RootId:    post.RootId,
+		ParentId:  post.ParentId,
+		Message:   post.Message,
+		Type:      post.Type,
+		Hashtags:  post.Hashtags,
+		FileIds:   post.FileIds,
+	}
+}
+
+func channelFromModel(channel *model.Channel) *Channel {
+	return &Channel{
+		Id:            channel.Id,
+		CreateAt:      channel.CreateAt,
+		UpdateAt:      channel.UpdateAt,
+		DeleteAt:      channel.DeleteAt,
+		TeamId:        channel.TeamId,
+		Type:          channel.Type,
+		DisplayName:   channel.DisplayName,
+		Name:          channel.Name,
+		Header:        channel.Header,
+		Purpose:       channel.Purpose,
+		LastPostAt:    channel.LastPostAt,
+		TotalMsgCount: channel.TotalMsgCount,
+		CreatorId:     channel.CreatorId,
+	}
+}
+
+func (r *CreatePostRequest) toModel() *model.Post {
+	return &model.Post{
+		ChannelId: r.ChannelId,
+		RootId:    r.RootId,
+		Message:   r.Message,
+		FileIds:   r.FileIds,
+	}
+}
+
+func postListFromModel(list *model.PostList) []*Post {
+	posts := make([]*Post, 0, len(list.Order))
+	for _, postId := range list.Order {
+		if post, ok := list.Posts[postId]; ok {
+			posts = append(posts, postFromModel(post))
+		}
+	}
+	return posts
+}
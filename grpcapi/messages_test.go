@@ -0,0 +1,68 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package grpcapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestPostFromModel(t *testing.T) {
+	post := &model.Post{
+		Id:        model.NewId(),
+		CreateAt:  1234,
+		UserId:    model.NewId(),
+		ChannelId: model.NewId(),
+		Message:   "hello",
+		FileIds:   model.StringArray{model.NewId()},
+		IsPinned:  true,
+	}
+
+	data, err := proto.Marshal(postFromModel(post))
+	require.Nil(t, err)
+
+	decoded := &Post{}
+	require.Nil(t, proto.Unmarshal(data, decoded))
+	assert.Equal(t, post.Id, decoded.Id)
+	assert.Equal(t, post.CreateAt, decoded.CreateAt)
+	assert.Equal(t, post.UserId, decoded.UserId)
+	assert.Equal(t, post.ChannelId, decoded.ChannelId)
+	assert.Equal(t, post.Message, decoded.Message)
+	assert.Equal(t, []string(post.FileIds), decoded.FileIds)
+	assert.True(t, decoded.IsPinned)
+}
+
+func TestSearchPostsResponse(t *testing.T) {
+	list := model.NewPostList()
+	first := &model.Post{Id: model.NewId(), Message: "first"}
+	second := &model.Post{Id: model.NewId(), Message: "second"}
+	list.AddPost(first)
+	list.AddPost(second)
+	list.AddOrder(second.Id)
+	list.AddOrder(first.Id)
+
+	data, err := proto.Marshal(&SearchPostsResponse{Posts: postListFromModel(list)})
+	require.Nil(t, err)
+
+	decoded := &SearchPostsResponse{}
+	require.Nil(t, proto.Unmarshal(data, decoded))
+	require.Len(t, decoded.Posts, 2)
+	assert.Equal(t, second.Id, decoded.Posts[0].Id)
+	assert.Equal(t, first.Id, decoded.Posts[1].Id)
+}
+
+func TestCodeForStatus(t *testing.T) {
+	assert.Equal(t, codes.InvalidArgument, codeForStatus(http.StatusBadRequest))
+	assert.Equal(t, codes.Unauthenticated, codeForStatus(http.StatusUnauthorized))
+	assert.Equal(t, codes.PermissionDenied, codeForStatus(http.StatusForbidden))
+	assert.Equal(t, codes.NotFound, codeForStatus(http.StatusNotFound))
+	assert.Equal(t, codes.Internal, codeForStatus(http.StatusInternalServerError))
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package grpcapi
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/mattermost/mattermost-server/app"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const (
+	SEARCH_POSTS_DEFAULT_PER_PAGE = 60
+
+	serviceName = "mattermost.api.v1.Mattermost"
+)
+
+type mattermostServer interface {
+	createPost(ctx context.Context, req *CreatePostRequest) (*Post, error)
+	getChannel(ctx context.Context, req *GetChannelRequest) (*Channel, error)
+	searchPosts(ctx context.Context, req *SearchPostsRequest) (*SearchPostsResponse, error)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*mattermostServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreatePost",
+			Handler: unaryHandler("CreatePost", func() interface{} { return &CreatePostRequest{} }, func(srv mattermostServer, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.createPost(ctx, req.(*CreatePostRequest))
+			}),
+		},
+		{
+			MethodName: "GetChannel",
+			Handler: unaryHandler("GetChannel", func() interface{} { return &GetChannelRequest{} }, func(srv mattermostServer, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.getChannel(ctx, req.(*GetChannelRequest))
+			}),
+		},
+		{
+			MethodName: "SearchPosts",
+			Handler: unaryHandler("SearchPosts", func() interface{} { return &SearchPostsRequest{} }, func(srv mattermostServer, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.searchPosts(ctx, req.(*SearchPostsRequest))
+			}),
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "mattermost.proto",
+}
+
+// unaryHandler builds the handler of a method in the same way that protoc would generate it, decoding the request
+// and passing it through the interceptor that authenticates the call.
+func unaryHandler(methodName string, newRequest func() interface{}, call func(srv mattermostServer, ctx context.Context, req interface{}) (interface{}, error)) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := newRequest()
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+
+		if interceptor == nil {
+			return call(srv.(mattermostServer), ctx, req)
+		}
+
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: "/" + serviceName + "/" + methodName,
+		}
+		return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return call(srv.(mattermostServer), ctx, req)
+		})
+	}
+}
+
+func newPermissionError(a *app.App, permission *model.Permission) *model.AppError {
+	return model.NewAppError("Permissions", "api.context.permissions.app_error", nil, "userId="+a.Session.UserId+", "+"permission="+permission.Id, http.StatusForbidden)
+}
+
+func newInvalidParamError(parameter string) *model.AppError {
+	return model.NewAppError("GrpcApi", "api.context.invalid_body_param.app_error", map[string]interface{}{"Name": parameter}, "", http.StatusBadRequest)
+}
+
+// The methods below return an error only when it's an *model.AppError, so that authenticate can convert it into a
+// status. They're checked in the same way as their REST API equivalents.
+
+func (api *API) createPost(ctx context.Context, req *CreatePostRequest) (*Post, error) {
+	a := appFromContext(ctx)
+
+	if !model.IsValidId(req.ChannelId) {
+		return nil, newInvalidParamError("channel_id")
+	}
+
+	post := req.toModel()
+	post.UserId = a.Session.UserId
+
+	hasPermission := false
+	if a.SessionHasPermissionToChannel(a.Session, post.ChannelId, model.PERMISSION_CREATE_POST) {
+		hasPermission = true
+	} else if channel, err := a.GetChannel(post.ChannelId); err == nil {
+		// Temporary permission check method until advanced permissions, please do not copy
+		if channel.Type == model.CHANNEL_OPEN && a.SessionHasPermissionToTeam(a.Session, channel.TeamId, model.PERMISSION_CREATE_POST_PUBLIC) {
+			hasPermission = true
+		}
+	}
+
+	if !hasPermission {
+		return nil, newPermissionError(a, model.PERMISSION_CREATE_POST)
+	}
+
+	rp, err := a.CreatePostAsUser(a.PostWithProxyRemovedFromImageURLs(post), true)
+	if err != nil {
+		return nil, err
+	}
+
+	a.UpdateLastActivityAtIfNeeded(a.Session)
+
+	return postFromModel(rp), nil
+}
+
+func (api *API) getChannel(ctx context.Context, req *GetChannelRequest) (*Channel, error) {
+	a := appFromContext(ctx)
+
+	if !model.IsValidId(req.ChannelId) {
+		return nil, newInvalidParamError("channel_id")
+	}
+
+	channel, err := a.GetChannel(req.ChannelId)
+	if err != nil {
+		return nil, err
+	}
+
+	if channel.Type == model.CHANNEL_OPEN {
+		if !a.SessionHasPermissionToTeam(a.Session, channel.TeamId, model.PERMISSION_READ_PUBLIC_CHANNEL) {
+			return nil, newPermissionError(a, model.PERMISSION_READ_PUBLIC_CHANNEL)
+		}
+	} else {
+		if !a.SessionHasPermissionToChannel(a.Session, channel.Id, model.PERMISSION_READ_CHANNEL) {
+			return nil, newPermissionError(a, model.PERMISSION_READ_CHANNEL)
+		}
+	}
+
+	return channelFromModel(channel), nil
+}
+
+func (api *API) searchPosts(ctx context.Context, req *SearchPostsRequest) (*SearchPostsResponse, error) {
+	a := appFromContext(ctx)
+
+	if !model.IsValidId(req.TeamId) {
+		return nil, newInvalidParamError("team_id")
+	}
+
+	if req.Terms == "" {
+		return nil, newInvalidParamError("terms")
+	}
+
+	if !a.SessionHasPermissionToTeam(a.Session, req.TeamId, model.PERMISSION_VIEW_TEAM) {
+		return nil, newPermissionError(a, model.PERMISSION_VIEW_TEAM)
+	}
+
+	// Searches are limited in the same way as those made through the REST API, with the time to wait before searching
+	// again sent as the retry-after header
+	if limited, retryAfter := a.Srv.RateLimitSearch(a.Session.UserId); limited {
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))))
+		return nil, model.NewAppError("GrpcApi", "api.context.search_rate_limited.app_error", nil, "", http.StatusTooManyRequests)
+	}
+
+	perPage := int(req.PerPage)
+	if perPage <= 0 {
+		perPage = SEARCH_POSTS_DEFAULT_PER_PAGE
+	}
+
+	results, err := a.SearchPostsInTeam(req.Terms, a.Session.UserId, req.TeamId, req.IsOrSearch, req.IncludeDeletedChannels, false, int(req.TimeZoneOffset), int(req.Page), perPage)
+	if metrics := a.Metrics; metrics != nil {
+		metrics.IncrementPostsSearchCounter()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &SearchPostsResponse{
+		Posts: postListFromModel(a.PreparePostListForClient(results.PostList)),
+	}, nil
+}
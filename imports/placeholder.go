@@ -6,6 +6,16 @@ package imports
 // This is a placeholder so this package can be imported in Team Edition when it will be otherwise empty
 
 import (
+	_ "github.com/mattermost/mattermost-server/jobs/coldstorage"
+	_ "github.com/mattermost/mattermost-server/jobs/countrepair"
+	_ "github.com/mattermost/mattermost-server/jobs/elasticsearchreindex"
+	_ "github.com/mattermost/mattermost-server/jobs/expirepins"
+	_ "github.com/mattermost/mattermost-server/jobs/fileintegrityscan"
+	_ "github.com/mattermost/mattermost-server/jobs/inactivechannels"
+	_ "github.com/mattermost/mattermost-server/jobs/inactiveusers"
+	_ "github.com/mattermost/mattermost-server/jobs/mergeteams"
+	_ "github.com/mattermost/mattermost-server/jobs/movechannel"
+	_ "github.com/mattermost/mattermost-server/jobs/pluginjobs"
 	_ "github.com/mattermost/mattermost-server/migrations"
 	_ "github.com/mattermost/mattermost-server/plugin/scheduler"
 )
@@ -0,0 +1,78 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// jobAffinity decides whether this server should run a job according to JobSettings.JobNodeAffinity. A job type with
+// an affinity is only run by servers tagged with it through ClusterSettings.NodeTags, unless none of them are online,
+// in which case any server may run it.
+type jobAffinity struct {
+	srv *JobServer
+
+	enabled     bool
+	affinity    map[string]string
+	localTags   []string
+	clusterName string
+
+	// onlineTags is only loaded once a job that this server isn't tagged for is found.
+	onlineTags map[string]bool
+}
+
+func (srv *JobServer) newJobAffinity() *jobAffinity {
+	cfg := srv.Config()
+
+	return &jobAffinity{
+		srv:         srv,
+		enabled:     *cfg.ClusterSettings.Enable && len(cfg.JobSettings.JobNodeAffinity) > 0,
+		affinity:    cfg.JobSettings.JobNodeAffinity,
+		localTags:   cfg.ClusterSettings.NodeTags,
+		clusterName: *cfg.ClusterSettings.ClusterName,
+	}
+}
+
+func (a *jobAffinity) canRun(job *model.Job) bool {
+	if !a.enabled {
+		return true
+	}
+
+	tag, ok := a.affinity[job.Type]
+	if !ok {
+		return true
+	}
+
+	for _, localTag := range a.localTags {
+		if localTag == tag {
+			return true
+		}
+	}
+
+	return !a.isTagOnline(tag)
+}
+
+func (a *jobAffinity) isTagOnline(tag string) bool {
+	if a.onlineTags == nil {
+		a.onlineTags = make(map[string]bool)
+
+		result := <-a.srv.Store.ClusterDiscovery().GetAll(model.CDS_TYPE_APP, a.clusterName)
+		if result.Err != nil {
+			// Rather than leaving jobs stuck, fall back to running them anywhere.
+			mlog.Error(fmt.Sprintf("Error occurred getting cluster nodes for job affinity: %v", result.Err.Error()))
+			return false
+		}
+
+		for _, node := range result.Data.([]*model.ClusterDiscovery) {
+			for _, t := range node.Tags {
+				a.onlineTags[t] = true
+			}
+		}
+	}
+
+	return a.onlineTags[tag]
+}
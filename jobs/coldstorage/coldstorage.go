@@ -0,0 +1,19 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package coldstorage
+
+import (
+	"github.com/mattermost/mattermost-server/app"
+	tjobs "github.com/mattermost/mattermost-server/jobs/interfaces"
+)
+
+type ColdStorageJobInterfaceImpl struct {
+	App *app.App
+}
+
+func init() {
+	app.RegisterJobsColdStorageJobInterface(func(a *app.App) tjobs.ColdStorageJobInterface {
+		return &ColdStorageJobInterfaceImpl{a}
+	})
+}
@@ -0,0 +1,19 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package countrepair
+
+import (
+	"github.com/mattermost/mattermost-server/app"
+	tjobs "github.com/mattermost/mattermost-server/jobs/interfaces"
+)
+
+type CountRepairJobInterfaceImpl struct {
+	App *app.App
+}
+
+func init() {
+	app.RegisterJobsCountRepairJobInterface(func(a *app.App) tjobs.CountRepairJobInterface {
+		return &CountRepairJobInterfaceImpl{a}
+	})
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package countrepair
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/app"
+	"github.com/mattermost/mattermost-server/jobs"
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+type Worker struct {
+	name      string
+	stop      chan bool
+	stopped   chan bool
+	jobs      chan model.Job
+	jobServer *jobs.JobServer
+	app       *app.App
+}
+
+func (m *CountRepairJobInterfaceImpl) MakeWorker() model.Worker {
+	worker := Worker{
+		name:      "CountRepair",
+		stop:      make(chan bool, 1),
+		stopped:   make(chan bool, 1),
+		jobs:      make(chan model.Job),
+		jobServer: m.App.Srv.Jobs,
+		app:       m.App,
+	}
+
+	return &worker
+}
+
+func (worker *Worker) Run() {
+	mlog.Debug("Worker started", mlog.String("worker", worker.name))
+
+	defer func() {
+		mlog.Debug("Worker finished", mlog.String("worker", worker.name))
+		worker.stopped <- true
+	}()
+
+	for {
+		select {
+		case <-worker.stop:
+			mlog.Debug("Worker received stop signal", mlog.String("worker", worker.name))
+			return
+		case job := <-worker.jobs:
+			mlog.Debug("Worker received a new candidate job.", mlog.String("worker", worker.name))
+			if interrupted := worker.DoJob(&job); interrupted {
+				mlog.Debug("Worker received stop signal", mlog.String("worker", worker.name))
+				return
+			}
+		}
+	}
+}
+
+func (worker *Worker) Stop() {
+	mlog.Debug("Worker stopping", mlog.String("worker", worker.name))
+	worker.stop <- true
+	<-worker.stopped
+}
+
+func (worker *Worker) JobChannel() chan<- model.Job {
+	return worker.jobs
+}
+
+// DoJob checks and repairs the message counts in the scope given in the job's data, from the job's checkpoint if it
+// has one. It returns true if the worker was stopped in the meantime, in which case the job is put back in the queue to
+// be resumed later.
+func (worker *Worker) DoJob(job *model.Job) bool {
+	if claimed, err := worker.jobServer.ClaimJob(job); err != nil {
+		mlog.Info("Worker experienced an error while trying to claim job",
+			mlog.String("worker", worker.name),
+			mlog.String("job_id", job.Id),
+			mlog.String("error", err.Error()))
+		return false
+	} else if !claimed {
+		return false
+	}
+
+	request := &model.CountRepairRequest{
+		TeamId:    job.Data["team_id"],
+		ChannelId: job.Data["channel_id"],
+		DryRun:    job.Data["dry_run"] == "true",
+	}
+
+	var checkpoint *model.CountRepairCheckpoint
+	if data, ok := job.Data[model.JOB_DATA_CHECKPOINT]; ok {
+		checkpoint = model.CountRepairCheckpointFromJson(strings.NewReader(data))
+		mlog.Info("Worker: Resuming job from its checkpoint", mlog.String("worker", worker.name), mlog.String("job_id", job.Id))
+	}
+
+	cancelCtx, cancelCancelWatcher := context.WithCancel(context.Background())
+	cancelWatcherChan := make(chan interface{}, 1)
+	go worker.jobServer.CancellationWatcher(cancelCtx, job.Id, cancelWatcherChan)
+	go worker.jobServer.HeartbeatWatcher(cancelCtx, job.Id)
+	defer cancelCancelWatcher()
+
+	canceled := false
+	interrupted := false
+	checkpoint, err := worker.app.RepairCounts(request, checkpoint, func(checkpoint *model.CountRepairCheckpoint) *model.AppError {
+		select {
+		case <-cancelWatcherChan:
+			canceled = true
+			return model.NewAppError("CountRepairWorker", "jobs.count_repair.canceled.app_error", nil, "", http.StatusOK)
+		case <-worker.stop:
+			interrupted = true
+			return model.NewAppError("CountRepairWorker", "jobs.count_repair.interrupted.app_error", nil, "", http.StatusOK)
+		default:
+		}
+
+		// The number of channels isn't known up front, so the progress isn't reported until the repair is done.
+		return worker.jobServer.SetJobCheckpoint(job, 0, checkpoint.ToJson())
+	})
+
+	if interrupted {
+		mlog.Info("Worker: Job has been interrupted and will be resumed later", mlog.String("worker", worker.name), mlog.String("job_id", job.Id))
+		if err := worker.jobServer.RequeueJob(job); err != nil {
+			mlog.Error("Worker: Failed to requeue job", mlog.String("worker", worker.name), mlog.String("job_id", job.Id), mlog.String("error", err.Error()))
+		}
+		return true
+	}
+
+	// The job can't be updated once cancellation was requested, which can be noticed before the watcher does.
+	if !canceled && err != nil {
+		if current, getErr := worker.jobServer.GetJob(job.Id); getErr == nil && current.Status == model.JOB_STATUS_CANCEL_REQUESTED {
+			canceled = true
+		}
+	}
+
+	if canceled {
+		mlog.Info("Worker: Job has been canceled via CancellationWatcher", mlog.String("worker", worker.name), mlog.String("job_id", job.Id))
+		worker.setJobCanceled(job)
+		return false
+	} else if err != nil {
+		mlog.Error("Worker: Failed to repair counts", mlog.String("worker", worker.name), mlog.String("job_id", job.Id), mlog.String("error", err.Error()))
+		worker.setJobError(job, err)
+		return false
+	}
+
+	if job.Data == nil {
+		job.Data = make(map[string]string)
+	}
+	job.Data["checked"] = strconv.FormatInt(checkpoint.Checked, 10)
+	job.Data["msg_count_discrepancies"] = strconv.FormatInt(checkpoint.MsgCountDiscrepancies, 10)
+	job.Data["member_discrepancies"] = strconv.FormatInt(checkpoint.MemberDiscrepancies, 10)
+	job.Data["repaired"] = strconv.FormatInt(checkpoint.Repaired, 10)
+	job.Data["skipped"] = strconv.FormatInt(checkpoint.Skipped, 10)
+	job.Progress = 100
+	if err := worker.jobServer.UpdateInProgressJobData(job); err != nil {
+		mlog.Error("Worker: Failed to update job data", mlog.String("worker", worker.name), mlog.String("job_id", job.Id), mlog.String("error", err.Error()))
+	}
+
+	mlog.Info("Worker: Job is complete", mlog.String("worker", worker.name), mlog.String("job_id", job.Id), mlog.Bool("dry_run", request.DryRun), mlog.Int64("checked", checkpoint.Checked), mlog.Int64("repaired", checkpoint.Repaired), mlog.Int64("skipped", checkpoint.Skipped))
+	worker.setJobSuccess(job)
+	return false
+}
+
+func (worker *Worker) setJobSuccess(job *model.Job) {
+	if err := worker.app.Srv.Jobs.SetJobSuccess(job); err != nil {
+		mlog.Error("Worker: Failed to set success for job", mlog.String("worker", worker.name), mlog.String("job_id", job.Id), mlog.String("error", err.Error()))
+		worker.setJobError(job, err)
+	}
+}
+
+func (worker *Worker) setJobCanceled(job *model.Job) {
+	if err := worker.app.Srv.Jobs.SetJobCanceled(job); err != nil {
+		mlog.Error("Worker: Failed to mark job as canceled", mlog.String("worker", worker.name), mlog.String("job_id", job.Id), mlog.String("error", err.Error()))
+	}
+}
+
+func (worker *Worker) setJobError(job *model.Job, appError *model.AppError) {
+	if err := worker.app.Srv.Jobs.SetJobError(job, appError); err != nil {
+		mlog.Error("Worker: Failed to set job error", mlog.String("worker", worker.name), mlog.String("job_id", job.Id), mlog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package elasticsearchreindex
+
+import (
+	"github.com/mattermost/mattermost-server/app"
+	tjobs "github.com/mattermost/mattermost-server/jobs/interfaces"
+)
+
+type ElasticsearchReindexJobInterfaceImpl struct {
+	App *app.App
+}
+
+func init() {
+	app.RegisterJobsElasticsearchReindexJobInterface(func(a *app.App) tjobs.ElasticsearchReindexJobInterface {
+		return &ElasticsearchReindexJobInterfaceImpl{a}
+	})
+}
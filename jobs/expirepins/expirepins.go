@@ -0,0 +1,19 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package expirepins
+
+import (
+	"github.com/mattermost/mattermost-server/app"
+	tjobs "github.com/mattermost/mattermost-server/jobs/interfaces"
+)
+
+type ExpirePinsJobInterfaceImpl struct {
+	App *app.App
+}
+
+func init() {
+	app.RegisterJobsExpirePinsJobInterface(func(a *app.App) tjobs.ExpirePinsJobInterface {
+		return &ExpirePinsJobInterfaceImpl{a}
+	})
+}
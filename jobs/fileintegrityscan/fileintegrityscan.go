@@ -0,0 +1,19 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package fileintegrityscan
+
+import (
+	"github.com/mattermost/mattermost-server/app"
+	tjobs "github.com/mattermost/mattermost-server/jobs/interfaces"
+)
+
+type FileIntegrityScanJobInterfaceImpl struct {
+	App *app.App
+}
+
+func init() {
+	app.RegisterJobsFileIntegrityScanJobInterface(func(a *app.App) tjobs.FileIntegrityScanJobInterface {
+		return &FileIntegrityScanJobInterfaceImpl{a}
+	})
+}
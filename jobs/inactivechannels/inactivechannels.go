@@ -0,0 +1,19 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package inactivechannels
+
+import (
+	"github.com/mattermost/mattermost-server/app"
+	tjobs "github.com/mattermost/mattermost-server/jobs/interfaces"
+)
+
+type InactiveChannelsJobInterfaceImpl struct {
+	App *app.App
+}
+
+func init() {
+	app.RegisterJobsInactiveChannelsJobInterface(func(a *app.App) tjobs.InactiveChannelsJobInterface {
+		return &InactiveChannelsJobInterfaceImpl{a}
+	})
+}
@@ -6,6 +6,7 @@ package jobs
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"net/http"
@@ -18,10 +19,21 @@ const (
 	CANCEL_WATCHER_POLLING_INTERVAL = 5000
 )
 
+// Jobs that save checkpoints are put back in the queue to be resumed when they haven't been updated for this long,
+// since the server running them has most likely gone away.
+// (Defining as `var` rather than `const` allows tests to lower the timeout.)
+var STALE_JOB_TIMEOUT = 10 * time.Minute
+
+// Workers running jobs that save checkpoints mark them as still being worked on this often, so that a job that's busy
+// with a slow batch isn't mistaken for a stale one and run a second time. It must be well under STALE_JOB_TIMEOUT.
+// (Defining as `var` rather than `const` allows tests to lower the interval.)
+var JOB_HEARTBEAT_INTERVAL = time.Minute
+
 func (srv *JobServer) CreateJob(jobType string, jobData map[string]string) (*model.Job, *model.AppError) {
 	job := model.Job{
 		Id:       model.NewId(),
 		Type:     jobType,
+		Priority: model.DefaultJobPriority(jobType),
 		CreateAt: model.GetMillis(),
 		Status:   model.JOB_STATUS_PENDING,
 		Data:     jobData,
@@ -101,6 +113,92 @@ func (srv *JobServer) SetJobError(job *model.Job, jobError *model.AppError) *mod
 	return nil
 }
 
+// RetryJob puts a job that failed back in the queue so that a worker runs it again once it has waited for
+// model.JobRetryDelay after the given number of failures. The error is kept in the job's data until the job finishes.
+func (srv *JobServer) RetryJob(job *model.Job, jobError *model.AppError, failures int) *model.AppError {
+	job.Status = model.JOB_STATUS_PENDING
+	job.Progress = 0
+	if job.Data == nil {
+		job.Data = make(map[string]string)
+	}
+	job.Data["error"] = jobError.Message + " — " + jobError.DetailedError
+	job.Data[model.JOB_DATA_RETRY_AT] = strconv.FormatInt(model.GetMillis()+int64(model.JobRetryDelay(failures)/time.Millisecond), 10)
+
+	if result := <-srv.Store.Job().UpdateOptimistically(job, model.JOB_STATUS_IN_PROGRESS); result.Err != nil {
+		return result.Err
+	} else if !result.Data.(bool) {
+		return model.NewAppError("Jobs.RetryJob", "jobs.retry_job.update.error", nil, "id="+job.Id, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// SetJobCheckpoint saves the progress of a job along with a checkpoint from which it can be resumed if it's
+// interrupted. It fails if the job is no longer in progress, e.g. because it was canceled or another worker took it
+// over, in which case the worker should stop.
+func (srv *JobServer) SetJobCheckpoint(job *model.Job, progress int64, checkpoint string) *model.AppError {
+	job.Status = model.JOB_STATUS_IN_PROGRESS
+	job.Progress = progress
+	if job.Data == nil {
+		job.Data = make(map[string]string)
+	}
+	job.Data[model.JOB_DATA_CHECKPOINT] = checkpoint
+
+	if result := <-srv.Store.Job().UpdateOptimistically(job, model.JOB_STATUS_IN_PROGRESS); result.Err != nil {
+		return result.Err
+	} else if !result.Data.(bool) {
+		return model.NewAppError("Jobs.SetJobCheckpoint", "jobs.set_job_checkpoint.not_in_progress.error", nil, "id="+job.Id, http.StatusConflict)
+	}
+
+	return nil
+}
+
+// RequeueJob puts a job that's in progress back in the queue without losing its data, so that a worker resumes it
+// from its last checkpoint. Workers use it to hand over their job when they're stopped.
+func (srv *JobServer) RequeueJob(job *model.Job) *model.AppError {
+	job.Status = model.JOB_STATUS_PENDING
+
+	if result := <-srv.Store.Job().UpdateOptimistically(job, model.JOB_STATUS_IN_PROGRESS); result.Err != nil {
+		return result.Err
+	} else if !result.Data.(bool) {
+		return model.NewAppError("Jobs.RequeueJob", "jobs.requeue_job.update.error", nil, "id="+job.Id, http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+// RequeueStaleJobs puts jobs with a checkpoint that haven't been updated for STALE_JOB_TIMEOUT back in the queue,
+// so that they're resumed rather than left in progress forever when the server running them dies. The workers running
+// them keep them updated with a HeartbeatWatcher for as long as they're alive. Stale jobs that were asked to cancel
+// are marked as canceled instead.
+func (srv *JobServer) RequeueStaleJobs() *model.AppError {
+	staleBefore := model.GetMillis() - int64(STALE_JOB_TIMEOUT/time.Millisecond)
+
+	for status, newStatus := range map[string]string{
+		model.JOB_STATUS_IN_PROGRESS:      model.JOB_STATUS_PENDING,
+		model.JOB_STATUS_CANCEL_REQUESTED: model.JOB_STATUS_CANCELED,
+	} {
+		result := <-srv.Store.Job().GetAllByStatus(status)
+		if result.Err != nil {
+			return result.Err
+		}
+
+		for _, job := range result.Data.([]*model.Job) {
+			if _, ok := job.Data[model.JOB_DATA_CHECKPOINT]; !ok || job.LastActivityAt >= staleBefore {
+				continue
+			}
+
+			if result := <-srv.Store.Job().UpdateStatusOptimistically(job.Id, status, newStatus); result.Err != nil {
+				return result.Err
+			} else if result.Data.(bool) {
+				mlog.Info("Job was stale and has been updated.", mlog.String("job_id", job.Id), mlog.String("job_type", job.Type), mlog.String("status", newStatus))
+			}
+		}
+	}
+
+	return nil
+}
+
 func (srv *JobServer) SetJobCanceled(job *model.Job) *model.AppError {
 	result := <-srv.Store.Job().UpdateStatus(job.Id, model.JOB_STATUS_CANCELED)
 	return result.Err
@@ -148,6 +246,29 @@ func (srv *JobServer) CancellationWatcher(ctx context.Context, jobId string, can
 	}
 }
 
+// HeartbeatWatcher marks a job as still being worked on every JOB_HEARTBEAT_INTERVAL until ctx is done, so that
+// RequeueStaleJobs leaves it alone for as long as the worker running it is alive. Workers start it alongside the
+// CancellationWatcher for any job that saves checkpoints.
+func (srv *JobServer) HeartbeatWatcher(ctx context.Context, jobId string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(JOB_HEARTBEAT_INTERVAL):
+			// A job that's been asked to cancel is still being worked on until the worker notices.
+			for _, status := range []string{model.JOB_STATUS_IN_PROGRESS, model.JOB_STATUS_CANCEL_REQUESTED} {
+				result := <-srv.Store.Job().UpdateLastActivityAt(jobId, status)
+				if result.Err != nil {
+					mlog.Warn("HeartbeatWatcher failed to update job", mlog.String("job_id", jobId), mlog.Err(result.Err))
+					break
+				} else if result.Data.(bool) {
+					break
+				}
+			}
+		}
+	}
+}
+
 func GenerateNextStartDateTime(now time.Time, nextStartTime time.Time) *time.Time {
 	nextTime := time.Date(now.Year(), now.Month(), now.Day(), nextStartTime.Hour(), nextStartTime.Minute(), 0, 0, time.Local)
 
@@ -23,6 +23,9 @@ type Watcher struct {
 	stop            chan struct{}
 	stopped         chan struct{}
 	pollingInterval int
+
+	// queuedJobTypes are the job types that had pending jobs when the queue depth was last reported.
+	queuedJobTypes map[string]bool
 }
 
 func (srv *JobServer) MakeWatcher(workers *Workers, pollingInterval int) *Watcher {
@@ -66,62 +69,113 @@ func (watcher *Watcher) Stop() {
 }
 
 func (watcher *Watcher) PollAndNotify() {
-	if result := <-watcher.srv.Store.Job().GetAllByStatus(model.JOB_STATUS_PENDING); result.Err != nil {
+	if err := watcher.srv.RequeueStaleJobs(); err != nil {
+		mlog.Error(fmt.Sprintf("Error occurred requeueing stale jobs: %v", err.Error()))
+	}
+
+	result := <-watcher.srv.Store.Job().GetAllByStatus(model.JOB_STATUS_PENDING)
+	if result.Err != nil {
 		mlog.Error(fmt.Sprintf("Error occurred getting all pending statuses: %v", result.Err.Error()))
-	} else {
-		jobs := result.Data.([]*model.Job)
-
-		for _, job := range jobs {
-			if job.Type == model.JOB_TYPE_DATA_RETENTION {
-				if watcher.workers.DataRetention != nil {
-					select {
-					case watcher.workers.DataRetention.JobChannel() <- *job:
-					default:
-					}
-				}
-			} else if job.Type == model.JOB_TYPE_MESSAGE_EXPORT {
-				if watcher.workers.MessageExport != nil {
-					select {
-					case watcher.workers.MessageExport.JobChannel() <- *job:
-					default:
-					}
-				}
-			} else if job.Type == model.JOB_TYPE_ELASTICSEARCH_POST_INDEXING {
-				if watcher.workers.ElasticsearchIndexing != nil {
-					select {
-					case watcher.workers.ElasticsearchIndexing.JobChannel() <- *job:
-					default:
-					}
-				}
-			} else if job.Type == model.JOB_TYPE_ELASTICSEARCH_POST_AGGREGATION {
-				if watcher.workers.ElasticsearchAggregation != nil {
-					select {
-					case watcher.workers.ElasticsearchAggregation.JobChannel() <- *job:
-					default:
-					}
-				}
-			} else if job.Type == model.JOB_TYPE_LDAP_SYNC {
-				if watcher.workers.LdapSync != nil {
-					select {
-					case watcher.workers.LdapSync.JobChannel() <- *job:
-					default:
-					}
-				}
-			} else if job.Type == model.JOB_TYPE_MIGRATIONS {
-				if watcher.workers.Migrations != nil {
-					select {
-					case watcher.workers.Migrations.JobChannel() <- *job:
-					default:
-					}
-				}
-			} else if job.Type == model.JOB_TYPE_PLUGINS {
-				if watcher.workers.Plugins != nil {
-					select {
-					case watcher.workers.Plugins.JobChannel() <- *job:
-					default:
-					}
-				}
-			}
+		return
+	}
+	jobs := result.Data.([]*model.Job)
+
+	watcher.reportQueueDepth(jobs)
+
+	limiter, err := watcher.srv.newJobLimiter()
+	if err != nil {
+		mlog.Error(fmt.Sprintf("Error occurred getting running jobs: %v", err.Error()))
+		return
+	}
+
+	affinity := watcher.srv.newJobAffinity()
+
+	// Every worker only takes one job at a time, so offering the jobs with the highest priority first makes sure
+	// that they're started first.
+	model.SortJobsByPriority(jobs)
+
+	now := model.GetMillis()
+	for _, job := range jobs {
+		if job.IsWaitingToRetry(now) {
+			continue
+		}
+
+		worker := watcher.workerForJobType(job.Type)
+		if worker == nil || !affinity.canRun(job) || !limiter.canStart(job) {
+			continue
+		}
+
+		select {
+		case worker.JobChannel() <- *job:
+			limiter.started(job)
+		default:
 		}
 	}
 }
+
+func (watcher *Watcher) workerForJobType(jobType string) model.Worker {
+	switch jobType {
+	case model.JOB_TYPE_DATA_RETENTION:
+		return watcher.workers.DataRetention
+	case model.JOB_TYPE_MESSAGE_EXPORT:
+		return watcher.workers.MessageExport
+	case model.JOB_TYPE_ELASTICSEARCH_POST_INDEXING:
+		return watcher.workers.ElasticsearchIndexing
+	case model.JOB_TYPE_ELASTICSEARCH_POST_AGGREGATION:
+		return watcher.workers.ElasticsearchAggregation
+	case model.JOB_TYPE_LDAP_SYNC:
+		return watcher.workers.LdapSync
+	case model.JOB_TYPE_MIGRATIONS:
+		return watcher.workers.Migrations
+	case model.JOB_TYPE_PLUGINS:
+		return watcher.workers.Plugins
+	case model.JOB_TYPE_EXPIRE_PINS:
+		return watcher.workers.ExpirePins
+	case model.JOB_TYPE_INACTIVE_USERS:
+		return watcher.workers.InactiveUsers
+	case model.JOB_TYPE_INACTIVE_CHANNELS:
+		return watcher.workers.InactiveChannels
+	case model.JOB_TYPE_COLD_STORAGE:
+		return watcher.workers.ColdStorage
+	case model.JOB_TYPE_FILE_INTEGRITY_SCAN:
+		return watcher.workers.FileIntegrityScan
+	case model.JOB_TYPE_MOVE_CHANNEL:
+		return watcher.workers.MoveChannel
+	case model.JOB_TYPE_MERGE_TEAMS:
+		return watcher.workers.MergeTeams
+	case model.JOB_TYPE_COUNT_REPAIR:
+		return watcher.workers.CountRepair
+	case model.JOB_TYPE_ELASTICSEARCH_SCOPED_REINDEX:
+		return watcher.workers.ElasticsearchReindex
+	case model.JOB_TYPE_PLUGIN_JOB:
+		return watcher.workers.PluginJobs
+	}
+
+	return nil
+}
+
+func (watcher *Watcher) reportQueueDepth(pendingJobs []*model.Job) {
+	metrics := watcher.srv.Metrics
+	if metrics == nil {
+		return
+	}
+
+	depths := make(map[string]int)
+	for _, job := range pendingJobs {
+		depths[job.Type]++
+	}
+
+	// Job types whose queue has emptied since the last poll are reported once more, so they don't stay stuck at
+	// their last depth.
+	for jobType := range watcher.queuedJobTypes {
+		if _, ok := depths[jobType]; !ok {
+			metrics.SetJobQueueDepth(jobType, 0)
+		}
+	}
+
+	watcher.queuedJobTypes = make(map[string]bool, len(depths))
+	for jobType, depth := range depths {
+		metrics.SetJobQueueDepth(jobType, float64(depth))
+		watcher.queuedJobTypes[jobType] = true
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package jobs
+
+import (
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// jobLimiter decides which pending jobs may be started given the jobs already running across the cluster, according
+// to JobSettings.MaxConcurrentJobs and JobSettings.MaxConcurrentJobsPerType. The limits are best effort since every
+// server polls for jobs independently, so servers polling at the same time may exceed them briefly.
+type jobLimiter struct {
+	maxConcurrentJobs        int
+	maxConcurrentJobsPerType map[string]int
+
+	running       int
+	runningByType map[string]int
+}
+
+func (srv *JobServer) newJobLimiter() (*jobLimiter, *model.AppError) {
+	cfg := srv.Config()
+
+	limiter := &jobLimiter{
+		maxConcurrentJobs:        *cfg.JobSettings.MaxConcurrentJobs,
+		maxConcurrentJobsPerType: cfg.JobSettings.MaxConcurrentJobsPerType,
+		runningByType:            make(map[string]int),
+	}
+
+	if limiter.maxConcurrentJobs == 0 && len(limiter.maxConcurrentJobsPerType) == 0 {
+		return limiter, nil
+	}
+
+	// Jobs that were asked to cancel keep running until they notice.
+	for _, status := range []string{model.JOB_STATUS_IN_PROGRESS, model.JOB_STATUS_CANCEL_REQUESTED} {
+		result := <-srv.Store.Job().GetAllByStatus(status)
+		if result.Err != nil {
+			return nil, result.Err
+		}
+
+		for _, job := range result.Data.([]*model.Job) {
+			limiter.started(job)
+		}
+	}
+
+	return limiter, nil
+}
+
+// canStart returns whether the job can be started without exceeding its type's limit. Once the overall limit is
+// reached, only high priority jobs are started so that they're not held up by long running ones.
+func (l *jobLimiter) canStart(job *model.Job) bool {
+	if limit, ok := l.maxConcurrentJobsPerType[job.Type]; ok && l.runningByType[job.Type] >= limit {
+		return false
+	}
+
+	if l.maxConcurrentJobs > 0 && l.running >= l.maxConcurrentJobs && job.Priority < model.JOB_PRIORITY_HIGH {
+		return false
+	}
+
+	return true
+}
+
+func (l *jobLimiter) started(job *model.Job) {
+	l.running++
+	l.runningByType[job.Type]++
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package jobs
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// ClusterLock is a lock that's held by at most one node across the cluster. It expires after its TTL unless it's
+// renewed, so a lock that was held by a node that died is eventually freed up for the others.
+type ClusterLock struct {
+	srv   *JobServer
+	name  string
+	owner string
+	ttl   time.Duration
+}
+
+// TryLock acquires the named lock for this server without waiting. It returns nil if the lock is held by another
+// node.
+func (srv *JobServer) TryLock(name string, ttl time.Duration) (*ClusterLock, *model.AppError) {
+	lock := &ClusterLock{
+		srv:   srv,
+		name:  name,
+		owner: srv.lockOwner,
+		ttl:   ttl,
+	}
+
+	result := <-srv.Store.ClusterLock().Acquire(lock.toModel())
+	if result.Err != nil {
+		return nil, result.Err
+	}
+
+	if !result.Data.(bool) {
+		return nil, nil
+	}
+
+	return lock, nil
+}
+
+// RunWithLock runs f if the named lock can be acquired, renewing the lock until f returns and releasing it
+// afterwards. It returns false without running f if the lock is held by another node.
+func (srv *JobServer) RunWithLock(name string, ttl time.Duration, f func()) (bool, *model.AppError) {
+	lock, err := srv.TryLock(name, ttl)
+	if err != nil {
+		return false, err
+	}
+	if lock == nil {
+		return false, nil
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		lock.keepAlive(stop)
+	}()
+
+	defer func() {
+		close(stop)
+		wg.Wait()
+
+		if err := lock.Release(); err != nil {
+			mlog.Error("Failed to release cluster lock", mlog.String("name", name), mlog.Err(err))
+		}
+	}()
+
+	f()
+
+	return true, nil
+}
+
+// Renew extends the lock by its TTL. It returns an error if the lock was lost in the meantime, either because it
+// expired or because it was taken over by another node.
+func (l *ClusterLock) Renew() *model.AppError {
+	result := <-l.srv.Store.ClusterLock().Renew(l.toModel())
+	if result.Err != nil {
+		return result.Err
+	}
+
+	if !result.Data.(bool) {
+		return model.NewAppError("ClusterLock.Renew", "jobs.cluster_lock.lost.app_error", nil, "name="+l.name, http.StatusConflict)
+	}
+
+	return nil
+}
+
+func (l *ClusterLock) Release() *model.AppError {
+	return (<-l.srv.Store.ClusterLock().Release(l.name, l.owner)).Err
+}
+
+func (l *ClusterLock) keepAlive(stop <-chan struct{}) {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := l.Renew(); err != nil {
+				mlog.Error("Failed to renew cluster lock", mlog.String("name", l.name), mlog.Err(err))
+			}
+		}
+	}
+}
+
+func (l *ClusterLock) toModel() *model.ClusterLock {
+	return &model.ClusterLock{
+		Name:     l.name,
+		Owner:    l.owner,
+		ExpireAt: model.GetMillis() + int64(l.ttl/time.Millisecond),
+	}
+}
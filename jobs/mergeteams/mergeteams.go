@@ -0,0 +1,19 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package mergeteams
+
+import (
+	"github.com/mattermost/mattermost-server/app"
+	tjobs "github.com/mattermost/mattermost-server/jobs/interfaces"
+)
+
+type MergeTeamsJobInterfaceImpl struct {
+	App *app.App
+}
+
+func init() {
+	app.RegisterJobsMergeTeamsJobInterface(func(a *app.App) tjobs.MergeTeamsJobInterface {
+		return &MergeTeamsJobInterfaceImpl{a}
+	})
+}
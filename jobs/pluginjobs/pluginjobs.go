@@ -0,0 +1,19 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package pluginjobs
+
+import (
+	"github.com/mattermost/mattermost-server/app"
+	tjobs "github.com/mattermost/mattermost-server/jobs/interfaces"
+)
+
+type PluginJobsInterfaceImpl struct {
+	App *app.App
+}
+
+func init() {
+	app.RegisterJobsPluginJobsInterface(func(a *app.App) tjobs.PluginJobsInterface {
+		return &PluginJobsInterfaceImpl{a}
+	})
+}
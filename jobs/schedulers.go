@@ -12,6 +12,8 @@ import (
 	"github.com/mattermost/mattermost-server/model"
 )
 
+const SCHEDULER_LOCK_TTL = 1 * time.Minute
+
 type Schedulers struct {
 	stop                 chan bool
 	stopped              chan bool
@@ -60,6 +62,22 @@ func (srv *JobServer) InitSchedulers() *Schedulers {
 		schedulers.schedulers = append(schedulers.schedulers, pluginsInterface.MakeScheduler())
 	}
 
+	if expirePinsInterface := srv.ExpirePins; expirePinsInterface != nil {
+		schedulers.schedulers = append(schedulers.schedulers, expirePinsInterface.MakeScheduler())
+	}
+
+	if inactiveUsersInterface := srv.InactiveUsers; inactiveUsersInterface != nil {
+		schedulers.schedulers = append(schedulers.schedulers, inactiveUsersInterface.MakeScheduler())
+	}
+
+	if inactiveChannelsInterface := srv.InactiveChannels; inactiveChannelsInterface != nil {
+		schedulers.schedulers = append(schedulers.schedulers, inactiveChannelsInterface.MakeScheduler())
+	}
+
+	if coldStorageInterface := srv.ColdStorage; coldStorageInterface != nil {
+		schedulers.schedulers = append(schedulers.schedulers, coldStorageInterface.MakeScheduler())
+	}
+
 	schedulers.nextRunTimes = make([]*time.Time, len(schedulers.schedulers))
 	return schedulers
 }
@@ -168,13 +186,29 @@ func (schedulers *Schedulers) setNextRunTime(cfg *model.Config, idx int, now tim
 }
 
 func (schedulers *Schedulers) scheduleJob(cfg *model.Config, scheduler model.Scheduler) (*model.Job, *model.AppError) {
+	var job *model.Job
+	var appErr *model.AppError
+
+	// Checking for pending jobs and scheduling a new one has to happen on a single node at a time, otherwise two nodes
+	// that both consider themselves the cluster leader can schedule the same job twice. If another node holds the
+	// lock, it's scheduling the job already.
+	if _, err := schedulers.jobs.RunWithLock("scheduler_"+scheduler.JobType(), SCHEDULER_LOCK_TTL, func() {
+		job, appErr = schedulers.scheduleJobLocked(cfg, scheduler)
+	}); err != nil {
+		return nil, err
+	}
+
+	return job, appErr
+}
+
+func (schedulers *Schedulers) scheduleJobLocked(cfg *model.Config, scheduler model.Scheduler) (*model.Job, *model.AppError) {
 	pendingJobs, err := schedulers.jobs.CheckForPendingJobsByType(scheduler.JobType())
 	if err != nil {
 		return nil, err
 	}
 
-	lastSuccessfulJob, err2 := schedulers.jobs.GetLastSuccessfulJobByType(scheduler.JobType())
-	if err2 != nil {
+	lastSuccessfulJob, err := schedulers.jobs.GetLastSuccessfulJobByType(scheduler.JobType())
+	if err != nil {
 		return nil, err
 	}
 
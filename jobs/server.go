@@ -4,6 +4,7 @@
 package jobs
 
 import (
+	"github.com/mattermost/mattermost-server/einterfaces"
 	ejobs "github.com/mattermost/mattermost-server/einterfaces/jobs"
 	tjobs "github.com/mattermost/mattermost-server/jobs/interfaces"
 	"github.com/mattermost/mattermost-server/model"
@@ -14,9 +15,13 @@ import (
 type JobServer struct {
 	ConfigService configservice.ConfigService
 	Store         store.Store
+	Metrics       einterfaces.MetricsInterface
 	Workers       *Workers
 	Schedulers    *Schedulers
 
+	// lockOwner identifies this server as the owner of the cluster locks it acquires.
+	lockOwner string
+
 	DataRetentionJob        ejobs.DataRetentionJobInterface
 	MessageExportJob        ejobs.MessageExportJobInterface
 	ElasticsearchAggregator ejobs.ElasticsearchAggregatorInterface
@@ -24,12 +29,23 @@ type JobServer struct {
 	LdapSync                ejobs.LdapSyncInterface
 	Migrations              tjobs.MigrationsJobInterface
 	Plugins                 tjobs.PluginsJobInterface
+	ExpirePins              tjobs.ExpirePinsJobInterface
+	InactiveUsers           tjobs.InactiveUsersJobInterface
+	InactiveChannels        tjobs.InactiveChannelsJobInterface
+	ColdStorage             tjobs.ColdStorageJobInterface
+	FileIntegrityScan       tjobs.FileIntegrityScanJobInterface
+	MoveChannel             tjobs.MoveChannelJobInterface
+	MergeTeams              tjobs.MergeTeamsJobInterface
+	CountRepair             tjobs.CountRepairJobInterface
+	ElasticsearchReindex    tjobs.ElasticsearchReindexJobInterface
+	PluginJobs              tjobs.PluginJobsInterface
 }
 
 func NewJobServer(configService configservice.ConfigService, store store.Store) *JobServer {
 	return &JobServer{
 		ConfigService: configService,
 		Store:         store,
+		lockOwner:     model.NewId(),
 	}
 }
 
@@ -23,6 +23,16 @@ type Workers struct {
 	LdapSync                 model.Worker
 	Migrations               model.Worker
 	Plugins                  model.Worker
+	ExpirePins               model.Worker
+	InactiveUsers            model.Worker
+	InactiveChannels         model.Worker
+	ColdStorage              model.Worker
+	FileIntegrityScan        model.Worker
+	MoveChannel              model.Worker
+	MergeTeams               model.Worker
+	CountRepair              model.Worker
+	ElasticsearchReindex     model.Worker
+	PluginJobs               model.Worker
 
 	listenerId string
 }
@@ -61,6 +71,46 @@ func (srv *JobServer) InitWorkers() *Workers {
 		workers.Plugins = pluginsInterface.MakeWorker()
 	}
 
+	if expirePinsInterface := srv.ExpirePins; expirePinsInterface != nil {
+		workers.ExpirePins = expirePinsInterface.MakeWorker()
+	}
+
+	if inactiveUsersInterface := srv.InactiveUsers; inactiveUsersInterface != nil {
+		workers.InactiveUsers = inactiveUsersInterface.MakeWorker()
+	}
+
+	if inactiveChannelsInterface := srv.InactiveChannels; inactiveChannelsInterface != nil {
+		workers.InactiveChannels = inactiveChannelsInterface.MakeWorker()
+	}
+
+	if coldStorageInterface := srv.ColdStorage; coldStorageInterface != nil {
+		workers.ColdStorage = coldStorageInterface.MakeWorker()
+	}
+
+	if fileIntegrityScanInterface := srv.FileIntegrityScan; fileIntegrityScanInterface != nil {
+		workers.FileIntegrityScan = fileIntegrityScanInterface.MakeWorker()
+	}
+
+	if moveChannelInterface := srv.MoveChannel; moveChannelInterface != nil {
+		workers.MoveChannel = moveChannelInterface.MakeWorker()
+	}
+
+	if mergeTeamsInterface := srv.MergeTeams; mergeTeamsInterface != nil {
+		workers.MergeTeams = mergeTeamsInterface.MakeWorker()
+	}
+
+	if countRepairInterface := srv.CountRepair; countRepairInterface != nil {
+		workers.CountRepair = countRepairInterface.MakeWorker()
+	}
+
+	if elasticsearchReindexInterface := srv.ElasticsearchReindex; elasticsearchReindexInterface != nil {
+		workers.ElasticsearchReindex = elasticsearchReindexInterface.MakeWorker()
+	}
+
+	if pluginJobsInterface := srv.PluginJobs; pluginJobsInterface != nil {
+		workers.PluginJobs = pluginJobsInterface.MakeWorker()
+	}
+
 	return workers
 }
 
@@ -96,6 +146,46 @@ func (workers *Workers) Start() *Workers {
 			go workers.Plugins.Run()
 		}
 
+		if workers.ExpirePins != nil {
+			go workers.ExpirePins.Run()
+		}
+
+		if workers.InactiveUsers != nil {
+			go workers.InactiveUsers.Run()
+		}
+
+		if workers.InactiveChannels != nil {
+			go workers.InactiveChannels.Run()
+		}
+
+		if workers.ColdStorage != nil {
+			go workers.ColdStorage.Run()
+		}
+
+		if workers.FileIntegrityScan != nil {
+			go workers.FileIntegrityScan.Run()
+		}
+
+		if workers.MoveChannel != nil {
+			go workers.MoveChannel.Run()
+		}
+
+		if workers.MergeTeams != nil {
+			go workers.MergeTeams.Run()
+		}
+
+		if workers.CountRepair != nil {
+			go workers.CountRepair.Run()
+		}
+
+		if workers.ElasticsearchReindex != nil {
+			go workers.ElasticsearchReindex.Run()
+		}
+
+		if workers.PluginJobs != nil {
+			go workers.PluginJobs.Run()
+		}
+
 		go workers.Watcher.Start()
 	})
 
@@ -181,6 +271,46 @@ func (workers *Workers) Stop() *Workers {
 		workers.Plugins.Stop()
 	}
 
+	if workers.ExpirePins != nil {
+		workers.ExpirePins.Stop()
+	}
+
+	if workers.InactiveUsers != nil {
+		workers.InactiveUsers.Stop()
+	}
+
+	if workers.InactiveChannels != nil {
+		workers.InactiveChannels.Stop()
+	}
+
+	if workers.ColdStorage != nil {
+		workers.ColdStorage.Stop()
+	}
+
+	if workers.FileIntegrityScan != nil {
+		workers.FileIntegrityScan.Stop()
+	}
+
+	if workers.MoveChannel != nil {
+		workers.MoveChannel.Stop()
+	}
+
+	if workers.MergeTeams != nil {
+		workers.MergeTeams.Stop()
+	}
+
+	if workers.CountRepair != nil {
+		workers.CountRepair.Stop()
+	}
+
+	if workers.ElasticsearchReindex != nil {
+		workers.ElasticsearchReindex.Stop()
+	}
+
+	if workers.PluginJobs != nil {
+		workers.PluginJobs.Stop()
+	}
+
 	mlog.Info("Stopped workers")
 
 	return workers
@@ -0,0 +1,80 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package migrations
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// TeamStorageUsageProgress is how far the team storage usage migration has got. Files created after StartedAt are
+// recorded as they're uploaded, so only those created before then are counted.
+type TeamStorageUsageProgress struct {
+	StartedAt  int64  `json:"started_at"`
+	LastFileId string `json:"last_file_id"`
+}
+
+func (p *TeamStorageUsageProgress) ToJson() string {
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+func TeamStorageUsageProgressFromJson(data io.Reader) *TeamStorageUsageProgress {
+	var o *TeamStorageUsageProgress
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+func (p *TeamStorageUsageProgress) IsValid() bool {
+	if p.StartedAt <= 0 {
+		return false
+	}
+
+	if len(p.LastFileId) != 26 {
+		return false
+	}
+
+	return true
+}
+
+// runTeamStorageUsageMigration counts the storage used by the files that were uploaded to each team before their usage
+// was recorded. The usage recorded so far is forgotten when the migration starts, so that the files it includes aren't
+// counted twice.
+func (worker *Worker) runTeamStorageUsageMigration(lastDone string) (bool, string, *model.AppError) {
+	var progress *TeamStorageUsageProgress
+	if len(lastDone) == 0 {
+		// Haven't started the migration yet.
+		if err := worker.app.ResetTeamStorageUsage(); err != nil {
+			return false, "", err
+		}
+
+		progress = &TeamStorageUsageProgress{
+			StartedAt:  model.GetMillis(),
+			LastFileId: strings.Repeat("0", 26),
+		}
+		return false, progress.ToJson(), nil
+	}
+
+	progress = TeamStorageUsageProgressFromJson(strings.NewReader(lastDone))
+	if progress == nil || !progress.IsValid() {
+		return false, "", model.NewAppError("MigrationsWorker.runTeamStorageUsageMigration", "migrations.worker.run_team_storage_usage_migration.invalid_progress", map[string]interface{}{"progress": lastDone}, "", http.StatusInternalServerError)
+	}
+
+	lastFileId, err := worker.app.CountTeamStorageUsage(progress.LastFileId, progress.StartedAt)
+	if err != nil {
+		return false, progress.ToJson(), err
+	}
+
+	if lastFileId == "" {
+		// We've reached the end of the files.
+		return true, progress.ToJson(), nil
+	}
+
+	progress.LastFileId = lastFileId
+	return false, progress.ToJson(), nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// BatchSubRequest is a single API call made as part of a batch. Path is relative to the API root, /api/v4, and may
+// include a query string.
+type BatchSubRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// BatchRequest is a list of API calls to be made one after the other in a single HTTP request, using the session of
+// that request. If StopOnError is set, no more calls are made after one fails.
+type BatchRequest struct {
+	Requests    []*BatchSubRequest `json:"requests"`
+	StopOnError bool               `json:"stop_on_error"`
+}
+
+// BatchSubResponse is the response to a BatchSubRequest. Body holds the response as it would have been returned by
+// the API, or a JSON string containing it if it isn't JSON.
+type BatchSubResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+func (r *BatchSubRequest) IsValid() *AppError {
+	switch r.Method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete:
+	default:
+		return NewAppError("BatchSubRequest.IsValid", "model.batch.is_valid.method.app_error", nil, "method="+r.Method, http.StatusBadRequest)
+	}
+
+	requestPath := r.Path
+	if index := strings.Index(requestPath, "?"); index != -1 {
+		requestPath = requestPath[:index]
+	}
+
+	// Routes are matched against the unescaped path, so that's the one that's checked
+	requestPath, err := url.PathUnescape(requestPath)
+	if err != nil || !strings.HasPrefix(requestPath, "/") || strings.HasPrefix(requestPath, "//") || strings.Contains(requestPath, "..") {
+		return NewAppError("BatchSubRequest.IsValid", "model.batch.is_valid.path.app_error", nil, "path="+r.Path, http.StatusBadRequest)
+	}
+
+	// Batches can't be nested, and the websocket can't be opened from one
+	requestPath = path.Clean(requestPath)
+	if requestPath == "/batch" || strings.HasPrefix(requestPath, "/batch/") || requestPath == "/websocket" {
+		return NewAppError("BatchSubRequest.IsValid", "model.batch.is_valid.path.app_error", nil, "path="+r.Path, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (r *BatchRequest) IsValid(maxRequests int) *AppError {
+	if len(r.Requests) == 0 {
+		return NewAppError("BatchRequest.IsValid", "model.batch.is_valid.empty.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(r.Requests) > maxRequests {
+		return NewAppError("BatchRequest.IsValid", "model.batch.is_valid.too_many.app_error", map[string]interface{}{"Max": maxRequests}, "", http.StatusBadRequest)
+	}
+
+	for _, request := range r.Requests {
+		if request == nil {
+			return NewAppError("BatchRequest.IsValid", "model.batch.is_valid.empty.app_error", nil, "", http.StatusBadRequest)
+		}
+		if err := request.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *BatchRequest) ToJson() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+func BatchRequestFromJson(data io.Reader) *BatchRequest {
+	var r *BatchRequest
+	json.NewDecoder(data).Decode(&r)
+	return r
+}
+
+func BatchSubResponsesToJson(responses []*BatchSubResponse) string {
+	b, _ := json.Marshal(responses)
+	return string(b)
+}
+
+func BatchSubResponsesFromJson(data io.Reader) []*BatchSubResponse {
+	var responses []*BatchSubResponse
+	json.NewDecoder(data).Decode(&responses)
+	return responses
+}
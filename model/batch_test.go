@@ -0,0 +1,70 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchSubRequestIsValid(t *testing.T) {
+	for _, tc := range []struct {
+		Method string
+		Path   string
+		Valid  bool
+	}{
+		{"GET", "/users/me", true},
+		{"GET", "/users/me/teams?page=0", true},
+		{"POST", "/channels", true},
+		{"PUT", "/users/me/patch", true},
+		{"DELETE", "/posts/abc", true},
+		{"PATCH", "/users/me", false},
+		{"", "/users/me", false},
+		{"GET", "users/me", false},
+		{"GET", "//example.com/users/me", false},
+		{"GET", "/users/../config", false},
+		{"POST", "/batch", false},
+		{"GET", "/websocket", false},
+		{"POST", "/%62atch", false},
+		{"POST", "/batch/", false},
+		{"POST", "/./batch", false},
+		{"GET", "/%77ebsocket", false},
+		{"GET", "/users/%2e%2e/config", false},
+		{"GET", "/users/%zz", false},
+		{"GET", "/users/me%20", true},
+	} {
+		err := (&BatchSubRequest{Method: tc.Method, Path: tc.Path}).IsValid()
+		assert.Equal(t, tc.Valid, err == nil, "%v %v", tc.Method, tc.Path)
+	}
+}
+
+func TestBatchRequestIsValid(t *testing.T) {
+	request := &BatchRequest{}
+	require.NotNil(t, request.IsValid(2))
+
+	request.Requests = []*BatchSubRequest{{Method: "GET", Path: "/users/me"}, {Method: "GET", Path: "/teams"}}
+	require.Nil(t, request.IsValid(2))
+
+	request.Requests = append(request.Requests, &BatchSubRequest{Method: "GET", Path: "/channels"})
+	err := request.IsValid(2)
+	require.NotNil(t, err)
+	assert.Equal(t, "model.batch.is_valid.too_many.app_error", err.Id)
+
+	request.Requests = []*BatchSubRequest{{Method: "GET", Path: "/batch"}}
+	require.NotNil(t, request.IsValid(2))
+}
+
+func TestBatchRequestJson(t *testing.T) {
+	request := &BatchRequest{
+		Requests:    []*BatchSubRequest{{Method: "POST", Path: "/posts", Body: []byte(`{"message":"hi"}`)}},
+		StopOnError: true,
+	}
+
+	decoded := BatchRequestFromJson(strings.NewReader(request.ToJson()))
+	require.NotNil(t, decoded)
+	assert.Equal(t, request, decoded)
+}
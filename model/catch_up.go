@@ -0,0 +1,88 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	// CATCH_UP_MAX_DAYS bounds how far back a catch up summary looks, however long a user has been away.
+	CATCH_UP_MAX_DAYS = 30
+)
+
+// CatchUpChannel holds how much has happened in a single channel since a user last visited.
+type CatchUpChannel struct {
+	ChannelId string `json:"channel_id"`
+	TeamId    string `json:"team_id"`
+	// NewPosts counts the posts made by other users. With collapsed threads it counts only root posts.
+	NewPosts int64 `json:"new_posts"`
+	// Mentions counts the new posts that @-mention the user by username.
+	Mentions int64 `json:"mentions"`
+	// ThreadReplies counts the new replies to threads that the user started or has replied to.
+	ThreadReplies int64 `json:"thread_replies"`
+	LastPostAt    int64 `json:"last_post_at"`
+
+	// NotifyProps are the user's notify props for the channel, used to leave out muted channels. They are never sent
+	// to clients.
+	NotifyProps StringMap `json:"-"`
+}
+
+// CatchUpTeam groups the channels of a catch up summary by team. Direct and group messages have an empty TeamId.
+type CatchUpTeam struct {
+	TeamId        string            `json:"team_id"`
+	NewPosts      int64             `json:"new_posts"`
+	Mentions      int64             `json:"mentions"`
+	ThreadReplies int64             `json:"thread_replies"`
+	Channels      []*CatchUpChannel `json:"channels"`
+}
+
+// CatchUpSummary is a consolidated summary of what has happened since a user's last visit. It only contains counts
+// so that a client can show it without fetching every channel's posts.
+type CatchUpSummary struct {
+	// Since is when posts are counted from. When catching up on what's unread, it's as far back as the summary looks.
+	Since         int64          `json:"since"`
+	NewPosts      int64          `json:"new_posts"`
+	Mentions      int64          `json:"mentions"`
+	ThreadReplies int64          `json:"thread_replies"`
+	Teams         []*CatchUpTeam `json:"teams"`
+	// HasMore is set when there are further channels with activity on the next page.
+	HasMore bool `json:"has_more"`
+}
+
+// AddChannel adds a channel to the summary, keeping the teams in the order that their first channel was added.
+func (o *CatchUpSummary) AddChannel(channel *CatchUpChannel) {
+	var team *CatchUpTeam
+	for _, t := range o.Teams {
+		if t.TeamId == channel.TeamId {
+			team = t
+			break
+		}
+	}
+	if team == nil {
+		team = &CatchUpTeam{TeamId: channel.TeamId, Channels: []*CatchUpChannel{}}
+		o.Teams = append(o.Teams, team)
+	}
+
+	team.Channels = append(team.Channels, channel)
+	team.NewPosts += channel.NewPosts
+	team.Mentions += channel.Mentions
+	team.ThreadReplies += channel.ThreadReplies
+
+	o.NewPosts += channel.NewPosts
+	o.Mentions += channel.Mentions
+	o.ThreadReplies += channel.ThreadReplies
+}
+
+func (o *CatchUpSummary) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func CatchUpSummaryFromJson(data io.Reader) *CatchUpSummary {
+	var o *CatchUpSummary
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
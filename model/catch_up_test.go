@@ -0,0 +1,52 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatchUpSummaryAddChannel(t *testing.T) {
+	teamId := NewId()
+	summary := &CatchUpSummary{Since: 1000, Teams: []*CatchUpTeam{}}
+
+	summary.AddChannel(&CatchUpChannel{ChannelId: NewId(), TeamId: teamId, NewPosts: 3, Mentions: 1})
+	summary.AddChannel(&CatchUpChannel{ChannelId: NewId(), TeamId: "", NewPosts: 2, ThreadReplies: 2})
+	summary.AddChannel(&CatchUpChannel{ChannelId: NewId(), TeamId: teamId, NewPosts: 1, ThreadReplies: 1})
+
+	require.Len(t, summary.Teams, 2)
+	assert.Equal(t, teamId, summary.Teams[0].TeamId)
+	assert.Len(t, summary.Teams[0].Channels, 2)
+	assert.Equal(t, int64(4), summary.Teams[0].NewPosts)
+	assert.Equal(t, int64(1), summary.Teams[0].Mentions)
+	assert.Equal(t, int64(1), summary.Teams[0].ThreadReplies)
+	assert.Equal(t, "", summary.Teams[1].TeamId)
+	assert.Len(t, summary.Teams[1].Channels, 1)
+
+	assert.Equal(t, int64(6), summary.NewPosts)
+	assert.Equal(t, int64(1), summary.Mentions)
+	assert.Equal(t, int64(3), summary.ThreadReplies)
+}
+
+func TestCatchUpSummaryJson(t *testing.T) {
+	summary := &CatchUpSummary{Since: 1000, Teams: []*CatchUpTeam{}}
+	summary.AddChannel(&CatchUpChannel{
+		ChannelId:   NewId(),
+		TeamId:      NewId(),
+		NewPosts:    2,
+		LastPostAt:  2000,
+		NotifyProps: StringMap{MARK_UNREAD_NOTIFY_PROP: CHANNEL_MARK_UNREAD_ALL},
+	})
+
+	json := summary.ToJson()
+	assert.NotContains(t, json, MARK_UNREAD_NOTIFY_PROP)
+
+	rsummary := CatchUpSummaryFromJson(strings.NewReader(json))
+	summary.Teams[0].Channels[0].NotifyProps = nil
+	assert.Equal(t, summary, rsummary)
+}
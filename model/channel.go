@@ -35,22 +35,26 @@ const (
 )
 
 type Channel struct {
-	Id            string                 `json:"id"`
-	CreateAt      int64                  `json:"create_at"`
-	UpdateAt      int64                  `json:"update_at"`
-	DeleteAt      int64                  `json:"delete_at"`
-	TeamId        string                 `json:"team_id"`
-	Type          string                 `json:"type"`
-	DisplayName   string                 `json:"display_name"`
-	Name          string                 `json:"name"`
-	Header        string                 `json:"header"`
-	Purpose       string                 `json:"purpose"`
-	LastPostAt    int64                  `json:"last_post_at"`
-	TotalMsgCount int64                  `json:"total_msg_count"`
-	ExtraUpdateAt int64                  `json:"extra_update_at"`
-	CreatorId     string                 `json:"creator_id"`
-	SchemeId      *string                `json:"scheme_id"`
-	Props         map[string]interface{} `json:"props" db:"-"`
+	Id                        string                 `json:"id"`
+	CreateAt                  int64                  `json:"create_at"`
+	UpdateAt                  int64                  `json:"update_at"`
+	DeleteAt                  int64                  `json:"delete_at"`
+	TeamId                    string                 `json:"team_id"`
+	Type                      string                 `json:"type"`
+	DisplayName               string                 `json:"display_name"`
+	Name                      string                 `json:"name"`
+	Header                    string                 `json:"header"`
+	Purpose                   string                 `json:"purpose"`
+	LastPostAt                int64                  `json:"last_post_at"`
+	TotalMsgCount             int64                  `json:"total_msg_count"`
+	ExtraUpdateAt             int64                  `json:"extra_update_at"`
+	CreatorId                 string                 `json:"creator_id"`
+	SchemeId                  *string                `json:"scheme_id"`
+	BannerPostId              string                 `json:"banner_post_id"`
+	MaxPostSize               int                    `json:"max_post_size"`
+	SuppressJoinLeaveMessages bool                   `json:"suppress_join_leave_messages"`
+	ExemptFromArchiving       bool                   `json:"exempt_from_archiving"`
+	Props                     map[string]interface{} `json:"props" db:"-"`
 }
 
 type ChannelWithTeamData struct {
@@ -65,6 +69,10 @@ type ChannelPatch struct {
 	Name        *string `json:"name"`
 	Header      *string `json:"header"`
 	Purpose     *string `json:"purpose"`
+	MaxPostSize *int    `json:"max_post_size"`
+
+	SuppressJoinLeaveMessages *bool `json:"suppress_join_leave_messages"`
+	ExemptFromArchiving       *bool `json:"exempt_from_archiving"`
 }
 
 type ChannelForExport struct {
@@ -107,6 +115,18 @@ func (o *Channel) Etag() string {
 	return Etag(o.Id, o.UpdateAt)
 }
 
+// LastModifiedAt returns the latest time at which any of the channel's stored fields changed. Fields computed when
+// the channel is returned, such as its props, aren't covered.
+func (o *Channel) LastModifiedAt() int64 {
+	lastModified := o.UpdateAt
+	for _, at := range []int64{o.DeleteAt, o.LastPostAt, o.ExtraUpdateAt} {
+		if at > lastModified {
+			lastModified = at
+		}
+	}
+	return lastModified
+}
+
 func (o *Channel) IsValid() *AppError {
 	if len(o.Id) != 26 {
 		return NewAppError("Channel.IsValid", "model.channel.is_valid.id.app_error", nil, "", http.StatusBadRequest)
@@ -144,6 +164,14 @@ func (o *Channel) IsValid() *AppError {
 		return NewAppError("Channel.IsValid", "model.channel.is_valid.creator_id.app_error", nil, "", http.StatusBadRequest)
 	}
 
+	if len(o.BannerPostId) != 0 && !IsValidId(o.BannerPostId) {
+		return NewAppError("Channel.IsValid", "model.channel.is_valid.banner_post_id.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if o.MaxPostSize < 0 {
+		return NewAppError("Channel.IsValid", "model.channel.is_valid.max_post_size.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
 	return nil
 }
 
@@ -181,6 +209,18 @@ func (o *Channel) Patch(patch *ChannelPatch) {
 	if patch.Purpose != nil {
 		o.Purpose = *patch.Purpose
 	}
+
+	if patch.MaxPostSize != nil {
+		o.MaxPostSize = *patch.MaxPostSize
+	}
+
+	if patch.SuppressJoinLeaveMessages != nil {
+		o.SuppressJoinLeaveMessages = *patch.SuppressJoinLeaveMessages
+	}
+
+	if patch.ExemptFromArchiving != nil {
+		o.ExemptFromArchiving = *patch.ExemptFromArchiving
+	}
 }
 
 func (o *Channel) MakeNonNil() {
@@ -0,0 +1,120 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	CHANNEL_ACTIVITY_GRANULARITY_HOUR = "hour"
+	CHANNEL_ACTIVITY_GRANULARITY_DAY  = "day"
+
+	CHANNEL_ACTIVITY_MAX_HOURS = 31 * 24
+	CHANNEL_ACTIVITY_MAX_DAYS  = 366
+
+	// Post counts are aggregated by the database into buckets of this size, in milliseconds, before being
+	// merged into the requested granularity. Every time zone offset in use is a multiple of 15 minutes.
+	CHANNEL_ACTIVITY_BASE_INTERVAL = 15 * 60 * 1000
+)
+
+type ChannelActivityBucket struct {
+	StartAt int64 `json:"start_at"`
+	Count   int64 `json:"count"`
+}
+
+type ChannelActivity struct {
+	ChannelId   string                   `json:"channel_id"`
+	Granularity string                   `json:"granularity"`
+	TimeZone    string                   `json:"time_zone"`
+	Since       int64                    `json:"since"`
+	Until       int64                    `json:"until"`
+	Buckets     []*ChannelActivityBucket `json:"buckets"`
+
+	location *time.Location
+}
+
+// NewChannelActivity validates the requested range and returns a ChannelActivity with an empty bucket for
+// every hour or day, in the given time zone, that overlaps [since, until).
+func NewChannelActivity(channelId string, granularity string, timeZone string, since int64, until int64) (*ChannelActivity, *AppError) {
+	var maxRange time.Duration
+	switch granularity {
+	case CHANNEL_ACTIVITY_GRANULARITY_HOUR:
+		maxRange = CHANNEL_ACTIVITY_MAX_HOURS * time.Hour
+	case CHANNEL_ACTIVITY_GRANULARITY_DAY:
+		maxRange = CHANNEL_ACTIVITY_MAX_DAYS * 24 * time.Hour
+	default:
+		return nil, NewAppError("NewChannelActivity", "model.channel_activity.granularity.app_error", nil, "granularity="+granularity, http.StatusBadRequest)
+	}
+
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, NewAppError("NewChannelActivity", "model.channel_activity.time_zone.app_error", nil, "time_zone="+timeZone, http.StatusBadRequest)
+	}
+
+	if since < 0 || until <= since || time.Duration(until-since)*time.Millisecond > maxRange {
+		return nil, NewAppError("NewChannelActivity", "model.channel_activity.range.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	activity := &ChannelActivity{
+		ChannelId:   channelId,
+		Granularity: granularity,
+		TimeZone:    location.String(),
+		Since:       since,
+		Until:       until,
+		Buckets:     []*ChannelActivityBucket{},
+		location:    location,
+	}
+
+	end := time.Unix(0, until*int64(time.Millisecond))
+	for t := activity.bucketStart(since); t.Before(end); t = activity.nextBucketStart(t) {
+		activity.Buckets = append(activity.Buckets, &ChannelActivityBucket{StartAt: GetMillisForTime(t)})
+	}
+
+	return activity, nil
+}
+
+func (o *ChannelActivity) bucketStart(millis int64) time.Time {
+	t := time.Unix(0, millis*int64(time.Millisecond)).In(o.location)
+
+	if o.Granularity == CHANNEL_ACTIVITY_GRANULARITY_DAY {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, o.location)
+	}
+
+	// Subtract rather than using time.Date so that repeated hours at the end of daylight saving time stay distinct.
+	return t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+}
+
+func (o *ChannelActivity) nextBucketStart(t time.Time) time.Time {
+	if o.Granularity == CHANNEL_ACTIVITY_GRANULARITY_DAY {
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, o.location)
+	}
+
+	return o.bucketStart(GetMillisForTime(t.Add(time.Hour)))
+}
+
+// AddCount adds count posts created at the given time to the bucket containing it.
+func (o *ChannelActivity) AddCount(createAt int64, count int64) {
+	startAt := GetMillisForTime(o.bucketStart(createAt))
+
+	i := sort.Search(len(o.Buckets), func(i int) bool { return o.Buckets[i].StartAt >= startAt })
+	if i < len(o.Buckets) && o.Buckets[i].StartAt == startAt {
+		o.Buckets[i].Count += count
+	}
+}
+
+func (o *ChannelActivity) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func ChannelActivityFromJson(data io.Reader) *ChannelActivity {
+	var o *ChannelActivity
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
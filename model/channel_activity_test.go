@@ -0,0 +1,93 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChannelActivity(t *testing.T) {
+	since := GetMillisForTime(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	t.Run("invalid granularity", func(t *testing.T) {
+		_, err := NewChannelActivity(NewId(), "week", "UTC", since, since+1000)
+		require.NotNil(t, err)
+		assert.Equal(t, "model.channel_activity.granularity.app_error", err.Id)
+	})
+
+	t.Run("invalid time zone", func(t *testing.T) {
+		_, err := NewChannelActivity(NewId(), CHANNEL_ACTIVITY_GRANULARITY_DAY, "Nowhere/Special", since, since+1000)
+		require.NotNil(t, err)
+		assert.Equal(t, "model.channel_activity.time_zone.app_error", err.Id)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, err := NewChannelActivity(NewId(), CHANNEL_ACTIVITY_GRANULARITY_DAY, "UTC", since, since)
+		require.NotNil(t, err)
+
+		_, err = NewChannelActivity(NewId(), CHANNEL_ACTIVITY_GRANULARITY_HOUR, "UTC", since, since+(CHANNEL_ACTIVITY_MAX_HOURS+1)*60*60*1000)
+		require.NotNil(t, err)
+		assert.Equal(t, "model.channel_activity.range.app_error", err.Id)
+	})
+
+	t.Run("hourly buckets", func(t *testing.T) {
+		activity, err := NewChannelActivity(NewId(), CHANNEL_ACTIVITY_GRANULARITY_HOUR, "UTC", since, since+24*60*60*1000)
+		require.Nil(t, err)
+		require.Len(t, activity.Buckets, 24)
+		assert.Equal(t, since, activity.Buckets[0].StartAt)
+		assert.Equal(t, since+60*60*1000, activity.Buckets[1].StartAt)
+	})
+
+	t.Run("daily buckets follow the time zone", func(t *testing.T) {
+		activity, err := NewChannelActivity(NewId(), CHANNEL_ACTIVITY_GRANULARITY_DAY, "Asia/Kolkata", since, since+2*24*60*60*1000)
+		require.Nil(t, err)
+
+		// Midnight UTC is 05:30 in India, so the first bucket starts on the previous UTC day.
+		require.Len(t, activity.Buckets, 3)
+		assert.Equal(t, GetMillisForTime(time.Date(2018, 12, 31, 18, 30, 0, 0, time.UTC)), activity.Buckets[0].StartAt)
+	})
+
+	t.Run("daylight saving time", func(t *testing.T) {
+		start := GetMillisForTime(time.Date(2019, 3, 10, 5, 0, 0, 0, time.UTC))
+		activity, err := NewChannelActivity(NewId(), CHANNEL_ACTIVITY_GRANULARITY_DAY, "America/New_York", start, start+24*60*60*1000)
+		require.Nil(t, err)
+
+		// March 10th only has 23 hours in New York.
+		require.Len(t, activity.Buckets, 2)
+		assert.Equal(t, int64(23*60*60*1000), activity.Buckets[1].StartAt-activity.Buckets[0].StartAt)
+	})
+}
+
+func TestChannelActivityAddCount(t *testing.T) {
+	since := GetMillisForTime(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	activity, err := NewChannelActivity(NewId(), CHANNEL_ACTIVITY_GRANULARITY_HOUR, "Asia/Kolkata", since, since+6*60*60*1000)
+	require.Nil(t, err)
+
+	// 00:15 UTC is 05:45 in India and belongs in the bucket starting at 05:00, which is 23:30 UTC.
+	activity.AddCount(since+CHANNEL_ACTIVITY_BASE_INTERVAL, 3)
+	activity.AddCount(since+2*CHANNEL_ACTIVITY_BASE_INTERVAL, 2)
+	activity.AddCount(since+10*24*60*60*1000, 5)
+
+	assert.Equal(t, since-30*60*1000, activity.Buckets[0].StartAt)
+	assert.Equal(t, int64(3), activity.Buckets[0].Count)
+	assert.Equal(t, int64(2), activity.Buckets[1].Count)
+
+	var total int64
+	for _, bucket := range activity.Buckets {
+		total += bucket.Count
+	}
+	assert.Equal(t, int64(5), total)
+
+	json := activity.ToJson()
+	ractivity := ChannelActivityFromJson(strings.NewReader(json))
+	require.NotNil(t, ractivity)
+	assert.Equal(t, activity.Buckets, ractivity.Buckets)
+	assert.Equal(t, "Asia/Kolkata", ractivity.TimeZone)
+}
@@ -0,0 +1,277 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	CHANNEL_DUPLICATES_DEFAULT_THRESHOLD = 0.8
+
+	// Channels are only compared with their closest neighbours when sorted by name, and with channels sharing an
+	// uncommon word, so that finding duplicates doesn't require comparing every pair of channels.
+	CHANNEL_DUPLICATES_WINDOW_SIZE     = 8
+	CHANNEL_DUPLICATES_MAX_TOKEN_BLOCK = 50
+	CHANNEL_DUPLICATES_MIN_TOKEN_RUNES = 3
+
+	CHANNEL_DUPLICATES_MIN_PURPOSE_TOKENS = 3
+)
+
+type ChannelDuplicateCandidate struct {
+	ChannelId   string `json:"channel_id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Type        string `json:"type"`
+	Purpose     string `json:"purpose"`
+	MemberCount int64  `json:"member_count"`
+	LastPostAt  int64  `json:"last_post_at"`
+}
+
+// ChannelDuplicateCluster is a group of channels that are likely to duplicate each other.
+type ChannelDuplicateCluster struct {
+	Channels []*ChannelDuplicateCandidate `json:"channels"`
+}
+
+func ChannelDuplicateClustersToJson(o []*ChannelDuplicateCluster) string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func ChannelDuplicateClustersFromJson(data io.Reader) []*ChannelDuplicateCluster {
+	var o []*ChannelDuplicateCluster
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+// FindDuplicateChannels groups channels whose URL names, display names or purposes are at least threshold similar,
+// on a scale from 0 to 1. Channels that aren't similar to any other channel are left out.
+func FindDuplicateChannels(channels []*Channel, threshold float64) [][]*Channel {
+	keys := make([]channelComparisonKeys, len(channels))
+	for i, channel := range channels {
+		keys[i] = newChannelComparisonKeys(channel)
+	}
+
+	parents := make([]int, len(channels))
+	for i := range parents {
+		parents[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+
+	compared := make(map[[2]int]bool)
+	compare := func(i, j int) {
+		if i == j {
+			return
+		}
+		if i > j {
+			i, j = j, i
+		}
+		if compared[[2]int{i, j}] {
+			return
+		}
+		compared[[2]int{i, j}] = true
+
+		if find(i) != find(j) && keys[i].similarity(keys[j]) >= threshold {
+			parents[find(i)] = find(j)
+		}
+	}
+
+	for _, key := range []func(k channelComparisonKeys) string{
+		func(k channelComparisonKeys) string { return k.name },
+		func(k channelComparisonKeys) string { return reverseString(k.name) },
+		func(k channelComparisonKeys) string { return k.displayName },
+		func(k channelComparisonKeys) string { return reverseString(k.displayName) },
+	} {
+		order := make([]int, len(channels))
+		sortKeys := make([]string, len(channels))
+		for i := range order {
+			order[i] = i
+			sortKeys[i] = key(keys[i])
+		}
+		sort.Slice(order, func(a, b int) bool { return sortKeys[order[a]] < sortKeys[order[b]] })
+
+		for a := range order {
+			for b := a + 1; b < len(order) && b <= a+CHANNEL_DUPLICATES_WINDOW_SIZE; b++ {
+				compare(order[a], order[b])
+			}
+		}
+	}
+
+	blocks := make(map[string][]int)
+	for i, k := range keys {
+		for token := range k.tokens {
+			blocks[token] = append(blocks[token], i)
+		}
+	}
+	for _, block := range blocks {
+		if len(block) > CHANNEL_DUPLICATES_MAX_TOKEN_BLOCK {
+			continue
+		}
+		for a := range block {
+			for b := a + 1; b < len(block); b++ {
+				compare(block[a], block[b])
+			}
+		}
+	}
+
+	clustersByRoot := make(map[int][]*Channel)
+	var roots []int
+	for i, channel := range channels {
+		root := find(i)
+		if _, ok := clustersByRoot[root]; !ok {
+			roots = append(roots, root)
+		}
+		clustersByRoot[root] = append(clustersByRoot[root], channel)
+	}
+
+	var clusters [][]*Channel
+	for _, root := range roots {
+		if len(clustersByRoot[root]) > 1 {
+			clusters = append(clusters, clustersByRoot[root])
+		}
+	}
+
+	return clusters
+}
+
+type channelComparisonKeys struct {
+	name          string
+	displayName   string
+	purposeTokens map[string]bool
+	tokens        map[string]bool
+}
+
+func newChannelComparisonKeys(channel *Channel) channelComparisonKeys {
+	keys := channelComparisonKeys{
+		name:          normalizeForComparison(channel.Name),
+		displayName:   normalizeForComparison(channel.DisplayName),
+		purposeTokens: tokenizeForComparison(channel.Purpose),
+		tokens:        tokenizeForComparison(channel.DisplayName),
+	}
+
+	for token := range keys.purposeTokens {
+		keys.tokens[token] = true
+	}
+
+	return keys
+}
+
+func (k channelComparisonKeys) similarity(other channelComparisonKeys) float64 {
+	score := stringSimilarity(k.name, other.name)
+	if s := stringSimilarity(k.displayName, other.displayName); s > score {
+		score = s
+	}
+
+	// Short purposes like "General discussion" say little about whether two channels are the same.
+	if len(k.purposeTokens) >= CHANNEL_DUPLICATES_MIN_PURPOSE_TOKENS && len(other.purposeTokens) >= CHANNEL_DUPLICATES_MIN_PURPOSE_TOKENS {
+		if s := tokenSimilarity(k.purposeTokens, other.purposeTokens); s > score {
+			score = s
+		}
+	}
+
+	return score
+}
+
+// normalizeForComparison lowercases a name and drops everything but letters and digits, so that "Dev-Ops" and
+// "devops" compare as equal.
+func normalizeForComparison(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+func tokenizeForComparison(s string) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, token := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(token)) >= CHANNEL_DUPLICATES_MIN_TOKEN_RUNES {
+			tokens[token] = true
+		}
+	}
+	return tokens
+}
+
+// stringSimilarity is one minus the edit distance between a and b relative to the length of the longer one.
+func stringSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+// tokenSimilarity is the Jaccard index of two sets of words.
+func tokenSimilarity(a, b map[string]bool) float64 {
+	shared := 0
+	for token := range a {
+		if b[token] {
+			shared++
+		}
+	}
+
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+
+	return float64(shared) / float64(union)
+}
+
+func reverseString(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, stringSimilarity("devops", "devops"))
+	assert.Equal(t, 0.0, stringSimilarity("", "devops"))
+	assert.InDelta(t, 5.0/6.0, stringSimilarity("devops", "devop"), 0.001)
+	assert.Equal(t, 3, editDistance([]rune("kitten"), []rune("sitting")))
+	assert.Equal(t, normalizeForComparison("Dev-Ops"), normalizeForComparison("devops"))
+}
+
+func TestFindDuplicateChannels(t *testing.T) {
+	makeChannel := func(name, displayName, purpose string) *Channel {
+		return &Channel{Id: NewId(), Name: name, DisplayName: displayName, Purpose: purpose}
+	}
+
+	devops := makeChannel("devops", "DevOps", "")
+	devOps := makeChannel("dev-ops", "Dev Ops", "")
+	devop := makeChannel("devop", "DevOp", "")
+	devopsTeam := makeChannel("devops-team", "DevOps Team", "")
+	marketing := makeChannel("marketing", "Marketing", "Campaign planning and launch coordination")
+	launches := makeChannel("launches", "Launches", "Launch coordination and campaign planning")
+	random := makeChannel("random", "Random", "General discussion")
+	offtopic := makeChannel("off-topic", "Off-Topic", "General discussion")
+
+	clusters := FindDuplicateChannels([]*Channel{devops, marketing, random, devOps, launches, offtopic, devopsTeam, devop}, CHANNEL_DUPLICATES_DEFAULT_THRESHOLD)
+	require.Len(t, clusters, 2)
+
+	assert.ElementsMatch(t, []*Channel{devops, devOps, devop}, clusters[0])
+	assert.ElementsMatch(t, []*Channel{marketing, launches}, clusters[1], "channels with the same purpose are duplicates")
+
+	assert.Empty(t, FindDuplicateChannels([]*Channel{devops, marketing}, CHANNEL_DUPLICATES_DEFAULT_THRESHOLD))
+}
+
+func TestFindDuplicateChannelsManyChannels(t *testing.T) {
+	// Channels following a naming convention share a prefix and would all be compared with each other if channels
+	// were grouped by prefix.
+	var channels []*Channel
+	for i := 0; i < 2000; i++ {
+		name := fmt.Sprintf("proj-%v", NewId())
+		channels = append(channels, &Channel{Id: NewId(), Name: name, DisplayName: strings.ToUpper(name), Purpose: "Work on " + NewId()})
+	}
+	duplicate := &Channel{Id: NewId(), Name: channels[1000].Name + "x", DisplayName: "Copy"}
+	channels = append(channels, duplicate)
+
+	clusters := FindDuplicateChannels(channels, 0.9)
+	require.Len(t, clusters, 1)
+	assert.ElementsMatch(t, []*Channel{channels[1000], duplicate}, clusters[0])
+}
+
+func TestChannelDuplicateClustersJson(t *testing.T) {
+	clusters := []*ChannelDuplicateCluster{{Channels: []*ChannelDuplicateCandidate{{ChannelId: NewId(), MemberCount: 3}}}}
+
+	rclusters := ChannelDuplicateClustersFromJson(strings.NewReader(ChannelDuplicateClustersToJson(clusters)))
+	require.Len(t, rclusters, 1)
+	assert.Equal(t, clusters[0].Channels[0], rclusters[0].Channels[0])
+}
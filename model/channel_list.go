@@ -18,6 +18,14 @@ func (o *ChannelList) ToJson() string {
 	}
 }
 
+func (o *ChannelList) ToCsv() string {
+	rows := make([]interface{}, len(*o))
+	for i, channel := range *o {
+		rows[i] = channel
+	}
+	return ToCsv(ChannelCsvColumns, rows)
+}
+
 func (o *ChannelList) Etag() string {
 
 	id := "0"
@@ -62,6 +70,14 @@ func (o *ChannelListWithTeamData) ToJson() string {
 	}
 }
 
+func (o *ChannelListWithTeamData) ToCsv() string {
+	rows := make([]interface{}, len(*o))
+	for i, channel := range *o {
+		rows[i] = channel
+	}
+	return ToCsv(ChannelWithTeamDataCsvColumns, rows)
+}
+
 func (o *ChannelListWithTeamData) Etag() string {
 
 	id := "0"
@@ -93,3 +109,33 @@ func ChannelListWithTeamDataFromJson(data io.Reader) *ChannelListWithTeamData {
 	json.NewDecoder(data).Decode(&o)
 	return o
 }
+
+// ChannelCsvColumns are the fields of a channel that are included when a list of channels is written as CSV. They can
+// be used with both *Channel and *ChannelWithTeamData.
+var ChannelCsvColumns = []CsvColumn{
+	{"id", func(row interface{}) string { return csvChannel(row).Id }},
+	{"create_at", func(row interface{}) string { return csvInt64(csvChannel(row).CreateAt) }},
+	{"update_at", func(row interface{}) string { return csvInt64(csvChannel(row).UpdateAt) }},
+	{"delete_at", func(row interface{}) string { return csvInt64(csvChannel(row).DeleteAt) }},
+	{"team_id", func(row interface{}) string { return csvChannel(row).TeamId }},
+	{"type", func(row interface{}) string { return csvChannel(row).Type }},
+	{"display_name", func(row interface{}) string { return csvChannel(row).DisplayName }},
+	{"name", func(row interface{}) string { return csvChannel(row).Name }},
+	{"header", func(row interface{}) string { return csvChannel(row).Header }},
+	{"purpose", func(row interface{}) string { return csvChannel(row).Purpose }},
+	{"last_post_at", func(row interface{}) string { return csvInt64(csvChannel(row).LastPostAt) }},
+	{"total_msg_count", func(row interface{}) string { return csvInt64(csvChannel(row).TotalMsgCount) }},
+	{"creator_id", func(row interface{}) string { return csvChannel(row).CreatorId }},
+}
+
+var ChannelWithTeamDataCsvColumns = append(ChannelCsvColumns[:len(ChannelCsvColumns):len(ChannelCsvColumns)],
+	CsvColumn{"team_name", func(row interface{}) string { return row.(*ChannelWithTeamData).TeamName }},
+	CsvColumn{"team_display_name", func(row interface{}) string { return row.(*ChannelWithTeamData).TeamDisplayName }},
+)
+
+func csvChannel(row interface{}) *Channel {
+	if channel, ok := row.(*ChannelWithTeamData); ok {
+		return &channel.Channel
+	}
+	return row.(*Channel)
+}
@@ -21,8 +21,21 @@ const (
 	IGNORE_CHANNEL_MENTIONS_OFF         = "off"
 	IGNORE_CHANNEL_MENTIONS_ON          = "on"
 	IGNORE_CHANNEL_MENTIONS_NOTIFY_PROP = "ignore_channel_mentions"
+
+	CHANNEL_MEMBER_SORT_USERNAME  = "username"
+	CHANNEL_MEMBER_SORT_NICKNAME  = "nickname"
+	CHANNEL_MEMBER_SORT_JOINED_AT = "joined_at"
+	CHANNEL_MEMBER_SORT_ROLE      = "role"
 )
 
+func IsValidChannelMemberSort(sort string) bool {
+	switch sort {
+	case CHANNEL_MEMBER_SORT_USERNAME, CHANNEL_MEMBER_SORT_NICKNAME, CHANNEL_MEMBER_SORT_JOINED_AT, CHANNEL_MEMBER_SORT_ROLE:
+		return true
+	}
+	return false
+}
+
 type ChannelUnread struct {
 	TeamId       string    `json:"team_id"`
 	ChannelId    string    `json:"channel_id"`
@@ -126,6 +139,16 @@ func (o *ChannelMember) IsValid() *AppError {
 		}
 	}
 
+	if muteSchedule := o.NotifyProps[MUTE_SCHEDULE_NOTIFY_PROP]; muteSchedule != "" {
+		schedule, err := ChannelMuteScheduleFromJson(muteSchedule)
+		if err != nil {
+			return NewAppError("ChannelMember.IsValid", "model.channel_member.is_valid.mute_schedule.app_error", nil, err.Error(), http.StatusBadRequest)
+		}
+		if appErr := schedule.IsValid(); appErr != nil {
+			return appErr
+		}
+	}
+
 	return nil
 }
 
@@ -70,6 +70,20 @@ func TestChannelMemberIsValid(t *testing.T) {
 	}
 }
 
+func TestIsValidChannelMemberSort(t *testing.T) {
+	for _, sort := range []string{CHANNEL_MEMBER_SORT_USERNAME, CHANNEL_MEMBER_SORT_NICKNAME, CHANNEL_MEMBER_SORT_JOINED_AT, CHANNEL_MEMBER_SORT_ROLE} {
+		if !IsValidChannelMemberSort(sort) {
+			t.Fatal("should be valid: " + sort)
+		}
+	}
+
+	for _, sort := range []string{"", "email"} {
+		if IsValidChannelMemberSort(sort) {
+			t.Fatal("should be invalid: " + sort)
+		}
+	}
+}
+
 func TestChannelUnreadJson(t *testing.T) {
 	o := ChannelUnread{ChannelId: NewId(), TeamId: NewId(), MsgCount: 5, MentionCount: 3}
 	json := o.ToJson()
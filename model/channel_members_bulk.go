@@ -0,0 +1,134 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+const (
+	CHANNEL_MEMBERS_BULK_MAX_USERS  = 1000
+	CHANNEL_MEMBERS_BULK_BATCH_SIZE = 100
+)
+
+// ChannelMembersBulkRequest selects the users to add to a channel, either by id or as the members of a group or
+// team. Exactly one source must be given.
+type ChannelMembersBulkRequest struct {
+	UserIds []string `json:"user_ids"`
+	GroupId string   `json:"group_id"`
+	TeamId  string   `json:"team_id"`
+}
+
+// ChannelMembersCopyRequest selects the channel whose members are copied to another channel.
+type ChannelMembersCopyRequest struct {
+	SourceChannelId string `json:"source_channel_id"`
+	PreserveRoles   bool   `json:"preserve_roles"`
+}
+
+// ChannelMemberRolesBulkRequest maps the ids of channel members to the roles to give them, each as a space-separated
+// list in the same way as when updating the roles of a single member.
+type ChannelMemberRolesBulkRequest map[string]string
+
+// ChannelMemberBulkResult reports the outcome of adding a single user. Users that were already members of the
+// channel are reported with their existing membership.
+type ChannelMemberBulkResult struct {
+	UserId string         `json:"user_id"`
+	Member *ChannelMember `json:"member,omitempty"`
+	Error  *AppError      `json:"error,omitempty"`
+}
+
+func (o *ChannelMembersBulkRequest) IsValid() *AppError {
+	sources := 0
+	if len(o.UserIds) > 0 {
+		sources++
+	}
+	if len(o.GroupId) > 0 {
+		sources++
+	}
+	if len(o.TeamId) > 0 {
+		sources++
+	}
+
+	if sources != 1 {
+		return NewAppError("ChannelMembersBulkRequest.IsValid", "model.channel_members_bulk.is_valid.source.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(o.UserIds) > CHANNEL_MEMBERS_BULK_MAX_USERS {
+		return NewAppError("ChannelMembersBulkRequest.IsValid", "model.channel_members_bulk.is_valid.too_many_users.app_error", map[string]interface{}{"Max": CHANNEL_MEMBERS_BULK_MAX_USERS}, "", http.StatusBadRequest)
+	}
+
+	for _, userId := range o.UserIds {
+		if !IsValidId(userId) {
+			return NewAppError("ChannelMembersBulkRequest.IsValid", "model.channel_members_bulk.is_valid.user_id.app_error", nil, "user_id="+userId, http.StatusBadRequest)
+		}
+	}
+
+	if len(o.GroupId) > 0 && !IsValidId(o.GroupId) {
+		return NewAppError("ChannelMembersBulkRequest.IsValid", "model.channel_members_bulk.is_valid.group_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(o.TeamId) > 0 && !IsValidId(o.TeamId) {
+		return NewAppError("ChannelMembersBulkRequest.IsValid", "model.channel_members_bulk.is_valid.team_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (o ChannelMemberRolesBulkRequest) IsValid() *AppError {
+	if len(o) == 0 {
+		return NewAppError("ChannelMemberRolesBulkRequest.IsValid", "model.channel_members_bulk.is_valid.no_users.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(o) > CHANNEL_MEMBERS_BULK_MAX_USERS {
+		return NewAppError("ChannelMemberRolesBulkRequest.IsValid", "model.channel_members_bulk.is_valid.too_many_users.app_error", map[string]interface{}{"Max": CHANNEL_MEMBERS_BULK_MAX_USERS}, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (o *ChannelMembersBulkRequest) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func ChannelMembersBulkRequestFromJson(data io.Reader) *ChannelMembersBulkRequest {
+	var o *ChannelMembersBulkRequest
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+func (o *ChannelMembersCopyRequest) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func ChannelMembersCopyRequestFromJson(data io.Reader) *ChannelMembersCopyRequest {
+	var o *ChannelMembersCopyRequest
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+func (o ChannelMemberRolesBulkRequest) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func ChannelMemberRolesBulkRequestFromJson(data io.Reader) ChannelMemberRolesBulkRequest {
+	var o ChannelMemberRolesBulkRequest
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+func ChannelMemberBulkResultsToJson(o []*ChannelMemberBulkResult) string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func ChannelMemberBulkResultsFromJson(data io.Reader) []*ChannelMemberBulkResult {
+	var o []*ChannelMemberBulkResult
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelMembersBulkRequestIsValid(t *testing.T) {
+	request := &ChannelMembersBulkRequest{}
+	require.NotNil(t, request.IsValid(), "a source is required")
+
+	request = &ChannelMembersBulkRequest{UserIds: []string{NewId()}, TeamId: NewId()}
+	require.NotNil(t, request.IsValid(), "only one source is allowed")
+
+	request = &ChannelMembersBulkRequest{UserIds: []string{NewId(), "junk"}}
+	require.NotNil(t, request.IsValid())
+
+	request = &ChannelMembersBulkRequest{UserIds: make([]string, CHANNEL_MEMBERS_BULK_MAX_USERS+1)}
+	for i := range request.UserIds {
+		request.UserIds[i] = NewId()
+	}
+	err := request.IsValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.channel_members_bulk.is_valid.too_many_users.app_error", err.Id)
+
+	request = &ChannelMembersBulkRequest{GroupId: "junk"}
+	require.NotNil(t, request.IsValid())
+
+	request = &ChannelMembersBulkRequest{UserIds: []string{NewId(), NewId()}}
+	require.Nil(t, request.IsValid())
+
+	request = &ChannelMembersBulkRequest{TeamId: NewId()}
+	require.Nil(t, request.IsValid())
+
+	rrequest := ChannelMembersBulkRequestFromJson(strings.NewReader(request.ToJson()))
+	require.NotNil(t, rrequest)
+	assert.Equal(t, request.TeamId, rrequest.TeamId)
+}
+
+func TestChannelMemberBulkResultsJson(t *testing.T) {
+	results := []*ChannelMemberBulkResult{
+		{UserId: NewId(), Member: &ChannelMember{ChannelId: NewId(), UserId: NewId()}},
+		{UserId: NewId(), Error: NewAppError("test", "test.app_error", nil, "", 400)},
+	}
+
+	rresults := ChannelMemberBulkResultsFromJson(strings.NewReader(ChannelMemberBulkResultsToJson(results)))
+	require.Len(t, rresults, 2)
+	assert.Equal(t, results[0].Member.ChannelId, rresults[0].Member.ChannelId)
+	assert.Nil(t, rresults[0].Error)
+	assert.Nil(t, rresults[1].Member)
+	assert.Equal(t, "test.app_error", rresults[1].Error.Id)
+}
+
+func TestChannelMemberRolesBulkRequestIsValid(t *testing.T) {
+	request := ChannelMemberRolesBulkRequest{}
+	require.NotNil(t, request.IsValid(), "a user is required")
+
+	request = ChannelMemberRolesBulkRequest{}
+	for i := 0; i <= CHANNEL_MEMBERS_BULK_MAX_USERS; i++ {
+		request[NewId()] = "channel_user"
+	}
+	err := request.IsValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.channel_members_bulk.is_valid.too_many_users.app_error", err.Id)
+
+	userId := NewId()
+	request = ChannelMemberRolesBulkRequest{userId: "channel_user channel_admin"}
+	require.Nil(t, request.IsValid())
+
+	rrequest := ChannelMemberRolesBulkRequestFromJson(strings.NewReader(request.ToJson()))
+	assert.Equal(t, "channel_user channel_admin", rrequest[userId])
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	CHANNEL_MENTION_COUNTS_MAX_USERS = 200
+	CHANNEL_MENTION_COUNTS_MAX_DAYS  = 90
+)
+
+// ChannelMentionCounts holds how many posts in a channel mentioned each of a set of users during [Since, Until).
+type ChannelMentionCounts struct {
+	ChannelId string           `json:"channel_id"`
+	Since     int64            `json:"since"`
+	Until     int64            `json:"until"`
+	Counts    map[string]int64 `json:"counts"`
+}
+
+// NewChannelMentionCounts validates the requested users and range and returns a ChannelMentionCounts with a zero
+// count for every user.
+func NewChannelMentionCounts(channelId string, userIds []string, since int64, until int64) (*ChannelMentionCounts, *AppError) {
+	if len(userIds) == 0 || len(userIds) > CHANNEL_MENTION_COUNTS_MAX_USERS {
+		return nil, NewAppError("NewChannelMentionCounts", "model.channel_mention_counts.user_ids.app_error", map[string]interface{}{"Max": CHANNEL_MENTION_COUNTS_MAX_USERS}, "", http.StatusBadRequest)
+	}
+
+	counts := make(map[string]int64, len(userIds))
+	for _, userId := range userIds {
+		if !IsValidId(userId) {
+			return nil, NewAppError("NewChannelMentionCounts", "model.channel_mention_counts.user_ids.app_error", map[string]interface{}{"Max": CHANNEL_MENTION_COUNTS_MAX_USERS}, "user_id="+userId, http.StatusBadRequest)
+		}
+		counts[userId] = 0
+	}
+
+	if since < 0 || until <= since || time.Duration(until-since)*time.Millisecond > CHANNEL_MENTION_COUNTS_MAX_DAYS*24*time.Hour {
+		return nil, NewAppError("NewChannelMentionCounts", "model.channel_mention_counts.range.app_error", map[string]interface{}{"MaxDays": CHANNEL_MENTION_COUNTS_MAX_DAYS}, "", http.StatusBadRequest)
+	}
+
+	return &ChannelMentionCounts{
+		ChannelId: channelId,
+		Since:     since,
+		Until:     until,
+		Counts:    counts,
+	}, nil
+}
+
+func (o *ChannelMentionCounts) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func ChannelMentionCountsFromJson(data io.Reader) *ChannelMentionCounts {
+	var o *ChannelMentionCounts
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChannelMentionCounts(t *testing.T) {
+	userId := NewId()
+	since := int64(1000)
+	day := int64(24 * 60 * 60 * 1000)
+
+	t.Run("valid", func(t *testing.T) {
+		counts, err := NewChannelMentionCounts(NewId(), []string{userId}, since, since+day)
+		require.Nil(t, err)
+		assert.Equal(t, map[string]int64{userId: 0}, counts.Counts)
+
+		rcounts := ChannelMentionCountsFromJson(strings.NewReader(counts.ToJson()))
+		require.NotNil(t, rcounts)
+		assert.Equal(t, counts, rcounts)
+	})
+
+	t.Run("no users", func(t *testing.T) {
+		_, err := NewChannelMentionCounts(NewId(), []string{}, since, since+day)
+		require.NotNil(t, err)
+		assert.Equal(t, "model.channel_mention_counts.user_ids.app_error", err.Id)
+	})
+
+	t.Run("too many users", func(t *testing.T) {
+		userIds := make([]string, CHANNEL_MENTION_COUNTS_MAX_USERS+1)
+		for i := range userIds {
+			userIds[i] = NewId()
+		}
+		_, err := NewChannelMentionCounts(NewId(), userIds, since, since+day)
+		require.NotNil(t, err)
+		assert.Equal(t, "model.channel_mention_counts.user_ids.app_error", err.Id)
+	})
+
+	t.Run("invalid user id", func(t *testing.T) {
+		_, err := NewChannelMentionCounts(NewId(), []string{"junk"}, since, since+day)
+		require.NotNil(t, err)
+		assert.Equal(t, "model.channel_mention_counts.user_ids.app_error", err.Id)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, err := NewChannelMentionCounts(NewId(), []string{userId}, since, since)
+		require.NotNil(t, err)
+		assert.Equal(t, "model.channel_mention_counts.range.app_error", err.Id)
+
+		_, err = NewChannelMentionCounts(NewId(), []string{userId}, since, since+(CHANNEL_MENTION_COUNTS_MAX_DAYS+1)*day)
+		require.NotNil(t, err)
+		assert.Equal(t, "model.channel_mention_counts.range.app_error", err.Id)
+	})
+}
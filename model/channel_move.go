@@ -0,0 +1,27 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ChannelMoveRequest asks for a channel to be moved to another team. A channel can't be moved while any of its
+// members aren't in the team unless RemoveMembersNotInTeam is set, in which case they're removed from the channel.
+type ChannelMoveRequest struct {
+	TeamId                 string `json:"team_id"`
+	RemoveMembersNotInTeam bool   `json:"remove_members_not_in_team"`
+}
+
+func (o *ChannelMoveRequest) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func ChannelMoveRequestFromJson(data io.Reader) *ChannelMoveRequest {
+	var o *ChannelMoveRequest
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelMoveRequestJson(t *testing.T) {
+	request := &ChannelMoveRequest{
+		TeamId:                 NewId(),
+		RemoveMembersNotInTeam: true,
+	}
+
+	rrequest := ChannelMoveRequestFromJson(strings.NewReader(request.ToJson()))
+
+	assert.Equal(t, request, rrequest)
+	assert.Nil(t, ChannelMoveRequestFromJson(strings.NewReader("junk")))
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const (
+	// MUTE_SCHEDULE_NOTIFY_PROP is the channel notify prop that holds a member's ChannelMuteSchedule as JSON.
+	MUTE_SCHEDULE_NOTIFY_PROP = "mute_schedule"
+
+	CHANNEL_MUTE_SCHEDULE_MAX_WINDOWS = 10
+
+	channelMuteWindowClockLayout = "15:04"
+)
+
+// ChannelMuteWindow is a recurring window of time during which a channel is muted for a member. Start and End are
+// wall-clock times, formatted as HH:MM, in the member's timezone, so that the window follows daylight saving time. A
+// window whose End isn't after its Start runs past midnight into the next day.
+type ChannelMuteWindow struct {
+	// Days are the days of the week that the window starts on, or every day if there are none.
+	Days  []time.Weekday `json:"days,omitempty"`
+	Start string         `json:"start"`
+	End   string         `json:"end"`
+}
+
+// ChannelMuteSchedule is the set of windows during which a channel is muted for a member. A channel is muted while
+// any of them applies, so windows may overlap.
+type ChannelMuteSchedule []*ChannelMuteWindow
+
+func (o ChannelMuteSchedule) IsValid() *AppError {
+	if len(o) > CHANNEL_MUTE_SCHEDULE_MAX_WINDOWS {
+		return NewAppError("ChannelMuteSchedule.IsValid", "model.channel_mute_schedule.is_valid.too_many_windows.app_error", map[string]interface{}{"Max": CHANNEL_MUTE_SCHEDULE_MAX_WINDOWS}, "", http.StatusBadRequest)
+	}
+
+	for _, window := range o {
+		if window == nil {
+			return NewAppError("ChannelMuteSchedule.IsValid", "model.channel_mute_schedule.is_valid.window.app_error", nil, "", http.StatusBadRequest)
+		}
+
+		if _, ok := parseChannelMuteWindowClock(window.Start); !ok {
+			return NewAppError("ChannelMuteSchedule.IsValid", "model.channel_mute_schedule.is_valid.start.app_error", nil, "start="+window.Start, http.StatusBadRequest)
+		}
+
+		if _, ok := parseChannelMuteWindowClock(window.End); !ok {
+			return NewAppError("ChannelMuteSchedule.IsValid", "model.channel_mute_schedule.is_valid.end.app_error", nil, "end="+window.End, http.StatusBadRequest)
+		}
+
+		for _, day := range window.Days {
+			if day < time.Sunday || day > time.Saturday {
+				return NewAppError("ChannelMuteSchedule.IsValid", "model.channel_mute_schedule.is_valid.day.app_error", nil, "", http.StatusBadRequest)
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsMutedAt returns whether any window of the schedule applies at the given time, which must already be in the
+// member's timezone.
+func (o ChannelMuteSchedule) IsMutedAt(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	previousDay := (t.Weekday() + 6) % 7
+
+	for _, window := range o {
+		start, ok := parseChannelMuteWindowClock(window.Start)
+		if !ok {
+			continue
+		}
+		end, ok := parseChannelMuteWindowClock(window.End)
+		if !ok {
+			continue
+		}
+
+		if start < end {
+			if window.startsOn(t.Weekday()) && minute >= start && minute < end {
+				return true
+			}
+			continue
+		}
+
+		// The window runs past midnight, so it's either in its first day or continuing from the day before
+		if window.startsOn(t.Weekday()) && minute >= start {
+			return true
+		}
+		if window.startsOn(previousDay) && minute < end {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o *ChannelMuteWindow) startsOn(day time.Weekday) bool {
+	if len(o.Days) == 0 {
+		return true
+	}
+
+	for _, d := range o.Days {
+		if d == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseChannelMuteWindowClock returns the number of minutes after midnight of a time formatted as HH:MM.
+func parseChannelMuteWindowClock(clock string) (int, bool) {
+	t, err := time.Parse(channelMuteWindowClockLayout, clock)
+	if err != nil {
+		return 0, false
+	}
+
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// IsChannelMuted returns whether a member has muted a channel at the given time, either manually or by the mute
+// schedule in their channel notify props, evaluated in their timezone. Muting the channel manually overrides the
+// schedule.
+func IsChannelMuted(channelNotifyProps StringMap, timezone string, now time.Time) bool {
+	if channelNotifyProps[MARK_UNREAD_NOTIFY_PROP] == CHANNEL_MARK_UNREAD_MENTION {
+		return true
+	}
+
+	value := channelNotifyProps[MUTE_SCHEDULE_NOTIFY_PROP]
+	if value == "" {
+		return false
+	}
+
+	schedule, err := ChannelMuteScheduleFromJson(value)
+	if err != nil {
+		return false
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	return schedule.IsMutedAt(now.In(location))
+}
+
+func (o ChannelMuteSchedule) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func ChannelMuteScheduleFromJson(data string) (ChannelMuteSchedule, error) {
+	var o ChannelMuteSchedule
+	err := json.Unmarshal([]byte(data), &o)
+	return o, err
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelMuteScheduleIsValid(t *testing.T) {
+	schedule := ChannelMuteSchedule{{Days: []time.Weekday{time.Monday}, Start: "09:00", End: "12:30"}}
+	require.Nil(t, schedule.IsValid())
+
+	schedule = ChannelMuteSchedule{{Start: "9am", End: "12:30"}}
+	require.NotNil(t, schedule.IsValid())
+
+	schedule = ChannelMuteSchedule{{Start: "09:00", End: "24:00"}}
+	require.NotNil(t, schedule.IsValid())
+
+	schedule = ChannelMuteSchedule{{Days: []time.Weekday{7}, Start: "09:00", End: "12:00"}}
+	require.NotNil(t, schedule.IsValid())
+
+	schedule = ChannelMuteSchedule{nil}
+	require.NotNil(t, schedule.IsValid())
+
+	schedule = ChannelMuteSchedule{}
+	for i := 0; i <= CHANNEL_MUTE_SCHEDULE_MAX_WINDOWS; i++ {
+		schedule = append(schedule, &ChannelMuteWindow{Start: "09:00", End: "10:00"})
+	}
+	err := schedule.IsValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.channel_mute_schedule.is_valid.too_many_windows.app_error", err.Id)
+}
+
+func TestChannelMuteScheduleIsMutedAt(t *testing.T) {
+	schedule := ChannelMuteSchedule{
+		{Days: []time.Weekday{time.Monday, time.Tuesday}, Start: "09:00", End: "12:00"},
+		{Days: []time.Weekday{time.Friday}, Start: "22:00", End: "07:00"},
+		{Start: "11:00", End: "13:00"},
+	}
+
+	for name, tc := range map[string]struct {
+		At       time.Time
+		Expected bool
+	}{
+		"before a window":                     {time.Date(2019, 6, 3, 8, 59, 0, 0, time.UTC), false},
+		"start of a window":                   {time.Date(2019, 6, 3, 9, 0, 0, 0, time.UTC), true},
+		"end of a window":                     {time.Date(2019, 6, 4, 12, 0, 0, 0, time.UTC), true},
+		"end of overlapping windows":          {time.Date(2019, 6, 4, 13, 0, 0, 0, time.UTC), false},
+		"window on another day":               {time.Date(2019, 6, 5, 9, 30, 0, 0, time.UTC), false},
+		"every day window":                    {time.Date(2019, 6, 5, 11, 30, 0, 0, time.UTC), true},
+		"overnight window before midnight":    {time.Date(2019, 6, 7, 23, 0, 0, 0, time.UTC), true},
+		"overnight window after midnight":     {time.Date(2019, 6, 8, 6, 59, 0, 0, time.UTC), true},
+		"overnight window ended":              {time.Date(2019, 6, 8, 7, 0, 0, 0, time.UTC), false},
+		"overnight window on the wrong day":   {time.Date(2019, 6, 7, 6, 0, 0, 0, time.UTC), false},
+		"overnight window starting on sunday": {time.Date(2019, 6, 9, 23, 0, 0, 0, time.UTC), false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, schedule.IsMutedAt(tc.At))
+		})
+	}
+}
+
+func TestIsChannelMuted(t *testing.T) {
+	schedule := ChannelMuteSchedule{{Start: "01:00", End: "03:00"}}
+	props := StringMap{MARK_UNREAD_NOTIFY_PROP: CHANNEL_MARK_UNREAD_ALL, MUTE_SCHEDULE_NOTIFY_PROP: schedule.ToJson()}
+
+	// 07:30 UTC is 02:30 in New York the day before daylight saving time starts, but 03:30 on the day it does
+	assert.True(t, IsChannelMuted(props, "America/New_York", time.Date(2019, 3, 9, 6, 30, 0, 0, time.UTC)))
+	assert.True(t, IsChannelMuted(props, "America/New_York", time.Date(2019, 3, 9, 7, 30, 0, 0, time.UTC)))
+	assert.True(t, IsChannelMuted(props, "America/New_York", time.Date(2019, 3, 10, 6, 30, 0, 0, time.UTC)))
+	assert.False(t, IsChannelMuted(props, "America/New_York", time.Date(2019, 3, 10, 7, 30, 0, 0, time.UTC)))
+
+	// Without a valid timezone, the schedule is in UTC
+	assert.True(t, IsChannelMuted(props, "", time.Date(2019, 3, 9, 2, 0, 0, 0, time.UTC)))
+	assert.True(t, IsChannelMuted(props, "Nowhere/Unknown", time.Date(2019, 3, 9, 2, 0, 0, 0, time.UTC)))
+
+	// Muting manually overrides the schedule
+	props[MARK_UNREAD_NOTIFY_PROP] = CHANNEL_MARK_UNREAD_MENTION
+	assert.True(t, IsChannelMuted(props, "", time.Date(2019, 3, 9, 12, 0, 0, 0, time.UTC)))
+
+	assert.False(t, IsChannelMuted(StringMap{}, "", time.Now()))
+	assert.False(t, IsChannelMuted(StringMap{MUTE_SCHEDULE_NOTIFY_PROP: "junk"}, "", time.Now()))
+}
+
+func TestChannelMemberIsValidMuteSchedule(t *testing.T) {
+	member := &ChannelMember{ChannelId: NewId(), UserId: NewId(), NotifyProps: GetDefaultChannelNotifyProps()}
+	require.Nil(t, member.IsValid())
+
+	member.NotifyProps[MUTE_SCHEDULE_NOTIFY_PROP] = ChannelMuteSchedule{{Start: "09:00", End: "17:00"}}.ToJson()
+	require.Nil(t, member.IsValid())
+
+	member.NotifyProps[MUTE_SCHEDULE_NOTIFY_PROP] = "junk"
+	require.NotNil(t, member.IsValid())
+
+	member.NotifyProps[MUTE_SCHEDULE_NOTIFY_PROP] = ChannelMuteSchedule{{Start: "09:00", End: "5pm"}}.ToJson()
+	require.NotNil(t, member.IsValid())
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"unicode/utf8"
+)
+
+const (
+	CHANNEL_TEMPLATE_NAME_MAX_RUNES = 64
+
+	// CHANNEL_TEMPLATE_PROP is the prop of the intro post of a channel created from a template that holds the id of
+	// the template.
+	CHANNEL_TEMPLATE_PROP = "channel_template_id"
+)
+
+// ChannelTemplate describes how channels of a team that are created from it are set up, such as an incident response
+// channel. The channels start with the template's header and purpose, and its intro message is posted and pinned.
+type ChannelTemplate struct {
+	Id           string `json:"id"`
+	TeamId       string `json:"team_id"`
+	CreatorId    string `json:"creator_id"`
+	CreateAt     int64  `json:"create_at"`
+	UpdateAt     int64  `json:"update_at"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Header       string `json:"header"`
+	Purpose      string `json:"purpose"`
+	IntroMessage string `json:"intro_message"`
+}
+
+// ChannelFromTemplate is a request to create a channel from a template.
+type ChannelFromTemplate struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+func (o *ChannelTemplate) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	o.CreateAt = GetMillis()
+	o.UpdateAt = o.CreateAt
+}
+
+func (o *ChannelTemplate) PreUpdate() {
+	o.UpdateAt = GetMillis()
+}
+
+func (o *ChannelTemplate) IsValid() *AppError {
+	if !IsValidId(o.Id) {
+		return NewAppError("ChannelTemplate.IsValid", "model.channel_template.is_valid.id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if !IsValidId(o.TeamId) {
+		return NewAppError("ChannelTemplate.IsValid", "model.channel_template.is_valid.team_id.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if !IsValidId(o.CreatorId) {
+		return NewAppError("ChannelTemplate.IsValid", "model.channel_template.is_valid.creator_id.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if o.CreateAt == 0 || o.UpdateAt == 0 {
+		return NewAppError("ChannelTemplate.IsValid", "model.channel_template.is_valid.create_at.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if o.Name == "" || utf8.RuneCountInString(o.Name) > CHANNEL_TEMPLATE_NAME_MAX_RUNES {
+		return NewAppError("ChannelTemplate.IsValid", "model.channel_template.is_valid.name.app_error", map[string]interface{}{"Max": CHANNEL_TEMPLATE_NAME_MAX_RUNES}, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if o.Type != CHANNEL_OPEN && o.Type != CHANNEL_PRIVATE {
+		return NewAppError("ChannelTemplate.IsValid", "model.channel_template.is_valid.type.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if utf8.RuneCountInString(o.Header) > CHANNEL_HEADER_MAX_RUNES {
+		return NewAppError("ChannelTemplate.IsValid", "model.channel.is_valid.header.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if utf8.RuneCountInString(o.Purpose) > CHANNEL_PURPOSE_MAX_RUNES {
+		return NewAppError("ChannelTemplate.IsValid", "model.channel.is_valid.purpose.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if utf8.RuneCountInString(o.IntroMessage) > POST_MESSAGE_MAX_RUNES_V1 {
+		return NewAppError("ChannelTemplate.IsValid", "model.channel_template.is_valid.intro_message.app_error", map[string]interface{}{"Max": POST_MESSAGE_MAX_RUNES_V1}, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (o *ChannelTemplate) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func ChannelTemplateFromJson(data io.Reader) *ChannelTemplate {
+	var o *ChannelTemplate
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+func ChannelTemplatesToJson(o []*ChannelTemplate) string {
+	if b, err := json.Marshal(o); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func ChannelTemplatesFromJson(data io.Reader) []*ChannelTemplate {
+	var o []*ChannelTemplate
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+func (o *ChannelFromTemplate) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func ChannelFromTemplateFromJson(data io.Reader) *ChannelFromTemplate {
+	var o *ChannelFromTemplate
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
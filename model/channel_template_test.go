@@ -0,0 +1,56 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannelTemplateJson(t *testing.T) {
+	template := &ChannelTemplate{TeamId: NewId(), CreatorId: NewId(), Name: "Incident", Type: CHANNEL_PRIVATE, Header: "header", Purpose: "purpose", IntroMessage: "intro"}
+	template.PreSave()
+
+	rtemplate := ChannelTemplateFromJson(strings.NewReader(template.ToJson()))
+	assert.Equal(t, template, rtemplate)
+
+	rtemplates := ChannelTemplatesFromJson(strings.NewReader(ChannelTemplatesToJson([]*ChannelTemplate{template})))
+	assert.Equal(t, []*ChannelTemplate{template}, rtemplates)
+}
+
+func TestChannelTemplateIsValid(t *testing.T) {
+	template := &ChannelTemplate{}
+	assert.NotNil(t, template.IsValid())
+
+	template.PreSave()
+	template.TeamId = NewId()
+	template.CreatorId = NewId()
+	assert.NotNil(t, template.IsValid())
+
+	template.Name = "Incident"
+	assert.NotNil(t, template.IsValid())
+
+	template.Type = CHANNEL_DIRECT
+	assert.NotNil(t, template.IsValid())
+
+	template.Type = CHANNEL_OPEN
+	assert.Nil(t, template.IsValid())
+
+	template.Name = strings.Repeat("a", CHANNEL_TEMPLATE_NAME_MAX_RUNES+1)
+	assert.NotNil(t, template.IsValid())
+	template.Name = "Incident"
+
+	template.Header = strings.Repeat("a", CHANNEL_HEADER_MAX_RUNES+1)
+	assert.NotNil(t, template.IsValid())
+	template.Header = ""
+
+	template.Purpose = strings.Repeat("a", CHANNEL_PURPOSE_MAX_RUNES+1)
+	assert.NotNil(t, template.IsValid())
+	template.Purpose = ""
+
+	template.IntroMessage = strings.Repeat("a", POST_MESSAGE_MAX_RUNES_V1+1)
+	assert.NotNil(t, template.IsValid())
+}
@@ -37,11 +37,14 @@ func TestChannelCopy(t *testing.T) {
 }
 
 func TestChannelPatch(t *testing.T) {
-	p := &ChannelPatch{Name: new(string), DisplayName: new(string), Header: new(string), Purpose: new(string)}
+	p := &ChannelPatch{Name: new(string), DisplayName: new(string), Header: new(string), Purpose: new(string), MaxPostSize: new(int), SuppressJoinLeaveMessages: new(bool), ExemptFromArchiving: new(bool)}
 	*p.Name = NewId()
 	*p.DisplayName = NewId()
 	*p.Header = NewId()
 	*p.Purpose = NewId()
+	*p.MaxPostSize = 280
+	*p.SuppressJoinLeaveMessages = true
+	*p.ExemptFromArchiving = true
 
 	o := Channel{Id: NewId(), Name: NewId()}
 	o.Patch(p)
@@ -58,6 +61,15 @@ func TestChannelPatch(t *testing.T) {
 	if *p.Purpose != o.Purpose {
 		t.Fatal("do not match")
 	}
+	if *p.MaxPostSize != o.MaxPostSize {
+		t.Fatal("do not match")
+	}
+	if *p.SuppressJoinLeaveMessages != o.SuppressJoinLeaveMessages {
+		t.Fatal("do not match")
+	}
+	if *p.ExemptFromArchiving != o.ExemptFromArchiving {
+		t.Fatal("do not match")
+	}
 }
 
 func TestChannelIsValid(t *testing.T) {
@@ -134,6 +146,26 @@ func TestChannelIsValid(t *testing.T) {
 	if err := o.IsValid(); err != nil {
 		t.Fatal(err)
 	}
+
+	o.BannerPostId = "1234"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.BannerPostId = NewId()
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.MaxPostSize = -1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.MaxPostSize = 280
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestChannelPreSave(t *testing.T) {
@@ -142,6 +174,19 @@ func TestChannelPreSave(t *testing.T) {
 	o.Etag()
 }
 
+func TestChannelLastModifiedAt(t *testing.T) {
+	o := Channel{UpdateAt: 10}
+	if o.LastModifiedAt() != 10 {
+		t.Fatal("should be the update time")
+	}
+
+	o.LastPostAt = 20
+	o.DeleteAt = 15
+	if o.LastModifiedAt() != 20 {
+		t.Fatal("should be the last post time")
+	}
+}
+
 func TestChannelPreUpdate(t *testing.T) {
 	o := Channel{Name: "test"}
 	o.PreUpdate()
@@ -0,0 +1,155 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// POST_PROPS_CHECKLIST is the prop of a checklist post that holds its items and whether they've been checked.
+	POST_PROPS_CHECKLIST = "checklist"
+
+	// The number and length of items are limited so that a checklist always fits in the props of a post.
+	CHECKLIST_MAX_ITEMS       = 30
+	CHECKLIST_ITEM_MAX_RUNES  = 100
+	CHECKLIST_TITLE_MAX_RUNES = 100
+)
+
+// Checklist is a list of items, such as the steps of a run-book, that's posted as a single post. Its items are
+// checked off by updating the post, which keeps their state in its props.
+type Checklist struct {
+	Title string           `json:"title"`
+	Items []*ChecklistItem `json:"items"`
+}
+
+type ChecklistItem struct {
+	Text      string `json:"text"`
+	CheckedBy string `json:"checked_by,omitempty"`
+	CheckedAt int64  `json:"checked_at,omitempty"`
+}
+
+// ChecklistRequest is a request to post a checklist to a channel, optionally as a reply to a thread.
+type ChecklistRequest struct {
+	ChannelId string   `json:"channel_id"`
+	RootId    string   `json:"root_id"`
+	Title     string   `json:"title"`
+	Items     []string `json:"items"`
+}
+
+// ChecklistItemCheck is a request to check or uncheck an item of a checklist, by its index.
+type ChecklistItemCheck struct {
+	Index   int  `json:"index"`
+	Checked bool `json:"checked"`
+}
+
+func (o *ChecklistRequest) IsValid() *AppError {
+	if !IsValidId(o.ChannelId) {
+		return NewAppError("ChecklistRequest.IsValid", "model.checklist.is_valid.channel_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if o.RootId != "" && !IsValidId(o.RootId) {
+		return NewAppError("ChecklistRequest.IsValid", "model.checklist.is_valid.root_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if utf8.RuneCountInString(o.Title) > CHECKLIST_TITLE_MAX_RUNES || strings.ContainsAny(o.Title, "\r\n") {
+		return NewAppError("ChecklistRequest.IsValid", "model.checklist.is_valid.title.app_error", map[string]interface{}{"Max": CHECKLIST_TITLE_MAX_RUNES}, "", http.StatusBadRequest)
+	}
+
+	if len(o.Items) == 0 || len(o.Items) > CHECKLIST_MAX_ITEMS {
+		return NewAppError("ChecklistRequest.IsValid", "model.checklist.is_valid.items.app_error", map[string]interface{}{"Max": CHECKLIST_MAX_ITEMS}, "", http.StatusBadRequest)
+	}
+
+	for _, item := range o.Items {
+		// Items are rendered as lines of a list, so they can't span several lines
+		if strings.TrimSpace(item) == "" || utf8.RuneCountInString(item) > CHECKLIST_ITEM_MAX_RUNES || strings.ContainsAny(item, "\r\n") {
+			return NewAppError("ChecklistRequest.IsValid", "model.checklist.is_valid.item.app_error", map[string]interface{}{"Max": CHECKLIST_ITEM_MAX_RUNES}, "", http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+// ToChecklist returns a checklist of the requested items, none of which are checked.
+func (o *ChecklistRequest) ToChecklist() *Checklist {
+	checklist := &Checklist{
+		Title: strings.TrimSpace(o.Title),
+		Items: make([]*ChecklistItem, len(o.Items)),
+	}
+
+	for i, item := range o.Items {
+		checklist.Items[i] = &ChecklistItem{Text: strings.TrimSpace(item)}
+	}
+
+	return checklist
+}
+
+// ToMessage renders a checklist as the message of its post, as a markdown task list.
+func (o *Checklist) ToMessage() string {
+	var message strings.Builder
+
+	if o.Title != "" {
+		message.WriteString("#### " + o.Title + "\n\n")
+	}
+
+	for i, item := range o.Items {
+		if i > 0 {
+			message.WriteString("\n")
+		}
+
+		if item.CheckedBy != "" {
+			message.WriteString("- [x] " + item.Text)
+		} else {
+			message.WriteString("- [ ] " + item.Text)
+		}
+	}
+
+	return message.String()
+}
+
+// ChecklistFromPost returns the checklist of a checklist post, or nil if the post isn't one.
+func ChecklistFromPost(post *Post) *Checklist {
+	if checklist, ok := post.Props[POST_PROPS_CHECKLIST].(*Checklist); ok {
+		return checklist
+	}
+
+	value, ok := post.Props[POST_PROPS_CHECKLIST]
+	if !ok {
+		return nil
+	}
+
+	// Props read from the database are decoded as generic JSON values
+	var checklist *Checklist
+	if enc, err := json.Marshal(value); err != nil || json.Unmarshal(enc, &checklist) != nil || checklist == nil || len(checklist.Items) == 0 {
+		return nil
+	}
+
+	return checklist
+}
+
+func (o *ChecklistRequest) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func ChecklistRequestFromJson(data io.Reader) *ChecklistRequest {
+	var o *ChecklistRequest
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+func (o *ChecklistItemCheck) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func ChecklistItemCheckFromJson(data io.Reader) *ChecklistItemCheck {
+	var o *ChecklistItemCheck
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecklistRequestIsValid(t *testing.T) {
+	request := &ChecklistRequest{ChannelId: NewId(), Title: "Deploy", Items: []string{"Build", "Ship"}}
+	assert.Nil(t, request.IsValid())
+
+	for name, modify := range map[string]func(*ChecklistRequest){
+		"missing channel": func(r *ChecklistRequest) { r.ChannelId = "" },
+		"invalid root":    func(r *ChecklistRequest) { r.RootId = "root" },
+		"multiline title": func(r *ChecklistRequest) { r.Title = "a\nb" },
+		"no items":        func(r *ChecklistRequest) { r.Items = nil },
+		"too many items":  func(r *ChecklistRequest) { r.Items = make([]string, CHECKLIST_MAX_ITEMS+1) },
+		"empty item":      func(r *ChecklistRequest) { r.Items = []string{" "} },
+		"multiline item":  func(r *ChecklistRequest) { r.Items = []string{"a\n- [x] b"} },
+		"long item":       func(r *ChecklistRequest) { r.Items = []string{strings.Repeat("a", CHECKLIST_ITEM_MAX_RUNES+1)} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			invalid := *request
+			modify(&invalid)
+			assert.NotNil(t, invalid.IsValid())
+		})
+	}
+}
+
+func TestChecklistToMessage(t *testing.T) {
+	checklist := (&ChecklistRequest{Title: "Deploy", Items: []string{" Build ", "Ship"}}).ToChecklist()
+	assert.Equal(t, "#### Deploy\n\n- [ ] Build\n- [ ] Ship", checklist.ToMessage())
+
+	checklist.Items[1].CheckedBy = NewId()
+	checklist.Title = ""
+	assert.Equal(t, "- [ ] Build\n- [x] Ship", checklist.ToMessage())
+}
+
+func TestChecklistFromPost(t *testing.T) {
+	assert.Nil(t, ChecklistFromPost(&Post{}))
+
+	checklist := (&ChecklistRequest{Title: "Deploy", Items: []string{"Build", "Ship"}}).ToChecklist()
+	checklist.Items[0].CheckedBy = NewId()
+	checklist.Items[0].CheckedAt = GetMillis()
+
+	post := &Post{}
+	post.AddProp(POST_PROPS_CHECKLIST, checklist)
+	assert.Equal(t, checklist, ChecklistFromPost(post))
+
+	// Props read from the database are generic JSON values
+	rpost := PostFromJson(strings.NewReader(post.ToJson()))
+	require.NotNil(t, rpost)
+	assert.Equal(t, checklist, ChecklistFromPost(rpost))
+
+	post.AddProp(POST_PROPS_CHECKLIST, "checklist")
+	assert.Nil(t, ChecklistFromPost(post))
+}
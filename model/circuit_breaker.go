@@ -0,0 +1,38 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	CIRCUIT_BREAKER_STATE_CLOSED    = "closed"
+	CIRCUIT_BREAKER_STATE_OPEN      = "open"
+	CIRCUIT_BREAKER_STATE_HALF_OPEN = "half_open"
+)
+
+// CircuitBreakerStatus describes the circuit breaker of an integration endpoint. While a breaker is open, requests to
+// its endpoint fail straight away until RetryAt, after which a single request is let through to probe the endpoint.
+type CircuitBreakerStatus struct {
+	Endpoint            string `json:"endpoint"`
+	State               string `json:"state"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	LastFailureAt       int64  `json:"last_failure_at"`
+	LastError           string `json:"last_error,omitempty"`
+	OpenedAt            int64  `json:"opened_at,omitempty"`
+	RetryAt             int64  `json:"retry_at,omitempty"`
+}
+
+func CircuitBreakerStatusListToJson(statuses []*CircuitBreakerStatus) string {
+	b, _ := json.Marshal(statuses)
+	return string(b)
+}
+
+func CircuitBreakerStatusListFromJson(data io.Reader) []*CircuitBreakerStatus {
+	var statuses []*CircuitBreakerStatus
+	json.NewDecoder(data).Decode(&statuses)
+	return statuses
+}
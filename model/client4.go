@@ -21,6 +21,7 @@ const (
 	HEADER_REQUEST_ID         = "X-Request-ID"
 	HEADER_VERSION_ID         = "X-Version-ID"
 	HEADER_CLUSTER_ID         = "X-Cluster-ID"
+	HEADER_NODE_ID            = "X-Mattermost-Node"
 	HEADER_ETAG_SERVER        = "ETag"
 	HEADER_ETAG_CLIENT        = "If-None-Match"
 	HEADER_FORWARDED          = "X-Forwarded-For"
@@ -31,9 +32,14 @@ const (
 	HEADER_AUTH               = "Authorization"
 	HEADER_REQUESTED_WITH     = "X-Requested-With"
 	HEADER_REQUESTED_WITH_XML = "XMLHttpRequest"
+	HEADER_CSRF_TOKEN         = "X-CSRF-Token"
+	HEADER_IDEMPOTENCY_KEY    = "Idempotency-Key"
+	HEADER_IDEMPOTENT_REPLAY  = "Idempotent-Replayed"
+	HEADER_MOBILE_APP         = "X-Mobile-App"
 	STATUS                    = "status"
 	STATUS_OK                 = "OK"
 	STATUS_FAIL               = "FAIL"
+	STATUS_UNREADY            = "UNREADY"
 	STATUS_REMOVE             = "REMOVE"
 
 	CLIENT_DIR = "client"
@@ -249,6 +255,10 @@ func (c *Client4) GetSystemRoute() string {
 	return fmt.Sprintf("/system")
 }
 
+func (c *Client4) GetBatchRoute() string {
+	return fmt.Sprintf("/batch")
+}
+
 func (c *Client4) GetTestEmailRoute() string {
 	return fmt.Sprintf("/email/test")
 }
@@ -463,6 +473,10 @@ func (c *Client4) doApiRequestReader(method, url string, data io.Reader, etag st
 		rq.Header.Set(HEADER_ETAG_CLIENT, etag)
 	}
 
+	if rq.ContentLength != 0 {
+		rq.Header.Set("Content-Type", "application/json")
+	}
+
 	if len(c.AuthToken) > 0 {
 		rq.Header.Set(HEADER_AUTH, c.AuthType+" "+c.AuthToken)
 	}
@@ -922,6 +936,17 @@ func (c *Client4) GetUsersByIds(userIds []string) ([]*User, *Response) {
 	return UserListFromJson(r.Body), BuildResponse(r)
 }
 
+// GetUserDisplayNames returns the display names of the given users, keyed by their ids, as formatted by the name
+// format preference of the current user.
+func (c *Client4) GetUserDisplayNames(userIds []string) (map[string]string, *Response) {
+	r, err := c.DoApiPost(c.GetUsersRoute()+"/display_names", ArrayToJson(userIds))
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return MapFromJson(r.Body), BuildResponse(r)
+}
+
 // GetUsersByUsernames returns a list of users based on the provided usernames.
 func (c *Client4) GetUsersByUsernames(usernames []string) ([]*User, *Response) {
 	r, err := c.DoApiPost(c.GetUsersRoute()+"/usernames", ArrayToJson(usernames))
@@ -1258,6 +1283,17 @@ func (c *Client4) GetUserAccessTokens(page int, perPage int) ([]*UserAccessToken
 	return UserAccessTokenListFromJson(r.Body), BuildResponse(r)
 }
 
+// ValidateUserAccessToken describes the user access token that the client is authenticated with, including whether
+// it's active and the user that it's for. Fails with a 401 if the token isn't valid.
+func (c *Client4) ValidateUserAccessToken() (*UserAccessTokenValidation, *Response) {
+	r, err := c.DoApiGet(c.GetUsersRoute()+"/me/tokens/validate", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return UserAccessTokenValidationFromJson(r.Body), BuildResponse(r)
+}
+
 // GetUserAccessToken will get a user access tokens' id, description, is_active
 // and the user_id of the user it is for. The actual token will not be returned.
 // Must have the 'read_user_access_token' permission and if getting for another
@@ -1524,7 +1560,8 @@ func (c *Client4) AddTeamMember(teamId, userId string) (*TeamMember, *Response)
 }
 
 // AddTeamMemberFromInvite adds a user to a team and return a team member using an invite id
-// or an invite token/data pair.
+// or an invite token/data pair. If the team is full and has a waitlist, joining with an invite
+// id adds the user to the waitlist instead, and the response has status 202 Accepted.
 func (c *Client4) AddTeamMemberFromInvite(token, inviteId string) (*TeamMember, *Response) {
 	var query string
 
@@ -1581,6 +1618,256 @@ func (c *Client4) GetTeamStats(teamId, etag string) (*TeamStats, *Response) {
 	return TeamStatsFromJson(r.Body), BuildResponse(r)
 }
 
+// GetTeamActivitySummary returns aggregate activity counts for a team between since and until.
+// Must have manage_team permission.
+func (c *Client4) GetTeamActivitySummary(teamId string, since int64, until int64) (*TeamActivitySummary, *Response) {
+	query := fmt.Sprintf("?since=%v&until=%v", since, until)
+	r, err := c.DoApiGet(c.GetTeamRoute(teamId)+"/activity"+query, "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return TeamActivitySummaryFromJson(r.Body), BuildResponse(r)
+}
+
+// GetTeamStorageUsage returns how many bytes the files uploaded to a team take up, along with its storage quota.
+// Must have manage_team permission.
+func (c *Client4) GetTeamStorageUsage(teamId string) (*TeamStorageUsage, *Response) {
+	r, err := c.DoApiGet(c.GetTeamRoute(teamId)+"/storage_usage", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return TeamStorageUsageFromJson(r.Body), BuildResponse(r)
+}
+
+// UpdateTeamStorageQuota sets the number of bytes of files that may be uploaded to a team, or 0 for no limit. Must be
+// a system administrator.
+func (c *Client4) UpdateTeamStorageQuota(teamId string, quota int64) (*Team, *Response) {
+	patch := &TeamStorageQuotaPatch{StorageQuota: &quota}
+	r, err := c.DoApiPut(c.GetTeamRoute(teamId)+"/storage_quota", patch.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return TeamFromJson(r.Body), BuildResponse(r)
+}
+
+// UpdateTeamAutoJoin sets whether new users whose email matches a team's allowed domains join
+// it automatically. Must be a system administrator.
+func (c *Client4) UpdateTeamAutoJoin(teamId string, autoJoin bool) (*Team, *Response) {
+	patch := &TeamAutoJoinPatch{AutoJoin: &autoJoin}
+	r, err := c.DoApiPut(c.GetTeamRoute(teamId)+"/auto_join", patch.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return TeamFromJson(r.Body), BuildResponse(r)
+}
+
+// PlanTeamMerge returns what merging a team into the target team of the request would do, without changing anything.
+func (c *Client4) PlanTeamMerge(teamId string, request *TeamMergeRequest) (*TeamMergeReport, *Response) {
+	dryRun := *request
+	dryRun.DryRun = true
+	r, err := c.DoApiPost(c.GetTeamRoute(teamId)+"/merge", dryRun.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return TeamMergeReportFromJson(r.Body), BuildResponse(r)
+}
+
+// MergeTeam starts a job that merges a team into the target team of the request, returning the job so that its
+// progress can be followed.
+func (c *Client4) MergeTeam(teamId string, request *TeamMergeRequest) (*Job, *Response) {
+	merge := *request
+	merge.DryRun = false
+	r, err := c.DoApiPost(c.GetTeamRoute(teamId)+"/merge", merge.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return JobFromJson(r.Body), BuildResponse(r)
+}
+
+// UpdateTeamMembershipLimit sets the number of active members that a team may have, or 0 for no
+// limit, and whether users joining it once it's full are waitlisted. Must be a system administrator.
+func (c *Client4) UpdateTeamMembershipLimit(teamId string, patch *TeamMembershipLimitPatch) (*Team, *Response) {
+	r, err := c.DoApiPut(c.GetTeamRoute(teamId)+"/membership_limit", patch.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return TeamFromJson(r.Body), BuildResponse(r)
+}
+
+// GetTeamWaitlist returns a page of the users waiting to join a team, oldest first. Must have
+// manage_team permission.
+func (c *Client4) GetTeamWaitlist(teamId string, page int, perPage int) ([]*TeamWaitlistEntry, *Response) {
+	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
+	r, err := c.DoApiGet(c.GetTeamRoute(teamId)+"/waitlist"+query, "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return TeamWaitlistEntriesFromJson(r.Body), BuildResponse(r)
+}
+
+// ApproveTeamWaitlistEntry adds a waitlisted user to a team. Must have manage_team permission.
+func (c *Client4) ApproveTeamWaitlistEntry(teamId string, userId string) (*TeamMember, *Response) {
+	r, err := c.DoApiPost(c.GetTeamRoute(teamId)+"/waitlist/"+userId+"/approve", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return TeamMemberFromJson(r.Body), BuildResponse(r)
+}
+
+// CreateTeamInvite creates an invite link to a team, which can expire, be limited to a number
+// of uses and add the users who join with it to channels of the team. Must have manage_team
+// permission, and manage_team_roles permission for invites that make users team admins.
+func (c *Client4) CreateTeamInvite(invite *TeamInvite) (*TeamInvite, *Response) {
+	r, err := c.DoApiPost(c.GetTeamRoute(invite.TeamId)+"/invites", invite.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return TeamInviteFromJson(r.Body), BuildResponse(r)
+}
+
+// GetTeamInvites returns the invite links to a team that haven't been revoked, with how often
+// they've been used. Must have manage_team permission.
+func (c *Client4) GetTeamInvites(teamId string) ([]*TeamInvite, *Response) {
+	r, err := c.DoApiGet(c.GetTeamRoute(teamId)+"/invites", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return TeamInvitesFromJson(r.Body), BuildResponse(r)
+}
+
+// RevokeTeamInvite stops an invite link to a team from working. Must have manage_team permission.
+func (c *Client4) RevokeTeamInvite(teamId string, inviteId string) (bool, *Response) {
+	r, err := c.DoApiDelete(c.GetTeamRoute(teamId) + "/invites/" + inviteId)
+	if err != nil {
+		return false, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return CheckStatusOK(r), BuildResponse(r)
+}
+
+// CreateChannelTemplate creates a template that channels of a team can be created from. Must
+// have manage_team permission.
+func (c *Client4) CreateChannelTemplate(template *ChannelTemplate) (*ChannelTemplate, *Response) {
+	r, err := c.DoApiPost(c.GetTeamRoute(template.TeamId)+"/channel_templates", template.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return ChannelTemplateFromJson(r.Body), BuildResponse(r)
+}
+
+// GetChannelTemplates returns the channel templates of a team.
+func (c *Client4) GetChannelTemplates(teamId string) ([]*ChannelTemplate, *Response) {
+	r, err := c.DoApiGet(c.GetTeamRoute(teamId)+"/channel_templates", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return ChannelTemplatesFromJson(r.Body), BuildResponse(r)
+}
+
+// UpdateChannelTemplate changes a channel template of a team. Must have manage_team permission.
+func (c *Client4) UpdateChannelTemplate(template *ChannelTemplate) (*ChannelTemplate, *Response) {
+	r, err := c.DoApiPut(c.GetTeamRoute(template.TeamId)+"/channel_templates/"+template.Id, template.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return ChannelTemplateFromJson(r.Body), BuildResponse(r)
+}
+
+// DeleteChannelTemplate deletes a channel template of a team. Must have manage_team permission.
+func (c *Client4) DeleteChannelTemplate(teamId string, templateId string) (bool, *Response) {
+	r, err := c.DoApiDelete(c.GetTeamRoute(teamId) + "/channel_templates/" + templateId)
+	if err != nil {
+		return false, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return CheckStatusOK(r), BuildResponse(r)
+}
+
+// CreateChannelFromTemplate creates a channel set up as described by a channel template. If the
+// user already created the channel from the template, it's returned instead.
+func (c *Client4) CreateChannelFromTemplate(teamId string, templateId string, request *ChannelFromTemplate) (*Channel, *Response) {
+	r, err := c.DoApiPost(c.GetTeamRoute(teamId)+"/channel_templates/"+templateId+"/channels", request.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return ChannelFromJson(r.Body), BuildResponse(r)
+}
+
+// GetTeamAccessGraph returns the members of a team, and every channel of the team along with its members, including
+// their roles. Must have manage_team permission.
+func (c *Client4) GetTeamAccessGraph(teamId string) ([]*TeamAccessGraphEntry, *Response) {
+	r, err := c.DoApiGet(c.GetTeamRoute(teamId)+"/access_graph", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return TeamAccessGraphFromJsonl(r.Body), BuildResponse(r)
+}
+
+// GetTeamInvitePreview returns the team, channels and role that joining with an invite link
+// would grant, without using the invite.
+func (c *Client4) GetTeamInvitePreview(inviteId string) (*TeamInvitePreview, *Response) {
+	r, err := c.DoApiGet(c.GetTeamsRoute()+"/invites/"+inviteId+"/preview", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return TeamInvitePreviewFromJson(r.Body), BuildResponse(r)
+}
+
+// AddTeamMemberFromTeamInvite adds the current user to a team with an invite link created by
+// CreateTeamInvite.
+func (c *Client4) AddTeamMemberFromTeamInvite(inviteId string) (*TeamMember, *Response) {
+	r, err := c.DoApiPost(c.GetTeamsRoute()+"/members/invite?team_invite_id="+url.QueryEscape(inviteId), "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return TeamMemberFromJson(r.Body), BuildResponse(r)
+}
+
+// RemoveTeamWaitlistEntry removes a user from the waitlist of a team without adding them to it.
+// Must be the waitlisted user or have manage_team permission.
+func (c *Client4) RemoveTeamWaitlistEntry(teamId string, userId string) (bool, *Response) {
+	r, err := c.DoApiDelete(c.GetTeamRoute(teamId) + "/waitlist/" + userId)
+	if err != nil {
+		return false, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return CheckStatusOK(r), BuildResponse(r)
+}
+
+// ExportUsersLastActivity returns a CSV export of every user's last activity. If inactiveSince
+// is non-zero, only users with no activity since then are included. Must be a system administrator.
+func (c *Client4) ExportUsersLastActivity(inactiveSince int64) ([]byte, *Response) {
+	r, appErr := c.DoApiGet(c.GetUsersRoute()+fmt.Sprintf("/last_activity/export?inactive_since=%v", inactiveSince), "")
+	if appErr != nil {
+		return nil, BuildErrorResponse(r, appErr)
+	}
+	defer closeBody(r)
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, BuildErrorResponse(r, NewAppError("ExportUsersLastActivity", "model.client.read_file.app_error", nil, err.Error(), r.StatusCode))
+	}
+	return data, BuildResponse(r)
+}
+
 // GetTotalUsersStats returns a total system user stats.
 // Must be authenticated.
 func (c *Client4) GetTotalUsersStats(etag string) (*UsersStats, *Response) {
@@ -1793,6 +2080,17 @@ func (c *Client4) RestoreChannel(channelId string) (*Channel, *Response) {
 	return ChannelFromJson(r.Body), BuildResponse(r)
 }
 
+// MoveChannelToTeam starts a job that moves a channel to another team, returning the job so that its progress can be
+// followed.
+func (c *Client4) MoveChannelToTeam(channelId string, request *ChannelMoveRequest) (*Job, *Response) {
+	r, err := c.DoApiPost(c.GetChannelRoute(channelId)+"/move", request.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return JobFromJson(r.Body), BuildResponse(r)
+}
+
 // CreateDirectChannel creates a direct message channel based on the two user
 // ids provided.
 func (c *Client4) CreateDirectChannel(userId1, userId2 string) (*Channel, *Response) {
@@ -1835,6 +2133,52 @@ func (c *Client4) GetChannelStats(channelId string, etag string) (*ChannelStats,
 	return ChannelStatsFromJson(r.Body), BuildResponse(r)
 }
 
+// GetChannelActivity returns the number of posts made in a channel between since and until, bucketed by
+// hour or day in the given time zone.
+func (c *Client4) GetChannelActivity(channelId string, granularity string, timeZone string, since int64, until int64) (*ChannelActivity, *Response) {
+	query := fmt.Sprintf("?granularity=%v&time_zone=%v&since=%v&until=%v", granularity, url.QueryEscape(timeZone), since, until)
+	r, err := c.DoApiGet(c.GetChannelRoute(channelId)+"/activity"+query, "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return ChannelActivityFromJson(r.Body), BuildResponse(r)
+}
+
+// GetChannelMentionCounts returns how many posts in a channel mentioned each of the given users between since and
+// until.
+func (c *Client4) GetChannelMentionCounts(channelId string, userIds []string, since int64, until int64) (*ChannelMentionCounts, *Response) {
+	query := fmt.Sprintf("?since=%v&until=%v", since, until)
+	r, err := c.DoApiPost(c.GetChannelRoute(channelId)+"/mentions/counts"+query, ArrayToJson(userIds))
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return ChannelMentionCountsFromJson(r.Body), BuildResponse(r)
+}
+
+// ResolveChannelMentions reports which of the given mention tokens would notify users, groups of users or nobody
+// if posted in a channel.
+func (c *Client4) ResolveChannelMentions(channelId string, mentions []string) (*MentionResolution, *Response) {
+	r, err := c.DoApiPost(c.GetChannelRoute(channelId)+"/mentions/resolve", ArrayToJson(mentions))
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return MentionResolutionFromJson(r.Body), BuildResponse(r)
+}
+
+// PreviewNotifications reports who would be notified, and by which means, if the draft post were sent to a
+// channel. Nothing is posted.
+func (c *Client4) PreviewNotifications(channelId string, draft *Post) (*NotificationPreview, *Response) {
+	r, err := c.DoApiPost(c.GetChannelRoute(channelId)+"/notifications/preview", draft.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return NotificationPreviewFromJson(r.Body), BuildResponse(r)
+}
+
 // GetChannelMembersTimezones gets a list of timezones for a channel.
 func (c *Client4) GetChannelMembersTimezones(channelId string) ([]string, *Response) {
 	r, err := c.DoApiGet(c.GetChannelRoute(channelId)+"/timezones", "")
@@ -1877,6 +2221,18 @@ func (c *Client4) GetDeletedChannelsForTeam(teamId string, page int, perPage int
 	return ChannelSliceFromJson(r.Body), BuildResponse(r)
 }
 
+// GetDuplicateChannelsForTeam returns groups of channels in a team whose names or purposes are at least threshold
+// similar, on a scale from 0 to 1.
+func (c *Client4) GetDuplicateChannelsForTeam(teamId string, threshold float64) ([]*ChannelDuplicateCluster, *Response) {
+	query := fmt.Sprintf("/duplicates?threshold=%v", threshold)
+	r, err := c.DoApiGet(c.GetChannelsForTeamRoute(teamId)+query, "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return ChannelDuplicateClustersFromJson(r.Body), BuildResponse(r)
+}
+
 // GetPublicChannelsByIdsForTeam returns a list of public channels based on provided team id string.
 func (c *Client4) GetPublicChannelsByIdsForTeam(teamId string, channelIds []string) ([]*Channel, *Response) {
 	r, err := c.DoApiPost(c.GetChannelsForTeamRoute(teamId)+"/ids", ArrayToJson(channelIds))
@@ -1897,6 +2253,16 @@ func (c *Client4) GetChannelsForTeamForUser(teamId, userId, etag string) ([]*Cha
 	return ChannelSliceFromJson(r.Body), BuildResponse(r)
 }
 
+// GetCommonChannels returns the channels that both the current user and the given user are members of.
+func (c *Client4) GetCommonChannels(userId string) ([]*Channel, *Response) {
+	r, err := c.DoApiGet(c.GetUserRoute(userId)+"/channels/common", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return ChannelSliceFromJson(r.Body), BuildResponse(r)
+}
+
 // SearchChannels returns the channels on a team matching the provided search term.
 func (c *Client4) SearchChannels(teamId string, search *ChannelSearch) ([]*Channel, *Response) {
 	r, err := c.DoApiPost(c.GetChannelsForTeamRoute(teamId)+"/search", search.ToJson())
@@ -1978,6 +2344,40 @@ func (c *Client4) GetChannelMembers(channelId string, page, perPage int, etag st
 	return ChannelMembersFromJson(r.Body), BuildResponse(r)
 }
 
+// UpdateChannelBanner makes a post in the channel its banner, replacing any previous one. Pass an empty postId to
+// remove the banner.
+func (c *Client4) UpdateChannelBanner(channelId, postId string) (*Channel, *Response) {
+	r, err := c.DoApiPut(c.GetChannelRoute(channelId)+"/banner", MapToJson(map[string]string{"post_id": postId}))
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return ChannelFromJson(r.Body), BuildResponse(r)
+}
+
+// GetChannelMembersSorted gets a page of channel members in the given CHANNEL_MEMBER_SORT_* order.
+func (c *Client4) GetChannelMembersSorted(channelId, sort string, page, perPage int) (*ChannelMembers, *Response) {
+	query := fmt.Sprintf("?sort=%v&page=%v&per_page=%v", sort, page, perPage)
+	r, err := c.DoApiGet(c.GetChannelMembersRoute(channelId)+query, "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return ChannelMembersFromJson(r.Body), BuildResponse(r)
+}
+
+// SearchChannelMembers gets a page of the members of a channel whose names match the term, optionally
+// restricted to those with the given role, such as CHANNEL_ADMIN_ROLE_ID.
+func (c *Client4) SearchChannelMembers(channelId, term, role string, page, perPage int) (*ChannelMembers, *Response) {
+	query := fmt.Sprintf("?term=%v&role=%v&page=%v&per_page=%v", url.QueryEscape(term), url.QueryEscape(role), page, perPage)
+	r, err := c.DoApiGet(c.GetChannelMembersRoute(channelId)+"/search"+query, "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return ChannelMembersFromJson(r.Body), BuildResponse(r)
+}
+
 // GetChannelMembersByIds gets the channel members in a channel for a list of user ids.
 func (c *Client4) GetChannelMembersByIds(channelId string, userIds []string) (*ChannelMembers, *Response) {
 	r, err := c.DoApiPost(c.GetChannelMembersRoute(channelId)+"/ids", ArrayToJson(userIds))
@@ -2041,6 +2441,17 @@ func (c *Client4) UpdateChannelRoles(channelId, userId, roles string) (bool, *Re
 	return CheckStatusOK(r), BuildResponse(r)
 }
 
+// UpdateChannelMembersRolesBulk updates the roles of several members of a channel at once, given as a map of user id
+// to roles. Returns the outcome for each user.
+func (c *Client4) UpdateChannelMembersRolesBulk(channelId string, request ChannelMemberRolesBulkRequest) ([]*ChannelMemberBulkResult, *Response) {
+	r, err := c.DoApiPut(c.GetChannelMembersRoute(channelId)+"/roles/bulk", request.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return ChannelMemberBulkResultsFromJson(r.Body), BuildResponse(r)
+}
+
 // UpdateChannelMemberSchemeRoles will update the scheme-derived roles on a channel for a user.
 func (c *Client4) UpdateChannelMemberSchemeRoles(channelId string, userId string, schemeRoles *SchemeRoles) (bool, *Response) {
 	r, err := c.DoApiPut(c.GetChannelMemberRoute(channelId, userId)+"/schemeRoles", schemeRoles.ToJson())
@@ -2072,6 +2483,28 @@ func (c *Client4) AddChannelMember(channelId, userId string) (*ChannelMember, *R
 	return ChannelMemberFromJson(r.Body), BuildResponse(r)
 }
 
+// AddChannelMembersBulk adds the users selected by the request, either by id or from a group or team, to a channel.
+// Returns the outcome for each user.
+func (c *Client4) AddChannelMembersBulk(channelId string, request *ChannelMembersBulkRequest) ([]*ChannelMemberBulkResult, *Response) {
+	r, err := c.DoApiPost(c.GetChannelMembersRoute(channelId)+"/bulk", request.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return ChannelMemberBulkResultsFromJson(r.Body), BuildResponse(r)
+}
+
+// CopyChannelMembers adds the members of the source channel to a channel, optionally keeping their channel admin
+// role. Returns the outcome for each user.
+func (c *Client4) CopyChannelMembers(channelId string, request *ChannelMembersCopyRequest) ([]*ChannelMemberBulkResult, *Response) {
+	r, err := c.DoApiPost(c.GetChannelMembersRoute(channelId)+"/copy", request.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return ChannelMemberBulkResultsFromJson(r.Body), BuildResponse(r)
+}
+
 // AddChannelMemberWithRootId adds user to channel and return a channel member. Post add to channel message has the postRootId.
 func (c *Client4) AddChannelMemberWithRootId(channelId, userId, postRootId string) (*ChannelMember, *Response) {
 	requestBody := map[string]string{"user_id": userId, "post_root_id": postRootId}
@@ -2127,6 +2560,37 @@ func (c *Client4) CreatePost(post *Post) (*Post, *Response) {
 	return PostFromJson(r.Body), BuildResponse(r)
 }
 
+// CreateChecklistPost posts a checklist of items, such as the steps of a run-book, as a single post.
+func (c *Client4) CreateChecklistPost(request *ChecklistRequest) (*Post, *Response) {
+	r, err := c.DoApiPost(c.GetPostsRoute()+"/checklist", request.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return PostFromJson(r.Body), BuildResponse(r)
+}
+
+// ScanPostMessage checks a message that's being composed for sensitive content that the user should be warned
+// about before posting it.
+func (c *Client4) ScanPostMessage(message string) (*DataLossPreventionScan, *Response) {
+	r, err := c.DoApiPost(c.GetPostsRoute()+"/scan", MapToJson(map[string]string{"message": message}))
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return DataLossPreventionScanFromJson(r.Body), BuildResponse(r)
+}
+
+// CheckChecklistItem checks or unchecks an item of a checklist post, and returns the updated post.
+func (c *Client4) CheckChecklistItem(postId string, check *ChecklistItemCheck) (*Post, *Response) {
+	r, err := c.DoApiPut(c.GetPostRoute(postId)+"/checklist", check.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return PostFromJson(r.Body), BuildResponse(r)
+}
+
 // CreatePostEphemeral creates a ephemeral post based on the provided post struct which is send to the given user id.
 func (c *Client4) CreatePostEphemeral(post *PostEphemeral) (*Post, *Response) {
 	r, err := c.DoApiPost(c.GetPostsEphemeralRoute(), post.ToUnsanitizedJson())
@@ -2167,6 +2631,17 @@ func (c *Client4) PinPost(postId string) (bool, *Response) {
 	return CheckStatusOK(r), BuildResponse(r)
 }
 
+// PinPostUntil pins a post based on provided post id string and automatically
+// unpins it once the given time, in milliseconds since the epoch, has passed.
+func (c *Client4) PinPostUntil(postId string, pinnedUntil int64) (bool, *Response) {
+	r, err := c.DoApiPost(c.GetPostRoute(postId)+"/pin", StringInterfaceToJson(map[string]interface{}{"pinned_until": pinnedUntil}))
+	if err != nil {
+		return false, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return CheckStatusOK(r), BuildResponse(r)
+}
+
 // UnpinPost unpin a post based on provided post id string.
 func (c *Client4) UnpinPost(postId string) (bool, *Response) {
 	r, err := c.DoApiPost(c.GetPostRoute(postId)+"/unpin", "")
@@ -2187,6 +2662,17 @@ func (c *Client4) GetPost(postId string, etag string) (*Post, *Response) {
 	return PostFromJson(r.Body), BuildResponse(r)
 }
 
+// GetPostExpanded gets a single post with the related objects named in expand, any of POST_EXPAND_*, embedded in its
+// metadata.
+func (c *Client4) GetPostExpanded(postId string, expand []string) (*Post, *Response) {
+	r, err := c.DoApiGet(c.GetPostRoute(postId)+"?expand="+url.QueryEscape(strings.Join(expand, ",")), "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return PostFromJson(r.Body), BuildResponse(r)
+}
+
 // DeletePost deletes a post from the provided post id string.
 func (c *Client4) DeletePost(postId string) (bool, *Response) {
 	r, err := c.DoApiDelete(c.GetPostRoute(postId))
@@ -2207,6 +2693,16 @@ func (c *Client4) GetPostThread(postId string, etag string) (*PostList, *Respons
 	return PostListFromJson(r.Body), BuildResponse(r)
 }
 
+// GetPostHereMentions gets who an @here in a post notified, which were the channel members online when it was made.
+func (c *Client4) GetPostHereMentions(postId string) (*PostHereMentions, *Response) {
+	r, err := c.DoApiGet(c.GetPostRoute(postId)+"/here_mentions", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return PostHereMentionsFromJson(r.Body), BuildResponse(r)
+}
+
 // GetPostsForChannel gets a page of posts with an array for ordering for a channel.
 func (c *Client4) GetPostsForChannel(channelId string, page, perPage int, etag string) (*PostList, *Response) {
 	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
@@ -2218,6 +2714,40 @@ func (c *Client4) GetPostsForChannel(channelId string, page, perPage int, etag s
 	return PostListFromJson(r.Body), BuildResponse(r)
 }
 
+// GetDeletedPostsForChannel gets a page of soft-deleted posts for a channel, most recently deleted first.
+// Requires permission to delete the posts of others in the channel.
+func (c *Client4) GetDeletedPostsForChannel(channelId string, page, perPage int) (*PostList, *Response) {
+	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
+	r, err := c.DoApiGet(c.GetChannelRoute(channelId)+"/posts/deleted"+query, "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return PostListFromJson(r.Body), BuildResponse(r)
+}
+
+// MovePosts moves the posts described by the move request from the given channel into the requested destination
+// channel, returning the posts as they now exist in the destination.
+func (c *Client4) MovePosts(channelId string, moveRequest *PostsMoveRequest) (*PostList, *Response) {
+	r, err := c.DoApiPost(c.GetChannelRoute(channelId)+"/posts/move", moveRequest.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return PostListFromJson(r.Body), BuildResponse(r)
+}
+
+// SplitPostThread creates the given channel in the post's team and moves the post's entire thread into it,
+// adding the thread's participants as members.
+func (c *Client4) SplitPostThread(postId string, channel *Channel) (*Channel, *Response) {
+	r, err := c.DoApiPost(c.GetPostRoute(postId)+"/thread/split", channel.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return ChannelFromJson(r.Body), BuildResponse(r)
+}
+
 // GetFlaggedPostsForUser returns flagged posts of a user based on user id string.
 func (c *Client4) GetFlaggedPostsForUser(userId string, page int, perPage int) (*PostList, *Response) {
 	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
@@ -2229,6 +2759,32 @@ func (c *Client4) GetFlaggedPostsForUser(userId string, page int, perPage int) (
 	return PostListFromJson(r.Body), BuildResponse(r)
 }
 
+// GetCatchUpSummary returns a summary of the activity in a user's channels since the given time. Passing 0 for since
+// summarizes the activity since the user last viewed each channel.
+func (c *Client4) GetCatchUpSummary(userId string, since int64, collapsedThreads bool, page int, perPage int) (*CatchUpSummary, *Response) {
+	query := fmt.Sprintf("?page=%v&per_page=%v&collapsed_threads=%v", page, perPage, collapsedThreads)
+	if since > 0 {
+		query += fmt.Sprintf("&since=%v", since)
+	}
+	r, err := c.DoApiGet(c.GetUserRoute(userId)+"/posts/catch_up"+query, "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return CatchUpSummaryFromJson(r.Body), BuildResponse(r)
+}
+
+// ExplainPostNotification returns whether a user would be sent push, email and desktop notifications for a post,
+// and why. Explaining the notifications of another user requires the manage_system permission.
+func (c *Client4) ExplainPostNotification(userId string, postId string) (*NotificationExplanation, *Response) {
+	r, err := c.DoApiGet(c.GetUserRoute(userId)+"/posts/"+postId+"/notification_explanation", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return NotificationExplanationFromJson(r.Body), BuildResponse(r)
+}
+
 // GetFlaggedPostsForUserInTeam returns flagged posts in team of a user based on user id string.
 func (c *Client4) GetFlaggedPostsForUserInTeam(userId string, teamId string, page int, perPage int) (*PostList, *Response) {
 	if len(teamId) == 0 || len(teamId) != 26 {
@@ -2311,6 +2867,33 @@ func (c *Client4) SearchPostsWithParams(teamId string, params *SearchParameter)
 	return PostListFromJson(r.Body), BuildResponse(r)
 }
 
+// ExportSearchResults returns every post in the team matching the terms of the search parameters, in the given
+// search export format, up to the maximum number of exported results configured on the server.
+func (c *Client4) ExportSearchResults(teamId string, format string, params *SearchParameter) ([]byte, *Response) {
+	r, appErr := c.DoApiPost(c.GetTeamRoute(teamId)+"/posts/search/export?format="+url.QueryEscape(format), params.SearchParameterToJson())
+	if appErr != nil {
+		return nil, BuildErrorResponse(r, appErr)
+	}
+	defer closeBody(r)
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, BuildErrorResponse(r, NewAppError("ExportSearchResults", "model.client.read_file.app_error", nil, err.Error(), r.StatusCode))
+	}
+	return data, BuildResponse(r)
+}
+
+// SearchPostsAllTime returns any posts matching the search parameters, including the ones that are older than the
+// server searches by default.
+func (c *Client4) SearchPostsAllTime(teamId string, params *SearchParameter) (*PostSearchResults, *Response) {
+	r, err := c.DoApiPost(c.GetTeamRoute(teamId)+"/posts/search?all_time=true", params.SearchParameterToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return PostSearchResultsFromJson(r.Body), BuildResponse(r)
+}
+
 // SearchPostsWithMatches returns any posts with matching terms string, including.
 func (c *Client4) SearchPostsWithMatches(teamId string, terms string, isOrSearch bool) (*PostSearchResults, *Response) {
 	requestBody := map[string]interface{}{"terms": terms, "is_or_search": isOrSearch}
@@ -2322,6 +2905,17 @@ func (c *Client4) SearchPostsWithMatches(teamId string, terms string, isOrSearch
 	return PostSearchResultsFromJson(r.Body), BuildResponse(r)
 }
 
+// SearchPostsInTeams returns posts with matching terms from all of the teams in params.TeamIds, or from all of
+// the user's teams if none are given, along with the team of each result.
+func (c *Client4) SearchPostsInTeams(params *SearchParameter) (*PostSearchResults, *Response) {
+	r, err := c.DoApiPost(c.GetPostsRoute()+"/search", params.SearchParameterToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return PostSearchResultsFromJson(r.Body), BuildResponse(r)
+}
+
 // DoPostAction performs a post action.
 func (c *Client4) DoPostAction(postId, actionId string) (bool, *Response) {
 	r, err := c.DoApiPost(c.GetPostRoute(postId)+"/actions/"+actionId, "")
@@ -2510,6 +3104,16 @@ func (c *Client4) GetFileInfo(fileId string) (*FileInfo, *Response) {
 	return FileInfoFromJson(r.Body), BuildResponse(r)
 }
 
+// VerifyFileIntegrity compares a file against the checksum recorded when it was uploaded. Must be a system admin.
+func (c *Client4) VerifyFileIntegrity(fileId string) (*FileIntegrityResult, *Response) {
+	r, err := c.DoApiGet(c.GetFileRoute(fileId)+"/integrity", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return FileIntegrityResultFromJson(r.Body), BuildResponse(r)
+}
+
 // GetFileInfosForPost gets all the file info objects attached to a post.
 func (c *Client4) GetFileInfosForPost(postId string, etag string) ([]*FileInfo, *Response) {
 	r, err := c.DoApiGet(c.GetPostRoute(postId)+"/files/info", etag)
@@ -2520,6 +3124,17 @@ func (c *Client4) GetFileInfosForPost(postId string, etag string) ([]*FileInfo,
 	return FileInfosFromJson(r.Body), BuildResponse(r)
 }
 
+// SearchFiles returns the files in the team with names matching the terms of the search parameters. The terms
+// support the in:, from:, on:, before: and after: filters.
+func (c *Client4) SearchFiles(teamId string, params *SearchParameter) ([]*FileInfo, *Response) {
+	r, err := c.DoApiPost(c.GetTeamRoute(teamId)+"/files/search", params.SearchParameterToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return FileInfosFromJson(r.Body), BuildResponse(r)
+}
+
 // General/System Section
 
 // GetPing will return ok if the running goRoutines are below the threshold and unhealthy for above.
@@ -2536,6 +3151,43 @@ func (c *Client4) GetPing() (string, *Response) {
 	return MapFromJson(r.Body)["status"], BuildResponse(r)
 }
 
+// GetSystemStatus returns a snapshot of the goroutines, memory, database connection pools and caches of the server
+// handling the request. Must have manage_system permission.
+func (c *Client4) GetSystemStatus() (*SystemStatus, *Response) {
+	r, err := c.DoApiGet(c.GetSystemRoute()+"/status", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return SystemStatusFromJson(r.Body), BuildResponse(r)
+}
+
+// GetReady will return ok once the server has finished starting up and can serve traffic, and unready otherwise.
+// ExecuteBatch makes several API calls in a single request, returning the response to each of them in order. If
+// stopOnError is set, the calls after the first one that fails aren't made and have no response.
+func (c *Client4) ExecuteBatch(requests []*BatchSubRequest, stopOnError bool) ([]*BatchSubResponse, *Response) {
+	batch := &BatchRequest{Requests: requests, StopOnError: stopOnError}
+	r, err := c.DoApiPost(c.GetBatchRoute(), batch.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return BatchSubResponsesFromJson(r.Body), BuildResponse(r)
+}
+
+func (c *Client4) GetReady() (string, *Response) {
+	r, err := c.DoApiGet(c.GetSystemRoute()+"/ready", "")
+	if r != nil && r.StatusCode == http.StatusServiceUnavailable {
+		defer r.Body.Close()
+		return STATUS_UNREADY, BuildErrorResponse(r, err)
+	}
+	if err != nil {
+		return "", BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return MapFromJson(r.Body)[STATUS], BuildResponse(r)
+}
+
 // TestEmail will attempt to connect to the configured SMTP server.
 func (c *Client4) TestEmail(config *Config) (bool, *Response) {
 	r, err := c.DoApiPost(c.GetTestEmailRoute(), config.ToJson())
@@ -2556,6 +3208,34 @@ func (c *Client4) TestS3Connection(config *Config) (bool, *Response) {
 	return CheckStatusOK(r), BuildResponse(r)
 }
 
+// CleanupOrphanedFiles finds the files that aren't referenced by any post and, unless dryRun is set, deletes them.
+// Files created or deleted within the last gracePeriodHours are left alone, or the last
+// ORPHANED_FILES_DEFAULT_GRACE_PERIOD_HOURS if it's 0.
+func (c *Client4) CleanupOrphanedFiles(dryRun bool, gracePeriodHours int) (*OrphanedFilesReport, *Response) {
+	query := fmt.Sprintf("?dry_run=%v", dryRun)
+	if gracePeriodHours > 0 {
+		query += fmt.Sprintf("&grace_period_hours=%v", gracePeriodHours)
+	}
+
+	r, err := c.DoApiPost("/file/orphaned/cleanup"+query, "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return OrphanedFilesReportFromJson(r.Body), BuildResponse(r)
+}
+
+// CleanupOrphanedMemberships finds the channel and team memberships whose channel, team or user doesn't exist and,
+// unless dryRun is set, deletes them.
+func (c *Client4) CleanupOrphanedMemberships(dryRun bool) (*OrphanedMembershipsReport, *Response) {
+	r, err := c.DoApiPost(fmt.Sprintf("/memberships/orphaned/cleanup?dry_run=%v", dryRun), "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return OrphanedMembershipsReportFromJson(r.Body), BuildResponse(r)
+}
+
 // GetConfig will retrieve the server config with some sanitized items.
 func (c *Client4) GetConfig() (*Config, *Response) {
 	r, err := c.DoApiGet(c.GetConfigRoute(), "")
@@ -2610,6 +3290,38 @@ func (c *Client4) GetOldClientLicense(etag string) (map[string]string, *Response
 	return MapFromJson(r.Body), BuildResponse(r)
 }
 
+// GetMaintenanceMode returns whether the server is in maintenance mode. Must be a system administrator.
+func (c *Client4) GetMaintenanceMode() (*MaintenanceMode, *Response) {
+	r, err := c.DoApiGet(c.GetSystemRoute()+"/maintenance_mode", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return MaintenanceModeFromJson(r.Body), BuildResponse(r)
+}
+
+// PatchMaintenanceMode turns maintenance mode on or off, or changes the message shown to users while it's on. Must be
+// a system administrator.
+func (c *Client4) PatchMaintenanceMode(patch *MaintenanceModePatch) (*MaintenanceMode, *Response) {
+	r, err := c.DoApiPut(c.GetSystemRoute()+"/maintenance_mode", patch.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return MaintenanceModeFromJson(r.Body), BuildResponse(r)
+}
+
+// RepairCounts starts a job that checks the message counts of the channels in the scope of the request against the
+// posts that they count, correcting them unless the request is a dry run. Must be a system admin.
+func (c *Client4) RepairCounts(request *CountRepairRequest) (*Job, *Response) {
+	r, err := c.DoApiPost(c.GetSystemRoute()+"/repair_counts", request.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return JobFromJson(r.Body), BuildResponse(r)
+}
+
 // DatabaseRecycle will recycle the connections. Discard current connection and get new one.
 func (c *Client4) DatabaseRecycle() (bool, *Response) {
 	r, err := c.DoApiPost(c.GetDatabaseRoute()+"/recycle", "")
@@ -2630,6 +3342,17 @@ func (c *Client4) InvalidateCaches() (bool, *Response) {
 	return CheckStatusOK(r), BuildResponse(r)
 }
 
+// GetIntegrationCircuitBreakers returns the circuit breakers of the integration endpoints that have failed recently on
+// the server handling the request, including any that are currently skipping requests.
+func (c *Client4) GetIntegrationCircuitBreakers() ([]*CircuitBreakerStatus, *Response) {
+	r, err := c.DoApiGet("/integrations/circuit_breakers", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return CircuitBreakerStatusListFromJson(r.Body), BuildResponse(r)
+}
+
 // UpdateConfig will update the server configuration.
 func (c *Client4) UpdateConfig(config *Config) (*Config, *Response) {
 	r, err := c.DoApiPut(c.GetConfigRoute(), config.ToJson())
@@ -2876,6 +3599,41 @@ func (c *Client4) UpdatePreferences(userId string, preferences *Preferences) (bo
 	return true, BuildResponse(r)
 }
 
+// SyncPreferences saves each of the user's preferences that was changed after the one stored
+// on the server, according to their update_at, and returns the stored preferences with the
+// same categories and names.
+func (c *Client4) SyncPreferences(userId string, preferences *Preferences) (Preferences, *Response) {
+	r, err := c.DoApiPost(c.GetPreferencesRoute(userId)+"/sync", preferences.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	synced, _ := PreferencesFromJson(r.Body)
+	return synced, BuildResponse(r)
+}
+
+// ExportPreferences returns the user's preferences in a form that can be imported into another account.
+func (c *Client4) ExportPreferences(userId string) (*UserPreferencesExport, *Response) {
+	r, err := c.DoApiGet(c.GetPreferencesRoute(userId)+"/export", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return UserPreferencesExportFromJson(r.Body), BuildResponse(r)
+}
+
+// ImportPreferences saves the preferences from an export for the user and returns those that
+// were saved, which leaves out any that refer to something the user can't access.
+func (c *Client4) ImportPreferences(userId string, export *UserPreferencesExport) (Preferences, *Response) {
+	r, err := c.DoApiPost(c.GetPreferencesRoute(userId)+"/import", export.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	imported, _ := PreferencesFromJson(r.Body)
+	return imported, BuildResponse(r)
+}
+
 // DeletePreferences deletes the user's preferences.
 func (c *Client4) DeletePreferences(userId string, preferences *Preferences) (bool, *Response) {
 	r, err := c.DoApiPost(c.GetPreferencesRoute(userId)+"/delete", preferences.ToJson())
@@ -3415,6 +4173,17 @@ func (c *Client4) PurgeElasticsearchIndexes() (bool, *Response) {
 	return CheckStatusOK(r), BuildResponse(r)
 }
 
+// ReindexElasticsearch creates a job that reindexes the posts of a single team or channel. Exactly one of teamId
+// and channelId must be given. The job's progress can be followed with GetJob.
+func (c *Client4) ReindexElasticsearch(teamId string, channelId string) (*Job, *Response) {
+	r, err := c.DoApiPost(c.GetElasticsearchRoute()+"/reindex", MapToJson(map[string]string{"team_id": teamId, "channel_id": channelId}))
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return JobFromJson(r.Body), BuildResponse(r)
+}
+
 // Data Retention Section
 
 // GetDataRetentionPolicy will get the current server data retention policy details.
@@ -3675,6 +4444,18 @@ func (c *Client4) AutocompleteEmoji(name string, etag string) ([]*Emoji, *Respon
 	return EmojiListFromJson(r.Body), BuildResponse(r)
 }
 
+// AutocompleteEmojiRanked returns the system and custom emoji starting with name, with the emoji that the current
+// user uses most often and most recently first.
+func (c *Client4) AutocompleteEmojiRanked(name string) ([]*EmojiSuggestion, *Response) {
+	query := fmt.Sprintf("?name=%v", url.QueryEscape(name))
+	r, err := c.DoApiGet(c.GetEmojisRoute()+"/autocomplete/ranked"+query, "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return EmojiSuggestionListFromJson(r.Body), BuildResponse(r)
+}
+
 // Reaction Section
 
 // SaveReaction saves an emoji reaction for a post. Returns the saved reaction if successful, otherwise an error will be returned.
@@ -3687,6 +4468,28 @@ func (c *Client4) SaveReaction(reaction *Reaction) (*Reaction, *Response) {
 	return ReactionFromJson(r.Body), BuildResponse(r)
 }
 
+// SaveBulkReaction adds the same emoji reaction to many posts at once. Returns the outcome for each post.
+func (c *Client4) SaveBulkReaction(bulkReaction *BulkReaction) ([]*BulkReactionResult, *Response) {
+	r, err := c.DoApiPost(c.GetReactionsRoute()+"/bulk", bulkReaction.ToJson())
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return BulkReactionResultsFromJson(r.Body), BuildResponse(r)
+}
+
+// GetUsersWhoReactedInChannel gets a page of the users who reacted with the given emoji to posts in a channel
+// since the given time, in milliseconds, ordered by when they first reacted.
+func (c *Client4) GetUsersWhoReactedInChannel(channelId, emojiName string, since int64, page, perPage int) ([]*User, *Response) {
+	query := fmt.Sprintf("?since=%v&page=%v&per_page=%v", since, page, perPage)
+	r, err := c.DoApiGet(c.GetChannelRoute(channelId)+"/reactions/"+emojiName+"/users"+query, "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+	return UserListFromJson(r.Body), BuildResponse(r)
+}
+
 // GetReactions returns a list of reactions to a post.
 func (c *Client4) GetReactions(postId string) ([]*Reaction, *Response) {
 	r, err := c.DoApiGet(c.GetPostRoute(postId)+"/reactions", "")
@@ -24,6 +24,10 @@ type ClusterDiscovery struct {
 	Port        int32  `json:"port"`
 	CreateAt    int64  `json:"create_at"`
 	LastPingAt  int64  `json:"last_ping_at"`
+
+	// Tags are taken from ClusterSettings.NodeTags and are used to decide which nodes should run which types of
+	// jobs.
+	Tags StringArray `json:"tags"`
 }
 
 func (o *ClusterDiscovery) PreSave() {
@@ -35,6 +39,20 @@ func (o *ClusterDiscovery) PreSave() {
 		o.CreateAt = GetMillis()
 		o.LastPingAt = o.CreateAt
 	}
+
+	if o.Tags == nil {
+		o.Tags = StringArray{}
+	}
+}
+
+func (o *ClusterDiscovery) HasTag(tag string) bool {
+	for _, t := range o.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (o *ClusterDiscovery) AutoFillHostname() {
@@ -57,3 +57,30 @@ func TestClusterDiscovery(t *testing.T) {
 	o.Hostname = ""
 	o.AutoFillIpAddress()
 }
+
+func TestClusterDiscoveryTags(t *testing.T) {
+	o := ClusterDiscovery{
+		Type:        "test_type",
+		ClusterName: "cluster_name",
+		Hostname:    "test_hostname",
+	}
+
+	o.PreSave()
+	if o.Tags == nil {
+		t.Fatal("tags should be set")
+	}
+
+	if o.HasTag("indexer") {
+		t.Fatal("shouldn't have tag")
+	}
+
+	o.Tags = StringArray{"indexer", "exporter"}
+	if !o.HasTag("exporter") {
+		t.Fatal("should have tag")
+	}
+
+	result := ClusterDiscoveryFromJson(strings.NewReader(o.ToJson()))
+	if !result.HasTag("indexer") {
+		t.Fatal("tags should be kept")
+	}
+}
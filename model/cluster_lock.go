@@ -0,0 +1,40 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"net/http"
+)
+
+const (
+	CLUSTER_LOCK_NAME_MAX_LENGTH = 64
+)
+
+// ClusterLock is a named lock held by a single owner across the cluster until it's released or ExpireAt passes,
+// so that a lock held by a node that dies is eventually freed up for another one.
+type ClusterLock struct {
+	Name     string `json:"name"`
+	Owner    string `json:"owner"`
+	ExpireAt int64  `json:"expire_at"`
+}
+
+func (l *ClusterLock) IsValid() *AppError {
+	if len(l.Name) == 0 || len(l.Name) > CLUSTER_LOCK_NAME_MAX_LENGTH {
+		return NewAppError("ClusterLock.IsValid", "model.cluster_lock.is_valid.name.app_error", nil, "name="+l.Name, http.StatusBadRequest)
+	}
+
+	if !IsValidId(l.Owner) {
+		return NewAppError("ClusterLock.IsValid", "model.cluster_lock.is_valid.owner.app_error", nil, "name="+l.Name, http.StatusBadRequest)
+	}
+
+	if l.ExpireAt == 0 {
+		return NewAppError("ClusterLock.IsValid", "model.cluster_lock.is_valid.expire_at.app_error", nil, "name="+l.Name, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (l *ClusterLock) IsExpired(now int64) bool {
+	return l.ExpireAt <= now
+}
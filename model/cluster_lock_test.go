@@ -0,0 +1,41 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterLockIsValid(t *testing.T) {
+	lock := &ClusterLock{
+		Name:     "scheduler_data_retention",
+		Owner:    NewId(),
+		ExpireAt: GetMillis(),
+	}
+	assert.Nil(t, lock.IsValid())
+
+	lock.Name = ""
+	assert.NotNil(t, lock.IsValid())
+
+	lock.Name = strings.Repeat("a", CLUSTER_LOCK_NAME_MAX_LENGTH+1)
+	assert.NotNil(t, lock.IsValid())
+
+	lock.Name = "scheduler_data_retention"
+	lock.Owner = "owner"
+	assert.NotNil(t, lock.IsValid())
+
+	lock.Owner = NewId()
+	lock.ExpireAt = 0
+	assert.NotNil(t, lock.IsValid())
+}
+
+func TestClusterLockIsExpired(t *testing.T) {
+	lock := &ClusterLock{ExpireAt: 1000}
+	assert.False(t, lock.IsExpired(999))
+	assert.True(t, lock.IsExpired(1000))
+	assert.True(t, lock.IsExpired(1001))
+}
@@ -11,6 +11,8 @@ import (
 const (
 	CLUSTER_EVENT_PUBLISH                                           = "publish"
 	CLUSTER_EVENT_UPDATE_STATUS                                     = "update_status"
+	CLUSTER_EVENT_PRESENCE_SYNC                                     = "presence_sync"
+	CLUSTER_EVENT_PRESENCE_UPDATE                                   = "presence_update"
 	CLUSTER_EVENT_INVALIDATE_ALL_CACHES                             = "inv_all_caches"
 	CLUSTER_EVENT_INVALIDATE_CACHE_FOR_REACTIONS                    = "inv_reactions"
 	CLUSTER_EVENT_INVALIDATE_CACHE_FOR_WEBHOOK                      = "inv_webhook"
@@ -24,6 +26,8 @@ const (
 	CLUSTER_EVENT_INVALIDATE_CACHE_FOR_ROLES                        = "inv_roles"
 	CLUSTER_EVENT_INVALIDATE_CACHE_FOR_SCHEMES                      = "inv_schemes"
 	CLUSTER_EVENT_INVALIDATE_CACHE_FOR_GROUPS                       = "inv_groups"
+	CLUSTER_EVENT_INVALIDATE_RESPONSE_CACHE                         = "inv_response_cache"
+	CLUSTER_EVENT_INVALIDATE_CACHE_FOR_PERMALINK_PREVIEW            = "inv_permalink_preview"
 
 	CLUSTER_SEND_BEST_EFFORT = "best_effort"
 	CLUSTER_SEND_RELIABLE    = "reliable"
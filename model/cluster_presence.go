@@ -0,0 +1,29 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ClusterPresence is sent between cluster nodes to share which users have websocket connections to each of them. With
+// CLUSTER_EVENT_PRESENCE_SYNC, UserIds lists every user connected to the node. With CLUSTER_EVENT_PRESENCE_UPDATE, it
+// lists users who just connected to or disconnected from it, depending on Connected.
+type ClusterPresence struct {
+	NodeId    string   `json:"node_id"`
+	UserIds   []string `json:"user_ids"`
+	Connected bool     `json:"connected"`
+}
+
+func (o *ClusterPresence) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func ClusterPresenceFromJson(data io.Reader) *ClusterPresence {
+	var o *ClusterPresence
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
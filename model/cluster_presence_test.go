@@ -0,0 +1,22 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterPresenceJson(t *testing.T) {
+	presence := &ClusterPresence{
+		NodeId:    NewId(),
+		UserIds:   []string{NewId(), NewId()},
+		Connected: true,
+	}
+
+	result := ClusterPresenceFromJson(strings.NewReader(presence.ToJson()))
+	assert.Equal(t, presence, result)
+}
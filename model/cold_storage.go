@@ -0,0 +1,30 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ColdStorageCheckpoint records how far a run of moving files to cold storage got. Files are moved in order of their
+// ids, so the run can resume right after the last file that was looked at. The age cutoff is kept as well so that a
+// resumed run moves the same files that it would have if it hadn't been interrupted.
+type ColdStorageCheckpoint struct {
+	Before  int64  `json:"before"`
+	AfterId string `json:"after_id"`
+	Moved   int64  `json:"moved"`
+	Failed  int64  `json:"failed"`
+}
+
+func (c *ColdStorageCheckpoint) ToJson() string {
+	b, _ := json.Marshal(c)
+	return string(b)
+}
+
+func ColdStorageCheckpointFromJson(data io.Reader) *ColdStorageCheckpoint {
+	var checkpoint *ColdStorageCheckpoint
+	json.NewDecoder(data).Decode(&checkpoint)
+	return checkpoint
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColdStorageCheckpointJson(t *testing.T) {
+	checkpoint := &ColdStorageCheckpoint{
+		Before:  GetMillis(),
+		AfterId: NewId(),
+		Moved:   100,
+		Failed:  2,
+	}
+
+	assert.Equal(t, checkpoint, ColdStorageCheckpointFromJson(strings.NewReader(checkpoint.ToJson())))
+	assert.Nil(t, ColdStorageCheckpointFromJson(strings.NewReader("garbage")))
+}
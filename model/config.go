@@ -8,10 +8,12 @@ import (
 	"encoding/json"
 	"io"
 	"math"
+	"mime"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -89,11 +91,60 @@ const (
 	SERVICE_SETTINGS_DEFAULT_TLS_KEY_FILE       = ""
 	SERVICE_SETTINGS_DEFAULT_READ_TIMEOUT       = 300
 	SERVICE_SETTINGS_DEFAULT_WRITE_TIMEOUT      = 300
+	SERVICE_SETTINGS_DEFAULT_REQUEST_TIMEOUT    = 0
 	SERVICE_SETTINGS_DEFAULT_MAX_LOGIN_ATTEMPTS = 10
 	SERVICE_SETTINGS_DEFAULT_ALLOW_CORS_FROM    = ""
 	SERVICE_SETTINGS_DEFAULT_LISTEN_AND_ADDRESS = ":8065"
 	SERVICE_SETTINGS_DEFAULT_GFYCAT_API_KEY     = "2_KtH_W5"
-	SERVICE_SETTINGS_DEFAULT_GFYCAT_API_SECRET  = "3wLVZPiswc3DnaiaFoLkDvB4X0IV6CpMkj4tf2inJRsBY6-FnkT08zGmppWFgeof"
+
+	SERVICE_SETTINGS_DEFAULT_RESPONSE_COMPRESSION_MIN_SIZE = 1400
+	SERVICE_SETTINGS_DEFAULT_GFYCAT_API_SECRET             = "3wLVZPiswc3DnaiaFoLkDvB4X0IV6CpMkj4tf2inJRsBY6-FnkT08zGmppWFgeof"
+
+	SERVICE_SETTINGS_DEFAULT_SEARCH_EXPORT_MAX_RESULTS = 10000
+
+	SERVICE_SETTINGS_DEFAULT_CACHE_PRELOADING_TIMEOUT_SECONDS = 30
+	SERVICE_SETTINGS_DEFAULT_CACHE_PRELOADING_MAX_USERS       = 1000
+
+	SERVICE_SETTINGS_DEFAULT_RESPONSE_CACHE_MAX_AGE_SECONDS = 60
+
+	SERVICE_SETTINGS_DEFAULT_MAX_BATCH_REQUESTS = 25
+
+	SERVICE_SETTINGS_DEFAULT_LOAD_SHEDDING_RETRY_AFTER_SECONDS = 5
+
+	SERVICE_SETTINGS_DEFAULT_WEBSOCKET_SEND_QUEUE_SIZE    = 256
+	SERVICE_SETTINGS_DEFAULT_WEBSOCKET_MAX_DROPPED_EVENTS = 100
+
+	SERVICE_SETTINGS_DEFAULT_MAXIMUM_PER_PAGE = 200
+
+	SERVICE_SETTINGS_DEFAULT_ERROR_PAGE_PATH = "/error"
+
+	SERVICE_SETTINGS_DEFAULT_REFERRER_POLICY = "same-origin"
+
+	SERVICE_SETTINGS_DEFAULT_ANALYTICS_CDN_HOST = "cdn.segment.com"
+
+	SERVICE_SETTINGS_DEFAULT_CSP_SCRIPT_SOURCES_CACHE_SIZE = 8
+
+	SERVICE_SETTINGS_DEFAULT_READINESS_CHECK_TIMEOUT_MILLISECONDS = 2000
+
+	READINESS_CHECK_DATABASE = "database"
+	READINESS_CHECK_SEARCH   = "search"
+
+	FRAME_OPTIONS_DENY        = "DENY"
+	FRAME_OPTIONS_SAME_ORIGIN = "SAMEORIGIN"
+
+	SESSION_COOKIE_SAME_SITE_UNSET  = ""
+	SESSION_COOKIE_SAME_SITE_STRICT = "Strict"
+	SESSION_COOKIE_SAME_SITE_LAX    = "Lax"
+	SESSION_COOKIE_SAME_SITE_NONE   = "None"
+
+	SESSION_COOKIE_SECURE_AUTO   = "auto"
+	SESSION_COOKIE_SECURE_ALWAYS = "always"
+
+	CSRF_PROTECTION_MODE_HEADER        = "header"
+	CSRF_PROTECTION_MODE_DOUBLE_SUBMIT = "double_submit"
+
+	WEBSOCKET_SLOW_CONSUMER_POLICY_DISCONNECT  = "disconnect"
+	WEBSOCKET_SLOW_CONSUMER_POLICY_DROP_OLDEST = "drop_oldest"
 
 	TEAM_SETTINGS_DEFAULT_MAX_USERS_PER_TEAM       = 50
 	TEAM_SETTINGS_DEFAULT_CUSTOM_BRAND_TEXT        = ""
@@ -163,6 +214,41 @@ const (
 	DATA_RETENTION_SETTINGS_DEFAULT_FILE_RETENTION_DAYS     = 365
 	DATA_RETENTION_SETTINGS_DEFAULT_DELETION_JOB_START_TIME = "02:00"
 
+	INACTIVE_USER_SETTINGS_DEFAULT_INACTIVE_DAYS  = 180
+	INACTIVE_USER_SETTINGS_DEFAULT_WARNING_DAYS   = 7
+	INACTIVE_USER_SETTINGS_DEFAULT_JOB_START_TIME = "03:00"
+
+	INACTIVE_CHANNEL_SETTINGS_DEFAULT_INACTIVE_DAYS  = 90
+	INACTIVE_CHANNEL_SETTINGS_DEFAULT_WARNING_DAYS   = 7
+	INACTIVE_CHANNEL_SETTINGS_DEFAULT_JOB_START_TIME = "03:30"
+
+	COLD_STORAGE_SETTINGS_DEFAULT_MIN_FILE_AGE_DAYS = 180
+	COLD_STORAGE_SETTINGS_DEFAULT_BATCH_SIZE        = 100
+	COLD_STORAGE_SETTINGS_DEFAULT_JOB_START_TIME    = "04:00"
+
+	FIREHOSE_DESTINATION_HTTP   = "http"
+	FIREHOSE_DESTINATION_CUSTOM = "custom"
+
+	FIREHOSE_SETTINGS_DEFAULT_BUFFER_SIZE                 = 10000
+	FIREHOSE_SETTINGS_DEFAULT_BATCH_SIZE                  = 100
+	FIREHOSE_SETTINGS_DEFAULT_FLUSH_INTERVAL_MILLISECONDS = 1000
+	FIREHOSE_SETTINGS_DEFAULT_SPOOL_DIRECTORY             = "./data/firehose/"
+
+	INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_REQUEST_TIMEOUT_SECONDS           = 30
+	INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_CONNECT_TIMEOUT_SECONDS           = 3
+	INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_MAX_IDLE_CONNS                    = 100
+	INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_MAX_IDLE_CONNS_PER_HOST           = 2
+	INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_MAX_CONNS_PER_HOST                = 0
+	INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_IDLE_CONN_TIMEOUT_SECONDS         = 90
+	INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_CIRCUIT_BREAKER_FAILURE_THRESHOLD = 5
+	INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_CIRCUIT_BREAKER_COOLDOWN_SECONDS  = 60
+
+	AUTOCOMPLETE_SETTINGS_DEFAULT_RESULT_LIMIT        = USER_SEARCH_DEFAULT_LIMIT
+	AUTOCOMPLETE_SETTINGS_DEFAULT_RECENCY_WEIGHT      = 0
+	AUTOCOMPLETE_SETTINGS_DEFAULT_MEMBERSHIP_WEIGHT   = 0
+	AUTOCOMPLETE_SETTINGS_DEFAULT_ALPHABETICAL_WEIGHT = 100
+	AUTOCOMPLETE_SETTINGS_MAX_WEIGHT                  = 100
+
 	PLUGIN_SETTINGS_DEFAULT_DIRECTORY        = "./plugins"
 	PLUGIN_SETTINGS_DEFAULT_CLIENT_DIRECTORY = "./client/plugins"
 
@@ -177,8 +263,22 @@ const (
 	CLIENT_SIDE_CERT_CHECK_PRIMARY_AUTH   = "primary"
 	CLIENT_SIDE_CERT_CHECK_SECONDARY_AUTH = "secondary"
 
+	CLIENT_CERT_AUTH_ATTRIBUTE_EMAIL = "email"
+	CLIENT_CERT_AUTH_ATTRIBUTE_CN    = "cn"
+	CLIENT_CERT_AUTH_ATTRIBUTE_SAN   = "san"
+
+	CORS_SETTINGS_DEFAULT_MAX_AGE_SECONDS = 86400
+
+	TRACE_SETTINGS_DEFAULT_SAMPLING_RATIO = 1.0
+
 	IMAGE_PROXY_TYPE_LOCAL      = "local"
 	IMAGE_PROXY_TYPE_ATMOS_CAMO = "atmos/camo"
+
+	CLUSTER_TRANSPORT_TCP  = "tcp"
+	CLUSTER_TRANSPORT_GRPC = "grpc"
+
+	LINK_REWRITE_SETTINGS_DEFAULT_EXPAND_TIMEOUT_MILLISECONDS = 2000
+	LINK_REWRITE_SETTINGS_MAX_EXPAND_TIMEOUT_MILLISECONDS     = 30000
 )
 
 var ServerTLSSupportedCiphers = map[string]uint16{
@@ -223,6 +323,9 @@ type ServiceSettings struct {
 	Forward80To443                                    *bool
 	ReadTimeout                                       *int
 	WriteTimeout                                      *int
+	RequestTimeout                                    *int
+	EnableResponseCompression                         *bool
+	ResponseCompressionMinSize                        *int
 	MaximumLoginAttempts                              *int
 	GoroutineHealthThreshold                          *int
 	GoogleDeveloperKey                                string
@@ -242,11 +345,16 @@ type ServiceSettings struct {
 	EnableMultifactorAuthentication                   *bool
 	EnforceMultifactorAuthentication                  *bool
 	EnableUserAccessTokens                            *bool
-	AllowCorsFrom                                     *string
-	CorsExposedHeaders                                *string
-	CorsAllowCredentials                              *bool
-	CorsDebug                                         *bool
+	DEPRECATED_DO_NOT_USE_AllowCorsFrom               *string `json:"AllowCorsFrom" mapstructure:"AllowCorsFrom"`               // This field is deprecated and must not be used.
+	DEPRECATED_DO_NOT_USE_CorsExposedHeaders          *string `json:"CorsExposedHeaders" mapstructure:"CorsExposedHeaders"`     // This field is deprecated and must not be used.
+	DEPRECATED_DO_NOT_USE_CorsAllowCredentials        *bool   `json:"CorsAllowCredentials" mapstructure:"CorsAllowCredentials"` // This field is deprecated and must not be used.
+	DEPRECATED_DO_NOT_USE_CorsDebug                   *bool   `json:"CorsDebug" mapstructure:"CorsDebug"`                       // This field is deprecated and must not be used.
 	AllowCookiesForSubdomains                         *bool
+	SessionCookieSameSite                             *string
+	SessionCookieDomain                               *string
+	SessionCookieSecure                               *string
+	CsrfProtectionMode                                *string
+	CsrfTokenRotationMinutes                          *int
 	SessionLengthWebInDays                            *int
 	SessionLengthMobileInDays                         *int
 	SessionLengthSSOInDays                            *int
@@ -264,8 +372,11 @@ type ServiceSettings struct {
 	DEPRECATED_DO_NOT_USE_RestrictPostDelete          *string `json:"RestrictPostDelete"`          // This field is deprecated and must not be used.
 	DEPRECATED_DO_NOT_USE_AllowEditPost               *string `json:"AllowEditPost"`               // This field is deprecated and must not be used.
 	PostEditTimeLimit                                 *int
+	PostEditTimeLimitsByRole                          map[string]int
 	TimeBetweenUserTypingUpdatesMilliseconds          *int64
 	EnablePostSearch                                  *bool
+	SearchExportMaxResults                            *int
+	SearchDefaultLookbackDays                         *int
 	EnableUserTypingMessages                          *bool
 	EnableChannelViewedMessages                       *bool
 	EnableUserStatuses                                *bool
@@ -285,6 +396,43 @@ type ServiceSettings struct {
 	DisableLegacyMFA                                  *bool
 	EnableEmailInvitations                            *bool
 	ExperimentalLdapGroupSync                         *bool
+	EnableCachePreloading                             *bool
+	CachePreloadingTimeoutSeconds                     *int
+	CachePreloadingMaxUsers                           *int
+	ResponseCacheEndpoints                            []string
+	ResponseCacheMaxAgeSeconds                        *int
+	MaxBatchRequests                                  *int
+	GrpcListenAddress                                 *string
+	LoadSheddingGoroutineThreshold                    *int
+	LoadSheddingMemoryThresholdMB                     *int
+	LoadSheddingRetryAfterSeconds                     *int
+	MaxConcurrentRequestsPerUser                      *int
+	EnableAccessLogging                               *bool
+	WebsocketSendQueueSize                            *int
+	WebsocketSlowConsumerPolicy                       *string
+	WebsocketMaxDroppedEvents                         *int
+	RejectUnacceptableResponseTypes                   *bool
+	MaximumPerPage                                    *int
+	ErrorPagePath                                     *string
+	ReferrerPolicy                                    *string
+	FrameOptions                                      *string
+	AnalyticsCdnHost                                  *string
+	CspScriptSourcesCacheSize                         *int
+	ReadinessChecks                                   []string
+	ReadinessCheckTimeoutMilliseconds                 *int
+	WebsocketAllowedOrigins                           []string
+	ClientCertAuth                                    *bool
+	ClientCertAuthRequired                            *bool
+	ClientCertAuthAttribute                           *string
+	ClientCertAuthCAFile                              *string
+	EnableAssetPreload                                *bool
+	TrustedProxyIPHeader                              *string
+	TrustedProxies                                    []string
+	MaintenanceMode                                   *bool
+	MaintenanceModeMessage                            *string
+	ExposeNodeHeader                                  *bool
+	SlowRequestThresholdMilliseconds                  *int
+	SlowUploadRequestThresholdMilliseconds            *int
 }
 
 func (s *ServiceSettings) SetDefaults() {
@@ -393,6 +541,18 @@ func (s *ServiceSettings) SetDefaults() {
 		s.WriteTimeout = NewInt(SERVICE_SETTINGS_DEFAULT_WRITE_TIMEOUT)
 	}
 
+	if s.RequestTimeout == nil {
+		s.RequestTimeout = NewInt(SERVICE_SETTINGS_DEFAULT_REQUEST_TIMEOUT)
+	}
+
+	if s.EnableResponseCompression == nil {
+		s.EnableResponseCompression = NewBool(false)
+	}
+
+	if s.ResponseCompressionMinSize == nil {
+		s.ResponseCompressionMinSize = NewInt(SERVICE_SETTINGS_DEFAULT_RESPONSE_COMPRESSION_MIN_SIZE)
+	}
+
 	if s.MaximumLoginAttempts == nil {
 		s.MaximumLoginAttempts = NewInt(SERVICE_SETTINGS_DEFAULT_MAX_LOGIN_ATTEMPTS)
 	}
@@ -409,6 +569,162 @@ func (s *ServiceSettings) SetDefaults() {
 		s.EnablePostSearch = NewBool(true)
 	}
 
+	if s.SearchExportMaxResults == nil {
+		s.SearchExportMaxResults = NewInt(SERVICE_SETTINGS_DEFAULT_SEARCH_EXPORT_MAX_RESULTS)
+	}
+
+	if s.SearchDefaultLookbackDays == nil {
+		s.SearchDefaultLookbackDays = NewInt(0)
+	}
+
+	if s.EnableCachePreloading == nil {
+		s.EnableCachePreloading = NewBool(false)
+	}
+
+	if s.CachePreloadingTimeoutSeconds == nil {
+		s.CachePreloadingTimeoutSeconds = NewInt(SERVICE_SETTINGS_DEFAULT_CACHE_PRELOADING_TIMEOUT_SECONDS)
+	}
+
+	if s.CachePreloadingMaxUsers == nil {
+		s.CachePreloadingMaxUsers = NewInt(SERVICE_SETTINGS_DEFAULT_CACHE_PRELOADING_MAX_USERS)
+	}
+
+	if s.ResponseCacheEndpoints == nil {
+		s.ResponseCacheEndpoints = []string{}
+	}
+
+	if s.ResponseCacheMaxAgeSeconds == nil {
+		s.ResponseCacheMaxAgeSeconds = NewInt(SERVICE_SETTINGS_DEFAULT_RESPONSE_CACHE_MAX_AGE_SECONDS)
+	}
+
+	if s.MaxBatchRequests == nil {
+		s.MaxBatchRequests = NewInt(SERVICE_SETTINGS_DEFAULT_MAX_BATCH_REQUESTS)
+	}
+
+	if s.GrpcListenAddress == nil {
+		s.GrpcListenAddress = NewString("")
+	}
+
+	if s.LoadSheddingGoroutineThreshold == nil {
+		s.LoadSheddingGoroutineThreshold = NewInt(0)
+	}
+
+	if s.LoadSheddingMemoryThresholdMB == nil {
+		s.LoadSheddingMemoryThresholdMB = NewInt(0)
+	}
+
+	if s.LoadSheddingRetryAfterSeconds == nil {
+		s.LoadSheddingRetryAfterSeconds = NewInt(SERVICE_SETTINGS_DEFAULT_LOAD_SHEDDING_RETRY_AFTER_SECONDS)
+	}
+
+	if s.MaxConcurrentRequestsPerUser == nil {
+		s.MaxConcurrentRequestsPerUser = NewInt(0)
+	}
+
+	if s.EnableAccessLogging == nil {
+		s.EnableAccessLogging = NewBool(false)
+	}
+
+	if s.WebsocketSendQueueSize == nil {
+		s.WebsocketSendQueueSize = NewInt(SERVICE_SETTINGS_DEFAULT_WEBSOCKET_SEND_QUEUE_SIZE)
+	}
+
+	if s.WebsocketSlowConsumerPolicy == nil {
+		s.WebsocketSlowConsumerPolicy = NewString(WEBSOCKET_SLOW_CONSUMER_POLICY_DISCONNECT)
+	}
+
+	if s.WebsocketMaxDroppedEvents == nil {
+		s.WebsocketMaxDroppedEvents = NewInt(SERVICE_SETTINGS_DEFAULT_WEBSOCKET_MAX_DROPPED_EVENTS)
+	}
+
+	if s.RejectUnacceptableResponseTypes == nil {
+		s.RejectUnacceptableResponseTypes = NewBool(false)
+	}
+
+	if s.MaximumPerPage == nil {
+		s.MaximumPerPage = NewInt(SERVICE_SETTINGS_DEFAULT_MAXIMUM_PER_PAGE)
+	}
+
+	if s.ErrorPagePath == nil {
+		s.ErrorPagePath = NewString(SERVICE_SETTINGS_DEFAULT_ERROR_PAGE_PATH)
+	}
+
+	if s.ReferrerPolicy == nil {
+		s.ReferrerPolicy = NewString(SERVICE_SETTINGS_DEFAULT_REFERRER_POLICY)
+	}
+
+	if s.FrameOptions == nil {
+		s.FrameOptions = NewString(FRAME_OPTIONS_DENY)
+	}
+
+	if s.AnalyticsCdnHost == nil {
+		s.AnalyticsCdnHost = NewString(SERVICE_SETTINGS_DEFAULT_ANALYTICS_CDN_HOST)
+	}
+
+	if s.CspScriptSourcesCacheSize == nil {
+		s.CspScriptSourcesCacheSize = NewInt(SERVICE_SETTINGS_DEFAULT_CSP_SCRIPT_SOURCES_CACHE_SIZE)
+	}
+
+	if s.ReadinessChecks == nil {
+		s.ReadinessChecks = []string{READINESS_CHECK_DATABASE, READINESS_CHECK_SEARCH}
+	}
+
+	if s.ReadinessCheckTimeoutMilliseconds == nil {
+		s.ReadinessCheckTimeoutMilliseconds = NewInt(SERVICE_SETTINGS_DEFAULT_READINESS_CHECK_TIMEOUT_MILLISECONDS)
+	}
+
+	if s.WebsocketAllowedOrigins == nil {
+		s.WebsocketAllowedOrigins = []string{}
+	}
+
+	if s.ClientCertAuth == nil {
+		s.ClientCertAuth = NewBool(false)
+	}
+
+	if s.ClientCertAuthRequired == nil {
+		s.ClientCertAuthRequired = NewBool(false)
+	}
+
+	if s.ClientCertAuthAttribute == nil {
+		s.ClientCertAuthAttribute = NewString(CLIENT_CERT_AUTH_ATTRIBUTE_EMAIL)
+	}
+
+	if s.ClientCertAuthCAFile == nil {
+		s.ClientCertAuthCAFile = NewString("")
+	}
+
+	if s.EnableAssetPreload == nil {
+		s.EnableAssetPreload = NewBool(false)
+	}
+
+	if s.TrustedProxyIPHeader == nil {
+		s.TrustedProxyIPHeader = NewString(HEADER_FORWARDED)
+	}
+
+	if s.TrustedProxies == nil {
+		s.TrustedProxies = []string{}
+	}
+
+	if s.MaintenanceMode == nil {
+		s.MaintenanceMode = NewBool(false)
+	}
+
+	if s.MaintenanceModeMessage == nil {
+		s.MaintenanceModeMessage = NewString("")
+	}
+
+	if s.ExposeNodeHeader == nil {
+		s.ExposeNodeHeader = NewBool(false)
+	}
+
+	if s.SlowRequestThresholdMilliseconds == nil {
+		s.SlowRequestThresholdMilliseconds = NewInt(0)
+	}
+
+	if s.SlowUploadRequestThresholdMilliseconds == nil {
+		s.SlowUploadRequestThresholdMilliseconds = NewInt(0)
+	}
+
 	if s.EnableUserTypingMessages == nil {
 		s.EnableUserTypingMessages = NewBool(true)
 	}
@@ -469,26 +785,46 @@ func (s *ServiceSettings) SetDefaults() {
 		s.WebsocketSecurePort = NewInt(443)
 	}
 
-	if s.AllowCorsFrom == nil {
-		s.AllowCorsFrom = NewString(SERVICE_SETTINGS_DEFAULT_ALLOW_CORS_FROM)
+	if s.DEPRECATED_DO_NOT_USE_AllowCorsFrom == nil {
+		s.DEPRECATED_DO_NOT_USE_AllowCorsFrom = NewString(SERVICE_SETTINGS_DEFAULT_ALLOW_CORS_FROM)
 	}
 
-	if s.CorsExposedHeaders == nil {
-		s.CorsExposedHeaders = NewString("")
+	if s.DEPRECATED_DO_NOT_USE_CorsExposedHeaders == nil {
+		s.DEPRECATED_DO_NOT_USE_CorsExposedHeaders = NewString("")
 	}
 
-	if s.CorsAllowCredentials == nil {
-		s.CorsAllowCredentials = NewBool(false)
+	if s.DEPRECATED_DO_NOT_USE_CorsAllowCredentials == nil {
+		s.DEPRECATED_DO_NOT_USE_CorsAllowCredentials = NewBool(false)
 	}
 
-	if s.CorsDebug == nil {
-		s.CorsDebug = NewBool(false)
+	if s.DEPRECATED_DO_NOT_USE_CorsDebug == nil {
+		s.DEPRECATED_DO_NOT_USE_CorsDebug = NewBool(false)
 	}
 
 	if s.AllowCookiesForSubdomains == nil {
 		s.AllowCookiesForSubdomains = NewBool(false)
 	}
 
+	if s.SessionCookieSameSite == nil {
+		s.SessionCookieSameSite = NewString(SESSION_COOKIE_SAME_SITE_UNSET)
+	}
+
+	if s.SessionCookieDomain == nil {
+		s.SessionCookieDomain = NewString("")
+	}
+
+	if s.SessionCookieSecure == nil {
+		s.SessionCookieSecure = NewString(SESSION_COOKIE_SECURE_AUTO)
+	}
+
+	if s.CsrfProtectionMode == nil {
+		s.CsrfProtectionMode = NewString(CSRF_PROTECTION_MODE_HEADER)
+	}
+
+	if s.CsrfTokenRotationMinutes == nil {
+		s.CsrfTokenRotationMinutes = NewInt(0)
+	}
+
 	if s.WebserverMode == nil {
 		s.WebserverMode = NewString("gzip")
 	} else if *s.WebserverMode == "regular" {
@@ -535,6 +871,10 @@ func (s *ServiceSettings) SetDefaults() {
 		s.PostEditTimeLimit = NewInt(-1)
 	}
 
+	if s.PostEditTimeLimitsByRole == nil {
+		s.PostEditTimeLimitsByRole = map[string]int{}
+	}
+
 	if s.EnablePreviewFeatures == nil {
 		s.EnablePreviewFeatures = NewBool(true)
 	}
@@ -597,6 +937,18 @@ type ClusterSettings struct {
 	MaxIdleConns                *int
 	MaxIdleConnsPerHost         *int
 	IdleConnTimeoutMilliseconds *int
+
+	// Changing the transport requires a restart.
+	Transport       *string
+	GrpcPort        *int
+	GrpcTLSCertFile *string
+	GrpcTLSKeyFile  *string
+	GrpcTLSCAFile   *string
+
+	// NodeTags are advertised by this node when it joins the cluster and are matched against
+	// JobSettings.JobNodeAffinity. Since they differ between nodes, they're usually set through the
+	// MM_CLUSTERSETTINGS_NODETAGS environment variable rather than the shared config.
+	NodeTags []string
 }
 
 func (s *ClusterSettings) SetDefaults() {
@@ -643,6 +995,30 @@ func (s *ClusterSettings) SetDefaults() {
 	if s.IdleConnTimeoutMilliseconds == nil {
 		s.IdleConnTimeoutMilliseconds = NewInt(90000)
 	}
+
+	if s.Transport == nil {
+		s.Transport = NewString(CLUSTER_TRANSPORT_TCP)
+	}
+
+	if s.GrpcPort == nil {
+		s.GrpcPort = NewInt(8076)
+	}
+
+	if s.GrpcTLSCertFile == nil {
+		s.GrpcTLSCertFile = NewString("")
+	}
+
+	if s.GrpcTLSKeyFile == nil {
+		s.GrpcTLSKeyFile = NewString("")
+	}
+
+	if s.GrpcTLSCAFile == nil {
+		s.GrpcTLSCAFile = NewString("")
+	}
+
+	if s.NodeTags == nil {
+		s.NodeTags = []string{}
+	}
 }
 
 type MetricsSettings struct {
@@ -828,6 +1204,14 @@ type FileSettings struct {
 	AmazonS3SignV2          *bool
 	AmazonS3SSE             *bool
 	AmazonS3Trace           *bool
+	InlineContentTypes      []string
+	EnableSvgUploads        *bool
+
+	AllowedUploadFileTypes []string
+	DeniedUploadFileTypes  []string
+	MaxFileSizeByType      map[string]int64
+
+	VerifyChecksumFileTypes []string
 }
 
 func (s *FileSettings) SetDefaults() {
@@ -873,6 +1257,42 @@ func (s *FileSettings) SetDefaults() {
 		s.MaxFileSize = NewInt64(52428800) // 50 MB
 	}
 
+	if s.EnableSvgUploads == nil {
+		s.EnableSvgUploads = NewBool(true)
+	}
+
+	if s.AllowedUploadFileTypes == nil {
+		s.AllowedUploadFileTypes = []string{}
+	}
+
+	if s.DeniedUploadFileTypes == nil {
+		s.DeniedUploadFileTypes = []string{}
+	}
+
+	if s.MaxFileSizeByType == nil {
+		s.MaxFileSizeByType = map[string]int64{}
+	}
+
+	if s.VerifyChecksumFileTypes == nil {
+		s.VerifyChecksumFileTypes = []string{}
+	}
+
+	if s.InlineContentTypes == nil {
+		// Types that browsers can't be made to run scripts from, unlike HTML or SVG
+		s.InlineContentTypes = []string{
+			"image/jpeg",
+			"image/png",
+			"image/bmp",
+			"image/gif",
+			"video/avi",
+			"video/mpeg",
+			"video/mp4",
+			"audio/mpeg",
+			"audio/wav",
+			"application/pdf",
+		}
+	}
+
 	if s.PublicLinkSalt == nil || len(*s.PublicLinkSalt) == 0 {
 		s.PublicLinkSalt = NewString(NewRandomString(32))
 	}
@@ -1009,6 +1429,7 @@ type RateLimitSettings struct {
 	VaryByRemoteAddr *bool
 	VaryByUser       *bool
 	VaryByHeader     string
+	PerUser          *bool
 }
 
 func (s *RateLimitSettings) SetDefaults() {
@@ -1035,6 +1456,10 @@ func (s *RateLimitSettings) SetDefaults() {
 	if s.VaryByUser == nil {
 		s.VaryByUser = NewBool(false)
 	}
+
+	if s.PerUser == nil {
+		s.PerUser = NewBool(false)
+	}
 }
 
 type PrivacySettings struct {
@@ -1803,159 +2228,722 @@ func (s *DataRetentionSettings) SetDefaults() {
 	}
 }
 
-type JobSettings struct {
-	RunJobs      *bool
-	RunScheduler *bool
+type InactiveUserSettings struct {
+	EnableDeactivation *bool
+	DryRun             *bool
+	InactiveDays       *int
+	WarningDays        *int
+	ExemptUsernames    *string
+	JobStartTime       *string
 }
 
-func (s *JobSettings) SetDefaults() {
-	if s.RunJobs == nil {
-		s.RunJobs = NewBool(true)
+func (s *InactiveUserSettings) SetDefaults() {
+	if s.EnableDeactivation == nil {
+		s.EnableDeactivation = NewBool(false)
 	}
 
-	if s.RunScheduler == nil {
-		s.RunScheduler = NewBool(true)
+	if s.DryRun == nil {
+		s.DryRun = NewBool(true)
 	}
-}
 
-type PluginState struct {
-	Enable bool
-}
+	if s.InactiveDays == nil {
+		s.InactiveDays = NewInt(INACTIVE_USER_SETTINGS_DEFAULT_INACTIVE_DAYS)
+	}
 
-type PluginSettings struct {
-	Enable          *bool
-	EnableUploads   *bool
-	Directory       *string
-	ClientDirectory *string
-	Plugins         map[string]map[string]interface{}
-	PluginStates    map[string]*PluginState
-}
+	if s.WarningDays == nil {
+		s.WarningDays = NewInt(INACTIVE_USER_SETTINGS_DEFAULT_WARNING_DAYS)
+	}
 
-func (s *PluginSettings) SetDefaults() {
-	if s.Enable == nil {
-		s.Enable = NewBool(true)
+	if s.ExemptUsernames == nil {
+		s.ExemptUsernames = NewString("")
 	}
 
-	if s.EnableUploads == nil {
-		s.EnableUploads = NewBool(false)
+	if s.JobStartTime == nil {
+		s.JobStartTime = NewString(INACTIVE_USER_SETTINGS_DEFAULT_JOB_START_TIME)
 	}
+}
 
-	if s.Directory == nil {
-		s.Directory = NewString(PLUGIN_SETTINGS_DEFAULT_DIRECTORY)
+// GetExemptUsernames returns the usernames of accounts, such as bots and service accounts, that are never deactivated.
+func (s *InactiveUserSettings) GetExemptUsernames() []string {
+	usernames := []string{}
+	for _, username := range strings.Split(*s.ExemptUsernames, ",") {
+		if username = strings.ToLower(strings.TrimSpace(username)); username != "" {
+			usernames = append(usernames, username)
+		}
 	}
+	return usernames
+}
 
-	if *s.Directory == "" {
-		*s.Directory = PLUGIN_SETTINGS_DEFAULT_DIRECTORY
+type InactiveChannelSettings struct {
+	EnableArchiving *bool
+	DryRun          *bool
+	InactiveDays    *int
+	WarningDays     *int
+	JobStartTime    *string
+}
+
+func (s *InactiveChannelSettings) SetDefaults() {
+	if s.EnableArchiving == nil {
+		s.EnableArchiving = NewBool(false)
 	}
 
-	if s.ClientDirectory == nil {
-		s.ClientDirectory = NewString(PLUGIN_SETTINGS_DEFAULT_CLIENT_DIRECTORY)
+	if s.DryRun == nil {
+		s.DryRun = NewBool(true)
 	}
 
-	if *s.ClientDirectory == "" {
-		*s.ClientDirectory = PLUGIN_SETTINGS_DEFAULT_CLIENT_DIRECTORY
+	if s.InactiveDays == nil {
+		s.InactiveDays = NewInt(INACTIVE_CHANNEL_SETTINGS_DEFAULT_INACTIVE_DAYS)
 	}
 
-	if s.Plugins == nil {
-		s.Plugins = make(map[string]map[string]interface{})
+	if s.WarningDays == nil {
+		s.WarningDays = NewInt(INACTIVE_CHANNEL_SETTINGS_DEFAULT_WARNING_DAYS)
 	}
 
-	if s.PluginStates == nil {
-		s.PluginStates = make(map[string]*PluginState)
+	if s.JobStartTime == nil {
+		s.JobStartTime = NewString(INACTIVE_CHANNEL_SETTINGS_DEFAULT_JOB_START_TIME)
 	}
 }
 
-type GlobalRelayMessageExportSettings struct {
-	CustomerType *string // must be either A9 or A10, dictates SMTP server url
-	SmtpUsername *string
-	SmtpPassword *string
-	EmailAddress *string // the address to send messages to
+// ColdStorageSettings configure a second file store that files are moved to once they're older than MinFileAgeDays.
+// Files that have been moved are still read from it when Enable is turned off, so its driver settings need to be kept
+// for as long as it holds any files.
+type ColdStorageSettings struct {
+	Enable                  *bool
+	MinFileAgeDays          *int
+	BatchSize               *int
+	JobStartTime            *string
+	DriverName              *string
+	Directory               *string
+	AmazonS3AccessKeyId     *string
+	AmazonS3SecretAccessKey *string
+	AmazonS3Bucket          *string
+	AmazonS3Region          *string
+	AmazonS3Endpoint        *string
+	AmazonS3SSL             *bool
 }
 
-func (s *GlobalRelayMessageExportSettings) SetDefaults() {
-	if s.CustomerType == nil {
-		s.CustomerType = NewString(GLOBALRELAY_CUSTOMER_TYPE_A9)
+func (s *ColdStorageSettings) SetDefaults() {
+	if s.Enable == nil {
+		s.Enable = NewBool(false)
 	}
-	if s.SmtpUsername == nil {
-		s.SmtpUsername = NewString("")
+
+	if s.MinFileAgeDays == nil {
+		s.MinFileAgeDays = NewInt(COLD_STORAGE_SETTINGS_DEFAULT_MIN_FILE_AGE_DAYS)
 	}
-	if s.SmtpPassword == nil {
-		s.SmtpPassword = NewString("")
+
+	if s.BatchSize == nil {
+		s.BatchSize = NewInt(COLD_STORAGE_SETTINGS_DEFAULT_BATCH_SIZE)
 	}
-	if s.EmailAddress == nil {
-		s.EmailAddress = NewString("")
+
+	if s.JobStartTime == nil {
+		s.JobStartTime = NewString(COLD_STORAGE_SETTINGS_DEFAULT_JOB_START_TIME)
 	}
-}
 
-type MessageExportSettings struct {
-	EnableExport        *bool
-	ExportFormat        *string
-	DailyRunTime        *string
-	ExportFromTimestamp *int64
-	BatchSize           *int
+	if s.DriverName == nil {
+		s.DriverName = NewString(IMAGE_DRIVER_LOCAL)
+	}
 
-	// formatter-specific settings - these are only expected to be non-nil if ExportFormat is set to the associated format
-	GlobalRelaySettings *GlobalRelayMessageExportSettings
-}
+	if s.Directory == nil {
+		s.Directory = NewString("")
+	}
 
-func (s *MessageExportSettings) SetDefaults() {
-	if s.EnableExport == nil {
-		s.EnableExport = NewBool(false)
+	if s.AmazonS3AccessKeyId == nil {
+		s.AmazonS3AccessKeyId = NewString("")
 	}
 
-	if s.ExportFormat == nil {
-		s.ExportFormat = NewString(COMPLIANCE_EXPORT_TYPE_ACTIANCE)
+	if s.AmazonS3SecretAccessKey == nil {
+		s.AmazonS3SecretAccessKey = NewString("")
 	}
 
-	if s.DailyRunTime == nil {
-		s.DailyRunTime = NewString("01:00")
+	if s.AmazonS3Bucket == nil {
+		s.AmazonS3Bucket = NewString("")
 	}
 
-	if s.ExportFromTimestamp == nil {
-		s.ExportFromTimestamp = NewInt64(0)
+	if s.AmazonS3Region == nil {
+		s.AmazonS3Region = NewString("")
 	}
 
-	if s.BatchSize == nil {
-		s.BatchSize = NewInt(10000)
+	if s.AmazonS3Endpoint == nil {
+		s.AmazonS3Endpoint = NewString("s3.amazonaws.com")
 	}
 
-	if s.GlobalRelaySettings == nil {
-		s.GlobalRelaySettings = &GlobalRelayMessageExportSettings{}
+	if s.AmazonS3SSL == nil {
+		s.AmazonS3SSL = NewBool(true)
 	}
-	s.GlobalRelaySettings.SetDefaults()
 }
 
-type DisplaySettings struct {
-	CustomUrlSchemes     []string
-	ExperimentalTimezone *bool
+// ToFileSettings returns the FileSettings used to connect to cold storage. Anything that isn't configured separately
+// for it, such as S3 encryption, is the same as for the main file store.
+func (s *ColdStorageSettings) ToFileSettings(fs FileSettings) *FileSettings {
+	fs.DriverName = NewString(*s.DriverName)
+	fs.Directory = *s.Directory
+	fs.AmazonS3AccessKeyId = *s.AmazonS3AccessKeyId
+	fs.AmazonS3SecretAccessKey = *s.AmazonS3SecretAccessKey
+	fs.AmazonS3Bucket = *s.AmazonS3Bucket
+	fs.AmazonS3Region = *s.AmazonS3Region
+	fs.AmazonS3Endpoint = *s.AmazonS3Endpoint
+	fs.AmazonS3SSL = NewBool(*s.AmazonS3SSL)
+	return &fs
 }
 
-func (s *DisplaySettings) SetDefaults() {
-	if s.CustomUrlSchemes == nil {
-		customUrlSchemes := []string{}
-		s.CustomUrlSchemes = customUrlSchemes
-	}
+// FirehoseSettings configure the event firehose, which streams domain events such as posts being created to an
+// HTTP endpoint, or to a destination like Kafka through a custom writer registered by a plugged in implementation.
+// Events that don't fit in the buffer, or that haven't been delivered when the server stops, are spooled to files in
+// SpoolDirectory until they can be. If SpoolDirectory is empty, they're dropped instead.
+type FirehoseSettings struct {
+	Enable                    *bool
+	Destination               *string
+	HTTPEndpoint              *string
+	BufferSize                *int
+	BatchSize                 *int
+	FlushIntervalMilliseconds *int
+	SpoolDirectory            *string
+}
 
-	if s.ExperimentalTimezone == nil {
+func (s *FirehoseSettings) SetDefaults() {
+	if s.Enable == nil {
+		s.Enable = NewBool(false)
+	}
+
+	if s.Destination == nil {
+		s.Destination = NewString(FIREHOSE_DESTINATION_HTTP)
+	}
+
+	if s.HTTPEndpoint == nil {
+		s.HTTPEndpoint = NewString("")
+	}
+
+	if s.BufferSize == nil {
+		s.BufferSize = NewInt(FIREHOSE_SETTINGS_DEFAULT_BUFFER_SIZE)
+	}
+
+	if s.BatchSize == nil {
+		s.BatchSize = NewInt(FIREHOSE_SETTINGS_DEFAULT_BATCH_SIZE)
+	}
+
+	if s.FlushIntervalMilliseconds == nil {
+		s.FlushIntervalMilliseconds = NewInt(FIREHOSE_SETTINGS_DEFAULT_FLUSH_INTERVAL_MILLISECONDS)
+	}
+
+	if s.SpoolDirectory == nil {
+		s.SpoolDirectory = NewString(FIREHOSE_SETTINGS_DEFAULT_SPOOL_DIRECTORY)
+	}
+}
+
+// IntegrationHTTPClientSettings configure the timeouts and connection limits of the HTTP client used to make requests
+// to one type of integration. A MaxConnsPerHost of 0 means there's no limit. Once an endpoint has failed
+// CircuitBreakerFailureThreshold times in a row, requests to it fail straight away for CircuitBreakerCooldownSeconds.
+// A CircuitBreakerFailureThreshold of 0 disables this.
+type IntegrationHTTPClientSettings struct {
+	RequestTimeoutSeconds          *int
+	ConnectTimeoutSeconds          *int
+	MaxIdleConns                   *int
+	MaxIdleConnsPerHost            *int
+	MaxConnsPerHost                *int
+	IdleConnTimeoutSeconds         *int
+	CircuitBreakerFailureThreshold *int
+	CircuitBreakerCooldownSeconds  *int
+}
+
+func (s *IntegrationHTTPClientSettings) SetDefaults() {
+	if s.RequestTimeoutSeconds == nil {
+		s.RequestTimeoutSeconds = NewInt(INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_REQUEST_TIMEOUT_SECONDS)
+	}
+
+	if s.ConnectTimeoutSeconds == nil {
+		s.ConnectTimeoutSeconds = NewInt(INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_CONNECT_TIMEOUT_SECONDS)
+	}
+
+	if s.MaxIdleConns == nil {
+		s.MaxIdleConns = NewInt(INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_MAX_IDLE_CONNS)
+	}
+
+	if s.MaxIdleConnsPerHost == nil {
+		s.MaxIdleConnsPerHost = NewInt(INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_MAX_IDLE_CONNS_PER_HOST)
+	}
+
+	if s.MaxConnsPerHost == nil {
+		s.MaxConnsPerHost = NewInt(INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_MAX_CONNS_PER_HOST)
+	}
+
+	if s.IdleConnTimeoutSeconds == nil {
+		s.IdleConnTimeoutSeconds = NewInt(INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_IDLE_CONN_TIMEOUT_SECONDS)
+	}
+
+	if s.CircuitBreakerFailureThreshold == nil {
+		s.CircuitBreakerFailureThreshold = NewInt(INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_CIRCUIT_BREAKER_FAILURE_THRESHOLD)
+	}
+
+	if s.CircuitBreakerCooldownSeconds == nil {
+		s.CircuitBreakerCooldownSeconds = NewInt(INTEGRATION_HTTP_CLIENT_SETTINGS_DEFAULT_CIRCUIT_BREAKER_COOLDOWN_SECONDS)
+	}
+}
+
+// IntegrationHTTPSettings configure the HTTP clients used for slash commands, for outgoing webhooks and interactive
+// message actions, and for fetching link previews, so that a misbehaving service of one type can't use up the
+// connections of the others.
+type IntegrationHTTPSettings struct {
+	SlashCommands IntegrationHTTPClientSettings
+	Webhooks      IntegrationHTTPClientSettings
+	LinkPreviews  IntegrationHTTPClientSettings
+}
+
+func (s *IntegrationHTTPSettings) SetDefaults() {
+	s.SlashCommands.SetDefaults()
+	s.Webhooks.SetDefaults()
+	s.LinkPreviews.SetDefaults()
+}
+
+type JobSettings struct {
+	RunJobs                  *bool
+	RunScheduler             *bool
+	MaxConcurrentJobs        *int
+	MaxConcurrentJobsPerType map[string]int
+
+	// JobNodeAffinity maps job types to the cluster node tag that should run them. Nodes without the tag only run
+	// those jobs when no node with the tag is online.
+	JobNodeAffinity map[string]string
+}
+
+func (s *JobSettings) SetDefaults() {
+	if s.RunJobs == nil {
+		s.RunJobs = NewBool(true)
+	}
+
+	if s.RunScheduler == nil {
+		s.RunScheduler = NewBool(true)
+	}
+
+	if s.MaxConcurrentJobs == nil {
+		s.MaxConcurrentJobs = NewInt(0)
+	}
+
+	if s.MaxConcurrentJobsPerType == nil {
+		s.MaxConcurrentJobsPerType = map[string]int{}
+	}
+
+	if s.JobNodeAffinity == nil {
+		s.JobNodeAffinity = map[string]string{}
+	}
+}
+
+type PluginState struct {
+	Enable bool
+}
+
+type PluginSettings struct {
+	Enable          *bool
+	EnableUploads   *bool
+	Directory       *string
+	ClientDirectory *string
+	Plugins         map[string]map[string]interface{}
+	PluginStates    map[string]*PluginState
+}
+
+func (s *PluginSettings) SetDefaults() {
+	if s.Enable == nil {
+		s.Enable = NewBool(true)
+	}
+
+	if s.EnableUploads == nil {
+		s.EnableUploads = NewBool(false)
+	}
+
+	if s.Directory == nil {
+		s.Directory = NewString(PLUGIN_SETTINGS_DEFAULT_DIRECTORY)
+	}
+
+	if *s.Directory == "" {
+		*s.Directory = PLUGIN_SETTINGS_DEFAULT_DIRECTORY
+	}
+
+	if s.ClientDirectory == nil {
+		s.ClientDirectory = NewString(PLUGIN_SETTINGS_DEFAULT_CLIENT_DIRECTORY)
+	}
+
+	if *s.ClientDirectory == "" {
+		*s.ClientDirectory = PLUGIN_SETTINGS_DEFAULT_CLIENT_DIRECTORY
+	}
+
+	if s.Plugins == nil {
+		s.Plugins = make(map[string]map[string]interface{})
+	}
+
+	if s.PluginStates == nil {
+		s.PluginStates = make(map[string]*PluginState)
+	}
+}
+
+type GlobalRelayMessageExportSettings struct {
+	CustomerType *string // must be either A9 or A10, dictates SMTP server url
+	SmtpUsername *string
+	SmtpPassword *string
+	EmailAddress *string // the address to send messages to
+}
+
+func (s *GlobalRelayMessageExportSettings) SetDefaults() {
+	if s.CustomerType == nil {
+		s.CustomerType = NewString(GLOBALRELAY_CUSTOMER_TYPE_A9)
+	}
+	if s.SmtpUsername == nil {
+		s.SmtpUsername = NewString("")
+	}
+	if s.SmtpPassword == nil {
+		s.SmtpPassword = NewString("")
+	}
+	if s.EmailAddress == nil {
+		s.EmailAddress = NewString("")
+	}
+}
+
+type MessageExportSettings struct {
+	EnableExport        *bool
+	ExportFormat        *string
+	DailyRunTime        *string
+	ExportFromTimestamp *int64
+	BatchSize           *int
+
+	// formatter-specific settings - these are only expected to be non-nil if ExportFormat is set to the associated format
+	GlobalRelaySettings *GlobalRelayMessageExportSettings
+}
+
+func (s *MessageExportSettings) SetDefaults() {
+	if s.EnableExport == nil {
+		s.EnableExport = NewBool(false)
+	}
+
+	if s.ExportFormat == nil {
+		s.ExportFormat = NewString(COMPLIANCE_EXPORT_TYPE_ACTIANCE)
+	}
+
+	if s.DailyRunTime == nil {
+		s.DailyRunTime = NewString("01:00")
+	}
+
+	if s.ExportFromTimestamp == nil {
+		s.ExportFromTimestamp = NewInt64(0)
+	}
+
+	if s.BatchSize == nil {
+		s.BatchSize = NewInt(10000)
+	}
+
+	if s.GlobalRelaySettings == nil {
+		s.GlobalRelaySettings = &GlobalRelayMessageExportSettings{}
+	}
+	s.GlobalRelaySettings.SetDefaults()
+}
+
+type DisplaySettings struct {
+	CustomUrlSchemes     []string
+	ExperimentalTimezone *bool
+}
+
+func (s *DisplaySettings) SetDefaults() {
+	if s.CustomUrlSchemes == nil {
+		customUrlSchemes := []string{}
+		s.CustomUrlSchemes = customUrlSchemes
+	}
+
+	if s.ExperimentalTimezone == nil {
 		s.ExperimentalTimezone = NewBool(false)
 	}
 }
 
-type TimezoneSettings struct {
-	SupportedTimezonesPath *string
+type AutocompleteSettings struct {
+	ResultLimit        *int
+	RecencyWeight      *int
+	MembershipWeight   *int
+	AlphabeticalWeight *int
+}
+
+func (s *AutocompleteSettings) SetDefaults() {
+	if s.ResultLimit == nil {
+		s.ResultLimit = NewInt(AUTOCOMPLETE_SETTINGS_DEFAULT_RESULT_LIMIT)
+	}
+
+	if s.RecencyWeight == nil {
+		s.RecencyWeight = NewInt(AUTOCOMPLETE_SETTINGS_DEFAULT_RECENCY_WEIGHT)
+	}
+
+	if s.MembershipWeight == nil {
+		s.MembershipWeight = NewInt(AUTOCOMPLETE_SETTINGS_DEFAULT_MEMBERSHIP_WEIGHT)
+	}
+
+	if s.AlphabeticalWeight == nil {
+		s.AlphabeticalWeight = NewInt(AUTOCOMPLETE_SETTINGS_DEFAULT_ALPHABETICAL_WEIGHT)
+	}
+}
+
+type TimezoneSettings struct {
+	SupportedTimezonesPath *string
+}
+
+func (s *TimezoneSettings) SetDefaults() {
+	if s.SupportedTimezonesPath == nil {
+		s.SupportedTimezonesPath = NewString(TIMEZONE_SETTINGS_DEFAULT_SUPPORTED_TIMEZONES_PATH)
+	}
+}
+
+type ImageProxySettings struct {
+	Enable                  *bool
+	ImageProxyType          *string
+	RemoteImageProxyURL     *string
+	RemoteImageProxyOptions *string
+}
+
+// CorsSettings is the policy for cross-origin requests made to the API. CORS is disabled unless at least one origin
+// is allowed. An allowed origin is either "*" or a scheme and host, such as https://example.com, which may contain a
+// single wildcard to match subdomains, such as https://*.example.com. Credentials are only allowed for origins that
+// are listed exactly, even when AllowCredentials is set.
+type CorsSettings struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials *bool
+	MaxAgeSeconds    *int
+	Debug            *bool
+}
+
+// SetDefaults migrates the deprecated CORS fields of ServiceSettings into any settings that haven't been set yet, and
+// then resets them, so that CorsSettings is the only place that the policy is configured once the config is saved.
+func (s *CorsSettings) SetDefaults(ss *ServiceSettings) {
+	if s.AllowedOrigins == nil {
+		if ss.DEPRECATED_DO_NOT_USE_AllowCorsFrom == nil {
+			s.AllowedOrigins = []string{}
+		} else {
+			s.AllowedOrigins = strings.Fields(*ss.DEPRECATED_DO_NOT_USE_AllowCorsFrom)
+		}
+	}
+
+	if s.AllowedMethods == nil {
+		s.AllowedMethods = []string{"POST", "GET", "OPTIONS", "PUT", "PATCH", "DELETE"}
+	}
+
+	if s.AllowedHeaders == nil {
+		s.AllowedHeaders = []string{"*"}
+	}
+
+	if s.ExposedHeaders == nil {
+		if ss.DEPRECATED_DO_NOT_USE_CorsExposedHeaders == nil {
+			s.ExposedHeaders = []string{}
+		} else {
+			s.ExposedHeaders = strings.Fields(*ss.DEPRECATED_DO_NOT_USE_CorsExposedHeaders)
+		}
+	}
+
+	if s.AllowCredentials == nil {
+		if ss.DEPRECATED_DO_NOT_USE_CorsAllowCredentials == nil {
+			s.AllowCredentials = NewBool(false)
+		} else {
+			s.AllowCredentials = NewBool(*ss.DEPRECATED_DO_NOT_USE_CorsAllowCredentials)
+		}
+	}
+
+	if s.MaxAgeSeconds == nil {
+		s.MaxAgeSeconds = NewInt(CORS_SETTINGS_DEFAULT_MAX_AGE_SECONDS)
+	}
+
+	if s.Debug == nil {
+		if ss.DEPRECATED_DO_NOT_USE_CorsDebug == nil {
+			s.Debug = NewBool(false)
+		} else {
+			s.Debug = NewBool(*ss.DEPRECATED_DO_NOT_USE_CorsDebug)
+		}
+	}
+
+	ss.DEPRECATED_DO_NOT_USE_AllowCorsFrom = NewString(SERVICE_SETTINGS_DEFAULT_ALLOW_CORS_FROM)
+	ss.DEPRECATED_DO_NOT_USE_CorsExposedHeaders = NewString("")
+	ss.DEPRECATED_DO_NOT_USE_CorsAllowCredentials = NewBool(false)
+	ss.DEPRECATED_DO_NOT_USE_CorsDebug = NewBool(false)
+}
+
+// TraceSettings configure distributed tracing of requests. Spans are sent to an OpenTelemetry collector using OTLP over
+// HTTP, with SamplingRatio being the fraction of requests that are traced when they aren't already part of a trace.
+type TraceSettings struct {
+	Enable        *bool
+	OTLPEndpoint  *string
+	SamplingRatio *float64
+}
+
+func (s *TraceSettings) SetDefaults() {
+	if s.Enable == nil {
+		s.Enable = NewBool(false)
+	}
+
+	if s.OTLPEndpoint == nil {
+		s.OTLPEndpoint = NewString("")
+	}
+
+	if s.SamplingRatio == nil {
+		s.SamplingRatio = NewFloat64(TRACE_SETTINGS_DEFAULT_SAMPLING_RATIO)
+	}
+}
+
+// NotificationDefaultSettings are the notification settings that new accounts start with, in place of the built-in
+// defaults, keyed by the notify prop they set. Those listed in EnforcedNotifyProps can't be changed by users.
+type NotificationDefaultSettings struct {
+	NotifyProps         map[string]string
+	EnforcedNotifyProps []string
+}
+
+func (s *NotificationDefaultSettings) SetDefaults() {
+	if s.NotifyProps == nil {
+		s.NotifyProps = map[string]string{}
+	}
+
+	if s.EnforcedNotifyProps == nil {
+		s.EnforcedNotifyProps = []string{}
+	}
+}
+
+// IsEnforced returns whether users are prevented from changing the given notify prop from its default.
+func (s *NotificationDefaultSettings) IsEnforced(prop string) bool {
+	for _, enforced := range s.EnforcedNotifyProps {
+		if enforced == prop {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EnforceNotifyProps sets the enforced notify props of a user to their defaults.
+func (s *NotificationDefaultSettings) EnforceNotifyProps(props StringMap) {
+	for _, prop := range s.EnforcedNotifyProps {
+		props[prop] = s.NotifyProps[prop]
+	}
+}
+
+// EnforceChannelNotifyProps resets the notify props of a channel member that override enforced ones of the user, so
+// that the user's enforced settings apply in the channel too.
+func (s *NotificationDefaultSettings) EnforceChannelNotifyProps(props StringMap) {
+	for _, prop := range s.EnforcedNotifyProps {
+		if _, ok := props[prop]; ok && IsChannelNotifyPropOverride(prop) {
+			props[prop] = CHANNEL_NOTIFY_DEFAULT
+		}
+	}
+}
+
+// IsChannelNotifyPropOverride returns whether a channel member notify prop overrides the user's notify prop of the same
+// name unless it's set to CHANNEL_NOTIFY_DEFAULT.
+func IsChannelNotifyPropOverride(prop string) bool {
+	return prop == DESKTOP_NOTIFY_PROP || prop == EMAIL_NOTIFY_PROP || prop == PUSH_NOTIFY_PROP
+}
+
+// DataLossPreventionPattern is an admin-managed regular expression that flags sensitive content in messages. When
+// Checksum is set, only matches that also pass that check are flagged.
+type DataLossPreventionPattern struct {
+	Name     string
+	Pattern  string
+	Checksum string
+}
+
+// DataLossPreventionSettings control the warnings users get about sensitive content, such as credit card numbers,
+// in the messages they're composing. With BlockMatchingPosts set, such messages can't be posted at all.
+type DataLossPreventionSettings struct {
+	Enable             *bool
+	BlockMatchingPosts *bool
+	Patterns           []DataLossPreventionPattern
+}
+
+func (s *DataLossPreventionSettings) SetDefaults() {
+	if s.Enable == nil {
+		s.Enable = NewBool(false)
+	}
+
+	if s.BlockMatchingPosts == nil {
+		s.BlockMatchingPosts = NewBool(false)
+	}
+
+	if s.Patterns == nil {
+		s.Patterns = []DataLossPreventionPattern{
+			{Name: "Credit card number", Pattern: DATA_LOSS_PREVENTION_CREDIT_CARD_PATTERN, Checksum: DATA_LOSS_PREVENTION_CHECKSUM_LUHN},
+			{Name: "Social Security number", Pattern: DATA_LOSS_PREVENTION_SSN_PATTERN},
+		}
+	}
+}
+
+// LinkRewriteSettings clean up the links in posts as they're made. The query parameters named in StripQueryParams, such
+// as those used for tracking, are removed from links, where a trailing * matches any parameter with that prefix. Links
+// to the URL shorteners in ExpandShortenerHosts are replaced by where they redirect to, when that can be found through
+// the link preview client within ExpandTimeoutMilliseconds. Links that can't be parsed or reached are left as they are.
+type LinkRewriteSettings struct {
+	Enable                    *bool
+	StripQueryParams          []string
+	ExpandShortenerHosts      []string
+	ExpandTimeoutMilliseconds *int
+}
+
+func (s *LinkRewriteSettings) SetDefaults() {
+	if s.Enable == nil {
+		s.Enable = NewBool(false)
+	}
+
+	if s.StripQueryParams == nil {
+		s.StripQueryParams = []string{"utm_*", "fbclid", "gclid", "mc_eid"}
+	}
+
+	if s.ExpandShortenerHosts == nil {
+		s.ExpandShortenerHosts = []string{}
+	}
+
+	if s.ExpandTimeoutMilliseconds == nil {
+		s.ExpandTimeoutMilliseconds = NewInt(LINK_REWRITE_SETTINGS_DEFAULT_EXPAND_TIMEOUT_MILLISECONDS)
+	}
+}
+
+// SearchRateLimitSettings limit how often post, file, user and channel searches can be run, separately from the general
+// RateLimitSettings, since each search is far more expensive than an ordinary request. A user may run PerUserPerMinute
+// searches a minute, and the server as a whole GlobalPerMinute, each allowing short bursts up to the given size. The
+// limits are kept in memory by each server, so in a cluster they apply to every node on its own.
+type SearchRateLimitSettings struct {
+	Enable           *bool
+	PerUserPerMinute *int
+	PerUserMaxBurst  *int
+	GlobalPerMinute  *int
+	GlobalMaxBurst   *int
+}
+
+func (s *SearchRateLimitSettings) SetDefaults() {
+	if s.Enable == nil {
+		s.Enable = NewBool(false)
+	}
+
+	if s.PerUserPerMinute == nil {
+		s.PerUserPerMinute = NewInt(30)
+	}
+
+	if s.PerUserMaxBurst == nil {
+		s.PerUserMaxBurst = NewInt(10)
+	}
+
+	if s.GlobalPerMinute == nil {
+		s.GlobalPerMinute = NewInt(1200)
+	}
+
+	if s.GlobalMaxBurst == nil {
+		s.GlobalMaxBurst = NewInt(100)
+	}
+}
+
+// FeatureFlagSettings turn on features that are still being rolled out, such as endpoints that are registered behind a
+// flag and respond as if they don't exist until it's enabled. Flags are enabled by setting them to true in Flags, and
+// changing them takes effect straight away.
+type FeatureFlagSettings struct {
+	Flags map[string]bool
 }
 
-func (s *TimezoneSettings) SetDefaults() {
-	if s.SupportedTimezonesPath == nil {
-		s.SupportedTimezonesPath = NewString(TIMEZONE_SETTINGS_DEFAULT_SUPPORTED_TIMEZONES_PATH)
+func (s *FeatureFlagSettings) SetDefaults() {
+	if s.Flags == nil {
+		s.Flags = map[string]bool{}
 	}
 }
 
-type ImageProxySettings struct {
-	Enable                  *bool
-	ImageProxyType          *string
-	RemoteImageProxyURL     *string
-	RemoteImageProxyOptions *string
+// IsEnabled returns whether a feature flag has been enabled. Flags that aren't set are disabled.
+func (s *FeatureFlagSettings) IsEnabled(flag string) bool {
+	return s.Flags[flag]
 }
 
 func (ips *ImageProxySettings) SetDefaults(ss ServiceSettings) {
@@ -1995,39 +2983,53 @@ func (ips *ImageProxySettings) SetDefaults(ss ServiceSettings) {
 type ConfigFunc func() *Config
 
 type Config struct {
-	ServiceSettings       ServiceSettings
-	TeamSettings          TeamSettings
-	ClientRequirements    ClientRequirements
-	SqlSettings           SqlSettings
-	LogSettings           LogSettings
-	PasswordSettings      PasswordSettings
-	FileSettings          FileSettings
-	EmailSettings         EmailSettings
-	RateLimitSettings     RateLimitSettings
-	PrivacySettings       PrivacySettings
-	SupportSettings       SupportSettings
-	AnnouncementSettings  AnnouncementSettings
-	ThemeSettings         ThemeSettings
-	GitLabSettings        SSOSettings
-	GoogleSettings        SSOSettings
-	Office365Settings     SSOSettings
-	LdapSettings          LdapSettings
-	ComplianceSettings    ComplianceSettings
-	LocalizationSettings  LocalizationSettings
-	SamlSettings          SamlSettings
-	NativeAppSettings     NativeAppSettings
-	ClusterSettings       ClusterSettings
-	MetricsSettings       MetricsSettings
-	ExperimentalSettings  ExperimentalSettings
-	AnalyticsSettings     AnalyticsSettings
-	ElasticsearchSettings ElasticsearchSettings
-	DataRetentionSettings DataRetentionSettings
-	MessageExportSettings MessageExportSettings
-	JobSettings           JobSettings
-	PluginSettings        PluginSettings
-	DisplaySettings       DisplaySettings
-	TimezoneSettings      TimezoneSettings
-	ImageProxySettings    ImageProxySettings
+	ServiceSettings         ServiceSettings
+	TeamSettings            TeamSettings
+	ClientRequirements      ClientRequirements
+	SqlSettings             SqlSettings
+	LogSettings             LogSettings
+	PasswordSettings        PasswordSettings
+	FileSettings            FileSettings
+	EmailSettings           EmailSettings
+	RateLimitSettings       RateLimitSettings
+	PrivacySettings         PrivacySettings
+	SupportSettings         SupportSettings
+	AnnouncementSettings    AnnouncementSettings
+	ThemeSettings           ThemeSettings
+	GitLabSettings          SSOSettings
+	GoogleSettings          SSOSettings
+	Office365Settings       SSOSettings
+	LdapSettings            LdapSettings
+	ComplianceSettings      ComplianceSettings
+	LocalizationSettings    LocalizationSettings
+	SamlSettings            SamlSettings
+	NativeAppSettings       NativeAppSettings
+	ClusterSettings         ClusterSettings
+	MetricsSettings         MetricsSettings
+	ExperimentalSettings    ExperimentalSettings
+	AnalyticsSettings       AnalyticsSettings
+	ElasticsearchSettings   ElasticsearchSettings
+	DataRetentionSettings   DataRetentionSettings
+	InactiveUserSettings    InactiveUserSettings
+	InactiveChannelSettings InactiveChannelSettings
+	ColdStorageSettings     ColdStorageSettings
+	FirehoseSettings        FirehoseSettings
+	IntegrationHTTPSettings IntegrationHTTPSettings
+	MessageExportSettings   MessageExportSettings
+	JobSettings             JobSettings
+	PluginSettings          PluginSettings
+	DisplaySettings         DisplaySettings
+	AutocompleteSettings    AutocompleteSettings
+	TimezoneSettings        TimezoneSettings
+	ImageProxySettings      ImageProxySettings
+	LinkRewriteSettings     LinkRewriteSettings
+	CorsSettings            CorsSettings
+	TraceSettings           TraceSettings
+
+	NotificationDefaultSettings NotificationDefaultSettings
+	DataLossPreventionSettings  DataLossPreventionSettings
+	SearchRateLimitSettings     SearchRateLimitSettings
+	FeatureFlagSettings         FeatureFlagSettings
 }
 
 func (o *Config) Clone() *Config {
@@ -2093,13 +3095,26 @@ func (o *Config) SetDefaults() {
 	o.ElasticsearchSettings.SetDefaults()
 	o.NativeAppSettings.SetDefaults()
 	o.DataRetentionSettings.SetDefaults()
+	o.InactiveUserSettings.SetDefaults()
+	o.InactiveChannelSettings.SetDefaults()
+	o.ColdStorageSettings.SetDefaults()
+	o.FirehoseSettings.SetDefaults()
+	o.IntegrationHTTPSettings.SetDefaults()
 	o.RateLimitSettings.SetDefaults()
 	o.LogSettings.SetDefaults()
 	o.JobSettings.SetDefaults()
 	o.MessageExportSettings.SetDefaults()
 	o.TimezoneSettings.SetDefaults()
 	o.DisplaySettings.SetDefaults()
+	o.AutocompleteSettings.SetDefaults()
 	o.ImageProxySettings.SetDefaults(o.ServiceSettings)
+	o.LinkRewriteSettings.SetDefaults()
+	o.CorsSettings.SetDefaults(&o.ServiceSettings)
+	o.TraceSettings.SetDefaults()
+	o.NotificationDefaultSettings.SetDefaults()
+	o.DataLossPreventionSettings.SetDefaults()
+	o.SearchRateLimitSettings.SetDefaults()
+	o.FeatureFlagSettings.SetDefaults()
 }
 
 func (o *Config) IsValid() *AppError {
@@ -2159,10 +3174,38 @@ func (o *Config) IsValid() *AppError {
 		return err
 	}
 
+	if err := o.InactiveUserSettings.isValid(); err != nil {
+		return err
+	}
+
+	if err := o.InactiveChannelSettings.isValid(); err != nil {
+		return err
+	}
+
+	if err := o.ColdStorageSettings.isValid(o.FileSettings); err != nil {
+		return err
+	}
+
+	if err := o.FirehoseSettings.isValid(); err != nil {
+		return err
+	}
+
+	if err := o.IntegrationHTTPSettings.isValid(); err != nil {
+		return err
+	}
+
 	if err := o.LocalizationSettings.isValid(); err != nil {
 		return err
 	}
 
+	if err := o.JobSettings.isValid(); err != nil {
+		return err
+	}
+
+	if err := o.ClusterSettings.isValid(); err != nil {
+		return err
+	}
+
 	if err := o.MessageExportSettings.isValid(o.FileSettings); err != nil {
 		return err
 	}
@@ -2171,10 +3214,38 @@ func (o *Config) IsValid() *AppError {
 		return err
 	}
 
+	if err := o.AutocompleteSettings.isValid(); err != nil {
+		return err
+	}
+
 	if err := o.ImageProxySettings.isValid(); err != nil {
 		return err
 	}
 
+	if err := o.LinkRewriteSettings.isValid(); err != nil {
+		return err
+	}
+
+	if err := o.CorsSettings.isValid(); err != nil {
+		return err
+	}
+
+	if err := o.TraceSettings.isValid(); err != nil {
+		return err
+	}
+
+	if err := o.NotificationDefaultSettings.isValid(); err != nil {
+		return err
+	}
+
+	if err := o.DataLossPreventionSettings.isValid(); err != nil {
+		return err
+	}
+
+	if err := o.SearchRateLimitSettings.isValid(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -2251,9 +3322,132 @@ func (fs *FileSettings) isValid() *AppError {
 		return NewAppError("Config.IsValid", "model.config.is_valid.file_salt.app_error", nil, "", http.StatusBadRequest)
 	}
 
+	for _, contentType := range fs.InlineContentTypes {
+		if mediaType, params, err := mime.ParseMediaType(contentType); err != nil || len(params) > 0 || mediaType != contentType || !strings.Contains(mediaType, "/") {
+			return NewAppError("Config.IsValid", "model.config.is_valid.inline_content_types.app_error", map[string]interface{}{"ContentType": contentType}, "", http.StatusBadRequest)
+		}
+	}
+
+	for _, fileTypes := range [][]string{fs.AllowedUploadFileTypes, fs.DeniedUploadFileTypes, fs.VerifyChecksumFileTypes} {
+		for _, fileType := range fileTypes {
+			if !isValidUploadFileType(fileType) {
+				return NewAppError("Config.IsValid", "model.config.is_valid.upload_file_type.app_error", map[string]interface{}{"FileType": fileType}, "", http.StatusBadRequest)
+			}
+		}
+	}
+
+	for fileType, limit := range fs.MaxFileSizeByType {
+		if !isValidUploadFileType(fileType) {
+			return NewAppError("Config.IsValid", "model.config.is_valid.upload_file_type.app_error", map[string]interface{}{"FileType": fileType}, "", http.StatusBadRequest)
+		}
+
+		if limit <= 0 {
+			return NewAppError("Config.IsValid", "model.config.is_valid.max_file_size_by_type.app_error", map[string]interface{}{"FileType": fileType}, "", http.StatusBadRequest)
+		}
+	}
+
 	return nil
 }
 
+func isValidUploadFileType(fileType string) bool {
+	if strings.HasPrefix(fileType, ".") {
+		return len(fileType) > 1 && fileType == strings.ToLower(fileType) && !strings.ContainsAny(fileType, "/\\ \t")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(fileType)
+	return err == nil && len(params) == 0 && mediaType == fileType && strings.Contains(mediaType, "/")
+}
+
+// matchesUploadFileType returns whether a file with the given name and detected content type is of an upload file type.
+func matchesUploadFileType(fileType, name, contentType string) bool {
+	if strings.HasPrefix(fileType, ".") {
+		return strings.ToLower(filepath.Ext(name)) == fileType
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	if strings.HasSuffix(fileType, "/*") {
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(fileType, "*"))
+	}
+
+	return mediaType == fileType
+}
+
+// GetUploadFileTypeViolation returns the entry of DeniedUploadFileTypes that forbids uploading a file with the given
+// name and detected content type, or "*" if AllowedUploadFileTypes is set and none of its entries match the file. An
+// empty string is returned if the file may be uploaded.
+func (fs *FileSettings) GetUploadFileTypeViolation(name, contentType string) string {
+	for _, fileType := range fs.DeniedUploadFileTypes {
+		if matchesUploadFileType(fileType, name, contentType) {
+			return fileType
+		}
+	}
+
+	if len(fs.AllowedUploadFileTypes) == 0 {
+		return ""
+	}
+
+	for _, fileType := range fs.AllowedUploadFileTypes {
+		if matchesUploadFileType(fileType, name, contentType) {
+			return ""
+		}
+	}
+
+	return "*"
+}
+
+// ShouldVerifyChecksum returns whether a file with the given name and content type matches VerifyChecksumFileTypes.
+func (fs *FileSettings) ShouldVerifyChecksum(name, contentType string) bool {
+	for _, fileType := range fs.VerifyChecksumFileTypes {
+		if matchesUploadFileType(fileType, name, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMaxUploadFileSize returns the largest file with the given name and detected content type that may be uploaded,
+// along with the entry of MaxFileSizeByType that set it. If no entry sets a smaller limit than MaxFileSize, the entry
+// is empty.
+func (fs *FileSettings) GetMaxUploadFileSize(name, contentType string) (int64, string) {
+	limit := *fs.MaxFileSize
+	limitFileType := ""
+
+	for fileType, fileTypeLimit := range fs.MaxFileSizeByType {
+		if !matchesUploadFileType(fileType, name, contentType) {
+			continue
+		}
+
+		// Ties are broken by name so that the same entry is always reported
+		if fileTypeLimit < limit || (fileTypeLimit == limit && limitFileType != "" && fileType < limitFileType) {
+			limit = fileTypeLimit
+			limitFileType = fileType
+		}
+	}
+
+	return limit, limitFileType
+}
+
+// IsInlineContentType returns whether files of the given content type may be displayed by browsers, rather than only
+// downloaded.
+func (fs *FileSettings) IsInlineContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, inlineContentType := range fs.InlineContentTypes {
+		if mediaType == inlineContentType {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (es *EmailSettings) isValid() *AppError {
 	if !(es.ConnectionSecurity == CONN_SECURITY_NONE || es.ConnectionSecurity == CONN_SECURITY_TLS || es.ConnectionSecurity == CONN_SECURITY_STARTTLS || es.ConnectionSecurity == CONN_SECURITY_PLAIN) {
 		return NewAppError("Config.IsValid", "model.config.is_valid.email_security.app_error", nil, "", http.StatusBadRequest)
@@ -2364,93 +3558,310 @@ func (ss *SamlSettings) isValid() *AppError {
 			}
 		}
 
-		if *ss.Encrypt {
-			if len(*ss.PrivateKeyFile) == 0 {
-				return NewAppError("Config.IsValid", "model.config.is_valid.saml_private_key.app_error", nil, "", http.StatusBadRequest)
-			}
+		if *ss.Encrypt {
+			if len(*ss.PrivateKeyFile) == 0 {
+				return NewAppError("Config.IsValid", "model.config.is_valid.saml_private_key.app_error", nil, "", http.StatusBadRequest)
+			}
+
+			if len(*ss.PublicCertificateFile) == 0 {
+				return NewAppError("Config.IsValid", "model.config.is_valid.saml_public_cert.app_error", nil, "", http.StatusBadRequest)
+			}
+		}
+
+		if len(*ss.EmailAttribute) == 0 {
+			return NewAppError("Config.IsValid", "model.config.is_valid.saml_email_attribute.app_error", nil, "", http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+func isValidListenAddress(address string) bool {
+	host, port, _ := net.SplitHostPort(address)
+	var isValidHost bool
+	if host == "" {
+		isValidHost = true
+	} else {
+		isValidHost = (net.ParseIP(host) != nil) || IsDomainName(host)
+	}
+	portInt, err := strconv.Atoi(port)
+	return err == nil && isValidHost && portInt >= 0 && portInt <= math.MaxUint16
+}
+
+// isValidErrorPagePath checks that the error page is a path on the site, since the error redirect must not be able to
+// send users to another host. The query string of the page is built from the error, so the path can't have one.
+func isValidErrorPagePath(errorPagePath string) bool {
+	if !strings.HasPrefix(errorPagePath, "/") || strings.HasPrefix(errorPagePath, "//") || strings.ContainsAny(errorPagePath, "?#\\") {
+		return false
+	}
+
+	u, err := url.Parse(errorPagePath)
+	return err == nil && u.Scheme == "" && u.Host == "" && u.Path == errorPagePath
+}
+
+// isValidAnalyticsCdnHost checks that the analytics host is a host, optionally with a scheme and port, since it's
+// written into the Content-Security-Policy header as a source.
+func isValidAnalyticsCdnHost(host string) bool {
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+
+	u, err := url.Parse(host)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Hostname() != "" && u.Path == "" && u.RawQuery == "" && u.Fragment == "" && u.User == nil && !strings.ContainsAny(host, " ;,'\"")
+}
+
+// isValidSessionCookie checks the attributes of session cookies against each other and the site URL.
+func (ss *ServiceSettings) isValidSessionCookie() *AppError {
+	switch *ss.SessionCookieSameSite {
+	case SESSION_COOKIE_SAME_SITE_UNSET, SESSION_COOKIE_SAME_SITE_STRICT, SESSION_COOKIE_SAME_SITE_LAX, SESSION_COOKIE_SAME_SITE_NONE:
+	default:
+		return NewAppError("Config.IsValid", "model.config.is_valid.session_cookie_same_site.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.SessionCookieSecure != SESSION_COOKIE_SECURE_AUTO && *ss.SessionCookieSecure != SESSION_COOKIE_SECURE_ALWAYS {
+		return NewAppError("Config.IsValid", "model.config.is_valid.session_cookie_secure.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	// SameSite=None always makes session cookies secure
+	if *ss.SessionCookieSameSite == SESSION_COOKIE_SAME_SITE_NONE || *ss.SessionCookieSecure == SESSION_COOKIE_SECURE_ALWAYS {
+		if siteURL, err := url.Parse(*ss.SiteURL); err == nil && siteURL.Scheme == "http" {
+			return NewAppError("Config.IsValid", "model.config.is_valid.session_cookie_insecure_site_url.app_error", nil, "", http.StatusBadRequest)
+		}
+	}
+
+	if domain := strings.TrimPrefix(*ss.SessionCookieDomain, "."); domain != "" {
+		if strings.ContainsAny(domain, ":/ ") {
+			return NewAppError("Config.IsValid", "model.config.is_valid.session_cookie_domain.app_error", nil, "", http.StatusBadRequest)
+		}
+
+		if siteURL, err := url.Parse(*ss.SiteURL); err == nil && siteURL.Hostname() != "" {
+			host := strings.ToLower(siteURL.Hostname())
+			domain = strings.ToLower(domain)
+			if host != domain && !strings.HasSuffix(host, "."+domain) {
+				return NewAppError("Config.IsValid", "model.config.is_valid.session_cookie_domain.app_error", nil, "", http.StatusBadRequest)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (ss *ServiceSettings) isValid() *AppError {
+	if !(*ss.ConnectionSecurity == CONN_SECURITY_NONE || *ss.ConnectionSecurity == CONN_SECURITY_TLS) {
+		return NewAppError("Config.IsValid", "model.config.is_valid.webserver_security.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.ConnectionSecurity == CONN_SECURITY_TLS && *ss.UseLetsEncrypt == false {
+		appErr := NewAppError("Config.IsValid", "model.config.is_valid.tls_cert_file.app_error", nil, "", http.StatusBadRequest)
+
+		if *ss.TLSCertFile == "" {
+			return appErr
+		} else if _, err := os.Stat(*ss.TLSCertFile); os.IsNotExist(err) {
+			return appErr
+		}
+
+		appErr = NewAppError("Config.IsValid", "model.config.is_valid.tls_key_file.app_error", nil, "", http.StatusBadRequest)
+
+		if *ss.TLSKeyFile == "" {
+			return appErr
+		} else if _, err := os.Stat(*ss.TLSKeyFile); os.IsNotExist(err) {
+			return appErr
+		}
+	}
+
+	if len(ss.TLSOverwriteCiphers) > 0 {
+		for _, cipher := range ss.TLSOverwriteCiphers {
+			if _, ok := ServerTLSSupportedCiphers[cipher]; !ok {
+				return NewAppError("Config.IsValid", "model.config.is_valid.tls_overwrite_cipher.app_error", map[string]interface{}{"name": cipher}, "", http.StatusBadRequest)
+			}
+		}
+	}
+
+	if *ss.ReadTimeout <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.read_timeout.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.WriteTimeout <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.write_timeout.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.RequestTimeout < 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.request_timeout.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.ResponseCompressionMinSize < 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.response_compression_min_size.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.TimeBetweenUserTypingUpdatesMilliseconds < 1000 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.time_between_user_typing.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.MaximumLoginAttempts <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.login_attempts.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.SearchExportMaxResults <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.search_export_max_results.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.SearchDefaultLookbackDays < 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.search_default_lookback_days.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.CachePreloadingTimeoutSeconds <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.cache_preloading_timeout.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.CachePreloadingMaxUsers <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.cache_preloading_max_users.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	for _, endpoint := range ss.ResponseCacheEndpoints {
+		if !IsValidResponseCacheEndpoint(endpoint) {
+			return NewAppError("Config.IsValid", "model.config.is_valid.response_cache_endpoints.app_error", map[string]interface{}{"Endpoint": endpoint}, "", http.StatusBadRequest)
+		}
+	}
+
+	if *ss.ResponseCacheMaxAgeSeconds < 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.response_cache_max_age.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.MaxBatchRequests <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.max_batch_requests.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(*ss.SiteURL) != 0 {
+		if _, err := url.ParseRequestURI(*ss.SiteURL); err != nil {
+			return NewAppError("Config.IsValid", "model.config.is_valid.site_url.app_error", nil, "", http.StatusBadRequest)
+		}
+	}
+
+	if err := ss.isValidSessionCookie(); err != nil {
+		return err
+	}
+
+	if len(*ss.WebsocketURL) != 0 {
+		if _, err := url.ParseRequestURI(*ss.WebsocketURL); err != nil {
+			return NewAppError("Config.IsValid", "model.config.is_valid.websocket_url.app_error", nil, "", http.StatusBadRequest)
+		}
+	}
+
+	for _, origin := range ss.WebsocketAllowedOrigins {
+		if !isValidCorsOrigin(origin) {
+			return NewAppError("Config.IsValid", "model.config.is_valid.websocket_allowed_origin.app_error", map[string]interface{}{"Origin": origin}, "", http.StatusBadRequest)
+		}
+	}
+
+	switch *ss.ClientCertAuthAttribute {
+	case CLIENT_CERT_AUTH_ATTRIBUTE_EMAIL, CLIENT_CERT_AUTH_ATTRIBUTE_CN, CLIENT_CERT_AUTH_ATTRIBUTE_SAN:
+	default:
+		return NewAppError("Config.IsValid", "model.config.is_valid.client_cert_auth_attribute.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	for roleName, timeLimit := range ss.PostEditTimeLimitsByRole {
+		if !IsValidRoleName(roleName) || timeLimit < 0 {
+			return NewAppError("Config.IsValid", "model.config.is_valid.post_edit_time_limits_by_role.app_error", map[string]interface{}{"Role": roleName}, "", http.StatusBadRequest)
+		}
+	}
+
+	if *ss.TrustedProxyIPHeader == "" {
+		return NewAppError("Config.IsValid", "model.config.is_valid.trusted_proxy_ip_header.app_error", nil, "", http.StatusBadRequest)
+	}
 
-			if len(*ss.PublicCertificateFile) == 0 {
-				return NewAppError("Config.IsValid", "model.config.is_valid.saml_public_cert.app_error", nil, "", http.StatusBadRequest)
-			}
+	for _, proxy := range ss.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return NewAppError("Config.IsValid", "model.config.is_valid.trusted_proxies.app_error", map[string]interface{}{"Proxy": proxy}, "", http.StatusBadRequest)
 		}
+	}
 
-		if len(*ss.EmailAttribute) == 0 {
-			return NewAppError("Config.IsValid", "model.config.is_valid.saml_email_attribute.app_error", nil, "", http.StatusBadRequest)
-		}
+	// Client certificates are only available when the server terminates TLS itself
+	if *ss.ClientCertAuth && (*ss.ConnectionSecurity != CONN_SECURITY_TLS || *ss.ClientCertAuthCAFile == "") {
+		return NewAppError("Config.IsValid", "model.config.is_valid.client_cert_auth.app_error", nil, "", http.StatusBadRequest)
 	}
 
-	return nil
-}
+	if !isValidListenAddress(*ss.ListenAddress) {
+		return NewAppError("Config.IsValid", "model.config.is_valid.listen_address.app_error", nil, "", http.StatusBadRequest)
+	}
 
-func (ss *ServiceSettings) isValid() *AppError {
-	if !(*ss.ConnectionSecurity == CONN_SECURITY_NONE || *ss.ConnectionSecurity == CONN_SECURITY_TLS) {
-		return NewAppError("Config.IsValid", "model.config.is_valid.webserver_security.app_error", nil, "", http.StatusBadRequest)
+	if len(*ss.GrpcListenAddress) != 0 && !isValidListenAddress(*ss.GrpcListenAddress) {
+		return NewAppError("Config.IsValid", "model.config.is_valid.grpc_listen_address.app_error", nil, "", http.StatusBadRequest)
 	}
 
-	if *ss.ConnectionSecurity == CONN_SECURITY_TLS && *ss.UseLetsEncrypt == false {
-		appErr := NewAppError("Config.IsValid", "model.config.is_valid.tls_cert_file.app_error", nil, "", http.StatusBadRequest)
+	if *ss.LoadSheddingGoroutineThreshold < 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.load_shedding_goroutine_threshold.app_error", nil, "", http.StatusBadRequest)
+	}
 
-		if *ss.TLSCertFile == "" {
-			return appErr
-		} else if _, err := os.Stat(*ss.TLSCertFile); os.IsNotExist(err) {
-			return appErr
-		}
+	if *ss.LoadSheddingMemoryThresholdMB < 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.load_shedding_memory_threshold.app_error", nil, "", http.StatusBadRequest)
+	}
 
-		appErr = NewAppError("Config.IsValid", "model.config.is_valid.tls_key_file.app_error", nil, "", http.StatusBadRequest)
+	if *ss.LoadSheddingRetryAfterSeconds <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.load_shedding_retry_after.app_error", nil, "", http.StatusBadRequest)
+	}
 
-		if *ss.TLSKeyFile == "" {
-			return appErr
-		} else if _, err := os.Stat(*ss.TLSKeyFile); os.IsNotExist(err) {
-			return appErr
-		}
+	if *ss.MaxConcurrentRequestsPerUser < 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.max_concurrent_requests_per_user.app_error", nil, "", http.StatusBadRequest)
 	}
 
-	if len(ss.TLSOverwriteCiphers) > 0 {
-		for _, cipher := range ss.TLSOverwriteCiphers {
-			if _, ok := ServerTLSSupportedCiphers[cipher]; !ok {
-				return NewAppError("Config.IsValid", "model.config.is_valid.tls_overwrite_cipher.app_error", map[string]interface{}{"name": cipher}, "", http.StatusBadRequest)
-			}
-		}
+	if *ss.WebsocketSendQueueSize <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.websocket_send_queue_size.app_error", nil, "", http.StatusBadRequest)
 	}
 
-	if *ss.ReadTimeout <= 0 {
-		return NewAppError("Config.IsValid", "model.config.is_valid.read_timeout.app_error", nil, "", http.StatusBadRequest)
+	if *ss.WebsocketSlowConsumerPolicy != WEBSOCKET_SLOW_CONSUMER_POLICY_DISCONNECT && *ss.WebsocketSlowConsumerPolicy != WEBSOCKET_SLOW_CONSUMER_POLICY_DROP_OLDEST {
+		return NewAppError("Config.IsValid", "model.config.is_valid.websocket_slow_consumer_policy.app_error", nil, "", http.StatusBadRequest)
 	}
 
-	if *ss.WriteTimeout <= 0 {
-		return NewAppError("Config.IsValid", "model.config.is_valid.write_timeout.app_error", nil, "", http.StatusBadRequest)
+	if *ss.WebsocketMaxDroppedEvents <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.websocket_max_dropped_events.app_error", nil, "", http.StatusBadRequest)
 	}
 
-	if *ss.TimeBetweenUserTypingUpdatesMilliseconds < 1000 {
-		return NewAppError("Config.IsValid", "model.config.is_valid.time_between_user_typing.app_error", nil, "", http.StatusBadRequest)
+	if *ss.MaximumPerPage <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.maximum_per_page.app_error", nil, "", http.StatusBadRequest)
 	}
 
-	if *ss.MaximumLoginAttempts <= 0 {
-		return NewAppError("Config.IsValid", "model.config.is_valid.login_attempts.app_error", nil, "", http.StatusBadRequest)
+	if !isValidErrorPagePath(*ss.ErrorPagePath) {
+		return NewAppError("Config.IsValid", "model.config.is_valid.error_page_path.app_error", nil, "", http.StatusBadRequest)
 	}
 
-	if len(*ss.SiteURL) != 0 {
-		if _, err := url.ParseRequestURI(*ss.SiteURL); err != nil {
-			return NewAppError("Config.IsValid", "model.config.is_valid.site_url.app_error", nil, "", http.StatusBadRequest)
-		}
+	switch *ss.ReferrerPolicy {
+	case "", "no-referrer", "no-referrer-when-downgrade", "origin", "origin-when-cross-origin", "same-origin", "strict-origin", "strict-origin-when-cross-origin", "unsafe-url":
+	default:
+		return NewAppError("Config.IsValid", "model.config.is_valid.referrer_policy.app_error", nil, "", http.StatusBadRequest)
 	}
 
-	if len(*ss.WebsocketURL) != 0 {
-		if _, err := url.ParseRequestURI(*ss.WebsocketURL); err != nil {
-			return NewAppError("Config.IsValid", "model.config.is_valid.websocket_url.app_error", nil, "", http.StatusBadRequest)
+	if *ss.FrameOptions != "" && *ss.FrameOptions != FRAME_OPTIONS_DENY && *ss.FrameOptions != FRAME_OPTIONS_SAME_ORIGIN {
+		return NewAppError("Config.IsValid", "model.config.is_valid.frame_options.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.AnalyticsCdnHost != "" && !isValidAnalyticsCdnHost(*ss.AnalyticsCdnHost) {
+		return NewAppError("Config.IsValid", "model.config.is_valid.analytics_cdn_host.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.CspScriptSourcesCacheSize <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.csp_script_sources_cache_size.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	for _, check := range ss.ReadinessChecks {
+		if check != READINESS_CHECK_DATABASE && check != READINESS_CHECK_SEARCH {
+			return NewAppError("Config.IsValid", "model.config.is_valid.readiness_checks.app_error", map[string]interface{}{"Check": check}, "", http.StatusBadRequest)
 		}
 	}
 
-	host, port, _ := net.SplitHostPort(*ss.ListenAddress)
-	var isValidHost bool
-	if host == "" {
-		isValidHost = true
-	} else {
-		isValidHost = (net.ParseIP(host) != nil) || IsDomainName(host)
+	if *ss.ReadinessCheckTimeoutMilliseconds <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.readiness_check_timeout.app_error", nil, "", http.StatusBadRequest)
 	}
-	portInt, err := strconv.Atoi(port)
-	if err != nil || !isValidHost || portInt < 0 || portInt > math.MaxUint16 {
-		return NewAppError("Config.IsValid", "model.config.is_valid.listen_address.app_error", nil, "", http.StatusBadRequest)
+
+	if *ss.SlowRequestThresholdMilliseconds < 0 || *ss.SlowUploadRequestThresholdMilliseconds < 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.slow_request_threshold.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.CsrfProtectionMode != CSRF_PROTECTION_MODE_HEADER && *ss.CsrfProtectionMode != CSRF_PROTECTION_MODE_DOUBLE_SUBMIT {
+		return NewAppError("Config.IsValid", "model.config.is_valid.csrf_protection_mode.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ss.CsrfTokenRotationMinutes < 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.csrf_token_rotation_minutes.app_error", nil, "", http.StatusBadRequest)
 	}
 
 	if *ss.ExperimentalGroupUnreadChannels != GROUP_UNREAD_CHANNELS_DISABLED &&
@@ -2512,6 +3923,194 @@ func (drs *DataRetentionSettings) isValid() *AppError {
 	return nil
 }
 
+func (ius *InactiveUserSettings) isValid() *AppError {
+	if *ius.InactiveDays <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.inactive_user.inactive_days.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ius.WarningDays < 0 || *ius.WarningDays >= *ius.InactiveDays {
+		return NewAppError("Config.IsValid", "model.config.is_valid.inactive_user.warning_days.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if _, err := time.Parse("15:04", *ius.JobStartTime); err != nil {
+		return NewAppError("Config.IsValid", "model.config.is_valid.inactive_user.job_start_time.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (ics *InactiveChannelSettings) isValid() *AppError {
+	if *ics.InactiveDays <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.inactive_channel.inactive_days.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *ics.WarningDays < 0 || *ics.WarningDays >= *ics.InactiveDays {
+		return NewAppError("Config.IsValid", "model.config.is_valid.inactive_channel.warning_days.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if _, err := time.Parse("15:04", *ics.JobStartTime); err != nil {
+		return NewAppError("Config.IsValid", "model.config.is_valid.inactive_channel.job_start_time.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (css *ColdStorageSettings) isValid(fs FileSettings) *AppError {
+	if *css.MinFileAgeDays <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.cold_storage.min_file_age_days.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *css.BatchSize <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.cold_storage.batch_size.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if _, err := time.Parse("15:04", *css.JobStartTime); err != nil {
+		return NewAppError("Config.IsValid", "model.config.is_valid.cold_storage.job_start_time.app_error", nil, err.Error(), http.StatusBadRequest)
+	}
+
+	if !*css.Enable {
+		return nil
+	}
+
+	// Moving a file to the same place that it's already in would delete it
+	switch *css.DriverName {
+	case IMAGE_DRIVER_LOCAL:
+		if *css.Directory == "" {
+			return NewAppError("Config.IsValid", "model.config.is_valid.cold_storage.directory.app_error", nil, "", http.StatusBadRequest)
+		}
+
+		if *fs.DriverName == IMAGE_DRIVER_LOCAL && filepath.Clean(*css.Directory) == filepath.Clean(fs.Directory) {
+			return NewAppError("Config.IsValid", "model.config.is_valid.cold_storage.same_as_file_storage.app_error", nil, "", http.StatusBadRequest)
+		}
+	case IMAGE_DRIVER_S3:
+		if *css.AmazonS3Bucket == "" {
+			return NewAppError("Config.IsValid", "model.config.is_valid.cold_storage.amazon_s3_bucket.app_error", nil, "", http.StatusBadRequest)
+		}
+
+		if *fs.DriverName == IMAGE_DRIVER_S3 && *css.AmazonS3Bucket == fs.AmazonS3Bucket && *css.AmazonS3Endpoint == fs.AmazonS3Endpoint {
+			return NewAppError("Config.IsValid", "model.config.is_valid.cold_storage.same_as_file_storage.app_error", nil, "", http.StatusBadRequest)
+		}
+	default:
+		return NewAppError("Config.IsValid", "model.config.is_valid.cold_storage.driver_name.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (fs *FirehoseSettings) isValid() *AppError {
+	switch *fs.Destination {
+	case FIREHOSE_DESTINATION_HTTP:
+		if *fs.Enable {
+			if _, err := url.ParseRequestURI(*fs.HTTPEndpoint); err != nil {
+				return NewAppError("Config.IsValid", "model.config.is_valid.firehose.http_endpoint.app_error", nil, "", http.StatusBadRequest)
+			}
+		}
+	case FIREHOSE_DESTINATION_CUSTOM:
+		// The custom writer is configured by whatever registers it
+	default:
+		return NewAppError("Config.IsValid", "model.config.is_valid.firehose.destination.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *fs.BufferSize <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.firehose.buffer_size.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *fs.BatchSize <= 0 || *fs.BatchSize > *fs.BufferSize {
+		return NewAppError("Config.IsValid", "model.config.is_valid.firehose.batch_size.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *fs.FlushIntervalMilliseconds <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.firehose.flush_interval.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (is *IntegrationHTTPSettings) isValid() *AppError {
+	if err := is.SlashCommands.isValid("SlashCommands"); err != nil {
+		return err
+	}
+
+	if err := is.Webhooks.isValid("Webhooks"); err != nil {
+		return err
+	}
+
+	return is.LinkPreviews.isValid("LinkPreviews")
+}
+
+func (ics *IntegrationHTTPClientSettings) isValid(name string) *AppError {
+	params := map[string]interface{}{"Name": name}
+
+	if *ics.RequestTimeoutSeconds <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.integration_http.request_timeout.app_error", params, "", http.StatusBadRequest)
+	}
+
+	if *ics.ConnectTimeoutSeconds <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.integration_http.connect_timeout.app_error", params, "", http.StatusBadRequest)
+	}
+
+	if *ics.MaxIdleConns < 0 || *ics.MaxIdleConnsPerHost < 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.integration_http.max_idle_conns.app_error", params, "", http.StatusBadRequest)
+	}
+
+	if *ics.MaxConnsPerHost < 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.integration_http.max_conns_per_host.app_error", params, "", http.StatusBadRequest)
+	}
+
+	if *ics.IdleConnTimeoutSeconds <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.integration_http.idle_conn_timeout.app_error", params, "", http.StatusBadRequest)
+	}
+
+	if *ics.CircuitBreakerFailureThreshold < 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.integration_http.circuit_breaker_failure_threshold.app_error", params, "", http.StatusBadRequest)
+	}
+
+	if *ics.CircuitBreakerCooldownSeconds <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.integration_http.circuit_breaker_cooldown.app_error", params, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (cs *ClusterSettings) isValid() *AppError {
+	switch *cs.Transport {
+	case CLUSTER_TRANSPORT_TCP:
+		// No other settings to validate
+	case CLUSTER_TRANSPORT_GRPC:
+		if *cs.GrpcTLSCertFile == "" || *cs.GrpcTLSKeyFile == "" || *cs.GrpcTLSCAFile == "" {
+			return NewAppError("Config.IsValid", "model.config.is_valid.cluster_grpc_tls.app_error", nil, "", http.StatusBadRequest)
+		}
+
+		if *cs.GrpcPort <= 0 || *cs.GrpcPort > 65535 || *cs.GrpcPort == *cs.GossipPort || *cs.GrpcPort == *cs.StreamingPort {
+			return NewAppError("Config.IsValid", "model.config.is_valid.cluster_grpc_port.app_error", nil, "", http.StatusBadRequest)
+		}
+	default:
+		return NewAppError("Config.IsValid", "model.config.is_valid.cluster_transport.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (js *JobSettings) isValid() *AppError {
+	if *js.MaxConcurrentJobs < 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.job.max_concurrent_jobs.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	for jobType, limit := range js.MaxConcurrentJobsPerType {
+		if limit <= 0 {
+			return NewAppError("Config.IsValid", "model.config.is_valid.job.max_concurrent_jobs_per_type.app_error", map[string]interface{}{"JobType": jobType}, "", http.StatusBadRequest)
+		}
+	}
+
+	for jobType, tag := range js.JobNodeAffinity {
+		if tag == "" {
+			return NewAppError("Config.IsValid", "model.config.is_valid.job.node_affinity.app_error", map[string]interface{}{"JobType": jobType}, "", http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
 func (ls *LocalizationSettings) isValid() *AppError {
 	if len(*ls.AvailableLocales) > 0 {
 		if !strings.Contains(*ls.AvailableLocales, *ls.DefaultClientLocale) {
@@ -2578,6 +4177,34 @@ func (ds *DisplaySettings) isValid() *AppError {
 	return nil
 }
 
+func (as *AutocompleteSettings) isValid() *AppError {
+	if *as.ResultLimit <= 0 || *as.ResultLimit > USER_SEARCH_MAX_LIMIT {
+		return NewAppError("Config.IsValid", "model.config.is_valid.autocomplete.result_limit.app_error", map[string]interface{}{"MaxLimit": USER_SEARCH_MAX_LIMIT}, "", http.StatusBadRequest)
+	}
+
+	for _, weight := range []int{*as.RecencyWeight, *as.MembershipWeight, *as.AlphabeticalWeight} {
+		if weight < 0 || weight > AUTOCOMPLETE_SETTINGS_MAX_WEIGHT {
+			return NewAppError("Config.IsValid", "model.config.is_valid.autocomplete.weight.app_error", map[string]interface{}{"MaxWeight": AUTOCOMPLETE_SETTINGS_MAX_WEIGHT}, "", http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+func (s *LinkRewriteSettings) isValid() *AppError {
+	if *s.ExpandTimeoutMilliseconds <= 0 || *s.ExpandTimeoutMilliseconds > LINK_REWRITE_SETTINGS_MAX_EXPAND_TIMEOUT_MILLISECONDS {
+		return NewAppError("Config.IsValid", "model.config.is_valid.link_rewrite.expand_timeout.app_error", map[string]interface{}{"Max": LINK_REWRITE_SETTINGS_MAX_EXPAND_TIMEOUT_MILLISECONDS}, "", http.StatusBadRequest)
+	}
+
+	for _, param := range s.StripQueryParams {
+		if param == "" || param == "*" {
+			return NewAppError("Config.IsValid", "model.config.is_valid.link_rewrite.strip_query_param.app_error", map[string]interface{}{"Param": param}, "", http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
 func (ips *ImageProxySettings) isValid() *AppError {
 	if *ips.Enable {
 		switch *ips.ImageProxyType {
@@ -2599,6 +4226,68 @@ func (ips *ImageProxySettings) isValid() *AppError {
 	return nil
 }
 
+func (s *CorsSettings) isValid() *AppError {
+	for _, origin := range s.AllowedOrigins {
+		if !isValidCorsOrigin(origin) {
+			return NewAppError("Config.IsValid", "model.config.is_valid.cors_allowed_origin.app_error", map[string]interface{}{"Origin": origin}, "", http.StatusBadRequest)
+		}
+	}
+
+	for _, method := range s.AllowedMethods {
+		switch method {
+		case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		default:
+			return NewAppError("Config.IsValid", "model.config.is_valid.cors_allowed_method.app_error", map[string]interface{}{"Method": method}, "", http.StatusBadRequest)
+		}
+	}
+
+	if *s.MaxAgeSeconds < 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.cors_max_age.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (s *TraceSettings) isValid() *AppError {
+	if *s.Enable {
+		if _, err := url.ParseRequestURI(*s.OTLPEndpoint); err != nil {
+			return NewAppError("Config.IsValid", "model.config.is_valid.trace.otlp_endpoint.app_error", nil, "", http.StatusBadRequest)
+		}
+	}
+
+	if *s.SamplingRatio < 0 || *s.SamplingRatio > 1 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.trace.sampling_ratio.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// isValidCorsOrigin returns whether an origin is "*" or a scheme and host with no path. The host may contain a single
+// wildcard standing for whole labels, such as https://*.example.com.
+func isValidCorsOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+
+	if i := strings.Index(origin, "*"); i != -1 {
+		if strings.Count(origin, "*") > 1 {
+			return false
+		}
+
+		prefix, suffix := origin[:i], origin[i+1:]
+		if !(strings.HasSuffix(prefix, "://") || strings.HasSuffix(prefix, ".")) || !strings.HasPrefix(suffix, ".") {
+			return false
+		}
+	}
+
+	u, err := url.Parse(strings.Replace(origin, "*", "wildcard", 1))
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.User == nil && u.Path == "" && u.RawQuery == "" && u.Fragment == ""
+}
+
 func (o *Config) GetSanitizeOptions() map[string]bool {
 	options := map[string]bool{}
 	options["fullname"] = o.PrivacySettings.ShowFullName
@@ -2617,6 +4306,10 @@ func (o *Config) Sanitize() {
 		o.FileSettings.AmazonS3SecretAccessKey = FAKE_SETTING
 	}
 
+	if o.ColdStorageSettings.AmazonS3SecretAccessKey != nil && len(*o.ColdStorageSettings.AmazonS3SecretAccessKey) > 0 {
+		*o.ColdStorageSettings.AmazonS3SecretAccessKey = FAKE_SETTING
+	}
+
 	o.EmailSettings.InviteSalt = FAKE_SETTING
 	if len(o.EmailSettings.SMTPPassword) > 0 {
 		o.EmailSettings.SMTPPassword = FAKE_SETTING
@@ -2639,3 +4332,84 @@ func (o *Config) Sanitize() {
 
 	*o.ElasticsearchSettings.Password = FAKE_SETTING
 }
+
+// defaultableNotifyProps are the values that each notify prop which admins can set a default for may take. Mention
+// keys depend on the username, so they can't have a default.
+var defaultableNotifyProps = map[string][]string{
+	DESKTOP_NOTIFY_PROP:          {USER_NOTIFY_ALL, USER_NOTIFY_MENTION, USER_NOTIFY_NONE},
+	DESKTOP_SOUND_NOTIFY_PROP:    {"true", "false"},
+	PUSH_NOTIFY_PROP:             {USER_NOTIFY_ALL, USER_NOTIFY_MENTION, USER_NOTIFY_NONE},
+	PUSH_STATUS_NOTIFY_PROP:      {STATUS_ONLINE, STATUS_AWAY, STATUS_OFFLINE},
+	EMAIL_NOTIFY_PROP:            {"true", "false"},
+	CHANNEL_MENTIONS_NOTIFY_PROP: {"true", "false"},
+	COMMENTS_NOTIFY_PROP:         {COMMENTS_NOTIFY_NEVER, COMMENTS_NOTIFY_ROOT, COMMENTS_NOTIFY_ANY},
+	FIRST_NAME_NOTIFY_PROP:       {"true", "false"},
+}
+
+func (s *NotificationDefaultSettings) isValid() *AppError {
+	for prop, value := range s.NotifyProps {
+		values, ok := defaultableNotifyProps[prop]
+		if !ok {
+			return NewAppError("Config.IsValid", "model.config.is_valid.notification_defaults.notify_prop.app_error", map[string]interface{}{"Prop": prop}, "", http.StatusBadRequest)
+		}
+
+		valid := false
+		for _, v := range values {
+			if v == value {
+				valid = true
+				break
+			}
+		}
+
+		if !valid {
+			return NewAppError("Config.IsValid", "model.config.is_valid.notification_defaults.value.app_error", map[string]interface{}{"Prop": prop, "Value": value}, "", http.StatusBadRequest)
+		}
+	}
+
+	// A notify prop can only be enforced once there's a default to enforce
+	for _, prop := range s.EnforcedNotifyProps {
+		if _, ok := s.NotifyProps[prop]; !ok {
+			return NewAppError("Config.IsValid", "model.config.is_valid.notification_defaults.enforced.app_error", map[string]interface{}{"Prop": prop}, "", http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+func (s *DataLossPreventionSettings) isValid() *AppError {
+	for _, pattern := range s.Patterns {
+		if pattern.Name == "" {
+			return NewAppError("Config.IsValid", "model.config.is_valid.data_loss_prevention.name.app_error", nil, "", http.StatusBadRequest)
+		}
+
+		if _, err := regexp.Compile(pattern.Pattern); pattern.Pattern == "" || err != nil {
+			return NewAppError("Config.IsValid", "model.config.is_valid.data_loss_prevention.pattern.app_error", map[string]interface{}{"Name": pattern.Name}, "", http.StatusBadRequest)
+		}
+
+		if pattern.Checksum != "" && pattern.Checksum != DATA_LOSS_PREVENTION_CHECKSUM_LUHN {
+			return NewAppError("Config.IsValid", "model.config.is_valid.data_loss_prevention.checksum.app_error", map[string]interface{}{"Name": pattern.Name}, "", http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+func (s *SearchRateLimitSettings) isValid() *AppError {
+	if *s.PerUserPerMinute <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.search_rate_limit.per_user_per_minute.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *s.PerUserMaxBurst <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.search_rate_limit.per_user_max_burst.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *s.GlobalPerMinute <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.search_rate_limit.global_per_minute.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if *s.GlobalMaxBurst <= 0 {
+		return NewAppError("Config.IsValid", "model.config.is_valid.search_rate_limit.global_max_burst.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
@@ -82,6 +82,104 @@ func TestConfigDefaultFileSettingsS3SSE(t *testing.T) {
 	}
 }
 
+func TestFileSettingsInlineContentTypes(t *testing.T) {
+	fs := FileSettings{}
+	fs.SetDefaults()
+	fs.PublicLinkSalt = NewString(NewRandomString(32))
+	require.Nil(t, fs.isValid())
+
+	assert.True(t, fs.IsInlineContentType("image/png"))
+	assert.True(t, fs.IsInlineContentType("Image/PNG"))
+	assert.True(t, fs.IsInlineContentType("application/pdf"))
+	assert.False(t, fs.IsInlineContentType("image/svg+xml"))
+	assert.False(t, fs.IsInlineContentType("text/html; charset=utf-8"))
+	assert.False(t, fs.IsInlineContentType("image/pngx"))
+	assert.False(t, fs.IsInlineContentType(""))
+
+	fs.InlineContentTypes = []string{"text/plain"}
+	require.Nil(t, fs.isValid())
+	assert.True(t, fs.IsInlineContentType("text/plain; charset=utf-8"))
+	assert.False(t, fs.IsInlineContentType("image/png"))
+
+	for _, contentType := range []string{"", "image", "Image/PNG", "text/plain; charset=utf-8", "image/*, text/plain"} {
+		fs.InlineContentTypes = []string{contentType}
+		err := fs.isValid()
+		require.NotNil(t, err, contentType)
+		assert.Equal(t, "model.config.is_valid.inline_content_types.app_error", err.Id)
+	}
+}
+
+func TestFileSettingsUploadFileTypes(t *testing.T) {
+	fs := FileSettings{}
+	fs.SetDefaults()
+	fs.PublicLinkSalt = NewString(NewRandomString(32))
+	require.Nil(t, fs.isValid())
+
+	assert.Equal(t, "", fs.GetUploadFileTypeViolation("program.exe", "application/x-msdownload"))
+	limit, fileType := fs.GetMaxUploadFileSize("image.png", "image/png")
+	assert.Equal(t, *fs.MaxFileSize, limit)
+	assert.Equal(t, "", fileType)
+
+	fs.DeniedUploadFileTypes = []string{".exe", "application/x-msdownload"}
+	require.Nil(t, fs.isValid())
+	assert.Equal(t, ".exe", fs.GetUploadFileTypeViolation("Program.EXE", "application/octet-stream"))
+	assert.Equal(t, "application/x-msdownload", fs.GetUploadFileTypeViolation("image.png", "application/x-msdownload"))
+	assert.Equal(t, "", fs.GetUploadFileTypeViolation("image.png", "image/png"))
+
+	fs.AllowedUploadFileTypes = []string{"image/*", ".txt"}
+	require.Nil(t, fs.isValid())
+	assert.Equal(t, "", fs.GetUploadFileTypeViolation("image.png", "image/png"))
+	assert.Equal(t, "", fs.GetUploadFileTypeViolation("notes.txt", "text/plain; charset=utf-8"))
+	assert.Equal(t, "*", fs.GetUploadFileTypeViolation("notes.md", "text/plain; charset=utf-8"))
+	assert.Equal(t, "*", fs.GetUploadFileTypeViolation("image.png", "imagex/png"))
+	assert.Equal(t, ".exe", fs.GetUploadFileTypeViolation("image.exe", "image/png"))
+
+	fs.MaxFileSizeByType = map[string]int64{"image/*": 10 * 1024 * 1024, "image/gif": 1024 * 1024, ".gif": 1024 * 1024, "video/*": 100 * 1024 * 1024}
+	require.Nil(t, fs.isValid())
+
+	limit, fileType = fs.GetMaxUploadFileSize("image.png", "image/png")
+	assert.Equal(t, int64(10*1024*1024), limit)
+	assert.Equal(t, "image/*", fileType)
+
+	limit, fileType = fs.GetMaxUploadFileSize("image.gif", "image/gif")
+	assert.Equal(t, int64(1024*1024), limit)
+	assert.Equal(t, ".gif", fileType)
+
+	limit, fileType = fs.GetMaxUploadFileSize("video.mp4", "video/mp4")
+	assert.Equal(t, *fs.MaxFileSize, limit)
+	assert.Equal(t, "", fileType)
+
+	for _, invalid := range []string{"", ".", "exe", ".EXE", ".e xe", "Image/PNG", "image/png; charset=utf-8"} {
+		fs.DeniedUploadFileTypes = []string{invalid}
+		err := fs.isValid()
+		require.NotNil(t, err, invalid)
+		assert.Equal(t, "model.config.is_valid.upload_file_type.app_error", err.Id)
+	}
+	fs.DeniedUploadFileTypes = []string{}
+
+	fs.MaxFileSizeByType = map[string]int64{"image/*": 0}
+	err := fs.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.max_file_size_by_type.app_error", err.Id)
+}
+
+func TestFileSettingsShouldVerifyChecksum(t *testing.T) {
+	fs := &FileSettings{}
+	fs.SetDefaults()
+	assert.False(t, fs.ShouldVerifyChecksum("contract.pdf", "application/pdf"))
+
+	fs.VerifyChecksumFileTypes = []string{"application/pdf", ".docx"}
+	require.Nil(t, fs.isValid())
+	assert.True(t, fs.ShouldVerifyChecksum("contract.pdf", "application/pdf"))
+	assert.True(t, fs.ShouldVerifyChecksum("contract.DOCX", "application/zip"))
+	assert.False(t, fs.ShouldVerifyChecksum("image.png", "image/png"))
+
+	fs.VerifyChecksumFileTypes = []string{"pdf"}
+	err := fs.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.upload_file_type.app_error", err.Id)
+}
+
 func TestConfigDefaultServiceSettingsExperimentalGroupUnreadChannels(t *testing.T) {
 	c1 := Config{}
 	c1.SetDefaults()
@@ -689,3 +787,1061 @@ func TestImageProxySettingsIsValid(t *testing.T) {
 		})
 	}
 }
+
+func TestInactiveUserSettingsIsValid(t *testing.T) {
+	for _, test := range []struct {
+		Name         string
+		InactiveDays int
+		WarningDays  int
+		JobStartTime string
+		ExpectError  bool
+	}{
+		{
+			Name:         "valid",
+			InactiveDays: 90,
+			WarningDays:  7,
+			JobStartTime: "03:00",
+			ExpectError:  false,
+		},
+		{
+			Name:         "no warning period",
+			InactiveDays: 90,
+			WarningDays:  0,
+			JobStartTime: "03:00",
+			ExpectError:  false,
+		},
+		{
+			Name:         "no inactive days",
+			InactiveDays: 0,
+			WarningDays:  0,
+			JobStartTime: "03:00",
+			ExpectError:  true,
+		},
+		{
+			Name:         "warning period too long",
+			InactiveDays: 7,
+			WarningDays:  7,
+			JobStartTime: "03:00",
+			ExpectError:  true,
+		},
+		{
+			Name:         "invalid job start time",
+			InactiveDays: 90,
+			WarningDays:  7,
+			JobStartTime: "3am",
+			ExpectError:  true,
+		},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			ius := &InactiveUserSettings{}
+			ius.SetDefaults()
+			ius.InactiveDays = &test.InactiveDays
+			ius.WarningDays = &test.WarningDays
+			ius.JobStartTime = &test.JobStartTime
+
+			err := ius.isValid()
+			if test.ExpectError {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestInactiveUserSettingsGetExemptUsernames(t *testing.T) {
+	ius := &InactiveUserSettings{}
+	ius.SetDefaults()
+	assert.Empty(t, ius.GetExemptUsernames())
+
+	ius.ExemptUsernames = NewString(" build-bot, ,Service-Account ")
+	assert.Equal(t, []string{"build-bot", "service-account"}, ius.GetExemptUsernames())
+}
+
+func TestAutocompleteSettingsIsValid(t *testing.T) {
+	for _, test := range []struct {
+		Name          string
+		ResultLimit   int
+		RecencyWeight int
+		ExpectError   bool
+	}{
+		{
+			Name:          "valid",
+			ResultLimit:   25,
+			RecencyWeight: 50,
+			ExpectError:   false,
+		},
+		{
+			Name:          "no results",
+			ResultLimit:   0,
+			RecencyWeight: 50,
+			ExpectError:   true,
+		},
+		{
+			Name:          "too many results",
+			ResultLimit:   USER_SEARCH_MAX_LIMIT + 1,
+			RecencyWeight: 50,
+			ExpectError:   true,
+		},
+		{
+			Name:          "negative weight",
+			ResultLimit:   25,
+			RecencyWeight: -1,
+			ExpectError:   true,
+		},
+		{
+			Name:          "weight too large",
+			ResultLimit:   25,
+			RecencyWeight: AUTOCOMPLETE_SETTINGS_MAX_WEIGHT + 1,
+			ExpectError:   true,
+		},
+	} {
+		t.Run(test.Name, func(t *testing.T) {
+			as := &AutocompleteSettings{}
+			as.SetDefaults()
+			as.ResultLimit = &test.ResultLimit
+			as.RecencyWeight = &test.RecencyWeight
+
+			err := as.isValid()
+			if test.ExpectError {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestJobSettingsIsValid(t *testing.T) {
+	js := &JobSettings{}
+	js.SetDefaults()
+	assert.Nil(t, js.isValid())
+
+	js.MaxConcurrentJobs = NewInt(-1)
+	assert.NotNil(t, js.isValid())
+
+	js.MaxConcurrentJobs = NewInt(2)
+	js.MaxConcurrentJobsPerType = map[string]int{JOB_TYPE_ELASTICSEARCH_SCOPED_REINDEX: 1}
+	assert.Nil(t, js.isValid())
+
+	js.MaxConcurrentJobsPerType[JOB_TYPE_MESSAGE_EXPORT] = 0
+	assert.NotNil(t, js.isValid())
+
+	delete(js.MaxConcurrentJobsPerType, JOB_TYPE_MESSAGE_EXPORT)
+	js.JobNodeAffinity = map[string]string{JOB_TYPE_ELASTICSEARCH_SCOPED_REINDEX: "indexer"}
+	assert.Nil(t, js.isValid())
+
+	js.JobNodeAffinity[JOB_TYPE_MESSAGE_EXPORT] = ""
+	assert.NotNil(t, js.isValid())
+}
+
+func TestClusterSettingsIsValid(t *testing.T) {
+	cs := &ClusterSettings{}
+	cs.SetDefaults()
+	assert.Nil(t, cs.isValid())
+
+	cs.Transport = NewString("udp")
+	assert.NotNil(t, cs.isValid())
+
+	cs.Transport = NewString(CLUSTER_TRANSPORT_GRPC)
+	assert.NotNil(t, cs.isValid())
+
+	cs.GrpcTLSCertFile = NewString("cert.pem")
+	cs.GrpcTLSKeyFile = NewString("key.pem")
+	cs.GrpcTLSCAFile = NewString("ca.pem")
+	assert.Nil(t, cs.isValid())
+
+	cs.GrpcPort = NewInt(*cs.StreamingPort)
+	assert.NotNil(t, cs.isValid())
+
+	cs.GrpcPort = NewInt(0)
+	assert.NotNil(t, cs.isValid())
+}
+
+func TestColdStorageSettingsIsValid(t *testing.T) {
+	fs := FileSettings{}
+	fs.SetDefaults()
+	fs.Directory = "./data/"
+
+	css := &ColdStorageSettings{}
+	css.SetDefaults()
+	assert.Nil(t, css.isValid(fs))
+
+	css.Enable = NewBool(true)
+	err := css.isValid(fs)
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.cold_storage.directory.app_error", err.Id)
+
+	css.Directory = NewString("data")
+	err = css.isValid(fs)
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.cold_storage.same_as_file_storage.app_error", err.Id)
+
+	css.Directory = NewString("./cold/")
+	assert.Nil(t, css.isValid(fs))
+
+	css.DriverName = NewString(IMAGE_DRIVER_S3)
+	err = css.isValid(fs)
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.cold_storage.amazon_s3_bucket.app_error", err.Id)
+
+	css.AmazonS3Bucket = NewString("archive")
+	assert.Nil(t, css.isValid(fs))
+
+	fs.DriverName = NewString(IMAGE_DRIVER_S3)
+	fs.AmazonS3Bucket = "archive"
+	err = css.isValid(fs)
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.cold_storage.same_as_file_storage.app_error", err.Id)
+
+	css.DriverName = NewString("tape")
+	err = css.isValid(fs)
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.cold_storage.driver_name.app_error", err.Id)
+
+	css.Enable = NewBool(false)
+	assert.Nil(t, css.isValid(fs))
+
+	css.MinFileAgeDays = NewInt(0)
+	err = css.isValid(fs)
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.cold_storage.min_file_age_days.app_error", err.Id)
+}
+
+func TestColdStorageSettingsToFileSettings(t *testing.T) {
+	fs := FileSettings{}
+	fs.SetDefaults()
+	fs.AmazonS3Bucket = "hot"
+	fs.AmazonS3SSE = NewBool(true)
+
+	css := &ColdStorageSettings{}
+	css.SetDefaults()
+	css.DriverName = NewString(IMAGE_DRIVER_S3)
+	css.AmazonS3Bucket = NewString("cold")
+	css.AmazonS3SSL = NewBool(false)
+
+	coldSettings := css.ToFileSettings(fs)
+	assert.Equal(t, IMAGE_DRIVER_S3, *coldSettings.DriverName)
+	assert.Equal(t, "cold", coldSettings.AmazonS3Bucket)
+	assert.False(t, *coldSettings.AmazonS3SSL)
+	assert.True(t, *coldSettings.AmazonS3SSE)
+
+	// The main file store's settings are left alone
+	assert.Equal(t, IMAGE_DRIVER_LOCAL, *fs.DriverName)
+	assert.Equal(t, "hot", fs.AmazonS3Bucket)
+	assert.True(t, *fs.AmazonS3SSL)
+}
+
+func TestFirehoseSettingsIsValid(t *testing.T) {
+	fs := &FirehoseSettings{}
+	fs.SetDefaults()
+	assert.Nil(t, fs.isValid())
+
+	fs.Enable = NewBool(true)
+	err := fs.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.firehose.http_endpoint.app_error", err.Id)
+
+	fs.HTTPEndpoint = NewString("https://analytics.example.com/events")
+	assert.Nil(t, fs.isValid())
+
+	fs.Destination = NewString("kafka")
+	err = fs.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.firehose.destination.app_error", err.Id)
+
+	fs.Destination = NewString(FIREHOSE_DESTINATION_CUSTOM)
+	assert.Nil(t, fs.isValid())
+
+	fs.BatchSize = NewInt(*fs.BufferSize + 1)
+	err = fs.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.firehose.batch_size.app_error", err.Id)
+}
+
+func TestIntegrationHTTPSettingsIsValid(t *testing.T) {
+	is := &IntegrationHTTPSettings{}
+	is.SetDefaults()
+	assert.Nil(t, is.isValid())
+
+	is.Webhooks.RequestTimeoutSeconds = NewInt(0)
+	err := is.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.integration_http.request_timeout.app_error", err.Id)
+	assert.Equal(t, "Webhooks", err.params["Name"])
+
+	is.Webhooks.RequestTimeoutSeconds = NewInt(5)
+	is.LinkPreviews.MaxConnsPerHost = NewInt(-1)
+	err = is.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.integration_http.max_conns_per_host.app_error", err.Id)
+
+	is.LinkPreviews.MaxConnsPerHost = NewInt(10)
+	is.SlashCommands.CircuitBreakerCooldownSeconds = NewInt(0)
+	err = is.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.integration_http.circuit_breaker_cooldown.app_error", err.Id)
+
+	is.SlashCommands.CircuitBreakerCooldownSeconds = NewInt(30)
+	is.SlashCommands.CircuitBreakerFailureThreshold = NewInt(0)
+	assert.Nil(t, is.isValid())
+}
+
+func TestServiceSettingsCachePreloadingIsValid(t *testing.T) {
+	ss := &ServiceSettings{}
+	ss.SetDefaults()
+	assert.False(t, *ss.EnableCachePreloading)
+	assert.Nil(t, ss.isValid())
+
+	ss.CachePreloadingTimeoutSeconds = NewInt(0)
+	err := ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.cache_preloading_timeout.app_error", err.Id)
+
+	ss.CachePreloadingTimeoutSeconds = NewInt(30)
+	ss.CachePreloadingMaxUsers = NewInt(-1)
+	err = ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.cache_preloading_max_users.app_error", err.Id)
+}
+
+func TestServiceSettingsResponseCacheIsValid(t *testing.T) {
+	ss := &ServiceSettings{}
+	ss.SetDefaults()
+	assert.Empty(t, ss.ResponseCacheEndpoints)
+	assert.Nil(t, ss.isValid())
+
+	ss.ResponseCacheEndpoints = []string{RESPONSE_CACHE_ENDPOINT_EMOJI_LIST, RESPONSE_CACHE_ENDPOINT_CLIENT_CONFIG}
+	assert.Nil(t, ss.isValid())
+
+	ss.ResponseCacheEndpoints = append(ss.ResponseCacheEndpoints, "posts")
+	err := ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.response_cache_endpoints.app_error", err.Id)
+
+	ss.ResponseCacheEndpoints = []string{}
+	ss.ResponseCacheMaxAgeSeconds = NewInt(-1)
+	err = ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.response_cache_max_age.app_error", err.Id)
+}
+
+func TestServiceSettingsCspScriptSourcesCacheSizeIsValid(t *testing.T) {
+	ss := &ServiceSettings{}
+	ss.SetDefaults()
+	assert.Equal(t, SERVICE_SETTINGS_DEFAULT_CSP_SCRIPT_SOURCES_CACHE_SIZE, *ss.CspScriptSourcesCacheSize)
+	assert.Nil(t, ss.isValid())
+
+	ss.CspScriptSourcesCacheSize = NewInt(0)
+	err := ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.csp_script_sources_cache_size.app_error", err.Id)
+}
+
+func TestServiceSettingsMaxBatchRequestsIsValid(t *testing.T) {
+	ss := &ServiceSettings{}
+	ss.SetDefaults()
+	assert.Equal(t, SERVICE_SETTINGS_DEFAULT_MAX_BATCH_REQUESTS, *ss.MaxBatchRequests)
+	assert.Nil(t, ss.isValid())
+
+	ss.MaxBatchRequests = NewInt(0)
+	err := ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.max_batch_requests.app_error", err.Id)
+}
+
+func TestServiceSettingsGrpcListenAddressIsValid(t *testing.T) {
+	ss := &ServiceSettings{}
+	ss.SetDefaults()
+	assert.Equal(t, "", *ss.GrpcListenAddress)
+	assert.Nil(t, ss.isValid())
+
+	ss.GrpcListenAddress = NewString(":8066")
+	assert.Nil(t, ss.isValid())
+
+	ss.GrpcListenAddress = NewString("localhost")
+	err := ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.grpc_listen_address.app_error", err.Id)
+}
+
+func TestServiceSettingsLoadSheddingIsValid(t *testing.T) {
+	ss := &ServiceSettings{}
+	ss.SetDefaults()
+	assert.Equal(t, 0, *ss.LoadSheddingGoroutineThreshold)
+	assert.Equal(t, 0, *ss.LoadSheddingMemoryThresholdMB)
+	assert.Nil(t, ss.isValid())
+
+	ss.LoadSheddingGoroutineThreshold = NewInt(-1)
+	err := ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.load_shedding_goroutine_threshold.app_error", err.Id)
+
+	ss.LoadSheddingGoroutineThreshold = NewInt(10000)
+	ss.LoadSheddingMemoryThresholdMB = NewInt(-1)
+	err = ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.load_shedding_memory_threshold.app_error", err.Id)
+
+	ss.LoadSheddingMemoryThresholdMB = NewInt(4096)
+	ss.LoadSheddingRetryAfterSeconds = NewInt(0)
+	err = ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.load_shedding_retry_after.app_error", err.Id)
+}
+
+func TestServiceSettingsMaxConcurrentRequestsPerUserIsValid(t *testing.T) {
+	ss := ServiceSettings{}
+	ss.SetDefaults()
+	assert.Equal(t, 0, *ss.MaxConcurrentRequestsPerUser)
+	assert.Nil(t, ss.isValid())
+
+	ss.MaxConcurrentRequestsPerUser = NewInt(-1)
+	err := ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.max_concurrent_requests_per_user.app_error", err.Id)
+}
+
+func TestServiceSettingsSlowRequestThresholdIsValid(t *testing.T) {
+	ss := ServiceSettings{}
+	ss.SetDefaults()
+	assert.Equal(t, 0, *ss.SlowRequestThresholdMilliseconds)
+	assert.Equal(t, 0, *ss.SlowUploadRequestThresholdMilliseconds)
+	assert.Nil(t, ss.isValid())
+
+	ss.SlowRequestThresholdMilliseconds = NewInt(-1)
+	err := ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.slow_request_threshold.app_error", err.Id)
+
+	ss.SlowRequestThresholdMilliseconds = NewInt(1000)
+	ss.SlowUploadRequestThresholdMilliseconds = NewInt(-1)
+	err = ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.slow_request_threshold.app_error", err.Id)
+}
+
+func TestServiceSettingsWebsocketSlowConsumerIsValid(t *testing.T) {
+	ss := &ServiceSettings{}
+	ss.SetDefaults()
+	assert.Equal(t, WEBSOCKET_SLOW_CONSUMER_POLICY_DISCONNECT, *ss.WebsocketSlowConsumerPolicy)
+	assert.Nil(t, ss.isValid())
+
+	ss.WebsocketSendQueueSize = NewInt(0)
+	err := ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.websocket_send_queue_size.app_error", err.Id)
+
+	ss.WebsocketSendQueueSize = NewInt(1024)
+	ss.WebsocketSlowConsumerPolicy = NewString("drop_newest")
+	err = ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.websocket_slow_consumer_policy.app_error", err.Id)
+
+	ss.WebsocketSlowConsumerPolicy = NewString(WEBSOCKET_SLOW_CONSUMER_POLICY_DROP_OLDEST)
+	assert.Nil(t, ss.isValid())
+
+	ss.WebsocketMaxDroppedEvents = NewInt(0)
+	err = ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.websocket_max_dropped_events.app_error", err.Id)
+
+	ss.WebsocketMaxDroppedEvents = NewInt(100)
+	assert.Equal(t, SERVICE_SETTINGS_DEFAULT_MAXIMUM_PER_PAGE, *ss.MaximumPerPage)
+	ss.MaximumPerPage = NewInt(0)
+	err = ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.maximum_per_page.app_error", err.Id)
+
+	ss.MaximumPerPage = NewInt(SERVICE_SETTINGS_DEFAULT_MAXIMUM_PER_PAGE)
+	assert.Equal(t, CSRF_PROTECTION_MODE_HEADER, *ss.CsrfProtectionMode)
+	ss.CsrfProtectionMode = NewString(CSRF_PROTECTION_MODE_DOUBLE_SUBMIT)
+	assert.Nil(t, ss.isValid())
+
+	ss.CsrfProtectionMode = NewString("cookie")
+	err = ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.csrf_protection_mode.app_error", err.Id)
+
+	ss.CsrfProtectionMode = NewString(CSRF_PROTECTION_MODE_HEADER)
+	ss.CsrfTokenRotationMinutes = NewInt(60)
+	assert.Nil(t, ss.isValid())
+
+	ss.CsrfTokenRotationMinutes = NewInt(-1)
+	err = ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.csrf_token_rotation_minutes.app_error", err.Id)
+
+	ss.CsrfTokenRotationMinutes = NewInt(0)
+	assert.Equal(t, SERVICE_SETTINGS_DEFAULT_REQUEST_TIMEOUT, *ss.RequestTimeout)
+	ss.RequestTimeout = NewInt(60)
+	assert.Nil(t, ss.isValid())
+
+	ss.RequestTimeout = NewInt(-1)
+	err = ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.request_timeout.app_error", err.Id)
+
+	ss.RequestTimeout = NewInt(0)
+	assert.False(t, *ss.EnableResponseCompression)
+	assert.Equal(t, SERVICE_SETTINGS_DEFAULT_RESPONSE_COMPRESSION_MIN_SIZE, *ss.ResponseCompressionMinSize)
+	ss.ResponseCompressionMinSize = NewInt(0)
+	assert.Nil(t, ss.isValid())
+
+	ss.ResponseCompressionMinSize = NewInt(-1)
+	err = ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.response_compression_min_size.app_error", err.Id)
+}
+
+func TestServiceSettingsIsValidErrorPagePath(t *testing.T) {
+	for name, tc := range map[string]struct {
+		ErrorPagePath string
+		Valid         bool
+	}{
+		"default":              {SERVICE_SETTINGS_DEFAULT_ERROR_PAGE_PATH, true},
+		"nested path":          {"/help/sso-error", true},
+		"empty":                {"", false},
+		"relative path":        {"error", false},
+		"absolute url":         {"https://example.com/error", false},
+		"protocol relative":    {"//example.com/error", false},
+		"backslash":            {"/\\example.com/error", false},
+		"query string":         {"/error?type=sso", false},
+		"fragment":             {"/error#sso", false},
+		"escaped path segment": {"/error%2Fpage", false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ss := ServiceSettings{}
+			ss.SetDefaults()
+			ss.ErrorPagePath = NewString(tc.ErrorPagePath)
+
+			err := ss.isValid()
+			if tc.Valid {
+				assert.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				assert.Equal(t, "model.config.is_valid.error_page_path.app_error", err.Id)
+			}
+		})
+	}
+}
+
+func TestServiceSettingsIsValidSecurityHeaders(t *testing.T) {
+	for name, tc := range map[string]struct {
+		ReferrerPolicy string
+		FrameOptions   string
+		ExpectedError  string
+	}{
+		"defaults":                {SERVICE_SETTINGS_DEFAULT_REFERRER_POLICY, FRAME_OPTIONS_DENY, ""},
+		"other referrer policy":   {"strict-origin-when-cross-origin", FRAME_OPTIONS_DENY, ""},
+		"same origin framing":     {SERVICE_SETTINGS_DEFAULT_REFERRER_POLICY, FRAME_OPTIONS_SAME_ORIGIN, ""},
+		"disabled":                {"", "", ""},
+		"invalid referrer policy": {"same-site", FRAME_OPTIONS_DENY, "model.config.is_valid.referrer_policy.app_error"},
+		"invalid frame options":   {SERVICE_SETTINGS_DEFAULT_REFERRER_POLICY, "ALLOW-FROM https://example.com", "model.config.is_valid.frame_options.app_error"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ss := ServiceSettings{}
+			ss.SetDefaults()
+			ss.ReferrerPolicy = NewString(tc.ReferrerPolicy)
+			ss.FrameOptions = NewString(tc.FrameOptions)
+
+			err := ss.isValid()
+			if tc.ExpectedError == "" {
+				assert.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				assert.Equal(t, tc.ExpectedError, err.Id)
+			}
+		})
+	}
+}
+
+func TestServiceSettingsIsValidAnalyticsCdnHost(t *testing.T) {
+	for name, tc := range map[string]struct {
+		AnalyticsCdnHost string
+		ExpectedValid    bool
+	}{
+		"default":       {SERVICE_SETTINGS_DEFAULT_ANALYTICS_CDN_HOST, true},
+		"empty":         {"", true},
+		"with port":     {"analytics.internal:8443", true},
+		"with scheme":   {"https://analytics.internal", true},
+		"with path":     {"analytics.internal/analytics.js", false},
+		"other scheme":  {"ftp://analytics.internal", false},
+		"two sources":   {"analytics.internal *", false},
+		"new directive": {"analytics.internal;script-src", false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ss := ServiceSettings{}
+			ss.SetDefaults()
+			ss.AnalyticsCdnHost = NewString(tc.AnalyticsCdnHost)
+
+			err := ss.isValid()
+			if tc.ExpectedValid {
+				assert.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				assert.Equal(t, "model.config.is_valid.analytics_cdn_host.app_error", err.Id)
+			}
+		})
+	}
+}
+
+func TestServiceSettingsIsValidReadinessChecks(t *testing.T) {
+	ss := ServiceSettings{}
+	ss.SetDefaults()
+	assert.Equal(t, []string{READINESS_CHECK_DATABASE, READINESS_CHECK_SEARCH}, ss.ReadinessChecks)
+	assert.Nil(t, ss.isValid())
+
+	ss.ReadinessChecks = []string{}
+	assert.Nil(t, ss.isValid())
+
+	ss.ReadinessChecks = []string{READINESS_CHECK_DATABASE, "cache"}
+	err := ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.readiness_checks.app_error", err.Id)
+
+	ss.ReadinessChecks = []string{READINESS_CHECK_DATABASE}
+	ss.ReadinessCheckTimeoutMilliseconds = NewInt(0)
+	err = ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.readiness_check_timeout.app_error", err.Id)
+}
+
+func TestServiceSettingsIsValidWebsocketAllowedOrigins(t *testing.T) {
+	ss := ServiceSettings{}
+	ss.SetDefaults()
+	assert.Empty(t, ss.WebsocketAllowedOrigins)
+	assert.Nil(t, ss.isValid())
+
+	ss.WebsocketAllowedOrigins = []string{"https://mattermost.com", "https://*.example.com"}
+	assert.Nil(t, ss.isValid())
+
+	ss.WebsocketAllowedOrigins = []string{"mattermost.com"}
+	err := ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.websocket_allowed_origin.app_error", err.Id)
+}
+
+func TestServiceSettingsIsValidClientCertAuth(t *testing.T) {
+	ss := ServiceSettings{}
+	ss.SetDefaults()
+	assert.False(t, *ss.ClientCertAuth)
+	assert.Equal(t, CLIENT_CERT_AUTH_ATTRIBUTE_EMAIL, *ss.ClientCertAuthAttribute)
+	assert.Nil(t, ss.isValid())
+
+	ss.ClientCertAuthAttribute = NewString("uid")
+	err := ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.client_cert_auth_attribute.app_error", err.Id)
+
+	ss.ClientCertAuthAttribute = NewString(CLIENT_CERT_AUTH_ATTRIBUTE_SAN)
+	ss.ClientCertAuth = NewBool(true)
+	err = ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.client_cert_auth.app_error", err.Id)
+
+	ss.ConnectionSecurity = NewString(CONN_SECURITY_TLS)
+	ss.UseLetsEncrypt = NewBool(true)
+	err = ss.isValid()
+	require.NotNil(t, err, "a CA file is required")
+	assert.Equal(t, "model.config.is_valid.client_cert_auth.app_error", err.Id)
+
+	ss.ClientCertAuthCAFile = NewString("./config/ca.pem")
+	assert.Nil(t, ss.isValid())
+}
+
+func TestServiceSettingsIsValidPostEditTimeLimitsByRole(t *testing.T) {
+	ss := ServiceSettings{}
+	ss.SetDefaults()
+	assert.Empty(t, ss.PostEditTimeLimitsByRole)
+	assert.Nil(t, ss.isValid())
+
+	ss.PostEditTimeLimitsByRole = map[string]int{CHANNEL_USER_ROLE_ID: 300, SYSTEM_ADMIN_ROLE_ID: 0}
+	assert.Nil(t, ss.isValid())
+
+	ss.PostEditTimeLimitsByRole = map[string]int{CHANNEL_USER_ROLE_ID: -1}
+	err := ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.post_edit_time_limits_by_role.app_error", err.Id)
+
+	ss.PostEditTimeLimitsByRole = map[string]int{"not a role": 300}
+	err = ss.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.post_edit_time_limits_by_role.app_error", err.Id)
+}
+
+func TestServiceSettingsIsValidSessionCookie(t *testing.T) {
+	for name, tc := range map[string]struct {
+		SameSite string
+		Domain   string
+		Secure   string
+		SiteURL  string
+		ErrorId  string
+	}{
+		"defaults":              {},
+		"strict":                {SameSite: "Strict"},
+		"none":                  {SameSite: "None"},
+		"invalid same site":     {SameSite: "lax", ErrorId: "model.config.is_valid.session_cookie_same_site.app_error"},
+		"always secure":         {Secure: "always"},
+		"invalid secure":        {Secure: "never", ErrorId: "model.config.is_valid.session_cookie_secure.app_error"},
+		"none over https":       {SameSite: "None", SiteURL: "https://chat.example.com"},
+		"none over http":        {SameSite: "None", SiteURL: "http://chat.example.com", ErrorId: "model.config.is_valid.session_cookie_insecure_site_url.app_error"},
+		"secure over http":      {Secure: "always", SiteURL: "http://chat.example.com", ErrorId: "model.config.is_valid.session_cookie_insecure_site_url.app_error"},
+		"lax over http":         {SameSite: "Lax", SiteURL: "http://chat.example.com"},
+		"domain of site":        {Domain: "chat.example.com", SiteURL: "https://chat.example.com"},
+		"parent domain":         {Domain: ".example.com", SiteURL: "https://chat.example.com"},
+		"domain without site":   {Domain: "example.com"},
+		"unrelated domain":      {Domain: "example.org", SiteURL: "https://chat.example.com", ErrorId: "model.config.is_valid.session_cookie_domain.app_error"},
+		"partial domain":        {Domain: "ample.com", SiteURL: "https://chat.example.com", ErrorId: "model.config.is_valid.session_cookie_domain.app_error"},
+		"domain with port":      {Domain: "example.com:8065", ErrorId: "model.config.is_valid.session_cookie_domain.app_error"},
+		"url instead of domain": {Domain: "https://example.com", ErrorId: "model.config.is_valid.session_cookie_domain.app_error"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ss := ServiceSettings{}
+			ss.SetDefaults()
+			if tc.SameSite != "" {
+				ss.SessionCookieSameSite = NewString(tc.SameSite)
+			}
+			if tc.Secure != "" {
+				ss.SessionCookieSecure = NewString(tc.Secure)
+			}
+			ss.SessionCookieDomain = NewString(tc.Domain)
+			ss.SiteURL = NewString(tc.SiteURL)
+
+			err := ss.isValidSessionCookie()
+			if tc.ErrorId == "" {
+				assert.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				assert.Equal(t, tc.ErrorId, err.Id)
+			}
+		})
+	}
+}
+
+func TestCorsSettingsSetDefaults(t *testing.T) {
+	t.Run("default, no old settings", func(t *testing.T) {
+		cs := CorsSettings{}
+		cs.SetDefaults(&ServiceSettings{})
+
+		assert.Empty(t, cs.AllowedOrigins)
+		assert.Equal(t, []string{"POST", "GET", "OPTIONS", "PUT", "PATCH", "DELETE"}, cs.AllowedMethods)
+		assert.Equal(t, []string{"*"}, cs.AllowedHeaders)
+		assert.Empty(t, cs.ExposedHeaders)
+		assert.False(t, *cs.AllowCredentials)
+		assert.Equal(t, CORS_SETTINGS_DEFAULT_MAX_AGE_SECONDS, *cs.MaxAgeSeconds)
+		assert.False(t, *cs.Debug)
+	})
+
+	t.Run("default, old settings", func(t *testing.T) {
+		cs := CorsSettings{}
+		ss := &ServiceSettings{
+			DEPRECATED_DO_NOT_USE_AllowCorsFrom:        NewString("http://mattermost.com  https://*.example.com"),
+			DEPRECATED_DO_NOT_USE_CorsExposedHeaders:   NewString("x-my-special-header x-blueberry"),
+			DEPRECATED_DO_NOT_USE_CorsAllowCredentials: NewBool(true),
+			DEPRECATED_DO_NOT_USE_CorsDebug:            NewBool(true),
+		}
+		cs.SetDefaults(ss)
+
+		assert.Equal(t, []string{"http://mattermost.com", "https://*.example.com"}, cs.AllowedOrigins)
+		assert.Equal(t, []string{"x-my-special-header", "x-blueberry"}, cs.ExposedHeaders)
+		assert.True(t, *cs.AllowCredentials)
+		assert.True(t, *cs.Debug)
+
+		assert.Equal(t, "", *ss.DEPRECATED_DO_NOT_USE_AllowCorsFrom)
+		assert.Equal(t, "", *ss.DEPRECATED_DO_NOT_USE_CorsExposedHeaders)
+		assert.False(t, *ss.DEPRECATED_DO_NOT_USE_CorsAllowCredentials)
+		assert.False(t, *ss.DEPRECATED_DO_NOT_USE_CorsDebug)
+	})
+
+	t.Run("old settings migrated by config", func(t *testing.T) {
+		c := Config{}
+		c.ServiceSettings.DEPRECATED_DO_NOT_USE_AllowCorsFrom = NewString("https://mattermost.com")
+		c.SetDefaults()
+
+		assert.Equal(t, []string{"https://mattermost.com"}, c.CorsSettings.AllowedOrigins)
+		assert.Equal(t, "", *c.ServiceSettings.DEPRECATED_DO_NOT_USE_AllowCorsFrom)
+
+		c.SetDefaults()
+		assert.Equal(t, []string{"https://mattermost.com"}, c.CorsSettings.AllowedOrigins, "migrating again shouldn't change anything")
+	})
+
+	t.Run("not default, old settings", func(t *testing.T) {
+		cs := CorsSettings{
+			AllowedOrigins:   []string{},
+			AllowCredentials: NewBool(false),
+		}
+		cs.SetDefaults(&ServiceSettings{
+			DEPRECATED_DO_NOT_USE_AllowCorsFrom:        NewString("http://mattermost.com"),
+			DEPRECATED_DO_NOT_USE_CorsAllowCredentials: NewBool(true),
+		})
+
+		assert.Empty(t, cs.AllowedOrigins)
+		assert.False(t, *cs.AllowCredentials)
+	})
+}
+
+func TestCorsSettingsIsValid(t *testing.T) {
+	for name, test := range map[string]struct {
+		AllowedOrigins []string
+		AllowedMethods []string
+		MaxAgeSeconds  int
+		ExpectedError  string
+	}{
+		"defaults": {},
+		"valid origins": {
+			AllowedOrigins: []string{"*", "http://mattermost.com", "https://*.example.com", "http://localhost:8065"},
+		},
+		"origin without a scheme": {
+			AllowedOrigins: []string{"mattermost.com"},
+			ExpectedError:  "model.config.is_valid.cors_allowed_origin.app_error",
+		},
+		"origin with a path": {
+			AllowedOrigins: []string{"https://mattermost.com/api"},
+			ExpectedError:  "model.config.is_valid.cors_allowed_origin.app_error",
+		},
+		"origin with two wildcards": {
+			AllowedOrigins: []string{"https://*.*.example.com"},
+			ExpectedError:  "model.config.is_valid.cors_allowed_origin.app_error",
+		},
+		"wildcard that isn't a whole label": {
+			AllowedOrigins: []string{"https://*example.com"},
+			ExpectedError:  "model.config.is_valid.cors_allowed_origin.app_error",
+		},
+		"unknown method": {
+			AllowedMethods: []string{"GET", "FETCH"},
+			ExpectedError:  "model.config.is_valid.cors_allowed_method.app_error",
+		},
+		"negative max age": {
+			MaxAgeSeconds: -1,
+			ExpectedError: "model.config.is_valid.cors_max_age.app_error",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cs := CorsSettings{
+				AllowedOrigins: test.AllowedOrigins,
+				AllowedMethods: test.AllowedMethods,
+				MaxAgeSeconds:  NewInt(test.MaxAgeSeconds),
+			}
+			cs.SetDefaults(&ServiceSettings{})
+
+			err := cs.isValid()
+			if test.ExpectedError == "" {
+				assert.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				assert.Equal(t, test.ExpectedError, err.Id)
+			}
+		})
+	}
+}
+
+func TestNotificationDefaultSettingsIsValid(t *testing.T) {
+	s := NotificationDefaultSettings{}
+	s.SetDefaults()
+	assert.Nil(t, s.isValid())
+
+	s.NotifyProps = map[string]string{EMAIL_NOTIFY_PROP: "false", PUSH_NOTIFY_PROP: USER_NOTIFY_NONE}
+	s.EnforcedNotifyProps = []string{EMAIL_NOTIFY_PROP}
+	assert.Nil(t, s.isValid())
+	assert.True(t, s.IsEnforced(EMAIL_NOTIFY_PROP))
+	assert.False(t, s.IsEnforced(PUSH_NOTIFY_PROP))
+
+	s.NotifyProps[MENTION_KEYS_NOTIFY_PROP] = "foo"
+	err := s.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.notification_defaults.notify_prop.app_error", err.Id)
+	delete(s.NotifyProps, MENTION_KEYS_NOTIFY_PROP)
+
+	s.NotifyProps[PUSH_NOTIFY_PROP] = "sometimes"
+	err = s.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.notification_defaults.value.app_error", err.Id)
+	s.NotifyProps[PUSH_NOTIFY_PROP] = USER_NOTIFY_NONE
+
+	s.EnforcedNotifyProps = append(s.EnforcedNotifyProps, DESKTOP_NOTIFY_PROP)
+	err = s.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.notification_defaults.enforced.app_error", err.Id)
+}
+
+func TestNotificationDefaultSettingsEnforceNotifyProps(t *testing.T) {
+	s := NotificationDefaultSettings{
+		NotifyProps:         map[string]string{EMAIL_NOTIFY_PROP: "false", PUSH_NOTIFY_PROP: USER_NOTIFY_NONE},
+		EnforcedNotifyProps: []string{EMAIL_NOTIFY_PROP},
+	}
+
+	props := StringMap{EMAIL_NOTIFY_PROP: "true", PUSH_NOTIFY_PROP: USER_NOTIFY_ALL}
+	s.EnforceNotifyProps(props)
+	assert.Equal(t, StringMap{EMAIL_NOTIFY_PROP: "false", PUSH_NOTIFY_PROP: USER_NOTIFY_ALL}, props)
+
+	channelProps := StringMap{EMAIL_NOTIFY_PROP: "true", PUSH_NOTIFY_PROP: USER_NOTIFY_ALL, MARK_UNREAD_NOTIFY_PROP: CHANNEL_MARK_UNREAD_MENTION}
+	s.EnforceChannelNotifyProps(channelProps)
+	assert.Equal(t, StringMap{EMAIL_NOTIFY_PROP: CHANNEL_NOTIFY_DEFAULT, PUSH_NOTIFY_PROP: USER_NOTIFY_ALL, MARK_UNREAD_NOTIFY_PROP: CHANNEL_MARK_UNREAD_MENTION}, channelProps)
+}
+
+func TestTraceSettingsIsValid(t *testing.T) {
+	ts := TraceSettings{}
+	ts.SetDefaults()
+	assert.Nil(t, ts.isValid())
+
+	ts.Enable = NewBool(true)
+	err := ts.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.trace.otlp_endpoint.app_error", err.Id)
+
+	ts.OTLPEndpoint = NewString("http://localhost:4318/v1/traces")
+	assert.Nil(t, ts.isValid())
+
+	ts.SamplingRatio = NewFloat64(1.5)
+	err = ts.isValid()
+	require.NotNil(t, err)
+	assert.Equal(t, "model.config.is_valid.trace.sampling_ratio.app_error", err.Id)
+
+	ts.SamplingRatio = NewFloat64(0)
+	assert.Nil(t, ts.isValid())
+}
+
+func TestDataLossPreventionSettingsIsValid(t *testing.T) {
+	for name, test := range map[string]struct {
+		Patterns      []DataLossPreventionPattern
+		ExpectedError string
+	}{
+		"defaults": {
+			Patterns: nil,
+		},
+		"empty": {
+			Patterns: []DataLossPreventionPattern{},
+		},
+		"missing name": {
+			Patterns:      []DataLossPreventionPattern{{Pattern: "secret"}},
+			ExpectedError: "model.config.is_valid.data_loss_prevention.name.app_error",
+		},
+		"missing pattern": {
+			Patterns:      []DataLossPreventionPattern{{Name: "Secret"}},
+			ExpectedError: "model.config.is_valid.data_loss_prevention.pattern.app_error",
+		},
+		"invalid pattern": {
+			Patterns:      []DataLossPreventionPattern{{Name: "Secret", Pattern: "secret("}},
+			ExpectedError: "model.config.is_valid.data_loss_prevention.pattern.app_error",
+		},
+		"luhn checksum": {
+			Patterns: []DataLossPreventionPattern{{Name: "Card", Pattern: "\\d+", Checksum: DATA_LOSS_PREVENTION_CHECKSUM_LUHN}},
+		},
+		"unknown checksum": {
+			Patterns:      []DataLossPreventionPattern{{Name: "Card", Pattern: "\\d+", Checksum: "crc32"}},
+			ExpectedError: "model.config.is_valid.data_loss_prevention.checksum.app_error",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			settings := DataLossPreventionSettings{Patterns: test.Patterns}
+			settings.SetDefaults()
+
+			err := settings.isValid()
+			if test.ExpectedError == "" {
+				assert.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				assert.Equal(t, test.ExpectedError, err.Id)
+			}
+		})
+	}
+}
+
+func TestSearchRateLimitSettingsIsValid(t *testing.T) {
+	for name, test := range map[string]struct {
+		Settings      SearchRateLimitSettings
+		ExpectedError string
+	}{
+		"defaults": {
+			Settings: SearchRateLimitSettings{},
+		},
+		"zero per user": {
+			Settings:      SearchRateLimitSettings{PerUserPerMinute: NewInt(0)},
+			ExpectedError: "model.config.is_valid.search_rate_limit.per_user_per_minute.app_error",
+		},
+		"negative per user burst": {
+			Settings:      SearchRateLimitSettings{PerUserMaxBurst: NewInt(-1)},
+			ExpectedError: "model.config.is_valid.search_rate_limit.per_user_max_burst.app_error",
+		},
+		"zero global": {
+			Settings:      SearchRateLimitSettings{GlobalPerMinute: NewInt(0)},
+			ExpectedError: "model.config.is_valid.search_rate_limit.global_per_minute.app_error",
+		},
+		"zero global burst": {
+			Settings:      SearchRateLimitSettings{GlobalMaxBurst: NewInt(0)},
+			ExpectedError: "model.config.is_valid.search_rate_limit.global_max_burst.app_error",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			settings := test.Settings
+			settings.SetDefaults()
+
+			err := settings.isValid()
+			if test.ExpectedError == "" {
+				assert.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				assert.Equal(t, test.ExpectedError, err.Id)
+			}
+		})
+	}
+}
+
+func TestFeatureFlagSettingsIsEnabled(t *testing.T) {
+	s := FeatureFlagSettings{}
+	s.SetDefaults()
+	assert.Empty(t, s.Flags)
+	assert.False(t, s.IsEnabled("new_endpoint"))
+
+	s.Flags["new_endpoint"] = true
+	s.Flags["old_endpoint"] = false
+	assert.True(t, s.IsEnabled("new_endpoint"))
+	assert.False(t, s.IsEnabled("old_endpoint"))
+	assert.False(t, s.IsEnabled("other_endpoint"))
+}
+
+func TestServiceSettingsTrustedProxies(t *testing.T) {
+	for name, test := range map[string]struct {
+		TrustedProxyIPHeader string
+		TrustedProxies       []string
+		ExpectedError        string
+	}{
+		"defaults": {
+			TrustedProxyIPHeader: HEADER_FORWARDED,
+		},
+		"addresses and ranges": {
+			TrustedProxyIPHeader: HEADER_FORWARDED,
+			TrustedProxies:       []string{"10.0.0.1", "172.16.0.0/12", "fd00::/8"},
+		},
+		"missing header": {
+			TrustedProxyIPHeader: "",
+			ExpectedError:        "model.config.is_valid.trusted_proxy_ip_header.app_error",
+		},
+		"invalid proxy": {
+			TrustedProxyIPHeader: HEADER_FORWARDED,
+			TrustedProxies:       []string{"10.0.0.0/33"},
+			ExpectedError:        "model.config.is_valid.trusted_proxies.app_error",
+		},
+		"hostname": {
+			TrustedProxyIPHeader: HEADER_FORWARDED,
+			TrustedProxies:       []string{"proxy.example.com"},
+			ExpectedError:        "model.config.is_valid.trusted_proxies.app_error",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := Config{}
+			c.SetDefaults()
+			c.ServiceSettings.TrustedProxyIPHeader = NewString(test.TrustedProxyIPHeader)
+			c.ServiceSettings.TrustedProxies = test.TrustedProxies
+
+			err := c.IsValid()
+			if test.ExpectedError == "" {
+				assert.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				assert.Equal(t, test.ExpectedError, err.Id)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// CountRepairRequest asks for the denormalized message counts of channels, which unread counts are worked out from, to
+// be checked against the posts that they count and corrected. ChannelId limits it to one channel and TeamId to the
+// channels of one team, while it otherwise covers every channel, including direct and group messages. With DryRun
+// set, the counts that are wrong are only reported.
+type CountRepairRequest struct {
+	TeamId    string `json:"team_id"`
+	ChannelId string `json:"channel_id"`
+	DryRun    bool   `json:"dry_run"`
+}
+
+// ChannelMsgCounts are the message counts of a channel next to what they should be. ActualMsgCount is the number of
+// posts that the channel's TotalMsgCount should have counted, and InvalidMemberCounts the number of its members whose
+// own counts are impossible, such as having read more messages than were ever posted.
+type ChannelMsgCounts struct {
+	ChannelId           string `json:"channel_id"`
+	TotalMsgCount       int64  `json:"total_msg_count"`
+	ActualMsgCount      int64  `json:"actual_msg_count"`
+	InvalidMemberCounts int64  `json:"invalid_member_counts"`
+}
+
+// CountRepairCheckpoint records how far a repair of message counts got. Channels are checked in order of their ids,
+// so the repair can resume right after the last channel that was checked. Channels whose counts were corrected by the
+// time they were repaired are skipped.
+type CountRepairCheckpoint struct {
+	AfterId               string `json:"after_id"`
+	Checked               int64  `json:"checked"`
+	MsgCountDiscrepancies int64  `json:"msg_count_discrepancies"`
+	MemberDiscrepancies   int64  `json:"member_discrepancies"`
+	Repaired              int64  `json:"repaired"`
+	Skipped               int64  `json:"skipped"`
+}
+
+func (o *CountRepairRequest) IsValid() *AppError {
+	if o.TeamId != "" && !IsValidId(o.TeamId) {
+		return NewAppError("CountRepairRequest.IsValid", "model.count_repair_request.is_valid.team_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if o.ChannelId != "" && !IsValidId(o.ChannelId) {
+		return NewAppError("CountRepairRequest.IsValid", "model.count_repair_request.is_valid.channel_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if o.TeamId != "" && o.ChannelId != "" {
+		return NewAppError("CountRepairRequest.IsValid", "model.count_repair_request.is_valid.scope.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (o *CountRepairRequest) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func CountRepairRequestFromJson(data io.Reader) *CountRepairRequest {
+	var o *CountRepairRequest
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+// IsConsistent returns whether neither the channel's message count nor those of its members need to be repaired.
+func (o *ChannelMsgCounts) IsConsistent() bool {
+	return o.TotalMsgCount == o.ActualMsgCount && o.InvalidMemberCounts == 0
+}
+
+func (c *CountRepairCheckpoint) ToJson() string {
+	b, _ := json.Marshal(c)
+	return string(b)
+}
+
+func CountRepairCheckpointFromJson(data io.Reader) *CountRepairCheckpoint {
+	var checkpoint *CountRepairCheckpoint
+	json.NewDecoder(data).Decode(&checkpoint)
+	return checkpoint
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountRepairRequestIsValid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		Request *CountRepairRequest
+		ErrorId string
+	}{
+		"everything":       {Request: &CountRepairRequest{}},
+		"team":             {Request: &CountRepairRequest{TeamId: NewId()}},
+		"channel":          {Request: &CountRepairRequest{ChannelId: NewId(), DryRun: true}},
+		"invalid team":     {Request: &CountRepairRequest{TeamId: "junk"}, ErrorId: "model.count_repair_request.is_valid.team_id.app_error"},
+		"invalid channel":  {Request: &CountRepairRequest{ChannelId: "junk"}, ErrorId: "model.count_repair_request.is_valid.channel_id.app_error"},
+		"team and channel": {Request: &CountRepairRequest{TeamId: NewId(), ChannelId: NewId()}, ErrorId: "model.count_repair_request.is_valid.scope.app_error"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := tc.Request.IsValid()
+			if tc.ErrorId == "" {
+				assert.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				assert.Equal(t, tc.ErrorId, err.Id)
+			}
+		})
+	}
+}
+
+func TestChannelMsgCountsIsConsistent(t *testing.T) {
+	assert.True(t, (&ChannelMsgCounts{TotalMsgCount: 5, ActualMsgCount: 5}).IsConsistent())
+	assert.False(t, (&ChannelMsgCounts{TotalMsgCount: 6, ActualMsgCount: 5}).IsConsistent())
+	assert.False(t, (&ChannelMsgCounts{TotalMsgCount: 5, ActualMsgCount: 5, InvalidMemberCounts: 1}).IsConsistent())
+}
+
+func TestCountRepairJson(t *testing.T) {
+	request := &CountRepairRequest{TeamId: NewId(), DryRun: true}
+	assert.Equal(t, request, CountRepairRequestFromJson(strings.NewReader(request.ToJson())))
+	assert.Nil(t, CountRepairRequestFromJson(strings.NewReader("garbage")))
+
+	checkpoint := &CountRepairCheckpoint{
+		AfterId:               NewId(),
+		Checked:               100,
+		MsgCountDiscrepancies: 3,
+		MemberDiscrepancies:   2,
+		Repaired:              4,
+		Skipped:               1,
+	}
+	assert.Equal(t, checkpoint, CountRepairCheckpointFromJson(strings.NewReader(checkpoint.ToJson())))
+	assert.Nil(t, CountRepairCheckpointFromJson(strings.NewReader("garbage")))
+}
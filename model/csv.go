@@ -0,0 +1,96 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// CsvColumn maps a field of a model to a column of a CSV file. Value is passed each model being written, so it must
+// accept whichever type the list of columns is used with.
+type CsvColumn struct {
+	Header string
+	Value  func(row interface{}) string
+}
+
+// CsvWriter writes CSV records like csv.Writer, except that cells which a spreadsheet would evaluate as a formula are
+// escaped with a leading quote, so that user provided text can't run anything when an export is opened. Every CSV file
+// that the server produces should be written through it.
+type CsvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func NewCsvWriter(w io.Writer) *CsvWriter {
+	return &CsvWriter{w: csv.NewWriter(w)}
+}
+
+func (w *CsvWriter) Write(record []string) error {
+	w.record = w.record[:0]
+	for _, cell := range record {
+		w.record = append(w.record, EscapeCsvFormula(cell))
+	}
+
+	return w.w.Write(w.record)
+}
+
+func (w *CsvWriter) Flush() {
+	w.w.Flush()
+}
+
+func (w *CsvWriter) Error() error {
+	return w.w.Error()
+}
+
+// EscapeCsvFormula prefixes a cell starting with one of the characters that begin a spreadsheet formula with a quote,
+// so that it's shown as text instead. Numbers, including negative ones, are left as they are.
+func EscapeCsvFormula(cell string) string {
+	if cell == "" {
+		return cell
+	}
+
+	switch cell[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		if _, err := strconv.ParseFloat(cell, 64); err == nil {
+			return cell
+		}
+
+		return "'" + cell
+	}
+
+	return cell
+}
+
+// ToCsv writes a header row naming the given columns, followed by a row for each of the given models.
+func ToCsv(columns []CsvColumn, rows []interface{}) string {
+	var b bytes.Buffer
+	w := NewCsvWriter(&b)
+
+	record := make([]string, len(columns))
+	for i, column := range columns {
+		record[i] = column.Header
+	}
+	w.Write(record)
+
+	for _, row := range rows {
+		for i, column := range columns {
+			record[i] = column.Value(row)
+		}
+		w.Write(record)
+	}
+
+	w.Flush()
+	return b.String()
+}
+
+func csvInt64(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
+
+func csvBool(b bool) string {
+	return strconv.FormatBool(b)
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToCsv(t *testing.T) {
+	columns := []CsvColumn{
+		{"name", func(row interface{}) string { return row.(string) }},
+		{"length", func(row interface{}) string { return csvInt64(int64(len(row.(string)))) }},
+	}
+
+	assert.Equal(t, "name,length\n", ToCsv(columns, nil))
+	assert.Equal(t, "name,length\nabc,3\n\"a,\"\"b\"\"\",5\n", ToCsv(columns, []interface{}{"abc", `a,"b"`}))
+	assert.Equal(t, "name,length\n'=1+2,4\n", ToCsv(columns, []interface{}{"=1+2"}))
+}
+
+func TestEscapeCsvFormula(t *testing.T) {
+	for input, expected := range map[string]string{
+		"":                  "",
+		"text":              "text",
+		"a=b":               "a=b",
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"+1+2":              "'+1+2",
+		"-2+3":              "'-2+3",
+		"@SUM(A1)":          "'@SUM(A1)",
+		"\t=1":              "'\t=1",
+		"\r=1":              "'\r=1",
+		"-12":               "-12",
+		"+1.5":              "+1.5",
+		"-":                 "'-",
+	} {
+		assert.Equal(t, expected, EscapeCsvFormula(input), input)
+	}
+}
+
+func TestCsvWriter(t *testing.T) {
+	var b strings.Builder
+	w := NewCsvWriter(&b)
+
+	record := []string{"=cmd", "ok"}
+	require.Nil(t, w.Write(record))
+	w.Flush()
+	require.Nil(t, w.Error())
+
+	assert.Equal(t, "'=cmd,ok\n", b.String())
+	assert.Equal(t, []string{"=cmd", "ok"}, record, "the record passed in shouldn't be modified")
+}
+
+func TestUserListToCsv(t *testing.T) {
+	users := []*User{
+		{Id: NewId(), Username: "user1", Email: "user1@example.com", EmailVerified: true, FirstName: "First, Name", CreateAt: 1234},
+		{Id: NewId(), Username: "user2"},
+	}
+
+	records, err := csv.NewReader(strings.NewReader(UserListToCsv(users))).ReadAll()
+	require.Nil(t, err)
+	require.Len(t, records, 3)
+
+	assert.Equal(t, []string{"id", "create_at", "update_at", "delete_at", "username", "auth_service", "email", "email_verified", "nickname", "first_name", "last_name", "position", "roles", "locale", "last_activity_at"}, records[0])
+	assert.Equal(t, []string{users[0].Id, "1234", "0", "0", "user1", "", "user1@example.com", "true", "", "First, Name", "", "", "", "", "0"}, records[1])
+	assert.Equal(t, users[1].Id, records[2][0])
+	assert.Equal(t, "", records[2][6])
+}
+
+func TestChannelListToCsv(t *testing.T) {
+	channel := &Channel{Id: NewId(), TeamId: NewId(), Type: CHANNEL_OPEN, DisplayName: "Town Square", Name: "town-square", TotalMsgCount: 10}
+
+	t.Run("channel list", func(t *testing.T) {
+		channels := ChannelList{channel}
+
+		records, err := csv.NewReader(strings.NewReader(channels.ToCsv())).ReadAll()
+		require.Nil(t, err)
+		require.Len(t, records, 2)
+		assert.Len(t, records[0], len(ChannelCsvColumns))
+		assert.Equal(t, []string{channel.Id, "0", "0", "0", channel.TeamId, "O", "Town Square", "town-square", "", "", "0", "10", ""}, records[1])
+	})
+
+	t.Run("channel list with team data", func(t *testing.T) {
+		channels := ChannelListWithTeamData{{Channel: *channel, TeamName: "team", TeamDisplayName: "Team"}}
+
+		records, err := csv.NewReader(strings.NewReader(channels.ToCsv())).ReadAll()
+		require.Nil(t, err)
+		require.Len(t, records, 2)
+		assert.Equal(t, []string{"team_name", "team_display_name"}, records[0][len(ChannelCsvColumns):])
+		assert.Equal(t, channel.Id, records[1][0])
+		assert.Equal(t, []string{"team", "Team"}, records[1][len(ChannelCsvColumns):])
+	})
+}
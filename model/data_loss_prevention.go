@@ -0,0 +1,133 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+)
+
+const (
+	DATA_LOSS_PREVENTION_CREDIT_CARD_PATTERN = `\b(?:\d[ -]?){12,18}\d\b`
+	DATA_LOSS_PREVENTION_SSN_PATTERN         = `\b\d{3}-\d{2}-\d{4}\b`
+
+	// DATA_LOSS_PREVENTION_CHECKSUM_LUHN only keeps the matches of a pattern whose digits pass the Luhn check, as the
+	// numbers of payment cards do, so that other long numbers such as order or phone numbers aren't flagged.
+	DATA_LOSS_PREVENTION_CHECKSUM_LUHN = "luhn"
+)
+
+// DataLossPreventionWarning is a match of a DataLossPreventionPattern in a message, given as the byte offsets of
+// the matching text.
+type DataLossPreventionWarning struct {
+	Name  string `json:"name"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// DataLossPreventionScan is the result of checking a message against the DataLossPreventionSettings. Blocked is
+// whether the message would be rejected if it were posted as is.
+type DataLossPreventionScan struct {
+	Warnings []*DataLossPreventionWarning `json:"warnings"`
+	Blocked  bool                         `json:"blocked"`
+}
+
+type dataLossPreventionMatcher struct {
+	name     string
+	re       *regexp.Regexp
+	checksum string
+}
+
+// DataLossPreventionScanner checks messages against the patterns in DataLossPreventionSettings, which are compiled
+// once when the scanner is created rather than for every message.
+type DataLossPreventionScanner struct {
+	matchers []*dataLossPreventionMatcher
+	block    bool
+}
+
+func NewDataLossPreventionScanner(settings *DataLossPreventionSettings) (*DataLossPreventionScanner, error) {
+	scanner := &DataLossPreventionScanner{
+		matchers: make([]*dataLossPreventionMatcher, 0, len(settings.Patterns)),
+		block:    *settings.BlockMatchingPosts,
+	}
+
+	for _, pattern := range settings.Patterns {
+		re, err := regexp.Compile(pattern.Pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		scanner.matchers = append(scanner.matchers, &dataLossPreventionMatcher{
+			name:     pattern.Name,
+			re:       re,
+			checksum: pattern.Checksum,
+		})
+	}
+
+	return scanner, nil
+}
+
+// Scan returns the matches of each of the patterns in the given message. A nil scanner, as used when data loss
+// prevention is disabled, never finds any.
+func (s *DataLossPreventionScanner) Scan(message string) *DataLossPreventionScan {
+	scan := &DataLossPreventionScan{Warnings: []*DataLossPreventionWarning{}}
+	if s == nil {
+		return scan
+	}
+
+	for _, matcher := range s.matchers {
+		for _, match := range matcher.re.FindAllStringIndex(message, -1) {
+			if matcher.checksum == DATA_LOSS_PREVENTION_CHECKSUM_LUHN && !IsValidLuhnNumber(message[match[0]:match[1]]) {
+				continue
+			}
+
+			scan.Warnings = append(scan.Warnings, &DataLossPreventionWarning{
+				Name:  matcher.name,
+				Start: match[0],
+				End:   match[1],
+			})
+		}
+	}
+
+	scan.Blocked = s.block && len(scan.Warnings) > 0
+
+	return scan
+}
+
+// IsValidLuhnNumber returns whether the digits in the given text pass the Luhn check. Any other characters, such as the
+// spaces or hyphens separating the groups of digits in a card number, are ignored.
+func IsValidLuhnNumber(text string) bool {
+	sum := 0
+	digits := 0
+	for i := len(text) - 1; i >= 0; i-- {
+		c := text[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		digit := int(c - '0')
+		if digits%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+
+		sum += digit
+		digits++
+	}
+
+	return digits > 1 && sum%10 == 0
+}
+
+func (s *DataLossPreventionScan) ToJson() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func DataLossPreventionScanFromJson(data io.Reader) *DataLossPreventionScan {
+	var s *DataLossPreventionScan
+	json.NewDecoder(data).Decode(&s)
+	return s
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataLossPreventionScanner(t *testing.T) {
+	settings := DataLossPreventionSettings{}
+	settings.SetDefaults()
+
+	message := "card 4111 1111 1111 1111 and ssn 123-45-6789"
+
+	t.Run("disabled", func(t *testing.T) {
+		var scanner *DataLossPreventionScanner
+		scan := scanner.Scan(message)
+		assert.Empty(t, scan.Warnings)
+		assert.False(t, scan.Blocked)
+	})
+
+	t.Run("default patterns", func(t *testing.T) {
+		scanner, err := NewDataLossPreventionScanner(&settings)
+		require.Nil(t, err)
+
+		scan := scanner.Scan(message)
+		require.Len(t, scan.Warnings, 2)
+		assert.Equal(t, "Credit card number", scan.Warnings[0].Name)
+		assert.Equal(t, "4111 1111 1111 1111", message[scan.Warnings[0].Start:scan.Warnings[0].End])
+		assert.Equal(t, "Social Security number", scan.Warnings[1].Name)
+		assert.Equal(t, "123-45-6789", message[scan.Warnings[1].Start:scan.Warnings[1].End])
+		assert.False(t, scan.Blocked)
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		scanner, err := NewDataLossPreventionScanner(&settings)
+		require.Nil(t, err)
+
+		scan := scanner.Scan("meeting at 10:30 in room 204")
+		assert.Empty(t, scan.Warnings)
+		assert.False(t, scan.Blocked)
+	})
+
+	t.Run("numbers failing the checksum", func(t *testing.T) {
+		scanner, err := NewDataLossPreventionScanner(&settings)
+		require.Nil(t, err)
+
+		assert.Empty(t, scanner.Scan("order 4111 1111 1111 1112").Warnings)
+		assert.Empty(t, scanner.Scan("tracking number 1234567890123456").Warnings)
+		assert.Len(t, scanner.Scan("amex 3782-822463-10005").Warnings, 1)
+	})
+
+	t.Run("blocking", func(t *testing.T) {
+		blocking := settings
+		blocking.BlockMatchingPosts = NewBool(true)
+
+		scanner, err := NewDataLossPreventionScanner(&blocking)
+		require.Nil(t, err)
+
+		assert.True(t, scanner.Scan(message).Blocked)
+		assert.False(t, scanner.Scan("nothing to see here").Blocked)
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		invalid := settings
+		invalid.Patterns = []DataLossPreventionPattern{{Name: "Secret", Pattern: "secret("}}
+
+		_, err := NewDataLossPreventionScanner(&invalid)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		scanner, err := NewDataLossPreventionScanner(&settings)
+		require.Nil(t, err)
+
+		scan := scanner.Scan(message)
+		assert.Equal(t, scan, DataLossPreventionScanFromJson(strings.NewReader(scan.ToJson())))
+	})
+}
+
+func TestIsValidLuhnNumber(t *testing.T) {
+	for number, expected := range map[string]bool{
+		"4111111111111111":    true,
+		"4111 1111 1111 1111": true,
+		"5500-0000-0000-0004": true,
+		"378282246310005":     true,
+		"4111111111111112":    false,
+		"1234567890123456":    false,
+		"0":                   false,
+		"":                    false,
+	} {
+		assert.Equal(t, expected, IsValidLuhnNumber(number), number)
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ElasticsearchReindexCheckpoint records how far a reindex of a team or channel got. Channels are reindexed in order
+// of their ids and posts in order of their creation, so the reindex can resume right after the last post that was
+// indexed.
+type ElasticsearchReindexCheckpoint struct {
+	ChannelId     string `json:"channel_id"`
+	AfterCreateAt int64  `json:"after_create_at"`
+	AfterId       string `json:"after_id"`
+	Reindexed     int64  `json:"reindexed"`
+}
+
+func (c *ElasticsearchReindexCheckpoint) ToJson() string {
+	b, _ := json.Marshal(c)
+	return string(b)
+}
+
+func ElasticsearchReindexCheckpointFromJson(data io.Reader) *ElasticsearchReindexCheckpoint {
+	var checkpoint *ElasticsearchReindexCheckpoint
+	json.NewDecoder(data).Decode(&checkpoint)
+	return checkpoint
+}
@@ -0,0 +1,23 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElasticsearchReindexCheckpointJson(t *testing.T) {
+	checkpoint := &ElasticsearchReindexCheckpoint{
+		ChannelId:     NewId(),
+		AfterCreateAt: 1234,
+		AfterId:       NewId(),
+		Reindexed:     500,
+	}
+
+	assert.Equal(t, checkpoint, ElasticsearchReindexCheckpointFromJson(strings.NewReader(checkpoint.ToJson())))
+	assert.Nil(t, ElasticsearchReindexCheckpointFromJson(strings.NewReader("garbage")))
+}
@@ -8,6 +8,9 @@ import (
 	"io"
 	"net/http"
 	"regexp"
+	"sort"
+	"strings"
+	"sync"
 )
 
 const (
@@ -31,6 +34,29 @@ func inSystemEmoji(emojiName string) bool {
 	return ok
 }
 
+var systemEmojiNames []string
+var systemEmojiNamesOnce sync.Once
+
+// SystemEmojiNamesWithPrefix returns the names, including aliases, of the system emoji starting with the given prefix
+// in alphabetical order.
+func SystemEmojiNamesWithPrefix(prefix string) []string {
+	systemEmojiNamesOnce.Do(func() {
+		systemEmojiNames = make([]string, 0, len(SystemEmojis))
+		for name := range SystemEmojis {
+			systemEmojiNames = append(systemEmojiNames, name)
+		}
+		sort.Strings(systemEmojiNames)
+	})
+
+	start := sort.SearchStrings(systemEmojiNames, prefix)
+	end := start
+	for end < len(systemEmojiNames) && strings.HasPrefix(systemEmojiNames[end], prefix) {
+		end++
+	}
+
+	return append([]string{}, systemEmojiNames[start:end]...)
+}
+
 func (emoji *Emoji) IsValid() *AppError {
 	if len(emoji.Id) != 26 {
 		return NewAppError("Emoji.IsValid", "model.emoji.id.app_error", nil, "", http.StatusBadRequest)
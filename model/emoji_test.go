@@ -61,3 +61,10 @@ func TestEmojiIsValid(t *testing.T) {
 	emoji.Name = "croissant"
 	require.NotNil(t, emoji.IsValid())
 }
+
+func TestSystemEmojiNamesWithPrefix(t *testing.T) {
+	require.Equal(t, []string{"thumbsdown", "thumbsup"}, SystemEmojiNamesWithPrefix("thumbs"))
+	require.Equal(t, []string{"tada"}, SystemEmojiNamesWithPrefix("tada"))
+	require.Empty(t, SystemEmojiNamesWithPrefix("notanemoji"))
+	require.Len(t, SystemEmojiNamesWithPrefix(""), len(SystemEmojis))
+}
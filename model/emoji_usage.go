@@ -0,0 +1,114 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"unicode/utf8"
+)
+
+const (
+	EMOJI_USAGE_MAX_ENTRIES = 20
+
+	// The weight of a use of an emoji halves every week so that recent favourites outrank old ones.
+	EMOJI_USAGE_HALF_LIFE = 7 * 24 * 60 * 60 * 1000
+
+	// Emoji usage is stored as a preference, so it has to fit in a preference value.
+	EMOJI_USAGE_MAX_LENGTH = 2000
+)
+
+type EmojiUsage struct {
+	Name       string `json:"name"`
+	Count      int64  `json:"count"`
+	LastUsedAt int64  `json:"last_used_at"`
+}
+
+// Score returns how strongly the emoji should be suggested at the given time, based on how often and how recently
+// it was used.
+func (u *EmojiUsage) Score(now int64) float64 {
+	age := now - u.LastUsedAt
+	if age < 0 {
+		age = 0
+	}
+	return float64(u.Count) * math.Pow(0.5, float64(age)/EMOJI_USAGE_HALF_LIFE)
+}
+
+type EmojiUsageList []*EmojiUsage
+
+// Record counts a use of each of the given emoji at the given time and returns the updated list, sorted by score and
+// pruned of the least used emoji so that it stays small.
+func (l EmojiUsageList) Record(names []string, now int64) EmojiUsageList {
+	usages := make(map[string]*EmojiUsage, len(l))
+	for _, usage := range l {
+		usages[usage.Name] = usage
+	}
+
+	for _, name := range names {
+		usage, ok := usages[name]
+		if !ok {
+			usage = &EmojiUsage{Name: name}
+			usages[name] = usage
+			l = append(l, usage)
+		}
+
+		usage.Count++
+		usage.LastUsedAt = now
+	}
+
+	sort.SliceStable(l, func(i, j int) bool {
+		return l[i].Score(now) > l[j].Score(now)
+	})
+
+	if len(l) > EMOJI_USAGE_MAX_ENTRIES {
+		l = l[:EMOJI_USAGE_MAX_ENTRIES]
+	}
+
+	for len(l) > 0 && utf8.RuneCountInString(l.ToJson()) > EMOJI_USAGE_MAX_LENGTH {
+		l = l[:len(l)-1]
+	}
+
+	return l
+}
+
+// Scores returns the score of each emoji in the list by name.
+func (l EmojiUsageList) Scores(now int64) map[string]float64 {
+	scores := make(map[string]float64, len(l))
+	for _, usage := range l {
+		scores[usage.Name] = usage.Score(now)
+	}
+	return scores
+}
+
+func (l EmojiUsageList) ToJson() string {
+	b, _ := json.Marshal(l)
+	return string(b)
+}
+
+func EmojiUsageListFromJson(data io.Reader) EmojiUsageList {
+	var l EmojiUsageList
+	json.NewDecoder(data).Decode(&l)
+	return l
+}
+
+// EmojiSuggestion is an emoji offered by autocomplete. System emoji have a Unicode code point sequence and custom
+// emoji have the id of the Emoji.
+type EmojiSuggestion struct {
+	Name    string `json:"name"`
+	Unicode string `json:"unicode,omitempty"`
+	EmojiId string `json:"emoji_id,omitempty"`
+}
+
+func EmojiSuggestionListToJson(suggestions []*EmojiSuggestion) string {
+	b, _ := json.Marshal(suggestions)
+	return string(b)
+}
+
+func EmojiSuggestionListFromJson(data io.Reader) []*EmojiSuggestion {
+	var suggestions []*EmojiSuggestion
+	json.NewDecoder(data).Decode(&suggestions)
+	return suggestions
+}
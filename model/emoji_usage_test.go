@@ -0,0 +1,72 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmojiUsageScore(t *testing.T) {
+	usage := &EmojiUsage{Name: "tada", Count: 4, LastUsedAt: 1000}
+
+	assert.Equal(t, float64(4), usage.Score(1000))
+	assert.Equal(t, float64(2), usage.Score(1000+EMOJI_USAGE_HALF_LIFE))
+	assert.Equal(t, float64(4), usage.Score(0))
+}
+
+func TestEmojiUsageListRecord(t *testing.T) {
+	now := GetMillis()
+
+	t.Run("counts uses", func(t *testing.T) {
+		l := EmojiUsageList{}.Record([]string{"tada", "smile"}, now)
+		l = l.Record([]string{"smile"}, now+1)
+
+		require.Len(t, l, 2)
+		assert.Equal(t, "smile", l[0].Name)
+		assert.Equal(t, int64(2), l[0].Count)
+		assert.Equal(t, now+1, l[0].LastUsedAt)
+		assert.Equal(t, "tada", l[1].Name)
+		assert.Equal(t, int64(1), l[1].Count)
+	})
+
+	t.Run("recent use outranks old use", func(t *testing.T) {
+		l := EmojiUsageList{{Name: "tada", Count: 3, LastUsedAt: now - 4*EMOJI_USAGE_HALF_LIFE}}
+		l = l.Record([]string{"smile"}, now)
+
+		require.Len(t, l, 2)
+		assert.Equal(t, "smile", l[0].Name)
+	})
+
+	t.Run("prunes the least used emoji", func(t *testing.T) {
+		l := EmojiUsageList{}
+		for i := 0; i < EMOJI_USAGE_MAX_ENTRIES+5; i++ {
+			l = l.Record([]string{"favourite", NewId()}, now+int64(i))
+		}
+
+		assert.Len(t, l, EMOJI_USAGE_MAX_ENTRIES)
+		assert.Equal(t, "favourite", l[0].Name)
+	})
+
+	t.Run("fits in a preference", func(t *testing.T) {
+		l := EmojiUsageList{}
+		for i := 0; i < EMOJI_USAGE_MAX_ENTRIES; i++ {
+			l = l.Record([]string{strings.Repeat("x", EMOJI_NAME_MAX_LENGTH-2) + NewId()[:2]}, now)
+		}
+
+		assert.True(t, len(l.ToJson()) <= EMOJI_USAGE_MAX_LENGTH)
+		assert.NotEmpty(t, l)
+
+		rl := EmojiUsageListFromJson(strings.NewReader(l.ToJson()))
+		assert.Equal(t, l, rl)
+	})
+}
+
+func TestEmojiUsageListScores(t *testing.T) {
+	l := EmojiUsageList{{Name: "tada", Count: 2, LastUsedAt: 1000}}
+	assert.Equal(t, map[string]float64{"tada": 2}, l.Scores(1000))
+}
@@ -15,6 +15,12 @@ import (
 	"strings"
 )
 
+const (
+	// Files are stored in the main file store unless they've been moved to cold storage because of their age.
+	FILE_INFO_STORAGE_TIER_HOT  = ""
+	FILE_INFO_STORAGE_TIER_COLD = "cold"
+)
+
 type FileInfo struct {
 	Id              string `json:"id"`
 	CreatorId       string `json:"user_id"`
@@ -32,6 +38,8 @@ type FileInfo struct {
 	Width           int    `json:"width,omitempty"`
 	Height          int    `json:"height,omitempty"`
 	HasPreviewImage bool   `json:"has_preview_image,omitempty"`
+	StorageTier     string `json:"-"` // not sent back to the client
+	Checksum        string `json:"-"` // not sent back to the client
 }
 
 func (info *FileInfo) ToJson() string {
@@ -105,13 +113,34 @@ func (o *FileInfo) IsValid() *AppError {
 		return NewAppError("FileInfo.IsValid", "model.file_info.is_valid.path.app_error", nil, "id="+o.Id, http.StatusBadRequest)
 	}
 
+	if o.StorageTier != FILE_INFO_STORAGE_TIER_HOT && o.StorageTier != FILE_INFO_STORAGE_TIER_COLD {
+		return NewAppError("FileInfo.IsValid", "model.file_info.is_valid.storage_tier.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
 	return nil
 }
 
+// GetPaths returns the paths of the file and of any thumbnail and preview that were generated for it.
+func (o *FileInfo) GetPaths() []string {
+	paths := []string{o.Path}
+	if o.ThumbnailPath != "" {
+		paths = append(paths, o.ThumbnailPath)
+	}
+	if o.PreviewPath != "" {
+		paths = append(paths, o.PreviewPath)
+	}
+	return paths
+}
+
 func (o *FileInfo) IsImage() bool {
 	return strings.HasPrefix(o.MimeType, "image")
 }
 
+// IsSvg returns whether the file is an SVG, which browsers may run scripts from unless it's been sanitized.
+func (o *FileInfo) IsSvg() bool {
+	return o.MimeType == "image/svg+xml" || strings.EqualFold(o.Extension, "svg")
+}
+
 func NewInfo(name string) *FileInfo {
 	info := &FileInfo{
 		Name: name,
@@ -58,6 +58,32 @@ func TestFileInfoIsValid(t *testing.T) {
 	if err := info.IsValid(); err != nil {
 		t.Fatal(err)
 	}
+
+	info.StorageTier = FILE_INFO_STORAGE_TIER_COLD
+	if err := info.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	info.StorageTier = "glacier"
+	if err := info.IsValid(); err == nil {
+		t.Fatal("unknown StorageTier isn't valid")
+	}
+}
+
+func TestFileInfoGetPaths(t *testing.T) {
+	info := &FileInfo{
+		Path: "fake/path.png",
+	}
+
+	if paths := info.GetPaths(); len(paths) != 1 || paths[0] != "fake/path.png" {
+		t.Fatal("should only return the file's path", paths)
+	}
+
+	info.ThumbnailPath = "fake/path_thumb.jpg"
+	info.PreviewPath = "fake/path_preview.jpg"
+	if paths := info.GetPaths(); len(paths) != 3 || paths[1] != "fake/path_thumb.jpg" || paths[2] != "fake/path_preview.jpg" {
+		t.Fatal("should return the thumbnail and preview paths too", paths)
+	}
 }
 
 func TestFileInfoIsImage(t *testing.T) {
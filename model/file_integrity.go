@@ -0,0 +1,57 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	FILE_INTEGRITY_STATUS_OK          = "ok"
+	FILE_INTEGRITY_STATUS_MISMATCH    = "mismatch"
+	FILE_INTEGRITY_STATUS_MISSING     = "missing"
+	FILE_INTEGRITY_STATUS_NO_CHECKSUM = "no_checksum"
+)
+
+// FileIntegrityResult is the outcome of comparing a file's contents against the checksum recorded in its FileInfo when
+// it was uploaded. Files uploaded before checksums were recorded have the status FILE_INTEGRITY_STATUS_NO_CHECKSUM.
+type FileIntegrityResult struct {
+	FileId           string `json:"file_id"`
+	Status           string `json:"status"`
+	ExpectedChecksum string `json:"expected_checksum,omitempty"`
+	ActualChecksum   string `json:"actual_checksum,omitempty"`
+}
+
+func (r *FileIntegrityResult) ToJson() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+func FileIntegrityResultFromJson(data io.Reader) *FileIntegrityResult {
+	var r *FileIntegrityResult
+	json.NewDecoder(data).Decode(&r)
+	return r
+}
+
+// FileIntegrityScanCheckpoint records how far a scan of every file got. Files are scanned in order of their ids, so
+// the scan can resume right after the last file that was checked.
+type FileIntegrityScanCheckpoint struct {
+	AfterId    string `json:"after_id"`
+	Checked    int64  `json:"checked"`
+	Mismatched int64  `json:"mismatched"`
+	Missing    int64  `json:"missing"`
+	NoChecksum int64  `json:"no_checksum"`
+}
+
+func (c *FileIntegrityScanCheckpoint) ToJson() string {
+	b, _ := json.Marshal(c)
+	return string(b)
+}
+
+func FileIntegrityScanCheckpointFromJson(data io.Reader) *FileIntegrityScanCheckpoint {
+	var checkpoint *FileIntegrityScanCheckpoint
+	json.NewDecoder(data).Decode(&checkpoint)
+	return checkpoint
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileIntegrityResultJson(t *testing.T) {
+	result := &FileIntegrityResult{
+		FileId:           NewId(),
+		Status:           FILE_INTEGRITY_STATUS_MISMATCH,
+		ExpectedChecksum: "abc",
+		ActualChecksum:   "def",
+	}
+
+	assert.Equal(t, result, FileIntegrityResultFromJson(strings.NewReader(result.ToJson())))
+	assert.Nil(t, FileIntegrityResultFromJson(strings.NewReader("garbage")))
+}
+
+func TestFileIntegrityScanCheckpointJson(t *testing.T) {
+	checkpoint := &FileIntegrityScanCheckpoint{
+		AfterId:    NewId(),
+		Checked:    500,
+		Mismatched: 1,
+		Missing:    2,
+		NoChecksum: 3,
+	}
+
+	assert.Equal(t, checkpoint, FileIntegrityScanCheckpointFromJson(strings.NewReader(checkpoint.ToJson())))
+	assert.Nil(t, FileIntegrityScanCheckpointFromJson(strings.NewReader("garbage")))
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	FIREHOSE_EVENT_POST_CREATED           = "post_created"
+	FIREHOSE_EVENT_POST_EDITED            = "post_edited"
+	FIREHOSE_EVENT_POST_DELETED           = "post_deleted"
+	FIREHOSE_EVENT_CHANNEL_CREATED        = "channel_created"
+	FIREHOSE_EVENT_CHANNEL_UPDATED        = "channel_updated"
+	FIREHOSE_EVENT_CHANNEL_DELETED        = "channel_deleted"
+	FIREHOSE_EVENT_CHANNEL_MEMBER_ADDED   = "channel_member_added"
+	FIREHOSE_EVENT_CHANNEL_MEMBER_REMOVED = "channel_member_removed"
+	FIREHOSE_EVENT_TEAM_MEMBER_ADDED      = "team_member_added"
+	FIREHOSE_EVENT_TEAM_MEMBER_REMOVED    = "team_member_removed"
+	FIREHOSE_EVENT_USER_CREATED           = "user_created"
+	FIREHOSE_EVENT_USER_UPDATED           = "user_updated"
+)
+
+// FirehoseEvent is a domain event sent to the event firehose. The ids of the team, channel and user that the event
+// is about are set whenever they apply, and Data holds the JSON of the object that the event is about in the same
+// form as the REST API returns it: a post, channel, channel member, team member or user depending on the type.
+type FirehoseEvent struct {
+	Id        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreateAt  int64           `json:"create_at"`
+	SiteURL   string          `json:"site_url"`
+	ActorId   string          `json:"actor_id,omitempty"`
+	TeamId    string          `json:"team_id,omitempty"`
+	ChannelId string          `json:"channel_id,omitempty"`
+	UserId    string          `json:"user_id,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *FirehoseEvent) ToJson() string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+func FirehoseEventListToJson(events []*FirehoseEvent) string {
+	b, _ := json.Marshal(events)
+	return string(b)
+}
+
+func FirehoseEventListFromJson(data io.Reader) []*FirehoseEvent {
+	var events []*FirehoseEvent
+	json.NewDecoder(data).Decode(&events)
+	return events
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirehoseEventListJson(t *testing.T) {
+	post := &Post{Id: NewId(), ChannelId: NewId(), Message: "hello"}
+	events := []*FirehoseEvent{
+		{
+			Id:        NewId(),
+			Type:      FIREHOSE_EVENT_POST_CREATED,
+			CreateAt:  GetMillis(),
+			ChannelId: post.ChannelId,
+			Data:      json.RawMessage(post.ToJson()),
+		},
+		{
+			Id:   NewId(),
+			Type: FIREHOSE_EVENT_USER_CREATED,
+		},
+	}
+
+	decoded := FirehoseEventListFromJson(strings.NewReader(FirehoseEventListToJson(events)))
+	require.Len(t, decoded, 2)
+	assert.Equal(t, events[0].Id, decoded[0].Id)
+	assert.Equal(t, FIREHOSE_EVENT_POST_CREATED, decoded[0].Type)
+	assert.Equal(t, post.Id, PostFromJson(strings.NewReader(string(decoded[0].Data))).Id)
+	assert.Empty(t, decoded[1].Data)
+	assert.NotContains(t, decoded[1].ToJson(), "\"data\"")
+}
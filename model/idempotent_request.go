@@ -0,0 +1,83 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+const (
+	IDEMPOTENT_REQUEST_ID_LENGTH       = 64
+	IDEMPOTENT_REQUEST_PATH_MAX_LENGTH = 512
+
+	// Clients are only expected to retry requests for a few minutes, after which a request with the same key is
+	// handled again as a new one.
+	IDEMPOTENT_REQUEST_EXPIRY_TIME = 1000 * 60 * 10 // 10 minutes
+)
+
+// IdempotentRequest records a request that a user made with an idempotency key, so that it's only handled once
+// across the cluster. StatusCode is 0 while the request is being handled. Once it has succeeded, its response is
+// kept so that it can be sent again when the request is retried. BodyHash ties the key to the request's body, so
+// that a key can't be reused for a request with different content.
+type IdempotentRequest struct {
+	Id         string `json:"id"`
+	UserId     string `json:"user_id"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	BodyHash   string `json:"body_hash"`
+	StatusCode int    `json:"status_code"`
+	Header     string `json:"header"`
+	Body       []byte `json:"body"`
+	CreateAt   int64  `json:"create_at"`
+}
+
+// IdempotentRequestId returns the id of the request made by a user with the given idempotency key. The key is chosen
+// by the client, so it's hashed to give ids a fixed length.
+func IdempotentRequestId(userId, key string) string {
+	hash := sha256.Sum256([]byte(userId + ":" + key))
+	return hex.EncodeToString(hash[:])
+}
+
+// HashIdempotentRequestBody returns the hash of a request's body that's stored as its BodyHash.
+func HashIdempotentRequestBody(body []byte) string {
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:])
+}
+
+func (o *IdempotentRequest) IsValid() *AppError {
+	if len(o.Id) != IDEMPOTENT_REQUEST_ID_LENGTH {
+		return NewAppError("IdempotentRequest.IsValid", "model.idempotent_request.is_valid.id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if !IsValidId(o.UserId) {
+		return NewAppError("IdempotentRequest.IsValid", "model.idempotent_request.is_valid.user_id.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if len(o.Method) == 0 || len(o.Path) == 0 || len(o.Path) > IDEMPOTENT_REQUEST_PATH_MAX_LENGTH {
+		return NewAppError("IdempotentRequest.IsValid", "model.idempotent_request.is_valid.request.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if o.CreateAt == 0 {
+		return NewAppError("IdempotentRequest.IsValid", "model.idempotent_request.is_valid.create_at.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// IsExpired returns whether the request was made long enough ago that the key can be used again.
+func (o *IdempotentRequest) IsExpired(now int64) bool {
+	return o.CreateAt < now-IDEMPOTENT_REQUEST_EXPIRY_TIME
+}
+
+// IsInProgress returns whether the request is still being handled.
+func (o *IdempotentRequest) IsInProgress() bool {
+	return o.StatusCode == 0
+}
+
+// Matches returns whether a request with the same idempotency key is the same request as this one.
+func (o *IdempotentRequest) Matches(method, path, bodyHash string) bool {
+	return o.Method == method && o.Path == path && o.BodyHash == bodyHash
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotentRequestId(t *testing.T) {
+	userId := NewId()
+
+	id := IdempotentRequestId(userId, "key")
+	assert.Len(t, id, IDEMPOTENT_REQUEST_ID_LENGTH)
+	assert.Equal(t, id, IdempotentRequestId(userId, "key"))
+	assert.NotEqual(t, id, IdempotentRequestId(userId, "other"))
+	assert.NotEqual(t, id, IdempotentRequestId(NewId(), "key"))
+}
+
+func TestIdempotentRequestIsValid(t *testing.T) {
+	request := &IdempotentRequest{
+		Id:       IdempotentRequestId(NewId(), "key"),
+		UserId:   NewId(),
+		Method:   "POST",
+		Path:     "/api/v4/posts",
+		BodyHash: HashIdempotentRequestBody([]byte("{}")),
+		CreateAt: GetMillis(),
+	}
+	assert.Nil(t, request.IsValid())
+
+	request.Path = strings.Repeat("a", IDEMPOTENT_REQUEST_PATH_MAX_LENGTH+1)
+	assert.NotNil(t, request.IsValid())
+
+	request.Path = "/api/v4/posts"
+	request.UserId = "junk"
+	assert.NotNil(t, request.IsValid())
+
+	request.UserId = NewId()
+	request.Id = "junk"
+	assert.NotNil(t, request.IsValid())
+
+	request.Id = IdempotentRequestId(request.UserId, "key")
+	request.CreateAt = 0
+	assert.NotNil(t, request.IsValid())
+}
+
+func TestIdempotentRequestIsExpired(t *testing.T) {
+	now := GetMillis()
+	request := &IdempotentRequest{CreateAt: now}
+	assert.False(t, request.IsExpired(now))
+
+	request.CreateAt = now - IDEMPOTENT_REQUEST_EXPIRY_TIME - 1
+	assert.True(t, request.IsExpired(now))
+}
+
+func TestIdempotentRequestMatches(t *testing.T) {
+	bodyHash := HashIdempotentRequestBody([]byte("{}"))
+	request := &IdempotentRequest{Method: "POST", Path: "/api/v4/posts", BodyHash: bodyHash}
+
+	assert.True(t, request.Matches("POST", "/api/v4/posts", bodyHash))
+	assert.False(t, request.Matches("PUT", "/api/v4/posts", bodyHash))
+	assert.False(t, request.Matches("POST", "/api/v4/other", bodyHash))
+	assert.False(t, request.Matches("POST", "/api/v4/posts", HashIdempotentRequestBody([]byte("{\"message\":\"changed\"}"))))
+}
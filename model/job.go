@@ -6,7 +6,10 @@ package model
 import (
 	"encoding/json"
 	"io"
+	"math/rand"
 	"net/http"
+	"sort"
+	"strconv"
 	"time"
 )
 
@@ -18,6 +21,16 @@ const (
 	JOB_TYPE_LDAP_SYNC                      = "ldap_sync"
 	JOB_TYPE_MIGRATIONS                     = "migrations"
 	JOB_TYPE_PLUGINS                        = "plugins"
+	JOB_TYPE_EXPIRE_PINS                    = "expire_pins"
+	JOB_TYPE_INACTIVE_USERS                 = "inactive_users"
+	JOB_TYPE_INACTIVE_CHANNELS              = "inactive_channels"
+	JOB_TYPE_COLD_STORAGE                   = "cold_storage"
+	JOB_TYPE_FILE_INTEGRITY_SCAN            = "file_integrity_scan"
+	JOB_TYPE_MOVE_CHANNEL                   = "move_channel"
+	JOB_TYPE_MERGE_TEAMS                    = "merge_teams"
+	JOB_TYPE_COUNT_REPAIR                   = "count_repair"
+	JOB_TYPE_ELASTICSEARCH_SCOPED_REINDEX   = "elasticsearch_scoped_reindex"
+	JOB_TYPE_PLUGIN_JOB                     = "plugin_job"
 
 	JOB_STATUS_PENDING          = "pending"
 	JOB_STATUS_IN_PROGRESS      = "in_progress"
@@ -25,6 +38,23 @@ const (
 	JOB_STATUS_ERROR            = "error"
 	JOB_STATUS_CANCEL_REQUESTED = "cancel_requested"
 	JOB_STATUS_CANCELED         = "canceled"
+
+	// Jobs with a higher priority are started first. When the number of jobs running across the cluster reaches
+	// JobSettings.MaxConcurrentJobs, only high priority jobs are started until others finish.
+	JOB_PRIORITY_LOW    = -1
+	JOB_PRIORITY_NORMAL = 0
+	JOB_PRIORITY_HIGH   = 1
+
+	// JOB_DATA_CHECKPOINT is the key of the job data where long running jobs save how far they got, so that they
+	// can be resumed by another worker if they're interrupted.
+	JOB_DATA_CHECKPOINT = "checkpoint"
+
+	// JOB_DATA_RETRY_AT is the key of the job data where a job that failed and was put back in the queue keeps the
+	// time in milliseconds before which it shouldn't be run again.
+	JOB_DATA_RETRY_AT = "retry_at"
+
+	JOB_RETRY_BASE_DELAY = 10 * time.Second
+	JOB_RETRY_MAX_DELAY  = 10 * time.Minute
 )
 
 type Job struct {
@@ -56,6 +86,16 @@ func (j *Job) IsValid() *AppError {
 	case JOB_TYPE_MESSAGE_EXPORT:
 	case JOB_TYPE_MIGRATIONS:
 	case JOB_TYPE_PLUGINS:
+	case JOB_TYPE_EXPIRE_PINS:
+	case JOB_TYPE_INACTIVE_USERS:
+	case JOB_TYPE_INACTIVE_CHANNELS:
+	case JOB_TYPE_COLD_STORAGE:
+	case JOB_TYPE_FILE_INTEGRITY_SCAN:
+	case JOB_TYPE_MOVE_CHANNEL:
+	case JOB_TYPE_MERGE_TEAMS:
+	case JOB_TYPE_COUNT_REPAIR:
+	case JOB_TYPE_ELASTICSEARCH_SCOPED_REINDEX:
+	case JOB_TYPE_PLUGIN_JOB:
 	default:
 		return NewAppError("Job.IsValid", "model.job.is_valid.type.app_error", nil, "id="+j.Id, http.StatusBadRequest)
 	}
@@ -74,6 +114,49 @@ func (j *Job) IsValid() *AppError {
 	return nil
 }
 
+// DefaultJobPriority returns the priority that jobs of the given type are created with. Jobs that keep the system
+// consistent or compliant come first, while jobs that can take hours, like indexing, come last.
+func DefaultJobPriority(jobType string) int64 {
+	switch jobType {
+	case JOB_TYPE_DATA_RETENTION, JOB_TYPE_LDAP_SYNC, JOB_TYPE_MIGRATIONS:
+		return JOB_PRIORITY_HIGH
+	case JOB_TYPE_ELASTICSEARCH_POST_INDEXING, JOB_TYPE_ELASTICSEARCH_POST_AGGREGATION, JOB_TYPE_ELASTICSEARCH_SCOPED_REINDEX:
+		return JOB_PRIORITY_LOW
+	}
+
+	return JOB_PRIORITY_NORMAL
+}
+
+// JobRetryDelay returns how long to wait before running a job again after it has failed the given number of times.
+// The delay doubles with every failure up to JOB_RETRY_MAX_DELAY, and up to half of it is random so that jobs that
+// failed at the same time aren't all retried at once.
+func JobRetryDelay(failures int) time.Duration {
+	delay := JOB_RETRY_MAX_DELAY
+	if failures < 1 {
+		delay = JOB_RETRY_BASE_DELAY
+	} else if failures <= 16 && JOB_RETRY_BASE_DELAY<<uint(failures-1) < JOB_RETRY_MAX_DELAY {
+		delay = JOB_RETRY_BASE_DELAY << uint(failures-1)
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// IsWaitingToRetry returns true if the job failed and was put back in the queue, but shouldn't be run again yet.
+func (j *Job) IsWaitingToRetry(now int64) bool {
+	retryAt, err := strconv.ParseInt(j.Data[JOB_DATA_RETRY_AT], 10, 64)
+	return err == nil && retryAt > now
+}
+
+// SortJobsByPriority sorts jobs by descending priority, then from the oldest to the newest.
+func SortJobsByPriority(jobs []*Job) {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if jobs[i].Priority != jobs[j].Priority {
+			return jobs[i].Priority > jobs[j].Priority
+		}
+		return jobs[i].CreateAt < jobs[j].CreateAt
+	})
+}
+
 func (js *Job) ToJson() string {
 	b, _ := json.Marshal(js)
 	return string(b)
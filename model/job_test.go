@@ -0,0 +1,54 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultJobPriority(t *testing.T) {
+	assert.Equal(t, int64(JOB_PRIORITY_HIGH), DefaultJobPriority(JOB_TYPE_DATA_RETENTION))
+	assert.Equal(t, int64(JOB_PRIORITY_NORMAL), DefaultJobPriority(JOB_TYPE_PLUGIN_JOB))
+	assert.Equal(t, int64(JOB_PRIORITY_LOW), DefaultJobPriority(JOB_TYPE_ELASTICSEARCH_SCOPED_REINDEX))
+}
+
+func TestSortJobsByPriority(t *testing.T) {
+	low := &Job{Id: "low", Priority: JOB_PRIORITY_LOW, CreateAt: 1}
+	oldNormal := &Job{Id: "old_normal", Priority: JOB_PRIORITY_NORMAL, CreateAt: 2}
+	newNormal := &Job{Id: "new_normal", Priority: JOB_PRIORITY_NORMAL, CreateAt: 3}
+	high := &Job{Id: "high", Priority: JOB_PRIORITY_HIGH, CreateAt: 4}
+
+	jobs := []*Job{low, newNormal, high, oldNormal}
+	SortJobsByPriority(jobs)
+
+	assert.Equal(t, []*Job{high, oldNormal, newNormal, low}, jobs)
+}
+
+func TestJobRetryDelay(t *testing.T) {
+	for failures, expected := range map[int]time.Duration{
+		0:   JOB_RETRY_BASE_DELAY,
+		1:   JOB_RETRY_BASE_DELAY,
+		2:   2 * JOB_RETRY_BASE_DELAY,
+		3:   4 * JOB_RETRY_BASE_DELAY,
+		10:  JOB_RETRY_MAX_DELAY,
+		100: JOB_RETRY_MAX_DELAY,
+	} {
+		for i := 0; i < 10; i++ {
+			delay := JobRetryDelay(failures)
+			assert.True(t, delay >= expected/2 && delay <= expected, "failures=%v delay=%v", failures, delay)
+		}
+	}
+}
+
+func TestJobIsWaitingToRetry(t *testing.T) {
+	now := GetMillis()
+
+	assert.False(t, (&Job{}).IsWaitingToRetry(now))
+	assert.False(t, (&Job{Data: map[string]string{JOB_DATA_RETRY_AT: strconv.FormatInt(now-1, 10)}}).IsWaitingToRetry(now))
+	assert.True(t, (&Job{Data: map[string]string{JOB_DATA_RETRY_AT: strconv.FormatInt(now+1000, 10)}}).IsWaitingToRetry(now))
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+const (
+	JSON_FIELD_NAMING_SNAKE_CASE = "snake_case"
+	JSON_FIELD_NAMING_CAMEL_CASE = "camelCase"
+)
+
+// SnakeToCamelCase converts a name like create_at to createAt. Underscores that don't separate two words, such as a
+// leading one, are left alone.
+func SnakeToCamelCase(name string) string {
+	if !strings.Contains(name, "_") {
+		return name
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '_' && i > 0 && name[i-1] != '_' && i+1 < len(name) && name[i+1] != '_' {
+			b.WriteString(strings.ToUpper(name[i+1 : i+2]))
+			i++
+			continue
+		}
+		b.WriteByte(name[i])
+	}
+	return b.String()
+}
+
+// jsonMapFields are the fields of the models that hold maps keyed by data rather than by field names, such as a post's
+// props or the images of its metadata, which are keyed by URL. Their contents are left exactly as they are, since
+// they're read back by key and may be written by integrations that expect them not to change.
+var jsonMapFields = map[string]bool{
+	"context":      true,
+	"data":         true,
+	"images":       true,
+	"notify_props": true,
+	"props":        true,
+	"submission":   true,
+	"timezone":     true,
+}
+
+// ConvertJsonFieldNames re-encodes JSON produced by the models, which always use snake_case field names, so that its
+// field names follow the given naming instead. The keys of maps such as props are data rather than field names, so
+// they're left alone, as is everything nested in them. The order of fields and the exact representation of numbers
+// are preserved.
+func ConvertJsonFieldNames(data []byte, naming string) ([]byte, error) {
+	if naming != JSON_FIELD_NAMING_CAMEL_CASE {
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var b bytes.Buffer
+	for decoder.More() {
+		if err := convertJsonValue(decoder, &b, true); err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+func convertJsonValue(decoder *json.Decoder, b *bytes.Buffer, convertKeys bool) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return writeJsonToken(b, token)
+	}
+
+	b.WriteRune(rune(delim))
+	for i := 0; decoder.More(); i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		convertValueKeys := convertKeys
+		if delim == '{' {
+			token, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+
+			key := token.(string)
+			if convertKeys {
+				convertValueKeys = !jsonMapFields[key]
+				key = SnakeToCamelCase(key)
+			}
+
+			if err := writeJsonToken(b, key); err != nil {
+				return err
+			}
+			b.WriteByte(':')
+		}
+
+		if err := convertJsonValue(decoder, b, convertValueKeys); err != nil {
+			return err
+		}
+	}
+
+	// Consume the closing delimiter
+	closing, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	b.WriteRune(rune(closing.(json.Delim)))
+
+	return nil
+}
+
+func writeJsonToken(b *bytes.Buffer, token json.Token) error {
+	if number, ok := token.(json.Number); ok {
+		b.WriteString(number.String())
+		return nil
+	}
+
+	encoded, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	b.Write(encoded)
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnakeToCamelCase(t *testing.T) {
+	for input, expected := range map[string]string{
+		"":                    "",
+		"id":                  "id",
+		"create_at":           "createAt",
+		"notify_props":        "notifyProps",
+		"last_picture_update": "lastPictureUpdate",
+		"is_valid_2":          "isValid2",
+		"_leading":            "_leading",
+		"trailing_":           "trailing_",
+		"double__underscore":  "double__underscore",
+		"alreadyCamel":        "alreadyCamel",
+	} {
+		assert.Equal(t, expected, SnakeToCamelCase(input), input)
+	}
+}
+
+func TestConvertJsonFieldNames(t *testing.T) {
+	t.Run("snake case is unchanged", func(t *testing.T) {
+		data := []byte(`{"create_at":1}`)
+
+		converted, err := ConvertJsonFieldNames(data, JSON_FIELD_NAMING_SNAKE_CASE)
+		require.Nil(t, err)
+		assert.Equal(t, data, converted)
+	})
+
+	t.Run("camel case", func(t *testing.T) {
+		user := &User{Id: "userid", Username: "user", CreateAt: 1234567890123, NotifyProps: StringMap{"mention_keys": "a,<b>"}}
+
+		converted, err := ConvertJsonFieldNames([]byte(user.ToJson()), JSON_FIELD_NAMING_CAMEL_CASE)
+		require.Nil(t, err)
+		assert.Equal(t, `{"id":"userid","createAt":1234567890123,"deleteAt":0,"username":"user","authService":"","email":"","nickname":"","firstName":"","lastName":"","position":"","roles":"","notifyProps":{"mention_keys":"a,\u003cb\u003e"},"locale":"","timezone":null}`, string(converted))
+	})
+
+	t.Run("nested values", func(t *testing.T) {
+		converted, err := ConvertJsonFieldNames([]byte(`[{"a_b":[{"c_d":1.50}],"e_f":null,"g_h":"i_j"},true,[]]`), JSON_FIELD_NAMING_CAMEL_CASE)
+		require.Nil(t, err)
+		assert.Equal(t, `[{"aB":[{"cD":1.50}],"eF":null,"gH":"i_j"},true,[]]`, string(converted))
+	})
+
+	t.Run("map values keep their keys", func(t *testing.T) {
+		post := &Post{Id: "postid", Props: StringInterface{"from_webhook": "true", "attachments": []interface{}{map[string]interface{}{"author_name": "a"}}}}
+		post.Metadata = &PostMetadata{Images: map[string]*PostImage{"https://example.com/a_b.png": {Width: 1}}}
+
+		converted, err := ConvertJsonFieldNames([]byte(post.ToJson()), JSON_FIELD_NAMING_CAMEL_CASE)
+		require.Nil(t, err)
+		assert.Contains(t, string(converted), `"author_name":"a"`)
+		assert.Contains(t, string(converted), `"from_webhook":"true"`)
+		assert.Contains(t, string(converted), `"images":{"https://example.com/a_b.png":`)
+		assert.Contains(t, string(converted), `"createAt":0`)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := ConvertJsonFieldNames([]byte(`{"a_b":`), JSON_FIELD_NAMING_CAMEL_CASE)
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// MaintenanceMode is whether the server only lets system admins in, and the message shown to everyone else.
+type MaintenanceMode struct {
+	Enable  bool   `json:"enable"`
+	Message string `json:"message"`
+}
+
+func (m *MaintenanceMode) ToJson() string {
+	b, _ := json.Marshal(m)
+	return string(b)
+}
+
+func MaintenanceModeFromJson(data io.Reader) *MaintenanceMode {
+	var m *MaintenanceMode
+	json.NewDecoder(data).Decode(&m)
+	return m
+}
+
+// MaintenanceModePatch changes the maintenance mode of the server. Fields that are left nil are kept as they are.
+type MaintenanceModePatch struct {
+	Enable  *bool   `json:"enable"`
+	Message *string `json:"message"`
+}
+
+func (p *MaintenanceModePatch) ToJson() string {
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+func MaintenanceModePatchFromJson(data io.Reader) *MaintenanceModePatch {
+	var p *MaintenanceModePatch
+	json.NewDecoder(data).Decode(&p)
+	return p
+}
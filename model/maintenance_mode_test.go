@@ -0,0 +1,30 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceModeJson(t *testing.T) {
+	maintenanceMode := &MaintenanceMode{Enable: true, Message: "Back at noon"}
+
+	rmaintenanceMode := MaintenanceModeFromJson(strings.NewReader(maintenanceMode.ToJson()))
+	require.NotNil(t, rmaintenanceMode)
+	assert.Equal(t, maintenanceMode, rmaintenanceMode)
+}
+
+func TestMaintenanceModePatchJson(t *testing.T) {
+	patch := &MaintenanceModePatch{Enable: NewBool(true)}
+
+	rpatch := MaintenanceModePatchFromJson(strings.NewReader(patch.ToJson()))
+	require.NotNil(t, rpatch)
+	require.NotNil(t, rpatch.Enable)
+	assert.True(t, *rpatch.Enable)
+	assert.Nil(t, rpatch.Message)
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+const MENTION_RESOLUTION_MAX_TOKENS = 100
+
+// ResolvedMention is a mention token that matched a user.
+type ResolvedMention struct {
+	Mention         string `json:"mention"`
+	UserId          string `json:"user_id"`
+	Username        string `json:"username"`
+	IsChannelMember bool   `json:"is_channel_member"`
+}
+
+// MentionResolution reports what each of a set of mention tokens would notify if posted in a channel.
+type MentionResolution struct {
+	ChannelId string             `json:"channel_id"`
+	Users     []*ResolvedMention `json:"users"`
+	Special   []string           `json:"special"`
+	Unknown   []string           `json:"unknown"`
+}
+
+// NormalizeMentionToken strips surrounding whitespace and the leading @ from a mention token and lowercases it,
+// so that "@Alice" and "alice" refer to the same mention.
+func NormalizeMentionToken(token string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(token), "@"))
+}
+
+// IsSpecialMention returns true if the normalized token notifies a group of users rather than a single one.
+func IsSpecialMention(token string) bool {
+	switch token {
+	case "channel", "all", "here":
+		return true
+	}
+	return false
+}
+
+func (o *MentionResolution) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func MentionResolutionFromJson(data io.Reader) *MentionResolution {
+	var o *MentionResolution
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
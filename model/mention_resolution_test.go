@@ -0,0 +1,40 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeMentionToken(t *testing.T) {
+	assert.Equal(t, "alice", NormalizeMentionToken("@Alice"))
+	assert.Equal(t, "alice", NormalizeMentionToken(" alice "))
+	assert.Equal(t, "channel", NormalizeMentionToken("@channel"))
+	assert.Equal(t, "", NormalizeMentionToken("@"))
+}
+
+func TestIsSpecialMention(t *testing.T) {
+	assert.True(t, IsSpecialMention("channel"))
+	assert.True(t, IsSpecialMention("all"))
+	assert.True(t, IsSpecialMention("here"))
+	assert.False(t, IsSpecialMention("alice"))
+	assert.False(t, IsSpecialMention("@channel"))
+}
+
+func TestMentionResolutionJson(t *testing.T) {
+	o := &MentionResolution{
+		ChannelId: NewId(),
+		Users:     []*ResolvedMention{{Mention: "@alice", UserId: NewId(), Username: "alice", IsChannelMember: true}},
+		Special:   []string{"@all"},
+		Unknown:   []string{"@nobody"},
+	}
+
+	ro := MentionResolutionFromJson(strings.NewReader(o.ToJson()))
+	require.NotNil(t, ro)
+	assert.Equal(t, o, ro)
+}
@@ -5,4 +5,5 @@ package model
 
 const (
 	MIGRATION_KEY_ADVANCED_PERMISSIONS_PHASE_2 = "migration_advanced_permissions_phase_2"
+	MIGRATION_KEY_TEAM_STORAGE_USAGE           = "migration_team_storage_usage"
 )
@@ -0,0 +1,72 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// The rules that decide whether a user is notified of a post, as given by a NotificationExplanation.
+const (
+	NOTIFICATION_REASON_ARCHIVED_CHANNEL   = "archived_channel"
+	NOTIFICATION_REASON_NOT_CHANNEL_MEMBER = "not_channel_member"
+	NOTIFICATION_REASON_OWN_POST           = "own_post"
+	NOTIFICATION_REASON_DISABLED           = "disabled"
+	NOTIFICATION_REASON_NOT_MENTIONED      = "not_mentioned"
+	NOTIFICATION_REASON_EMAIL_NOT_VERIFIED = "email_not_verified"
+	NOTIFICATION_REASON_DEACTIVATED        = "deactivated"
+	NOTIFICATION_REASON_SYSTEM_MESSAGE     = "system_message"
+	NOTIFICATION_REASON_AUTO_RESPONDER     = "auto_responder"
+	NOTIFICATION_REASON_MUTED              = "muted"
+	NOTIFICATION_REASON_CHANNEL_SETTING    = "channel_setting"
+	NOTIFICATION_REASON_USER_SETTING       = "user_setting"
+	NOTIFICATION_REASON_DND                = "dnd"
+	NOTIFICATION_REASON_OUT_OF_OFFICE      = "out_of_office"
+	NOTIFICATION_REASON_STATUS             = "status"
+	NOTIFICATION_REASON_MENTION            = "mention"
+	NOTIFICATION_REASON_ALL_ACTIVITY       = "all_activity"
+)
+
+// The ways that a user can be mentioned by a post, as given by a NotificationExplanation.
+const (
+	MENTION_REASON_DIRECT_MESSAGE   = "direct_message"
+	MENTION_REASON_USERNAME         = "username"
+	MENTION_REASON_KEYWORD          = "keyword"
+	MENTION_REASON_CHANNEL_WIDE     = "channel_wide"
+	MENTION_REASON_THREAD_REPLY     = "thread_reply"
+	MENTION_REASON_ADDED_TO_CHANNEL = "added_to_channel"
+)
+
+// NotificationDecision is whether a user would be sent one kind of notification, along with the
+// NOTIFICATION_REASON_* that decided it.
+type NotificationDecision struct {
+	Send   bool   `json:"send"`
+	Reason string `json:"reason"`
+}
+
+// NotificationExplanation explains whether a user would be notified of a post, so that missing or unwanted
+// notifications can be diagnosed.
+type NotificationExplanation struct {
+	PostId string `json:"post_id"`
+	UserId string `json:"user_id"`
+	// MentionReason is the MENTION_REASON_* that the post mentions the user by, or empty if it doesn't.
+	MentionReason string `json:"mention_reason"`
+	// Status is the user's status that the decisions were made with, which is their current one.
+	Status  string                `json:"status"`
+	Push    *NotificationDecision `json:"push"`
+	Email   *NotificationDecision `json:"email"`
+	Desktop *NotificationDecision `json:"desktop"`
+}
+
+func (o *NotificationExplanation) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func NotificationExplanationFromJson(data io.Reader) *NotificationExplanation {
+	var o *NotificationExplanation
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
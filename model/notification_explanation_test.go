@@ -0,0 +1,28 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationExplanationJson(t *testing.T) {
+	explanation := &NotificationExplanation{
+		PostId:        NewId(),
+		UserId:        NewId(),
+		MentionReason: MENTION_REASON_USERNAME,
+		Status:        STATUS_AWAY,
+		Push:          &NotificationDecision{Send: true, Reason: NOTIFICATION_REASON_MENTION},
+		Email:         &NotificationDecision{Reason: NOTIFICATION_REASON_CHANNEL_SETTING},
+		Desktop:       &NotificationDecision{Reason: NOTIFICATION_REASON_MUTED},
+	}
+
+	json := explanation.ToJson()
+	rexplanation := NotificationExplanationFromJson(strings.NewReader(json))
+
+	assert.Equal(t, explanation, rexplanation)
+}
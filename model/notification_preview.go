@@ -0,0 +1,40 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// NotificationPreviewRecipient describes how a single user would be notified about a post.
+type NotificationPreviewRecipient struct {
+	UserId    string `json:"user_id"`
+	Username  string `json:"username"`
+	Mentioned bool   `json:"mentioned"`
+	Desktop   bool   `json:"desktop"`
+	Push      bool   `json:"push"`
+	Email     bool   `json:"email"`
+}
+
+// NotificationPreview lists the users who would be notified if a draft post were sent to a channel.
+type NotificationPreview struct {
+	ChannelId            string                          `json:"channel_id"`
+	Recipients           []*NotificationPreviewRecipient `json:"recipients"`
+	HereMentioned        bool                            `json:"here_mentioned"`
+	ChannelMentioned     bool                            `json:"channel_mentioned"`
+	AllMentioned         bool                            `json:"all_mentioned"`
+	OutOfChannelMentions []string                        `json:"out_of_channel_mentions"`
+}
+
+func (o *NotificationPreview) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func NotificationPreviewFromJson(data io.Reader) *NotificationPreview {
+	var o *NotificationPreview
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	ORPHANED_FILES_DEFAULT_GRACE_PERIOD_HOURS = 24
+	ORPHANED_FILES_MIN_GRACE_PERIOD_HOURS     = 1
+)
+
+// OrphanedFilesReport lists the files that aren't referenced by any post. FileInfos are those that were never attached
+// to a post or whose post has been deleted, and Paths are the directories in the file store that hold uploaded files
+// without any FileInfo. Unless DryRun is set, they've been deleted. Size is the total size of the FileInfos, not
+// including their thumbnails and previews or the files in Paths, whose sizes aren't known.
+type OrphanedFilesReport struct {
+	DryRun    bool        `json:"dry_run"`
+	FileInfos []*FileInfo `json:"file_infos"`
+	Paths     []string    `json:"paths"`
+	Size      int64       `json:"size"`
+}
+
+func (r *OrphanedFilesReport) ToJson() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+func OrphanedFilesReportFromJson(data io.Reader) *OrphanedFilesReport {
+	var r *OrphanedFilesReport
+	json.NewDecoder(data).Decode(&r)
+	return r
+}
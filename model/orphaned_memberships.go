@@ -0,0 +1,45 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// OrphanedChannelMember is a channel membership whose channel or user no longer exists.
+type OrphanedChannelMember struct {
+	ChannelId     string `json:"channel_id"`
+	UserId        string `json:"user_id"`
+	ChannelExists bool   `json:"channel_exists"`
+	UserExists    bool   `json:"user_exists"`
+}
+
+// OrphanedTeamMember is a team membership whose team or user no longer exists.
+type OrphanedTeamMember struct {
+	TeamId     string `json:"team_id"`
+	UserId     string `json:"user_id"`
+	TeamExists bool   `json:"team_exists"`
+	UserExists bool   `json:"user_exists"`
+}
+
+// OrphanedMembershipsReport lists the channel and team memberships that refer to a channel, team or user that doesn't
+// exist, as opposed to one that's been archived or deactivated. Unless DryRun is set, they've been deleted, except for
+// any that stopped being orphaned while the check was running and are left out of the report.
+type OrphanedMembershipsReport struct {
+	DryRun         bool                     `json:"dry_run"`
+	ChannelMembers []*OrphanedChannelMember `json:"channel_members"`
+	TeamMembers    []*OrphanedTeamMember    `json:"team_members"`
+}
+
+func (r *OrphanedMembershipsReport) ToJson() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+func OrphanedMembershipsReportFromJson(data io.Reader) *OrphanedMembershipsReport {
+	var r *OrphanedMembershipsReport
+	json.NewDecoder(data).Decode(&r)
+	return r
+}
@@ -0,0 +1,22 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+const PERMALINK_PREVIEW_MAX_MESSAGE_RUNES = 300
+
+// PermalinkPreview holds what's needed to render a preview of a post that another post links to with a permalink. If
+// the user viewing the preview can't read the linked post, only PostId and NoAccess are set.
+type PermalinkPreview struct {
+	PostId             string `json:"post_id"`
+	NoAccess           bool   `json:"no_access,omitempty"`
+	Message            string `json:"message,omitempty"`
+	UserId             string `json:"user_id,omitempty"`
+	Username           string `json:"username,omitempty"`
+	CreateAt           int64  `json:"create_at,omitempty"`
+	ChannelId          string `json:"channel_id,omitempty"`
+	ChannelDisplayName string `json:"channel_display_name,omitempty"`
+	ChannelType        string `json:"channel_type,omitempty"`
+	TeamId             string `json:"team_id,omitempty"`
+	TeamName           string `json:"team_name,omitempty"`
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strconv"
+)
+
+// Keys of the job data that are set by the server for jobs enqueued by plugins. Plugins can't use them for their
+// own data.
+const (
+	PLUGIN_JOB_DATA_PLUGIN_ID    = "plugin_id"
+	PLUGIN_JOB_DATA_TYPE         = "plugin_job_type"
+	PLUGIN_JOB_DATA_ATTEMPTS     = "attempts"
+	PLUGIN_JOB_DATA_MAX_ATTEMPTS = "max_attempts"
+
+	PLUGIN_JOB_TYPE_MAX_LENGTH      = 64
+	PLUGIN_JOB_DEFAULT_MAX_ATTEMPTS = 3
+	PLUGIN_JOB_MAXIMUM_MAX_ATTEMPTS = 10
+)
+
+func IsReservedPluginJobDataKey(key string) bool {
+	switch key {
+	case PLUGIN_JOB_DATA_PLUGIN_ID, PLUGIN_JOB_DATA_TYPE, PLUGIN_JOB_DATA_ATTEMPTS, PLUGIN_JOB_DATA_MAX_ATTEMPTS, JOB_DATA_RETRY_AT, "error":
+		return true
+	}
+	return false
+}
+
+// PluginJobAttempts returns how many times a job enqueued by a plugin has been run so far, and how many times it may
+// be run in total.
+func PluginJobAttempts(job *Job) (attempts int, maxAttempts int) {
+	attempts, _ = strconv.Atoi(job.Data[PLUGIN_JOB_DATA_ATTEMPTS])
+
+	maxAttempts, err := strconv.Atoi(job.Data[PLUGIN_JOB_DATA_MAX_ATTEMPTS])
+	if err != nil || maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return attempts, maxAttempts
+}
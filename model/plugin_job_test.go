@@ -0,0 +1,26 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluginJobAttempts(t *testing.T) {
+	attempts, maxAttempts := PluginJobAttempts(&Job{Data: map[string]string{PLUGIN_JOB_DATA_ATTEMPTS: "2", PLUGIN_JOB_DATA_MAX_ATTEMPTS: "5"}})
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, 5, maxAttempts)
+
+	attempts, maxAttempts = PluginJobAttempts(&Job{})
+	assert.Equal(t, 0, attempts)
+	assert.Equal(t, 1, maxAttempts)
+}
+
+func TestIsReservedPluginJobDataKey(t *testing.T) {
+	assert.True(t, IsReservedPluginJobDataKey(PLUGIN_JOB_DATA_PLUGIN_ID))
+	assert.True(t, IsReservedPluginJobDataKey("error"))
+	assert.False(t, IsReservedPluginJobDataKey("key"))
+}
@@ -29,6 +29,7 @@ const (
 	POST_ADD_TO_CHANNEL         = "system_add_to_channel"
 	POST_REMOVE_FROM_CHANNEL    = "system_remove_from_channel"
 	POST_MOVE_CHANNEL           = "system_move_channel"
+	POST_MOVE_POSTS             = "system_move_posts"
 	POST_ADD_TO_TEAM            = "system_add_to_team"
 	POST_REMOVE_FROM_TEAM       = "system_remove_from_team"
 	POST_HEADER_CHANGE          = "system_header_change"
@@ -36,6 +37,7 @@ const (
 	POST_CONVERT_CHANNEL        = "system_convert_channel"
 	POST_PURPOSE_CHANGE         = "system_purpose_change"
 	POST_CHANNEL_DELETED        = "system_channel_deleted"
+	POST_CHANNEL_INACTIVE       = "system_channel_inactive"
 	POST_EPHEMERAL              = "system_ephemeral"
 	POST_CHANGE_CHANNEL_PRIVACY = "system_change_chan_privacy"
 	POST_FILEIDS_MAX_RUNES      = 150
@@ -50,6 +52,9 @@ const (
 	PROPS_ADD_CHANNEL_MEMBER    = "add_channel_member"
 	POST_PROPS_ADDED_USER_ID    = "addedUserId"
 	POST_PROPS_DELETE_BY        = "deleteBy"
+	POST_PROPS_PREVIEWED_POST   = "previewed_post"
+	POST_PROPS_QUOTED_POST_ID   = "quoted_post_id"
+	POST_PROPS_QUOTE            = "quote"
 )
 
 type Post struct {
@@ -80,6 +85,9 @@ type Post struct {
 	PendingPostId string          `json:"pending_post_id" db:"-"`
 	HasReactions  bool            `json:"has_reactions,omitempty"`
 
+	// PinnedUntil, if set, is the time at which a pinned post will be automatically unpinned.
+	PinnedUntil int64 `json:"pinned_until,omitempty"`
+
 	// Transient data populated before sending a post to the client
 	Metadata *PostMetadata `json:"metadata,omitempty" db:"-"`
 }
@@ -95,6 +103,7 @@ type PostPatch struct {
 	Props        *StringInterface `json:"props"`
 	FileIds      *StringArray     `json:"file_ids"`
 	HasReactions *bool            `json:"has_reactions"`
+	PinnedUntil  *int64           `json:"pinned_until"`
 }
 
 type SearchParameter struct {
@@ -104,6 +113,9 @@ type SearchParameter struct {
 	Page                   *int    `json:"page"`
 	PerPage                *int    `json:"per_page"`
 	IncludeDeletedChannels *bool   `json:"include_deleted_channels"`
+
+	// TeamIds limits searches across teams to the given teams. It's ignored when searching a single team.
+	TeamIds []string `json:"team_ids"`
 }
 
 func (o *PostPatch) WithRewrittenImageURLs(f func(string) string) *PostPatch {
@@ -220,6 +232,7 @@ func (o *Post) IsValid(maxPostSize int) *AppError {
 		POST_ADD_TO_CHANNEL,
 		POST_REMOVE_FROM_CHANNEL,
 		POST_MOVE_CHANNEL,
+		POST_MOVE_POSTS,
 		POST_ADD_TO_TEAM,
 		POST_REMOVE_FROM_TEAM,
 		POST_SLACK_ATTACHMENT,
@@ -228,6 +241,7 @@ func (o *Post) IsValid(maxPostSize int) *AppError {
 		POST_DISPLAYNAME_CHANGE,
 		POST_CONVERT_CHANNEL,
 		POST_CHANNEL_DELETED,
+		POST_CHANNEL_INACTIVE,
 		POST_CHANGE_CHANNEL_PRIVACY:
 	default:
 		if !strings.HasPrefix(o.Type, POST_CUSTOM_TYPE_PREFIX) {
@@ -235,6 +249,10 @@ func (o *Post) IsValid(maxPostSize int) *AppError {
 		}
 	}
 
+	if o.PinnedUntil < 0 || (o.PinnedUntil > 0 && !o.IsPinned) {
+		return NewAppError("Post.IsValid", "model.post.is_valid.pinned_until.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
 	if utf8.RuneCountInString(ArrayToJson(o.Filenames)) > POST_FILENAMES_MAX_RUNES {
 		return NewAppError("Post.IsValid", "model.post.is_valid.filenames.app_error", nil, "id="+o.Id, http.StatusBadRequest)
 	}
@@ -253,6 +271,7 @@ func (o *Post) IsValid(maxPostSize int) *AppError {
 func (o *Post) SanitizeProps() {
 	membersToSanitize := []string{
 		PROPS_ADD_CHANNEL_MEMBER,
+		POST_PROPS_QUOTE,
 	}
 
 	for _, member := range membersToSanitize {
@@ -333,6 +352,15 @@ func (p *Post) Patch(patch *PostPatch) {
 	if patch.HasReactions != nil {
 		p.HasReactions = *patch.HasReactions
 	}
+
+	if patch.PinnedUntil != nil {
+		p.PinnedUntil = *patch.PinnedUntil
+	}
+
+	// Unpinning a post cancels any scheduled expiry
+	if !p.IsPinned {
+		p.PinnedUntil = 0
+	}
 }
 
 func (o *PostPatch) ToJson() string {
@@ -7,6 +7,7 @@ const (
 	POST_EMBED_IMAGE              PostEmbedType = "image"
 	POST_EMBED_MESSAGE_ATTACHMENT PostEmbedType = "message_attachment"
 	POST_EMBED_OPENGRAPH          PostEmbedType = "opengraph"
+	POST_EMBED_PERMALINK          PostEmbedType = "permalink"
 )
 
 type PostEmbedType string
@@ -17,6 +18,7 @@ type PostEmbed struct {
 	// The URL of the embedded content. Used for image and OpenGraph embeds.
 	URL string `json:"url,omitempty"`
 
-	// Any additional data for the embedded content. Only used for OpenGraph embeds.
+	// Any additional data for the embedded content. Used for OpenGraph embeds and for permalink embeds, which hold a
+	// *PermalinkPreview.
 	Data interface{} `json:"data,omitempty"`
 }
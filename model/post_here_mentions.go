@@ -0,0 +1,58 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// PostHereMentions records who an @here in a post notified: the members of its channel who were online when it was
+// posted. It's fixed when the post is made, so clients can show who was mentioned without relying on their own view
+// of who's online, which may have changed since.
+type PostHereMentions struct {
+	PostId   string      `json:"post_id"`
+	UserIds  StringArray `json:"user_ids"`
+	CreateAt int64       `json:"create_at"`
+}
+
+func (o *PostHereMentions) PreSave() {
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+
+	if o.UserIds == nil {
+		o.UserIds = StringArray{}
+	}
+}
+
+func (o *PostHereMentions) IsValid() *AppError {
+	if !IsValidId(o.PostId) {
+		return NewAppError("PostHereMentions.IsValid", "model.post_here_mentions.is_valid.post_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	for _, userId := range o.UserIds {
+		if !IsValidId(userId) {
+			return NewAppError("PostHereMentions.IsValid", "model.post_here_mentions.is_valid.user_id.app_error", nil, "post_id="+o.PostId, http.StatusBadRequest)
+		}
+	}
+
+	if o.CreateAt == 0 {
+		return NewAppError("PostHereMentions.IsValid", "model.post_here_mentions.is_valid.create_at.app_error", nil, "post_id="+o.PostId, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (o *PostHereMentions) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func PostHereMentionsFromJson(data io.Reader) *PostHereMentions {
+	var o *PostHereMentions
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostHereMentionsIsValid(t *testing.T) {
+	mentions := &PostHereMentions{PostId: NewId(), UserIds: StringArray{NewId(), NewId()}}
+	mentions.PreSave()
+	require.Nil(t, mentions.IsValid())
+
+	mentions.UserIds = append(mentions.UserIds, "junk")
+	require.NotNil(t, mentions.IsValid())
+	assert.Equal(t, "model.post_here_mentions.is_valid.user_id.app_error", mentions.IsValid().Id)
+
+	mentions.UserIds = nil
+	mentions.PostId = "junk"
+	require.NotNil(t, mentions.IsValid())
+
+	mentions = &PostHereMentions{PostId: NewId()}
+	require.NotNil(t, mentions.IsValid())
+	mentions.PreSave()
+	require.Nil(t, mentions.IsValid())
+	assert.NotNil(t, mentions.UserIds)
+}
+
+func TestPostHereMentionsJson(t *testing.T) {
+	mentions := &PostHereMentions{PostId: NewId(), UserIds: StringArray{NewId()}, CreateAt: 1}
+
+	rmentions := PostHereMentionsFromJson(strings.NewReader(mentions.ToJson()))
+	require.NotNil(t, rmentions)
+	assert.Equal(t, mentions, rmentions)
+}
@@ -30,6 +30,28 @@ func (o *PostList) WithRewrittenImageURLs(f func(string) string) *PostList {
 	return &copy
 }
 
+// Clone returns a copy of the list that can be changed without affecting the original, including the order, the posts
+// and their props.
+func (o *PostList) Clone() *PostList {
+	copy := &PostList{
+		Order: append([]string(nil), o.Order...),
+		Posts: make(map[string]*Post, len(o.Posts)),
+	}
+
+	for id, post := range o.Posts {
+		postCopy := post.Clone()
+		if post.Props != nil {
+			postCopy.Props = make(StringInterface, len(post.Props))
+			for key, value := range post.Props {
+				postCopy.Props[key] = value
+			}
+		}
+		copy.Posts[id] = postCopy
+	}
+
+	return copy
+}
+
 func (o *PostList) StripActionIntegrations() {
 	posts := o.Posts
 	o.Posts = make(map[string]*Post)
@@ -90,3 +90,23 @@ func TestPostListSortByCreateAt(t *testing.T) {
 	assert.EqualValues(t, pl.Order[1], p1.Id)
 	assert.EqualValues(t, pl.Order[2], p2.Id)
 }
+
+func TestPostListClone(t *testing.T) {
+	pl := NewPostList()
+	p1 := &Post{Id: NewId(), Message: "message", Props: StringInterface{"key": "value"}}
+	pl.AddPost(p1)
+	pl.AddOrder(p1.Id)
+
+	clone := pl.Clone()
+	assert.Equal(t, pl, clone)
+
+	clone.AddOrder(NewId())
+	clone.Posts[p1.Id].Message = "changed"
+	clone.Posts[p1.Id].AddProp("key", "changed")
+	clone.Posts[NewId()] = &Post{}
+
+	assert.Equal(t, []string{p1.Id}, pl.Order)
+	assert.Len(t, pl.Posts, 1)
+	assert.Equal(t, "message", p1.Message)
+	assert.Equal(t, "value", p1.Props["key"])
+}
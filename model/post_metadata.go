@@ -7,6 +7,12 @@ import (
 	"encoding/json"
 )
 
+// Related objects that can be embedded in the metadata of posts by passing their names in the expand parameter.
+const (
+	POST_EXPAND_USER    = "user"
+	POST_EXPAND_CHANNEL = "channel"
+)
+
 type PostMetadata struct {
 	// Embeds holds information required to render content embedded in the post. This includes the OpenGraph metadata
 	// for links in the post.
@@ -26,6 +32,16 @@ type PostMetadata struct {
 
 	// Reactions holds reactions made to the post.
 	Reactions []*Reaction `json:"reactions,omitempty"`
+
+	// User holds the author of the post, sanitized for the user making the request, if it was expanded.
+	User *User `json:"user,omitempty"`
+
+	// Channel holds the channel containing the post, if it was expanded.
+	Channel *Channel `json:"channel,omitempty"`
+
+	// Quote holds the post that this post quotes as it was when this post was created, if it quotes one. Like a
+	// permalink embed, it only identifies the quoted post until the user viewing it has been checked for access.
+	Quote *PermalinkPreview `json:"quote,omitempty"`
 }
 
 type PostImage struct {
@@ -0,0 +1,50 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+const (
+	POST_MOVE_MAX_POSTS = 200
+)
+
+// PostsMoveRequest describes a set of posts to relocate from their current channel into ChannelId.
+type PostsMoveRequest struct {
+	ChannelId     string   `json:"channel_id"`
+	PostIds       []string `json:"post_ids"`
+	IncludeThread bool     `json:"include_thread"`
+}
+
+func (o *PostsMoveRequest) IsValid() *AppError {
+	if len(o.ChannelId) != 26 {
+		return NewAppError("PostsMoveRequest.IsValid", "model.posts_move_request.is_valid.channel_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if len(o.PostIds) == 0 || len(o.PostIds) > POST_MOVE_MAX_POSTS {
+		return NewAppError("PostsMoveRequest.IsValid", "model.posts_move_request.is_valid.post_ids.app_error", map[string]interface{}{"Max": POST_MOVE_MAX_POSTS}, "", http.StatusBadRequest)
+	}
+
+	for _, postId := range o.PostIds {
+		if len(postId) != 26 {
+			return NewAppError("PostsMoveRequest.IsValid", "model.posts_move_request.is_valid.post_id.app_error", nil, "post_id="+postId, http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+func (o *PostsMoveRequest) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func PostsMoveRequestFromJson(data io.Reader) *PostsMoveRequest {
+	var o *PostsMoveRequest
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
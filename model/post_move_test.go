@@ -0,0 +1,38 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostsMoveRequestJson(t *testing.T) {
+	o := PostsMoveRequest{ChannelId: NewId(), PostIds: []string{NewId(), NewId()}, IncludeThread: true}
+	ro := PostsMoveRequestFromJson(strings.NewReader(o.ToJson()))
+
+	assert.Equal(t, o, *ro)
+}
+
+func TestPostsMoveRequestIsValid(t *testing.T) {
+	o := PostsMoveRequest{}
+	assert.NotNil(t, o.IsValid())
+
+	o.ChannelId = NewId()
+	assert.NotNil(t, o.IsValid())
+
+	o.PostIds = []string{"junk"}
+	assert.NotNil(t, o.IsValid())
+
+	o.PostIds = []string{NewId()}
+	assert.Nil(t, o.IsValid())
+
+	o.PostIds = make([]string, POST_MOVE_MAX_POSTS+1)
+	for i := range o.PostIds {
+		o.PostIds[i] = NewId()
+	}
+	assert.NotNil(t, o.IsValid())
+}
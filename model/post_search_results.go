@@ -13,12 +13,40 @@ type PostSearchMatches map[string][]string
 type PostSearchResults struct {
 	*PostList
 	Matches PostSearchMatches `json:"matches"`
+	// Scores holds the relevance of each result, keyed by post id. It's only set when plugins have had the chance to
+	// re-rank the results.
+	Scores map[string]float64 `json:"scores,omitempty"`
+	// TeamIds maps each result to the team it was posted in for searches across multiple teams. Results from direct
+	// and group messages have an empty team id.
+	TeamIds map[string]string `json:"team_ids,omitempty"`
+	// SearchedAfter is the time from which posts were searched when the search was limited to recent posts by
+	// default, or 0 if posts of any age were searched.
+	SearchedAfter int64 `json:"searched_after,omitempty"`
+}
+
+// PostSearchQuery describes a post search that was made by a user. It's passed to plugins along with the results.
+type PostSearchQuery struct {
+	UserId string `json:"user_id"`
+	// TeamId is empty for searches across multiple teams, whose teams are listed in TeamIds instead.
+	TeamId     string   `json:"team_id"`
+	TeamIds    []string `json:"team_ids,omitempty"`
+	Terms      string   `json:"terms"`
+	IsOrSearch bool     `json:"is_or_search"`
 }
 
 func MakePostSearchResults(posts *PostList, matches PostSearchMatches) *PostSearchResults {
 	return &PostSearchResults{
-		posts,
-		matches,
+		PostList: posts,
+		Matches:  matches,
+	}
+}
+
+// SetRankScores scores the results from 1 down to 0 according to their order, for search backends that rank results
+// without scoring them.
+func (o *PostSearchResults) SetRankScores() {
+	o.Scores = make(map[string]float64, len(o.Order))
+	for i, postId := range o.Order {
+		o.Scores[postId] = 1 - float64(i)/float64(len(o.Order))
 	}
 }
 
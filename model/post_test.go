@@ -96,6 +96,16 @@ func TestPostIsValid(t *testing.T) {
 	if err := o.IsValid(maxPostSize); err != nil {
 		t.Fatal(err)
 	}
+
+	o.PinnedUntil = GetMillis()
+	if err := o.IsValid(maxPostSize); err == nil {
+		t.Fatal("should be invalid when not pinned")
+	}
+
+	o.IsPinned = true
+	if err := o.IsValid(maxPostSize); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestPostPreSave(t *testing.T) {
@@ -117,6 +127,21 @@ func TestPostPreSave(t *testing.T) {
 	o.Etag()
 }
 
+func TestPostPatchPinnedUntil(t *testing.T) {
+	p := Post{}
+
+	p.Patch(&PostPatch{IsPinned: NewBool(true), PinnedUntil: NewInt64(1234)})
+	assert.True(t, p.IsPinned)
+	assert.Equal(t, int64(1234), p.PinnedUntil)
+
+	p.Patch(&PostPatch{Message: NewString("edited")})
+	assert.Equal(t, int64(1234), p.PinnedUntil)
+
+	p.Patch(&PostPatch{IsPinned: NewBool(false)})
+	assert.False(t, p.IsPinned)
+	assert.Zero(t, p.PinnedUntil, "unpinning should cancel the expiry")
+}
+
 func TestPostIsSystemMessage(t *testing.T) {
 	post1 := Post{Message: "test_1"}
 	post1.PreSave()
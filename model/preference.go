@@ -14,6 +14,7 @@ import (
 
 const (
 	PREFERENCE_CATEGORY_DIRECT_CHANNEL_SHOW = "direct_channel_show"
+	PREFERENCE_CATEGORY_GROUP_CHANNEL_SHOW  = "group_channel_show"
 	PREFERENCE_CATEGORY_TUTORIAL_STEPS      = "tutorial_step"
 	PREFERENCE_CATEGORY_ADVANCED_SETTINGS   = "advanced_settings"
 	PREFERENCE_CATEGORY_FLAGGED_POST        = "flagged_post"
@@ -37,6 +38,12 @@ const (
 	PREFERENCE_NAME_LAST_CHANNEL = "channel"
 	PREFERENCE_NAME_LAST_TEAM    = "team"
 
+	PREFERENCE_CATEGORY_INACTIVITY       = "inactivity"
+	PREFERENCE_NAME_INACTIVITY_WARNED_AT = "warned_at"
+
+	PREFERENCE_CATEGORY_EMOJI_USAGE = "emoji_usage"
+	PREFERENCE_NAME_EMOJI_USAGE     = "usage"
+
 	PREFERENCE_CATEGORY_NOTIFICATIONS = "notifications"
 	PREFERENCE_NAME_EMAIL_INTERVAL    = "email_interval"
 
@@ -54,6 +61,9 @@ type Preference struct {
 	Category string `json:"category"`
 	Name     string `json:"name"`
 	Value    string `json:"value"`
+	// UpdateAt is when the value was last changed, which decides whether a synced value replaces it, see
+	// App.SyncPreferences.
+	UpdateAt int64 `json:"update_at"`
 }
 
 func (o *Preference) ToJson() string {
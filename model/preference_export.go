@@ -0,0 +1,32 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	USER_PREFERENCES_EXPORT_VERSION = 1
+)
+
+// UserPreferencesExport is a user's preferences in a form that can be imported into another account. The preferences
+// don't have a user id, and are mapped to the account that they're imported into.
+type UserPreferencesExport struct {
+	Version     int         `json:"version"`
+	ExportAt    int64       `json:"export_at"`
+	Preferences Preferences `json:"preferences"`
+}
+
+func (o *UserPreferencesExport) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func UserPreferencesExportFromJson(data io.Reader) *UserPreferencesExport {
+	var o *UserPreferencesExport
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
@@ -0,0 +1,24 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserPreferencesExportJson(t *testing.T) {
+	export := &UserPreferencesExport{
+		Version:     USER_PREFERENCES_EXPORT_VERSION,
+		ExportAt:    GetMillis(),
+		Preferences: Preferences{{Category: PREFERENCE_CATEGORY_DISPLAY_SETTINGS, Name: PREFERENCE_NAME_USE_MILITARY_TIME, Value: "true"}},
+	}
+
+	rexport := UserPreferencesExportFromJson(strings.NewReader(export.ToJson()))
+	assert.Equal(t, export, rexport)
+
+	assert.Nil(t, UserPreferencesExportFromJson(strings.NewReader("junk")))
+}
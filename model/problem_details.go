@@ -0,0 +1,49 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// PROBLEM_DETAILS_CONTENT_TYPE is the content type of errors that are written as ProblemDetails.
+const PROBLEM_DETAILS_CONTENT_TYPE = "application/problem+json"
+
+// ProblemDetails is an error in the form described by RFC 7807, for clients such as API gateways that expect errors to
+// be written that way. Errors aren't given a type of their own, so their title is the text of their status code as
+// that RFC says, while Id is an extension member holding the id of the AppError that the problem was made from.
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Id       string `json:"id,omitempty"`
+}
+
+// NewProblemDetails describes an error as a problem, with the message of the error as its detail and the id of the
+// request that it happened in as its instance.
+func NewProblemDetails(err *AppError) *ProblemDetails {
+	return &ProblemDetails{
+		Type:     "about:blank",
+		Title:    http.StatusText(err.StatusCode),
+		Status:   err.StatusCode,
+		Detail:   err.Message,
+		Instance: err.RequestId,
+		Id:       err.Id,
+	}
+}
+
+func (p *ProblemDetails) ToJson() string {
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+func ProblemDetailsFromJson(data io.Reader) *ProblemDetails {
+	var p *ProblemDetails
+	json.NewDecoder(data).Decode(&p)
+	return p
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProblemDetails(t *testing.T) {
+	err := NewAppError("Test", "model.test.app_error", nil, "internal details", http.StatusNotFound)
+	err.Message = "Unable to find the thing."
+	err.RequestId = NewId()
+
+	problem := NewProblemDetails(err)
+	assert.Equal(t, &ProblemDetails{
+		Type:     "about:blank",
+		Title:    "Not Found",
+		Status:   http.StatusNotFound,
+		Detail:   "Unable to find the thing.",
+		Instance: err.RequestId,
+		Id:       "model.test.app_error",
+	}, problem)
+
+	assert.NotContains(t, problem.ToJson(), "internal details")
+	assert.Equal(t, problem, ProblemDetailsFromJson(strings.NewReader(problem.ToJson())))
+}
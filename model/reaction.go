@@ -10,6 +10,10 @@ import (
 	"regexp"
 )
 
+const (
+	BULK_REACTION_MAX_POSTS = 200
+)
+
 type Reaction struct {
 	UserId    string `json:"user_id"`
 	PostId    string `json:"post_id"`
@@ -90,3 +94,54 @@ func (o *Reaction) PreSave() {
 		o.CreateAt = GetMillis()
 	}
 }
+
+// BulkReaction is a request to add the same reaction to many posts at once.
+type BulkReaction struct {
+	UserId    string   `json:"user_id"`
+	EmojiName string   `json:"emoji_name"`
+	PostIds   []string `json:"post_ids"`
+}
+
+// BulkReactionResult reports whether the reaction was added to one of the posts of a BulkReaction.
+type BulkReactionResult struct {
+	PostId   string    `json:"post_id"`
+	Reaction *Reaction `json:"reaction,omitempty"`
+	Error    *AppError `json:"error,omitempty"`
+}
+
+func (o *BulkReaction) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func BulkReactionFromJson(data io.Reader) *BulkReaction {
+	var o *BulkReaction
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+func (o *BulkReaction) IsValid() *AppError {
+	if len(o.PostIds) == 0 || len(o.PostIds) > BULK_REACTION_MAX_POSTS {
+		return NewAppError("BulkReaction.IsValid", "model.reaction.bulk.is_valid.post_ids.app_error", map[string]interface{}{"Max": BULK_REACTION_MAX_POSTS}, "", http.StatusBadRequest)
+	}
+
+	for _, postId := range o.PostIds {
+		reaction := &Reaction{UserId: o.UserId, PostId: postId, EmojiName: o.EmojiName, CreateAt: 1}
+		if err := reaction.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func BulkReactionResultsToJson(o []*BulkReactionResult) string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func BulkReactionResultsFromJson(data io.Reader) []*BulkReactionResult {
+	var o []*BulkReactionResult
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
@@ -82,3 +82,45 @@ func TestReactionIsValid(t *testing.T) {
 		t.Fatal("create at should be invalid")
 	}
 }
+
+func TestBulkReactionIsValid(t *testing.T) {
+	bulkReaction := BulkReaction{
+		UserId:    NewId(),
+		EmojiName: "emoji",
+		PostIds:   []string{NewId(), NewId()},
+	}
+
+	if err := bulkReaction.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	bulkReaction.PostIds = []string{NewId(), "junk"}
+	if err := bulkReaction.IsValid(); err == nil {
+		t.Fatal("post ids should be invalid")
+	}
+
+	bulkReaction.PostIds = []string{}
+	if err := bulkReaction.IsValid(); err == nil {
+		t.Fatal("post ids should be required")
+	}
+
+	bulkReaction.PostIds = make([]string, BULK_REACTION_MAX_POSTS+1)
+	for i := range bulkReaction.PostIds {
+		bulkReaction.PostIds[i] = NewId()
+	}
+	if err := bulkReaction.IsValid(); err == nil {
+		t.Fatal("too many post ids")
+	}
+
+	bulkReaction.PostIds = []string{NewId()}
+	bulkReaction.EmojiName = "emoji name"
+	if err := bulkReaction.IsValid(); err == nil {
+		t.Fatal("emoji name should be invalid")
+	}
+
+	bulkReaction.EmojiName = "emoji"
+	bulkReaction.UserId = ""
+	if err := bulkReaction.IsValid(); err == nil {
+		t.Fatal("user id should be invalid")
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+// Names of the API endpoints whose responses can be cached by listing them in ServiceSettings.ResponseCacheEndpoints.
+const (
+	RESPONSE_CACHE_ENDPOINT_EMOJI_LIST    = "emoji_list"
+	RESPONSE_CACHE_ENDPOINT_CLIENT_CONFIG = "client_config"
+)
+
+func IsValidResponseCacheEndpoint(endpoint string) bool {
+	return endpoint == RESPONSE_CACHE_ENDPOINT_EMOJI_LIST || endpoint == RESPONSE_CACHE_ENDPOINT_CLIENT_CONFIG
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+const (
+	SEARCH_EXPORT_FORMAT_JSONL = "jsonl"
+	SEARCH_EXPORT_FORMAT_CSV   = "csv"
+)
+
+// PostSearchExportRow is a post matching a search, along with the names of its channel and author, as it's written
+// to a search export.
+type PostSearchExportRow struct {
+	PostId      string `json:"post_id"`
+	CreateAt    int64  `json:"create_at"`
+	TeamId      string `json:"team_id"`
+	ChannelId   string `json:"channel_id"`
+	ChannelName string `json:"channel_name"`
+	UserId      string `json:"user_id"`
+	Username    string `json:"username"`
+	RootId      string `json:"root_id"`
+	Message     string `json:"message"`
+}
+
+func IsValidSearchExportFormat(format string) bool {
+	return format == SEARCH_EXPORT_FORMAT_JSONL || format == SEARCH_EXPORT_FORMAT_CSV
+}
+
+func (r *PostSearchExportRow) ToJson() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// PostSearchExportCSVHeader returns the column names of a CSV search export, in the same order as CSVRecord.
+func PostSearchExportCSVHeader() []string {
+	return []string{"post_id", "create_at", "team_id", "channel_id", "channel_name", "user_id", "username", "root_id", "message"}
+}
+
+func (r *PostSearchExportRow) CSVRecord() []string {
+	return []string{
+		r.PostId,
+		strconv.FormatInt(r.CreateAt, 10),
+		r.TeamId,
+		r.ChannelId,
+		r.ChannelName,
+		r.UserId,
+		r.Username,
+		r.RootId,
+		r.Message,
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidSearchExportFormat(t *testing.T) {
+	assert.True(t, IsValidSearchExportFormat(SEARCH_EXPORT_FORMAT_JSONL))
+	assert.True(t, IsValidSearchExportFormat(SEARCH_EXPORT_FORMAT_CSV))
+	assert.False(t, IsValidSearchExportFormat(""))
+	assert.False(t, IsValidSearchExportFormat("xml"))
+}
+
+func TestPostSearchExportRow(t *testing.T) {
+	row := &PostSearchExportRow{
+		PostId:      NewId(),
+		CreateAt:    1234,
+		TeamId:      NewId(),
+		ChannelId:   NewId(),
+		ChannelName: "town-square",
+		UserId:      NewId(),
+		Username:    "someone",
+		Message:     "hello, \"world\"",
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var decoded PostSearchExportRow
+		require.Nil(t, json.Unmarshal([]byte(row.ToJson()), &decoded))
+		assert.Equal(t, *row, decoded)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		record := row.CSVRecord()
+		require.Len(t, record, len(PostSearchExportCSVHeader()))
+		assert.Equal(t, row.PostId, record[0])
+		assert.Equal(t, "1234", record[1])
+		assert.Equal(t, row.Message, record[len(record)-1])
+	})
+}
@@ -4,20 +4,26 @@
 package model
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"io"
+	"strconv"
 	"strings"
 )
 
 const (
 	SESSION_COOKIE_TOKEN              = "MMAUTHTOKEN"
 	SESSION_COOKIE_USER               = "MMUSERID"
+	SESSION_COOKIE_CSRF               = "MMCSRF"
 	SESSION_CACHE_SIZE                = 35000
 	SESSION_PROP_PLATFORM             = "platform"
 	SESSION_PROP_OS                   = "os"
 	SESSION_PROP_BROWSER              = "browser"
 	SESSION_PROP_TYPE                 = "type"
 	SESSION_PROP_USER_ACCESS_TOKEN_ID = "user_access_token_id"
+	SESSION_PROP_CSRF                 = "csrf"
+	SESSION_PROP_CSRF_PREVIOUS        = "csrf_previous"
+	SESSION_PROP_CSRF_ISSUED_AT       = "csrf_issued_at"
 	SESSION_TYPE_USER_ACCESS_TOKEN    = "UserAccessToken"
 	SESSION_ACTIVITY_TIMEOUT          = 1000 * 60 * 5 // 5 minutes
 	SESSION_USER_ACCESS_TOKEN_EXPIRY  = 100 * 365     // 100 years
@@ -135,9 +141,16 @@ func (me *Session) GetUserRoles() []string {
 	return strings.Fields(me.Roles)
 }
 
+// GenerateCSRF replaces the CSRF token of the session. The token that it replaces is still accepted by IsValidCSRF
+// until the next one is generated, so that requests that were already sent when it was rotated don't fail.
 func (me *Session) GenerateCSRF() string {
+	if previous := me.GetCSRF(); previous != "" {
+		me.AddProp(SESSION_PROP_CSRF_PREVIOUS, previous)
+	}
+
 	token := NewId()
-	me.AddProp("csrf", token)
+	me.AddProp(SESSION_PROP_CSRF, token)
+	me.AddProp(SESSION_PROP_CSRF_ISSUED_AT, strconv.FormatInt(GetMillis(), 10))
 	return token
 }
 
@@ -146,7 +159,32 @@ func (me *Session) GetCSRF() string {
 		return ""
 	}
 
-	return me.Props["csrf"]
+	return me.Props[SESSION_PROP_CSRF]
+}
+
+// GetCSRFIssuedAt returns when the CSRF token of the session was generated, or 0 if that isn't known.
+func (me *Session) GetCSRFIssuedAt() int64 {
+	if me.Props == nil {
+		return 0
+	}
+
+	issuedAt, _ := strconv.ParseInt(me.Props[SESSION_PROP_CSRF_ISSUED_AT], 10, 64)
+	return issuedAt
+}
+
+// IsValidCSRF returns whether the given token is the CSRF token of the session, or the one that it replaced.
+func (me *Session) IsValidCSRF(token string) bool {
+	if token == "" || me.Props == nil {
+		return false
+	}
+
+	for _, key := range []string{SESSION_PROP_CSRF, SESSION_PROP_CSRF_PREVIOUS} {
+		if expected := me.Props[key]; expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+			return true
+		}
+	}
+
+	return false
 }
 
 func SessionsToJson(o []*Session) string {
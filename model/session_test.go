@@ -75,4 +75,25 @@ func TestSessionCSRF(t *testing.T) {
 	token2 := s.GetCSRF()
 	assert.NotEmpty(t, token2)
 	assert.Equal(t, token, token2)
+	assert.NotZero(t, s.GetCSRFIssuedAt())
+}
+
+func TestSessionIsValidCSRF(t *testing.T) {
+	s := Session{}
+	assert.False(t, s.IsValidCSRF(""))
+
+	token := s.GenerateCSRF()
+	assert.True(t, s.IsValidCSRF(token))
+	assert.False(t, s.IsValidCSRF(""))
+	assert.False(t, s.IsValidCSRF(NewId()))
+
+	token2 := s.GenerateCSRF()
+	assert.NotEqual(t, token, token2)
+	assert.True(t, s.IsValidCSRF(token2))
+	assert.True(t, s.IsValidCSRF(token), "the previous token should be accepted until the next rotation")
+
+	token3 := s.GenerateCSRF()
+	assert.True(t, s.IsValidCSRF(token3))
+	assert.True(t, s.IsValidCSRF(token2))
+	assert.False(t, s.IsValidCSRF(token))
 }
@@ -0,0 +1,62 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// SystemStatus is a snapshot of the health of a server that's cheap enough to gather without enabling metrics.
+type SystemStatus struct {
+	Timestamp  int64          `json:"timestamp"`
+	Goroutines int            `json:"goroutines"`
+	Memory     *MemoryStats   `json:"memory"`
+	DbPools    []*DbPoolStats `json:"db_pools"`
+	Caches     []*CacheStats  `json:"caches"`
+}
+
+type MemoryStats struct {
+	Alloc        uint64 `json:"alloc"`
+	TotalAlloc   uint64 `json:"total_alloc"`
+	Sys          uint64 `json:"sys"`
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	NumGC        uint32 `json:"num_gc"`
+	PauseTotalNs uint64 `json:"pause_total_ns"`
+}
+
+// DbPoolStats describes the connection pool of the master database or one of its replicas.
+type DbPoolStats struct {
+	Name                     string `json:"name"`
+	MaxOpenConnections       int    `json:"max_open_connections"`
+	OpenConnections          int    `json:"open_connections"`
+	InUse                    int    `json:"in_use"`
+	Idle                     int    `json:"idle"`
+	WaitCount                int64  `json:"wait_count"`
+	WaitDurationMilliseconds int64  `json:"wait_duration_milliseconds"`
+}
+
+// CacheStats describes the usage of an in-memory cache since the server started. HitRate is the fraction of lookups
+// that were hits, or 0 if there haven't been any.
+type CacheStats struct {
+	Name    string  `json:"name"`
+	Size    int     `json:"size"`
+	Len     int     `json:"len"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+func (s *SystemStatus) ToJson() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func SystemStatusFromJson(data io.Reader) *SystemStatus {
+	var s *SystemStatus
+	json.NewDecoder(data).Decode(&s)
+	return s
+}
@@ -17,6 +17,7 @@ const (
 	TEAM_OPEN                       = "O"
 	TEAM_INVITE                     = "I"
 	TEAM_ALLOWED_DOMAINS_MAX_LENGTH = 500
+	TEAM_CHANNEL_PATTERN_MAX_LENGTH = 128
 	TEAM_COMPANY_NAME_MAX_LENGTH    = 64
 	TEAM_DESCRIPTION_MAX_LENGTH     = 255
 	TEAM_DISPLAY_NAME_MAX_RUNES     = 64
@@ -41,6 +42,24 @@ type Team struct {
 	AllowOpenInvite    bool    `json:"allow_open_invite"`
 	LastTeamIconUpdate int64   `json:"last_team_icon_update,omitempty"`
 	SchemeId           *string `json:"scheme_id"`
+	MaxPostSize        int     `json:"max_post_size"`
+	// ChannelNamePattern and ChannelDisplayNamePattern are regular expressions that the whole URL name and display
+	// name of new or renamed channels in the team must match. Empty patterns allow any name.
+	ChannelNamePattern        string `json:"channel_name_pattern"`
+	ChannelDisplayNamePattern string `json:"channel_display_name_pattern"`
+	// InactiveChannelDays overrides InactiveChannelSettings.InactiveDays for the team's channels when non-zero.
+	InactiveChannelDays int `json:"inactive_channel_days"`
+	// StorageQuota is the number of bytes of files that may be uploaded to the team, or 0 for no limit. It can only
+	// be changed by system admins, see TeamStorageQuotaPatch.
+	StorageQuota int64 `json:"storage_quota"`
+	// MaxMembers is the number of active members that the team may have, or 0 for no limit. Once it's reached, users
+	// who join through the team's invite link are added to its waitlist if AllowWaitlist is set. They can only be
+	// changed by system admins, see TeamMembershipLimitPatch.
+	MaxMembers    int  `json:"max_members"`
+	AllowWaitlist bool `json:"allow_waitlist"`
+	// AutoJoin adds new users whose email matches the team's AllowedDomains to the team and its default channels. It
+	// can only be changed by system admins, see TeamAutoJoinPatch.
+	AutoJoin bool `json:"auto_join"`
 }
 
 type TeamPatch struct {
@@ -50,6 +69,12 @@ type TeamPatch struct {
 	AllowedDomains  *string `json:"allowed_domains"`
 	InviteId        *string `json:"invite_id"`
 	AllowOpenInvite *bool   `json:"allow_open_invite"`
+	MaxPostSize     *int    `json:"max_post_size"`
+
+	ChannelNamePattern        *string `json:"channel_name_pattern"`
+	ChannelDisplayNamePattern *string `json:"channel_display_name_pattern"`
+
+	InactiveChannelDays *int `json:"inactive_channel_days"`
 }
 
 type TeamForExport struct {
@@ -117,6 +142,17 @@ func (o *Team) Etag() string {
 	return Etag(o.Id, o.UpdateAt)
 }
 
+// LastModifiedAt returns the latest time at which any of the team's stored fields changed.
+func (o *Team) LastModifiedAt() int64 {
+	lastModified := o.UpdateAt
+	for _, at := range []int64{o.DeleteAt, o.LastTeamIconUpdate} {
+		if at > lastModified {
+			lastModified = at
+		}
+	}
+	return lastModified
+}
+
 func (o *Team) IsValid() *AppError {
 
 	if len(o.Id) != 26 {
@@ -171,6 +207,31 @@ func (o *Team) IsValid() *AppError {
 		return NewAppError("Team.IsValid", "model.team.is_valid.domains.app_error", nil, "id="+o.Id, http.StatusBadRequest)
 	}
 
+	if o.MaxPostSize < 0 {
+		return NewAppError("Team.IsValid", "model.team.is_valid.max_post_size.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	for _, pattern := range []string{o.ChannelNamePattern, o.ChannelDisplayNamePattern} {
+		if len(pattern) > TEAM_CHANNEL_PATTERN_MAX_LENGTH {
+			return NewAppError("Team.IsValid", "model.team.is_valid.channel_pattern.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+		}
+		if _, err := compileChannelPattern(pattern); err != nil {
+			return NewAppError("Team.IsValid", "model.team.is_valid.channel_pattern.app_error", nil, "id="+o.Id+", "+err.Error(), http.StatusBadRequest)
+		}
+	}
+
+	if o.InactiveChannelDays < 0 {
+		return NewAppError("Team.IsValid", "model.team.is_valid.inactive_channel_days.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if o.StorageQuota < 0 {
+		return NewAppError("Team.IsValid", "model.team.is_valid.storage_quota.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if o.MaxMembers < 0 {
+		return NewAppError("Team.IsValid", "model.team.is_valid.max_members.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
 	return nil
 }
 
@@ -273,6 +334,51 @@ func (t *Team) Patch(patch *TeamPatch) {
 	if patch.AllowOpenInvite != nil {
 		t.AllowOpenInvite = *patch.AllowOpenInvite
 	}
+
+	if patch.MaxPostSize != nil {
+		t.MaxPostSize = *patch.MaxPostSize
+	}
+
+	if patch.ChannelNamePattern != nil {
+		t.ChannelNamePattern = *patch.ChannelNamePattern
+	}
+
+	if patch.ChannelDisplayNamePattern != nil {
+		t.ChannelDisplayNamePattern = *patch.ChannelDisplayNamePattern
+	}
+
+	if patch.InactiveChannelDays != nil {
+		t.InactiveChannelDays = *patch.InactiveChannelDays
+	}
+}
+
+// IsChannelNameAllowed returns true if the URL name matches the team's ChannelNamePattern.
+func (t *Team) IsChannelNameAllowed(name string) bool {
+	return matchesChannelPattern(t.ChannelNamePattern, name)
+}
+
+// IsChannelDisplayNameAllowed returns true if the display name matches the team's ChannelDisplayNamePattern.
+func (t *Team) IsChannelDisplayNameAllowed(displayName string) bool {
+	return matchesChannelPattern(t.ChannelDisplayNamePattern, displayName)
+}
+
+// compileChannelPattern compiles a channel naming pattern so that it must match the whole name.
+func compileChannelPattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+func matchesChannelPattern(pattern string, name string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	re, err := compileChannelPattern(pattern)
+	if err != nil {
+		// Invalid patterns are rejected when the team is saved.
+		return true
+	}
+
+	return re.MatchString(name)
 }
 
 func (t *TeamPatch) ToJson() string {
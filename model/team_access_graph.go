@@ -0,0 +1,44 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	TEAM_ACCESS_GRAPH_ENTRY_TEAM_MEMBER    = "team_member"
+	TEAM_ACCESS_GRAPH_ENTRY_CHANNEL        = "channel"
+	TEAM_ACCESS_GRAPH_ENTRY_CHANNEL_MEMBER = "channel_member"
+)
+
+// TeamAccessGraphEntry is a line of the access graph of a team, which lists the members of the team, and then each of
+// its channels followed by the channel's members, along with their roles. Only the field matching the type is set.
+type TeamAccessGraphEntry struct {
+	Type          string         `json:"type"`
+	TeamMember    *TeamMember    `json:"team_member,omitempty"`
+	Channel       *Channel       `json:"channel,omitempty"`
+	ChannelMember *ChannelMember `json:"channel_member,omitempty"`
+}
+
+func (o *TeamAccessGraphEntry) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+// TeamAccessGraphFromJsonl reads the entries of a team's access graph, one per line, until the end of data or the
+// first one that can't be read.
+func TeamAccessGraphFromJsonl(data io.Reader) []*TeamAccessGraphEntry {
+	entries := []*TeamAccessGraphEntry{}
+
+	decoder := json.NewDecoder(data)
+	for {
+		var entry *TeamAccessGraphEntry
+		if err := decoder.Decode(&entry); err != nil || entry == nil {
+			return entries
+		}
+		entries = append(entries, entry)
+	}
+}
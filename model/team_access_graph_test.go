@@ -0,0 +1,25 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamAccessGraphFromJsonl(t *testing.T) {
+	member := &TeamAccessGraphEntry{Type: TEAM_ACCESS_GRAPH_ENTRY_TEAM_MEMBER, TeamMember: &TeamMember{TeamId: NewId(), UserId: NewId(), Roles: "team_user"}}
+	channel := &TeamAccessGraphEntry{Type: TEAM_ACCESS_GRAPH_ENTRY_CHANNEL, Channel: &Channel{Id: NewId(), Name: "town-square"}}
+
+	entries := TeamAccessGraphFromJsonl(strings.NewReader(member.ToJson() + "\n" + channel.ToJson() + "\n"))
+	require.Len(t, entries, 2)
+	assert.Equal(t, member, entries[0])
+	assert.Equal(t, channel, entries[1])
+
+	assert.Empty(t, TeamAccessGraphFromJsonl(strings.NewReader("")))
+	assert.Len(t, TeamAccessGraphFromJsonl(strings.NewReader(member.ToJson()+"\n{")), 1)
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	TEAM_ACTIVITY_MAX_DAYS = 366
+)
+
+// TeamActivityDay holds the activity for a team on a single UTC day, formatted as YYYY-MM-DD.
+type TeamActivityDay struct {
+	Date        string `json:"date"`
+	ActiveUsers int64  `json:"active_users"`
+	PostCount   int64  `json:"post_count"`
+}
+
+// TeamActivitySummary holds aggregate activity metrics for a team. It deliberately contains
+// only counts so that it can be shared without exposing who said what.
+type TeamActivitySummary struct {
+	TeamId      string             `json:"team_id"`
+	Since       int64              `json:"since"`
+	Until       int64              `json:"until"`
+	ActiveUsers int64              `json:"active_users"`
+	TotalPosts  int64              `json:"total_posts"`
+	NewChannels int64              `json:"new_channels"`
+	Days        []*TeamActivityDay `json:"days"`
+}
+
+func (o *TeamActivitySummary) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func TeamActivitySummaryFromJson(data io.Reader) *TeamActivitySummary {
+	var o *TeamActivitySummary
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
@@ -0,0 +1,28 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamActivitySummaryJson(t *testing.T) {
+	summary := &TeamActivitySummary{
+		TeamId:      NewId(),
+		Since:       1000,
+		Until:       2000,
+		ActiveUsers: 2,
+		TotalPosts:  5,
+		NewChannels: 1,
+		Days:        []*TeamActivityDay{{Date: "2019-01-01", ActiveUsers: 2, PostCount: 5}},
+	}
+
+	json := summary.ToJson()
+	rsummary := TeamActivitySummaryFromJson(strings.NewReader(json))
+
+	assert.Equal(t, summary, rsummary)
+}
@@ -0,0 +1,25 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// TeamAutoJoinPatch sets the AutoJoin of a team.
+type TeamAutoJoinPatch struct {
+	AutoJoin *bool `json:"auto_join"`
+}
+
+func (p *TeamAutoJoinPatch) ToJson() string {
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+func TeamAutoJoinPatchFromJson(data io.Reader) *TeamAutoJoinPatch {
+	var p *TeamAutoJoinPatch
+	json.NewDecoder(data).Decode(&p)
+	return p
+}
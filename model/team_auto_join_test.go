@@ -0,0 +1,25 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamAutoJoinPatchJson(t *testing.T) {
+	patch := &TeamAutoJoinPatch{AutoJoin: NewBool(true)}
+
+	rpatch := TeamAutoJoinPatchFromJson(strings.NewReader(patch.ToJson()))
+	require.NotNil(t, rpatch)
+	require.NotNil(t, rpatch.AutoJoin)
+	assert.True(t, *rpatch.AutoJoin)
+
+	rpatch = TeamAutoJoinPatchFromJson(strings.NewReader("{}"))
+	require.NotNil(t, rpatch)
+	assert.Nil(t, rpatch.AutoJoin)
+}
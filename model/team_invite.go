@@ -0,0 +1,146 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+const (
+	TEAM_INVITE_MAX_CHANNELS = 50
+)
+
+// TeamInvite is an invite link to a team that, unlike the team's InviteId, can expire, be limited to a number of uses
+// and add the users who join with it to some of the team's channels or make them team admins. Its Id is the secret
+// that's shared in the link.
+type TeamInvite struct {
+	Id        string `json:"id"`
+	TeamId    string `json:"team_id"`
+	CreatorId string `json:"creator_id"`
+	CreateAt  int64  `json:"create_at"`
+	DeleteAt  int64  `json:"delete_at"`
+
+	// ExpireAt is when the invite stops working, or 0 if it doesn't expire.
+	ExpireAt int64 `json:"expire_at"`
+
+	// MaxUses is how many users can join with the invite, or 0 for no limit, and Uses how many have.
+	MaxUses int `json:"max_uses"`
+	Uses    int `json:"uses"`
+
+	// ChannelIds are the channels of the team that users who join with the invite are added to, and SchemeAdmin
+	// makes them admins of the team.
+	ChannelIds  StringArray `json:"channel_ids"`
+	SchemeAdmin bool        `json:"scheme_admin"`
+}
+
+func (o *TeamInvite) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+
+	if o.ChannelIds == nil {
+		o.ChannelIds = StringArray{}
+	}
+}
+
+func (o *TeamInvite) IsValid() *AppError {
+	if !IsValidId(o.Id) {
+		return NewAppError("TeamInvite.IsValid", "model.team_invite.is_valid.id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if !IsValidId(o.TeamId) {
+		return NewAppError("TeamInvite.IsValid", "model.team_invite.is_valid.team_id.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if !IsValidId(o.CreatorId) {
+		return NewAppError("TeamInvite.IsValid", "model.team_invite.is_valid.creator_id.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if o.CreateAt == 0 {
+		return NewAppError("TeamInvite.IsValid", "model.team_invite.is_valid.create_at.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if o.ExpireAt < 0 || (o.ExpireAt != 0 && o.ExpireAt <= o.CreateAt) {
+		return NewAppError("TeamInvite.IsValid", "model.team_invite.is_valid.expire_at.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if o.MaxUses < 0 || o.Uses < 0 {
+		return NewAppError("TeamInvite.IsValid", "model.team_invite.is_valid.max_uses.app_error", nil, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	if len(o.ChannelIds) > TEAM_INVITE_MAX_CHANNELS {
+		return NewAppError("TeamInvite.IsValid", "model.team_invite.is_valid.channel_ids.app_error", map[string]interface{}{"Max": TEAM_INVITE_MAX_CHANNELS}, "id="+o.Id, http.StatusBadRequest)
+	}
+
+	for _, channelId := range o.ChannelIds {
+		if !IsValidId(channelId) {
+			return NewAppError("TeamInvite.IsValid", "model.team_invite.is_valid.channel_ids.app_error", map[string]interface{}{"Max": TEAM_INVITE_MAX_CHANNELS}, "id="+o.Id, http.StatusBadRequest)
+		}
+	}
+
+	return nil
+}
+
+// TeamInvitePreview is what a user is shown about an invite link before accepting it.
+type TeamInvitePreview struct {
+	TeamId          string     `json:"team_id"`
+	TeamName        string     `json:"team_name"`
+	TeamDisplayName string     `json:"team_display_name"`
+	TeamDescription string     `json:"team_description"`
+	ExpireAt        int64      `json:"expire_at"`
+	SchemeAdmin     bool       `json:"scheme_admin"`
+	Channels        []*Channel `json:"channels"`
+}
+
+func (o *TeamInvitePreview) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func TeamInvitePreviewFromJson(data io.Reader) *TeamInvitePreview {
+	var o *TeamInvitePreview
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+// IsExpired returns whether the invite has expired at the given time.
+func (o *TeamInvite) IsExpired(now int64) bool {
+	return o.ExpireAt != 0 && now >= o.ExpireAt
+}
+
+// IsExhausted returns whether as many users as allowed have joined with the invite.
+func (o *TeamInvite) IsExhausted() bool {
+	return o.MaxUses != 0 && o.Uses >= o.MaxUses
+}
+
+func (o *TeamInvite) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func TeamInviteFromJson(data io.Reader) *TeamInvite {
+	var o *TeamInvite
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+func TeamInvitesToJson(o []*TeamInvite) string {
+	if b, err := json.Marshal(o); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func TeamInvitesFromJson(data io.Reader) []*TeamInvite {
+	var o []*TeamInvite
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
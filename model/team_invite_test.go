@@ -0,0 +1,85 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamInviteJson(t *testing.T) {
+	invite := &TeamInvite{TeamId: NewId(), CreatorId: NewId(), ExpireAt: GetMillis() + 1000, MaxUses: 10, ChannelIds: StringArray{NewId()}, SchemeAdmin: true}
+	invite.PreSave()
+
+	rinvite := TeamInviteFromJson(strings.NewReader(invite.ToJson()))
+	assert.Equal(t, invite, rinvite)
+
+	rinvites := TeamInvitesFromJson(strings.NewReader(TeamInvitesToJson([]*TeamInvite{invite})))
+	assert.Equal(t, []*TeamInvite{invite}, rinvites)
+}
+
+func TestTeamInviteIsValid(t *testing.T) {
+	invite := &TeamInvite{}
+	assert.NotNil(t, invite.IsValid())
+
+	invite.PreSave()
+	assert.NotNil(t, invite.IsValid())
+	assert.NotNil(t, invite.ChannelIds)
+
+	invite.TeamId = NewId()
+	assert.NotNil(t, invite.IsValid())
+
+	invite.CreatorId = NewId()
+	assert.Nil(t, invite.IsValid())
+
+	invite.ExpireAt = invite.CreateAt
+	assert.NotNil(t, invite.IsValid())
+
+	invite.ExpireAt = invite.CreateAt + 1000
+	assert.Nil(t, invite.IsValid())
+
+	invite.MaxUses = -1
+	assert.NotNil(t, invite.IsValid())
+
+	invite.MaxUses = 5
+	assert.Nil(t, invite.IsValid())
+
+	invite.ChannelIds = StringArray{"junk"}
+	assert.NotNil(t, invite.IsValid())
+
+	invite.ChannelIds = make(StringArray, TEAM_INVITE_MAX_CHANNELS+1)
+	for i := range invite.ChannelIds {
+		invite.ChannelIds[i] = NewId()
+	}
+	assert.NotNil(t, invite.IsValid())
+
+	invite.ChannelIds = invite.ChannelIds[:TEAM_INVITE_MAX_CHANNELS]
+	assert.Nil(t, invite.IsValid())
+}
+
+func TestTeamInviteIsExpiredAndExhausted(t *testing.T) {
+	invite := &TeamInvite{}
+	assert.False(t, invite.IsExpired(GetMillis()))
+	assert.False(t, invite.IsExhausted())
+
+	invite.ExpireAt = 2000
+	assert.False(t, invite.IsExpired(1999))
+	assert.True(t, invite.IsExpired(2000))
+
+	invite.MaxUses = 2
+	invite.Uses = 1
+	assert.False(t, invite.IsExhausted())
+
+	invite.Uses = 2
+	assert.True(t, invite.IsExhausted())
+}
+
+func TestTeamInvitePreviewJson(t *testing.T) {
+	preview := &TeamInvitePreview{TeamId: NewId(), TeamName: "name", Channels: []*Channel{{Id: NewId()}}}
+
+	rpreview := TeamInvitePreviewFromJson(strings.NewReader(preview.ToJson()))
+	assert.Equal(t, preview, rpreview)
+}
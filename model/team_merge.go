@@ -0,0 +1,117 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+const (
+	// Channels of the source team whose names are taken in the target team are either renamed when they're moved, or
+	// merged into the channel that has their name.
+	TEAM_MERGE_CHANNEL_COLLISION_RENAME = "rename"
+	TEAM_MERGE_CHANNEL_COLLISION_MERGE  = "merge"
+
+	TEAM_MERGE_RESOLUTION_MOVE   = "move"
+	TEAM_MERGE_RESOLUTION_RENAME = "rename"
+	TEAM_MERGE_RESOLUTION_MERGE  = "merge"
+	TEAM_MERGE_RESOLUTION_SKIP   = "skip"
+
+	TEAM_MERGE_REASON_NAME_TAKEN      = "name_taken"
+	TEAM_MERGE_REASON_DEFAULT_CHANNEL = "default_channel"
+	TEAM_MERGE_REASON_TYPE_MISMATCH   = "type_mismatch"
+	TEAM_MERGE_REASON_TARGET_ARCHIVED = "target_archived"
+	TEAM_MERGE_REASON_ARCHIVED        = "archived"
+)
+
+// TeamMergeRequest asks for a team to be merged into the target team. With DryRun set, nothing is changed and the
+// merge is only planned, so that its conflicts can be reviewed first.
+type TeamMergeRequest struct {
+	TargetTeamId           string `json:"target_team_id"`
+	ChannelCollisionPolicy string `json:"channel_collision_policy"`
+	DryRun                 bool   `json:"dry_run"`
+}
+
+// TeamMergeChannel describes what merging teams does, or would do, with one of the channels of the source team.
+type TeamMergeChannel struct {
+	ChannelId  string `json:"channel_id"`
+	Name       string `json:"name"`
+	Resolution string `json:"resolution"`
+
+	// Reason is why a channel isn't simply moved, one of TEAM_MERGE_REASON_*.
+	Reason string `json:"reason,omitempty"`
+
+	// NewName is the name that a renamed channel has in the target team.
+	NewName string `json:"new_name,omitempty"`
+
+	// TargetChannelId is the channel of the target team that has the channel's name, which it's merged into if it's
+	// merged.
+	TargetChannelId string `json:"target_channel_id,omitempty"`
+}
+
+// TeamMergeReport describes the changes made, or that would be made, by merging a team into another.
+type TeamMergeReport struct {
+	SourceTeamId string `json:"source_team_id"`
+	TargetTeamId string `json:"target_team_id"`
+	DryRun       bool   `json:"dry_run"`
+
+	// NewMembers are the members of the source team who are added to the target team, and ExistingMembers the number
+	// who were already in both.
+	NewMembers      []string `json:"new_members"`
+	ExistingMembers int      `json:"existing_members"`
+
+	Channels []*TeamMergeChannel `json:"channels"`
+
+	// MovedCommands are the triggers of the slash commands that are moved to the target team, and CommandConflicts
+	// those that are left in the source team since the target team already has a command with the same trigger.
+	MovedCommands    []string `json:"moved_commands"`
+	CommandConflicts []string `json:"command_conflicts"`
+}
+
+func (o *TeamMergeRequest) IsValid() *AppError {
+	if !IsValidId(o.TargetTeamId) {
+		return NewAppError("TeamMergeRequest.IsValid", "model.team_merge_request.is_valid.target_team_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if o.ChannelCollisionPolicy != TEAM_MERGE_CHANNEL_COLLISION_RENAME && o.ChannelCollisionPolicy != TEAM_MERGE_CHANNEL_COLLISION_MERGE {
+		return NewAppError("TeamMergeRequest.IsValid", "model.team_merge_request.is_valid.channel_collision_policy.app_error", nil, "channel_collision_policy="+o.ChannelCollisionPolicy, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (o *TeamMergeRequest) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func TeamMergeRequestFromJson(data io.Reader) *TeamMergeRequest {
+	var o *TeamMergeRequest
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+// Conflicts returns the channels that can't simply be moved to the target team.
+func (o *TeamMergeReport) Conflicts() []*TeamMergeChannel {
+	conflicts := []*TeamMergeChannel{}
+	for _, channel := range o.Channels {
+		if channel.Resolution != TEAM_MERGE_RESOLUTION_MOVE {
+			conflicts = append(conflicts, channel)
+		}
+	}
+	return conflicts
+}
+
+func (o *TeamMergeReport) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func TeamMergeReportFromJson(data io.Reader) *TeamMergeReport {
+	var o *TeamMergeReport
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
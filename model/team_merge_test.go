@@ -0,0 +1,54 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamMergeRequestJson(t *testing.T) {
+	o := TeamMergeRequest{TargetTeamId: NewId(), ChannelCollisionPolicy: TEAM_MERGE_CHANNEL_COLLISION_MERGE, DryRun: true}
+	ro := TeamMergeRequestFromJson(strings.NewReader(o.ToJson()))
+
+	assert.Equal(t, o, *ro)
+}
+
+func TestTeamMergeRequestIsValid(t *testing.T) {
+	o := TeamMergeRequest{}
+	assert.NotNil(t, o.IsValid())
+
+	o.TargetTeamId = NewId()
+	assert.NotNil(t, o.IsValid())
+
+	o.ChannelCollisionPolicy = "junk"
+	assert.NotNil(t, o.IsValid())
+
+	o.ChannelCollisionPolicy = TEAM_MERGE_CHANNEL_COLLISION_RENAME
+	assert.Nil(t, o.IsValid())
+
+	o.ChannelCollisionPolicy = TEAM_MERGE_CHANNEL_COLLISION_MERGE
+	assert.Nil(t, o.IsValid())
+}
+
+func TestTeamMergeReport(t *testing.T) {
+	o := TeamMergeReport{
+		SourceTeamId: NewId(),
+		TargetTeamId: NewId(),
+		NewMembers:   []string{NewId()},
+		Channels: []*TeamMergeChannel{
+			{ChannelId: NewId(), Name: "moved", Resolution: TEAM_MERGE_RESOLUTION_MOVE},
+			{ChannelId: NewId(), Name: "town-square", Resolution: TEAM_MERGE_RESOLUTION_MERGE, Reason: TEAM_MERGE_REASON_DEFAULT_CHANNEL, TargetChannelId: NewId()},
+		},
+		MovedCommands:    []string{"moved"},
+		CommandConflicts: []string{},
+	}
+
+	ro := TeamMergeReportFromJson(strings.NewReader(o.ToJson()))
+	assert.Equal(t, o, *ro)
+
+	assert.Equal(t, []*TeamMergeChannel{o.Channels[1]}, o.Conflicts())
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// TeamStorageUsage is how many bytes the files uploaded to a team take up in the file store, along with the team's
+// StorageQuota. Copies of a file that share its stored data, such as those made by CopyFileInfos, are only counted
+// once.
+type TeamStorageUsage struct {
+	TeamId string `json:"team_id"`
+	Used   int64  `json:"used"`
+	Quota  int64  `json:"quota"`
+}
+
+func (o *TeamStorageUsage) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func TeamStorageUsageFromJson(data io.Reader) *TeamStorageUsage {
+	var o *TeamStorageUsage
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+// TeamStorageQuotaPatch sets the StorageQuota of a team.
+type TeamStorageQuotaPatch struct {
+	StorageQuota *int64 `json:"storage_quota"`
+}
+
+func (p *TeamStorageQuotaPatch) ToJson() string {
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+func TeamStorageQuotaPatchFromJson(data io.Reader) *TeamStorageQuotaPatch {
+	var p *TeamStorageQuotaPatch
+	json.NewDecoder(data).Decode(&p)
+	return p
+}
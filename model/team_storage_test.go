@@ -0,0 +1,38 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamStorageUsageJson(t *testing.T) {
+	usage := &TeamStorageUsage{
+		TeamId: NewId(),
+		Used:   1024,
+		Quota:  4096,
+	}
+
+	json := usage.ToJson()
+	rusage := TeamStorageUsageFromJson(strings.NewReader(json))
+
+	assert.Equal(t, usage, rusage)
+}
+
+func TestTeamStorageQuotaPatchJson(t *testing.T) {
+	patch := &TeamStorageQuotaPatch{StorageQuota: NewInt64(4096)}
+
+	rpatch := TeamStorageQuotaPatchFromJson(strings.NewReader(patch.ToJson()))
+	require.NotNil(t, rpatch)
+	require.NotNil(t, rpatch.StorageQuota)
+	assert.Equal(t, int64(4096), *rpatch.StorageQuota)
+
+	rpatch = TeamStorageQuotaPatchFromJson(strings.NewReader("{}"))
+	require.NotNil(t, rpatch)
+	assert.Nil(t, rpatch.StorageQuota)
+}
@@ -6,6 +6,8 @@ package model
 import (
 	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestTeamJson(t *testing.T) {
@@ -62,6 +64,77 @@ func TestTeamIsValid(t *testing.T) {
 	if err := o.IsValid(); err != nil {
 		t.Fatal(err)
 	}
+
+	o.MaxPostSize = -1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.MaxPostSize = 280
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.ChannelNamePattern = "proj-("
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.ChannelNamePattern = "proj-.+"
+	o.ChannelDisplayNamePattern = strings.Repeat("a", TEAM_CHANNEL_PATTERN_MAX_LENGTH+1)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.ChannelDisplayNamePattern = "Project .+"
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.InactiveChannelDays = -1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.InactiveChannelDays = 60
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.StorageQuota = -1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.StorageQuota = 1024 * 1024 * 1024
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.MaxMembers = -1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.MaxMembers = 100
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTeamChannelNamePatterns(t *testing.T) {
+	o := Team{}
+	assert.True(t, o.IsChannelNameAllowed("anything"))
+	assert.True(t, o.IsChannelDisplayNameAllowed("Anything"))
+
+	o.ChannelNamePattern = "proj-[a-z0-9-]+"
+	o.ChannelDisplayNamePattern = "Project .+"
+
+	assert.True(t, o.IsChannelNameAllowed("proj-apollo"))
+	assert.False(t, o.IsChannelNameAllowed("apollo"))
+	assert.False(t, o.IsChannelNameAllowed("old-proj-apollo"), "the whole name must match")
+	assert.True(t, o.IsChannelDisplayNameAllowed("Project Apollo"))
+	assert.False(t, o.IsChannelDisplayNameAllowed("Apollo"))
 }
 
 func TestTeamPreSave(t *testing.T) {
@@ -130,3 +203,14 @@ func TestCleanTeamName(t *testing.T) {
 		t.Fatal("didn't clean name properly")
 	}
 }
+
+func TestTeamLastModifiedAt(t *testing.T) {
+	o := Team{UpdateAt: 10}
+	assert.Equal(t, int64(10), o.LastModifiedAt())
+
+	o.LastTeamIconUpdate = 20
+	assert.Equal(t, int64(20), o.LastModifiedAt())
+
+	o.DeleteAt = 30
+	assert.Equal(t, int64(30), o.LastModifiedAt())
+}
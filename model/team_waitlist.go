@@ -0,0 +1,82 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// TeamWaitlistEntry is a request to join a team that had reached its MaxMembers, which waits for a team admin to
+// approve it.
+type TeamWaitlistEntry struct {
+	TeamId   string `json:"team_id"`
+	UserId   string `json:"user_id"`
+	CreateAt int64  `json:"create_at"`
+}
+
+func (o *TeamWaitlistEntry) PreSave() {
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
+
+func (o *TeamWaitlistEntry) IsValid() *AppError {
+	if !IsValidId(o.TeamId) {
+		return NewAppError("TeamWaitlistEntry.IsValid", "model.team_waitlist_entry.is_valid.team_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if !IsValidId(o.UserId) {
+		return NewAppError("TeamWaitlistEntry.IsValid", "model.team_waitlist_entry.is_valid.user_id.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	if o.CreateAt == 0 {
+		return NewAppError("TeamWaitlistEntry.IsValid", "model.team_waitlist_entry.is_valid.create_at.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+func (o *TeamWaitlistEntry) ToJson() string {
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func TeamWaitlistEntryFromJson(data io.Reader) *TeamWaitlistEntry {
+	var o *TeamWaitlistEntry
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+func TeamWaitlistEntriesToJson(o []*TeamWaitlistEntry) string {
+	if b, err := json.Marshal(o); err != nil {
+		return "[]"
+	} else {
+		return string(b)
+	}
+}
+
+func TeamWaitlistEntriesFromJson(data io.Reader) []*TeamWaitlistEntry {
+	var o []*TeamWaitlistEntry
+	json.NewDecoder(data).Decode(&o)
+	return o
+}
+
+// TeamMembershipLimitPatch sets the MaxMembers and AllowWaitlist of a team.
+type TeamMembershipLimitPatch struct {
+	MaxMembers    *int  `json:"max_members"`
+	AllowWaitlist *bool `json:"allow_waitlist"`
+}
+
+func (p *TeamMembershipLimitPatch) ToJson() string {
+	b, _ := json.Marshal(p)
+	return string(b)
+}
+
+func TeamMembershipLimitPatchFromJson(data io.Reader) *TeamMembershipLimitPatch {
+	var p *TeamMembershipLimitPatch
+	json.NewDecoder(data).Decode(&p)
+	return p
+}
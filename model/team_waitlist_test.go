@@ -0,0 +1,52 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamWaitlistEntryJson(t *testing.T) {
+	entry := &TeamWaitlistEntry{TeamId: NewId(), UserId: NewId(), CreateAt: GetMillis()}
+
+	rentry := TeamWaitlistEntryFromJson(strings.NewReader(entry.ToJson()))
+	assert.Equal(t, entry, rentry)
+
+	rentries := TeamWaitlistEntriesFromJson(strings.NewReader(TeamWaitlistEntriesToJson([]*TeamWaitlistEntry{entry})))
+	assert.Equal(t, []*TeamWaitlistEntry{entry}, rentries)
+}
+
+func TestTeamWaitlistEntryIsValid(t *testing.T) {
+	entry := &TeamWaitlistEntry{}
+	assert.NotNil(t, entry.IsValid())
+
+	entry.TeamId = NewId()
+	assert.NotNil(t, entry.IsValid())
+
+	entry.UserId = NewId()
+	assert.NotNil(t, entry.IsValid())
+
+	entry.PreSave()
+	assert.Nil(t, entry.IsValid())
+}
+
+func TestTeamMembershipLimitPatchJson(t *testing.T) {
+	patch := &TeamMembershipLimitPatch{MaxMembers: NewInt(100), AllowWaitlist: NewBool(true)}
+
+	rpatch := TeamMembershipLimitPatchFromJson(strings.NewReader(patch.ToJson()))
+	require.NotNil(t, rpatch)
+	require.NotNil(t, rpatch.MaxMembers)
+	require.NotNil(t, rpatch.AllowWaitlist)
+	assert.Equal(t, 100, *rpatch.MaxMembers)
+	assert.True(t, *rpatch.AllowWaitlist)
+
+	rpatch = TeamMembershipLimitPatchFromJson(strings.NewReader("{}"))
+	require.NotNil(t, rpatch)
+	assert.Nil(t, rpatch.MaxMembers)
+	assert.Nil(t, rpatch.AllowWaitlist)
+}
@@ -11,11 +11,14 @@ const (
 	TOKEN_TYPE_OAUTH      = "oauth"
 )
 
+// Token is a single-use token. It's deleted once it's MAX_TOKEN_EXIPRY_TIME old, unless ExpireAt is set, in which case
+// it's kept until then instead.
 type Token struct {
 	Token    string
 	CreateAt int64
 	Type     string
 	Extra    string
+	ExpireAt int64
 }
 
 func NewToken(tokentype, extra string) *Token {
@@ -547,6 +547,34 @@ func UserListToJson(u []*User) string {
 	return string(b)
 }
 
+// UserCsvColumns are the fields of a user that are included when a list of users is written as CSV. Sanitized fields
+// are left blank.
+var UserCsvColumns = []CsvColumn{
+	{"id", func(row interface{}) string { return row.(*User).Id }},
+	{"create_at", func(row interface{}) string { return csvInt64(row.(*User).CreateAt) }},
+	{"update_at", func(row interface{}) string { return csvInt64(row.(*User).UpdateAt) }},
+	{"delete_at", func(row interface{}) string { return csvInt64(row.(*User).DeleteAt) }},
+	{"username", func(row interface{}) string { return row.(*User).Username }},
+	{"auth_service", func(row interface{}) string { return row.(*User).AuthService }},
+	{"email", func(row interface{}) string { return row.(*User).Email }},
+	{"email_verified", func(row interface{}) string { return csvBool(row.(*User).EmailVerified) }},
+	{"nickname", func(row interface{}) string { return row.(*User).Nickname }},
+	{"first_name", func(row interface{}) string { return row.(*User).FirstName }},
+	{"last_name", func(row interface{}) string { return row.(*User).LastName }},
+	{"position", func(row interface{}) string { return row.(*User).Position }},
+	{"roles", func(row interface{}) string { return row.(*User).Roles }},
+	{"locale", func(row interface{}) string { return row.(*User).Locale }},
+	{"last_activity_at", func(row interface{}) string { return csvInt64(row.(*User).LastActivityAt) }},
+}
+
+func UserListToCsv(u []*User) string {
+	rows := make([]interface{}, len(u))
+	for i, user := range u {
+		rows[i] = user
+	}
+	return ToCsv(UserCsvColumns, rows)
+}
+
 func UserListFromJson(data io.Reader) []*User {
 	var users []*User
 	json.NewDecoder(data).Decode(&users)
@@ -63,3 +63,25 @@ func UserAccessTokenListFromJson(data io.Reader) []*UserAccessToken {
 	json.NewDecoder(data).Decode(&t)
 	return t
 }
+
+// UserAccessTokenValidation describes the user access token that a request was made with, so that integrations can
+// check what their token is for. ExpiresAt is 0 since user access tokens stay valid until they're revoked or disabled.
+type UserAccessTokenValidation struct {
+	TokenId     string `json:"token_id"`
+	IsActive    bool   `json:"is_active"`
+	Description string `json:"description"`
+	UserId      string `json:"user_id"`
+	Username    string `json:"username"`
+	ExpiresAt   int64  `json:"expires_at"`
+}
+
+func (v *UserAccessTokenValidation) ToJson() string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func UserAccessTokenValidationFromJson(data io.Reader) *UserAccessTokenValidation {
+	var v *UserAccessTokenValidation
+	json.NewDecoder(data).Decode(&v)
+	return v
+}
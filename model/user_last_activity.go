@@ -0,0 +1,48 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strconv"
+)
+
+const (
+	USER_LAST_ACTIVITY_STATUS_ACTIVE   = "active"
+	USER_LAST_ACTIVITY_STATUS_INACTIVE = "inactive"
+)
+
+var UserLastActivityCsvHeader = []string{"user_id", "username", "email", "auth_service", "last_activity_at", "status"}
+
+// UserLastActivity records when a user was last seen, taken as the most recent of their status and session activity.
+type UserLastActivity struct {
+	UserId             string
+	Username           string
+	Email              string
+	AuthService        string
+	Roles              string
+	Locale             string
+	CreateAt           int64
+	DeleteAt           int64
+	LastPasswordUpdate int64
+	LastActivityAt     int64
+}
+
+func (o *UserLastActivity) Status() string {
+	if o.DeleteAt != 0 {
+		return USER_LAST_ACTIVITY_STATUS_INACTIVE
+	}
+	return USER_LAST_ACTIVITY_STATUS_ACTIVE
+}
+
+// ToCsvRecord returns the fields of o in the order given by UserLastActivityCsvHeader.
+func (o *UserLastActivity) ToCsvRecord() []string {
+	return []string{
+		o.UserId,
+		o.Username,
+		o.Email,
+		o.AuthService,
+		strconv.FormatInt(o.LastActivityAt, 10),
+		o.Status(),
+	}
+}
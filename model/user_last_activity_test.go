@@ -0,0 +1,27 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserLastActivityToCsvRecord(t *testing.T) {
+	activity := &UserLastActivity{
+		UserId:         NewId(),
+		Username:       "someone",
+		Email:          "someone@example.com",
+		AuthService:    USER_AUTH_SERVICE_SAML,
+		LastActivityAt: 1234,
+	}
+
+	record := activity.ToCsvRecord()
+	assert.Len(t, record, len(UserLastActivityCsvHeader))
+	assert.Equal(t, []string{activity.UserId, "someone", "someone@example.com", USER_AUTH_SERVICE_SAML, "1234", USER_LAST_ACTIVITY_STATUS_ACTIVE}, record)
+
+	activity.DeleteAt = GetMillis()
+	assert.Equal(t, USER_LAST_ACTIVITY_STATUS_INACTIVE, activity.ToCsvRecord()[5])
+}
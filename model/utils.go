@@ -44,12 +44,12 @@ func AppErrorInit(t goi18n.TranslateFunc) {
 
 type AppError struct {
 	Id            string `json:"id"`
-	Message       string `json:"message"`               // Message to be display to the end user without debugging information
-	DetailedError string `json:"detailed_error"`        // Internal error string to help the developer
-	RequestId     string `json:"request_id,omitempty"`  // The RequestId that's also set in the header
-	StatusCode    int    `json:"status_code,omitempty"` // The http status code
-	Where         string `json:"-"`                     // The function where it happened in the form of Struct.Func
-	IsOAuth       bool   `json:"is_oauth,omitempty"`    // Whether the error is OAuth specific
+	Message       string `json:"message"`              // Message to be display to the end user without debugging information
+	DetailedError string `json:"detailed_error"`       // Internal error string to help the developer
+	RequestId     string `json:"request_id,omitempty"` // The RequestId that's also set in the header
+	StatusCode    int    `json:"status_code"`          // The http status code
+	Where         string `json:"-"`                    // The function where it happened in the form of Struct.Func
+	IsOAuth       bool   `json:"is_oauth,omitempty"`   // Whether the error is OAuth specific
 	params        map[string]interface{}
 }
 
@@ -83,6 +83,38 @@ func (er *AppError) ToJson() string {
 	return string(b)
 }
 
+// appErrorJson has the fields of AppError without its methods, so that they can be marshalled as usual along with
+// the params of the error.
+type appErrorJson AppError
+
+// MarshalJSON includes the params that the message of an error was translated with, so that clients can show their
+// own text for the error.
+func (er *AppError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&struct {
+		*appErrorJson
+		Params map[string]interface{} `json:"params,omitempty"`
+	}{
+		appErrorJson: (*appErrorJson)(er),
+		Params:       er.params,
+	})
+}
+
+func (er *AppError) UnmarshalJSON(data []byte) error {
+	decoded := &struct {
+		*appErrorJson
+		Params map[string]interface{} `json:"params"`
+	}{
+		appErrorJson: (*appErrorJson)(er),
+	}
+
+	if err := json.Unmarshal(data, decoded); err != nil {
+		return err
+	}
+
+	er.params = decoded.Params
+	return nil
+}
+
 // AppErrorFromJson will decode the input and return an AppError
 func AppErrorFromJson(data io.Reader) *AppError {
 	str := ""
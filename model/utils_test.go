@@ -4,6 +4,7 @@
 package model
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -76,6 +77,29 @@ func TestAppError(t *testing.T) {
 	t.Log(err.Error())
 }
 
+func TestAppErrorJson(t *testing.T) {
+	err := NewAppError("TestAppErrorJson", "message", map[string]interface{}{"Max": 10}, "details", http.StatusBadRequest)
+
+	var decoded map[string]interface{}
+	require.Nil(t, json.Unmarshal([]byte(err.ToJson()), &decoded))
+	assert.Equal(t, "message", decoded["id"])
+	assert.Equal(t, float64(http.StatusBadRequest), decoded["status_code"])
+	assert.Equal(t, map[string]interface{}{"Max": float64(10)}, decoded["params"])
+	assert.NotContains(t, decoded, "Where")
+
+	rerr := AppErrorFromJson(strings.NewReader(err.ToJson()))
+	assert.Equal(t, err.Id, rerr.Id)
+	assert.Equal(t, err.StatusCode, rerr.StatusCode)
+	assert.Equal(t, err.DetailedError, rerr.DetailedError)
+	assert.Equal(t, map[string]interface{}{"Max": float64(10)}, rerr.params)
+
+	// Errors without params still report their status
+	var decodedWithoutParams map[string]interface{}
+	require.Nil(t, json.Unmarshal([]byte((&AppError{Id: "message"}).ToJson()), &decodedWithoutParams))
+	assert.NotContains(t, decodedWithoutParams, "params")
+	assert.Contains(t, decodedWithoutParams, "status_code")
+}
+
 func TestAppErrorJunk(t *testing.T) {
 	rerr := AppErrorFromJson(strings.NewReader("<html><body>This is a broken test</body></html>"))
 	require.Equal(t, "body: <html><body>This is a broken test</body></html>", rerr.DetailedError)
@@ -19,6 +19,7 @@ const (
 	WEBSOCKET_EVENT_CHANNEL_DELETED         = "channel_deleted"
 	WEBSOCKET_EVENT_CHANNEL_UPDATED         = "channel_updated"
 	WEBSOCKET_EVENT_CHANNEL_MEMBER_UPDATED  = "channel_member_updated"
+	WEBSOCKET_EVENT_CHANNEL_MEMBERS_UPDATED = "channel_members_updated"
 	WEBSOCKET_EVENT_DIRECT_ADDED            = "direct_added"
 	WEBSOCKET_EVENT_GROUP_ADDED             = "group_added"
 	WEBSOCKET_EVENT_NEW_USER                = "new_user"
@@ -28,6 +29,7 @@ const (
 	WEBSOCKET_EVENT_DELETE_TEAM             = "delete_team"
 	WEBSOCKET_EVENT_RESTORE_TEAM            = "restore_team"
 	WEBSOCKET_EVENT_USER_ADDED              = "user_added"
+	WEBSOCKET_EVENT_USERS_ADDED             = "users_added"
 	WEBSOCKET_EVENT_USER_UPDATED            = "user_updated"
 	WEBSOCKET_EVENT_USER_ROLE_UPDATED       = "user_role_updated"
 	WEBSOCKET_EVENT_MEMBERROLE_UPDATED      = "memberrole_updated"
@@ -41,6 +43,7 @@ const (
 	WEBSOCKET_AUTHENTICATION_CHALLENGE      = "authentication_challenge"
 	WEBSOCKET_EVENT_REACTION_ADDED          = "reaction_added"
 	WEBSOCKET_EVENT_REACTION_REMOVED        = "reaction_removed"
+	WEBSOCKET_EVENT_REACTIONS_ADDED         = "reactions_added"
 	WEBSOCKET_EVENT_RESPONSE                = "response"
 	WEBSOCKET_EVENT_EMOJI_ADDED             = "emoji_added"
 	WEBSOCKET_EVENT_CHANNEL_VIEWED          = "channel_viewed"
@@ -51,6 +54,7 @@ const (
 	WEBSOCKET_EVENT_LICENSE_CHANGED         = "license_changed"
 	WEBSOCKET_EVENT_CONFIG_CHANGED          = "config_changed"
 	WEBSOCKET_EVENT_OPEN_DIALOG             = "open_dialog"
+	WEBSOCKET_EVENT_PINNED_POSTS_UPDATED    = "pinned_posts_updated"
 )
 
 type WebSocketMessage interface {
@@ -69,12 +73,16 @@ type WebsocketBroadcast struct {
 }
 
 type precomputedWebSocketEventJSON struct {
+	Id        json.RawMessage
 	Event     json.RawMessage
 	Data      json.RawMessage
 	Broadcast json.RawMessage
 }
 
 type WebSocketEvent struct {
+	// Id identifies the event across the cluster so that it's never delivered twice to the same connection, even if
+	// it reaches a node more than once. It's set when the event is published.
+	Id        string                 `json:"id,omitempty"`
 	Event     string                 `json:"event"`
 	Data      map[string]interface{} `json:"data"`
 	Broadcast *WebsocketBroadcast    `json:"broadcast"`
@@ -86,10 +94,15 @@ type WebSocketEvent struct {
 // PrecomputeJSON precomputes and stores the serialized JSON for all fields other than Sequence.
 // This makes ToJson much more efficient when sending the same event to multiple connections.
 func (m *WebSocketEvent) PrecomputeJSON() {
+	var id []byte
+	if m.Id != "" {
+		id, _ = json.Marshal(m.Id)
+	}
 	event, _ := json.Marshal(m.Event)
 	data, _ := json.Marshal(m.Data)
 	broadcast, _ := json.Marshal(m.Broadcast)
 	m.precomputedJSON = &precomputedWebSocketEventJSON{
+		Id:        json.RawMessage(id),
 		Event:     json.RawMessage(event),
 		Data:      json.RawMessage(data),
 		Broadcast: json.RawMessage(broadcast),
@@ -115,6 +128,9 @@ func (o *WebSocketEvent) EventType() string {
 
 func (o *WebSocketEvent) ToJson() string {
 	if o.precomputedJSON != nil {
+		if o.precomputedJSON.Id != nil {
+			return fmt.Sprintf(`{"id": %s, "event": %s, "data": %s, "broadcast": %s, "seq": %d}`, o.precomputedJSON.Id, o.precomputedJSON.Event, o.precomputedJSON.Data, o.precomputedJSON.Broadcast, o.Sequence)
+		}
 		return fmt.Sprintf(`{"event": %s, "data": %s, "broadcast": %s, "seq": %d}`, o.precomputedJSON.Event, o.precomputedJSON.Data, o.precomputedJSON.Broadcast, o.Sequence)
 	}
 	b, _ := json.Marshal(o)
@@ -66,6 +66,16 @@ func TestWebSocketEvent_PrecomputeJSON(t *testing.T) {
 	after := event.ToJson()
 
 	assert.JSONEq(t, before, after)
+
+	event = NewWebSocketEvent(WEBSOCKET_EVENT_POSTED, "foo", "bar", "baz", nil)
+	event.Id = NewId()
+
+	before = event.ToJson()
+	event.PrecomputeJSON()
+	after = event.ToJson()
+
+	assert.JSONEq(t, before, after)
+	assert.Equal(t, event.Id, WebSocketEventFromJson(strings.NewReader(after)).Id)
 }
 
 var stringSink string
@@ -480,6 +480,25 @@ type API interface {
 	//
 	// Minimum server version: 5.7
 	SendMail(to, subject, htmlBody string) *model.AppError
+
+	// EnqueueJob creates a job of a plugin-defined type that's run in the background by this plugin's RunJob hook,
+	// on whichever server of the cluster claims it first. Jobs are persisted, so pending jobs survive restarts.
+	// data is passed along to RunJob. A job whose RunJob returns an error is retried, after a delay that grows with
+	// every attempt, until it has been run maxAttempts times, or 3 times if maxAttempts is 0.
+	//
+	// Minimum server version: 5.9
+	EnqueueJob(jobType string, data map[string]string, maxAttempts int) (*model.Job, *model.AppError)
+
+	// GetJob gets a job that was enqueued by this plugin, to check its status and progress.
+	//
+	// Minimum server version: 5.9
+	GetJob(jobId string) (*model.Job, *model.AppError)
+
+	// CancelJob cancels a job that was enqueued by this plugin. A pending job won't be run at all, while a
+	// running job is marked as cancel_requested and is expected to stop early; RunJob can check for it with GetJob.
+	//
+	// Minimum server version: 5.9
+	CancelJob(jobId string) *model.AppError
 }
 
 var handshake = plugin.HandshakeConfig{
@@ -440,6 +440,79 @@ func (s *hooksRPCServer) UserHasLoggedIn(args *Z_UserHasLoggedInArgs, returns *Z
 	return nil
 }
 
+func init() {
+	hookNameToId["SearchResultsWillBeReturned"] = SearchResultsWillBeReturnedId
+}
+
+type Z_SearchResultsWillBeReturnedArgs struct {
+	A *Context
+	B *model.PostSearchQuery
+	C *model.PostSearchResults
+}
+
+type Z_SearchResultsWillBeReturnedReturns struct {
+	A *model.PostSearchResults
+}
+
+func (g *hooksRPCClient) SearchResultsWillBeReturned(c *Context, query *model.PostSearchQuery, results *model.PostSearchResults) *model.PostSearchResults {
+	_args := &Z_SearchResultsWillBeReturnedArgs{c, query, results}
+	_returns := &Z_SearchResultsWillBeReturnedReturns{}
+	if g.implemented[SearchResultsWillBeReturnedId] {
+		if err := g.client.Call("Plugin.SearchResultsWillBeReturned", _args, _returns); err != nil {
+			g.log.Error("RPC call SearchResultsWillBeReturned to plugin failed.", mlog.Err(err))
+		}
+	}
+	return _returns.A
+}
+
+func (s *hooksRPCServer) SearchResultsWillBeReturned(args *Z_SearchResultsWillBeReturnedArgs, returns *Z_SearchResultsWillBeReturnedReturns) error {
+	if hook, ok := s.impl.(interface {
+		SearchResultsWillBeReturned(c *Context, query *model.PostSearchQuery, results *model.PostSearchResults) *model.PostSearchResults
+	}); ok {
+		returns.A = hook.SearchResultsWillBeReturned(args.A, args.B, args.C)
+
+	} else {
+		return encodableError(fmt.Errorf("Hook SearchResultsWillBeReturned called but not implemented."))
+	}
+	return nil
+}
+
+func init() {
+	hookNameToId["RunJob"] = RunJobId
+}
+
+type Z_RunJobArgs struct {
+	A *Context
+	B *model.Job
+}
+
+type Z_RunJobReturns struct {
+	A error
+}
+
+func (g *hooksRPCClient) RunJob(c *Context, job *model.Job) error {
+	_args := &Z_RunJobArgs{c, job}
+	_returns := &Z_RunJobReturns{}
+	if g.implemented[RunJobId] {
+		if err := g.client.Call("Plugin.RunJob", _args, _returns); err != nil {
+			g.log.Error("RPC call RunJob to plugin failed.", mlog.Err(err))
+		}
+	}
+	return _returns.A
+}
+
+func (s *hooksRPCServer) RunJob(args *Z_RunJobArgs, returns *Z_RunJobReturns) error {
+	if hook, ok := s.impl.(interface {
+		RunJob(c *Context, job *model.Job) error
+	}); ok {
+		returns.A = hook.RunJob(args.A, args.B)
+		returns.A = encodableError(returns.A)
+	} else {
+		return encodableError(fmt.Errorf("Hook RunJob called but not implemented."))
+	}
+	return nil
+}
+
 type Z_RegisterCommandArgs struct {
 	A *model.Command
 }
@@ -3569,3 +3642,91 @@ func (s *apiRPCServer) SendMail(args *Z_SendMailArgs, returns *Z_SendMailReturns
 	}
 	return nil
 }
+
+type Z_EnqueueJobArgs struct {
+	A string
+	B map[string]string
+	C int
+}
+
+type Z_EnqueueJobReturns struct {
+	A *model.Job
+	B *model.AppError
+}
+
+func (g *apiRPCClient) EnqueueJob(jobType string, data map[string]string, maxAttempts int) (*model.Job, *model.AppError) {
+	_args := &Z_EnqueueJobArgs{jobType, data, maxAttempts}
+	_returns := &Z_EnqueueJobReturns{}
+	if err := g.client.Call("Plugin.EnqueueJob", _args, _returns); err != nil {
+		log.Printf("RPC call to EnqueueJob API failed: %s", err.Error())
+	}
+	return _returns.A, _returns.B
+}
+
+func (s *apiRPCServer) EnqueueJob(args *Z_EnqueueJobArgs, returns *Z_EnqueueJobReturns) error {
+	if hook, ok := s.impl.(interface {
+		EnqueueJob(jobType string, data map[string]string, maxAttempts int) (*model.Job, *model.AppError)
+	}); ok {
+		returns.A, returns.B = hook.EnqueueJob(args.A, args.B, args.C)
+	} else {
+		return encodableError(fmt.Errorf("API EnqueueJob called but not implemented."))
+	}
+	return nil
+}
+
+type Z_GetJobArgs struct {
+	A string
+}
+
+type Z_GetJobReturns struct {
+	A *model.Job
+	B *model.AppError
+}
+
+func (g *apiRPCClient) GetJob(jobId string) (*model.Job, *model.AppError) {
+	_args := &Z_GetJobArgs{jobId}
+	_returns := &Z_GetJobReturns{}
+	if err := g.client.Call("Plugin.GetJob", _args, _returns); err != nil {
+		log.Printf("RPC call to GetJob API failed: %s", err.Error())
+	}
+	return _returns.A, _returns.B
+}
+
+func (s *apiRPCServer) GetJob(args *Z_GetJobArgs, returns *Z_GetJobReturns) error {
+	if hook, ok := s.impl.(interface {
+		GetJob(jobId string) (*model.Job, *model.AppError)
+	}); ok {
+		returns.A, returns.B = hook.GetJob(args.A)
+	} else {
+		return encodableError(fmt.Errorf("API GetJob called but not implemented."))
+	}
+	return nil
+}
+
+type Z_CancelJobArgs struct {
+	A string
+}
+
+type Z_CancelJobReturns struct {
+	A *model.AppError
+}
+
+func (g *apiRPCClient) CancelJob(jobId string) *model.AppError {
+	_args := &Z_CancelJobArgs{jobId}
+	_returns := &Z_CancelJobReturns{}
+	if err := g.client.Call("Plugin.CancelJob", _args, _returns); err != nil {
+		log.Printf("RPC call to CancelJob API failed: %s", err.Error())
+	}
+	return _returns.A
+}
+
+func (s *apiRPCServer) CancelJob(args *Z_CancelJobArgs, returns *Z_CancelJobReturns) error {
+	if hook, ok := s.impl.(interface {
+		CancelJob(jobId string) *model.AppError
+	}); ok {
+		returns.A = hook.CancelJob(args.A)
+	} else {
+		return encodableError(fmt.Errorf("API CancelJob called but not implemented."))
+	}
+	return nil
+}
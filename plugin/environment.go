@@ -94,6 +94,16 @@ func (env *Environment) IsActive(id string) bool {
 	return ok
 }
 
+// Implements returns true if the plugin with the given id is active and implements the given hook.
+func (env *Environment) Implements(id string, hookId int) bool {
+	if p, ok := env.activePlugins.Load(id); ok {
+		activePlugin := p.(activePlugin)
+		return activePlugin.supervisor != nil && activePlugin.supervisor.Implements(hookId)
+	}
+
+	return false
+}
+
 // Statuses returns a list of plugin statuses representing the state of every plugin
 func (env *Environment) Statuses() (model.PluginStatuses, error) {
 	plugins, err := env.Available()
@@ -15,24 +15,26 @@ import (
 // Feel free to add more, but do not change existing assignments. Follow the naming convention of
 // <HookName>Id as the autogenerated glue code depends on that.
 const (
-	OnActivateId            = 0
-	OnDeactivateId          = 1
-	ServeHTTPId             = 2
-	OnConfigurationChangeId = 3
-	ExecuteCommandId        = 4
-	MessageWillBePostedId   = 5
-	MessageWillBeUpdatedId  = 6
-	MessageHasBeenPostedId  = 7
-	MessageHasBeenUpdatedId = 8
-	UserHasJoinedChannelId  = 9
-	UserHasLeftChannelId    = 10
-	UserHasJoinedTeamId     = 11
-	UserHasLeftTeamId       = 12
-	ChannelHasBeenCreatedId = 13
-	FileWillBeUploadedId    = 14
-	UserWillLogInId         = 15
-	UserHasLoggedInId       = 16
-	TotalHooksId            = iota
+	OnActivateId                  = 0
+	OnDeactivateId                = 1
+	ServeHTTPId                   = 2
+	OnConfigurationChangeId       = 3
+	ExecuteCommandId              = 4
+	MessageWillBePostedId         = 5
+	MessageWillBeUpdatedId        = 6
+	MessageHasBeenPostedId        = 7
+	MessageHasBeenUpdatedId       = 8
+	UserHasJoinedChannelId        = 9
+	UserHasLeftChannelId          = 10
+	UserHasJoinedTeamId           = 11
+	UserHasLeftTeamId             = 12
+	ChannelHasBeenCreatedId       = 13
+	FileWillBeUploadedId          = 14
+	UserWillLogInId               = 15
+	UserHasLoggedInId             = 16
+	SearchResultsWillBeReturnedId = 17
+	RunJobId                      = 18
+	TotalHooksId                  = iota
 )
 
 // Hooks describes the methods a plugin may implement to automatically receive the corresponding
@@ -143,4 +145,22 @@ type Hooks interface {
 	// Note that this method will be called for files uploaded by plugins, including the plugin that uploaded the post.
 	// FileInfo.Size will be automatically set properly if you modify the file.
 	FileWillBeUploaded(c *Context, info *model.FileInfo, file io.Reader, output io.Writer) (*model.FileInfo, string)
+
+	// SearchResultsWillBeReturned is invoked after posts have been searched for, before the results are returned
+	// to the user. Results.Scores holds the relevance of each result on a scale from 0 to 1, in the order given by
+	// the search backend.
+	//
+	// To re-rank the results, change their scores and reorder results.Order. Posts may also be annotated by
+	// changing their props, and results may be left out by removing them from results.Order. Return the modified
+	// results, or nil to leave them unchanged.
+	//
+	// Posts in channels that the searching user can't read are removed from the returned results.
+	SearchResultsWillBeReturned(c *Context, query *model.PostSearchQuery, results *model.PostSearchResults) *model.PostSearchResults
+
+	// RunJob is invoked on a job worker to run a job that was enqueued by this plugin with API.EnqueueJob. The
+	// plugin-defined type of the job and the data it was enqueued with are in job.Data.
+	//
+	// Return an error if the job failed, in which case it's retried until it has been attempted as many times as
+	// requested. Long-running jobs should check periodically whether they were canceled, using API.GetJob.
+	RunJob(c *Context, job *model.Job) error
 }
@@ -62,6 +62,22 @@ func (_m *API) AddReaction(reaction *model.Reaction) (*model.Reaction, *model.Ap
 	return r0, r1
 }
 
+// CancelJob provides a mock function with given fields: jobId
+func (_m *API) CancelJob(jobId string) *model.AppError {
+	ret := _m.Called(jobId)
+
+	var r0 *model.AppError
+	if rf, ok := ret.Get(0).(func(string) *model.AppError); ok {
+		r0 = rf(jobId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.AppError)
+		}
+	}
+
+	return r0
+}
+
 // CopyFileInfos provides a mock function with given fields: userId, fileIds
 func (_m *API) CopyFileInfos(userId string, fileIds []string) ([]string, *model.AppError) {
 	ret := _m.Called(userId, fileIds)
@@ -365,6 +381,31 @@ func (_m *API) EnablePlugin(id string) *model.AppError {
 	return r0
 }
 
+// EnqueueJob provides a mock function with given fields: jobType, data, maxAttempts
+func (_m *API) EnqueueJob(jobType string, data map[string]string, maxAttempts int) (*model.Job, *model.AppError) {
+	ret := _m.Called(jobType, data, maxAttempts)
+
+	var r0 *model.Job
+	if rf, ok := ret.Get(0).(func(string, map[string]string, int) *model.Job); ok {
+		r0 = rf(jobType, data, maxAttempts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Job)
+		}
+	}
+
+	var r1 *model.AppError
+	if rf, ok := ret.Get(1).(func(string, map[string]string, int) *model.AppError); ok {
+		r1 = rf(jobType, data, maxAttempts)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*model.AppError)
+		}
+	}
+
+	return r0, r1
+}
+
 // GetChannel provides a mock function with given fields: channelId
 func (_m *API) GetChannel(channelId string) (*model.Channel, *model.AppError) {
 	ret := _m.Called(channelId)
@@ -811,6 +852,31 @@ func (_m *API) GetGroupChannel(userIds []string) (*model.Channel, *model.AppErro
 	return r0, r1
 }
 
+// GetJob provides a mock function with given fields: jobId
+func (_m *API) GetJob(jobId string) (*model.Job, *model.AppError) {
+	ret := _m.Called(jobId)
+
+	var r0 *model.Job
+	if rf, ok := ret.Get(0).(func(string) *model.Job); ok {
+		r0 = rf(jobId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Job)
+		}
+	}
+
+	var r1 *model.AppError
+	if rf, ok := ret.Get(1).(func(string) *model.AppError); ok {
+		r1 = rf(jobId)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*model.AppError)
+		}
+	}
+
+	return r0, r1
+}
+
 // GetLDAPUserAttributes provides a mock function with given fields: userId, attributes
 func (_m *API) GetLDAPUserAttributes(userId string, attributes []string) (map[string]string, *model.AppError) {
 	ret := _m.Called(userId, attributes)
@@ -189,6 +189,36 @@ func (_m *Hooks) OnDeactivate() error {
 	return r0
 }
 
+// RunJob provides a mock function with given fields: c, job
+func (_m *Hooks) RunJob(c *plugin.Context, job *model.Job) error {
+	ret := _m.Called(c, job)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*plugin.Context, *model.Job) error); ok {
+		r0 = rf(c, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SearchResultsWillBeReturned provides a mock function with given fields: c, query, results
+func (_m *Hooks) SearchResultsWillBeReturned(c *plugin.Context, query *model.PostSearchQuery, results *model.PostSearchResults) *model.PostSearchResults {
+	ret := _m.Called(c, query, results)
+
+	var r0 *model.PostSearchResults
+	if rf, ok := ret.Get(0).(func(*plugin.Context, *model.PostSearchQuery, *model.PostSearchResults) *model.PostSearchResults); ok {
+		r0 = rf(c, query, results)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.PostSearchResults)
+		}
+	}
+
+	return r0
+}
+
 // ServeHTTP provides a mock function with given fields: c, w, r
 func (_m *Hooks) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Request) {
 	_m.Called(c, w, r)
@@ -0,0 +1,51 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+// Package clustertransport provides the transports that cluster implementations can use to send cluster messages
+// between nodes, as selected by ClusterSettings.Transport.
+package clustertransport
+
+import (
+	"fmt"
+
+	"github.com/mattermost/mattermost-server/einterfaces"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// MessageHandler is called with every cluster message received from another node.
+type MessageHandler func(msg *model.ClusterMessage)
+
+// Transport delivers cluster messages between nodes. The semantics of the messages are the same regardless of the
+// transport, so a cluster implementation only needs to pick one according to the config.
+type Transport interface {
+	// Start listens for messages from other nodes on the given address, calling handler with each of them.
+	Start(listenAddress string, handler MessageHandler) error
+
+	// Stop stops listening for messages and closes the connections to other nodes.
+	Stop()
+
+	// Send delivers the message to the node listening on the given address. Messages sent with
+	// model.CLUSTER_SEND_RELIABLE wait for the node to become reachable instead of failing immediately.
+	Send(address string, msg *model.ClusterMessage) error
+}
+
+// NodeAddress returns the address that a node registered through cluster discovery listens on for messages sent
+// through a transport listening on the given port.
+func NodeAddress(node *model.ClusterDiscovery, port int) string {
+	return fmt.Sprintf("%v:%v", node.Hostname, port)
+}
+
+// New returns the transport selected by the given settings, or nil if the cluster implementation should use its
+// built-in TCP transport.
+func New(settings *model.ClusterSettings, metrics einterfaces.MetricsInterface) (Transport, error) {
+	switch *settings.Transport {
+	case model.CLUSTER_TRANSPORT_GRPC:
+		transport, err := NewGrpcTransport(*settings.GrpcTLSCertFile, *settings.GrpcTLSKeyFile, *settings.GrpcTLSCAFile, metrics)
+		if err != nil {
+			return nil, err
+		}
+		return transport, nil
+	default:
+		return nil, nil
+	}
+}
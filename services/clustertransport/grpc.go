@@ -0,0 +1,239 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package clustertransport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
+
+	"github.com/mattermost/mattermost-server/einterfaces"
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const (
+	GRPC_SEND_TIMEOUT = 10 * time.Second
+
+	grpcServiceName = "mattermost.cluster.Cluster"
+	grpcSendMethod  = "/" + grpcServiceName + "/Send"
+)
+
+// jsonCodec lets cluster messages be sent over gRPC as JSON, the same encoding used by the TCP transport, rather
+// than requiring protocol buffer definitions for them.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+type grpcSendResponse struct{}
+
+type grpcClusterServer interface {
+	send(ctx context.Context, msg *model.ClusterMessage) (*grpcSendResponse, error)
+}
+
+var grpcServiceDesc = grpc.ServiceDesc{
+	ServiceName: grpcServiceName,
+	HandlerType: (*grpcClusterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Send",
+			Handler:    grpcSendHandler,
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+func grpcSendHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	msg := &model.ClusterMessage{}
+	if err := dec(msg); err != nil {
+		return nil, err
+	}
+
+	if interceptor == nil {
+		return srv.(grpcClusterServer).send(ctx, msg)
+	}
+
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: grpcSendMethod,
+	}
+	return interceptor(ctx, msg, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(grpcClusterServer).send(ctx, req.(*model.ClusterMessage))
+	})
+}
+
+// GrpcTransport sends cluster messages over gRPC. Nodes authenticate each other with mutual TLS, so every node must
+// present a certificate signed by the configured certificate authority.
+type GrpcTransport struct {
+	tlsConfig *tls.Config
+	metrics   einterfaces.MetricsInterface
+	handler   MessageHandler
+
+	server   *grpc.Server
+	listener net.Listener
+
+	mutex   sync.Mutex
+	clients map[string]*grpc.ClientConn
+}
+
+func NewGrpcTransport(certFile, keyFile, caFile string, metrics einterfaces.MetricsInterface) (*GrpcTransport, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load cluster certificate")
+	}
+
+	ca, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read cluster certificate authority")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("failed to parse cluster certificate authority")
+	}
+
+	return &GrpcTransport{
+		tlsConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientCAs:    pool,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			RootCAs:      pool,
+			MinVersion:   tls.VersionTLS12,
+		},
+		metrics: metrics,
+		clients: make(map[string]*grpc.ClientConn),
+	}, nil
+}
+
+func (t *GrpcTransport) Start(listenAddress string, handler MessageHandler) error {
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		return errors.Wrap(err, "failed to listen for cluster messages")
+	}
+
+	t.handler = handler
+	t.listener = listener
+	t.server = grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(t.tlsConfig)),
+		grpc.UnaryInterceptor(t.observe),
+	)
+	t.server.RegisterService(&grpcServiceDesc, t)
+
+	server := t.server
+	go func() {
+		// Serve only returns once the transport is stopped or the listener fails, after which this node no longer
+		// receives any cluster messages sent through the transport.
+		if err := server.Serve(listener); err != nil && err != grpc.ErrServerStopped {
+			mlog.Critical("The cluster transport stopped receiving cluster messages", mlog.String("address", listener.Addr().String()), mlog.Err(err))
+		}
+	}()
+
+	return nil
+}
+
+// Addr returns the address that the transport is listening on.
+func (t *GrpcTransport) Addr() net.Addr {
+	return t.listener.Addr()
+}
+
+func (t *GrpcTransport) Stop() {
+	if t.server != nil {
+		t.server.GracefulStop()
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for address, client := range t.clients {
+		client.Close()
+		delete(t.clients, address)
+	}
+}
+
+func (t *GrpcTransport) Send(address string, msg *model.ClusterMessage) error {
+	client, err := t.getClient(address)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), GRPC_SEND_TIMEOUT)
+	defer cancel()
+
+	opts := []grpc.CallOption{grpc.CallContentSubtype(jsonCodec{}.Name())}
+	if msg.SendType == model.CLUSTER_SEND_RELIABLE {
+		opts = append(opts, grpc.WaitForReady(true))
+	}
+
+	if err := client.Invoke(ctx, grpcSendMethod, msg, &grpcSendResponse{}, opts...); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to send cluster message to %v", address))
+	}
+
+	return nil
+}
+
+func (t *GrpcTransport) getClient(address string) (*grpc.ClientConn, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if client, ok := t.clients[address]; ok {
+		return client, nil
+	}
+
+	// Connections are established lazily, so this doesn't block on the other node being reachable.
+	client, err := grpc.Dial(address, grpc.WithTransportCredentials(credentials.NewTLS(t.tlsConfig)))
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to connect to %v", address))
+	}
+	t.clients[address] = client
+
+	return client, nil
+}
+
+func (t *GrpcTransport) send(ctx context.Context, msg *model.ClusterMessage) (*grpcSendResponse, error) {
+	t.handler(msg)
+	return &grpcSendResponse{}, nil
+}
+
+// observe records the same metrics for every message received over gRPC as the TCP transport does.
+func (t *GrpcTransport) observe(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if t.metrics == nil {
+		return handler(ctx, req)
+	}
+
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	t.metrics.IncrementClusterRequest()
+	t.metrics.ObserveClusterRequestDuration(time.Since(start).Seconds())
+	if msg, ok := req.(*model.ClusterMessage); ok {
+		t.metrics.IncrementClusterEventType(msg.Event)
+	}
+
+	return resp, err
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package clustertransport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+type testCertificateAuthority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	dir  string
+}
+
+func newTestCertificateAuthority(t *testing.T, dir string) *testCertificateAuthority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Cluster CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	writePEM(t, filepath.Join(dir, "ca.pem"), "CERTIFICATE", der)
+
+	return &testCertificateAuthority{cert: cert, key: key, dir: dir}
+}
+
+// issue creates a certificate for a node, returning the paths of the certificate and key files.
+func (ca *testCertificateAuthority) issue(t *testing.T, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile := filepath.Join(ca.dir, name+".pem")
+	keyFile := filepath.Join(ca.dir, name+"-key.pem")
+	writePEM(t, certFile, "CERTIFICATE", der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDer)
+
+	return certFile, keyFile
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+}
+
+func TestGrpcTransport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clustertransport")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newTestCertificateAuthority(t, dir)
+	caFile := filepath.Join(dir, "ca.pem")
+
+	certFile, keyFile := ca.issue(t, "node1")
+	node1, err := NewGrpcTransport(certFile, keyFile, caFile, nil)
+	require.NoError(t, err)
+
+	received := make(chan *model.ClusterMessage, 1)
+	require.NoError(t, node1.Start("127.0.0.1:0", func(msg *model.ClusterMessage) {
+		received <- msg
+	}))
+	defer node1.Stop()
+
+	t.Run("send", func(t *testing.T) {
+		certFile, keyFile := ca.issue(t, "node2")
+		node2, err := NewGrpcTransport(certFile, keyFile, caFile, nil)
+		require.NoError(t, err)
+		defer node2.Stop()
+
+		msg := &model.ClusterMessage{
+			Event:    model.CLUSTER_EVENT_PUBLISH,
+			SendType: model.CLUSTER_SEND_RELIABLE,
+			Data:     "data",
+			Props:    map[string]string{"key": "value"},
+		}
+		require.NoError(t, node2.Send(node1.Addr().String(), msg))
+
+		select {
+		case result := <-received:
+			assert.Equal(t, msg.Event, result.Event)
+			assert.Equal(t, msg.Data, result.Data)
+			assert.Equal(t, msg.Props, result.Props)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "message wasn't received")
+		}
+	})
+
+	t.Run("nodes without a certificate from the certificate authority are rejected", func(t *testing.T) {
+		otherDir, err := ioutil.TempDir("", "clustertransport")
+		require.NoError(t, err)
+		defer os.RemoveAll(otherDir)
+
+		otherCa := newTestCertificateAuthority(t, otherDir)
+		certFile, keyFile := otherCa.issue(t, "node3")
+
+		// The node trusts node1's certificate authority, but its own certificate isn't signed by it.
+		node3, err := NewGrpcTransport(certFile, keyFile, caFile, nil)
+		require.NoError(t, err)
+		defer node3.Stop()
+
+		assert.Error(t, node3.Send(node1.Addr().String(), &model.ClusterMessage{Event: model.CLUSTER_EVENT_PUBLISH}))
+		assert.Empty(t, received)
+	})
+}
+
+func TestNew(t *testing.T) {
+	settings := &model.ClusterSettings{}
+	settings.SetDefaults()
+
+	transport, err := New(settings, nil)
+	require.NoError(t, err)
+	assert.Nil(t, transport)
+
+	settings.Transport = model.NewString(model.CLUSTER_TRANSPORT_GRPC)
+	settings.GrpcTLSCertFile = model.NewString("missing.pem")
+	settings.GrpcTLSKeyFile = model.NewString("missing.pem")
+	settings.GrpcTLSCAFile = model.NewString("missing.pem")
+
+	_, err = New(settings, nil)
+	assert.Error(t, err)
+}
+
+func TestNodeAddress(t *testing.T) {
+	assert.Equal(t, "node1:8076", NodeAddress(&model.ClusterDiscovery{Hostname: "node1", Port: 8075}, 8076))
+}
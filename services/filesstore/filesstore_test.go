@@ -142,6 +142,22 @@ func (s *FileBackendTestSuite) TestFileExists() {
 	s.False(res)
 }
 
+func (s *FileBackendTestSuite) TestFileSize() {
+	b := []byte("testimage")
+	path := "tests/" + model.NewId() + ".png"
+
+	_, err := s.backend.WriteFile(bytes.NewReader(b), path)
+	s.Nil(err)
+	defer s.backend.RemoveFile(path)
+
+	size, err := s.backend.FileSize(path)
+	s.Nil(err)
+	s.Equal(int64(len(b)), size)
+
+	_, err = s.backend.FileSize("tests/idontexist.png")
+	s.NotNil(err)
+}
+
 func (s *FileBackendTestSuite) TestCopyFile() {
 	b := []byte("test")
 	path1 := "tests/" + model.NewId()
@@ -52,6 +52,29 @@ func (_m *FileBackend) FileExists(path string) (bool, *model.AppError) {
 	return r0, r1
 }
 
+// FileSize provides a mock function with given fields: path
+func (_m *FileBackend) FileSize(path string) (int64, *model.AppError) {
+	ret := _m.Called(path)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(string) int64); ok {
+		r0 = rf(path)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 *model.AppError
+	if rf, ok := ret.Get(1).(func(string) *model.AppError); ok {
+		r1 = rf(path)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*model.AppError)
+		}
+	}
+
+	return r0, r1
+}
+
 // ListDirectory provides a mock function with given fields: path
 func (_m *FileBackend) ListDirectory(path string) (*[]string, *model.AppError) {
 	ret := _m.Called(path)
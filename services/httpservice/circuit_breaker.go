@@ -0,0 +1,174 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package httpservice
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+const CIRCUIT_BREAKER_CACHE_SIZE = 10000
+
+var CircuitOpen error = errors.New("requests to this integration are being skipped because it has failed repeatedly, it will be retried after a cool-down period")
+
+// circuitBreaker tracks the consecutive failures of requests to an integration endpoint.
+type circuitBreaker struct {
+	mutex  sync.Mutex
+	status model.CircuitBreakerStatus
+}
+
+// allow returns whether a request may be made to the endpoint. Once the cool-down period of an open breaker has
+// passed, a single request is allowed through to probe the endpoint, and the rest fail until it completes.
+func (b *circuitBreaker) allow(now int64) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.status.State {
+	case model.CIRCUIT_BREAKER_STATE_OPEN:
+		if now < b.status.RetryAt {
+			return false
+		}
+		b.status.State = model.CIRCUIT_BREAKER_STATE_HALF_OPEN
+		return true
+	case model.CIRCUIT_BREAKER_STATE_HALF_OPEN:
+		return false
+	}
+
+	return true
+}
+
+func (b *circuitBreaker) recordFailure(now int64, reason string, failureThreshold int, cooldown time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.status.ConsecutiveFailures++
+	b.status.LastFailureAt = now
+	b.status.LastError = reason
+
+	if b.status.State != model.CIRCUIT_BREAKER_STATE_HALF_OPEN && b.status.ConsecutiveFailures < failureThreshold {
+		return
+	}
+
+	if b.status.State == model.CIRCUIT_BREAKER_STATE_CLOSED {
+		mlog.Warn("An integration has failed repeatedly, so requests to it will be skipped for a while", mlog.String("endpoint", b.status.Endpoint), mlog.Int("failures", b.status.ConsecutiveFailures), mlog.String("error", reason))
+	}
+
+	b.status.State = model.CIRCUIT_BREAKER_STATE_OPEN
+	b.status.OpenedAt = now
+	b.status.RetryAt = now + int64(cooldown/time.Millisecond)
+}
+
+func (b *circuitBreaker) recordSuccess() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.status.State != model.CIRCUIT_BREAKER_STATE_CLOSED {
+		mlog.Info("An integration has recovered, so requests to it will no longer be skipped", mlog.String("endpoint", b.status.Endpoint))
+	}
+
+	b.status.State = model.CIRCUIT_BREAKER_STATE_CLOSED
+	b.status.ConsecutiveFailures = 0
+	b.status.OpenedAt = 0
+	b.status.RetryAt = 0
+}
+
+func (b *circuitBreaker) getStatus() *model.CircuitBreakerStatus {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	status := b.status
+	return &status
+}
+
+// circuitBreakers holds the breakers of the integration endpoints that have failed recently. A breaker is only added
+// once its endpoint fails, so that endpoints which are working don't take up any space.
+type circuitBreakers struct {
+	cache *utils.Cache
+}
+
+func newCircuitBreakers() *circuitBreakers {
+	return &circuitBreakers{
+		cache: utils.NewLru(CIRCUIT_BREAKER_CACHE_SIZE),
+	}
+}
+
+func (c *circuitBreakers) get(endpoint string) *circuitBreaker {
+	if breaker, ok := c.cache.Get(endpoint); ok {
+		return breaker.(*circuitBreaker)
+	}
+	return nil
+}
+
+func (c *circuitBreakers) getOrAdd(endpoint string) *circuitBreaker {
+	breaker, _ := c.cache.GetOrAdd(endpoint, &circuitBreaker{
+		status: model.CircuitBreakerStatus{
+			Endpoint: endpoint,
+			State:    model.CIRCUIT_BREAKER_STATE_CLOSED,
+		},
+	}, 0)
+	return breaker.(*circuitBreaker)
+}
+
+// statuses returns the breakers of the endpoints that have failed since they last succeeded.
+func (c *circuitBreakers) statuses() []*model.CircuitBreakerStatus {
+	statuses := []*model.CircuitBreakerStatus{}
+	for _, key := range c.cache.Keys() {
+		breaker := c.get(key.(string))
+		if breaker == nil {
+			continue
+		}
+
+		if status := breaker.getStatus(); status.ConsecutiveFailures > 0 {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// circuitBreakerTransport is an implementation of http.RoundTripper that fails requests straight away once their
+// endpoint has failed failureThreshold times in a row, until the cool-down period has passed. Errors and 5xx
+// responses both count as failures.
+type circuitBreakerTransport struct {
+	breakers         *circuitBreakers
+	failureThreshold int
+	cooldown         time.Duration
+
+	// Transport is the underlying http.RoundTripper that is actually used to make the request
+	Transport http.RoundTripper
+}
+
+func (t *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := circuitBreakerEndpoint(req.URL)
+
+	if breaker := t.breakers.get(endpoint); breaker != nil && !breaker.allow(model.GetMillis()) {
+		mlog.Debug("Skipped a request to an integration that has failed repeatedly", mlog.String("endpoint", endpoint))
+		return nil, CircuitOpen
+	}
+
+	resp, err := t.Transport.RoundTrip(req)
+
+	if err != nil {
+		t.breakers.getOrAdd(endpoint).recordFailure(model.GetMillis(), err.Error(), t.failureThreshold, t.cooldown)
+	} else if resp.StatusCode >= http.StatusInternalServerError {
+		t.breakers.getOrAdd(endpoint).recordFailure(model.GetMillis(), fmt.Sprintf("received status %v", resp.StatusCode), t.failureThreshold, t.cooldown)
+	} else if breaker := t.breakers.get(endpoint); breaker != nil {
+		breaker.recordSuccess()
+	}
+
+	return resp, err
+}
+
+// circuitBreakerEndpoint identifies the endpoint of a request without its query string, since that may contain
+// secrets such as the token of a slash command.
+func circuitBreakerEndpoint(u *url.URL) string {
+	return u.Scheme + "://" + u.Host + u.Path
+}
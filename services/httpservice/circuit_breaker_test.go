@@ -0,0 +1,115 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package httpservice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestCircuitBreakerTransport(t *testing.T) {
+	status := http.StatusInternalServerError
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	breakers := newCircuitBreakers()
+	client := &http.Client{
+		Transport: &circuitBreakerTransport{
+			breakers:         breakers,
+			failureThreshold: 2,
+			cooldown:         50 * time.Millisecond,
+			Transport:        http.DefaultTransport,
+		},
+	}
+
+	get := func() error {
+		resp, err := client.Get(server.URL + "/hook?token=secret")
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	assert.Empty(t, breakers.statuses())
+
+	require.Nil(t, get())
+	require.Nil(t, get())
+	assert.Equal(t, 2, requests)
+
+	statuses := breakers.statuses()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, server.URL+"/hook", statuses[0].Endpoint)
+	assert.Equal(t, model.CIRCUIT_BREAKER_STATE_OPEN, statuses[0].State)
+	assert.Equal(t, 2, statuses[0].ConsecutiveFailures)
+	assert.Equal(t, "received status 500", statuses[0].LastError)
+
+	t.Run("requests fail straight away while open", func(t *testing.T) {
+		err := get()
+		require.NotNil(t, err)
+		assert.Equal(t, CircuitOpen, err.(*url.Error).Err)
+		assert.Equal(t, 2, requests)
+	})
+
+	t.Run("other endpoints aren't affected", func(t *testing.T) {
+		resp, err := client.Get(server.URL + "/other")
+		require.Nil(t, err)
+		resp.Body.Close()
+		assert.Equal(t, 3, requests)
+	})
+
+	t.Run("a failed probe opens the breaker again", func(t *testing.T) {
+		time.Sleep(60 * time.Millisecond)
+
+		require.Nil(t, get())
+		assert.Equal(t, 4, requests)
+
+		err := get()
+		require.NotNil(t, err)
+		assert.Equal(t, CircuitOpen, err.(*url.Error).Err)
+	})
+
+	t.Run("a successful probe closes the breaker", func(t *testing.T) {
+		time.Sleep(60 * time.Millisecond)
+		status = http.StatusOK
+
+		require.Nil(t, get())
+		require.Nil(t, get())
+		assert.Equal(t, 6, requests)
+
+		for _, status := range breakers.statuses() {
+			assert.NotEqual(t, server.URL+"/hook", status.Endpoint)
+		}
+	})
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	breaker := &circuitBreaker{status: model.CircuitBreakerStatus{State: model.CIRCUIT_BREAKER_STATE_CLOSED}}
+
+	breaker.recordFailure(1000, "failed", 1, time.Second)
+	assert.Equal(t, model.CIRCUIT_BREAKER_STATE_OPEN, breaker.getStatus().State)
+	assert.Equal(t, int64(2000), breaker.getStatus().RetryAt)
+
+	assert.False(t, breaker.allow(1999))
+	assert.True(t, breaker.allow(2000))
+	assert.Equal(t, model.CIRCUIT_BREAKER_STATE_HALF_OPEN, breaker.getStatus().State)
+
+	// Only the first request after the cool-down period probes the endpoint
+	assert.False(t, breaker.allow(2001))
+
+	breaker.recordSuccess()
+	assert.True(t, breaker.allow(2002))
+	assert.Equal(t, 0, breaker.getStatus().ConsecutiveFailures)
+}
@@ -136,9 +136,29 @@ func dialContextFilter(dial DialContextFunction, allowHost func(host string) boo
 	}
 }
 
+// TransportSettings are the timeouts and connection limits of a transport. A MaxConnsPerHost of 0 means there's no
+// limit.
+type TransportSettings struct {
+	ConnectTimeout      time.Duration
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+	IdleConnTimeout     time.Duration
+}
+
+var DefaultTransportSettings = TransportSettings{
+	ConnectTimeout:  ConnectTimeout,
+	MaxIdleConns:    100,
+	IdleConnTimeout: 90 * time.Second,
+}
+
 func NewTransport(enableInsecureConnections bool, allowHost func(host string) bool, allowIP func(ip net.IP) bool) http.RoundTripper {
+	return NewTransportWithSettings(DefaultTransportSettings, enableInsecureConnections, allowHost, allowIP)
+}
+
+func NewTransportWithSettings(settings TransportSettings, enableInsecureConnections bool, allowHost func(host string) bool, allowIP func(ip net.IP) bool) http.RoundTripper {
 	dialContext := (&net.Dialer{
-		Timeout:   ConnectTimeout,
+		Timeout:   settings.ConnectTimeout,
 		KeepAlive: 30 * time.Second,
 	}).DialContext
 
@@ -150,9 +170,11 @@ func NewTransport(enableInsecureConnections bool, allowHost func(host string) bo
 		&http.Transport{
 			Proxy:                 http.ProxyFromEnvironment,
 			DialContext:           dialContext,
-			MaxIdleConns:          100,
-			IdleConnTimeout:       90 * time.Second,
-			TLSHandshakeTimeout:   ConnectTimeout,
+			MaxIdleConns:          settings.MaxIdleConns,
+			MaxIdleConnsPerHost:   settings.MaxIdleConnsPerHost,
+			MaxConnsPerHost:       settings.MaxConnsPerHost,
+			IdleConnTimeout:       settings.IdleConnTimeout,
+			TLSHandshakeTimeout:   settings.ConnectTimeout,
 			ExpectContinueTimeout: 1 * time.Second,
 			TLSClientConfig: &tls.Config{
 				InsecureSkipVerify: enableInsecureConnections,
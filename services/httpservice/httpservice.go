@@ -6,9 +6,12 @@ package httpservice
 import (
 	"net"
 	"net/http"
+	"reflect"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/mattermost/mattermost-server/model"
 	"github.com/mattermost/mattermost-server/services/configservice"
 )
 
@@ -25,19 +28,65 @@ type HTTPService interface {
 	// - A Mattermost-specific user agent header
 	// - Additional security for untrusted and insecure connections
 	MakeTransport(trustURLs bool) http.RoundTripper
+
+	// MakeIntegrationClient returns an http client for making requests to untrusted integrations with the given
+	// timeouts and connection limits. Clients made with the same settings share a transport, so that its connection
+	// limits apply across all of their requests. Requests to an endpoint that keeps failing are short-circuited by a
+	// circuit breaker according to the settings.
+	MakeIntegrationClient(settings model.IntegrationHTTPClientSettings) *http.Client
+
+	// GetCircuitBreakerStatuses returns the circuit breakers of the integration endpoints that have failed since they
+	// last succeeded on this server.
+	GetCircuitBreakerStatuses() []*model.CircuitBreakerStatus
+}
+
+type integrationTransportKey struct {
+	settings         TransportSettings
+	insecure         bool
+	failureThreshold int
+	cooldown         time.Duration
 }
 
 type HTTPServiceImpl struct {
 	configService configservice.ConfigService
 
 	RequestTimeout time.Duration
+
+	integrationTransports     map[integrationTransportKey]http.RoundTripper
+	integrationTransportsLock sync.Mutex
+	circuitBreakers           *circuitBreakers
 }
 
 func MakeHTTPService(configService configservice.ConfigService) HTTPService {
-	return &HTTPServiceImpl{
-		configService,
-		RequestTimeout,
+	h := &HTTPServiceImpl{
+		configService:         configService,
+		RequestTimeout:        RequestTimeout,
+		integrationTransports: make(map[integrationTransportKey]http.RoundTripper),
+		circuitBreakers:       newCircuitBreakers(),
 	}
+
+	configService.AddConfigListener(h.onConfigChange)
+
+	return h
+}
+
+// onConfigChange drops the cached integration transports when the settings they were made with change, so that
+// clients made afterwards use the new settings instead of waiting for a restart.
+func (h *HTTPServiceImpl) onConfigChange(oldConfig, newConfig *model.Config) {
+	if reflect.DeepEqual(oldConfig.IntegrationHTTPSettings, newConfig.IntegrationHTTPSettings) &&
+		reflect.DeepEqual(oldConfig.ServiceSettings.EnableInsecureOutgoingConnections, newConfig.ServiceSettings.EnableInsecureOutgoingConnections) &&
+		reflect.DeepEqual(oldConfig.ServiceSettings.AllowedUntrustedInternalConnections, newConfig.ServiceSettings.AllowedUntrustedInternalConnections) {
+		return
+	}
+
+	h.clearIntegrationTransports()
+}
+
+func (h *HTTPServiceImpl) clearIntegrationTransports() {
+	h.integrationTransportsLock.Lock()
+	defer h.integrationTransportsLock.Unlock()
+
+	h.integrationTransports = make(map[integrationTransportKey]http.RoundTripper)
 }
 
 func (h *HTTPServiceImpl) MakeClient(trustURLs bool) *http.Client {
@@ -48,10 +97,56 @@ func (h *HTTPServiceImpl) MakeClient(trustURLs bool) *http.Client {
 }
 
 func (h *HTTPServiceImpl) MakeTransport(trustURLs bool) http.RoundTripper {
-	insecure := h.configService.Config().ServiceSettings.EnableInsecureOutgoingConnections != nil && *h.configService.Config().ServiceSettings.EnableInsecureOutgoingConnections
+	return h.makeTransport(DefaultTransportSettings, h.insecure(), trustURLs)
+}
+
+func (h *HTTPServiceImpl) MakeIntegrationClient(settings model.IntegrationHTTPClientSettings) *http.Client {
+	key := integrationTransportKey{
+		settings: TransportSettings{
+			ConnectTimeout:      time.Duration(*settings.ConnectTimeoutSeconds) * time.Second,
+			MaxIdleConns:        *settings.MaxIdleConns,
+			MaxIdleConnsPerHost: *settings.MaxIdleConnsPerHost,
+			MaxConnsPerHost:     *settings.MaxConnsPerHost,
+			IdleConnTimeout:     time.Duration(*settings.IdleConnTimeoutSeconds) * time.Second,
+		},
+		insecure:         h.insecure(),
+		failureThreshold: *settings.CircuitBreakerFailureThreshold,
+		cooldown:         time.Duration(*settings.CircuitBreakerCooldownSeconds) * time.Second,
+	}
+
+	h.integrationTransportsLock.Lock()
+	transport, ok := h.integrationTransports[key]
+	if !ok {
+		transport = h.makeTransport(key.settings, key.insecure, false)
+		if key.failureThreshold > 0 {
+			transport = &circuitBreakerTransport{
+				breakers:         h.circuitBreakers,
+				failureThreshold: key.failureThreshold,
+				cooldown:         key.cooldown,
+				Transport:        transport,
+			}
+		}
+		h.integrationTransports[key] = transport
+	}
+	h.integrationTransportsLock.Unlock()
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   time.Duration(*settings.RequestTimeoutSeconds) * time.Second,
+	}
+}
+
+func (h *HTTPServiceImpl) GetCircuitBreakerStatuses() []*model.CircuitBreakerStatus {
+	return h.circuitBreakers.statuses()
+}
+
+func (h *HTTPServiceImpl) insecure() bool {
+	return h.configService.Config().ServiceSettings.EnableInsecureOutgoingConnections != nil && *h.configService.Config().ServiceSettings.EnableInsecureOutgoingConnections
+}
 
+func (h *HTTPServiceImpl) makeTransport(settings TransportSettings, insecure bool, trustURLs bool) http.RoundTripper {
 	if trustURLs {
-		return NewTransport(insecure, nil, nil)
+		return NewTransportWithSettings(settings, insecure, nil, nil)
 	}
 
 	allowHost := func(host string) bool {
@@ -93,5 +188,5 @@ func (h *HTTPServiceImpl) MakeTransport(trustURLs bool) http.RoundTripper {
 		return false
 	}
 
-	return NewTransport(insecure, allowHost, allowIP)
+	return NewTransportWithSettings(settings, insecure, allowHost, allowIP)
 }
@@ -0,0 +1,67 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package httpservice
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils/testutils"
+)
+
+func TestMakeIntegrationClient(t *testing.T) {
+	config := &model.Config{}
+	config.SetDefaults()
+	h := MakeHTTPService(&testutils.StaticConfigService{Cfg: config})
+
+	settings := config.IntegrationHTTPSettings.SlashCommands
+	settings.RequestTimeoutSeconds = model.NewInt(5)
+	settings.MaxIdleConnsPerHost = model.NewInt(4)
+	settings.MaxConnsPerHost = model.NewInt(8)
+
+	client := h.MakeIntegrationClient(settings)
+	assert.Equal(t, 5*time.Second, client.Timeout)
+
+	breaker := client.Transport.(*circuitBreakerTransport)
+	assert.Equal(t, 5, breaker.failureThreshold)
+	assert.Equal(t, time.Minute, breaker.cooldown)
+
+	transport := breaker.Transport.(*MattermostTransport).Transport.(*http.Transport)
+	assert.Equal(t, 4, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 8, transport.MaxConnsPerHost)
+	assert.Equal(t, 100, transport.MaxIdleConns)
+	assert.Equal(t, 90*time.Second, transport.IdleConnTimeout)
+	assert.Equal(t, 3*time.Second, transport.TLSHandshakeTimeout)
+
+	t.Run("clients with the same settings share a transport", func(t *testing.T) {
+		other := h.MakeIntegrationClient(settings)
+		assert.True(t, client.Transport == other.Transport)
+	})
+
+	t.Run("clients with different settings don't share a transport", func(t *testing.T) {
+		other := config.IntegrationHTTPSettings.Webhooks
+		other.MaxConnsPerHost = model.NewInt(2)
+		other.CircuitBreakerFailureThreshold = model.NewInt(0)
+
+		otherClient := h.MakeIntegrationClient(other)
+		require.False(t, client.Transport == otherClient.Transport)
+		assert.Equal(t, 2, otherClient.Transport.(*MattermostTransport).Transport.(*http.Transport).MaxConnsPerHost)
+	})
+	t.Run("transports are made again when the settings change", func(t *testing.T) {
+		impl := h.(*HTTPServiceImpl)
+
+		impl.onConfigChange(config, config)
+		assert.True(t, client.Transport == h.MakeIntegrationClient(settings).Transport)
+
+		newConfig := config.Clone()
+		newConfig.IntegrationHTTPSettings.Webhooks.RequestTimeoutSeconds = model.NewInt(10)
+		impl.onConfigChange(config, newConfig)
+		assert.False(t, client.Transport == h.MakeIntegrationClient(settings).Transport)
+	})
+}
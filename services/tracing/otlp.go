@@ -0,0 +1,161 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const OTLP_SERVICE_NAME = "mattermost-server"
+
+// These match the status codes of OpenTelemetry.
+const (
+	otlpStatusUnset = 0
+	otlpStatusError = 2
+)
+
+// OTLPExporter posts spans to an OpenTelemetry collector using the JSON encoding of OTLP over HTTP, treating any
+// response other than a 2xx as a failure.
+type OTLPExporter struct {
+	client   *http.Client
+	endpoint string
+}
+
+func NewOTLPExporter(client *http.Client, endpoint string) *OTLPExporter {
+	return &OTLPExporter{
+		client:   client,
+		endpoint: endpoint,
+	}
+}
+
+type otlpAnyValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttribute struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceId           string          `json:"traceId"`
+	SpanId            string          `json:"spanId"`
+	ParentSpanId      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpExportRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+func toOTLPAttributes(attributes []Attribute) []otlpAttribute {
+	otlpAttributes := make([]otlpAttribute, len(attributes))
+	for i, attribute := range attributes {
+		otlpAttributes[i] = otlpAttribute{Key: attribute.Key, Value: otlpAnyValue{StringValue: attribute.Value}}
+	}
+	return otlpAttributes
+}
+
+func toOTLPSpan(span *Span) otlpSpan {
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+
+	s := otlpSpan{
+		TraceId:           hex.EncodeToString(span.context.TraceId[:]),
+		SpanId:            hex.EncodeToString(span.context.SpanId[:]),
+		Name:              span.name,
+		Kind:              span.kind,
+		StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+		Attributes:        toOTLPAttributes(span.attributes),
+		Status:            otlpStatus{Code: otlpStatusUnset},
+	}
+
+	if span.parentSpanId != [8]byte{} {
+		s.ParentSpanId = hex.EncodeToString(span.parentSpanId[:])
+	}
+
+	if span.err != "" {
+		s.Status = otlpStatus{Code: otlpStatusError, Message: span.err}
+	}
+
+	return s
+}
+
+func encodeOTLP(spans []*Span) ([]byte, error) {
+	scopeSpans := otlpScopeSpans{Spans: make([]otlpSpan, len(spans))}
+	scopeSpans.Scope.Name = OTLP_SERVICE_NAME
+	for i, span := range spans {
+		scopeSpans.Spans[i] = toOTLPSpan(span)
+	}
+
+	resourceSpans := otlpResourceSpans{ScopeSpans: []otlpScopeSpans{scopeSpans}}
+	resourceSpans.Resource.Attributes = toOTLPAttributes([]Attribute{
+		{"service.name", OTLP_SERVICE_NAME},
+		{"service.version", model.CurrentVersion},
+	})
+
+	return json.Marshal(otlpExportRequest{ResourceSpans: []otlpResourceSpans{resourceSpans}})
+}
+
+func (e *OTLPExporter) Export(spans []*Span) error {
+	body, err := encodeOTLP(spans)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP endpoint returned status %v", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SpanKind int
+
+// These match the span kinds of OpenTelemetry.
+const (
+	SPAN_KIND_INTERNAL SpanKind = 1
+	SPAN_KIND_SERVER   SpanKind = 2
+)
+
+// SpanContext identifies a span and the trace that it's part of, and is what's propagated between services.
+type SpanContext struct {
+	TraceId [16]byte
+	SpanId  [8]byte
+	Sampled bool
+}
+
+// ParseTraceparent reads a span context from a W3C traceparent header, returning false if it isn't valid.
+func ParseTraceparent(header string) (SpanContext, bool) {
+	var sc SpanContext
+
+	// version-traceid-spanid-flags, where versions after 00 may append more fields
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, false
+	}
+
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, false
+	}
+
+	if _, err := hex.Decode(sc.TraceId[:], []byte(parts[1])); err != nil || sc.TraceId == [16]byte{} {
+		return sc, false
+	}
+
+	if _, err := hex.Decode(sc.SpanId[:], []byte(parts[2])); err != nil || sc.SpanId == [8]byte{} {
+		return sc, false
+	}
+
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return sc, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+
+	return sc, true
+}
+
+// Traceparent formats the span context as a W3C traceparent header.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.TraceId[:]) + "-" + hex.EncodeToString(sc.SpanId[:]) + "-" + flags
+}
+
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Span records an operation that's part of a trace. All of its methods may be called on a nil span, which is what's
+// returned while tracing is disabled or a trace isn't sampled, so that callers don't need to check for it.
+type Span struct {
+	tracer *Tracer
+
+	mutex        sync.Mutex
+	context      SpanContext
+	parentSpanId [8]byte
+	name         string
+	kind         SpanKind
+	start        time.Time
+	end          time.Time
+	attributes   []Attribute
+	err          string
+	ended        bool
+}
+
+func newSpan(tracer *Tracer, traceId [16]byte, parentSpanId [8]byte, name string, kind SpanKind) *Span {
+	span := &Span{
+		tracer: tracer,
+		context: SpanContext{
+			TraceId: traceId,
+			Sampled: true,
+		},
+		parentSpanId: parentSpanId,
+		name:         name,
+		kind:         kind,
+		start:        time.Now(),
+	}
+	rand.Read(span.context.SpanId[:])
+
+	return span
+}
+
+// Context returns the span context that identifies the span to the services that it calls.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.context
+}
+
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.name = name
+}
+
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.attributes = append(s.attributes, Attribute{key, value})
+}
+
+// SetError marks the operation as having failed.
+func (s *Span) SetError(message string) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.err = message
+}
+
+// StartChild starts a span for an operation that's part of this one. It must be ended by calling End.
+func (s *Span) StartChild(name string) *Span {
+	if s == nil {
+		return nil
+	}
+
+	return newSpan(s.tracer, s.context.TraceId, s.context.SpanId, name, SPAN_KIND_INTERNAL)
+}
+
+// End records the end of the operation and queues the span to be exported. Calling it more than once has no effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mutex.Unlock()
+
+	s.tracer.add(s)
+}
+
+// isSampled decides whether a new trace is sampled based on its id, so that every service that makes the same decision
+// for the trace without being told agrees on it.
+func isSampled(traceId [16]byte, ratio float64) bool {
+	if ratio >= 1 {
+		return true
+	} else if ratio <= 0 {
+		return false
+	}
+
+	return binary.BigEndian.Uint64(traceId[8:])>>1 < uint64(ratio*(1<<63))
+}
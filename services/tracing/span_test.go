@@ -0,0 +1,90 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package tracing
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	for name, tc := range map[string]struct {
+		Header  string
+		Valid   bool
+		Sampled bool
+	}{
+		"sampled":            {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		"not sampled":        {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		"future version":     {"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		"empty":              {"", false, false},
+		"invalid version":    {"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		"extra fields in 00": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		"zero trace id":      {"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		"zero span id":       {"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		"short trace id":     {"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", false, false},
+		"not hex":            {"00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01", false, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sc, ok := ParseTraceparent(tc.Header)
+			assert.Equal(t, tc.Valid, ok)
+			assert.Equal(t, tc.Sampled, sc.Sampled)
+		})
+	}
+
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := ParseTraceparent(header)
+	require.True(t, ok)
+	assert.Equal(t, header, sc.Traceparent())
+}
+
+func TestIsSampled(t *testing.T) {
+	low := [16]byte{}
+	high := [16]byte{8: 0xff, 9: 0xff, 10: 0xff, 11: 0xff, 12: 0xff, 13: 0xff, 14: 0xff, 15: 0xff}
+
+	assert.True(t, isSampled(high, 1))
+	assert.False(t, isSampled(low, 0))
+	assert.True(t, isSampled(low, 0.5))
+	assert.False(t, isSampled(high, 0.5))
+}
+
+func TestSpan(t *testing.T) {
+	t.Run("nil span", func(t *testing.T) {
+		var span *Span
+
+		assert.NotPanics(t, func() {
+			span.SetName("name")
+			span.SetAttribute("key", "value")
+			span.SetError("error")
+			assert.Nil(t, span.StartChild("child"))
+			assert.Equal(t, SpanContext{}, span.Context())
+			span.End()
+		})
+	})
+
+	t.Run("child", func(t *testing.T) {
+		tracer := NewTracer(nil, 1)
+
+		span := tracer.StartRequestSpan(&http.Request{Method: "GET", Header: http.Header{}})
+		require.NotNil(t, span)
+
+		child := span.StartChild("child")
+		assert.Equal(t, span.Context().TraceId, child.Context().TraceId)
+		assert.NotEqual(t, span.Context().SpanId, child.Context().SpanId)
+		assert.Equal(t, span.Context().SpanId, child.parentSpanId)
+		assert.Equal(t, SPAN_KIND_INTERNAL, child.kind)
+	})
+
+	t.Run("end more than once", func(t *testing.T) {
+		tracer := NewTracer(nil, 1)
+
+		span := tracer.StartRequestSpan(&http.Request{Method: "GET", Header: http.Header{}})
+		span.End()
+		span.End()
+
+		assert.Len(t, tracer.spans, 1)
+	})
+}
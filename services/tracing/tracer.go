@@ -0,0 +1,156 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package tracing
+
+import (
+	"crypto/rand"
+	"net/http"
+	"time"
+
+	"github.com/mattermost/mattermost-server/mlog"
+)
+
+const (
+	TRACER_BUFFER_SIZE    = 10000
+	TRACER_BATCH_SIZE     = 512
+	TRACER_FLUSH_INTERVAL = 5 * time.Second
+
+	TRACEPARENT_HEADER = "traceparent"
+)
+
+// Exporter sends finished spans to wherever they're collected.
+type Exporter interface {
+	Export(spans []*Span) error
+}
+
+// Tracer starts spans and exports them in batches once they've ended. A nil tracer is a valid tracer that never starts
+// any spans, and is used while tracing is disabled.
+type Tracer struct {
+	exporter      Exporter
+	samplingRatio float64
+	spans         chan *Span
+	batchSize     int
+	flushInterval time.Duration
+
+	stopping chan struct{}
+	stopped  chan struct{}
+}
+
+func NewTracer(exporter Exporter, samplingRatio float64) *Tracer {
+	return &Tracer{
+		exporter:      exporter,
+		samplingRatio: samplingRatio,
+		spans:         make(chan *Span, TRACER_BUFFER_SIZE),
+		batchSize:     TRACER_BATCH_SIZE,
+		flushInterval: TRACER_FLUSH_INTERVAL,
+		stopping:      make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+}
+
+// StartRequestSpan starts a span for handling a request, continuing the trace of its traceparent header if it has one.
+// Otherwise, a new trace is started if it's picked by the sampling ratio.
+func (t *Tracer) StartRequestSpan(r *http.Request) *Span {
+	if t == nil {
+		return nil
+	}
+
+	var traceId [16]byte
+	var parentSpanId [8]byte
+
+	if parent, ok := ParseTraceparent(r.Header.Get(TRACEPARENT_HEADER)); ok {
+		if !parent.Sampled {
+			return nil
+		}
+
+		traceId = parent.TraceId
+		parentSpanId = parent.SpanId
+	} else {
+		rand.Read(traceId[:])
+		if !isSampled(traceId, t.samplingRatio) {
+			return nil
+		}
+	}
+
+	return newSpan(t, traceId, parentSpanId, r.Method, SPAN_KIND_SERVER)
+}
+
+func (t *Tracer) add(span *Span) {
+	select {
+	case t.spans <- span:
+	default:
+		mlog.Debug("The tracer's buffer was full so a span was dropped")
+	}
+}
+
+func (t *Tracer) Start() {
+	go t.run()
+}
+
+// Stop exports any spans that have already ended before returning.
+func (t *Tracer) Stop() {
+	close(t.stopping)
+	<-t.stopped
+}
+
+// run collects spans into batches, exporting each batch once it's full or the flush interval has passed. Unlike the
+// event firehose, batches that fail to export are dropped rather than retried, since traces are only useful for a
+// short time and a collector that's down shouldn't cause spans to pile up.
+func (t *Tracer) run() {
+	defer close(t.stopped)
+
+	ticker := time.NewTicker(t.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, t.batchSize)
+	for {
+		select {
+		case span := <-t.spans:
+			batch = append(batch, span)
+			if len(batch) < t.batchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-t.stopping:
+			t.flush(batch)
+			return
+		}
+
+		if len(batch) == 0 {
+			continue
+		}
+
+		t.export(batch)
+		batch = make([]*Span, 0, t.batchSize)
+	}
+}
+
+// flush exports the given batch along with any spans that are still buffered.
+func (t *Tracer) flush(batch []*Span) {
+	receiving := true
+	for receiving {
+		select {
+		case span := <-t.spans:
+			batch = append(batch, span)
+		default:
+			receiving = false
+		}
+	}
+
+	for len(batch) > 0 {
+		size := t.batchSize
+		if size > len(batch) {
+			size = len(batch)
+		}
+
+		t.export(batch[:size])
+		batch = batch[size:]
+	}
+}
+
+func (t *Tracer) export(batch []*Span) {
+	if err := t.exporter.Export(batch); err != nil {
+		mlog.Warn("Failed to export trace spans, so they were dropped", mlog.Int("spans", len(batch)), mlog.Err(err))
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package tracing
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartRequestSpan(t *testing.T) {
+	newRequest := func(traceparent string) *http.Request {
+		r := httptest.NewRequest("GET", "/api/v4/users", nil)
+		if traceparent != "" {
+			r.Header.Set(TRACEPARENT_HEADER, traceparent)
+		}
+		return r
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		var tracer *Tracer
+		r := newRequest("")
+
+		assert.Nil(t, tracer.StartRequestSpan(r))
+		assert.Equal(t, 0.0, testing.AllocsPerRun(100, func() {
+			span := tracer.StartRequestSpan(r)
+			span.SetAttribute("key", "value")
+			span.End()
+		}))
+	})
+
+	t.Run("new trace", func(t *testing.T) {
+		span := NewTracer(nil, 1).StartRequestSpan(newRequest(""))
+		require.NotNil(t, span)
+		assert.NotEqual(t, [16]byte{}, span.Context().TraceId)
+		assert.Equal(t, [8]byte{}, span.parentSpanId)
+		assert.Equal(t, SPAN_KIND_SERVER, span.kind)
+
+		assert.Nil(t, NewTracer(nil, 0).StartRequestSpan(newRequest("")))
+	})
+
+	t.Run("continued trace", func(t *testing.T) {
+		parent, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+		span := NewTracer(nil, 0).StartRequestSpan(newRequest(parent.Traceparent()))
+		require.NotNil(t, span, "should follow the sampling decision of the parent")
+		assert.Equal(t, parent.TraceId, span.Context().TraceId)
+		assert.Equal(t, parent.SpanId, span.parentSpanId)
+
+		parent.Sampled = false
+		assert.Nil(t, NewTracer(nil, 1).StartRequestSpan(newRequest(parent.Traceparent())))
+	})
+}
+
+func TestTracerExportsToOTLP(t *testing.T) {
+	var mutex sync.Mutex
+	var requests []otlpExportRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.Nil(t, err)
+
+		var request otlpExportRequest
+		require.Nil(t, json.Unmarshal(body, &request))
+
+		mutex.Lock()
+		requests = append(requests, request)
+		mutex.Unlock()
+	}))
+	defer server.Close()
+
+	tracer := NewTracer(NewOTLPExporter(http.DefaultClient, server.URL), 1)
+	tracer.Start()
+
+	span := tracer.StartRequestSpan(httptest.NewRequest("GET", "/api/v4/users", nil))
+	span.SetName("GET /api/v4/users")
+	span.SetAttribute("http.method", "GET")
+	span.SetError("api.context.404.app_error")
+	child := span.StartChild("child")
+	child.End()
+	span.End()
+
+	tracer.Stop()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	require.Len(t, requests, 1)
+	require.Len(t, requests[0].ResourceSpans, 1)
+	require.Len(t, requests[0].ResourceSpans[0].ScopeSpans, 1)
+
+	spans := requests[0].ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, spans[1].SpanId, spans[0].ParentSpanId)
+	assert.Equal(t, otlpStatusUnset, spans[0].Status.Code)
+
+	assert.Equal(t, "GET /api/v4/users", spans[1].Name)
+	assert.Equal(t, SPAN_KIND_SERVER, spans[1].Kind)
+	assert.Equal(t, "", spans[1].ParentSpanId)
+	assert.Equal(t, []otlpAttribute{{"http.method", otlpAnyValue{"GET"}}}, spans[1].Attributes)
+	assert.Equal(t, otlpStatus{Code: otlpStatusError, Message: "api.context.404.app_error"}, spans[1].Status)
+}
+
+func TestOTLPExporterReturnsErrorOnFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(http.DefaultClient, server.URL)
+
+	span := NewTracer(nil, 1).StartRequestSpan(httptest.NewRequest("GET", "/", nil))
+	assert.NotNil(t, exporter.Export([]*Span{span}))
+}
@@ -191,6 +191,14 @@ func (s *LayeredStore) LinkMetadata() LinkMetadataStore {
 	return s.DatabaseLayer.LinkMetadata()
 }
 
+func (s *LayeredStore) ClusterLock() ClusterLockStore {
+	return s.DatabaseLayer.ClusterLock()
+}
+
+func (s *LayeredStore) IdempotentRequest() IdempotentRequestStore {
+	return s.DatabaseLayer.IdempotentRequest()
+}
+
 func (s *LayeredStore) MarkSystemRanUnitTests() {
 	s.DatabaseLayer.MarkSystemRanUnitTests()
 }
@@ -224,6 +232,14 @@ func (s *LayeredStore) TotalSearchDbConnections() int {
 	return s.DatabaseLayer.TotalSearchDbConnections()
 }
 
+func (s *LayeredStore) DbPoolStats() []*model.DbPoolStats {
+	return s.DatabaseLayer.DbPoolStats()
+}
+
+func (s *LayeredStore) CacheStats() []*model.CacheStats {
+	return append(s.LocalCacheLayer.CacheStats(), s.DatabaseLayer.CacheStats()...)
+}
+
 type LayeredReactionStore struct {
 	*LayeredStore
 }
@@ -252,6 +268,12 @@ func (s *LayeredReactionStore) BulkGetForPosts(postIds []string) StoreChannel {
 	})
 }
 
+func (s *LayeredReactionStore) GetUserIdsForEmojiInChannel(channelId string, emojiName string, since int64, offset int, limit int) StoreChannel {
+	return s.RunQuery(func(supplier LayeredStoreSupplier) *LayeredStoreSupplierResult {
+		return supplier.ReactionsGetUserIdsForEmojiInChannel(s.TmpContext, channelId, emojiName, since, offset, limit)
+	})
+}
+
 func (s *LayeredReactionStore) DeleteAllWithEmojiName(emojiName string) StoreChannel {
 	return s.RunQuery(func(supplier LayeredStoreSupplier) *LayeredStoreSupplierResult {
 		return supplier.ReactionDeleteAllWithEmojiName(s.TmpContext, emojiName)
@@ -30,6 +30,7 @@ type LayeredStoreSupplier interface {
 	ReactionDeleteAllWithEmojiName(ctx context.Context, emojiName string, hints ...LayeredStoreHint) *LayeredStoreSupplierResult
 	ReactionPermanentDeleteBatch(ctx context.Context, endTime int64, limit int64, hints ...LayeredStoreHint) *LayeredStoreSupplierResult
 	ReactionsBulkGetForPosts(ctx context.Context, postIds []string, hints ...LayeredStoreHint) *LayeredStoreSupplierResult
+	ReactionsGetUserIdsForEmojiInChannel(ctx context.Context, channelId string, emojiName string, since int64, offset int, limit int, hints ...LayeredStoreHint) *LayeredStoreSupplierResult
 
 	// Roles
 	RoleSave(ctx context.Context, role *model.Role, hints ...LayeredStoreHint) *LayeredStoreSupplierResult
@@ -49,6 +49,15 @@ type ObjectCache interface {
 	GetInvalidateClusterEvent() string
 }
 
+func (s *LocalCacheSupplier) CacheStats() []*model.CacheStats {
+	return []*model.CacheStats{
+		s.reactionCache.Stats(),
+		s.roleCache.Stats(),
+		s.schemeCache.Stats(),
+		s.groupCache.Stats(),
+	}
+}
+
 func NewLocalCacheSupplier(metrics einterfaces.MetricsInterface, cluster einterfaces.ClusterInterface) *LocalCacheSupplier {
 	supplier := &LocalCacheSupplier{
 		reactionCache: utils.NewLruWithParams(REACTION_CACHE_SIZE, "Reaction", REACTION_CACHE_SEC, model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_REACTIONS),
@@ -56,3 +56,8 @@ func (s *LocalCacheSupplier) ReactionsBulkGetForPosts(ctx context.Context, postI
 	// Ignoring this.
 	return s.Next().ReactionsBulkGetForPosts(ctx, postIds, hints...)
 }
+
+func (s *LocalCacheSupplier) ReactionsGetUserIdsForEmojiInChannel(ctx context.Context, channelId string, emojiName string, since int64, offset int, limit int, hints ...LayeredStoreHint) *LayeredStoreSupplierResult {
+	// Ignoring this.
+	return s.Next().ReactionsGetUserIdsForEmojiInChannel(ctx, channelId, emojiName, since, offset, limit, hints...)
+}
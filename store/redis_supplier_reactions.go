@@ -59,3 +59,8 @@ func (s *RedisSupplier) ReactionsBulkGetForPosts(ctx context.Context, postIds []
 	// Ignoring this.
 	return s.Next().ReactionsBulkGetForPosts(ctx, postIds, hints...)
 }
+
+func (s *RedisSupplier) ReactionsGetUserIdsForEmojiInChannel(ctx context.Context, channelId string, emojiName string, since int64, offset int, limit int, hints ...LayeredStoreHint) *LayeredStoreSupplierResult {
+	// Ignoring this.
+	return s.Next().ReactionsGetUserIdsForEmojiInChannel(ctx, channelId, emojiName, since, offset, limit, hints...)
+}
@@ -32,6 +32,8 @@ const (
 	CHANNEL_MEMBERS_COUNTS_CACHE_SEC  = 1800 // 30 mins
 
 	CHANNEL_CACHE_SEC = 900 // 15 mins
+
+	REPAIR_MSG_COUNTS_MEMBER_BATCH_SIZE = 1000
 )
 
 type SqlChannelStore struct {
@@ -284,6 +286,7 @@ func NewSqlChannelStore(sqlStore SqlStore, metrics einterfaces.MetricsInterface)
 		table.ColMap("Purpose").SetMaxSize(250)
 		table.ColMap("CreatorId").SetMaxSize(26)
 		table.ColMap("SchemeId").SetMaxSize(26)
+		table.ColMap("BannerPostId").SetMaxSize(26)
 
 		tablem := db.AddTableWithName(channelMember{}, "ChannelMembers").SetKeys(false, "ChannelId", "UserId")
 		tablem.ColMap("ChannelId").SetMaxSize(26)
@@ -955,6 +958,83 @@ func (s SqlChannelStore) GetChannels(teamId string, userId string, includeDelete
 	})
 }
 
+// GetCommonChannels returns the undeleted channels that both users are members of, leaving out channels on teams
+// that the first user is no longer a member of.
+func (s SqlChannelStore) GetCommonChannels(userId string, otherUserId string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		query := `
+			SELECT
+				Channels.*
+			FROM
+				Channels
+			INNER JOIN
+				ChannelMembers Mine ON Mine.ChannelId = Channels.Id AND Mine.UserId = :UserId
+			INNER JOIN
+				ChannelMembers Theirs ON Theirs.ChannelId = Channels.Id AND Theirs.UserId = :OtherUserId
+			LEFT JOIN
+				TeamMembers ON TeamMembers.TeamId = Channels.TeamId AND TeamMembers.UserId = :UserId
+			WHERE
+				Channels.DeleteAt = 0
+				AND (Channels.TeamId = '' OR TeamMembers.DeleteAt = 0)
+			ORDER BY Channels.DisplayName, Channels.Id`
+
+		data := &model.ChannelList{}
+		if _, err := s.GetReplica().Select(data, query, map[string]interface{}{"UserId": userId, "OtherUserId": otherUserId}); err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.GetCommonChannels", "store.sql_channel.get_common_channels.app_error", nil, "user_id="+userId+", other_user_id="+otherUserId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = data
+	})
+}
+
+// GetCommonChannelCounts returns, for each of the other users that shares at least one channel with the given user,
+// the number of channels they have in common.
+func (s SqlChannelStore) GetCommonChannelCounts(userId string, otherUserIds []string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		counts := map[string]int64{}
+		if len(otherUserIds) == 0 {
+			result.Data = counts
+			return
+		}
+
+		props := map[string]interface{}{"UserId": userId}
+		idQuery := ""
+		for index, otherUserId := range otherUserIds {
+			if len(idQuery) > 0 {
+				idQuery += ", "
+			}
+
+			props["userId"+strconv.Itoa(index)] = otherUserId
+			idQuery += ":userId" + strconv.Itoa(index)
+		}
+
+		var rows []struct {
+			UserId string
+			Count  int64
+		}
+		query := `
+			SELECT
+				Theirs.UserId, COUNT(*) AS Count
+			FROM
+				ChannelMembers Theirs
+			INNER JOIN
+				ChannelMembers Mine ON Mine.ChannelId = Theirs.ChannelId AND Mine.UserId = :UserId
+			WHERE
+				Theirs.UserId IN (` + idQuery + `)
+			GROUP BY Theirs.UserId`
+		if _, err := s.GetReplica().Select(&rows, query, props); err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.GetCommonChannelCounts", "store.sql_channel.get_common_channels.app_error", nil, "user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for _, row := range rows {
+			counts[row.UserId] = row.Count
+		}
+		result.Data = counts
+	})
+}
+
 func (s SqlChannelStore) GetAllChannels(offset int, limit int, includeDeleted bool) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		deleteFilter := "AND c.DeleteAt = 0"
@@ -1204,6 +1284,59 @@ func (s SqlChannelStore) GetByNames(teamId string, names []string, allowFromCach
 	})
 }
 
+// GetByIds returns the channels with the given ids, including deleted ones, in no particular order. Ids that don't
+// match a channel are ignored.
+func (s SqlChannelStore) GetByIds(channelIds []string, allowFromCache bool) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		channels := []*model.Channel{}
+
+		if allowFromCache {
+			var misses []string
+			visited := make(map[string]struct{})
+			for _, id := range channelIds {
+				if _, ok := visited[id]; ok {
+					continue
+				}
+				visited[id] = struct{}{}
+				if cacheItem, ok := channelCache.Get(id); ok {
+					if s.metrics != nil {
+						s.metrics.IncrementMemCacheHitCounter("Channel")
+					}
+					channels = append(channels, (cacheItem.(*model.Channel)).DeepCopy())
+				} else {
+					if s.metrics != nil {
+						s.metrics.IncrementMemCacheMissCounter("Channel")
+					}
+					misses = append(misses, id)
+				}
+			}
+			channelIds = misses
+		}
+
+		if len(channelIds) > 0 {
+			props := map[string]interface{}{}
+			var idPlaceholders []string
+			for _, id := range channelIds {
+				key := fmt.Sprintf("Id%v", len(idPlaceholders))
+				props[key] = id
+				idPlaceholders = append(idPlaceholders, ":"+key)
+			}
+
+			var dbChannels []*model.Channel
+			if _, err := s.GetReplica().Select(&dbChannels, `SELECT * FROM Channels WHERE Id IN (`+strings.Join(idPlaceholders, ", ")+`)`, props); err != nil && err != sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlChannelStore.GetByIds", "store.sql_channel.get_by_ids.app_error", nil, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			for _, channel := range dbChannels {
+				channelCache.AddWithExpiresInSecs(channel.Id, channel.DeepCopy(), CHANNEL_CACHE_SEC)
+				channels = append(channels, channel)
+			}
+		}
+
+		result.Data = channels
+	})
+}
+
 func (s SqlChannelStore) GetByNameIncludeDeleted(teamId string, name string, allowFromCache bool) store.StoreChannel {
 	return s.getByName(teamId, name, true, allowFromCache)
 }
@@ -1279,6 +1412,32 @@ func (s SqlChannelStore) GetDeleted(teamId string, offset int, limit int) store.
 	})
 }
 
+// GetInactive returns the open and private channels of a team that were created and last posted in before the
+// given time.
+func (s SqlChannelStore) GetInactive(teamId string, before int64) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		channels := &model.ChannelList{}
+
+		if _, err := s.GetReplica().Select(channels, `
+			SELECT
+				*
+			FROM
+				Channels
+			WHERE
+				TeamId = :TeamId
+				AND Type IN ('O', 'P')
+				AND DeleteAt = 0
+				AND CreateAt < :Before
+				AND LastPostAt < :Before
+			ORDER BY Id`, map[string]interface{}{"TeamId": teamId, "Before": before}); err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.GetInactive", "store.sql_channel.get_inactive.app_error", nil, "teamId="+teamId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = channels
+	})
+}
+
 var CHANNEL_MEMBERS_WITH_SCHEME_SELECT_QUERY = `
 	SELECT
 		ChannelMembers.*,
@@ -1330,6 +1489,59 @@ func (s SqlChannelStore) SaveMember(member *model.ChannelMember) store.StoreChan
 	})
 }
 
+// SaveMultipleMembers saves members of the same channel in a single transaction. If any of them can't be saved,
+// none are.
+func (s SqlChannelStore) SaveMultipleMembers(members []*model.ChannelMember) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if len(members) == 0 {
+			result.Data = []*model.ChannelMember{}
+			return
+		}
+
+		for _, member := range members {
+			defer s.InvalidateAllChannelMembersForUser(member.UserId)
+		}
+
+		cr := <-s.GetFromMaster(members[0].ChannelId)
+		if cr.Err != nil {
+			result.Err = cr.Err
+			return
+		}
+
+		channel := cr.Data.(*model.Channel)
+
+		transaction, err := s.GetMaster().Begin()
+		if err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.SaveMultipleMembers", "store.sql_channel.save_member.open_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		savedMembers := make([]*model.ChannelMember, 0, len(members))
+		for _, member := range members {
+			if member.ChannelId != channel.Id {
+				transaction.Rollback()
+				result.Err = model.NewAppError("SqlChannelStore.SaveMultipleMembers", "store.sql_channel.save_multiple_members.channel_id.app_error", nil, "channel_id="+member.ChannelId, http.StatusBadRequest)
+				return
+			}
+
+			memberResult := s.saveMemberT(transaction, member, channel)
+			if memberResult.Err != nil {
+				transaction.Rollback()
+				result.Err = memberResult.Err
+				return
+			}
+			savedMembers = append(savedMembers, memberResult.Data.(*model.ChannelMember))
+		}
+
+		if err := transaction.Commit(); err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.SaveMultipleMembers", "store.sql_channel.save_member.commit_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = savedMembers
+	})
+}
+
 func (s SqlChannelStore) saveMemberT(transaction *gorp.Transaction, member *model.ChannelMember, channel *model.Channel) store.StoreResult {
 	result := store.StoreResult{}
 
@@ -1390,6 +1602,62 @@ func (s SqlChannelStore) UpdateMember(member *model.ChannelMember) store.StoreCh
 	})
 }
 
+// UpdateMultipleMembers updates existing channel members in a single transaction. If any of them can't be updated,
+// none are.
+func (s SqlChannelStore) UpdateMultipleMembers(members []*model.ChannelMember) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if len(members) == 0 {
+			result.Data = []*model.ChannelMember{}
+			return
+		}
+
+		for _, member := range members {
+			defer s.InvalidateAllChannelMembersForUser(member.UserId)
+		}
+
+		transaction, err := s.GetMaster().Begin()
+		if err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.UpdateMultipleMembers", "store.sql_channel.update_member.open_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		updatedMembers := make([]*model.ChannelMember, 0, len(members))
+		for _, member := range members {
+			member.PreUpdate()
+
+			if result.Err = member.IsValid(); result.Err != nil {
+				transaction.Rollback()
+				return
+			}
+
+			if _, err := transaction.Update(NewChannelMemberFromModel(member)); err != nil {
+				transaction.Rollback()
+				result.Err = model.NewAppError("SqlChannelStore.UpdateMultipleMembers", "store.sql_channel.update_member.app_error", nil, "channel_id="+member.ChannelId+", "+"user_id="+member.UserId+", "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			var dbMember channelMemberWithSchemeRoles
+			if err := transaction.SelectOne(&dbMember, CHANNEL_MEMBERS_WITH_SCHEME_SELECT_QUERY+"WHERE ChannelMembers.ChannelId = :ChannelId AND ChannelMembers.UserId = :UserId", map[string]interface{}{"ChannelId": member.ChannelId, "UserId": member.UserId}); err != nil {
+				transaction.Rollback()
+				if err == sql.ErrNoRows {
+					result.Err = model.NewAppError("SqlChannelStore.UpdateMultipleMembers", store.MISSING_CHANNEL_MEMBER_ERROR, nil, "channel_id="+member.ChannelId+"user_id="+member.UserId+","+err.Error(), http.StatusNotFound)
+					return
+				}
+				result.Err = model.NewAppError("SqlChannelStore.UpdateMultipleMembers", "store.sql_channel.get_member.app_error", nil, "channel_id="+member.ChannelId+"user_id="+member.UserId+","+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			updatedMembers = append(updatedMembers, dbMember.ToModel())
+		}
+
+		if err := transaction.Commit(); err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.UpdateMultipleMembers", "store.sql_channel.update_member.commit_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = updatedMembers
+	})
+}
+
 func (s SqlChannelStore) GetMembers(channelId string, offset, limit int) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		var dbMembers channelMemberWithSchemeRolesList
@@ -1403,6 +1671,142 @@ func (s SqlChannelStore) GetMembers(channelId string, offset, limit int) store.S
 	})
 }
 
+// GetMembersForTeamAfter returns a page of the members of every channel of a team, ordered by channel id and then user
+// id, starting after the given channel and user ids.
+func (s SqlChannelStore) GetMembersForTeamAfter(teamId string, afterChannelId string, afterUserId string, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var dbMembers channelMemberWithSchemeRolesList
+		_, err := s.GetReplica().Select(&dbMembers, CHANNEL_MEMBERS_WITH_SCHEME_SELECT_QUERY+`
+			WHERE
+				Channels.TeamId = :TeamId
+				AND (ChannelMembers.ChannelId > :AfterChannelId OR (ChannelMembers.ChannelId = :AfterChannelId AND ChannelMembers.UserId > :AfterUserId))
+			ORDER BY
+				ChannelMembers.ChannelId, ChannelMembers.UserId
+			LIMIT :Limit`, map[string]interface{}{"TeamId": teamId, "AfterChannelId": afterChannelId, "AfterUserId": afterUserId, "Limit": limit})
+		if err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.GetMembersForTeamAfter", "store.sql_channel.get_members.app_error", nil, "team_id="+teamId+","+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = dbMembers.ToModel()
+	})
+}
+
+// GetMembersSorted returns a page of the members of a channel in the given CHANNEL_MEMBER_SORT_* order. Ties are
+// broken by user id so that paging through the members never skips or repeats one.
+func (s SqlChannelStore) GetMembersSorted(channelId string, sort string, offset, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		query := CHANNEL_MEMBERS_WITH_SCHEME_SELECT_QUERY + `
+			INNER JOIN
+				Users ON ChannelMembers.UserId = Users.Id
+			JOIN_CLAUSE
+			WHERE
+				ChannelMembers.ChannelId = :ChannelId
+			ORDER BY ORDER_CLAUSE, ChannelMembers.UserId ASC
+			LIMIT :Limit OFFSET :Offset`
+
+		joinClause := ""
+		var orderClause string
+		switch sort {
+		case model.CHANNEL_MEMBER_SORT_USERNAME:
+			orderClause = "Users.Username ASC"
+		case model.CHANNEL_MEMBER_SORT_NICKNAME:
+			orderClause = "Users.Nickname ASC, Users.Username ASC"
+		case model.CHANNEL_MEMBER_SORT_JOINED_AT:
+			// A member can have joined and left several times, so only the latest join counts.
+			joinClause = `
+				LEFT JOIN
+					(SELECT UserId, MAX(JoinTime) AS JoinTime FROM ChannelMemberHistory WHERE ChannelId = :ChannelId GROUP BY UserId) History
+				ON ChannelMembers.UserId = History.UserId`
+			orderClause = "COALESCE(History.JoinTime, 0) ASC"
+		case model.CHANNEL_MEMBER_SORT_ROLE:
+			orderClause = "CASE WHEN ChannelMembers.SchemeAdmin = true OR ChannelMembers.Roles LIKE '%" + model.CHANNEL_ADMIN_ROLE_ID + "%' THEN 0 ELSE 1 END ASC, Users.Username ASC"
+		default:
+			result.Err = model.NewAppError("SqlChannelStore.GetMembersSorted", "store.sql_channel.get_members_sorted.sort.app_error", nil, "sort="+sort, http.StatusBadRequest)
+			return
+		}
+
+		query = strings.Replace(query, "JOIN_CLAUSE", joinClause, 1)
+		query = strings.Replace(query, "ORDER_CLAUSE", orderClause, 1)
+
+		var dbMembers channelMemberWithSchemeRolesList
+		if _, err := s.GetReplica().Select(&dbMembers, query, map[string]interface{}{"ChannelId": channelId, "Limit": limit, "Offset": offset}); err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.GetMembersSorted", "store.sql_channel.get_members.app_error", nil, "channel_id="+channelId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = dbMembers.ToModel()
+	})
+}
+
+func (s SqlChannelStore) SearchMembers(channelId string, term string, role string, allowFullNames bool, offset, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		query := CHANNEL_MEMBERS_WITH_SCHEME_SELECT_QUERY + `
+			INNER JOIN
+				Users ON ChannelMembers.UserId = Users.Id
+			WHERE
+				ChannelMembers.ChannelId = :ChannelId
+				SEARCH_CLAUSE
+				ROLE_CLAUSE
+			ORDER BY Users.Username ASC
+			LIMIT :Limit OFFSET :Offset`
+		parameters := map[string]interface{}{"ChannelId": channelId, "Limit": limit, "Offset": offset}
+
+		// These chars must be removed from the like query.
+		for _, c := range ignoreLikeSearchChar {
+			term = strings.Replace(term, c, "", -1)
+		}
+
+		// These chars must be escaped in the like query.
+		for _, c := range escapeLikeSearchChar {
+			term = strings.Replace(term, c, "*"+c, -1)
+		}
+
+		if strings.TrimSpace(term) == "" {
+			query = strings.Replace(query, "SEARCH_CLAUSE", "", 1)
+		} else {
+			searchType := USER_SEARCH_TYPE_NAMES_NO_FULL_NAME
+			if allowFullNames {
+				searchType = USER_SEARCH_TYPE_NAMES
+			}
+
+			fields := []string{}
+			for _, field := range searchType {
+				fields = append(fields, "Users."+field)
+			}
+
+			isPostgreSQL := s.DriverName() == model.DATABASE_DRIVER_POSTGRES
+			query = generateSearchQuery(query, strings.Fields(term), fields, parameters, isPostgreSQL, "")
+		}
+
+		// Members can hold the default channel roles implicitly through the scheme flags instead of the Roles column,
+		// which holds whole role names separated by spaces.
+		roleClause := "CONCAT(' ', ChannelMembers.Roles, ' ') LIKE :Role ESCAPE '*'"
+		switch role {
+		case "":
+			query = strings.Replace(query, "ROLE_CLAUSE", "", 1)
+		case model.CHANNEL_ADMIN_ROLE_ID:
+			query = strings.Replace(query, "ROLE_CLAUSE", "AND (ChannelMembers.SchemeAdmin = true OR "+roleClause+")", 1)
+		case model.CHANNEL_USER_ROLE_ID:
+			query = strings.Replace(query, "ROLE_CLAUSE", "AND (ChannelMembers.SchemeUser = true OR "+roleClause+")", 1)
+		default:
+			query = strings.Replace(query, "ROLE_CLAUSE", "AND "+roleClause, 1)
+		}
+		for _, c := range escapeLikeSearchChar {
+			role = strings.Replace(role, c, "*"+c, -1)
+		}
+		parameters["Role"] = "% " + role + " %"
+
+		var dbMembers channelMemberWithSchemeRolesList
+		if _, err := s.GetReplica().Select(&dbMembers, query, parameters); err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.SearchMembers", "store.sql_channel.search_members.app_error", nil, "channel_id="+channelId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = dbMembers.ToModel()
+	})
+}
+
 func (s SqlChannelStore) GetChannelMembersTimezones(channelId string) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		var dbMembersTimezone []map[string]string
@@ -1909,6 +2313,20 @@ func (s SqlChannelStore) AnalyticsDeletedTypeCount(teamId string, channelType st
 	})
 }
 
+func (s SqlChannelStore) AnalyticsCreatedCount(teamId string, since int64, until int64) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		query := "SELECT COUNT(Id) AS Value FROM Channels WHERE TeamId = :TeamId AND CreateAt >= :Since AND CreateAt < :Until"
+
+		v, err := s.GetReplica().SelectInt(query, map[string]interface{}{"TeamId": teamId, "Since": since, "Until": until})
+		if err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.AnalyticsCreatedCount", "store.sql_channel.analytics_created_count.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = v
+	})
+}
+
 func (s SqlChannelStore) GetMembersForUser(teamId string, userId string) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		var dbMembers channelMemberWithSchemeRolesList
@@ -2517,3 +2935,401 @@ func (s SqlChannelStore) GetChannelMembersForExport(userId string, teamId string
 		result.Data = members
 	})
 }
+
+// MoveToTeam moves a channel to another team, giving it the given name there, and moves the webhooks of the channel
+// along with it. Members of the channel who aren't members of the team are removed from it in the same transaction if
+// removeMembersNotInTeam is set, and otherwise the channel isn't moved, so that no one can join the channel without
+// being in the team while it's moved, and no one is removed from a channel that then fails to move. The removed
+// members are returned.
+func (s SqlChannelStore) MoveToTeam(channel *model.Channel, teamId string, name string, removeMembersNotInTeam bool) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		oldTeamId, oldName := channel.TeamId, channel.Name
+		defer s.InvalidateChannel(channel.Id)
+		defer s.InvalidateChannelByName(oldTeamId, oldName)
+
+		transaction, err := s.GetMaster().Begin()
+		if err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.MoveToTeam", "store.sql_channel.move_to_team.open_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var dbMembers channelMemberWithSchemeRolesList
+		if _, err := transaction.Select(&dbMembers, CHANNEL_MEMBERS_WITH_SCHEME_SELECT_QUERY+`
+			WHERE
+				ChannelMembers.ChannelId = :ChannelId
+				AND ChannelMembers.UserId NOT IN (
+					SELECT
+						UserId
+					FROM
+						TeamMembers
+					WHERE
+						TeamId = :TeamId
+						AND DeleteAt = 0
+				)`, map[string]interface{}{"ChannelId": channel.Id, "TeamId": teamId}); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewAppError("SqlChannelStore.MoveToTeam", "store.sql_channel.move_to_team.app_error", nil, "channel_id="+channel.Id+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		removedMembers := dbMembers.ToModel()
+
+		if len(*removedMembers) > 0 && !removeMembersNotInTeam {
+			transaction.Rollback()
+			result.Err = model.NewAppError("SqlChannelStore.MoveToTeam", "store.sql_channel.move_to_team.members_not_in_team.app_error", map[string]interface{}{"Count": len(*removedMembers)}, "channel_id="+channel.Id+", team_id="+teamId, http.StatusBadRequest)
+			return
+		}
+
+		leaveTime := model.GetMillis()
+		for _, member := range *removedMembers {
+			params := map[string]interface{}{"ChannelId": channel.Id, "UserId": member.UserId, "LeaveTime": leaveTime}
+
+			if _, err := transaction.Exec("DELETE FROM ChannelMembers WHERE ChannelId = :ChannelId AND UserId = :UserId", params); err != nil {
+				transaction.Rollback()
+				result.Err = model.NewAppError("SqlChannelStore.MoveToTeam", "store.sql_channel.move_to_team.app_error", nil, "channel_id="+channel.Id+", user_id="+member.UserId+", "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if _, err := transaction.Exec("UPDATE ChannelMemberHistory SET LeaveTime = :LeaveTime WHERE UserId = :UserId AND ChannelId = :ChannelId AND LeaveTime IS NULL", params); err != nil {
+				transaction.Rollback()
+				result.Err = model.NewAppError("SqlChannelStore.MoveToTeam", "store.sql_channel.move_to_team.app_error", nil, "channel_id="+channel.Id+", user_id="+member.UserId+", "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		channel.TeamId = teamId
+		channel.Name = name
+
+		*result = s.updateChannelT(transaction, channel)
+		if result.Err != nil {
+			transaction.Rollback()
+			channel.TeamId, channel.Name = oldTeamId, oldName
+			return
+		}
+
+		if err := s.upsertPublicChannelT(transaction, channel); err != nil {
+			transaction.Rollback()
+			channel.TeamId, channel.Name = oldTeamId, oldName
+			result.Err = model.NewAppError("SqlChannelStore.MoveToTeam", "store.sql_channel.update.upsert_public_channel.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for _, table := range []string{"IncomingWebhooks", "OutgoingWebhooks"} {
+			if _, err := transaction.Exec("UPDATE "+table+" SET TeamId = :TeamId, UpdateAt = :UpdateAt WHERE ChannelId = :ChannelId", map[string]interface{}{"TeamId": teamId, "UpdateAt": channel.UpdateAt, "ChannelId": channel.Id}); err != nil {
+				transaction.Rollback()
+				channel.TeamId, channel.Name = oldTeamId, oldName
+				result.Err = model.NewAppError("SqlChannelStore.MoveToTeam", "store.sql_channel.move_to_team.app_error", nil, "channel_id="+channel.Id+", "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if err := transaction.Commit(); err != nil {
+			channel.TeamId, channel.Name = oldTeamId, oldName
+			result.Err = model.NewAppError("SqlChannelStore.MoveToTeam", "store.sql_channel.move_to_team.commit_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = removedMembers
+	})
+}
+
+// MergeInto moves the posts and webhooks of a channel into another, which may be in another team, adding the posts to
+// its message count. The members of the channel aren't changed, since adding them to the other channel is up to the
+// app. The number of posts moved is returned.
+func (s SqlChannelStore) MergeInto(source *model.Channel, target *model.Channel) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		defer s.InvalidateChannel(target.Id)
+
+		transaction, err := s.GetMaster().Begin()
+		if err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.MergeInto", "store.sql_channel.merge_into.open_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		posts, err := transaction.Exec("UPDATE Posts SET ChannelId = :TargetId WHERE ChannelId = :SourceId", map[string]interface{}{"TargetId": target.Id, "SourceId": source.Id})
+		if err != nil {
+			transaction.Rollback()
+			result.Err = model.NewAppError("SqlChannelStore.MergeInto", "store.sql_channel.merge_into.app_error", nil, "source_id="+source.Id+", target_id="+target.Id+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		count, err := posts.RowsAffected()
+		if err != nil {
+			transaction.Rollback()
+			result.Err = model.NewAppError("SqlChannelStore.MergeInto", "store.sql_channel.merge_into.app_error", nil, "source_id="+source.Id+", target_id="+target.Id+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		updateAt := model.GetMillis()
+		for _, table := range []string{"IncomingWebhooks", "OutgoingWebhooks"} {
+			if _, err := transaction.Exec("UPDATE "+table+" SET ChannelId = :TargetId, TeamId = :TeamId, UpdateAt = :UpdateAt WHERE ChannelId = :SourceId", map[string]interface{}{"TargetId": target.Id, "TeamId": target.TeamId, "UpdateAt": updateAt, "SourceId": source.Id}); err != nil {
+				transaction.Rollback()
+				result.Err = model.NewAppError("SqlChannelStore.MergeInto", "store.sql_channel.merge_into.app_error", nil, "source_id="+source.Id+", target_id="+target.Id+", "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if _, err := transaction.Exec(`
+			UPDATE
+				Channels
+			SET
+				TotalMsgCount = TotalMsgCount + :Count,
+				LastPostAt = GREATEST(LastPostAt, :LastPostAt),
+				UpdateAt = :UpdateAt
+			WHERE
+				Id = :TargetId`, map[string]interface{}{"Count": count, "LastPostAt": source.LastPostAt, "UpdateAt": updateAt, "TargetId": target.Id}); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewAppError("SqlChannelStore.MergeInto", "store.sql_channel.merge_into.app_error", nil, "source_id="+source.Id+", target_id="+target.Id+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := transaction.Commit(); err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.MergeInto", "store.sql_channel.merge_into.commit_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = count
+	})
+}
+
+// GetMsgCountsForRepair returns the message counts of channels ordered by id, along with the number of posts that
+// each should have counted and the number of their members whose counts are impossible. With a channel id only that
+// channel is included, and with a team id only the channels of that team. The counts are read from the master, since
+// a lagging replica would report discrepancies that don't exist.
+func (s SqlChannelStore) GetMsgCountsForRepair(teamId string, channelId string, afterId string, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		props := map[string]interface{}{"AfterId": afterId, "Limit": limit}
+
+		scopeQuery := ""
+		if channelId != "" {
+			scopeQuery = "AND c.Id = :ChannelId"
+			props["ChannelId"] = channelId
+		} else if teamId != "" {
+			scopeQuery = "AND c.TeamId = :TeamId"
+			props["TeamId"] = teamId
+		}
+
+		var counts []*model.ChannelMsgCounts
+		if _, err := s.GetMaster().Select(&counts, `
+			SELECT
+				c.Id ChannelId,
+				c.TotalMsgCount TotalMsgCount,
+				(
+					SELECT
+						COUNT(*)
+					FROM
+						Posts p
+					WHERE
+						p.ChannelId = c.Id
+						AND p.Type NOT IN (`+uncountedPostTypesQuery(props)+`)
+				) ActualMsgCount,
+				(
+					SELECT
+						COUNT(*)
+					FROM
+						ChannelMembers cm
+					WHERE
+						cm.ChannelId = c.Id
+						AND (cm.MsgCount < 0 OR cm.MsgCount > c.TotalMsgCount OR cm.MentionCount < 0)
+				) InvalidMemberCounts
+			FROM
+				Channels c
+			WHERE
+				c.Id > :AfterId
+				`+scopeQuery+`
+			ORDER BY
+				c.Id
+			LIMIT :Limit`, props); err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.GetMsgCountsForRepair", "store.sql_channel.get_msg_counts_for_repair.app_error", nil, "team_id="+teamId+", channel_id="+channelId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = counts
+	})
+}
+
+// RepairMsgCounts sets the message count of a channel to the number of posts that it should have counted, shifting the
+// counts of its members by the same amount so that their unread counts are kept, as far as they're possible. Member
+// counts that are still impossible are clamped. The counts are checked again while the channel is locked, so that the
+// counts given only pick out the channels worth repairing, and false is returned if the channel no longer needs it.
+//
+// Posts are saved before they're counted, so only those up to the channel's LastPostAt, which is moved along as
+// they're counted, are taken to be counted already. The members are then updated REPAIR_MSG_COUNTS_MEMBER_BATCH_SIZE
+// at a time, leaving out any who view the channel in the meantime, since their counts are already up to date.
+func (s SqlChannelStore) RepairMsgCounts(counts *model.ChannelMsgCounts) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		defer s.InvalidateChannel(counts.ChannelId)
+
+		transaction, err := s.GetMaster().Begin()
+		if err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.RepairMsgCounts", "store.sql_channel.repair_msg_counts.open_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		props := map[string]interface{}{"ChannelId": counts.ChannelId}
+
+		var channel model.Channel
+		if err := transaction.SelectOne(&channel, "SELECT * FROM Channels WHERE Id = :ChannelId FOR UPDATE", props); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewAppError("SqlChannelStore.RepairMsgCounts", "store.sql_channel.repair_msg_counts.app_error", nil, "channel_id="+counts.ChannelId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		props["LastPostAt"] = channel.LastPostAt
+		actualMsgCount, err := transaction.SelectInt(`
+			SELECT
+				COUNT(*)
+			FROM
+				Posts
+			WHERE
+				ChannelId = :ChannelId
+				AND CreateAt <= :LastPostAt
+				AND Type NOT IN (`+uncountedPostTypesQuery(props)+`)`, props)
+		if err != nil {
+			transaction.Rollback()
+			result.Err = model.NewAppError("SqlChannelStore.RepairMsgCounts", "store.sql_channel.repair_msg_counts.app_error", nil, "channel_id="+counts.ChannelId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		delta := actualMsgCount - channel.TotalMsgCount
+		props["ActualMsgCount"] = actualMsgCount
+		props["Delta"] = delta
+		props["RepairedAt"] = model.GetMillis()
+
+		memberQuery := "MsgCount < 0 OR MsgCount > :ActualMsgCount OR MentionCount < 0"
+		if delta != 0 {
+			memberQuery = "1 = 1"
+		}
+
+		if delta == 0 {
+			if invalid, err := transaction.SelectInt("SELECT COUNT(*) FROM ChannelMembers WHERE ChannelId = :ChannelId AND ("+memberQuery+")", props); err != nil {
+				transaction.Rollback()
+				result.Err = model.NewAppError("SqlChannelStore.RepairMsgCounts", "store.sql_channel.repair_msg_counts.app_error", nil, "channel_id="+counts.ChannelId+", "+err.Error(), http.StatusInternalServerError)
+				return
+			} else if invalid == 0 {
+				transaction.Rollback()
+				result.Data = false
+				return
+			}
+		} else if _, err := transaction.Exec("UPDATE Channels SET TotalMsgCount = :ActualMsgCount WHERE Id = :ChannelId", props); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewAppError("SqlChannelStore.RepairMsgCounts", "store.sql_channel.repair_msg_counts.app_error", nil, "channel_id="+counts.ChannelId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := transaction.Commit(); err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.RepairMsgCounts", "store.sql_channel.repair_msg_counts.commit_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		props["AfterUserId"] = ""
+		props["Limit"] = REPAIR_MSG_COUNTS_MEMBER_BATCH_SIZE
+		for {
+			var userIds []string
+			if _, err := s.GetMaster().Select(&userIds, `
+				SELECT
+					UserId
+				FROM
+					ChannelMembers
+				WHERE
+					ChannelId = :ChannelId
+					AND UserId > :AfterUserId
+					AND LastUpdateAt <= :RepairedAt
+					AND (`+memberQuery+`)
+				ORDER BY
+					UserId
+				LIMIT :Limit`, props); err != nil {
+				result.Err = model.NewAppError("SqlChannelStore.RepairMsgCounts", "store.sql_channel.repair_msg_counts.app_error", nil, "channel_id="+counts.ChannelId+", "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if len(userIds) == 0 {
+				break
+			}
+
+			userIdKeys := make([]string, len(userIds))
+			for i, userId := range userIds {
+				key := "UserId" + strconv.Itoa(i)
+				userIdKeys[i] = ":" + key
+				props[key] = userId
+			}
+
+			if _, err := s.GetMaster().Exec(`
+				UPDATE
+					ChannelMembers
+				SET
+					MsgCount = LEAST(GREATEST(MsgCount + :Delta, 0), :ActualMsgCount),
+					MentionCount = GREATEST(MentionCount, 0),
+					LastUpdateAt = :RepairedAt
+				WHERE
+					ChannelId = :ChannelId
+					AND UserId IN (`+strings.Join(userIdKeys, ", ")+`)
+					AND LastUpdateAt <= :RepairedAt`, props); err != nil {
+				result.Err = model.NewAppError("SqlChannelStore.RepairMsgCounts", "store.sql_channel.repair_msg_counts.app_error", nil, "channel_id="+counts.ChannelId+", "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			props["AfterUserId"] = userIds[len(userIds)-1]
+		}
+
+		result.Data = true
+	})
+}
+
+// GetOrphanedMembers returns the channel members whose channel or user doesn't exist, ordered by channel id and then
+// user id, starting after the member with the given ids.
+func (s SqlChannelStore) GetOrphanedMembers(afterChannelId string, afterUserId string, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var members []*model.OrphanedChannelMember
+		if _, err := s.GetReplica().Select(&members, `
+			SELECT
+				ChannelMembers.ChannelId ChannelId,
+				ChannelMembers.UserId UserId,
+				Channels.Id IS NOT NULL ChannelExists,
+				Users.Id IS NOT NULL UserExists
+			FROM
+				ChannelMembers
+				LEFT JOIN Channels ON Channels.Id = ChannelMembers.ChannelId
+				LEFT JOIN Users ON Users.Id = ChannelMembers.UserId
+			WHERE
+				(Channels.Id IS NULL OR Users.Id IS NULL)
+				AND (
+					ChannelMembers.ChannelId > :AfterChannelId
+					OR (ChannelMembers.ChannelId = :AfterChannelId AND ChannelMembers.UserId > :AfterUserId)
+				)
+			ORDER BY
+				ChannelMembers.ChannelId, ChannelMembers.UserId
+			LIMIT :Limit`, map[string]interface{}{"AfterChannelId": afterChannelId, "AfterUserId": afterUserId, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.GetOrphanedMembers", "store.sql_channel.get_orphaned_members.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = members
+	})
+}
+
+// RemoveOrphanedMember deletes a channel member if its channel or user doesn't exist, checking again as it does so in
+// case either was created since the member was found. It returns whether the member was deleted.
+func (s SqlChannelStore) RemoveOrphanedMember(channelId string, userId string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		sqlResult, err := s.GetMaster().Exec(`
+			DELETE FROM
+				ChannelMembers
+			WHERE
+				ChannelId = :ChannelId
+				AND UserId = :UserId
+				AND (
+					NOT EXISTS (SELECT 1 FROM Channels WHERE Channels.Id = :ChannelId)
+					OR NOT EXISTS (SELECT 1 FROM Users WHERE Users.Id = :UserId)
+				)`, map[string]interface{}{"ChannelId": channelId, "UserId": userId})
+		if err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.RemoveOrphanedMember", "store.sql_channel.remove_orphaned_member.app_error", nil, "channel_id="+channelId+", user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rowsAffected, err := sqlResult.RowsAffected()
+		if err != nil {
+			result.Err = model.NewAppError("SqlChannelStore.RemoveOrphanedMember", "store.sql_channel.remove_orphaned_member.app_error", nil, "channel_id="+channelId+", user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = rowsAffected > 0
+	})
+}
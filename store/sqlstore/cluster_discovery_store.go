@@ -23,6 +23,7 @@ func NewSqlClusterDiscoveryStore(sqlStore SqlStore) store.ClusterDiscoveryStore
 		table.ColMap("Type").SetMaxSize(64)
 		table.ColMap("ClusterName").SetMaxSize(64)
 		table.ColMap("Hostname").SetMaxSize(512)
+		table.ColMap("Tags").SetMaxSize(512)
 	}
 
 	return s
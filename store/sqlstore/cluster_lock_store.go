@@ -0,0 +1,140 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package sqlstore
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/store"
+)
+
+type SqlClusterLockStore struct {
+	SqlStore
+}
+
+func NewSqlClusterLockStore(sqlStore SqlStore) store.ClusterLockStore {
+	s := &SqlClusterLockStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.ClusterLock{}, "ClusterLocks").SetKeys(false, "Name")
+		table.ColMap("Name").SetMaxSize(model.CLUSTER_LOCK_NAME_MAX_LENGTH)
+		table.ColMap("Owner").SetMaxSize(26)
+	}
+
+	return s
+}
+
+func (s SqlClusterLockStore) CreateIndexesIfNotExists() {
+}
+
+// Acquire takes the lock if nobody holds it, if it has expired or if it's already held by the same owner, in which
+// case its expiry is extended. The result's Data is true if the lock was acquired.
+func (s SqlClusterLockStore) Acquire(lock *model.ClusterLock) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if result.Err = lock.IsValid(); result.Err != nil {
+			return
+		}
+
+		sqlResult, err := s.GetMaster().Exec(`
+			UPDATE
+				ClusterLocks
+			SET
+				Owner = :Owner,
+				ExpireAt = :ExpireAt
+			WHERE
+				Name = :Name
+				AND (Owner = :Owner OR ExpireAt <= :Now)`,
+			map[string]interface{}{"Name": lock.Name, "Owner": lock.Owner, "ExpireAt": lock.ExpireAt, "Now": model.GetMillis()})
+		if err != nil {
+			result.Err = model.NewAppError("SqlClusterLockStore.Acquire", "store.sql_cluster_lock.acquire.app_error", nil, "name="+lock.Name+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if rows, _ := sqlResult.RowsAffected(); rows == 1 {
+			result.Data = true
+			return
+		}
+
+		if err := s.GetMaster().Insert(lock); err != nil {
+			if !IsUniqueConstraintError(err, []string{"Name", "clusterlocks_pkey", "PRIMARY"}) {
+				result.Err = model.NewAppError("SqlClusterLockStore.Acquire", "store.sql_cluster_lock.acquire.app_error", nil, "name="+lock.Name+", "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			// Either another owner holds the lock or the update above didn't change anything because the same owner
+			// re-acquired it with an unchanged expiry.
+			owner, err := s.GetMaster().SelectStr("SELECT Owner FROM ClusterLocks WHERE Name = :Name AND ExpireAt > :Now", map[string]interface{}{"Name": lock.Name, "Now": model.GetMillis()})
+			if err != nil {
+				result.Err = model.NewAppError("SqlClusterLockStore.Acquire", "store.sql_cluster_lock.acquire.app_error", nil, "name="+lock.Name+", "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			result.Data = owner == lock.Owner
+			return
+		}
+
+		result.Data = true
+	})
+}
+
+// Renew extends the expiry of a lock that's still held by the same owner. The result's Data is false if the lock
+// expired or was taken by somebody else in the meantime.
+func (s SqlClusterLockStore) Renew(lock *model.ClusterLock) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if result.Err = lock.IsValid(); result.Err != nil {
+			return
+		}
+
+		if _, err := s.GetMaster().Exec(`
+			UPDATE
+				ClusterLocks
+			SET
+				ExpireAt = :ExpireAt
+			WHERE
+				Name = :Name
+				AND Owner = :Owner
+				AND ExpireAt > :Now`,
+			map[string]interface{}{"Name": lock.Name, "Owner": lock.Owner, "ExpireAt": lock.ExpireAt, "Now": model.GetMillis()}); err != nil {
+			result.Err = model.NewAppError("SqlClusterLockStore.Renew", "store.sql_cluster_lock.renew.app_error", nil, "name="+lock.Name+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// MySQL doesn't count rows that are updated to the values they already had, so check the owner instead of
+		// relying on the number of affected rows.
+		count, err := s.GetMaster().SelectInt("SELECT COUNT(*) FROM ClusterLocks WHERE Name = :Name AND Owner = :Owner AND ExpireAt = :ExpireAt", map[string]interface{}{"Name": lock.Name, "Owner": lock.Owner, "ExpireAt": lock.ExpireAt})
+		if err != nil {
+			result.Err = model.NewAppError("SqlClusterLockStore.Renew", "store.sql_cluster_lock.renew.app_error", nil, "name="+lock.Name+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = count == 1
+	})
+}
+
+// Release frees up the lock if it's held by the given owner.
+func (s SqlClusterLockStore) Release(name string, owner string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if _, err := s.GetMaster().Exec("DELETE FROM ClusterLocks WHERE Name = :Name AND Owner = :Owner", map[string]interface{}{"Name": name, "Owner": owner}); err != nil {
+			result.Err = model.NewAppError("SqlClusterLockStore.Release", "store.sql_cluster_lock.release.app_error", nil, "name="+name+", "+err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func (s SqlClusterLockStore) Get(name string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var lock model.ClusterLock
+		if err := s.GetMaster().SelectOne(&lock, "SELECT * FROM ClusterLocks WHERE Name = :Name", map[string]interface{}{"Name": name}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlClusterLockStore.Get", "store.sql_cluster_lock.get.missing.app_error", nil, "name="+name, http.StatusNotFound)
+			} else {
+				result.Err = model.NewAppError("SqlClusterLockStore.Get", "store.sql_cluster_lock.get.app_error", nil, "name="+name+", "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		result.Data = &lock
+	})
+}
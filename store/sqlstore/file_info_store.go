@@ -6,6 +6,8 @@ package sqlstore
 import (
 	"database/sql"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/mattermost/mattermost-server/einterfaces"
 	"github.com/mattermost/mattermost-server/model"
@@ -49,6 +51,8 @@ func NewSqlFileInfoStore(sqlStore SqlStore, metrics einterfaces.MetricsInterface
 		table.ColMap("Name").SetMaxSize(256)
 		table.ColMap("Extension").SetMaxSize(64)
 		table.ColMap("MimeType").SetMaxSize(256)
+		table.ColMap("StorageTier").SetMaxSize(16)
+		table.ColMap("Checksum").SetMaxSize(64)
 	}
 
 	return s
@@ -201,6 +205,107 @@ func (fs SqlFileInfoStore) GetForUser(userId string) store.StoreChannel {
 	})
 }
 
+// Search returns the files whose names contain the search terms and that were posted in channels of the team, or
+// in direct and group messages, that the user belongs to. Unlike post search, the in: and from: filters of the
+// params must already have been resolved to channel and user ids.
+func (fs SqlFileInfoStore) Search(teamId string, userId string, params *model.SearchParams, offset int, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		queryParams := map[string]interface{}{
+			"TeamId": teamId,
+			"UserId": userId,
+			"Offset": offset,
+			"Limit":  limit,
+		}
+
+		deletedQueryPart := "AND DeleteAt = 0"
+		if params.IncludeDeletedChannels {
+			deletedQueryPart = ""
+		}
+
+		channelFilter := ""
+		if len(params.InChannels) > 0 {
+			channelFilter = "AND Id IN (" + fileSearchInClause("InChannel", params.InChannels, queryParams) + ")"
+		}
+
+		userFilter := ""
+		if len(params.FromUsers) > 0 {
+			userFilter = "AND FileInfo.CreatorId IN (" + fileSearchInClause("FromUser", params.FromUsers, queryParams) + ")"
+		}
+
+		var termClauses []string
+		for i, term := range strings.Fields(params.Terms) {
+			term = strings.Trim(term, `"*`)
+			if term == "" {
+				continue
+			}
+			for _, c := range escapeLikeSearchChar {
+				term = strings.Replace(term, c, "*"+c, -1)
+			}
+
+			paramName := "Term" + strconv.Itoa(i)
+			termClauses = append(termClauses, "LOWER(FileInfo.Name) LIKE :"+paramName+" ESCAPE '*'")
+			queryParams[paramName] = "%" + strings.ToLower(term) + "%"
+		}
+
+		termFilter := ""
+		if len(termClauses) > 0 {
+			operator := " AND "
+			if params.OrTerms {
+				operator = " OR "
+			}
+			termFilter = "AND (" + strings.Join(termClauses, operator) + ")"
+		}
+
+		var infos []*model.FileInfo
+		if _, err := fs.GetSearchReplica().Select(&infos, `
+			SELECT
+				FileInfo.*
+			FROM
+				FileInfo
+				INNER JOIN Posts ON Posts.Id = FileInfo.PostId
+			WHERE
+				FileInfo.DeleteAt = 0
+				AND Posts.DeleteAt = 0
+				AND Posts.ChannelId IN (
+					SELECT
+						Id
+					FROM
+						Channels,
+						ChannelMembers
+					WHERE
+						Id = ChannelId
+						AND (TeamId = :TeamId OR TeamId = '')
+						AND UserId = :UserId
+						`+deletedQueryPart+`
+						`+channelFilter+`)
+				`+userFilter+`
+				`+searchCreateDateClause(params, "FileInfo.CreateAt", queryParams)+`
+				`+termFilter+`
+			ORDER BY
+				FileInfo.CreateAt DESC
+			LIMIT :Limit
+			OFFSET :Offset`, queryParams); err != nil {
+			result.Err = model.NewAppError("SqlFileInfoStore.Search", "store.sql_file_info.search.app_error", nil, "team_id="+teamId+", user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = infos
+	})
+}
+
+func fileSearchInClause(prefix string, values []string, queryParams map[string]interface{}) string {
+	clause := ""
+	for i, value := range values {
+		paramName := prefix + strconv.Itoa(i)
+		if i > 0 {
+			clause += ", "
+		}
+		clause += ":" + paramName
+		queryParams[paramName] = value
+	}
+	return clause
+}
+
 func (fs SqlFileInfoStore) AttachToPost(fileId, postId, creatorId string) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		sqlResult, err := fs.GetMaster().Exec(
@@ -303,3 +408,202 @@ func (s SqlFileInfoStore) PermanentDeleteByUser(userId string) store.StoreChanne
 		}
 	})
 }
+
+// GetOrphaned returns the FileInfos that aren't attached to a post that still exists, which are those that were never
+// attached to one, those deleted along with their post and those whose post was deleted. Only FileInfos created, and
+// deleted, before the given time are returned so that files that are still being uploaded or posted aren't included.
+// They're ordered by Id, starting after afterId.
+func (fs SqlFileInfoStore) GetOrphaned(before int64, afterId string, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var infos []*model.FileInfo
+
+		if _, err := fs.GetReplica().Select(&infos,
+			`SELECT
+				FileInfo.*
+			FROM
+				FileInfo
+				LEFT JOIN Posts ON Posts.Id = FileInfo.PostId
+			WHERE
+				FileInfo.Id > :AfterId
+				AND FileInfo.CreateAt < :Before
+				AND (
+					Posts.Id IS NULL
+					OR (FileInfo.DeleteAt > 0 AND FileInfo.DeleteAt < :Before)
+					OR (Posts.DeleteAt > 0 AND Posts.DeleteAt < :Before)
+				)
+			ORDER BY
+				FileInfo.Id
+			LIMIT :Limit`, map[string]interface{}{"Before": before, "AfterId": afterId, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlFileInfoStore.GetOrphaned",
+				"store.sql_file_info.get_orphaned.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = infos
+		}
+	})
+}
+
+// GetExistingIds returns which of the given ids belong to a FileInfo, including ones that have been deleted.
+func (fs SqlFileInfoStore) GetExistingIds(ids []string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if len(ids) == 0 {
+			result.Data = []string{}
+			return
+		}
+
+		keys, params := MapStringsToQueryParams(ids, "FileId")
+
+		var existingIds []string
+		if _, err := fs.GetReplica().Select(&existingIds, `SELECT Id FROM FileInfo WHERE Id IN `+keys, params); err != nil {
+			result.Err = model.NewAppError("SqlFileInfoStore.GetExistingIds",
+				"store.sql_file_info.get_existing_ids.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = existingIds
+		}
+	})
+}
+
+// GetForColdStorage returns the FileInfos for files attached to posts that were uploaded before the given time and
+// are still in the main file store, ordered by id.
+func (fs SqlFileInfoStore) GetForColdStorage(before int64, afterId string, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var infos []*model.FileInfo
+
+		if _, err := fs.GetReplica().Select(&infos,
+			`SELECT
+				*
+			FROM
+				FileInfo
+			WHERE
+				Id > :AfterId
+				AND CreateAt < :Before
+				AND DeleteAt = 0
+				AND PostId != ''
+				AND StorageTier = :StorageTier
+			ORDER BY
+				Id
+			LIMIT :Limit`, map[string]interface{}{"Before": before, "AfterId": afterId, "StorageTier": model.FILE_INFO_STORAGE_TIER_HOT, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlFileInfoStore.GetForColdStorage",
+				"store.sql_file_info.get_for_cold_storage.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = infos
+		}
+	})
+}
+
+func (fs SqlFileInfoStore) UpdateStorageTier(fileId string, storageTier string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if _, err := fs.GetMaster().Exec(
+			`UPDATE
+				FileInfo
+			SET
+				StorageTier = :StorageTier,
+				UpdateAt = :UpdateAt
+			WHERE
+				Id = :Id`, map[string]interface{}{"StorageTier": storageTier, "UpdateAt": model.GetMillis(), "Id": fileId}); err != nil {
+			result.Err = model.NewAppError("SqlFileInfoStore.UpdateStorageTier",
+				"store.sql_file_info.update_storage_tier.app_error", nil, "file_id="+fileId+", err="+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = fileId
+		}
+	})
+}
+
+// GetBatchForIntegrityScan returns the FileInfos of the files that haven't been deleted, ordered by id.
+func (fs SqlFileInfoStore) GetBatchForIntegrityScan(afterId string, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var infos []*model.FileInfo
+
+		if _, err := fs.GetReplica().Select(&infos,
+			`SELECT
+				*
+			FROM
+				FileInfo
+			WHERE
+				Id > :AfterId
+				AND DeleteAt = 0
+			ORDER BY
+				Id
+			LIMIT :Limit`, map[string]interface{}{"AfterId": afterId, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlFileInfoStore.GetBatchForIntegrityScan",
+				"store.sql_file_info.get_batch_for_integrity_scan.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = infos
+		}
+	})
+}
+
+// CountByPath returns the number of FileInfos, including deleted ones, whose files are stored at the given path. A
+// copied FileInfo shares the files of the original rather than having its own.
+func (fs SqlFileInfoStore) CountByPath(path string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		count, err := fs.GetMaster().SelectInt("SELECT COUNT(*) FROM FileInfo WHERE Path = :Path", map[string]interface{}{"Path": path})
+		if err != nil {
+			result.Err = model.NewAppError("SqlFileInfoStore.CountByPath",
+				"store.sql_file_info.count_by_path.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = count
+		}
+	})
+}
+
+// GetStorageSizeForChannel returns the number of bytes taken up by the files uploaded to a channel, which are the ones
+// stored below its directory. Files shared by copies of a FileInfo are only counted once.
+func (fs SqlFileInfoStore) GetStorageSizeForChannel(channelId string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		size, err := fs.GetReplica().SelectInt(`
+			SELECT
+				COALESCE(SUM(Size), 0)
+			FROM (
+				SELECT
+					Path,
+					MAX(Size) AS Size
+				FROM
+					FileInfo
+				WHERE
+					Path LIKE :Pattern
+				GROUP BY
+					Path
+			) AS ChannelFiles`, map[string]interface{}{"Pattern": "%/channels/" + channelId + "/%"})
+		if err != nil {
+			result.Err = model.NewAppError("SqlFileInfoStore.GetStorageSizeForChannel",
+				"store.sql_file_info.get_storage_size_for_channel.app_error", nil, "channel_id="+channelId+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = size
+		}
+	})
+}
+
+// GetBatchForStorageUsage returns the FileInfos created before a time, including deleted ones since their files are
+// kept until they're cleaned up, whose ids come after afterId in order. Copies that share the files of another
+// FileInfo are left out, so that each stored file is only returned once.
+func (fs SqlFileInfoStore) GetBatchForStorageUsage(afterId string, before int64, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var infos []*model.FileInfo
+
+		if _, err := fs.GetReplica().Select(&infos,
+			`SELECT
+				*
+			FROM
+				FileInfo
+			WHERE
+				Id > :AfterId
+				AND CreateAt < :Before
+				AND NOT EXISTS (
+					SELECT
+						1
+					FROM
+						FileInfo Original
+					WHERE
+						Original.Path = FileInfo.Path
+						AND Original.Id < FileInfo.Id
+				)
+			ORDER BY
+				Id
+			LIMIT :Limit`, map[string]interface{}{"AfterId": afterId, "Before": before, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlFileInfoStore.GetBatchForStorageUsage",
+				"store.sql_file_info.get_batch_for_storage_usage.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = infos
+		}
+	})
+}
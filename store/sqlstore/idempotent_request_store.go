@@ -0,0 +1,112 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package sqlstore
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/store"
+)
+
+type SqlIdempotentRequestStore struct {
+	SqlStore
+}
+
+func NewSqlIdempotentRequestStore(sqlStore SqlStore) store.IdempotentRequestStore {
+	s := &SqlIdempotentRequestStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.IdempotentRequest{}, "IdempotentRequests").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(model.IDEMPOTENT_REQUEST_ID_LENGTH)
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("Method").SetMaxSize(16)
+		table.ColMap("Path").SetMaxSize(model.IDEMPOTENT_REQUEST_PATH_MAX_LENGTH)
+		table.ColMap("BodyHash").SetMaxSize(64)
+		table.ColMap("Header").SetMaxSize(4000)
+	}
+
+	return s
+}
+
+func (s SqlIdempotentRequestStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_idempotentrequests_create_at", "IdempotentRequests", "CreateAt")
+}
+
+// Save records a request that's starting to be handled. The result's Data is false if a request with the same id has
+// already been recorded, which is how concurrent requests with the same idempotency key are told apart across the
+// cluster.
+func (s SqlIdempotentRequestStore) Save(request *model.IdempotentRequest) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if result.Err = request.IsValid(); result.Err != nil {
+			return
+		}
+
+		if err := s.GetMaster().Insert(request); err != nil {
+			if IsUniqueConstraintError(err, []string{"Id", "idempotentrequests_pkey", "PRIMARY"}) {
+				result.Data = false
+				return
+			}
+
+			result.Err = model.NewAppError("SqlIdempotentRequestStore.Save", "store.sql_idempotent_request.save.app_error", nil, "id="+request.Id+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = true
+	})
+}
+
+func (s SqlIdempotentRequestStore) Get(id string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var request model.IdempotentRequest
+		if err := s.GetMaster().SelectOne(&request, "SELECT * FROM IdempotentRequests WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlIdempotentRequestStore.Get", "store.sql_idempotent_request.get.missing.app_error", nil, "id="+id, http.StatusNotFound)
+			} else {
+				result.Err = model.NewAppError("SqlIdempotentRequestStore.Get", "store.sql_idempotent_request.get.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		result.Data = &request
+	})
+}
+
+// Complete saves the response to a request that was in progress so that it can be sent again when the request is
+// retried.
+func (s SqlIdempotentRequestStore) Complete(request *model.IdempotentRequest) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if _, err := s.GetMaster().Exec(`
+			UPDATE
+				IdempotentRequests
+			SET
+				StatusCode = :StatusCode,
+				Header = :Header,
+				Body = :Body
+			WHERE
+				Id = :Id
+				AND StatusCode = 0`,
+			map[string]interface{}{"Id": request.Id, "StatusCode": request.StatusCode, "Header": request.Header, "Body": request.Body}); err != nil {
+			result.Err = model.NewAppError("SqlIdempotentRequestStore.Complete", "store.sql_idempotent_request.complete.app_error", nil, "id="+request.Id+", "+err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func (s SqlIdempotentRequestStore) Delete(id string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if _, err := s.GetMaster().Exec("DELETE FROM IdempotentRequests WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewAppError("SqlIdempotentRequestStore.Delete", "store.sql_idempotent_request.delete.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func (s SqlIdempotentRequestStore) Cleanup() {
+	mlog.Debug("Cleaning up idempotent request store.")
+	deltime := model.GetMillis() - model.IDEMPOTENT_REQUEST_EXPIRY_TIME
+	if _, err := s.GetMaster().Exec("DELETE FROM IdempotentRequests WHERE CreateAt < :DelTime", map[string]interface{}{"DelTime": deltime}); err != nil {
+		mlog.Error("Unable to cleanup idempotent request store.", mlog.Err(err))
+	}
+}
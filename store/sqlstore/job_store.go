@@ -137,6 +137,28 @@ func (jss SqlJobStore) UpdateStatusOptimistically(id string, currentStatus strin
 	})
 }
 
+// UpdateLastActivityAt marks a job as still being worked on, provided that it has the given status, without changing
+// anything else about it. The result is whether the job was updated.
+func (jss SqlJobStore) UpdateLastActivityAt(id string, status string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if sqlResult, err := jss.GetMaster().Exec(
+			`UPDATE
+				Jobs
+			SET
+				LastActivityAt = :LastActivityAt
+			WHERE
+				Id = :Id
+			AND
+				Status = :Status`, map[string]interface{}{"Id": id, "Status": status, "LastActivityAt": model.GetMillis()}); err != nil {
+			result.Err = model.NewAppError("SqlJobStore.UpdateLastActivityAt", "store.sql_job.update.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+		} else if rows, err := sqlResult.RowsAffected(); err != nil {
+			result.Err = model.NewAppError("SqlJobStore.UpdateLastActivityAt", "store.sql_job.update.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = rows == 1
+		}
+	})
+}
+
 func (jss SqlJobStore) Get(id string) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		var status *model.Job
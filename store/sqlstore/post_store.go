@@ -4,6 +4,7 @@
 package sqlstore
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -35,6 +36,42 @@ const (
 	LAST_POSTS_CACHE_SEC  = 900 // 15 minutes
 )
 
+// uncountedPostTypes are the types of the posts that aren't counted in the TotalMsgCount of their channel, so that
+// members joining and leaving don't mark it as unread.
+var uncountedPostTypes = []string{
+	model.POST_JOIN_LEAVE,
+	model.POST_ADD_REMOVE,
+	model.POST_JOIN_CHANNEL,
+	model.POST_LEAVE_CHANNEL,
+	model.POST_JOIN_TEAM,
+	model.POST_LEAVE_TEAM,
+	model.POST_ADD_TO_CHANNEL,
+	model.POST_REMOVE_FROM_CHANNEL,
+	model.POST_ADD_TO_TEAM,
+	model.POST_REMOVE_FROM_TEAM,
+}
+
+// uncountedPostTypesQuery adds the uncounted post types to the parameters of a query, returning the list of them to
+// use in it.
+func uncountedPostTypesQuery(props map[string]interface{}) string {
+	keys := make([]string, len(uncountedPostTypes))
+	for i, postType := range uncountedPostTypes {
+		key := "Type" + strconv.Itoa(i)
+		keys[i] = ":" + key
+		props[key] = postType
+	}
+	return strings.Join(keys, ", ")
+}
+
+func isCountedPostType(postType string) bool {
+	for _, uncounted := range uncountedPostTypes {
+		if postType == uncounted {
+			return false
+		}
+	}
+	return true
+}
+
 func (s *SqlPostStore) ClearCaches() {
 	s.lastPostTimeCache.Purge()
 	s.lastPostsCache.Purge()
@@ -68,6 +105,10 @@ func NewSqlPostStore(sqlStore SqlStore, metrics einterfaces.MetricsInterface) st
 		table.ColMap("Props").SetMaxSize(8000)
 		table.ColMap("Filenames").SetMaxSize(model.POST_FILENAMES_MAX_RUNES)
 		table.ColMap("FileIds").SetMaxSize(150)
+
+		tableh := db.AddTableWithName(model.PostHereMentions{}, "PostHereMentions").SetKeys(false, "PostId")
+		tableh.ColMap("PostId").SetMaxSize(26)
+		tableh.ColMap("UserIds").SetMaxSize(65535)
 	}
 
 	return s
@@ -81,6 +122,7 @@ func (s *SqlPostStore) CreateIndexesIfNotExists() {
 	s.CreateIndexIfNotExists("idx_posts_root_id", "Posts", "RootId")
 	s.CreateIndexIfNotExists("idx_posts_user_id", "Posts", "UserId")
 	s.CreateIndexIfNotExists("idx_posts_is_pinned", "Posts", "IsPinned")
+	s.CreateIndexIfNotExists("idx_posts_pinned_until", "Posts", "PinnedUntil")
 
 	s.CreateCompositeIndexIfNotExists("idx_posts_channel_id_update_at", "Posts", []string{"ChannelId", "UpdateAt"})
 	s.CreateCompositeIndexIfNotExists("idx_posts_channel_id_delete_at_create_at", "Posts", []string{"ChannelId", "DeleteAt", "CreateAt"})
@@ -114,11 +156,7 @@ func (s *SqlPostStore) Save(post *model.Post) store.StoreChannel {
 		} else {
 			time := post.UpdateAt
 
-			if post.Type != model.POST_JOIN_LEAVE && post.Type != model.POST_ADD_REMOVE &&
-				post.Type != model.POST_JOIN_CHANNEL && post.Type != model.POST_LEAVE_CHANNEL &&
-				post.Type != model.POST_JOIN_TEAM && post.Type != model.POST_LEAVE_TEAM &&
-				post.Type != model.POST_ADD_TO_CHANNEL && post.Type != model.POST_REMOVE_FROM_CHANNEL &&
-				post.Type != model.POST_ADD_TO_TEAM && post.Type != model.POST_REMOVE_FROM_TEAM {
+			if isCountedPostType(post.Type) {
 				s.GetMaster().Exec("UPDATE Channels SET LastPostAt = GREATEST(:LastPostAt, LastPostAt), TotalMsgCount = TotalMsgCount + 1 WHERE Id = :ChannelId", map[string]interface{}{"LastPostAt": time, "ChannelId": post.ChannelId})
 			} else {
 				// don't update TotalMsgCount for unimportant messages so that the channel isn't marked as unread
@@ -199,6 +237,127 @@ func (s *SqlPostStore) Overwrite(post *model.Post) store.StoreChannel {
 	})
 }
 
+// OverwriteIfUnchanged replaces the message and props of a post, but only if it hasn't been updated since oldUpdateAt,
+// so that concurrent changes made from the same version of the post can't undo each other. The result's Data is the
+// post if it was updated, or nil if it was changed in the meantime.
+func (s *SqlPostStore) OverwriteIfUnchanged(post *model.Post, oldUpdateAt int64) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		post.UpdateAt = model.GetMillis()
+		if post.UpdateAt <= oldUpdateAt {
+			post.UpdateAt = oldUpdateAt + 1
+		}
+
+		var maxPostSize int
+		if result := <-s.GetMaxPostSize(); result.Err != nil {
+			result.Err = model.NewAppError("SqlPostStore.OverwriteIfUnchanged", "store.sql_post.overwrite.app_error", nil, "id="+post.Id+", "+result.Err.Error(), http.StatusInternalServerError)
+			return
+		} else {
+			maxPostSize = result.Data.(int)
+		}
+
+		if result.Err = post.IsValid(maxPostSize); result.Err != nil {
+			return
+		}
+
+		sqlResult, err := s.GetMaster().Exec("UPDATE Posts SET Message = :Message, Props = :Props, UpdateAt = :UpdateAt WHERE Id = :Id AND UpdateAt = :OldUpdateAt", map[string]interface{}{"Message": post.Message, "Props": model.StringInterfaceToJson(post.Props), "UpdateAt": post.UpdateAt, "Id": post.Id, "OldUpdateAt": oldUpdateAt})
+		if err != nil {
+			result.Err = model.NewAppError("SqlPostStore.OverwriteIfUnchanged", "store.sql_post.overwrite.app_error", nil, "id="+post.Id+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if rows, err := sqlResult.RowsAffected(); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.OverwriteIfUnchanged", "store.sql_post.overwrite.app_error", nil, "id="+post.Id+", "+err.Error(), http.StatusInternalServerError)
+		} else if rows == 1 {
+			result.Data = post
+		}
+	})
+}
+
+// MoveToChannel saves the channel and thread of posts that are being moved from one channel to another, and moves their
+// count from the message count of one channel to the other, all in one transaction. If any of the posts has been
+// deleted or moved out of the channel in the meantime, none of them are moved.
+func (s *SqlPostStore) MoveToChannel(posts []*model.Post, fromChannelId string, toChannelId string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		transaction, err := s.GetMaster().Begin()
+		if err != nil {
+			result.Err = model.NewAppError("SqlPostStore.MoveToChannel", "store.sql_post.move_to_channel.open_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		updateAt := model.GetMillis()
+		var lastPostAt int64
+		for _, post := range posts {
+			sqlResult, err := transaction.Exec(`
+				UPDATE
+					Posts
+				SET
+					ChannelId = :ChannelId,
+					RootId = :RootId,
+					ParentId = :ParentId,
+					UpdateAt = :UpdateAt
+				WHERE
+					Id = :Id
+					AND ChannelId = :FromChannelId
+					AND DeleteAt = 0`, map[string]interface{}{"ChannelId": toChannelId, "RootId": post.RootId, "ParentId": post.ParentId, "UpdateAt": updateAt, "Id": post.Id, "FromChannelId": fromChannelId})
+			if err != nil {
+				transaction.Rollback()
+				result.Err = model.NewAppError("SqlPostStore.MoveToChannel", "store.sql_post.move_to_channel.app_error", nil, "id="+post.Id+", "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if rows, err := sqlResult.RowsAffected(); err != nil {
+				transaction.Rollback()
+				result.Err = model.NewAppError("SqlPostStore.MoveToChannel", "store.sql_post.move_to_channel.app_error", nil, "id="+post.Id+", "+err.Error(), http.StatusInternalServerError)
+				return
+			} else if rows != 1 {
+				transaction.Rollback()
+				result.Err = model.NewAppError("SqlPostStore.MoveToChannel", "store.sql_post.move_to_channel.changed.app_error", nil, "id="+post.Id, http.StatusConflict)
+				return
+			}
+
+			post.ChannelId = toChannelId
+			post.UpdateAt = updateAt
+			if post.CreateAt > lastPostAt {
+				lastPostAt = post.CreateAt
+			}
+		}
+
+		if _, err := transaction.Exec(`
+			UPDATE
+				Channels
+			SET
+				TotalMsgCount = GREATEST(TotalMsgCount - :Count, 0),
+				UpdateAt = :UpdateAt
+			WHERE
+				Id = :ChannelId`, map[string]interface{}{"Count": len(posts), "UpdateAt": updateAt, "ChannelId": fromChannelId}); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewAppError("SqlPostStore.MoveToChannel", "store.sql_post.move_to_channel.app_error", nil, "channel_id="+fromChannelId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if _, err := transaction.Exec(`
+			UPDATE
+				Channels
+			SET
+				TotalMsgCount = TotalMsgCount + :Count,
+				LastPostAt = GREATEST(LastPostAt, :LastPostAt),
+				UpdateAt = :UpdateAt
+			WHERE
+				Id = :ChannelId`, map[string]interface{}{"Count": len(posts), "LastPostAt": lastPostAt, "UpdateAt": updateAt, "ChannelId": toChannelId}); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewAppError("SqlPostStore.MoveToChannel", "store.sql_post.move_to_channel.app_error", nil, "channel_id="+toChannelId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := transaction.Commit(); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.MoveToChannel", "store.sql_post.move_to_channel.commit_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = posts
+	})
+}
+
 func (s *SqlPostStore) GetFlaggedPosts(userId string, offset int, limit int) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		pl := model.NewPostList()
@@ -291,6 +450,59 @@ func (s *SqlPostStore) GetFlaggedPostsForChannel(userId, channelId string, offse
 	})
 }
 
+func (s *SqlPostStore) GetDeletedPostsForChannel(channelId string, offset int, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		pl := model.NewPostList()
+
+		var posts []*model.Post
+		query := `
+			SELECT
+				*
+			FROM Posts
+			WHERE
+				ChannelId = :ChannelId
+				AND DeleteAt != 0
+			ORDER BY DeleteAt DESC
+			LIMIT :Limit OFFSET :Offset`
+
+		if _, err := s.GetReplica().Select(&posts, query, map[string]interface{}{"ChannelId": channelId, "Offset": offset, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.GetDeletedPostsForChannel", "store.sql_post.get_deleted_posts.app_error", nil, "channelId="+channelId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for _, post := range posts {
+			pl.AddPost(post)
+			pl.AddOrder(post.Id)
+		}
+
+		result.Data = pl
+	})
+}
+
+func (s *SqlPostStore) GetExpiredPinnedPosts(before int64, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var posts []*model.Post
+		query := `
+			SELECT
+				*
+			FROM Posts
+			WHERE
+				IsPinned = true
+				AND PinnedUntil > 0
+				AND PinnedUntil <= :Before
+				AND DeleteAt = 0
+			ORDER BY PinnedUntil ASC
+			LIMIT :Limit`
+
+		if _, err := s.GetMaster().Select(&posts, query, map[string]interface{}{"Before": before, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.GetExpiredPinnedPosts", "store.sql_post.get_expired_pinned_posts.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = posts
+	})
+}
+
 func (s *SqlPostStore) Get(id string) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		pl := model.NewPostList()
@@ -413,6 +625,11 @@ func (s *SqlPostStore) Delete(postId string, time int64, deleteByID string) stor
 
 		post.Props[model.POST_PROPS_DELETE_BY] = deleteByID
 
+		if err = s.deleteHereMentions("SELECT Id FROM Posts WHERE Id = :Id OR RootId = :RootId", map[string]interface{}{"Id": postId, "RootId": postId}); err != nil {
+			result.Err = appErr(err.Error())
+			return
+		}
+
 		_, err = s.GetMaster().Exec("UPDATE Posts SET DeleteAt = :DeleteAt, UpdateAt = :UpdateAt, Props = :Props WHERE Id = :Id OR RootId = :RootId", map[string]interface{}{"DeleteAt": time, "UpdateAt": time, "Id": postId, "RootId": postId, "Props": model.StringInterfaceToJson(post.Props)})
 		if err != nil {
 			result.Err = appErr(err.Error())
@@ -422,7 +639,10 @@ func (s *SqlPostStore) Delete(postId string, time int64, deleteByID string) stor
 
 func (s *SqlPostStore) permanentDelete(postId string) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
-		_, err := s.GetMaster().Exec("DELETE FROM Posts WHERE Id = :Id OR RootId = :RootId", map[string]interface{}{"Id": postId, "RootId": postId})
+		err := s.deleteHereMentions("SELECT Id FROM Posts WHERE Id = :Id OR RootId = :RootId", map[string]interface{}{"Id": postId, "RootId": postId})
+		if err == nil {
+			_, err = s.GetMaster().Exec("DELETE FROM Posts WHERE Id = :Id OR RootId = :RootId", map[string]interface{}{"Id": postId, "RootId": postId})
+		}
 		if err != nil {
 			result.Err = model.NewAppError("SqlPostStore.Delete", "store.sql_post.permanent_delete.app_error", nil, "id="+postId+", err="+err.Error(), http.StatusInternalServerError)
 		}
@@ -431,7 +651,10 @@ func (s *SqlPostStore) permanentDelete(postId string) store.StoreChannel {
 
 func (s *SqlPostStore) permanentDeleteAllCommentByUser(userId string) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
-		_, err := s.GetMaster().Exec("DELETE FROM Posts WHERE UserId = :UserId AND RootId != ''", map[string]interface{}{"UserId": userId})
+		err := s.deleteHereMentions("SELECT Id FROM Posts WHERE UserId = :UserId AND RootId != ''", map[string]interface{}{"UserId": userId})
+		if err == nil {
+			_, err = s.GetMaster().Exec("DELETE FROM Posts WHERE UserId = :UserId AND RootId != ''", map[string]interface{}{"UserId": userId})
+		}
 		if err != nil {
 			result.Err = model.NewAppError("SqlPostStore.permanentDeleteAllCommentByUser", "store.sql_post.permanent_delete_all_comments_by_user.app_error", nil, "userId="+userId+", err="+err.Error(), http.StatusInternalServerError)
 		}
@@ -475,12 +698,20 @@ func (s *SqlPostStore) PermanentDeleteByUser(userId string) store.StoreChannel {
 				return
 			}
 		}
+
+		if err := s.removeUserFromHereMentions(userId); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.PermanentDeleteByUser", "store.sql_post.permanent_delete_by_user.app_error", nil, "userId="+userId+", err="+err.Error(), http.StatusInternalServerError)
+		}
 	})
 }
 
 func (s *SqlPostStore) PermanentDeleteByChannel(channelId string) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
-		if _, err := s.GetMaster().Exec("DELETE FROM Posts WHERE ChannelId = :ChannelId", map[string]interface{}{"ChannelId": channelId}); err != nil {
+		err := s.deleteHereMentions("SELECT Id FROM Posts WHERE ChannelId = :ChannelId", map[string]interface{}{"ChannelId": channelId})
+		if err == nil {
+			_, err = s.GetMaster().Exec("DELETE FROM Posts WHERE ChannelId = :ChannelId", map[string]interface{}{"ChannelId": channelId})
+		}
+		if err != nil {
 			result.Err = model.NewAppError("SqlPostStore.PermanentDeleteByChannel", "store.sql_post.permanent_delete_by_channel.app_error", nil, "channel_id="+channelId+", "+err.Error(), http.StatusInternalServerError)
 		}
 	})
@@ -547,6 +778,133 @@ func (s *SqlPostStore) GetPosts(channelId string, offset int, limit int, allowFr
 	})
 }
 
+// GetPostsWithTermsInChannel returns a page of the non-system posts created in a channel during [since, until) whose
+// message contains any of the given terms, ignoring case. It is a cheap prefilter for callers that parse
+// messages themselves, so only the Id, UserId, Message and CreateAt of the posts are filled in.
+func (s *SqlPostStore) GetPostsWithTermsInChannel(channelId string, terms []string, since int64, until int64, offset int, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if len(terms) == 0 {
+			result.Data = []*model.Post{}
+			return
+		}
+
+		params := map[string]interface{}{"ChannelId": channelId, "Since": since, "Until": until, "Offset": offset, "Limit": limit}
+
+		termClauses := make([]string, len(terms))
+		for i, term := range terms {
+			for _, c := range escapeLikeSearchChar {
+				term = strings.Replace(term, c, "*"+c, -1)
+			}
+
+			key := "Term" + strconv.Itoa(i)
+			params[key] = "%" + strings.ToLower(term) + "%"
+			termClauses[i] = "LOWER(Message) LIKE :" + key + " ESCAPE '*'"
+		}
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts,
+			`SELECT
+				Id, UserId, Message, CreateAt
+			FROM
+				Posts
+			WHERE
+				ChannelId = :ChannelId
+				AND CreateAt >= :Since
+				AND CreateAt < :Until
+				AND DeleteAt = 0
+				AND Type NOT LIKE '`+model.POST_SYSTEM_MESSAGE_PREFIX+`%'
+				AND (`+strings.Join(termClauses, " OR ")+`)
+			ORDER BY CreateAt, Id
+			LIMIT :Limit
+			OFFSET :Offset`, params); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.GetPostsWithTermsInChannel", "store.sql_post.get_posts_with_terms_in_channel.app_error", nil, "channelId="+channelId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = posts
+	})
+}
+
+// GetCatchUpForUser returns, for each channel that the user belongs to, counts of the posts made by other users after
+// since, ordered by the most recently active channel first. When sinceLastViewed is set, only the posts made after the
+// user last viewed each channel are counted too. When collapsedThreads is set, replies are left out of the new post
+// counts so that they are only counted as thread replies. Mentions are the channel's unread mentions, as counted when
+// notifications are sent.
+func (s *SqlPostStore) GetCatchUpForUser(userId string, since int64, sinceLastViewed bool, collapsedThreads bool, offset int, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		lastViewedQuery := ""
+		if sinceLastViewed {
+			lastViewedQuery = "AND Posts.CreateAt > ChannelMembers.LastViewedAt"
+		}
+
+		newPosts := "COUNT(Posts.Id)"
+		if collapsedThreads {
+			newPosts = "SUM(CASE WHEN Posts.RootId = '' THEN 1 ELSE 0 END)"
+		}
+
+		query :=
+			`SELECT
+				Posts.ChannelId AS ChannelId,
+				Channels.TeamId AS TeamId,
+				ChannelMembers.NotifyProps AS NotifyProps,
+				` + newPosts + ` AS NewPosts,
+				ChannelMembers.MentionCount AS Mentions,
+				SUM(CASE WHEN Posts.RootId != '' AND EXISTS (
+					SELECT 1 FROM Posts AS Participated
+					WHERE
+						Participated.UserId = :UserId
+						AND (Participated.Id = Posts.RootId OR Participated.RootId = Posts.RootId)
+				) THEN 1 ELSE 0 END) AS ThreadReplies,
+				MAX(Posts.CreateAt) AS LastPostAt
+			FROM Posts
+				INNER JOIN ChannelMembers ON ChannelMembers.ChannelId = Posts.ChannelId AND ChannelMembers.UserId = :UserId
+				INNER JOIN Channels ON Channels.Id = Posts.ChannelId AND Channels.DeleteAt = 0
+			WHERE
+				Posts.CreateAt > :Since
+				` + lastViewedQuery + `
+				AND Posts.UserId != :UserId
+				AND Posts.DeleteAt = 0
+				AND Posts.Type NOT LIKE '` + model.POST_SYSTEM_MESSAGE_PREFIX + `%'
+			GROUP BY Posts.ChannelId, Channels.TeamId, ChannelMembers.NotifyProps, ChannelMembers.MentionCount
+			ORDER BY LastPostAt DESC, ChannelId
+			LIMIT :Limit OFFSET :Offset`
+
+		var rows []struct {
+			ChannelId     string
+			TeamId        string
+			NotifyProps   string
+			NewPosts      int64
+			Mentions      int64
+			ThreadReplies int64
+			LastPostAt    int64
+		}
+		if _, err := s.GetReplica().Select(&rows, query, map[string]interface{}{
+			"UserId": userId,
+			"Since":  since,
+			"Limit":  limit,
+			"Offset": offset,
+		}); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.GetCatchUpForUser", "store.sql_post.get_catch_up_for_user.app_error", nil, "userId="+userId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		channels := make([]*model.CatchUpChannel, 0, len(rows))
+		for _, row := range rows {
+			channels = append(channels, &model.CatchUpChannel{
+				ChannelId:     row.ChannelId,
+				TeamId:        row.TeamId,
+				NewPosts:      row.NewPosts,
+				Mentions:      row.Mentions,
+				ThreadReplies: row.ThreadReplies,
+				LastPostAt:    row.LastPostAt,
+				NotifyProps:   model.MapFromJson(strings.NewReader(row.NotifyProps)),
+			})
+		}
+
+		result.Data = channels
+	})
+}
+
 func (s *SqlPostStore) GetPostsSince(channelId string, time int64, allowFromCache bool) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		if allowFromCache {
@@ -773,11 +1131,72 @@ var specialSearchChar = []string{
 	":",
 }
 
+// searchCreateDateClause returns the condition on the given column for the on:, after: and before: filters of a
+// search, adding its parameters to queryParams. It returns an empty string when none of the filters are set.
+func searchCreateDateClause(params *model.SearchParams, column string, queryParams map[string]interface{}) string {
+	if len(params.OnDate) > 1 {
+		onDateStart, onDateEnd := params.GetOnDateMillis()
+		queryParams["OnDateStart"] = strconv.FormatInt(onDateStart, 10)
+		queryParams["OnDateEnd"] = strconv.FormatInt(onDateEnd, 10)
+
+		// between `on date` start of day and end of day
+		return "AND " + column + " BETWEEN :OnDateStart AND :OnDateEnd "
+	} else if len(params.AfterDate) > 1 && len(params.BeforeDate) > 1 {
+		afterDate := params.GetAfterDateMillis()
+		beforeDate := params.GetBeforeDateMillis()
+		queryParams["OnDateStart"] = strconv.FormatInt(afterDate, 10)
+		queryParams["OnDateEnd"] = strconv.FormatInt(beforeDate, 10)
+
+		// between clause
+		return "AND " + column + " BETWEEN :OnDateStart AND :OnDateEnd "
+	} else if len(params.AfterDate) > 1 {
+		afterDate := params.GetAfterDateMillis()
+		queryParams["AfterDate"] = strconv.FormatInt(afterDate, 10)
+
+		// greater than `after date`
+		return "AND " + column + " >= :AfterDate "
+	} else if len(params.BeforeDate) > 1 {
+		beforeDate := params.GetBeforeDateMillis()
+		queryParams["BeforeDate"] = strconv.FormatInt(beforeDate, 10)
+
+		// less than `before date`
+		return "AND " + column + " <= :BeforeDate "
+	}
+
+	// no create date filters set
+	return ""
+}
+
 func (s *SqlPostStore) Search(teamId string, userId string, params *model.SearchParams) store.StoreChannel {
+	return s.SearchInTeams([]string{teamId}, userId, params)
+}
+
+// SearchInTeams searches the posts that the user can read in any of the given teams, as well as in the user's
+// direct and group messages.
+func (s *SqlPostStore) SearchInTeams(teamIds []string, userId string, params *model.SearchParams) store.StoreChannel {
+	return s.SearchInTeamsPage(teamIds, userId, params, 0, 100)
+}
+
+// SearchInTeamsPage is like SearchInTeams, but returns the given page of the results, newest first.
+func (s *SqlPostStore) SearchInTeamsPage(teamIds []string, userId string, params *model.SearchParams, offset int, limit int) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		queryParams := map[string]interface{}{
-			"TeamId": teamId,
 			"UserId": userId,
+			"Offset": offset,
+			"Limit":  limit,
+		}
+
+		teamIdsClause := ""
+		for i, teamId := range teamIds {
+			paramName := "TeamId" + strconv.Itoa(i)
+			if i > 0 {
+				teamIdsClause += ", "
+			}
+			teamIdsClause += ":" + paramName
+			queryParams[paramName] = teamId
+		}
+		if teamIdsClause == "" {
+			teamIdsClause = "''"
 		}
 
 		termMap := map[string]bool{}
@@ -825,14 +1244,15 @@ func (s *SqlPostStore) Search(teamId string, userId string, params *model.Search
 						ChannelMembers
 					WHERE
 						Id = ChannelId
-							AND (TeamId = :TeamId OR TeamId = '')
+							AND (TeamId IN (` + teamIdsClause + `) OR TeamId = '')
 							AND UserId = :UserId
 							` + deletedQueryPart + `
 							CHANNEL_FILTER)
 				CREATEDATE_CLAUSE							
 				SEARCH_CLAUSE
 				ORDER BY CreateAt DESC
-			LIMIT 100`
+			LIMIT :Limit
+			OFFSET :Offset`
 
 		if len(params.InChannels) > 1 {
 			inClause := ":InChannel0"
@@ -870,7 +1290,7 @@ func (s *SqlPostStore) Search(teamId string, userId string, params *model.Search
 						Users,
 						TeamMembers
 					WHERE
-						TeamMembers.TeamId = :TeamId
+						TeamMembers.TeamId IN (`+teamIdsClause+`)
 						AND Users.Id = TeamMembers.UserId
 						AND Username IN (`+inClause+`))`, 1)
 		} else if len(params.FromUsers) == 1 {
@@ -883,7 +1303,7 @@ func (s *SqlPostStore) Search(teamId string, userId string, params *model.Search
 						Users,
 						TeamMembers
 					WHERE
-						TeamMembers.TeamId = :TeamId
+						TeamMembers.TeamId IN (`+teamIdsClause+`)
 						AND Users.Id = TeamMembers.UserId
 						AND Username = :FromUser)`, 1)
 		} else {
@@ -891,39 +1311,7 @@ func (s *SqlPostStore) Search(teamId string, userId string, params *model.Search
 		}
 
 		// handle after: before: on: filters
-		if len(params.AfterDate) > 1 || len(params.BeforeDate) > 1 || len(params.OnDate) > 1 {
-			if len(params.OnDate) > 1 {
-				onDateStart, onDateEnd := params.GetOnDateMillis()
-				queryParams["OnDateStart"] = strconv.FormatInt(onDateStart, 10)
-				queryParams["OnDateEnd"] = strconv.FormatInt(onDateEnd, 10)
-
-				// between `on date` start of day and end of day
-				searchQuery = strings.Replace(searchQuery, "CREATEDATE_CLAUSE", "AND CreateAt BETWEEN :OnDateStart AND :OnDateEnd ", 1)
-			} else if len(params.AfterDate) > 1 && len(params.BeforeDate) > 1 {
-				afterDate := params.GetAfterDateMillis()
-				beforeDate := params.GetBeforeDateMillis()
-				queryParams["OnDateStart"] = strconv.FormatInt(afterDate, 10)
-				queryParams["OnDateEnd"] = strconv.FormatInt(beforeDate, 10)
-
-				// between clause
-				searchQuery = strings.Replace(searchQuery, "CREATEDATE_CLAUSE", "AND CreateAt BETWEEN :OnDateStart AND :OnDateEnd ", 1)
-			} else if len(params.AfterDate) > 1 {
-				afterDate := params.GetAfterDateMillis()
-				queryParams["AfterDate"] = strconv.FormatInt(afterDate, 10)
-
-				// greater than `after date`
-				searchQuery = strings.Replace(searchQuery, "CREATEDATE_CLAUSE", "AND CreateAt >= :AfterDate ", 1)
-			} else if len(params.BeforeDate) > 1 {
-				beforeDate := params.GetBeforeDateMillis()
-				queryParams["BeforeDate"] = strconv.FormatInt(beforeDate, 10)
-
-				// less than `before date`
-				searchQuery = strings.Replace(searchQuery, "CREATEDATE_CLAUSE", "AND CreateAt <= :BeforeDate ", 1)
-			}
-		} else {
-			// no create date filters set
-			searchQuery = strings.Replace(searchQuery, "CREATEDATE_CLAUSE", "", 1)
-		}
+		searchQuery = strings.Replace(searchQuery, "CREATEDATE_CLAUSE", searchCreateDateClause(params, "CreateAt", queryParams), 1)
 
 		if terms == "" {
 			// we've already confirmed that we have a channel or user to search for
@@ -1096,6 +1484,100 @@ func (s *SqlPostStore) AnalyticsPostCountsByDay(teamId string) store.StoreChanne
 	})
 }
 
+func (s *SqlPostStore) AnalyticsPostCountsByInterval(channelId string, since int64, until int64, interval int64) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		bucket := "CreateAt DIV :Interval"
+		if s.DriverName() == model.DATABASE_DRIVER_POSTGRES {
+			bucket = "CreateAt / :Interval"
+		}
+
+		query :=
+			`SELECT
+				` + bucket + ` AS Bucket,
+				COUNT(Id) AS Count
+			FROM Posts
+			WHERE
+				ChannelId = :ChannelId
+				AND CreateAt >= :Since
+				AND CreateAt < :Until
+				AND DeleteAt = 0
+				AND Type NOT LIKE '` + model.POST_SYSTEM_MESSAGE_PREFIX + `%'
+			GROUP BY Bucket
+			ORDER BY Bucket`
+
+		var rows []struct {
+			Bucket int64
+			Count  int64
+		}
+		if _, err := s.GetReplica().Select(&rows, query, map[string]interface{}{"ChannelId": channelId, "Since": since, "Until": until, "Interval": interval}); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.AnalyticsPostCountsByInterval", "store.sql_post.analytics_post_counts_by_interval.app_error", nil, "channelId="+channelId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		buckets := make([]*model.ChannelActivityBucket, 0, len(rows))
+		for _, row := range rows {
+			buckets = append(buckets, &model.ChannelActivityBucket{StartAt: row.Bucket * interval, Count: row.Count})
+		}
+
+		result.Data = buckets
+	})
+}
+
+func (s *SqlPostStore) AnalyticsTeamActivityByDay(teamId string, since int64, until int64) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		day := "DATE(FROM_UNIXTIME(Posts.CreateAt / 1000))"
+		if s.DriverName() == model.DATABASE_DRIVER_POSTGRES {
+			day = "TO_CHAR(DATE(TO_TIMESTAMP(Posts.CreateAt / 1000)), 'YYYY-MM-DD')"
+		}
+
+		query :=
+			`SELECT
+				` + day + ` AS Date,
+				COUNT(DISTINCT Posts.UserId) AS ActiveUsers,
+				COUNT(Posts.Id) AS PostCount
+			FROM Posts
+				INNER JOIN Channels ON Posts.ChannelId = Channels.Id AND Channels.TeamId = :TeamId
+			WHERE
+				Posts.CreateAt >= :Since
+				AND Posts.CreateAt < :Until
+				AND Posts.DeleteAt = 0
+				AND Posts.Type NOT LIKE '` + model.POST_SYSTEM_MESSAGE_PREFIX + `%'
+			GROUP BY ` + day + `
+			ORDER BY Date`
+
+		var days []*model.TeamActivityDay
+		if _, err := s.GetReplica().Select(&days, query, map[string]interface{}{"TeamId": teamId, "Since": since, "Until": until}); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.AnalyticsTeamActivityByDay", "store.sql_post.analytics_team_activity_by_day.app_error", nil, "teamId="+teamId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = days
+	})
+}
+
+func (s *SqlPostStore) AnalyticsPostersCount(teamId string, since int64, until int64) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		query :=
+			`SELECT
+				COUNT(DISTINCT Posts.UserId)
+			FROM Posts
+				INNER JOIN Channels ON Posts.ChannelId = Channels.Id AND Channels.TeamId = :TeamId
+			WHERE
+				Posts.CreateAt >= :Since
+				AND Posts.CreateAt < :Until
+				AND Posts.DeleteAt = 0
+				AND Posts.Type NOT LIKE '` + model.POST_SYSTEM_MESSAGE_PREFIX + `%'`
+
+		v, err := s.GetReplica().SelectInt(query, map[string]interface{}{"TeamId": teamId, "Since": since, "Until": until})
+		if err != nil {
+			result.Err = model.NewAppError("SqlPostStore.AnalyticsPostersCount", "store.sql_post.analytics_posters_count.app_error", nil, "teamId="+teamId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = v
+	})
+}
+
 func (s *SqlPostStore) AnalyticsPostCount(teamId string, mustHaveFile bool, mustHaveHashtag bool) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		query :=
@@ -1200,6 +1682,35 @@ func (s *SqlPostStore) GetPostsBatchForIndexing(startTime int64, endTime int64,
 	})
 }
 
+// GetPostsBatchForChannelIndexing returns the next batch of the channel's posts, including deleted ones, after the
+// given post, ordered by CreateAt and then by Id. Paging by post rather than by offset means that batches aren't
+// shifted by posts being created or deleted while the channel is being indexed.
+func (s *SqlPostStore) GetPostsBatchForChannelIndexing(channelId string, afterCreateAt int64, afterId string, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var posts []*model.Post
+		_, err := s.GetSearchReplica().Select(&posts,
+			`SELECT
+				*
+			FROM
+				Posts
+			WHERE
+				ChannelId = :ChannelId
+				AND (CreateAt > :CreateAt OR (CreateAt = :CreateAt AND Id > :Id))
+			ORDER BY
+				CreateAt ASC, Id ASC
+			LIMIT
+				:Limit`,
+			map[string]interface{}{"ChannelId": channelId, "CreateAt": afterCreateAt, "Id": afterId, "Limit": limit})
+
+		if err != nil {
+			result.Err = model.NewAppError("SqlPostStore.GetPostsBatchForChannelIndexing", "store.sql_post.get_posts_batch_for_channel_indexing.get.app_error", nil, "channel_id="+channelId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = posts
+	})
+}
+
 func (s *SqlPostStore) PermanentDeleteBatch(endTime int64, limit int64) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		var query string
@@ -1209,6 +1720,12 @@ func (s *SqlPostStore) PermanentDeleteBatch(endTime int64, limit int64) store.St
 			query = "DELETE from Posts WHERE CreateAt < :EndTime LIMIT :Limit"
 		}
 
+		// @here mentions are recorded when their post is made, so they're old enough to go along with it
+		if _, err := s.GetMaster().Exec("DELETE FROM PostHereMentions WHERE CreateAt < :EndTime", map[string]interface{}{"EndTime": endTime}); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.PermanentDeleteBatch", "store.sql_post.permanent_delete_batch.app_error", nil, ""+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
 		sqlResult, err := s.GetMaster().Exec(query, map[string]interface{}{"EndTime": endTime, "Limit": limit})
 		if err != nil {
 			result.Err = model.NewAppError("SqlPostStore.PermanentDeleteBatch", "store.sql_post.permanent_delete_batch.app_error", nil, ""+err.Error(), http.StatusInternalServerError)
@@ -1363,3 +1880,64 @@ func (s *SqlPostStore) GetRepliesForExport(parentId string) store.StoreChannel {
 		}
 	})
 }
+
+func (s *SqlPostStore) SaveHereMentions(mentions *model.PostHereMentions) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		mentions.PreSave()
+		if result.Err = mentions.IsValid(); result.Err != nil {
+			return
+		}
+
+		if err := s.GetMaster().Insert(mentions); err != nil {
+			result.Err = model.NewAppError("SqlPostStore.SaveHereMentions", "store.sql_post.save_here_mentions.app_error", nil, "post_id="+mentions.PostId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Data = mentions
+	})
+}
+
+func (s *SqlPostStore) GetHereMentions(postId string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var mentions model.PostHereMentions
+		if err := s.GetReplica().SelectOne(&mentions, "SELECT * FROM PostHereMentions WHERE PostId = :PostId", map[string]interface{}{"PostId": postId}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlPostStore.GetHereMentions", "store.sql_post.get_here_mentions.missing.app_error", nil, "post_id="+postId, http.StatusNotFound)
+				return
+			}
+			result.Err = model.NewAppError("SqlPostStore.GetHereMentions", "store.sql_post.get_here_mentions.app_error", nil, "post_id="+postId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Data = &mentions
+	})
+}
+
+// deleteHereMentions deletes who @here mentioned in the posts with the ids that postIdsQuery selects, so that they don't
+// outlive the posts.
+func (s *SqlPostStore) deleteHereMentions(postIdsQuery string, params map[string]interface{}) error {
+	_, err := s.GetMaster().Exec("DELETE FROM PostHereMentions WHERE PostId IN ("+postIdsQuery+")", params)
+	return err
+}
+
+// removeUserFromHereMentions removes a user from everyone that @here mentioned in posts.
+func (s *SqlPostStore) removeUserFromHereMentions(userId string) error {
+	var mentions []*model.PostHereMentions
+	if _, err := s.GetMaster().Select(&mentions, "SELECT * FROM PostHereMentions WHERE UserIds LIKE :UserId", map[string]interface{}{"UserId": "%" + userId + "%"}); err != nil {
+		return err
+	}
+
+	for _, mention := range mentions {
+		userIds := model.StringArray{}
+		for _, id := range mention.UserIds {
+			if id != userId {
+				userIds = append(userIds, id)
+			}
+		}
+		mention.UserIds = userIds
+
+		if _, err := s.GetMaster().Update(mention); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -61,7 +61,12 @@ func (s SqlPreferenceStore) Save(preferences *model.Preferences) store.StoreChan
 		if err != nil {
 			result.Err = model.NewAppError("SqlPreferenceStore.Save", "store.sql_preference.save.open_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
 		} else {
-			for _, preference := range *preferences {
+			for i := range *preferences {
+				if (*preferences)[i].UpdateAt == 0 {
+					(*preferences)[i].UpdateAt = model.GetMillis()
+				}
+
+				preference := (*preferences)[i]
 				if upsertResult := s.save(transaction, &preference); upsertResult.Err != nil {
 					*result = upsertResult
 					break
@@ -98,17 +103,19 @@ func (s SqlPreferenceStore) save(transaction *gorp.Transaction, preference *mode
 		"Category": preference.Category,
 		"Name":     preference.Name,
 		"Value":    preference.Value,
+		"UpdateAt": preference.UpdateAt,
 	}
 
 	if s.DriverName() == model.DATABASE_DRIVER_MYSQL {
 		if _, err := transaction.Exec(
 			`INSERT INTO
 				Preferences
-				(UserId, Category, Name, Value)
+				(UserId, Category, Name, Value, UpdateAt)
 			VALUES
-				(:UserId, :Category, :Name, :Value)
+				(:UserId, :Category, :Name, :Value, :UpdateAt)
 			ON DUPLICATE KEY UPDATE
-				Value = :Value`, params); err != nil {
+				Value = :Value,
+				UpdateAt = :UpdateAt`, params); err != nil {
 			result.Err = model.NewAppError("SqlPreferenceStore.save", "store.sql_preference.save.updating.app_error", nil, err.Error(), http.StatusInternalServerError)
 		}
 	} else if s.DriverName() == model.DATABASE_DRIVER_POSTGRES {
@@ -139,6 +146,97 @@ func (s SqlPreferenceStore) save(transaction *gorp.Transaction, preference *mode
 	return result
 }
 
+// SaveNewer saves each of the preferences that is newer than the stored one, by UpdateAt, or isn't stored yet, returning
+// those that were saved.
+func (s SqlPreferenceStore) SaveNewer(preferences *model.Preferences) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		transaction, err := s.GetMaster().Begin()
+		if err != nil {
+			result.Err = model.NewAppError("SqlPreferenceStore.SaveNewer", "store.sql_preference.save.open_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		saved := model.Preferences{}
+		for _, preference := range *preferences {
+			var wasSaved bool
+			if wasSaved, result.Err = s.saveNewer(transaction, &preference); result.Err != nil {
+				transaction.Rollback()
+				return
+			}
+
+			if wasSaved {
+				saved = append(saved, preference)
+			}
+		}
+
+		if err := transaction.Commit(); err != nil {
+			result.Err = model.NewAppError("SqlPreferenceStore.SaveNewer", "store.sql_preference.save.commit_transaction.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = saved
+	})
+}
+
+func (s SqlPreferenceStore) saveNewer(transaction *gorp.Transaction, preference *model.Preference) (bool, *model.AppError) {
+	preference.PreUpdate()
+
+	if err := preference.IsValid(); err != nil {
+		return false, err
+	}
+
+	params := map[string]interface{}{
+		"UserId":   preference.UserId,
+		"Category": preference.Category,
+		"Name":     preference.Name,
+		"Value":    preference.Value,
+		"UpdateAt": preference.UpdateAt,
+	}
+
+	sqlResult, err := transaction.Exec(
+		`UPDATE
+			Preferences
+		SET
+			Value = :Value,
+			UpdateAt = :UpdateAt
+		WHERE
+			UserId = :UserId
+			AND Category = :Category
+			AND Name = :Name
+			AND UpdateAt < :UpdateAt`, params)
+	if err != nil {
+		return false, model.NewAppError("SqlPreferenceStore.saveNewer", "store.sql_preference.save.updating.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	if rows, _ := sqlResult.RowsAffected(); rows == 1 {
+		return true, nil
+	}
+
+	count, err := transaction.SelectInt(
+		`SELECT
+			count(0)
+		FROM
+			Preferences
+		WHERE
+			UserId = :UserId
+			AND Category = :Category
+			AND Name = :Name`, params)
+	if err != nil {
+		return false, model.NewAppError("SqlPreferenceStore.saveNewer", "store.sql_preference.save.updating.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	// The stored preference is at least as new as this one
+	if count != 0 {
+		return false, nil
+	}
+
+	if result := s.insert(transaction, preference); result.Err != nil {
+		return false, result.Err
+	}
+
+	return true, nil
+}
+
 func (s SqlPreferenceStore) insert(transaction *gorp.Transaction, preference *model.Preference) store.StoreResult {
 	result := store.StoreResult{}
 
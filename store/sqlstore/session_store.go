@@ -206,6 +206,16 @@ func (me SqlSessionStore) UpdateDeviceId(id string, deviceId string, expiresAt i
 	})
 }
 
+func (me SqlSessionStore) UpdateProps(session *model.Session) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if _, err := me.GetMaster().Exec("UPDATE Sessions SET Props = :Props WHERE Id = :Id", map[string]interface{}{"Props": model.MapToJson(session.Props), "Id": session.Id}); err != nil {
+			result.Err = model.NewAppError("SqlSessionStore.UpdateProps", "store.sql_session.update_props.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = session
+		}
+	})
+}
+
 func (me SqlSessionStore) AnalyticsSessionCount() store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		query :=
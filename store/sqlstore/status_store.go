@@ -155,6 +155,17 @@ func (s SqlStatusStore) GetTotalActiveUsersCount() store.StoreChannel {
 	})
 }
 
+func (s SqlStatusStore) GetRecentlyActive(limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var statuses []*model.Status
+		if _, err := s.GetReplica().Select(&statuses, "SELECT * FROM Status WHERE LastActivityAt > 0 ORDER BY LastActivityAt DESC LIMIT :Limit", map[string]interface{}{"Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlStatusStore.GetRecentlyActive", "store.sql_status.get_recently_active.app_error", nil, err.Error(), http.StatusInternalServerError)
+		} else {
+			result.Data = statuses
+		}
+	})
+}
+
 func (s SqlStatusStore) UpdateLastActivityAt(userId string, lastActivityAt int64) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		if _, err := s.GetMaster().Exec("UPDATE Status SET LastActivityAt = :Time WHERE UserId = :UserId", map[string]interface{}{"UserId": userId, "Time": lastActivityAt}); err != nil {
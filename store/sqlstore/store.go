@@ -8,6 +8,7 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/mattermost/gorp"
 
+	"github.com/mattermost/mattermost-server/model"
 	"github.com/mattermost/mattermost-server/store"
 )
 
@@ -48,6 +49,8 @@ type SqlStore interface {
 	TotalMasterDbConnections() int
 	TotalReadDbConnections() int
 	TotalSearchDbConnections() int
+	DbPoolStats() []*model.DbPoolStats
+	CacheStats() []*model.CacheStats
 	MarkSystemRanUnitTests()
 	DoesTableExist(tablename string) bool
 	DoesColumnExist(tableName string, columName string) bool
@@ -97,4 +100,6 @@ type SqlStore interface {
 	TermsOfService() store.TermsOfServiceStore
 	UserTermsOfService() store.UserTermsOfServiceStore
 	LinkMetadata() store.LinkMetadataStore
+	ClusterLock() store.ClusterLockStore
+	IdempotentRequest() store.IdempotentRequestStore
 }
@@ -97,6 +97,8 @@ type SqlSupplierOldStores struct {
 	group                store.GroupStore
 	UserTermsOfService   store.UserTermsOfServiceStore
 	linkMetadata         store.LinkMetadataStore
+	clusterLock          store.ClusterLockStore
+	idempotentRequest    store.IdempotentRequestStore
 }
 
 type SqlSupplier struct {
@@ -148,6 +150,8 @@ func NewSqlSupplier(settings model.SqlSettings, metrics einterfaces.MetricsInter
 	supplier.oldStores.TermsOfService = NewSqlTermsOfServiceStore(supplier, metrics)
 	supplier.oldStores.UserTermsOfService = NewSqlUserTermsOfServiceStore(supplier)
 	supplier.oldStores.linkMetadata = NewSqlLinkMetadataStore(supplier)
+	supplier.oldStores.clusterLock = NewSqlClusterLockStore(supplier)
+	supplier.oldStores.idempotentRequest = NewSqlIdempotentRequestStore(supplier)
 
 	initSqlSupplierReactions(supplier)
 	initSqlSupplierRoles(supplier)
@@ -187,6 +191,8 @@ func NewSqlSupplier(settings model.SqlSettings, metrics einterfaces.MetricsInter
 	supplier.oldStores.TermsOfService.(SqlTermsOfServiceStore).CreateIndexesIfNotExists()
 	supplier.oldStores.UserTermsOfService.(SqlUserTermsOfServiceStore).CreateIndexesIfNotExists()
 	supplier.oldStores.linkMetadata.(*SqlLinkMetadataStore).CreateIndexesIfNotExists()
+	supplier.oldStores.clusterLock.(*SqlClusterLockStore).CreateIndexesIfNotExists()
+	supplier.oldStores.idempotentRequest.(*SqlIdempotentRequestStore).CreateIndexesIfNotExists()
 
 	supplier.CreateIndexesIfNotExistsGroups()
 
@@ -336,6 +342,65 @@ func (ss *SqlSupplier) TotalSearchDbConnections() int {
 	return count
 }
 
+func (ss *SqlSupplier) DbPoolStats() []*model.DbPoolStats {
+	stats := []*model.DbPoolStats{dbPoolStats("master", ss.GetMaster())}
+
+	for i, db := range ss.replicas {
+		stats = append(stats, dbPoolStats(fmt.Sprintf("replica-%v", i), db))
+	}
+
+	for i, db := range ss.searchReplicas {
+		stats = append(stats, dbPoolStats(fmt.Sprintf("search-replica-%v", i), db))
+	}
+
+	return stats
+}
+
+func dbPoolStats(name string, db *gorp.DbMap) *model.DbPoolStats {
+	stats := db.Db.Stats()
+
+	return &model.DbPoolStats{
+		Name:                     name,
+		MaxOpenConnections:       stats.MaxOpenConnections,
+		OpenConnections:          stats.OpenConnections,
+		InUse:                    stats.InUse,
+		Idle:                     stats.Idle,
+		WaitCount:                stats.WaitCount,
+		WaitDurationMilliseconds: int64(stats.WaitDuration / time.Millisecond),
+	}
+}
+
+func (ss *SqlSupplier) CacheStats() []*model.CacheStats {
+	stats := []*model.CacheStats{
+		namedCacheStats("Channel", channelCache),
+		namedCacheStats("Channel By Name", channelByNameCache),
+		namedCacheStats("Channel Member Counts", channelMemberCountsCache),
+		namedCacheStats("All Channel Members for User", allChannelMembersForUserCache),
+		namedCacheStats("All Channel Members Notify Props for Channel", allChannelMembersNotifyPropsForChannelCache),
+		namedCacheStats("Emoji", emojiCache),
+		namedCacheStats("File Info", fileInfoCache),
+		namedCacheStats("Profiles in Channel", profilesInChannelCache),
+		namedCacheStats("Profile By Ids", profileByIdsCache),
+		namedCacheStats("Terms Of Service", termsOfServiceCache),
+		namedCacheStats("Webhook", webhookCache),
+	}
+
+	if postStore, ok := ss.oldStores.post.(*SqlPostStore); ok {
+		stats = append(stats,
+			namedCacheStats("Last Post Time", postStore.lastPostTimeCache),
+			namedCacheStats("Last Posts", postStore.lastPostsCache),
+		)
+	}
+
+	return stats
+}
+
+func namedCacheStats(name string, cache *utils.Cache) *model.CacheStats {
+	stats := cache.Stats()
+	stats.Name = name
+	return stats
+}
+
 func (ss *SqlSupplier) MarkSystemRanUnitTests() {
 	if result := <-ss.System().Get(); result.Err == nil {
 		props := result.Data.(model.StringMap)
@@ -1040,6 +1105,14 @@ func (ss *SqlSupplier) LinkMetadata() store.LinkMetadataStore {
 	return ss.oldStores.linkMetadata
 }
 
+func (ss *SqlSupplier) ClusterLock() store.ClusterLockStore {
+	return ss.oldStores.clusterLock
+}
+
+func (ss *SqlSupplier) IdempotentRequest() store.IdempotentRequestStore {
+	return ss.oldStores.idempotentRequest
+}
+
 func (ss *SqlSupplier) DropAllTables() {
 	ss.master.TruncateTables()
 }
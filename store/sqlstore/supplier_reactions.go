@@ -125,6 +125,34 @@ func (s *SqlSupplier) ReactionsBulkGetForPosts(ctx context.Context, postIds []st
 	return result
 }
 
+func (s *SqlSupplier) ReactionsGetUserIdsForEmojiInChannel(ctx context.Context, channelId string, emojiName string, since int64, offset int, limit int, hints ...store.LayeredStoreHint) *store.LayeredStoreSupplierResult {
+	result := store.NewSupplierResult()
+
+	// Users are ordered by their first matching reaction so that paging is stable while new reactions come in.
+	var userIds []string
+	if _, err := s.GetReplica().Select(&userIds, `SELECT
+				Reactions.UserId
+			FROM
+				Reactions
+				INNER JOIN Posts ON Posts.Id = Reactions.PostId
+			WHERE
+				Posts.ChannelId = :ChannelId
+				AND Posts.DeleteAt = 0
+				AND Reactions.EmojiName = :EmojiName
+				AND Reactions.CreateAt >= :Since
+			GROUP BY
+				Reactions.UserId
+			ORDER BY
+				MIN(Reactions.CreateAt), Reactions.UserId
+			LIMIT :Limit OFFSET :Offset`, map[string]interface{}{"ChannelId": channelId, "EmojiName": emojiName, "Since": since, "Limit": limit, "Offset": offset}); err != nil {
+		result.Err = model.NewAppError("SqlReactionStore.GetUserIdsForEmojiInChannel", "store.sql_reaction.get_user_ids_for_emoji_in_channel.app_error", nil, "channel_id="+channelId+", emoji_name="+emojiName+", "+err.Error(), http.StatusInternalServerError)
+	} else {
+		result.Data = userIds
+	}
+
+	return result
+}
+
 func (s *SqlSupplier) ReactionDeleteAllWithEmojiName(ctx context.Context, emojiName string, hints ...store.LayeredStoreHint) *store.LayeredStoreSupplierResult {
 	result := store.NewSupplierResult()
 
@@ -42,6 +42,13 @@ func NewTeamMemberFromModel(tm *model.TeamMember) *teamMember {
 	}
 }
 
+// teamStorageUsage is kept apart from the Teams table so that updating a team can't overwrite the usage recorded by
+// an upload that happens at the same time.
+type teamStorageUsage struct {
+	TeamId    string
+	UsedBytes int64
+}
+
 type teamMemberWithSchemeRoles struct {
 	TeamId                     string
 	UserId                     string
@@ -145,11 +152,36 @@ func NewSqlTeamStore(sqlStore SqlStore) store.TeamStore {
 		table.ColMap("CompanyName").SetMaxSize(64)
 		table.ColMap("AllowedDomains").SetMaxSize(500)
 		table.ColMap("InviteId").SetMaxSize(32)
+		table.ColMap("ChannelNamePattern").SetMaxSize(128)
+		table.ColMap("ChannelDisplayNamePattern").SetMaxSize(128)
 
 		tablem := db.AddTableWithName(teamMember{}, "TeamMembers").SetKeys(false, "TeamId", "UserId")
 		tablem.ColMap("TeamId").SetMaxSize(26)
 		tablem.ColMap("UserId").SetMaxSize(26)
 		tablem.ColMap("Roles").SetMaxSize(64)
+
+		tableu := db.AddTableWithName(teamStorageUsage{}, "TeamStorageUsage").SetKeys(false, "TeamId")
+		tableu.ColMap("TeamId").SetMaxSize(26)
+
+		tablew := db.AddTableWithName(model.TeamWaitlistEntry{}, "TeamWaitlist").SetKeys(false, "TeamId", "UserId")
+		tablew.ColMap("TeamId").SetMaxSize(26)
+		tablew.ColMap("UserId").SetMaxSize(26)
+
+		tablei := db.AddTableWithName(model.TeamInvite{}, "TeamInvites").SetKeys(false, "Id")
+		tablei.ColMap("Id").SetMaxSize(26)
+		tablei.ColMap("TeamId").SetMaxSize(26)
+		tablei.ColMap("CreatorId").SetMaxSize(26)
+		tablei.ColMap("ChannelIds").SetMaxSize(2048)
+
+		tablect := db.AddTableWithName(model.ChannelTemplate{}, "ChannelTemplates").SetKeys(false, "Id")
+		tablect.ColMap("Id").SetMaxSize(26)
+		tablect.ColMap("TeamId").SetMaxSize(26)
+		tablect.ColMap("CreatorId").SetMaxSize(26)
+		tablect.ColMap("Name").SetMaxSize(64)
+		tablect.ColMap("Type").SetMaxSize(1)
+		tablect.ColMap("Header").SetMaxSize(1024)
+		tablect.ColMap("Purpose").SetMaxSize(250)
+		tablect.ColMap("IntroMessage").SetMaxSize(4000)
 	}
 
 	return s
@@ -166,6 +198,10 @@ func (s SqlTeamStore) CreateIndexesIfNotExists() {
 	s.CreateIndexIfNotExists("idx_teammembers_team_id", "TeamMembers", "TeamId")
 	s.CreateIndexIfNotExists("idx_teammembers_user_id", "TeamMembers", "UserId")
 	s.CreateIndexIfNotExists("idx_teammembers_delete_at", "TeamMembers", "DeleteAt")
+
+	s.CreateIndexIfNotExists("idx_teaminvites_team_id", "TeamInvites", "TeamId")
+
+	s.CreateIndexIfNotExists("idx_channeltemplates_team_id", "ChannelTemplates", "TeamId")
 }
 
 func (s SqlTeamStore) Save(team *model.Team) store.StoreChannel {
@@ -360,6 +396,24 @@ func (s SqlTeamStore) GetAll() store.StoreChannel {
 	})
 }
 
+func (s SqlTeamStore) GetAutoJoinTeams() store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var data []*model.Team
+		if _, err := s.GetReplica().Select(&data, "SELECT * FROM Teams WHERE AutoJoin = :AutoJoin AND AllowedDomains != '' AND DeleteAt = 0 ORDER BY Id", map[string]interface{}{"AutoJoin": true}); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.GetAutoJoinTeams", "store.sql_team.get_auto_join_teams.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for _, team := range data {
+			if len(team.InviteId) == 0 {
+				team.InviteId = team.Id
+			}
+		}
+
+		result.Data = data
+	})
+}
+
 func (s SqlTeamStore) GetAllPage(offset int, limit int) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		var data []*model.Team
@@ -450,6 +504,22 @@ func (s SqlTeamStore) PermanentDelete(teamId string) store.StoreChannel {
 			result.Err = model.NewAppError("SqlTeamStore.Delete", "store.sql_team.permanent_delete.app_error", nil, "teamId="+teamId+", "+err.Error(), http.StatusInternalServerError)
 			return
 		}
+		if _, err := s.GetMaster().Exec("DELETE FROM TeamStorageUsage WHERE TeamId = :TeamId", map[string]interface{}{"TeamId": teamId}); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.Delete", "store.sql_team.permanent_delete.app_error", nil, "teamId="+teamId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, err := s.GetMaster().Exec("DELETE FROM TeamWaitlist WHERE TeamId = :TeamId", map[string]interface{}{"TeamId": teamId}); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.Delete", "store.sql_team.permanent_delete.app_error", nil, "teamId="+teamId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, err := s.GetMaster().Exec("DELETE FROM TeamInvites WHERE TeamId = :TeamId", map[string]interface{}{"TeamId": teamId}); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.Delete", "store.sql_team.permanent_delete.app_error", nil, "teamId="+teamId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if _, err := s.GetMaster().Exec("DELETE FROM ChannelTemplates WHERE TeamId = :TeamId", map[string]interface{}{"TeamId": teamId}); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.Delete", "store.sql_team.permanent_delete.app_error", nil, "teamId="+teamId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
 	})
 }
 
@@ -589,6 +659,21 @@ func (s SqlTeamStore) GetMembers(teamId string, offset int, limit int) store.Sto
 	})
 }
 
+// GetMembersAfter returns a page of the members of a team ordered by user id, starting after the given user id, so that
+// every member can be paged through without being skipped or repeated.
+func (s SqlTeamStore) GetMembersAfter(teamId string, afterUserId string, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var dbMembers teamMemberWithSchemeRolesList
+		_, err := s.GetReplica().Select(&dbMembers, TEAM_MEMBERS_WITH_SCHEME_SELECT_QUERY+"WHERE TeamMembers.TeamId = :TeamId AND TeamMembers.UserId > :AfterUserId AND TeamMembers.DeleteAt = 0 ORDER BY TeamMembers.UserId LIMIT :Limit", map[string]interface{}{"TeamId": teamId, "AfterUserId": afterUserId, "Limit": limit})
+		if err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.GetMembersAfter", "store.sql_team.get_members.app_error", nil, "teamId="+teamId+" "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = dbMembers.ToModel()
+	})
+}
+
 func (s SqlTeamStore) GetTotalMemberCount(teamId string) store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		count, err := s.GetReplica().SelectInt(`
@@ -980,3 +1065,351 @@ func (s SqlTeamStore) GetTeamMembersForExport(userId string) store.StoreChannel
 		result.Data = members
 	})
 }
+
+// GetStorageUsage returns the number of bytes that the files uploaded to a team take up.
+func (s SqlTeamStore) GetStorageUsage(teamId string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		used, err := s.GetMaster().SelectNullInt("SELECT UsedBytes FROM TeamStorageUsage WHERE TeamId = :TeamId", map[string]interface{}{"TeamId": teamId})
+		if err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.GetStorageUsage", "store.sql_team.get_storage_usage.app_error", nil, "teamId="+teamId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Data = used.Int64
+	})
+}
+
+// UpdateStorageUsage adds delta, which is negative when files are removed, to the storage used by a team. The usage
+// never goes below zero.
+func (s SqlTeamStore) UpdateStorageUsage(teamId string, delta int64) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		update := func() (int64, error) {
+			sqlResult, err := s.GetMaster().Exec(
+				`UPDATE
+					TeamStorageUsage
+				SET
+					UsedBytes = GREATEST(UsedBytes + :Delta, 0)
+				WHERE
+					TeamId = :TeamId`, map[string]interface{}{"Delta": delta, "TeamId": teamId})
+			if err != nil {
+				return 0, err
+			}
+			return sqlResult.RowsAffected()
+		}
+
+		rows, err := update()
+		if err == nil && rows == 0 {
+			usage := &teamStorageUsage{TeamId: teamId}
+			if delta > 0 {
+				usage.UsedBytes = delta
+			}
+
+			// The row may already exist without having changed, or have been inserted by another update since
+			if insertErr := s.GetMaster().Insert(usage); insertErr != nil {
+				_, err = update()
+			}
+		}
+
+		if err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.UpdateStorageUsage", "store.sql_team.update_storage_usage.app_error", nil, "teamId="+teamId+", "+err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// SaveWaitlistEntry adds a user to the waitlist of a team. It returns the entry that's already there if the user is
+// waitlisted already.
+func (s SqlTeamStore) SaveWaitlistEntry(entry *model.TeamWaitlistEntry) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		entry.PreSave()
+		if result.Err = entry.IsValid(); result.Err != nil {
+			return
+		}
+
+		if err := s.GetMaster().Insert(entry); err != nil {
+			if !IsUniqueConstraintError(err, []string{"TeamId", "teamwaitlist_pkey", "PRIMARY"}) {
+				result.Err = model.NewAppError("SqlTeamStore.SaveWaitlistEntry", "store.sql_team.save_waitlist_entry.app_error", nil, "team_id="+entry.TeamId+", user_id="+entry.UserId+", "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if err := s.GetMaster().SelectOne(entry, "SELECT * FROM TeamWaitlist WHERE TeamId = :TeamId AND UserId = :UserId", map[string]interface{}{"TeamId": entry.TeamId, "UserId": entry.UserId}); err != nil {
+				result.Err = model.NewAppError("SqlTeamStore.SaveWaitlistEntry", "store.sql_team.save_waitlist_entry.app_error", nil, "team_id="+entry.TeamId+", user_id="+entry.UserId+", "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		result.Data = entry
+	})
+}
+
+func (s SqlTeamStore) GetWaitlistEntry(teamId string, userId string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var entry model.TeamWaitlistEntry
+		if err := s.GetReplica().SelectOne(&entry, "SELECT * FROM TeamWaitlist WHERE TeamId = :TeamId AND UserId = :UserId", map[string]interface{}{"TeamId": teamId, "UserId": userId}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlTeamStore.GetWaitlistEntry", "store.sql_team.get_waitlist_entry.missing.app_error", nil, "team_id="+teamId+", user_id="+userId, http.StatusNotFound)
+				return
+			}
+			result.Err = model.NewAppError("SqlTeamStore.GetWaitlistEntry", "store.sql_team.get_waitlist_entry.app_error", nil, "team_id="+teamId+", user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Data = &entry
+	})
+}
+
+// GetWaitlist returns a page of the users waiting to join a team, oldest first.
+func (s SqlTeamStore) GetWaitlist(teamId string, offset int, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var entries []*model.TeamWaitlistEntry
+		if _, err := s.GetReplica().Select(&entries,
+			`SELECT
+				*
+			FROM
+				TeamWaitlist
+			WHERE
+				TeamId = :TeamId
+			ORDER BY
+				CreateAt, UserId
+			LIMIT :Limit
+			OFFSET :Offset`, map[string]interface{}{"TeamId": teamId, "Limit": limit, "Offset": offset}); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.GetWaitlist", "store.sql_team.get_waitlist.app_error", nil, "team_id="+teamId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Data = entries
+	})
+}
+
+func (s SqlTeamStore) RemoveWaitlistEntry(teamId string, userId string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if _, err := s.GetMaster().Exec("DELETE FROM TeamWaitlist WHERE TeamId = :TeamId AND UserId = :UserId", map[string]interface{}{"TeamId": teamId, "UserId": userId}); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.RemoveWaitlistEntry", "store.sql_team.remove_waitlist_entry.app_error", nil, "team_id="+teamId+", user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func (s SqlTeamStore) SaveInvite(invite *model.TeamInvite) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		invite.PreSave()
+		if result.Err = invite.IsValid(); result.Err != nil {
+			return
+		}
+
+		if err := s.GetMaster().Insert(invite); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.SaveInvite", "store.sql_team.save_invite.app_error", nil, "team_id="+invite.TeamId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Data = invite
+	})
+}
+
+func (s SqlTeamStore) GetInvite(id string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var invite model.TeamInvite
+		if err := s.GetMaster().SelectOne(&invite, "SELECT * FROM TeamInvites WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlTeamStore.GetInvite", "store.sql_team.get_invite.missing.app_error", nil, "id="+id, http.StatusNotFound)
+				return
+			}
+			result.Err = model.NewAppError("SqlTeamStore.GetInvite", "store.sql_team.get_invite.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Data = &invite
+	})
+}
+
+// GetInvitesForTeam returns the invites to a team that haven't been revoked, newest first, including those that have
+// expired or been used up.
+func (s SqlTeamStore) GetInvitesForTeam(teamId string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var invites []*model.TeamInvite
+		if _, err := s.GetReplica().Select(&invites, "SELECT * FROM TeamInvites WHERE TeamId = :TeamId AND DeleteAt = 0 ORDER BY CreateAt DESC", map[string]interface{}{"TeamId": teamId}); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.GetInvitesForTeam", "store.sql_team.get_invites_for_team.app_error", nil, "team_id="+teamId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Data = invites
+	})
+}
+
+func (s SqlTeamStore) RevokeInvite(id string, time int64) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if _, err := s.GetMaster().Exec("UPDATE TeamInvites SET DeleteAt = :DeleteAt WHERE Id = :Id AND DeleteAt = 0", map[string]interface{}{"DeleteAt": time, "Id": id}); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.RevokeInvite", "store.sql_team.revoke_invite.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// UseInvite counts a use of an invite unless it has been revoked, expired at the given time or been used up, so that
+// the limit holds when several users join with the invite at once. Data is whether the use was counted.
+func (s SqlTeamStore) UseInvite(id string, now int64) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				TeamInvites
+			SET
+				Uses = Uses + 1
+			WHERE
+				Id = :Id
+				AND DeleteAt = 0
+				AND (ExpireAt = 0 OR ExpireAt > :Now)
+				AND (MaxUses = 0 OR Uses < MaxUses)`, map[string]interface{}{"Id": id, "Now": now})
+		if err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.UseInvite", "store.sql_team.use_invite.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rows, err := sqlResult.RowsAffected()
+		if err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.UseInvite", "store.sql_team.use_invite.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Data = rows == 1
+	})
+}
+
+// ReleaseInvite takes back a use of an invite counted by UseInvite, for when the user couldn't join with it after all.
+func (s SqlTeamStore) ReleaseInvite(id string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if _, err := s.GetMaster().Exec("UPDATE TeamInvites SET Uses = Uses - 1 WHERE Id = :Id AND Uses > 0", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.ReleaseInvite", "store.sql_team.release_invite.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func (s SqlTeamStore) SaveChannelTemplate(template *model.ChannelTemplate) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		template.PreSave()
+		if result.Err = template.IsValid(); result.Err != nil {
+			return
+		}
+
+		if err := s.GetMaster().Insert(template); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.SaveChannelTemplate", "store.sql_team.save_channel_template.app_error", nil, "team_id="+template.TeamId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Data = template
+	})
+}
+
+func (s SqlTeamStore) UpdateChannelTemplate(template *model.ChannelTemplate) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		template.PreUpdate()
+		if result.Err = template.IsValid(); result.Err != nil {
+			return
+		}
+
+		count, err := s.GetMaster().Update(template)
+		if err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.UpdateChannelTemplate", "store.sql_team.update_channel_template.app_error", nil, "id="+template.Id+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if count != 1 {
+			result.Err = model.NewAppError("SqlTeamStore.UpdateChannelTemplate", "store.sql_team.get_channel_template.missing.app_error", nil, "id="+template.Id, http.StatusNotFound)
+			return
+		}
+		result.Data = template
+	})
+}
+
+func (s SqlTeamStore) GetChannelTemplate(id string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var template model.ChannelTemplate
+		if err := s.GetReplica().SelectOne(&template, "SELECT * FROM ChannelTemplates WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlTeamStore.GetChannelTemplate", "store.sql_team.get_channel_template.missing.app_error", nil, "id="+id, http.StatusNotFound)
+				return
+			}
+			result.Err = model.NewAppError("SqlTeamStore.GetChannelTemplate", "store.sql_team.get_channel_template.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Data = &template
+	})
+}
+
+// GetChannelTemplatesForTeam returns the channel templates of a team sorted by name.
+func (s SqlTeamStore) GetChannelTemplatesForTeam(teamId string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var templates []*model.ChannelTemplate
+		if _, err := s.GetReplica().Select(&templates, "SELECT * FROM ChannelTemplates WHERE TeamId = :TeamId ORDER BY Name", map[string]interface{}{"TeamId": teamId}); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.GetChannelTemplatesForTeam", "store.sql_team.get_channel_templates_for_team.app_error", nil, "team_id="+teamId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		result.Data = templates
+	})
+}
+
+func (s SqlTeamStore) DeleteChannelTemplate(id string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if _, err := s.GetMaster().Exec("DELETE FROM ChannelTemplates WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.DeleteChannelTemplate", "store.sql_team.delete_channel_template.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// GetOrphanedMembers returns the team members whose team or user doesn't exist, ordered by team id and then user id,
+// starting after the member with the given ids.
+func (s SqlTeamStore) GetOrphanedMembers(afterTeamId string, afterUserId string, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		var members []*model.OrphanedTeamMember
+		if _, err := s.GetReplica().Select(&members, `
+			SELECT
+				TeamMembers.TeamId TeamId,
+				TeamMembers.UserId UserId,
+				Teams.Id IS NOT NULL TeamExists,
+				Users.Id IS NOT NULL UserExists
+			FROM
+				TeamMembers
+				LEFT JOIN Teams ON Teams.Id = TeamMembers.TeamId
+				LEFT JOIN Users ON Users.Id = TeamMembers.UserId
+			WHERE
+				(Teams.Id IS NULL OR Users.Id IS NULL)
+				AND (
+					TeamMembers.TeamId > :AfterTeamId
+					OR (TeamMembers.TeamId = :AfterTeamId AND TeamMembers.UserId > :AfterUserId)
+				)
+			ORDER BY
+				TeamMembers.TeamId, TeamMembers.UserId
+			LIMIT :Limit`, map[string]interface{}{"AfterTeamId": afterTeamId, "AfterUserId": afterUserId, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.GetOrphanedMembers", "store.sql_team.get_orphaned_members.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = members
+	})
+}
+
+// RemoveOrphanedMember deletes a team member if its team or user doesn't exist, checking again as it does so in case
+// either was created since the member was found. It returns whether the member was deleted.
+func (s SqlTeamStore) RemoveOrphanedMember(teamId string, userId string) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		sqlResult, err := s.GetMaster().Exec(`
+			DELETE FROM
+				TeamMembers
+			WHERE
+				TeamId = :TeamId
+				AND UserId = :UserId
+				AND (
+					NOT EXISTS (SELECT 1 FROM Teams WHERE Teams.Id = :TeamId)
+					OR NOT EXISTS (SELECT 1 FROM Users WHERE Users.Id = :UserId)
+				)`, map[string]interface{}{"TeamId": teamId, "UserId": userId})
+		if err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.RemoveOrphanedMember", "store.sql_team.remove_orphaned_member.app_error", nil, "team_id="+teamId+", user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rowsAffected, err := sqlResult.RowsAffected()
+		if err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.RemoveOrphanedMember", "store.sql_team.remove_orphaned_member.app_error", nil, "team_id="+teamId+", user_id="+userId+", "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = rowsAffected > 0
+	})
+}
+
+// ResetStorageUsage forgets the storage used by every team, so that it can be counted again from the files that have
+// been uploaded.
+func (s SqlTeamStore) ResetStorageUsage() store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		if _, err := s.GetMaster().Exec("DELETE FROM TeamStorageUsage"); err != nil {
+			result.Err = model.NewAppError("SqlTeamStore.ResetStorageUsage", "store.sql_team.reset_storage_usage.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -39,7 +39,11 @@ func (s SqlTokenStore) Save(token *model.Token) store.StoreChannel {
 		}
 
 		if err := s.GetMaster().Insert(token); err != nil {
-			result.Err = model.NewAppError("SqlTokenStore.Save", "store.sql_recover.save.app_error", nil, "", http.StatusInternalServerError)
+			if IsUniqueConstraintError(err, []string{"PRIMARY", "tokens_pkey"}) {
+				result.Err = model.NewAppError("SqlTokenStore.Save", "store.sql_recover.save.exists.app_error", nil, "", http.StatusBadRequest)
+			} else {
+				result.Err = model.NewAppError("SqlTokenStore.Save", "store.sql_recover.save.app_error", nil, "", http.StatusInternalServerError)
+			}
 		}
 	})
 }
@@ -70,8 +74,9 @@ func (s SqlTokenStore) GetByToken(tokenString string) store.StoreChannel {
 
 func (s SqlTokenStore) Cleanup() {
 	mlog.Debug("Cleaning up token store.")
-	deltime := model.GetMillis() - model.MAX_TOKEN_EXIPRY_TIME
-	if _, err := s.GetMaster().Exec("DELETE FROM Tokens WHERE CreateAt < :DelTime", map[string]interface{}{"DelTime": deltime}); err != nil {
+	now := model.GetMillis()
+	deltime := now - model.MAX_TOKEN_EXIPRY_TIME
+	if _, err := s.GetMaster().Exec("DELETE FROM Tokens WHERE (ExpireAt = 0 AND CreateAt < :DelTime) OR (ExpireAt > 0 AND ExpireAt < :Now)", map[string]interface{}{"DelTime": deltime, "Now": now}); err != nil {
 		mlog.Error("Unable to cleanup token store.")
 	}
 }
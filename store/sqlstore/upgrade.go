@@ -569,6 +569,25 @@ func UpgradeDatabaseToVersion58(sqlStore SqlStore) {
 
 func UpgradeDatabaseToVersion59(sqlStore SqlStore) {
 	if shouldPerformUpgrade(sqlStore, VERSION_5_8_0, VERSION_5_9_0) {
+		sqlStore.CreateColumnIfNotExists("Posts", "PinnedUntil", "bigint(20)", "bigint", "0")
+		sqlStore.CreateColumnIfNotExists("Channels", "BannerPostId", "varchar(26)", "varchar(26)", "")
+		sqlStore.CreateColumnIfNotExists("Channels", "MaxPostSize", "int(11)", "integer", "0")
+		sqlStore.CreateColumnIfNotExists("Teams", "MaxPostSize", "int(11)", "integer", "0")
+		sqlStore.CreateColumnIfNotExists("Channels", "SuppressJoinLeaveMessages", "boolean", "boolean", "0")
+		sqlStore.CreateColumnIfNotExists("Teams", "ChannelNamePattern", "varchar(128)", "varchar(128)", "")
+		sqlStore.CreateColumnIfNotExists("Teams", "ChannelDisplayNamePattern", "varchar(128)", "varchar(128)", "")
+		sqlStore.CreateColumnIfNotExists("Channels", "ExemptFromArchiving", "boolean", "boolean", "0")
+		sqlStore.CreateColumnIfNotExists("Teams", "InactiveChannelDays", "int(11)", "integer", "0")
+		sqlStore.CreateColumnIfNotExists("ClusterDiscovery", "Tags", "varchar(512)", "varchar(512)", "[]")
+		sqlStore.CreateColumnIfNotExists("FileInfo", "StorageTier", "varchar(16)", "varchar(16)", "")
+		sqlStore.CreateColumnIfNotExists("FileInfo", "Checksum", "varchar(64)", "varchar(64)", "")
+		sqlStore.CreateColumnIfNotExists("Teams", "StorageQuota", "bigint(20)", "bigint", "0")
+		sqlStore.CreateColumnIfNotExists("Teams", "MaxMembers", "int(11)", "integer", "0")
+		sqlStore.CreateColumnIfNotExists("Teams", "AllowWaitlist", "boolean", "boolean", "0")
+		sqlStore.CreateColumnIfNotExists("Teams", "AutoJoin", "boolean", "boolean", "0")
+		sqlStore.CreateColumnIfNotExists("Preferences", "UpdateAt", "bigint(20)", "bigint", "0")
+		sqlStore.CreateColumnIfNotExists("Tokens", "ExpireAt", "bigint(20)", "bigint", "0")
+
 		saveSchemaVersion(sqlStore, VERSION_5_9_0)
 	}
 }
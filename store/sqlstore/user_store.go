@@ -353,6 +353,48 @@ func (us SqlUserStore) GetAllAfter(limit int, afterId string) store.StoreChannel
 	})
 }
 
+// GetLastActivityAfter returns, ordered by user id, the last activity of users whose id sorts after afterId.
+// If inactiveSince is non-zero, only users with no activity since then are returned.
+func (us SqlUserStore) GetLastActivityAfter(afterId string, inactiveSince int64, limit int) store.StoreChannel {
+	return store.Do(func(result *store.StoreResult) {
+		query := `
+			SELECT
+				Users.Id AS UserId,
+				Users.Username,
+				Users.Email,
+				Users.AuthService,
+				Users.Roles,
+				Users.Locale,
+				Users.CreateAt,
+				Users.DeleteAt,
+				Users.LastPasswordUpdate,
+				GREATEST(COALESCE(MAX(Status.LastActivityAt), 0), COALESCE(MAX(Sessions.LastActivityAt), 0)) AS LastActivityAt
+			FROM Users
+				LEFT JOIN Status ON Status.UserId = Users.Id
+				LEFT JOIN Sessions ON Sessions.UserId = Users.Id
+			WHERE
+				Users.Id > :AfterId
+			GROUP BY Users.Id, Users.Username, Users.Email, Users.AuthService, Users.Roles, Users.Locale, Users.CreateAt, Users.DeleteAt, Users.LastPasswordUpdate`
+
+		if inactiveSince > 0 {
+			query += `
+			HAVING GREATEST(COALESCE(MAX(Status.LastActivityAt), 0), COALESCE(MAX(Sessions.LastActivityAt), 0)) < :InactiveSince`
+		}
+
+		query += `
+			ORDER BY Users.Id
+			LIMIT :Limit`
+
+		var data []*model.UserLastActivity
+		if _, err := us.GetReplica().Select(&data, query, map[string]interface{}{"AfterId": afterId, "InactiveSince": inactiveSince, "Limit": limit}); err != nil {
+			result.Err = model.NewAppError("SqlUserStore.GetLastActivityAfter", "store.sql_user.get_last_activity.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result.Data = data
+	})
+}
+
 func (s SqlUserStore) GetEtagForAllProfiles() store.StoreChannel {
 	return store.Do(func(result *store.StoreResult) {
 		updateAt, err := s.GetReplica().SelectInt("SELECT UpdateAt FROM Users ORDER BY UpdateAt DESC LIMIT 1")
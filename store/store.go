@@ -69,6 +69,8 @@ type Store interface {
 	Group() GroupStore
 	UserTermsOfService() UserTermsOfServiceStore
 	LinkMetadata() LinkMetadataStore
+	ClusterLock() ClusterLockStore
+	IdempotentRequest() IdempotentRequestStore
 	MarkSystemRanUnitTests()
 	Close()
 	LockToMaster()
@@ -77,6 +79,8 @@ type Store interface {
 	TotalMasterDbConnections() int
 	TotalReadDbConnections() int
 	TotalSearchDbConnections() int
+	DbPoolStats() []*model.DbPoolStats
+	CacheStats() []*model.CacheStats
 }
 
 type TeamStore interface {
@@ -90,6 +94,7 @@ type TeamStore interface {
 	SearchOpen(term string) StoreChannel
 	GetAll() StoreChannel
 	GetAllPage(offset int, limit int) StoreChannel
+	GetAutoJoinTeams() StoreChannel
 	GetAllTeamListing() StoreChannel
 	GetAllTeamPageListing(offset int, limit int) StoreChannel
 	GetTeamsByUserId(userId string) StoreChannel
@@ -100,6 +105,7 @@ type TeamStore interface {
 	UpdateMember(member *model.TeamMember) StoreChannel
 	GetMember(teamId string, userId string) StoreChannel
 	GetMembers(teamId string, offset int, limit int) StoreChannel
+	GetMembersAfter(teamId string, afterUserId string, limit int) StoreChannel
 	GetMembersByIds(teamId string, userIds []string) StoreChannel
 	GetTotalMemberCount(teamId string) StoreChannel
 	GetActiveMemberCount(teamId string) StoreChannel
@@ -117,6 +123,26 @@ type TeamStore interface {
 	AnalyticsGetTeamCountForScheme(schemeId string) StoreChannel
 	GetAllForExportAfter(limit int, afterId string) StoreChannel
 	GetTeamMembersForExport(userId string) StoreChannel
+	GetStorageUsage(teamId string) StoreChannel
+	UpdateStorageUsage(teamId string, delta int64) StoreChannel
+	ResetStorageUsage() StoreChannel
+	SaveWaitlistEntry(entry *model.TeamWaitlistEntry) StoreChannel
+	GetWaitlistEntry(teamId string, userId string) StoreChannel
+	GetWaitlist(teamId string, offset int, limit int) StoreChannel
+	RemoveWaitlistEntry(teamId string, userId string) StoreChannel
+	SaveInvite(invite *model.TeamInvite) StoreChannel
+	GetInvite(id string) StoreChannel
+	GetInvitesForTeam(teamId string) StoreChannel
+	RevokeInvite(id string, time int64) StoreChannel
+	UseInvite(id string, now int64) StoreChannel
+	ReleaseInvite(id string) StoreChannel
+	SaveChannelTemplate(template *model.ChannelTemplate) StoreChannel
+	UpdateChannelTemplate(template *model.ChannelTemplate) StoreChannel
+	GetChannelTemplate(id string) StoreChannel
+	GetChannelTemplatesForTeam(teamId string) StoreChannel
+	DeleteChannelTemplate(id string) StoreChannel
+	GetOrphanedMembers(afterTeamId string, afterUserId string, limit int) StoreChannel
+	RemoveOrphanedMember(teamId string, userId string) StoreChannel
 }
 
 type ChannelStore interface {
@@ -135,10 +161,14 @@ type ChannelStore interface {
 	PermanentDelete(channelId string) StoreChannel
 	GetByName(team_id string, name string, allowFromCache bool) StoreChannel
 	GetByNames(team_id string, names []string, allowFromCache bool) StoreChannel
+	GetByIds(channelIds []string, allowFromCache bool) StoreChannel
 	GetByNameIncludeDeleted(team_id string, name string, allowFromCache bool) StoreChannel
 	GetDeletedByName(team_id string, name string) StoreChannel
 	GetDeleted(team_id string, offset int, limit int) StoreChannel
+	GetInactive(teamId string, before int64) StoreChannel
 	GetChannels(teamId string, userId string, includeDeleted bool) StoreChannel
+	GetCommonChannels(userId string, otherUserId string) StoreChannel
+	GetCommonChannelCounts(userId string, otherUserIds []string) StoreChannel
 	GetAllChannels(page, perPage int, includeDeleted bool) StoreChannel
 	GetMoreChannels(teamId string, userId string, offset int, limit int) StoreChannel
 	GetPublicChannelsForTeam(teamId string, offset int, limit int) StoreChannel
@@ -148,8 +178,13 @@ type ChannelStore interface {
 	GetAll(teamId string) StoreChannel
 	GetForPost(postId string) StoreChannel
 	SaveMember(member *model.ChannelMember) StoreChannel
+	SaveMultipleMembers(members []*model.ChannelMember) StoreChannel
 	UpdateMember(member *model.ChannelMember) StoreChannel
+	UpdateMultipleMembers(members []*model.ChannelMember) StoreChannel
 	GetMembers(channelId string, offset, limit int) StoreChannel
+	GetMembersForTeamAfter(teamId string, afterChannelId string, afterUserId string, limit int) StoreChannel
+	GetMembersSorted(channelId string, sort string, offset, limit int) StoreChannel
+	SearchMembers(channelId string, term string, role string, allowFullNames bool, offset, limit int) StoreChannel
 	GetMember(channelId string, userId string) StoreChannel
 	GetChannelMembersTimezones(channelId string) StoreChannel
 	GetAllChannelMembersForUser(userId string, allowFromCache bool, includeDeleted bool) StoreChannel
@@ -176,6 +211,7 @@ type ChannelStore interface {
 	SearchMore(userId string, teamId string, term string) StoreChannel
 	GetMembersByIds(channelId string, userIds []string) StoreChannel
 	AnalyticsDeletedTypeCount(teamId string, channelType string) StoreChannel
+	AnalyticsCreatedCount(teamId string, since int64, until int64) StoreChannel
 	GetChannelUnread(channelId, userId string) StoreChannel
 	ClearCaches()
 	GetChannelsByScheme(schemeId string, offset int, limit int) StoreChannel
@@ -186,6 +222,12 @@ type ChannelStore interface {
 	GetAllChannelsForExportAfter(limit int, afterId string) StoreChannel
 	GetChannelMembersForExport(userId string, teamId string) StoreChannel
 	RemoveAllDeactivatedMembers(channelId string) StoreChannel
+	MoveToTeam(channel *model.Channel, teamId string, name string, removeMembersNotInTeam bool) StoreChannel
+	MergeInto(source *model.Channel, target *model.Channel) StoreChannel
+	GetMsgCountsForRepair(teamId string, channelId string, afterId string, limit int) StoreChannel
+	RepairMsgCounts(counts *model.ChannelMsgCounts) StoreChannel
+	GetOrphanedMembers(afterChannelId string, afterUserId string, limit int) StoreChannel
+	RemoveOrphanedMember(channelId string, userId string) StoreChannel
 }
 
 type ChannelMemberHistoryStore interface {
@@ -207,25 +249,39 @@ type PostStore interface {
 	GetFlaggedPosts(userId string, offset int, limit int) StoreChannel
 	GetFlaggedPostsForTeam(userId, teamId string, offset int, limit int) StoreChannel
 	GetFlaggedPostsForChannel(userId, channelId string, offset int, limit int) StoreChannel
+	GetDeletedPostsForChannel(channelId string, offset int, limit int) StoreChannel
+	GetExpiredPinnedPosts(before int64, limit int) StoreChannel
 	GetPostsBefore(channelId string, postId string, numPosts int, offset int) StoreChannel
 	GetPostsAfter(channelId string, postId string, numPosts int, offset int) StoreChannel
 	GetPostsSince(channelId string, time int64, allowFromCache bool) StoreChannel
+	GetPostsWithTermsInChannel(channelId string, terms []string, since int64, until int64, offset int, limit int) StoreChannel
+	GetCatchUpForUser(userId string, since int64, sinceLastViewed bool, collapsedThreads bool, offset int, limit int) StoreChannel
 	GetEtag(channelId string, allowFromCache bool) StoreChannel
 	Search(teamId string, userId string, params *model.SearchParams) StoreChannel
+	SearchInTeams(teamIds []string, userId string, params *model.SearchParams) StoreChannel
+	SearchInTeamsPage(teamIds []string, userId string, params *model.SearchParams, offset int, limit int) StoreChannel
 	AnalyticsUserCountsWithPostsByDay(teamId string) StoreChannel
 	AnalyticsPostCountsByDay(teamId string) StoreChannel
+	AnalyticsPostCountsByInterval(channelId string, since int64, until int64, interval int64) StoreChannel
 	AnalyticsPostCount(teamId string, mustHaveFile bool, mustHaveHashtag bool) StoreChannel
+	AnalyticsTeamActivityByDay(teamId string, since int64, until int64) StoreChannel
+	AnalyticsPostersCount(teamId string, since int64, until int64) StoreChannel
 	ClearCaches()
 	InvalidateLastPostTimeCache(channelId string)
 	GetPostsCreatedAt(channelId string, time int64) StoreChannel
 	Overwrite(post *model.Post) StoreChannel
+	OverwriteIfUnchanged(post *model.Post, oldUpdateAt int64) StoreChannel
+	MoveToChannel(posts []*model.Post, fromChannelId string, toChannelId string) StoreChannel
 	GetPostsByIds(postIds []string) StoreChannel
 	GetPostsBatchForIndexing(startTime int64, endTime int64, limit int) StoreChannel
+	GetPostsBatchForChannelIndexing(channelId string, afterCreateAt int64, afterId string, limit int) StoreChannel
 	PermanentDeleteBatch(endTime int64, limit int64) StoreChannel
 	GetOldest() StoreChannel
 	GetMaxPostSize() StoreChannel
 	GetParentsForExportAfter(limit int, afterId string) StoreChannel
 	GetRepliesForExport(parentId string) StoreChannel
+	SaveHereMentions(mentions *model.PostHereMentions) StoreChannel
+	GetHereMentions(postId string) StoreChannel
 }
 
 type UserStore interface {
@@ -284,6 +340,7 @@ type UserStore interface {
 	ClearAllCustomRoleAssignments() StoreChannel
 	InferSystemInstallDate() StoreChannel
 	GetAllAfter(limit int, afterId string) StoreChannel
+	GetLastActivityAfter(afterId string, inactiveSince int64, limit int) StoreChannel
 }
 
 type SessionStore interface {
@@ -297,6 +354,7 @@ type SessionStore interface {
 	UpdateLastActivityAt(sessionId string, time int64) StoreChannel
 	UpdateRoles(userId string, roles string) StoreChannel
 	UpdateDeviceId(id string, deviceId string, expiresAt int64) StoreChannel
+	UpdateProps(session *model.Session) StoreChannel
 	AnalyticsSessionCount() StoreChannel
 	Cleanup(expiryTime int64, batchSize int64)
 }
@@ -404,6 +462,7 @@ type CommandWebhookStore interface {
 
 type PreferenceStore interface {
 	Save(preferences *model.Preferences) StoreChannel
+	SaveNewer(preferences *model.Preferences) StoreChannel
 	Get(userId string, category string, name string) StoreChannel
 	GetCategory(userId string, category string) StoreChannel
 	GetAll(userId string) StoreChannel
@@ -446,6 +505,7 @@ type StatusStore interface {
 	GetAllFromTeam(teamId string) StoreChannel
 	ResetAll() StoreChannel
 	GetTotalActiveUsersCount() StoreChannel
+	GetRecentlyActive(limit int) StoreChannel
 	UpdateLastActivityAt(userId string, lastActivityAt int64) StoreChannel
 }
 
@@ -455,12 +515,21 @@ type FileInfoStore interface {
 	GetByPath(path string) StoreChannel
 	GetForPost(postId string, readFromMaster bool, allowFromCache bool) StoreChannel
 	GetForUser(userId string) StoreChannel
+	Search(teamId string, userId string, params *model.SearchParams, offset int, limit int) StoreChannel
 	InvalidateFileInfosForPostCache(postId string)
 	AttachToPost(fileId string, postId string, creatorId string) StoreChannel
 	DeleteForPost(postId string) StoreChannel
 	PermanentDelete(fileId string) StoreChannel
 	PermanentDeleteBatch(endTime int64, limit int64) StoreChannel
 	PermanentDeleteByUser(userId string) StoreChannel
+	GetOrphaned(before int64, afterId string, limit int) StoreChannel
+	GetExistingIds(ids []string) StoreChannel
+	GetForColdStorage(before int64, afterId string, limit int) StoreChannel
+	UpdateStorageTier(fileId string, storageTier string) StoreChannel
+	GetBatchForIntegrityScan(afterId string, limit int) StoreChannel
+	CountByPath(path string) StoreChannel
+	GetBatchForStorageUsage(afterId string, before int64, limit int) StoreChannel
+	GetStorageSizeForChannel(channelId string) StoreChannel
 	ClearCaches()
 }
 
@@ -471,6 +540,7 @@ type ReactionStore interface {
 	DeleteAllWithEmojiName(emojiName string) StoreChannel
 	PermanentDeleteBatch(endTime int64, limit int64) StoreChannel
 	BulkGetForPosts(postIds []string) StoreChannel
+	GetUserIdsForEmojiInChannel(channelId string, emojiName string, since int64, offset int, limit int) StoreChannel
 }
 
 type JobStore interface {
@@ -478,6 +548,7 @@ type JobStore interface {
 	UpdateOptimistically(job *model.Job, currentStatus string) StoreChannel
 	UpdateStatus(id string, status string) StoreChannel
 	UpdateStatusOptimistically(id string, currentStatus string, newStatus string) StoreChannel
+	UpdateLastActivityAt(id string, status string) StoreChannel
 	Get(id string) StoreChannel
 	GetAllPage(offset int, limit int) StoreChannel
 	GetAllByType(jobType string) StoreChannel
@@ -569,3 +640,18 @@ type LinkMetadataStore interface {
 	Save(linkMetadata *model.LinkMetadata) StoreChannel
 	Get(url string, timestamp int64) StoreChannel
 }
+
+type ClusterLockStore interface {
+	Acquire(lock *model.ClusterLock) StoreChannel
+	Renew(lock *model.ClusterLock) StoreChannel
+	Release(name string, owner string) StoreChannel
+	Get(name string) StoreChannel
+}
+
+type IdempotentRequestStore interface {
+	Save(request *model.IdempotentRequest) StoreChannel
+	Get(id string) StoreChannel
+	Complete(request *model.IdempotentRequest) StoreChannel
+	Delete(id string) StoreChannel
+	Cleanup()
+}
@@ -46,11 +46,17 @@ func TestChannelStore(t *testing.T, ss store.Store, s SqlSupplier) {
 	t.Run("Delete", func(t *testing.T) { testChannelStoreDelete(t, ss) })
 	t.Run("GetByName", func(t *testing.T) { testChannelStoreGetByName(t, ss) })
 	t.Run("GetByNames", func(t *testing.T) { testChannelStoreGetByNames(t, ss) })
+	t.Run("GetByIds", func(t *testing.T) { testChannelStoreGetByIds(t, ss) })
 	t.Run("GetDeletedByName", func(t *testing.T) { testChannelStoreGetDeletedByName(t, ss) })
 	t.Run("GetDeleted", func(t *testing.T) { testChannelStoreGetDeleted(t, ss) })
+	t.Run("GetInactive", func(t *testing.T) { testChannelStoreGetInactive(t, ss) })
 	t.Run("ChannelMemberStore", func(t *testing.T) { testChannelMemberStore(t, ss) })
+	t.Run("SaveMultipleMembers", func(t *testing.T) { testChannelStoreSaveMultipleMembers(t, ss) })
+	t.Run("UpdateMultipleMembers", func(t *testing.T) { testChannelStoreUpdateMultipleMembers(t, ss) })
 	t.Run("ChannelDeleteMemberStore", func(t *testing.T) { testChannelDeleteMemberStore(t, ss) })
 	t.Run("GetChannels", func(t *testing.T) { testChannelStoreGetChannels(t, ss) })
+	t.Run("GetCommonChannels", func(t *testing.T) { testChannelStoreGetCommonChannels(t, ss) })
+	t.Run("GetCommonChannelCounts", func(t *testing.T) { testChannelStoreGetCommonChannelCounts(t, ss) })
 	t.Run("GetAllChannels", func(t *testing.T) { testChannelStoreGetAllChannels(t, ss) })
 	t.Run("GetMoreChannels", func(t *testing.T) { testChannelStoreGetMoreChannels(t, ss) })
 	t.Run("GetPublicChannelsForTeam", func(t *testing.T) { testChannelStoreGetPublicChannelsForTeam(t, ss) })
@@ -68,7 +74,11 @@ func TestChannelStore(t *testing.T, ss store.Store, s SqlSupplier) {
 	t.Run("SearchAllChannels", func(t *testing.T) { testChannelStoreSearchAllChannels(t, ss) })
 	t.Run("AutocompleteInTeamForSearch", func(t *testing.T) { testChannelStoreAutocompleteInTeamForSearch(t, ss) })
 	t.Run("GetMembersByIds", func(t *testing.T) { testChannelStoreGetMembersByIds(t, ss) })
+	t.Run("GetMembersSorted", func(t *testing.T) { testChannelStoreGetMembersSorted(t, ss) })
+	t.Run("GetMembersForTeamAfter", func(t *testing.T) { testChannelStoreGetMembersForTeamAfter(t, ss) })
+	t.Run("SearchMembers", func(t *testing.T) { testChannelStoreSearchMembers(t, ss) })
 	t.Run("AnalyticsDeletedTypeCount", func(t *testing.T) { testChannelStoreAnalyticsDeletedTypeCount(t, ss) })
+	t.Run("AnalyticsCreatedCount", func(t *testing.T) { testChannelStoreAnalyticsCreatedCount(t, ss) })
 	t.Run("GetPinnedPosts", func(t *testing.T) { testChannelStoreGetPinnedPosts(t, ss) })
 	t.Run("MaxChannelsPerTeam", func(t *testing.T) { testChannelStoreMaxChannelsPerTeam(t, ss) })
 	t.Run("GetChannelsByScheme", func(t *testing.T) { testChannelStoreGetChannelsByScheme(t, ss) })
@@ -79,6 +89,10 @@ func TestChannelStore(t *testing.T, ss store.Store, s SqlSupplier) {
 	t.Run("GetAllChannelsForExportAfter", func(t *testing.T) { testChannelStoreGetAllChannelsForExportAfter(t, ss) })
 	t.Run("GetChannelMembersForExport", func(t *testing.T) { testChannelStoreGetChannelMembersForExport(t, ss) })
 	t.Run("RemoveAllDeactivatedMembers", func(t *testing.T) { testChannelStoreRemoveAllDeactivatedMembers(t, ss) })
+	t.Run("MoveToTeam", func(t *testing.T) { testChannelStoreMoveToTeam(t, ss) })
+	t.Run("MergeInto", func(t *testing.T) { testChannelStoreMergeInto(t, ss) })
+	t.Run("RepairMsgCounts", func(t *testing.T) { testChannelStoreRepairMsgCounts(t, ss) })
+	t.Run("OrphanedMembers", func(t *testing.T) { testChannelStoreOrphanedMembers(t, ss) })
 }
 
 func testChannelStoreSave(t *testing.T, ss store.Store) {
@@ -646,6 +660,46 @@ func testChannelStoreGetByNames(t *testing.T, ss store.Store) {
 	assert.Len(t, channels, 0)
 }
 
+func testChannelStoreGetByIds(t *testing.T, ss store.Store) {
+	o1 := store.Must(ss.Channel().Save(&model.Channel{
+		TeamId:      model.NewId(),
+		DisplayName: "Name",
+		Name:        "zz" + model.NewId() + "b",
+		Type:        model.CHANNEL_OPEN,
+	}, -1)).(*model.Channel)
+
+	o2 := store.Must(ss.Channel().Save(&model.Channel{
+		TeamId:      o1.TeamId,
+		DisplayName: "Name",
+		Name:        "zz" + model.NewId() + "b",
+		Type:        model.CHANNEL_OPEN,
+	}, -1)).(*model.Channel)
+	store.Must(ss.Channel().Delete(o2.Id, model.GetMillis()))
+
+	for _, allowFromCache := range []bool{false, true} {
+		for index, tc := range []struct {
+			Ids         []string
+			ExpectedIds []string
+		}{
+			{[]string{o1.Id}, []string{o1.Id}},
+			{[]string{o1.Id, o2.Id}, []string{o1.Id, o2.Id}},
+			{nil, nil},
+			{[]string{model.NewId()}, nil},
+			{[]string{o1.Id, model.NewId(), o2.Id, o2.Id}, []string{o1.Id, o2.Id}},
+		} {
+			r := <-ss.Channel().GetByIds(tc.Ids, allowFromCache)
+			require.Nil(t, r.Err)
+			var ids []string
+			for _, channel := range r.Data.([]*model.Channel) {
+				ids = append(ids, channel.Id)
+			}
+			sort.Strings(ids)
+			sort.Strings(tc.ExpectedIds)
+			assert.Equal(t, tc.ExpectedIds, ids, "tc %v, allowFromCache %v", index, allowFromCache)
+		}
+	}
+}
+
 func testChannelStoreGetDeletedByName(t *testing.T, ss store.Store) {
 	o1 := model.Channel{}
 	o1.TeamId = model.NewId()
@@ -671,6 +725,37 @@ func testChannelStoreGetDeletedByName(t *testing.T, ss store.Store) {
 	}
 }
 
+func testChannelStoreGetInactive(t *testing.T, ss store.Store) {
+	teamId := model.NewId()
+
+	o1 := &model.Channel{TeamId: teamId, DisplayName: "Channel1", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}
+	store.Must(ss.Channel().Save(o1, -1))
+
+	o2 := &model.Channel{TeamId: teamId, DisplayName: "Channel2", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_PRIVATE}
+	store.Must(ss.Channel().Save(o2, -1))
+
+	o3 := &model.Channel{TeamId: teamId, DisplayName: "Channel3", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}
+	store.Must(ss.Channel().Save(o3, -1))
+	store.Must(ss.Channel().Delete(o3.Id, model.GetMillis()))
+
+	o4 := &model.Channel{TeamId: model.NewId(), DisplayName: "Channel4", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}
+	store.Must(ss.Channel().Save(o4, -1))
+
+	before := model.GetMillis() + 1000
+
+	store.Must(ss.Post().Save(&model.Post{ChannelId: o2.Id, UserId: model.NewId(), Message: "message", CreateAt: before + 1}))
+
+	result := <-ss.Channel().GetInactive(teamId, before)
+	require.Nil(t, result.Err)
+	list := result.Data.(*model.ChannelList)
+	require.Len(t, *list, 1)
+	assert.Equal(t, o1.Id, (*list)[0].Id)
+
+	result = <-ss.Channel().GetInactive(teamId, o1.CreateAt)
+	require.Nil(t, result.Err)
+	assert.Empty(t, *result.Data.(*model.ChannelList), "channels created after the cutoff should not be inactive")
+}
+
 func testChannelStoreGetDeleted(t *testing.T, ss store.Store) {
 	o1 := model.Channel{}
 	o1.TeamId = model.NewId()
@@ -751,6 +836,82 @@ func testChannelStoreGetDeleted(t *testing.T, ss store.Store) {
 
 }
 
+func testChannelStoreSaveMultipleMembers(t *testing.T, ss store.Store) {
+	channel := store.Must(ss.Channel().Save(&model.Channel{
+		TeamId:      model.NewId(),
+		DisplayName: "NameName",
+		Name:        "zz" + model.NewId() + "b",
+		Type:        model.CHANNEL_OPEN,
+	}, -1)).(*model.Channel)
+
+	var members []*model.ChannelMember
+	for i := 0; i < 3; i++ {
+		user := store.Must(ss.User().Save(&model.User{Email: MakeEmail(), Nickname: model.NewId()})).(*model.User)
+		members = append(members, &model.ChannelMember{ChannelId: channel.Id, UserId: user.Id, NotifyProps: model.GetDefaultChannelNotifyProps()})
+	}
+
+	result := <-ss.Channel().SaveMultipleMembers(members[:2])
+	require.Nil(t, result.Err)
+	saved := result.Data.([]*model.ChannelMember)
+	require.Len(t, saved, 2)
+	assert.Equal(t, members[0].UserId, saved[0].UserId)
+	assert.Equal(t, members[1].UserId, saved[1].UserId)
+
+	// A member that already exists fails the whole batch.
+	result = <-ss.Channel().SaveMultipleMembers([]*model.ChannelMember{
+		{ChannelId: channel.Id, UserId: members[2].UserId, NotifyProps: model.GetDefaultChannelNotifyProps()},
+		{ChannelId: channel.Id, UserId: members[0].UserId, NotifyProps: model.GetDefaultChannelNotifyProps()},
+	})
+	require.NotNil(t, result.Err)
+
+	count := (<-ss.Channel().GetMemberCount(channel.Id, false)).Data.(int64)
+	assert.EqualValues(t, 2, count)
+
+	result = <-ss.Channel().SaveMultipleMembers([]*model.ChannelMember{})
+	require.Nil(t, result.Err)
+	assert.Empty(t, result.Data.([]*model.ChannelMember))
+}
+
+func testChannelStoreUpdateMultipleMembers(t *testing.T, ss store.Store) {
+	channel := store.Must(ss.Channel().Save(&model.Channel{
+		TeamId:      model.NewId(),
+		DisplayName: "NameName",
+		Name:        "zz" + model.NewId() + "b",
+		Type:        model.CHANNEL_OPEN,
+	}, -1)).(*model.Channel)
+
+	var members []*model.ChannelMember
+	for i := 0; i < 2; i++ {
+		user := store.Must(ss.User().Save(&model.User{Email: MakeEmail(), Nickname: model.NewId()})).(*model.User)
+		members = append(members, store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: channel.Id, UserId: user.Id, SchemeUser: true, NotifyProps: model.GetDefaultChannelNotifyProps()})).(*model.ChannelMember))
+	}
+
+	members[0].SchemeAdmin = true
+	members[1].ExplicitRoles = "custom_role"
+
+	result := <-ss.Channel().UpdateMultipleMembers(members)
+	require.Nil(t, result.Err)
+	updated := result.Data.([]*model.ChannelMember)
+	require.Len(t, updated, 2)
+	assert.True(t, updated[0].SchemeAdmin)
+	assert.Equal(t, "custom_role", updated[1].ExplicitRoles)
+
+	// An invalid member fails the whole batch.
+	members[0].SchemeAdmin = false
+	result = <-ss.Channel().UpdateMultipleMembers([]*model.ChannelMember{
+		members[0],
+		{ChannelId: channel.Id, UserId: "invalid", NotifyProps: model.GetDefaultChannelNotifyProps()},
+	})
+	require.NotNil(t, result.Err)
+
+	member := store.Must(ss.Channel().GetMember(channel.Id, members[0].UserId)).(*model.ChannelMember)
+	assert.True(t, member.SchemeAdmin)
+
+	result = <-ss.Channel().UpdateMultipleMembers([]*model.ChannelMember{})
+	require.Nil(t, result.Err)
+	assert.Empty(t, result.Data.([]*model.ChannelMember))
+}
+
 func testChannelMemberStore(t *testing.T, ss store.Store) {
 	c1 := model.Channel{}
 	c1.TeamId = model.NewId()
@@ -1387,6 +1548,85 @@ func testChannelStoreGetChannelCounts(t *testing.T, ss store.Store) {
 	}
 }
 
+func testChannelStoreGetCommonChannels(t *testing.T, ss store.Store) {
+	u1 := store.Must(ss.User().Save(&model.User{Email: MakeEmail(), Username: "a" + model.NewId()})).(*model.User)
+	u2 := store.Must(ss.User().Save(&model.User{Email: MakeEmail(), Username: "a" + model.NewId()})).(*model.User)
+
+	teamId := model.NewId()
+	leftTeamId := model.NewId()
+	store.Must(ss.Team().SaveMember(&model.TeamMember{TeamId: teamId, UserId: u1.Id}, -1))
+	store.Must(ss.Team().SaveMember(&model.TeamMember{TeamId: teamId, UserId: u2.Id}, -1))
+	store.Must(ss.Team().SaveMember(&model.TeamMember{TeamId: leftTeamId, UserId: u1.Id, DeleteAt: model.GetMillis()}, -1))
+	store.Must(ss.Team().SaveMember(&model.TeamMember{TeamId: leftTeamId, UserId: u2.Id}, -1))
+
+	makeChannel := func(teamId string, deleteAt int64, userIds ...string) *model.Channel {
+		channel := store.Must(ss.Channel().Save(&model.Channel{
+			TeamId:      teamId,
+			DisplayName: "Channel" + model.NewId(),
+			Name:        "zz" + model.NewId() + "b",
+			Type:        model.CHANNEL_OPEN,
+			DeleteAt:    deleteAt,
+		}, -1)).(*model.Channel)
+
+		for _, userId := range userIds {
+			store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: channel.Id, UserId: userId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+		}
+		return channel
+	}
+
+	shared := makeChannel(teamId, 0, u1.Id, u2.Id)
+	makeChannel(teamId, 0, u1.Id)
+	makeChannel(teamId, 0, u2.Id)
+	makeChannel(teamId, model.GetMillis(), u1.Id, u2.Id)
+	makeChannel(leftTeamId, 0, u1.Id, u2.Id)
+	direct := store.Must(ss.Channel().CreateDirectChannel(u1.Id, u2.Id)).(*model.Channel)
+
+	result := <-ss.Channel().GetCommonChannels(u1.Id, u2.Id)
+	require.Nil(t, result.Err)
+
+	channelIds := []string{}
+	for _, channel := range *result.Data.(*model.ChannelList) {
+		channelIds = append(channelIds, channel.Id)
+	}
+	assert.ElementsMatch(t, []string{shared.Id, direct.Id}, channelIds)
+
+	result = <-ss.Channel().GetCommonChannels(u1.Id, model.NewId())
+	require.Nil(t, result.Err)
+	assert.Empty(t, *result.Data.(*model.ChannelList))
+}
+
+func testChannelStoreGetCommonChannelCounts(t *testing.T, ss store.Store) {
+	userId := model.NewId()
+	otherUserId1 := model.NewId()
+	otherUserId2 := model.NewId()
+	otherUserId3 := model.NewId()
+
+	for _, memberIds := range [][]string{
+		{userId, otherUserId1, otherUserId2},
+		{userId, otherUserId1},
+		{otherUserId1, otherUserId3},
+	} {
+		channel := store.Must(ss.Channel().Save(&model.Channel{
+			TeamId:      model.NewId(),
+			DisplayName: "ChannelA",
+			Name:        "zz" + model.NewId() + "b",
+			Type:        model.CHANNEL_OPEN,
+		}, -1)).(*model.Channel)
+
+		for _, memberId := range memberIds {
+			store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: channel.Id, UserId: memberId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+		}
+	}
+
+	result := <-ss.Channel().GetCommonChannelCounts(userId, []string{otherUserId1, otherUserId2, otherUserId3})
+	require.Nil(t, result.Err)
+	assert.Equal(t, map[string]int64{otherUserId1: 2, otherUserId2: 1}, result.Data.(map[string]int64))
+
+	result = <-ss.Channel().GetCommonChannelCounts(userId, []string{})
+	require.Nil(t, result.Err)
+	assert.Empty(t, result.Data.(map[string]int64))
+}
+
 func testChannelStoreGetMembersForUser(t *testing.T, ss store.Store) {
 	t1 := model.Team{}
 	t1.DisplayName = "Name"
@@ -2430,6 +2670,176 @@ func testChannelStoreGetMembersByIds(t *testing.T, ss store.Store) {
 	}
 }
 
+func testChannelStoreGetMembersSorted(t *testing.T, ss store.Store) {
+	o1 := model.Channel{}
+	o1.TeamId = model.NewId()
+	o1.DisplayName = "ChannelA"
+	o1.Name = "zz" + model.NewId() + "b"
+	o1.Type = model.CHANNEL_OPEN
+	store.Must(ss.Channel().Save(&o1, -1))
+
+	prefix := model.NewId()[:8]
+
+	u1 := store.Must(ss.User().Save(&model.User{Email: MakeEmail(), Username: "c" + prefix, Nickname: "b"})).(*model.User)
+	u2 := store.Must(ss.User().Save(&model.User{Email: MakeEmail(), Username: "a" + prefix, Nickname: "c"})).(*model.User)
+	u3 := store.Must(ss.User().Save(&model.User{Email: MakeEmail(), Username: "b" + prefix, Nickname: "b"})).(*model.User)
+
+	store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: o1.Id, UserId: u1.Id, SchemeUser: true, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+	store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: o1.Id, UserId: u2.Id, SchemeUser: true, Roles: "custom_moderator", NotifyProps: model.GetDefaultChannelNotifyProps()}))
+	store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: o1.Id, UserId: u3.Id, SchemeUser: true, SchemeAdmin: true, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	store.Must(ss.ChannelMemberHistory().LogJoinEvent(u2.Id, o1.Id, 100))
+	store.Must(ss.ChannelMemberHistory().LogLeaveEvent(u2.Id, o1.Id, 200))
+	store.Must(ss.ChannelMemberHistory().LogJoinEvent(u2.Id, o1.Id, 300))
+	store.Must(ss.ChannelMemberHistory().LogJoinEvent(u3.Id, o1.Id, 200))
+	store.Must(ss.ChannelMemberHistory().LogJoinEvent(u1.Id, o1.Id, 250))
+
+	getSorted := func(sort string, offset, limit int) []string {
+		result := <-ss.Channel().GetMembersSorted(o1.Id, sort, offset, limit)
+		require.Nil(t, result.Err)
+
+		userIds := []string{}
+		for _, member := range *result.Data.(*model.ChannelMembers) {
+			userIds = append(userIds, member.UserId)
+		}
+		return userIds
+	}
+
+	assert.Equal(t, []string{u2.Id, u3.Id, u1.Id}, getSorted(model.CHANNEL_MEMBER_SORT_USERNAME, 0, 100))
+	assert.Equal(t, []string{u3.Id, u1.Id, u2.Id}, getSorted(model.CHANNEL_MEMBER_SORT_NICKNAME, 0, 100))
+	assert.Equal(t, []string{u3.Id, u1.Id, u2.Id}, getSorted(model.CHANNEL_MEMBER_SORT_JOINED_AT, 0, 100))
+	assert.Equal(t, []string{u3.Id, u2.Id, u1.Id}, getSorted(model.CHANNEL_MEMBER_SORT_ROLE, 0, 100))
+	assert.Equal(t, []string{u3.Id}, getSorted(model.CHANNEL_MEMBER_SORT_USERNAME, 1, 1))
+
+	result := <-ss.Channel().GetMembersSorted(o1.Id, "junk", 0, 100)
+	assert.NotNil(t, result.Err)
+}
+
+func testChannelStoreGetMembersForTeamAfter(t *testing.T, ss store.Store) {
+	teamId := model.NewId()
+
+	o1 := store.Must(ss.Channel().Save(&model.Channel{TeamId: teamId, DisplayName: "ChannelA", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}, -1)).(*model.Channel)
+	o2 := store.Must(ss.Channel().Save(&model.Channel{TeamId: teamId, DisplayName: "ChannelB", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_PRIVATE}, -1)).(*model.Channel)
+	o3 := store.Must(ss.Channel().Save(&model.Channel{TeamId: model.NewId(), DisplayName: "ChannelC", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}, -1)).(*model.Channel)
+
+	userId1 := model.NewId()
+	userId2 := model.NewId()
+
+	store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: o1.Id, UserId: userId1, SchemeUser: true, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+	store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: o1.Id, UserId: userId2, SchemeUser: true, SchemeAdmin: true, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+	store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: o2.Id, UserId: userId1, SchemeUser: true, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+	store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: o3.Id, UserId: userId1, SchemeUser: true, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	type key struct{ channelId, userId string }
+	expected := []key{{o1.Id, userId1}, {o1.Id, userId2}, {o2.Id, userId1}}
+	sort.Slice(expected, func(i, j int) bool {
+		if expected[i].channelId != expected[j].channelId {
+			return expected[i].channelId < expected[j].channelId
+		}
+		return expected[i].userId < expected[j].userId
+	})
+
+	var actual []key
+	after := key{}
+	for {
+		result := <-ss.Channel().GetMembersForTeamAfter(teamId, after.channelId, after.userId, 2)
+		require.Nil(t, result.Err)
+
+		members := *result.Data.(*model.ChannelMembers)
+		if len(members) == 0 {
+			break
+		}
+
+		for _, member := range members {
+			actual = append(actual, key{member.ChannelId, member.UserId})
+			if member.ChannelId == o1.Id && member.UserId == userId2 {
+				assert.Contains(t, member.Roles, model.CHANNEL_ADMIN_ROLE_ID)
+			}
+		}
+		after = actual[len(actual)-1]
+	}
+
+	assert.Equal(t, expected, actual)
+}
+
+func testChannelStoreSearchMembers(t *testing.T, ss store.Store) {
+	o1 := model.Channel{}
+	o1.TeamId = model.NewId()
+	o1.DisplayName = "ChannelA"
+	o1.Name = "zz" + model.NewId() + "b"
+	o1.Type = model.CHANNEL_OPEN
+	store.Must(ss.Channel().Save(&o1, -1))
+
+	prefix := model.NewId()[:8]
+
+	u1 := store.Must(ss.User().Save(&model.User{Email: MakeEmail(), Username: "a" + prefix + "alice", FirstName: "Zed"})).(*model.User)
+	u2 := store.Must(ss.User().Save(&model.User{Email: MakeEmail(), Username: "a" + prefix + "bob"})).(*model.User)
+	u3 := store.Must(ss.User().Save(&model.User{Email: MakeEmail(), Username: "b" + prefix + "carol"})).(*model.User)
+
+	store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: o1.Id, UserId: u1.Id, SchemeUser: true, SchemeAdmin: true, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+	store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: o1.Id, UserId: u2.Id, SchemeUser: true, Roles: "custom_moderator", NotifyProps: model.GetDefaultChannelNotifyProps()}))
+	store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: o1.Id, UserId: u3.Id, SchemeUser: true, SchemeAdmin: true, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	search := func(term string, role string, allowFullNames bool, offset, limit int) []string {
+		result := <-ss.Channel().SearchMembers(o1.Id, term, role, allowFullNames, offset, limit)
+		require.Nil(t, result.Err)
+
+		userIds := []string{}
+		for _, member := range *result.Data.(*model.ChannelMembers) {
+			assert.Equal(t, o1.Id, member.ChannelId)
+			userIds = append(userIds, member.UserId)
+		}
+		return userIds
+	}
+
+	assert.Equal(t, []string{u1.Id, u2.Id, u3.Id}, search("", "", false, 0, 100))
+	assert.Equal(t, []string{u1.Id, u2.Id}, search("a"+prefix, "", false, 0, 100))
+	assert.Equal(t, []string{u1.Id, u3.Id}, search("", model.CHANNEL_ADMIN_ROLE_ID, false, 0, 100))
+	assert.Equal(t, []string{u1.Id}, search("a"+prefix, model.CHANNEL_ADMIN_ROLE_ID, false, 0, 100))
+	assert.Equal(t, []string{u2.Id}, search("", model.CHANNEL_USER_ROLE_ID, false, 1, 1))
+	assert.Equal(t, []string{u2.Id}, search("", "custom_moderator", false, 0, 100))
+	assert.Empty(t, search("", "moderator", false, 0, 100), "roles should only match whole role names")
+	assert.Empty(t, search("", "custom_moderato_", false, 0, 100), "wildcards in roles should be matched literally")
+	assert.Empty(t, search("zed", "", false, 0, 100))
+	assert.Equal(t, []string{u1.Id}, search("zed", "", true, 0, 100))
+}
+
+func testChannelStoreAnalyticsCreatedCount(t *testing.T, ss store.Store) {
+	teamId := model.NewId()
+	since := model.GetMillis()
+
+	o1 := model.Channel{}
+	o1.TeamId = teamId
+	o1.DisplayName = "ChannelA"
+	o1.Name = "zz" + model.NewId() + "b"
+	o1.Type = model.CHANNEL_OPEN
+	store.Must(ss.Channel().Save(&o1, -1))
+
+	o2 := model.Channel{}
+	o2.TeamId = teamId
+	o2.DisplayName = "ChannelB"
+	o2.Name = "zz" + model.NewId() + "b"
+	o2.Type = model.CHANNEL_PRIVATE
+	store.Must(ss.Channel().Save(&o2, -1))
+
+	o3 := model.Channel{}
+	o3.TeamId = model.NewId()
+	o3.DisplayName = "ChannelC"
+	o3.Name = "zz" + model.NewId() + "b"
+	o3.Type = model.CHANNEL_OPEN
+	store.Must(ss.Channel().Save(&o3, -1))
+
+	until := model.GetMillis() + 1
+
+	result := <-ss.Channel().AnalyticsCreatedCount(teamId, since, until)
+	require.Nil(t, result.Err)
+	assert.Equal(t, int64(2), result.Data.(int64))
+
+	result = <-ss.Channel().AnalyticsCreatedCount(teamId, until, until+1000)
+	require.Nil(t, result.Err)
+	assert.Equal(t, int64(0), result.Data.(int64))
+}
+
 func testChannelStoreAnalyticsDeletedTypeCount(t *testing.T, ss store.Store) {
 	o1 := model.Channel{}
 	o1.TeamId = model.NewId()
@@ -3124,3 +3534,236 @@ func testChannelStoreRemoveAllDeactivatedMembers(t *testing.T, ss store.Store) {
 	assert.Len(t, *d2, 1)
 	assert.Equal(t, (*d2)[0].UserId, u3.Id)
 }
+
+func testChannelStoreMoveToTeam(t *testing.T, ss store.Store) {
+	t1 := store.Must(ss.Team().Save(&model.Team{DisplayName: "Name", Name: model.NewId(), Email: MakeEmail(), Type: model.TEAM_OPEN})).(*model.Team)
+	t2 := store.Must(ss.Team().Save(&model.Team{DisplayName: "Name", Name: model.NewId(), Email: MakeEmail(), Type: model.TEAM_OPEN})).(*model.Team)
+
+	c1 := store.Must(ss.Channel().Save(&model.Channel{TeamId: t1.Id, DisplayName: "Channel1", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}, -1)).(*model.Channel)
+
+	u1 := store.Must(ss.User().Save(&model.User{Email: MakeEmail(), Nickname: model.NewId()})).(*model.User)
+	store.Must(ss.Team().SaveMember(&model.TeamMember{TeamId: t1.Id, UserId: u1.Id}, -1))
+	store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: u1.Id, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	incoming := store.Must(ss.Webhook().SaveIncoming(&model.IncomingWebhook{ChannelId: c1.Id, UserId: u1.Id, TeamId: t1.Id})).(*model.IncomingWebhook)
+	outgoing := store.Must(ss.Webhook().SaveOutgoing(&model.OutgoingWebhook{ChannelId: c1.Id, CreatorId: u1.Id, TeamId: t1.Id, CallbackURLs: []string{"http://nowhere.com/"}})).(*model.OutgoingWebhook)
+
+	t.Run("members must be in the team", func(t *testing.T) {
+		name := c1.Name
+
+		result := <-ss.Channel().MoveToTeam(c1, t2.Id, "newname", false)
+		require.NotNil(t, result.Err)
+		assert.Equal(t, "store.sql_channel.move_to_team.members_not_in_team.app_error", result.Err.Id)
+		assert.Equal(t, t1.Id, c1.TeamId)
+		assert.Equal(t, name, c1.Name)
+
+		channel := store.Must(ss.Channel().Get(c1.Id, false)).(*model.Channel)
+		assert.Equal(t, t1.Id, channel.TeamId)
+	})
+
+	store.Must(ss.Team().SaveMember(&model.TeamMember{TeamId: t2.Id, UserId: u1.Id}, -1))
+
+	u2 := store.Must(ss.User().Save(&model.User{Email: MakeEmail(), Nickname: model.NewId()})).(*model.User)
+	store.Must(ss.Team().SaveMember(&model.TeamMember{TeamId: t1.Id, UserId: u2.Id}, -1))
+	store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: u2.Id, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	name := "zz" + model.NewId() + "b"
+	result := <-ss.Channel().MoveToTeam(c1, t2.Id, name, true)
+	require.Nil(t, result.Err)
+	assert.Equal(t, t2.Id, c1.TeamId)
+	assert.Equal(t, name, c1.Name)
+
+	removedMembers := *result.Data.(*model.ChannelMembers)
+	require.Len(t, removedMembers, 1)
+	assert.Equal(t, u2.Id, removedMembers[0].UserId)
+
+	assert.NotNil(t, (<-ss.Channel().GetMember(c1.Id, u2.Id)).Err)
+	assert.Nil(t, (<-ss.Channel().GetMember(c1.Id, u1.Id)).Err)
+
+	channel := store.Must(ss.Channel().GetByName(t2.Id, name, false)).(*model.Channel)
+	assert.Equal(t, c1.Id, channel.Id)
+
+	assert.NotNil(t, (<-ss.Channel().GetByName(t1.Id, name, false)).Err)
+
+	movedIncoming := store.Must(ss.Webhook().GetIncoming(incoming.Id, false)).(*model.IncomingWebhook)
+	assert.Equal(t, t2.Id, movedIncoming.TeamId)
+
+	movedOutgoing := store.Must(ss.Webhook().GetOutgoing(outgoing.Id)).(*model.OutgoingWebhook)
+	assert.Equal(t, t2.Id, movedOutgoing.TeamId)
+}
+
+func testChannelStoreMergeInto(t *testing.T, ss store.Store) {
+	t1 := store.Must(ss.Team().Save(&model.Team{DisplayName: "Name", Name: model.NewId(), Email: MakeEmail(), Type: model.TEAM_OPEN})).(*model.Team)
+	t2 := store.Must(ss.Team().Save(&model.Team{DisplayName: "Name", Name: model.NewId(), Email: MakeEmail(), Type: model.TEAM_OPEN})).(*model.Team)
+
+	source := store.Must(ss.Channel().Save(&model.Channel{TeamId: t1.Id, DisplayName: "Source", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}, -1)).(*model.Channel)
+	target := store.Must(ss.Channel().Save(&model.Channel{TeamId: t2.Id, DisplayName: "Target", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}, -1)).(*model.Channel)
+
+	userId := model.NewId()
+	p1 := store.Must(ss.Post().Save(&model.Post{ChannelId: source.Id, UserId: userId, Message: "source"})).(*model.Post)
+	p2 := store.Must(ss.Post().Save(&model.Post{ChannelId: source.Id, UserId: userId, Message: "source"})).(*model.Post)
+	p3 := store.Must(ss.Post().Save(&model.Post{ChannelId: target.Id, UserId: userId, Message: "target"})).(*model.Post)
+
+	source = store.Must(ss.Channel().Get(source.Id, false)).(*model.Channel)
+	target = store.Must(ss.Channel().Get(target.Id, false)).(*model.Channel)
+
+	hook := store.Must(ss.Webhook().SaveIncoming(&model.IncomingWebhook{ChannelId: source.Id, UserId: userId, TeamId: t1.Id})).(*model.IncomingWebhook)
+
+	result := <-ss.Channel().MergeInto(source, target)
+	require.Nil(t, result.Err)
+	assert.Equal(t, int64(2), result.Data.(int64))
+
+	for _, post := range []*model.Post{p1, p2, p3} {
+		moved := store.Must(ss.Post().GetSingle(post.Id)).(*model.Post)
+		assert.Equal(t, target.Id, moved.ChannelId)
+	}
+
+	merged := store.Must(ss.Channel().Get(target.Id, false)).(*model.Channel)
+	assert.Equal(t, target.TotalMsgCount+2, merged.TotalMsgCount)
+	if source.LastPostAt > target.LastPostAt {
+		assert.Equal(t, source.LastPostAt, merged.LastPostAt)
+	} else {
+		assert.Equal(t, target.LastPostAt, merged.LastPostAt)
+	}
+
+	movedHook := store.Must(ss.Webhook().GetIncoming(hook.Id, false)).(*model.IncomingWebhook)
+	assert.Equal(t, target.Id, movedHook.ChannelId)
+	assert.Equal(t, t2.Id, movedHook.TeamId)
+}
+
+func testChannelStoreRepairMsgCounts(t *testing.T, ss store.Store) {
+	team := store.Must(ss.Team().Save(&model.Team{DisplayName: "Name", Name: model.NewId(), Email: MakeEmail(), Type: model.TEAM_OPEN})).(*model.Team)
+	channel := store.Must(ss.Channel().Save(&model.Channel{TeamId: team.Id, DisplayName: "Drifted", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}, -1)).(*model.Channel)
+	other := store.Must(ss.Channel().Save(&model.Channel{TeamId: team.Id, DisplayName: "Other", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}, -1)).(*model.Channel)
+
+	userId := model.NewId()
+	store.Must(ss.Post().Save(&model.Post{ChannelId: channel.Id, UserId: userId, Message: "counted"}))
+	store.Must(ss.Post().Save(&model.Post{ChannelId: channel.Id, UserId: userId, Message: "counted"}))
+	store.Must(ss.Post().Save(&model.Post{ChannelId: channel.Id, UserId: userId, Message: "joined", Type: model.POST_JOIN_CHANNEL}))
+	store.Must(ss.Post().Save(&model.Post{ChannelId: other.Id, UserId: userId, Message: "counted"}))
+
+	// Make the counts drift as past bugs have
+	channel = store.Must(ss.Channel().Get(channel.Id, false)).(*model.Channel)
+	require.Equal(t, int64(2), channel.TotalMsgCount)
+	channel.TotalMsgCount = 5
+	store.Must(ss.Channel().Update(channel))
+
+	newMember := func(msgCount, mentionCount int64) *model.ChannelMember {
+		return store.Must(ss.Channel().SaveMember(&model.ChannelMember{
+			ChannelId:    channel.Id,
+			UserId:       model.NewId(),
+			MsgCount:     msgCount,
+			MentionCount: mentionCount,
+			NotifyProps:  model.GetDefaultChannelNotifyProps(),
+		})).(*model.ChannelMember)
+	}
+	unread := newMember(4, 0)
+	readTooMuch := newMember(6, 0)
+	negativeMentions := newMember(5, -1)
+
+	result := <-ss.Channel().GetMsgCountsForRepair(team.Id, "", "", 100)
+	require.Nil(t, result.Err)
+	counts := result.Data.([]*model.ChannelMsgCounts)
+	require.Len(t, counts, 2)
+
+	var drifted *model.ChannelMsgCounts
+	for _, c := range counts {
+		if c.ChannelId == channel.Id {
+			drifted = c
+		} else {
+			assert.True(t, c.IsConsistent())
+		}
+	}
+	require.NotNil(t, drifted)
+	assert.Equal(t, &model.ChannelMsgCounts{ChannelId: channel.Id, TotalMsgCount: 5, ActualMsgCount: 2, InvalidMemberCounts: 2}, drifted)
+
+	result = <-ss.Channel().GetMsgCountsForRepair("", channel.Id, "", 100)
+	require.Nil(t, result.Err)
+	assert.Equal(t, []*model.ChannelMsgCounts{drifted}, result.Data.([]*model.ChannelMsgCounts))
+
+	// The counts are checked again when repairing, so ones that are out of date are still repaired correctly
+	stale := *drifted
+	stale.TotalMsgCount = 4
+
+	result = <-ss.Channel().RepairMsgCounts(&stale)
+	require.Nil(t, result.Err)
+	assert.True(t, result.Data.(bool))
+
+	assert.Equal(t, int64(2), store.Must(ss.Channel().Get(channel.Id, false)).(*model.Channel).TotalMsgCount)
+
+	// Unread counts are kept where they're possible
+	member := store.Must(ss.Channel().GetMember(channel.Id, unread.UserId)).(*model.ChannelMember)
+	assert.Equal(t, int64(1), member.MsgCount)
+
+	member = store.Must(ss.Channel().GetMember(channel.Id, readTooMuch.UserId)).(*model.ChannelMember)
+	assert.Equal(t, int64(2), member.MsgCount)
+
+	member = store.Must(ss.Channel().GetMember(channel.Id, negativeMentions.UserId)).(*model.ChannelMember)
+	assert.Equal(t, int64(2), member.MsgCount)
+	assert.Equal(t, int64(0), member.MentionCount)
+
+	result = <-ss.Channel().GetMsgCountsForRepair("", channel.Id, "", 100)
+	require.Nil(t, result.Err)
+	assert.True(t, result.Data.([]*model.ChannelMsgCounts)[0].IsConsistent())
+
+	result = <-ss.Channel().RepairMsgCounts(drifted)
+	require.Nil(t, result.Err)
+	assert.False(t, result.Data.(bool), "a channel that's been repaired already should be skipped")
+}
+
+func testChannelStoreOrphanedMembers(t *testing.T, ss store.Store) {
+	team := store.Must(ss.Team().Save(&model.Team{DisplayName: "Name", Name: model.NewId(), Email: MakeEmail(), Type: model.TEAM_OPEN})).(*model.Team)
+	channel := store.Must(ss.Channel().Save(&model.Channel{TeamId: team.Id, DisplayName: "Kept", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}, -1)).(*model.Channel)
+	deleted := store.Must(ss.Channel().Save(&model.Channel{TeamId: team.Id, DisplayName: "Deleted", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}, -1)).(*model.Channel)
+	user := store.Must(ss.User().Save(&model.User{Email: MakeEmail(), Username: model.NewId()})).(*model.User)
+
+	newMember := func(channelId, userId string) *model.ChannelMember {
+		return store.Must(ss.Channel().SaveMember(&model.ChannelMember{
+			ChannelId:   channelId,
+			UserId:      userId,
+			NotifyProps: model.GetDefaultChannelNotifyProps(),
+		})).(*model.ChannelMember)
+	}
+	kept := newMember(channel.Id, user.Id)
+	missingUser := newMember(channel.Id, model.NewId())
+	missingChannel := newMember(deleted.Id, user.Id)
+	store.Must(ss.Channel().PermanentDelete(deleted.Id))
+
+	// Other tests leave orphaned members behind too, so every page is checked for the ones made here
+	getOrphaned := func() map[string]*model.OrphanedChannelMember {
+		orphaned := map[string]*model.OrphanedChannelMember{}
+		afterChannelId, afterUserId := "", ""
+		for {
+			result := <-ss.Channel().GetOrphanedMembers(afterChannelId, afterUserId, 2)
+			require.Nil(t, result.Err)
+			members := result.Data.([]*model.OrphanedChannelMember)
+			for _, member := range members {
+				orphaned[member.ChannelId+member.UserId] = member
+				afterChannelId, afterUserId = member.ChannelId, member.UserId
+			}
+			if len(members) < 2 {
+				return orphaned
+			}
+		}
+	}
+
+	orphaned := getOrphaned()
+	assert.NotContains(t, orphaned, kept.ChannelId+kept.UserId)
+	assert.Equal(t, &model.OrphanedChannelMember{ChannelId: channel.Id, UserId: missingUser.UserId, ChannelExists: true, UserExists: false}, orphaned[missingUser.ChannelId+missingUser.UserId])
+	assert.Equal(t, &model.OrphanedChannelMember{ChannelId: deleted.Id, UserId: user.Id, ChannelExists: false, UserExists: true}, orphaned[missingChannel.ChannelId+missingChannel.UserId])
+
+	result := <-ss.Channel().RemoveOrphanedMember(kept.ChannelId, kept.UserId)
+	require.Nil(t, result.Err)
+	assert.False(t, result.Data.(bool))
+	store.Must(ss.Channel().GetMember(kept.ChannelId, kept.UserId))
+
+	for _, member := range []*model.ChannelMember{missingUser, missingChannel} {
+		result = <-ss.Channel().RemoveOrphanedMember(member.ChannelId, member.UserId)
+		require.Nil(t, result.Err)
+		assert.True(t, result.Data.(bool))
+	}
+
+	orphaned = getOrphaned()
+	assert.NotContains(t, orphaned, missingUser.ChannelId+missingUser.UserId)
+	assert.NotContains(t, orphaned, missingChannel.ChannelId+missingChannel.UserId)
+}
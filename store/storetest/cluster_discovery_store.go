@@ -147,6 +147,7 @@ func testClusterDiscoveryGetStore(t *testing.T, ss store.Store) {
 		ClusterName: "cluster_name",
 		Hostname:    "hostname2",
 		Type:        testType1,
+		Tags:        model.StringArray{"indexer"},
 	}
 	store.Must(ss.ClusterDiscovery().Save(discovery2))
 
@@ -176,6 +177,12 @@ func testClusterDiscoveryGetStore(t *testing.T, ss store.Store) {
 		if len(list) != 2 {
 			t.Fatal("Should only have returned 2")
 		}
+
+		for _, discovery := range list {
+			if discovery.Hostname == "hostname2" && !discovery.HasTag("indexer") {
+				t.Fatal("Should have returned tags")
+			}
+		}
 	}
 
 	if result := <-ss.ClusterDiscovery().GetAll(testType2, "cluster_name"); result.Err != nil {
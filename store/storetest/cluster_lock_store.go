@@ -0,0 +1,119 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package storetest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/store"
+)
+
+func TestClusterLockStore(t *testing.T, ss store.Store) {
+	t.Run("Acquire", func(t *testing.T) { testClusterLockStoreAcquire(t, ss) })
+	t.Run("Renew", func(t *testing.T) { testClusterLockStoreRenew(t, ss) })
+	t.Run("Release", func(t *testing.T) { testClusterLockStoreRelease(t, ss) })
+}
+
+func acquireClusterLock(t *testing.T, ss store.Store, name string, owner string, expireAt int64) bool {
+	result := <-ss.ClusterLock().Acquire(&model.ClusterLock{Name: name, Owner: owner, ExpireAt: expireAt})
+	require.Nil(t, result.Err)
+	return result.Data.(bool)
+}
+
+func testClusterLockStoreAcquire(t *testing.T, ss store.Store) {
+	name := "test_" + model.NewId()
+	owner1 := model.NewId()
+	owner2 := model.NewId()
+	expireAt := model.GetMillis() + 60*1000
+
+	assert.True(t, acquireClusterLock(t, ss, name, owner1, expireAt))
+
+	result := <-ss.ClusterLock().Get(name)
+	require.Nil(t, result.Err)
+	assert.Equal(t, owner1, result.Data.(*model.ClusterLock).Owner)
+
+	// Held by another owner
+	assert.False(t, acquireClusterLock(t, ss, name, owner2, expireAt))
+
+	// Re-acquiring by the same owner extends the lock, even with the same expiry
+	assert.True(t, acquireClusterLock(t, ss, name, owner1, expireAt))
+	assert.True(t, acquireClusterLock(t, ss, name, owner1, expireAt+1000))
+
+	result = <-ss.ClusterLock().Get(name)
+	require.Nil(t, result.Err)
+	assert.Equal(t, expireAt+1000, result.Data.(*model.ClusterLock).ExpireAt)
+
+	// Expired locks can be taken over
+	expiredName := "test_" + model.NewId()
+	assert.True(t, acquireClusterLock(t, ss, expiredName, owner1, model.GetMillis()-1000))
+	assert.True(t, acquireClusterLock(t, ss, expiredName, owner2, expireAt))
+
+	result = <-ss.ClusterLock().Get(expiredName)
+	require.Nil(t, result.Err)
+	assert.Equal(t, owner2, result.Data.(*model.ClusterLock).Owner)
+
+	result = <-ss.ClusterLock().Acquire(&model.ClusterLock{Name: "", Owner: owner1, ExpireAt: expireAt})
+	assert.NotNil(t, result.Err)
+}
+
+func testClusterLockStoreRenew(t *testing.T, ss store.Store) {
+	name := "test_" + model.NewId()
+	owner1 := model.NewId()
+	owner2 := model.NewId()
+	expireAt := model.GetMillis() + 60*1000
+
+	require.True(t, acquireClusterLock(t, ss, name, owner1, expireAt))
+
+	result := <-ss.ClusterLock().Renew(&model.ClusterLock{Name: name, Owner: owner1, ExpireAt: expireAt + 1000})
+	require.Nil(t, result.Err)
+	assert.True(t, result.Data.(bool))
+
+	result = <-ss.ClusterLock().Renew(&model.ClusterLock{Name: name, Owner: owner1, ExpireAt: expireAt + 1000})
+	require.Nil(t, result.Err)
+	assert.True(t, result.Data.(bool))
+
+	result = <-ss.ClusterLock().Renew(&model.ClusterLock{Name: name, Owner: owner2, ExpireAt: expireAt + 2000})
+	require.Nil(t, result.Err)
+	assert.False(t, result.Data.(bool))
+
+	// A lock that has expired can't be renewed since somebody else may have taken it over
+	expiredName := "test_" + model.NewId()
+	require.True(t, acquireClusterLock(t, ss, expiredName, owner1, model.GetMillis()-1000))
+
+	result = <-ss.ClusterLock().Renew(&model.ClusterLock{Name: expiredName, Owner: owner1, ExpireAt: expireAt})
+	require.Nil(t, result.Err)
+	assert.False(t, result.Data.(bool))
+
+	result = <-ss.ClusterLock().Renew(&model.ClusterLock{Name: "test_" + model.NewId(), Owner: owner1, ExpireAt: expireAt})
+	require.Nil(t, result.Err)
+	assert.False(t, result.Data.(bool))
+}
+
+func testClusterLockStoreRelease(t *testing.T, ss store.Store) {
+	name := "test_" + model.NewId()
+	owner1 := model.NewId()
+	owner2 := model.NewId()
+	expireAt := model.GetMillis() + 60*1000
+
+	require.True(t, acquireClusterLock(t, ss, name, owner1, expireAt))
+
+	// Only the owner can release the lock
+	result := <-ss.ClusterLock().Release(name, owner2)
+	require.Nil(t, result.Err)
+	assert.False(t, acquireClusterLock(t, ss, name, owner2, expireAt))
+
+	result = <-ss.ClusterLock().Release(name, owner1)
+	require.Nil(t, result.Err)
+
+	result = <-ss.ClusterLock().Get(name)
+	require.NotNil(t, result.Err)
+	assert.Equal(t, http.StatusNotFound, result.Err.StatusCode)
+
+	assert.True(t, acquireClusterLock(t, ss, name, owner2, expireAt))
+}
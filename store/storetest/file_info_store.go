@@ -5,6 +5,7 @@ package storetest
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/mattermost/mattermost-server/model"
@@ -19,11 +20,20 @@ func TestFileInfoStore(t *testing.T, ss store.Store) {
 	t.Run("FileInfoSaveGetByPath", func(t *testing.T) { testFileInfoSaveGetByPath(t, ss) })
 	t.Run("FileInfoGetForPost", func(t *testing.T) { testFileInfoGetForPost(t, ss) })
 	t.Run("FileInfoGetForUser", func(t *testing.T) { testFileInfoGetForUser(t, ss) })
+	t.Run("FileInfoSearch", func(t *testing.T) { testFileInfoSearch(t, ss) })
 	t.Run("FileInfoAttachToPost", func(t *testing.T) { testFileInfoAttachToPost(t, ss) })
 	t.Run("FileInfoDeleteForPost", func(t *testing.T) { testFileInfoDeleteForPost(t, ss) })
 	t.Run("FileInfoPermanentDelete", func(t *testing.T) { testFileInfoPermanentDelete(t, ss) })
 	t.Run("FileInfoPermanentDeleteBatch", func(t *testing.T) { testFileInfoPermanentDeleteBatch(t, ss) })
 	t.Run("FileInfoPermanentDeleteByUser", func(t *testing.T) { testFileInfoPermanentDeleteByUser(t, ss) })
+	t.Run("FileInfoGetOrphaned", func(t *testing.T) { testFileInfoGetOrphaned(t, ss) })
+	t.Run("FileInfoGetExistingIds", func(t *testing.T) { testFileInfoGetExistingIds(t, ss) })
+	t.Run("FileInfoGetForColdStorage", func(t *testing.T) { testFileInfoGetForColdStorage(t, ss) })
+	t.Run("FileInfoUpdateStorageTier", func(t *testing.T) { testFileInfoUpdateStorageTier(t, ss) })
+	t.Run("FileInfoGetBatchForIntegrityScan", func(t *testing.T) { testFileInfoGetBatchForIntegrityScan(t, ss) })
+	t.Run("FileInfoCountByPath", func(t *testing.T) { testFileInfoCountByPath(t, ss) })
+	t.Run("FileInfoGetStorageSizeForChannel", func(t *testing.T) { testFileInfoGetStorageSizeForChannel(t, ss) })
+	t.Run("FileInfoGetBatchForStorageUsage", func(t *testing.T) { testFileInfoGetBatchForStorageUsage(t, ss) })
 }
 
 func testFileInfoSaveGet(t *testing.T, ss store.Store) {
@@ -206,6 +216,96 @@ func testFileInfoGetForUser(t *testing.T, ss store.Store) {
 	}
 }
 
+func testFileInfoSearch(t *testing.T, ss store.Store) {
+	teamId := model.NewId()
+	userId := model.NewId()
+	otherUserId := model.NewId()
+
+	channel1 := store.Must(ss.Channel().Save(&model.Channel{
+		TeamId:      teamId,
+		DisplayName: "Channel1",
+		Name:        "zz" + model.NewId() + "b",
+		Type:        model.CHANNEL_OPEN,
+	}, -1)).(*model.Channel)
+	channel2 := store.Must(ss.Channel().Save(&model.Channel{
+		TeamId:      teamId,
+		DisplayName: "Channel2",
+		Name:        "zz" + model.NewId() + "b",
+		Type:        model.CHANNEL_OPEN,
+	}, -1)).(*model.Channel)
+	notMember := store.Must(ss.Channel().Save(&model.Channel{
+		TeamId:      teamId,
+		DisplayName: "Channel3",
+		Name:        "zz" + model.NewId() + "b",
+		Type:        model.CHANNEL_OPEN,
+	}, -1)).(*model.Channel)
+
+	for _, channel := range []*model.Channel{channel1, channel2} {
+		store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: channel.Id, UserId: userId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+	}
+
+	post1 := store.Must(ss.Post().Save(&model.Post{ChannelId: channel1.Id, UserId: userId, Message: "files"})).(*model.Post)
+	post2 := store.Must(ss.Post().Save(&model.Post{ChannelId: channel2.Id, UserId: otherUserId, Message: "files"})).(*model.Post)
+	post3 := store.Must(ss.Post().Save(&model.Post{ChannelId: notMember.Id, UserId: otherUserId, Message: "files"})).(*model.Post)
+
+	infos := []*model.FileInfo{
+		{PostId: post1.Id, CreatorId: userId, Path: "a", Name: "quarterly-report.pdf", CreateAt: 1000},
+		{PostId: post2.Id, CreatorId: otherUserId, Path: "b", Name: "Quarterly_Report_draft.docx", CreateAt: 2000},
+		{PostId: post3.Id, CreatorId: otherUserId, Path: "c", Name: "quarterly-report-secret.pdf", CreateAt: 3000},
+		{PostId: post1.Id, CreatorId: userId, Path: "d", Name: "holiday.png", CreateAt: 4000},
+	}
+	for i, info := range infos {
+		infos[i] = store.Must(ss.FileInfo().Save(info)).(*model.FileInfo)
+		defer func(id string) {
+			<-ss.FileInfo().PermanentDelete(id)
+		}(infos[i].Id)
+	}
+
+	search := func(params *model.SearchParams, offset, limit int) []string {
+		result := <-ss.FileInfo().Search(teamId, userId, params, offset, limit)
+		require.Nil(t, result.Err)
+
+		ids := []string{}
+		for _, info := range result.Data.([]*model.FileInfo) {
+			ids = append(ids, info.Id)
+		}
+		return ids
+	}
+
+	t.Run("only returns files from the user's channels", func(t *testing.T) {
+		assert.Equal(t, []string{infos[1].Id, infos[0].Id}, search(&model.SearchParams{Terms: "quarterly"}, 0, 60))
+	})
+
+	t.Run("matches every term unless or-searching", func(t *testing.T) {
+		assert.Equal(t, []string{infos[0].Id}, search(&model.SearchParams{Terms: "report .pdf"}, 0, 60))
+		assert.Equal(t, []string{infos[3].Id, infos[1].Id, infos[0].Id}, search(&model.SearchParams{Terms: "holiday quarterly", OrTerms: true}, 0, 60))
+	})
+
+	t.Run("escapes wildcard characters", func(t *testing.T) {
+		assert.Equal(t, []string{infos[1].Id}, search(&model.SearchParams{Terms: "report_"}, 0, 60))
+	})
+
+	t.Run("filters by channel and user", func(t *testing.T) {
+		assert.Equal(t, []string{infos[1].Id}, search(&model.SearchParams{Terms: "quarterly", InChannels: []string{channel2.Id}}, 0, 60))
+		assert.Equal(t, []string{infos[3].Id, infos[0].Id}, search(&model.SearchParams{FromUsers: []string{userId}}, 0, 60))
+	})
+
+	t.Run("filters by date", func(t *testing.T) {
+		params := &model.SearchParams{Terms: "quarterly", BeforeDate: "1970-01-02"}
+		assert.Equal(t, []string{infos[1].Id, infos[0].Id}, search(params, 0, 60))
+
+		params = &model.SearchParams{Terms: "quarterly", AfterDate: "1970-01-02"}
+		assert.Empty(t, search(params, 0, 60))
+	})
+
+	t.Run("paginates", func(t *testing.T) {
+		params := &model.SearchParams{Terms: "quarterly"}
+		assert.Equal(t, []string{infos[1].Id}, search(params, 0, 1))
+		assert.Equal(t, []string{infos[0].Id}, search(params, 1, 1))
+		assert.Empty(t, search(params, 2, 1))
+	})
+}
+
 func testFileInfoAttachToPost(t *testing.T, ss store.Store) {
 	t.Run("should attach files", func(t *testing.T) {
 		userId := model.NewId()
@@ -383,3 +483,258 @@ func testFileInfoPermanentDeleteByUser(t *testing.T, ss store.Store) {
 		t.Fatal(result.Err)
 	}
 }
+
+func testFileInfoGetOrphaned(t *testing.T, ss store.Store) {
+	userId := model.NewId()
+	channelId := model.NewId()
+
+	post := store.Must(ss.Post().Save(&model.Post{ChannelId: channelId, UserId: userId, Message: "files"})).(*model.Post)
+	deletedPost := store.Must(ss.Post().Save(&model.Post{ChannelId: channelId, UserId: userId, Message: "files"})).(*model.Post)
+
+	saveFileInfo := func(postId string) *model.FileInfo {
+		return store.Must(ss.FileInfo().Save(&model.FileInfo{
+			CreatorId: userId,
+			PostId:    postId,
+			Path:      "file.txt",
+		})).(*model.FileInfo)
+	}
+
+	attached := saveFileInfo(post.Id)
+	unattached := saveFileInfo("")
+	missingPost := saveFileInfo(model.NewId())
+	onDeletedPost := saveFileInfo(deletedPost.Id)
+	deleted := saveFileInfo(model.NewId())
+
+	store.Must(ss.Post().Delete(deletedPost.Id, model.GetMillis(), ""))
+	store.Must(ss.FileInfo().DeleteForPost(deleted.PostId))
+
+	getOrphanedIds := func(before int64) map[string]bool {
+		ids := map[string]bool{}
+
+		afterId := ""
+		for {
+			result := <-ss.FileInfo().GetOrphaned(before, afterId, 2)
+			require.Nil(t, result.Err)
+
+			infos := result.Data.([]*model.FileInfo)
+			if len(infos) == 0 {
+				return ids
+			}
+
+			for _, info := range infos {
+				require.True(t, info.Id > afterId)
+				ids[info.Id] = true
+				afterId = info.Id
+			}
+		}
+	}
+
+	orphanedIds := getOrphanedIds(model.GetMillis() + 1000)
+	assert.False(t, orphanedIds[attached.Id])
+	assert.True(t, orphanedIds[unattached.Id])
+	assert.True(t, orphanedIds[missingPost.Id])
+	assert.True(t, orphanedIds[onDeletedPost.Id])
+	assert.True(t, orphanedIds[deleted.Id])
+
+	// Files created or deleted after the given time are still within their grace period
+	orphanedIds = getOrphanedIds(unattached.CreateAt)
+	assert.False(t, orphanedIds[unattached.Id])
+	assert.False(t, orphanedIds[onDeletedPost.Id])
+	assert.False(t, orphanedIds[deleted.Id])
+}
+
+func testFileInfoGetExistingIds(t *testing.T, ss store.Store) {
+	info := store.Must(ss.FileInfo().Save(&model.FileInfo{
+		CreatorId: model.NewId(),
+		PostId:    model.NewId(),
+		Path:      "file.txt",
+	})).(*model.FileInfo)
+	deleted := store.Must(ss.FileInfo().Save(&model.FileInfo{
+		CreatorId: model.NewId(),
+		PostId:    model.NewId(),
+		Path:      "file.txt",
+	})).(*model.FileInfo)
+	store.Must(ss.FileInfo().DeleteForPost(deleted.PostId))
+
+	result := <-ss.FileInfo().GetExistingIds([]string{info.Id, deleted.Id, model.NewId()})
+	require.Nil(t, result.Err)
+	assert.ElementsMatch(t, []string{info.Id, deleted.Id}, result.Data.([]string))
+
+	result = <-ss.FileInfo().GetExistingIds([]string{})
+	require.Nil(t, result.Err)
+	assert.Empty(t, result.Data.([]string))
+}
+
+func testFileInfoGetForColdStorage(t *testing.T, ss store.Store) {
+	saveInfo := func(createAt int64, postId string) *model.FileInfo {
+		return store.Must(ss.FileInfo().Save(&model.FileInfo{
+			CreatorId: model.NewId(),
+			PostId:    postId,
+			CreateAt:  createAt,
+			Path:      "file.txt",
+		})).(*model.FileInfo)
+	}
+
+	old := saveInfo(1000, model.NewId())
+	old2 := saveInfo(1500, model.NewId())
+	recent := saveInfo(3000, model.NewId())
+	unattached := saveInfo(1000, "")
+	deleted := saveInfo(1000, model.NewId())
+	store.Must(ss.FileInfo().DeleteForPost(deleted.PostId))
+	cold := saveInfo(1000, model.NewId())
+	store.Must(ss.FileInfo().UpdateStorageTier(cold.Id, model.FILE_INFO_STORAGE_TIER_COLD))
+
+	ids := map[string]bool{old.Id: true, old2.Id: true, recent.Id: true, unattached.Id: true, deleted.Id: true, cold.Id: true}
+	getIds := func(afterId string, limit int) []string {
+		result := <-ss.FileInfo().GetForColdStorage(2000, afterId, limit)
+		require.Nil(t, result.Err)
+
+		var found []string
+		for _, info := range result.Data.([]*model.FileInfo) {
+			if ids[info.Id] {
+				found = append(found, info.Id)
+			}
+		}
+		return found
+	}
+
+	assert.ElementsMatch(t, []string{old.Id, old2.Id}, getIds("", 1000))
+
+	first, second := old.Id, old2.Id
+	if second < first {
+		first, second = second, first
+	}
+	assert.NotContains(t, getIds(first, 1000), first)
+	assert.Contains(t, getIds(first, 1000), second)
+}
+
+func testFileInfoUpdateStorageTier(t *testing.T, ss store.Store) {
+	info := store.Must(ss.FileInfo().Save(&model.FileInfo{
+		CreatorId: model.NewId(),
+		PostId:    model.NewId(),
+		CreateAt:  1000,
+		Path:      "file.txt",
+	})).(*model.FileInfo)
+	assert.Equal(t, model.FILE_INFO_STORAGE_TIER_HOT, info.StorageTier)
+
+	result := <-ss.FileInfo().UpdateStorageTier(info.Id, model.FILE_INFO_STORAGE_TIER_COLD)
+	require.Nil(t, result.Err)
+
+	updated := store.Must(ss.FileInfo().Get(info.Id)).(*model.FileInfo)
+	assert.Equal(t, model.FILE_INFO_STORAGE_TIER_COLD, updated.StorageTier)
+	assert.True(t, updated.UpdateAt > info.UpdateAt)
+}
+
+func testFileInfoGetBatchForIntegrityScan(t *testing.T, ss store.Store) {
+	info := store.Must(ss.FileInfo().Save(&model.FileInfo{
+		CreatorId: model.NewId(),
+		PostId:    model.NewId(),
+		Path:      "file.txt",
+		Checksum:  "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+	})).(*model.FileInfo)
+	deleted := store.Must(ss.FileInfo().Save(&model.FileInfo{
+		CreatorId: model.NewId(),
+		PostId:    model.NewId(),
+		Path:      "file.txt",
+	})).(*model.FileInfo)
+	store.Must(ss.FileInfo().DeleteForPost(deleted.PostId))
+
+	var found []*model.FileInfo
+	afterId := ""
+	for {
+		result := <-ss.FileInfo().GetBatchForIntegrityScan(afterId, 100)
+		require.Nil(t, result.Err)
+		infos := result.Data.([]*model.FileInfo)
+		if len(infos) == 0 {
+			break
+		}
+
+		for _, candidate := range infos {
+			assert.True(t, candidate.Id > afterId, "should be ordered by id")
+			if candidate.Id == info.Id || candidate.Id == deleted.Id {
+				found = append(found, candidate)
+			}
+		}
+		afterId = infos[len(infos)-1].Id
+	}
+
+	require.Len(t, found, 1)
+	assert.Equal(t, info.Id, found[0].Id)
+	assert.Equal(t, info.Checksum, found[0].Checksum)
+}
+
+func testFileInfoCountByPath(t *testing.T, ss store.Store) {
+	path := "countbypath/" + model.NewId() + "/file.txt"
+
+	result := <-ss.FileInfo().CountByPath(path)
+	require.Nil(t, result.Err)
+	assert.Equal(t, int64(0), result.Data.(int64))
+
+	info := store.Must(ss.FileInfo().Save(&model.FileInfo{
+		CreatorId: model.NewId(),
+		PostId:    model.NewId(),
+		Path:      path,
+	})).(*model.FileInfo)
+	store.Must(ss.FileInfo().Save(&model.FileInfo{
+		CreatorId: model.NewId(),
+		Path:      path,
+	}))
+
+	// Deleted FileInfos still refer to the file
+	store.Must(ss.FileInfo().DeleteForPost(info.PostId))
+
+	result = <-ss.FileInfo().CountByPath(path)
+	require.Nil(t, result.Err)
+	assert.Equal(t, int64(2), result.Data.(int64))
+}
+
+func testFileInfoGetStorageSizeForChannel(t *testing.T, ss store.Store) {
+	channelId := model.NewId()
+
+	result := <-ss.FileInfo().GetStorageSizeForChannel(channelId)
+	require.Nil(t, result.Err)
+	assert.Equal(t, int64(0), result.Data.(int64))
+
+	path := "20190101/teams/noteam/channels/" + channelId + "/users/" + model.NewId() + "/" + model.NewId() + "/file.txt"
+	store.Must(ss.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), Path: path, Size: 10}))
+
+	// Copies share the stored file, so they don't take up any more storage
+	store.Must(ss.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), Path: path, Size: 10}))
+
+	store.Must(ss.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), Path: "teams/" + model.NewId() + "/channels/" + channelId + "/users/" + model.NewId() + "/" + model.NewId() + "/other.txt", Size: 5}))
+	store.Must(ss.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), Path: "20190101/teams/noteam/channels/" + model.NewId() + "/users/" + model.NewId() + "/" + model.NewId() + "/file.txt", Size: 100}))
+
+	result = <-ss.FileInfo().GetStorageSizeForChannel(channelId)
+	require.Nil(t, result.Err)
+	assert.Equal(t, int64(15), result.Data.(int64))
+}
+
+func testFileInfoGetBatchForStorageUsage(t *testing.T, ss store.Store) {
+	dir := "storageusage/" + model.NewId() + "/"
+
+	original := store.Must(ss.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), PostId: model.NewId(), Path: dir + "a.txt", CreateAt: 1000})).(*model.FileInfo)
+	store.Must(ss.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), Path: original.Path, CreateAt: 1000}))
+	deleted := store.Must(ss.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), PostId: model.NewId(), Path: dir + "b.txt", CreateAt: 1000})).(*model.FileInfo)
+	store.Must(ss.FileInfo().DeleteForPost(deleted.PostId))
+	store.Must(ss.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), Path: dir + "c.txt", CreateAt: 3000}))
+
+	var found []string
+	afterId := ""
+	for {
+		infos := store.Must(ss.FileInfo().GetBatchForStorageUsage(afterId, 2000, 100)).([]*model.FileInfo)
+		if len(infos) == 0 {
+			break
+		}
+
+		for _, info := range infos {
+			assert.True(t, info.Id > afterId)
+			if strings.HasPrefix(info.Path, dir) {
+				found = append(found, info.Path)
+			}
+		}
+		afterId = infos[len(infos)-1].Id
+	}
+
+	// Copies and files created since are left out, but deleted files are still stored
+	assert.ElementsMatch(t, []string{dir + "a.txt", dir + "b.txt"}, found)
+}
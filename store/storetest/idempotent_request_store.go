@@ -0,0 +1,112 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package storetest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/store"
+)
+
+func TestIdempotentRequestStore(t *testing.T, ss store.Store) {
+	t.Run("Save", func(t *testing.T) { testIdempotentRequestStoreSave(t, ss) })
+	t.Run("Complete", func(t *testing.T) { testIdempotentRequestStoreComplete(t, ss) })
+	t.Run("Delete", func(t *testing.T) { testIdempotentRequestStoreDelete(t, ss) })
+	t.Run("Cleanup", func(t *testing.T) { testIdempotentRequestStoreCleanup(t, ss) })
+}
+
+func newIdempotentRequest() *model.IdempotentRequest {
+	userId := model.NewId()
+	return &model.IdempotentRequest{
+		Id:       model.IdempotentRequestId(userId, model.NewId()),
+		UserId:   userId,
+		Method:   "POST",
+		Path:     "/api/v4/posts",
+		BodyHash: model.HashIdempotentRequestBody([]byte("{}")),
+		CreateAt: model.GetMillis(),
+	}
+}
+
+func testIdempotentRequestStoreSave(t *testing.T, ss store.Store) {
+	request := newIdempotentRequest()
+
+	result := <-ss.IdempotentRequest().Save(request)
+	require.Nil(t, result.Err)
+	assert.True(t, result.Data.(bool))
+
+	result = <-ss.IdempotentRequest().Save(request)
+	require.Nil(t, result.Err)
+	assert.False(t, result.Data.(bool), "a request with the same id should only be saved once")
+
+	result = <-ss.IdempotentRequest().Get(request.Id)
+	require.Nil(t, result.Err)
+	saved := result.Data.(*model.IdempotentRequest)
+	assert.Equal(t, request.UserId, saved.UserId)
+	assert.Equal(t, request.BodyHash, saved.BodyHash)
+	assert.True(t, saved.IsInProgress())
+
+	result = <-ss.IdempotentRequest().Get(model.IdempotentRequestId(model.NewId(), "missing"))
+	require.NotNil(t, result.Err)
+	assert.Equal(t, http.StatusNotFound, result.Err.StatusCode)
+
+	invalid := newIdempotentRequest()
+	invalid.UserId = ""
+	assert.NotNil(t, (<-ss.IdempotentRequest().Save(invalid)).Err)
+}
+
+func testIdempotentRequestStoreComplete(t *testing.T, ss store.Store) {
+	request := newIdempotentRequest()
+	require.Nil(t, (<-ss.IdempotentRequest().Save(request)).Err)
+
+	request.StatusCode = http.StatusCreated
+	request.Header = `{"Location":["/created"]}`
+	request.Body = []byte("created")
+	require.Nil(t, (<-ss.IdempotentRequest().Complete(request)).Err)
+
+	result := <-ss.IdempotentRequest().Get(request.Id)
+	require.Nil(t, result.Err)
+	saved := result.Data.(*model.IdempotentRequest)
+	assert.Equal(t, http.StatusCreated, saved.StatusCode)
+	assert.Equal(t, request.Header, saved.Header)
+	assert.Equal(t, []byte("created"), saved.Body)
+
+	// A request is only completed once.
+	request.StatusCode = http.StatusOK
+	require.Nil(t, (<-ss.IdempotentRequest().Complete(request)).Err)
+
+	result = <-ss.IdempotentRequest().Get(request.Id)
+	require.Nil(t, result.Err)
+	assert.Equal(t, http.StatusCreated, result.Data.(*model.IdempotentRequest).StatusCode)
+}
+
+func testIdempotentRequestStoreDelete(t *testing.T, ss store.Store) {
+	request := newIdempotentRequest()
+	require.Nil(t, (<-ss.IdempotentRequest().Save(request)).Err)
+
+	require.Nil(t, (<-ss.IdempotentRequest().Delete(request.Id)).Err)
+	assert.NotNil(t, (<-ss.IdempotentRequest().Get(request.Id)).Err)
+
+	result := <-ss.IdempotentRequest().Save(request)
+	require.Nil(t, result.Err)
+	assert.True(t, result.Data.(bool), "the key should be usable again once the request is deleted")
+}
+
+func testIdempotentRequestStoreCleanup(t *testing.T, ss store.Store) {
+	expired := newIdempotentRequest()
+	expired.CreateAt = model.GetMillis() - model.IDEMPOTENT_REQUEST_EXPIRY_TIME - 1000
+	require.Nil(t, (<-ss.IdempotentRequest().Save(expired)).Err)
+
+	recent := newIdempotentRequest()
+	require.Nil(t, (<-ss.IdempotentRequest().Save(recent)).Err)
+
+	ss.IdempotentRequest().Cleanup()
+
+	assert.NotNil(t, (<-ss.IdempotentRequest().Get(expired.Id)).Err)
+	assert.Nil(t, (<-ss.IdempotentRequest().Get(recent.Id)).Err)
+}
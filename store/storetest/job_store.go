@@ -23,6 +23,7 @@ func TestJobStore(t *testing.T, ss store.Store) {
 	t.Run("GetCountByStatusAndType", func(t *testing.T) { testJobStoreGetCountByStatusAndType(t, ss) })
 	t.Run("JobUpdateOptimistically", func(t *testing.T) { testJobUpdateOptimistically(t, ss) })
 	t.Run("JobUpdateStatusUpdateStatusOptimistically", func(t *testing.T) { testJobUpdateStatusUpdateStatusOptimistically(t, ss) })
+	t.Run("JobUpdateLastActivityAt", func(t *testing.T) { testJobUpdateLastActivityAt(t, ss) })
 	t.Run("JobDelete", func(t *testing.T) { testJobDelete(t, ss) })
 }
 
@@ -495,6 +496,30 @@ func testJobUpdateStatusUpdateStatusOptimistically(t *testing.T, ss store.Store)
 	}
 }
 
+func testJobUpdateLastActivityAt(t *testing.T, ss store.Store) {
+	job := store.Must(ss.Job().Save(&model.Job{
+		Id:       model.NewId(),
+		Type:     model.JOB_TYPE_COLD_STORAGE,
+		CreateAt: model.GetMillis(),
+		Status:   model.JOB_STATUS_IN_PROGRESS,
+		Progress: 40,
+	})).(*model.Job)
+	defer ss.Job().Delete(job.Id)
+
+	time.Sleep(2 * time.Millisecond)
+
+	updated := store.Must(ss.Job().UpdateLastActivityAt(job.Id, model.JOB_STATUS_PENDING)).(bool)
+	assert.False(t, updated, "a job with another status shouldn't be updated")
+
+	updated = store.Must(ss.Job().UpdateLastActivityAt(job.Id, model.JOB_STATUS_IN_PROGRESS)).(bool)
+	assert.True(t, updated)
+
+	received := store.Must(ss.Job().Get(job.Id)).(*model.Job)
+	assert.True(t, received.LastActivityAt > job.LastActivityAt, "lastActivityAt wasn't updated")
+	assert.Equal(t, model.JOB_STATUS_IN_PROGRESS, received.Status)
+	assert.EqualValues(t, 40, received.Progress)
+}
+
 func testJobDelete(t *testing.T, ss store.Store) {
 	job := store.Must(ss.Job().Save(&model.Job{
 		Id: model.NewId(),
@@ -13,6 +13,22 @@ type ChannelStore struct {
 	mock.Mock
 }
 
+// AnalyticsCreatedCount provides a mock function with given fields: teamId, since, until
+func (_m *ChannelStore) AnalyticsCreatedCount(teamId string, since int64, until int64) store.StoreChannel {
+	ret := _m.Called(teamId, since, until)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, int64, int64) store.StoreChannel); ok {
+		r0 = rf(teamId, since, until)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // AnalyticsDeletedTypeCount provides a mock function with given fields: teamId, channelType
 func (_m *ChannelStore) AnalyticsDeletedTypeCount(teamId string, channelType string) store.StoreChannel {
 	ret := _m.Called(teamId, channelType)
@@ -226,6 +242,22 @@ func (_m *ChannelStore) GetAllChannelsForExportAfter(limit int, afterId string)
 	return r0
 }
 
+// GetByIds provides a mock function with given fields: channelIds, allowFromCache
+func (_m *ChannelStore) GetByIds(channelIds []string, allowFromCache bool) store.StoreChannel {
+	ret := _m.Called(channelIds, allowFromCache)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func([]string, bool) store.StoreChannel); ok {
+		r0 = rf(channelIds, allowFromCache)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetByName provides a mock function with given fields: team_id, name, allowFromCache
 func (_m *ChannelStore) GetByName(team_id string, name string, allowFromCache bool) store.StoreChannel {
 	ret := _m.Called(team_id, name, allowFromCache)
@@ -370,6 +402,38 @@ func (_m *ChannelStore) GetChannelsByScheme(schemeId string, offset int, limit i
 	return r0
 }
 
+// GetCommonChannelCounts provides a mock function with given fields: userId, otherUserIds
+func (_m *ChannelStore) GetCommonChannelCounts(userId string, otherUserIds []string) store.StoreChannel {
+	ret := _m.Called(userId, otherUserIds)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, []string) store.StoreChannel); ok {
+		r0 = rf(userId, otherUserIds)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// GetCommonChannels provides a mock function with given fields: userId, otherUserId
+func (_m *ChannelStore) GetCommonChannels(userId string, otherUserId string) store.StoreChannel {
+	ret := _m.Called(userId, otherUserId)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string) store.StoreChannel); ok {
+		r0 = rf(userId, otherUserId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetDeleted provides a mock function with given fields: team_id, offset, limit
 func (_m *ChannelStore) GetDeleted(team_id string, offset int, limit int) store.StoreChannel {
 	ret := _m.Called(team_id, offset, limit)
@@ -434,6 +498,22 @@ func (_m *ChannelStore) GetFromMaster(id string) store.StoreChannel {
 	return r0
 }
 
+// GetInactive provides a mock function with given fields: teamId, before
+func (_m *ChannelStore) GetInactive(teamId string, before int64) store.StoreChannel {
+	ret := _m.Called(teamId, before)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, int64) store.StoreChannel); ok {
+		r0 = rf(teamId, before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetMember provides a mock function with given fields: channelId, userId
 func (_m *ChannelStore) GetMember(channelId string, userId string) store.StoreChannel {
 	ret := _m.Called(channelId, userId)
@@ -528,6 +608,22 @@ func (_m *ChannelStore) GetMembersByIds(channelId string, userIds []string) stor
 	return r0
 }
 
+// GetMembersForTeamAfter provides a mock function with given fields: teamId, afterChannelId, afterUserId, limit
+func (_m *ChannelStore) GetMembersForTeamAfter(teamId string, afterChannelId string, afterUserId string, limit int) store.StoreChannel {
+	ret := _m.Called(teamId, afterChannelId, afterUserId, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string, string, int) store.StoreChannel); ok {
+		r0 = rf(teamId, afterChannelId, afterUserId, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetMembersForUser provides a mock function with given fields: teamId, userId
 func (_m *ChannelStore) GetMembersForUser(teamId string, userId string) store.StoreChannel {
 	ret := _m.Called(teamId, userId)
@@ -544,6 +640,22 @@ func (_m *ChannelStore) GetMembersForUser(teamId string, userId string) store.St
 	return r0
 }
 
+// GetMembersSorted provides a mock function with given fields: channelId, sort, offset, limit
+func (_m *ChannelStore) GetMembersSorted(channelId string, sort string, offset int, limit int) store.StoreChannel {
+	ret := _m.Called(channelId, sort, offset, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string, int, int) store.StoreChannel); ok {
+		r0 = rf(channelId, sort, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetMoreChannels provides a mock function with given fields: teamId, userId, offset, limit
 func (_m *ChannelStore) GetMoreChannels(teamId string, userId string, offset int, limit int) store.StoreChannel {
 	ret := _m.Called(teamId, userId, offset, limit)
@@ -560,6 +672,38 @@ func (_m *ChannelStore) GetMoreChannels(teamId string, userId string, offset int
 	return r0
 }
 
+// GetMsgCountsForRepair provides a mock function with given fields: teamId, channelId, afterId, limit
+func (_m *ChannelStore) GetMsgCountsForRepair(teamId string, channelId string, afterId string, limit int) store.StoreChannel {
+	ret := _m.Called(teamId, channelId, afterId, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string, string, int) store.StoreChannel); ok {
+		r0 = rf(teamId, channelId, afterId, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// GetOrphanedMembers provides a mock function with given fields: afterChannelId, afterUserId, limit
+func (_m *ChannelStore) GetOrphanedMembers(afterChannelId string, afterUserId string, limit int) store.StoreChannel {
+	ret := _m.Called(afterChannelId, afterUserId, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string, int) store.StoreChannel); ok {
+		r0 = rf(afterChannelId, afterUserId, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetPinnedPosts provides a mock function with given fields: channelId
 func (_m *ChannelStore) GetPinnedPosts(channelId string) store.StoreChannel {
 	ret := _m.Called(channelId)
@@ -679,6 +823,22 @@ func (_m *ChannelStore) IsUserInChannelUseCache(userId string, channelId string)
 	return r0
 }
 
+// MergeInto provides a mock function with given fields: source, target
+func (_m *ChannelStore) MergeInto(source *model.Channel, target *model.Channel) store.StoreChannel {
+	ret := _m.Called(source, target)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(*model.Channel, *model.Channel) store.StoreChannel); ok {
+		r0 = rf(source, target)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // MigrateChannelMembers provides a mock function with given fields: fromChannelId, fromUserId
 func (_m *ChannelStore) MigrateChannelMembers(fromChannelId string, fromUserId string) store.StoreChannel {
 	ret := _m.Called(fromChannelId, fromUserId)
@@ -709,6 +869,22 @@ func (_m *ChannelStore) MigratePublicChannels() error {
 	return r0
 }
 
+// MoveToTeam provides a mock function with given fields: channel, teamId, name, removeMembersNotInTeam
+func (_m *ChannelStore) MoveToTeam(channel *model.Channel, teamId string, name string, removeMembersNotInTeam bool) store.StoreChannel {
+	ret := _m.Called(channel, teamId, name, removeMembersNotInTeam)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(*model.Channel, string, string, bool) store.StoreChannel); ok {
+		r0 = rf(channel, teamId, name, removeMembersNotInTeam)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // PermanentDelete provides a mock function with given fields: channelId
 func (_m *ChannelStore) PermanentDelete(channelId string) store.StoreChannel {
 	ret := _m.Called(channelId)
@@ -805,6 +981,38 @@ func (_m *ChannelStore) RemoveMember(channelId string, userId string) store.Stor
 	return r0
 }
 
+// RemoveOrphanedMember provides a mock function with given fields: channelId, userId
+func (_m *ChannelStore) RemoveOrphanedMember(channelId string, userId string) store.StoreChannel {
+	ret := _m.Called(channelId, userId)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string) store.StoreChannel); ok {
+		r0 = rf(channelId, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// RepairMsgCounts provides a mock function with given fields: counts
+func (_m *ChannelStore) RepairMsgCounts(counts *model.ChannelMsgCounts) store.StoreChannel {
+	ret := _m.Called(counts)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(*model.ChannelMsgCounts) store.StoreChannel); ok {
+		r0 = rf(counts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // ResetAllChannelSchemes provides a mock function with given fields:
 func (_m *ChannelStore) ResetAllChannelSchemes() store.StoreChannel {
 	ret := _m.Called()
@@ -885,6 +1093,22 @@ func (_m *ChannelStore) SaveMember(member *model.ChannelMember) store.StoreChann
 	return r0
 }
 
+// SaveMultipleMembers provides a mock function with given fields: members
+func (_m *ChannelStore) SaveMultipleMembers(members []*model.ChannelMember) store.StoreChannel {
+	ret := _m.Called(members)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func([]*model.ChannelMember) store.StoreChannel); ok {
+		r0 = rf(members)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // SearchAllChannels provides a mock function with given fields: term, includeDeleted
 func (_m *ChannelStore) SearchAllChannels(term string, includeDeleted bool) store.StoreChannel {
 	ret := _m.Called(term, includeDeleted)
@@ -917,6 +1141,22 @@ func (_m *ChannelStore) SearchInTeam(teamId string, term string, includeDeleted
 	return r0
 }
 
+// SearchMembers provides a mock function with given fields: channelId, term, role, allowFullNames, offset, limit
+func (_m *ChannelStore) SearchMembers(channelId string, term string, role string, allowFullNames bool, offset int, limit int) store.StoreChannel {
+	ret := _m.Called(channelId, term, role, allowFullNames, offset, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string, string, bool, int, int) store.StoreChannel); ok {
+		r0 = rf(channelId, term, role, allowFullNames, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // SearchMore provides a mock function with given fields: userId, teamId, term
 func (_m *ChannelStore) SearchMore(userId string, teamId string, term string) store.StoreChannel {
 	ret := _m.Called(userId, teamId, term)
@@ -996,3 +1236,19 @@ func (_m *ChannelStore) UpdateMember(member *model.ChannelMember) store.StoreCha
 
 	return r0
 }
+
+// UpdateMultipleMembers provides a mock function with given fields: members
+func (_m *ChannelStore) UpdateMultipleMembers(members []*model.ChannelMember) store.StoreChannel {
+	ret := _m.Called(members)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func([]*model.ChannelMember) store.StoreChannel); ok {
+		r0 = rf(members)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
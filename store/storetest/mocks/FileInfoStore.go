@@ -34,6 +34,22 @@ func (_m *FileInfoStore) ClearCaches() {
 	_m.Called()
 }
 
+// CountByPath provides a mock function with given fields: path
+func (_m *FileInfoStore) CountByPath(path string) store.StoreChannel {
+	ret := _m.Called(path)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string) store.StoreChannel); ok {
+		r0 = rf(path)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // DeleteForPost provides a mock function with given fields: postId
 func (_m *FileInfoStore) DeleteForPost(postId string) store.StoreChannel {
 	ret := _m.Called(postId)
@@ -66,6 +82,38 @@ func (_m *FileInfoStore) Get(id string) store.StoreChannel {
 	return r0
 }
 
+// GetBatchForIntegrityScan provides a mock function with given fields: afterId, limit
+func (_m *FileInfoStore) GetBatchForIntegrityScan(afterId string, limit int) store.StoreChannel {
+	ret := _m.Called(afterId, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, int) store.StoreChannel); ok {
+		r0 = rf(afterId, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// GetBatchForStorageUsage provides a mock function with given fields: afterId, before, limit
+func (_m *FileInfoStore) GetBatchForStorageUsage(afterId string, before int64, limit int) store.StoreChannel {
+	ret := _m.Called(afterId, before, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, int64, int) store.StoreChannel); ok {
+		r0 = rf(afterId, before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetByPath provides a mock function with given fields: path
 func (_m *FileInfoStore) GetByPath(path string) store.StoreChannel {
 	ret := _m.Called(path)
@@ -82,6 +130,38 @@ func (_m *FileInfoStore) GetByPath(path string) store.StoreChannel {
 	return r0
 }
 
+// GetExistingIds provides a mock function with given fields: ids
+func (_m *FileInfoStore) GetExistingIds(ids []string) store.StoreChannel {
+	ret := _m.Called(ids)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func([]string) store.StoreChannel); ok {
+		r0 = rf(ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// GetForColdStorage provides a mock function with given fields: before, afterId, limit
+func (_m *FileInfoStore) GetForColdStorage(before int64, afterId string, limit int) store.StoreChannel {
+	ret := _m.Called(before, afterId, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(int64, string, int) store.StoreChannel); ok {
+		r0 = rf(before, afterId, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetForPost provides a mock function with given fields: postId, readFromMaster, allowFromCache
 func (_m *FileInfoStore) GetForPost(postId string, readFromMaster bool, allowFromCache bool) store.StoreChannel {
 	ret := _m.Called(postId, readFromMaster, allowFromCache)
@@ -114,6 +194,38 @@ func (_m *FileInfoStore) GetForUser(userId string) store.StoreChannel {
 	return r0
 }
 
+// GetOrphaned provides a mock function with given fields: before, afterId, limit
+func (_m *FileInfoStore) GetOrphaned(before int64, afterId string, limit int) store.StoreChannel {
+	ret := _m.Called(before, afterId, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(int64, string, int) store.StoreChannel); ok {
+		r0 = rf(before, afterId, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// GetStorageSizeForChannel provides a mock function with given fields: channelId
+func (_m *FileInfoStore) GetStorageSizeForChannel(channelId string) store.StoreChannel {
+	ret := _m.Called(channelId)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string) store.StoreChannel); ok {
+		r0 = rf(channelId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // InvalidateFileInfosForPostCache provides a mock function with given fields: postId
 func (_m *FileInfoStore) InvalidateFileInfosForPostCache(postId string) {
 	_m.Called(postId)
@@ -182,3 +294,35 @@ func (_m *FileInfoStore) Save(info *model.FileInfo) store.StoreChannel {
 
 	return r0
 }
+
+// Search provides a mock function with given fields: teamId, userId, params, offset, limit
+func (_m *FileInfoStore) Search(teamId string, userId string, params *model.SearchParams, offset int, limit int) store.StoreChannel {
+	ret := _m.Called(teamId, userId, params, offset, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string, *model.SearchParams, int, int) store.StoreChannel); ok {
+		r0 = rf(teamId, userId, params, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// UpdateStorageTier provides a mock function with given fields: fileId, storageTier
+func (_m *FileInfoStore) UpdateStorageTier(fileId string, storageTier string) store.StoreChannel {
+	ret := _m.Called(fileId, storageTier)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string) store.StoreChannel); ok {
+		r0 = rf(fileId, storageTier)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
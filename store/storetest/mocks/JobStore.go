@@ -189,6 +189,22 @@ func (_m *JobStore) UpdateStatus(id string, status string) store.StoreChannel {
 	return r0
 }
 
+// UpdateLastActivityAt provides a mock function with given fields: id, status
+func (_m *JobStore) UpdateLastActivityAt(id string, status string) store.StoreChannel {
+	ret := _m.Called(id, status)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string) store.StoreChannel); ok {
+		r0 = rf(id, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // UpdateStatusOptimistically provides a mock function with given fields: id, currentStatus, newStatus
 func (_m *JobStore) UpdateStatusOptimistically(id string, currentStatus string, newStatus string) store.StoreChannel {
 	ret := _m.Called(id, currentStatus, newStatus)
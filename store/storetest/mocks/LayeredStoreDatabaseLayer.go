@@ -30,6 +30,22 @@ func (_m *LayeredStoreDatabaseLayer) Audit() store.AuditStore {
 	return r0
 }
 
+// CacheStats provides a mock function with given fields:
+func (_m *LayeredStoreDatabaseLayer) CacheStats() []*model.CacheStats {
+	ret := _m.Called()
+
+	var r0 []*model.CacheStats
+	if rf, ok := ret.Get(0).(func() []*model.CacheStats); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.CacheStats)
+		}
+	}
+
+	return r0
+}
+
 // Channel provides a mock function with given fields:
 func (_m *LayeredStoreDatabaseLayer) Channel() store.ChannelStore {
 	ret := _m.Called()
@@ -83,6 +99,22 @@ func (_m *LayeredStoreDatabaseLayer) ClusterDiscovery() store.ClusterDiscoverySt
 	return r0
 }
 
+// ClusterLock provides a mock function with given fields:
+func (_m *LayeredStoreDatabaseLayer) ClusterLock() store.ClusterLockStore {
+	ret := _m.Called()
+
+	var r0 store.ClusterLockStore
+	if rf, ok := ret.Get(0).(func() store.ClusterLockStore); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.ClusterLockStore)
+		}
+	}
+
+	return r0
+}
+
 // Command provides a mock function with given fields:
 func (_m *LayeredStoreDatabaseLayer) Command() store.CommandStore {
 	ret := _m.Called()
@@ -131,6 +163,22 @@ func (_m *LayeredStoreDatabaseLayer) Compliance() store.ComplianceStore {
 	return r0
 }
 
+// DbPoolStats provides a mock function with given fields:
+func (_m *LayeredStoreDatabaseLayer) DbPoolStats() []*model.DbPoolStats {
+	ret := _m.Called()
+
+	var r0 []*model.DbPoolStats
+	if rf, ok := ret.Get(0).(func() []*model.DbPoolStats); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.DbPoolStats)
+		}
+	}
+
+	return r0
+}
+
 // DropAllTables provides a mock function with given fields:
 func (_m *LayeredStoreDatabaseLayer) DropAllTables() {
 	_m.Called()
@@ -552,6 +600,22 @@ func (_m *LayeredStoreDatabaseLayer) GroupUpdateGroupSyncable(ctx context.Contex
 	return r0
 }
 
+// IdempotentRequest provides a mock function with given fields:
+func (_m *LayeredStoreDatabaseLayer) IdempotentRequest() store.IdempotentRequestStore {
+	ret := _m.Called()
+
+	var r0 store.IdempotentRequestStore
+	if rf, ok := ret.Get(0).(func() store.IdempotentRequestStore); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.IdempotentRequestStore)
+		}
+	}
+
+	return r0
+}
+
 // Job provides a mock function with given fields:
 func (_m *LayeredStoreDatabaseLayer) Job() store.JobStore {
 	ret := _m.Called()
@@ -890,6 +954,29 @@ func (_m *LayeredStoreDatabaseLayer) ReactionsBulkGetForPosts(ctx context.Contex
 	return r0
 }
 
+// ReactionsGetUserIdsForEmojiInChannel provides a mock function with given fields: ctx, channelId, emojiName, since, offset, limit, hints
+func (_m *LayeredStoreDatabaseLayer) ReactionsGetUserIdsForEmojiInChannel(ctx context.Context, channelId string, emojiName string, since int64, offset int, limit int, hints ...store.LayeredStoreHint) *store.LayeredStoreSupplierResult {
+	_va := make([]interface{}, len(hints))
+	for _i := range hints {
+		_va[_i] = hints[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, channelId, emojiName, since, offset, limit)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *store.LayeredStoreSupplierResult
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, int, int, ...store.LayeredStoreHint) *store.LayeredStoreSupplierResult); ok {
+		r0 = rf(ctx, channelId, emojiName, since, offset, limit, hints...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*store.LayeredStoreSupplierResult)
+		}
+	}
+
+	return r0
+}
+
 // Role provides a mock function with given fields:
 func (_m *LayeredStoreDatabaseLayer) Role() store.RoleStore {
 	ret := _m.Called()
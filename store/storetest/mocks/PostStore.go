@@ -45,6 +45,54 @@ func (_m *PostStore) AnalyticsPostCountsByDay(teamId string) store.StoreChannel
 	return r0
 }
 
+// AnalyticsPostCountsByInterval provides a mock function with given fields: channelId, since, until, interval
+func (_m *PostStore) AnalyticsPostCountsByInterval(channelId string, since int64, until int64, interval int64) store.StoreChannel {
+	ret := _m.Called(channelId, since, until, interval)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, int64, int64, int64) store.StoreChannel); ok {
+		r0 = rf(channelId, since, until, interval)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// AnalyticsPostersCount provides a mock function with given fields: teamId, since, until
+func (_m *PostStore) AnalyticsPostersCount(teamId string, since int64, until int64) store.StoreChannel {
+	ret := _m.Called(teamId, since, until)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, int64, int64) store.StoreChannel); ok {
+		r0 = rf(teamId, since, until)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// AnalyticsTeamActivityByDay provides a mock function with given fields: teamId, since, until
+func (_m *PostStore) AnalyticsTeamActivityByDay(teamId string, since int64, until int64) store.StoreChannel {
+	ret := _m.Called(teamId, since, until)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, int64, int64) store.StoreChannel); ok {
+		r0 = rf(teamId, since, until)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // AnalyticsUserCountsWithPostsByDay provides a mock function with given fields: teamId
 func (_m *PostStore) AnalyticsUserCountsWithPostsByDay(teamId string) store.StoreChannel {
 	ret := _m.Called(teamId)
@@ -98,6 +146,38 @@ func (_m *PostStore) Get(id string) store.StoreChannel {
 	return r0
 }
 
+// GetCatchUpForUser provides a mock function with given fields: userId, since, sinceLastViewed, collapsedThreads, offset, limit
+func (_m *PostStore) GetCatchUpForUser(userId string, since int64, sinceLastViewed bool, collapsedThreads bool, offset int, limit int) store.StoreChannel {
+	ret := _m.Called(userId, since, sinceLastViewed, collapsedThreads, offset, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, int64, bool, bool, int, int) store.StoreChannel); ok {
+		r0 = rf(userId, since, sinceLastViewed, collapsedThreads, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// GetDeletedPostsForChannel provides a mock function with given fields: channelId, offset, limit
+func (_m *PostStore) GetDeletedPostsForChannel(channelId string, offset int, limit int) store.StoreChannel {
+	ret := _m.Called(channelId, offset, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, int, int) store.StoreChannel); ok {
+		r0 = rf(channelId, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetEtag provides a mock function with given fields: channelId, allowFromCache
 func (_m *PostStore) GetEtag(channelId string, allowFromCache bool) store.StoreChannel {
 	ret := _m.Called(channelId, allowFromCache)
@@ -114,6 +194,22 @@ func (_m *PostStore) GetEtag(channelId string, allowFromCache bool) store.StoreC
 	return r0
 }
 
+// GetExpiredPinnedPosts provides a mock function with given fields: before, limit
+func (_m *PostStore) GetExpiredPinnedPosts(before int64, limit int) store.StoreChannel {
+	ret := _m.Called(before, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(int64, int) store.StoreChannel); ok {
+		r0 = rf(before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetFlaggedPosts provides a mock function with given fields: userId, offset, limit
 func (_m *PostStore) GetFlaggedPosts(userId string, offset int, limit int) store.StoreChannel {
 	ret := _m.Called(userId, offset, limit)
@@ -162,6 +258,22 @@ func (_m *PostStore) GetFlaggedPostsForTeam(userId string, teamId string, offset
 	return r0
 }
 
+// GetHereMentions provides a mock function with given fields: postId
+func (_m *PostStore) GetHereMentions(postId string) store.StoreChannel {
+	ret := _m.Called(postId)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string) store.StoreChannel); ok {
+		r0 = rf(postId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetMaxPostSize provides a mock function with given fields:
 func (_m *PostStore) GetMaxPostSize() store.StoreChannel {
 	ret := _m.Called()
@@ -242,6 +354,22 @@ func (_m *PostStore) GetPostsAfter(channelId string, postId string, numPosts int
 	return r0
 }
 
+// GetPostsBatchForChannelIndexing provides a mock function with given fields: channelId, afterCreateAt, afterId, limit
+func (_m *PostStore) GetPostsBatchForChannelIndexing(channelId string, afterCreateAt int64, afterId string, limit int) store.StoreChannel {
+	ret := _m.Called(channelId, afterCreateAt, afterId, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, int64, string, int) store.StoreChannel); ok {
+		r0 = rf(channelId, afterCreateAt, afterId, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetPostsBatchForIndexing provides a mock function with given fields: startTime, endTime, limit
 func (_m *PostStore) GetPostsBatchForIndexing(startTime int64, endTime int64, limit int) store.StoreChannel {
 	ret := _m.Called(startTime, endTime, limit)
@@ -322,6 +450,22 @@ func (_m *PostStore) GetPostsSince(channelId string, time int64, allowFromCache
 	return r0
 }
 
+// GetPostsWithTermsInChannel provides a mock function with given fields: channelId, terms, since, until, offset, limit
+func (_m *PostStore) GetPostsWithTermsInChannel(channelId string, terms []string, since int64, until int64, offset int, limit int) store.StoreChannel {
+	ret := _m.Called(channelId, terms, since, until, offset, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, []string, int64, int64, int, int) store.StoreChannel); ok {
+		r0 = rf(channelId, terms, since, until, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetRepliesForExport provides a mock function with given fields: parentId
 func (_m *PostStore) GetRepliesForExport(parentId string) store.StoreChannel {
 	ret := _m.Called(parentId)
@@ -359,6 +503,22 @@ func (_m *PostStore) InvalidateLastPostTimeCache(channelId string) {
 	_m.Called(channelId)
 }
 
+// MoveToChannel provides a mock function with given fields: posts, fromChannelId, toChannelId
+func (_m *PostStore) MoveToChannel(posts []*model.Post, fromChannelId string, toChannelId string) store.StoreChannel {
+	ret := _m.Called(posts, fromChannelId, toChannelId)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func([]*model.Post, string, string) store.StoreChannel); ok {
+		r0 = rf(posts, fromChannelId, toChannelId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // Overwrite provides a mock function with given fields: post
 func (_m *PostStore) Overwrite(post *model.Post) store.StoreChannel {
 	ret := _m.Called(post)
@@ -375,6 +535,22 @@ func (_m *PostStore) Overwrite(post *model.Post) store.StoreChannel {
 	return r0
 }
 
+// OverwriteIfUnchanged provides a mock function with given fields: post, oldUpdateAt
+func (_m *PostStore) OverwriteIfUnchanged(post *model.Post, oldUpdateAt int64) store.StoreChannel {
+	ret := _m.Called(post, oldUpdateAt)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(*model.Post, int64) store.StoreChannel); ok {
+		r0 = rf(post, oldUpdateAt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // PermanentDeleteBatch provides a mock function with given fields: endTime, limit
 func (_m *PostStore) PermanentDeleteBatch(endTime int64, limit int64) store.StoreChannel {
 	ret := _m.Called(endTime, limit)
@@ -439,6 +615,22 @@ func (_m *PostStore) Save(post *model.Post) store.StoreChannel {
 	return r0
 }
 
+// SaveHereMentions provides a mock function with given fields: mentions
+func (_m *PostStore) SaveHereMentions(mentions *model.PostHereMentions) store.StoreChannel {
+	ret := _m.Called(mentions)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(*model.PostHereMentions) store.StoreChannel); ok {
+		r0 = rf(mentions)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // Search provides a mock function with given fields: teamId, userId, params
 func (_m *PostStore) Search(teamId string, userId string, params *model.SearchParams) store.StoreChannel {
 	ret := _m.Called(teamId, userId, params)
@@ -455,6 +647,38 @@ func (_m *PostStore) Search(teamId string, userId string, params *model.SearchPa
 	return r0
 }
 
+// SearchInTeams provides a mock function with given fields: teamIds, userId, params
+func (_m *PostStore) SearchInTeams(teamIds []string, userId string, params *model.SearchParams) store.StoreChannel {
+	ret := _m.Called(teamIds, userId, params)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func([]string, string, *model.SearchParams) store.StoreChannel); ok {
+		r0 = rf(teamIds, userId, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// SearchInTeamsPage provides a mock function with given fields: teamIds, userId, params, offset, limit
+func (_m *PostStore) SearchInTeamsPage(teamIds []string, userId string, params *model.SearchParams, offset int, limit int) store.StoreChannel {
+	ret := _m.Called(teamIds, userId, params, offset, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func([]string, string, *model.SearchParams, int, int) store.StoreChannel); ok {
+		r0 = rf(teamIds, userId, params, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: newPost, oldPost
 func (_m *PostStore) Update(newPost *model.Post, oldPost *model.Post) store.StoreChannel {
 	ret := _m.Called(newPost, oldPost)
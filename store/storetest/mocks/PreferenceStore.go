@@ -172,3 +172,19 @@ func (_m *PreferenceStore) Save(preferences *model.Preferences) store.StoreChann
 
 	return r0
 }
+
+// SaveNewer provides a mock function with given fields: preferences
+func (_m *PreferenceStore) SaveNewer(preferences *model.Preferences) store.StoreChannel {
+	ret := _m.Called(preferences)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(*model.Preferences) store.StoreChannel); ok {
+		r0 = rf(preferences)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
@@ -77,6 +77,22 @@ func (_m *ReactionStore) GetForPost(postId string, allowFromCache bool) store.St
 	return r0
 }
 
+// GetUserIdsForEmojiInChannel provides a mock function with given fields: channelId, emojiName, since, offset, limit
+func (_m *ReactionStore) GetUserIdsForEmojiInChannel(channelId string, emojiName string, since int64, offset int, limit int) store.StoreChannel {
+	ret := _m.Called(channelId, emojiName, since, offset, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string, int64, int, int) store.StoreChannel); ok {
+		r0 = rf(channelId, emojiName, since, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // PermanentDeleteBatch provides a mock function with given fields: endTime, limit
 func (_m *ReactionStore) PermanentDeleteBatch(endTime int64, limit int64) store.StoreChannel {
 	ret := _m.Called(endTime, limit)
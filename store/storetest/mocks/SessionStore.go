@@ -178,6 +178,22 @@ func (_m *SessionStore) UpdateLastActivityAt(sessionId string, time int64) store
 	return r0
 }
 
+// UpdateProps provides a mock function with given fields: session
+func (_m *SessionStore) UpdateProps(session *model.Session) store.StoreChannel {
+	ret := _m.Called(session)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(*model.Session) store.StoreChannel); ok {
+		r0 = rf(session)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // UpdateRoles provides a mock function with given fields: userId, roles
 func (_m *SessionStore) UpdateRoles(userId string, roles string) store.StoreChannel {
 	ret := _m.Called(userId, roles)
@@ -6,6 +6,7 @@ package mocks
 
 import gorp "github.com/mattermost/gorp"
 import mock "github.com/stretchr/testify/mock"
+import model "github.com/mattermost/mattermost-server/model"
 
 import store "github.com/mattermost/mattermost-server/store"
 
@@ -58,6 +59,22 @@ func (_m *SqlStore) Audit() store.AuditStore {
 	return r0
 }
 
+// CacheStats provides a mock function with given fields:
+func (_m *SqlStore) CacheStats() []*model.CacheStats {
+	ret := _m.Called()
+
+	var r0 []*model.CacheStats
+	if rf, ok := ret.Get(0).(func() []*model.CacheStats); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.CacheStats)
+		}
+	}
+
+	return r0
+}
+
 // Channel provides a mock function with given fields:
 func (_m *SqlStore) Channel() store.ChannelStore {
 	ret := _m.Called()
@@ -95,6 +112,22 @@ func (_m *SqlStore) ClusterDiscovery() store.ClusterDiscoveryStore {
 	return r0
 }
 
+// ClusterLock provides a mock function with given fields:
+func (_m *SqlStore) ClusterLock() store.ClusterLockStore {
+	ret := _m.Called()
+
+	var r0 store.ClusterLockStore
+	if rf, ok := ret.Get(0).(func() store.ClusterLockStore); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.ClusterLockStore)
+		}
+	}
+
+	return r0
+}
+
 // Command provides a mock function with given fields:
 func (_m *SqlStore) Command() store.CommandStore {
 	ret := _m.Called()
@@ -227,6 +260,22 @@ func (_m *SqlStore) CreateUniqueIndexIfNotExists(indexName string, tableName str
 	return r0
 }
 
+// DbPoolStats provides a mock function with given fields:
+func (_m *SqlStore) DbPoolStats() []*model.DbPoolStats {
+	ret := _m.Called()
+
+	var r0 []*model.DbPoolStats
+	if rf, ok := ret.Get(0).(func() []*model.DbPoolStats); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.DbPoolStats)
+		}
+	}
+
+	return r0
+}
+
 // DoesColumnExist provides a mock function with given fields: tableName, columName
 func (_m *SqlStore) DoesColumnExist(tableName string, columName string) bool {
 	ret := _m.Called(tableName, columName)
@@ -407,6 +456,22 @@ func (_m *SqlStore) GetSearchReplica() *gorp.DbMap {
 	return r0
 }
 
+// IdempotentRequest provides a mock function with given fields:
+func (_m *SqlStore) IdempotentRequest() store.IdempotentRequestStore {
+	ret := _m.Called()
+
+	var r0 store.IdempotentRequestStore
+	if rf, ok := ret.Get(0).(func() store.IdempotentRequestStore); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.IdempotentRequestStore)
+		}
+	}
+
+	return r0
+}
+
 // Job provides a mock function with given fields:
 func (_m *SqlStore) Job() store.JobStore {
 	ret := _m.Called()
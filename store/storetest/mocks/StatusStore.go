@@ -93,6 +93,22 @@ func (_m *StatusStore) GetOnlineAway() store.StoreChannel {
 	return r0
 }
 
+// GetRecentlyActive provides a mock function with given fields: limit
+func (_m *StatusStore) GetRecentlyActive(limit int) store.StoreChannel {
+	ret := _m.Called(limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(int) store.StoreChannel); ok {
+		r0 = rf(limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetTotalActiveUsersCount provides a mock function with given fields:
 func (_m *StatusStore) GetTotalActiveUsersCount() store.StoreChannel {
 	ret := _m.Called()
@@ -5,6 +5,7 @@
 package mocks
 
 import mock "github.com/stretchr/testify/mock"
+import model "github.com/mattermost/mattermost-server/model"
 import store "github.com/mattermost/mattermost-server/store"
 
 // Store is an autogenerated mock type for the Store type
@@ -28,6 +29,22 @@ func (_m *Store) Audit() store.AuditStore {
 	return r0
 }
 
+// CacheStats provides a mock function with given fields:
+func (_m *Store) CacheStats() []*model.CacheStats {
+	ret := _m.Called()
+
+	var r0 []*model.CacheStats
+	if rf, ok := ret.Get(0).(func() []*model.CacheStats); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.CacheStats)
+		}
+	}
+
+	return r0
+}
+
 // Channel provides a mock function with given fields:
 func (_m *Store) Channel() store.ChannelStore {
 	ret := _m.Called()
@@ -81,6 +98,22 @@ func (_m *Store) ClusterDiscovery() store.ClusterDiscoveryStore {
 	return r0
 }
 
+// ClusterLock provides a mock function with given fields:
+func (_m *Store) ClusterLock() store.ClusterLockStore {
+	ret := _m.Called()
+
+	var r0 store.ClusterLockStore
+	if rf, ok := ret.Get(0).(func() store.ClusterLockStore); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.ClusterLockStore)
+		}
+	}
+
+	return r0
+}
+
 // Command provides a mock function with given fields:
 func (_m *Store) Command() store.CommandStore {
 	ret := _m.Called()
@@ -129,6 +162,22 @@ func (_m *Store) Compliance() store.ComplianceStore {
 	return r0
 }
 
+// DbPoolStats provides a mock function with given fields:
+func (_m *Store) DbPoolStats() []*model.DbPoolStats {
+	ret := _m.Called()
+
+	var r0 []*model.DbPoolStats
+	if rf, ok := ret.Get(0).(func() []*model.DbPoolStats); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*model.DbPoolStats)
+		}
+	}
+
+	return r0
+}
+
 // DropAllTables provides a mock function with given fields:
 func (_m *Store) DropAllTables() {
 	_m.Called()
@@ -182,6 +231,22 @@ func (_m *Store) Group() store.GroupStore {
 	return r0
 }
 
+// IdempotentRequest provides a mock function with given fields:
+func (_m *Store) IdempotentRequest() store.IdempotentRequestStore {
+	ret := _m.Called()
+
+	var r0 store.IdempotentRequestStore
+	if rf, ok := ret.Get(0).(func() store.IdempotentRequestStore); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.IdempotentRequestStore)
+		}
+	}
+
+	return r0
+}
+
 // Job provides a mock function with given fields:
 func (_m *Store) Job() store.JobStore {
 	ret := _m.Called()
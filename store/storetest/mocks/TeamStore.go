@@ -61,6 +61,22 @@ func (_m *TeamStore) ClearAllCustomRoleAssignments() store.StoreChannel {
 	return r0
 }
 
+// DeleteChannelTemplate provides a mock function with given fields: id
+func (_m *TeamStore) DeleteChannelTemplate(id string) store.StoreChannel {
+	ret := _m.Called(id)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string) store.StoreChannel); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // Get provides a mock function with given fields: id
 func (_m *TeamStore) Get(id string) store.StoreChannel {
 	ret := _m.Called(id)
@@ -173,6 +189,22 @@ func (_m *TeamStore) GetAllTeamPageListing(offset int, limit int) store.StoreCha
 	return r0
 }
 
+// GetAutoJoinTeams provides a mock function with given fields:
+func (_m *TeamStore) GetAutoJoinTeams() store.StoreChannel {
+	ret := _m.Called()
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func() store.StoreChannel); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetByInviteId provides a mock function with given fields: inviteId
 func (_m *TeamStore) GetByInviteId(inviteId string) store.StoreChannel {
 	ret := _m.Called(inviteId)
@@ -205,6 +237,38 @@ func (_m *TeamStore) GetByName(name string) store.StoreChannel {
 	return r0
 }
 
+// GetChannelTemplate provides a mock function with given fields: id
+func (_m *TeamStore) GetChannelTemplate(id string) store.StoreChannel {
+	ret := _m.Called(id)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string) store.StoreChannel); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// GetChannelTemplatesForTeam provides a mock function with given fields: teamId
+func (_m *TeamStore) GetChannelTemplatesForTeam(teamId string) store.StoreChannel {
+	ret := _m.Called(teamId)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string) store.StoreChannel); ok {
+		r0 = rf(teamId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetChannelUnreadsForAllTeams provides a mock function with given fields: excludeTeamId, userId
 func (_m *TeamStore) GetChannelUnreadsForAllTeams(excludeTeamId string, userId string) store.StoreChannel {
 	ret := _m.Called(excludeTeamId, userId)
@@ -237,6 +301,38 @@ func (_m *TeamStore) GetChannelUnreadsForTeam(teamId string, userId string) stor
 	return r0
 }
 
+// GetInvite provides a mock function with given fields: id
+func (_m *TeamStore) GetInvite(id string) store.StoreChannel {
+	ret := _m.Called(id)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string) store.StoreChannel); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// GetInvitesForTeam provides a mock function with given fields: teamId
+func (_m *TeamStore) GetInvitesForTeam(teamId string) store.StoreChannel {
+	ret := _m.Called(teamId)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string) store.StoreChannel); ok {
+		r0 = rf(teamId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetMember provides a mock function with given fields: teamId, userId
 func (_m *TeamStore) GetMember(teamId string, userId string) store.StoreChannel {
 	ret := _m.Called(teamId, userId)
@@ -269,6 +365,22 @@ func (_m *TeamStore) GetMembers(teamId string, offset int, limit int) store.Stor
 	return r0
 }
 
+// GetMembersAfter provides a mock function with given fields: teamId, afterUserId, limit
+func (_m *TeamStore) GetMembersAfter(teamId string, afterUserId string, limit int) store.StoreChannel {
+	ret := _m.Called(teamId, afterUserId, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string, int) store.StoreChannel); ok {
+		r0 = rf(teamId, afterUserId, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetMembersByIds provides a mock function with given fields: teamId, userIds
 func (_m *TeamStore) GetMembersByIds(teamId string, userIds []string) store.StoreChannel {
 	ret := _m.Called(teamId, userIds)
@@ -285,6 +397,38 @@ func (_m *TeamStore) GetMembersByIds(teamId string, userIds []string) store.Stor
 	return r0
 }
 
+// GetOrphanedMembers provides a mock function with given fields: afterTeamId, afterUserId, limit
+func (_m *TeamStore) GetOrphanedMembers(afterTeamId string, afterUserId string, limit int) store.StoreChannel {
+	ret := _m.Called(afterTeamId, afterUserId, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string, int) store.StoreChannel); ok {
+		r0 = rf(afterTeamId, afterUserId, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// GetStorageUsage provides a mock function with given fields: teamId
+func (_m *TeamStore) GetStorageUsage(teamId string) store.StoreChannel {
+	ret := _m.Called(teamId)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string) store.StoreChannel); ok {
+		r0 = rf(teamId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetTeamMembersForExport provides a mock function with given fields: userId
 func (_m *TeamStore) GetTeamMembersForExport(userId string) store.StoreChannel {
 	ret := _m.Called(userId)
@@ -365,6 +509,38 @@ func (_m *TeamStore) GetTotalMemberCount(teamId string) store.StoreChannel {
 	return r0
 }
 
+// GetWaitlist provides a mock function with given fields: teamId, offset, limit
+func (_m *TeamStore) GetWaitlist(teamId string, offset int, limit int) store.StoreChannel {
+	ret := _m.Called(teamId, offset, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, int, int) store.StoreChannel); ok {
+		r0 = rf(teamId, offset, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// GetWaitlistEntry provides a mock function with given fields: teamId, userId
+func (_m *TeamStore) GetWaitlistEntry(teamId string, userId string) store.StoreChannel {
+	ret := _m.Called(teamId, userId)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string) store.StoreChannel); ok {
+		r0 = rf(teamId, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // MigrateTeamMembers provides a mock function with given fields: fromTeamId, fromUserId
 func (_m *TeamStore) MigrateTeamMembers(fromTeamId string, fromUserId string) store.StoreChannel {
 	ret := _m.Called(fromTeamId, fromUserId)
@@ -397,6 +573,22 @@ func (_m *TeamStore) PermanentDelete(teamId string) store.StoreChannel {
 	return r0
 }
 
+// ReleaseInvite provides a mock function with given fields: id
+func (_m *TeamStore) ReleaseInvite(id string) store.StoreChannel {
+	ret := _m.Called(id)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string) store.StoreChannel); ok {
+		r0 = rf(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // RemoveAllMembersByTeam provides a mock function with given fields: teamId
 func (_m *TeamStore) RemoveAllMembersByTeam(teamId string) store.StoreChannel {
 	ret := _m.Called(teamId)
@@ -445,6 +637,38 @@ func (_m *TeamStore) RemoveMember(teamId string, userId string) store.StoreChann
 	return r0
 }
 
+// RemoveOrphanedMember provides a mock function with given fields: teamId, userId
+func (_m *TeamStore) RemoveOrphanedMember(teamId string, userId string) store.StoreChannel {
+	ret := _m.Called(teamId, userId)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string) store.StoreChannel); ok {
+		r0 = rf(teamId, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// RemoveWaitlistEntry provides a mock function with given fields: teamId, userId
+func (_m *TeamStore) RemoveWaitlistEntry(teamId string, userId string) store.StoreChannel {
+	ret := _m.Called(teamId, userId)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, string) store.StoreChannel); ok {
+		r0 = rf(teamId, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // ResetAllTeamSchemes provides a mock function with given fields:
 func (_m *TeamStore) ResetAllTeamSchemes() store.StoreChannel {
 	ret := _m.Called()
@@ -461,6 +685,38 @@ func (_m *TeamStore) ResetAllTeamSchemes() store.StoreChannel {
 	return r0
 }
 
+// ResetStorageUsage provides a mock function with given fields:
+func (_m *TeamStore) ResetStorageUsage() store.StoreChannel {
+	ret := _m.Called()
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func() store.StoreChannel); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// RevokeInvite provides a mock function with given fields: id, time
+func (_m *TeamStore) RevokeInvite(id string, time int64) store.StoreChannel {
+	ret := _m.Called(id, time)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, int64) store.StoreChannel); ok {
+		r0 = rf(id, time)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // Save provides a mock function with given fields: team
 func (_m *TeamStore) Save(team *model.Team) store.StoreChannel {
 	ret := _m.Called(team)
@@ -477,6 +733,38 @@ func (_m *TeamStore) Save(team *model.Team) store.StoreChannel {
 	return r0
 }
 
+// SaveChannelTemplate provides a mock function with given fields: template
+func (_m *TeamStore) SaveChannelTemplate(template *model.ChannelTemplate) store.StoreChannel {
+	ret := _m.Called(template)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(*model.ChannelTemplate) store.StoreChannel); ok {
+		r0 = rf(template)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// SaveInvite provides a mock function with given fields: invite
+func (_m *TeamStore) SaveInvite(invite *model.TeamInvite) store.StoreChannel {
+	ret := _m.Called(invite)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(*model.TeamInvite) store.StoreChannel); ok {
+		r0 = rf(invite)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // SaveMember provides a mock function with given fields: member, maxUsersPerTeam
 func (_m *TeamStore) SaveMember(member *model.TeamMember, maxUsersPerTeam int) store.StoreChannel {
 	ret := _m.Called(member, maxUsersPerTeam)
@@ -493,6 +781,22 @@ func (_m *TeamStore) SaveMember(member *model.TeamMember, maxUsersPerTeam int) s
 	return r0
 }
 
+// SaveWaitlistEntry provides a mock function with given fields: entry
+func (_m *TeamStore) SaveWaitlistEntry(entry *model.TeamWaitlistEntry) store.StoreChannel {
+	ret := _m.Called(entry)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(*model.TeamWaitlistEntry) store.StoreChannel); ok {
+		r0 = rf(entry)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // SearchAll provides a mock function with given fields: term
 func (_m *TeamStore) SearchAll(term string) store.StoreChannel {
 	ret := _m.Called(term)
@@ -557,6 +861,22 @@ func (_m *TeamStore) Update(team *model.Team) store.StoreChannel {
 	return r0
 }
 
+// UpdateChannelTemplate provides a mock function with given fields: template
+func (_m *TeamStore) UpdateChannelTemplate(template *model.ChannelTemplate) store.StoreChannel {
+	ret := _m.Called(template)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(*model.ChannelTemplate) store.StoreChannel); ok {
+		r0 = rf(template)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // UpdateDisplayName provides a mock function with given fields: name, teamId
 func (_m *TeamStore) UpdateDisplayName(name string, teamId string) store.StoreChannel {
 	ret := _m.Called(name, teamId)
@@ -604,3 +924,35 @@ func (_m *TeamStore) UpdateMember(member *model.TeamMember) store.StoreChannel {
 
 	return r0
 }
+
+// UpdateStorageUsage provides a mock function with given fields: teamId, delta
+func (_m *TeamStore) UpdateStorageUsage(teamId string, delta int64) store.StoreChannel {
+	ret := _m.Called(teamId, delta)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, int64) store.StoreChannel); ok {
+		r0 = rf(teamId, delta)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
+// UseInvite provides a mock function with given fields: id, now
+func (_m *TeamStore) UseInvite(id string, now int64) store.StoreChannel {
+	ret := _m.Called(id, now)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, int64) store.StoreChannel); ok {
+		r0 = rf(id, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
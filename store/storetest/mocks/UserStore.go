@@ -322,6 +322,22 @@ func (_m *UserStore) GetForLogin(loginId string, allowSignInWithUsername bool, a
 	return r0
 }
 
+// GetLastActivityAfter provides a mock function with given fields: afterId, inactiveSince, limit
+func (_m *UserStore) GetLastActivityAfter(afterId string, inactiveSince int64, limit int) store.StoreChannel {
+	ret := _m.Called(afterId, inactiveSince, limit)
+
+	var r0 store.StoreChannel
+	if rf, ok := ret.Get(0).(func(string, int64, int) store.StoreChannel); ok {
+		r0 = rf(afterId, inactiveSince, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(store.StoreChannel)
+		}
+	}
+
+	return r0
+}
+
 // GetNewUsersForTeam provides a mock function with given fields: teamId, offset, limit
 func (_m *UserStore) GetNewUsersForTeam(teamId string, offset int, limit int) store.StoreChannel {
 	ret := _m.Called(teamId, offset, limit)
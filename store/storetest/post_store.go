@@ -5,6 +5,8 @@ package storetest
 
 import (
 	"fmt"
+	"net/http"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -33,20 +35,32 @@ func TestPostStore(t *testing.T, ss store.Store) {
 	t.Run("GetPostsBeforeAfter", func(t *testing.T) { testPostStoreGetPostsBeforeAfter(t, ss) })
 	t.Run("GetPostsSince", func(t *testing.T) { testPostStoreGetPostsSince(t, ss) })
 	t.Run("Search", func(t *testing.T) { testPostStoreSearch(t, ss) })
+	t.Run("SearchInTeams", func(t *testing.T) { testPostStoreSearchInTeams(t, ss) })
+	t.Run("SearchInTeamsPage", func(t *testing.T) { testPostStoreSearchInTeamsPage(t, ss) })
 	t.Run("UserCountsWithPostsByDay", func(t *testing.T) { testUserCountsWithPostsByDay(t, ss) })
 	t.Run("PostCountsByDay", func(t *testing.T) { testPostCountsByDay(t, ss) })
+	t.Run("PostCountsByInterval", func(t *testing.T) { testPostCountsByInterval(t, ss) })
+	t.Run("TeamActivityByDay", func(t *testing.T) { testPostStoreTeamActivityByDay(t, ss) })
+	t.Run("GetCatchUpForUser", func(t *testing.T) { testPostStoreGetCatchUpForUser(t, ss) })
 	t.Run("GetFlaggedPostsForTeam", func(t *testing.T) { testPostStoreGetFlaggedPostsForTeam(t, ss) })
 	t.Run("GetFlaggedPosts", func(t *testing.T) { testPostStoreGetFlaggedPosts(t, ss) })
 	t.Run("GetFlaggedPostsForChannel", func(t *testing.T) { testPostStoreGetFlaggedPostsForChannel(t, ss) })
+	t.Run("GetDeletedPostsForChannel", func(t *testing.T) { testPostStoreGetDeletedPostsForChannel(t, ss) })
+	t.Run("GetExpiredPinnedPosts", func(t *testing.T) { testPostStoreGetExpiredPinnedPosts(t, ss) })
+	t.Run("GetPostsWithTermsInChannel", func(t *testing.T) { testPostStoreGetPostsWithTermsInChannel(t, ss) })
 	t.Run("GetPostsCreatedAt", func(t *testing.T) { testPostStoreGetPostsCreatedAt(t, ss) })
 	t.Run("Overwrite", func(t *testing.T) { testPostStoreOverwrite(t, ss) })
+	t.Run("OverwriteIfUnchanged", func(t *testing.T) { testPostStoreOverwriteIfUnchanged(t, ss) })
+	t.Run("MoveToChannel", func(t *testing.T) { testPostStoreMoveToChannel(t, ss) })
 	t.Run("GetPostsByIds", func(t *testing.T) { testPostStoreGetPostsByIds(t, ss) })
 	t.Run("GetPostsBatchForIndexing", func(t *testing.T) { testPostStoreGetPostsBatchForIndexing(t, ss) })
+	t.Run("GetPostsBatchForChannelIndexing", func(t *testing.T) { testPostStoreGetPostsBatchForChannelIndexing(t, ss) })
 	t.Run("PermanentDeleteBatch", func(t *testing.T) { testPostStorePermanentDeleteBatch(t, ss) })
 	t.Run("GetOldest", func(t *testing.T) { testPostStoreGetOldest(t, ss) })
 	t.Run("TestGetMaxPostSize", func(t *testing.T) { testGetMaxPostSize(t, ss) })
 	t.Run("GetParentsForExportAfter", func(t *testing.T) { testPostStoreGetParentsForExportAfter(t, ss) })
 	t.Run("GetRepliesForExport", func(t *testing.T) { testPostStoreGetRepliesForExport(t, ss) })
+	t.Run("HereMentions", func(t *testing.T) { testPostStoreHereMentions(t, ss) })
 }
 
 func testPostStoreSave(t *testing.T, ss store.Store) {
@@ -864,6 +878,60 @@ func testPostStoreGetPostsSince(t *testing.T, ss store.Store) {
 	}
 }
 
+func testPostStoreSearchInTeams(t *testing.T, ss store.Store) {
+	userId := model.NewId()
+
+	var channels []*model.Channel
+	for _, teamId := range []string{model.NewId(), model.NewId(), model.NewId()} {
+		channel := store.Must(ss.Channel().Save(&model.Channel{TeamId: teamId, DisplayName: "Channel", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}, -1)).(*model.Channel)
+		store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: channel.Id, UserId: userId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+		channels = append(channels, channel)
+	}
+
+	var posts []*model.Post
+	for _, channel := range channels {
+		posts = append(posts, store.Must(ss.Post().Save(&model.Post{ChannelId: channel.Id, UserId: model.NewId(), Message: "crossteam search"})).(*model.Post))
+	}
+
+	result := <-ss.Post().SearchInTeams([]string{channels[0].TeamId, channels[1].TeamId}, userId, &model.SearchParams{Terms: "crossteam"})
+	require.Nil(t, result.Err)
+	list := result.Data.(*model.PostList)
+	assert.Len(t, list.Order, 2)
+	assert.Contains(t, list.Posts, posts[0].Id)
+	assert.Contains(t, list.Posts, posts[1].Id)
+	assert.NotContains(t, list.Posts, posts[2].Id)
+
+	result = <-ss.Post().SearchInTeams([]string{}, userId, &model.SearchParams{Terms: "crossteam"})
+	require.Nil(t, result.Err)
+	assert.Empty(t, result.Data.(*model.PostList).Order)
+}
+
+func testPostStoreSearchInTeamsPage(t *testing.T, ss store.Store) {
+	userId := model.NewId()
+
+	channel := store.Must(ss.Channel().Save(&model.Channel{TeamId: model.NewId(), DisplayName: "Channel", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}, -1)).(*model.Channel)
+	store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: channel.Id, UserId: userId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	var posts []*model.Post
+	for i := 0; i < 5; i++ {
+		posts = append(posts, store.Must(ss.Post().Save(&model.Post{ChannelId: channel.Id, UserId: userId, Message: "paged search", CreateAt: int64(1000 + i)})).(*model.Post))
+	}
+
+	params := &model.SearchParams{Terms: "paged"}
+
+	result := <-ss.Post().SearchInTeamsPage([]string{channel.TeamId}, userId, params, 0, 2)
+	require.Nil(t, result.Err)
+	assert.Equal(t, []string{posts[4].Id, posts[3].Id}, result.Data.(*model.PostList).Order)
+
+	result = <-ss.Post().SearchInTeamsPage([]string{channel.TeamId}, userId, params, 4, 2)
+	require.Nil(t, result.Err)
+	assert.Equal(t, []string{posts[0].Id}, result.Data.(*model.PostList).Order)
+
+	result = <-ss.Post().SearchInTeamsPage([]string{channel.TeamId}, userId, params, 6, 2)
+	require.Nil(t, result.Err)
+	assert.Empty(t, result.Data.(*model.PostList).Order)
+}
+
 func testPostStoreSearch(t *testing.T, ss store.Store) {
 	teamId := model.NewId()
 	userId := model.NewId()
@@ -1192,6 +1260,198 @@ func testPostCountsByDay(t *testing.T, ss store.Store) {
 	}
 }
 
+func testPostCountsByInterval(t *testing.T, ss store.Store) {
+	channelId := model.NewId()
+	interval := int64(60 * 1000)
+	since := (model.GetMillis() / interval) * interval
+
+	for _, createAt := range []int64{since, since + 1000, since + interval, since + 3*interval} {
+		o := &model.Post{}
+		o.ChannelId = channelId
+		o.UserId = model.NewId()
+		o.CreateAt = createAt
+		o.Message = "zz" + model.NewId() + "b"
+		store.Must(ss.Post().Save(o))
+	}
+
+	deleted := &model.Post{}
+	deleted.ChannelId = channelId
+	deleted.UserId = model.NewId()
+	deleted.CreateAt = since
+	deleted.Message = "zz" + model.NewId() + "b"
+	deleted = store.Must(ss.Post().Save(deleted)).(*model.Post)
+	store.Must(ss.Post().Delete(deleted.Id, model.GetMillis(), ""))
+
+	system := &model.Post{}
+	system.ChannelId = channelId
+	system.UserId = model.NewId()
+	system.CreateAt = since
+	system.Type = model.POST_JOIN_CHANNEL
+	system.Message = "zz" + model.NewId() + "b"
+	store.Must(ss.Post().Save(system))
+
+	r := <-ss.Post().AnalyticsPostCountsByInterval(channelId, since, since+3*interval, interval)
+	require.Nil(t, r.Err)
+	buckets := r.Data.([]*model.ChannelActivityBucket)
+	require.Len(t, buckets, 2)
+	assert.Equal(t, since, buckets[0].StartAt)
+	assert.Equal(t, int64(2), buckets[0].Count)
+	assert.Equal(t, since+interval, buckets[1].StartAt)
+	assert.Equal(t, int64(1), buckets[1].Count)
+}
+
+func testPostStoreTeamActivityByDay(t *testing.T, ss store.Store) {
+	c1 := &model.Channel{}
+	c1.TeamId = model.NewId()
+	c1.DisplayName = "Channel1"
+	c1.Name = "zz" + model.NewId() + "b"
+	c1.Type = model.CHANNEL_OPEN
+	c1 = store.Must(ss.Channel().Save(c1, -1)).(*model.Channel)
+
+	day := int64(24 * 60 * 60 * 1000)
+	since := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano() / int64(time.Millisecond)
+	user1 := model.NewId()
+	user2 := model.NewId()
+
+	for _, p := range []struct {
+		userId   string
+		createAt int64
+	}{
+		{user1, since + 1000},
+		{user1, since + 2000},
+		{user2, since + 3000},
+		{user2, since + day + 1000},
+	} {
+		o := &model.Post{}
+		o.ChannelId = c1.Id
+		o.UserId = p.userId
+		o.CreateAt = p.createAt
+		o.Message = "zz" + model.NewId() + "b"
+		store.Must(ss.Post().Save(o))
+	}
+
+	other := &model.Post{}
+	other.ChannelId = model.NewId()
+	other.UserId = user1
+	other.CreateAt = since + 1000
+	other.Message = "zz" + model.NewId() + "b"
+	store.Must(ss.Post().Save(other))
+
+	r := <-ss.Post().AnalyticsTeamActivityByDay(c1.TeamId, since, since+2*day)
+	require.Nil(t, r.Err)
+	days := r.Data.([]*model.TeamActivityDay)
+	require.Len(t, days, 2)
+	assert.Equal(t, "2019-01-01", days[0].Date)
+	assert.Equal(t, int64(3), days[0].PostCount)
+	assert.Equal(t, int64(2), days[0].ActiveUsers)
+	assert.Equal(t, "2019-01-02", days[1].Date)
+	assert.Equal(t, int64(1), days[1].PostCount)
+	assert.Equal(t, int64(1), days[1].ActiveUsers)
+
+	r = <-ss.Post().AnalyticsPostersCount(c1.TeamId, since, since+2*day)
+	require.Nil(t, r.Err)
+	assert.Equal(t, int64(2), r.Data.(int64))
+
+	r = <-ss.Post().AnalyticsPostersCount(c1.TeamId, since+day, since+2*day)
+	require.Nil(t, r.Err)
+	assert.Equal(t, int64(1), r.Data.(int64))
+}
+
+func testPostStoreGetCatchUpForUser(t *testing.T, ss store.Store) {
+	userId := model.NewId()
+	otherUserId := model.NewId()
+	username := "catchup" + model.NewId()
+
+	c1 := &model.Channel{}
+	c1.TeamId = model.NewId()
+	c1.DisplayName = "Channel1"
+	c1.Name = "zz" + model.NewId() + "b"
+	c1.Type = model.CHANNEL_OPEN
+	c1 = store.Must(ss.Channel().Save(c1, -1)).(*model.Channel)
+
+	c2 := &model.Channel{}
+	c2.TeamId = model.NewId()
+	c2.DisplayName = "Channel2"
+	c2.Name = "zz" + model.NewId() + "b"
+	c2.Type = model.CHANNEL_OPEN
+	c2 = store.Must(ss.Channel().Save(c2, -1)).(*model.Channel)
+
+	notMember := &model.Channel{}
+	notMember.TeamId = c1.TeamId
+	notMember.DisplayName = "NotMember"
+	notMember.Name = "zz" + model.NewId() + "b"
+	notMember.Type = model.CHANNEL_OPEN
+	notMember = store.Must(ss.Channel().Save(notMember, -1)).(*model.Channel)
+
+	since := model.GetMillis() - 10000
+
+	store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: userId, MentionCount: 1, LastViewedAt: since + 2500, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+	store.Must(ss.Channel().SaveMember(&model.ChannelMember{ChannelId: c2.Id, UserId: userId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	save := func(channelId, userId, rootId, message string, createAt int64) *model.Post {
+		o := &model.Post{}
+		o.ChannelId = channelId
+		o.UserId = userId
+		o.RootId = rootId
+		o.ParentId = rootId
+		o.Message = message
+		o.CreateAt = createAt
+		return store.Must(ss.Post().Save(o)).(*model.Post)
+	}
+
+	root := save(c1.Id, userId, "", "my thread", since-1000)
+	save(c1.Id, otherUserId, "", "before since", since-500)
+	save(c1.Id, otherUserId, root.Id, "reply to my thread", since+1000)
+	save(c1.Id, otherUserId, "", "hello @"+username, since+2000)
+	otherRoot := save(c1.Id, otherUserId, "", "someone else's thread", since+3000)
+	save(c1.Id, otherUserId, otherRoot.Id, "reply to someone else's thread", since+4000)
+	save(c1.Id, userId, "", "my own post", since+5000)
+	save(c2.Id, otherUserId, "", "other channel", since+6000)
+	save(notMember.Id, otherUserId, "", "not a member", since+7000)
+
+	r := <-ss.Post().GetCatchUpForUser(userId, since, false, false, 0, 10)
+	require.Nil(t, r.Err)
+	channels := r.Data.([]*model.CatchUpChannel)
+	require.Len(t, channels, 2)
+
+	assert.Equal(t, c2.Id, channels[0].ChannelId)
+	assert.Equal(t, c2.TeamId, channels[0].TeamId)
+	assert.Equal(t, int64(1), channels[0].NewPosts)
+	assert.Equal(t, since+6000, channels[0].LastPostAt)
+
+	assert.Equal(t, c1.Id, channels[1].ChannelId)
+	assert.Equal(t, int64(4), channels[1].NewPosts)
+	assert.Equal(t, int64(1), channels[1].Mentions)
+	assert.Equal(t, int64(1), channels[1].ThreadReplies)
+	assert.Equal(t, since+4000, channels[1].LastPostAt)
+	assert.Equal(t, model.CHANNEL_MARK_UNREAD_ALL, channels[1].NotifyProps[model.MARK_UNREAD_NOTIFY_PROP])
+
+	r = <-ss.Post().GetCatchUpForUser(userId, since, false, true, 0, 10)
+	require.Nil(t, r.Err)
+	channels = r.Data.([]*model.CatchUpChannel)
+	require.Len(t, channels, 2)
+	assert.Equal(t, int64(2), channels[1].NewPosts)
+	assert.Equal(t, int64(1), channels[1].ThreadReplies)
+
+	r = <-ss.Post().GetCatchUpForUser(userId, since, false, false, 1, 10)
+	require.Nil(t, r.Err)
+	channels = r.Data.([]*model.CatchUpChannel)
+	require.Len(t, channels, 1)
+	assert.Equal(t, c1.Id, channels[0].ChannelId)
+
+	// Only the posts made since the channels were last viewed
+	r = <-ss.Post().GetCatchUpForUser(userId, since-10000, true, false, 0, 10)
+	require.Nil(t, r.Err)
+	channels = r.Data.([]*model.CatchUpChannel)
+	require.Len(t, channels, 2)
+	assert.Equal(t, c2.Id, channels[0].ChannelId)
+	assert.Equal(t, int64(1), channels[0].NewPosts)
+	assert.Equal(t, c1.Id, channels[1].ChannelId)
+	assert.Equal(t, int64(2), channels[1].NewPosts)
+	assert.Equal(t, int64(1), channels[1].Mentions)
+	assert.Equal(t, int64(0), channels[1].ThreadReplies)
+}
+
 func testPostStoreGetFlaggedPostsForTeam(t *testing.T, ss store.Store) {
 	c1 := &model.Channel{}
 	c1.TeamId = model.NewId()
@@ -1558,6 +1818,101 @@ func testPostStoreGetFlaggedPostsForChannel(t *testing.T, ss store.Store) {
 	}
 }
 
+func testPostStoreGetDeletedPostsForChannel(t *testing.T, ss store.Store) {
+	channelId := model.NewId()
+	deleteByID := model.NewId()
+
+	o1 := &model.Post{}
+	o1.ChannelId = channelId
+	o1.UserId = model.NewId()
+	o1.Message = "zz" + model.NewId() + "b"
+	o1 = (<-ss.Post().Save(o1)).Data.(*model.Post)
+
+	o2 := &model.Post{}
+	o2.ChannelId = channelId
+	o2.UserId = model.NewId()
+	o2.Message = "zz" + model.NewId() + "b"
+	o2 = (<-ss.Post().Save(o2)).Data.(*model.Post)
+
+	o3 := &model.Post{}
+	o3.ChannelId = channelId
+	o3.UserId = model.NewId()
+	o3.Message = "zz" + model.NewId() + "b"
+	o3 = (<-ss.Post().Save(o3)).Data.(*model.Post)
+
+	r := <-ss.Post().GetDeletedPostsForChannel(channelId, 0, 10)
+	require.Nil(t, r.Err)
+	assert.Len(t, r.Data.(*model.PostList).Order, 0)
+
+	require.Nil(t, (<-ss.Post().Delete(o1.Id, model.GetMillis(), deleteByID)).Err)
+	time.Sleep(2 * time.Millisecond)
+	require.Nil(t, (<-ss.Post().Delete(o3.Id, model.GetMillis(), deleteByID)).Err)
+
+	r = <-ss.Post().GetDeletedPostsForChannel(channelId, 0, 10)
+	require.Nil(t, r.Err)
+	pl := r.Data.(*model.PostList)
+	require.Len(t, pl.Order, 2)
+	assert.Equal(t, o3.Id, pl.Order[0], "most recently deleted post should be first")
+	assert.Equal(t, o1.Id, pl.Order[1])
+	assert.Equal(t, deleteByID, pl.Posts[o1.Id].Props[model.POST_PROPS_DELETE_BY])
+	assert.NotZero(t, pl.Posts[o1.Id].DeleteAt)
+	assert.NotContains(t, pl.Posts, o2.Id)
+
+	r = <-ss.Post().GetDeletedPostsForChannel(channelId, 1, 1)
+	require.Nil(t, r.Err)
+	pl = r.Data.(*model.PostList)
+	require.Len(t, pl.Order, 1)
+	assert.Equal(t, o1.Id, pl.Order[0])
+}
+
+func testPostStoreGetExpiredPinnedPosts(t *testing.T, ss store.Store) {
+	now := model.GetMillis()
+
+	expired := &model.Post{}
+	expired.ChannelId = model.NewId()
+	expired.UserId = model.NewId()
+	expired.Message = "zz" + model.NewId() + "b"
+	expired.IsPinned = true
+	expired.PinnedUntil = now - 1000
+	expired = (<-ss.Post().Save(expired)).Data.(*model.Post)
+
+	pending := &model.Post{}
+	pending.ChannelId = expired.ChannelId
+	pending.UserId = model.NewId()
+	pending.Message = "zz" + model.NewId() + "b"
+	pending.IsPinned = true
+	pending.PinnedUntil = now + 100000
+	pending = (<-ss.Post().Save(pending)).Data.(*model.Post)
+
+	forever := &model.Post{}
+	forever.ChannelId = expired.ChannelId
+	forever.UserId = model.NewId()
+	forever.Message = "zz" + model.NewId() + "b"
+	forever.IsPinned = true
+	forever = (<-ss.Post().Save(forever)).Data.(*model.Post)
+
+	deleted := &model.Post{}
+	deleted.ChannelId = expired.ChannelId
+	deleted.UserId = model.NewId()
+	deleted.Message = "zz" + model.NewId() + "b"
+	deleted.IsPinned = true
+	deleted.PinnedUntil = now - 1000
+	deleted = (<-ss.Post().Save(deleted)).Data.(*model.Post)
+	require.Nil(t, (<-ss.Post().Delete(deleted.Id, model.GetMillis(), "")).Err)
+
+	r := <-ss.Post().GetExpiredPinnedPosts(now, 1000)
+	require.Nil(t, r.Err)
+
+	ids := map[string]bool{}
+	for _, post := range r.Data.([]*model.Post) {
+		ids[post.Id] = true
+	}
+	assert.True(t, ids[expired.Id])
+	assert.False(t, ids[pending.Id])
+	assert.False(t, ids[forever.Id])
+	assert.False(t, ids[deleted.Id])
+}
+
 func testPostStoreGetPostsCreatedAt(t *testing.T, ss store.Store) {
 	createTime := model.GetMillis() + 1
 
@@ -1595,6 +1950,86 @@ func testPostStoreGetPostsCreatedAt(t *testing.T, ss store.Store) {
 	assert.Equal(t, 2, len(r1))
 }
 
+func testPostStoreOverwriteIfUnchanged(t *testing.T, ss store.Store) {
+	o1 := &model.Post{}
+	o1.ChannelId = model.NewId()
+	o1.UserId = model.NewId()
+	o1.Message = "zz" + model.NewId() + "b"
+	o1 = store.Must(ss.Post().Save(o1)).(*model.Post)
+
+	stale := o1.Clone()
+
+	o1.Message = "zz" + model.NewId() + "c"
+	o1.AddProp("key", "value")
+	result := <-ss.Post().OverwriteIfUnchanged(o1, o1.UpdateAt)
+	require.Nil(t, result.Err)
+	require.NotNil(t, result.Data)
+
+	saved := store.Must(ss.Post().GetSingle(o1.Id)).(*model.Post)
+	assert.Equal(t, o1.Message, saved.Message)
+	assert.Equal(t, "value", saved.Props["key"])
+	assert.True(t, saved.UpdateAt > stale.UpdateAt)
+
+	// A change made from an older version of the post is refused
+	stale.Message = "zz" + model.NewId() + "d"
+	result = <-ss.Post().OverwriteIfUnchanged(stale, stale.UpdateAt)
+	require.Nil(t, result.Err)
+	assert.Nil(t, result.Data)
+
+	saved = store.Must(ss.Post().GetSingle(o1.Id)).(*model.Post)
+	assert.Equal(t, o1.Message, saved.Message)
+}
+
+func testPostStoreMoveToChannel(t *testing.T, ss store.Store) {
+	teamId := model.NewId()
+	from := store.Must(ss.Channel().Save(&model.Channel{TeamId: teamId, DisplayName: "From", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}, -1)).(*model.Channel)
+	to := store.Must(ss.Channel().Save(&model.Channel{TeamId: teamId, DisplayName: "To", Name: "zz" + model.NewId() + "b", Type: model.CHANNEL_OPEN}, -1)).(*model.Channel)
+
+	root := store.Must(ss.Post().Save(&model.Post{ChannelId: from.Id, UserId: model.NewId(), Message: "zz" + model.NewId() + "b"})).(*model.Post)
+	reply := store.Must(ss.Post().Save(&model.Post{ChannelId: from.Id, UserId: model.NewId(), Message: "zz" + model.NewId() + "b", RootId: root.Id, ParentId: root.Id})).(*model.Post)
+	other := store.Must(ss.Post().Save(&model.Post{ChannelId: from.Id, UserId: model.NewId(), Message: "zz" + model.NewId() + "b"})).(*model.Post)
+
+	t.Run("nothing is moved if a post has changed", func(t *testing.T) {
+		deleted := store.Must(ss.Post().Save(&model.Post{ChannelId: from.Id, UserId: model.NewId(), Message: "zz" + model.NewId() + "b"})).(*model.Post)
+		store.Must(ss.Post().Delete(deleted.Id, model.GetMillis(), ""))
+
+		result := <-ss.Post().MoveToChannel([]*model.Post{other.Clone(), deleted}, from.Id, to.Id)
+		require.NotNil(t, result.Err)
+		assert.Equal(t, "store.sql_post.move_to_channel.changed.app_error", result.Err.Id)
+
+		saved := store.Must(ss.Post().GetSingle(other.Id)).(*model.Post)
+		assert.Equal(t, from.Id, saved.ChannelId)
+	})
+
+	fromBefore := store.Must(ss.Channel().Get(from.Id, false)).(*model.Channel)
+
+	// The reply leaves its thread behind
+	movedReply := reply.Clone()
+	movedReply.RootId = ""
+	movedReply.ParentId = ""
+
+	result := <-ss.Post().MoveToChannel([]*model.Post{movedReply, other.Clone()}, from.Id, to.Id)
+	require.Nil(t, result.Err)
+
+	saved := store.Must(ss.Post().GetSingle(reply.Id)).(*model.Post)
+	assert.Equal(t, to.Id, saved.ChannelId)
+	assert.Equal(t, "", saved.RootId)
+	assert.Equal(t, "", saved.ParentId)
+
+	saved = store.Must(ss.Post().GetSingle(root.Id)).(*model.Post)
+	assert.Equal(t, from.Id, saved.ChannelId)
+
+	ss.Channel().InvalidateChannel(from.Id)
+	ss.Channel().InvalidateChannel(to.Id)
+
+	fromAfter := store.Must(ss.Channel().Get(from.Id, false)).(*model.Channel)
+	assert.Equal(t, fromBefore.TotalMsgCount-2, fromAfter.TotalMsgCount)
+
+	toAfter := store.Must(ss.Channel().Get(to.Id, false)).(*model.Channel)
+	assert.Equal(t, int64(2), toAfter.TotalMsgCount)
+	assert.Equal(t, other.CreateAt, toAfter.LastPostAt)
+}
+
 func testPostStoreOverwrite(t *testing.T, ss store.Store) {
 	o1 := &model.Post{}
 	o1.ChannelId = model.NewId()
@@ -1794,6 +2229,41 @@ func testPostStoreGetPostsBatchForIndexing(t *testing.T, ss store.Store) {
 	}
 }
 
+func testPostStoreGetPostsBatchForChannelIndexing(t *testing.T, ss store.Store) {
+	channelId := model.NewId()
+
+	var posts []*model.Post
+	for i := 0; i < 3; i++ {
+		posts = append(posts, store.Must(ss.Post().Save(&model.Post{ChannelId: channelId, UserId: model.NewId(), Message: "zz" + model.NewId() + "b", CreateAt: 1000})).(*model.Post))
+	}
+	posts = append(posts, store.Must(ss.Post().Save(&model.Post{ChannelId: channelId, UserId: model.NewId(), Message: "zz" + model.NewId() + "b", CreateAt: 2000})).(*model.Post))
+	store.Must(ss.Post().Delete(posts[3].Id, model.GetMillis(), ""))
+	store.Must(ss.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "zz" + model.NewId() + "b", CreateAt: 1500}))
+
+	// Posts created at the same time are ordered by id.
+	sameTime := posts[:3]
+	sort.Slice(sameTime, func(i, j int) bool { return sameTime[i].Id < sameTime[j].Id })
+
+	var ids []string
+	afterCreateAt, afterId := int64(0), ""
+	for {
+		result := <-ss.Post().GetPostsBatchForChannelIndexing(channelId, afterCreateAt, afterId, 2)
+		require.Nil(t, result.Err)
+		batch := result.Data.([]*model.Post)
+		if len(batch) == 0 {
+			break
+		}
+		require.True(t, len(batch) <= 2)
+
+		for _, post := range batch {
+			ids = append(ids, post.Id)
+		}
+		afterCreateAt, afterId = batch[len(batch)-1].CreateAt, batch[len(batch)-1].Id
+	}
+
+	assert.Equal(t, []string{posts[0].Id, posts[1].Id, posts[2].Id, posts[3].Id}, ids)
+}
+
 func testPostStorePermanentDeleteBatch(t *testing.T, ss store.Store) {
 	o1 := &model.Post{}
 	o1.ChannelId = model.NewId()
@@ -1956,3 +2426,119 @@ func testPostStoreGetRepliesForExport(t *testing.T, ss store.Store) {
 	assert.Equal(t, reply1.Message, p2.Message)
 	assert.Equal(t, reply1.Username, u1.Username)
 }
+
+func testPostStoreGetPostsWithTermsInChannel(t *testing.T, ss store.Store) {
+	channelId := model.NewId()
+	since := model.GetMillis() - 10000
+
+	save := func(message string, createAt int64, postType string) *model.Post {
+		return store.Must(ss.Post().Save(&model.Post{
+			ChannelId: channelId,
+			UserId:    model.NewId(),
+			Message:   message,
+			CreateAt:  createAt,
+			Type:      postType,
+		})).(*model.Post)
+	}
+
+	post1 := save("hello @Alice_1", since+1000, "")
+	post2 := save("@bob and @alice_1", since+2000, "")
+	save("hello @alicex1", since+3000, "")
+	save("too early @bob", since-1000, "")
+	save("too late @bob", since+20000, "")
+	save("@alice_1 joined", since+1000, model.POST_JOIN_CHANNEL)
+	deleted := save("deleted @bob", since+1000, "")
+	store.Must(ss.Post().Delete(deleted.Id, model.GetMillis(), ""))
+	store.Must(ss.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "@bob", CreateAt: since + 1000}))
+
+	r := <-ss.Post().GetPostsWithTermsInChannel(channelId, []string{"@alice_1", "@bob"}, since, since+10000, 0, 10)
+	require.Nil(t, r.Err)
+	posts := r.Data.([]*model.Post)
+	require.Len(t, posts, 2)
+	assert.Equal(t, post1.Id, posts[0].Id)
+	assert.Equal(t, post1.Message, posts[0].Message)
+	assert.Equal(t, post2.Id, posts[1].Id)
+
+	r = <-ss.Post().GetPostsWithTermsInChannel(channelId, []string{"@alice_1", "@bob"}, since, since+10000, 1, 1)
+	require.Nil(t, r.Err)
+	posts = r.Data.([]*model.Post)
+	require.Len(t, posts, 1)
+	assert.Equal(t, post2.Id, posts[0].Id)
+
+	r = <-ss.Post().GetPostsWithTermsInChannel(channelId, []string{}, since, since+10000, 0, 10)
+	require.Nil(t, r.Err)
+	assert.Empty(t, r.Data.([]*model.Post))
+}
+
+func testPostStoreHereMentions(t *testing.T, ss store.Store) {
+	postId := model.NewId()
+
+	result := <-ss.Post().GetHereMentions(postId)
+	require.NotNil(t, result.Err)
+	assert.Equal(t, http.StatusNotFound, result.Err.StatusCode)
+
+	mentions := &model.PostHereMentions{PostId: postId, UserIds: model.StringArray{model.NewId(), model.NewId()}}
+	result = <-ss.Post().SaveHereMentions(mentions)
+	require.Nil(t, result.Err)
+
+	result = <-ss.Post().GetHereMentions(postId)
+	require.Nil(t, result.Err)
+	saved := result.Data.(*model.PostHereMentions)
+	assert.Equal(t, mentions.UserIds, saved.UserIds)
+	assert.NotZero(t, saved.CreateAt)
+
+	// Nobody being online is still recorded
+	emptyPostId := model.NewId()
+	result = <-ss.Post().SaveHereMentions(&model.PostHereMentions{PostId: emptyPostId})
+	require.Nil(t, result.Err)
+
+	result = <-ss.Post().GetHereMentions(emptyPostId)
+	require.Nil(t, result.Err)
+	assert.Empty(t, result.Data.(*model.PostHereMentions).UserIds)
+
+	result = <-ss.Post().SaveHereMentions(mentions)
+	assert.NotNil(t, result.Err, "the mentions of a post are only recorded once")
+
+	t.Run("deleted with their post", func(t *testing.T) {
+		post := store.Must(ss.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "@here"})).(*model.Post)
+		store.Must(ss.Post().SaveHereMentions(&model.PostHereMentions{PostId: post.Id}))
+
+		store.Must(ss.Post().Delete(post.Id, model.GetMillis(), post.UserId))
+
+		result := <-ss.Post().GetHereMentions(post.Id)
+		require.NotNil(t, result.Err)
+		assert.Equal(t, http.StatusNotFound, result.Err.StatusCode)
+	})
+
+	t.Run("deleted with their channel", func(t *testing.T) {
+		post := store.Must(ss.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "@here"})).(*model.Post)
+		store.Must(ss.Post().SaveHereMentions(&model.PostHereMentions{PostId: post.Id}))
+
+		store.Must(ss.Post().PermanentDeleteByChannel(post.ChannelId))
+
+		result := <-ss.Post().GetHereMentions(post.Id)
+		require.NotNil(t, result.Err)
+		assert.Equal(t, http.StatusNotFound, result.Err.StatusCode)
+	})
+
+	t.Run("deleted with their author and forgetting deleted users", func(t *testing.T) {
+		deletedUserId := model.NewId()
+		otherUserId := model.NewId()
+
+		post := store.Must(ss.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: deletedUserId, Message: "@here"})).(*model.Post)
+		store.Must(ss.Post().SaveHereMentions(&model.PostHereMentions{PostId: post.Id}))
+
+		otherPost := store.Must(ss.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: otherUserId, Message: "@here"})).(*model.Post)
+		store.Must(ss.Post().SaveHereMentions(&model.PostHereMentions{PostId: otherPost.Id, UserIds: model.StringArray{deletedUserId, otherUserId}}))
+
+		store.Must(ss.Post().PermanentDeleteByUser(deletedUserId))
+
+		result := <-ss.Post().GetHereMentions(post.Id)
+		require.NotNil(t, result.Err)
+		assert.Equal(t, http.StatusNotFound, result.Err.StatusCode)
+
+		result = <-ss.Post().GetHereMentions(otherPost.Id)
+		require.Nil(t, result.Err)
+		assert.Equal(t, model.StringArray{otherUserId}, result.Data.(*model.PostHereMentions).UserIds)
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/mattermost/mattermost-server/model"
 	"github.com/mattermost/mattermost-server/store"
@@ -14,6 +15,7 @@ import (
 
 func TestPreferenceStore(t *testing.T, ss store.Store) {
 	t.Run("PreferenceSave", func(t *testing.T) { testPreferenceSave(t, ss) })
+	t.Run("PreferenceSaveNewer", func(t *testing.T) { testPreferenceSaveNewer(t, ss) })
 	t.Run("PreferenceGet", func(t *testing.T) { testPreferenceGet(t, ss) })
 	t.Run("PreferenceGetCategory", func(t *testing.T) { testPreferenceGetCategory(t, ss) })
 	t.Run("PreferenceGetAll", func(t *testing.T) { testPreferenceGetAll(t, ss) })
@@ -65,6 +67,37 @@ func testPreferenceSave(t *testing.T, ss store.Store) {
 	}
 }
 
+func testPreferenceSaveNewer(t *testing.T, ss store.Store) {
+	userId := model.NewId()
+
+	stored := model.Preferences{
+		{UserId: userId, Category: model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS, Name: "a", Value: "stored", UpdateAt: 1000},
+		{UserId: userId, Category: model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS, Name: "b", Value: "stored", UpdateAt: 1000},
+	}
+	store.Must(ss.Preference().Save(&stored))
+
+	synced := model.Preferences{
+		{UserId: userId, Category: model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS, Name: "a", Value: "newer", UpdateAt: 2000},
+		{UserId: userId, Category: model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS, Name: "b", Value: "older", UpdateAt: 1000},
+		{UserId: userId, Category: model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS, Name: "c", Value: "new", UpdateAt: 500},
+	}
+	result := <-ss.Preference().SaveNewer(&synced)
+	require.Nil(t, result.Err)
+	assert.Equal(t, model.Preferences{synced[0], synced[2]}, result.Data.(model.Preferences))
+
+	result = <-ss.Preference().GetCategory(userId, model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS)
+	require.Nil(t, result.Err)
+	values := map[string]string{}
+	for _, preference := range result.Data.(model.Preferences) {
+		values[preference.Name] = preference.Value
+	}
+	assert.Equal(t, map[string]string{"a": "newer", "b": "stored", "c": "new"}, values)
+
+	invalid := model.Preferences{{UserId: userId, Category: "", Name: "d", UpdateAt: 3000}}
+	result = <-ss.Preference().SaveNewer(&invalid)
+	assert.NotNil(t, result.Err)
+}
+
 func testPreferenceGet(t *testing.T, ss store.Store) {
 	userId := model.NewId()
 	category := model.PREFERENCE_CATEGORY_DIRECT_CHANNEL_SHOW
@@ -6,6 +6,9 @@ package storetest
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/mattermost/mattermost-server/model"
 	"github.com/mattermost/mattermost-server/store"
 )
@@ -17,6 +20,7 @@ func TestReactionStore(t *testing.T, ss store.Store) {
 	t.Run("ReactionDeleteAllWithEmojiName", func(t *testing.T) { testReactionDeleteAllWithEmojiName(t, ss) })
 	t.Run("PermanentDeleteBatch", func(t *testing.T) { testReactionStorePermanentDeleteBatch(t, ss) })
 	t.Run("ReactionBulkGetForPosts", func(t *testing.T) { testReactionBulkGetForPosts(t, ss) })
+	t.Run("ReactionGetUserIdsForEmojiInChannel", func(t *testing.T) { testReactionGetUserIdsForEmojiInChannel(t, ss) })
 }
 
 func testReactionSave(t *testing.T, ss store.Store) {
@@ -415,3 +419,52 @@ func testReactionBulkGetForPosts(t *testing.T, ss store.Store) {
 	}
 
 }
+
+func testReactionGetUserIdsForEmojiInChannel(t *testing.T, ss store.Store) {
+	channelId := model.NewId()
+	post1 := store.Must(ss.Post().Save(&model.Post{ChannelId: channelId, UserId: model.NewId(), Message: "post1"})).(*model.Post)
+	post2 := store.Must(ss.Post().Save(&model.Post{ChannelId: channelId, UserId: model.NewId(), Message: "post2"})).(*model.Post)
+	deletedPost := store.Must(ss.Post().Save(&model.Post{ChannelId: channelId, UserId: model.NewId(), Message: "deleted", DeleteAt: model.GetMillis()})).(*model.Post)
+	otherPost := store.Must(ss.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "other"})).(*model.Post)
+
+	userId1 := model.NewId()
+	userId2 := model.NewId()
+	userId3 := model.NewId()
+
+	reactions := []*model.Reaction{
+		{UserId: userId2, PostId: post1.Id, EmojiName: "thumbsup", CreateAt: 1000},
+		{UserId: userId1, PostId: post1.Id, EmojiName: "thumbsup", CreateAt: 2000},
+		{UserId: userId2, PostId: post2.Id, EmojiName: "thumbsup", CreateAt: 3000},
+		{UserId: userId3, PostId: post2.Id, EmojiName: "thumbsup", CreateAt: 4000},
+		{UserId: userId1, PostId: post2.Id, EmojiName: "smile", CreateAt: 500},
+		{UserId: model.NewId(), PostId: deletedPost.Id, EmojiName: "thumbsup", CreateAt: 1500},
+		{UserId: model.NewId(), PostId: otherPost.Id, EmojiName: "thumbsup", CreateAt: 1500},
+	}
+	for _, reaction := range reactions {
+		store.Must(ss.Reaction().Save(reaction))
+	}
+
+	t.Run("all", func(t *testing.T) {
+		result := <-ss.Reaction().GetUserIdsForEmojiInChannel(channelId, "thumbsup", 0, 0, 100)
+		require.Nil(t, result.Err)
+		assert.Equal(t, []string{userId2, userId1, userId3}, result.Data.([]string))
+	})
+
+	t.Run("since", func(t *testing.T) {
+		result := <-ss.Reaction().GetUserIdsForEmojiInChannel(channelId, "thumbsup", 2500, 0, 100)
+		require.Nil(t, result.Err)
+		assert.Equal(t, []string{userId2, userId3}, result.Data.([]string))
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		result := <-ss.Reaction().GetUserIdsForEmojiInChannel(channelId, "thumbsup", 0, 1, 1)
+		require.Nil(t, result.Err)
+		assert.Equal(t, []string{userId1}, result.Data.([]string))
+	})
+
+	t.Run("no reactions", func(t *testing.T) {
+		result := <-ss.Reaction().GetUserIdsForEmojiInChannel(channelId, "frowning", 0, 0, 100)
+		require.Nil(t, result.Err)
+		assert.Empty(t, result.Data.([]string))
+	})
+}
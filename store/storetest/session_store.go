@@ -26,6 +26,7 @@ func TestSessionStore(t *testing.T, ss store.Store) {
 	t.Run("SessionUpdateDeviceId", func(t *testing.T) { testSessionUpdateDeviceId(t, ss) })
 	t.Run("SessionUpdateDeviceId2", func(t *testing.T) { testSessionUpdateDeviceId2(t, ss) })
 	t.Run("UpdateLastActivityAt", func(t *testing.T) { testSessionStoreUpdateLastActivityAt(t, ss) })
+	t.Run("UpdateProps", func(t *testing.T) { testSessionStoreUpdateProps(t, ss) })
 	t.Run("SessionCount", func(t *testing.T) { testSessionCount(t, ss) })
 }
 
@@ -220,6 +221,22 @@ func testSessionUpdateDeviceId2(t *testing.T, ss store.Store) {
 	}
 }
 
+func testSessionStoreUpdateProps(t *testing.T, ss store.Store) {
+	s1 := model.Session{}
+	s1.UserId = model.NewId()
+	s1.AddProp(model.SESSION_PROP_PLATFORM, "Linux")
+	store.Must(ss.Session().Save(&s1))
+
+	token := s1.GenerateCSRF()
+	if err := (<-ss.Session().UpdateProps(&s1)).Err; err != nil {
+		t.Fatal(err)
+	}
+
+	session := store.Must(ss.Session().Get(s1.Id)).(*model.Session)
+	assert.Equal(t, token, session.GetCSRF())
+	assert.Equal(t, "Linux", session.Props[model.SESSION_PROP_PLATFORM])
+}
+
 func testSessionStoreUpdateLastActivityAt(t *testing.T, ss store.Store) {
 	s1 := model.Session{}
 	s1.UserId = model.NewId()
@@ -18,6 +18,7 @@ func TestStatusStore(t *testing.T, ss store.Store) {
 	t.Run("", func(t *testing.T) { testStatusStore(t, ss) })
 	t.Run("ActiveUserCount", func(t *testing.T) { testActiveUserCount(t, ss) })
 	t.Run("GetAllFromTeam", func(t *testing.T) { testGetAllFromTeam(t, ss) })
+	t.Run("GetRecentlyActive", func(t *testing.T) { testGetRecentlyActive(t, ss) })
 }
 
 func testStatusStore(t *testing.T, ss store.Store) {
@@ -193,3 +194,23 @@ func testGetAllFromTeam(t *testing.T, ss store.Store) {
 		}, result.Data.([]*model.Status))
 	}
 }
+
+func testGetRecentlyActive(t *testing.T, ss store.Store) {
+	// Far enough in the future that statuses saved by other tests don't get in the way.
+	now := model.GetMillis() + 365*24*60*60*1000
+
+	status1 := &model.Status{UserId: model.NewId(), Status: model.STATUS_OFFLINE, LastActivityAt: now + 1}
+	status2 := &model.Status{UserId: model.NewId(), Status: model.STATUS_ONLINE, LastActivityAt: now + 3}
+	status3 := &model.Status{UserId: model.NewId(), Status: model.STATUS_AWAY, LastActivityAt: now + 2}
+	for _, status := range []*model.Status{status1, status2, status3} {
+		require.Nil(t, (<-ss.Status().SaveOrUpdate(status)).Err)
+	}
+
+	result := <-ss.Status().GetRecentlyActive(2)
+	require.Nil(t, result.Err)
+
+	statuses := result.Data.([]*model.Status)
+	require.Len(t, statuses, 2)
+	assert.Equal(t, status2.UserId, statuses[0].UserId)
+	assert.Equal(t, status3.UserId, statuses[1].UserId)
+}
@@ -6,6 +6,7 @@ package storetest
 import (
 	"github.com/stretchr/testify/mock"
 
+	"github.com/mattermost/mattermost-server/model"
 	"github.com/mattermost/mattermost-server/store"
 	"github.com/mattermost/mattermost-server/store/storetest/mocks"
 )
@@ -49,6 +50,8 @@ type Store struct {
 	GroupStore                mocks.GroupStore
 	UserTermsOfServiceStore   mocks.UserTermsOfServiceStore
 	LinkMetadataStore         mocks.LinkMetadataStore
+	ClusterLockStore          mocks.ClusterLockStore
+	IdempotentRequestStore    mocks.IdempotentRequestStore
 }
 
 func (s *Store) Team() store.TeamStore                             { return &s.TeamStore }
@@ -83,14 +86,20 @@ func (s *Store) ChannelMemberHistory() store.ChannelMemberHistoryStore {
 }
 func (s *Store) Group() store.GroupStore               { return &s.GroupStore }
 func (s *Store) LinkMetadata() store.LinkMetadataStore { return &s.LinkMetadataStore }
-func (s *Store) MarkSystemRanUnitTests()               { /* do nothing */ }
-func (s *Store) Close()                                { /* do nothing */ }
-func (s *Store) LockToMaster()                         { /* do nothing */ }
-func (s *Store) UnlockFromMaster()                     { /* do nothing */ }
-func (s *Store) DropAllTables()                        { /* do nothing */ }
-func (s *Store) TotalMasterDbConnections() int         { return 1 }
-func (s *Store) TotalReadDbConnections() int           { return 1 }
-func (s *Store) TotalSearchDbConnections() int         { return 1 }
+func (s *Store) ClusterLock() store.ClusterLockStore   { return &s.ClusterLockStore }
+func (s *Store) IdempotentRequest() store.IdempotentRequestStore {
+	return &s.IdempotentRequestStore
+}
+func (s *Store) MarkSystemRanUnitTests()           { /* do nothing */ }
+func (s *Store) Close()                            { /* do nothing */ }
+func (s *Store) LockToMaster()                     { /* do nothing */ }
+func (s *Store) UnlockFromMaster()                 { /* do nothing */ }
+func (s *Store) DropAllTables()                    { /* do nothing */ }
+func (s *Store) TotalMasterDbConnections() int     { return 1 }
+func (s *Store) TotalReadDbConnections() int       { return 1 }
+func (s *Store) TotalSearchDbConnections() int     { return 1 }
+func (s *Store) DbPoolStats() []*model.DbPoolStats { return []*model.DbPoolStats{} }
+func (s *Store) CacheStats() []*model.CacheStats   { return []*model.CacheStats{} }
 
 func (s *Store) AssertExpectations(t mock.TestingT) bool {
 	return mock.AssertExpectationsForObjects(t,
@@ -4,6 +4,8 @@
 package storetest
 
 import (
+	"net/http"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -30,12 +32,14 @@ func TestTeamStore(t *testing.T, ss store.Store) {
 	t.Run("ByUserId", func(t *testing.T) { testTeamStoreByUserId(t, ss) })
 	t.Run("GetAllTeamListing", func(t *testing.T) { testGetAllTeamListing(t, ss) })
 	t.Run("GetAllTeamPageListing", func(t *testing.T) { testGetAllTeamPageListing(t, ss) })
+	t.Run("GetAutoJoinTeams", func(t *testing.T) { testTeamStoreGetAutoJoinTeams(t, ss) })
 	t.Run("Delete", func(t *testing.T) { testDelete(t, ss) })
 	t.Run("TeamCount", func(t *testing.T) { testTeamCount(t, ss) })
 	t.Run("TeamMembers", func(t *testing.T) { testTeamMembers(t, ss) })
 	t.Run("SaveTeamMemberMaxMembers", func(t *testing.T) { testSaveTeamMemberMaxMembers(t, ss) })
 	t.Run("GetTeamMember", func(t *testing.T) { testGetTeamMember(t, ss) })
 	t.Run("GetTeamMembersByIds", func(t *testing.T) { testGetTeamMembersByIds(t, ss) })
+	t.Run("GetTeamMembersAfter", func(t *testing.T) { testGetTeamMembersAfter(t, ss) })
 	t.Run("MemberCount", func(t *testing.T) { testTeamStoreMemberCount(t, ss) })
 	t.Run("GetChannelUnreadsForAllTeams", func(t *testing.T) { testGetChannelUnreadsForAllTeams(t, ss) })
 	t.Run("GetChannelUnreadsForTeam", func(t *testing.T) { testGetChannelUnreadsForTeam(t, ss) })
@@ -47,6 +51,11 @@ func TestTeamStore(t *testing.T, ss store.Store) {
 	t.Run("AnalyticsGetTeamCountForScheme", func(t *testing.T) { testTeamStoreAnalyticsGetTeamCountForScheme(t, ss) })
 	t.Run("GetAllForExportAfter", func(t *testing.T) { testTeamStoreGetAllForExportAfter(t, ss) })
 	t.Run("GetTeamMembersForExport", func(t *testing.T) { testTeamStoreGetTeamMembersForExport(t, ss) })
+	t.Run("StorageUsage", func(t *testing.T) { testTeamStoreStorageUsage(t, ss) })
+	t.Run("Waitlist", func(t *testing.T) { testTeamStoreWaitlist(t, ss) })
+	t.Run("Invites", func(t *testing.T) { testTeamStoreInvites(t, ss) })
+	t.Run("ChannelTemplates", func(t *testing.T) { testTeamStoreChannelTemplates(t, ss) })
+	t.Run("OrphanedMembers", func(t *testing.T) { testTeamStoreOrphanedMembers(t, ss) })
 }
 
 func testTeamStoreSave(t *testing.T, ss store.Store) {
@@ -1389,3 +1398,289 @@ func testTeamStoreGetTeamMembersForExport(t *testing.T, ss store.Store) {
 	assert.Equal(t, u1.Id, tmfe1.UserId)
 	assert.Equal(t, t1.Name, tmfe1.TeamName)
 }
+
+func testTeamStoreGetAutoJoinTeams(t *testing.T, ss store.Store) {
+	newTeam := func(allowedDomains string, autoJoin bool) *model.Team {
+		team := &model.Team{
+			DisplayName:    "DisplayName",
+			Name:           "zz" + model.NewId(),
+			Email:          MakeEmail(),
+			Type:           model.TEAM_INVITE,
+			AllowedDomains: allowedDomains,
+			AutoJoin:       autoJoin,
+		}
+		return store.Must(ss.Team().Save(team)).(*model.Team)
+	}
+
+	autoJoinTeam := newTeam("example.com", true)
+	newTeam("example.com", false)
+	// Without domains, every user would join the team
+	newTeam("", true)
+	deletedTeam := newTeam("example.com", true)
+	deletedTeam.DeleteAt = model.GetMillis()
+	store.Must(ss.Team().Update(deletedTeam))
+
+	result := <-ss.Team().GetAutoJoinTeams()
+	require.Nil(t, result.Err)
+	teams := result.Data.([]*model.Team)
+
+	ids := []string{}
+	for _, team := range teams {
+		assert.True(t, team.AutoJoin)
+		ids = append(ids, team.Id)
+	}
+	assert.Contains(t, ids, autoJoinTeam.Id)
+	assert.NotContains(t, ids, deletedTeam.Id)
+}
+
+func testTeamStoreStorageUsage(t *testing.T, ss store.Store) {
+	teamId := model.NewId()
+
+	getUsage := func() int64 {
+		result := <-ss.Team().GetStorageUsage(teamId)
+		require.Nil(t, result.Err)
+		return result.Data.(int64)
+	}
+
+	assert.Equal(t, int64(0), getUsage())
+
+	store.Must(ss.Team().UpdateStorageUsage(teamId, 100))
+	assert.Equal(t, int64(100), getUsage())
+
+	store.Must(ss.Team().UpdateStorageUsage(teamId, 50))
+	assert.Equal(t, int64(150), getUsage())
+
+	store.Must(ss.Team().UpdateStorageUsage(teamId, -120))
+	assert.Equal(t, int64(30), getUsage())
+
+	// Usage can't go below zero, such as when files uploaded before it was recorded are removed
+	store.Must(ss.Team().UpdateStorageUsage(teamId, -100))
+	assert.Equal(t, int64(0), getUsage())
+
+	store.Must(ss.Team().UpdateStorageUsage(teamId, 0))
+	assert.Equal(t, int64(0), getUsage())
+
+	store.Must(ss.Team().UpdateStorageUsage(teamId, 10))
+	store.Must(ss.Team().PermanentDelete(teamId))
+	assert.Equal(t, int64(0), getUsage())
+
+	store.Must(ss.Team().UpdateStorageUsage(teamId, 10))
+	store.Must(ss.Team().ResetStorageUsage())
+	assert.Equal(t, int64(0), getUsage())
+
+	store.Must(ss.Team().UpdateStorageUsage(teamId, 20))
+	assert.Equal(t, int64(20), getUsage())
+}
+
+func testTeamStoreWaitlist(t *testing.T, ss store.Store) {
+	teamId := model.NewId()
+	userId1 := model.NewId()
+	userId2 := model.NewId()
+
+	entry1 := store.Must(ss.Team().SaveWaitlistEntry(&model.TeamWaitlistEntry{TeamId: teamId, UserId: userId1, CreateAt: 1000})).(*model.TeamWaitlistEntry)
+	store.Must(ss.Team().SaveWaitlistEntry(&model.TeamWaitlistEntry{TeamId: teamId, UserId: userId2, CreateAt: 2000}))
+	store.Must(ss.Team().SaveWaitlistEntry(&model.TeamWaitlistEntry{TeamId: model.NewId(), UserId: userId1}))
+
+	// Waitlisting a user again keeps their place
+	entry := store.Must(ss.Team().SaveWaitlistEntry(&model.TeamWaitlistEntry{TeamId: teamId, UserId: userId1})).(*model.TeamWaitlistEntry)
+	assert.Equal(t, entry1, entry)
+
+	result := <-ss.Team().SaveWaitlistEntry(&model.TeamWaitlistEntry{TeamId: "junk", UserId: userId1})
+	assert.NotNil(t, result.Err)
+
+	entry = store.Must(ss.Team().GetWaitlistEntry(teamId, userId1)).(*model.TeamWaitlistEntry)
+	assert.Equal(t, entry1, entry)
+
+	result = <-ss.Team().GetWaitlistEntry(teamId, model.NewId())
+	require.NotNil(t, result.Err)
+	assert.Equal(t, http.StatusNotFound, result.Err.StatusCode)
+
+	entries := store.Must(ss.Team().GetWaitlist(teamId, 0, 10)).([]*model.TeamWaitlistEntry)
+	require.Len(t, entries, 2)
+	assert.Equal(t, userId1, entries[0].UserId)
+	assert.Equal(t, userId2, entries[1].UserId)
+
+	entries = store.Must(ss.Team().GetWaitlist(teamId, 1, 10)).([]*model.TeamWaitlistEntry)
+	require.Len(t, entries, 1)
+	assert.Equal(t, userId2, entries[0].UserId)
+
+	store.Must(ss.Team().RemoveWaitlistEntry(teamId, userId1))
+	entries = store.Must(ss.Team().GetWaitlist(teamId, 0, 10)).([]*model.TeamWaitlistEntry)
+	require.Len(t, entries, 1)
+	assert.Equal(t, userId2, entries[0].UserId)
+
+	store.Must(ss.Team().PermanentDelete(teamId))
+	entries = store.Must(ss.Team().GetWaitlist(teamId, 0, 10)).([]*model.TeamWaitlistEntry)
+	assert.Empty(t, entries)
+}
+
+func testTeamStoreInvites(t *testing.T, ss store.Store) {
+	teamId := model.NewId()
+	now := model.GetMillis()
+
+	invite := store.Must(ss.Team().SaveInvite(&model.TeamInvite{TeamId: teamId, CreatorId: model.NewId(), CreateAt: now - 2000, MaxUses: 2, ChannelIds: model.StringArray{model.NewId()}, SchemeAdmin: true})).(*model.TeamInvite)
+	expiring := store.Must(ss.Team().SaveInvite(&model.TeamInvite{TeamId: teamId, CreatorId: model.NewId(), CreateAt: now - 1000, ExpireAt: now + 1000})).(*model.TeamInvite)
+	store.Must(ss.Team().SaveInvite(&model.TeamInvite{TeamId: model.NewId(), CreatorId: model.NewId()}))
+
+	result := <-ss.Team().SaveInvite(&model.TeamInvite{TeamId: teamId})
+	assert.NotNil(t, result.Err)
+
+	rinvite := store.Must(ss.Team().GetInvite(invite.Id)).(*model.TeamInvite)
+	assert.Equal(t, invite, rinvite)
+
+	result = <-ss.Team().GetInvite(model.NewId())
+	require.NotNil(t, result.Err)
+	assert.Equal(t, http.StatusNotFound, result.Err.StatusCode)
+
+	invites := store.Must(ss.Team().GetInvitesForTeam(teamId)).([]*model.TeamInvite)
+	require.Len(t, invites, 2)
+	assert.Equal(t, expiring.Id, invites[0].Id)
+	assert.Equal(t, invite.Id, invites[1].Id)
+
+	t.Run("uses are limited", func(t *testing.T) {
+		assert.True(t, store.Must(ss.Team().UseInvite(invite.Id, now)).(bool))
+		assert.True(t, store.Must(ss.Team().UseInvite(invite.Id, now)).(bool))
+		assert.False(t, store.Must(ss.Team().UseInvite(invite.Id, now)).(bool))
+
+		store.Must(ss.Team().ReleaseInvite(invite.Id))
+		assert.Equal(t, 1, store.Must(ss.Team().GetInvite(invite.Id)).(*model.TeamInvite).Uses)
+
+		assert.True(t, store.Must(ss.Team().UseInvite(invite.Id, now)).(bool))
+		assert.Equal(t, 2, store.Must(ss.Team().GetInvite(invite.Id)).(*model.TeamInvite).Uses)
+	})
+
+	t.Run("expired invites can't be used", func(t *testing.T) {
+		assert.True(t, store.Must(ss.Team().UseInvite(expiring.Id, now)).(bool))
+		assert.False(t, store.Must(ss.Team().UseInvite(expiring.Id, expiring.ExpireAt)).(bool))
+	})
+
+	t.Run("revoked invites can't be used", func(t *testing.T) {
+		store.Must(ss.Team().RevokeInvite(expiring.Id, now))
+		assert.False(t, store.Must(ss.Team().UseInvite(expiring.Id, now)).(bool))
+		assert.Equal(t, now, store.Must(ss.Team().GetInvite(expiring.Id)).(*model.TeamInvite).DeleteAt)
+
+		invites := store.Must(ss.Team().GetInvitesForTeam(teamId)).([]*model.TeamInvite)
+		require.Len(t, invites, 1)
+		assert.Equal(t, invite.Id, invites[0].Id)
+	})
+
+	store.Must(ss.Team().PermanentDelete(teamId))
+	result = <-ss.Team().GetInvite(invite.Id)
+	assert.NotNil(t, result.Err)
+}
+
+func testGetTeamMembersAfter(t *testing.T, ss store.Store) {
+	teamId := model.NewId()
+
+	userIds := []string{model.NewId(), model.NewId()}
+	sort.Strings(userIds)
+	for _, userId := range userIds {
+		store.Must(ss.Team().SaveMember(&model.TeamMember{TeamId: teamId, UserId: userId}, -1))
+	}
+	store.Must(ss.Team().SaveMember(&model.TeamMember{TeamId: teamId, UserId: model.NewId(), DeleteAt: model.GetMillis()}, -1))
+	store.Must(ss.Team().SaveMember(&model.TeamMember{TeamId: model.NewId(), UserId: userIds[0]}, -1))
+
+	result := <-ss.Team().GetMembersAfter(teamId, "", 1)
+	require.Nil(t, result.Err)
+	members := result.Data.([]*model.TeamMember)
+	require.Len(t, members, 1)
+	assert.Equal(t, userIds[0], members[0].UserId)
+
+	result = <-ss.Team().GetMembersAfter(teamId, members[0].UserId, 10)
+	require.Nil(t, result.Err)
+	members = result.Data.([]*model.TeamMember)
+	require.Len(t, members, 1, "members who left the team shouldn't be returned")
+	assert.Equal(t, userIds[1], members[0].UserId)
+
+	result = <-ss.Team().GetMembersAfter(teamId, userIds[1], 10)
+	require.Nil(t, result.Err)
+	assert.Empty(t, result.Data.([]*model.TeamMember))
+}
+
+func testTeamStoreChannelTemplates(t *testing.T, ss store.Store) {
+	teamId := model.NewId()
+
+	incident := store.Must(ss.Team().SaveChannelTemplate(&model.ChannelTemplate{TeamId: teamId, CreatorId: model.NewId(), Name: "Incident", Type: model.CHANNEL_PRIVATE, Header: "header", Purpose: "purpose", IntroMessage: "intro"})).(*model.ChannelTemplate)
+	announcements := store.Must(ss.Team().SaveChannelTemplate(&model.ChannelTemplate{TeamId: teamId, CreatorId: model.NewId(), Name: "Announcements", Type: model.CHANNEL_OPEN})).(*model.ChannelTemplate)
+	store.Must(ss.Team().SaveChannelTemplate(&model.ChannelTemplate{TeamId: model.NewId(), CreatorId: model.NewId(), Name: "Other", Type: model.CHANNEL_OPEN}))
+
+	result := <-ss.Team().SaveChannelTemplate(&model.ChannelTemplate{TeamId: teamId, CreatorId: model.NewId(), Type: model.CHANNEL_OPEN})
+	assert.NotNil(t, result.Err)
+
+	rtemplate := store.Must(ss.Team().GetChannelTemplate(incident.Id)).(*model.ChannelTemplate)
+	assert.Equal(t, incident, rtemplate)
+
+	result = <-ss.Team().GetChannelTemplate(model.NewId())
+	require.NotNil(t, result.Err)
+	assert.Equal(t, http.StatusNotFound, result.Err.StatusCode)
+
+	templates := store.Must(ss.Team().GetChannelTemplatesForTeam(teamId)).([]*model.ChannelTemplate)
+	require.Len(t, templates, 2)
+	assert.Equal(t, announcements.Id, templates[0].Id)
+	assert.Equal(t, incident.Id, templates[1].Id)
+
+	incident.Header = "new header"
+	store.Must(ss.Team().UpdateChannelTemplate(incident))
+	assert.Equal(t, "new header", store.Must(ss.Team().GetChannelTemplate(incident.Id)).(*model.ChannelTemplate).Header)
+
+	result = <-ss.Team().UpdateChannelTemplate(&model.ChannelTemplate{Id: model.NewId(), TeamId: teamId, CreatorId: model.NewId(), CreateAt: 1, Name: "Missing", Type: model.CHANNEL_OPEN})
+	require.NotNil(t, result.Err)
+	assert.Equal(t, http.StatusNotFound, result.Err.StatusCode)
+
+	store.Must(ss.Team().DeleteChannelTemplate(announcements.Id))
+	result = <-ss.Team().GetChannelTemplate(announcements.Id)
+	assert.NotNil(t, result.Err)
+
+	store.Must(ss.Team().PermanentDelete(teamId))
+	result = <-ss.Team().GetChannelTemplate(incident.Id)
+	assert.NotNil(t, result.Err)
+}
+
+func testTeamStoreOrphanedMembers(t *testing.T, ss store.Store) {
+	team := store.Must(ss.Team().Save(&model.Team{DisplayName: "Kept", Name: model.NewId(), Email: MakeEmail(), Type: model.TEAM_OPEN})).(*model.Team)
+	deleted := store.Must(ss.Team().Save(&model.Team{DisplayName: "Deleted", Name: model.NewId(), Email: MakeEmail(), Type: model.TEAM_OPEN})).(*model.Team)
+	user := store.Must(ss.User().Save(&model.User{Email: MakeEmail(), Username: model.NewId()})).(*model.User)
+
+	kept := store.Must(ss.Team().SaveMember(&model.TeamMember{TeamId: team.Id, UserId: user.Id}, -1)).(*model.TeamMember)
+	missingUser := store.Must(ss.Team().SaveMember(&model.TeamMember{TeamId: team.Id, UserId: model.NewId()}, -1)).(*model.TeamMember)
+	missingTeam := store.Must(ss.Team().SaveMember(&model.TeamMember{TeamId: deleted.Id, UserId: user.Id}, -1)).(*model.TeamMember)
+	store.Must(ss.Team().PermanentDelete(deleted.Id))
+
+	// Other tests leave orphaned members behind too, so every page is checked for the ones made here
+	getOrphaned := func() map[string]*model.OrphanedTeamMember {
+		orphaned := map[string]*model.OrphanedTeamMember{}
+		afterTeamId, afterUserId := "", ""
+		for {
+			result := <-ss.Team().GetOrphanedMembers(afterTeamId, afterUserId, 2)
+			require.Nil(t, result.Err)
+			members := result.Data.([]*model.OrphanedTeamMember)
+			for _, member := range members {
+				orphaned[member.TeamId+member.UserId] = member
+				afterTeamId, afterUserId = member.TeamId, member.UserId
+			}
+			if len(members) < 2 {
+				return orphaned
+			}
+		}
+	}
+
+	orphaned := getOrphaned()
+	assert.NotContains(t, orphaned, kept.TeamId+kept.UserId)
+	assert.Equal(t, &model.OrphanedTeamMember{TeamId: team.Id, UserId: missingUser.UserId, TeamExists: true, UserExists: false}, orphaned[missingUser.TeamId+missingUser.UserId])
+	assert.Equal(t, &model.OrphanedTeamMember{TeamId: deleted.Id, UserId: user.Id, TeamExists: false, UserExists: true}, orphaned[missingTeam.TeamId+missingTeam.UserId])
+
+	result := <-ss.Team().RemoveOrphanedMember(kept.TeamId, kept.UserId)
+	require.Nil(t, result.Err)
+	assert.False(t, result.Data.(bool))
+	store.Must(ss.Team().GetMember(kept.TeamId, kept.UserId))
+
+	for _, member := range []*model.TeamMember{missingUser, missingTeam} {
+		result = <-ss.Team().RemoveOrphanedMember(member.TeamId, member.UserId)
+		require.Nil(t, result.Err)
+		assert.True(t, result.Data.(bool))
+	}
+
+	orphaned = getOrphaned()
+	assert.NotContains(t, orphaned, missingUser.TeamId+missingUser.UserId)
+	assert.NotContains(t, orphaned, missingTeam.TeamId+missingTeam.UserId)
+}
@@ -64,6 +64,7 @@ func TestUserStore(t *testing.T, ss store.Store) {
 	t.Run("GetProfilesNotInTeam", func(t *testing.T) { testUserStoreGetProfilesNotInTeam(t, ss) })
 	t.Run("ClearAllCustomRoleAssignments", func(t *testing.T) { testUserStoreClearAllCustomRoleAssignments(t, ss) })
 	t.Run("GetAllAfter", func(t *testing.T) { testUserStoreGetAllAfter(t, ss) })
+	t.Run("GetLastActivityAfter", func(t *testing.T) { testUserStoreGetLastActivityAfter(t, ss) })
 }
 
 func testUserStoreSave(t *testing.T, ss store.Store) {
@@ -2774,3 +2775,54 @@ func testUserStoreGetAllAfter(t *testing.T, ss store.Store) {
 		assert.NotEqual(t, u1.Id, u.Id)
 	}
 }
+
+func testUserStoreGetLastActivityAfter(t *testing.T, ss store.Store) {
+	now := model.GetMillis()
+
+	u1 := &model.User{Email: MakeEmail(), Username: model.NewId()}
+	store.Must(ss.User().Save(u1))
+	defer func() { store.Must(ss.User().PermanentDelete(u1.Id)) }()
+	store.Must(ss.Status().SaveOrUpdate(&model.Status{UserId: u1.Id, Status: model.STATUS_ONLINE, LastActivityAt: now - 1000}))
+
+	u2 := &model.User{Email: MakeEmail(), Username: model.NewId()}
+	store.Must(ss.User().Save(u2))
+	defer func() { store.Must(ss.User().PermanentDelete(u2.Id)) }()
+	store.Must(ss.Status().SaveOrUpdate(&model.Status{UserId: u2.Id, Status: model.STATUS_OFFLINE, LastActivityAt: now - 100000}))
+	s2 := store.Must(ss.Session().Save(&model.Session{UserId: u2.Id})).(*model.Session)
+	store.Must(ss.Session().UpdateLastActivityAt(s2.Id, now-50000))
+	defer func() { store.Must(ss.Session().Remove(s2.Id)) }()
+
+	u3 := &model.User{Email: MakeEmail(), Username: model.NewId(), DeleteAt: now}
+	store.Must(ss.User().Save(u3))
+	defer func() { store.Must(ss.User().PermanentDelete(u3.Id)) }()
+
+	getAll := func(inactiveSince int64) map[string]*model.UserLastActivity {
+		activities := map[string]*model.UserLastActivity{}
+		afterId := strings.Repeat("0", 26)
+		for {
+			result := <-ss.User().GetLastActivityAfter(afterId, inactiveSince, 1)
+			require.Nil(t, result.Err)
+			batch := result.Data.([]*model.UserLastActivity)
+			if len(batch) == 0 {
+				return activities
+			}
+			activities[batch[0].UserId] = batch[0]
+			afterId = batch[0].UserId
+		}
+	}
+
+	activities := getAll(0)
+	require.Contains(t, activities, u1.Id)
+	require.Contains(t, activities, u2.Id)
+	require.Contains(t, activities, u3.Id)
+	assert.Equal(t, u1.Username, activities[u1.Id].Username)
+	assert.Equal(t, now-1000, activities[u1.Id].LastActivityAt)
+	assert.Equal(t, now-50000, activities[u2.Id].LastActivityAt, "should use the most recent session activity")
+	assert.Equal(t, int64(0), activities[u3.Id].LastActivityAt)
+	assert.Equal(t, model.USER_LAST_ACTIVITY_STATUS_INACTIVE, activities[u3.Id].Status())
+
+	activities = getAll(now - 10000)
+	assert.NotContains(t, activities, u1.Id)
+	assert.Contains(t, activities, u2.Id)
+	assert.Contains(t, activities, u3.Id)
+}
@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/mattermost/mattermost-server/model"
@@ -26,40 +27,158 @@ func CheckOrigin(r *http.Request, allowedOrigins string) bool {
 	if allowedOrigins == "*" {
 		return true
 	}
-	for _, allowed := range strings.Split(allowedOrigins, " ") {
+	for _, allowed := range strings.Fields(allowedOrigins) {
 		if allowed == origin {
 			return true
 		}
+
+		if MatchWildcardOrigin(allowed, origin) {
+			return true
+		}
 	}
 	return false
 }
 
+// MatchWildcardOrigin returns whether an origin matches an allowed origin containing a single wildcard, such as
+// https://*.example.com. The wildcard stands for one or more whole labels of the host, so it only matches on a "."
+// boundary: https://*.example.com matches https://a.b.example.com, but not https://evil-example.com or
+// https://example.com.evil.com. A wildcard that isn't a whole label of the allowed origin never matches anything.
+func MatchWildcardOrigin(allowed, origin string) bool {
+	i := strings.Index(allowed, "*")
+	if i == -1 {
+		return false
+	}
+
+	prefix, suffix := allowed[:i], allowed[i+1:]
+	if !(strings.HasSuffix(prefix, "://") || strings.HasSuffix(prefix, ".")) || !strings.HasPrefix(suffix, ".") {
+		return false
+	}
+
+	if len(origin) <= len(prefix)+len(suffix) || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+
+	for _, label := range strings.Split(origin[len(prefix):len(origin)-len(suffix)], ".") {
+		if !isHostLabel(label) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isHostLabel(label string) bool {
+	if label == "" {
+		return false
+	}
+
+	for _, c := range label {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+
+	return true
+}
+
 func OriginChecker(allowedOrigins string) func(*http.Request) bool {
 	return func(r *http.Request) bool {
 		return CheckOrigin(r, allowedOrigins)
 	}
 }
 
+// SanitizeAppError removes the internal details of an error that's about to be sent to a client, unless developer mode
+// is enabled.
+func SanitizeAppError(config *model.Config, err *model.AppError) {
+	if !*config.ServiceSettings.EnableDeveloper {
+		err.DetailedError = ""
+	}
+}
+
+// RenderAppErrorJson sends an error to an API client as JSON, which includes its id, status code and the params of its
+// message, along with its internal details only in developer mode.
+func RenderAppErrorJson(config *model.Config, w http.ResponseWriter, err *model.AppError) {
+	SanitizeAppError(config, err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.StatusCode)
+	w.Write([]byte(err.ToJson()))
+}
+
+// RenderApiError sends an error to an API client, written as an RFC 7807 problem if the client asks for one and as the
+// usual JSON otherwise. Every error that an API client might get should be written this way, so that clients asking for
+// problems get them no matter what rejected their request.
+func RenderApiError(config *model.Config, w http.ResponseWriter, r *http.Request, err *model.AppError) {
+	w.Header().Add("Vary", "Accept")
+	if PrefersProblemJson(r) {
+		RenderAppErrorProblemJson(config, w, err)
+	} else {
+		RenderAppErrorJson(config, w, err)
+	}
+}
+
+// RenderMobileAppError sends an error to the mobile app at the end of an SSO login. The mobile app reads the error from
+// the body of a 200 response, and the status of the error may be a redirect meant for the web app, which would leave
+// the mobile app with nowhere to go.
+func RenderMobileAppError(config *model.Config, w http.ResponseWriter, err *model.AppError) {
+	SanitizeAppError(config, err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(err.ToJson()))
+}
+
+// RenderAppErrorProblemJson writes an error as an RFC 7807 problem, see model.ProblemDetails.
+func RenderAppErrorProblemJson(config *model.Config, w http.ResponseWriter, err *model.AppError) {
+	SanitizeAppError(config, err)
+
+	w.Header().Set("Content-Type", model.PROBLEM_DETAILS_CONTENT_TYPE)
+	w.WriteHeader(err.StatusCode)
+	w.Write([]byte(model.NewProblemDetails(err).ToJson()))
+}
+
+// RenderWebAppError sends the user to the error page with the translated message of an error. The id of the error is
+// passed along too, so that the page can show its own text for the error in the user's locale.
 func RenderWebAppError(config *model.Config, w http.ResponseWriter, r *http.Request, err *model.AppError, s crypto.Signer) {
-	RenderWebError(config, w, r, err.StatusCode, url.Values{
+	params := url.Values{
 		"message": []string{err.Message},
-	}, s)
+		"status":  []string{strconv.Itoa(err.StatusCode)},
+	}
+
+	// Hardened mode clears the id of internal errors
+	if err.Id != "" {
+		params.Set("error_id", err.Id)
+	}
+
+	RenderWebError(config, w, r, err.StatusCode, params, s)
+}
+
+// errorPagePath returns the escaped path of the page that web errors are rendered by, below the subpath of the site.
+func errorPagePath(config *model.Config) string {
+	errorPage := model.SERVICE_SETTINGS_DEFAULT_ERROR_PAGE_PATH
+	if config.ServiceSettings.ErrorPagePath != nil && *config.ServiceSettings.ErrorPagePath != "" {
+		errorPage = *config.ServiceSettings.ErrorPagePath
+	}
+
+	subpath, _ := GetSubpathFromConfig(config)
+
+	return (&url.URL{Path: path.Join("/", subpath, errorPage)}).EscapedPath()
 }
 
 func RenderWebError(config *model.Config, w http.ResponseWriter, r *http.Request, status int, params url.Values, s crypto.Signer) {
 	queryString := params.Encode()
 
-	subpath, _ := GetSubpathFromConfig(config)
+	errorPage := errorPagePath(config)
 
 	h := crypto.SHA256
 	sum := h.New()
-	sum.Write([]byte(path.Join(subpath, "error") + "?" + queryString))
+	sum.Write([]byte(errorPage + "?" + queryString))
 	signature, err := s.Sign(rand.Reader, sum.Sum(nil), h)
 	if err != nil {
 		http.Error(w, "", http.StatusInternalServerError)
 		return
 	}
-	destination := path.Join(subpath, "error") + "?" + queryString + "&s=" + base64.URLEncoding.EncodeToString(signature)
+	destination := errorPage + "?" + queryString + "&s=" + base64.URLEncoding.EncodeToString(signature)
 
 	if status >= 300 && status < 400 {
 		http.Redirect(w, r, destination, status)
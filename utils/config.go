@@ -535,6 +535,8 @@ func GenerateClientConfig(c *model.Config, diagnosticId string, license *model.L
 	props["PasswordRequireNumber"] = strconv.FormatBool(*c.PasswordSettings.Number)
 	props["PasswordRequireSymbol"] = strconv.FormatBool(*c.PasswordSettings.Symbol)
 	props["CustomUrlSchemes"] = strings.Join(c.DisplaySettings.CustomUrlSchemes, ",")
+	props["EnforcedNotifyProps"] = strings.Join(c.NotificationDefaultSettings.EnforcedNotifyProps, ",")
+	props["EnableDataLossPrevention"] = strconv.FormatBool(*c.DataLossPreventionSettings.Enable)
 
 	if license != nil {
 		props["ExperimentalHideTownSquareinLHS"] = strconv.FormatBool(*c.TeamSettings.ExperimentalHideTownSquareinLHS)
@@ -0,0 +1,68 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package utils
+
+import (
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+type acceptedMediaRange struct {
+	mediaRange string
+	quality    float64
+}
+
+// NegotiateContentType returns the supported content type that's most preferred by the given Accept header, or false
+// if none of them are acceptable. Media ranges of equal quality are preferred in the order they're listed, and the
+// first supported content type is used when there's no Accept header.
+func NegotiateContentType(accept string, supported []string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return supported[0], true
+	}
+
+	var accepted []acceptedMediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if quality > 0 {
+			accepted = append(accepted, acceptedMediaRange{mediaRange, quality})
+		}
+	}
+
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].quality > accepted[j].quality
+	})
+
+	for _, a := range accepted {
+		for _, contentType := range supported {
+			if a.mediaRange == "*/*" || a.mediaRange == contentType || (strings.HasSuffix(a.mediaRange, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(a.mediaRange, "*"))) {
+				return contentType, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// PrefersProblemJson returns whether the client would rather have errors written as RFC 7807 problems than as the
+// usual JSON, which is only the case when it asks for them.
+func PrefersProblemJson(r *http.Request) bool {
+	contentType, _ := NegotiateContentType(r.Header.Get("Accept"), []string{"application/json", model.PROBLEM_DETAILS_CONTENT_TYPE})
+	return contentType == model.PROBLEM_DETAILS_CONTENT_TYPE
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateContentType(t *testing.T) {
+	supported := []string{"application/json", "text/csv"}
+
+	for name, test := range map[string]struct {
+		Accept     string
+		Expected   string
+		Acceptable bool
+	}{
+		"no accept header":          {"", "application/json", true},
+		"json":                      {"application/json", "application/json", true},
+		"csv":                       {"text/csv", "text/csv", true},
+		"csv with parameters":       {"text/csv; charset=utf-8", "text/csv", true},
+		"anything":                  {"*/*", "application/json", true},
+		"any text":                  {"text/*", "text/csv", true},
+		"browser":                   {"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "application/json", true},
+		"first of equal preference": {"text/csv, application/json", "text/csv", true},
+		"higher quality preferred":  {"text/csv;q=0.5, application/json", "application/json", true},
+		"zero quality excluded":     {"text/csv;q=0, */*;q=0.1", "application/json", true},
+		"unsupported":               {"application/xml", "", false},
+		"malformed":                 {"text/csv;q=high", "", false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			contentType, ok := NegotiateContentType(test.Accept, supported)
+			assert.Equal(t, test.Acceptable, ok)
+			assert.Equal(t, test.Expected, contentType)
+		})
+	}
+}
+
+func TestPrefersProblemJson(t *testing.T) {
+	for accept, expected := range map[string]bool{
+		"":                         false,
+		"*/*":                      false,
+		"application/json":         false,
+		"application/problem+json": true,
+		"application/json, application/problem+json":       false,
+		"application/json;q=0.5, application/problem+json": true,
+		"application/*": false,
+	} {
+		t.Run(accept, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v4/test", nil)
+			r.Header.Set("Accept", accept)
+			assert.Equal(t, expected, PrefersProblemJson(r))
+		})
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"container/list"
 	"sync"
 	"time"
+
+	"github.com/mattermost/mattermost-server/model"
 )
 
 // Cache is a thread-safe fixed size LRU cache.
@@ -24,6 +26,8 @@ type Cache struct {
 	invalidateClusterEvent string
 	currentGeneration      int64
 	len                    int
+	hits                   int64
+	misses                 int64
 }
 
 // entry is used to hold a value in the evictList.
@@ -114,7 +118,17 @@ func (c *Cache) Get(key interface{}) (value interface{}, ok bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	return c.getValue(key)
+	value, ok = c.getValue(key)
+	c.recordLookup(ok)
+	return value, ok
+}
+
+func (c *Cache) recordLookup(hit bool) {
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
 }
 
 func (c *Cache) getValue(key interface{}) (value interface{}, ok bool) {
@@ -141,9 +155,11 @@ func (c *Cache) GetOrAdd(key, value interface{}, ttl time.Duration) (actual inte
 
 	// Check for existing item
 	if value, ok := c.getValue(key); ok {
+		c.recordLookup(true)
 		return value, true
 	}
 
+	c.recordLookup(false)
 	c.add(key, value, ttl)
 
 	return value, false
@@ -177,6 +193,25 @@ func (c *Cache) Keys() []interface{} {
 	return keys
 }
 
+// Stats returns the number of hits and misses of lookups in the cache since it was created, along with its size.
+func (c *Cache) Stats() *model.CacheStats {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	stats := &model.CacheStats{
+		Name:   c.name,
+		Size:   c.size,
+		Len:    c.len,
+		Hits:   c.hits,
+		Misses: c.misses,
+	}
+	if lookups := c.hits + c.misses; lookups > 0 {
+		stats.HitRate = float64(c.hits) / float64(lookups)
+	}
+
+	return stats
+}
+
 // Len returns the number of items in the cache.
 func (c *Cache) Len() int {
 	c.lock.RLock()
@@ -131,3 +131,24 @@ func TestLRUGetOrAdd(t *testing.T) {
 	assert.Equal(t, 5, value)
 	assert.False(t, loaded)
 }
+
+func TestLRUStats(t *testing.T) {
+	l := NewLruWithParams(128, "Test", 0, "")
+
+	stats := l.Stats()
+	assert.Equal(t, "Test", stats.Name)
+	assert.Equal(t, 128, stats.Size)
+	assert.Equal(t, 0.0, stats.HitRate)
+
+	l.Add(1, 1)
+	l.Get(1)
+	l.Get(1)
+	l.Get(2)
+	l.GetOrAdd(1, 1, 0)
+
+	stats = l.Stats()
+	assert.Equal(t, 1, stats.Len)
+	assert.Equal(t, int64(3), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, 0.75, stats.HitRate)
+}
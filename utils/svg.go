@@ -0,0 +1,273 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const SVG_NAMESPACE = "http://www.w3.org/2000/svg"
+
+// svgBlockedElements can run scripts or embed other documents, so they're removed along with everything in them.
+var svgBlockedElements = map[string]bool{
+	"script":        true,
+	"foreignobject": true,
+	"iframe":        true,
+	"embed":         true,
+	"object":        true,
+	"handler":       true,
+	"listener":      true,
+	"audio":         true,
+	"video":         true,
+	"meta":          true,
+	"link":          true,
+	"base":          true,
+}
+
+// svgAnimationElements can change the attributes of other elements, such as setting a link to a javascript: URL.
+var svgAnimationElements = map[string]bool{
+	"set":              true,
+	"animate":          true,
+	"animatecolor":     true,
+	"animatemotion":    true,
+	"animatetransform": true,
+}
+
+// svgAttrsStartingWithOn are the presentation attributes that would otherwise be mistaken for event handlers.
+var svgAttrsStartingWithOn = map[string]bool{
+	"offset":      true,
+	"opacity":     true,
+	"operator":    true,
+	"order":       true,
+	"orient":      true,
+	"orientation": true,
+}
+
+// svgEmbeddableImagePrefixes are the data URLs that images in an SVG may be embedded as. An SVG image can't be
+// embedded this way, since it could in turn contain anything.
+var svgEmbeddableImagePrefixes = []string{
+	"data:image/png",
+	"data:image/jpeg",
+	"data:image/gif",
+	"data:image/bmp",
+	"data:image/webp",
+}
+
+// SanitizeSvg removes everything from an SVG that could run a script or load something from elsewhere, which are
+// script and other embedding elements, event handler attributes, and links or styles that refer to anything outside
+// of the SVG itself. Comments, processing instructions and DTDs are removed as well. An error is returned if the data
+// isn't a well-formed SVG document.
+func SanitizeSvg(data []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	var out bytes.Buffer
+	var open []xml.Name
+	skipDepth := 0
+	sawRoot := false
+
+	// The text of a style element is only checked once it's complete, since it may be split across CDATA sections
+	var styleText *bytes.Buffer
+
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "failed to parse SVG")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(open) == 0 {
+				if sawRoot {
+					return nil, errors.New("failed to parse SVG: more than one root element")
+				} else if t.Name.Space != "" || t.Name.Local != "svg" || svgDefaultNamespace(t) != SVG_NAMESPACE {
+					return nil, errors.New("failed to parse SVG: the root element isn't an SVG element")
+				}
+				sawRoot = true
+			}
+			open = append(open, t.Name)
+
+			if skipDepth > 0 || styleText != nil || !isAllowedSvgElement(t) {
+				skipDepth++
+				continue
+			}
+
+			if strings.EqualFold(t.Name.Local, "style") {
+				styleText = &bytes.Buffer{}
+			}
+
+			out.WriteByte('<')
+			writeSvgName(&out, t.Name)
+			for _, attr := range t.Attr {
+				if isAllowedSvgAttr(attr) {
+					out.WriteByte(' ')
+					writeSvgName(&out, attr.Name)
+					out.WriteString(`="`)
+					xml.EscapeText(&out, []byte(attr.Value))
+					out.WriteByte('"')
+				}
+			}
+			out.WriteByte('>')
+
+		case xml.EndElement:
+			if len(open) == 0 || open[len(open)-1] != t.Name {
+				return nil, errors.Errorf("failed to parse SVG: unexpected end element </%s>", t.Name.Local)
+			}
+			open = open[:len(open)-1]
+
+			if skipDepth > 0 {
+				skipDepth--
+				continue
+			}
+
+			if styleText != nil {
+				if !hasExternalCssReference(styleText.String()) {
+					xml.EscapeText(&out, styleText.Bytes())
+				}
+				styleText = nil
+			}
+
+			out.WriteString("</")
+			writeSvgName(&out, t.Name)
+			out.WriteByte('>')
+
+		case xml.CharData:
+			if len(open) == 0 {
+				if len(bytes.TrimSpace(t)) > 0 {
+					return nil, errors.New("failed to parse SVG: text outside of the root element")
+				}
+				continue
+			}
+
+			if skipDepth > 0 {
+				continue
+			}
+
+			if styleText != nil {
+				styleText.Write(t)
+			} else {
+				xml.EscapeText(&out, t)
+			}
+		}
+	}
+
+	if !sawRoot {
+		return nil, errors.New("failed to parse SVG: no root element")
+	} else if len(open) > 0 {
+		return nil, errors.Errorf("failed to parse SVG: element <%s> isn't closed", open[len(open)-1].Local)
+	}
+
+	return out.Bytes(), nil
+}
+
+func svgDefaultNamespace(element xml.StartElement) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func isAllowedSvgElement(element xml.StartElement) bool {
+	// Prefixed elements belong to other vocabularies, such as XHTML or an editor's metadata
+	if element.Name.Space != "" {
+		return false
+	}
+
+	if namespace := svgDefaultNamespace(element); namespace != "" && namespace != SVG_NAMESPACE {
+		return false
+	}
+
+	name := strings.ToLower(element.Name.Local)
+	if svgBlockedElements[name] {
+		return false
+	}
+
+	if svgAnimationElements[name] {
+		for _, attr := range element.Attr {
+			if strings.EqualFold(attr.Name.Local, "attributeName") {
+				target := strings.ToLower(strings.TrimSpace(attr.Value))
+				if i := strings.LastIndex(target, ":"); i != -1 {
+					target = target[i+1:]
+				}
+
+				if target == "href" || target == "src" || target == "style" || isSvgEventAttr(target) {
+					return false
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+func isAllowedSvgAttr(attr xml.Attr) bool {
+	name := strings.ToLower(attr.Name.Local)
+	value := strings.TrimSpace(attr.Value)
+
+	switch {
+	case isSvgEventAttr(name):
+		return false
+	case attr.Name.Space == "xml" && name == "base":
+		return false
+	case name == "href" || name == "src":
+		return strings.HasPrefix(value, "#") || isEmbeddableSvgImage(value)
+	}
+
+	return !hasExternalCssReference(value)
+}
+
+func isSvgEventAttr(name string) bool {
+	return strings.HasPrefix(name, "on") && !svgAttrsStartingWithOn[name]
+}
+
+func isEmbeddableSvgImage(value string) bool {
+	value = strings.ToLower(value)
+	for _, prefix := range svgEmbeddableImagePrefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasExternalCssReference returns whether CSS, or an attribute that may hold a url(), refers to anything other than an
+// element of the document that it's in.
+func hasExternalCssReference(css string) bool {
+	css = strings.ToLower(css)
+
+	// Escapes could be used to hide any of the below, and aren't needed by anything that an SVG would normally contain
+	if strings.Contains(css, "\\") || strings.Contains(css, "@import") || strings.Contains(css, "expression(") || strings.Contains(css, "javascript:") {
+		return true
+	}
+
+	for rest := css; ; {
+		i := strings.Index(rest, "url(")
+		if i == -1 {
+			return false
+		}
+		rest = rest[i+len("url("):]
+
+		target := strings.TrimLeft(rest, " \t\r\n\"'")
+		if !strings.HasPrefix(target, "#") {
+			return true
+		}
+	}
+}
+
+func writeSvgName(out *bytes.Buffer, name xml.Name) {
+	if name.Space != "" {
+		out.WriteString(name.Space)
+		out.WriteByte(':')
+	}
+	out.WriteString(name.Local)
+}
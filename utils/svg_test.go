@@ -0,0 +1,88 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeSvg(t *testing.T) {
+	for name, tc := range map[string]struct {
+		Input    string
+		Expected string
+	}{
+		"plain drawing": {
+			`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><rect x="1" y="1" width="8" height="8" fill="red" opacity="0.5"/></svg>`,
+			`<svg xmlns="http://www.w3.org/2000/svg" width="10" height="10"><rect x="1" y="1" width="8" height="8" fill="red" opacity="0.5"></rect></svg>`,
+		},
+		"declaration, doctype and comments": {
+			"<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<!DOCTYPE svg PUBLIC \"-//W3C//DTD SVG 1.1//EN\" \"http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd\">\n<!-- drawn by hand --><svg xmlns=\"http://www.w3.org/2000/svg\"><g/></svg>",
+			`<svg xmlns="http://www.w3.org/2000/svg"><g></g></svg>`,
+		},
+		"scripts": {
+			`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script><SCRIPT><![CDATA[alert(2)]]></SCRIPT><g/></svg>`,
+			`<svg xmlns="http://www.w3.org/2000/svg"><g></g></svg>`,
+		},
+		"event handlers": {
+			`<svg xmlns="http://www.w3.org/2000/svg" onload="alert(1)"><circle r="1" onClick="alert(2)"/></svg>`,
+			`<svg xmlns="http://www.w3.org/2000/svg"><circle r="1"></circle></svg>`,
+		},
+		"embedded documents": {
+			`<svg xmlns="http://www.w3.org/2000/svg"><foreignObject><iframe src="https://example.com"/></foreignObject><g/></svg>`,
+			`<svg xmlns="http://www.w3.org/2000/svg"><g></g></svg>`,
+		},
+		"elements from other namespaces": {
+			`<svg xmlns="http://www.w3.org/2000/svg" xmlns:html="http://www.w3.org/1999/xhtml"><html:img src="x" onerror="alert(1)"/><g xmlns="http://www.w3.org/1999/xhtml"><img src="x"/></g></svg>`,
+			`<svg xmlns="http://www.w3.org/2000/svg" xmlns:html="http://www.w3.org/1999/xhtml"></svg>`,
+		},
+		"links": {
+			`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><a href="javascript:alert(1)"><use xlink:href="#shape"/><use href="https://example.com/sprite.svg#shape"/></a></svg>`,
+			`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"><a><use xlink:href="#shape"></use><use></use></a></svg>`,
+		},
+		"embedded images": {
+			`<svg xmlns="http://www.w3.org/2000/svg"><image href="data:image/png;base64,AAAA"/><image href="data:image/svg+xml;base64,AAAA"/></svg>`,
+			`<svg xmlns="http://www.w3.org/2000/svg"><image href="data:image/png;base64,AAAA"></image><image></image></svg>`,
+		},
+		"animations of links": {
+			`<svg xmlns="http://www.w3.org/2000/svg"><a><set attributeName="href" to="javascript:alert(1)"/><animate attributeName="opacity" from="0" to="1"/></a></svg>`,
+			`<svg xmlns="http://www.w3.org/2000/svg"><a><animate attributeName="opacity" from="0" to="1"></animate></a></svg>`,
+		},
+		"styles": {
+			`<svg xmlns="http://www.w3.org/2000/svg"><style>rect { fill: url(#gradient); }</style><style>@import "https://example.com/a.css";</style><style>rect { background: u<![CDATA[rl(https://example.com/a.png)]]> }</style><rect style="fill: url('https://example.com/a.png')" fill="url(#gradient)"/></svg>`,
+			`<svg xmlns="http://www.w3.org/2000/svg"><style>rect { fill: url(#gradient); }</style><style></style><style></style><rect fill="url(#gradient)"></rect></svg>`,
+		},
+		"escaped text": {
+			`<svg xmlns="http://www.w3.org/2000/svg"><text title="&quot;a&quot; &lt; b">a &amp; b</text></svg>`,
+			`<svg xmlns="http://www.w3.org/2000/svg"><text title="&#34;a&#34; &lt; b">a &amp; b</text></svg>`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			output, err := SanitizeSvg([]byte(tc.Input))
+			require.NoError(t, err)
+			assert.Equal(t, tc.Expected, string(output))
+		})
+	}
+}
+
+func TestSanitizeSvgInvalid(t *testing.T) {
+	for name, input := range map[string]string{
+		"empty":            ``,
+		"not xml":          `not an svg`,
+		"not an svg":       `<html xmlns="http://www.w3.org/1999/xhtml"></html>`,
+		"no namespace":     `<svg></svg>`,
+		"unclosed element": `<svg xmlns="http://www.w3.org/2000/svg"><g>`,
+		"mismatched end":   `<svg xmlns="http://www.w3.org/2000/svg"><g></a></svg>`,
+		"two roots":        `<svg xmlns="http://www.w3.org/2000/svg"></svg><svg xmlns="http://www.w3.org/2000/svg"></svg>`,
+		"trailing text":    `<svg xmlns="http://www.w3.org/2000/svg"></svg>text`,
+		"custom entity":    `<!DOCTYPE svg [<!ENTITY xxe SYSTEM "file:///etc/passwd">]><svg xmlns="http://www.w3.org/2000/svg"><text>&xxe;</text></svg>`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := SanitizeSvg([]byte(input))
+			assert.Error(t, err)
+		})
+	}
+}
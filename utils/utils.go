@@ -87,6 +87,56 @@ func GetIpAddress(r *http.Request) string {
 	return address
 }
 
+// GetRealClientIpAddress returns the IP address of the client that made a request through a chain of trusted proxies,
+// given as addresses or CIDR ranges. Starting from the address that the request came from, the addresses in the given
+// header, such as X-Forwarded-For, are walked from the right, and the first one that isn't a trusted proxy is the
+// client's. Without any trusted proxies, the address that the request came from is returned as is.
+func GetRealClientIpAddress(r *http.Request, header string, trustedProxies []string) string {
+	address, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		address = r.RemoteAddr
+	}
+
+	if len(trustedProxies) == 0 || !isIpAddressInRanges(address, trustedProxies) {
+		return address
+	}
+
+	hops := strings.Split(strings.Join(r.Header[http.CanonicalHeaderKey(header)], ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			// Anything that isn't an address can't have been added by a trusted proxy
+			break
+		}
+
+		address = hop
+		if !isIpAddressInRanges(address, trustedProxies) {
+			break
+		}
+	}
+
+	return address
+}
+
+func isIpAddressInRanges(address string, ranges []string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	for _, entry := range ranges {
+		if otherIp := net.ParseIP(entry); otherIp != nil && otherIp.Equal(ip) {
+			return true
+		}
+
+		if _, ipRange, err := net.ParseCIDR(entry); err == nil && ipRange.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func GetHostnameFromSiteURL(siteURL string) string {
 	u, err := url.Parse(siteURL)
 	if err != nil {
@@ -99,3 +99,38 @@ func TestGetIpAddress(t *testing.T) {
 
 	assert.Equal(t, "10.2.0.1", GetIpAddress(&httpRequest5))
 }
+
+func TestGetRealClientIpAddress(t *testing.T) {
+	trustedProxies := []string{"10.0.0.0/16", "192.168.1.1"}
+
+	for name, tc := range map[string]struct {
+		RemoteAddr     string
+		Forwarded      []string
+		TrustedProxies []string
+		Expected       string
+	}{
+		"no trusted proxies":          {RemoteAddr: "10.0.0.1:12345", Forwarded: []string{"1.2.3.4"}, Expected: "10.0.0.1"},
+		"untrusted remote address":    {RemoteAddr: "5.6.7.8:12345", Forwarded: []string{"1.2.3.4"}, TrustedProxies: trustedProxies, Expected: "5.6.7.8"},
+		"single proxy":                {RemoteAddr: "10.0.0.1:12345", Forwarded: []string{"1.2.3.4"}, TrustedProxies: trustedProxies, Expected: "1.2.3.4"},
+		"chain of proxies":            {RemoteAddr: "10.0.0.1:12345", Forwarded: []string{"1.2.3.4, 192.168.1.1, 10.0.5.5"}, TrustedProxies: trustedProxies, Expected: "1.2.3.4"},
+		"spoofed addresses":           {RemoteAddr: "10.0.0.1:12345", Forwarded: []string{"9.9.9.9, 1.2.3.4"}, TrustedProxies: trustedProxies, Expected: "1.2.3.4"},
+		"multiple headers":            {RemoteAddr: "10.0.0.1:12345", Forwarded: []string{"9.9.9.9, 1.2.3.4", "10.0.0.2"}, TrustedProxies: trustedProxies, Expected: "1.2.3.4"},
+		"only trusted proxies":        {RemoteAddr: "10.0.0.1:12345", Forwarded: []string{"192.168.1.1, 10.0.0.2"}, TrustedProxies: trustedProxies, Expected: "192.168.1.1"},
+		"no header":                   {RemoteAddr: "10.0.0.1:12345", TrustedProxies: trustedProxies, Expected: "10.0.0.1"},
+		"invalid address in header":   {RemoteAddr: "10.0.0.1:12345", Forwarded: []string{"1.2.3.4, unknown"}, TrustedProxies: trustedProxies, Expected: "10.0.0.1"},
+		"ipv6":                        {RemoteAddr: "[fd00::1]:12345", Forwarded: []string{"2001:db8::1"}, TrustedProxies: []string{"fd00::/8"}, Expected: "2001:db8::1"},
+		"remote address without port": {RemoteAddr: "10.0.0.1", Forwarded: []string{"1.2.3.4"}, TrustedProxies: trustedProxies, Expected: "1.2.3.4"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := &http.Request{
+				Header:     http.Header{},
+				RemoteAddr: tc.RemoteAddr,
+			}
+			for _, forwarded := range tc.Forwarded {
+				r.Header.Add("X-Forwarded-For", forwarded)
+			}
+
+			assert.Equal(t, tc.Expected, GetRealClientIpAddress(r, "X-Forwarded-For", tc.TrustedProxies))
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/mattermost/mattermost-server/mlog"
+)
+
+// accessLogResponseWriter keeps track of the status code and size of a response so that they can be written to the
+// access log once the request has been handled.
+type accessLogResponseWriter struct {
+	http.ResponseWriter
+	statusCode   int
+	bytesWritten int64
+}
+
+func (w *accessLogResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *accessLogResponseWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytesWritten += int64(n)
+	return n, err
+}
+
+func (w *accessLogResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack is needed for the websocket to be upgraded through the access log.
+func (w *accessLogResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the underlying response writer doesn't support hijacking")
+	}
+
+	// The connection is handed over to the websocket, so its upgrade is logged as switching protocols
+	w.statusCode = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+func (w *accessLogResponseWriter) status() int {
+	if w.statusCode == 0 {
+		return http.StatusOK
+	}
+	return w.statusCode
+}
+
+// logAccess writes a structured line to the log for a request that has been handled.
+func logAccess(c *Context, r *http.Request, w *accessLogResponseWriter, start time.Time) {
+	fields := []mlog.Field{
+		mlog.String("request_id", c.App.RequestId),
+		mlog.String("user_id", c.App.Session.UserId),
+		mlog.String("method", r.Method),
+		mlog.String("path", c.App.Path),
+		mlog.Int("status_code", w.status()),
+		mlog.Int64("duration_ms", int64(time.Since(start)/time.Millisecond)),
+		mlog.Int64("bytes_written", w.bytesWritten),
+		mlog.String("ip_addr", c.RealClientIP()),
+	}
+	if c.timedOut {
+		fields = append(fields, mlog.Bool("timed_out", true))
+	}
+
+	c.App.Log.Info("Handled HTTP request", fields...)
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLogResponseWriter(t *testing.T) {
+	t.Run("implicit status", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		w := &accessLogResponseWriter{ResponseWriter: recorder}
+
+		w.Write([]byte("hello"))
+		w.Write([]byte(" world"))
+
+		assert.Equal(t, http.StatusOK, w.status())
+		assert.Equal(t, int64(11), w.bytesWritten)
+		assert.Equal(t, "hello world", recorder.Body.String())
+	})
+
+	t.Run("explicit status", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		w := &accessLogResponseWriter{ResponseWriter: recorder}
+
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("{}"))
+
+		assert.Equal(t, http.StatusNotFound, w.status())
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, int64(2), w.bytesWritten)
+	})
+
+	t.Run("nothing written", func(t *testing.T) {
+		w := &accessLogResponseWriter{ResponseWriter: httptest.NewRecorder()}
+
+		assert.Equal(t, http.StatusOK, w.status())
+		assert.Equal(t, int64(0), w.bytesWritten)
+	})
+
+	t.Run("hijacking isn't supported by the underlying writer", func(t *testing.T) {
+		w := &accessLogResponseWriter{ResponseWriter: httptest.NewRecorder()}
+
+		_, _, err := w.Hijack()
+		assert.NotNil(t, err)
+	})
+}
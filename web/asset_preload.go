@@ -0,0 +1,126 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/mlog"
+)
+
+// assetManifestFilename is the build manifest that the webapp writes alongside its bundles, listing the files that
+// make up each of its entry points.
+const assetManifestFilename = "asset-manifest.json"
+
+type assetManifest struct {
+	Entrypoints map[string]struct {
+		Js  []string `json:"js"`
+		Css []string `json:"css"`
+	} `json:"entrypoints"`
+}
+
+type preloadAsset struct {
+	path string
+	as   string
+}
+
+// assetPreloadCache remembers the critical assets listed in the build manifest so that it's only read again once
+// the webapp has been replaced.
+type assetPreloadCache struct {
+	mutex    sync.Mutex
+	filename string
+	modTime  time.Time
+	assets   []preloadAsset
+}
+
+var assetPreloads = &assetPreloadCache{}
+
+// get returns the JS and CSS of every entry point in the build manifest in the given directory, or nothing if there
+// is no manifest.
+func (c *assetPreloadCache) get(staticDir string) ([]preloadAsset, error) {
+	filename := filepath.Join(staticDir, assetManifestFilename)
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.filename == filename && c.modTime.Equal(info.ModTime()) {
+		return c.assets, nil
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var manifest assetManifest
+	if err := json.NewDecoder(file).Decode(&manifest); err != nil {
+		return nil, err
+	}
+
+	// Sort the entry points so that the assets are always hinted in the same order
+	names := make([]string, 0, len(manifest.Entrypoints))
+	for name := range manifest.Entrypoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	assets := []preloadAsset{}
+	for _, name := range names {
+		entrypoint := manifest.Entrypoints[name]
+		for _, css := range entrypoint.Css {
+			assets = append(assets, preloadAsset{path: css, as: "style"})
+		}
+		for _, js := range entrypoint.Js {
+			assets = append(assets, preloadAsset{path: js, as: "script"})
+		}
+	}
+
+	c.filename = filename
+	c.modTime = info.ModTime()
+	c.assets = assets
+
+	return assets, nil
+}
+
+// setAssetPreloads hints the critical assets of the webapp to the browser before it has parsed the index page. They
+// are pushed when the connection is HTTP/2 and otherwise given as Link preload headers.
+func setAssetPreloads(w http.ResponseWriter, r *http.Request, staticDir string, subpath string) {
+	assets, err := assetPreloads.get(staticDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			mlog.Warn("Failed to read the webapp build manifest", mlog.String("filename", filepath.Join(staticDir, assetManifestFilename)), mlog.Err(err))
+		}
+		return
+	}
+
+	pusher, canPush := w.(http.Pusher)
+
+	for _, asset := range assets {
+		assetPath := path.Join(subpath, "static", path.Clean("/"+asset.path))
+
+		if canPush && r.ProtoMajor == 2 {
+			if err := pusher.Push(assetPath, nil); err == nil {
+				continue
+			} else if err != http.ErrNotSupported {
+				mlog.Debug("Failed to push a webapp asset", mlog.String("path", assetPath), mlog.Err(err))
+			}
+		}
+
+		w.Header().Add("Link", fmt.Sprintf("<%s>; rel=preload; as=%s", assetPath, asset.as))
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (r *pushRecorder) Push(target string, opts *http.PushOptions) error {
+	r.pushed = append(r.pushed, target)
+	return nil
+}
+
+func TestSetAssetPreloads(t *testing.T) {
+	dir, err := ioutil.TempDir("", "static")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	t.Run("no manifest", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		setAssetPreloads(response, httptest.NewRequest("GET", "/", nil), dir, "/")
+		assert.Empty(t, response.Header()["Link"])
+	})
+
+	manifest := `{"entrypoints": {"main": {"js": ["main.1234.js"], "css": ["main.5678.css"]}}}`
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, assetManifestFilename), []byte(manifest), 0600))
+
+	t.Run("link headers", func(t *testing.T) {
+		response := httptest.NewRecorder()
+		setAssetPreloads(response, httptest.NewRequest("GET", "/", nil), dir, "/subpath")
+		assert.Equal(t, []string{
+			"</subpath/static/main.5678.css>; rel=preload; as=style",
+			"</subpath/static/main.1234.js>; rel=preload; as=script",
+		}, response.Header()["Link"])
+	})
+
+	t.Run("http/2 push", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/", nil)
+		request.ProtoMajor = 2
+		response := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		setAssetPreloads(response, request, dir, "/")
+		assert.Equal(t, []string{"/static/main.5678.css", "/static/main.1234.js"}, response.pushed)
+		assert.Empty(t, response.Header()["Link"])
+	})
+
+	t.Run("http/1.1 with a pusher", func(t *testing.T) {
+		response := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		setAssetPreloads(response, httptest.NewRequest("GET", "/", nil), dir, "/")
+		assert.Empty(t, response.pushed)
+		assert.Len(t, response.Header()["Link"], 2)
+	})
+}
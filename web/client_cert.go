@@ -0,0 +1,43 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/app"
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// authenticateClientCert signs in the user identified by the verified client certificate of a request without a
+// session, as if they'd logged in, see App.LoginByClientCert. Unless ServiceSettings.ClientCertAuthRequired is set,
+// requests whose certificate is missing, doesn't match a user or whose user can't log in are left to other
+// authentication.
+func authenticateClientCert(c *Context, w http.ResponseWriter, r *http.Request) {
+	// Browsers present client certificates to any site that asks, just like cookies, so requests that change anything
+	// need the same proof that they came from our own client as those authenticated by a cookie
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Header.Get(model.HEADER_REQUESTED_WITH) != model.HEADER_REQUESTED_WITH_XML {
+		if *c.App.Config().ServiceSettings.ClientCertAuthRequired {
+			c.Err = model.NewAppError("ServeHTTP", "api.context.client_cert.app_error", nil, "missing "+model.HEADER_REQUESTED_WITH, http.StatusUnauthorized)
+		}
+		return
+	}
+
+	var session *model.Session
+	err := model.NewAppError("ServeHTTP", "api.context.client_cert.app_error", nil, "no client certificate", http.StatusUnauthorized)
+	if cert := app.ClientCertFromRequest(r); cert != nil {
+		session, err = c.App.LoginByClientCert(w, r, cert)
+	}
+
+	if err != nil {
+		c.Log.Debug("Unable to authenticate with client certificate", mlog.Err(err))
+		if *c.App.Config().ServiceSettings.ClientCertAuthRequired {
+			c.Err = model.NewAppError("ServeHTTP", "api.context.client_cert.app_error", nil, err.Error(), http.StatusUnauthorized)
+		}
+		return
+	}
+
+	c.App.Session = *session
+}
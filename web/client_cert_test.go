@@ -0,0 +1,103 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/store"
+)
+
+func TestHandlerServeHTTPClientCertAuth(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.ClientCertAuth = true
+		*cfg.ServiceSettings.ClientCertAuthAttribute = model.CLIENT_CERT_AUTH_ATTRIBUTE_SAN
+	})
+
+	var sessionUserId string
+	handler := web.NewHandler(func(c *Context, w http.ResponseWriter, r *http.Request) {
+		sessionUserId = c.App.Session.UserId
+	})
+
+	serve := func(method string, email string) *httptest.ResponseRecorder {
+		sessionUserId = ""
+		request := httptest.NewRequest(method, "/api/v4/test", nil)
+		if email != "" {
+			cert := &x509.Certificate{Subject: pkix.Name{CommonName: "smartcard"}, EmailAddresses: []string{email}}
+			request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("certificate of a user", func(t *testing.T) {
+		response := serve("GET", th.BasicUser.Email)
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, th.BasicUser.Id, sessionUserId)
+		assert.NotEmpty(t, response.Header().Get(model.HEADER_TOKEN))
+
+		sessions, err := th.App.GetSessions(th.BasicUser.Id)
+		require.Nil(t, err)
+
+		// Later requests with the same certificate reuse the session
+		response = serve("GET", th.BasicUser.Email)
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, th.BasicUser.Id, sessionUserId)
+		assert.Empty(t, response.Header().Get(model.HEADER_TOKEN))
+
+		reused, err := th.App.GetSessions(th.BasicUser.Id)
+		require.Nil(t, err)
+		assert.Len(t, reused, len(sessions))
+	})
+
+	t.Run("not used for users that can't log in", func(t *testing.T) {
+		user, err := th.App.CreateUser(&model.User{Email: model.NewId() + "success+test@simulator.amazonses.com", Password: "passwd1", EmailVerified: true})
+		require.Nil(t, err)
+
+		store.Must(th.App.Srv.Store.User().UpdateFailedPasswordAttempts(user.Id, *th.App.Config().ServiceSettings.MaximumLoginAttempts))
+		th.App.InvalidateCacheForUser(user.Id)
+
+		assert.Equal(t, http.StatusOK, serve("GET", user.Email).Code)
+		assert.Empty(t, sessionUserId)
+	})
+
+	t.Run("falls through without a matching certificate", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("GET", "").Code)
+		assert.Empty(t, sessionUserId)
+
+		assert.Equal(t, http.StatusOK, serve("GET", "unknown@example.com").Code)
+		assert.Empty(t, sessionUserId)
+	})
+
+	t.Run("not used for cross-site requests", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("POST", th.BasicUser.Email).Code)
+		assert.Empty(t, sessionUserId)
+	})
+
+	t.Run("required", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.ClientCertAuthRequired = true })
+
+		response := serve("GET", "unknown@example.com")
+		assert.Equal(t, http.StatusUnauthorized, response.Code)
+		require.Equal(t, "api.context.client_cert.app_error", model.AppErrorFromJson(response.Body).Id)
+
+		assert.Equal(t, http.StatusOK, serve("GET", th.BasicUser.Email).Code)
+		assert.Equal(t, th.BasicUser.Id, sessionUserId)
+	})
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// incompressibleContentTypes are already compressed, so compressing them again would cost time without saving space.
+// Images, audio and video, other than SVGs, are treated the same way.
+var incompressibleContentTypes = map[string]bool{
+	"application/gzip":             true,
+	"application/pdf":              true,
+	"application/x-7z-compressed":  true,
+	"application/x-gzip":           true,
+	"application/x-rar-compressed": true,
+	"application/zip":              true,
+	"font/woff":                    true,
+	"font/woff2":                   true,
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// acceptsGzip returns whether the client accepts responses compressed with gzip. Brotli isn't offered, even to clients
+// that prefer it, since there's no encoder for it available to the server.
+func acceptsGzip(r *http.Request) bool {
+	gzipQ, anyQ := -1.0, -1.0
+
+	for _, value := range r.Header["Accept-Encoding"] {
+		for _, coding := range strings.Split(value, ",") {
+			parts := strings.Split(coding, ";")
+			name := strings.ToLower(strings.TrimSpace(parts[0]))
+
+			q := 1.0
+			for _, param := range parts[1:] {
+				param = strings.TrimSpace(param)
+				if strings.HasPrefix(param, "q=") {
+					if parsed, err := strconv.ParseFloat(param[len("q="):], 64); err == nil {
+						q = parsed
+					}
+				}
+			}
+
+			switch name {
+			case "gzip", "x-gzip":
+				gzipQ = q
+			case "*":
+				anyQ = q
+			}
+		}
+	}
+
+	// gzip is only accepted through a wildcard if it isn't listed itself, which may be done to refuse it
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+	return anyQ > 0
+}
+
+func isCompressibleContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	if mediaType == "image/svg+xml" {
+		return true
+	}
+
+	if strings.HasPrefix(mediaType, "image/") || strings.HasPrefix(mediaType, "audio/") || strings.HasPrefix(mediaType, "video/") {
+		return false
+	}
+
+	return !incompressibleContentTypes[mediaType]
+}
+
+// compressingResponseWriter compresses responses with gzip once they're known to be at least minSize bytes long. Until
+// then, the start of the response is held back, along with its status code, so that smaller responses can still be
+// written as they are. finish must be called once the response is complete.
+type compressingResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+
+	started    bool
+	statusCode int
+	buf        []byte
+	gzipWriter *gzip.Writer
+}
+
+func (w *compressingResponseWriter) WriteHeader(statusCode int) {
+	if w.started {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+func (w *compressingResponseWriter) Write(b []byte) (int, error) {
+	if !w.started {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.minSize {
+			return len(b), nil
+		}
+
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if w.gzipWriter != nil {
+		return w.gzipWriter.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressingResponseWriter) Flush() {
+	if !w.started {
+		w.start()
+	}
+
+	if w.gzipWriter != nil {
+		w.gzipWriter.Flush()
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressingResponseWriter) finish() {
+	if !w.started {
+		w.start()
+	}
+
+	if w.gzipWriter != nil {
+		w.gzipWriter.Close()
+		w.gzipWriter.Reset(nil)
+		gzipWriterPool.Put(w.gzipWriter)
+		w.gzipWriter = nil
+	}
+}
+
+func (w *compressingResponseWriter) shouldCompress() bool {
+	switch w.statusCode {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return false
+	}
+
+	header := w.Header()
+	if len(w.buf) == 0 || len(w.buf) < w.minSize || header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+
+	// Once compressed, the content type could no longer be detected from the response
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	return isCompressibleContentType(header.Get("Content-Type"))
+}
+
+// start writes the status code and the part of the response that was held back, having decided whether to compress it.
+func (w *compressingResponseWriter) start() error {
+	w.started = true
+
+	if w.shouldCompress() {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Del("Content-Length")
+
+		w.gzipWriter = gzipWriterPool.Get().(*gzip.Writer)
+		w.gzipWriter.Reset(w.ResponseWriter)
+	}
+
+	if w.statusCode != 0 {
+		w.ResponseWriter.WriteHeader(w.statusCode)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.gzipWriter != nil {
+		_, err = w.gzipWriter.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	for acceptEncoding, expected := range map[string]bool{
+		"":                      false,
+		"gzip":                  true,
+		"GZIP":                  true,
+		"deflate, gzip;q=0.5":   true,
+		"br":                    false,
+		"br, gzip":              true,
+		"gzip;q=0":              false,
+		"*":                     true,
+		"*;q=0":                 false,
+		"gzip;q=0, *":           false,
+		"identity, *;q=0.1, br": true,
+	} {
+		r := httptest.NewRequest("GET", "/api/v4/test", nil)
+		if acceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		assert.Equal(t, expected, acceptsGzip(r), acceptEncoding)
+	}
+}
+
+func TestIsCompressibleContentType(t *testing.T) {
+	assert.True(t, isCompressibleContentType("application/json"))
+	assert.True(t, isCompressibleContentType("text/html; charset=utf-8"))
+	assert.True(t, isCompressibleContentType("image/svg+xml"))
+	assert.False(t, isCompressibleContentType("image/png"))
+	assert.False(t, isCompressibleContentType("video/mp4"))
+	assert.False(t, isCompressibleContentType("application/zip"))
+	assert.False(t, isCompressibleContentType(""))
+}
+
+func TestCompressingResponseWriter(t *testing.T) {
+	body := strings.Repeat(`{"id": "abcdefghijklmnopqrstuvwxyz"}`, 100)
+
+	write := func(minSize int, contentType string, statusCode int, writes ...string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		w := &compressingResponseWriter{ResponseWriter: recorder, minSize: minSize}
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.Header().Set("Content-Length", "12345")
+		if statusCode != 0 {
+			w.WriteHeader(statusCode)
+		}
+		for _, b := range writes {
+			n, err := w.Write([]byte(b))
+			require.Nil(t, err)
+			require.Equal(t, len(b), n)
+		}
+		w.finish()
+		return recorder
+	}
+
+	decompress := func(t *testing.T, data []byte) string {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		require.Nil(t, err)
+		decompressed, err := ioutil.ReadAll(reader)
+		require.Nil(t, err)
+		return string(decompressed)
+	}
+
+	t.Run("compresses large responses", func(t *testing.T) {
+		recorder := write(1400, "application/json", http.StatusCreated, body[:1000], body[1000:])
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+		assert.Empty(t, recorder.Header().Get("Content-Length"))
+		assert.True(t, recorder.Body.Len() < len(body))
+		assert.Equal(t, body, decompress(t, recorder.Body.Bytes()))
+	})
+
+	t.Run("doesn't compress small responses", func(t *testing.T) {
+		recorder := write(1400, "application/json", http.StatusNotFound, `{"id": "a"}`)
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+		assert.Equal(t, "12345", recorder.Header().Get("Content-Length"))
+		assert.Equal(t, `{"id": "a"}`, recorder.Body.String())
+	})
+
+	t.Run("doesn't compress already compressed content types", func(t *testing.T) {
+		recorder := write(1400, "image/png", 0, body)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+		assert.Equal(t, body, recorder.Body.String())
+	})
+
+	t.Run("doesn't compress partial content", func(t *testing.T) {
+		recorder := write(1400, "text/plain", http.StatusPartialContent, body)
+		assert.Equal(t, http.StatusPartialContent, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+		assert.Equal(t, body, recorder.Body.String())
+	})
+
+	t.Run("detects a missing content type before compressing", func(t *testing.T) {
+		recorder := write(0, "", 0, "<html><body>"+body+"</body></html>")
+		assert.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("writes the status of empty responses", func(t *testing.T) {
+		recorder := write(0, "application/json", http.StatusNoContent)
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("Content-Encoding"))
+		assert.Equal(t, 0, recorder.Body.Len())
+	})
+
+	t.Run("flushes", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		w := &compressingResponseWriter{ResponseWriter: recorder, minSize: 0}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(body))
+		w.Flush()
+		assert.True(t, recorder.Flushed)
+		assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+
+		w.Write([]byte(body))
+		w.finish()
+		assert.Equal(t, body+body, decompress(t, recorder.Body.Bytes()))
+	})
+}
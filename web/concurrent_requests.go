@@ -0,0 +1,46 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+// acquireConcurrentRequest counts a request against ServiceSettings.MaxConcurrentRequestsPerUser, using the user of the
+// session, or the IP address of requests made without one. It returns false if the request should be rejected because
+// too many are already in progress, or otherwise a function that must be called once the request has been handled.
+// Static content and websocket connections, which are expected to last as long as a client is open, aren't counted.
+func (h Handler) acquireConcurrentRequest(c *Context, r *http.Request) (func(), bool) {
+	max := *c.App.Config().ServiceSettings.MaxConcurrentRequestsPerUser
+	if max == 0 || h.IsStatic || websocket.IsWebSocketUpgrade(r) {
+		return func() {}, true
+	}
+
+	key := "ip:" + c.App.IpAddress
+	if c.App.Session.UserId != "" {
+		key = "user:" + c.App.Session.UserId
+	}
+
+	if !c.App.Srv.AcquireConcurrentRequest(key, max) {
+		return nil, false
+	}
+
+	return func() { c.App.Srv.ReleaseConcurrentRequest(key) }, true
+}
+
+// writeTooManyConcurrentRequests rejects a request because too many others are in progress for the same user. Like
+// load shedding, the rejection is expected, so it isn't logged.
+func writeTooManyConcurrentRequests(c *Context, w http.ResponseWriter, r *http.Request) {
+	err := model.NewAppError("ServeHTTP", "api.context.too_many_concurrent_requests.app_error", nil, "", http.StatusTooManyRequests)
+	c.Locale()
+	err.Translate(c.App.T)
+	err.RequestId = c.App.RequestId
+
+	utils.RenderApiError(c.App.Config(), w, r, err)
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestHandlerServeHTTPMaxConcurrentRequestsPerUser(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.MaxConcurrentRequestsPerUser = 1 })
+
+	session, err := th.App.CreateSession(&model.Session{UserId: th.BasicUser.Id, Roles: th.BasicUser.Roles})
+	require.Nil(t, err)
+
+	started := make(chan bool)
+	finish := make(chan bool)
+	handler := web.NewHandler(func(c *Context, w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("block") != "" {
+			started <- true
+			<-finish
+		}
+	})
+
+	serve := func(token string, query string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("GET", "/api/v4/test"+query, nil)
+		if token != "" {
+			request.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+token)
+		}
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response
+	}
+
+	done := make(chan int)
+	go func() {
+		done <- serve(session.Token, "?block=true").Code
+	}()
+	<-started
+
+	response := serve(session.Token, "")
+	assert.Equal(t, http.StatusTooManyRequests, response.Code)
+	assert.Equal(t, "api.context.too_many_concurrent_requests.app_error", model.AppErrorFromJson(response.Body).Id)
+
+	// Requests without a session are counted by IP address instead
+	assert.Equal(t, http.StatusOK, serve("", "").Code)
+
+	finish <- true
+	assert.Equal(t, http.StatusOK, <-done)
+
+	assert.Equal(t, http.StatusOK, serve(session.Token, "").Code)
+
+	t.Run("requests that panic stop being counted", func(t *testing.T) {
+		panicking := web.NewHandler(func(c *Context, w http.ResponseWriter, r *http.Request) {
+			panic("handler panicked")
+		})
+
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		request.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+session.Token)
+		assert.Panics(t, func() { panicking.ServeHTTP(httptest.NewRecorder(), request) })
+
+		assert.Equal(t, http.StatusOK, serve(session.Token, "").Code)
+	})
+
+	t.Run("requests that time out are counted until their handler returns", func(t *testing.T) {
+		slow := &Handler{
+			GetGlobalAppOptions: web.GetGlobalAppOptions,
+			HandleFunc: func(c *Context, w http.ResponseWriter, r *http.Request) {
+				started <- true
+				<-finish
+			},
+			Timeout: 10 * time.Millisecond,
+		}
+
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		request.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+session.Token)
+		response := httptest.NewRecorder()
+
+		timedOut := make(chan bool)
+		go func() {
+			slow.ServeHTTP(response, request)
+			timedOut <- true
+		}()
+		<-started
+		<-timedOut
+		assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+
+		assert.Equal(t, http.StatusTooManyRequests, serve(session.Token, "").Code)
+
+		finish <- true
+		// The slot is released by the handler's goroutine once it returns, which the test can't wait for directly
+		code := serve(session.Token, "").Code
+		for i := 0; i < 100 && code != http.StatusOK; i++ {
+			time.Sleep(10 * time.Millisecond)
+			code = serve(session.Token, "").Code
+		}
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("no limit when set to zero", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.MaxConcurrentRequestsPerUser = 0 })
+
+		go func() {
+			done <- serve(session.Token, "?block=true").Code
+		}()
+		<-started
+
+		assert.Equal(t, http.StatusOK, serve(session.Token, "").Code)
+
+		finish <- true
+		assert.Equal(t, http.StatusOK, <-done)
+	})
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// contentEtag returns a strong ETag derived from a response body, so that it changes whenever anything in the
+// response does and stays the same across nodes and restarts otherwise.
+func contentEtag(body []byte) string {
+	hash := sha256.Sum256(body)
+	return formatContentEtag(hash[:])
+}
+
+func formatContentEtag(hash []byte) string {
+	return `"` + base64.RawURLEncoding.EncodeToString(hash) + `"`
+}
+
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteConditionally writes a response to a GET request along with ETag and Last-Modified validators, or only
+// 304 Not Modified if the client's copy is still current. The ETag is derived from the body, so it covers computed
+// fields as well. lastModified is the latest time in milliseconds at which anything in the response changed, or 0 if
+// that can't be known, in which case If-Modified-Since is ignored. As required by RFC 7232, If-Modified-Since is
+// only used when the request doesn't have an If-None-Match header.
+func (c *Context) WriteConditionally(body []byte, lastModified int64, routeName string, w http.ResponseWriter, r *http.Request) {
+	etag := contentEtag(body)
+	w.Header().Set(model.HEADER_ETAG_SERVER, etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Del("Expires")
+	if lastModified > 0 {
+		w.Header().Set("Last-Modified", time.Unix(0, lastModified*int64(time.Millisecond)).UTC().Format(http.TimeFormat))
+	}
+
+	notModified := false
+	if ifNoneMatch := r.Header.Get(model.HEADER_ETAG_CLIENT); len(ifNoneMatch) > 0 {
+		notModified = etagMatches(ifNoneMatch, etag)
+	} else if ifModifiedSince := r.Header.Get("If-Modified-Since"); len(ifModifiedSince) > 0 && lastModified > 0 {
+		if since, err := http.ParseTime(ifModifiedSince); err == nil {
+			// Last-Modified only has a precision of seconds
+			notModified = lastModified/1000 <= since.Unix()
+		}
+	}
+
+	if notModified {
+		w.WriteHeader(http.StatusNotModified)
+		if c.App.Metrics != nil {
+			c.App.Metrics.IncrementEtagHitCounter(routeName)
+		}
+		return
+	}
+
+	if c.App.Metrics != nil {
+		c.App.Metrics.IncrementEtagMissCounter(routeName)
+	}
+
+	w.Write(body)
+}
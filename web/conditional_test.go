@@ -0,0 +1,74 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-server/app"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestEtagMatches(t *testing.T) {
+	assert.True(t, etagMatches(`"abc"`, `"abc"`))
+	assert.True(t, etagMatches(`"xyz", W/"abc"`, `"abc"`))
+	assert.True(t, etagMatches(`*`, `"abc"`))
+	assert.False(t, etagMatches(``, `"abc"`))
+	assert.False(t, etagMatches(`"xyz"`, `"abc"`))
+}
+
+func TestWriteConditionally(t *testing.T) {
+	c := &Context{App: &app.App{}}
+	body := []byte(`{"id":"test"}`)
+	lastModified := int64(1546300800000) // 2019-01-01T00:00:00Z
+
+	write := func(headers map[string]string, lastModified int64) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/", nil)
+		for key, value := range headers {
+			r.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		c.WriteConditionally(body, lastModified, "Test", w, r)
+		return w
+	}
+
+	w := write(nil, lastModified)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, string(body), w.Body.String())
+	assert.Equal(t, "Tue, 01 Jan 2019 00:00:00 GMT", w.Header().Get("Last-Modified"))
+	etag := w.Header().Get(model.HEADER_ETAG_SERVER)
+	assert.NotEmpty(t, etag)
+
+	t.Run("if none match", func(t *testing.T) {
+		w := write(map[string]string{model.HEADER_ETAG_CLIENT: etag}, lastModified)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+
+		w = write(map[string]string{model.HEADER_ETAG_CLIENT: `"other"`}, lastModified)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("if modified since", func(t *testing.T) {
+		w := write(map[string]string{"If-Modified-Since": "Tue, 01 Jan 2019 00:00:00 GMT"}, lastModified)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+
+		w = write(map[string]string{"If-Modified-Since": "Tue, 01 Jan 2019 00:00:00 GMT"}, lastModified+1000)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("if none match takes precedence", func(t *testing.T) {
+		w := write(map[string]string{model.HEADER_ETAG_CLIENT: `"other"`, "If-Modified-Since": "Tue, 01 Jan 2019 00:00:00 GMT"}, lastModified)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("without a modification time", func(t *testing.T) {
+		w := write(map[string]string{"If-Modified-Since": "Tue, 01 Jan 2019 00:00:00 GMT"}, 0)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Last-Modified"))
+	})
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+const (
+	CONTENT_TYPE_JSON = "application/json"
+	CONTENT_TYPE_CSV  = "text/csv"
+)
+
+// NegotiateContentType picks which of the supported content types, the first of which is the default, to respond with
+// based on the Accept header of the request. If the client doesn't accept any of them, the default is used unless
+// ServiceSettings.RejectUnacceptableResponseTypes is set, in which case c.Err is set to a 406 instead.
+func (c *Context) NegotiateContentType(w http.ResponseWriter, r *http.Request, supported ...string) string {
+	w.Header().Add("Vary", "Accept")
+
+	if contentType, ok := utils.NegotiateContentType(r.Header.Get("Accept"), supported); ok {
+		return contentType
+	}
+
+	if *c.App.Config().ServiceSettings.RejectUnacceptableResponseTypes {
+		c.Err = model.NewAppError("NegotiateContentType", "api.context.not_acceptable.app_error", map[string]interface{}{"ContentTypes": strings.Join(supported, ", ")}, "", http.StatusNotAcceptable)
+		return ""
+	}
+
+	return supported[0]
+}
+
+// EtagForContentType distinguishes the ETag of a response by the content type it's written in, so that a client can't
+// be told that a cached response in one format is still valid for a request in another. JSON responses keep the ETag
+// as it is, so that existing clients' caches stay valid.
+func EtagForContentType(etag string, contentType string) string {
+	if etag == "" || contentType == CONTENT_TYPE_JSON {
+		return etag
+	}
+
+	return etag + "." + contentType
+}
+
+// WriteCsv writes a CSV response, replacing the JSON content type that API responses have by default.
+func WriteCsv(w http.ResponseWriter, csv string) {
+	w.Header().Set("Content-Type", CONTENT_TYPE_CSV+"; charset=utf-8")
+	w.Write([]byte(csv))
+}
@@ -4,6 +4,9 @@
 package web
 
 import (
+	"context"
+	"mime"
+	"net"
 	"net/http"
 	"path"
 	"regexp"
@@ -12,6 +15,7 @@ import (
 	"github.com/mattermost/mattermost-server/app"
 	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/services/tracing"
 	"github.com/mattermost/mattermost-server/utils"
 )
 
@@ -21,6 +25,36 @@ type Context struct {
 	Params        *Params
 	Err           *model.AppError
 	siteURLHeader string
+	realClientIP  string
+
+	// CspNonce is generated for each response of a static handler and allowed by its Content-Security-Policy, so that
+	// inline scripts rendered into the page can be tagged with it.
+	CspNonce string
+
+	// Span traces the handling of the request, and is nil unless tracing is enabled and the request was sampled.
+	Span *tracing.Span
+
+	// Ctx is the context of the request, which is cancelled once the request times out. Handlers that make slow calls
+	// should pass it along or check it, since their response is discarded after the timeout anyway.
+	Ctx context.Context
+
+	// timedOut is set when the handler didn't respond before the request timed out.
+	timedOut bool
+
+	// releaseConcurrentRequest stops counting the request against ServiceSettings.MaxConcurrentRequestsPerUser. It's
+	// taken over by the handler when the request times out, since the handler keeps running until it returns.
+	releaseConcurrentRequest func()
+
+	// locale is the locale of the response once it has been resolved by Locale.
+	locale string
+
+	// isMobileApp is whether the request was made by one of the mobile apps, as detected when the request started.
+	isMobileApp bool
+
+	// channelMember and teamMember are the memberships of the session's user that were loaded by
+	// RequireChannelMember and RequireTeamMember, so that they're only looked up once per request.
+	channelMember *model.ChannelMember
+	teamMember    *model.TeamMember
 }
 
 func (c *Context) LogAudit(extraInfo string) {
@@ -81,10 +115,21 @@ func (c *Context) LogDebug(err *model.AppError) {
 	)
 }
 
+// RealClientIP returns the IP address of the client that made the request, looking past the server's trusted proxies.
+func (c *Context) RealClientIP() string {
+	return c.realClientIP
+}
+
 func (c *Context) IsSystemAdmin() bool {
 	return c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_SYSTEM)
 }
 
+// IsMobileApp returns whether the request was made by one of the mobile apps, for handlers whose responses differ for
+// them, such as by rendering errors as JSON rather than redirecting to an error page.
+func (c *Context) IsMobileApp() bool {
+	return c.isMobileApp
+}
+
 func (c *Context) SessionRequired() {
 	if !*c.App.Config().ServiceSettings.EnableUserAccessTokens && c.App.Session.Props[model.SESSION_PROP_TYPE] == model.SESSION_TYPE_USER_ACCESS_TOKEN {
 		c.Err = model.NewAppError("", "api.context.session_expired.app_error", nil, "UserAccessToken", http.StatusUnauthorized)
@@ -97,6 +142,147 @@ func (c *Context) SessionRequired() {
 	}
 }
 
+// RequireChannelMember rejects the request unless the user of the session is a member of the channel in the request's
+// channel_id and has the given permission in it, whether through their channel, team or system roles. It fails with a
+// 401 without a session and a 403 otherwise. A nil permission only requires membership. The membership is then
+// available from ChannelMember for the rest of the request.
+func (c *Context) RequireChannelMember(permission *model.Permission) *Context {
+	c.RequireChannelId()
+	if c.Err != nil {
+		return c
+	}
+
+	if c.App.Session.UserId == "" {
+		c.Err = model.NewAppError("RequireChannelMember", "api.context.session_expired.app_error", nil, "UserRequired", http.StatusUnauthorized)
+		return c
+	}
+
+	member := c.channelMember
+	if member == nil || member.ChannelId != c.Params.ChannelId {
+		var err *model.AppError
+		if member, err = c.App.GetChannelMember(c.Params.ChannelId, c.App.Session.UserId); err != nil {
+			if err.StatusCode != http.StatusNotFound {
+				c.Err = err
+				return c
+			}
+
+			c.setMembershipError("RequireChannelMember", permission)
+			return c
+		}
+		c.channelMember = member
+	}
+
+	if permission == nil || c.App.RolesGrantPermission(member.GetRoles(), permission.Id) {
+		return c
+	}
+
+	channel, err := c.App.GetChannel(c.Params.ChannelId)
+	if err != nil {
+		c.Err = err
+		return c
+	}
+
+	if channel.TeamId != "" {
+		if !c.App.SessionHasPermissionToTeam(c.App.Session, channel.TeamId, permission) {
+			c.SetPermissionError(permission)
+		}
+	} else if !c.App.SessionHasPermissionTo(c.App.Session, permission) {
+		c.SetPermissionError(permission)
+	}
+
+	return c
+}
+
+// RequireTeamMember is like RequireChannelMember for the team in the request's team_id, where the permission may be
+// granted by the user's team or system roles. The membership is then available from TeamMember.
+func (c *Context) RequireTeamMember(permission *model.Permission) *Context {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return c
+	}
+
+	if c.App.Session.UserId == "" {
+		c.Err = model.NewAppError("RequireTeamMember", "api.context.session_expired.app_error", nil, "UserRequired", http.StatusUnauthorized)
+		return c
+	}
+
+	member := c.teamMember
+	if member == nil || member.TeamId != c.Params.TeamId {
+		var err *model.AppError
+		if member, err = c.App.GetTeamMember(c.Params.TeamId, c.App.Session.UserId); err != nil {
+			if err.StatusCode != http.StatusNotFound {
+				c.Err = err
+				return c
+			}
+
+			c.setMembershipError("RequireTeamMember", permission)
+			return c
+		}
+
+		// Users who have left a team keep a deleted membership of it
+		if member.DeleteAt != 0 {
+			c.setMembershipError("RequireTeamMember", permission)
+			return c
+		}
+		c.teamMember = member
+	}
+
+	if permission == nil || c.App.RolesGrantPermission(member.GetRoles(), permission.Id) {
+		return c
+	}
+
+	if !c.App.SessionHasPermissionTo(c.App.Session, permission) {
+		c.SetPermissionError(permission)
+	}
+
+	return c
+}
+
+// ChannelMember returns the membership of the session's user in the request's channel, once it has been checked by
+// RequireChannelMember.
+func (c *Context) ChannelMember() *model.ChannelMember {
+	return c.channelMember
+}
+
+// TeamMember returns the membership of the session's user in the request's team, once it has been checked by
+// RequireTeamMember.
+func (c *Context) TeamMember() *model.TeamMember {
+	return c.teamMember
+}
+
+func (c *Context) setMembershipError(where string, permission *model.Permission) {
+	if permission != nil {
+		c.SetPermissionError(permission)
+		return
+	}
+
+	c.Err = model.NewAppError(where, "api.context.membership_required.app_error", nil, "userId="+c.App.Session.UserId, http.StatusForbidden)
+}
+
+// SystemAdminRequired rejects requests that weren't made by a system admin, with a 401 if there's no session so that
+// clients can tell that they need to log in, and a 403 otherwise. Every attempt is audited, including those that were
+// already rejected, such as for having an expired session, since they were still attempts at an admin action.
+func (c *Context) SystemAdminRequired(r *http.Request) {
+	route := routeTemplate(r)
+	if route == "" {
+		route = r.URL.Path
+	}
+
+	if c.Err == nil {
+		if len(c.App.Session.UserId) == 0 {
+			c.Err = model.NewAppError("SystemAdminRequired", "api.context.session_expired.app_error", nil, "SystemAdminRequired", http.StatusUnauthorized)
+		} else if !c.IsSystemAdmin() {
+			c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		}
+	}
+
+	result := "allowed"
+	if c.Err != nil {
+		result = "denied"
+	}
+	c.LogAudit("system_admin_required method=" + r.Method + " route=" + route + " result=" + result)
+}
+
 func (c *Context) MfaRequired() {
 	// Must be licensed for MFA and have it configured for enforcement
 	if license := c.App.License(); license == nil || !*license.Features.MFA || !*c.App.Config().ServiceSettings.EnableMultifactorAuthentication || !*c.App.Config().ServiceSettings.EnforceMultifactorAuthentication {
@@ -132,6 +318,68 @@ func (c *Context) MfaRequired() {
 	}
 }
 
+// RequireConnectionSecurity rejects a request that wasn't made over HTTPS, either directly or through a proxy that set
+// X-Forwarded-Proto, when the site is meant to be served over HTTPS. That usually means TLS offloading has been
+// misconfigured, so sensitive endpoints such as logins fail instead of silently working over plain HTTP. Requests from
+// the internal addresses listed in ServiceSettings.AllowedUntrustedInternalConnections are allowed regardless.
+func (c *Context) RequireConnectionSecurity(r *http.Request) {
+	if !strings.HasPrefix(strings.ToLower(*c.App.Config().ServiceSettings.SiteURL), "https://") || app.GetProtocol(r) == "https" {
+		return
+	}
+
+	if isAllowedInternalConnection(c.App.IpAddress, *c.App.Config().ServiceSettings.AllowedUntrustedInternalConnections) {
+		return
+	}
+
+	c.Err = model.NewAppError("RequireConnectionSecurity", "api.context.insecure_connection.app_error", nil, "forwarded_proto="+r.Header.Get(model.HEADER_FORWARDED_PROTO), http.StatusBadRequest)
+}
+
+// RequireJSONContentType rejects a request with a body whose Content-Type isn't JSON, so that a handler never tries to
+// decode a form or anything else as JSON and fails in some confusing way later on. Requests without a body, such as
+// most GETs and DELETEs, are allowed regardless.
+func (c *Context) RequireJSONContentType(r *http.Request) {
+	if r.ContentLength == 0 {
+		return
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == CONTENT_TYPE_JSON {
+		return
+	}
+
+	c.Err = model.NewAppError("RequireJSONContentType", "api.context.unsupported_content_type.app_error", nil, "content_type="+contentType, http.StatusUnsupportedMediaType)
+}
+
+// isAllowedInternalConnection returns whether the given IP address matches one of the addresses or CIDR ranges in a
+// whitespace-separated list such as ServiceSettings.AllowedUntrustedInternalConnections.
+func isAllowedInternalConnection(ipAddress string, allowed string) bool {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return false
+	}
+
+	for _, entry := range strings.Fields(allowed) {
+		if entry == ipAddress {
+			return true
+		}
+
+		if _, ipRange, err := net.ParseCIDR(entry); err == nil && ipRange.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CustomMfaRequired is used in place of MfaRequired when a custom MFA verifier has been configured, leaving it up to
+// the verifier to decide whether the session has completed its second factor.
+func (c *Context) CustomMfaRequired(r *http.Request) {
+	session := c.App.Session
+	if err := c.App.MfaVerifier.Verify(&session, r); err != nil {
+		c.Err = err
+	}
+}
+
 func (c *Context) RemoveSessionCookie(w http.ResponseWriter, r *http.Request) {
 	cookie := &http.Cookie{
 		Name:     model.SESSION_COOKIE_TOKEN,
@@ -140,8 +388,8 @@ func (c *Context) RemoveSessionCookie(w http.ResponseWriter, r *http.Request) {
 		MaxAge:   -1,
 		HttpOnly: true,
 	}
-
-	http.SetCookie(w, cookie)
+	// The cookie is only removed if its attributes match the ones it was set with
+	c.App.SetSessionCookie(w, r, cookie)
 }
 
 func (c *Context) SetInvalidParam(parameter string) {
@@ -230,6 +478,17 @@ func (c *Context) RequireInviteId() *Context {
 	return c
 }
 
+func (c *Context) RequireTemplateId() *Context {
+	if c.Err != nil {
+		return c
+	}
+
+	if len(c.Params.TemplateId) != 26 {
+		c.SetInvalidUrlParam("template_id")
+	}
+	return c
+}
+
 func (c *Context) RequireTokenId() *Context {
 	if c.Err != nil {
 		return c
@@ -2,7 +2,14 @@ package web
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
 )
 
 func TestRequireHookId(t *testing.T) {
@@ -29,3 +36,248 @@ func TestRequireHookId(t *testing.T) {
 		}
 	})
 }
+
+func TestRequireConnectionSecurity(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.SiteURL = "https://mattermost.example.com"
+		*cfg.ServiceSettings.AllowedUntrustedInternalConnections = "10.0.0.0/8 192.168.1.5"
+	})
+
+	for name, tc := range map[string]struct {
+		ForwardedProto string
+		IpAddress      string
+		Allowed        bool
+	}{
+		"https through a proxy":            {ForwardedProto: "https", IpAddress: "1.2.3.4", Allowed: true},
+		"plain http":                       {IpAddress: "1.2.3.4", Allowed: false},
+		"http through a proxy":             {ForwardedProto: "http", IpAddress: "1.2.3.4", Allowed: false},
+		"plain http from an allowed range": {IpAddress: "10.1.2.3", Allowed: true},
+		"plain http from an allowed host":  {IpAddress: "192.168.1.5", Allowed: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			th.App.IpAddress = tc.IpAddress
+			c := &Context{App: th.App}
+
+			r := httptest.NewRequest("GET", "/login/sso/saml", nil)
+			if tc.ForwardedProto != "" {
+				r.Header.Set(model.HEADER_FORWARDED_PROTO, tc.ForwardedProto)
+			}
+
+			c.RequireConnectionSecurity(r)
+			if tc.Allowed {
+				assert.Nil(t, c.Err)
+			} else {
+				require.NotNil(t, c.Err)
+				assert.Equal(t, "api.context.insecure_connection.app_error", c.Err.Id)
+				assert.Equal(t, http.StatusBadRequest, c.Err.StatusCode)
+			}
+		})
+	}
+
+	t.Run("sites that aren't served over https are unaffected", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.SiteURL = "http://mattermost.example.com"
+		})
+		th.App.IpAddress = "1.2.3.4"
+		c := &Context{App: th.App}
+
+		c.RequireConnectionSecurity(httptest.NewRequest("GET", "/login/sso/saml", nil))
+		assert.Nil(t, c.Err)
+	})
+}
+
+func TestRequireJSONContentType(t *testing.T) {
+	for name, tc := range map[string]struct {
+		Method      string
+		Body        string
+		ContentType string
+		Allowed     bool
+	}{
+		"json":                  {Method: "POST", Body: "{}", ContentType: "application/json", Allowed: true},
+		"json with a charset":   {Method: "PUT", Body: "{}", ContentType: "application/json; charset=utf-8", Allowed: true},
+		"form":                  {Method: "POST", Body: "a=b", ContentType: "application/x-www-form-urlencoded", Allowed: false},
+		"multipart form":        {Method: "POST", Body: "--x--", ContentType: "Multipart/Form-Data; boundary=x", Allowed: false},
+		"json in upper case":    {Method: "POST", Body: "{}", ContentType: "Application/JSON", Allowed: true},
+		"plain text":            {Method: "POST", Body: "{}", ContentType: "text/plain;charset=UTF-8", Allowed: false},
+		"missing":               {Method: "POST", Body: "{}", Allowed: false},
+		"malformed":             {Method: "POST", Body: "{}", ContentType: "application/json; =", Allowed: false},
+		"get without a body":    {Method: "GET", Allowed: true},
+		"delete without a body": {Method: "DELETE", Allowed: true},
+		"post without a body":   {Method: "POST", ContentType: "application/x-www-form-urlencoded", Allowed: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := &Context{}
+
+			r := httptest.NewRequest(tc.Method, "/api/v4/test", strings.NewReader(tc.Body))
+			if tc.ContentType != "" {
+				r.Header.Set("Content-Type", tc.ContentType)
+			}
+
+			c.RequireJSONContentType(r)
+			if tc.Allowed {
+				assert.Nil(t, c.Err)
+			} else {
+				require.NotNil(t, c.Err)
+				assert.Equal(t, "api.context.unsupported_content_type.app_error", c.Err.Id)
+				assert.Equal(t, http.StatusUnsupportedMediaType, c.Err.StatusCode)
+			}
+		})
+	}
+}
+
+func TestPagination(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.MaximumPerPage = 100
+	})
+
+	for name, tc := range map[string]struct {
+		Query    string
+		Expected Pagination
+		Invalid  bool
+	}{
+		"defaults":           {Query: "", Expected: Pagination{Page: 0, PerPage: PER_PAGE_DEFAULT, Offset: 0}},
+		"page":               {Query: "page=2&per_page=10", Expected: Pagination{Page: 2, PerPage: 10, Offset: 20}},
+		"capped per page":    {Query: "page=1&per_page=1000000", Expected: Pagination{Page: 1, PerPage: 100, Offset: 100}},
+		"empty page":         {Query: "per_page=0", Expected: Pagination{Page: 0, PerPage: 0, Offset: 0}},
+		"malformed page":     {Query: "page=first", Invalid: true},
+		"negative page":      {Query: "page=-1", Invalid: true},
+		"malformed per page": {Query: "per_page=1.5", Invalid: true},
+		"negative per page":  {Query: "per_page=-10", Invalid: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := &Context{App: th.App, Params: ParamsFromRequest(httptest.NewRequest("GET", "/api/v4/users?"+tc.Query, nil))}
+
+			pagination := c.Pagination()
+			if !tc.Invalid {
+				require.Nil(t, c.Err)
+				assert.Equal(t, tc.Expected, pagination)
+			} else {
+				require.NotNil(t, c.Err)
+				assert.Equal(t, http.StatusBadRequest, c.Err.StatusCode)
+				assert.Equal(t, "api.context.invalid_url_param.app_error", c.Err.Id)
+			}
+		})
+	}
+
+	t.Run("from values", func(t *testing.T) {
+		c := &Context{App: th.App}
+		assert.Equal(t, Pagination{Page: 3, PerPage: 100, Offset: 300}, c.NewPagination(3, 500))
+		assert.Nil(t, c.Err)
+
+		c.NewPagination(-1, 10)
+		require.NotNil(t, c.Err)
+		assert.Equal(t, "api.context.invalid_body_param.app_error", c.Err.Id)
+	})
+}
+
+func TestRequireChannelMember(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	newContext := func(user *model.User, channelId string) *Context {
+		c := &Context{
+			App:    th.App,
+			Params: &Params{ChannelId: channelId},
+		}
+		if user != nil {
+			c.App.Session = model.Session{UserId: user.Id, Roles: user.GetRawRoles()}
+		} else {
+			c.App.Session = model.Session{}
+		}
+		return c
+	}
+
+	t.Run("member with permission", func(t *testing.T) {
+		c := newContext(th.BasicUser, th.BasicChannel.Id)
+		c.RequireChannelMember(model.PERMISSION_READ_CHANNEL)
+		require.Nil(t, c.Err)
+		require.NotNil(t, c.ChannelMember())
+		assert.Equal(t, th.BasicUser.Id, c.ChannelMember().UserId)
+
+		// The membership is reused by later checks
+		c.RequireChannelMember(model.PERMISSION_CREATE_POST)
+		assert.Nil(t, c.Err)
+	})
+
+	t.Run("member without permission", func(t *testing.T) {
+		c := newContext(th.BasicUser, th.BasicChannel.Id)
+		c.RequireChannelMember(model.PERMISSION_MANAGE_SYSTEM)
+		require.NotNil(t, c.Err)
+		assert.Equal(t, http.StatusForbidden, c.Err.StatusCode)
+		assert.Equal(t, "api.context.permissions.app_error", c.Err.Id)
+	})
+
+	t.Run("non-member", func(t *testing.T) {
+		c := newContext(th.SystemAdminUser, th.BasicChannel.Id)
+		c.RequireChannelMember(nil)
+		require.NotNil(t, c.Err)
+		assert.Equal(t, http.StatusForbidden, c.Err.StatusCode)
+		assert.Equal(t, "api.context.membership_required.app_error", c.Err.Id)
+		assert.Nil(t, c.ChannelMember())
+	})
+
+	t.Run("no session", func(t *testing.T) {
+		c := newContext(nil, th.BasicChannel.Id)
+		c.RequireChannelMember(model.PERMISSION_READ_CHANNEL)
+		require.NotNil(t, c.Err)
+		assert.Equal(t, http.StatusUnauthorized, c.Err.StatusCode)
+	})
+
+	t.Run("invalid channel id", func(t *testing.T) {
+		c := newContext(th.BasicUser, "junk")
+		c.RequireChannelMember(model.PERMISSION_READ_CHANNEL)
+		require.NotNil(t, c.Err)
+		assert.Equal(t, http.StatusBadRequest, c.Err.StatusCode)
+	})
+}
+
+func TestRequireTeamMember(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	newContext := func(user *model.User) *Context {
+		c := &Context{
+			App:    th.App,
+			Params: &Params{TeamId: th.BasicTeam.Id},
+		}
+		c.App.Session = model.Session{UserId: user.Id, Roles: user.GetRawRoles()}
+		return c
+	}
+
+	t.Run("member with permission", func(t *testing.T) {
+		c := newContext(th.BasicUser)
+		c.RequireTeamMember(model.PERMISSION_VIEW_TEAM)
+		require.Nil(t, c.Err)
+		require.NotNil(t, c.TeamMember())
+		assert.Equal(t, th.BasicUser.Id, c.TeamMember().UserId)
+	})
+
+	t.Run("member without permission", func(t *testing.T) {
+		c := newContext(th.BasicUser)
+		c.RequireTeamMember(model.PERMISSION_MANAGE_TEAM)
+		require.NotNil(t, c.Err)
+		assert.Equal(t, http.StatusForbidden, c.Err.StatusCode)
+	})
+
+	t.Run("system admins still need to be members", func(t *testing.T) {
+		c := newContext(th.SystemAdminUser)
+		c.RequireTeamMember(model.PERMISSION_VIEW_TEAM)
+		require.NotNil(t, c.Err)
+		assert.Equal(t, http.StatusForbidden, c.Err.StatusCode)
+	})
+
+	t.Run("former member", func(t *testing.T) {
+		require.Nil(t, th.App.LeaveTeam(th.BasicTeam, th.BasicUser, th.BasicUser.Id))
+
+		c := newContext(th.BasicUser)
+		c.RequireTeamMember(nil)
+		require.NotNil(t, c.Err)
+		assert.Equal(t, "api.context.membership_required.app_error", c.Err.Id)
+	})
+}
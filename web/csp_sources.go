@@ -0,0 +1,62 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"sync"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+// cspScriptSourcesCache holds the script-src sources of each combination of subpath and analytics source, up to
+// ServiceSettings.CspScriptSourcesCacheSize of them. It's replaced by an empty cache of the new size when that changes.
+var cspScriptSourcesCache struct {
+	mutex sync.Mutex
+	size  int
+	cache *utils.Cache
+}
+
+// getCspScriptSourcesCache returns the cache of script-src sources, rebuilding it first if the configured size has
+// changed.
+func getCspScriptSourcesCache(config *model.Config) *utils.Cache {
+	size := *config.ServiceSettings.CspScriptSourcesCacheSize
+
+	cspScriptSourcesCache.mutex.Lock()
+	defer cspScriptSourcesCache.mutex.Unlock()
+
+	if cspScriptSourcesCache.cache == nil || cspScriptSourcesCache.size != size {
+		cspScriptSourcesCache.size = size
+		cspScriptSourcesCache.cache = utils.NewLru(size)
+	}
+
+	return cspScriptSourcesCache.cache
+}
+
+// cspScriptSourcesKey is everything that the script-src sources of static content are built from, so that a config
+// change never finds the sources of the old config, which are instead evicted once they're least recently used.
+type cspScriptSourcesKey struct {
+	subpath         string
+	analyticsSource string
+}
+
+// cspScriptSources returns the sources, other than 'self' and the nonce, that the Content-Security-Policy of static
+// content allows scripts from: analytics, when it's enabled, and the script that sets the subpath that the static
+// assets were rewritten for.
+func cspScriptSources(subpath string, config *model.Config) string {
+	key := cspScriptSourcesKey{
+		subpath:         subpath,
+		analyticsSource: cspAnalyticsSource(config),
+	}
+
+	cache := getCspScriptSourcesCache(config)
+	if sources, ok := cache.Get(key); ok {
+		return sources.(string)
+	}
+
+	sources := key.analyticsSource + utils.GetSubpathScriptHash(subpath)
+	cache.Add(key, sources)
+
+	return sources
+}
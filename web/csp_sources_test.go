@@ -0,0 +1,72 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+func TestCspScriptSources(t *testing.T) {
+	config := &model.Config{}
+	config.SetDefaults()
+
+	getCspScriptSourcesCache(config).Purge()
+	defer getCspScriptSourcesCache(config).Purge()
+	*config.LogSettings.EnableDiagnostics = true
+	*config.ServiceSettings.AnalyticsCdnHost = "cdn.example.com"
+
+	t.Run("the sources are the same as they're built from", func(t *testing.T) {
+		for _, subpath := range []string{"", "/", "/subpath"} {
+			expected := cspAnalyticsSource(config) + utils.GetSubpathScriptHash(subpath)
+
+			assert.Equal(t, expected, cspScriptSources(subpath, config))
+			assert.Equal(t, expected, cspScriptSources(subpath, config), "cached sources should be unchanged")
+		}
+	})
+
+	t.Run("config changes are followed", func(t *testing.T) {
+		assert.Contains(t, cspScriptSources("/subpath", config), " cdn.example.com/analytics.js/")
+
+		*config.LogSettings.EnableDiagnostics = false
+		assert.Equal(t, utils.GetSubpathScriptHash("/subpath"), cspScriptSources("/subpath", config))
+
+		*config.LogSettings.EnableDiagnostics = true
+		*config.ServiceSettings.AnalyticsCdnHost = "other.example.com"
+		assert.Contains(t, cspScriptSources("/subpath", config), " other.example.com/analytics.js/")
+	})
+
+	t.Run("the cache stays bounded", func(t *testing.T) {
+		size := *config.ServiceSettings.CspScriptSourcesCacheSize
+		for i := 0; i < 10*size; i++ {
+			subpath := fmt.Sprintf("/subpath%d", i)
+			assert.Equal(t, cspAnalyticsSource(config)+utils.GetSubpathScriptHash(subpath), cspScriptSources(subpath, config))
+			assert.True(t, getCspScriptSourcesCache(config).Len() <= size)
+		}
+	})
+
+	t.Run("the cache is resized when the config changes", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			cspScriptSources(fmt.Sprintf("/subpath%d", i), config)
+		}
+		assert.Equal(t, *config.ServiceSettings.CspScriptSourcesCacheSize, getCspScriptSourcesCache(config).Len())
+
+		*config.ServiceSettings.CspScriptSourcesCacheSize = 2
+		defer func() {
+			*config.ServiceSettings.CspScriptSourcesCacheSize = model.SERVICE_SETTINGS_DEFAULT_CSP_SCRIPT_SOURCES_CACHE_SIZE
+		}()
+
+		assert.Equal(t, 0, getCspScriptSourcesCache(config).Len())
+		for i := 0; i < 10; i++ {
+			subpath := fmt.Sprintf("/subpath%d", i)
+			assert.Equal(t, cspAnalyticsSource(config)+utils.GetSubpathScriptHash(subpath), cspScriptSources(subpath, config))
+			assert.True(t, getCspScriptSourcesCache(config).Len() <= 2)
+		}
+	})
+}
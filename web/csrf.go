@@ -0,0 +1,38 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// isCsrfSafeMethod returns whether requests with the given method are never expected to change state, and so don't
+// need to carry a CSRF token.
+func isCsrfSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// CsrfTokenRequired validates the CSRF token of a request that was authenticated by the session cookie. In double
+// submit mode, state changing requests must echo the token of the CSRF cookie in the X-CSRF-Token header. The token of
+// the session is rotated as configured, and the CSRF cookie is refreshed when the browser doesn't have the current one.
+func (c *Context) CsrfTokenRequired(w http.ResponseWriter, r *http.Request) {
+	if *c.App.Config().ServiceSettings.CsrfProtectionMode == model.CSRF_PROTECTION_MODE_DOUBLE_SUBMIT && !isCsrfSafeMethod(r.Method) {
+		headerToken := r.Header.Get(model.HEADER_CSRF_TOKEN)
+
+		cookieToken := ""
+		if cookie, err := r.Cookie(model.SESSION_COOKIE_CSRF); err == nil {
+			cookieToken = cookie.Value
+		}
+
+		if headerToken == "" || subtle.ConstantTimeCompare([]byte(headerToken), []byte(cookieToken)) != 1 || !c.App.Session.IsValidCSRF(headerToken) {
+			c.Err = model.NewAppError("CsrfTokenRequired", "api.context.session_expired.app_error", nil, "Appears to be a CSRF attempt, the X-CSRF-Token header doesn't match the CSRF token of the session", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	c.App.RefreshCsrfToken(w, r, &c.App.Session)
+}
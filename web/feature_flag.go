@@ -0,0 +1,28 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// FeatureFlagged puts the endpoint of a handler behind a feature flag when registering its route, see
+// Handler.FeatureFlag. The handler must be one of those provided by Web or the API.
+func FeatureFlagged(flag string, handler http.Handler) http.Handler {
+	h, ok := handler.(*Handler)
+	if !ok {
+		panic(fmt.Sprintf("only a *web.Handler can be put behind feature flag %v, not a %T", flag, handler))
+	}
+
+	h.FeatureFlag = flag
+	return h
+}
+
+// isDisabledByFeatureFlag returns whether the handler's endpoint is behind a feature flag that isn't enabled.
+func (h Handler) isDisabledByFeatureFlag(config *model.Config) bool {
+	return h.FeatureFlag != "" && !config.FeatureFlagSettings.IsEnabled(h.FeatureFlag)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestHandlerServeHTTPFeatureFlag(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	handled := 0
+	handler := FeatureFlagged("new_endpoint", web.NewHandler(func(c *Context, w http.ResponseWriter, r *http.Request) {
+		handled++
+	}))
+
+	serve := func() *httptest.ResponseRecorder {
+		request := httptest.NewRequest("GET", "/api/v4/new", nil)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response
+	}
+
+	response := serve()
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.Equal(t, "api.context.404.app_error", model.AppErrorFromJson(response.Body).Id)
+	assert.Equal(t, 0, handled, "the endpoint shouldn't be reached while its flag is disabled")
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		cfg.FeatureFlagSettings.Flags["new_endpoint"] = true
+	})
+
+	response = serve()
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, 1, handled)
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		cfg.FeatureFlagSettings.Flags["new_endpoint"] = false
+	})
+
+	response = serve()
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.Equal(t, 1, handled)
+}
+
+func TestFeatureFlagged(t *testing.T) {
+	handler := FeatureFlagged("new_endpoint", &Handler{})
+	assert.Equal(t, "new_endpoint", handler.(*Handler).FeatureFlag)
+
+	assert.Panics(t, func() {
+		FeatureFlagged("new_endpoint", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	})
+}
@@ -4,16 +4,25 @@
 package web
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/mattermost/mattermost-server/app"
 	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/model"
 	"github.com/mattermost/mattermost-server/utils"
 )
 
+// DRAINING_RETRY_AFTER_SECONDS is how long clients are asked to wait before retrying requests that are rejected
+// because the server is shutting down, by which time a load balancer should be routing them to another server.
+const DRAINING_RETRY_AFTER_SECONDS = 5
+
 func (w *Web) NewHandler(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
 	return &Handler{
 		GetGlobalAppOptions: w.GetGlobalAppOptions,
@@ -25,9 +34,23 @@ func (w *Web) NewHandler(h func(*Context, http.ResponseWriter, *http.Request)) h
 	}
 }
 
+// NewSecureHandler provides a handler like NewHandler for sensitive endpoints, such as logins, that must not be served
+// over plain HTTP when the site is meant to be served over HTTPS.
+func (w *Web) NewSecureHandler(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
+	return &Handler{
+		GetGlobalAppOptions:     w.GetGlobalAppOptions,
+		HandleFunc:              h,
+		RequireSession:          false,
+		TrustRequester:          false,
+		RequireMfa:              false,
+		IsStatic:                false,
+		RequireSecureConnection: true,
+	}
+}
+
 func (w *Web) NewStaticHandler(h func(*Context, http.ResponseWriter, *http.Request)) http.Handler {
-	// Determine the CSP SHA directive needed for subpath support, if any. This value is fixed
-	// on server start and intentionally requires a restart to take effect.
+	// The subpath that the static assets were rewritten for on server start, which the CSP SHA directive needed for
+	// subpath support, if any, is computed from. It intentionally requires a restart to take effect.
 	subpath, _ := utils.GetSubpathFromConfig(w.ConfigService.Config())
 
 	return &Handler{
@@ -38,7 +61,7 @@ func (w *Web) NewStaticHandler(h func(*Context, http.ResponseWriter, *http.Reque
 		RequireMfa:          false,
 		IsStatic:            true,
 
-		cspShaDirective: utils.GetSubpathScriptHash(subpath),
+		cspSubpath: subpath,
 	}
 }
 
@@ -50,7 +73,47 @@ type Handler struct {
 	RequireMfa          bool
 	IsStatic            bool
 
-	cspShaDirective string
+	// RequireSystemAdmin rejects requests from anyone but system admins and audits every attempt, see
+	// Context.SystemAdminRequired.
+	RequireSystemAdmin bool
+
+	// RequireSecureConnection rejects requests that weren't made over HTTPS when the site is meant to be served over it,
+	// see Context.RequireConnectionSecurity.
+	RequireSecureConnection bool
+
+	// Critical handlers, such as health checks and the WebSocket, are never rejected while the server is shedding load.
+	Critical bool
+
+	// RequireJSONBody rejects requests with a body that isn't declared to be JSON, see Context.RequireJSONContentType.
+	// Handlers that accept files or forms must leave it unset.
+	RequireJSONBody bool
+
+	// IsSearch handlers run searches, which are limited by SearchRateLimitSettings before they reach the store.
+	IsSearch bool
+
+	// MaxBodyBytes is the largest request body that the handler accepts, or DEFAULT_MAX_BODY_BYTES if it isn't set.
+	// Larger bodies are rejected with a 413.
+	MaxBodyBytes int64
+
+	// IsUpload handlers accept files, so their request bodies may also be as large as FileSettings.MaxFileSize.
+	IsUpload bool
+
+	// Timeout overrides ServiceSettings.RequestTimeout for the handler. Unlike the default, it also applies to uploads.
+	Timeout time.Duration
+
+	// ResponseCache is the name of the endpoint, one of model.RESPONSE_CACHE_ENDPOINT_*, under which successful GET
+	// responses are cached when it's listed in ServiceSettings.ResponseCacheEndpoints.
+	ResponseCache string
+
+	// Idempotent handlers only handle a user's request once for each Idempotency-Key header that it's made with, so
+	// that clients can safely retry requests that change something, see serveIdempotent.
+	Idempotent bool
+
+	// FeatureFlag is the name of the flag in FeatureFlagSettings that the endpoint is behind, if any. Until the flag is
+	// enabled, requests to the endpoint are answered as if it didn't exist.
+	FeatureFlag string
+
+	cspSubpath string
 }
 
 func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -64,11 +127,77 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	c.App.T, _ = utils.GetTranslationsAndLocale(w, r)
 	c.App.RequestId = model.NewId()
 	c.App.IpAddress = utils.GetIpAddress(r)
+	c.realClientIP = c.App.Srv.RealClientIpAddress(r)
 	c.App.UserAgent = r.UserAgent()
 	c.App.AcceptLanguage = r.Header.Get("Accept-Language")
 	c.Params = ParamsFromRequest(r)
 	c.App.Path = r.URL.Path
 	c.Log = c.App.Log
+	c.isMobileApp = IsMobileAppRequest(r)
+
+	// Checked on every request so that flags can be flipped without a restart
+	if h.isDisabledByFeatureFlag(c.App.Config()) {
+		Handle404(c.App, w, r)
+		return
+	}
+
+	timeout := h.requestTimeout(c.App.Config(), r)
+	if timeout > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
+	c.Ctx = r.Context()
+	c.App.Context = c.Ctx
+
+	c.Span = c.App.Srv.Tracer().StartRequestSpan(r)
+	if c.Span != nil {
+		c.App.Span = c.Span
+		defer endRequestSpan(c, r)
+	}
+
+	if *c.App.Config().ServiceSettings.EnableAccessLogging {
+		accessLogWriter := &accessLogResponseWriter{ResponseWriter: w}
+		w = accessLogWriter
+		defer logAccess(c, r, accessLogWriter, now)
+	}
+
+	if threshold := h.slowRequestThreshold(c.App.Config(), r); threshold > 0 {
+		defer logSlowRequest(c, r, now, threshold)
+	}
+
+	// Compressing below the writers that transform the response, such as for camelCase, means that it's the final
+	// response that's compressed, while the access log still records its status and compressed size. Static files are
+	// compressed according to ServiceSettings.WebserverMode instead.
+	if *c.App.Config().ServiceSettings.EnableResponseCompression && !h.IsStatic && !websocket.IsWebSocketUpgrade(r) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		if acceptsGzip(r) {
+			compressingWriter := &compressingResponseWriter{
+				ResponseWriter: w,
+				minSize:        *c.App.Config().ServiceSettings.ResponseCompressionMinSize,
+			}
+			w = compressingWriter
+			defer compressingWriter.finish()
+		}
+	}
+
+	// JSON is written with snake_case field names and converted afterwards for clients that ask for camelCase, which
+	// is also reflected in the ETags that they send and receive
+	if !h.IsStatic && !websocket.IsWebSocketUpgrade(r) && requestedJsonFieldNaming(r) == model.JSON_FIELD_NAMING_CAMEL_CASE {
+		stripCamelCaseEtagSuffixes(r)
+		camelCaseWriter := &camelCaseResponseWriter{ResponseWriter: w}
+		w = camelCaseWriter
+		defer camelCaseWriter.finish()
+		w.Header().Add("Vary", "Accept")
+	}
+
+	// Once the server starts shutting down, ask clients to go elsewhere instead of letting them race its listener
+	// being closed. Websocket reconnects are still let through so that they're closed cleanly along with the others.
+	if c.App.Srv.IsDraining() && !websocket.IsWebSocketUpgrade(r) {
+		writeServiceUnavailable(c, w, r, "api.context.server_shutting_down.app_error", DRAINING_RETRY_AFTER_SECONDS)
+		return
+	}
 
 	token, tokenLocation := app.ParseAuthTokenFromRequest(r)
 
@@ -86,20 +215,44 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set(model.HEADER_REQUEST_ID, c.App.RequestId)
 	w.Header().Set(model.HEADER_VERSION_ID, fmt.Sprintf("%v.%v.%v.%v", model.CurrentVersion, model.BuildNumber, c.App.ClientConfigHash(), c.App.License() != nil))
+	if nodeId := c.App.ExposedNodeId(); nodeId != "" {
+		w.Header().Set(model.HEADER_NODE_ID, nodeId)
+	}
 
 	if *c.App.Config().ServiceSettings.TLSStrictTransport {
 		w.Header().Set("Strict-Transport-Security", fmt.Sprintf("max-age=%d", *c.App.Config().ServiceSettings.TLSStrictTransportMaxAge))
 	}
 
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if referrerPolicy := *c.App.Config().ServiceSettings.ReferrerPolicy; referrerPolicy != "" {
+		w.Header().Set("Referrer-Policy", referrerPolicy)
+	}
+
 	if h.IsStatic {
 		// Instruct the browser not to display us in an iframe unless is the same origin for anti-clickjacking
 		w.Header().Set("X-Frame-Options", "SAMEORIGIN")
 		// Set content security policy. This is also specified in the root.html of the webapp in a meta tag.
-		w.Header().Set("Content-Security-Policy", fmt.Sprintf(
-			"frame-ancestors 'self'; script-src 'self' cdn.segment.com/analytics.js/%s",
-			h.cspShaDirective,
-		))
+		if nonce, err := newCspNonce(); err != nil {
+			// Without a nonce, no inline script is allowed, and the request fails rather than risk a guessable one
+			c.Err = model.NewAppError("ServeHTTP", "api.context.csp_nonce.app_error", nil, err.Error(), http.StatusInternalServerError)
+			w.Header().Set("Content-Security-Policy", fmt.Sprintf(
+				"frame-ancestors 'self'; script-src 'self'%s",
+				cspScriptSources(h.cspSubpath, c.App.Config()),
+			))
+		} else {
+			c.CspNonce = nonce
+			w.Header().Set("Content-Security-Policy", fmt.Sprintf(
+				"frame-ancestors 'self'; script-src 'self'%s 'nonce-%s'",
+				cspScriptSources(h.cspSubpath, c.App.Config()),
+				c.CspNonce,
+			))
+		}
 	} else {
+		// Nothing but static content is meant to be framed, and that's left to its frame-ancestors policy
+		if frameOptions := *c.App.Config().ServiceSettings.FrameOptions; frameOptions != "" {
+			w.Header().Set("X-Frame-Options", frameOptions)
+		}
+
 		// All api response bodies will be JSON formatted by default
 		w.Header().Set("Content-Type", "application/json")
 
@@ -108,29 +261,43 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	timings := &requestTimings{start: now}
+
 	if len(token) != 0 {
+		authStart := time.Now()
 		session, err := c.App.GetSession(token)
 
 		if err != nil {
 			c.Log.Info("Invalid session", mlog.Err(err))
 			if err.StatusCode == http.StatusInternalServerError {
 				c.Err = err
-			} else if h.RequireSession {
-				c.RemoveSessionCookie(w, r)
-				c.Err = model.NewAppError("ServeHTTP", "api.context.session_expired.app_error", nil, "token="+token, http.StatusUnauthorized)
+			} else {
+				// The session has expired, such as from being idle, or been revoked, so the cookie is of no further use
+				if h.RequireSession || tokenLocation == app.TokenLocationCookie {
+					c.RemoveSessionCookie(w, r)
+				}
+				if h.RequireSession {
+					c.Err = model.NewAppError("ServeHTTP", "api.context.session_expired.app_error", nil, "token="+token, http.StatusUnauthorized)
+				}
 			}
 		} else if !session.IsOAuth && tokenLocation == app.TokenLocationQueryString {
 			c.Err = model.NewAppError("ServeHTTP", "api.context.token_provided.app_error", nil, "token="+token, http.StatusUnauthorized)
 		} else {
 			c.App.Session = *session
+			c.App.RecordSessionActivity(*session)
 		}
+		timings.auth = time.Since(authStart)
 
 		// Rate limit by UserID
-		if c.App.Srv.RateLimiter != nil && c.App.Srv.RateLimiter.UserIdRateLimit(c.App.Session.UserId, w) {
+		if c.App.Srv.RateLimiter != nil && c.App.Srv.RateLimiter.UserIdRateLimit(r, c.App.Session.UserId, w) {
 			return
 		}
 	}
 
+	if c.Err == nil && c.App.Session.UserId == "" && !h.IsStatic && *c.App.Config().ServiceSettings.ClientCertAuth {
+		authenticateClientCert(c, w, r)
+	}
+
 	c.Log = c.App.Log.With(
 		mlog.String("path", c.App.Path),
 		mlog.String("request_id", c.App.RequestId),
@@ -139,20 +306,88 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		mlog.String("method", r.Method),
 	)
 
+	if c.Err == nil && h.isInMaintenanceMode(c, r) {
+		writeMaintenanceMode(c, w, r)
+		return
+	}
+
+	if c.Err == nil && h.shouldShedLoad(c) {
+		h.shedLoad(c, w, r)
+		return
+	}
+
+	if c.Err == nil {
+		release, ok := h.acquireConcurrentRequest(c, r)
+		if !ok {
+			writeTooManyConcurrentRequests(c, w, r)
+			return
+		}
+		c.releaseConcurrentRequest = release
+
+		// Deferred so that the request stops being counted even if its handler panics
+		defer func() {
+			if c.releaseConcurrentRequest != nil {
+				c.releaseConcurrentRequest()
+			}
+		}()
+	}
+
+	if c.Err == nil && h.RequireSecureConnection {
+		c.RequireConnectionSecurity(r)
+	}
+
 	if c.Err == nil && h.RequireSession {
 		c.SessionRequired()
 	}
 
+	if c.Err == nil && tokenLocation == app.TokenLocationCookie && h.RequireSession && !h.TrustRequester {
+		c.CsrfTokenRequired(w, r)
+	}
+
 	if c.Err == nil && h.RequireMfa {
-		c.MfaRequired()
+		if c.App.MfaVerifier != nil {
+			c.CustomMfaRequired(r)
+		} else {
+			c.MfaRequired()
+		}
+	}
+
+	if h.RequireSystemAdmin {
+		c.SystemAdminRequired(r)
+	}
+
+	if c.Err == nil && h.RequireJSONBody {
+		c.RequireJSONContentType(r)
 	}
 
+	if c.Err == nil && h.IsSearch {
+		if limited, retryAfter := c.App.Srv.RateLimitSearch(c.App.Session.UserId); limited {
+			writeSearchRateLimited(c, w, r, retryAfter)
+			return
+		}
+	}
+
+	var body *countingBody
+	maxBodyBytes := h.maxBodyBytes(c.App.Config())
 	if c.Err == nil {
-		h.HandleFunc(c, w, r)
+		body = h.limitRequestBody(c, w, r, maxBodyBytes)
+	}
+
+	if c.Err == nil {
+		handlerStart := time.Now()
+		h.serveRecovering(c, w, r, timeout)
+		timings.handler = time.Since(handlerStart)
+	}
+
+	// A handler that fails because it reached the end of what it was allowed to read will usually report the body as
+	// malformed, so replace that with a clearer error
+	if body != nil && !c.timedOut && body.bytesRead > maxBodyBytes {
+		c.Err = newRequestBodyTooLargeError(maxBodyBytes)
 	}
 
 	// Handle errors that have occurred
 	if c.Err != nil {
+		c.Locale()
 		c.Err.Translate(c.App.T)
 		c.Err.RequestId = c.App.RequestId
 
@@ -164,24 +399,26 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		c.Err.Where = r.URL.Path
 
-		// Block out detailed error when not in developer mode
-		if !*c.App.Config().ServiceSettings.EnableDeveloper {
-			c.Err.DetailedError = ""
+		// Errors that are returned without a status code, such as by a JSONHandlerFunc, would otherwise make
+		// WriteHeader panic
+		if c.Err.StatusCode == 0 {
+			c.Err.StatusCode = http.StatusInternalServerError
 		}
 
-		// Sanitize all 5xx error messages in hardened mode
+		// Block out detailed error when not in developer mode
+		utils.SanitizeAppError(c.App.Config(), c.Err)
+
+		// Sanitize all 5xx error messages in hardened mode, including the params of their messages
 		if *c.App.Config().ServiceSettings.ExperimentalEnableHardenedMode && c.Err.StatusCode >= 500 {
-			c.Err.Id = ""
-			c.Err.Message = "Internal Server Error"
-			c.Err.DetailedError = ""
-			c.Err.StatusCode = 500
-			c.Err.Where = ""
-			c.Err.IsOAuth = false
+			c.Err = &model.AppError{
+				Message:    "Internal Server Error",
+				RequestId:  c.Err.RequestId,
+				StatusCode: http.StatusInternalServerError,
+			}
 		}
 
-		if IsApiCall(c.App, r) || IsWebhookCall(c.App, r) || len(r.Header.Get("X-Mobile-App")) > 0 {
-			w.WriteHeader(c.Err.StatusCode)
-			w.Write([]byte(c.Err.ToJson()))
+		if IsJsonErrorPath(c.App, r) || c.IsMobileApp() {
+			utils.RenderApiError(c.App.Config(), w, r, c.Err)
 		} else {
 			utils.RenderWebAppError(c.App.Config(), w, r, c.Err, c.App.AsymmetricSigningKey())
 		}
@@ -197,6 +434,105 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != model.API_URL_SUFFIX+"/websocket" {
 			elapsed := float64(time.Since(now)) / float64(time.Second)
 			c.App.Metrics.ObserveHttpRequestDuration(elapsed)
+			observeRequestTimings(c, r, timings)
 		}
 	}
 }
+
+func (h Handler) serve(c *Context, w http.ResponseWriter, r *http.Request) {
+	if len(h.ResponseCache) > 0 && r.Method == "GET" && c.App.IsResponseCacheEnabled(h.ResponseCache) {
+		h.serveWithResponseCache(c, w, r)
+	} else if h.isIdempotentRequest(c, r) {
+		h.serveIdempotent(c, w, r)
+	} else {
+		h.HandleFunc(c, w, r)
+	}
+}
+
+// shouldShedLoad returns whether the request should be rejected because the server is over its load shedding
+// thresholds. Requests from system admins are still let through so that they can investigate.
+func (h Handler) shouldShedLoad(c *Context) bool {
+	if h.Critical || h.IsStatic || !c.App.Srv.IsSheddingLoad() {
+		return false
+	}
+
+	return !c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_SYSTEM)
+}
+
+func (h Handler) shedLoad(c *Context, w http.ResponseWriter, r *http.Request) {
+	writeServiceUnavailable(c, w, r, "api.context.load_shedding.app_error", *c.App.Config().ServiceSettings.LoadSheddingRetryAfterSeconds)
+
+	if c.App.Metrics != nil {
+		c.App.Metrics.IncrementHttpRequestShed()
+	}
+}
+
+// maintenanceModeRoutes are the routes that anyone may still use in maintenance mode, since the webapp needs them to
+// show its login page.
+var maintenanceModeRoutes = map[string]bool{
+	model.API_URL_SUFFIX + "/config/client":  true,
+	model.API_URL_SUFFIX + "/license/client": true,
+}
+
+// isInMaintenanceMode returns whether the request should be rejected because the server is in maintenance mode. Only
+// system admins are let through, along with health checks, static files and logging in so that admins can still reach
+// the server. It's read from the config of each request, so toggling it applies straight away.
+func (h Handler) isInMaintenanceMode(c *Context, r *http.Request) bool {
+	if !*c.App.Config().ServiceSettings.MaintenanceMode {
+		return false
+	}
+
+	// The WebSocket is critical so that it survives load shedding, but it must not let users back in here
+	if (h.Critical && !websocket.IsWebSocketUpgrade(r)) || h.IsStatic || h.RequireSecureConnection {
+		return false
+	}
+
+	if maintenanceModeRoutes[routeTemplate(r)] {
+		return false
+	}
+
+	return !c.App.SessionHasPermissionTo(c.App.Session, model.PERMISSION_MANAGE_SYSTEM)
+}
+
+func writeMaintenanceMode(c *Context, w http.ResponseWriter, r *http.Request) {
+	err := model.NewAppError("ServeHTTP", "api.context.maintenance_mode.app_error", nil, "", http.StatusServiceUnavailable)
+	err.Translate(c.App.T)
+	if message := *c.App.Config().ServiceSettings.MaintenanceModeMessage; message != "" {
+		err.Message = message
+	}
+	err.RequestId = c.App.RequestId
+
+	utils.RenderApiError(c.App.Config(), w, r, err)
+}
+
+// writeServiceUnavailable rejects a request that the server can't handle right now, asking the client to retry it
+// later. The rejection is expected, so unlike other errors it isn't logged.
+func writeServiceUnavailable(c *Context, w http.ResponseWriter, r *http.Request, errId string, retryAfterSeconds int) {
+	err := model.NewAppError("ServeHTTP", errId, nil, "", http.StatusServiceUnavailable)
+	err.Translate(c.App.T)
+	err.RequestId = c.App.RequestId
+
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds))
+	utils.RenderApiError(c.App.Config(), w, r, err)
+}
+
+// newCspNonce generates a random value that allows an inline script to run for a single response. It must never be
+// reused, since anyone who knows it can use it to inject scripts.
+func newCspNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// cspAnalyticsSource returns the script-src source that the webapp loads analytics from, or nothing when diagnostics
+// are disabled and so analytics is never loaded. It's read from the config of each request, so it follows config
+// changes without a restart.
+func cspAnalyticsSource(config *model.Config) string {
+	if !*config.LogSettings.EnableDiagnostics || *config.ServiceSettings.AnalyticsCdnHost == "" {
+		return ""
+	}
+
+	return " " + *config.ServiceSettings.AnalyticsCdnHost + "/analytics.js/"
+}
@@ -4,12 +4,21 @@
 package web
 
 import (
+	"compress/gzip"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/mattermost/mattermost-server/app"
 	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/testlib"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -29,6 +38,9 @@ func TestHandlerServeHTTPErrors(t *testing.T) {
 	}
 	handler := web.NewHandler(handlerForHTTPErrors)
 
+	RegisterJsonErrorPathPrefix("/receivers")
+	defer UnregisterJsonErrorPathPrefix("/receivers")
+
 	var flagtests = []struct {
 		name     string
 		url      string
@@ -39,13 +51,16 @@ func TestHandlerServeHTTPErrors(t *testing.T) {
 		{"not redirect on desktop api endpoint", "/api/v4/test", false, false},
 		{"not redirect on mobile non-api endpoint", "/login/sso/saml", true, false},
 		{"not redirect on mobile api endpoint", "/api/v4/test", true, false},
+		{"not redirect on desktop webhook endpoint", "/hooks/test", false, false},
+		{"not redirect on desktop registered json error endpoint", "/receivers/test", false, false},
+		{"redirect on desktop endpoint sharing a prefix with a registered one", "/receiversx/test", false, true},
 	}
 
 	for _, tt := range flagtests {
 		t.Run(tt.name, func(t *testing.T) {
 			request := httptest.NewRequest("GET", tt.url, nil)
 			if tt.mobile {
-				request.Header.Add("X-Mobile-App", "mattermost")
+				request.Header.Add(model.HEADER_MOBILE_APP, "mattermost")
 			}
 			response := httptest.NewRecorder()
 			handler.ServeHTTP(response, request)
@@ -110,6 +125,42 @@ func TestHandlerServeHTTPSecureTransport(t *testing.T) {
 }
 
 func handlerForCSPHeader(c *Context, w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(c.CspNonce))
+}
+
+var cspHeaderPattern = regexp.MustCompile(`^frame-ancestors 'self'; script-src 'self' cdn.segment.com/analytics.js/ 'nonce-[A-Za-z0-9+/]{22}=='$`)
+
+func TestHandlerServeCSPHeaderWhenNotModified(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	dir, err := ioutil.TempDir("", "static")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "root.html")
+	require.Nil(t, ioutil.WriteFile(filename, []byte("<html></html>"), 0600))
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+	handler := web.NewStaticHandler(func(c *Context, w http.ResponseWriter, r *http.Request) {
+		serveStaticFile(w, r, filename)
+	})
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/", nil))
+	require.Equal(t, http.StatusOK, response.Code)
+	etag := response.Header().Get(model.HEADER_ETAG_SERVER)
+	require.NotEmpty(t, etag)
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set(model.HEADER_ETAG_CLIENT, etag)
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusNotModified, response.Code)
+	assert.Empty(t, response.Body.String())
+	assert.Equal(t, etag, response.Header().Get(model.HEADER_ETAG_SERVER))
+	assert.Regexp(t, cspHeaderPattern, response.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "SAMEORIGIN", response.Header().Get("X-Frame-Options"))
 }
 
 func TestHandlerServeCSPHeader(t *testing.T) {
@@ -119,14 +170,7 @@ func TestHandlerServeCSPHeader(t *testing.T) {
 
 		web := New(th.Server, th.Server.AppOptions, th.Server.Router)
 
-		handler := Handler{
-			GetGlobalAppOptions: web.GetGlobalAppOptions,
-			HandleFunc:          handlerForCSPHeader,
-			RequireSession:      false,
-			TrustRequester:      false,
-			RequireMfa:          false,
-			IsStatic:            false,
-		}
+		handler := NewTestHandler(web.GetGlobalAppOptions, handlerForCSPHeader)
 
 		request := httptest.NewRequest("POST", "/api/v4/test", nil)
 		response := httptest.NewRecorder()
@@ -141,20 +185,22 @@ func TestHandlerServeCSPHeader(t *testing.T) {
 
 		web := New(th.Server, th.Server.AppOptions, th.Server.Router)
 
-		handler := Handler{
-			GetGlobalAppOptions: web.GetGlobalAppOptions,
-			HandleFunc:          handlerForCSPHeader,
-			RequireSession:      false,
-			TrustRequester:      false,
-			RequireMfa:          false,
-			IsStatic:            true,
-		}
+		handler := NewTestHandler(web.GetGlobalAppOptions, handlerForCSPHeader, HandlerIsStatic(true))
 
 		request := httptest.NewRequest("POST", "/", nil)
 		response := httptest.NewRecorder()
 		handler.ServeHTTP(response, request)
 		assert.Equal(t, 200, response.Code)
-		assert.Equal(t, response.Header()["Content-Security-Policy"], []string{"frame-ancestors 'self'; script-src 'self' cdn.segment.com/analytics.js/"})
+		assert.Regexp(t, cspHeaderPattern, response.Header().Get("Content-Security-Policy"))
+
+		// The nonce is available to the handler and is different for every response
+		nonce := response.Body.String()
+		assert.Contains(t, response.Header().Get("Content-Security-Policy"), "'nonce-"+nonce+"'")
+
+		response = httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest("POST", "/", nil))
+		assert.Regexp(t, cspHeaderPattern, response.Header().Get("Content-Security-Policy"))
+		assert.NotEqual(t, nonce, response.Body.String())
 	})
 
 	t.Run("static, with subpath", func(t *testing.T) {
@@ -167,20 +213,13 @@ func TestHandlerServeCSPHeader(t *testing.T) {
 
 		web := New(th.Server, th.Server.AppOptions, th.Server.Router)
 
-		handler := Handler{
-			GetGlobalAppOptions: web.GetGlobalAppOptions,
-			HandleFunc:          handlerForCSPHeader,
-			RequireSession:      false,
-			TrustRequester:      false,
-			RequireMfa:          false,
-			IsStatic:            true,
-		}
+		handler := NewTestHandler(web.GetGlobalAppOptions, handlerForCSPHeader, HandlerIsStatic(true))
 
 		request := httptest.NewRequest("POST", "/", nil)
 		response := httptest.NewRecorder()
 		handler.ServeHTTP(response, request)
 		assert.Equal(t, 200, response.Code)
-		assert.Equal(t, response.Header()["Content-Security-Policy"], []string{"frame-ancestors 'self'; script-src 'self' cdn.segment.com/analytics.js/"})
+		assert.Regexp(t, cspHeaderPattern, response.Header().Get("Content-Security-Policy"))
 
 		// TODO: It's hard to unit test this now that the CSP directive is effectively
 		// decided in Setup(). Circle back to this in master once the memory store is
@@ -195,8 +234,998 @@ func TestHandlerServeCSPHeader(t *testing.T) {
 		response = httptest.NewRecorder()
 		handler.ServeHTTP(response, request)
 		assert.Equal(t, 200, response.Code)
-		assert.Equal(t, response.Header()["Content-Security-Policy"], []string{"frame-ancestors 'self'; script-src 'self' cdn.segment.com/analytics.js/"})
+		assert.Regexp(t, cspHeaderPattern, response.Header().Get("Content-Security-Policy"))
 		// TODO: See above.
 		// assert.Contains(t, response.Header()["Content-Security-Policy"], "frame-ancestors 'self'; script-src 'self' cdn.segment.com/analytics.js/ 'sha256-tPOjw+tkVs9axL78ZwGtYl975dtyPHB6LYKAO2R3gR4='", "csp header incorrectly changed after subpath changed")
 	})
 }
+
+func TestHandlerServeCSPHeaderAnalyticsCdnHost(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+	handler := NewTestHandler(web.GetGlobalAppOptions, handlerForCSPHeader, HandlerIsStatic(true))
+
+	getCSPHeader := func() string {
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest("GET", "/", nil))
+		require.Equal(t, http.StatusOK, response.Code)
+		return response.Header().Get("Content-Security-Policy")
+	}
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.LogSettings.EnableDiagnostics = true
+		*cfg.ServiceSettings.AnalyticsCdnHost = "analytics.example.com"
+	})
+	assert.Regexp(t, `^frame-ancestors 'self'; script-src 'self' analytics.example.com/analytics.js/ 'nonce-[A-Za-z0-9+/]{22}=='$`, getCSPHeader())
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.LogSettings.EnableDiagnostics = false
+	})
+	assert.Regexp(t, `^frame-ancestors 'self'; script-src 'self' 'nonce-[A-Za-z0-9+/]{22}=='$`, getCSPHeader())
+}
+
+func TestHandlerServeHTTPSecurityHeaders(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	t.Run("api", func(t *testing.T) {
+		handler := NewTestHandler(web.GetGlobalAppOptions, handlerForCSPHeader)
+
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest("GET", "/api/v4/test", nil))
+		assert.Equal(t, "nosniff", response.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "same-origin", response.Header().Get("Referrer-Policy"))
+		assert.Equal(t, "DENY", response.Header().Get("X-Frame-Options"))
+	})
+
+	t.Run("static content can be framed by the site", func(t *testing.T) {
+		handler := NewTestHandler(web.GetGlobalAppOptions, handlerForCSPHeader, HandlerIsStatic(true))
+
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, "nosniff", response.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "same-origin", response.Header().Get("Referrer-Policy"))
+		assert.Equal(t, "SAMEORIGIN", response.Header().Get("X-Frame-Options"))
+		assert.Regexp(t, cspHeaderPattern, response.Header().Get("Content-Security-Policy"))
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.ReferrerPolicy = "no-referrer"
+			*cfg.ServiceSettings.FrameOptions = ""
+		})
+
+		handler := NewTestHandler(web.GetGlobalAppOptions, handlerForCSPHeader)
+
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest("GET", "/api/v4/test", nil))
+		assert.Equal(t, "nosniff", response.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "no-referrer", response.Header().Get("Referrer-Policy"))
+		assert.Empty(t, response.Header()["X-Frame-Options"])
+	})
+}
+
+func handlerForLoadShedding(c *Context, w http.ResponseWriter, r *http.Request) {
+}
+
+func TestHandlerServeHTTPLoadShedding(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.LoadSheddingGoroutineThreshold = 1
+		*cfg.ServiceSettings.LoadSheddingRetryAfterSeconds = 7
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !th.Server.IsSheddingLoad() && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	require.True(t, th.Server.IsSheddingLoad())
+
+	t.Run("non-critical requests are rejected", func(t *testing.T) {
+		handler := web.NewHandler(handlerForLoadShedding)
+
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+		assert.Equal(t, "7", response.Header().Get("Retry-After"))
+	})
+
+	t.Run("critical requests are let through", func(t *testing.T) {
+		handler := Handler{
+			GetGlobalAppOptions: web.GetGlobalAppOptions,
+			HandleFunc:          handlerForLoadShedding,
+			Critical:            true,
+		}
+
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("requests from system admins are let through", func(t *testing.T) {
+		session, err := th.App.CreateSession(&model.Session{UserId: th.SystemAdminUser.Id, Roles: th.SystemAdminUser.Roles})
+		require.Nil(t, err)
+
+		handler := web.NewHandler(handlerForLoadShedding)
+
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		request.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+session.Token)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+}
+
+func handlerForMaintenanceMode(c *Context, w http.ResponseWriter, r *http.Request) {
+}
+
+func TestHandlerServeHTTPMaintenanceMode(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.MaintenanceMode = true
+		*cfg.ServiceSettings.MaintenanceModeMessage = "Back at noon"
+	})
+
+	session, err := th.App.CreateSession(&model.Session{UserId: th.BasicUser.Id, Roles: th.BasicUser.Roles})
+	require.Nil(t, err)
+	adminSession, err := th.App.CreateSession(&model.Session{UserId: th.SystemAdminUser.Id, Roles: th.SystemAdminUser.Roles})
+	require.Nil(t, err)
+
+	t.Run("anonymous requests are rejected", func(t *testing.T) {
+		handler := web.NewHandler(handlerForMaintenanceMode)
+
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+
+		appErr := model.AppErrorFromJson(response.Body)
+		require.NotNil(t, appErr)
+		assert.Equal(t, "api.context.maintenance_mode.app_error", appErr.Id)
+		assert.Equal(t, "Back at noon", appErr.Message)
+	})
+
+	t.Run("requests from users are rejected", func(t *testing.T) {
+		handler := web.NewHandler(handlerForMaintenanceMode)
+
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		request.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+session.Token)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	})
+
+	t.Run("requests from system admins are let through", func(t *testing.T) {
+		handler := web.NewHandler(handlerForMaintenanceMode)
+
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		request.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+adminSession.Token)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("critical requests are let through", func(t *testing.T) {
+		handler := Handler{
+			GetGlobalAppOptions: web.GetGlobalAppOptions,
+			HandleFunc:          handlerForMaintenanceMode,
+			Critical:            true,
+		}
+
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("websocket upgrades from users are rejected", func(t *testing.T) {
+		handler := Handler{
+			GetGlobalAppOptions: web.GetGlobalAppOptions,
+			HandleFunc:          handlerForMaintenanceMode,
+			Critical:            true,
+			TrustRequester:      true,
+		}
+
+		request := httptest.NewRequest("GET", "/api/v4/websocket", nil)
+		request.Header.Set("Connection", "Upgrade")
+		request.Header.Set("Upgrade", "websocket")
+		request.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+session.Token)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	})
+
+	t.Run("logging in is let through", func(t *testing.T) {
+		handler := web.NewSecureHandler(handlerForMaintenanceMode)
+
+		request := httptest.NewRequest("POST", "/api/v4/users/login", nil)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("turning it off applies straight away", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.MaintenanceMode = false
+		})
+
+		handler := web.NewHandler(handlerForMaintenanceMode)
+
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		request.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+session.Token)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+}
+
+func TestHandlerServeHTTPRequireJSONBody(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	handled := false
+	handler := Handler{
+		GetGlobalAppOptions: web.GetGlobalAppOptions,
+		HandleFunc: func(c *Context, w http.ResponseWriter, r *http.Request) {
+			handled = true
+		},
+		RequireJSONBody: true,
+	}
+
+	request := httptest.NewRequest("POST", "/api/v4/test", strings.NewReader("name=test"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusUnsupportedMediaType, response.Code)
+	assert.False(t, handled)
+
+	request = httptest.NewRequest("POST", "/api/v4/test", strings.NewReader(`{"name": "test"}`))
+	request.Header.Set("Content-Type", "application/json; charset=utf-8")
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.True(t, handled)
+}
+
+func TestHandlerServeHTTPSearchRateLimit(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.SearchRateLimitSettings.Enable = true
+		*cfg.SearchRateLimitSettings.PerUserPerMinute = 1
+		*cfg.SearchRateLimitSettings.PerUserMaxBurst = 0
+	})
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	handled := 0
+	handler := Handler{
+		GetGlobalAppOptions: web.GetGlobalAppOptions,
+		HandleFunc: func(c *Context, w http.ResponseWriter, r *http.Request) {
+			handled++
+		},
+		IsSearch: true,
+	}
+
+	request := httptest.NewRequest("POST", "/api/v4/test/search", nil)
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, 1, handled)
+
+	request = httptest.NewRequest("POST", "/api/v4/test/search", nil)
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusTooManyRequests, response.Code)
+	assert.NotEmpty(t, response.Header().Get("Retry-After"))
+	assert.Equal(t, 1, handled, "limited searches shouldn't be handled")
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.SearchRateLimitSettings.Enable = false
+	})
+
+	request = httptest.NewRequest("POST", "/api/v4/test/search", nil)
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, 2, handled)
+}
+
+type testMfaVerifier struct {
+	verified []string
+	err      *model.AppError
+}
+
+func (v *testMfaVerifier) Verify(session *model.Session, r *http.Request) *model.AppError {
+	v.verified = append(v.verified, session.UserId)
+	return v.err
+}
+
+func handlerForCustomMfa(c *Context, w http.ResponseWriter, r *http.Request) {
+}
+
+func TestHandlerServeHTTPCustomMfa(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	session, err := th.App.CreateSession(&model.Session{UserId: th.BasicUser.Id, Roles: th.BasicUser.Roles})
+	require.Nil(t, err)
+
+	serve := func(requireMfa bool) *httptest.ResponseRecorder {
+		handler := Handler{
+			GetGlobalAppOptions: web.GetGlobalAppOptions,
+			HandleFunc:          handlerForCustomMfa,
+			RequireSession:      true,
+			RequireMfa:          requireMfa,
+		}
+
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		request.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+session.Token)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response
+	}
+
+	verifier := &testMfaVerifier{}
+	th.Server.MfaVerifier = verifier
+	defer func() {
+		th.Server.MfaVerifier = nil
+	}()
+
+	t.Run("the verifier is called for handlers that require MFA", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(true).Code)
+		assert.Equal(t, []string{th.BasicUser.Id}, verifier.verified)
+	})
+
+	t.Run("the verifier isn't called for handlers that don't require MFA", func(t *testing.T) {
+		verifier.verified = nil
+		assert.Equal(t, http.StatusOK, serve(false).Code)
+		assert.Empty(t, verifier.verified)
+	})
+
+	t.Run("errors from the verifier are returned", func(t *testing.T) {
+		verifier.err = model.NewAppError("Verify", "api.context.mfa_required.app_error", nil, "", http.StatusForbidden)
+		assert.Equal(t, http.StatusForbidden, serve(true).Code)
+	})
+}
+
+func handlerForSystemAdmin(c *Context, w http.ResponseWriter, r *http.Request) {
+}
+
+func TestHandlerServeHTTPRequireSystemAdmin(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	serve := func(user *model.User) *httptest.ResponseRecorder {
+		handler := Handler{
+			GetGlobalAppOptions: web.GetGlobalAppOptions,
+			HandleFunc:          handlerForSystemAdmin,
+			RequireSystemAdmin:  true,
+		}
+
+		request := httptest.NewRequest("POST", "/api/v4/test", nil)
+		if user != nil {
+			session, err := th.App.CreateSession(&model.Session{UserId: user.Id, Roles: user.Roles})
+			require.Nil(t, err)
+			request.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+session.Token)
+		}
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response
+	}
+
+	lastAudit := func(userId string) *model.Audit {
+		audits, err := th.App.GetAudits(userId, 1)
+		require.Nil(t, err)
+		require.Len(t, audits, 1)
+		return &audits[0]
+	}
+
+	t.Run("no session", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve(nil).Code)
+	})
+
+	t.Run("not a system admin", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve(th.BasicUser).Code)
+
+		audit := lastAudit(th.BasicUser.Id)
+		assert.Equal(t, "/api/v4/test", audit.Action)
+		assert.Equal(t, "system_admin_required method=POST route=/api/v4/test result=denied", audit.ExtraInfo)
+		assert.NotEmpty(t, audit.IpAddress)
+	})
+
+	t.Run("system admin", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(th.SystemAdminUser).Code)
+
+		audit := lastAudit(th.SystemAdminUser.Id)
+		assert.Equal(t, "system_admin_required method=POST route=/api/v4/test result=allowed", audit.ExtraInfo)
+	})
+}
+
+func handlerForCsrf(c *Context, w http.ResponseWriter, r *http.Request) {
+}
+
+func TestHandlerServeHTTPCsrf(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	session := &model.Session{UserId: th.BasicUser.Id, Roles: th.BasicUser.Roles}
+	session.GenerateCSRF()
+	session, err := th.App.CreateSession(session)
+	require.Nil(t, err)
+
+	serve := func(method string, trustRequester bool, csrfCookie, csrfHeader string) *httptest.ResponseRecorder {
+		handler := Handler{
+			GetGlobalAppOptions: web.GetGlobalAppOptions,
+			HandleFunc:          handlerForCsrf,
+			RequireSession:      true,
+			TrustRequester:      trustRequester,
+		}
+
+		request := httptest.NewRequest(method, "/api/v4/test", nil)
+		request.AddCookie(&http.Cookie{Name: model.SESSION_COOKIE_TOKEN, Value: session.Token})
+		if csrfCookie != "" {
+			request.AddCookie(&http.Cookie{Name: model.SESSION_COOKIE_CSRF, Value: csrfCookie})
+		}
+		if csrfHeader != "" {
+			request.Header.Set(model.HEADER_CSRF_TOKEN, csrfHeader)
+		}
+		if !trustRequester {
+			request.Header.Set(model.HEADER_REQUESTED_WITH, model.HEADER_REQUESTED_WITH_XML)
+		}
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response
+	}
+
+	csrfCookieOf := func(response *httptest.ResponseRecorder) *http.Cookie {
+		for _, cookie := range response.Result().Cookies() {
+			if cookie.Name == model.SESSION_COOKIE_CSRF {
+				return cookie
+			}
+		}
+		return nil
+	}
+
+	token := session.GetCSRF()
+
+	t.Run("header mode doesn't require the CSRF token", func(t *testing.T) {
+		response := serve("POST", false, "", "")
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		cookie := csrfCookieOf(response)
+		require.NotNil(t, cookie, "the missing CSRF cookie should be set")
+		assert.Equal(t, token, cookie.Value)
+		assert.False(t, cookie.HttpOnly)
+	})
+
+	t.Run("the CSRF cookie isn't sent again when it's current", func(t *testing.T) {
+		assert.Nil(t, csrfCookieOf(serve("GET", false, token, "")))
+	})
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.CsrfProtectionMode = model.CSRF_PROTECTION_MODE_DOUBLE_SUBMIT
+	})
+
+	t.Run("double submit mode requires the CSRF token for state changing requests", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("POST", false, token, token).Code)
+		assert.Equal(t, http.StatusUnauthorized, serve("POST", false, token, "").Code)
+		assert.Equal(t, http.StatusUnauthorized, serve("POST", false, "", token).Code)
+		assert.Equal(t, http.StatusUnauthorized, serve("POST", false, model.NewId(), model.NewId()).Code)
+		assert.Equal(t, http.StatusOK, serve("GET", false, token, "").Code)
+	})
+
+	t.Run("double submit mode doesn't apply to handlers that trust the requester", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("POST", true, "", "").Code)
+	})
+
+	t.Run("double submit mode doesn't apply to API tokens", func(t *testing.T) {
+		handler := Handler{
+			GetGlobalAppOptions: web.GetGlobalAppOptions,
+			HandleFunc:          handlerForCsrf,
+			RequireSession:      true,
+		}
+
+		request := httptest.NewRequest("POST", "/api/v4/test", nil)
+		request.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+session.Token)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.CsrfTokenRotationMinutes = 1
+	})
+
+	t.Run("CSRF tokens are rotated when they're due", func(t *testing.T) {
+		session.Props[model.SESSION_PROP_CSRF_ISSUED_AT] = strconv.FormatInt(model.GetMillis()-2*60*1000, 10)
+		th.App.AddSessionToCache(session)
+
+		response := serve("POST", false, token, token)
+		assert.Equal(t, http.StatusOK, response.Code)
+
+		cookie := csrfCookieOf(response)
+		require.NotNil(t, cookie, "the rotated CSRF token should be sent")
+		assert.NotEqual(t, token, cookie.Value)
+
+		rotated, err := th.App.GetSession(session.Token)
+		require.Nil(t, err)
+		assert.Equal(t, cookie.Value, rotated.GetCSRF())
+
+		// Requests that were sent with the previous token are still accepted
+		assert.Equal(t, http.StatusOK, serve("POST", false, token, token).Code)
+		assert.Equal(t, http.StatusOK, serve("POST", false, cookie.Value, cookie.Value).Code)
+	})
+}
+
+func handlerForTimeout(c *Context, w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-c.Ctx.Done():
+	case <-time.After(time.Second):
+	}
+
+	w.Write([]byte(`{"status":"OK"}`))
+}
+
+func TestHandlerServeHTTPCompression(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+	body := strings.Repeat(`{"user_id": "abcdefghijklmnopqrstuvwxyz"}`, 100)
+
+	handler := NewTestHandler(web.GetGlobalAppOptions, func(c *Context, w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	})
+
+	serve := func(acceptEncoding string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		request.Header.Set("Accept-Encoding", acceptEncoding)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		response := serve("gzip")
+		assert.Empty(t, response.Header().Get("Content-Encoding"))
+		assert.Equal(t, body, response.Body.String())
+	})
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.EnableResponseCompression = true
+		*cfg.ServiceSettings.TLSStrictTransport = true
+	})
+
+	t.Run("compressed when accepted", func(t *testing.T) {
+		response := serve("gzip, deflate")
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "gzip", response.Header().Get("Content-Encoding"))
+		assert.Contains(t, response.Header()["Vary"], "Accept-Encoding")
+		assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
+		assert.NotEmpty(t, response.Header().Get("Strict-Transport-Security"))
+
+		reader, err := gzip.NewReader(response.Body)
+		require.Nil(t, err)
+		decompressed, err := ioutil.ReadAll(reader)
+		require.Nil(t, err)
+		assert.Equal(t, body, string(decompressed))
+	})
+
+	t.Run("not compressed when not accepted", func(t *testing.T) {
+		response := serve("identity")
+		assert.Empty(t, response.Header().Get("Content-Encoding"))
+		assert.Contains(t, response.Header()["Vary"], "Accept-Encoding")
+		assert.Equal(t, body, response.Body.String())
+	})
+
+	t.Run("not compressed below the minimum size", func(t *testing.T) {
+		th.App.UpdateConfig(func(cfg *model.Config) {
+			*cfg.ServiceSettings.ResponseCompressionMinSize = len(body) + 1
+		})
+
+		response := serve("gzip")
+		assert.Empty(t, response.Header().Get("Content-Encoding"))
+		assert.Equal(t, body, response.Body.String())
+	})
+}
+
+func TestHandlerServeHTTPRequestTimeout(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	serve := func(handler Handler) *httptest.ResponseRecorder {
+		handler.GetGlobalAppOptions = web.GetGlobalAppOptions
+		handler.HandleFunc = handlerForTimeout
+
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("requests don't time out by default", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(Handler{}).Code)
+	})
+
+	t.Run("handlers can set their own timeout", func(t *testing.T) {
+		response := serve(Handler{Timeout: 10 * time.Millisecond})
+		assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+		assert.Contains(t, response.Body.String(), "api.context.request_timeout.app_error")
+		assert.NotContains(t, response.Body.String(), `"status":"OK"`)
+	})
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.RequestTimeout = 1
+	})
+
+	t.Run("the configured timeout doesn't apply to uploads", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(Handler{IsUpload: true}).Code)
+	})
+
+	t.Run("the configured timeout applies to other handlers", func(t *testing.T) {
+		response := serve(Handler{})
+		assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+		assert.Contains(t, response.Body.String(), "api.context.request_timeout.app_error")
+	})
+}
+
+func handlerForDraining(c *Context, w http.ResponseWriter, r *http.Request) {
+}
+
+func TestHandlerServeHTTPWhileDraining(t *testing.T) {
+	s, err := app.NewServer(app.StoreOverride(mainHelper.Store), app.DisableConfigWatch)
+	require.Nil(t, err)
+
+	web := New(s, s.AppOptions, s.Router)
+	handler := web.NewHandler(handlerForDraining)
+
+	request := httptest.NewRequest("GET", "/api/v4/test", nil)
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	require.Nil(t, s.Shutdown())
+	require.True(t, s.IsDraining())
+
+	t.Run("new requests are rejected", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+		assert.Equal(t, "5", response.Header().Get("Retry-After"))
+	})
+
+	t.Run("websocket reconnects are let through", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/api/v4/websocket", nil)
+		request.Header.Set("Connection", "Upgrade")
+		request.Header.Set("Upgrade", "websocket")
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+}
+
+func handlerForRequestBodyLimits(c *Context, w http.ResponseWriter, r *http.Request) {
+	props := model.MapFromJson(r.Body)
+	if props == nil {
+		c.SetInvalidParam("body")
+		return
+	}
+
+	ReturnStatusOK(w)
+}
+
+func TestHandlerServeHTTPRequestBodyLimits(t *testing.T) {
+	s, err := app.NewServer(app.StoreOverride(mainHelper.Store), app.DisableConfigWatch)
+	require.Nil(t, err)
+	defer s.Shutdown()
+
+	s.UpdateConfig(func(cfg *model.Config) {
+		*cfg.FileSettings.MaxFileSize = 2 * DEFAULT_MAX_BODY_BYTES
+	})
+
+	web := New(s, s.AppOptions, s.Router)
+	largeBody := `{"message": "` + strings.Repeat("a", DEFAULT_MAX_BODY_BYTES) + `"}`
+
+	t.Run("small body", func(t *testing.T) {
+		handler := web.NewHandler(handlerForRequestBodyLimits)
+
+		request := httptest.NewRequest("POST", "/api/v4/test", strings.NewReader(`{"message": "hello"}`))
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("declared length is too large", func(t *testing.T) {
+		handler := web.NewHandler(handlerForRequestBodyLimits)
+
+		request := httptest.NewRequest("POST", "/api/v4/test", strings.NewReader(largeBody))
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+		assert.Contains(t, response.Body.String(), "api.context.request_body_too_large.app_error")
+	})
+
+	t.Run("body is too large without a declared length", func(t *testing.T) {
+		handler := web.NewHandler(handlerForRequestBodyLimits)
+
+		request := httptest.NewRequest("POST", "/api/v4/test", strings.NewReader(largeBody))
+		request.ContentLength = -1
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+		assert.Contains(t, response.Body.String(), "api.context.request_body_too_large.app_error")
+	})
+
+	t.Run("malformed body is still a bad request", func(t *testing.T) {
+		handler := web.NewHandler(handlerForRequestBodyLimits)
+
+		request := httptest.NewRequest("POST", "/api/v4/test", strings.NewReader(`{"message": `))
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+
+	t.Run("upload handlers accept bodies up to the max file size", func(t *testing.T) {
+		handler := &Handler{
+			GetGlobalAppOptions: web.GetGlobalAppOptions,
+			HandleFunc:          handlerForRequestBodyLimits,
+			IsUpload:            true,
+		}
+
+		request := httptest.NewRequest("POST", "/api/v4/test", strings.NewReader(largeBody))
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("handlers can set their own limit", func(t *testing.T) {
+		handler := &Handler{
+			GetGlobalAppOptions: web.GetGlobalAppOptions,
+			HandleFunc:          handlerForRequestBodyLimits,
+			MaxBodyBytes:        10,
+		}
+
+		request := httptest.NewRequest("POST", "/api/v4/test", strings.NewReader(`{"message": "hello"}`))
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, response.Code)
+	})
+}
+
+func handlerForCamelCaseJson(c *Context, w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("fail") != "" {
+		c.SetInvalidParam("fail")
+		return
+	}
+
+	team := &model.Team{Id: "teamid", DisplayName: "Team", UpdateAt: 1234}
+	if c.HandleEtag(team.Etag(), "Test", w, r) {
+		return
+	}
+
+	w.Header().Set(model.HEADER_ETAG_SERVER, team.Etag())
+	w.Write([]byte(team.ToJson()))
+}
+
+func TestHandlerServeHTTPCamelCaseJson(t *testing.T) {
+	s, err := app.NewServer(app.StoreOverride(mainHelper.Store), app.DisableConfigWatch)
+	require.Nil(t, err)
+	defer s.Shutdown()
+
+	web := New(s, s.AppOptions, s.Router)
+	handler := web.NewHandler(handlerForCamelCaseJson)
+	etag := (&model.Team{Id: "teamid", DisplayName: "Team", UpdateAt: 1234}).Etag()
+
+	t.Run("snake case by default", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Contains(t, response.Body.String(), `"display_name":"Team"`)
+		assert.Equal(t, etag, response.Header().Get(model.HEADER_ETAG_SERVER))
+	})
+
+	t.Run("camel case", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		request.Header.Set("Accept", "application/json; naming=camelCase")
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Contains(t, response.Body.String(), `"displayName":"Team"`)
+		assert.NotContains(t, response.Body.String(), `"display_name"`)
+		assert.Equal(t, etag+camelCaseEtagSuffix, response.Header().Get(model.HEADER_ETAG_SERVER))
+		assert.Contains(t, response.Header()["Vary"], "Accept")
+	})
+
+	t.Run("camel case etag matches", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		request.Header.Set("Accept", "application/json; naming=camelCase")
+		request.Header.Set(model.HEADER_ETAG_CLIENT, etag+camelCaseEtagSuffix)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusNotModified, response.Code)
+		assert.Equal(t, etag+camelCaseEtagSuffix, response.Header().Get(model.HEADER_ETAG_SERVER))
+		assert.Empty(t, response.Body.String())
+	})
+
+	t.Run("snake case etag doesn't match a camel case request", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		request.Header.Set("Accept", "application/json; naming=camelCase")
+		request.Header.Set(model.HEADER_ETAG_CLIENT, etag)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Contains(t, response.Body.String(), `"displayName":"Team"`)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/api/v4/test?fail=true", nil)
+		request.Header.Set("Accept", "application/json; naming=camelCase")
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Contains(t, response.Body.String(), `"statusCode":400`)
+	})
+}
+
+type nodeIdClusterInterface struct {
+	testlib.FakeClusterInterface
+}
+
+func (c *nodeIdClusterInterface) GetClusterId() string { return "node1" }
+
+func TestHandlerServeHTTPNodeHeader(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	th.Server.Cluster = &nodeIdClusterInterface{}
+	defer func() {
+		th.Server.Cluster = nil
+	}()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+	handler := Handler{
+		GetGlobalAppOptions: web.GetGlobalAppOptions,
+		HandleFunc:          func(c *Context, w http.ResponseWriter, r *http.Request) {},
+	}
+
+	request := httptest.NewRequest("GET", "/api/v4/test", nil)
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	assert.Empty(t, response.Header().Get(model.HEADER_NODE_ID), "the node shouldn't be exposed by default")
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.ServiceSettings.ExposeNodeHeader = true
+	})
+
+	request = httptest.NewRequest("GET", "/api/v4/test", nil)
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	assert.Equal(t, "node1", response.Header().Get(model.HEADER_NODE_ID))
+}
+
+func TestHandlerServeHTTPBearerToken(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.ServiceSettings.EnableUserAccessTokens = true })
+
+	accessToken, err := th.App.CreateUserAccessToken(&model.UserAccessToken{UserId: th.BasicUser.Id, Description: "cli"})
+	require.Nil(t, err)
+
+	cookieSession, err := th.App.CreateSession(&model.Session{UserId: th.SystemAdminUser.Id, Roles: th.SystemAdminUser.Roles})
+	require.Nil(t, err)
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	var userId string
+	handler := web.NewHandler(func(c *Context, w http.ResponseWriter, r *http.Request) {
+		userId = c.App.Session.UserId
+	})
+
+	serve := func(authHeader string, cookie string) int {
+		userId = ""
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		if authHeader != "" {
+			request.Header.Set(model.HEADER_AUTH, authHeader)
+		}
+		if cookie != "" {
+			request.AddCookie(&http.Cookie{Name: model.SESSION_COOKIE_TOKEN, Value: cookie})
+		}
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response.Code
+	}
+
+	t.Run("bearer only", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("Bearer "+accessToken.Token, ""))
+		assert.Equal(t, th.BasicUser.Id, userId)
+	})
+
+	t.Run("cookie only", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("", cookieSession.Token))
+		assert.Equal(t, th.SystemAdminUser.Id, userId)
+	})
+
+	t.Run("both present", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("Bearer "+accessToken.Token, cookieSession.Token))
+		assert.Equal(t, th.BasicUser.Id, userId, "the bearer token should be preferred over the cookie")
+	})
+
+	t.Run("malformed header with a cookie", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("Bearer", cookieSession.Token))
+		assert.Equal(t, th.SystemAdminUser.Id, userId)
+
+		assert.Equal(t, http.StatusOK, serve("Basic "+accessToken.Token, cookieSession.Token))
+		assert.Equal(t, th.SystemAdminUser.Id, userId)
+	})
+}
+
+func TestHandlerServeHTTPProblemJson(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+	handler := web.NewHandler(func(c *Context, w http.ResponseWriter, r *http.Request) {
+		c.Err = model.NewAppError("test", "api.context.invalid_body_param.app_error", map[string]interface{}{"Name": "test"}, "", http.StatusConflict)
+	})
+
+	t.Run("problem", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		request.Header.Set("Accept", model.PROBLEM_DETAILS_CONTENT_TYPE)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusConflict, response.Code)
+		assert.Equal(t, model.PROBLEM_DETAILS_CONTENT_TYPE, response.Header().Get("Content-Type"))
+
+		problem := model.ProblemDetailsFromJson(response.Body)
+		require.NotNil(t, problem)
+		assert.Equal(t, "about:blank", problem.Type)
+		assert.Equal(t, "Conflict", problem.Title)
+		assert.Equal(t, http.StatusConflict, problem.Status)
+		assert.NotEmpty(t, problem.Detail)
+		assert.Equal(t, response.Header().Get(model.HEADER_REQUEST_ID), problem.Instance)
+		assert.Equal(t, "api.context.invalid_body_param.app_error", problem.Id)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		request := httptest.NewRequest("GET", "/api/v4/test", nil)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		assert.Equal(t, http.StatusConflict, response.Code)
+		assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
+
+		err := model.AppErrorFromJson(response.Body)
+		assert.Equal(t, "api.context.invalid_body_param.app_error", err.Id)
+		assert.Equal(t, http.StatusConflict, err.StatusCode)
+	})
+}
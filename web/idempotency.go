@@ -0,0 +1,77 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/app"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// isIdempotentRequest returns whether the request should be handled at most once for its idempotency key, which is
+// only the case for requests that change something and are made by a user to an Idempotent handler.
+func (h Handler) isIdempotentRequest(c *Context, r *http.Request) bool {
+	return h.Idempotent && r.Method != "GET" && r.Method != "HEAD" && len(c.App.Session.UserId) > 0 && len(r.Header.Get(model.HEADER_IDEMPOTENCY_KEY)) > 0
+}
+
+// serveIdempotent calls HandleFunc unless the user has already made the request with the same idempotency key, in
+// which case the response to that request is sent again instead. A retry made while the original request is still in
+// progress is rejected with a 409, and one made with a different body is rejected with a 422. Failed requests aren't
+// remembered, so they can be retried.
+func (h Handler) serveIdempotent(c *Context, w http.ResponseWriter, r *http.Request) {
+	userId := c.App.Session.UserId
+	key := r.Header.Get(model.HEADER_IDEMPOTENCY_KEY)
+
+	body, readErr := ioutil.ReadAll(r.Body)
+	if readErr != nil {
+		c.Err = model.NewAppError("serveIdempotent", "api.context.idempotency_key.read_body.app_error", nil, readErr.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	cached, err := c.App.StartIdempotentRequest(userId, key, r, model.HashIdempotentRequestBody(body))
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if cached != nil {
+		for name, values := range cached.Header {
+			if name != model.HEADER_REQUEST_ID {
+				w.Header()[name] = values
+			}
+		}
+		w.Header().Set(model.HEADER_IDEMPOTENT_REPLAY, "true")
+		w.WriteHeader(cached.StatusCode)
+		w.Write(cached.Body)
+		return
+	}
+
+	var response *app.IdempotentResponse
+	defer func() {
+		c.App.FinishIdempotentRequest(userId, key, response)
+	}()
+
+	recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+	h.HandleFunc(c, recorder, r)
+
+	if c.Err == nil && recorder.statusCode < http.StatusBadRequest {
+		header := make(http.Header, len(w.Header()))
+		for name, values := range w.Header() {
+			header[name] = append([]string(nil), values...)
+		}
+
+		response = &app.IdempotentResponse{
+			StatusCode: recorder.statusCode,
+			Header:     header,
+			Body:       recorder.body.Bytes(),
+		}
+	}
+
+	recorder.flush(w)
+}
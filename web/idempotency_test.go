@@ -0,0 +1,133 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestHandlerServeIdempotent(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	session, err := th.App.CreateSession(&model.Session{UserId: th.BasicUser.Id, Roles: th.BasicUser.Roles})
+	require.Nil(t, err)
+
+	calls := 0
+	var during func()
+	handler := &Handler{
+		GetGlobalAppOptions: web.GetGlobalAppOptions,
+		HandleFunc: func(c *Context, w http.ResponseWriter, r *http.Request) {
+			calls++
+			if during != nil {
+				during()
+			}
+			if r.URL.Query().Get("fail") != "" {
+				c.Err = model.NewAppError("test", "test", nil, "", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Location", "/created")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("created"))
+		},
+		RequireSession: true,
+		Idempotent:     true,
+	}
+
+	postBody := func(path string, key string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("POST", path, strings.NewReader(body))
+		request.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+session.Token)
+		if key != "" {
+			request.Header.Set(model.HEADER_IDEMPOTENCY_KEY, key)
+		}
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response
+	}
+	post := func(path string, key string) *httptest.ResponseRecorder {
+		return postBody(path, key, "")
+	}
+
+	t.Run("retries are sent the first response", func(t *testing.T) {
+		calls = 0
+		key := model.NewId()
+
+		response := post("/api/v4/test", key)
+		assert.Equal(t, http.StatusCreated, response.Code)
+		assert.Empty(t, response.Header().Get(model.HEADER_IDEMPOTENT_REPLAY))
+
+		response = post("/api/v4/test", key)
+		assert.Equal(t, http.StatusCreated, response.Code)
+		assert.Equal(t, "created", response.Body.String())
+		assert.Equal(t, "/created", response.Header().Get("Location"))
+		assert.Equal(t, "true", response.Header().Get(model.HEADER_IDEMPOTENT_REPLAY))
+		assert.Equal(t, 1, calls)
+
+		post("/api/v4/test", model.NewId())
+		assert.Equal(t, 2, calls, "different keys are handled separately")
+	})
+
+	t.Run("requests without a key are always handled", func(t *testing.T) {
+		calls = 0
+		post("/api/v4/test", "")
+		post("/api/v4/test", "")
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("failed requests can be retried", func(t *testing.T) {
+		calls = 0
+		key := model.NewId()
+		assert.Equal(t, http.StatusBadRequest, post("/api/v4/test?fail=1", key).Code)
+		assert.Equal(t, http.StatusCreated, post("/api/v4/test", key).Code)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("keys can't be reused for other requests", func(t *testing.T) {
+		key := model.NewId()
+		post("/api/v4/test", key)
+		assert.Equal(t, http.StatusUnprocessableEntity, post("/api/v4/other", key).Code)
+	})
+
+	t.Run("keys can't be reused with a different body", func(t *testing.T) {
+		calls = 0
+		key := model.NewId()
+		assert.Equal(t, http.StatusCreated, postBody("/api/v4/test", key, `{"message":"first"}`).Code)
+		assert.Equal(t, http.StatusCreated, postBody("/api/v4/test", key, `{"message":"first"}`).Code)
+		assert.Equal(t, http.StatusUnprocessableEntity, postBody("/api/v4/test", key, `{"message":"second"}`).Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("concurrent retries are rejected", func(t *testing.T) {
+		calls = 0
+		key := model.NewId()
+
+		var concurrent *httptest.ResponseRecorder
+		during = func() {
+			during = nil
+			concurrent = post("/api/v4/test", key)
+		}
+		defer func() {
+			during = nil
+		}()
+
+		assert.Equal(t, http.StatusCreated, post("/api/v4/test", key).Code)
+		require.NotNil(t, concurrent)
+		assert.Equal(t, http.StatusConflict, concurrent.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("keys are too long", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, post("/api/v4/test", model.NewRandomString(256)).Code)
+	})
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// The reasons that a field of a request body can be rejected for. Validation functions may use their own, but should
+// prefer these so that clients can handle them the same way for every endpoint.
+const (
+	FIELD_ERROR_REQUIRED     = "required"
+	FIELD_ERROR_INVALID      = "invalid"
+	FIELD_ERROR_INVALID_TYPE = "invalid_type"
+	FIELD_ERROR_UNKNOWN      = "unknown"
+)
+
+// FieldErrors collects the fields of a request body that are invalid, mapping the JSON name of each to the reason that
+// it was rejected for, such as FIELD_ERROR_REQUIRED.
+type FieldErrors map[string]string
+
+// Add rejects a field for the given reason, unless it's already been rejected for another.
+func (e FieldErrors) Add(field string, reason string) {
+	if _, ok := e[field]; !ok {
+		e[field] = reason
+	}
+}
+
+func (e FieldErrors) names() []string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// DecodeJSONBody decodes the JSON body of a request into target, and then passes it to validate, if given, to check
+// its fields. Fields of the wrong type, and fields that target doesn't have when strict is set, are rejected along
+// with the ones found by validate, so that every problem with a body is reported at once. It returns false with c.Err
+// set to a 400 whose params list the invalid fields if the body can't be used.
+func (c *Context) DecodeJSONBody(r *http.Request, target interface{}, strict bool, validate func(fieldErrors FieldErrors)) bool {
+	decoder := json.NewDecoder(r.Body)
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+
+	fieldErrors := FieldErrors{}
+
+	if err := decoder.Decode(target); err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+			fieldErrors.Add(typeErr.Field, FIELD_ERROR_INVALID_TYPE)
+		} else if field, ok := unknownFieldFromDecodeError(err); ok {
+			fieldErrors.Add(field, FIELD_ERROR_UNKNOWN)
+		} else {
+			details := err.Error()
+			if err == io.EOF {
+				details = "empty body"
+			}
+
+			c.Err = model.NewAppError("DecodeJSONBody", "api.context.invalid_body.app_error", nil, details, http.StatusBadRequest)
+			return false
+		}
+	} else if validate != nil {
+		validate(fieldErrors)
+	}
+
+	if len(fieldErrors) > 0 {
+		names := fieldErrors.names()
+		params := map[string]interface{}{
+			"Names":  strings.Join(names, ", "),
+			"Fields": map[string]string(fieldErrors),
+		}
+		c.Err = model.NewAppError("DecodeJSONBody", "api.context.invalid_body_fields.app_error", params, "", http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// unknownFieldFromDecodeError returns the name of the field that a strict json.Decoder failed on, since the error for
+// it doesn't have a type of its own.
+func unknownFieldFromDecodeError(err error) (string, bool) {
+	const prefix = "json: unknown field "
+	if !strings.HasPrefix(err.Error(), prefix) {
+		return "", false
+	}
+
+	field, unquoteErr := strconv.Unquote(strings.TrimPrefix(err.Error(), prefix))
+	if unquoteErr != nil {
+		return "", false
+	}
+
+	return field, true
+}
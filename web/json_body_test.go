@@ -0,0 +1,101 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRequestBody struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func validateTestRequestBody(body *testRequestBody) func(FieldErrors) {
+	return func(fieldErrors FieldErrors) {
+		if body.Name == "" {
+			fieldErrors.Add("name", FIELD_ERROR_REQUIRED)
+		}
+		if body.Count < 0 {
+			fieldErrors.Add("count", FIELD_ERROR_INVALID)
+		}
+	}
+}
+
+func TestDecodeJSONBody(t *testing.T) {
+	for name, tc := range map[string]struct {
+		Body           string
+		Strict         bool
+		ExpectedError  string
+		ExpectedFields map[string]string
+	}{
+		"valid": {
+			Body: `{"name": "test", "count": 1}`,
+		},
+		"unknown field": {
+			Body: `{"name": "test", "colour": "red"}`,
+		},
+		"unknown field in strict mode": {
+			Body:           `{"name": "test", "colour": "red"}`,
+			Strict:         true,
+			ExpectedError:  "api.context.invalid_body_fields.app_error",
+			ExpectedFields: map[string]string{"colour": FIELD_ERROR_UNKNOWN},
+		},
+		"wrong type": {
+			Body:           `{"name": "test", "count": "one"}`,
+			ExpectedError:  "api.context.invalid_body_fields.app_error",
+			ExpectedFields: map[string]string{"count": FIELD_ERROR_INVALID_TYPE},
+		},
+		"failed validation": {
+			Body:           `{"count": -1}`,
+			ExpectedError:  "api.context.invalid_body_fields.app_error",
+			ExpectedFields: map[string]string{"name": FIELD_ERROR_REQUIRED, "count": FIELD_ERROR_INVALID},
+		},
+		"malformed": {
+			Body:          `{"name": `,
+			ExpectedError: "api.context.invalid_body.app_error",
+		},
+		"empty": {
+			Body:          ``,
+			ExpectedError: "api.context.invalid_body.app_error",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := &Context{}
+			r := httptest.NewRequest("POST", "/api/v4/test", strings.NewReader(tc.Body))
+
+			var body testRequestBody
+			ok := c.DecodeJSONBody(r, &body, tc.Strict, validateTestRequestBody(&body))
+
+			if tc.ExpectedError == "" {
+				assert.True(t, ok)
+				assert.Nil(t, c.Err)
+				assert.Equal(t, "test", body.Name)
+				return
+			}
+
+			assert.False(t, ok)
+			require.NotNil(t, c.Err)
+			assert.Equal(t, tc.ExpectedError, c.Err.Id)
+			assert.Equal(t, http.StatusBadRequest, c.Err.StatusCode)
+
+			if tc.ExpectedFields != nil {
+				params := struct {
+					Params struct {
+						Fields map[string]string `json:"Fields"`
+					} `json:"params"`
+				}{}
+				require.Nil(t, json.Unmarshal([]byte(c.Err.ToJson()), &params))
+				assert.Equal(t, tc.ExpectedFields, params.Params.Fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/mattermost/mattermost-server/services/configservice"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+// jsonErrorPathRegistry holds the path prefixes whose errors are always rendered as JSON, rather than by redirecting
+// to the error page, because they're called by programs rather than browsers.
+type jsonErrorPathRegistry struct {
+	mutex    sync.RWMutex
+	prefixes map[string]bool
+}
+
+// jsonErrorPaths starts out with the built-in routes that are called programmatically: the REST API under /api, the
+// incoming webhooks and slash command responses under /hooks, and the health checks under /health.
+var jsonErrorPaths = &jsonErrorPathRegistry{
+	prefixes: map[string]bool{
+		"/api":    true,
+		"/hooks":  true,
+		"/health": true,
+	},
+}
+
+// RegisterJsonErrorPathPrefix makes the errors of every route below the given path prefix, such as "/receivers", be
+// rendered as JSON, whatever the client. The prefix is relative to the subpath of the site.
+func RegisterJsonErrorPathPrefix(prefix string) {
+	jsonErrorPaths.mutex.Lock()
+	defer jsonErrorPaths.mutex.Unlock()
+
+	jsonErrorPaths.prefixes[path.Join("/", prefix)] = true
+}
+
+// UnregisterJsonErrorPathPrefix undoes RegisterJsonErrorPathPrefix, so that the errors below the given path prefix are
+// rendered for the client again.
+func UnregisterJsonErrorPathPrefix(prefix string) {
+	jsonErrorPaths.mutex.Lock()
+	defer jsonErrorPaths.mutex.Unlock()
+
+	delete(jsonErrorPaths.prefixes, path.Join("/", prefix))
+}
+
+// IsJsonErrorPath returns whether the errors of a request must be rendered as JSON because it's below one of the
+// registered path prefixes.
+func IsJsonErrorPath(config configservice.ConfigService, r *http.Request) bool {
+	subpath, _ := utils.GetSubpathFromConfig(config.Config())
+
+	jsonErrorPaths.mutex.RLock()
+	defer jsonErrorPaths.mutex.RUnlock()
+
+	for prefix := range jsonErrorPaths.prefixes {
+		if strings.HasPrefix(r.URL.Path, path.Join(subpath, prefix)+"/") {
+			return true
+		}
+	}
+
+	return false
+}
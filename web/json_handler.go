@@ -0,0 +1,89 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// JSONHandlerFunc is a handler that returns the value to respond with instead of writing it itself. The value is
+// marshalled to JSON by the Handler, and a returned error is handled in the same way as if it had been set as c.Err.
+// Returning a nil value responds with {"status": "OK"}.
+type JSONHandlerFunc func(*Context, *http.Request) (interface{}, *model.AppError)
+
+// JSONResponse can be returned by a JSONHandlerFunc to respond with a status code other than 200 OK. A zero
+// StatusCode is treated as 200 OK.
+type JSONResponse struct {
+	StatusCode int
+	Data       interface{}
+}
+
+// jsonMarshaller is implemented by the model types that have their own JSON serialization, which is used in
+// preference to encoding/json so that responses are the same as those of handlers that call ToJson themselves.
+type jsonMarshaller interface {
+	ToJson() string
+}
+
+func (w *Web) NewJSONHandler(h JSONHandlerFunc) http.Handler {
+	return &Handler{
+		GetGlobalAppOptions: w.GetGlobalAppOptions,
+		HandleFunc:          HandleJSON(h),
+		RequireSession:      false,
+		TrustRequester:      false,
+		RequireMfa:          false,
+		IsStatic:            false,
+		RequireJSONBody:     true,
+	}
+}
+
+// HandleJSON adapts a JSONHandlerFunc to the signature of Handler.HandleFunc, so that it can be used anywhere that a
+// regular handler can, such as in the API's session-required handlers.
+func HandleJSON(h JSONHandlerFunc) func(*Context, http.ResponseWriter, *http.Request) {
+	return func(c *Context, w http.ResponseWriter, r *http.Request) {
+		value, err := h(c, r)
+		if err != nil {
+			c.Err = err
+			return
+		} else if c.Err != nil {
+			return
+		}
+
+		writeJSONResponse(c, w, value)
+	}
+}
+
+func writeJSONResponse(c *Context, w http.ResponseWriter, value interface{}) {
+	statusCode := http.StatusOK
+	if response, ok := value.(*JSONResponse); ok {
+		statusCode = response.StatusCode
+		value = response.Data
+	} else if response, ok := value.(JSONResponse); ok {
+		statusCode = response.StatusCode
+		value = response.Data
+	}
+
+	if value == nil {
+		value = map[string]string{model.STATUS: model.STATUS_OK}
+	}
+
+	var body []byte
+	if marshaller, ok := value.(jsonMarshaller); ok {
+		body = []byte(marshaller.ToJson())
+	} else {
+		var err error
+		if body, err = json.Marshal(value); err != nil {
+			c.Err = model.NewAppError("writeJSONResponse", "web.json_handler.marshal.app_error", nil, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", CONTENT_TYPE_JSON)
+	if statusCode != 0 && statusCode != http.StatusOK {
+		w.WriteHeader(statusCode)
+	}
+	w.Write(body)
+}
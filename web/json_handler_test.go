@@ -0,0 +1,113 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestHandleJSON(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	serve := func(h JSONHandlerFunc) *httptest.ResponseRecorder {
+		handler := NewTestHandler(web.GetGlobalAppOptions, HandleJSON(h))
+
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, httptest.NewRequest("GET", "/api/v4/test", nil))
+		return response
+	}
+
+	t.Run("model types are serialized with ToJson", func(t *testing.T) {
+		response := serve(func(c *Context, r *http.Request) (interface{}, *model.AppError) {
+			return th.BasicChannel, nil
+		})
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, CONTENT_TYPE_JSON, response.Header().Get("Content-Type"))
+		assert.Equal(t, th.BasicChannel.ToJson(), response.Body.String())
+	})
+
+	t.Run("other values are marshalled with encoding/json", func(t *testing.T) {
+		response := serve(func(c *Context, r *http.Request) (interface{}, *model.AppError) {
+			return []string{"a", "b"}, nil
+		})
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, `["a","b"]`, response.Body.String())
+	})
+
+	t.Run("nil responds with status OK", func(t *testing.T) {
+		response := serve(func(c *Context, r *http.Request) (interface{}, *model.AppError) {
+			return nil, nil
+		})
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, model.STATUS_OK, model.MapFromJson(response.Body)[model.STATUS])
+	})
+
+	t.Run("status code", func(t *testing.T) {
+		response := serve(func(c *Context, r *http.Request) (interface{}, *model.AppError) {
+			return &JSONResponse{StatusCode: http.StatusCreated, Data: th.BasicChannel}, nil
+		})
+
+		assert.Equal(t, http.StatusCreated, response.Code)
+		assert.Equal(t, th.BasicChannel.ToJson(), response.Body.String())
+	})
+
+	t.Run("missing status code", func(t *testing.T) {
+		response := serve(func(c *Context, r *http.Request) (interface{}, *model.AppError) {
+			return &JSONResponse{Data: th.BasicChannel}, nil
+		})
+
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, th.BasicChannel.ToJson(), response.Body.String())
+	})
+
+	t.Run("error without a status code", func(t *testing.T) {
+		response := serve(func(c *Context, r *http.Request) (interface{}, *model.AppError) {
+			return nil, model.NewAppError("test", "api.context.permissions.app_error", nil, "", 0)
+		})
+
+		assert.Equal(t, http.StatusInternalServerError, response.Code)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		response := serve(func(c *Context, r *http.Request) (interface{}, *model.AppError) {
+			return th.BasicChannel, model.NewAppError("test", "api.context.permissions.app_error", nil, "", http.StatusForbidden)
+		})
+
+		assert.Equal(t, http.StatusForbidden, response.Code)
+		appErr := model.AppErrorFromJson(response.Body)
+		require.NotNil(t, appErr)
+		assert.Equal(t, "api.context.permissions.app_error", appErr.Id)
+	})
+
+	t.Run("error set on the context", func(t *testing.T) {
+		response := serve(func(c *Context, r *http.Request) (interface{}, *model.AppError) {
+			c.SetInvalidParam("channel_id")
+			return nil, nil
+		})
+
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.NotContains(t, response.Body.String(), model.STATUS_OK)
+	})
+
+	t.Run("unmarshallable value", func(t *testing.T) {
+		response := serve(func(c *Context, r *http.Request) (interface{}, *model.AppError) {
+			return make(chan int), nil
+		})
+
+		assert.Equal(t, http.StatusInternalServerError, response.Code)
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// JSON_NAMING_PARAM is the parameter of the Accept header that clients use to ask for JSON field names other than the
+// default snake_case, such as "Accept: application/json; naming=camelCase".
+const JSON_NAMING_PARAM = "naming"
+
+// camelCaseEtagSuffix distinguishes the ETags of camelCase responses from those of the same resources in snake_case,
+// since they're different representations and mustn't be mistaken for each other by caches.
+const camelCaseEtagSuffix = "-camelCase"
+
+// requestedJsonFieldNaming returns the naming of JSON fields asked for by the Accept header of a request.
+func requestedJsonFieldNaming(r *http.Request) string {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != CONTENT_TYPE_JSON {
+			continue
+		}
+
+		if strings.EqualFold(params[JSON_NAMING_PARAM], model.JSON_FIELD_NAMING_CAMEL_CASE) {
+			return model.JSON_FIELD_NAMING_CAMEL_CASE
+		}
+	}
+
+	return model.JSON_FIELD_NAMING_SNAKE_CASE
+}
+
+func addCamelCaseEtagSuffix(etag string) string {
+	if strings.HasSuffix(etag, `"`) && len(etag) > 1 {
+		return etag[:len(etag)-1] + camelCaseEtagSuffix + `"`
+	}
+	return etag + camelCaseEtagSuffix
+}
+
+// stripCamelCaseEtagSuffixes rewrites the If-None-Match header of a request for camelCase JSON so that handlers can
+// compare it against the ETags they compute as usual. ETags of snake_case responses are dropped so that they never
+// match.
+func stripCamelCaseEtagSuffixes(r *http.Request) {
+	ifNoneMatch := r.Header.Get(model.HEADER_ETAG_CLIENT)
+	if ifNoneMatch == "" {
+		return
+	}
+
+	var etags []string
+	for _, etag := range strings.Split(ifNoneMatch, ",") {
+		etag = strings.TrimSpace(etag)
+		if etag == "*" {
+			etags = append(etags, etag)
+		} else if quoted := strings.TrimSuffix(etag, camelCaseEtagSuffix+`"`); quoted != etag {
+			etags = append(etags, quoted+`"`)
+		} else if unquoted := strings.TrimSuffix(etag, camelCaseEtagSuffix); unquoted != etag {
+			etags = append(etags, unquoted)
+		}
+	}
+
+	if len(etags) == 0 {
+		r.Header.Del(model.HEADER_ETAG_CLIENT)
+	} else {
+		r.Header.Set(model.HEADER_ETAG_CLIENT, strings.Join(etags, ", "))
+	}
+}
+
+// camelCaseResponseWriter holds back JSON responses so that their field names can be converted to camelCase once
+// they've been written in full. Whether a response is JSON is decided by its Content-Type when it starts being
+// written, and anything else, such as a file download, is passed straight through.
+type camelCaseResponseWriter struct {
+	http.ResponseWriter
+	decided    bool
+	buffering  bool
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *camelCaseResponseWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+
+	contentType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	w.buffering = contentType == CONTENT_TYPE_JSON
+}
+
+func (w *camelCaseResponseWriter) WriteHeader(statusCode int) {
+	w.decide()
+	if !w.buffering {
+		w.ResponseWriter.WriteHeader(statusCode)
+	} else if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+func (w *camelCaseResponseWriter) Write(b []byte) (int, error) {
+	w.decide()
+	if !w.buffering {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.body.Write(b)
+}
+
+// finish converts and writes the held back response, if there is one. A body that can't be converted, which
+// shouldn't happen for a JSON response, is written as it is.
+func (w *camelCaseResponseWriter) finish() {
+	if !w.buffering {
+		return
+	}
+
+	if etag := w.Header().Get(model.HEADER_ETAG_SERVER); etag != "" {
+		w.Header().Set(model.HEADER_ETAG_SERVER, addCamelCaseEtagSuffix(etag))
+	}
+
+	body := w.body.Bytes()
+	if converted, err := model.ConvertJsonFieldNames(body, model.JSON_FIELD_NAMING_CAMEL_CASE); err == nil {
+		body = converted
+	}
+	w.Header().Del("Content-Length")
+
+	if w.statusCode != 0 {
+		w.ResponseWriter.WriteHeader(w.statusCode)
+	}
+	w.ResponseWriter.Write(body)
+}
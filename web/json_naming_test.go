@@ -0,0 +1,51 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestRequestedJsonFieldNaming(t *testing.T) {
+	for accept, expected := range map[string]string{
+		"":                                    model.JSON_FIELD_NAMING_SNAKE_CASE,
+		"application/json":                    model.JSON_FIELD_NAMING_SNAKE_CASE,
+		"application/json; naming=snake_case": model.JSON_FIELD_NAMING_SNAKE_CASE,
+		"application/json; naming=camelCase":  model.JSON_FIELD_NAMING_CAMEL_CASE,
+		"application/json;naming=camelcase":   model.JSON_FIELD_NAMING_CAMEL_CASE,
+		"text/csv, application/json; naming=camelCase;q=0.5": model.JSON_FIELD_NAMING_CAMEL_CASE,
+		"text/csv; naming=camelCase":                         model.JSON_FIELD_NAMING_SNAKE_CASE,
+	} {
+		request := httptest.NewRequest("GET", "/api/v4/users", nil)
+		request.Header.Set("Accept", accept)
+		assert.Equal(t, expected, requestedJsonFieldNaming(request), accept)
+	}
+}
+
+func TestStripCamelCaseEtagSuffixes(t *testing.T) {
+	for ifNoneMatch, expected := range map[string]string{
+		"":                         "",
+		"5.10.abc-camelCase":       "5.10.abc",
+		`"abc-camelCase"`:          `"abc"`,
+		`W/"abc-camelCase", "def"`: `W/"abc"`,
+		"5.10.abc":                 "",
+		"*":                        "*",
+	} {
+		request := httptest.NewRequest("GET", "/api/v4/users", nil)
+		if ifNoneMatch != "" {
+			request.Header.Set(model.HEADER_ETAG_CLIENT, ifNoneMatch)
+		}
+
+		stripCamelCaseEtagSuffixes(request)
+		assert.Equal(t, expected, request.Header.Get(model.HEADER_ETAG_CLIENT), ifNoneMatch)
+	}
+
+	assert.Equal(t, "5.10.abc-camelCase", addCamelCaseEtagSuffix("5.10.abc"))
+	assert.Equal(t, `"abc-camelCase"`, addCamelCaseEtagSuffix(`"abc"`))
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// JSON_STREAM_FLUSH_ELEMENTS is how many elements of a streamed JSON array are written between flushes of the response.
+const JSON_STREAM_FLUSH_ELEMENTS = 100
+
+// JSONArrayProducer produces the elements of a streamed JSON array, such as by paging through them in the store,
+// passing each to write as soon as it has it. It stops if write returns an error, which means that the response can't
+// be written to any more, and returns that error or any of its own.
+type JSONArrayProducer func(write func(element interface{}) *model.AppError) *model.AppError
+
+// StreamJSONArray responds with a JSON array of the elements that produce writes, writing each one as it's produced
+// rather than building the whole array first, so that handlers listing many objects don't need to hold them all in
+// memory. Elements are marshalled like the responses of JSON handlers.
+//
+// The status of the response can't be changed once the array has been started, which happens when the first element is
+// written. An error before then is set as c.Err as usual, while one after is logged and the array is ended early,
+// leaving the client with fewer elements than it should have. Responses converted to camelCase are still buffered in
+// full, since they're converted as a whole.
+func (c *Context) StreamJSONArray(w http.ResponseWriter, produce JSONArrayProducer) {
+	stream := &jsonArrayStream{w: w}
+
+	if err := produce(stream.write); err != nil {
+		if !stream.started {
+			c.Err = err
+			return
+		}
+
+		c.Log.Error("Ended a streamed JSON array early", mlog.Int("elements", stream.count), mlog.Err(err))
+	}
+
+	stream.close()
+}
+
+// jsonArrayStream writes a JSON array to a response one element at a time.
+type jsonArrayStream struct {
+	w       http.ResponseWriter
+	buffer  bytes.Buffer
+	started bool
+	count   int
+}
+
+func (s *jsonArrayStream) write(element interface{}) *model.AppError {
+	// Each element is marshalled before anything is written, so that one that can't be leaves the array intact
+	s.buffer.Reset()
+	if marshaller, ok := element.(jsonMarshaller); ok {
+		s.buffer.WriteString(marshaller.ToJson())
+	} else if err := json.NewEncoder(&s.buffer).Encode(element); err != nil {
+		return model.NewAppError("StreamJSONArray", "web.json_handler.marshal.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	if !s.started {
+		s.w.Header().Set("Content-Type", CONTENT_TYPE_JSON)
+		if _, err := s.w.Write([]byte("[")); err != nil {
+			return model.NewAppError("StreamJSONArray", "web.json_stream.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+		s.started = true
+	} else if _, err := s.w.Write([]byte(",")); err != nil {
+		return model.NewAppError("StreamJSONArray", "web.json_stream.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	if _, err := s.w.Write(bytes.TrimSpace(s.buffer.Bytes())); err != nil {
+		return model.NewAppError("StreamJSONArray", "web.json_stream.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	s.count++
+	if s.count%JSON_STREAM_FLUSH_ELEMENTS == 0 {
+		s.flush()
+	}
+
+	return nil
+}
+
+// close ends the array, or writes an empty one if no elements were written.
+func (s *jsonArrayStream) close() {
+	if !s.started {
+		s.w.Header().Set("Content-Type", CONTENT_TYPE_JSON)
+		s.w.Write([]byte("[]"))
+		return
+	}
+
+	s.w.Write([]byte("]"))
+	s.flush()
+}
+
+func (s *jsonArrayStream) flush() {
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
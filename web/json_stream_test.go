@@ -0,0 +1,132 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+type flushCountingRecorder struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (r *flushCountingRecorder) Flush() {
+	r.flushes++
+	r.ResponseRecorder.Flush()
+}
+
+func newJSONStreamContext() *Context {
+	return &Context{
+		Log: mlog.NewLogger(&mlog.LoggerConfiguration{
+			EnableConsole: true,
+			ConsoleLevel:  "error",
+		}),
+	}
+}
+
+func TestContextStreamJSONArray(t *testing.T) {
+	t.Run("elements", func(t *testing.T) {
+		c := newJSONStreamContext()
+		w := httptest.NewRecorder()
+
+		c.StreamJSONArray(w, func(write func(interface{}) *model.AppError) *model.AppError {
+			write(&model.Team{Id: "team1"})
+			write(map[string]string{"id": "team2"})
+			write("team3")
+			return nil
+		})
+
+		require.Nil(t, c.Err)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, CONTENT_TYPE_JSON, w.Header().Get("Content-Type"))
+
+		teams := model.TeamListFromJson(w.Body)
+		require.Len(t, teams, 3)
+		assert.Equal(t, "team1", teams[0].Id)
+		assert.Equal(t, "team2", teams[1].Id)
+	})
+
+	t.Run("no elements", func(t *testing.T) {
+		c := newJSONStreamContext()
+		w := httptest.NewRecorder()
+
+		c.StreamJSONArray(w, func(write func(interface{}) *model.AppError) *model.AppError {
+			return nil
+		})
+
+		require.Nil(t, c.Err)
+		assert.Equal(t, CONTENT_TYPE_JSON, w.Header().Get("Content-Type"))
+		assert.Equal(t, "[]", w.Body.String())
+	})
+
+	t.Run("flushes periodically", func(t *testing.T) {
+		c := newJSONStreamContext()
+		w := &flushCountingRecorder{ResponseRecorder: httptest.NewRecorder()}
+
+		c.StreamJSONArray(w, func(write func(interface{}) *model.AppError) *model.AppError {
+			for i := 0; i < JSON_STREAM_FLUSH_ELEMENTS*2+1; i++ {
+				write(i)
+			}
+			return nil
+		})
+
+		require.Nil(t, c.Err)
+		assert.Equal(t, 3, w.flushes, "should flush after every batch of elements and at the end")
+	})
+
+	t.Run("error before the first element", func(t *testing.T) {
+		c := newJSONStreamContext()
+		w := httptest.NewRecorder()
+
+		c.StreamJSONArray(w, func(write func(interface{}) *model.AppError) *model.AppError {
+			return model.NewAppError("test", "test.app_error", nil, "", http.StatusNotFound)
+		})
+
+		require.NotNil(t, c.Err)
+		assert.Equal(t, http.StatusNotFound, c.Err.StatusCode)
+		assert.Empty(t, w.Body.String(), "nothing should be written so that the error can be")
+	})
+
+	t.Run("error after the first element", func(t *testing.T) {
+		c := newJSONStreamContext()
+		w := httptest.NewRecorder()
+
+		c.StreamJSONArray(w, func(write func(interface{}) *model.AppError) *model.AppError {
+			write("a")
+			write("b")
+			return model.NewAppError("test", "test.app_error", nil, "", http.StatusInternalServerError)
+		})
+
+		assert.Nil(t, c.Err, "the status can't be changed once the array has started")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `["a","b"]`, w.Body.String())
+	})
+
+	t.Run("element that can't be marshalled", func(t *testing.T) {
+		c := newJSONStreamContext()
+		w := httptest.NewRecorder()
+
+		c.StreamJSONArray(w, func(write func(interface{}) *model.AppError) *model.AppError {
+			if err := write("a"); err != nil {
+				return err
+			}
+			if err := write(make(chan int)); err != nil {
+				return err
+			}
+			return nil
+		})
+
+		assert.Nil(t, c.Err)
+		assert.Equal(t, `["a"]`, w.Body.String())
+	})
+}
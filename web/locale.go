@@ -0,0 +1,98 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"strings"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+// Locale returns the locale that the response should be in, which is the first supported one of the locale query
+// parameter, the locale of the user making the request, the Accept-Language header and the site's default client
+// locale. Unsupported locales are skipped rather than rejected. The locale is only resolved once, after which c.App.T
+// translates into it, which is what errors returned by the handler are translated with.
+func (c *Context) Locale() string {
+	if c.locale == "" {
+		c.locale = c.resolveLocale()
+		c.App.T = utils.GetUserTranslations(c.locale)
+	}
+
+	return c.locale
+}
+
+func (c *Context) resolveLocale() string {
+	supported := c.supportedLocales()
+
+	if locale, ok := matchLocale(c.Params.Locale, supported); ok {
+		return locale
+	}
+
+	if len(c.App.Session.UserId) > 0 {
+		if user, err := c.App.GetUser(c.App.Session.UserId); err == nil {
+			if locale, ok := matchLocale(user.Locale, supported); ok {
+				return locale
+			}
+		}
+	}
+
+	// Languages are listed in order of preference, so any quality values are ignored
+	for _, language := range strings.Split(c.App.AcceptLanguage, ",") {
+		language = strings.Split(language, ";")[0]
+		if locale, ok := matchLocale(language, supported); ok {
+			return locale
+		}
+	}
+
+	if locale, ok := matchLocale(*c.App.Config().LocalizationSettings.DefaultClientLocale, supported); ok {
+		return locale
+	}
+
+	return model.DEFAULT_LOCALE
+}
+
+// supportedLocales returns the locales that there are translations for, limited to those in
+// LocalizationSettings.AvailableLocales if it's set, keyed by their lower case names.
+func (c *Context) supportedLocales() map[string]string {
+	settings := c.App.Config().LocalizationSettings
+
+	var available map[string]bool
+	if len(*settings.AvailableLocales) > 0 {
+		available = map[string]bool{*settings.DefaultClientLocale: true}
+		for _, locale := range strings.Split(*settings.AvailableLocales, ",") {
+			available[strings.TrimSpace(locale)] = true
+		}
+	}
+
+	supported := make(map[string]string)
+	for locale := range utils.GetSupportedLocales() {
+		if available == nil || available[locale] {
+			supported[strings.ToLower(locale)] = locale
+		}
+	}
+
+	return supported
+}
+
+// matchLocale returns the supported locale that was asked for, ignoring case and whether it's written like pt_BR or
+// pt-BR, or else the supported locale for its language.
+func matchLocale(requested string, supported map[string]string) (string, bool) {
+	requested = strings.ToLower(strings.Replace(strings.TrimSpace(requested), "_", "-", -1))
+	if requested == "" {
+		return "", false
+	}
+
+	if locale, ok := supported[requested]; ok {
+		return locale, true
+	}
+
+	if language := strings.Split(requested, "-")[0]; language != requested {
+		if locale, ok := supported[language]; ok {
+			return locale, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestMatchLocale(t *testing.T) {
+	supported := map[string]string{"en": "en", "es": "es", "pt-br": "pt-BR"}
+
+	for requested, expected := range map[string]string{
+		"es":     "es",
+		" ES ":   "es",
+		"pt_BR":  "pt-BR",
+		"pt-br":  "pt-BR",
+		"en-US":  "en",
+		"es-419": "es",
+		"pt":     "",
+		"fr":     "",
+		"":       "",
+	} {
+		locale, ok := matchLocale(requested, supported)
+		assert.Equal(t, expected, locale, requested)
+		assert.Equal(t, expected != "", ok, requested)
+	}
+}
+
+func TestContextLocale(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.LocalizationSettings.DefaultClientLocale = "es"
+		*cfg.LocalizationSettings.AvailableLocales = ""
+	})
+
+	user := th.BasicUser
+	user.Locale = "fr"
+	_, err := th.App.UpdateUser(user, false)
+	assert.Nil(t, err)
+
+	locale := func(param string, userId string, acceptLanguage string) string {
+		app := *th.App
+		app.Session = model.Session{UserId: userId}
+		app.AcceptLanguage = acceptLanguage
+
+		c := &Context{App: &app, Params: &Params{Locale: param}}
+		return c.Locale()
+	}
+
+	assert.Equal(t, "de", locale("de", user.Id, "ja"), "the query parameter comes first")
+	assert.Equal(t, "fr", locale("", user.Id, "ja"), "then the user's locale")
+	assert.Equal(t, "fr", locale("xx", user.Id, "ja"), "unsupported locales are skipped")
+	assert.Equal(t, "ja", locale("", "", "xx, ja-JP;q=0.9, de;q=0.8"), "then the Accept-Language header")
+	assert.Equal(t, "es", locale("", "", ""), "then the default client locale")
+
+	th.App.UpdateConfig(func(cfg *model.Config) {
+		*cfg.LocalizationSettings.AvailableLocales = "es,ja"
+	})
+
+	assert.Equal(t, "ja", locale("de", user.Id, "ja"), "locales that aren't available are skipped")
+
+	c := &Context{App: th.App, Params: &Params{Locale: "ja"}}
+	c.Locale()
+	err = model.NewAppError("test", "api.context.invalid_param.app_error", map[string]interface{}{"Name": "test"}, "", http.StatusBadRequest)
+	err.Translate(c.App.T)
+	assert.Equal(t, "不正なtestパラメーターです", err.Message)
+}
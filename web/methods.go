@@ -0,0 +1,78 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/services/configservice"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+// AllowedMethods returns the methods that routes of the router were registered with for the path of the request, so
+// that a request for a path that exists with another method can be told apart from one for a path that doesn't exist
+// at all. Routes registered without methods, such as catch-alls, are ignored, as are those behind a feature flag that
+// isn't enabled.
+func AllowedMethods(config *model.Config, router *mux.Router, r *http.Request) []string {
+	allowed := map[string]bool{}
+
+	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil || len(methods) == 0 {
+			return nil
+		}
+
+		if handler, ok := route.GetHandler().(*Handler); ok && handler.isDisabledByFeatureFlag(config) {
+			return nil
+		}
+
+		candidate := *r
+		candidate.Method = methods[0]
+		if route.Match(&candidate, &mux.RouteMatch{}) {
+			for _, method := range methods {
+				allowed[method] = true
+			}
+		}
+
+		return nil
+	})
+
+	methods := make([]string, 0, len(allowed))
+	for method := range allowed {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	return methods
+}
+
+// HandleMethodNotAllowed rejects a request whose method isn't one of those allowed for its path, which are listed in
+// the Allow header of the response.
+func HandleMethodNotAllowed(config configservice.ConfigService, w http.ResponseWriter, r *http.Request, allowed []string) {
+	err := model.NewAppError("HandleMethodNotAllowed", "api.context.method_not_allowed.app_error", map[string]interface{}{"Method": r.Method}, "allowed="+strings.Join(allowed, ","), http.StatusMethodNotAllowed)
+
+	mlog.Debug(fmt.Sprintf("%v: code=405 method=%v ip=%v", r.URL.Path, r.Method, utils.GetIpAddress(r)))
+
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+
+	if IsApiCall(config, r) {
+		utils.RenderApiError(config.Config(), w, r, err)
+	} else {
+		utils.RenderWebAppError(config.Config(), w, r, err, config.AsymmetricSigningKey())
+	}
+}
+
+// methodNotAllowedHandler is used by the router when a path only matched routes registered for other methods.
+func (w *Web) methodNotAllowedHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		HandleMethodNotAllowed(w.ConfigService, rw, r, AllowedMethods(w.ConfigService.Config(), w.MainRouter, r))
+	})
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestAllowedMethods(t *testing.T) {
+	config := &model.Config{}
+	config.SetDefaults()
+	config.FeatureFlagSettings.Flags["enabled"] = true
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	root := mux.NewRouter()
+	api := root.PathPrefix("/api/v4").Subrouter()
+	users := api.PathPrefix("/users/{user_id:[a-z0-9]+}").Subrouter()
+	users.Handle("", handler).Methods("GET")
+	users.Handle("", handler).Methods("PUT", "DELETE")
+	users.Handle("/image", handler).Methods("GET")
+	api.Handle("/system/ping", handler).Methods("GET")
+	api.Handle("/system/enabled", FeatureFlagged("enabled", &Handler{})).Methods("GET")
+	api.Handle("/system/disabled", FeatureFlagged("disabled", &Handler{})).Methods("GET")
+	root.Handle("/api/v4/{anything:.*}", handler)
+
+	for path, expected := range map[string][]string{
+		"/api/v4/users/abc":       {"DELETE", "GET", "PUT"},
+		"/api/v4/users/abc/image": {"GET"},
+		"/api/v4/users/ABC":       {},
+		"/api/v4/system/ping":     {"GET"},
+		"/api/v4/system/pong":     {},
+		"/api/v4/system/enabled":  {"GET"},
+		"/api/v4/system/disabled": {},
+		"/login":                  {},
+	} {
+		t.Run(path, func(t *testing.T) {
+			assert.Equal(t, expected, AllowedMethods(config, root, httptest.NewRequest("POST", path, nil)))
+		})
+	}
+
+	t.Run("only the routes of the given router are considered", func(t *testing.T) {
+		root.Handle("/{anything:.*}", handler).Methods("GET")
+
+		assert.Equal(t, []string{"GET"}, AllowedMethods(config, root, httptest.NewRequest("POST", "/api/v4/system/pong", nil)))
+		assert.Equal(t, []string{}, AllowedMethods(config, api, httptest.NewRequest("POST", "/api/v4/system/pong", nil)))
+		assert.Equal(t, []string{"DELETE", "GET", "PUT"}, AllowedMethods(config, api, httptest.NewRequest("POST", "/api/v4/users/abc", nil)))
+	})
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ROUTE_UNMATCHED labels the metrics of requests that weren't routed by a registered pattern.
+const ROUTE_UNMATCHED = "unmatched"
+
+// requestTimings records how long each phase of handling a request took. A phase that didn't happen, such as looking
+// up the session of a request without a token, is left at zero and isn't observed.
+type requestTimings struct {
+	start   time.Time
+	auth    time.Duration
+	handler time.Duration
+}
+
+// routeTemplate returns the pattern of the route that a request matched, or "" if it wasn't routed by a mux.Router.
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return ""
+}
+
+// observeRequestTimings records the time spent in each phase of handling a request, labeled by the pattern of its route
+// rather than its path so that the ids in the path don't create a series for every channel, user and so on.
+func observeRequestTimings(c *Context, r *http.Request, timings *requestTimings) {
+	if c.App.Metrics == nil {
+		return
+	}
+
+	route := routeTemplate(r)
+	if route == "" {
+		route = ROUTE_UNMATCHED
+	}
+
+	if timings.auth > 0 {
+		c.App.Metrics.ObserveHttpRequestAuthDuration(route, timings.auth.Seconds())
+	}
+	if timings.handler > 0 {
+		c.App.Metrics.ObserveHttpRequestHandlerDuration(route, timings.handler.Seconds())
+	}
+	c.App.Metrics.ObserveHttpRequestTotalDuration(route, time.Since(timings.start).Seconds())
+}
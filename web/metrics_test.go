@@ -0,0 +1,73 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-server/app"
+	"github.com/mattermost/mattermost-server/einterfaces"
+)
+
+type requestTimingsMetrics struct {
+	einterfaces.MetricsInterface
+
+	auth    map[string]int
+	handler map[string]int
+	total   map[string]int
+}
+
+func (m *requestTimingsMetrics) ObserveHttpRequestAuthDuration(route string, elapsed float64) {
+	m.auth[route]++
+}
+
+func (m *requestTimingsMetrics) ObserveHttpRequestHandlerDuration(route string, elapsed float64) {
+	m.handler[route]++
+}
+
+func (m *requestTimingsMetrics) ObserveHttpRequestTotalDuration(route string, elapsed float64) {
+	m.total[route]++
+}
+
+func TestObserveRequestTimings(t *testing.T) {
+	metrics := &requestTimingsMetrics{
+		auth:    map[string]int{},
+		handler: map[string]int{},
+		total:   map[string]int{},
+	}
+	c := &Context{App: &app.App{Metrics: metrics}}
+
+	router := mux.NewRouter()
+	channels := router.PathPrefix("/api/v4/channels/{channel_id:[A-Za-z0-9]+}").Subrouter()
+	channels.HandleFunc("/posts", func(w http.ResponseWriter, r *http.Request) {
+		observeRequestTimings(c, r, &requestTimings{start: time.Now(), auth: time.Millisecond, handler: time.Millisecond})
+	})
+	channels.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		observeRequestTimings(c, r, &requestTimings{start: time.Now()})
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v4/channels/abc/posts", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v4/channels/def/posts", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v4/channels/abc/stats", nil))
+	observeRequestTimings(c, httptest.NewRequest("GET", "/api/v4/channels/abc/posts", nil), &requestTimings{start: time.Now()})
+
+	assert.Equal(t, map[string]int{"/api/v4/channels/{channel_id:[A-Za-z0-9]+}/posts": 2}, metrics.auth)
+	assert.Equal(t, map[string]int{"/api/v4/channels/{channel_id:[A-Za-z0-9]+}/posts": 2}, metrics.handler)
+	assert.Equal(t, map[string]int{
+		"/api/v4/channels/{channel_id:[A-Za-z0-9]+}/posts": 2,
+		"/api/v4/channels/{channel_id:[A-Za-z0-9]+}/stats": 1,
+		ROUTE_UNMATCHED: 1,
+	}, metrics.total)
+
+	t.Run("disabled metrics", func(t *testing.T) {
+		c := &Context{App: &app.App{}}
+		observeRequestTimings(c, httptest.NewRequest("GET", "/api/v4/channels/abc/posts", nil), &requestTimings{start: time.Now(), auth: time.Millisecond})
+	})
+}
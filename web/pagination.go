@@ -0,0 +1,66 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"strconv"
+)
+
+// Pagination is a page of results that a client asked for.
+type Pagination struct {
+	Page    int
+	PerPage int
+
+	// Offset is the number of results before the page.
+	Offset int
+}
+
+// Pagination reads the page and per_page query parameters of the request, which default to the first page of
+// PER_PAGE_DEFAULT results. c.Err is set if either is malformed or negative.
+func (c *Context) Pagination() Pagination {
+	page := PAGE_DEFAULT
+	if c.Params.rawPage != "" {
+		val, err := strconv.Atoi(c.Params.rawPage)
+		if err != nil || val < 0 {
+			c.SetInvalidUrlParam("page")
+			return Pagination{}
+		}
+		page = val
+	}
+
+	perPage := PER_PAGE_DEFAULT
+	if c.Params.rawPerPage != "" {
+		val, err := strconv.Atoi(c.Params.rawPerPage)
+		if err != nil || val < 0 {
+			c.SetInvalidUrlParam("per_page")
+			return Pagination{}
+		}
+		perPage = val
+	}
+
+	return c.NewPagination(page, perPage)
+}
+
+// NewPagination validates a page that was asked for somewhere other than the query string, such as in the body of a
+// search request, setting c.Err if page or perPage is negative. Like the page of the query string, perPage is capped
+// at ServiceSettings.MaximumPerPage.
+func (c *Context) NewPagination(page, perPage int) Pagination {
+	if page < 0 {
+		c.SetInvalidParam("page")
+		return Pagination{}
+	} else if perPage < 0 {
+		c.SetInvalidParam("per_page")
+		return Pagination{}
+	}
+
+	if maximum := *c.App.Config().ServiceSettings.MaximumPerPage; perPage > maximum {
+		perPage = maximum
+	}
+
+	return Pagination{
+		Page:    page,
+		PerPage: perPage,
+		Offset:  page * perPage,
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// handlerPanic is a panic carried over from the goroutine of a handler with a timeout, along with the stack trace of
+// where it happened rather than of where it's re-raised.
+type handlerPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// serveRecovering runs the handler, turning a panic into an internal server error so that the request is still
+// answered, logged and counted like any other that fails. The error that's returned doesn't say what went wrong, but
+// the panic is logged with its stack trace.
+func (h Handler) serveRecovering(c *Context, w http.ResponseWriter, r *http.Request, timeout time.Duration) {
+	defer func() {
+		p := recover()
+		if p == nil {
+			return
+		}
+
+		stack := debug.Stack()
+		if hp, ok := p.(*handlerPanic); ok {
+			p = hp.value
+			stack = hp.stack
+		}
+
+		// The server uses this to abort a response on purpose, so let it do so
+		if p == http.ErrAbortHandler {
+			panic(p)
+		}
+
+		c.Log.Error("Handler panicked", mlog.Any("panic", p), mlog.String("stack", string(stack)))
+		c.Err = model.NewAppError("ServeHTTP", "api.context.panic.app_error", nil, "", http.StatusInternalServerError)
+	}()
+
+	if timeout > 0 {
+		h.serveWithTimeout(c, w, r, timeout)
+	} else {
+		h.serve(c, w, r)
+	}
+}
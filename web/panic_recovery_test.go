@@ -0,0 +1,57 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func handlerForPanic(c *Context, w http.ResponseWriter, r *http.Request) {
+	panic("deliberate panic")
+}
+
+func TestHandlerServeHTTPPanic(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	serve := func(handler Handler, url string) *httptest.ResponseRecorder {
+		handler.GetGlobalAppOptions = web.GetGlobalAppOptions
+		handler.HandleFunc = handlerForPanic
+
+		request := httptest.NewRequest("GET", url, nil)
+		response := httptest.NewRecorder()
+		assert.NotPanics(t, func() {
+			handler.ServeHTTP(response, request)
+		})
+		return response
+	}
+
+	t.Run("api routes get a JSON error", func(t *testing.T) {
+		response := serve(Handler{}, "/api/v4/test")
+		assert.Equal(t, http.StatusInternalServerError, response.Code)
+		assert.Contains(t, response.Body.String(), "api.context.panic.app_error")
+		assert.NotContains(t, response.Body.String(), "deliberate panic")
+		assert.NotEmpty(t, response.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("handlers with a timeout", func(t *testing.T) {
+		response := serve(Handler{Timeout: time.Minute}, "/api/v4/test")
+		assert.Equal(t, http.StatusInternalServerError, response.Code)
+		assert.Contains(t, response.Body.String(), "api.context.panic.app_error")
+	})
+
+	t.Run("browser routes redirect to the error page", func(t *testing.T) {
+		response := serve(Handler{}, "/login/sso/saml")
+		assert.Equal(t, http.StatusInternalServerError, response.Code)
+		assert.Contains(t, response.Body.String(), "/error?")
+		assert.Contains(t, response.Body.String(), "api.context.panic.app_error")
+	})
+}
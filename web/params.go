@@ -24,6 +24,7 @@ type Params struct {
 	UserId         string
 	TeamId         string
 	InviteId       string
+	TemplateId     string
 	TokenId        string
 	ChannelId      string
 	PostId         string
@@ -58,6 +59,16 @@ type Params struct {
 	RemoteId       string
 	SyncableId     string
 	SyncableType   model.GroupSyncableType
+	Expand         []string
+
+	// Locale is the locale that the response was asked to be in, which is only used if it's supported, see
+	// Context.Locale.
+	Locale string
+
+	// rawPage and rawPerPage are the page and per_page query parameters as they were given, so that Context.Pagination
+	// can reject malformed ones instead of falling back to the defaults.
+	rawPage    string
+	rawPerPage string
 }
 
 func ParamsFromRequest(r *http.Request) *Params {
@@ -74,6 +85,10 @@ func ParamsFromRequest(r *http.Request) *Params {
 		params.TeamId = val
 	}
 
+	if val, ok := props["template_id"]; ok {
+		params.TemplateId = val
+	}
+
 	if val, ok := props["invite_id"]; ok {
 		params.InviteId = val
 	}
@@ -187,6 +202,16 @@ func ParamsFromRequest(r *http.Request) *Params {
 	}
 
 	params.Scope = query.Get("scope")
+	params.Locale = query.Get("locale")
+
+	for _, expand := range strings.Split(query.Get("expand"), ",") {
+		if expand = strings.TrimSpace(expand); len(expand) > 0 {
+			params.Expand = append(params.Expand, expand)
+		}
+	}
+
+	params.rawPage = query.Get("page")
+	params.rawPerPage = query.Get("per_page")
 
 	if val, err := strconv.Atoi(query.Get("page")); err != nil || val < 0 {
 		params.Page = PAGE_DEFAULT
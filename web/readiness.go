@@ -0,0 +1,60 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// readinessResponse is the body of the readiness endpoints. Reason explains why the server isn't ready, while Failed
+// maps the name of each dependency that didn't respond in time to the reason.
+type readinessResponse struct {
+	Status string            `json:"status"`
+	Reason string            `json:"reason,omitempty"`
+	Failed map[string]string `json:"failed,omitempty"`
+}
+
+// InitReadiness adds the readiness endpoint, which orchestrators use to decide whether to route traffic to the server.
+// It's the same as the API's system/ready endpoint, but outside of the API for orchestrators that probe a fixed path.
+func (w *Web) InitReadiness() {
+	w.MainRouter.Handle("/health/ready", &Handler{
+		GetGlobalAppOptions: w.GetGlobalAppOptions,
+		HandleFunc:          ServeReadiness,
+		Critical:            true,
+	}).Methods("GET")
+}
+
+// ServeReadiness reports whether the server is ready to serve traffic, see App.CheckReadiness. Unlike the ping
+// endpoint, which only reports that the server is alive and is meant for liveness probes, it fails with a 503 while the
+// server is starting up or its dependencies can't be reached.
+func ServeReadiness(c *Context, w http.ResponseWriter, r *http.Request) {
+	response := readinessResponse{Status: model.STATUS_OK}
+
+	if failed, err := c.App.CheckReadiness(); err != nil {
+		err.Translate(c.App.T)
+		response.Status = model.STATUS_UNREADY
+		response.Reason = err.Message
+		mlog.Debug("Server isn't ready to serve traffic", mlog.Err(err))
+
+		if len(failed) > 0 {
+			response.Failed = make(map[string]string, len(failed))
+			for name, err := range failed {
+				err.Translate(c.App.T)
+				response.Failed[name] = err.Message
+				mlog.Warn("A dependency of the server isn't ready", mlog.String("dependency", name), mlog.Err(err))
+			}
+		}
+	}
+
+	b, _ := json.Marshal(response)
+
+	if response.Status != model.STATUS_OK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(b)
+}
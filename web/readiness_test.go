@@ -0,0 +1,46 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestReadiness(t *testing.T) {
+	th := Setup()
+	defer th.TearDown()
+
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+
+	response := httptest.NewRecorder()
+	web.MainRouter.ServeHTTP(response, httptest.NewRequest("GET", "/health/ready", nil))
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
+
+	var body readinessResponse
+	require.Nil(t, json.Unmarshal(response.Body.Bytes(), &body))
+	assert.Equal(t, model.STATUS_OK, body.Status)
+	assert.Empty(t, body.Reason)
+	assert.Empty(t, body.Failed)
+
+	th.App.UpdateConfig(func(cfg *model.Config) { *cfg.PluginSettings.Enable = true })
+	th.App.ShutDownPlugins()
+
+	response = httptest.NewRecorder()
+	web.MainRouter.ServeHTTP(response, httptest.NewRequest("GET", "/health/ready", nil))
+	require.Equal(t, http.StatusServiceUnavailable, response.Code)
+
+	body = readinessResponse{}
+	require.Nil(t, json.Unmarshal(response.Body.Bytes(), &body))
+	assert.Equal(t, model.STATUS_UNREADY, body.Status)
+	assert.NotEmpty(t, body.Reason)
+}
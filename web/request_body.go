@@ -0,0 +1,73 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+const (
+	// DEFAULT_MAX_BODY_BYTES is the largest request body accepted by handlers that don't set MaxBodyBytes, which is
+	// plenty for any JSON payload that the API accepts.
+	DEFAULT_MAX_BODY_BYTES = 10 * 1024 * 1024
+
+	// UPLOAD_BODY_OVERHEAD_BYTES leaves room in the body of an upload for the multipart boundaries and any other fields
+	// sent along with the file.
+	UPLOAD_BODY_OVERHEAD_BYTES = 1024 * 1024
+)
+
+// countingBody keeps track of how much of a request body has been read, so that a handler failing because it reached
+// the limit of http.MaxBytesReader can be told apart from one failing because the body was malformed.
+type countingBody struct {
+	io.ReadCloser
+	bytesRead int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.bytesRead += int64(n)
+	return n, err
+}
+
+// maxBodyBytes returns the largest request body that the handler accepts.
+func (h Handler) maxBodyBytes(config *model.Config) int64 {
+	maxBytes := h.MaxBodyBytes
+	if maxBytes <= 0 {
+		maxBytes = DEFAULT_MAX_BODY_BYTES
+	}
+
+	if h.IsUpload {
+		if *config.FileSettings.MaxFileSize > maxBytes {
+			maxBytes = *config.FileSettings.MaxFileSize
+		}
+		maxBytes += UPLOAD_BODY_OVERHEAD_BYTES
+	}
+
+	return maxBytes
+}
+
+// limitRequestBody stops the handler from reading more of the request body than it accepts, rejecting it straight
+// away if it's declared to be larger than that. The returned body, if any, should be checked once the request has been
+// handled to see whether the limit was reached.
+func (h Handler) limitRequestBody(c *Context, w http.ResponseWriter, r *http.Request, maxBytes int64) *countingBody {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	if r.ContentLength > maxBytes {
+		c.Err = newRequestBodyTooLargeError(maxBytes)
+		return nil
+	}
+
+	body := &countingBody{ReadCloser: r.Body}
+	r.Body = http.MaxBytesReader(w, body, maxBytes)
+	return body
+}
+
+func newRequestBodyTooLargeError(maxBytes int64) *model.AppError {
+	return model.NewAppError("ServeHTTP", "api.context.request_body_too_large.app_error", map[string]interface{}{"MaxBytes": maxBytes}, "", http.StatusRequestEntityTooLarge)
+}
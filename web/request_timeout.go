@@ -0,0 +1,190 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// requestTimeout returns how long the handler has to respond to a request, or 0 if it may take as long as it needs.
+// Uploads and the WebSocket aren't subject to ServiceSettings.RequestTimeout, since they're expected to take long.
+// Plugin routes are served by App.ServePluginRequest rather than a Handler, so they're never timed out here either;
+// it's up to each plugin to bound how long its own requests take.
+func (h Handler) requestTimeout(config *model.Config, r *http.Request) time.Duration {
+	if websocket.IsWebSocketUpgrade(r) {
+		return 0
+	}
+
+	if h.Timeout > 0 {
+		return h.Timeout
+	}
+
+	if h.IsUpload || h.IsStatic {
+		return 0
+	}
+
+	return time.Duration(*config.ServiceSettings.RequestTimeout) * time.Second
+}
+
+// timeoutResponseWriter stops a handler from writing its response once the request has timed out, since the error
+// response is written in its place. Headers are kept apart from those of the underlying response writer until the
+// response is started, so that a handler that's still running can't race with the error response.
+type timeoutResponseWriter struct {
+	http.ResponseWriter
+
+	mutex       sync.Mutex
+	header      http.Header
+	wroteHeader bool
+	timedOut    bool
+}
+
+func newTimeoutResponseWriter(w http.ResponseWriter) *timeoutResponseWriter {
+	header := make(http.Header, len(w.Header()))
+	for key, values := range w.Header() {
+		header[key] = append([]string(nil), values...)
+	}
+
+	return &timeoutResponseWriter{
+		ResponseWriter: w,
+		header:         header,
+	}
+}
+
+func (w *timeoutResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutResponseWriter) WriteHeader(statusCode int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.timedOut {
+		w.writeHeaderLocked(statusCode)
+	}
+}
+
+func (w *timeoutResponseWriter) Write(b []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	w.writeHeaderLocked(http.StatusOK)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timeoutResponseWriter) Flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.timedOut {
+		return
+	}
+
+	w.writeHeaderLocked(http.StatusOK)
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *timeoutResponseWriter) writeHeaderLocked(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	header := w.ResponseWriter.Header()
+	for key := range header {
+		delete(header, key)
+	}
+	for key, values := range w.header {
+		header[key] = values
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// timeOut discards anything that the handler writes from now on, unless it has already started its response, in which
+// case false is returned and the handler must be let finish it.
+func (w *timeoutResponseWriter) timeOut() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.wroteHeader {
+		return false
+	}
+
+	w.timedOut = true
+	return true
+}
+
+// serveWithTimeout runs the handler until it's done or the context of the request times out, whichever comes first.
+// The handler works on a copy of the context so that it can't race with the timeout error being handled, and the copy
+// is only taken over if it finishes in time. Timing out only replaces the response: a handler that times out keeps
+// running until it returns, and it's only then that the request stops counting as in progress for its user. The
+// context is handed down as App.Context so that outgoing requests made through App.RequestContext give up with it.
+func (h Handler) serveWithTimeout(c *Context, w http.ResponseWriter, r *http.Request, timeout time.Duration) {
+	app := *c.App
+	handlerContext := *c
+	handlerContext.App = &app
+
+	timeoutWriter := newTimeoutResponseWriter(w)
+	done := make(chan *handlerPanic, 1)
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- &handlerPanic{value: p, stack: debug.Stack()}
+			} else {
+				done <- nil
+			}
+		}()
+
+		h.serve(&handlerContext, timeoutWriter, r)
+	}()
+
+	select {
+	case p := <-done:
+		if p != nil {
+			panic(p)
+		}
+	case <-c.Ctx.Done():
+		if timeoutWriter.timeOut() {
+			c.timedOut = true
+			c.Log = c.Log.With(mlog.Bool("timed_out", true))
+			c.Err = model.NewAppError("ServeHTTP", "api.context.request_timeout.app_error", nil, fmt.Sprintf("timeout=%v", timeout), http.StatusServiceUnavailable)
+
+			release := c.releaseConcurrentRequest
+			c.releaseConcurrentRequest = nil
+
+			go func() {
+				if p := <-done; p != nil {
+					mlog.Error("Handler panicked after its request timed out", mlog.String("path", r.URL.Path), mlog.Any("panic", p.value), mlog.String("stack", string(p.stack)))
+				}
+
+				if release != nil {
+					release()
+				}
+			}()
+			return
+		}
+
+		// The response has already started, so it can't be replaced by an error
+		if p := <-done; p != nil {
+			panic(p)
+		}
+	}
+
+	*c = handlerContext
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestHandlerRequestTimeout(t *testing.T) {
+	config := &model.Config{}
+	config.SetDefaults()
+	*config.ServiceSettings.RequestTimeout = 30
+
+	request := httptest.NewRequest("GET", "/api/v4/test", nil)
+	upgrade := httptest.NewRequest("GET", "/api/v4/websocket", nil)
+	upgrade.Header.Set("Connection", "upgrade")
+	upgrade.Header.Set("Upgrade", "websocket")
+
+	assert.Equal(t, 30*time.Second, Handler{}.requestTimeout(config, request))
+	assert.Equal(t, time.Minute, Handler{Timeout: time.Minute}.requestTimeout(config, request))
+	assert.Equal(t, time.Duration(0), Handler{IsUpload: true}.requestTimeout(config, request))
+	assert.Equal(t, time.Minute, Handler{IsUpload: true, Timeout: time.Minute}.requestTimeout(config, request))
+	assert.Equal(t, time.Duration(0), Handler{}.requestTimeout(config, upgrade))
+	assert.Equal(t, time.Duration(0), Handler{Timeout: time.Minute}.requestTimeout(config, upgrade))
+
+	*config.ServiceSettings.RequestTimeout = 0
+	assert.Equal(t, time.Duration(0), Handler{}.requestTimeout(config, request))
+}
+
+func TestTimeoutResponseWriter(t *testing.T) {
+	t.Run("responses are written until the request times out", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		recorder.Header().Set("Content-Type", "application/json")
+		w := newTimeoutResponseWriter(recorder)
+
+		w.Header().Set("ETag", "abc")
+		w.Header().Del("Content-Type")
+		assert.Empty(t, recorder.Header().Get("ETag"), "headers shouldn't be sent before the response is started")
+
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+
+		assert.False(t, w.timeOut(), "a started response shouldn't be replaced")
+		w.Write([]byte(" world"))
+
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		assert.Equal(t, "hello world", recorder.Body.String())
+		assert.Equal(t, "abc", recorder.Header().Get("ETag"))
+		assert.Empty(t, recorder.Header().Get("Content-Type"))
+	})
+
+	t.Run("nothing is written after the request times out", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		w := newTimeoutResponseWriter(recorder)
+		w.Header().Set("ETag", "abc")
+
+		assert.True(t, w.timeOut())
+
+		w.WriteHeader(http.StatusCreated)
+		n, err := w.Write([]byte("hello"))
+		w.Flush()
+
+		assert.Equal(t, 0, n)
+		assert.Equal(t, http.ErrHandlerTimeout, err)
+		assert.False(t, recorder.Flushed)
+		assert.Empty(t, recorder.Body.String())
+		assert.Empty(t, recorder.Header().Get("ETag"))
+	})
+}
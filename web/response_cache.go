@@ -0,0 +1,91 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/app"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// responseRecorder buffers a response so that it can be cached before being written.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) flush(w http.ResponseWriter) {
+	if r.wroteHeader {
+		w.WriteHeader(r.statusCode)
+	}
+	if r.body.Len() > 0 {
+		w.Write(r.body.Bytes())
+	}
+}
+
+// serveWithResponseCache serves a GET request from the response cache of the handler's endpoint if possible, calling
+// HandleFunc and caching its response otherwise. Only successful responses are cached. The response is sent with an
+// ETag so that clients can revalidate it with If-None-Match instead of downloading it again.
+func (h Handler) serveWithResponseCache(c *Context, w http.ResponseWriter, r *http.Request) {
+	// Cacheable responses are the same for every user, but may differ between logged in and anonymous users
+	key := r.URL.Query().Encode()
+	if len(c.App.Session.UserId) > 0 {
+		key += "|session"
+	}
+
+	cached := c.App.GetCachedResponse(h.ResponseCache, key)
+	if cached == nil {
+		recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		h.HandleFunc(c, recorder, r)
+
+		if c.Err != nil || recorder.statusCode != http.StatusOK {
+			recorder.flush(w)
+			return
+		}
+
+		cached = &app.CachedResponse{ETag: contentEtag(recorder.body.Bytes()), Body: recorder.body.Bytes()}
+		c.App.AddCachedResponse(h.ResponseCache, key, cached)
+	}
+
+	visibility := "public"
+	if len(c.App.Session.UserId) > 0 {
+		visibility = "private"
+	}
+
+	w.Header().Del("Expires")
+	w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", visibility, *c.App.Config().ServiceSettings.ResponseCacheMaxAgeSeconds))
+	w.Header().Add("Vary", "Authorization, Cookie")
+	w.Header().Set(model.HEADER_ETAG_SERVER, cached.ETag)
+
+	if etagMatches(r.Header.Get(model.HEADER_ETAG_CLIENT), cached.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		if c.App.Metrics != nil {
+			c.App.Metrics.IncrementEtagHitCounter(h.ResponseCache)
+		}
+		return
+	}
+
+	if c.App.Metrics != nil {
+		c.App.Metrics.IncrementEtagMissCounter(h.ResponseCache)
+	}
+
+	w.Write(cached.Body)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/app"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestHandlerServeWithResponseCache(t *testing.T) {
+	s, err := app.NewServer(app.StoreOverride(mainHelper.Store), app.DisableConfigWatch)
+	require.Nil(t, err)
+	defer s.Shutdown()
+
+	a := s.FakeApp()
+	web := New(s, s.AppOptions, s.Router)
+
+	calls := 0
+	body := "first"
+	handler := &Handler{
+		GetGlobalAppOptions: web.GetGlobalAppOptions,
+		HandleFunc: func(c *Context, w http.ResponseWriter, r *http.Request) {
+			calls++
+			if r.URL.Query().Get("fail") != "" {
+				c.Err = model.NewAppError("test", "test", nil, "", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(body))
+		},
+		ResponseCache: model.RESPONSE_CACHE_ENDPOINT_EMOJI_LIST,
+	}
+
+	get := func(query string, etag string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("GET", "/api/v4/test"+query, nil)
+		if etag != "" {
+			request.Header.Set(model.HEADER_ETAG_CLIENT, etag)
+		}
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		response := get("", "")
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Empty(t, response.Header().Get(model.HEADER_ETAG_SERVER))
+
+		get("", "")
+		assert.Equal(t, 2, calls)
+	})
+
+	a.UpdateConfig(func(cfg *model.Config) {
+		cfg.ServiceSettings.ResponseCacheEndpoints = []string{model.RESPONSE_CACHE_ENDPOINT_EMOJI_LIST}
+	})
+	calls = 0
+
+	t.Run("enabled", func(t *testing.T) {
+		response := get("", "")
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "first", response.Body.String())
+		assert.Equal(t, "public, max-age=60", response.Header().Get("Cache-Control"))
+		assert.Empty(t, response.Header().Get("Expires"))
+		etag := response.Header().Get(model.HEADER_ETAG_SERVER)
+		require.NotEmpty(t, etag)
+
+		response = get("", "")
+		assert.Equal(t, "first", response.Body.String())
+		assert.Equal(t, etag, response.Header().Get(model.HEADER_ETAG_SERVER))
+		assert.Equal(t, 1, calls, "second request should be served from the cache")
+
+		response = get("", etag)
+		assert.Equal(t, http.StatusNotModified, response.Code)
+		assert.Empty(t, response.Body.String())
+
+		get("?page=1", "")
+		assert.Equal(t, 2, calls, "different query strings are cached separately")
+	})
+
+	t.Run("errors aren't cached", func(t *testing.T) {
+		calls = 0
+		response := get("?fail=1", "")
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		get("?fail=1", "")
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("invalidated", func(t *testing.T) {
+		calls = 0
+		etag := get("", "").Header().Get(model.HEADER_ETAG_SERVER)
+
+		body = "second"
+		a.InvalidateResponseCache(model.RESPONSE_CACHE_ENDPOINT_EMOJI_LIST)
+
+		response := get("", etag)
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "second", response.Body.String())
+		assert.NotEqual(t, etag, response.Header().Get(model.HEADER_ETAG_SERVER))
+		assert.Equal(t, 1, calls)
+	})
+}
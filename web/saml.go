@@ -10,11 +10,22 @@ import (
 
 	"github.com/mattermost/mattermost-server/mlog"
 	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+// The errors that a failed SAML login is reported as, so that the error page can explain what went wrong. See
+// samlLoginError.
+const (
+	SAML_ERROR_EXPIRED_ASSERTION = "api.user.saml.expired_assertion.app_error"
+	SAML_ERROR_INVALID_SIGNATURE = "api.user.saml.invalid_signature.app_error"
+	SAML_ERROR_INVALID_RESPONSE  = "api.user.saml.invalid_response.app_error"
+	SAML_ERROR_UNKNOWN_USER      = "api.user.saml.unknown_user.app_error"
+	SAML_ERROR_REUSED_ASSERTION  = "api.user.saml.replayed_assertion.app_error"
 )
 
 func (w *Web) InitSaml() {
-	w.MainRouter.Handle("/login/sso/saml", w.NewHandler(loginWithSaml)).Methods("GET")
-	w.MainRouter.Handle("/login/sso/saml", w.NewHandler(completeSaml)).Methods("POST")
+	w.MainRouter.Handle("/login/sso/saml", w.NewSecureHandler(loginWithSaml)).Methods("GET")
+	w.MainRouter.Handle("/login/sso/saml", w.NewSecureHandler(completeSaml)).Methods("POST")
 }
 
 func loginWithSaml(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -86,15 +97,29 @@ func completeSaml(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	action := relayProps["action"]
 	if user, err := samlInterface.DoLogin(encodedXML, relayProps); err != nil {
+		err = samlLoginError(err)
 		if action == model.OAUTH_ACTION_MOBILE {
 			err.Translate(c.App.T)
-			w.Write([]byte(err.ToJson()))
+			utils.RenderMobileAppError(c.App.Config(), w, err)
 		} else {
 			c.Err = err
 			c.Err.StatusCode = http.StatusFound
 		}
 		return
 	} else {
+		// The assertion has been verified, so it can be recorded without letting anyone else use it up
+		if err := c.App.RecordSamlAssertion(encodedXML); err != nil {
+			c.LogAuditWithUserId(user.Id, "SAML assertion rejected")
+			if action == model.OAUTH_ACTION_MOBILE {
+				err.Translate(c.App.T)
+				utils.RenderMobileAppError(c.App.Config(), w, err)
+			} else {
+				c.Err = err
+				c.Err.StatusCode = http.StatusFound
+			}
+			return
+		}
+
 		if err := c.App.CheckUserAllAuthenticationCriteria(user, ""); err != nil {
 			c.Err = err
 			c.Err.StatusCode = http.StatusFound
@@ -149,3 +174,29 @@ func completeSaml(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+// samlLoginError maps the known reasons for a SAML login to fail to one of the SAML_ERROR_* errors, keeping the
+// original error in its details for the logs. Other errors are returned unchanged.
+func samlLoginError(err *model.AppError) *model.AppError {
+	var id string
+	switch err.Id {
+	case "ent.saml.do_login.validate.app_error":
+		details := strings.ToLower(err.DetailedError)
+		switch {
+		case strings.Contains(details, "expired") || strings.Contains(details, "notonorafter"):
+			id = SAML_ERROR_EXPIRED_ASSERTION
+		case strings.Contains(details, "signature"):
+			id = SAML_ERROR_INVALID_SIGNATURE
+		default:
+			id = SAML_ERROR_INVALID_RESPONSE
+		}
+	case "ent.saml.do_login.parse.app_error", "ent.saml.do_login.decrypt.app_error", "ent.saml.do_login.empty_response.app_error":
+		id = SAML_ERROR_INVALID_RESPONSE
+	case "store.sql_user.get_by_auth.missing_account.app_error", "store.sql_user.missing_account.const":
+		id = SAML_ERROR_UNKNOWN_USER
+	default:
+		return err
+	}
+
+	return model.NewAppError("completeSaml", id, nil, "error_id="+err.Id+", "+err.DetailedError, http.StatusFound)
+}
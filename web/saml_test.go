@@ -0,0 +1,96 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// fakeSaml accepts any SAML response as being from the given user.
+type fakeSaml struct {
+	user *model.User
+}
+
+func (s *fakeSaml) ConfigureSP() *model.AppError { return nil }
+
+func (s *fakeSaml) BuildRequest(relayState string) (*model.SamlAuthRequest, *model.AppError) {
+	return &model.SamlAuthRequest{}, nil
+}
+
+func (s *fakeSaml) DoLogin(encodedXML string, relayState map[string]string) (*model.User, *model.AppError) {
+	return s.user, nil
+}
+
+func (s *fakeSaml) GetMetadata() (string, *model.AppError) { return "", nil }
+
+func TestCompleteSamlReplayedAssertion(t *testing.T) {
+	th := Setup().InitBasic()
+	defer th.TearDown()
+
+	th.Server.Saml = &fakeSaml{user: th.BasicUser}
+	web := New(th.Server, th.Server.AppOptions, th.Server.Router)
+	handler := web.NewSecureHandler(completeSaml)
+
+	samlResponse := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf(
+		`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="_%s">`+
+			`<saml:Assertion ID="_%s"><saml:Conditions NotOnOrAfter="%s"/></saml:Assertion>`+
+			`</samlp:Response>`,
+		model.NewId(), model.NewId(), time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	)))
+
+	complete := func() *httptest.ResponseRecorder {
+		request := httptest.NewRequest("POST", "/login/sso/saml", strings.NewReader(url.Values{"SAMLResponse": {samlResponse}}.Encode()))
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response
+	}
+
+	response := complete()
+	require.Equal(t, http.StatusFound, response.Code)
+	assert.NotContains(t, response.Header().Get("Location"), "error")
+
+	response = complete()
+	require.Equal(t, http.StatusFound, response.Code)
+	assert.Contains(t, response.Header().Get("Location"), "error_id="+SAML_ERROR_REUSED_ASSERTION)
+}
+
+func TestSamlLoginError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		Id            string
+		DetailedError string
+		ExpectedId    string
+	}{
+		"expired assertion":     {"ent.saml.do_login.validate.app_error", "assertion has expired", SAML_ERROR_EXPIRED_ASSERTION},
+		"expired condition":     {"ent.saml.do_login.validate.app_error", "NotOnOrAfter is in the past", SAML_ERROR_EXPIRED_ASSERTION},
+		"signature mismatch":    {"ent.saml.do_login.validate.app_error", "Signature could not be verified", SAML_ERROR_INVALID_SIGNATURE},
+		"other validation":      {"ent.saml.do_login.validate.app_error", "missing audience", SAML_ERROR_INVALID_RESPONSE},
+		"unparseable response":  {"ent.saml.do_login.parse.app_error", "", SAML_ERROR_INVALID_RESPONSE},
+		"undecryptable":         {"ent.saml.do_login.decrypt.app_error", "", SAML_ERROR_INVALID_RESPONSE},
+		"unknown user":          {"store.sql_user.get_by_auth.missing_account.app_error", "", SAML_ERROR_UNKNOWN_USER},
+		"other errors are kept": {"ent.saml.attribute.app_error", "", "ent.saml.attribute.app_error"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := samlLoginError(model.NewAppError("DoLogin", tc.Id, nil, tc.DetailedError, http.StatusBadRequest))
+
+			assert.Equal(t, tc.ExpectedId, err.Id)
+			if tc.ExpectedId != tc.Id {
+				assert.Equal(t, http.StatusFound, err.StatusCode)
+				assert.Contains(t, err.DetailedError, "error_id="+tc.Id)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mattermost/mattermost-server/model"
+	"github.com/mattermost/mattermost-server/utils"
+)
+
+// writeSearchRateLimited rejects a search because its user, or the server as a whole, has run too many recently. The
+// Retry-After header tells the client how many seconds to wait before searching again.
+func writeSearchRateLimited(c *Context, w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	err := model.NewAppError("ServeHTTP", "api.context.search_rate_limited.app_error", nil, "", http.StatusTooManyRequests)
+	c.Locale()
+	err.Translate(c.App.T)
+	err.RequestId = c.App.RequestId
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	utils.RenderApiError(c.App.Config(), w, r, err)
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+// slowRequestThreshold returns how long a request may take before it's logged as slow, or 0 if it never is. Uploads
+// have a threshold of their own, and the WebSocket is never logged since its connections are meant to stay open.
+func (h Handler) slowRequestThreshold(config *model.Config, r *http.Request) time.Duration {
+	if websocket.IsWebSocketUpgrade(r) {
+		return 0
+	}
+
+	if h.IsUpload {
+		return time.Duration(*config.ServiceSettings.SlowUploadRequestThresholdMilliseconds) * time.Millisecond
+	}
+
+	return time.Duration(*config.ServiceSettings.SlowRequestThresholdMilliseconds) * time.Millisecond
+}
+
+// logSlowRequest logs a warning about a request that has been handled if it took at least as long as the threshold.
+func logSlowRequest(c *Context, r *http.Request, start time.Time, threshold time.Duration) {
+	duration := time.Since(start)
+	if duration < threshold {
+		return
+	}
+
+	route := routeTemplate(r)
+	if route == "" {
+		route = ROUTE_UNMATCHED
+	}
+
+	c.App.Log.Warn("Slow HTTP request",
+		mlog.String("request_id", c.App.RequestId),
+		mlog.String("route", route),
+		mlog.String("method", r.Method),
+		mlog.String("user_id", c.App.Session.UserId),
+		mlog.Int64("duration_ms", int64(duration/time.Millisecond)),
+		mlog.Int64("threshold_ms", int64(threshold/time.Millisecond)),
+	)
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestHandlerSlowRequestThreshold(t *testing.T) {
+	config := &model.Config{}
+	config.SetDefaults()
+
+	request := httptest.NewRequest("GET", "/api/v4/test", nil)
+	upgrade := httptest.NewRequest("GET", "/api/v4/websocket", nil)
+	upgrade.Header.Set("Connection", "upgrade")
+	upgrade.Header.Set("Upgrade", "websocket")
+
+	assert.Equal(t, time.Duration(0), Handler{}.slowRequestThreshold(config, request), "slow requests shouldn't be logged by default")
+	assert.Equal(t, time.Duration(0), Handler{IsUpload: true}.slowRequestThreshold(config, request))
+
+	*config.ServiceSettings.SlowRequestThresholdMilliseconds = 500
+	*config.ServiceSettings.SlowUploadRequestThresholdMilliseconds = 30000
+
+	assert.Equal(t, 500*time.Millisecond, Handler{}.slowRequestThreshold(config, request))
+	assert.Equal(t, 30*time.Second, Handler{IsUpload: true}.slowRequestThreshold(config, request))
+	assert.Equal(t, time.Duration(0), Handler{}.slowRequestThreshold(config, upgrade))
+}
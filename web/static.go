@@ -34,8 +34,8 @@ func (w *Web) InitStatic() {
 
 		mime.AddExtensionType(".wasm", "application/wasm")
 
-		staticHandler := staticFilesHandler(http.StripPrefix(path.Join(subpath, "static"), http.FileServer(http.Dir(staticDir))))
-		pluginHandler := staticFilesHandler(http.StripPrefix(path.Join(subpath, "static", "plugins"), http.FileServer(http.Dir(*w.ConfigService.Config().PluginSettings.ClientDirectory))))
+		staticHandler := staticFilesHandler(http.StripPrefix(path.Join(subpath, "static"), etagFileServer(staticDir)))
+		pluginHandler := staticFilesHandler(http.StripPrefix(path.Join(subpath, "static", "plugins"), etagFileServer(*w.ConfigService.Config().PluginSettings.ClientDirectory)))
 
 		if *w.ConfigService.Config().ServiceSettings.WebserverMode == "gzip" {
 			staticHandler = gziphandler.GzipHandler(staticHandler)
@@ -76,7 +76,13 @@ func root(c *Context, w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-cache, max-age=31556926, public")
 
 	staticDir, _ := fileutils.FindDir(model.CLIENT_DIR)
-	http.ServeFile(w, r, filepath.Join(staticDir, "root.html"))
+
+	if *c.App.Config().ServiceSettings.EnableAssetPreload {
+		subpath, _ := utils.GetSubpathFromConfig(c.App.Config())
+		setAssetPreloads(w, r, staticDir, subpath)
+	}
+
+	serveStaticFile(w, r, filepath.Join(staticDir, "root.html"))
 }
 
 func staticFilesHandler(handler http.Handler) http.Handler {
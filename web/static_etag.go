@@ -0,0 +1,105 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/mlog"
+	"github.com/mattermost/mattermost-server/model"
+)
+
+type staticEtag struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// staticEtagCache remembers the ETags of static files, which are derived from their content, so that a file is only
+// hashed again once it's been changed.
+type staticEtagCache struct {
+	mutex sync.Mutex
+	etags map[string]staticEtag
+}
+
+var staticEtags = &staticEtagCache{etags: make(map[string]staticEtag)}
+
+// get returns the ETag of a file, or an empty string for a directory.
+func (c *staticEtagCache) get(filename string) (string, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return "", err
+	} else if info.IsDir() {
+		return "", nil
+	}
+
+	c.mutex.Lock()
+	cached, ok := c.etags[filename]
+	c.mutex.Unlock()
+
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.etag, nil
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	etag := formatContentEtag(hash.Sum(nil))
+
+	c.mutex.Lock()
+	c.etags[filename] = staticEtag{modTime: info.ModTime(), size: info.Size(), etag: etag}
+	c.mutex.Unlock()
+
+	return etag, nil
+}
+
+// setStaticEtag sets the ETag of a static file that's about to be served by http.ServeFile or http.FileServer, which
+// then answer a matching If-None-Match with 304 Not Modified. They also set Last-Modified and handle
+// If-Modified-Since themselves. Any headers that were already set, such as the Content-Security-Policy of static
+// handlers, are kept on a 304.
+func setStaticEtag(w http.ResponseWriter, filename string) {
+	etag, err := staticEtags.get(filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			mlog.Warn("Failed to compute the ETag of a static file", mlog.String("filename", filename), mlog.Err(err))
+		}
+		return
+	}
+
+	if etag != "" {
+		w.Header().Set(model.HEADER_ETAG_SERVER, etag)
+	}
+}
+
+// serveStaticFile serves a file along with validators that let clients avoid downloading it again.
+func serveStaticFile(w http.ResponseWriter, r *http.Request, filename string) {
+	setStaticEtag(w, filename)
+	http.ServeFile(w, r, filename)
+}
+
+// etagFileServer serves the files in a directory like http.FileServer, but with ETags.
+func etagFileServer(dir string) http.Handler {
+	fileServer := http.FileServer(http.Dir(dir))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Resolve the file in the same way as http.Dir
+		setStaticEtag(w, filepath.Join(dir, filepath.FromSlash(path.Clean("/"+r.URL.Path))))
+		fileServer.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/model"
+)
+
+func TestEtagFileServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "static")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "main.js")
+	require.Nil(t, ioutil.WriteFile(filename, []byte("console.log('a');"), 0600))
+	require.Nil(t, os.Mkdir(filepath.Join(dir, "files"), 0700))
+
+	handler := etagFileServer(dir)
+
+	get := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest("GET", path, nil)
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response
+	}
+
+	response := get("/main.js", nil)
+	require.Equal(t, http.StatusOK, response.Code)
+	etag := response.Header().Get(model.HEADER_ETAG_SERVER)
+	assert.Equal(t, contentEtag([]byte("console.log('a');")), etag)
+	assert.NotEmpty(t, response.Header().Get("Last-Modified"))
+
+	t.Run("matching etag", func(t *testing.T) {
+		response := get("/main.js", map[string]string{model.HEADER_ETAG_CLIENT: etag})
+		assert.Equal(t, http.StatusNotModified, response.Code)
+		assert.Empty(t, response.Body.String())
+		assert.Equal(t, etag, response.Header().Get(model.HEADER_ETAG_SERVER))
+	})
+
+	t.Run("different etag", func(t *testing.T) {
+		response := get("/main.js", map[string]string{model.HEADER_ETAG_CLIENT: `"abc"`})
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "console.log('a');", response.Body.String())
+	})
+
+	t.Run("if modified since", func(t *testing.T) {
+		response := get("/main.js", map[string]string{"If-Modified-Since": time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)})
+		assert.Equal(t, http.StatusNotModified, response.Code)
+	})
+
+	t.Run("changed file", func(t *testing.T) {
+		require.Nil(t, ioutil.WriteFile(filename, []byte("console.log('bb');"), 0600))
+		require.Nil(t, os.Chtimes(filename, time.Now(), time.Now().Add(time.Minute)))
+
+		response := get("/main.js", map[string]string{model.HEADER_ETAG_CLIENT: etag})
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, contentEtag([]byte("console.log('bb');")), response.Header().Get(model.HEADER_ETAG_SERVER))
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		response := get("/files/", nil)
+		assert.Empty(t, response.Header().Get(model.HEADER_ETAG_SERVER))
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		response := get("/missing.js", nil)
+		assert.Equal(t, http.StatusNotFound, response.Code)
+		assert.Empty(t, response.Header().Get(model.HEADER_ETAG_SERVER))
+	})
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+
+	"github.com/mattermost/mattermost-server/app"
+)
+
+// HandlerOption configures a Handler created by NewTestHandler.
+type HandlerOption func(h *Handler)
+
+func HandlerRequireSession(requireSession bool) HandlerOption {
+	return func(h *Handler) {
+		h.RequireSession = requireSession
+	}
+}
+
+func HandlerTrustRequester(trustRequester bool) HandlerOption {
+	return func(h *Handler) {
+		h.TrustRequester = trustRequester
+	}
+}
+
+func HandlerIsStatic(isStatic bool) HandlerOption {
+	return func(h *Handler) {
+		h.IsStatic = isStatic
+	}
+}
+
+// NewTestHandler assembles a Handler in the same way as Web.NewHandler, but without needing a Web, so that handlers
+// can be unit tested against whatever app options the test provides, such as ones that override the store with a mock.
+// If getGlobalAppOptions is nil, the handler creates its Apps without any options.
+func NewTestHandler(getGlobalAppOptions app.AppOptionCreator, h func(*Context, http.ResponseWriter, *http.Request), options ...HandlerOption) *Handler {
+	if getGlobalAppOptions == nil {
+		getGlobalAppOptions = func() []app.AppOption {
+			return nil
+		}
+	}
+
+	handler := &Handler{
+		GetGlobalAppOptions: getGlobalAppOptions,
+		HandleFunc:          h,
+		RequireSession:      false,
+		TrustRequester:      false,
+		RequireMfa:          false,
+		IsStatic:            false,
+	}
+
+	for _, option := range options {
+		option(handler)
+	}
+
+	return handler
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattermost/mattermost-server/app"
+)
+
+func TestNewTestHandler(t *testing.T) {
+	handleFunc := func(c *Context, w http.ResponseWriter, r *http.Request) {}
+
+	t.Run("defaults", func(t *testing.T) {
+		handler := NewTestHandler(nil, handleFunc)
+
+		require.NotNil(t, handler.GetGlobalAppOptions)
+		assert.Empty(t, handler.GetGlobalAppOptions())
+		assert.NotNil(t, handler.HandleFunc)
+		assert.False(t, handler.RequireSession)
+		assert.False(t, handler.TrustRequester)
+		assert.False(t, handler.RequireMfa)
+		assert.False(t, handler.IsStatic)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		called := false
+		getGlobalAppOptions := func() []app.AppOption {
+			called = true
+			return []app.AppOption{}
+		}
+
+		handler := NewTestHandler(getGlobalAppOptions, handleFunc, HandlerRequireSession(true), HandlerTrustRequester(true), HandlerIsStatic(true))
+
+		handler.GetGlobalAppOptions()
+		assert.True(t, called)
+		assert.True(t, handler.RequireSession)
+		assert.True(t, handler.TrustRequester)
+		assert.True(t, handler.IsStatic)
+	})
+}
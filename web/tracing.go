@@ -0,0 +1,34 @@
+// Copyright (c) 2019-present Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package web
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// endRequestSpan records what's known about a request once it's been handled, such as the pattern of the route that it
+// matched, before ending its span.
+func endRequestSpan(c *Context, r *http.Request) {
+	route := routeTemplate(r)
+	if route == "" {
+		route = r.URL.Path
+	}
+
+	c.Span.SetName(r.Method + " " + route)
+	c.Span.SetAttribute("http.method", r.Method)
+	c.Span.SetAttribute("http.route", route)
+	c.Span.SetAttribute("mattermost.request_id", c.App.RequestId)
+
+	if c.App.Session.UserId != "" {
+		c.Span.SetAttribute("enduser.id", c.App.Session.UserId)
+	}
+
+	if c.Err != nil {
+		c.Span.SetAttribute("http.status_code", strconv.Itoa(c.Err.StatusCode))
+		c.Span.SetError(c.Err.Id)
+	}
+
+	c.Span.End()
+}
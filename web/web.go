@@ -36,8 +36,11 @@ func New(config configservice.ConfigService, globalOptions app.AppOptionCreator,
 
 	web.InitWebhooks()
 	web.InitSaml()
+	web.InitReadiness()
 	web.InitStatic()
 
+	root.MethodNotAllowedHandler = web.methodNotAllowedHandler()
+
 	return web
 }
 
@@ -65,9 +68,10 @@ func Handle404(config configservice.ConfigService, w http.ResponseWriter, r *htt
 	mlog.Debug(fmt.Sprintf("%v: code=404 ip=%v", r.URL.Path, utils.GetIpAddress(r)))
 
 	if IsApiCall(config, r) {
-		w.WriteHeader(err.StatusCode)
 		err.DetailedError = "There doesn't appear to be an api call for the url='" + r.URL.Path + "'.  Typo? are you missing a team_id or user_id as part of the url?"
-		w.Write([]byte(err.ToJson()))
+		utils.RenderApiError(config.Config(), w, r, err)
+	} else if IsJsonErrorPath(config, r) {
+		utils.RenderApiError(config.Config(), w, r, err)
 	} else {
 		utils.RenderWebAppError(config.Config(), w, r, err, config.AsymmetricSigningKey())
 	}
@@ -85,6 +89,27 @@ func IsWebhookCall(a *app.App, r *http.Request) bool {
 	return strings.HasPrefix(r.URL.Path, path.Join(subpath, "hooks")+"/")
 }
 
+// IsMobileAppRequest returns whether a request was made by one of the mobile apps, which identify themselves with the
+// X-Mobile-App header. If any knownValues are given, the header must have one of them.
+func IsMobileAppRequest(r *http.Request, knownValues ...string) bool {
+	value := r.Header.Get(model.HEADER_MOBILE_APP)
+	if value == "" {
+		return false
+	}
+
+	if len(knownValues) == 0 {
+		return true
+	}
+
+	for _, knownValue := range knownValues {
+		if value == knownValue {
+			return true
+		}
+	}
+
+	return false
+}
+
 func ReturnStatusOK(w http.ResponseWriter) {
 	m := make(map[string]string)
 	m[model.STATUS] = model.STATUS_OK
@@ -5,6 +5,7 @@ package web
 
 import (
 	"fmt"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/mattermost/mattermost-server/app"
@@ -137,3 +138,21 @@ func TestCheckClientCompatability(t *testing.T) {
 		})
 	}
 }
+
+func TestIsMobileAppRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v4/users/me", nil)
+	if IsMobileAppRequest(r) {
+		t.Fatal("request without the header should not be from the mobile app")
+	}
+
+	r.Header.Set(model.HEADER_MOBILE_APP, "mattermost")
+	if !IsMobileAppRequest(r) {
+		t.Fatal("request with the header should be from the mobile app")
+	}
+	if !IsMobileAppRequest(r, "other", "mattermost") {
+		t.Fatal("request with a known value should be from the mobile app")
+	}
+	if IsMobileAppRequest(r, "other") {
+		t.Fatal("request with an unknown value should not be from the mobile app")
+	}
+}